@@ -0,0 +1,505 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package advisory matches vulnerability advisory feeds received from the cloud against installed software.
+//
+// Advisories are persisted and checked locally against installed services, layers, components and packages listed in
+// service SBOMs on each feed update and periodically, so new installations are checked while the unit is offline.
+// Security alert is raised for each advisory affecting the unit and is raised again only if set of affected items
+// changes.
+package advisory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/utils/semverutils"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/imagemanager"
+	"github.com/aosedge/aos_communicationmanager/sbom"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// AlertTagVulnerability vulnerability alert tag.
+const AlertTagVulnerability = "vulnerabilityAlert"
+
+// Affected item types.
+const (
+	ItemTypeService   = "service"
+	ItemTypeLayer     = "layer"
+	ItemTypeComponent = "component"
+	ItemTypePackage   = "package"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Storage advisories storage.
+type Storage interface {
+	SetAdvisories(state json.RawMessage) error
+	GetAdvisories() (json.RawMessage, error)
+}
+
+// AlertSender alert sender.
+type AlertSender interface {
+	SendAlert(alert interface{})
+}
+
+// ImageProvider provides installed services, layers and their SBOMs.
+type ImageProvider interface {
+	GetServicesStatus() ([]unitstatushandler.ServiceStatus, error)
+	GetLayersStatus() ([]unitstatushandler.LayerStatus, error)
+	GetServiceInfo(serviceID string) (imagemanager.ServiceInfo, error)
+	GetSBOMs() ([]sbom.Document, error)
+}
+
+// ComponentProvider provides installed components.
+type ComponentProvider interface {
+	GetStatus() ([]cloudprotocol.ComponentStatus, error)
+}
+
+// AffectedItem installed item affected by vulnerability.
+type AffectedItem struct {
+	Type      string   `json:"type"`
+	ID        string   `json:"id"`
+	Version   string   `json:"version"`
+	Workloads []string `json:"workloads,omitempty"`
+}
+
+// VulnerabilityAlert alert raised when installed items are affected by vulnerability advisory. Priority is 1 for
+// critical severity and grows as severity decreases.
+type VulnerabilityAlert struct {
+	cloudprotocol.AlertItem
+	AdvisoryID string         `json:"advisoryId"`
+	Severity   string         `json:"severity"`
+	Score      float64        `json:"score,omitempty"`
+	Priority   int            `json:"priority"`
+	Summary    string         `json:"summary,omitempty"`
+	Affected   []AffectedItem `json:"affected"`
+	Workloads  []string       `json:"workloads,omitempty"`
+}
+
+// Matcher vulnerability advisories matcher.
+type Matcher struct {
+	sync.Mutex
+
+	config            config.Advisories
+	storage           Storage
+	imageProvider     ImageProvider
+	componentProvider ComponentProvider
+	alertSender       AlertSender
+	state             advisoriesState
+
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// advisoriesState persisted advisories feed with fingerprints of raised alerts by advisory ID.
+type advisoriesState struct {
+	Advisories []amqp.Advisory   `json:"advisories"`
+	Raised     map[string]string `json:"raised,omitempty"`
+}
+
+// inventoryItem installed item which can be affected by vulnerability. IDs contains all identifiers the item can be
+// referenced by in advisories.
+type inventoryItem struct {
+	itemType  string
+	ids       []string
+	version   string
+	workloads []string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates vulnerability advisories matcher.
+func New(
+	cfg config.Advisories, storage Storage, imageProvider ImageProvider, componentProvider ComponentProvider,
+	alertSender AlertSender,
+) (matcher *Matcher, err error) {
+	log.WithFields(log.Fields{
+		"checkPeriod": cfg.CheckPeriod, "minSeverity": cfg.MinSeverity,
+	}).Debug("Create vulnerability advisories matcher")
+
+	matcher = &Matcher{
+		config: cfg, storage: storage, imageProvider: imageProvider, componentProvider: componentProvider,
+		alertSender: alertSender,
+	}
+
+	data, err := storage.GetAdvisories()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if len(data) != 0 {
+		if err = json.Unmarshal(data, &matcher.state); err != nil {
+			log.Errorf("Can't parse advisories: %v", err)
+		}
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	matcher.cancelFunc = cancelFunc
+
+	matcher.wg.Add(1)
+
+	go matcher.handleCheck(ctx)
+
+	return matcher, nil
+}
+
+// Close closes vulnerability advisories matcher.
+func (matcher *Matcher) Close() {
+	matcher.cancelFunc()
+	matcher.wg.Wait()
+}
+
+// SetAdvisories replaces advisories feed and checks installed items against it.
+func (matcher *Matcher) SetAdvisories(advisories []amqp.Advisory) error {
+	log.WithField("count", len(advisories)).Info("Set vulnerability advisories")
+
+	matcher.Lock()
+	matcher.state.Advisories = advisories
+	matcher.Unlock()
+
+	return matcher.Check()
+}
+
+// Check checks installed items against advisories and raises alerts for new or changed matches.
+func (matcher *Matcher) Check() error {
+	items, err := matcher.getInventory()
+	if err != nil {
+		return err
+	}
+
+	alerts := matcher.matchAdvisories(items)
+
+	if matcher.alertSender != nil {
+		for _, alert := range alerts {
+			matcher.alertSender.SendAlert(alert)
+		}
+	}
+
+	return matcher.save()
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (matcher *Matcher) handleCheck(ctx context.Context) {
+	defer matcher.wg.Done()
+
+	ticker := time.NewTicker(matcher.config.CheckPeriod.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := matcher.Check(); err != nil {
+				log.Errorf("Can't check vulnerability advisories: %v", err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (matcher *Matcher) save() error {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	data, err := json.Marshal(matcher.state)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = matcher.storage.SetAdvisories(data); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (matcher *Matcher) matchAdvisories(items []inventoryItem) (alerts []VulnerabilityAlert) {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	raised := make(map[string]string)
+	minRank := getSeverityRank(matcher.config.MinSeverity)
+
+	for _, advisory := range matcher.state.Advisories {
+		if getSeverityRank(advisory.Severity) < minRank {
+			continue
+		}
+
+		affected := matchAdvisory(advisory, items)
+		if len(affected) == 0 {
+			continue
+		}
+
+		fingerprint := getFingerprint(advisory, affected)
+
+		raised[advisory.ID] = fingerprint
+
+		if matcher.state.Raised[advisory.ID] == fingerprint {
+			continue
+		}
+
+		alert := VulnerabilityAlert{
+			AlertItem:  cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: AlertTagVulnerability},
+			AdvisoryID: advisory.ID,
+			Severity:   advisory.Severity,
+			Score:      advisory.Score,
+			Priority:   getPriority(advisory.Severity),
+			Summary:    advisory.Summary,
+			Affected:   affected,
+			Workloads:  getWorkloads(affected),
+		}
+
+		log.WithFields(log.Fields{
+			"advisoryID": alert.AdvisoryID, "severity": alert.Severity, "workloads": alert.Workloads,
+		}).Warn("Installed items affected by vulnerability")
+
+		alerts = append(alerts, alert)
+	}
+
+	matcher.state.Raised = raised
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].Priority != alerts[j].Priority {
+			return alerts[i].Priority < alerts[j].Priority
+		}
+
+		if alerts[i].Score != alerts[j].Score {
+			return alerts[i].Score > alerts[j].Score
+		}
+
+		return len(alerts[i].Workloads) > len(alerts[j].Workloads)
+	})
+
+	return alerts
+}
+
+func (matcher *Matcher) getInventory() (items []inventoryItem, err error) {
+	services, err := matcher.imageProvider.GetServicesStatus()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	layerWorkloads := make(map[string][]string)
+	activeServices := make(map[string]string)
+
+	for _, service := range services {
+		if service.ServiceID == "" || service.Cached {
+			continue
+		}
+
+		activeServices[service.ServiceID] = service.Version
+
+		items = append(items, inventoryItem{
+			itemType: ItemTypeService, ids: []string{service.ServiceID}, version: service.Version,
+			workloads: []string{service.ServiceID},
+		})
+
+		serviceInfo, err := matcher.imageProvider.GetServiceInfo(service.ServiceID)
+		if err != nil {
+			log.WithField("serviceID", service.ServiceID).Errorf("Can't get service info: %v", err)
+
+			continue
+		}
+
+		for _, digest := range serviceInfo.Layers {
+			layerWorkloads[digest] = append(layerWorkloads[digest], service.ServiceID)
+		}
+	}
+
+	layers, err := matcher.imageProvider.GetLayersStatus()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	for _, layer := range layers {
+		if layer.Cached {
+			continue
+		}
+
+		items = append(items, inventoryItem{
+			itemType: ItemTypeLayer, ids: []string{layer.LayerID, layer.Digest}, version: layer.Version,
+			workloads: layerWorkloads[layer.Digest],
+		})
+	}
+
+	if matcher.componentProvider != nil {
+		components, err := matcher.componentProvider.GetStatus()
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		for _, component := range components {
+			if component.Status != cloudprotocol.InstalledStatus {
+				continue
+			}
+
+			items = append(items, inventoryItem{
+				itemType: ItemTypeComponent, ids: []string{component.ComponentID, component.ComponentType},
+				version: component.Version,
+			})
+		}
+	}
+
+	documents, err := matcher.imageProvider.GetSBOMs()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	for _, document := range documents {
+		if activeServices[document.ServiceID] != document.Version {
+			continue
+		}
+
+		packages, err := getSBOMPackages(document)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"serviceID": document.ServiceID, "digest": document.Digest,
+			}).Errorf("Can't parse SBOM: %v", err)
+
+			continue
+		}
+
+		items = append(items, packages...)
+	}
+
+	return items, nil
+}
+
+func matchAdvisory(advisory amqp.Advisory, items []inventoryItem) (affected []AffectedItem) {
+	for _, item := range items {
+		for _, affectedRange := range advisory.Affected {
+			if affectedRange.Type != item.itemType || !slices.Contains(item.ids, affectedRange.ID) ||
+				!isVersionAffected(item.version, affectedRange) {
+				continue
+			}
+
+			affected = append(affected, AffectedItem{
+				Type: item.itemType, ID: affectedRange.ID, Version: item.version, Workloads: item.workloads,
+			})
+
+			break
+		}
+	}
+
+	return affected
+}
+
+// isVersionAffected returns true if version is listed in affected versions or is within affected range. Versions
+// which can't be compared with range bounds are considered as not affected.
+func isVersionAffected(version string, affectedRange amqp.AffectedRange) bool {
+	if len(affectedRange.Versions) != 0 {
+		return slices.Contains(affectedRange.Versions, version)
+	}
+
+	for _, bound := range []struct {
+		version string
+		isOut   func(result int) bool
+	}{
+		{affectedRange.Introduced, func(result int) bool { return result < 0 }},
+		{affectedRange.Fixed, func(result int) bool { return result >= 0 }},
+		{affectedRange.LastAffected, func(result int) bool { return result > 0 }},
+	} {
+		if bound.version == "" {
+			continue
+		}
+
+		result, err := semverutils.Compare(version, bound.version)
+		if err != nil {
+			log.WithFields(log.Fields{"version": version, "bound": bound.version}).Debugf("Can't compare versions: %v", err)
+
+			return false
+		}
+
+		if bound.isOut(result) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getFingerprint(advisory amqp.Advisory, affected []AffectedItem) string {
+	keys := make([]string, 0, len(affected))
+
+	for _, item := range affected {
+		keys = append(keys, fmt.Sprintf("%s:%s@%s", item.Type, item.ID, item.Version))
+	}
+
+	sort.Strings(keys)
+
+	return advisory.Severity + "|" + strings.Join(keys, ",")
+}
+
+func getWorkloads(affected []AffectedItem) (workloads []string) {
+	for _, item := range affected {
+		for _, workload := range item.Workloads {
+			if !slices.Contains(workloads, workload) {
+				workloads = append(workloads, workload)
+			}
+		}
+	}
+
+	sort.Strings(workloads)
+
+	return workloads
+}
+
+func getSeverityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case config.SeverityCritical:
+		return 4
+
+	case config.SeverityHigh:
+		return 3
+
+	case config.SeverityMedium:
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+func getPriority(severity string) int {
+	return getSeverityRank(config.SeverityCritical) - getSeverityRank(severity) + 1
+}
@@ -0,0 +1,293 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisory
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/imagemanager"
+	"github.com/aosedge/aos_communicationmanager/sbom"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	state json.RawMessage
+}
+
+type testImageProvider struct {
+	services []unitstatushandler.ServiceStatus
+	layers   []unitstatushandler.LayerStatus
+	infos    map[string]imagemanager.ServiceInfo
+	sboms    []sbom.Document
+}
+
+type testComponentProvider struct {
+	components []cloudprotocol.ComponentStatus
+}
+
+type testAlertSender struct {
+	sync.Mutex
+	alerts []VulnerabilityAlert
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestMatchAdvisories(t *testing.T) {
+	storage := &testStorage{}
+	alertSender := &testAlertSender{}
+	imageProvider := &testImageProvider{
+		services: []unitstatushandler.ServiceStatus{
+			{ServiceStatus: cloudprotocol.ServiceStatus{ServiceID: "service1", Version: "1.2.0"}},
+			{ServiceStatus: cloudprotocol.ServiceStatus{ServiceID: "service2", Version: "2.0.0"}},
+			{ServiceStatus: cloudprotocol.ServiceStatus{ServiceID: "service3", Version: "1.0.0"}, Cached: true},
+		},
+		layers: []unitstatushandler.LayerStatus{
+			{LayerStatus: cloudprotocol.LayerStatus{LayerID: "baseLayer", Digest: "sha256:1", Version: "3.1.0"}},
+		},
+		infos: map[string]imagemanager.ServiceInfo{
+			"service1": {Layers: []string{"sha256:1"}},
+			"service2": {Layers: []string{"sha256:1"}},
+		},
+		sboms: []sbom.Document{
+			{
+				ServiceID: "service2", Version: "2.0.0", Format: sbom.FormatSPDX, Document: json.RawMessage(
+					`{"packages":[{"name":"openssl","versionInfo":"3.0.11","externalRefs":[` +
+						`{"referenceType":"purl","referenceLocator":"pkg:deb/debian/openssl@3.0.11?arch=arm64"}]}]}`),
+			},
+			{
+				ServiceID: "service1", Version: "1.2.0", Format: sbom.FormatCycloneDX, Document: json.RawMessage(
+					`{"components":[{"name":"app","version":"1.0.0","components":` +
+						`[{"name":"zlib","version":"1.2.13","purl":"pkg:generic/zlib@1.2.13"}]}]}`),
+			},
+		},
+	}
+	componentProvider := &testComponentProvider{
+		components: []cloudprotocol.ComponentStatus{
+			{ComponentID: "rootfs", ComponentType: "rootfs", Version: "5.0.0", Status: cloudprotocol.InstalledStatus},
+		},
+	}
+
+	cfg := config.Advisories{CheckPeriod: aostypes.Duration{Duration: time.Hour}, MinSeverity: config.SeverityMedium}
+
+	matcher, err := New(cfg, storage, imageProvider, componentProvider, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create advisories matcher: %v", err)
+	}
+
+	advisories := []amqp.Advisory{
+		{
+			ID: "CVE-1", Severity: config.SeverityHigh, Score: 7.5, Affected: []amqp.AffectedRange{
+				{Type: ItemTypePackage, ID: "pkg:deb/debian/openssl", Introduced: "3.0.0", Fixed: "3.0.12"},
+			},
+		},
+		{
+			ID: "CVE-2", Severity: config.SeverityCritical, Score: 9.8, Affected: []amqp.AffectedRange{
+				{Type: ItemTypeLayer, ID: "sha256:1", LastAffected: "3.1.0"},
+			},
+		},
+		{
+			ID: "CVE-3", Severity: config.SeverityLow, Affected: []amqp.AffectedRange{
+				{Type: ItemTypeService, ID: "service1"},
+			},
+		},
+		{
+			ID: "CVE-4", Severity: config.SeverityHigh, Score: 8.1, Affected: []amqp.AffectedRange{
+				{Type: ItemTypeService, ID: "service3"},
+				{Type: ItemTypeComponent, ID: "rootfs", Versions: []string{"4.0.0"}},
+				{Type: ItemTypePackage, ID: "zlib", Fixed: "1.2.13"},
+			},
+		},
+		{
+			ID: "CVE-5", Severity: config.SeverityMedium, Score: 5.0, Affected: []amqp.AffectedRange{
+				{Type: ItemTypeComponent, ID: "rootfs", Versions: []string{"5.0.0"}},
+				{Type: ItemTypePackage, ID: "zlib", Introduced: "1.2.0"},
+			},
+		},
+	}
+
+	if err = matcher.SetAdvisories(advisories); err != nil {
+		t.Fatalf("Can't set advisories: %v", err)
+	}
+
+	alerts := alertSender.getAlerts()
+
+	if len(alerts) != 3 {
+		t.Fatalf("Wrong alerts count: %d", len(alerts))
+	}
+
+	for i, expected := range []struct {
+		advisoryID string
+		priority   int
+		affected   int
+		workloads  []string
+	}{
+		{"CVE-2", 1, 1, []string{"service1", "service2"}},
+		{"CVE-1", 2, 1, []string{"service2"}},
+		{"CVE-5", 3, 2, []string{"service1"}},
+	} {
+		alert := alerts[i]
+
+		if alert.Tag != AlertTagVulnerability || alert.AdvisoryID != expected.advisoryID ||
+			alert.Priority != expected.priority || len(alert.Affected) != expected.affected {
+			t.Errorf("Wrong alert: %+v", alert)
+		}
+
+		if !slices.Equal(alert.Workloads, expected.workloads) {
+			t.Errorf("Wrong alert %s workloads: %v", alert.AdvisoryID, alert.Workloads)
+		}
+	}
+
+	// Alerts are not raised again on restart till affected items change
+
+	matcher.Close()
+
+	restored, err := New(cfg, storage, imageProvider, componentProvider, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create advisories matcher: %v", err)
+	}
+	defer restored.Close()
+
+	if err = restored.Check(); err != nil {
+		t.Fatalf("Can't check advisories: %v", err)
+	}
+
+	if alerts = alertSender.getAlerts(); len(alerts) != 3 {
+		t.Errorf("Wrong alerts count: %d", len(alerts))
+	}
+
+	imageProvider.services[2].Cached = false
+
+	if err = restored.Check(); err != nil {
+		t.Fatalf("Can't check advisories: %v", err)
+	}
+
+	if alerts = alertSender.getAlerts(); len(alerts) != 4 || alerts[3].AdvisoryID != "CVE-4" {
+		t.Errorf("Wrong alerts: %v", alerts)
+	}
+}
+
+func TestGetPurlBase(t *testing.T) {
+	for purl, expected := range map[string]string{
+		"pkg:deb/debian/openssl@3.0.11?arch=arm64": "pkg:deb/debian/openssl",
+		"pkg:npm/%40angular/core@16.0.0":           "pkg:npm/%40angular/core",
+		"pkg:golang/github.com/pkg/errors#sub":     "pkg:golang/github.com/pkg/errors",
+		"pkg:generic/zlib":                         "pkg:generic/zlib",
+	} {
+		if base := getPurlBase(purl); base != expected {
+			t.Errorf("Wrong purl base for %s: %s", purl, base)
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * testStorage
+ **********************************************************************************************************************/
+
+func (storage *testStorage) SetAdvisories(state json.RawMessage) error {
+	storage.state = state
+
+	return nil
+}
+
+func (storage *testStorage) GetAdvisories() (json.RawMessage, error) {
+	return storage.state, nil
+}
+
+/***********************************************************************************************************************
+ * testImageProvider
+ **********************************************************************************************************************/
+
+func (provider *testImageProvider) GetServicesStatus() ([]unitstatushandler.ServiceStatus, error) {
+	return provider.services, nil
+}
+
+func (provider *testImageProvider) GetLayersStatus() ([]unitstatushandler.LayerStatus, error) {
+	return provider.layers, nil
+}
+
+func (provider *testImageProvider) GetServiceInfo(serviceID string) (imagemanager.ServiceInfo, error) {
+	info, ok := provider.infos[serviceID]
+	if !ok {
+		return imagemanager.ServiceInfo{}, imagemanager.ErrNotExist
+	}
+
+	return info, nil
+}
+
+func (provider *testImageProvider) GetSBOMs() ([]sbom.Document, error) {
+	return provider.sboms, nil
+}
+
+/***********************************************************************************************************************
+ * testComponentProvider
+ **********************************************************************************************************************/
+
+func (provider *testComponentProvider) GetStatus() ([]cloudprotocol.ComponentStatus, error) {
+	return provider.components, nil
+}
+
+/***********************************************************************************************************************
+ * testAlertSender
+ **********************************************************************************************************************/
+
+func (sender *testAlertSender) SendAlert(alert interface{}) {
+	sender.Lock()
+	defer sender.Unlock()
+
+	if vulnerabilityAlert, ok := alert.(VulnerabilityAlert); ok {
+		sender.alerts = append(sender.alerts, vulnerabilityAlert)
+	}
+}
+
+func (sender *testAlertSender) getAlerts() []VulnerabilityAlert {
+	sender.Lock()
+	defer sender.Unlock()
+
+	return sender.alerts
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisory
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+
+	"github.com/aosedge/aos_communicationmanager/sbom"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const spdxPurlReferenceType = "purl"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type spdxDocument struct {
+	Packages []struct {
+		Name         string `json:"name"`
+		VersionInfo  string `json:"versionInfo"`
+		ExternalRefs []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+type cycloneDXComponent struct {
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	Purl       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXDocument struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getSBOMPackages returns packages listed in SBOM document. Package is identified by its name and package URL
+// without version, qualifiers and subpath.
+func getSBOMPackages(document sbom.Document) (items []inventoryItem, err error) {
+	workloads := []string{document.ServiceID}
+
+	switch document.Format {
+	case sbom.FormatSPDX:
+		var spdx spdxDocument
+
+		if err = json.Unmarshal(document.Document, &spdx); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		for _, spdxPackage := range spdx.Packages {
+			ids := []string{spdxPackage.Name}
+
+			for _, ref := range spdxPackage.ExternalRefs {
+				if ref.ReferenceType == spdxPurlReferenceType {
+					ids = append(ids, getPurlBase(ref.ReferenceLocator))
+				}
+			}
+
+			items = append(items, inventoryItem{
+				itemType: ItemTypePackage, ids: ids, version: spdxPackage.VersionInfo, workloads: workloads,
+			})
+		}
+
+	case sbom.FormatCycloneDX:
+		var cycloneDX cycloneDXDocument
+
+		if err = json.Unmarshal(document.Document, &cycloneDX); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		items = appendCycloneDXComponents(items, cycloneDX.Components, workloads)
+
+	default:
+		return nil, aoserrors.Errorf("unsupported SBOM format: %s", document.Format)
+	}
+
+	return items, nil
+}
+
+func appendCycloneDXComponents(
+	items []inventoryItem, components []cycloneDXComponent, workloads []string,
+) []inventoryItem {
+	for _, component := range components {
+		ids := []string{component.Name}
+
+		if component.Purl != "" {
+			ids = append(ids, getPurlBase(component.Purl))
+		}
+
+		items = append(items, inventoryItem{
+			itemType: ItemTypePackage, ids: ids, version: component.Version, workloads: workloads,
+		})
+
+		items = appendCycloneDXComponents(items, component.Components, workloads)
+	}
+
+	return items
+}
+
+// getPurlBase returns package URL without version, qualifiers and subpath.
+func getPurlBase(purl string) string {
+	if index := strings.IndexAny(purl, "?#"); index >= 0 {
+		purl = purl[:index]
+	}
+
+	if index := strings.LastIndex(purl, "@"); index > strings.LastIndex(purl, "/") {
+		purl = purl[:index]
+	}
+
+	return purl
+}
@@ -26,11 +26,13 @@ import (
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/resourcemonitor"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_common/utils/alertutils"
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/extension"
 )
 
 /***********************************************************************************************************************
@@ -50,9 +52,21 @@ const (
 type Sender interface {
 	SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
 	UnsubscribeFromConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
+	SubscribeForTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error
+	UnsubscribeFromTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error
 	SendAlerts(alerts cloudprotocol.Alerts) error
 }
 
+// MonitoringSnapshotProvider provides monitoring snapshot for critical alert.
+type MonitoringSnapshotProvider interface {
+	GetMonitoringSnapshot(alert interface{}) (snapshot interface{}, err error)
+}
+
+// AlertsConsumer local alerts consumer. AlertReceived is called synchronously and shouldn't block.
+type AlertsConsumer interface {
+	AlertReceived(alert interface{})
+}
+
 // Alerts instance.
 type Alerts struct {
 	sync.RWMutex
@@ -62,10 +76,19 @@ type Alerts struct {
 	senderCancelFunction context.CancelFunc
 	config               config.Alerts
 	sender               Sender
+	snapshotProvider     MonitoringSnapshotProvider
 	alertsSize           int
 	skippedAlerts        uint32
 	duplicatedAlerts     uint32
 	isConnected          bool
+	rateFactor           int
+	consumers            []AlertsConsumer
+	routers              []extension.AlertRouter
+}
+
+type pendingSnapshot struct {
+	alert interface{}
+	due   time.Time
 }
 
 /***********************************************************************************************************************
@@ -73,14 +96,18 @@ type Alerts struct {
  **********************************************************************************************************************/
 
 // New creates new alerts object.
-func New(config config.Alerts, sender Sender) (instance *Alerts, err error) {
+func New(
+	config config.Alerts, sender Sender, snapshotProvider MonitoringSnapshotProvider,
+) (instance *Alerts, err error) {
 	log.Debug("New alerts")
 
 	instance = &Alerts{
 		config:               config,
 		sender:               sender,
+		snapshotProvider:     snapshotProvider,
 		alertsChannel:        make(chan interface{}, alertChannelSize),
 		alertsPackageChannel: make(chan cloudprotocol.Alerts, config.MaxOfflineMessages),
+		rateFactor:           1,
 	}
 
 	ctx, cancelFunction := context.WithCancel(context.Background())
@@ -91,6 +118,10 @@ func New(config config.Alerts, sender Sender) (instance *Alerts, err error) {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if err = instance.sender.SubscribeForTelemetryProfileChanges(instance); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
 	go instance.processAlertChannels(ctx)
 
 	return instance, nil
@@ -104,13 +135,51 @@ func (instance *Alerts) Close() {
 		log.Errorf("Can't unsubscribe from connection events: %v", err)
 	}
 
+	if err := instance.sender.UnsubscribeFromTelemetryProfileChanges(instance); err != nil {
+		log.Errorf("Can't unsubscribe from telemetry profile changes: %v", err)
+	}
+
 	if instance.senderCancelFunction != nil {
 		instance.senderCancelFunction()
 	}
 }
 
+// SubscribeForAlerts subscribes local consumer for alerts.
+func (instance *Alerts) SubscribeForAlerts(consumer AlertsConsumer) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	instance.consumers = append(instance.consumers, consumer)
+}
+
+// UnsubscribeFromAlerts unsubscribes local consumer from alerts.
+func (instance *Alerts) UnsubscribeFromAlerts(consumer AlertsConsumer) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	for i, item := range instance.consumers {
+		if item == consumer {
+			instance.consumers = append(instance.consumers[:i], instance.consumers[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// SetAlertRouters sets alert routers. Alerts rejected by any router are not sent to the cloud.
+func (instance *Alerts) SetAlertRouters(routers []extension.AlertRouter) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	instance.routers = routers
+}
+
 // SendAlert sends alert.
 func (instance *Alerts) SendAlert(alert interface{}) {
+	if !instance.routeAlert(alert) {
+		return
+	}
+
 	select {
 	case instance.alertsChannel <- alert:
 
@@ -143,6 +212,36 @@ func (instance *Alerts) CloudDisconnected() {
 	instance.isConnected = false
 }
 
+// TelemetryProfileChanged indicates telemetry profile is changed.
+func (instance *Alerts) TelemetryProfileChanged(profile amqphandler.TelemetryProfile) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	log.WithFields(log.Fields{
+		"profile": profile.Name, "rateFactor": profile.RateFactor,
+	}).Debug("Alerts telemetry profile changed")
+
+	instance.rateFactor = max(profile.RateFactor, 1)
+}
+
+// ConfigReloaded applies reloaded config.
+func (instance *Alerts) ConfigReloaded(cfg *config.Config) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if cfg.Alerts.SendPeriod.Duration <= 0 {
+		log.Warnf("Wrong alerts send period: %v", cfg.Alerts.SendPeriod)
+
+		return
+	}
+
+	if instance.config.SendPeriod != cfg.Alerts.SendPeriod {
+		log.WithField("sendPeriod", cfg.Alerts.SendPeriod).Info("Alerts send period changed")
+	}
+
+	instance.config.SendPeriod = cfg.Alerts.SendPeriod
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
@@ -150,10 +249,15 @@ func (instance *Alerts) CloudDisconnected() {
 func (instance *Alerts) processAlertChannels(ctx context.Context) {
 	var (
 		sendTicker           = time.NewTicker(instance.config.SendPeriod.Duration)
+		snapshotTimer        = time.NewTimer(instance.config.MonitoringSnapshotWindow.Duration)
 		alertsChannel        = instance.alertsChannel
 		alertsPackageChannel chan cloudprotocol.Alerts
+		// Snapshot window is the same for all alerts, so pending snapshots are ordered by due time
+		pendingSnapshots []pendingSnapshot
 	)
 
+	snapshotTimer.Stop()
+
 	for {
 		select {
 		case alert := <-alertsChannel:
@@ -161,6 +265,29 @@ func (instance *Alerts) processAlertChannels(ctx context.Context) {
 				alertsChannel = nil
 			}
 
+			if instance.isSnapshotRequired(alert) {
+				pendingSnapshots = append(pendingSnapshots, pendingSnapshot{
+					alert: alert, due: time.Now().Add(instance.config.MonitoringSnapshotWindow.Duration),
+				})
+
+				if len(pendingSnapshots) == 1 {
+					snapshotTimer.Reset(instance.config.MonitoringSnapshotWindow.Duration)
+				}
+			}
+
+		case <-snapshotTimer.C:
+			for len(pendingSnapshots) > 0 && !time.Now().Before(pendingSnapshots[0].due) {
+				if instance.addMonitoringSnapshot(pendingSnapshots[0].alert) {
+					alertsChannel = nil
+				}
+
+				pendingSnapshots = pendingSnapshots[1:]
+			}
+
+			if len(pendingSnapshots) > 0 {
+				snapshotTimer.Reset(time.Until(pendingSnapshots[0].due))
+			}
+
 		case alertsPackage := <-alertsPackageChannel:
 			if err := instance.sender.SendAlerts(alertsPackage); err != nil {
 				log.Errorf("Can't send alerts: %s", err)
@@ -181,15 +308,59 @@ func (instance *Alerts) processAlertChannels(ctx context.Context) {
 				alertsPackageChannel = instance.alertsPackageChannel
 			}
 
+			sendTicker.Reset(instance.config.SendPeriod.Duration * time.Duration(instance.rateFactor))
+
 			instance.RUnlock()
 
 		case <-ctx.Done():
 			sendTicker.Stop()
+			snapshotTimer.Stop()
 			return
 		}
 	}
 }
 
+// routeAlert notifies local consumers and returns if the alert should be sent to the cloud.
+func (instance *Alerts) routeAlert(alert interface{}) (sendToCloud bool) {
+	instance.RLock()
+	defer instance.RUnlock()
+
+	for _, consumer := range instance.consumers {
+		consumer.AlertReceived(alert)
+	}
+
+	sendToCloud = true
+
+	for _, router := range instance.routers {
+		if !router.RouteAlert(alert) {
+			sendToCloud = false
+		}
+	}
+
+	return sendToCloud
+}
+
+func (instance *Alerts) isSnapshotRequired(alert interface{}) bool {
+	return instance.snapshotProvider != nil && instance.config.MonitoringSnapshotWindow.Duration > 0 &&
+		isCriticalAlert(alert)
+}
+
+// addMonitoringSnapshot adds monitoring snapshot taken after the snapshot window of the critical alert. Snapshot is
+// routed as other alerts but is added directly as it is called from alerts processing.
+func (instance *Alerts) addMonitoringSnapshot(alert interface{}) (bufferIsFull bool) {
+	snapshot, err := instance.snapshotProvider.GetMonitoringSnapshot(alert)
+	if err != nil {
+		log.Errorf("Can't get monitoring snapshot: %v", err)
+		return false
+	}
+
+	if !instance.routeAlert(snapshot) {
+		return false
+	}
+
+	return instance.addAlert(snapshot)
+}
+
 func (instance *Alerts) addAlert(item interface{}) (bufferIsFull bool) {
 	instance.Lock()
 	defer instance.Unlock()
@@ -238,3 +409,16 @@ func (instance *Alerts) prepareAlertsPackage() {
 	instance.duplicatedAlerts = 0
 	instance.alertsSize = 0
 }
+
+func isCriticalAlert(alert interface{}) bool {
+	switch concreteAlert := alert.(type) {
+	case cloudprotocol.SystemQuotaAlert:
+		return concreteAlert.Status == resourcemonitor.AlertStatusRaise
+
+	case cloudprotocol.InstanceQuotaAlert:
+		return concreteAlert.Status == resourcemonitor.AlertStatusRaise
+
+	default:
+		return false
+	}
+}
@@ -22,16 +22,19 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/resourcemonitor"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_communicationmanager/alerts"
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/extension"
 )
 
 /***********************************************************************************************************************
@@ -52,9 +55,25 @@ func init() {
  * Types
  **********************************************************************************************************************/
 
+type testSnapshotProvider struct {
+	sync.Mutex
+	alerts []interface{}
+}
+
+type testAlertsConsumer struct {
+	sync.Mutex
+	alerts []interface{}
+}
+
+type testAlertRouter struct {
+	sync.Mutex
+	alerts []interface{}
+}
+
 type testSender struct {
-	consumer      amqphandler.ConnectionEventsConsumer
-	alertsChannel chan cloudprotocol.Alerts
+	consumer          amqphandler.ConnectionEventsConsumer
+	telemetryConsumer amqphandler.TelemetryProfileConsumer
+	alertsChannel     chan cloudprotocol.Alerts
 }
 
 /***********************************************************************************************************************
@@ -77,7 +96,7 @@ func TestAlertsMaxMessageSize(t *testing.T) {
 		MaxMessageSize:     512,
 		MaxOfflineMessages: 32,
 	},
-		sender)
+		sender, nil)
 	if err != nil {
 		t.Fatalf("Can't create alerts: %v", err)
 	}
@@ -127,7 +146,7 @@ func TestAlertsDuplicationMessages(t *testing.T) {
 		MaxMessageSize:     1024,
 		MaxOfflineMessages: 32,
 	},
-		sender)
+		sender, nil)
 	if err != nil {
 		t.Fatalf("Can't create alerts: %v", err)
 	}
@@ -158,6 +177,99 @@ func TestAlertsDuplicationMessages(t *testing.T) {
 	}
 }
 
+func TestAlertsConsumer(t *testing.T) {
+	sender := newTestSender()
+
+	alertsHandler, err := alerts.New(config.Alerts{
+		SendPeriod:         aostypes.Duration{Duration: 1 * time.Second},
+		MaxMessageSize:     1024,
+		MaxOfflineMessages: 32,
+	},
+		sender, nil)
+	if err != nil {
+		t.Fatalf("Can't create alerts: %v", err)
+	}
+	defer alertsHandler.Close()
+
+	consumer := &testAlertsConsumer{}
+
+	alertsHandler.SubscribeForAlerts(consumer)
+
+	alertItem := cloudprotocol.DownloadAlert{
+		AlertItem: cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagDownloadProgress},
+		Message:   randomString(32),
+	}
+
+	alertsHandler.SendAlert(alertItem)
+
+	alertsHandler.UnsubscribeFromAlerts(consumer)
+
+	alertsHandler.SendAlert(alertItem)
+
+	consumer.Lock()
+	defer consumer.Unlock()
+
+	if !reflect.DeepEqual(consumer.alerts, []interface{}{alertItem}) {
+		t.Errorf("Wrong received alerts: %v", consumer.alerts)
+	}
+}
+
+func TestMonitoringSnapshot(t *testing.T) {
+	sender := newTestSender()
+	snapshotProvider := &testSnapshotProvider{}
+
+	alertsHandler, err := alerts.New(config.Alerts{
+		SendPeriod:               aostypes.Duration{Duration: 1 * time.Second},
+		MaxMessageSize:           4096,
+		MaxOfflineMessages:       32,
+		MonitoringSnapshotWindow: aostypes.Duration{Duration: 500 * time.Millisecond},
+	},
+		sender, snapshotProvider)
+	if err != nil {
+		t.Fatalf("Can't create alerts: %v", err)
+	}
+	defer alertsHandler.Close()
+
+	sender.consumer.CloudConnected()
+
+	quotaAlert := cloudprotocol.SystemQuotaAlert{
+		AlertItem: cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagSystemQuota},
+		NodeID:    "node1",
+		Parameter: "cpu",
+		Value:     95,
+		Status:    resourcemonitor.AlertStatusRaise,
+	}
+
+	alertsHandler.SendAlert(quotaAlert)
+
+	fallAlert := quotaAlert
+	fallAlert.Status = resourcemonitor.AlertStatusFall
+
+	alertsHandler.SendAlert(fallAlert)
+
+	alerts, err := sender.waitResult(2 * time.Second)
+	if err != nil {
+		t.Fatalf("Wait alerts error: %v", err)
+	}
+
+	expectedAlerts := cloudprotocol.Alerts{Items: []interface{}{
+		quotaAlert, fallAlert, cloudprotocol.SystemAlert{
+			AlertItem: quotaAlert.AlertItem, NodeID: quotaAlert.NodeID, Message: "snapshot",
+		},
+	}}
+
+	if !reflect.DeepEqual(alerts, expectedAlerts) {
+		t.Errorf("Incorrect alerts: %v", alerts)
+	}
+
+	snapshotProvider.Lock()
+	defer snapshotProvider.Unlock()
+
+	if !reflect.DeepEqual(snapshotProvider.alerts, []interface{}{quotaAlert}) {
+		t.Errorf("Wrong snapshot trigger alerts: %v", snapshotProvider.alerts)
+	}
+}
+
 func TestAlertsOfflineMessages(t *testing.T) {
 	const (
 		numOfflineMessages = 32
@@ -171,7 +283,7 @@ func TestAlertsOfflineMessages(t *testing.T) {
 		MaxMessageSize:     256,
 		MaxOfflineMessages: numOfflineMessages,
 	},
-		sender)
+		sender, nil)
 	if err != nil {
 		t.Fatalf("Can't create alerts: %v", err)
 	}
@@ -227,6 +339,56 @@ func TestAlertsOfflineMessages(t *testing.T) {
 	}
 }
 
+func TestAlertRouters(t *testing.T) {
+	sender := newTestSender()
+
+	alertsHandler, err := alerts.New(config.Alerts{
+		SendPeriod:         aostypes.Duration{Duration: 500 * time.Millisecond},
+		MaxMessageSize:     1024,
+		MaxOfflineMessages: 32,
+	},
+		sender, nil)
+	if err != nil {
+		t.Fatalf("Can't create alerts: %v", err)
+	}
+	defer alertsHandler.Close()
+
+	router := &testAlertRouter{}
+
+	alertsHandler.SetAlertRouters([]extension.AlertRouter{router})
+
+	sender.consumer.CloudConnected()
+
+	downloadAlert := cloudprotocol.DownloadAlert{
+		AlertItem: cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagDownloadProgress},
+		Message:   randomString(32),
+	}
+
+	systemAlert := cloudprotocol.SystemAlert{
+		AlertItem: cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagSystemError},
+		Message:   randomString(32),
+	}
+
+	alertsHandler.SendAlert(downloadAlert)
+	alertsHandler.SendAlert(systemAlert)
+
+	receivedAlerts, err := sender.waitResult(2 * time.Second)
+	if err != nil {
+		t.Fatalf("Wait alerts error: %v", err)
+	}
+
+	if !reflect.DeepEqual(receivedAlerts, cloudprotocol.Alerts{Items: []interface{}{systemAlert}}) {
+		t.Errorf("Wrong sent alerts: %v", receivedAlerts)
+	}
+
+	router.Lock()
+	defer router.Unlock()
+
+	if !reflect.DeepEqual(router.alerts, []interface{}{downloadAlert, systemAlert}) {
+		t.Errorf("Wrong routed alerts: %v", router.alerts)
+	}
+}
+
 /***********************************************************************************************************************
  * Interfaces
  **********************************************************************************************************************/
@@ -249,12 +411,60 @@ func (sender *testSender) UnsubscribeFromConnectionEvents(consumer amqphandler.C
 	return nil
 }
 
+func (sender *testSender) SubscribeForTelemetryProfileChanges(
+	consumer amqphandler.TelemetryProfileConsumer,
+) error {
+	sender.telemetryConsumer = consumer
+
+	return nil
+}
+
+func (sender *testSender) UnsubscribeFromTelemetryProfileChanges(
+	consumer amqphandler.TelemetryProfileConsumer,
+) error {
+	return nil
+}
+
 func (sender *testSender) SendAlerts(alerts cloudprotocol.Alerts) (err error) {
 	sender.alertsChannel <- alerts
 
 	return nil
 }
 
+func (provider *testSnapshotProvider) GetMonitoringSnapshot(alert interface{}) (interface{}, error) {
+	provider.Lock()
+	defer provider.Unlock()
+
+	provider.alerts = append(provider.alerts, alert)
+
+	quotaAlert, ok := alert.(cloudprotocol.SystemQuotaAlert)
+	if !ok {
+		return nil, errors.New("unsupported alert")
+	}
+
+	return cloudprotocol.SystemAlert{
+		AlertItem: quotaAlert.AlertItem, NodeID: quotaAlert.NodeID, Message: "snapshot",
+	}, nil
+}
+
+func (router *testAlertRouter) RouteAlert(alert interface{}) bool {
+	router.Lock()
+	defer router.Unlock()
+
+	router.alerts = append(router.alerts, alert)
+
+	_, isDownloadAlert := alert.(cloudprotocol.DownloadAlert)
+
+	return !isDownloadAlert
+}
+
+func (consumer *testAlertsConsumer) AlertReceived(alert interface{}) {
+	consumer.Lock()
+	defer consumer.Unlock()
+
+	consumer.alerts = append(consumer.alerts, alert)
+}
+
 func (sender *testSender) waitResult(timeout time.Duration) (cloudprotocol.Alerts, error) {
 	for {
 		select {
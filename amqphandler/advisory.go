@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// VulnerabilityAdvisoriesMessageType vulnerability advisories message type.
+const VulnerabilityAdvisoriesMessageType = "vulnerabilityAdvisories"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// AffectedRange item versions affected by vulnerability. Type is one of service, layer, component or package. ID is
+// service ID, layer ID or digest, component ID or type, package name or package URL without version. Versions lists
+// affected versions explicitly, introduced, fixed and lastAffected define affected semver range. All versions are
+// affected if neither is set.
+type AffectedRange struct {
+	Type         string   `json:"type"`
+	ID           string   `json:"id"`
+	Versions     []string `json:"versions,omitempty"`
+	Introduced   string   `json:"introduced,omitempty"`
+	Fixed        string   `json:"fixed,omitempty"`
+	LastAffected string   `json:"lastAffected,omitempty"`
+}
+
+// Advisory vulnerability advisory, e.g. CVE. Severity is one of low, medium, high or critical.
+type Advisory struct {
+	ID       string          `json:"id"`
+	Severity string          `json:"severity"`
+	Score    float64         `json:"score,omitempty"`
+	Summary  string          `json:"summary,omitempty"`
+	Affected []AffectedRange `json:"affected"`
+}
+
+// VulnerabilityAdvisories vulnerability advisories feed. Each message replaces previously received feed.
+type VulnerabilityAdvisories struct {
+	MessageType string     `json:"messageType"`
+	Advisories  []Advisory `json:"advisories"`
+}
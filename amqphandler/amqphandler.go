@@ -27,12 +27,17 @@ import (
 	"net/http"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 	log "github.com/sirupsen/logrus"
 	"github.com/streadway/amqp"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
+	"github.com/aosedge/aos_communicationmanager/utils/jsonschema"
 )
 
 /***********************************************************************************************************************
@@ -46,11 +51,16 @@ const (
 	receiveChannelSize = 16
 )
 
+const healthCheckPollPeriod = 100 * time.Millisecond
+
 const (
 	amqpSecureScheme   = "amqps"
 	amqpInsecureScheme = "amqp"
 )
 
+// CampaignIDHeader AMQP header which contains campaign ID of the message.
+const CampaignIDHeader = "campaignId"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -61,17 +71,24 @@ type AmqpHandler struct { //nolint:stylecheck
 
 	// MessageChannel channel for amqp messages
 	MessageChannel chan Message
+	// EmergencyUpdateChannel separate channel for emergency updates
+	EmergencyUpdateChannel chan *EmergencyUpdate
 
-	sendChannel    chan cloudprotocol.Message
-	pendingChannel chan cloudprotocol.Message
+	sendChannel    chan outgoingMessage
+	pendingChannel chan outgoingMessage
 	sendTry        int
+	healthChannel  chan struct{}
+	senderDone     chan struct{}
+	publishStarted atomic.Int64
 
 	sendConnection    *amqp.Connection
 	receiveConnection *amqp.Connection
 
-	cryptoContext CryptoContext
+	cryptoContext  CryptoContext
+	circuitBreaker CircuitBreaker
 
 	systemID string
+	scheme   string
 
 	cancelFunc context.CancelFunc
 
@@ -79,6 +96,21 @@ type AmqpHandler struct { //nolint:stylecheck
 
 	isConnected               bool
 	connectionEventsConsumers []ConnectionEventsConsumer
+
+	desiredStatusSchema *jsonschema.Schema
+	unitConfigSchema    *jsonschema.Schema
+
+	telemetryMutex       sync.Mutex
+	telemetryNotifyMutex sync.Mutex
+	telemetryConfig      config.AdaptiveTelemetry
+	telemetryProfile     TelemetryProfile
+	telemetryConsumers   []TelemetryProfileConsumer
+	link                 linkEstimator
+
+	offlineMutex             sync.Mutex
+	offlineQueueConfig       config.OfflineQueue
+	networkEventsQueueConfig config.OfflineQueueCategory
+	offlineMessages          []outgoingMessage
 }
 
 // CryptoContext interface to access crypto functions.
@@ -87,9 +119,36 @@ type CryptoContext interface {
 	DecryptMetadata(input []byte) ([]byte, error)
 }
 
+// CircuitBreaker circuit breaker of cloud connection.
+type CircuitBreaker interface {
+	Allow() error
+	Done(err error)
+}
+
 // Message AMQP message.
 type Message interface{}
 
+// UnitStatus unit status with hash of instances placement on nodes. Units with the same placement hash run the same
+// instances on the same nodes.
+type UnitStatus struct {
+	cloudprotocol.UnitStatus
+	PlacementHash string                  `json:"placementHash,omitempty"`
+	Capabilities  *UnitCapabilities       `json:"capabilities,omitempty"`
+	Maintenance   *MaintenanceStatus      `json:"maintenance,omitempty"`
+	WipedServices []ServiceWipeStatus     `json:"wipedServices,omitempty"`
+	Networks      []ProviderNetworkStatus `json:"networks,omitempty"`
+	FeatureFlags  map[string]bool         `json:"featureFlags,omitempty"`
+}
+
+// outgoingMessage cloud message with correlation and campaign IDs of the flow which the message belongs to.
+type outgoingMessage struct {
+	message       cloudprotocol.Message
+	correlationID string
+	campaignID    string
+	category      string
+	timestamp     time.Time
+}
+
 // ConnectionEventsConsumer connection events consumer interface.
 type ConnectionEventsConsumer interface {
 	CloudConnected()
@@ -102,10 +161,10 @@ type ConnectionEventsConsumer interface {
 
 var messageMap = map[string]func() interface{}{ //nolint:gochecknoglobals
 	cloudprotocol.DesiredStatusMessageType: func() interface{} {
-		return &cloudprotocol.DesiredStatus{}
+		return &DesiredStatus{}
 	},
 	cloudprotocol.RequestLogMessageType: func() interface{} {
-		return &cloudprotocol.RequestLog{}
+		return &RequestLog{}
 	},
 	cloudprotocol.StateAcceptanceMessageType: func() interface{} {
 		return &cloudprotocol.StateAcceptance{}
@@ -131,6 +190,39 @@ var messageMap = map[string]func() interface{}{ //nolint:gochecknoglobals
 	cloudprotocol.DeprovisioningRequestMessageType: func() interface{} {
 		return &cloudprotocol.DeprovisioningRequest{}
 	},
+	OwnerChangeRequestMessageType: func() interface{} {
+		return &OwnerChangeRequest{}
+	},
+	DesiredStatusConfirmationMessageType: func() interface{} {
+		return &DesiredStatusConfirmation{}
+	},
+	EmergencyUpdateMessageType: func() interface{} {
+		return &EmergencyUpdate{}
+	},
+	LogPartsRequestMessageType: func() interface{} {
+		return &LogPartsRequest{}
+	},
+	DecryptionInfoUpdateMessageType: func() interface{} {
+		return &DecryptionInfoUpdate{}
+	},
+	MaintenanceModeRequestMessageType: func() interface{} {
+		return &MaintenanceModeRequest{}
+	},
+	FeatureFlagsMessageType: func() interface{} {
+		return &FeatureFlags{}
+	},
+	SBOMRequestMessageType: func() interface{} {
+		return &SBOMRequest{}
+	},
+	VulnerabilityAdvisoriesMessageType: func() interface{} {
+		return &VulnerabilityAdvisories{}
+	},
+	AttestationRequestMessageType: func() interface{} {
+		return &AttestationRequest{}
+	},
+	NodeRebootRequestMessageType: func() interface{} {
+		return &NodeRebootRequest{}
+	},
 }
 
 var (
@@ -145,24 +237,66 @@ var (
  **********************************************************************************************************************/
 
 // New creates new amqp object.
-func New() (*AmqpHandler, error) {
+func New(cfg *config.Config) (*AmqpHandler, error) {
 	log.Debug("New AMQP")
 
 	handler := &AmqpHandler{
-		sendChannel:    make(chan cloudprotocol.Message, sendChannelSize),
-		pendingChannel: make(chan cloudprotocol.Message, 1),
+		EmergencyUpdateChannel: make(chan *EmergencyUpdate, emergencyChannelSize),
+		sendChannel:            make(chan outgoingMessage, sendChannelSize),
+		pendingChannel:         make(chan outgoingMessage, 1),
+		healthChannel:          make(chan struct{}),
+		telemetryConfig:        cfg.AdaptiveTelemetry,
+		offlineQueueConfig:     cfg.OfflineQueue,
+	}
+
+	handler.telemetryConfig.Enabled = cfg.GetFeatureFlags()[config.FeatureAdaptiveTelemetry]
+	handler.offlineQueueConfig.Alerts = getRetentionQueueConfig(cfg, config.RetentionAlerts)
+	handler.networkEventsQueueConfig = getRetentionQueueConfig(cfg, config.RetentionNetworkEvents)
+
+	handler.telemetryProfile = handler.createTelemetryProfile(TelemetryProfileFull)
+
+	var err error
+
+	if handler.desiredStatusSchema, err = jsonschema.New(desiredStatusSchemaData); err != nil {
+		return nil, err
 	}
 
 	return handler, nil
 }
 
+// SetCircuitBreaker sets circuit breaker of cloud connection. Connection attempts are rejected while the circuit is
+// open.
+func (handler *AmqpHandler) SetCircuitBreaker(circuitBreaker CircuitBreaker) {
+	handler.Lock()
+	defer handler.Unlock()
+
+	handler.circuitBreaker = circuitBreaker
+}
+
+// SetUnitConfigSchema sets schema of unit config received in desired status. Desired status is rejected if its raw
+// unit config doesn't match the schema.
+func (handler *AmqpHandler) SetUnitConfigSchema(schema *jsonschema.Schema) {
+	handler.Lock()
+	defer handler.Unlock()
+
+	handler.unitConfigSchema = schema
+}
+
 // Connect connects to cloud.
-func (handler *AmqpHandler) Connect(cryptoContext CryptoContext, sdURL, systemID string, insecure bool) error {
+func (handler *AmqpHandler) Connect(cryptoContext CryptoContext, sdURL, systemID string, insecure bool) (err error) {
 	handler.Lock()
 	defer handler.Unlock()
 
 	log.WithFields(log.Fields{"url": sdURL}).Debug("AMQP connect")
 
+	if handler.circuitBreaker != nil {
+		if err = handler.circuitBreaker.Allow(); err != nil {
+			return err
+		}
+
+		defer func() { handler.circuitBreaker.Done(err) }()
+	}
+
 	handler.cryptoContext = cryptoContext
 	handler.systemID = systemID
 
@@ -189,10 +323,14 @@ func (handler *AmqpHandler) Connect(cryptoContext CryptoContext, sdURL, systemID
 		scheme = amqpInsecureScheme
 	}
 
+	handler.resetLinkEstimation()
+
 	if err := handler.setupConnections(scheme, connectionInfo, tlsConfig); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
+	handler.scheme = scheme
+
 	handler.isConnected = true
 
 	handler.notifyCloudConnected()
@@ -229,13 +367,21 @@ func (handler *AmqpHandler) Disconnect() error {
 }
 
 // SendUnitStatus sends unit status.
-func (handler *AmqpHandler) SendUnitStatus(unitStatus cloudprotocol.UnitStatus) error {
+func (handler *AmqpHandler) SendUnitStatus(unitStatus UnitStatus) error {
 	handler.Lock()
 	defer handler.Unlock()
 
 	unitStatus.MessageType = cloudprotocol.UnitStatusMessageType
 
-	return handler.scheduleMessage(unitStatus, false)
+	if unitStatus.Capabilities != nil {
+		capabilities := *unitStatus.Capabilities
+
+		handler.setProtocolCapabilities(&capabilities)
+
+		unitStatus.Capabilities = &capabilities
+	}
+
+	return handler.scheduleCategoryMessage(unitStatus, messageCategoryStatuses, false)
 }
 
 // SendDeltaUnitStatus sends delta unit status.
@@ -245,7 +391,7 @@ func (handler *AmqpHandler) SendDeltaUnitStatus(deltaUnitStatus cloudprotocol.De
 
 	deltaUnitStatus.MessageType = cloudprotocol.UnitStatusMessageType
 
-	return handler.scheduleMessage(deltaUnitStatus, false)
+	return handler.scheduleCategoryMessage(deltaUnitStatus, messageCategoryStatuses, false)
 }
 
 // SendMonitoringData sends monitoring data.
@@ -255,7 +401,49 @@ func (handler *AmqpHandler) SendMonitoringData(monitoringData cloudprotocol.Moni
 
 	monitoringData.MessageType = cloudprotocol.MonitoringMessageType
 
-	return handler.scheduleMessage(monitoringData, false)
+	return handler.scheduleCategoryMessage(monitoringData, messageCategoryMonitoring, false)
+}
+
+// GetSendQueueLength returns number of messages waiting to be sent.
+func (handler *AmqpHandler) GetSendQueueLength() int {
+	return len(handler.sendChannel) + len(handler.pendingChannel) + handler.getOfflineQueueLength()
+}
+
+// CheckHealth checks that AMQP sender loop is alive. The loop is not checked while disconnected. While message is
+// being published, the loop is considered alive unless publishing takes longer than send timeout.
+func (handler *AmqpHandler) CheckHealth() error {
+	handler.Lock()
+
+	if !handler.isConnected || handler.senderDone == nil {
+		handler.Unlock()
+
+		return nil
+	}
+
+	senderDone := handler.senderDone
+
+	handler.Unlock()
+
+	for {
+		// Sender loop doesn't handle health requests while publishing, it is healthy till publishing is not stuck
+		if started := handler.publishStarted.Load(); started != 0 {
+			if time.Since(time.Unix(0, started)) > sendTimeout {
+				return aoserrors.New("message publishing is stuck")
+			}
+
+			return nil
+		}
+
+		select {
+		case handler.healthChannel <- struct{}{}:
+			return nil
+
+		case <-senderDone:
+			return nil
+
+		case <-time.After(healthCheckPollPeriod):
+		}
+	}
 }
 
 // SendServiceNewState sends new state message.
@@ -278,16 +466,6 @@ func (handler *AmqpHandler) SendInstanceStateRequest(request cloudprotocol.State
 	return handler.scheduleMessage(request, true)
 }
 
-// SendLog sends system or service logs.
-func (handler *AmqpHandler) SendLog(serviceLog cloudprotocol.PushLog) error {
-	handler.Lock()
-	defer handler.Unlock()
-
-	serviceLog.MessageType = cloudprotocol.PushLogMessageType
-
-	return handler.scheduleMessage(serviceLog, true)
-}
-
 // SendAlerts sends alerts message.
 func (handler *AmqpHandler) SendAlerts(alerts cloudprotocol.Alerts) error {
 	handler.Lock()
@@ -295,7 +473,7 @@ func (handler *AmqpHandler) SendAlerts(alerts cloudprotocol.Alerts) error {
 
 	alerts.MessageType = cloudprotocol.AlertsMessageType
 
-	return handler.scheduleMessage(alerts, true)
+	return handler.scheduleCategoryMessage(alerts, messageCategoryAlerts, true)
 }
 
 // SendIssueUnitCerts sends request to issue new certificates.
@@ -501,19 +679,24 @@ func (handler *AmqpHandler) setupSendConnection(
 		return aoserrors.Wrap(err)
 	}
 
+	handler.senderDone = make(chan struct{})
+
 	handler.wg.Add(1)
 
-	go handler.runSender(amqpChannel, params)
+	go handler.runSender(amqpChannel, params, handler.senderDone)
 
 	return nil
 }
 
-func (handler *AmqpHandler) runSender(amqpChannel *amqp.Channel, params cloudprotocol.SendParams) {
+func (handler *AmqpHandler) runSender(
+	amqpChannel *amqp.Channel, params cloudprotocol.SendParams, senderDone chan struct{},
+) {
 	log.Info("Start AMQP sender")
 
 	defer func() {
 		log.Info("AMQP sender closed")
 
+		close(senderDone)
 		handler.wg.Done()
 	}()
 
@@ -521,11 +704,24 @@ func (handler *AmqpHandler) runSender(amqpChannel *amqp.Channel, params cloudpro
 	confirmChannel := amqpChannel.NotifyPublish(make(chan amqp.Confirmation, 1))
 	sendChannel := handler.sendChannel
 
+	// Send time is measured from the first send try till the message is acknowledged, so retries are counted as well
+	var sendStart time.Time
+
 	if len(handler.pendingChannel) > 0 {
 		sendChannel = nil
+		sendStart = time.Now()
 	}
 
 	for {
+		if sendChannel != nil {
+			if message, ok := handler.popOfflineMessage(); ok {
+				handler.sendTry = 0
+				sendChannel = nil
+				sendStart = time.Now()
+				handler.pendingChannel <- message
+			}
+		}
+
 		select {
 		case err := <-errorChannel:
 			if err != nil {
@@ -534,13 +730,34 @@ func (handler *AmqpHandler) runSender(amqpChannel *amqp.Channel, params cloudpro
 
 			return
 
+		case <-handler.healthChannel:
+
 		case message := <-sendChannel:
+			if handler.isMessageExpired(message) {
+				log.WithField("category", message.category).Debug("Drop expired message")
+
+				break
+			}
+
 			handler.sendTry = 0
 			sendChannel = nil
+			sendStart = time.Now()
 			handler.pendingChannel <- message
 
 		case message := <-handler.pendingChannel:
-			if err := handler.sendMessage(message, amqpChannel, params); err != nil {
+			if err := faultinjection.Fail(faultinjection.AMQPDisconnect); err != nil {
+				handler.pendingChannel <- message
+				handler.MessageChannel <- err
+
+				return
+			}
+
+			handler.publishStarted.Store(time.Now().UnixNano())
+
+			size, err := handler.sendMessage(message, amqpChannel, params)
+			if err != nil {
+				handler.publishStarted.Store(0)
+
 				log.Warnf("Can't send message: %v", err)
 
 				sendChannel = handler.sendChannel
@@ -548,12 +765,18 @@ func (handler *AmqpHandler) runSender(amqpChannel *amqp.Channel, params cloudpro
 				break
 			}
 
-			if confirm, ok := <-confirmChannel; !ok || !confirm.Ack {
+			confirm, ok := <-confirmChannel
+
+			handler.publishStarted.Store(0)
+
+			if !ok || !confirm.Ack {
 				handler.pendingChannel <- message
 
 				break
 			}
 
+			handler.processSendSample(size, time.Since(sendStart))
+
 			sendChannel = handler.sendChannel
 		}
 	}
@@ -656,6 +879,18 @@ func (handler *AmqpHandler) runReceiver(deliveryChannel <-chan amqp.Delivery, pa
 			if err != nil {
 				log.Errorf("Can't unmarshal incoming message %s", err)
 
+				var schemaErr *schemaError
+
+				if errors.As(err, &schemaErr) {
+					handler.rejectDesiredStatus(schemaErr)
+				}
+
+				continue
+			}
+
+			if emergencyUpdate, ok := decodedData.(*EmergencyUpdate); ok {
+				handler.queueEmergencyUpdate(emergencyUpdate)
+
 				continue
 			}
 
@@ -664,6 +899,14 @@ func (handler *AmqpHandler) runReceiver(deliveryChannel <-chan amqp.Delivery, pa
 	}
 }
 
+func (handler *AmqpHandler) rejectDesiredStatus(schemaErr *schemaError) {
+	if err := handler.SendDesiredStatusRejection(DesiredStatusRejection{
+		CorrelationID: schemaErr.correlationID, CampaignID: schemaErr.campaignID, Errors: schemaErr.errors,
+	}); err != nil {
+		log.Errorf("Can't send desired status rejection: %v", err)
+	}
+}
+
 func (handler *AmqpHandler) unmarshalReceiveData(data []byte) (Message, error) {
 	if len(data) == 0 {
 		//nolint:nilnil
@@ -687,6 +930,16 @@ func (handler *AmqpHandler) unmarshalReceiveData(data []byte) (Message, error) {
 		return nil, aoserrors.New("AMQP unsupported message type")
 	}
 
+	if messageType.Type == cloudprotocol.DesiredStatusMessageType {
+		handler.Lock()
+		unitConfigSchema := handler.unitConfigSchema
+		handler.Unlock()
+
+		if err := validateDesiredStatus(handler.desiredStatusSchema, unitConfigSchema, data); err != nil {
+			return nil, err
+		}
+	}
+
 	messageData := messageTypeFunc()
 
 	if err := json.Unmarshal(data, &messageData); err != nil {
@@ -694,7 +947,7 @@ func (handler *AmqpHandler) unmarshalReceiveData(data []byte) (Message, error) {
 	}
 
 	// print DesiredStatus message
-	desiredStatus, ok := messageData.(*cloudprotocol.DesiredStatus)
+	desiredStatus, ok := messageData.(*DesiredStatus)
 	if !ok {
 		return messageData, nil
 	}
@@ -772,25 +1025,15 @@ func (handler *AmqpHandler) notifyCloudDisconnected() {
 }
 
 func (handler *AmqpHandler) scheduleMessage(data interface{}, important bool) error {
-	if !important && !handler.isConnected {
-		return ErrNotConnected
-	}
-
-	select {
-	case handler.sendChannel <- handler.createCloudMessage(data):
-		return nil
-
-	case <-time.After(sendTimeout):
-		return ErrSendChannelFull
-	}
+	return handler.scheduleCategoryMessage(data, messageCategoryNone, important)
 }
 
 func (handler *AmqpHandler) sendMessage(
-	message cloudprotocol.Message, amqpChannel *amqp.Channel, params cloudprotocol.SendParams,
-) error {
-	data, err := json.Marshal(message)
+	message outgoingMessage, amqpChannel *amqp.Channel, params cloudprotocol.SendParams,
+) (size int, err error) {
+	data, err := json.Marshal(message.message)
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return 0, aoserrors.Wrap(err)
 	}
 
 	if handler.sendTry > 1 {
@@ -800,7 +1043,7 @@ func (handler *AmqpHandler) sendMessage(
 	}
 
 	if handler.sendTry++; handler.sendTry > sendMaxTry {
-		return aoserrors.New("sending message max try reached")
+		return 0, aoserrors.New("sending message max try reached")
 	}
 
 	if err := amqpChannel.Publish(
@@ -809,14 +1052,24 @@ func (handler *AmqpHandler) sendMessage(
 		params.Mandatory,     // mandatory
 		params.Immediate,     // immediate
 		amqp.Publishing{
-			ContentType:  "application/json",
-			DeliveryMode: amqp.Persistent,
-			UserId:       params.User,
-			Body:         data,
+			ContentType:   "application/json",
+			DeliveryMode:  amqp.Persistent,
+			UserId:        params.User,
+			CorrelationId: message.correlationID,
+			Headers:       getMessageHeaders(message),
+			Body:          data,
 		}); err != nil {
 		// Do not return error in this case for purpose rescheduling message
 		log.Errorf("Error publishing AMQP message: %v", err)
 	}
 
-	return nil
+	return len(data), nil
+}
+
+func getMessageHeaders(message outgoingMessage) amqp.Table {
+	if message.campaignID == "" {
+		return nil
+	}
+
+	return amqp.Table{CampaignIDHeader: message.campaignID}
 }
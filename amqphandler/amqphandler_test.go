@@ -27,6 +27,7 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
 )
 
 /***********************************************************************************************************************
@@ -222,7 +224,7 @@ func TestReceiveMessages(t *testing.T) {
 	cryptoContext := &testCryptoContext{}
 	rootfs := "rootfs"
 
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
@@ -261,36 +263,46 @@ func TestReceiveMessages(t *testing.T) {
 		},
 		{
 			messageType: cloudprotocol.RequestLogMessageType,
-			expectedData: &cloudprotocol.RequestLog{
+			expectedData: &amqphandler.RequestLog{
 				MessageType: cloudprotocol.RequestLogMessageType,
 				LogID:       "someID",
 				LogType:     cloudprotocol.ServiceLog,
-				Filter: cloudprotocol.LogFilter{
-					InstanceFilter: cloudprotocol.NewInstanceFilter("service2", "", -1),
-					From:           nil, Till: nil,
+				Filter: amqphandler.LogFilter{
+					LogFilter: cloudprotocol.LogFilter{
+						InstanceFilter: cloudprotocol.NewInstanceFilter("service2", "", -1),
+						From:           nil, Till: nil,
+					},
+					Pattern:  "error|fail",
+					Severity: amqphandler.LogSeverityWarning,
+					MaxSize:  1024,
 				},
 			},
 		},
 		{
 			messageType: cloudprotocol.RequestLogMessageType,
-			expectedData: &cloudprotocol.RequestLog{
+			expectedData: &amqphandler.RequestLog{
 				MessageType: cloudprotocol.RequestLogMessageType,
 				LogID:       "someID",
 				LogType:     cloudprotocol.CrashLog,
-				Filter: cloudprotocol.LogFilter{
-					InstanceFilter: cloudprotocol.NewInstanceFilter("service3", "", -1),
-					From:           nil, Till: nil,
+				Filter: amqphandler.LogFilter{
+					LogFilter: cloudprotocol.LogFilter{
+						InstanceFilter: cloudprotocol.NewInstanceFilter("service3", "", -1),
+						From:           nil, Till: nil,
+					},
 				},
 			},
 		},
 		{
 			messageType: cloudprotocol.RequestLogMessageType,
-			expectedData: &cloudprotocol.RequestLog{
+			expectedData: &amqphandler.RequestLog{
 				MessageType: cloudprotocol.RequestLogMessageType,
 				LogID:       "someID",
 				LogType:     cloudprotocol.SystemLog,
-				Filter: cloudprotocol.LogFilter{
-					From: nil, Till: nil,
+				Filter: amqphandler.LogFilter{
+					LogFilter: cloudprotocol.LogFilter{
+						From: nil, Till: nil,
+						NodeIDs: []string{"node0", "node1"},
+					},
 				},
 			},
 		},
@@ -322,21 +334,24 @@ func TestReceiveMessages(t *testing.T) {
 		},
 		{
 			messageType: cloudprotocol.DesiredStatusMessageType,
-			expectedData: &cloudprotocol.DesiredStatus{
-				MessageType: cloudprotocol.DesiredStatusMessageType,
-				UnitConfig:  &cloudprotocol.UnitConfig{},
-				Components: []cloudprotocol.ComponentInfo{
-					{Version: "1.0.0", ComponentID: &rootfs},
-				},
-				Layers: []cloudprotocol.LayerInfo{
-					{Version: "1.0", LayerID: "l1", Digest: "digest"},
-				},
-				Services: []cloudprotocol.ServiceInfo{
-					{Version: "1.0", ServiceID: "serv1", ProviderID: "p1"},
+			expectedData: &amqphandler.DesiredStatus{
+				DesiredStatus: cloudprotocol.DesiredStatus{
+					MessageType: cloudprotocol.DesiredStatusMessageType,
+					UnitConfig:  &cloudprotocol.UnitConfig{Version: "1.0.0"},
+					Components: []cloudprotocol.ComponentInfo{
+						{Version: "1.0.0", ComponentID: &rootfs, ComponentType: "rootfs"},
+					},
+					Layers: []cloudprotocol.LayerInfo{
+						{Version: "1.0", LayerID: "l1", Digest: "digest"},
+					},
+					Services: []cloudprotocol.ServiceInfo{
+						{Version: "1.0", ServiceID: "serv1", ProviderID: "p1"},
+					},
+					Instances:    []cloudprotocol.InstanceInfo{{ServiceID: "s1", SubjectID: "subj1", NumInstances: 1}},
+					FOTASchedule: cloudprotocol.ScheduleRule{TTL: uint64(100), Type: cloudprotocol.ForceUpdate},
+					SOTASchedule: cloudprotocol.ScheduleRule{TTL: uint64(200), Type: cloudprotocol.TriggerUpdate},
 				},
-				Instances:    []cloudprotocol.InstanceInfo{{ServiceID: "s1", SubjectID: "subj1", NumInstances: 1}},
-				FOTASchedule: cloudprotocol.ScheduleRule{TTL: uint64(100), Type: "type"},
-				SOTASchedule: cloudprotocol.ScheduleRule{TTL: uint64(200), Type: "type2"},
+				RequireConfirmation: true,
 			},
 		},
 		{
@@ -367,6 +382,71 @@ func TestReceiveMessages(t *testing.T) {
 				Password:    "password-1",
 			},
 		},
+		{
+			messageType: amqphandler.OwnerChangeRequestMessageType,
+			expectedData: &amqphandler.OwnerChangeRequest{
+				MessageType: amqphandler.OwnerChangeRequestMessageType,
+				Info:        json.RawMessage(`{"requestId":"request-1"}`),
+				Authorization: cloudprotocol.Signs{
+					ChainName: "chain1", Alg: "RSA/SHA256", Value: []byte("signature"),
+					TrustedTimestamp: "2024-01-01T00:00:00Z",
+				},
+			},
+		},
+		{
+			messageType: amqphandler.DesiredStatusConfirmationMessageType,
+			expectedData: &amqphandler.DesiredStatusConfirmation{
+				MessageType: amqphandler.DesiredStatusConfirmationMessageType,
+				ReportID:    "report-1",
+				Confirmed:   true,
+			},
+		},
+		{
+			messageType: amqphandler.EmergencyUpdateMessageType,
+			expectedData: &amqphandler.EmergencyUpdate{
+				MessageType: amqphandler.EmergencyUpdateMessageType,
+				Update:      json.RawMessage(`{"updateId":"update-1"}`),
+				Authorization: cloudprotocol.Signs{
+					ChainName: "chain1", Alg: "RSA/SHA256", Value: []byte("signature"),
+					TrustedTimestamp: "2024-01-01T00:00:00Z",
+				},
+			},
+		},
+		{
+			messageType: amqphandler.DecryptionInfoUpdateMessageType,
+			expectedData: &amqphandler.DecryptionInfoUpdate{
+				MessageType: amqphandler.DecryptionInfoUpdateMessageType,
+				RequestID:   "request-1",
+				Items: []amqphandler.ItemDecryptionInfo{
+					{
+						DecryptionItem: amqphandler.DecryptionItem{
+							ID: "service1", Type: amqphandler.DownloadTypeService, Version: "1.0.0",
+						},
+						DecryptionInfo: cloudprotocol.DecryptionInfo{
+							BlockAlg: "AES256/CBC/pkcs7", BlockIv: []byte{1, 2}, BlockKey: []byte{3, 4},
+							AsymAlg: "RSA/PKCS1v1_5",
+						},
+					},
+				},
+			},
+		},
+		{
+			messageType: amqphandler.LogPartsRequestMessageType,
+			expectedData: &amqphandler.LogPartsRequest{
+				MessageType: amqphandler.LogPartsRequestMessageType,
+				LogID:       "log0",
+				NodeID:      "node0",
+				Parts:       []uint64{2, 5},
+			},
+		},
+		{
+			messageType: amqphandler.MaintenanceModeRequestMessageType,
+			expectedData: &amqphandler.MaintenanceModeRequest{
+				MessageType: amqphandler.MaintenanceModeRequestMessageType,
+				Enabled:     true,
+				Reason:      "workshop",
+			},
+		},
 	}
 
 	for _, data := range testData {
@@ -384,6 +464,12 @@ func TestReceiveMessages(t *testing.T) {
 				continue
 			}
 
+		case emergencyUpdate := <-amqpHandler.EmergencyUpdateChannel:
+			if !reflect.DeepEqual(data.expectedData, emergencyUpdate) {
+				t.Errorf("Wrong emergency update received: %v %v", data.expectedData, emergencyUpdate)
+				continue
+			}
+
 		case err = <-testClient.errChannel:
 			t.Fatalf("AMQP error: %v", err)
 			return
@@ -395,10 +481,68 @@ func TestReceiveMessages(t *testing.T) {
 	}
 }
 
+func TestDesiredStatusRejection(t *testing.T) {
+	cryptoContext := &testCryptoContext{}
+
+	amqpHandler, err := amqphandler.New(&config.Config{})
+	if err != nil {
+		t.Fatalf("Can't create amqp: %v", err)
+	}
+	defer amqpHandler.Close()
+
+	if err := amqpHandler.Connect(cryptoContext, serviceDiscoveryURL, systemID, true); err != nil {
+		t.Fatalf("Can't establish connection: %v", err)
+	}
+
+	desiredStatus := map[string]interface{}{
+		"messageType":   cloudprotocol.DesiredStatusMessageType,
+		"correlationId": "correlation-1",
+		"services":      []interface{}{map[string]interface{}{"id": "serv1"}},
+		"instances":     []interface{}{map[string]interface{}{"serviceId": "serv1", "subjectId": 1}},
+	}
+
+	if err = sendCloudMessage(cloudprotocol.DesiredStatusMessageType, desiredStatus); err != nil {
+		t.Fatalf("Can't send message: %v", err)
+	}
+
+	expectedRejection := &amqphandler.DesiredStatusRejection{
+		MessageType:   amqphandler.DesiredStatusRejectionMessageType,
+		CorrelationID: "correlation-1",
+		Errors: []amqphandler.ValidationError{
+			{Path: "/instances/0/subjectId", Message: "wrong type: expected string, got integer"},
+			{Path: "/services/0/version", Message: "required property is missing"},
+		},
+	}
+
+	select {
+	case delivery := <-testClient.delivery:
+		var receivedData struct {
+			Data *amqphandler.DesiredStatusRejection `json:"data"`
+		}
+
+		if err = json.Unmarshal(delivery.Body, &receivedData); err != nil {
+			t.Fatalf("Error parsing message: %v", err)
+		}
+
+		if !reflect.DeepEqual(receivedData.Data, expectedRejection) {
+			t.Errorf("Wrong rejection received: %v", receivedData.Data)
+		}
+
+	case receiveMessage := <-amqpHandler.MessageChannel:
+		t.Errorf("Unexpected message received: %v", receiveMessage)
+
+	case err = <-testClient.errChannel:
+		t.Fatalf("AMQP error: %v", err)
+
+	case <-time.After(5 * time.Second):
+		t.Error("Waiting rejection timeout")
+	}
+}
+
 func TestSendMessages(t *testing.T) {
 	cryptoContext := &testCryptoContext{}
 
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
@@ -508,15 +652,21 @@ func TestSendMessages(t *testing.T) {
 		ServiceInstances: instanceMonitoringData,
 	}
 
-	pushServiceLogData := cloudprotocol.PushLog{
-		MessageType: cloudprotocol.PushLogMessageType,
-		LogID:       "log0",
-		PartsCount:  2,
-		Part:        1,
-		Content:     []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
-		ErrorInfo: &cloudprotocol.ErrorInfo{
-			Message: "Error",
+	pushServiceLogData := amqphandler.PushLog{
+		PushLog: cloudprotocol.PushLog{
+			MessageType: cloudprotocol.PushLogMessageType,
+			LogID:       "log0",
+			PartsCount:  2,
+			Part:        1,
+			Content:     []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			ErrorInfo: &cloudprotocol.ErrorInfo{
+				Message: "Error",
+			},
 		},
+		Truncated:    true,
+		Compression:  amqphandler.LogCompressionZstd,
+		Checksum:     "0102",
+		PartChecksum: "0304",
 	}
 
 	now := time.Now().UTC()
@@ -580,6 +730,39 @@ func TestSendMessages(t *testing.T) {
 		ErrorInfo:   nil,
 	}
 
+	ownerChangeStatus := amqphandler.OwnerChangeStatus{
+		MessageType:  amqphandler.OwnerChangeStatusMessageType,
+		RequestID:    "request-1",
+		Report:       json.RawMessage(`{"requestId":"request-1"}`),
+		Signature:    []byte("signature"),
+		Certificates: [][]byte{[]byte("certificate")},
+	}
+
+	decryptionInfoRequest := amqphandler.DecryptionInfoRequest{
+		MessageType:   amqphandler.DecryptionInfoRequestMessageType,
+		RequestID:     "request-1",
+		CorrelationID: "correlation-1",
+		Items: []amqphandler.DecryptionItem{
+			{ID: "layer1", Type: amqphandler.DownloadTypeLayer, Version: "1.0.0"},
+		},
+	}
+
+	overrideBundleReport := amqphandler.OverrideBundleReport{
+		MessageType: amqphandler.OverrideBundleReportMessageType,
+		BundleID:    "bundle-1",
+		AppliedAt:   time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC),
+		DesiredStatus: cloudprotocol.DesiredStatus{
+			Components: []cloudprotocol.ComponentInfo{{ComponentType: "type1", Version: "2.0.0"}},
+		},
+	}
+
+	emergencyUpdateStatus := amqphandler.EmergencyUpdateStatus{
+		MessageType: amqphandler.EmergencyUpdateStatusMessageType,
+		UpdateID:    "update-1",
+		Status:      cloudprotocol.ErrorStatus,
+		ErrorInfo:   &cloudprotocol.ErrorInfo{Message: "authorization failed"},
+	}
+
 	issueCerts := cloudprotocol.IssueUnitCerts{
 		MessageType: cloudprotocol.IssueUnitCertsMessageType,
 		Requests: []cloudprotocol.IssueCertData{
@@ -596,17 +779,46 @@ func TestSendMessages(t *testing.T) {
 		},
 	}
 
+	nodeCapabilities := []amqphandler.NodeCapabilities{
+		{
+			NodeID:          "mainNode",
+			Runners:         []amqphandler.RunnerCapability{{Name: "crun", Version: "1.1.0"}, {Name: "runc"}},
+			LayerMediaTypes: []string{"application/vnd.oci.image.layer.v1.tar"},
+		},
+	}
+
+	protocolFeatures := []string{
+		cloudprotocol.DesiredStatusMessageType, cloudprotocol.RequestLogMessageType,
+		cloudprotocol.StateAcceptanceMessageType, cloudprotocol.UpdateStateMessageType,
+		cloudprotocol.RenewCertsNotificationMessageType, cloudprotocol.IssuedUnitCertsMessageType,
+		cloudprotocol.OverrideEnvVarsMessageType, cloudprotocol.StartProvisioningRequestMessageType,
+		cloudprotocol.FinishProvisioningRequestMessageType, cloudprotocol.DeprovisioningRequestMessageType,
+		amqphandler.OwnerChangeRequestMessageType, amqphandler.DesiredStatusConfirmationMessageType,
+		amqphandler.EmergencyUpdateMessageType, amqphandler.LogPartsRequestMessageType,
+		amqphandler.DecryptionInfoUpdateMessageType, amqphandler.MaintenanceModeRequestMessageType,
+	}
+
+	sort.Strings(protocolFeatures)
+
 	testData := []messageDesc{
 		{
 			call: func() error {
-				return aoserrors.Wrap(amqpHandler.SendUnitStatus(cloudprotocol.UnitStatus{
-					UnitConfig:   unitConfigData,
-					Components:   componentSetupData,
-					Layers:       layersSetupData,
-					Services:     serviceSetupData,
-					Instances:    instances,
-					Nodes:        nodeConfiguration,
-					UnitSubjects: []string{"subject"},
+				return aoserrors.Wrap(amqpHandler.SendUnitStatus(amqphandler.UnitStatus{
+					UnitStatus: cloudprotocol.UnitStatus{
+						UnitConfig:   unitConfigData,
+						Components:   componentSetupData,
+						Layers:       layersSetupData,
+						Services:     serviceSetupData,
+						Instances:    instances,
+						Nodes:        nodeConfiguration,
+						UnitSubjects: []string{"subject"},
+					},
+					PlacementHash: "placementHash",
+					Capabilities: &amqphandler.UnitCapabilities{
+						Transports: []string{"https"},
+						Features:   map[string]bool{"emergencyUpdate": true},
+						Nodes:      nodeCapabilities,
+					},
 				}))
 			},
 			data: cloudprotocol.Message{
@@ -614,19 +826,31 @@ func TestSendMessages(t *testing.T) {
 					SystemID: systemID,
 					Version:  cloudprotocol.ProtocolVersion,
 				},
-				Data: &cloudprotocol.UnitStatus{
-					MessageType:  cloudprotocol.UnitStatusMessageType,
-					UnitConfig:   unitConfigData,
-					Components:   componentSetupData,
-					Layers:       layersSetupData,
-					Services:     serviceSetupData,
-					Instances:    instances,
-					Nodes:        nodeConfiguration,
-					UnitSubjects: []string{"subject"},
+				Data: &amqphandler.UnitStatus{
+					UnitStatus: cloudprotocol.UnitStatus{
+						MessageType:  cloudprotocol.UnitStatusMessageType,
+						UnitConfig:   unitConfigData,
+						Components:   componentSetupData,
+						Layers:       layersSetupData,
+						Services:     serviceSetupData,
+						Instances:    instances,
+						Nodes:        nodeConfiguration,
+						UnitSubjects: []string{"subject"},
+					},
+					PlacementHash: "placementHash",
+					Capabilities: &amqphandler.UnitCapabilities{
+						ProtocolVersion:  cloudprotocol.ProtocolVersion,
+						ProtocolFeatures: protocolFeatures,
+						Transports:       []string{"amqp", "https"},
+						Features:         map[string]bool{"emergencyUpdate": true},
+						Nodes:            nodeCapabilities,
+					},
 				},
 			},
 			getDataType: func() interface{} {
-				return &cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType}
+				return &amqphandler.UnitStatus{
+					UnitStatus: cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType},
+				}
 			},
 		},
 		{
@@ -701,17 +925,23 @@ func TestSendMessages(t *testing.T) {
 					SystemID: systemID,
 					Version:  cloudprotocol.ProtocolVersion,
 				},
-				Data: &cloudprotocol.PushLog{
-					MessageType: cloudprotocol.PushLogMessageType,
-					LogID:       pushServiceLogData.LogID,
-					PartsCount:  pushServiceLogData.PartsCount,
-					Part:        pushServiceLogData.Part,
-					Content:     pushServiceLogData.Content,
-					ErrorInfo:   pushServiceLogData.ErrorInfo,
+				Data: &amqphandler.PushLog{
+					PushLog: cloudprotocol.PushLog{
+						MessageType: cloudprotocol.PushLogMessageType,
+						LogID:       pushServiceLogData.LogID,
+						PartsCount:  pushServiceLogData.PartsCount,
+						Part:        pushServiceLogData.Part,
+						Content:     pushServiceLogData.Content,
+						ErrorInfo:   pushServiceLogData.ErrorInfo,
+					},
+					Truncated:    true,
+					Compression:  amqphandler.LogCompressionZstd,
+					Checksum:     "0102",
+					PartChecksum: "0304",
 				},
 			},
 			getDataType: func() interface{} {
-				return &cloudprotocol.PushLog{MessageType: cloudprotocol.PushLogMessageType}
+				return &amqphandler.PushLog{}
 			},
 		},
 		{
@@ -837,6 +1067,66 @@ func TestSendMessages(t *testing.T) {
 				return &cloudprotocol.DeprovisioningResponse{MessageType: cloudprotocol.DeprovisioningResponseMessageType}
 			},
 		},
+		{
+			call: func() error {
+				return aoserrors.Wrap(amqpHandler.SendOwnerChangeStatus(ownerChangeStatus))
+			},
+			data: cloudprotocol.Message{
+				Header: cloudprotocol.MessageHeader{
+					SystemID: systemID,
+					Version:  cloudprotocol.ProtocolVersion,
+				},
+				Data: &ownerChangeStatus,
+			},
+			getDataType: func() interface{} {
+				return &amqphandler.OwnerChangeStatus{}
+			},
+		},
+		{
+			call: func() error {
+				return aoserrors.Wrap(amqpHandler.SendEmergencyUpdateStatus(emergencyUpdateStatus))
+			},
+			data: cloudprotocol.Message{
+				Header: cloudprotocol.MessageHeader{
+					SystemID: systemID,
+					Version:  cloudprotocol.ProtocolVersion,
+				},
+				Data: &emergencyUpdateStatus,
+			},
+			getDataType: func() interface{} {
+				return &amqphandler.EmergencyUpdateStatus{}
+			},
+		},
+		{
+			call: func() error {
+				return aoserrors.Wrap(amqpHandler.SendOverrideBundleReport(overrideBundleReport))
+			},
+			data: cloudprotocol.Message{
+				Header: cloudprotocol.MessageHeader{
+					SystemID: systemID,
+					Version:  cloudprotocol.ProtocolVersion,
+				},
+				Data: &overrideBundleReport,
+			},
+			getDataType: func() interface{} {
+				return &amqphandler.OverrideBundleReport{}
+			},
+		},
+		{
+			call: func() error {
+				return aoserrors.Wrap(amqpHandler.SendDecryptionInfoRequest(decryptionInfoRequest))
+			},
+			data: cloudprotocol.Message{
+				Header: cloudprotocol.MessageHeader{
+					SystemID: systemID,
+					Version:  cloudprotocol.ProtocolVersion,
+				},
+				Data: &decryptionInfoRequest,
+			},
+			getDataType: func() interface{} {
+				return &amqphandler.DecryptionInfoRequest{}
+			},
+		},
 	}
 
 	for _, message := range testData {
@@ -886,7 +1176,7 @@ func TestSendMessages(t *testing.T) {
 }
 
 func TestConnectionEvents(t *testing.T) {
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
@@ -931,7 +1221,7 @@ func TestConnectionEvents(t *testing.T) {
 }
 
 func TestConnectionEventsError(t *testing.T) {
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
@@ -963,7 +1253,7 @@ func TestConnectionEventsError(t *testing.T) {
 func TestSendMultipleMessages(t *testing.T) {
 	const numMessages = 1000
 
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
@@ -975,9 +1265,9 @@ func TestSendMultipleMessages(t *testing.T) {
 
 	testData := []func() error{
 		func() error {
-			return aoserrors.Wrap(amqpHandler.SendUnitStatus(
-				cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType}),
-			)
+			return aoserrors.Wrap(amqpHandler.SendUnitStatus(amqphandler.UnitStatus{
+				UnitStatus: cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType},
+			}))
 		},
 		func() error {
 			return aoserrors.Wrap(amqpHandler.SendDeltaUnitStatus(
@@ -1005,7 +1295,7 @@ func TestSendMultipleMessages(t *testing.T) {
 		},
 		func() error {
 			return aoserrors.Wrap(
-				amqpHandler.SendLog(cloudprotocol.PushLog{MessageType: cloudprotocol.PushLogMessageType}),
+				amqpHandler.SendLog(amqphandler.PushLog{}),
 			)
 		},
 		func() error {
@@ -1050,14 +1340,16 @@ func TestSendMultipleMessages(t *testing.T) {
 func TestSendDisconnectMessages(t *testing.T) {
 	const sendQueueSize = 32
 
-	amqpHandler, err := amqphandler.New()
+	amqpHandler, err := amqphandler.New(&config.Config{})
 	if err != nil {
 		t.Fatalf("Can't create amqp: %v", err)
 	}
 	defer amqpHandler.Close()
 
 	// Send unimportant message
-	err = amqpHandler.SendUnitStatus(cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType})
+	err = amqpHandler.SendUnitStatus(amqphandler.UnitStatus{
+		UnitStatus: cloudprotocol.UnitStatus{MessageType: cloudprotocol.UnitStatusMessageType},
+	})
 	if !errors.Is(err, amqphandler.ErrNotConnected) {
 		t.Errorf("Wrong error type: %v", err)
 	}
@@ -1112,6 +1404,86 @@ func TestSendDisconnectMessages(t *testing.T) {
 	}
 }
 
+func TestOfflineQueue(t *testing.T) {
+	amqpHandler, err := amqphandler.New(&config.Config{OfflineQueue: config.OfflineQueue{
+		Alerts:     config.OfflineQueueCategory{MaxCount: 2},
+		Monitoring: config.OfflineQueueCategory{TTL: aostypes.Duration{Duration: time.Second}, MaxCount: 4},
+	}})
+	if err != nil {
+		t.Fatalf("Can't create amqp: %v", err)
+	}
+	defer amqpHandler.Close()
+
+	// Statuses are not buffered
+
+	if err = amqpHandler.SendUnitStatus(amqphandler.UnitStatus{}); !errors.Is(err, amqphandler.ErrNotConnected) {
+		t.Errorf("Wrong error type: %v", err)
+	}
+
+	if err = amqpHandler.SendMonitoringData(cloudprotocol.Monitoring{}); err != nil {
+		t.Errorf("Can't send monitoring data: %v", err)
+	}
+
+	// The oldest alerts message should be dropped
+
+	for _, tag := range []string{"alert0", "alert1", "alert2"} {
+		if err = amqpHandler.SendAlerts(cloudprotocol.Alerts{
+			Items: []interface{}{cloudprotocol.AlertItem{Tag: tag}},
+		}); err != nil {
+			t.Errorf("Can't send alerts: %v", err)
+		}
+	}
+
+	if length := amqpHandler.GetSendQueueLength(); length != 3 {
+		t.Errorf("Wrong send queue length: %d", length)
+	}
+
+	// Monitoring message should expire
+
+	time.Sleep(2 * time.Second)
+
+	if err = amqpHandler.Connect(&testCryptoContext{}, serviceDiscoveryURL, systemID, true); err != nil {
+		t.Errorf("Can't establish connection: %v", err)
+	}
+
+	for _, expectedTag := range []string{"alert1", "alert2"} {
+		select {
+		case delivery := <-testClient.delivery:
+			var message struct {
+				Data struct {
+					MessageType string `json:"messageType"`
+					Items       []struct {
+						Tag string `json:"tag"`
+					} `json:"items"`
+				} `json:"data"`
+			}
+
+			if err = json.Unmarshal(delivery.Body, &message); err != nil {
+				t.Errorf("Can't parse json message: %v", err)
+				continue
+			}
+
+			if message.Data.MessageType != cloudprotocol.AlertsMessageType || len(message.Data.Items) != 1 ||
+				message.Data.Items[0].Tag != expectedTag {
+				t.Errorf("Wrong message: %s", string(delivery.Body))
+			}
+
+		case err = <-testClient.errChannel:
+			t.Errorf("AMQP error: %v", err)
+
+		case <-time.After(5 * time.Second):
+			t.Fatal("Waiting message timeout")
+		}
+	}
+
+	select {
+	case delivery := <-testClient.delivery:
+		t.Errorf("Unexpected message: %s", string(delivery.Body))
+
+	case <-time.After(time.Second):
+	}
+}
+
 /***********************************************************************************************************************
  * Interfaces
  **********************************************************************************************************************/
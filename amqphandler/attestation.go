@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import "encoding/json"
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Attestation message types.
+const (
+	AttestationRequestMessageType = "attestationRequest"
+	AttestationReportMessageType  = "attestationReport"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// AttestationRequest integrity attestation request. Nonce is included into the report to prove its freshness.
+type AttestationRequest struct {
+	MessageType string `json:"messageType"`
+	RequestID   string `json:"requestId"`
+	Nonce       string `json:"nonce,omitempty"`
+}
+
+// AttestationReport integrity attestation report. Signature is calculated over report bytes with online key,
+// certificates contain DER encoded online certificate chain.
+type AttestationReport struct {
+	MessageType  string          `json:"messageType"`
+	RequestID    string          `json:"requestId"`
+	Report       json.RawMessage `json:"report,omitempty"`
+	Signature    []byte          `json:"signature,omitempty"`
+	Certificates [][]byte        `json:"certificates,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendAttestationReport sends integrity attestation report.
+func (handler *AmqpHandler) SendAttestationReport(report AttestationReport) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	report.MessageType = AttestationReportMessageType
+
+	return handler.scheduleMessage(report, true)
+}
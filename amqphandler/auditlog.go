@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"encoding/json"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// AuditLogMessageType audit log message type.
+const AuditLogMessageType = "auditLog"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// AuditLog audit log records exported to the cloud. Signature is calculated over records bytes with online key,
+// certificates contain DER encoded online certificate chain.
+type AuditLog struct {
+	MessageType  string          `json:"messageType"`
+	Records      json.RawMessage `json:"records"`
+	Signature    []byte          `json:"signature"`
+	Certificates [][]byte        `json:"certificates"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendAuditLog sends audit log records.
+func (handler *AmqpHandler) SendAuditLog(auditLog AuditLog) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	auditLog.MessageType = AuditLogMessageType
+
+	return handler.scheduleMessage(auditLog, true)
+}
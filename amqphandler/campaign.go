@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// CampaignSummaryMessageType campaign summary message type.
+const CampaignSummaryMessageType = "campaignSummary"
+
+// Campaign states.
+const (
+	CampaignStateInProgress = "inProgress"
+	CampaignStateCompleted  = "completed"
+	CampaignStateFailed     = "failed"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CampaignUpdate FOTA or SOTA update performed as part of the campaign.
+type CampaignUpdate struct {
+	Type          string                   `json:"type"`
+	CorrelationID string                   `json:"correlationId"`
+	State         string                   `json:"state"`
+	Timestamp     time.Time                `json:"timestamp"`
+	ErrorInfo     *cloudprotocol.ErrorInfo `json:"errorInfo,omitempty"`
+}
+
+// CampaignSummary summary of desired statuses and updates of the campaign on the unit. Desired statuses are identified
+// by correlation IDs.
+type CampaignSummary struct {
+	MessageType     string           `json:"messageType"`
+	CampaignID      string           `json:"campaignId"`
+	State           string           `json:"state"`
+	StartedAt       time.Time        `json:"startedAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+	DesiredStatuses []string         `json:"desiredStatuses"`
+	Updates         []CampaignUpdate `json:"updates,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendCampaignSummary sends campaign summary.
+func (handler *AmqpHandler) SendCampaignSummary(summary CampaignSummary) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	summary.MessageType = CampaignSummaryMessageType
+
+	return handler.scheduleMessage(summary, true)
+}
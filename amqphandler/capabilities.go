@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"sort"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// UnitCapabilities unit capabilities announced in full unit status. The cloud may use it to tailor desired status
+// for the unit.
+type UnitCapabilities struct {
+	ProtocolVersion uint64 `json:"protocolVersion"`
+	// ProtocolFeatures cloud message types supported by the unit.
+	ProtocolFeatures []string `json:"protocolFeatures"`
+	// Transports cloud connection and download URL schemes supported by the unit.
+	Transports []string `json:"transports"`
+	// Features CM feature flags.
+	Features map[string]bool    `json:"features,omitempty"`
+	Nodes    []NodeCapabilities `json:"nodes,omitempty"`
+}
+
+// NodeCapabilities node capabilities.
+type NodeCapabilities struct {
+	NodeID          string             `json:"nodeId"`
+	Runners         []RunnerCapability `json:"runners"`
+	LayerMediaTypes []string           `json:"layerMediaTypes,omitempty"`
+}
+
+// RunnerCapability runner supported by node.
+type RunnerCapability struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// setProtocolCapabilities sets protocol related capabilities known by AMQP handler.
+func (handler *AmqpHandler) setProtocolCapabilities(capabilities *UnitCapabilities) {
+	capabilities.ProtocolVersion = cloudprotocol.ProtocolVersion
+	capabilities.ProtocolFeatures = make([]string, 0, len(messageMap))
+
+	for messageType := range messageMap {
+		capabilities.ProtocolFeatures = append(capabilities.ProtocolFeatures, messageType)
+	}
+
+	sort.Strings(capabilities.ProtocolFeatures)
+
+	if handler.scheme != "" {
+		capabilities.Transports = append([]string{handler.scheme}, capabilities.Transports...)
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Decryption info message types.
+const (
+	DecryptionInfoRequestMessageType = "decryptionInfoRequest"
+	DecryptionInfoUpdateMessageType  = "decryptionInfoUpdate"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// DecryptionItem item which decryption info is requested. Item type is one of download item types, layers are
+// identified by digest.
+type DecryptionItem struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// DecryptionInfoRequest requests decryption info of the items encrypted for the outdated receiver certificate.
+type DecryptionInfoRequest struct {
+	MessageType   string           `json:"messageType"`
+	RequestID     string           `json:"requestId"`
+	CorrelationID string           `json:"correlationId,omitempty"`
+	Items         []DecryptionItem `json:"items"`
+}
+
+// ItemDecryptionInfo decryption info of the item for the current receiver certificate.
+type ItemDecryptionInfo struct {
+	DecryptionItem
+	DecryptionInfo cloudprotocol.DecryptionInfo `json:"decryptionInfo"`
+}
+
+// DecryptionInfoUpdate decryption info update received in response to decryption info request.
+type DecryptionInfoUpdate struct {
+	MessageType string               `json:"messageType"`
+	RequestID   string               `json:"requestId"`
+	Items       []ItemDecryptionInfo `json:"items"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendDecryptionInfoRequest sends decryption info request.
+func (handler *AmqpHandler) SendDecryptionInfoRequest(request DecryptionInfoRequest) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	request.MessageType = DecryptionInfoRequestMessageType
+
+	return handler.scheduleMessage(request, true)
+}
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"fmt"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Desired status report message types.
+const (
+	DesiredStatusReportMessageType       = "desiredStatusReport"
+	DesiredStatusConfirmationMessageType = "desiredStatusConfirmation"
+	DesiredStatusRejectionMessageType    = "desiredStatusRejection"
+)
+
+const maxRejectionErrors = 50
+
+// Download item types.
+const (
+	DownloadTypeService   = "service"
+	DownloadTypeLayer     = "layer"
+	DownloadTypeComponent = "component"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// DesiredStatus desired status with processing options. If dry run is set, the desired status is not applied and only
+// the changes report is sent. If confirmation is required, the desired status containing destructive changes is
+// applied only after it is confirmed by desired status confirmation message. Correlation ID marks logs and messages
+// related to the desired status, it is generated by CM if not set by the cloud. Optional campaign ID groups desired
+// statuses of the same rollout.
+type DesiredStatus struct {
+	cloudprotocol.DesiredStatus
+	DryRun              bool   `json:"dryRun,omitempty"`
+	RequireConfirmation bool   `json:"requireConfirmation,omitempty"`
+	CorrelationID       string `json:"correlationId,omitempty"`
+	CampaignID          string `json:"campaignId,omitempty"`
+}
+
+// ItemChange changed item. From version is empty for added items, to version is empty for removed items.
+type ItemChange struct {
+	ID          string `json:"id"`
+	Type        string `json:"type,omitempty"`
+	FromVersion string `json:"fromVersion,omitempty"`
+	ToVersion   string `json:"toVersion,omitempty"`
+}
+
+// InstanceMove instance which is moved from the node.
+type InstanceMove struct {
+	aostypes.InstanceIdent
+	NodeID string `json:"nodeId"`
+}
+
+// DownloadItem item required to be downloaded.
+type DownloadItem struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Size    uint64 `json:"size"`
+}
+
+// DesiredStatusReport changes which desired status will make on the unit.
+type DesiredStatusReport struct {
+	MessageType          string                   `json:"messageType"`
+	ReportID             string                   `json:"reportId"`
+	CorrelationID        string                   `json:"correlationId,omitempty"`
+	CampaignID           string                   `json:"campaignId,omitempty"`
+	DryRun               bool                     `json:"dryRun,omitempty"`
+	ConfirmationRequired bool                     `json:"confirmationRequired,omitempty"`
+	UnitConfig           *ItemChange              `json:"unitConfig,omitempty"`
+	AddedServices        []ItemChange             `json:"addedServices,omitempty"`
+	UpdatedServices      []ItemChange             `json:"updatedServices,omitempty"`
+	RemovedServices      []ItemChange             `json:"removedServices,omitempty"`
+	AddedLayers          []ItemChange             `json:"addedLayers,omitempty"`
+	RemovedLayers        []ItemChange             `json:"removedLayers,omitempty"`
+	Components           []ItemChange             `json:"components,omitempty"`
+	StartedInstances     []aostypes.InstanceIdent `json:"startedInstances,omitempty"`
+	StoppedInstances     []aostypes.InstanceIdent `json:"stoppedInstances,omitempty"`
+	MovedInstances       []InstanceMove           `json:"movedInstances,omitempty"`
+	Downloads            []DownloadItem           `json:"downloads,omitempty"`
+	DownloadSize         uint64                   `json:"downloadSize"`
+}
+
+// DesiredStatusConfirmation confirms or rejects desired status waiting for confirmation.
+type DesiredStatusConfirmation struct {
+	MessageType string `json:"messageType"`
+	ReportID    string `json:"reportId"`
+	Confirmed   bool   `json:"confirmed"`
+}
+
+// ValidationError desired status validation error. Path is JSON pointer to the invalid value.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// DesiredStatusRejection rejects invalid desired status. Desired status is not processed if it is rejected.
+type DesiredStatusRejection struct {
+	MessageType   string            `json:"messageType"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	CampaignID    string            `json:"campaignId,omitempty"`
+	Errors        []ValidationError `json:"errors"`
+	Truncated     bool              `json:"truncated,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendDesiredStatusReport sends desired status changes report.
+func (handler *AmqpHandler) SendDesiredStatusReport(report DesiredStatusReport) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	report.MessageType = DesiredStatusReportMessageType
+
+	return handler.scheduleMessage(report, true)
+}
+
+// SendDesiredStatusRejection sends invalid desired status rejection.
+func (handler *AmqpHandler) SendDesiredStatusRejection(rejection DesiredStatusRejection) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	rejection.MessageType = DesiredStatusRejectionMessageType
+
+	if len(rejection.Errors) > maxRejectionErrors {
+		rejection.Errors = rejection.Errors[:maxRejectionErrors]
+		rejection.Truncated = true
+	}
+
+	return handler.scheduleMessage(rejection, true)
+}
+
+// String returns validation error string representation.
+func (validationErr ValidationError) String() string {
+	if validationErr.Path == "" {
+		return validationErr.Message
+	}
+
+	return fmt.Sprintf("%s: %s", validationErr.Path, validationErr.Message)
+}
+
+// IsDestructive returns true if the report contains changes which remove software, stop or move instances or flash
+// components.
+func (report DesiredStatusReport) IsDestructive() bool {
+	return len(report.RemovedServices) != 0 || len(report.RemovedLayers) != 0 || len(report.Components) != 0 ||
+		len(report.StoppedInstances) != 0 || len(report.MovedInstances) != 0
+}
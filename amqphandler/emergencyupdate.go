@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Emergency update message types.
+const (
+	EmergencyUpdateMessageType       = "emergencyUpdate"
+	EmergencyUpdateStatusMessageType = "emergencyUpdateStatus"
+)
+
+// emergencyChannelSize emergency updates are rare, the queue only buffers updates received while previous one is
+// being applied.
+const emergencyChannelSize = 2
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// EmergencyUpdate emergency update of single component. Update contains JSON encoded emergency update info signed by
+// authorization sign. Certificates and certificate chains are used to verify the authorization and the component.
+type EmergencyUpdate struct {
+	MessageType   string                           `json:"messageType"`
+	Update        json.RawMessage                  `json:"update"`
+	Authorization cloudprotocol.Signs              `json:"authorization"`
+	CertChains    []cloudprotocol.CertificateChain `json:"certificateChains,omitempty"`
+	Certs         []cloudprotocol.Certificate      `json:"certificates,omitempty"`
+}
+
+// EmergencyUpdateInfo emergency update info. The update is rejected after valid until time. Schedule is used only if
+// emergency updates are not allowed to ignore timetable by unit policy.
+type EmergencyUpdateInfo struct {
+	UpdateID   string                      `json:"updateId"`
+	ValidUntil time.Time                   `json:"validUntil"`
+	Component  cloudprotocol.ComponentInfo `json:"component"`
+	Schedule   cloudprotocol.ScheduleRule  `json:"schedule,omitempty"`
+}
+
+// EmergencyUpdateStatus emergency update status.
+type EmergencyUpdateStatus struct {
+	MessageType string                   `json:"messageType"`
+	UpdateID    string                   `json:"updateId"`
+	Status      string                   `json:"status"`
+	ErrorInfo   *cloudprotocol.ErrorInfo `json:"errorInfo,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendEmergencyUpdateStatus sends emergency update status.
+func (handler *AmqpHandler) SendEmergencyUpdateStatus(status EmergencyUpdateStatus) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	status.MessageType = EmergencyUpdateStatusMessageType
+
+	return handler.scheduleMessage(status, true)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// queueEmergencyUpdate puts emergency update to the separate queue which is not blocked by regular messages.
+func (handler *AmqpHandler) queueEmergencyUpdate(update *EmergencyUpdate) {
+	select {
+	case handler.EmergencyUpdateChannel <- update:
+
+	default:
+		log.Error("Emergency update queue is full, update dropped")
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// FeatureFlagsMessageType feature flags message type.
+const FeatureFlagsMessageType = "featureFlags"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// FeatureFlags sets unit feature flags. Flags which are not set are reset to their defaults.
+type FeatureFlags struct {
+	MessageType string          `json:"messageType"`
+	Flags       map[string]bool `json:"flags"`
+}
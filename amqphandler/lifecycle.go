@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// InstanceLifecycleMessageType instance lifecycle message type.
+const InstanceLifecycleMessageType = "instanceLifecycle"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// InstanceLifecycleEvent instance lifecycle event.
+type InstanceLifecycleEvent struct {
+	aostypes.InstanceIdent
+	Timestamp  time.Time `json:"timestamp"`
+	Event      string    `json:"event"`
+	NodeID     string    `json:"nodeId,omitempty"`
+	PrevNodeID string    `json:"prevNodeId,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// InstanceLifecycle batch of instance lifecycle events sorted by time.
+type InstanceLifecycle struct {
+	MessageType string                   `json:"messageType"`
+	Events      []InstanceLifecycleEvent `json:"events"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendInstanceLifecycle sends instance lifecycle events.
+func (handler *AmqpHandler) SendInstanceLifecycle(lifecycle InstanceLifecycle) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	lifecycle.MessageType = InstanceLifecycleMessageType
+
+	return handler.scheduleMessage(lifecycle, true)
+}
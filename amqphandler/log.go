@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// LogPartsRequestMessageType log parts request message type.
+const LogPartsRequestMessageType = "logPartsRequest"
+
+// Log compression algorithms.
+const (
+	LogCompressionGzip = "gzip"
+	LogCompressionZstd = "zstd"
+)
+
+// Log severities in ascending order of importance.
+const (
+	LogSeverityDebug    = "debug"
+	LogSeverityInfo     = "info"
+	LogSeverityNotice   = "notice"
+	LogSeverityWarning  = "warning"
+	LogSeverityError    = "error"
+	LogSeverityCritical = "critical"
+	LogSeverityAlert    = "alert"
+	LogSeverityEmerg    = "emergency"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// LogFilter log request filter. Time range, node and instance filters are applied by nodes, pattern and severity
+// filters and size cap are applied by CM to the collected log.
+type LogFilter struct {
+	cloudprotocol.LogFilter
+	// Pattern regular expression log lines should match.
+	Pattern string `json:"pattern,omitempty"`
+	// Severity minimal severity of log lines.
+	Severity string `json:"severity,omitempty"`
+	// MaxSize maximum size of node log after filtering, the log is truncated if exceeded.
+	MaxSize uint64 `json:"maxSize,omitempty"`
+}
+
+// RequestLog request log message.
+type RequestLog struct {
+	MessageType string    `json:"messageType"`
+	LogID       string    `json:"logId"`
+	LogType     string    `json:"logType"`
+	Filter      LogFilter `json:"filter"`
+}
+
+// PushLog push log message. Truncated is set in the last part of the log which exceeds requested size. Checksum is
+// SHA-256 hex digest of the whole compressed log, part checksum is SHA-256 hex digest of the part content.
+type PushLog struct {
+	cloudprotocol.PushLog
+	Truncated    bool   `json:"truncated,omitempty"`
+	Compression  string `json:"compression,omitempty"`
+	Checksum     string `json:"checksum,omitempty"`
+	PartChecksum string `json:"partChecksum,omitempty"`
+}
+
+// LogPartsRequest requests to resend log parts which are lost or corrupted. All parts are resent if parts are not set.
+type LogPartsRequest struct {
+	MessageType string   `json:"messageType"`
+	LogID       string   `json:"logId"`
+	NodeID      string   `json:"nodeId"`
+	Parts       []uint64 `json:"parts,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendLog sends system or service logs.
+func (handler *AmqpHandler) SendLog(pushLog PushLog) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	pushLog.MessageType = cloudprotocol.PushLogMessageType
+
+	return handler.scheduleCategoryMessage(pushLog, messageCategoryLogs, true)
+}
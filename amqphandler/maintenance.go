@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import "time"
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// MaintenanceModeRequestMessageType maintenance mode request message type.
+const MaintenanceModeRequestMessageType = "maintenanceModeRequest"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// MaintenanceModeRequest enables or disables unit maintenance mode.
+type MaintenanceModeRequest struct {
+	MessageType string `json:"messageType"`
+	Enabled     bool   `json:"enabled"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// MaintenanceStatus unit maintenance mode reported in full unit status while the mode is enabled.
+type MaintenanceStatus struct {
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
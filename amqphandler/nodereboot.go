@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import "github.com/aosedge/aos_common/api/cloudprotocol"
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Node reboot message types.
+const (
+	NodeRebootRequestMessageType = "nodeRebootRequest"
+	NodeRebootStatusMessageType  = "nodeRebootStatus"
+)
+
+// Node reboot statuses.
+const (
+	NodeRebootScheduled = "scheduled"
+	NodeRebootDone      = "done"
+	NodeRebootFailed    = "failed"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// NodeRebootRequest requests reboot of the nodes. Whole unit is rebooted if no node IDs are set. Nodes are rebooted
+// within the timetable window or immediately if timetable is empty.
+type NodeRebootRequest struct {
+	MessageType string                         `json:"messageType"`
+	RequestID   string                         `json:"requestId"`
+	NodeIDs     []string                       `json:"nodeIds,omitempty"`
+	Timetable   []cloudprotocol.TimetableEntry `json:"timetable,omitempty"`
+}
+
+// NodeRebootResult reboot result of the node.
+type NodeRebootResult struct {
+	NodeID string `json:"nodeId"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NodeRebootStatus node reboot request status.
+type NodeRebootStatus struct {
+	MessageType string             `json:"messageType"`
+	RequestID   string             `json:"requestId"`
+	Status      string             `json:"status"`
+	Nodes       []NodeRebootResult `json:"nodes,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendNodeRebootStatus sends node reboot request status.
+func (handler *AmqpHandler) SendNodeRebootStatus(status NodeRebootStatus) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	status.MessageType = NodeRebootStatusMessageType
+
+	return handler.scheduleMessage(status, true)
+}
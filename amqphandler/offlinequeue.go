@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Message categories of offline queue.
+const (
+	messageCategoryNone       = ""
+	messageCategoryAlerts     = "alerts"
+	messageCategoryStatuses   = "statuses"
+	messageCategoryMonitoring = "monitoring"
+	messageCategoryLogs       = "logs"
+
+	messageCategoryNetworkEvents = "networkEvents"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// scheduleCategoryMessage schedules message of the category. While offline, the message is put to the offline queue
+// if the category is buffered, otherwise it is handled as not categorized message.
+func (handler *AmqpHandler) scheduleCategoryMessage(data interface{}, category string, important bool) error {
+	message := outgoingMessage{
+		message:       handler.createCloudMessage(data),
+		correlationID: logging.GetCorrelationID(),
+		campaignID:    logging.GetCampaignID(),
+		category:      category,
+		timestamp:     time.Now(),
+	}
+
+	if !handler.isConnected {
+		if categoryConfig := handler.getCategoryConfig(category); categoryConfig.MaxCount > 0 {
+			handler.queueOfflineMessage(message, categoryConfig)
+
+			return nil
+		}
+
+		if !important {
+			return ErrNotConnected
+		}
+	}
+
+	select {
+	case handler.sendChannel <- message:
+		return nil
+
+	case <-time.After(sendTimeout):
+		return ErrSendChannelFull
+	}
+}
+
+func (handler *AmqpHandler) getCategoryConfig(category string) config.OfflineQueueCategory {
+	switch category {
+	case messageCategoryAlerts:
+		return handler.offlineQueueConfig.Alerts
+
+	case messageCategoryStatuses:
+		return handler.offlineQueueConfig.Statuses
+
+	case messageCategoryMonitoring:
+		return handler.offlineQueueConfig.Monitoring
+
+	case messageCategoryLogs:
+		return handler.offlineQueueConfig.Logs
+
+	case messageCategoryNetworkEvents:
+		return handler.networkEventsQueueConfig
+
+	default:
+		return config.OfflineQueueCategory{}
+	}
+}
+
+// getRetentionQueueConfig returns offline queue config of the category which is bounded by retention policy.
+func getRetentionQueueConfig(cfg *config.Config, dataClass string) config.OfflineQueueCategory {
+	policy, ok := cfg.GetRetentionPolicy(dataClass)
+	if !ok {
+		return config.OfflineQueueCategory{}
+	}
+
+	return config.OfflineQueueCategory{TTL: policy.MaxAge, MaxCount: policy.MaxRecords}
+}
+
+func (handler *AmqpHandler) isMessageExpired(message outgoingMessage) bool {
+	ttl := handler.getCategoryConfig(message.category).TTL.Duration
+
+	return ttl > 0 && time.Since(message.timestamp) > ttl
+}
+
+func (handler *AmqpHandler) queueOfflineMessage(message outgoingMessage, categoryConfig config.OfflineQueueCategory) {
+	handler.offlineMutex.Lock()
+	defer handler.offlineMutex.Unlock()
+
+	var (
+		count       int
+		oldestIndex = -1
+	)
+
+	for i := len(handler.offlineMessages) - 1; i >= 0; i-- {
+		offlineMessage := handler.offlineMessages[i]
+
+		if offlineMessage.category != message.category {
+			continue
+		}
+
+		if handler.isMessageExpired(offlineMessage) {
+			handler.offlineMessages = append(handler.offlineMessages[:i], handler.offlineMessages[i+1:]...)
+
+			continue
+		}
+
+		count++
+		oldestIndex = i
+	}
+
+	if count >= categoryConfig.MaxCount && oldestIndex >= 0 {
+		log.WithField("category", message.category).Warn("Offline queue is full, drop the oldest message")
+
+		handler.offlineMessages = append(
+			handler.offlineMessages[:oldestIndex], handler.offlineMessages[oldestIndex+1:]...)
+	}
+
+	handler.offlineMessages = append(handler.offlineMessages, message)
+}
+
+// popOfflineMessage returns the oldest not expired offline message.
+func (handler *AmqpHandler) popOfflineMessage() (message outgoingMessage, ok bool) {
+	handler.offlineMutex.Lock()
+	defer handler.offlineMutex.Unlock()
+
+	for len(handler.offlineMessages) > 0 {
+		message, handler.offlineMessages = handler.offlineMessages[0], handler.offlineMessages[1:]
+
+		if !handler.isMessageExpired(message) {
+			return message, true
+		}
+
+		log.WithField("category", message.category).Debug("Drop expired offline message")
+	}
+
+	return message, false
+}
+
+func (handler *AmqpHandler) getOfflineQueueLength() int {
+	handler.offlineMutex.Lock()
+	defer handler.offlineMutex.Unlock()
+
+	return len(handler.offlineMessages)
+}
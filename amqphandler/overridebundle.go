@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// OverrideBundleReportMessageType override bundle report message type.
+const OverrideBundleReportMessageType = "overrideBundleReport"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// OverrideBundleReport reports desired status applied on the unit from local override bundle.
+type OverrideBundleReport struct {
+	MessageType   string                      `json:"messageType"`
+	BundleID      string                      `json:"bundleId"`
+	AppliedAt     time.Time                   `json:"appliedAt"`
+	DesiredStatus cloudprotocol.DesiredStatus `json:"desiredStatus"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendOverrideBundleReport sends override bundle report.
+func (handler *AmqpHandler) SendOverrideBundleReport(report OverrideBundleReport) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	report.MessageType = OverrideBundleReportMessageType
+
+	return handler.scheduleMessage(report, true)
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"encoding/json"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Owner change message types.
+const (
+	OwnerChangeRequestMessageType = "ownerChangeRequest"
+	OwnerChangeStatusMessageType  = "ownerChangeStatus"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// OwnerChangeRequest owner change request. Info contains JSON encoded owner change request info signed by
+// authorization sign. Certificates and certificate chains are used to verify the authorization.
+type OwnerChangeRequest struct {
+	MessageType   string                           `json:"messageType"`
+	Info          json.RawMessage                  `json:"info"`
+	Authorization cloudprotocol.Signs              `json:"authorization"`
+	CertChains    []cloudprotocol.CertificateChain `json:"certificateChains,omitempty"`
+	Certs         []cloudprotocol.Certificate      `json:"certificates,omitempty"`
+}
+
+// OwnerChangeStatus owner change completion report. Signature is calculated over report bytes with online key,
+// certificates contain DER encoded online certificate chain.
+type OwnerChangeStatus struct {
+	MessageType  string          `json:"messageType"`
+	RequestID    string          `json:"requestId"`
+	Report       json.RawMessage `json:"report"`
+	Signature    []byte          `json:"signature"`
+	Certificates [][]byte        `json:"certificates"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendOwnerChangeStatus sends owner change completion report.
+func (handler *AmqpHandler) SendOwnerChangeStatus(status OwnerChangeStatus) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	status.MessageType = OwnerChangeStatusMessageType
+
+	return handler.scheduleMessage(status, true)
+}
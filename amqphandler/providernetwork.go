@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// ProviderNetworkEventsMessageType provider network events message type.
+const ProviderNetworkEventsMessageType = "providerNetworkEvents"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ProviderNetworkEvent provider network lifecycle event.
+type ProviderNetworkEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Event     string    `json:"event"`
+	NetworkID string    `json:"networkId"`
+	Subnet    string    `json:"subnet,omitempty"`
+	VlanID    uint64    `json:"vlanId,omitempty"`
+	NodeID    string    `json:"nodeId,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+}
+
+// ProviderNetworkEvents batch of provider network lifecycle events.
+type ProviderNetworkEvents struct {
+	MessageType string                 `json:"messageType"`
+	Events      []ProviderNetworkEvent `json:"events"`
+}
+
+// ProviderNetworkNode node attached to provider network.
+type ProviderNetworkNode struct {
+	NodeID string `json:"nodeId"`
+	IP     string `json:"ip"`
+}
+
+// ProviderNetworkStatus provider network reported in full unit status.
+type ProviderNetworkStatus struct {
+	NetworkID string                `json:"networkId"`
+	Subnet    string                `json:"subnet"`
+	VlanID    uint64                `json:"vlanId"`
+	Nodes     []ProviderNetworkNode `json:"nodes,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendProviderNetworkEvents sends provider network lifecycle events.
+func (handler *AmqpHandler) SendProviderNetworkEvents(events ProviderNetworkEvents) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	events.MessageType = ProviderNetworkEventsMessageType
+
+	return handler.scheduleCategoryMessage(events, messageCategoryNetworkEvents, true)
+}
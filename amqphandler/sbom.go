@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import "github.com/aosedge/aos_communicationmanager/sbom"
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// SBOM message types.
+const (
+	SBOMRequestMessageType = "sbomRequest"
+	SBOMMessageType        = "sbom"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SBOMRequest requests unit aggregate SBOM.
+type SBOMRequest struct {
+	MessageType string `json:"messageType"`
+	RequestID   string `json:"requestId"`
+}
+
+// SBOM unit aggregate SBOM sent in response to SBOM request.
+type SBOM struct {
+	MessageType string          `json:"messageType"`
+	RequestID   string          `json:"requestId"`
+	Services    []sbom.Document `json:"services"`
+	Error       string          `json:"error,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendSBOM sends unit aggregate SBOM.
+func (handler *AmqpHandler) SendSBOM(unitSBOM SBOM) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	unitSBOM.MessageType = SBOMMessageType
+
+	return handler.scheduleMessage(unitSBOM, true)
+}
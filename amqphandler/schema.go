@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+
+	"github.com/aosedge/aos_communicationmanager/utils/jsonschema"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type schemaError struct {
+	correlationID string
+	campaignID    string
+	errors        []ValidationError
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//go:embed desiredstatus.schema.json
+var desiredStatusSchemaData []byte
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+func (err *schemaError) Error() string {
+	messages := make([]string, 0, len(err.errors))
+
+	for _, validationErr := range err.errors {
+		messages = append(messages, validationErr.String())
+	}
+
+	return "message doesn't match schema: " + strings.Join(messages, ", ")
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// validateDesiredStatus validates raw desired status and its unit config if unit config schema is set.
+func validateDesiredStatus(desiredStatusSchema, unitConfigSchema *jsonschema.Schema, data []byte) error {
+	violations, err := desiredStatusSchema.Validate(data)
+	if err != nil {
+		return err
+	}
+
+	var desiredStatus struct {
+		CorrelationID string          `json:"correlationId"`
+		CampaignID    string          `json:"campaignId"`
+		UnitConfig    json.RawMessage `json:"unitConfig"`
+	}
+
+	// fields are decoded on best effort basis as they may be invalid as well
+	_ = json.Unmarshal(data, &desiredStatus)
+
+	if unitConfigSchema != nil && len(desiredStatus.UnitConfig) != 0 && string(desiredStatus.UnitConfig) != "null" {
+		unitConfigViolations, err := unitConfigSchema.Validate(desiredStatus.UnitConfig)
+		if err != nil {
+			return err
+		}
+
+		for _, violation := range unitConfigViolations {
+			violation.Path = "/unitConfig" + violation.Path
+			violations = append(violations, violation)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	validationErrors := make([]ValidationError, 0, len(violations))
+
+	for _, violation := range violations {
+		validationErrors = append(validationErrors, ValidationError{Path: violation.Path, Message: violation.Message})
+	}
+
+	return &schemaError{
+		correlationID: desiredStatus.CorrelationID, campaignID: desiredStatus.CampaignID, errors: validationErrors,
+	}
+}
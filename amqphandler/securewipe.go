@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import "time"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ServiceWipeStatus confirms secure wipe of removed service data reported in full unit status.
+type ServiceWipeStatus struct {
+	ServiceID string    `json:"serviceId"`
+	Version   string    `json:"version"`
+	WipedAt   time.Time `json:"wipedAt"`
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Telemetry profile names.
+const (
+	TelemetryProfileFull    = "full"
+	TelemetryProfileReduced = "reduced"
+)
+
+const (
+	// weight of new sample in the average link values.
+	linkSampleWeight = 0.25
+	// link values should be restoreHysteresis times better than limits to restore full profile.
+	restoreHysteresis = 2
+	// messages smaller than this size are not used to estimate bandwidth.
+	minBandwidthSampleSize = 16 * 1024
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// TelemetryProfile telemetry profile.
+type TelemetryProfile struct {
+	Name string
+	// RateFactor send periods and aggregation of telemetry data should be multiplied by this factor.
+	RateFactor int
+}
+
+// TelemetryProfileConsumer telemetry profile consumer interface.
+type TelemetryProfileConsumer interface {
+	TelemetryProfileChanged(profile TelemetryProfile)
+}
+
+type linkEstimator struct {
+	latency   float64
+	bandwidth float64
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetTelemetryProfile returns current telemetry profile.
+func (handler *AmqpHandler) GetTelemetryProfile() TelemetryProfile {
+	handler.telemetryMutex.Lock()
+	defer handler.telemetryMutex.Unlock()
+
+	return handler.telemetryProfile
+}
+
+// SubscribeForTelemetryProfileChanges subscribes for telemetry profile changes.
+func (handler *AmqpHandler) SubscribeForTelemetryProfileChanges(consumer TelemetryProfileConsumer) error {
+	handler.telemetryMutex.Lock()
+	defer handler.telemetryMutex.Unlock()
+
+	for _, subscribedConsumer := range handler.telemetryConsumers {
+		if subscribedConsumer == consumer {
+			return aoserrors.New("already subscribed")
+		}
+	}
+
+	handler.telemetryConsumers = append(handler.telemetryConsumers, consumer)
+
+	return nil
+}
+
+// UnsubscribeFromTelemetryProfileChanges unsubscribes from telemetry profile changes.
+func (handler *AmqpHandler) UnsubscribeFromTelemetryProfileChanges(consumer TelemetryProfileConsumer) error {
+	handler.telemetryMutex.Lock()
+	defer handler.telemetryMutex.Unlock()
+
+	for i, subscribedConsumer := range handler.telemetryConsumers {
+		if subscribedConsumer == consumer {
+			handler.telemetryConsumers = append(handler.telemetryConsumers[:i], handler.telemetryConsumers[i+1:]...)
+
+			return nil
+		}
+	}
+
+	return aoserrors.New("not subscribed")
+}
+
+// FeatureFlagsChanged enables or disables telemetry rates adaptation by adaptiveTelemetry feature flag. Full profile
+// is restored when adaptation is disabled.
+func (handler *AmqpHandler) FeatureFlagsChanged(flags map[string]bool) {
+	handler.telemetryMutex.Lock()
+
+	enabled := flags[config.FeatureAdaptiveTelemetry]
+
+	if enabled == handler.telemetryConfig.Enabled {
+		handler.telemetryMutex.Unlock()
+
+		return
+	}
+
+	log.WithField("enabled", enabled).Info("Adaptive telemetry changed")
+
+	handler.telemetryConfig.Enabled = enabled
+	handler.link = linkEstimator{}
+
+	if enabled || handler.telemetryProfile.Name == TelemetryProfileFull {
+		handler.telemetryMutex.Unlock()
+
+		return
+	}
+
+	handler.setTelemetryProfile(TelemetryProfileFull)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (handler *AmqpHandler) resetLinkEstimation() {
+	handler.telemetryMutex.Lock()
+	defer handler.telemetryMutex.Unlock()
+
+	handler.link = linkEstimator{}
+}
+
+func (handler *AmqpHandler) processSendSample(size int, sendTime time.Duration) {
+	handler.telemetryMutex.Lock()
+
+	if !handler.telemetryConfig.Enabled {
+		handler.telemetryMutex.Unlock()
+
+		return
+	}
+
+	handler.link.addSample(size, sendTime)
+
+	profileName := handler.telemetryProfile.Name
+
+	switch profileName {
+	case TelemetryProfileFull:
+		if handler.link.isConstrained(handler.telemetryConfig.MaxSendLatency.Duration,
+			handler.telemetryConfig.MinBandwidth, 1) {
+			profileName = TelemetryProfileReduced
+		}
+
+	case TelemetryProfileReduced:
+		if !handler.link.isConstrained(handler.telemetryConfig.MaxSendLatency.Duration,
+			handler.telemetryConfig.MinBandwidth, restoreHysteresis) {
+			profileName = TelemetryProfileFull
+		}
+	}
+
+	if profileName == handler.telemetryProfile.Name {
+		handler.telemetryMutex.Unlock()
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"profile":   profileName,
+		"latency":   time.Duration(handler.link.latency),
+		"bandwidth": uint64(handler.link.bandwidth),
+	}).Info("Telemetry profile changed")
+
+	handler.setTelemetryProfile(profileName)
+}
+
+// setTelemetryProfile sets telemetry profile and notifies consumers. It should be called with telemetry mutex locked,
+// the mutex is unlocked before notifying, so consumers may call handler methods. Notify mutex is taken before unlock
+// to keep notifications in the order of profile changes.
+func (handler *AmqpHandler) setTelemetryProfile(name string) {
+	handler.telemetryProfile = handler.createTelemetryProfile(name)
+
+	profile := handler.telemetryProfile
+	consumers := slices.Clone(handler.telemetryConsumers)
+
+	handler.telemetryNotifyMutex.Lock()
+	defer handler.telemetryNotifyMutex.Unlock()
+
+	handler.telemetryMutex.Unlock()
+
+	for _, consumer := range consumers {
+		consumer.TelemetryProfileChanged(profile)
+	}
+}
+
+func (handler *AmqpHandler) createTelemetryProfile(name string) TelemetryProfile {
+	if name == TelemetryProfileReduced && handler.telemetryConfig.ReducedRateFactor > 1 {
+		return TelemetryProfile{Name: name, RateFactor: handler.telemetryConfig.ReducedRateFactor}
+	}
+
+	return TelemetryProfile{Name: name, RateFactor: 1}
+}
+
+func (estimator *linkEstimator) addSample(size int, sendTime time.Duration) {
+	estimator.latency = average(estimator.latency, float64(sendTime))
+
+	if size >= minBandwidthSampleSize && sendTime > 0 {
+		estimator.bandwidth = average(estimator.bandwidth, float64(size)/sendTime.Seconds())
+	}
+}
+
+func (estimator *linkEstimator) isConstrained(maxLatency time.Duration, minBandwidth uint64, margin float64) bool {
+	if maxLatency > 0 && estimator.latency*margin > float64(maxLatency) {
+		return true
+	}
+
+	if minBandwidth > 0 && estimator.bandwidth != 0 && estimator.bandwidth < float64(minBandwidth)*margin {
+		return true
+	}
+
+	return false
+}
+
+func average(current, sample float64) float64 {
+	if current == 0 {
+		return sample
+	}
+
+	return current*(1-linkSampleWeight) + sample*linkSampleWeight
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqphandler
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// UnitMonitoringMessageType unit monitoring message type.
+const UnitMonitoringMessageType = "unitMonitoring"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// UnitMonitoring unit-level monitoring rollup.
+type UnitMonitoring struct {
+	MessageType string                    `json:"messageType"`
+	Timestamp   time.Time                 `json:"timestamp"`
+	Nodes       int                       `json:"nodes"`
+	RAM         uint64                    `json:"ram"`
+	CPU         uint64                    `json:"cpu"`
+	Disk        uint64                    `json:"disk"`
+	Download    uint64                    `json:"download"`
+	Upload      uint64                    `json:"upload"`
+	Instances   map[string]int            `json:"instances"`
+	Networks    map[string]NetworkTraffic `json:"networks,omitempty"`
+	Transfers   map[string]NodeTransfers  `json:"transfers,omitempty"`
+	DNS         []InstanceDNS             `json:"dns,omitempty"`
+	CM          *CMMonitoring             `json:"cm,omitempty"`
+}
+
+// NetworkTraffic provider network traffic.
+type NetworkTraffic struct {
+	Instances int    `json:"instances"`
+	Download  uint64 `json:"download"`
+	Upload    uint64 `json:"upload"`
+}
+
+// NodeTransfers node file transfers statistics.
+type NodeTransfers struct {
+	Active    int    `json:"active"`
+	Finished  uint64 `json:"finished"`
+	Rejected  uint64 `json:"rejected"`
+	SentBytes uint64 `json:"sentBytes"`
+}
+
+// InstanceDNS instance DNS resolution statistics.
+type InstanceDNS struct {
+	aostypes.InstanceIdent
+	Queries     uint64            `json:"queries"`
+	NXDomain    uint64            `json:"nxDomain"`
+	UniqueNames int               `json:"uniqueNames"`
+	TopNames    map[string]uint64 `json:"topNames,omitempty"`
+}
+
+// CMMonitoring communication manager self-monitoring data.
+type CMMonitoring struct {
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heapAlloc"`
+	HeapSys    uint64 `json:"heapSys"`
+	NumGC      uint32 `json:"numGC"`
+	// GCPause last GC pause in nanoseconds.
+	GCPause         uint64 `json:"gcPause"`
+	DBSize          uint64 `json:"dbSize"`
+	SendQueue       int    `json:"sendQueue"`
+	MonitoringQueue int    `json:"monitoringQueue"`
+	HistoryQueue    int    `json:"historyQueue"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SendUnitMonitoringData sends unit monitoring rollup.
+func (handler *AmqpHandler) SendUnitMonitoringData(unitMonitoring UnitMonitoring) error {
+	handler.Lock()
+	defer handler.Unlock()
+
+	unitMonitoring.MessageType = UnitMonitoringMessageType
+
+	return handler.scheduleCategoryMessage(unitMonitoring, messageCategoryMonitoring, false)
+}
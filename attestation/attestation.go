@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package attestation creates signed integrity attestation reports of the unit.
+//
+// The report contains SHA3-256 digests of installed service and layer images, unit config and CM executable measured
+// at request time. Image digests are compared with digests recorded on installation, so the cloud can verify that the
+// unit runs exactly what was deployed. The report is signed with the unit online key.
+package attestation
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/image"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/imagemanager"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// HashAlgorithm attestation digests algorithm.
+const HashAlgorithm = "sha3-256"
+
+// Measured item types.
+const (
+	ItemTypeService    = "service"
+	ItemTypeLayer      = "layer"
+	ItemTypeUnitConfig = "unitConfig"
+	ItemTypeBinary     = "binary"
+)
+
+const binaryID = "aos_communicationmanager"
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used in unit tests
+var executablePath = os.Executable
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ImageProvider provides installed services and layers.
+type ImageProvider interface {
+	GetServicesStatus() ([]unitstatushandler.ServiceStatus, error)
+	GetLayersStatus() ([]unitstatushandler.LayerStatus, error)
+	GetServiceInfo(serviceID string) (imagemanager.ServiceInfo, error)
+	GetLayerInfo(digest string) (imagemanager.LayerInfo, error)
+}
+
+// Signer signs data with unit online key.
+type Signer interface {
+	SignData(data []byte) (signature []byte, certificates [][]byte, err error)
+}
+
+// ReportSender sends attestation report to the cloud.
+type ReportSender interface {
+	SendAttestationReport(report amqp.AttestationReport) error
+}
+
+// Report integrity attestation report.
+type Report struct {
+	RequestID     string    `json:"requestId"`
+	Nonce         string    `json:"nonce,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	HashAlgorithm string    `json:"hashAlgorithm"`
+	Items         []Item    `json:"items"`
+}
+
+// Item measured item. Expected is digest recorded on installation, Match is false if measured digest differs from
+// expected one or the item can't be measured.
+type Item struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Version  string `json:"version,omitempty"`
+	Digest   string `json:"digest,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Match    bool   `json:"match"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Attestation integrity attestation.
+type Attestation struct {
+	sync.Mutex
+
+	config        *config.Config
+	imageProvider ImageProvider
+	signer        Signer
+	reportSender  ReportSender
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates integrity attestation.
+func New(
+	config *config.Config, imageProvider ImageProvider, signer Signer, reportSender ReportSender,
+) *Attestation {
+	log.Debug("Create integrity attestation")
+
+	return &Attestation{config: config, imageProvider: imageProvider, signer: signer, reportSender: reportSender}
+}
+
+// CreateReport measures installed items and creates attestation report.
+func (attestation *Attestation) CreateReport(requestID, nonce string) (report Report, err error) {
+	attestation.Lock()
+	defer attestation.Unlock()
+
+	log.WithField("requestID", requestID).Debug("Create attestation report")
+
+	report = Report{
+		RequestID: requestID, Nonce: nonce, Timestamp: time.Now().UTC(), HashAlgorithm: HashAlgorithm,
+	}
+
+	services, err := attestation.imageProvider.GetServicesStatus()
+	if err != nil {
+		return report, aoserrors.Wrap(err)
+	}
+
+	for _, service := range services {
+		if service.ServiceID == "" || service.Cached {
+			continue
+		}
+
+		item := Item{Type: ItemTypeService, ID: service.ServiceID, Version: service.Version}
+
+		serviceInfo, err := attestation.imageProvider.GetServiceInfo(service.ServiceID)
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.measure(serviceInfo.Path, serviceInfo.Sha256)
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	layers, err := attestation.imageProvider.GetLayersStatus()
+	if err != nil {
+		return report, aoserrors.Wrap(err)
+	}
+
+	for _, layer := range layers {
+		if layer.Cached {
+			continue
+		}
+
+		item := Item{Type: ItemTypeLayer, ID: layer.Digest, Version: layer.Version}
+
+		layerInfo, err := attestation.imageProvider.GetLayerInfo(layer.Digest)
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.measure(layerInfo.Path, layerInfo.Sha256)
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	unitConfig := Item{Type: ItemTypeUnitConfig, ID: attestation.config.UnitConfigFile}
+
+	if _, err := os.Stat(attestation.config.UnitConfigFile); !errors.Is(err, os.ErrNotExist) {
+		unitConfig.measure(attestation.config.UnitConfigFile, nil)
+
+		report.Items = append(report.Items, unitConfig)
+	}
+
+	binary := Item{Type: ItemTypeBinary, ID: binaryID}
+
+	if path, err := executablePath(); err != nil {
+		binary.Error = err.Error()
+	} else {
+		binary.measure(path, nil)
+	}
+
+	report.Items = append(report.Items, binary)
+
+	return report, nil
+}
+
+// SendReport creates, signs and sends attestation report to the cloud. Error is sent to the cloud if the report
+// can't be created.
+func (attestation *Attestation) SendReport(requestID, nonce string) error {
+	message := amqp.AttestationReport{RequestID: requestID}
+
+	if err := attestation.signReport(requestID, nonce, &message); err != nil {
+		log.WithField("requestID", requestID).Errorf("Can't create attestation report: %v", err)
+
+		message = amqp.AttestationReport{RequestID: requestID, Error: err.Error()}
+	}
+
+	return aoserrors.Wrap(attestation.reportSender.SendAttestationReport(message))
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (attestation *Attestation) signReport(requestID, nonce string, message *amqp.AttestationReport) (err error) {
+	report, err := attestation.CreateReport(requestID, nonce)
+	if err != nil {
+		return err
+	}
+
+	if message.Report, err = json.Marshal(report); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if message.Signature, message.Certificates, err = attestation.signer.SignData(message.Report); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// measure calculates digest of the file and compares it with expected one if set.
+func (item *Item) measure(path string, expected []byte) {
+	fileInfo, err := image.CreateFileInfo(context.Background(), path)
+	if err != nil {
+		item.Error = err.Error()
+
+		return
+	}
+
+	item.Digest = hex.EncodeToString(fileInfo.Sha256)
+	item.Match = true
+
+	if expected != nil {
+		item.Expected = hex.EncodeToString(expected)
+		item.Match = item.Digest == item.Expected
+	}
+}
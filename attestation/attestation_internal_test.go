@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/image"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/imagemanager"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testImageProvider struct {
+	services map[string]imagemanager.ServiceInfo
+	layers   map[string]imagemanager.LayerInfo
+}
+
+type testSigner struct{}
+
+type testReportSender struct {
+	reports []amqp.AttestationReport
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestAttestationReport(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	servicePath := createFile(t, tmpDir, "service1", "service content")
+	layerPath := createFile(t, tmpDir, "layer1", "layer content")
+	unitConfigPath := createFile(t, tmpDir, "aos_unit.cfg", `{"version":"1.0.0"}`)
+	binaryPath := createFile(t, tmpDir, "aos_communicationmanager", "binary content")
+
+	executablePath = func() (string, error) { return binaryPath, nil }
+	defer func() { executablePath = os.Executable }()
+
+	imageProvider := &testImageProvider{
+		services: map[string]imagemanager.ServiceInfo{
+			"service1": {
+				ServiceInfo: aostypes.ServiceInfo{ServiceID: "service1", Version: "1.0.0", Sha256: getDigest(t, servicePath)},
+				Path:        servicePath,
+			},
+		},
+		layers: map[string]imagemanager.LayerInfo{
+			"sha256:1": {
+				LayerInfo: aostypes.LayerInfo{Digest: "sha256:1", Version: "2.0.0", Sha256: getDigest(t, layerPath)},
+				Path:      layerPath,
+			},
+		},
+	}
+	reportSender := &testReportSender{}
+
+	// Modify layer after installation
+
+	createFile(t, tmpDir, "layer1", "modified layer content")
+
+	attestation := New(&config.Config{UnitConfigFile: unitConfigPath}, imageProvider, &testSigner{}, reportSender)
+
+	if err := attestation.SendReport("request1", "nonce1"); err != nil {
+		t.Fatalf("Can't send attestation report: %v", err)
+	}
+
+	if len(reportSender.reports) != 1 {
+		t.Fatalf("Wrong reports count: %d", len(reportSender.reports))
+	}
+
+	message := reportSender.reports[0]
+
+	if message.RequestID != "request1" || message.Error != "" || string(message.Signature) != string(message.Report) {
+		t.Errorf("Wrong attestation report message: %v", message)
+	}
+
+	var report Report
+
+	if err := json.Unmarshal(message.Report, &report); err != nil {
+		t.Fatalf("Can't parse attestation report: %v", err)
+	}
+
+	if report.Nonce != "nonce1" || report.HashAlgorithm != HashAlgorithm || len(report.Items) != 4 {
+		t.Fatalf("Wrong attestation report: %v", report)
+	}
+
+	for _, expected := range []struct {
+		itemType string
+		id       string
+		match    bool
+	}{
+		{ItemTypeService, "service1", true},
+		{ItemTypeLayer, "sha256:1", false},
+		{ItemTypeUnitConfig, unitConfigPath, true},
+		{ItemTypeBinary, binaryID, true},
+	} {
+		found := false
+
+		for _, item := range report.Items {
+			if item.Type != expected.itemType || item.ID != expected.id {
+				continue
+			}
+
+			found = true
+
+			if item.Match != expected.match || item.Digest == "" {
+				t.Errorf("Wrong item: %v", item)
+			}
+		}
+
+		if !found {
+			t.Errorf("Item %s %s not found", expected.itemType, expected.id)
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * testImageProvider
+ **********************************************************************************************************************/
+
+func (provider *testImageProvider) GetServicesStatus() (services []unitstatushandler.ServiceStatus, err error) {
+	for _, service := range provider.services {
+		services = append(services, unitstatushandler.ServiceStatus{
+			ServiceStatus: cloudprotocol.ServiceStatus{ServiceID: service.ServiceID, Version: service.Version},
+		})
+	}
+
+	return services, nil
+}
+
+func (provider *testImageProvider) GetLayersStatus() (layers []unitstatushandler.LayerStatus, err error) {
+	for _, layer := range provider.layers {
+		layers = append(layers, unitstatushandler.LayerStatus{
+			LayerStatus: cloudprotocol.LayerStatus{Digest: layer.Digest, Version: layer.Version},
+		})
+	}
+
+	return layers, nil
+}
+
+func (provider *testImageProvider) GetServiceInfo(serviceID string) (imagemanager.ServiceInfo, error) {
+	service, ok := provider.services[serviceID]
+	if !ok {
+		return imagemanager.ServiceInfo{}, imagemanager.ErrNotExist
+	}
+
+	return service, nil
+}
+
+func (provider *testImageProvider) GetLayerInfo(digest string) (imagemanager.LayerInfo, error) {
+	layer, ok := provider.layers[digest]
+	if !ok {
+		return imagemanager.LayerInfo{}, imagemanager.ErrNotExist
+	}
+
+	return layer, nil
+}
+
+/***********************************************************************************************************************
+ * testSigner
+ **********************************************************************************************************************/
+
+func (signer *testSigner) SignData(data []byte) (signature []byte, certificates [][]byte, err error) {
+	return data, [][]byte{[]byte("certificate")}, nil
+}
+
+/***********************************************************************************************************************
+ * testReportSender
+ **********************************************************************************************************************/
+
+func (sender *testReportSender) SendAttestationReport(report amqp.AttestationReport) error {
+	sender.reports = append(sender.reports, report)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func createFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	filePath := filepath.Join(dir, name)
+
+	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Can't create file: %v", err)
+	}
+
+	return filePath
+}
+
+func getDigest(t *testing.T, filePath string) []byte {
+	t.Helper()
+
+	fileInfo, err := image.CreateFileInfo(context.Background(), filePath)
+	if err != nil {
+		t.Fatalf("Can't get file info: %v", err)
+	}
+
+	return fileInfo.Sha256
+}
@@ -0,0 +1,329 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Action sources.
+const (
+	SourceCloud  = "cloud"
+	SourceLocal  = "local"
+	SourceSystem = "system"
+)
+
+const exportBatchSize = 100
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrNotExist not exist error.
+var ErrNotExist = errors.New("entry does not exist")
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Record audit log record. Hash is calculated over the previous record hash and the record data, so any modification
+// or removal of stored records breaks the chain. Signature is calculated over the hash with unit online key.
+type Record struct {
+	ID        uint64    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+	Actor     string    `json:"actor,omitempty"`
+	Action    string    `json:"action"`
+	Details   string    `json:"details,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Hash      []byte    `json:"hash"`
+	Signature []byte    `json:"signature,omitempty"`
+}
+
+// Filter audit log records filter. Zero fields are not applied.
+type Filter struct {
+	From        time.Time `json:"from"`
+	Till        time.Time `json:"till"`
+	Source      string    `json:"source,omitempty"`
+	Action      string    `json:"action,omitempty"`
+	Limit       int       `json:"limit,omitempty"`
+	NotExported bool      `json:"-"`
+}
+
+// Storage audit log storage.
+type Storage interface {
+	AddAuditRecord(record Record) error
+	GetAuditRecords(filter Filter) ([]Record, error)
+	GetLastAuditRecord() (Record, error)
+	SetAuditRecordsExported(lastID uint64) error
+}
+
+// Signer signs data with unit online key.
+type Signer interface {
+	SignData(data []byte) (signature []byte, certificates [][]byte, err error)
+}
+
+// Sender sends audit log to the cloud.
+type Sender interface {
+	SendAuditLog(auditLog amqp.AuditLog) error
+	SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+	UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+}
+
+// AuditLog append-only audit log of externally triggered actions.
+type AuditLog struct {
+	sync.Mutex
+
+	storage       Storage
+	signer        Signer
+	sender        Sender
+	lastRecord    Record
+	isConnected   bool
+	exportChannel chan struct{}
+	stopChannel   chan struct{}
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates audit log instance.
+func New(storage Storage, signer Signer, sender Sender) (auditLog *AuditLog, err error) {
+	log.Debug("Create audit log")
+
+	auditLog = &AuditLog{
+		storage:       storage,
+		signer:        signer,
+		sender:        sender,
+		exportChannel: make(chan struct{}, 1),
+		stopChannel:   make(chan struct{}),
+	}
+
+	if auditLog.lastRecord, err = storage.GetLastAuditRecord(); err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = sender.SubscribeForConnectionEvents(auditLog); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	go auditLog.handleExport()
+
+	return auditLog, nil
+}
+
+// Close closes audit log instance.
+func (auditLog *AuditLog) Close() {
+	log.Debug("Close audit log")
+
+	if err := auditLog.sender.UnsubscribeFromConnectionEvents(auditLog); err != nil {
+		log.Errorf("Can't unsubscribe from connection events: %v", err)
+	}
+
+	close(auditLog.stopChannel)
+}
+
+// Record adds action to the audit log. Record errors are logged as the action is already performed.
+func (auditLog *AuditLog) Record(source, actor, action, details string, actionErr error) {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+
+	record := Record{
+		ID:        auditLog.lastRecord.ID + 1,
+		Timestamp: time.Now().UTC(),
+		Source:    source,
+		Actor:     actor,
+		Action:    action,
+		Details:   details,
+	}
+
+	if actionErr != nil {
+		record.Error = actionErr.Error()
+	}
+
+	log.WithFields(log.Fields{
+		"id": record.ID, "source": source, "actor": actor, "action": action, "details": details, "error": record.Error,
+	}).Debug("Audit record")
+
+	hash, err := getRecordHash(auditLog.lastRecord.Hash, record)
+	if err != nil {
+		log.Errorf("Can't calculate audit record hash: %v", err)
+
+		return
+	}
+
+	record.Hash = hash
+
+	// Unsigned record is kept to not lose the action, its hash still protects the chain
+	if record.Signature, _, err = auditLog.signer.SignData(hash); err != nil {
+		log.Errorf("Can't sign audit record: %v", err)
+	}
+
+	if err = auditLog.storage.AddAuditRecord(record); err != nil {
+		log.Errorf("Can't store audit record: %v", err)
+
+		return
+	}
+
+	auditLog.lastRecord = record
+
+	if auditLog.isConnected {
+		auditLog.triggerExport()
+	}
+}
+
+// GetRecords returns audit log records.
+func (auditLog *AuditLog) GetRecords(filter Filter) ([]Record, error) {
+	records, err := auditLog.storage.GetAuditRecords(filter)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return records, nil
+}
+
+// Verify checks hash chain of sequential records. Previous hash is hash of the record preceding the first one.
+func Verify(prevHash []byte, records []Record) error {
+	for _, record := range records {
+		hash, err := getRecordHash(prevHash, record)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(hash, record.Hash) {
+			return aoserrors.Errorf("audit record %d hash mismatch", record.ID)
+		}
+
+		prevHash = record.Hash
+	}
+
+	return nil
+}
+
+// CloudConnected indicates connection to the cloud is established.
+func (auditLog *AuditLog) CloudConnected() {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+
+	auditLog.isConnected = true
+
+	auditLog.triggerExport()
+}
+
+// CloudDisconnected indicates connection to the cloud is lost.
+func (auditLog *AuditLog) CloudDisconnected() {
+	auditLog.Lock()
+	defer auditLog.Unlock()
+
+	auditLog.isConnected = false
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func getRecordHash(prevHash []byte, record Record) ([]byte, error) {
+	record.Hash, record.Signature = nil, nil
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	hash := sha256.New()
+
+	hash.Write(prevHash)
+	hash.Write(data)
+
+	return hash.Sum(nil), nil
+}
+
+func (auditLog *AuditLog) triggerExport() {
+	select {
+	case auditLog.exportChannel <- struct{}{}:
+
+	default:
+	}
+}
+
+func (auditLog *AuditLog) handleExport() {
+	for {
+		select {
+		case <-auditLog.exportChannel:
+			if err := auditLog.export(); err != nil {
+				log.Errorf("Can't export audit log: %v", err)
+			}
+
+		case <-auditLog.stopChannel:
+			return
+		}
+	}
+}
+
+func (auditLog *AuditLog) export() error {
+	for {
+		records, err := auditLog.storage.GetAuditRecords(Filter{NotExported: true, Limit: exportBatchSize})
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if len(records) == 0 {
+			return nil
+		}
+
+		recordsData, err := json.Marshal(records)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		signature, certificates, err := auditLog.signer.SignData(recordsData)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = auditLog.sender.SendAuditLog(amqp.AuditLog{
+			Records:      recordsData,
+			Signature:    signature,
+			Certificates: certificates,
+		}); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = auditLog.storage.SetAuditRecordsExported(records[len(records)-1].ID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if len(records) < exportBatchSize {
+			return nil
+		}
+	}
+}
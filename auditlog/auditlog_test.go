@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditlog_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/auditlog"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	sync.Mutex
+
+	records    []auditlog.Record
+	exportedID uint64
+}
+
+type testSigner struct{}
+
+type testSender struct {
+	auditLogs chan amqp.AuditLog
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRecord(t *testing.T) {
+	storage := &testStorage{}
+	sender := &testSender{auditLogs: make(chan amqp.AuditLog, 1)}
+
+	auditLog, err := auditlog.New(storage, &testSigner{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	auditLog.Record(auditlog.SourceCloud, "", "DesiredStatus", "", nil)
+	auditLog.Record(auditlog.SourceLocal, "hmi", "StartSOTAUpdate", "", aoserrors.New("no update"))
+	auditLog.Record(auditlog.SourceSystem, "", "UnitConfigRevert", "1.0.1 to 1.0.0", nil)
+
+	records, err := auditLog.GetRecords(auditlog.Filter{})
+	if err != nil {
+		t.Fatalf("Can't get records: %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("Wrong records count: %d", len(records))
+	}
+
+	if records[1].ID != 2 || records[1].Actor != "hmi" || !strings.HasPrefix(records[1].Error, "no update") {
+		t.Errorf("Wrong record: %v", records[1])
+	}
+
+	for _, record := range records {
+		if string(record.Signature) != "signature" {
+			t.Errorf("Wrong record signature: %s", record.Signature)
+		}
+	}
+
+	if err = auditlog.Verify(nil, records); err != nil {
+		t.Errorf("Can't verify records: %v", err)
+	}
+
+	records[1].Action = "StartFOTAUpdate"
+
+	if err = auditlog.Verify(nil, records); err == nil {
+		t.Error("Error expected for modified record")
+	}
+
+	if err = auditlog.Verify(nil, []auditlog.Record{records[0], records[2]}); err == nil {
+		t.Error("Error expected for removed record")
+	}
+
+	// Chain is continued after restart
+
+	restartedAuditLog, err := auditlog.New(storage, &testSigner{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create audit log: %v", err)
+	}
+	defer restartedAuditLog.Close()
+
+	restartedAuditLog.Record(auditlog.SourceCloud, "", "OverrideEnvVars", "", nil)
+
+	if records, err = restartedAuditLog.GetRecords(auditlog.Filter{}); err != nil {
+		t.Fatalf("Can't get records: %v", err)
+	}
+
+	if len(records) != 4 || records[3].ID != 4 {
+		t.Fatalf("Wrong records: %v", records)
+	}
+
+	if err = auditlog.Verify(nil, records); err != nil {
+		t.Errorf("Can't verify records: %v", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	storage := &testStorage{}
+	sender := &testSender{auditLogs: make(chan amqp.AuditLog, 1)}
+
+	auditLog, err := auditlog.New(storage, &testSigner{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	auditLog.Record(auditlog.SourceCloud, "", "DesiredStatus", "", nil)
+	auditLog.Record(auditlog.SourceLocal, "tool", "ChangeOwner", "", nil)
+
+	select {
+	case <-sender.auditLogs:
+		t.Fatal("Audit log should not be sent before connection")
+
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	auditLog.CloudConnected()
+
+	records := receiveAuditLog(t, sender)
+
+	if len(records) != 2 || records[0].Action != "DesiredStatus" || records[1].Action != "ChangeOwner" {
+		t.Errorf("Wrong exported records: %v", records)
+	}
+
+	auditLog.Record(auditlog.SourceLocal, "tool", "ReloadConfig", "", nil)
+
+	if records = receiveAuditLog(t, sender); len(records) != 1 || records[0].ID != 3 {
+		t.Errorf("Wrong exported records: %v", records)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (storage *testStorage) AddAuditRecord(record auditlog.Record) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.records = append(storage.records, record)
+
+	return nil
+}
+
+func (storage *testStorage) GetAuditRecords(filter auditlog.Filter) (records []auditlog.Record, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, record := range storage.records {
+		if filter.NotExported && record.ID <= storage.exportedID {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (storage *testStorage) GetLastAuditRecord() (auditlog.Record, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if len(storage.records) == 0 {
+		return auditlog.Record{}, auditlog.ErrNotExist
+	}
+
+	return storage.records[len(storage.records)-1], nil
+}
+
+func (storage *testStorage) SetAuditRecordsExported(lastID uint64) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.exportedID = lastID
+
+	return nil
+}
+
+func (signer *testSigner) SignData(data []byte) (signature []byte, certificates [][]byte, err error) {
+	return []byte("signature"), [][]byte{[]byte("certificate")}, nil
+}
+
+func (sender *testSender) SendAuditLog(auditLog amqp.AuditLog) error {
+	sender.auditLogs <- auditLog
+
+	return nil
+}
+
+func (sender *testSender) SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+func (sender *testSender) UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func receiveAuditLog(t *testing.T, sender *testSender) (records []auditlog.Record) {
+	t.Helper()
+
+	select {
+	case auditLog := <-sender.auditLogs:
+		if string(auditLog.Signature) != "signature" || len(auditLog.Certificates) != 1 {
+			t.Errorf("Wrong audit log signature: %v", auditLog)
+		}
+
+		if err := json.Unmarshal(auditLog.Records, &records); err != nil {
+			t.Fatalf("Can't unmarshal records: %v", err)
+		}
+
+		return records
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait audit log timeout")
+	}
+
+	return nil
+}
@@ -0,0 +1,363 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bandwidthbudget tracks traffic of metered connection against daily and monthly budget.
+//
+// Downloader, log collector and monitoring controller report their traffic to the budget and consult it before
+// receiving or sending data. When usage of daily or monthly budget reaches the defer threshold, non-critical traffic
+// is deferred till the budget is reset and an alert is raised. Critical traffic, such as emergency updates, is never
+// deferred but is accounted.
+package bandwidthbudget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Traffic categories.
+const (
+	CategoryDownload   = "download"
+	CategoryLog        = "log"
+	CategoryMonitoring = "monitoring"
+)
+
+const (
+	saveUsagePeriod    = 1 * time.Minute
+	alertCoreComponent = "aos-communicationmanager"
+)
+
+const (
+	alertNone = iota
+	alertDeferred
+	alertExhausted
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used in unit tests
+var (
+	// checkDeferPeriod period of checking whether deferred traffic is allowed.
+	checkDeferPeriod = 1 * time.Minute
+	// timeNow returns current time.
+	timeNow = time.Now
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Storage bandwidth usage storage.
+type Storage interface {
+	SetBandwidthUsage(usage json.RawMessage) error
+	GetBandwidthUsage() (json.RawMessage, error)
+}
+
+// AlertSender alert sender.
+type AlertSender interface {
+	SendAlert(alert interface{})
+}
+
+// Usage traffic usage of current budget periods.
+type Usage struct {
+	DayStart   time.Time `json:"dayStart"`
+	MonthStart time.Time `json:"monthStart"`
+	Daily      uint64    `json:"daily"`
+	Monthly    uint64    `json:"monthly"`
+	// Categories monthly usage by traffic category.
+	Categories map[string]uint64 `json:"categories,omitempty"`
+}
+
+// Budget bandwidth budget.
+type Budget struct {
+	sync.Mutex
+
+	config      config.BandwidthBudget
+	storage     Storage
+	alertSender AlertSender
+	state       budgetState
+	changed     bool
+	deferred    bool
+
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// budgetState persisted usage with alerts already raised in current periods.
+type budgetState struct {
+	Usage
+	DailyAlert   int `json:"dailyAlert,omitempty"`
+	MonthlyAlert int `json:"monthlyAlert,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates bandwidth budget.
+func New(cfg config.BandwidthBudget, storage Storage, alertSender AlertSender) (budget *Budget, err error) {
+	log.WithFields(log.Fields{
+		"dailyLimit": cfg.DailyLimit, "monthlyLimit": cfg.MonthlyLimit, "billingDay": cfg.BillingDay,
+	}).Debug("Create bandwidth budget")
+
+	budget = &Budget{config: cfg, storage: storage, alertSender: alertSender}
+
+	data, err := storage.GetBandwidthUsage()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if len(data) != 0 {
+		if err = json.Unmarshal(data, &budget.state); err != nil {
+			log.Errorf("Can't parse bandwidth usage: %v", err)
+		}
+	}
+
+	budget.updatePeriods()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	budget.cancelFunc = cancelFunc
+
+	budget.wg.Add(1)
+
+	go budget.handleSave(ctx)
+
+	return budget, nil
+}
+
+// Close closes bandwidth budget and saves current usage.
+func (budget *Budget) Close() {
+	budget.cancelFunc()
+	budget.wg.Wait()
+
+	budget.save()
+}
+
+// AddUsage accounts traffic of the category.
+func (budget *Budget) AddUsage(category string, size uint64) {
+	if size == 0 {
+		return
+	}
+
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.updatePeriods()
+
+	budget.state.Daily += size
+	budget.state.Monthly += size
+
+	if budget.state.Categories == nil {
+		budget.state.Categories = make(map[string]uint64)
+	}
+
+	budget.state.Categories[category] += size
+	budget.changed = true
+
+	budget.checkAlerts()
+}
+
+// IsDeferred returns true if non-critical traffic should be deferred.
+func (budget *Budget) IsDeferred() bool {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.updatePeriods()
+
+	deferred := isLimitReached(budget.state.Daily, budget.config.DailyLimit, budget.config.DeferThreshold) ||
+		isLimitReached(budget.state.Monthly, budget.config.MonthlyLimit, budget.config.DeferThreshold)
+
+	if deferred != budget.deferred {
+		log.WithField("deferred", deferred).Info("Non-critical traffic deferral changed")
+
+		budget.deferred = deferred
+	}
+
+	return deferred
+}
+
+// Wait waits till non-critical traffic is allowed.
+func (budget *Budget) Wait(ctx context.Context) error {
+	for budget.IsDeferred() {
+		select {
+		case <-ctx.Done():
+			return aoserrors.Wrap(ctx.Err())
+
+		case <-time.After(checkDeferPeriod):
+		}
+	}
+
+	return nil
+}
+
+// GetUsage returns traffic usage of current budget periods.
+func (budget *Budget) GetUsage() Usage {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.updatePeriods()
+
+	usage := budget.state.Usage
+	usage.Categories = maps.Clone(usage.Categories)
+
+	return usage
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (budget *Budget) handleSave(ctx context.Context) {
+	defer budget.wg.Done()
+
+	ticker := time.NewTicker(saveUsagePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			budget.save()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (budget *Budget) save() {
+	budget.Lock()
+	defer budget.Unlock()
+
+	if !budget.changed {
+		return
+	}
+
+	data, err := json.Marshal(budget.state)
+	if err != nil {
+		log.Errorf("Can't marshal bandwidth usage: %v", err)
+
+		return
+	}
+
+	if err = budget.storage.SetBandwidthUsage(data); err != nil {
+		log.Errorf("Can't save bandwidth usage: %v", err)
+
+		return
+	}
+
+	budget.changed = false
+}
+
+// updatePeriods resets usage of expired budget periods.
+func (budget *Budget) updatePeriods() {
+	now := timeNow()
+
+	if dayStart := getDayStart(now); !budget.state.DayStart.Equal(dayStart) {
+		budget.state.DayStart = dayStart
+		budget.state.Daily = 0
+		budget.state.DailyAlert = alertNone
+		budget.changed = true
+	}
+
+	if monthStart := getBillingPeriodStart(now, budget.config.BillingDay); !budget.state.MonthStart.Equal(monthStart) {
+		budget.state.MonthStart = monthStart
+		budget.state.Monthly = 0
+		budget.state.Categories = nil
+		budget.state.MonthlyAlert = alertNone
+		budget.changed = true
+	}
+}
+
+func (budget *Budget) checkAlerts() {
+	budget.state.DailyAlert = budget.checkAlert(
+		"daily", budget.state.Daily, budget.config.DailyLimit, budget.state.DailyAlert)
+	budget.state.MonthlyAlert = budget.checkAlert(
+		"monthly", budget.state.Monthly, budget.config.MonthlyLimit, budget.state.MonthlyAlert)
+}
+
+func (budget *Budget) checkAlert(period string, used, limit uint64, raisedAlert int) int {
+	alert := alertNone
+
+	switch {
+	case isLimitReached(used, limit, 100):
+		alert = alertExhausted
+
+	case isLimitReached(used, limit, budget.config.DeferThreshold):
+		alert = alertDeferred
+	}
+
+	if alert <= raisedAlert {
+		return raisedAlert
+	}
+
+	message := fmt.Sprintf("Bandwidth budget: %s usage %s of %s, non-critical traffic is deferred",
+		period, bytefmt.ByteSize(used), bytefmt.ByteSize(limit))
+
+	if alert == alertExhausted {
+		message = fmt.Sprintf("Bandwidth budget: %s budget %s is exhausted", period, bytefmt.ByteSize(limit))
+	}
+
+	log.WithFields(log.Fields{"categories": budget.state.Categories}).Warn(message)
+
+	if budget.alertSender != nil {
+		budget.alertSender.SendAlert(cloudprotocol.CoreAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagAosCore},
+			CoreComponent: alertCoreComponent,
+			Message:       message,
+		})
+	}
+
+	return alert
+}
+
+func isLimitReached(used, limit uint64, percent int) bool {
+	return limit != 0 && used*100 >= limit*uint64(percent)
+}
+
+func getDayStart(now time.Time) time.Time {
+	year, month, day := now.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+}
+
+// getBillingPeriodStart returns start of the monthly billing period which starts on the billing day.
+func getBillingPeriodStart(now time.Time, billingDay int) time.Time {
+	year, month, day := now.Date()
+
+	if day < billingDay {
+		month--
+	}
+
+	return time.Date(year, month, billingDay, 0, 0, 0, 0, now.Location())
+}
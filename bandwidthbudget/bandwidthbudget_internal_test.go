@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bandwidthbudget
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	usage json.RawMessage
+}
+
+type testAlertSender struct {
+	sync.Mutex
+	alerts []cloudprotocol.CoreAlert
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestBudget(t *testing.T) {
+	storage := &testStorage{}
+	alertSender := &testAlertSender{}
+	now := time.Date(2024, time.March, 20, 10, 0, 0, 0, time.UTC)
+
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	cfg := config.BandwidthBudget{DailyLimit: 1000, MonthlyLimit: 2500, BillingDay: 15, DeferThreshold: 90}
+
+	budget, err := New(cfg, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create bandwidth budget: %v", err)
+	}
+
+	budget.AddUsage(CategoryDownload, 800)
+	budget.AddUsage(CategoryLog, 50)
+
+	if budget.IsDeferred() {
+		t.Error("Traffic should not be deferred")
+	}
+
+	budget.AddUsage(CategoryMonitoring, 50)
+
+	if !budget.IsDeferred() {
+		t.Error("Traffic should be deferred")
+	}
+
+	budget.AddUsage(CategoryDownload, 200)
+
+	if alerts := alertSender.getAlerts(); len(alerts) != 2 {
+		t.Errorf("Wrong alerts count: %d", len(alerts))
+	}
+
+	usage := budget.GetUsage()
+
+	if usage.Daily != 1100 || usage.Monthly != 1100 || usage.Categories[CategoryDownload] != 1000 ||
+		!usage.MonthStart.Equal(time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong usage: %v", usage)
+	}
+
+	// Daily usage is reset on next day, usage is restored on restart
+
+	budget.Close()
+
+	restored, err := New(cfg, storage, alertSender)
+	if err != nil {
+		t.Fatalf("Can't create bandwidth budget: %v", err)
+	}
+	defer restored.Close()
+
+	now = now.Add(24 * time.Hour)
+
+	if restored.IsDeferred() {
+		t.Error("Traffic should not be deferred on next day")
+	}
+
+	restored.AddUsage(CategoryDownload, 1200)
+
+	if !restored.IsDeferred() {
+		t.Error("Traffic should be deferred by monthly budget")
+	}
+
+	if usage = restored.GetUsage(); usage.Daily != 1200 || usage.Monthly != 2300 {
+		t.Errorf("Wrong usage: %v", usage)
+	}
+
+	// Only exhausted daily alert is raised when both levels are reached at once
+
+	if alerts := alertSender.getAlerts(); len(alerts) != 4 {
+		t.Errorf("Wrong alerts count: %d", len(alerts))
+	}
+
+	// Monthly usage is reset on billing day
+
+	now = time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC)
+
+	if restored.IsDeferred() {
+		t.Error("Traffic should not be deferred on billing day")
+	}
+
+	if usage = restored.GetUsage(); usage.Monthly != 0 || len(usage.Categories) != 0 {
+		t.Errorf("Wrong usage: %v", usage)
+	}
+}
+
+func TestWait(t *testing.T) {
+	checkDeferPeriod = 10 * time.Millisecond
+
+	budget, err := New(config.BandwidthBudget{DailyLimit: 100, BillingDay: 1, DeferThreshold: 50},
+		&testStorage{}, nil)
+	if err != nil {
+		t.Fatalf("Can't create bandwidth budget: %v", err)
+	}
+	defer budget.Close()
+
+	if err = budget.Wait(context.Background()); err != nil {
+		t.Errorf("Wait error: %v", err)
+	}
+
+	budget.AddUsage(CategoryDownload, 50)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelFunc()
+
+	if err = budget.Wait(ctx); err == nil {
+		t.Error("Wait error expected")
+	}
+
+	timeNow = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	defer func() { timeNow = time.Now }()
+
+	if err = budget.Wait(context.Background()); err != nil {
+		t.Errorf("Wait error: %v", err)
+	}
+}
+
+/***********************************************************************************************************************
+ * testStorage
+ **********************************************************************************************************************/
+
+func (storage *testStorage) SetBandwidthUsage(usage json.RawMessage) error {
+	storage.usage = usage
+
+	return nil
+}
+
+func (storage *testStorage) GetBandwidthUsage() (json.RawMessage, error) {
+	return storage.usage, nil
+}
+
+/***********************************************************************************************************************
+ * testAlertSender
+ **********************************************************************************************************************/
+
+func (sender *testAlertSender) SendAlert(alert interface{}) {
+	sender.Lock()
+	defer sender.Unlock()
+
+	if coreAlert, ok := alert.(cloudprotocol.CoreAlert); ok {
+		sender.alerts = append(sender.alerts, coreAlert)
+	}
+}
+
+func (sender *testAlertSender) getAlerts() []cloudprotocol.CoreAlert {
+	sender.Lock()
+	defer sender.Unlock()
+
+	return sender.alerts
+}
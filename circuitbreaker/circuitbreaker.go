@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package circuitbreaker protects outbound calls of CM modules with per endpoint circuit breakers and rate limits.
+//
+// Cloud connection, downloader and IAM calls consult the breaker of their endpoint before each call and report the
+// call result. When consecutive failures reach the policy threshold, the circuit is opened and calls are rejected
+// without touching the backend till the open timeout expires. Then one trial call is allowed: the circuit is closed if
+// it succeeds and opened again otherwise. Calls above the endpoint rate limit are rejected as well. It prevents retry
+// storms from draining the battery when the backend misbehaves.
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Circuit states.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "halfOpen"
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var (
+	// ErrOpen is returned when call is rejected by open circuit.
+	ErrOpen = errors.New("circuit is open")
+	// ErrRateLimited is returned when call is rejected by rate limit.
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// timeNow returns current time.
+var timeNow = time.Now //nolint:gochecknoglobals // used in unit tests
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CircuitBreakers circuit breakers of all outbound endpoints.
+type CircuitBreakers struct {
+	breakers map[string]*Breaker
+}
+
+// Breaker circuit breaker of outbound endpoint.
+type Breaker struct {
+	sync.Mutex
+
+	endpoint  string
+	policy    config.CircuitBreakerPolicy
+	state     string
+	failures  int
+	openedAt  time.Time
+	trialCall bool
+	tokens    float64
+	updatedAt time.Time
+	rejected  uint64
+}
+
+// State circuit breaker state.
+type State struct {
+	Endpoint string `json:"endpoint"`
+	State    string `json:"state"`
+	// Failures number of consecutive failures.
+	Failures int `json:"failures"`
+	// Rejected total number of rejected calls.
+	Rejected uint64 `json:"rejected"`
+	// RetryAt time of next trial call of open circuit.
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates circuit breakers of all endpoints.
+func New(cfg *config.Config) *CircuitBreakers {
+	breakers := &CircuitBreakers{breakers: make(map[string]*Breaker)}
+
+	for _, endpoint := range []string{
+		config.CircuitBreakerCloud, config.CircuitBreakerDownload, config.CircuitBreakerIAM,
+	} {
+		policy := cfg.GetCircuitBreakerPolicy(endpoint)
+
+		log.WithFields(log.Fields{
+			"endpoint": endpoint, "rateLimit": policy.RateLimit, "failureThreshold": policy.FailureThreshold,
+		}).Debug("Create circuit breaker")
+
+		breakers.breakers[endpoint] = &Breaker{
+			endpoint: endpoint, policy: policy, state: StateClosed, tokens: float64(max(policy.Burst, 1)),
+			updatedAt: timeNow(),
+		}
+	}
+
+	return breakers
+}
+
+// Get returns circuit breaker of the endpoint.
+func (breakers *CircuitBreakers) Get(endpoint string) *Breaker {
+	return breakers.breakers[endpoint]
+}
+
+// GetStates returns states of all circuit breakers sorted by endpoint.
+func (breakers *CircuitBreakers) GetStates() []State {
+	states := make([]State, 0, len(breakers.breakers))
+
+	for _, breaker := range breakers.breakers {
+		states = append(states, breaker.GetState())
+	}
+
+	slices.SortFunc(states, func(a, b State) int {
+		return strings.Compare(a.Endpoint, b.Endpoint)
+	})
+
+	return states
+}
+
+// Allow returns error if the call should be rejected. Result of allowed call should be reported with Done.
+func (breaker *Breaker) Allow() error {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := timeNow()
+
+	switch breaker.state {
+	case StateOpen:
+		if now.Sub(breaker.openedAt) < breaker.policy.OpenTimeout.Duration {
+			return breaker.reject(ErrOpen)
+		}
+
+		breaker.setState(StateHalfOpen)
+
+	case StateHalfOpen:
+		if breaker.trialCall {
+			return breaker.reject(ErrOpen)
+		}
+	}
+
+	if breaker.policy.RateLimit > 0 {
+		breaker.tokens = min(float64(max(breaker.policy.Burst, 1)),
+			breaker.tokens+now.Sub(breaker.updatedAt).Seconds()*breaker.policy.RateLimit)
+		breaker.updatedAt = now
+
+		if breaker.tokens < 1 {
+			return breaker.reject(ErrRateLimited)
+		}
+
+		breaker.tokens--
+	}
+
+	if breaker.state == StateHalfOpen {
+		breaker.trialCall = true
+	}
+
+	return nil
+}
+
+// Done reports result of allowed call. Canceled calls don't change the circuit state.
+func (breaker *Breaker) Done(err error) {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	trialCall := breaker.trialCall
+
+	breaker.trialCall = false
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if err == nil {
+		breaker.failures = 0
+
+		if breaker.state != StateClosed {
+			breaker.setState(StateClosed)
+		}
+
+		return
+	}
+
+	breaker.failures++
+
+	if (trialCall && breaker.state == StateHalfOpen) ||
+		(breaker.policy.FailureThreshold > 0 && breaker.failures >= breaker.policy.FailureThreshold) {
+		breaker.openedAt = timeNow()
+
+		if breaker.state != StateOpen {
+			breaker.setState(StateOpen)
+		}
+	}
+}
+
+// Do calls the function if it is allowed by the breaker and reports its result.
+func (breaker *Breaker) Do(call func() error) error {
+	if err := breaker.Allow(); err != nil {
+		return err
+	}
+
+	err := call()
+
+	breaker.Done(err)
+
+	return err
+}
+
+// GetState returns circuit breaker state.
+func (breaker *Breaker) GetState() State {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	state := State{
+		Endpoint: breaker.endpoint, State: breaker.state, Failures: breaker.failures, Rejected: breaker.rejected,
+	}
+
+	if breaker.state == StateOpen {
+		retryAt := breaker.openedAt.Add(breaker.policy.OpenTimeout.Duration)
+
+		state.RetryAt = &retryAt
+	}
+
+	return state
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (breaker *Breaker) reject(err error) error {
+	breaker.rejected++
+
+	return aoserrors.Errorf("%s: %w", breaker.endpoint, err)
+}
+
+func (breaker *Breaker) setState(state string) {
+	logFields := log.Fields{"endpoint": breaker.endpoint, "state": state, "failures": breaker.failures}
+
+	if state == StateOpen {
+		log.WithFields(logFields).Warn("Circuit breaker opened")
+	} else {
+		log.WithFields(logFields).Info("Circuit breaker state changed")
+	}
+
+	breaker.state = state
+}
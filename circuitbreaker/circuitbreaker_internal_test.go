@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var errTest = errors.New("test error")
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, time.March, 20, 10, 0, 0, 0, time.UTC)
+
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	breaker := New(&config.Config{CircuitBreaker: config.CircuitBreaker{
+		Default: config.CircuitBreakerPolicy{FailureThreshold: 3, OpenTimeout: aostypes.Duration{Duration: time.Minute}},
+	}}).Get(config.CircuitBreakerCloud)
+
+	// Consecutive failures open the circuit
+
+	for range 3 {
+		if err := breaker.Do(func() error { return errTest }); !errors.Is(err, errTest) {
+			t.Errorf("Unexpected call error: %v", err)
+		}
+	}
+
+	if err := breaker.Do(func() error { return nil }); !errors.Is(err, ErrOpen) {
+		t.Errorf("Call should be rejected by open circuit: %v", err)
+	}
+
+	state := breaker.GetState()
+
+	if state.State != StateOpen || state.Failures != 3 || state.Rejected != 1 || state.RetryAt == nil ||
+		!state.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Unexpected breaker state: %v", state)
+	}
+
+	// Failed trial call opens the circuit again
+
+	now = now.Add(time.Minute)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Trial call should be allowed: %v", err)
+	}
+
+	if err := breaker.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Only one trial call should be allowed: %v", err)
+	}
+
+	breaker.Done(errTest)
+
+	if state := breaker.GetState(); state.State != StateOpen {
+		t.Errorf("Unexpected breaker state: %s", state.State)
+	}
+
+	// Canceled trial call doesn't change the state
+
+	now = now.Add(time.Minute)
+
+	if err := breaker.Do(func() error { return context.Canceled }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected call error: %v", err)
+	}
+
+	if state := breaker.GetState(); state.State != StateHalfOpen {
+		t.Errorf("Unexpected breaker state: %s", state.State)
+	}
+
+	// Successful trial call closes the circuit
+
+	if err := breaker.Do(func() error { return nil }); err != nil {
+		t.Errorf("Trial call should be allowed: %v", err)
+	}
+
+	if state := breaker.GetState(); state.State != StateClosed || state.Failures != 0 || state.RetryAt != nil {
+		t.Errorf("Unexpected breaker state: %v", state)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, time.March, 20, 10, 0, 0, 0, time.UTC)
+
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	breakers := New(&config.Config{CircuitBreaker: config.CircuitBreaker{
+		Policies: map[string]config.CircuitBreakerPolicy{
+			config.CircuitBreakerDownload: {RateLimit: 0.5, Burst: 2},
+		},
+	}})
+
+	breaker := breakers.Get(config.CircuitBreakerDownload)
+
+	for range 2 {
+		if err := breaker.Do(func() error { return nil }); err != nil {
+			t.Errorf("Call should be allowed: %v", err)
+		}
+	}
+
+	if err := breaker.Do(func() error { return nil }); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Call should be rate limited: %v", err)
+	}
+
+	now = now.Add(2 * time.Second)
+
+	if err := breaker.Do(func() error { return nil }); err != nil {
+		t.Errorf("Call should be allowed: %v", err)
+	}
+
+	// Failures don't open the circuit without failure threshold
+
+	for range 10 {
+		now = now.Add(2 * time.Second)
+
+		_ = breaker.Do(func() error { return errTest })
+	}
+
+	states := breakers.GetStates()
+
+	if len(states) != 3 || states[0].Endpoint != config.CircuitBreakerCloud ||
+		states[1].Endpoint != config.CircuitBreakerDownload || states[2].Endpoint != config.CircuitBreakerIAM {
+		t.Fatalf("Unexpected breaker states: %v", states)
+	}
+
+	if states[1].State != StateClosed || states[1].Failures != 10 || states[1].Rejected != 1 {
+		t.Errorf("Unexpected breaker state: %v", states[1])
+	}
+}
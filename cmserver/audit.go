@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/auditlog"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// GetAuditLogMethod local service method which returns audit log records.
+const GetAuditLogMethod = "GetAuditLog"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// AuditLog records management actions and provides audit log records.
+type AuditLog interface {
+	Record(source, actor, action, details string, actionErr error)
+	GetRecords(filter auditlog.Filter) ([]auditlog.Record, error)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// auditUnary records calls of all methods except read only ones, including not authorized calls.
+func (server *CMServer) auditUnary(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	if requiredRole, ok := methodRoles[info.FullMethod]; ok && requiredRole == RoleReader {
+		return handler(ctx, req)
+	}
+
+	resp, err := handler(ctx, req)
+
+	server.auditLog.Record(auditlog.SourceLocal, getClientActor(ctx), path.Base(info.FullMethod), "", err)
+
+	return resp, err
+}
+
+func (server *CMServer) getAuditLog(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.auditLog == nil {
+		return nil, status.Error(codes.Unimplemented, "audit log is not supported")
+	}
+
+	var filter auditlog.Filter
+
+	if err := DecodeLocalMessage(pbRequest, &filter); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.WithFields(log.Fields{
+		"from": filter.From, "till": filter.Till, "source": filter.Source, "action": filter.Action,
+	}).Debug("Get audit log")
+
+	records, err := server.auditLog.GetRecords(filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Records []auditlog.Record `json:"records"`
+	}{Records: records})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+// getClientActor returns client certificate identity or client address for insecure connection.
+func getClientActor(ctx context.Context) string {
+	if identity, _, err := getClientIdentity(ctx); err == nil {
+		return identity
+	}
+
+	if clientPeer, ok := peer.FromContext(ctx); ok && clientPeer.Addr != nil {
+		return clientPeer.Addr.String()
+	}
+
+	return ""
+}
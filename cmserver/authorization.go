@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	pb "github.com/aosedge/aos_common/api/communicationmanager"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Client roles. Each role includes permissions of the previous one.
+const (
+	// RoleReader allows to read statuses, monitoring, config and diagnostics.
+	RoleReader = "reader"
+	// RoleOperator allows to trigger updates.
+	RoleOperator = "operator"
+	// RoleService allows all methods including state import, config reload and owner change.
+	RoleService = "service"
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var roleLevels = map[string]int{
+	RoleReader:   1,
+	RoleOperator: 2, //nolint:mnd
+	RoleService:  3, //nolint:mnd
+}
+
+// Methods which are not listed require service role.
+//
+//nolint:gochecknoglobals
+var methodRoles = map[string]string{
+	updateSchedulerMethod("SubscribeNotifications"): RoleReader,
+	updateSchedulerMethod("StartFOTAUpdate"):        RoleOperator,
+	updateSchedulerMethod("StartSOTAUpdate"):        RoleOperator,
+	localMethod(GetMonitoringHistoryMethod):         RoleReader,
+	localMethod(GetEffectiveConfigMethod):           RoleReader,
+	localMethod(GetProviderNetworksMethod):          RoleReader,
+	localMethod(GetInstanceNetworksMethod):          RoleReader,
+	localMethod(GetDNSRecordsMethod):                RoleReader,
+	localMethod(CheckConnectivityMethod):            RoleReader,
+	localMethod(SimulateFirewallMethod):             RoleReader,
+	localMethod(StartTrafficMirrorMethod):           RoleService,
+	localMethod(StopTrafficMirrorMethod):            RoleService,
+	localMethod(GetTrafficMirrorsMethod):            RoleReader,
+	localMethod(SubscribeEventsMethod):              RoleReader,
+	localMethod(SubscribeStateChangesMethod):        RoleService,
+	localMethod(ExportStateMethod):                  RoleService,
+	localMethod(ImportStateMethod):                  RoleService,
+	localMethod(ReloadConfigMethod):                 RoleService,
+	localMethod(ChangeOwnerMethod):                  RoleService,
+	localMethod(GetAuditLogMethod):                  RoleService,
+	localMethod(GetFaultInjectionMethod):            RoleService,
+	localMethod(SetFaultInjectionMethod):            RoleService,
+	localMethod(GetLogLevelsMethod):                 RoleReader,
+	localMethod(SetLogLevelsMethod):                 RoleService,
+	localMethod(UpdateDeviceAvailabilityMethod):     RoleService,
+	localMethod(DrainNodeGroupMethod):               RoleService,
+	localMethod(GetMaintenanceModeMethod):           RoleReader,
+	localMethod(SetMaintenanceModeMethod):           RoleService,
+	localMethod(ApplyOverrideBundleMethod):          RoleService,
+	localMethod(GetCircuitBreakersMethod):           RoleReader,
+	localMethod(GetSBOMMethod):                      RoleReader,
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func updateSchedulerMethod(name string) string {
+	return "/" + pb.UpdateSchedulerService_ServiceDesc.ServiceName + "/" + name
+}
+
+func localMethod(name string) string {
+	return "/" + LocalServiceName + "/" + name
+}
+
+func (server *CMServer) getAuthorizationOptions() ([]grpc.ServerOption, error) {
+	certURL, keyURL, err := server.certProvider.GetCertificate(server.config.CertStorage, nil, "")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	tlsConfig, err := server.cryptocontext.GetServerMutualTLSConfig(certURL, keyURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(server.authorizeUnary),
+		grpc.ChainStreamInterceptor(server.authorizeStream),
+	}, nil
+}
+
+func (server *CMServer) authorizeUnary(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	if err := server.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (server *CMServer) authorizeStream(
+	srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	if err := server.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+func (server *CMServer) authorize(ctx context.Context, fullMethod string) error {
+	identity, roles, err := getClientIdentity(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	server.Lock()
+	roles = append(roles, server.config.CMServerAuthorization.Roles[identity]...)
+	server.Unlock()
+
+	requiredRole := getRequiredRole(fullMethod)
+
+	level := getRolesLevel(roles)
+
+	// Clients without roles keep update scheduler methods which are available without authorization
+	if level == 0 && strings.HasPrefix(fullMethod, updateSchedulerMethod("")) {
+		level = roleLevels[RoleOperator]
+	}
+
+	if level < roleLevels[requiredRole] {
+		log.WithFields(log.Fields{
+			"identity": identity, "roles": roles, "method": fullMethod,
+		}).Warn("Client is not authorized")
+
+		return status.Errorf(codes.PermissionDenied, "method requires %s role", requiredRole)
+	}
+
+	return nil
+}
+
+// getServiceRejectionOptions returns options which refuse service role methods if authorization is disabled.
+func getServiceRejectionOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(
+			ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+		) (any, error) {
+			if err := rejectServiceMethod(info.FullMethod); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(
+			srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+		) error {
+			if err := rejectServiceMethod(info.FullMethod); err != nil {
+				return err
+			}
+
+			return handler(srv, stream)
+		}),
+	}
+}
+
+func rejectServiceMethod(fullMethod string) error {
+	if getRequiredRole(fullMethod) != RoleService {
+		return nil
+	}
+
+	log.WithField("method", fullMethod).Warn("Service method is refused as CM server authorization is disabled")
+
+	return status.Error(codes.PermissionDenied, "method requires enabled CM server authorization")
+}
+
+func getRequiredRole(fullMethod string) string {
+	requiredRole, ok := methodRoles[fullMethod]
+	if !ok {
+		return RoleService
+	}
+
+	return requiredRole
+}
+
+func getClientIdentity(ctx context.Context) (identity string, roles []string, err error) {
+	clientPeer, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", nil, aoserrors.New("client peer not found")
+	}
+
+	tlsInfo, ok := clientPeer.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", nil, aoserrors.New("client certificate not found")
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+
+	return cert.Subject.CommonName, cert.Subject.OrganizationalUnit, nil
+}
+
+func getRolesLevel(roles []string) (level int) {
+	for _, role := range roles {
+		if roleLevel := roleLevels[role]; roleLevel > level {
+			level = roleLevel
+		}
+	}
+
+	return level
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/circuitbreaker"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// GetCircuitBreakersMethod returns state of outbound calls circuit breakers.
+const GetCircuitBreakersMethod = "GetCircuitBreakers"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CircuitBreakerStateProvider provides state of outbound calls circuit breakers.
+type CircuitBreakerStateProvider interface {
+	GetStates() []circuitbreaker.State
+}
+
+// CircuitBreakersResponse circuit breakers state response.
+type CircuitBreakersResponse struct {
+	Breakers []circuitbreaker.State `json:"breakers"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getCircuitBreakers(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.circuitBreakers == nil {
+		return nil, status.Error(codes.Unimplemented, "circuit breakers are not supported")
+	}
+
+	response, err := EncodeLocalMessage(CircuitBreakersResponse{Breakers: server.circuitBreakers.GetStates()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
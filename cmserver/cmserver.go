@@ -66,6 +66,7 @@ type UpdateState int
 type UpdateStatus struct {
 	State UpdateState
 	Error *cloudprotocol.ErrorInfo
+	ETA   *time.Time
 }
 
 // UpdateFOTAStatus FOTA update status for update scheduler service.
@@ -95,6 +96,25 @@ type UpdateHandler interface {
 	StartSOTAUpdate() (err error)
 }
 
+// LocalServiceProviders providers of CM local service requests. Requests of not set providers are rejected as
+// unimplemented.
+type LocalServiceProviders struct {
+	MonitoringProvider    MonitoringHistoryProvider
+	BackupProvider        StateBackupProvider
+	UnitConfigProvider    UnitConfigProvider
+	ConfigReloader        ConfigReloader
+	OwnerChanger          OwnerChanger
+	NetworkDiagnostics    NetworkDiagnostics
+	AuditLog              AuditLog
+	DeviceAvailability    DeviceAvailabilityHandler
+	NodeGroupDrainer      NodeGroupDrainer
+	MaintenanceHandler    MaintenanceModeHandler
+	OverrideBundleHandler OverrideBundleHandler
+	CircuitBreakers       CircuitBreakerStateProvider
+	SBOMProvider          SBOMProvider
+	StateChanges          StateChangesProvider
+}
+
 // CMServer CM server instance.
 type CMServer struct {
 	config        *config.Config
@@ -111,6 +131,22 @@ type CMServer struct {
 	stopChannel       chan struct{}
 	updatehandler     UpdateHandler
 	restartTimer      *time.Timer
+	eventSubscribers  []*eventSubscriber
+
+	monitoringProvider    MonitoringHistoryProvider
+	backupProvider        StateBackupProvider
+	unitConfigProvider    UnitConfigProvider
+	configReloader        ConfigReloader
+	ownerChanger          OwnerChanger
+	networkDiagnostics    NetworkDiagnostics
+	auditLog              AuditLog
+	deviceAvailability    DeviceAvailabilityHandler
+	nodeGroupDrainer      NodeGroupDrainer
+	maintenanceHandler    MaintenanceModeHandler
+	overrideBundleHandler OverrideBundleHandler
+	circuitBreakers       CircuitBreakerStateProvider
+	sbomProvider          SBOMProvider
+	stateChanges          StateChangesProvider
 
 	sync.Mutex
 }
@@ -127,7 +163,7 @@ type CertificateProvider interface {
 
 // New creates new IAM server instance.
 func New(
-	cfg *config.Config, handler UpdateHandler, certProvider CertificateProvider,
+	cfg *config.Config, handler UpdateHandler, providers LocalServiceProviders, certProvider CertificateProvider,
 	cryptocontext *cryptutils.CryptoContext, insecure bool,
 ) (server *CMServer, err error) {
 	server = &CMServer{
@@ -142,9 +178,29 @@ func New(
 		certChannel:       make(<-chan *iamanager.CertInfo),
 		stopChannel:       make(chan struct{}, 1),
 		updatehandler:     handler,
+
+		monitoringProvider:    providers.MonitoringProvider,
+		backupProvider:        providers.BackupProvider,
+		unitConfigProvider:    providers.UnitConfigProvider,
+		configReloader:        providers.ConfigReloader,
+		ownerChanger:          providers.OwnerChanger,
+		networkDiagnostics:    providers.NetworkDiagnostics,
+		auditLog:              providers.AuditLog,
+		deviceAvailability:    providers.DeviceAvailability,
+		nodeGroupDrainer:      providers.NodeGroupDrainer,
+		maintenanceHandler:    providers.MaintenanceHandler,
+		overrideBundleHandler: providers.OverrideBundleHandler,
+		circuitBreakers:       providers.CircuitBreakers,
+		sbomProvider:          providers.SBOMProvider,
+		stateChanges:          providers.StateChanges,
 	}
 
 	pb.RegisterUpdateSchedulerServiceServer(server.grpcServer, server)
+	server.registerLocalService()
+
+	if cfg.CMServerAuthorization.Enabled && insecure {
+		log.Warn("CM server authorization is disabled for insecure connection")
+	}
 
 	if cfg.CMServerURL != "" {
 		if err := server.startGRPCServer(); err != nil {
@@ -177,6 +233,14 @@ func (server *CMServer) Close() {
 	server.clients = nil
 }
 
+// ConfigReloaded updates config reported by effective config request.
+func (server *CMServer) ConfigReloaded(cfg *config.Config) {
+	server.Lock()
+	defer server.Unlock()
+
+	server.config = cfg
+}
+
 // SubscribeNotifications subscribes on SOTA FOTA packages status changes.
 func (server *CMServer) SubscribeNotifications(
 	req *empty.Empty, stream pb.UpdateSchedulerService_SubscribeNotificationsServer,
@@ -273,6 +337,7 @@ func (server *CMServer) handleChannels() {
 			}
 
 			server.notifyAllClients(&notification)
+			server.notifyEvent(newFOTAEvent(fotaStatus))
 
 			server.Unlock()
 
@@ -290,6 +355,7 @@ func (server *CMServer) handleChannels() {
 			}
 
 			server.notifyAllClients(&notification)
+			server.notifyEvent(newSOTAEvent(sotaStatus))
 
 			server.Unlock()
 
@@ -315,14 +381,32 @@ func (server *CMServer) startGRPCServer() error {
 
 	server.clients = []pb.UpdateSchedulerService_SubscribeNotificationsServer{}
 
-	var opts []grpc.ServerOption
+	var (
+		opts []grpc.ServerOption
+		err  error
+	)
+
+	if server.config.CMServerAuthorization.Enabled && !server.insecureConn {
+		opts, err = server.getAuthorizationOptions()
+	} else {
+		opts, err = grpchelpers.NewProtectedServerOptions(server.cryptocontext, server.certProvider,
+			server.config.CertStorage, server.insecureConn)
+
+		// Any client with Aos certificate is accepted, so methods which require service role are not available
+		if !server.insecureConn {
+			opts = append(opts, getServiceRejectionOptions()...)
+		}
+	}
 
-	opts, err := grpchelpers.NewProtectedServerOptions(server.cryptocontext, server.certProvider,
-		server.config.CertStorage, server.insecureConn)
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
 
+	// Audit interceptor goes first to record not authorized calls as well
+	if server.auditLog != nil {
+		opts = append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(server.auditUnary)}, opts...)
+	}
+
 	err = server.grpcServer.RestartServer(opts)
 	if err != nil {
 		return aoserrors.Wrap(err)
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// UpdateDeviceAvailabilityMethod local service method to report node device added or removed.
+const UpdateDeviceAvailabilityMethod = "UpdateDeviceAvailability"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// DeviceAvailabilityHandler handles node devices availability.
+type DeviceAvailabilityHandler interface {
+	UpdateDeviceAvailability(nodeID, deviceName string, available bool) error
+}
+
+// DeviceAvailabilityRequest device availability request.
+type DeviceAvailabilityRequest struct {
+	NodeID    string `json:"nodeId"`
+	Device    string `json:"device"`
+	Available bool   `json:"available"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) updateDeviceAvailability(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.deviceAvailability == nil {
+		return nil, status.Error(codes.Unimplemented, "device availability is not supported")
+	}
+
+	var request DeviceAvailabilityRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.NodeID == "" || request.Device == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong device availability request")
+	}
+
+	log.WithFields(log.Fields{
+		"nodeID": request.NodeID, "device": request.Device, "available": request.Available,
+	}).Info("Update device availability")
+
+	if err := server.deviceAvailability.UpdateDeviceAvailability(
+		request.NodeID, request.Device, request.Available); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(request)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const redactedValue = "<redacted>"
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var secretKeyRegexp = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|pin$)`)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// UnitConfigProvider provides unit config applied to the unit.
+type UnitConfigProvider interface {
+	GetStatus() (cloudprotocol.UnitConfigStatus, error)
+	GetCurrentNodeConfig() (cloudprotocol.NodeConfig, error)
+}
+
+// EffectiveConfig configuration CM is running with.
+type EffectiveConfig struct {
+	Config      map[string]any                  `json:"config"`
+	UnitConfig  *cloudprotocol.UnitConfigStatus `json:"unitConfig,omitempty"`
+	NodeConfig  *cloudprotocol.NodeConfig       `json:"nodeConfig,omitempty"`
+	Environment map[string]string               `json:"environment,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getEffectiveConfig(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	log.Debug("Get effective config")
+
+	effectiveConfig, err := server.createEffectiveConfig()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(effectiveConfig)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) createEffectiveConfig() (effectiveConfig EffectiveConfig, err error) {
+	server.Lock()
+	data, err := json.Marshal(server.config)
+	server.Unlock()
+
+	if err != nil {
+		return effectiveConfig, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, &effectiveConfig.Config); err != nil {
+		return effectiveConfig, aoserrors.Wrap(err)
+	}
+
+	redactSecrets(effectiveConfig.Config)
+
+	if server.unitConfigProvider != nil {
+		unitConfigStatus, err := server.unitConfigProvider.GetStatus()
+		if err != nil {
+			log.Errorf("Can't get unit config status: %v", err)
+		} else {
+			effectiveConfig.UnitConfig = &unitConfigStatus
+		}
+
+		if nodeConfig, err := server.unitConfigProvider.GetCurrentNodeConfig(); err == nil {
+			effectiveConfig.NodeConfig = &nodeConfig
+		}
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+
+		if !strings.HasPrefix(name, config.EnvPrefix) {
+			continue
+		}
+
+		if effectiveConfig.Environment == nil {
+			effectiveConfig.Environment = make(map[string]string)
+		}
+
+		effectiveConfig.Environment[name] = redactValue(name, value)
+	}
+
+	return effectiveConfig, nil
+}
+
+// redactSecrets replaces values of secret fields and URL passwords in place.
+func redactSecrets(value any) {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, item := range typedValue {
+			if stringValue, ok := item.(string); ok {
+				typedValue[key] = redactValue(key, stringValue)
+
+				continue
+			}
+
+			if secretKeyRegexp.MatchString(key) && item != nil {
+				typedValue[key] = redactedValue
+
+				continue
+			}
+
+			redactSecrets(item)
+		}
+
+	case []any:
+		for i, item := range typedValue {
+			if stringValue, ok := item.(string); ok {
+				typedValue[i] = redactValue("", stringValue)
+
+				continue
+			}
+
+			redactSecrets(item)
+		}
+	}
+}
+
+func redactValue(key, value string) string {
+	if value == "" {
+		return value
+	}
+
+	if secretKeyRegexp.MatchString(key) {
+		return redactedValue
+	}
+
+	if !strings.Contains(value, "://") {
+		return value
+	}
+
+	valueURL, err := url.Parse(value)
+	if err != nil || valueURL.User == nil {
+		return value
+	}
+
+	if _, ok := valueURL.User.Password(); !ok {
+		return value
+	}
+
+	return valueURL.Redacted()
+}
@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// SubscribeEventsMethod server streaming local service method which sends events as they happen.
+const SubscribeEventsMethod = "SubscribeEvents"
+
+// Event types.
+const (
+	EventFOTAStatus       = "fotaStatus"
+	EventSOTAStatus       = "sotaStatus"
+	EventInstancesStatus  = "instancesStatus"
+	EventDownloadProgress = "downloadProgress"
+)
+
+const eventChannelSize = 32
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SubscribeEventsRequest subscribe events request. All events are sent if types are not set.
+type SubscribeEventsRequest struct {
+	Types []string `json:"types,omitempty"`
+}
+
+// Event local service event.
+type Event struct {
+	Type            string                          `json:"type"`
+	Timestamp       time.Time                       `json:"timestamp"`
+	State           string                          `json:"state,omitempty"`
+	ETA             *time.Time                      `json:"eta,omitempty"`
+	Error           *cloudprotocol.ErrorInfo        `json:"error,omitempty"`
+	Components      []cloudprotocol.ComponentStatus `json:"components,omitempty"`
+	UnitConfig      *cloudprotocol.UnitConfigStatus `json:"unitConfig,omitempty"`
+	InstallServices []cloudprotocol.ServiceStatus   `json:"installServices,omitempty"`
+	RemoveServices  []cloudprotocol.ServiceStatus   `json:"removeServices,omitempty"`
+	InstallLayers   []cloudprotocol.LayerStatus     `json:"installLayers,omitempty"`
+	RemoveLayers    []cloudprotocol.LayerStatus     `json:"removeLayers,omitempty"`
+	Instances       []cloudprotocol.InstanceStatus  `json:"instances,omitempty"`
+	Download        *cloudprotocol.DownloadAlert    `json:"download,omitempty"`
+}
+
+type eventSubscriber struct {
+	types   []string
+	channel chan *structpb.Struct
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// ProcessRunStatus sends instances status event.
+func (server *CMServer) ProcessRunStatus(instances []cloudprotocol.InstanceStatus) {
+	server.Lock()
+	defer server.Unlock()
+
+	server.notifyEvent(Event{Type: EventInstancesStatus, Instances: instances})
+}
+
+// ProcessUpdateInstanceStatus sends instances status event.
+func (server *CMServer) ProcessUpdateInstanceStatus(instances []cloudprotocol.InstanceStatus) {
+	server.Lock()
+	defer server.Unlock()
+
+	server.notifyEvent(Event{Type: EventInstancesStatus, Instances: instances})
+}
+
+// AlertReceived sends download progress event.
+func (server *CMServer) AlertReceived(alert interface{}) {
+	downloadAlert, ok := alert.(cloudprotocol.DownloadAlert)
+	if !ok {
+		return
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	server.notifyEvent(Event{Type: EventDownloadProgress, Download: &downloadAlert})
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newFOTAEvent(fotaStatus UpdateFOTAStatus) Event {
+	return Event{
+		Type: EventFOTAStatus, State: fotaStatus.State.String(), ETA: fotaStatus.ETA, Error: fotaStatus.Error,
+		Components: fotaStatus.Components,
+	}
+}
+
+func newSOTAEvent(sotaStatus UpdateSOTAStatus) Event {
+	return Event{
+		Type: EventSOTAStatus, State: sotaStatus.State.String(), ETA: sotaStatus.ETA, Error: sotaStatus.Error,
+		UnitConfig: sotaStatus.UnitConfig, InstallServices: sotaStatus.InstallServices,
+		RemoveServices: sotaStatus.RemoveServices, InstallLayers: sotaStatus.InstallLayers,
+		RemoveLayers: sotaStatus.RemoveLayers,
+	}
+}
+
+func (server *CMServer) notifyEvent(event Event) {
+	if len(server.eventSubscribers) == 0 {
+		return
+	}
+
+	event.Timestamp = time.Now().UTC()
+
+	message, err := EncodeLocalMessage(event)
+	if err != nil {
+		log.Errorf("Can't encode event: %v", err)
+
+		return
+	}
+
+	for _, subscriber := range server.eventSubscribers {
+		subscriber.send(event.Type, message)
+	}
+}
+
+func (server *CMServer) subscribeEvents(srv any, stream grpc.ServerStream) error {
+	pbRequest := &structpb.Struct{}
+
+	if err := stream.RecvMsg(pbRequest); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	var request SubscribeEventsRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.WithField("types", request.Types).Debug("New local events subscriber")
+
+	subscriber := &eventSubscriber{types: request.Types, channel: make(chan *structpb.Struct, eventChannelSize)}
+
+	server.Lock()
+
+	for _, event := range []Event{newFOTAEvent(server.currentFOTAStatus), newSOTAEvent(server.currentSOTAStatus)} {
+		event.Timestamp = time.Now().UTC()
+
+		message, err := EncodeLocalMessage(event)
+		if err != nil {
+			server.Unlock()
+
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		subscriber.send(event.Type, message)
+	}
+
+	server.eventSubscribers = append(server.eventSubscribers, subscriber)
+
+	server.Unlock()
+
+	defer server.unsubscribeEvents(subscriber)
+
+	for {
+		select {
+		case message := <-subscriber.channel:
+			if err := stream.SendMsg(message); err != nil {
+				return aoserrors.Wrap(err)
+			}
+
+		case <-stream.Context().Done():
+			return nil
+
+		case <-server.stopChannel:
+			return nil
+		}
+	}
+}
+
+func (server *CMServer) unsubscribeEvents(subscriber *eventSubscriber) {
+	server.Lock()
+	defer server.Unlock()
+
+	if index := slices.Index(server.eventSubscribers, subscriber); index >= 0 {
+		server.eventSubscribers = slices.Delete(server.eventSubscribers, index, index+1)
+	}
+}
+
+func (subscriber *eventSubscriber) send(eventType string, message *structpb.Struct) {
+	if len(subscriber.types) != 0 && !slices.Contains(subscriber.types, eventType) {
+		return
+	}
+
+	select {
+	case subscriber.channel <- message:
+
+	default:
+		log.WithField("type", eventType).Warn("Skip event, subscriber channel is full")
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Fault injection local service methods. Faults are injected only if CM is built with faultinjection build tag.
+const (
+	GetFaultInjectionMethod = "GetFaultInjection"
+	SetFaultInjectionMethod = "SetFaultInjection"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getFaultInjection(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	config, err := faultinjection.GetConfig()
+	if err != nil {
+		return nil, faultInjectionError(err)
+	}
+
+	response, err := EncodeLocalMessage(config)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) setFaultInjection(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	var config faultinjection.Config
+
+	if err := DecodeLocalMessage(pbRequest, &config); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := faultinjection.SetConfig(config); err != nil {
+		return nil, faultInjectionError(err)
+	}
+
+	response, err := EncodeLocalMessage(config)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func faultInjectionError(err error) error {
+	if errors.Is(err, faultinjection.ErrNotSupported) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+
+	return status.Error(codes.InvalidArgument, err.Error())
+}
@@ -0,0 +1,564 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/monitorcontroller"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
+	"github.com/aosedge/aos_communicationmanager/ownerchange"
+	"github.com/aosedge/aos_communicationmanager/statebackup"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// LocalServiceName name of CM local gRPC service. The service provides API for local tools. Requests and responses
+// of all methods are google.protobuf.Struct messages.
+const LocalServiceName = "communicationmanager.v3.LocalService"
+
+// Local service methods.
+const (
+	GetMonitoringHistoryMethod = "GetMonitoringHistory"
+	ExportStateMethod          = "ExportState"
+	ImportStateMethod          = "ImportState"
+	GetEffectiveConfigMethod   = "GetEffectiveConfig"
+	ReloadConfigMethod         = "ReloadConfig"
+	ChangeOwnerMethod          = "ChangeOwner"
+	GetProviderNetworksMethod  = "GetProviderNetworks"
+	GetInstanceNetworksMethod  = "GetInstanceNetworks"
+	GetDNSRecordsMethod        = "GetDNSRecords"
+	CheckConnectivityMethod    = "CheckConnectivity"
+	SimulateFirewallMethod     = "SimulateFirewall"
+	StartTrafficMirrorMethod   = "StartTrafficMirror"
+	StopTrafficMirrorMethod    = "StopTrafficMirror"
+	GetTrafficMirrorsMethod    = "GetTrafficMirrors"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// MonitoringHistoryProvider provides monitoring history.
+type MonitoringHistoryProvider interface {
+	GetMonitoringHistory(request monitorcontroller.HistoryRequest) ([]monitorcontroller.HistoryItem, error)
+}
+
+// StateBackupProvider exports and imports CM state.
+type StateBackupProvider interface {
+	ExportState(fileName string) (hash string, err error)
+	ImportState(fileName, hash string) error
+}
+
+// ConfigReloader reloads CM config.
+type ConfigReloader interface {
+	Reload() (changed bool, err error)
+}
+
+// OwnerChanger performs owner change.
+type OwnerChanger interface {
+	ChangeOwner(request ownerchange.Request) (ownerchange.Report, error)
+}
+
+// NetworkDiagnostics provides networks state for diagnostics.
+type NetworkDiagnostics interface {
+	GetProviderNetworks() []networkmanager.NetworkParametersStorage
+	GetInstanceNetworks() []networkmanager.InstanceNetworkInfo
+	GetDNSRecords() []networkmanager.DNSRecord
+	CheckConnectivity(request networkmanager.ConnectivityRequest) (networkmanager.ConnectivityResult, error)
+	SimulateFirewall(
+		request networkmanager.FirewallSimulationRequest) (networkmanager.FirewallSimulationResult, error)
+	StartTrafficMirror(request networkmanager.TrafficMirrorRequest) (networkmanager.TrafficMirror, error)
+	StopTrafficMirror(id string) error
+	GetTrafficMirrors() []networkmanager.TrafficMirror
+}
+
+// ReloadConfigResponse reload config response.
+type ReloadConfigResponse struct {
+	Changed bool `json:"changed"`
+}
+
+// StateBackupRequest export/import state request.
+type StateBackupRequest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// StateBackupResponse export/import state response.
+type StateBackupResponse struct {
+	Path            string `json:"path"`
+	SHA256          string `json:"sha256,omitempty"`
+	RestartRequired bool   `json:"restartRequired,omitempty"`
+}
+
+type localMethodHandler func(ctx context.Context, request *structpb.Struct) (*structpb.Struct, error)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// EncodeLocalMessage encodes value to local service message.
+func EncodeLocalMessage(value any) (*structpb.Struct, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	message := &structpb.Struct{}
+
+	if err = protojson.Unmarshal(data, message); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return message, nil
+}
+
+// DecodeLocalMessage decodes local service message to value.
+func DecodeLocalMessage(message *structpb.Struct, value any) error {
+	data, err := protojson.Marshal(message)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, value); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) registerLocalService() {
+	methods := map[string]localMethodHandler{
+		GetMonitoringHistoryMethod:     server.getMonitoringHistory,
+		ExportStateMethod:              server.exportState,
+		ImportStateMethod:              server.importState,
+		GetEffectiveConfigMethod:       server.getEffectiveConfig,
+		ReloadConfigMethod:             server.reloadConfig,
+		ChangeOwnerMethod:              server.changeOwner,
+		GetProviderNetworksMethod:      server.getProviderNetworks,
+		GetInstanceNetworksMethod:      server.getInstanceNetworks,
+		GetDNSRecordsMethod:            server.getDNSRecords,
+		CheckConnectivityMethod:        server.checkConnectivity,
+		SimulateFirewallMethod:         server.simulateFirewall,
+		StartTrafficMirrorMethod:       server.startTrafficMirror,
+		StopTrafficMirrorMethod:        server.stopTrafficMirror,
+		GetTrafficMirrorsMethod:        server.getTrafficMirrors,
+		GetAuditLogMethod:              server.getAuditLog,
+		GetFaultInjectionMethod:        server.getFaultInjection,
+		SetFaultInjectionMethod:        server.setFaultInjection,
+		GetLogLevelsMethod:             server.getLogLevels,
+		SetLogLevelsMethod:             server.setLogLevels,
+		UpdateDeviceAvailabilityMethod: server.updateDeviceAvailability,
+		DrainNodeGroupMethod:           server.drainNodeGroup,
+		GetMaintenanceModeMethod:       server.getMaintenanceMode,
+		SetMaintenanceModeMethod:       server.setMaintenanceMode,
+		ApplyOverrideBundleMethod:      server.applyOverrideBundle,
+		GetCircuitBreakersMethod:       server.getCircuitBreakers,
+		GetSBOMMethod:                  server.getSBOM,
+	}
+
+	desc := &grpc.ServiceDesc{
+		ServiceName: LocalServiceName,
+		HandlerType: (*any)(nil),
+		Metadata:    "localservice",
+	}
+
+	for name, handler := range methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler:    newLocalMethodHandler(name, handler),
+		})
+	}
+
+	desc.Streams = append(desc.Streams, grpc.StreamDesc{
+		StreamName:    SubscribeEventsMethod,
+		Handler:       server.subscribeEvents,
+		ServerStreams: true,
+	})
+
+	desc.Streams = append(desc.Streams, grpc.StreamDesc{
+		StreamName:    SubscribeStateChangesMethod,
+		Handler:       server.subscribeStateChanges,
+		ServerStreams: true,
+	})
+
+	server.grpcServer.RegisterService(desc, server)
+}
+
+func newLocalMethodHandler(name string, handler localMethodHandler) grpc.MethodHandler {
+	return func(
+		srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor,
+	) (any, error) {
+		request := &structpb.Struct{}
+
+		if err := dec(request); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if interceptor == nil {
+			return handler(ctx, request)
+		}
+
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + LocalServiceName + "/" + name}
+
+		return interceptor(ctx, request, info, func(ctx context.Context, req any) (any, error) {
+			structReq, ok := req.(*structpb.Struct)
+			if !ok {
+				return nil, status.Error(codes.InvalidArgument, "wrong request type")
+			}
+
+			return handler(ctx, structReq)
+		})
+	}
+}
+
+func (server *CMServer) getMonitoringHistory(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.monitoringProvider == nil {
+		return nil, status.Error(codes.Unimplemented, "monitoring history is not supported")
+	}
+
+	var request monitorcontroller.HistoryRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.WithFields(log.Fields{
+		"nodeID": request.NodeID, "from": request.From, "till": request.Till, "interval": request.Interval,
+	}).Debug("Get monitoring history")
+
+	items, err := server.monitoringProvider.GetMonitoringHistory(request)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Items []monitorcontroller.HistoryItem `json:"items"`
+	}{Items: items})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) exportState(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.backupProvider == nil {
+		return nil, status.Error(codes.Unimplemented, "state backup is not supported")
+	}
+
+	var request StateBackupRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.Path == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong export state request")
+	}
+
+	hash, err := server.backupProvider.ExportState(request.Path)
+	if err != nil {
+		return nil, stateBackupError(err, codes.Internal)
+	}
+
+	response, err := EncodeLocalMessage(StateBackupResponse{Path: request.Path, SHA256: hash})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) importState(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.backupProvider == nil {
+		return nil, status.Error(codes.Unimplemented, "state backup is not supported")
+	}
+
+	var request StateBackupRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.Path == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong import state request")
+	}
+
+	if err := server.backupProvider.ImportState(request.Path, request.SHA256); err != nil {
+		return nil, stateBackupError(err, codes.FailedPrecondition)
+	}
+
+	response, err := EncodeLocalMessage(StateBackupResponse{Path: request.Path, RestartRequired: true})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) reloadConfig(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.configReloader == nil {
+		return nil, status.Error(codes.Unimplemented, "config reload is not supported")
+	}
+
+	changed, err := server.configReloader.Reload()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(ReloadConfigResponse{Changed: changed})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) changeOwner(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.ownerChanger == nil {
+		return nil, status.Error(codes.Unimplemented, "owner change is not supported")
+	}
+
+	var request ownerchange.Request
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || len(request.Info) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "wrong change owner request")
+	}
+
+	report, err := server.ownerChanger.ChangeOwner(request)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(report)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) getProviderNetworks(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Networks []networkmanager.NetworkParametersStorage `json:"networks"`
+	}{Networks: server.networkDiagnostics.GetProviderNetworks()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) getInstanceNetworks(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Instances []networkmanager.InstanceNetworkInfo `json:"instances"`
+	}{Instances: server.networkDiagnostics.GetInstanceNetworks()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) getDNSRecords(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Records []networkmanager.DNSRecord `json:"records"`
+	}{Records: server.networkDiagnostics.GetDNSRecords()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) checkConnectivity(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	var request networkmanager.ConnectivityRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil ||
+		request.Source.ServiceID == "" || request.Destination.ServiceID == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong check connectivity request")
+	}
+
+	log.WithFields(log.Fields{
+		"source": request.Source, "destination": request.Destination, "port": request.Port,
+		"protocol": request.Protocol,
+	}).Debug("Check connectivity")
+
+	result, err := server.networkDiagnostics.CheckConnectivity(request)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) simulateFirewall(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	var request networkmanager.FirewallSimulationRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil ||
+		request.Source.ServiceID == "" || request.Destination.ServiceID == "" || request.Port == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong simulate firewall request")
+	}
+
+	log.WithFields(log.Fields{
+		"source": request.Source, "destination": request.Destination, "port": request.Port,
+		"protocol": request.Protocol,
+	}).Debug("Simulate firewall")
+
+	result, err := server.networkDiagnostics.SimulateFirewall(request)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) startTrafficMirror(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	var request networkmanager.TrafficMirrorRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil ||
+		request.Source.ServiceID == "" || request.Collector.ServiceID == "" || request.Duration.Duration <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "wrong start traffic mirror request")
+	}
+
+	log.WithFields(log.Fields{
+		"source": request.Source, "collector": request.Collector, "port": request.Port,
+		"protocol": request.Protocol, "duration": request.Duration,
+	}).Debug("Start traffic mirror")
+
+	mirror, err := server.networkDiagnostics.StartTrafficMirror(request)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(mirror)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) stopTrafficMirror(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.ID == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong stop traffic mirror request")
+	}
+
+	log.WithField("id", request.ID).Debug("Stop traffic mirror")
+
+	if err := server.networkDiagnostics.StopTrafficMirror(request.ID); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &structpb.Struct{}, nil
+}
+
+func (server *CMServer) getTrafficMirrors(
+	ctx context.Context, pbRequest *structpb.Struct,
+) (*structpb.Struct, error) {
+	if server.networkDiagnostics == nil {
+		return nil, status.Error(codes.Unimplemented, "network diagnostics is not supported")
+	}
+
+	response, err := EncodeLocalMessage(struct {
+		Mirrors []networkmanager.TrafficMirror `json:"mirrors"`
+	}{Mirrors: server.networkDiagnostics.GetTrafficMirrors()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func stateBackupError(err error, defaultCode codes.Code) error {
+	switch {
+	case errors.Is(err, statebackup.ErrWrongPath):
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case errors.Is(err, statebackup.ErrEncryptionEnabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	default:
+		return status.Error(defaultCode, err.Error())
+	}
+}
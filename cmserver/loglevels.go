@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Log levels local service methods. Levels set by the method are reset on config reload.
+const (
+	GetLogLevelsMethod = "GetLogLevels"
+	SetLogLevelsMethod = "SetLogLevels"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getLogLevels(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	response, err := EncodeLocalMessage(logging.GetLevels())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) setLogLevels(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	var levels logging.Levels
+
+	if err := DecodeLocalMessage(pbRequest, &levels); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.WithFields(log.Fields{"level": levels.Level, "moduleLevels": levels.ModuleLevels}).Info("Set log levels")
+
+	if err := logging.SetLevels(levels); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(logging.GetLevels())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Maintenance mode local service methods.
+const (
+	GetMaintenanceModeMethod = "GetMaintenanceMode"
+	SetMaintenanceModeMethod = "SetMaintenanceMode"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// MaintenanceMode unit maintenance mode.
+type MaintenanceMode struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// MaintenanceModeHandler enables and disables unit maintenance mode.
+type MaintenanceModeHandler interface {
+	SetMaintenanceMode(enabled bool, reason string) error
+	GetMaintenanceMode() MaintenanceMode
+}
+
+// SetMaintenanceModeRequest set maintenance mode request.
+type SetMaintenanceModeRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getMaintenanceMode(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.maintenanceHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "maintenance mode is not supported")
+	}
+
+	response, err := EncodeLocalMessage(server.maintenanceHandler.GetMaintenanceMode())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
+
+func (server *CMServer) setMaintenanceMode(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.maintenanceHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "maintenance mode is not supported")
+	}
+
+	var request SetMaintenanceModeRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "wrong set maintenance mode request")
+	}
+
+	log.WithFields(log.Fields{"enabled": request.Enabled, "reason": request.Reason}).Info("Set maintenance mode")
+
+	if err := server.maintenanceHandler.SetMaintenanceMode(request.Enabled, request.Reason); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(server.maintenanceHandler.GetMaintenanceMode())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// DrainNodeGroupMethod local service method to drain or restore node group.
+const DrainNodeGroupMethod = "DrainNodeGroup"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// NodeGroupDrainer drains node groups.
+type NodeGroupDrainer interface {
+	DrainNodeGroup(group string, drained bool) error
+}
+
+// DrainNodeGroupRequest drain node group request.
+type DrainNodeGroupRequest struct {
+	Group   string `json:"group"`
+	Drained bool   `json:"drained"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) drainNodeGroup(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.nodeGroupDrainer == nil {
+		return nil, status.Error(codes.Unimplemented, "node group drain is not supported")
+	}
+
+	var request DrainNodeGroupRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.Group == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong drain node group request")
+	}
+
+	log.WithFields(log.Fields{"group": request.Group, "drained": request.Drained}).Info("Drain node group")
+
+	if err := server.nodeGroupDrainer.DrainNodeGroup(request.Group, request.Drained); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(request)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
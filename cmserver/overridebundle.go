@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// ApplyOverrideBundleMethod local service method to apply override bundle from local media.
+const ApplyOverrideBundleMethod = "ApplyOverrideBundle"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// OverrideBundleHandler applies signed override bundles.
+type OverrideBundleHandler interface {
+	ProcessOverrideBundle(bundleDir string) (bundleID string, err error)
+}
+
+// ApplyOverrideBundleRequest apply override bundle request.
+type ApplyOverrideBundleRequest struct {
+	BundleDir string `json:"bundleDir"`
+}
+
+// ApplyOverrideBundleResponse apply override bundle response.
+type ApplyOverrideBundleResponse struct {
+	BundleID string `json:"bundleId"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) applyOverrideBundle(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.overrideBundleHandler == nil {
+		return nil, status.Error(codes.Unimplemented, "override bundles are not supported")
+	}
+
+	var request ApplyOverrideBundleRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil || request.BundleDir == "" {
+		return nil, status.Error(codes.InvalidArgument, "wrong apply override bundle request")
+	}
+
+	log.WithField("bundleDir", request.BundleDir).Info("Apply override bundle")
+
+	bundleID, err := server.overrideBundleHandler.ProcessOverrideBundle(request.BundleDir)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(ApplyOverrideBundleResponse{BundleID: bundleID})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
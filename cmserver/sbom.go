@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/sbom"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// GetSBOMMethod returns unit aggregate SBOM.
+const GetSBOMMethod = "GetSBOM"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SBOMProvider provides SBOM documents of active services.
+type SBOMProvider interface {
+	GetSBOMs() ([]sbom.Document, error)
+}
+
+// SBOMResponse unit aggregate SBOM response.
+type SBOMResponse struct {
+	Services []sbom.Document `json:"services"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) getSBOM(ctx context.Context, pbRequest *structpb.Struct) (*structpb.Struct, error) {
+	if server.sbomProvider == nil {
+		return nil, status.Error(codes.Unimplemented, "SBOM is not supported")
+	}
+
+	documents, err := server.sbomProvider.GetSBOMs()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response, err := EncodeLocalMessage(SBOMResponse{Services: documents})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return response, nil
+}
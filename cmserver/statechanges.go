@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmserver
+
+import (
+	"errors"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/aosedge/aos_communicationmanager/statestream"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// SubscribeStateChangesMethod server streaming local service method which sends state changes as they are committed.
+const SubscribeStateChangesMethod = "SubscribeStateChanges"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// StateChangesProvider provides state changes stream.
+type StateChangesProvider interface {
+	Subscribe(epoch string, since uint64) (history []statestream.Change, channel <-chan statestream.Change, err error)
+	Unsubscribe(channel <-chan statestream.Change)
+}
+
+// SubscribeStateChangesRequest subscribe state changes request. If since is set, changes of the epoch following the
+// since sequence are sent first, otherwise only new changes are sent.
+type SubscribeStateChangesRequest struct {
+	Epoch string `json:"epoch,omitempty"`
+	Since uint64 `json:"since,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (server *CMServer) subscribeStateChanges(srv any, stream grpc.ServerStream) error {
+	if server.stateChanges == nil {
+		return status.Error(codes.Unimplemented, "state changes stream is not supported")
+	}
+
+	pbRequest := &structpb.Struct{}
+
+	if err := stream.RecvMsg(pbRequest); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	var request SubscribeStateChangesRequest
+
+	if err := DecodeLocalMessage(pbRequest, &request); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.WithFields(log.Fields{
+		"epoch": request.Epoch, "since": request.Since,
+	}).Debug("New local state changes subscriber")
+
+	history, channel, err := server.stateChanges.Subscribe(request.Epoch, request.Since)
+	if err != nil {
+		if errors.Is(err, statestream.ErrSequenceExpired) {
+			return status.Error(codes.OutOfRange, err.Error())
+		}
+
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	defer server.stateChanges.Unsubscribe(channel)
+
+	for _, change := range history {
+		if err := sendStateChange(stream, change); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case change, ok := <-channel:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber is too slow, resubscribe from last sequence")
+			}
+
+			if err := sendStateChange(stream, change); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+
+		case <-server.stopChannel:
+			return nil
+		}
+	}
+}
+
+func sendStateChange(stream grpc.ServerStream, change statestream.Change) error {
+	message, err := EncodeLocalMessage(change)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if err = stream.SendMsg(message); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
@@ -19,12 +19,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -40,22 +45,42 @@ import (
 	"github.com/google/go-tpm/legacy/tpm2"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/advisory"
 	"github.com/aosedge/aos_communicationmanager/alerts"
 	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/attestation"
+	"github.com/aosedge/aos_communicationmanager/auditlog"
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
+	"github.com/aosedge/aos_communicationmanager/circuitbreaker"
 	"github.com/aosedge/aos_communicationmanager/cmserver"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/database"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/extension"
 	"github.com/aosedge/aos_communicationmanager/fcrypt"
+	"github.com/aosedge/aos_communicationmanager/featureflags"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
+	"github.com/aosedge/aos_communicationmanager/iamcache"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/lifecycle"
+	"github.com/aosedge/aos_communicationmanager/logcollector"
+	"github.com/aosedge/aos_communicationmanager/logging"
 	"github.com/aosedge/aos_communicationmanager/monitorcontroller"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
+	"github.com/aosedge/aos_communicationmanager/nodereboot"
+	"github.com/aosedge/aos_communicationmanager/ownerchange"
+	"github.com/aosedge/aos_communicationmanager/quotaenforcer"
+	"github.com/aosedge/aos_communicationmanager/simulator"
 	"github.com/aosedge/aos_communicationmanager/smcontroller"
+	"github.com/aosedge/aos_communicationmanager/standby"
+	"github.com/aosedge/aos_communicationmanager/statebackup"
+	"github.com/aosedge/aos_communicationmanager/statestream"
 	"github.com/aosedge/aos_communicationmanager/storagestate"
 	"github.com/aosedge/aos_communicationmanager/umcontroller"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
 	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+	"github.com/aosedge/aos_communicationmanager/watchdog"
 )
 
 /***********************************************************************************************************************
@@ -65,6 +90,8 @@ import (
 const (
 	initReconnectTimeout = 10 * time.Second
 	maxReconnectTimeout  = 10 * time.Minute
+	coreComponentName    = "aos-communicationmanager"
+	flushPeriod          = 100 * time.Millisecond
 )
 
 /***********************************************************************************************************************
@@ -75,22 +102,55 @@ type communicationManager struct {
 	db                *database.Database
 	amqp              *amqp.AmqpHandler
 	iam               *iamclient.Client
+	iamCache          *iamcache.Cache
 	crypt             *fcrypt.CryptoHandler
+	auditLog          *auditlog.AuditLog
 	cryptoContext     *cryptutils.CryptoContext
 	journalAlerts     *journalalerts.JournalAlerts
 	alerts            *alerts.Alerts
 	monitorcontroller *monitorcontroller.MonitorController
 	resourcemonitor   *resourcemonitor.ResourceMonitor
 	downloader        *downloader.Downloader
-	smController      *smcontroller.Controller
+	smController      smController
+	logCollector      *logcollector.Collector
 	umController      *umcontroller.Controller
 	unitConfig        *unitconfig.Instance
 	statusHandler     *unitstatushandler.Instance
 	launcher          *launcher.Launcher
+	lifecycle         *lifecycle.Lifecycle
+	healthChecker     *healthcheck.Checker
 	imagemanager      *imagemanager.Imagemanager
 	network           *networkmanager.NetworkManager
 	storageState      *storagestate.StorageState
+	stateBackup       *statebackup.StateBackup
+	ownerChange       *ownerchange.OwnerChange
 	cmServer          *cmserver.CMServer
+	watchdog          *watchdog.Watchdog
+	featureFlags      *featureflags.FeatureFlags
+	bandwidthBudget   *bandwidthbudget.Budget
+	circuitBreakers   *circuitbreaker.CircuitBreakers
+	advisories        *advisory.Matcher
+	attestation       *attestation.Attestation
+	quotaEnforcer     *quotaenforcer.Enforcer
+	nodeReboot        *nodereboot.Orchestrator
+	standbyServer     *standby.Server
+	takeover          *standby.Takeover
+	stateStream       *statestream.Stream
+	restartChannel    chan struct{}
+	restartOnce       sync.Once
+	fencedChannel     chan struct{}
+	fencedOnce        sync.Once
+}
+
+type smController interface {
+	unitconfig.Client
+	networkmanager.NodeManager
+	launcher.NodeManager
+	unitstatushandler.SystemQuotaAlertProvider
+	logcollector.LogProvider
+	OverrideEnvVars(envVars cloudprotocol.OverrideEnvVars) error
+	GetUpdateInstancesStatusChannel() <-chan []cloudprotocol.InstanceStatus
+	Close() error
 }
 
 type journalHook struct {
@@ -122,7 +182,9 @@ func init() {
  **********************************************************************************************************************/
 
 //nolint:funlen
-func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err error) {
+func newCommunicationManager(
+	ctx context.Context, cfg *config.Config, reloader *config.Reloader, secretResolver *config.SecretResolver,
+) (cm *communicationManager, err error) {
 	defer func() {
 		if err != nil {
 			cm.close()
@@ -130,58 +192,170 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 		}
 	}()
 
-	cm = &communicationManager{}
+	cm = &communicationManager{restartChannel: make(chan struct{}), fencedChannel: make(chan struct{})}
+
+	// AMQP handler, IAM client and IAM cache are required to resolve IAM secrets. They are created from config with
+	// resolved file and env secrets and it is checked below that IAM secrets don't change their parameters.
+
+	iamCfg := cfg
+
+	if cm.amqp, err = amqp.New(cfg); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if err = initPKCS(cfg.Crypt); err != nil {
+		return nil, err
+	}
+
+	if cm.cryptoContext, err = cryptutils.NewCryptoContext(cfg.Crypt.CACert); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
 
-	// Try again after reset
-	if cm.db, err = database.New(cfg); err != nil {
+	if cm.iam, err = iamclient.New(cfg.IAMPublicServerURL, cfg.IAMProtectedServerURL, cfg.CertStorage,
+		cm.amqp, cm.cryptoContext, false); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if cm.iamCache, err = iamcache.New(cfg, cm.iam, cm.cryptoContext); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	// IAM secrets can be resolved only when IAM client is created
+
+	if err = secretResolver.RegisterProvider(
+		config.IAMSecretProvider, config.NewCertSecretProvider(cm.iamCache)); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if cfg, err = reloader.ResolveSecrets(); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if err = config.CheckSecretsResolved(cfg); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if err = checkIAMParams(iamCfg, cfg); err != nil {
+		return cm, err
+	}
+
+	cm.circuitBreakers = circuitbreaker.New(cfg)
+
+	cm.amqp.SetCircuitBreaker(cm.circuitBreakers.Get(config.CircuitBreakerCloud))
+	cm.iamCache.SetCircuitBreaker(cm.circuitBreakers.Get(config.CircuitBreakerIAM))
+
+	if cfg.Standby.Role == config.StandbyRoleStandby {
+		// Standby doesn't connect to SMs, UMs and the cloud till the primary is lost
+		if _, err = daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
+			log.Errorf("Can't notify systemd: %s", err)
+		}
+
+		if cm.takeover, err = standby.WaitFailover(ctx, cfg,
+			standby.NewTLSParams(cfg, cm.iamCache, cm.cryptoContext), database.ImportSnapshot); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+	}
+
+	if err = statebackup.ApplyPendingState(cfg, database.ImportSnapshot); err != nil {
+		log.Errorf("Can't apply imported state: %v", err)
+	}
+
+	// Try again after reset. Encrypted database is not reset if its key is not available.
+	if cm.db, err = database.New(cfg, cm.iamCache, cm.cryptoContext); err != nil {
 		log.Errorf("Can't create DB: %s", err)
 
+		if errors.Is(err, database.ErrEncryptionKeyNotAvailable) {
+			return cm, aoserrors.Wrap(err)
+		}
+
 		if err = reset(cfg); err != nil {
 			log.Errorf("Can't reset CM: %s", err)
 		}
 
-		if cm.db, err = database.New(cfg); err != nil {
+		if cm.db, err = database.New(cfg, cm.iamCache, cm.cryptoContext); err != nil {
 			return cm, aoserrors.Wrap(err)
 		}
 	}
 
-	if cm.amqp, err = amqp.New(); err != nil {
-		return cm, aoserrors.Wrap(err)
+	if cm.takeover != nil {
+		// Snapshot is already imported, so CM continues with it even if later changes can't be applied
+		if err = cm.takeover.ApplyChanges(cm.db); err != nil {
+			log.Errorf("Can't apply replicated state changes: %v", err)
+		}
 	}
 
-	if cm.cryptoContext, err = cryptutils.NewCryptoContext(cfg.Crypt.CACert); err != nil {
-		return nil, aoserrors.Wrap(err)
+	// Primary replicates state changes to standby
+	if cfg.StateStream.Enabled || cfg.Standby.Role == config.StandbyRolePrimary {
+		cm.stateStream = statestream.New(cfg.StateStream)
+		cm.db.SetStatePublisher(cm.stateStream)
 	}
 
-	if cm.iam, err = iamclient.New(cfg.IAMPublicServerURL, cfg.IAMProtectedServerURL, cfg.CertStorage,
-		cm.amqp, cm.cryptoContext, false); err != nil {
+	if cfg.Standby.Role == config.StandbyRolePrimary {
+		if cm.standbyServer, err = standby.NewServer(
+			cfg, cm.db, cm.stateStream, standby.NewTLSParams(cfg, cm.iamCache, cm.cryptoContext),
+			cm.fence); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+	}
+
+	if cm.crypt, err = fcrypt.New(cfg, cm.iamCache, cm.cryptoContext); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if err = initPKCS(cfg.Crypt); err != nil {
-		return nil, err
+	if cm.auditLog, err = auditlog.New(cm.db, cm.crypt, cm.amqp); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	extensions, err := extension.New(cfg)
+	if err != nil {
+		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.crypt, err = fcrypt.New(cm.iam, cm.cryptoContext, cfg.ServiceDiscoveryURL); err != nil {
+	if cm.monitorcontroller, err = monitorcontroller.New(cfg, cm.amqp, cm.db, cm.db); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.alerts, err = alerts.New(cfg.Alerts, cm.amqp); err != nil {
+	if cm.alerts, err = alerts.New(cfg.Alerts, cm.amqp, cm.monitorcontroller); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
+	cm.alerts.SetAlertRouters(extensions.AlertRouters)
+
+	if report := cm.db.GetRecoveryReport(); report != nil {
+		cm.alerts.SendAlert(cloudprotocol.CoreAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagAosCore},
+			NodeID:        cm.iam.GetNodeID(),
+			CoreComponent: coreComponentName,
+			Message:       report.String(),
+		})
+	}
+
 	if cfg.Alerts.JournalAlerts != nil {
 		if cm.journalAlerts, err = journalalerts.New(*cfg.Alerts.JournalAlerts, nil, cm.db, cm.alerts); err != nil {
 			return cm, aoserrors.Wrap(err)
 		}
 	}
 
-	if cm.monitorcontroller, err = monitorcontroller.New(cfg, cm.amqp); err != nil {
-		return cm, aoserrors.Wrap(err)
+	var nodeInfoProvider launcher.NodeInfoProvider = cm.iam
+
+	if cfg.SMController.SimulationScenario != "" {
+		smSimulator, err := simulator.New(cfg.SMController.SimulationScenario)
+		if err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+
+		cm.smController, nodeInfoProvider = smSimulator, smSimulator
+	} else {
+		controller, err := smcontroller.New(
+			cfg, cm.amqp, cm.alerts, cm.monitorcontroller, cm.iamCache, cm.cryptoContext, false)
+		if err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+
+		cm.smController = controller
 	}
 
-	if cm.smController, err = smcontroller.New(
-		cfg, cm.amqp, cm.alerts, cm.monitorcontroller, cm.iam, cm.cryptoContext, false); err != nil {
+	if cm.logCollector, err = logcollector.New(cfg, cm.smController, nodeInfoProvider, cm.amqp); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
@@ -189,6 +363,15 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 		return cm, aoserrors.Wrap(err)
 	}
 
+	cm.unitConfig.SetAuditRecorder(cm.auditLog)
+
+	unitConfigSchema, err := unitconfig.NewSchema()
+	if err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	cm.amqp.SetUnitConfigSchema(unitConfigSchema)
+
 	if cfg.Monitoring.MonitorConfig != nil {
 		if cm.resourcemonitor, err = resourcemonitor.New(*cfg.Monitoring.MonitorConfig, cm.iam, cm.unitConfig,
 			nil, cm.alerts); err != nil {
@@ -211,39 +394,214 @@ func newCommunicationManager(cfg *config.Config) (cm *communicationManager, err
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.umController, err = umcontroller.New(cfg, cm.db, cm.iam, cm.iam, cm.cryptoContext, cm.crypt, false); err != nil {
+	cm.downloader.SetCircuitBreaker(cm.circuitBreakers.Get(config.CircuitBreakerDownload))
+
+	if cfg.BandwidthBudget.DailyLimit != 0 || cfg.BandwidthBudget.MonthlyLimit != 0 {
+		if cm.bandwidthBudget, err = bandwidthbudget.New(cfg.BandwidthBudget, cm.db, cm.alerts); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+
+		cm.downloader.SetBandwidthBudget(cm.bandwidthBudget)
+		cm.logCollector.SetBandwidthBudget(cm.bandwidthBudget)
+		cm.monitorcontroller.SetBandwidthBudget(cm.bandwidthBudget)
+	}
+
+	if cm.umController, err = umcontroller.New(
+		cfg, cm.db, cm.iamCache, cm.iam, cm.cryptoContext, cm.crypt, false); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if cm.storageState, err = storagestate.New(cfg, cm.amqp, cm.alerts, cm.db, cm.iamCache, cm.cryptoContext); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.storageState, err = storagestate.New(cfg, cm.amqp, cm.db); err != nil {
+	if cm.imagemanager, err = imagemanager.New(cfg, cm.db, cm.crypt, cm.iamCache, cm.cryptoContext); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.imagemanager, err = imagemanager.New(cfg, cm.db, cm.crypt); err != nil {
+	cm.monitorcontroller.SetTransferStatsProviders(cm.imagemanager, cm.umController)
+
+	if cm.advisories, err = advisory.New(
+		cfg.Advisories, cm.db, cm.imagemanager, cm.umController, cm.alerts); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.network, err = networkmanager.New(cm.db, cm.smController, cfg); err != nil {
+	cm.attestation = attestation.New(cfg, cm.imagemanager, cm.crypt, cm.amqp)
+
+	if cm.network, err = networkmanager.New(cm.db, cm.smController, cm.alerts, cfg); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
+	if mirrorSender, ok := cm.smController.(networkmanager.TrafficMirrorSender); ok {
+		cm.network.SetTrafficMirrorSender(mirrorSender)
+	}
+
+	cm.network.SetNetworkEventsSender(cm.amqp)
+
+	cm.monitorcontroller.SetNetworkProvider(cm.network)
+	cm.monitorcontroller.SetDNSStatsProvider(cm.network)
+
 	if cm.launcher, err = launcher.New(
-		cfg, cm.db, cm.iam, cm.smController, cm.imagemanager, cm.unitConfig, cm.storageState, cm.network); err != nil {
+		cfg, cm.db, nodeInfoProvider, cm.smController, cm.imagemanager, cm.unitConfig, cm.storageState, cm.network,
+		cm.iam); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
+	cm.launcher.SetPlacementPolicies(extensions.PlacementPolicies)
+	cm.launcher.SetAlertSender(cm.alerts)
+	cm.storageState.SetInstanceStopper(cm.launcher)
+
+	if cm.lifecycle, err = lifecycle.New(cfg, cm.db, cm.amqp); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	cm.launcher.SetLifecycleRecorder(cm.lifecycle)
+
+	cm.healthChecker = healthcheck.New()
+
+	cm.launcher.SetHealthChecker(cm.healthChecker)
+
+	if cfg.QuotaEnforcement.Enabled {
+		cm.quotaEnforcer = quotaenforcer.New(cfg.QuotaEnforcement, cm.launcher, cm.alerts)
+
+		cm.alerts.SubscribeForAlerts(cm.quotaEnforcer)
+	}
+
+	if connectionProvider, ok := cm.smController.(nodereboot.ConnectionProvider); ok {
+		if cm.nodeReboot, err = nodereboot.New(
+			cfg.NodeReboot, cm.db, nodeInfoProvider, cm.launcher, connectionProvider, cm.amqp); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+	}
+
 	if cm.statusHandler, err = unitstatushandler.New(cfg, cm.iam, cm.unitConfig, cm.umController,
-		cm.imagemanager, cm.launcher, cm.downloader, cm.db, cm.amqp, cm.smController); err != nil {
+		cm.imagemanager, cm.launcher, cm.downloader, cm.db, cm.amqp, cm.smController, cm.crypt); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	cm.statusHandler.SetUpdateGates(extensions.UpdateGates)
+	cm.statusHandler.SetNodeCapabilitiesProvider(cm.launcher)
+	cm.statusHandler.SetProviderNetworksProvider(cm.network)
+	cm.statusHandler.SetSchedulingFreezer(cm.launcher)
+
+	if cfg.SecureWipe.Enabled {
+		cm.statusHandler.SetServiceWipers([]unitstatushandler.ServiceWiper{cm.launcher, cm.imagemanager})
+	}
+
+	if cm.stateBackup, err = statebackup.New(cfg, cm.db); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	if cm.ownerChange, err = ownerchange.New(cfg, []ownerchange.UpdateStopper{cm.downloader, cm.imagemanager},
+		cm.launcher, cm.storageState, cm.network, cm.db, cm.iamCache, cm.iam, cm.crypt, cm.crypt, cm.amqp,
+		cm); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	var stateChanges cmserver.StateChangesProvider
+
+	if cfg.StateStream.Enabled {
+		stateChanges = cm.stateStream
+	}
+
+	if cm.cmServer, err = cmserver.New(cfg, cm.statusHandler, cmserver.LocalServiceProviders{
+		MonitoringProvider:    cm.monitorcontroller,
+		BackupProvider:        cm.stateBackup,
+		UnitConfigProvider:    cm.unitConfig,
+		ConfigReloader:        reloader,
+		OwnerChanger:          cm.ownerChange,
+		NetworkDiagnostics:    cm.network,
+		AuditLog:              cm.auditLog,
+		DeviceAvailability:    cm.launcher,
+		NodeGroupDrainer:      cm.launcher,
+		MaintenanceHandler:    cm.statusHandler,
+		OverrideBundleHandler: cm.statusHandler,
+		CircuitBreakers:       cm.circuitBreakers,
+		SBOMProvider:          cm.imagemanager,
+		StateChanges:          stateChanges,
+	}, cm.iamCache, cm.cryptoContext, false); err != nil {
+		return cm, aoserrors.Wrap(err)
+	}
+
+	cm.alerts.SubscribeForAlerts(cm.cmServer)
+
+	healthCheckers := map[string]watchdog.HealthChecker{"storage": cm.db, "amqp": cm.amqp}
+
+	if checker, ok := cm.smController.(watchdog.HealthChecker); ok {
+		healthCheckers["smController"] = checker
+	}
+
+	if cm.watchdog, err = watchdog.New(cfg, healthCheckers); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
-	if cm.cmServer, err = cmserver.New(cfg, cm.statusHandler, cm.iam, cm.cryptoContext, false); err != nil {
+	if reason := cm.watchdog.GetRestartReason(); reason != "" {
+		log.WithField("reason", reason).Warn("CM was restarted due to failed health check")
+
+		cm.auditLog.Record(auditlog.SourceSystem, "", "WatchdogRestart", reason, nil)
+	}
+
+	if cm.featureFlags, err = featureflags.New(cfg, cm.db); err != nil {
 		return cm, aoserrors.Wrap(err)
 	}
 
+	for _, consumer := range []featureflags.Consumer{cm.amqp, cm.launcher, cm.statusHandler} {
+		if err = cm.featureFlags.Subscribe(consumer); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+	}
+
+	for _, consumer := range []config.ReloadConsumer{
+		cm, cm.crypt, cm.alerts, cm.monitorcontroller, cm.downloader, cm.unitConfig, cm.statusHandler, cm.cmServer,
+	} {
+		if err = reloader.SubscribeForConfigReload(consumer); err != nil {
+			return cm, aoserrors.Wrap(err)
+		}
+	}
+
 	return cm, nil
 }
 
+func (cm *communicationManager) ConfigReloaded(cfg *config.Config) {
+	// Command line flag takes precedence over config as on start
+
+	level := logging.GetLevels().Level
+
+	if isFlagSet("v") {
+		level = flag.Lookup("v").Value.String()
+	} else if cfg.LogLevel != "" {
+		level = cfg.LogLevel
+	}
+
+	if err := logging.SetLevels(logging.Levels{Level: level, ModuleLevels: cfg.ModuleLogLevels}); err != nil {
+		log.Errorf("Can't set log levels: %v", err)
+	}
+}
+
+// Restart restarts CM in process. Messages pending to be sent to the cloud are flushed on shutdown.
+func (cm *communicationManager) Restart() {
+	cm.auditLog.Record(auditlog.SourceSystem, "", "Restart", "", nil)
+
+	cm.restartOnce.Do(func() { close(cm.restartChannel) })
+}
+
+// fence stops CM fenced by promoted standby. CM is not restarted as fenced primary can't start till it is
+// re-provisioned.
+func (cm *communicationManager) fence() {
+	cm.fencedOnce.Do(func() { close(cm.fencedChannel) })
+}
+
+// checkIAMParams checks that parameters of modules created before IAM secrets are resolved are not changed by them.
+func checkIAMParams(iamCfg, cfg *config.Config) error {
+	if iamCfg.IAMPublicServerURL != cfg.IAMPublicServerURL ||
+		iamCfg.IAMProtectedServerURL != cfg.IAMProtectedServerURL ||
+		iamCfg.CertStorage != cfg.CertStorage || iamCfg.Crypt.CACert != cfg.Crypt.CACert {
+		return aoserrors.New("IAM secrets can't be used in parameters required to connect to IAM")
+	}
+
+	return nil
+}
+
 func initPKCS(cfg config.Crypt) (err error) {
 	cryptutils.DefaultPKCS11Library = cfg.Pkcs11Library
 
@@ -257,22 +615,105 @@ func initPKCS(cfg config.Crypt) (err error) {
 	return nil
 }
 
+// shutdown stops accepting desired statuses, waits for updates being applied and flushes messages pending to be sent
+// to the cloud. All steps are limited by timeout, the update state is persisted and continued on next start anyway.
+// On restart, service manager is notified about reloading to keep the service active till CM is ready again.
+func (cm *communicationManager) shutdown(timeout time.Duration, restart bool) {
+	log.WithFields(log.Fields{"timeout": timeout, "restart": restart}).Info("Shutdown communication manager")
+
+	notifyState := daemon.SdNotifyStopping
+	if restart {
+		notifyState = daemon.SdNotifyReloading
+	}
+
+	if _, err := daemon.SdNotify(false, notifyState); err != nil {
+		log.Errorf("Can't notify systemd: %s", err)
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), timeout)
+	defer cancelFunc()
+
+	if err := cm.statusHandler.Shutdown(ctx); err != nil {
+		log.Warnf("Shutdown with not finished update: %v", err)
+	}
+
+	for cm.amqp.GetSendQueueLength() != 0 {
+		select {
+		case <-ctx.Done():
+			log.Warn("Shutdown with not sent cloud messages")
+
+			return
+
+		case <-time.After(flushPeriod):
+		}
+	}
+}
+
 func (cm *communicationManager) close() {
+	// Close watchdog
+	if cm.watchdog != nil {
+		cm.watchdog.Close()
+	}
+
 	// Close CM server
 	if cm.cmServer != nil {
+		if cm.alerts != nil {
+			cm.alerts.UnsubscribeFromAlerts(cm.cmServer)
+		}
+
 		cm.cmServer.Close()
 	}
 
+	// Close owner change
+	if cm.ownerChange != nil {
+		cm.ownerChange.Close()
+	}
+
+	// Close audit log
+	if cm.auditLog != nil {
+		cm.auditLog.Close()
+	}
+
 	// Close unit status handler
 	if cm.statusHandler != nil {
 		cm.statusHandler.Close()
 	}
 
+	// Close node reboot orchestrator
+	if cm.nodeReboot != nil {
+		cm.nodeReboot.Close()
+	}
+
+	// Close quota enforcer
+	if cm.quotaEnforcer != nil {
+		cm.quotaEnforcer.Close()
+	}
+
 	// Close CM launcher
 	if cm.launcher != nil {
 		cm.launcher.Close()
 	}
 
+	// Close instance lifecycle
+	if cm.lifecycle != nil {
+		cm.lifecycle.Close()
+	}
+
+	// Close health checker
+	if cm.healthChecker != nil {
+		cm.healthChecker.Close()
+	}
+
+	// Close network manager
+	if cm.network != nil {
+		cm.network.Close()
+	}
+
+	// Close vulnerability advisories matcher
+	if cm.advisories != nil {
+		cm.advisories.Close()
+	}
+
 	// Close CM image manager
 	if cm.imagemanager != nil {
 		cm.imagemanager.Close()
@@ -288,6 +729,11 @@ func (cm *communicationManager) close() {
 		cm.umController.Close()
 	}
 
+	// Close log collector
+	if cm.logCollector != nil {
+		cm.logCollector.Close()
+	}
+
 	// Close SM controller
 	if cm.smController != nil {
 		cm.smController.Close()
@@ -318,6 +764,16 @@ func (cm *communicationManager) close() {
 		cm.downloader.Close()
 	}
 
+	// Close bandwidth budget
+	if cm.bandwidthBudget != nil {
+		cm.bandwidthBudget.Close()
+	}
+
+	// Close IAM cache
+	if cm.iamCache != nil {
+		cm.iamCache.Close()
+	}
+
 	// Close iam
 	if cm.iam != nil {
 		cm.iam.Close()
@@ -338,6 +794,16 @@ func (cm *communicationManager) close() {
 		cryptutils.DefaultTPMDevice.Close()
 	}
 
+	// Close standby replication server
+	if cm.standbyServer != nil {
+		cm.standbyServer.Close()
+	}
+
+	// Stop fencing of former primary
+	if cm.takeover != nil {
+		cm.takeover.Close()
+	}
+
 	// Close DB
 	if cm.db != nil {
 		cm.db.Close()
@@ -345,13 +811,108 @@ func (cm *communicationManager) close() {
 }
 
 func (cm *communicationManager) processMessage(message amqp.Message) (err error) {
+	defer func() {
+		if details, ok := getAuditDetails(message); ok {
+			cm.auditLog.Record(
+				auditlog.SourceCloud, "", strings.TrimPrefix(fmt.Sprintf("%T", message), "*"), details, err)
+		}
+	}()
+
 	switch data := message.(type) {
-	case *cloudprotocol.DesiredStatus:
-		log.Info("Receive desired status message")
+	case *amqp.DesiredStatus:
+		if data.CorrelationID == "" {
+			data.CorrelationID = logging.NewCorrelationID()
+		}
+
+		log.WithFields(log.Fields{
+			"dryRun":                   data.DryRun,
+			"requireConfirmation":      data.RequireConfirmation,
+			logging.CorrelationIDField: data.CorrelationID,
+		}).Info("Receive desired status message")
 
-		cm.statusHandler.ProcessDesiredStatus(*data)
+		if err = cm.statusHandler.ProcessDesiredStatusRequest(*data); err != nil {
+			return aoserrors.Wrap(err)
+		}
 
-		return nil
+	case *amqp.DesiredStatusConfirmation:
+		log.WithFields(log.Fields{
+			"reportID":  data.ReportID,
+			"confirmed": data.Confirmed,
+		}).Info("Receive desired status confirmation message")
+
+		if err = cm.statusHandler.ConfirmDesiredStatus(*data); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.MaintenanceModeRequest:
+		log.WithFields(log.Fields{
+			"enabled": data.Enabled,
+			"reason":  data.Reason,
+		}).Info("Receive maintenance mode request message")
+
+		if err = cm.statusHandler.SetMaintenanceMode(data.Enabled, data.Reason); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.FeatureFlags:
+		log.WithField("flags", data.Flags).Info("Receive feature flags message")
+
+		if err = cm.featureFlags.SetFlags(data.Flags); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.VulnerabilityAdvisories:
+		log.WithField("count", len(data.Advisories)).Info("Receive vulnerability advisories message")
+
+		if err = cm.advisories.SetAdvisories(data.Advisories); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.AttestationRequest:
+		log.WithField("requestID", data.RequestID).Info("Receive attestation request message")
+
+		// Measuring images takes time, don't block message handling
+		go func() {
+			if err := cm.attestation.SendReport(data.RequestID, data.Nonce); err != nil {
+				log.Errorf("Can't send attestation report: %v", err)
+			}
+		}()
+
+	case *amqp.NodeRebootRequest:
+		log.WithField("requestID", data.RequestID).Info("Receive node reboot request message")
+
+		if cm.nodeReboot == nil {
+			return aoserrors.Wrap(cm.amqp.SendNodeRebootStatus(amqp.NodeRebootStatus{
+				RequestID: data.RequestID, Status: amqp.NodeRebootFailed, Error: "node reboot is not supported",
+			}))
+		}
+
+		if err = cm.nodeReboot.RebootNodes(*data); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.SBOMRequest:
+		log.WithField("requestID", data.RequestID).Info("Receive SBOM request message")
+
+		unitSBOM := amqp.SBOM{RequestID: data.RequestID}
+
+		if unitSBOM.Services, err = cm.imagemanager.GetSBOMs(); err != nil {
+			unitSBOM.Error = err.Error()
+		}
+
+		if err = cm.amqp.SendSBOM(unitSBOM); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.DecryptionInfoUpdate:
+		log.WithFields(log.Fields{
+			"requestID": data.RequestID,
+			"items":     len(data.Items),
+		}).Info("Receive decryption info update message")
+
+		if err = cm.statusHandler.ProcessDecryptionInfoUpdate(*data); err != nil {
+			return aoserrors.Wrap(err)
+		}
 
 	case *cloudprotocol.OverrideEnvVars:
 		log.Info("Receive override env vars message")
@@ -380,7 +941,7 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 			return aoserrors.Wrap(err)
 		}
 
-	case *cloudprotocol.RequestLog:
+	case *amqp.RequestLog:
 		log.WithFields(log.Fields{
 			"LogID":   data.LogID,
 			"LogType": data.LogType,
@@ -388,7 +949,18 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 			"till":    data.Filter.Till,
 		}).Info("Receive request service log message")
 
-		if err = cm.smController.GetLog(*data); err != nil {
+		if err = cm.logCollector.ProcessLogRequest(*data); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+	case *amqp.LogPartsRequest:
+		log.WithFields(log.Fields{
+			"LogID":  data.LogID,
+			"NodeID": data.NodeID,
+			"parts":  data.Parts,
+		}).Info("Receive log parts request message")
+
+		if err = cm.logCollector.ProcessLogPartsRequest(*data); err != nil {
 			return aoserrors.Wrap(err)
 		}
 
@@ -399,6 +971,8 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 			return aoserrors.New("unit secure version mismatch")
 		}
 
+		cm.iamCache.SetUnitSecrets(data.UnitSecrets)
+
 		if err = cm.iam.RenewCertificatesNotification(
 			data.UnitSecrets, data.Certificates); err != nil {
 			return aoserrors.Wrap(err)
@@ -432,6 +1006,21 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 			return aoserrors.Wrap(err)
 		}
 
+	case *amqp.OwnerChangeRequest:
+		log.Info("Receive owner change request message")
+
+		// Owner change stops instances and waits for pending messages, don't block message handling
+		go func() {
+			if _, err := cm.ownerChange.ChangeOwner(ownerchange.Request{
+				Info:          data.Info,
+				Authorization: data.Authorization,
+				CertChains:    data.CertChains,
+				Certs:         data.Certs,
+			}); err != nil {
+				log.Errorf("Can't change owner: %v", err)
+			}
+		}()
+
 	default:
 		log.Warnf("Receive unsupported amqp message: %s", reflect.TypeOf(data))
 	}
@@ -439,6 +1028,75 @@ func (cm *communicationManager) processMessage(message amqp.Message) (err error)
 	return nil
 }
 
+// getAuditDetails returns details of cloud message to be recorded to the audit log. Messages which don't change the unit
+// (requests of logs, reports and so on) are not management actions and are not recorded.
+func getAuditDetails(message amqp.Message) (details string, ok bool) {
+	var fields []string
+
+	addField := func(name, value string) {
+		if value != "" {
+			fields = append(fields, name+"="+value)
+		}
+	}
+
+	switch data := message.(type) {
+	case *amqp.DesiredStatus:
+		addField("correlationID", data.CorrelationID)
+		addField("campaignID", data.CampaignID)
+
+		if data.UnitConfig != nil {
+			addField("unitConfigVersion", data.UnitConfig.Version)
+		}
+
+		if data.DryRun {
+			addField("dryRun", "true")
+		}
+
+	case *amqp.DesiredStatusConfirmation:
+		addField("reportID", data.ReportID)
+		addField("confirmed", strconv.FormatBool(data.Confirmed))
+
+	case *amqp.MaintenanceModeRequest:
+		addField("enabled", strconv.FormatBool(data.Enabled))
+		addField("reason", data.Reason)
+
+	case *amqp.FeatureFlags:
+		addField("flags", fmt.Sprint(data.Flags))
+
+	case *amqp.NodeRebootRequest:
+		addField("requestID", data.RequestID)
+		addField("nodes", strings.Join(data.NodeIDs, ","))
+
+	case *amqp.EmergencyUpdate:
+		var info amqp.EmergencyUpdateInfo
+
+		if err := json.Unmarshal(data.Update, &info); err == nil {
+			addField("updateID", info.UpdateID)
+		}
+
+	case *cloudprotocol.UpdateState:
+		addField("serviceID", data.ServiceID)
+		addField("checksum", data.Checksum)
+
+	case *cloudprotocol.StartProvisioningRequest:
+		addField("nodeID", data.NodeID)
+
+	case *cloudprotocol.FinishProvisioningRequest:
+		addField("nodeID", data.NodeID)
+
+	case *cloudprotocol.DeprovisioningRequest:
+		addField("nodeID", data.NodeID)
+
+	case *cloudprotocol.OverrideEnvVars, *cloudprotocol.RenewCertsNotification, *cloudprotocol.IssuedUnitCerts,
+		*amqp.OwnerChangeRequest:
+
+	default:
+		return "", false
+	}
+
+	return strings.Join(fields, " "), true
+}
+
 func (cm *communicationManager) handleMessages(ctx context.Context) {
 	for {
 		select {
@@ -458,11 +1116,12 @@ func (cm *communicationManager) handleMessages(ctx context.Context) {
 	}
 }
 
-func (cm *communicationManager) handleConnection(ctx context.Context, serviceDiscoveryURLs []string) {
+func (cm *communicationManager) handleConnection(ctx context.Context) {
 	for {
 		_ = retryhelper.Retry(ctx,
 			func() (err error) {
-				for _, serviceDiscoveryURL := range serviceDiscoveryURLs {
+				// Get URLs on each attempt to use reloaded service discovery URL, e.g. with rotated credentials
+				for _, serviceDiscoveryURL := range cm.crypt.GetServiceDiscoveryURLs() {
 					if err = cm.amqp.Connect(cm.crypt, serviceDiscoveryURL, cm.iam.GetSystemID(), false); err == nil {
 						return nil
 					} else {
@@ -494,6 +1153,25 @@ func (cm *communicationManager) handleConnection(ctx context.Context, serviceDis
 	}
 }
 
+func (cm *communicationManager) handleEmergencyUpdates(ctx context.Context) {
+	for {
+		select {
+		case update := <-cm.amqp.EmergencyUpdateChannel:
+			err := cm.statusHandler.ProcessEmergencyUpdate(*update)
+			if err != nil {
+				log.Errorf("Can't process emergency update: %v", err)
+			}
+
+			details, _ := getAuditDetails(update)
+
+			cm.auditLog.Record(auditlog.SourceCloud, "", "EmergencyUpdate", details, err)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (cm *communicationManager) handleStatusChannels(ctx context.Context) {
 	for {
 		select {
@@ -502,8 +1180,15 @@ func (cm *communicationManager) handleStatusChannels(ctx context.Context) {
 				log.Errorf("Can't process run statusL %v", err)
 			}
 
+			cm.monitorcontroller.ProcessRunStatus(runStatus)
+			cm.cmServer.ProcessRunStatus(runStatus)
+			cm.lifecycle.ProcessRunStatus(runStatus)
+
 		case instanceStatus := <-cm.smController.GetUpdateInstancesStatusChannel():
 			cm.statusHandler.ProcessUpdateInstanceStatus(instanceStatus)
+			cm.monitorcontroller.ProcessUpdateInstanceStatus(instanceStatus)
+			cm.cmServer.ProcessUpdateInstanceStatus(instanceStatus)
+			cm.lifecycle.ProcessUpdateInstanceStatus(instanceStatus)
 
 		case <-ctx.Done():
 			return
@@ -540,6 +1225,11 @@ func (hook *journalHook) Fire(entry *log.Entry) (err error) {
 		return aoserrors.Wrap(err)
 	}
 
+	// Entry is filtered by module log level
+	if logMessage == "" {
+		return nil
+	}
+
 	err = journal.Print(hook.severityMap[entry.Level], "%s", logMessage)
 
 	return aoserrors.Wrap(err)
@@ -570,6 +1260,194 @@ func reset(cfg *config.Config) (err error) {
 	return nil
 }
 
+func handleConfigReload(
+	ctx context.Context, reloadChannel <-chan os.Signal, reloader *config.Reloader, refreshPeriod time.Duration,
+) {
+	var refreshChannel <-chan time.Time
+
+	// Config is reloaded periodically to pick up rotated secrets
+	if refreshPeriod > 0 {
+		refreshTicker := time.NewTicker(refreshPeriod)
+		defer refreshTicker.Stop()
+
+		refreshChannel = refreshTicker.C
+	}
+
+	for {
+		select {
+		case <-reloadChannel:
+			if _, err := reloader.Reload(); err != nil {
+				log.Errorf("Can't reload config: %v", err)
+			}
+
+		case <-refreshChannel:
+			if _, err := reloader.Reload(); err != nil {
+				log.Errorf("Can't refresh config secrets: %v", err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//nolint:forbidigo // check result is printed to stdout
+func checkConfig(fileName string, overrides []string) (ok bool) {
+	report, err := config.Check(fileName, overrides...)
+	if err != nil {
+		fmt.Printf("Can't check config: %v\n", err)
+
+		return false
+	}
+
+	if len(report.UnknownKeys) != 0 {
+		fmt.Println("Unknown keys:")
+
+		for _, key := range report.UnknownKeys {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+
+	if len(report.TypeErrors) != 0 {
+		fmt.Println("Type errors:")
+
+		for _, typeError := range report.TypeErrors {
+			fmt.Printf("  %s: %s\n", typeError.Key, typeError.Reason)
+		}
+	}
+
+	if report.Error != nil {
+		fmt.Printf("Invalid config: %v\n", report.Error)
+	}
+
+	if len(report.Changes) != 0 {
+		fmt.Println("Changed from defaults (default -> value):")
+
+		for _, change := range report.Changes {
+			fmt.Printf("  %s: %s -> %s\n", change.Key, change.DefaultValue, change.Value)
+		}
+	}
+
+	if report.HasProblems() {
+		return false
+	}
+
+	fmt.Printf("Config %s is valid\n", fileName)
+
+	return true
+}
+
+func isFlagSet(name string) (isSet bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+
+	return isSet
+}
+
+// loadConfig parses config, applies log settings and resolves config secrets. IAM secrets are resolved later when
+// IAM client is created.
+func loadConfig(fileName, logLevel string, overrides []string) (
+	cfg *config.Config, reloader *config.Reloader, secretResolver *config.SecretResolver, err error,
+) {
+	if cfg, err = config.New(fileName, overrides...); err != nil {
+		return nil, nil, nil, aoserrors.Wrap(err)
+	}
+
+	// Set log level: command line flag takes precedence over config
+
+	if cfg.LogLevel != "" && !isFlagSet("v") {
+		logLevel = cfg.LogLevel
+	}
+
+	if err = logging.SetFormat(cfg.LogFormat); err != nil {
+		return nil, nil, nil, aoserrors.Wrap(err)
+	}
+
+	if err = logging.SetLevels(logging.Levels{Level: logLevel, ModuleLevels: cfg.ModuleLogLevels}); err != nil {
+		return nil, nil, nil, aoserrors.Wrap(err)
+	}
+
+	secretResolver = config.NewSecretResolver(cfg.SecretCacheTTL.Duration)
+	reloader = config.NewReloader(fileName, cfg, secretResolver, overrides...)
+
+	if cfg, err = reloader.ResolveSecrets(); err != nil {
+		return nil, nil, nil, aoserrors.Errorf("can't resolve config secrets: %v", err)
+	}
+
+	return cfg, reloader, secretResolver, nil
+}
+
+// run creates and runs CM till it is terminated or restart is requested.
+func run(
+	cfg *config.Config, reloader *config.Reloader, secretResolver *config.SecretResolver,
+	terminateChannel, reloadChannel <-chan os.Signal,
+) (restart bool, err error) {
+	startCtx, stopStartSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	cm, err := newCommunicationManager(startCtx, cfg, reloader, secretResolver)
+
+	terminated := startCtx.Err() != nil
+
+	stopStartSignals()
+
+	if err != nil {
+		if terminated {
+			log.Info("Communication manager is terminated on start")
+
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	// Notify systemd
+	if _, err = daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
+		log.Errorf("Can't notify systemd: %s", err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go cm.handleConnection(ctx)
+	go cm.handleStatusChannels(ctx)
+	go cm.handleEmergencyUpdates(ctx)
+
+	// Handle SIGHUP
+
+	var secretRefreshPeriod time.Duration
+
+	if secretResolver.HasReferences() {
+		secretRefreshPeriod = cfg.SecretCacheTTL.Duration
+	}
+
+	go handleConfigReload(ctx, reloadChannel, reloader, secretRefreshPeriod)
+
+	// Handle SIGTERM
+
+	select {
+	case <-terminateChannel:
+		log.Info("Terminate communication manager")
+
+	case <-cm.fencedChannel:
+		log.Warn("Stop communication manager fenced by promoted standby")
+
+	case <-cm.restartChannel:
+		log.Info("Restart communication manager")
+
+		restart = true
+	}
+
+	// Shutdown before cancel to keep cloud connection while pending messages are flushed
+	cm.shutdown(cfg.ShutdownTimeout.Duration, restart)
+
+	cancelFunc()
+	cm.close()
+
+	return restart, nil
+}
+
 /***********************************************************************************************************************
  * Main
  **********************************************************************************************************************/
@@ -581,6 +1459,11 @@ func main() {
 	doReset := flag.Bool("reset", false, `cleanup working directory`)
 	showVersion := flag.Bool("version", false, `show communication manager version`)
 	useJournal := flag.Bool("j", false, "output logs to systemd journal")
+	doCheckConfig := flag.Bool("check-config", false, `check config file and show parameters changed from defaults`)
+
+	var configOverrides config.Overrides
+
+	flag.Var(&configOverrides, "o", `override config parameter: "<key>=<value>", can be repeated`)
 
 	flag.Parse()
 
@@ -592,6 +1475,16 @@ func main() {
 		return
 	}
 
+	// Check config
+
+	if *doCheckConfig {
+		if !checkConfig(*configFile, configOverrides) {
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	// Set log output
 
 	if *useJournal {
@@ -601,22 +1494,13 @@ func main() {
 		log.SetOutput(os.Stdout)
 	}
 
-	// Set log level
-
-	logLevel, err := log.ParseLevel(*strLogLevel)
-	if err != nil {
-		log.Fatalf("Error: %s", err)
-	}
-
-	log.SetLevel(logLevel)
-
 	// Parse config
 
-	cfg, err := config.New(*configFile)
+	cfg, reloader, secretResolver, err := loadConfig(*configFile, *strLogLevel, configOverrides)
 	if err != nil {
 		// Config is important to make CM works properly. If we can't parse the config no reason to continue.
 		// If the error is temporary CM will be restarted by systemd.
-		log.Fatalf("Can't parse config: %s", err)
+		log.Fatalf("Can't load config: %s", err)
 	}
 
 	// Do reset
@@ -634,32 +1518,33 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.WithFields(log.Fields{"configFile": *configFile, "version": GitSummary}).Info("Start communication manager")
-
-	cm, err := newCommunicationManager(cfg)
-	if err != nil {
-		log.Fatalf("Can't create communication manager: %s", err)
-	}
+	// Signals are handled before CM is created: standby waits for failover within CM creation
 
-	defer cm.close()
+	terminateChannel := make(chan os.Signal, 1)
 
-	// Notify systemd
-	if _, err = daemon.SdNotify(false, daemon.SdNotifyReady); err != nil {
-		log.Errorf("Can't notify systemd: %s", err)
-	}
+	signal.Notify(terminateChannel, os.Interrupt, syscall.SIGTERM)
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	reloadChannel := make(chan os.Signal, 1)
 
-	go cm.handleConnection(ctx, cm.crypt.GetServiceDiscoveryURLs())
-	go cm.handleStatusChannels(ctx)
+	signal.Notify(reloadChannel, syscall.SIGHUP)
 
-	// Handle SIGTERM
+	for {
+		log.WithFields(log.Fields{"configFile": *configFile, "version": GitSummary}).Info("Start communication manager")
 
-	terminateChannel := make(chan os.Signal, 1)
+		restart, err := run(cfg, reloader, secretResolver, terminateChannel, reloadChannel)
+		if err != nil {
+			log.Fatalf("Can't create communication manager: %s", err)
+		}
 
-	signal.Notify(terminateChannel, os.Interrupt, syscall.SIGTERM)
+		if !restart {
+			return
+		}
 
-	<-terminateChannel
+		// CM is restarted in process with fresh config and secret providers, e.g. to re-onboard the unit after owner
+		// change
 
-	cancelFunc()
+		if cfg, reloader, secretResolver, err = loadConfig(*configFile, *strLogLevel, configOverrides); err != nil {
+			log.Fatalf("Can't load config: %s", err)
+		}
+	}
 }
@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CheckReport config file check report.
+type CheckReport struct {
+	UnknownKeys []string
+	TypeErrors  []KeyError
+	Error       error
+	Changes     []KeyChange
+}
+
+// KeyError config key error.
+type KeyError struct {
+	Key    string
+	Reason string
+}
+
+// KeyChange config parameter which differs from default value.
+type KeyChange struct {
+	Key          string
+	DefaultValue string
+	Value        string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// Check checks config file: reports unknown keys, type errors and parameters which differ from defaults.
+func Check(fileName string, overrides ...string) (report CheckReport, err error) {
+	raw, err := os.ReadFile(fileName)
+	if err != nil {
+		return report, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(raw, new(interface{})); err != nil {
+		report.Error = aoserrors.Wrap(err)
+
+		return report, nil
+	}
+
+	report.checkValue("", raw, reflect.TypeOf(Config{}))
+
+	if len(report.UnknownKeys) != 0 || len(report.TypeErrors) != 0 {
+		return report, nil
+	}
+
+	config, err := New(fileName, overrides...)
+	if err != nil {
+		report.Error = err
+
+		return report, nil
+	}
+
+	defaultConfig := newDefaultConfig()
+
+	defaultConfig.setDefaultPaths()
+
+	if report.Changes, err = diffConfigs(defaultConfig, config); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// HasProblems returns true if config file has problems.
+func (report *CheckReport) HasProblems() bool {
+	return len(report.UnknownKeys) != 0 || len(report.TypeErrors) != 0 || report.Error != nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (report *CheckReport) checkValue(key string, raw json.RawMessage, valueType reflect.Type) {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	if string(raw) == "null" {
+		return
+	}
+
+	isUnmarshaler := reflect.PointerTo(valueType).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem())
+
+	switch {
+	case valueType.Kind() == reflect.Struct && !isUnmarshaler:
+		var object map[string]json.RawMessage
+
+		if err := json.Unmarshal(raw, &object); err != nil {
+			report.addTypeError(key, err)
+
+			return
+		}
+
+		for _, name := range sortedKeys(object) {
+			index := findFieldIndex(valueType, name)
+			if index < 0 {
+				report.UnknownKeys = append(report.UnknownKeys, joinKey(key, name))
+
+				continue
+			}
+
+			report.checkValue(joinKey(key, name), object[name], valueType.Field(index).Type)
+		}
+
+	case valueType.Kind() == reflect.Slice && valueType.Elem().Kind() == reflect.Struct && !isUnmarshaler:
+		var items []json.RawMessage
+
+		if err := json.Unmarshal(raw, &items); err != nil {
+			report.addTypeError(key, err)
+
+			return
+		}
+
+		for i, item := range items {
+			report.checkValue(fmt.Sprintf("%s[%d]", key, i), item, valueType.Elem())
+		}
+
+	default:
+		if err := json.Unmarshal(raw, reflect.New(valueType).Interface()); err != nil {
+			report.addTypeError(key, err)
+		}
+	}
+}
+
+func (report *CheckReport) addTypeError(key string, err error) {
+	reason := err.Error()
+
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err, &typeErr) {
+		reason = fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+
+	report.TypeErrors = append(report.TypeErrors, KeyError{Key: key, Reason: reason})
+}
+
+func diffConfigs(defaultConfig, config *Config) (changes []KeyChange, err error) {
+	defaultValues, err := flattenConfig(defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := flattenConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range defaultValues {
+		if _, ok := values[key]; !ok {
+			values[key] = "null"
+		}
+	}
+
+	for _, key := range sortedKeys(values) {
+		defaultValue, ok := defaultValues[key]
+		if !ok {
+			defaultValue = "null"
+		}
+
+		if values[key] != defaultValue {
+			changes = append(changes, KeyChange{Key: key, DefaultValue: defaultValue, Value: values[key]})
+		}
+	}
+
+	return changes, nil
+}
+
+// flattenConfig returns config parameters as JSON values mapped by dot separated keys.
+func flattenConfig(config *Config) (values map[string]string, err error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	var object map[string]interface{}
+
+	if err = json.Unmarshal(data, &object); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	values = make(map[string]string)
+
+	if err = flattenValue("", object, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func flattenValue(key string, value interface{}, values map[string]string) error {
+	if object, ok := value.(map[string]interface{}); ok {
+		for name, item := range object {
+			if err := flattenValue(joinKey(key, name), item, values); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	values[key] = string(data)
+
+	return nil
+}
+
+func joinKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + keySeparator + name
+}
+
+func sortedKeys[T any](object map[string]T) []string {
+	keys := make([]string, 0, len(object))
+
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
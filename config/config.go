@@ -20,16 +20,130 @@ package config
 
 import (
 	"encoding/json"
+	"net"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
 	"github.com/aosedge/aos_common/journalalerts"
 	"github.com/aosedge/aos_common/resourcemonitor"
 )
 
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// EnvPrefix prefix of environment variables related to CM configuration.
+const EnvPrefix = "AOS_CM_"
+
+// MaxVlanID max VLAN ID.
+const MaxVlanID = 4095
+
+// Network isolation levels.
+const (
+	IsolationOpen   = "open"
+	IsolationStrict = "strict"
+)
+
+// IP allocation strategies.
+const (
+	IPAllocationSequential = "sequential"
+	IPAllocationRandom     = "random"
+)
+
+// Unreachable node policies.
+const (
+	UnreachableNodeWait    = "wait"
+	UnreachableNodeProceed = "proceed"
+)
+
+// SM channel compression algorithms.
+const (
+	SMCompressionGzip = "gzip"
+)
+
+// Log upload compression algorithms.
+const (
+	LogCompressionNone = "none"
+	LogCompressionGzip = "gzip"
+	LogCompressionZstd = "zstd"
+)
+
+// Health check restart policies.
+const (
+	HealthRestartNever     = "never"
+	HealthRestartOnFailure = "onFailure"
+)
+
+// Retention data classes.
+const (
+	RetentionMonitoring        = "monitoring"
+	RetentionAuditLog          = "auditLog"
+	RetentionUpdateHistory     = "updateHistory"
+	RetentionInstanceLifecycle = "instanceLifecycle"
+	RetentionAlerts            = "alerts"
+	RetentionNetworkEvents     = "networkEvents"
+)
+
+// Circuit breaker endpoints.
+const (
+	CircuitBreakerCloud    = "cloud"
+	CircuitBreakerDownload = "download"
+	CircuitBreakerIAM      = "iam"
+)
+
+// Vulnerability advisory severities in ascending order.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Instance quota enforcement actions.
+const (
+	QuotaActionAlert    = "alert"
+	QuotaActionThrottle = "throttle"
+	QuotaActionRestart  = "restart"
+	QuotaActionStop     = "stop"
+)
+
+// Standby roles.
+const (
+	StandbyRolePrimary = "primary"
+	StandbyRoleStandby = "standby"
+)
+
+// Feature flags.
+const (
+	FeatureAdaptiveTelemetry = "adaptiveTelemetry"
+	FeatureNodeScoring       = "nodeScoring"
+	FeatureDeltaUnitStatus   = "deltaUnitStatus"
+)
+
+const maxNodeGroupNameLen = 63
+
+// maxBillingDay max billing day which exists in all months.
+const maxBillingDay = 28
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var nodeGroupNameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+// oidRegexp matches dotted OID in canonical form.
+//
+//nolint:gochecknoglobals
+var oidRegexp = regexp.MustCompile(`^[0-2](\.(0|[1-9][0-9]*))+$`)
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -39,6 +153,9 @@ type Crypt struct {
 	CACert        string `json:"caCert"`
 	TpmDevice     string `json:"tpmDevice,omitempty"`
 	Pkcs11Library string `json:"pkcs11Library,omitempty"`
+	Provider      string `json:"provider"`
+	KeyURL        string `json:"keyUrl,omitempty"`
+	CACertURL     string `json:"caCertUrl,omitempty"`
 }
 
 // UMController configuration for update controller.
@@ -54,14 +171,26 @@ type Monitoring struct {
 	MaxOfflineMessages int                     `json:"maxOfflineMessages"`
 	SendPeriod         aostypes.Duration       `json:"sendPeriod"`
 	MaxMessageSize     int                     `json:"maxMessageSize"`
+	HistoryRetention   aostypes.Duration       `json:"historyRetention"`
+	MaxHistoryRecords  int                     `json:"maxHistoryRecords"`
+	UnitRollupPeriod   aostypes.Duration       `json:"unitRollupPeriod"`
+}
+
+// AdaptiveTelemetry configuration of telemetry rates adaptation to connection quality.
+type AdaptiveTelemetry struct {
+	Enabled           bool              `json:"enabled"`
+	MaxSendLatency    aostypes.Duration `json:"maxSendLatency"`
+	MinBandwidth      uint64            `json:"minBandwidth"`
+	ReducedRateFactor int               `json:"reducedRateFactor"`
 }
 
 // Alerts configuration for alerts.
 type Alerts struct {
-	JournalAlerts      *journalalerts.Config `json:"journalAlerts,omitempty"`
-	SendPeriod         aostypes.Duration     `json:"sendPeriod"`
-	MaxMessageSize     int                   `json:"maxMessageSize"`
-	MaxOfflineMessages int                   `json:"maxOfflineMessages"`
+	JournalAlerts            *journalalerts.Config `json:"journalAlerts,omitempty"`
+	SendPeriod               aostypes.Duration     `json:"sendPeriod"`
+	MaxMessageSize           int                   `json:"maxMessageSize"`
+	MaxOfflineMessages       int                   `json:"maxOfflineMessages"`
+	MonitoringSnapshotWindow aostypes.Duration     `json:"monitoringSnapshotWindow"`
 }
 
 // Migration struct represents path for db migration.
@@ -85,49 +214,735 @@ type SMController struct {
 	CMServerURL            string            `json:"cmServerUrl"`
 	NodesConnectionTimeout aostypes.Duration `json:"nodesConnectionTimeout"`
 	UpdateTTL              aostypes.Duration `json:"updateTtl"`
+	SimulationScenario     string            `json:"simulationScenario,omitempty"`
+	// UnreachableNodePolicy policy of running instances when node is not reachable: wait - wait for the node till
+	// nodes connection timeout, proceed - run instances on reachable nodes and send instances of unreachable node when
+	// it returns.
+	UnreachableNodePolicy string `json:"unreachableNodePolicy"`
+	// Compression compression of messages sent to SM: gzip. It is used for nodes which SM accepts it and disabled if
+	// not set.
+	Compression string `json:"compression,omitempty"`
+}
+
+// Balancing instances balancing configuration.
+type Balancing struct {
+	// ScoringWeights weights of node scoring criteria. If not set, the node with top priority and the most available
+	// resources is selected.
+	ScoringWeights *NodeScoringWeights `json:"scoringWeights,omitempty"`
+	// CriticalServices IDs of services allowed to use reserved capacity. Critical services are scheduled first.
+	CriticalServices []string `json:"criticalServices,omitempty"`
+	// ReservedCapacity capacity held back from not critical services by node type.
+	ReservedCapacity map[string]ReservedCapacity `json:"reservedCapacity,omitempty"`
+	// MaxUnavailable max number of instances replaced at the same time on service update by service ID. Nodes are
+	// updated in waves to meet the limit.
+	MaxUnavailable map[string]int `json:"maxUnavailable,omitempty"`
+	// NodeGroups named node groups by group name. Group name can be used as instance label to place instances on
+	// group nodes.
+	NodeGroups map[string]NodeGroup `json:"nodeGroups,omitempty"`
+	// RolloutGroups order of node groups in which nodes are updated. Nodes not included in listed groups are
+	// updated last.
+	RolloutGroups []string `json:"rolloutGroups,omitempty"`
+	// ActivationSchedules timetable windows in which instances of the service are run by service ID. Instances are
+	// stopped outside the windows.
+	ActivationSchedules map[string][]cloudprotocol.TimetableEntry `json:"activationSchedules,omitempty"`
+	// Overcommit ratios of node CPU and RAM available for instances scheduling. Instances of nodes under sustained
+	// resource pressure are evicted on rebalancing starting from the lowest priority.
+	Overcommit *OvercommitRatios `json:"overcommit,omitempty"`
+}
+
+// OvercommitRatios node capacity overcommit ratios, e.g. 1.5 allows to schedule instances which requested resources
+// exceed node capacity by 50%. Not set ratio means no overcommit.
+type OvercommitRatios struct {
+	CPU float64 `json:"cpu,omitempty"`
+	RAM float64 `json:"ram,omitempty"`
+}
+
+// NodeGroup node group definition. Node belongs to the group if it is listed in node IDs or has all group labels.
+type NodeGroup struct {
+	Labels  []string `json:"labels,omitempty"`
+	NodeIDs []string `json:"nodeIds,omitempty"`
+}
+
+// ReservedCapacity node capacity reserved for critical services.
+type ReservedCapacity struct {
+	CPU     uint64         `json:"cpu"`
+	RAM     uint64         `json:"ram"`
+	Devices map[string]int `json:"devices,omitempty"`
+}
+
+// NodeScoringWeights weights of node scoring criteria. Each criterion is normalized to 0..1 range.
+type NodeScoringWeights struct {
+	Priority        float64  `json:"priority"`
+	Load            float64  `json:"load"`
+	DeviceHeadroom  float64  `json:"deviceHeadroom"`
+	LabelPreference float64  `json:"labelPreference"`
+	PreferredLabels []string `json:"preferredLabels"`
+}
+
+// DatabaseEncryption database at-rest encryption configuration.
+type DatabaseEncryption struct {
+	Enabled bool `json:"enabled"`
+	// CertType IAM certificate type which key wraps the database encryption key.
+	CertType string `json:"certType"`
+	// RuntimeDir non persistent dir of the decrypted database.
+	RuntimeDir string `json:"runtimeDir"`
+	// SyncPeriod period of the database encryption if it is changed. Changes committed after the last sync are lost on
+	// power loss, the database is also encrypted on shutdown.
+	SyncPeriod aostypes.Duration `json:"syncPeriod"`
+}
+
+// DatabaseMaintenance database maintenance configuration.
+type DatabaseMaintenance struct {
+	VacuumPeriod aostypes.Duration `json:"vacuumPeriod"`
+	// MaxSize database size budget in bytes, history records are pruned to keep database within it.
+	MaxSize uint64 `json:"maxSize"`
+}
+
+// RetentionPolicy retention policy of history data class.
+type RetentionPolicy struct {
+	// MaxAge records older than max age are removed, records don't expire if zero.
+	MaxAge aostypes.Duration `json:"maxAge"`
+	// MaxRecords max number of kept records, the oldest records are removed first. Not limited if zero.
+	MaxRecords int `json:"maxRecords"`
+	// Priority classes with lower priority are pruned first when database exceeds its size budget.
+	Priority int `json:"priority"`
+}
+
+// Retention history data retention configuration.
+type Retention struct {
+	// PrunePeriod period of applying retention policies to the database.
+	PrunePeriod aostypes.Duration `json:"prunePeriod"`
+	// Policies retention policies by data class. Policies which are not set are derived from legacy per-module
+	// settings.
+	Policies map[string]RetentionPolicy `json:"policies"`
+}
+
+// BandwidthBudget traffic budget of metered connection shared by downloader, log uploads and monitoring.
+type BandwidthBudget struct {
+	// DailyLimit daily traffic limit in bytes. Not limited if zero.
+	DailyLimit uint64 `json:"dailyLimit"`
+	// MonthlyLimit monthly traffic limit in bytes. Not limited if zero.
+	MonthlyLimit uint64 `json:"monthlyLimit"`
+	// BillingDay day of month the monthly budget is reset on.
+	BillingDay int `json:"billingDay"`
+	// DeferThreshold budget usage percent at which non-critical traffic is deferred.
+	DeferThreshold int `json:"deferThreshold"`
+}
+
+// CircuitBreakerPolicy outbound calls policy of the endpoint.
+type CircuitBreakerPolicy struct {
+	// RateLimit max calls per second. Not limited if zero.
+	RateLimit float64 `json:"rateLimit"`
+	// Burst max number of calls allowed at once above the rate limit.
+	Burst int `json:"burst"`
+	// FailureThreshold number of consecutive failures which opens the circuit. The circuit is never opened if zero.
+	FailureThreshold int `json:"failureThreshold"`
+	// OpenTimeout time the circuit stays open before a trial call is allowed.
+	OpenTimeout aostypes.Duration `json:"openTimeout"`
+}
+
+// CircuitBreaker outbound calls circuit breaker and rate limit configuration.
+type CircuitBreaker struct {
+	// Default policy of endpoints which are not set in policies.
+	Default CircuitBreakerPolicy `json:"default"`
+	// Policies policies by endpoint: cloud, download and iam.
+	Policies map[string]CircuitBreakerPolicy `json:"policies"`
+}
+
+// QuotaEnforcement instance quota enforcement configuration.
+type QuotaEnforcement struct {
+	Enabled bool `json:"enabled"`
+	// Window period of continuous quota exceeding after which action is applied.
+	Window aostypes.Duration `json:"window"`
+	// Action default action: alert, throttle, restart or stop.
+	Action string `json:"action"`
+	// Actions actions by quota parameter, e.g. cpu or ram.
+	Actions map[string]string `json:"actions,omitempty"`
+	// ThrottleDuration period the throttled instance is suspended for.
+	ThrottleDuration aostypes.Duration `json:"throttleDuration"`
+}
+
+// NodeReboot node reboot orchestration configuration.
+type NodeReboot struct {
+	// Command reboot command, the node ID is passed as the last argument.
+	Command []string `json:"command,omitempty"`
+	// DrainTimeout time given to instances to move from the node before reboot.
+	DrainTimeout aostypes.Duration `json:"drainTimeout"`
+	// RebootTimeout max time to wait for SM of rebooted node to reconnect.
+	RebootTimeout aostypes.Duration `json:"rebootTimeout"`
+}
+
+// Standby warm standby failover configuration.
+type Standby struct {
+	// Role CM role: primary or standby, failover is disabled if not set.
+	Role string `json:"role,omitempty"`
+	// ReplicationURL address of primary replication server: primary listens on it, standby connects to it.
+	ReplicationURL string `json:"replicationUrl,omitempty"`
+	// TLS enables HTTPS with client certificate verification.
+	TLS bool `json:"tls"`
+	// CertType IAM certificate type of replication channel, certStorage is used if not set.
+	CertType string `json:"certType,omitempty"`
+	// SyncPeriod period of incremental state replication and primary liveness check.
+	SyncPeriod aostypes.Duration `json:"syncPeriod"`
+	// SnapshotPeriod minimal period of full database snapshot replication, changes between snapshots are replicated
+	// incrementally.
+	SnapshotPeriod aostypes.Duration `json:"snapshotPeriod"`
+	// FailoverTimeout time after last successful replication when standby takes over. It is also the lease granted by
+	// primary to standby: standby doesn't take over till the lease expires.
+	FailoverTimeout aostypes.Duration `json:"failoverTimeout"`
+}
+
+// StateStream state change stream configuration.
+type StateStream struct {
+	Enabled bool `json:"enabled"`
+	// HistorySize number of last changes kept to let subscribers resume from the last received change.
+	HistorySize int `json:"historySize"`
+}
+
+// Advisories vulnerability advisories matching configuration.
+type Advisories struct {
+	// CheckPeriod period of matching advisories against installed services, layers and components.
+	CheckPeriod aostypes.Duration `json:"checkPeriod"`
+	// MinSeverity min severity of advisories which raise alerts: low, medium, high or critical.
+	MinSeverity string `json:"minSeverity"`
+}
+
+// StorageQuota storage and state quota enforcement configuration.
+type StorageQuota struct {
+	// AlertThresholds quota usage percents at which alerts are raised.
+	AlertThresholds []int `json:"alertThresholds"`
+	// Action performed when quota is exceeded: alert, block or evict. Evict stops the instance till next desired
+	// status, instance data is kept.
+	Action      string            `json:"action"`
+	CheckPeriod aostypes.Duration `json:"checkPeriod"`
+}
+
+// StorageEncryption per-instance storage and state encryption configuration. Existing not encrypted storages and
+// states are migrated into encrypted directories on instance setup.
+type StorageEncryption struct {
+	Enabled  bool   `json:"enabled"`
+	CertType string `json:"certType"`
+}
+
+// SecureWipe secure deletion of removed service data configuration.
+type SecureWipe struct {
+	// Enabled enables shredding of service images, instances storages and states on service removal.
+	Enabled bool `json:"enabled"`
+	// Passes number of times the data is overwritten before removal.
+	Passes int `json:"passes"`
+}
+
+// IAMCache IAM certificates and permissions cache configuration.
+type IAMCache struct {
+	CertTTL                 aostypes.Duration `json:"certTtl"`
+	PermissionsTTL          aostypes.Duration `json:"permissionsTtl"`
+	PermissionsMaxStaleness aostypes.Duration `json:"permissionsMaxStaleness"`
+	CheckPeriod             aostypes.Duration `json:"checkPeriod"`
+	RenewBefore             aostypes.Duration `json:"renewBefore"`
+}
+
+// Watchdog watchdog and subsystems health checks configuration.
+type Watchdog struct {
+	CheckPeriod  aostypes.Duration `json:"checkPeriod"`
+	CheckTimeout aostypes.Duration `json:"checkTimeout"`
+	MaxFailures  int               `json:"maxFailures"`
+	ReasonFile   string            `json:"reasonFile"`
+}
+
+// DNSQueryLog DNS server query logging configuration.
+type DNSQueryLog struct {
+	// Enabled enables DNS query logging and per instance resolution statistics.
+	Enabled bool `json:"enabled"`
+	// PollPeriod period of reading DNS query log.
+	PollPeriod aostypes.Duration `json:"pollPeriod"`
+	// TopNames number of most queried names reported per instance.
+	TopNames int `json:"topNames"`
+}
+
+// DNSSecurity DNS server cache poisoning protection configuration.
+type DNSSecurity struct {
+	// DNSSEC enables DNSSEC validation of upstream responses.
+	DNSSEC bool `json:"dnssec"`
+	// TrustAnchors DNSSEC trust anchors in dnsmasq trust-anchor format.
+	TrustAnchors []string `json:"trustAnchors"`
+	// Hardened enables rebind protection and random source ports of upstream queries starting from MinPort.
+	Hardened bool `json:"hardened"`
+	// MinPort lowest source port of upstream queries in hardened mode.
+	MinPort int `json:"minPort"`
+}
+
+// DNSCache DNS server record TTL and negative caching configuration.
+type DNSCache struct {
+	// RecordTTL TTL of generated instance and service discovery records.
+	RecordTTL aostypes.Duration `json:"recordTtl"`
+	// NegativeTTL negative caching duration of upstream replies without SOA record.
+	NegativeTTL aostypes.Duration `json:"negativeTtl"`
+	// NoNegativeCache disables negative caching.
+	NoNegativeCache bool `json:"noNegativeCache"`
+}
+
+// Network network manager configuration.
+type Network struct {
+	// ReservedVlanIDs VLAN IDs reserved for non-Aos traffic, they are never allocated to provider networks.
+	ReservedVlanIDs []uint64 `json:"reservedVlanIds"`
+	// ReservedSubnets CIDR ranges reserved for non-Aos traffic, they are never allocated to networks.
+	ReservedSubnets []string `json:"reservedSubnets"`
+	// Isolation isolation of provider networks by network ID, networks without entry are open without host access.
+	Isolation map[string]NetworkIsolation `json:"isolation"`
+	// ScheduledRules firewall rules active only during timetable windows.
+	ScheduledRules []ScheduledFirewallRule `json:"scheduledRules"`
+	// CNIExport adds CNI configuration of instance network to run request.
+	CNIExport bool `json:"cniExport"`
+	// MaxMirrorDuration maximum duration of instance traffic mirror.
+	MaxMirrorDuration aostypes.Duration `json:"maxMirrorDuration"`
+	// IPAllocation instance IP allocation of provider networks by network ID.
+	IPAllocation map[string]IPAllocation `json:"ipAllocation"`
+}
+
+// IPAllocation instance IP allocation of provider network.
+type IPAllocation struct {
+	// Strategy IP allocation strategy: sequential - the lowest free IP, random - random free IP.
+	Strategy string `json:"strategy"`
+	// StickyRanges subnet IP ranges assigned only to instances of the range services.
+	StickyRanges []StickyIPRange `json:"stickyRanges"`
+}
+
+// StickyIPRange range of subnet IPs reserved for instances of the services. The range is set by host offsets in the
+// network subnet, as the subnet is allocated dynamically.
+type StickyIPRange struct {
+	ServiceIDs []string `json:"serviceIds"`
+	First      uint32   `json:"first"`
+	Last       uint32   `json:"last"`
+}
+
+// ScheduledFirewallRule firewall rule of provider network which is active only during timetable windows, e.g.
+// diagnostics port open during maintenance.
+type ScheduledFirewallRule struct {
+	NetworkID string                         `json:"networkId"`
+	SrcIP     string                         `json:"srcIp"`
+	DstPort   string                         `json:"dstPort"`
+	Proto     string                         `json:"proto"`
+	Timetable []cloudprotocol.TimetableEntry `json:"timetable"`
+}
+
+// NetworkIsolation provider network isolation.
+type NetworkIsolation struct {
+	// Level isolation level: open - instances of the network can talk to each other, strict - only connections
+	// allowed by explicit rules are permitted.
+	Level string `json:"level"`
+	// HostAccess permits instances of the network to access the node.
+	HostAccess bool `json:"hostAccess"`
+}
+
+// InstanceLifecycle instance lifecycle events configuration.
+type InstanceLifecycle struct {
+	// SendPeriod period of sending batched lifecycle events to the cloud.
+	SendPeriod aostypes.Duration `json:"sendPeriod"`
+	// MaxEvents max number of stored events, oldest exported events are removed. Used if instanceLifecycle retention
+	// policy is not set.
+	MaxEvents int `json:"maxEvents"`
+}
+
+// HealthChecks service health checks configuration.
+type HealthChecks struct {
+	// StartupTimeout time to wait for health of started instances before run status is sent. Instances which are not
+	// healthy within the timeout are reported as failed.
+	StartupTimeout aostypes.Duration `json:"startupTimeout"`
+	// RestartPolicy policy of unhealthy instances: never - report only, onFailure - restart instances of the node.
+	RestartPolicy string `json:"restartPolicy"`
+	// MaxRestarts max number of restarts of unhealthy instance till it becomes healthy.
+	MaxRestarts int `json:"maxRestarts"`
+}
+
+// UpdateWindow timetable update window admission configuration.
+type UpdateWindow struct {
+	// Admission enables deferring of timetable updates which are estimated to not fit remaining update window time.
+	Admission bool `json:"admission"`
+	// DefaultLinkSpeed link speed in bytes per second used to estimate download time till it is measured.
+	DefaultLinkSpeed uint64 `json:"defaultLinkSpeed"`
+	// DefaultInstallTime estimated install time of a component, service or layer.
+	DefaultInstallTime aostypes.Duration `json:"defaultInstallTime"`
+}
+
+// EmergencyUpdate emergency update channel configuration.
+type EmergencyUpdate struct {
+	// Enabled enables processing of emergency component updates received from the cloud.
+	Enabled bool `json:"enabled"`
+	// IgnoreTimetable allows emergency updates to be executed immediately regardless of timetable schedule.
+	IgnoreTimetable bool `json:"ignoreTimetable"`
+	// SignerEKU OID of extended key usage the authorization signing certificate should have.
+	SignerEKU string `json:"signerEku"`
+}
+
+// OverrideBundle signed local override bundle configuration.
+type OverrideBundle struct {
+	// Enabled enables processing of override bundles from local media.
+	Enabled bool `json:"enabled"`
+	// MediaDirs absolute paths of local media mount points bundles and their artifacts are accepted from.
+	MediaDirs []string `json:"mediaDirs"`
+}
+
+// OwnerChange owner change configuration.
+type OwnerChange struct {
+	// MaxValidity maximum time from now till request valid until time, requests valid longer are rejected.
+	MaxValidity aostypes.Duration `json:"maxValidity"`
+}
+
+// LogCollector cloud requested log collection configuration.
+type LogCollector struct {
+	// CollectTimeout time to wait for node logs, nodes which don't provide the log in time are reported as absent.
+	CollectTimeout aostypes.Duration `json:"collectTimeout"`
+	// MaxLogSize maximum size of filtered node log if it is not set in the request.
+	MaxLogSize uint64 `json:"maxLogSize"`
+	// ChunkSize maximum size of log part sent to the cloud.
+	ChunkSize uint64 `json:"chunkSize"`
+	// RetentionTime time to keep uploaded logs to resend parts requested by the cloud.
+	RetentionTime aostypes.Duration `json:"retentionTime"`
+	// MaxRetainedSize maximum total size of uploaded logs kept to resend parts, the oldest logs are dropped first.
+	MaxRetainedSize uint64 `json:"maxRetainedSize"`
+	// Compression log upload compression: gzip (default), zstd or none. zstd should be enabled only if the cloud
+	// supports it.
+	Compression string `json:"compression"`
+}
+
+// OfflineQueueCategory offline queue parameters of cloud messages category.
+type OfflineQueueCategory struct {
+	// TTL time after which not delivered message is dropped, messages don't expire if zero.
+	TTL aostypes.Duration `json:"ttl"`
+	// MaxCount max number of messages kept while offline, the oldest messages are dropped first. If zero, messages
+	// of the category are not buffered by the offline queue.
+	MaxCount int `json:"maxCount"`
+}
+
+// OfflineQueue queue of cloud messages sent while the unit is offline.
+type OfflineQueue struct {
+	Alerts     OfflineQueueCategory `json:"alerts"`
+	Statuses   OfflineQueueCategory `json:"statuses"`
+	Monitoring OfflineQueueCategory `json:"monitoring"`
+	Logs       OfflineQueueCategory `json:"logs"`
+}
+
+// FileServer file server configuration.
+type FileServer struct {
+	// TLS enables HTTPS with client certificate verification.
+	TLS bool `json:"tls"`
+	// CertType IAM certificate type of the server certificate, certStorage is used if not set.
+	CertType string `json:"certType"`
+	// URLTTL validity period of signed node URLs, URLs are not signed if zero. It should cover nodes connection
+	// timeout, which limits time given to nodes to download images and report run status.
+	URLTTL aostypes.Duration `json:"urlTtl"`
+	// URLKeyFile file to persist URL signing key, so signed URLs survive CM restart.
+	URLKeyFile string `json:"urlKeyFile"`
+	// MaxConcurrentTransfers max number of concurrent transfers, unlimited if zero.
+	MaxConcurrentTransfers int `json:"maxConcurrentTransfers"`
+	// MaxBandwidth max total transfer rate in bytes per second, unlimited if zero.
+	MaxBandwidth uint64 `json:"maxBandwidth"`
+}
+
+// CMServerAuthorization CM server authorization configuration.
+type CMServerAuthorization struct {
+	// Enabled enables per method authorization of clients by their certificates (default). If disabled, any client with
+	// Aos certificate is accepted and methods which require service role are refused.
+	Enabled bool `json:"enabled"`
+	// Roles roles granted to client identities (certificate common names) in addition to certificate roles.
+	Roles map[string][]string `json:"roles"`
 }
 
 // Config instance.
 type Config struct {
-	Crypt                 Crypt             `json:"fcrypt"`
-	CertStorage           string            `json:"certStorage"`
-	ServiceDiscoveryURL   string            `json:"serviceDiscoveryUrl"`
-	IAMProtectedServerURL string            `json:"iamProtectedServerUrl"`
-	IAMPublicServerURL    string            `json:"iamPublicServerUrl"`
-	CMServerURL           string            `json:"cmServerUrl"`
-	Downloader            Downloader        `json:"downloader"`
-	StorageDir            string            `json:"storageDir"`
-	StateDir              string            `json:"stateDir"`
-	WorkingDir            string            `json:"workingDir"`
-	ImageStoreDir         string            `json:"imageStoreDir"`
-	ComponentsDir         string            `json:"componentsDir"`
-	UnitConfigFile        string            `json:"unitConfigFile"`
-	ServiceTTL            aostypes.Duration `json:"serviceTtlDays"`
-	LayerTTL              aostypes.Duration `json:"layerTtlDays"`
-	UnitStatusSendTimeout aostypes.Duration `json:"unitStatusSendTimeout"`
-	Monitoring            Monitoring        `json:"monitoring"`
-	Alerts                Alerts            `json:"alerts"`
-	Migration             Migration         `json:"migration"`
-	SMController          SMController      `json:"smController"`
-	UMController          UMController      `json:"umController"`
-	DNSIP                 string            `json:"dnsIp"`
+	Crypt                 Crypt                 `json:"fcrypt"`
+	CertStorage           string                `json:"certStorage"`
+	ServiceDiscoveryURL   string                `json:"serviceDiscoveryUrl"`
+	IAMProtectedServerURL string                `json:"iamProtectedServerUrl"`
+	IAMPublicServerURL    string                `json:"iamPublicServerUrl"`
+	CMServerURL           string                `json:"cmServerUrl"`
+	CMServerAuthorization CMServerAuthorization `json:"cmServerAuthorization"`
+	Downloader            Downloader            `json:"downloader"`
+	StorageDir            string                `json:"storageDir"`
+	StateDir              string                `json:"stateDir"`
+	StorageQuota          StorageQuota          `json:"storageQuota"`
+	StateVerifyPeriod     aostypes.Duration     `json:"stateVerifyPeriod"`
+	StateSnapshots        int                   `json:"stateSnapshots"`
+	StorageEncryption     StorageEncryption     `json:"storageEncryption"`
+	SecureWipe            SecureWipe            `json:"secureWipe"`
+	WorkingDir            string                `json:"workingDir"`
+	ImageStoreDir         string                `json:"imageStoreDir"`
+	ComponentsDir         string                `json:"componentsDir"`
+	UnitConfigFile        string                `json:"unitConfigFile"`
+	StateBackupDir        string                `json:"stateBackupDir"`
+	UnitConfigTimeout     aostypes.Duration     `json:"unitConfigTimeout"`
+	NodeConfigInheritance bool                  `json:"nodeConfigInheritance"`
+	ServiceTTL            aostypes.Duration     `json:"serviceTtlDays"`
+	LayerTTL              aostypes.Duration     `json:"layerTtlDays"`
+	UnitStatusSendTimeout aostypes.Duration     `json:"unitStatusSendTimeout"`
+	ShutdownTimeout       aostypes.Duration     `json:"shutdownTimeout"`
+	Monitoring            Monitoring            `json:"monitoring"`
+	AdaptiveTelemetry     AdaptiveTelemetry     `json:"adaptiveTelemetry"`
+	Alerts                Alerts                `json:"alerts"`
+	Migration             Migration             `json:"migration"`
+	DatabaseEncryption    DatabaseEncryption    `json:"databaseEncryption"`
+	DatabaseMaintenance   DatabaseMaintenance   `json:"databaseMaintenance"`
+	Retention             Retention             `json:"retention"`
+	BandwidthBudget       BandwidthBudget       `json:"bandwidthBudget"`
+	CircuitBreaker        CircuitBreaker        `json:"circuitBreaker"`
+	Advisories            Advisories            `json:"advisories"`
+	QuotaEnforcement      QuotaEnforcement      `json:"quotaEnforcement"`
+	NodeReboot            NodeReboot            `json:"nodeReboot"`
+	Standby               Standby               `json:"standby"`
+	StateStream           StateStream           `json:"stateStream"`
+	SMController          SMController          `json:"smController"`
+	Balancing             Balancing             `json:"balancing"`
+	InstanceLifecycle     InstanceLifecycle     `json:"instanceLifecycle"`
+	HealthChecks          HealthChecks          `json:"healthChecks"`
+	UMController          UMController          `json:"umController"`
+	UpdateWindow          UpdateWindow          `json:"updateWindow"`
+	EmergencyUpdate       EmergencyUpdate       `json:"emergencyUpdate"`
+	OverrideBundle        OverrideBundle        `json:"overrideBundle"`
+	OwnerChange           OwnerChange           `json:"ownerChange"`
+	LogCollector          LogCollector          `json:"logCollector"`
+	OfflineQueue          OfflineQueue          `json:"offlineQueue"`
+	FileServer            FileServer            `json:"fileServer"`
+	DNSIP                 string                `json:"dnsIp"`
+	DNSQueryLog           DNSQueryLog           `json:"dnsQueryLog"`
+	DNSSecurity           DNSSecurity           `json:"dnsSecurity"`
+	DNSCache              DNSCache              `json:"dnsCache"`
+	Network               Network               `json:"network"`
+	LogLevel              string                `json:"logLevel"`
+	ModuleLogLevels       map[string]string     `json:"moduleLogLevels,omitempty"`
+	LogFormat             string                `json:"logFormat"`
+	SecretCacheTTL        aostypes.Duration     `json:"secretCacheTtl"`
+	IAMCache              IAMCache              `json:"iamCache"`
+	Watchdog              Watchdog              `json:"watchdog"`
+	// FeatureFlags default state of feature flags. Flags which are not set are derived from related module settings.
+	FeatureFlags map[string]bool `json:"featureFlags,omitempty"`
 }
 
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
 
-// New creates new config object.
-func New(fileName string) (config *Config, err error) {
+// New creates new config object. Config file parameters are overridden by environment variables and then by
+// overrides in "<key>=<value>" format (see Overrides).
+func New(fileName string, overrides ...string) (config *Config, err error) {
 	raw, err := os.ReadFile(fileName)
 	if err != nil {
 		return config, aoserrors.Wrap(err)
 	}
 
-	config = &Config{
+	config = newDefaultConfig()
+
+	if err = json.Unmarshal(raw, &config); err != nil {
+		return config, aoserrors.Wrap(err)
+	}
+
+	if err = applyEnvOverrides(config, os.Environ()); err != nil {
+		return config, err
+	}
+
+	if err = applyOverrides(config, overrides); err != nil {
+		return config, err
+	}
+
+	if err = config.Network.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.Balancing.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.Retention.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.EmergencyUpdate.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.OverrideBundle.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.OwnerChange.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.BandwidthBudget.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.CircuitBreaker.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.Advisories.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.QuotaEnforcement.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.Standby.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.IAMCache.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.FileServer.validate(config.SMController.NodesConnectionTimeout.Duration); err != nil {
+		return config, err
+	}
+
+	if err = config.StateStream.validate(); err != nil {
+		return config, err
+	}
+
+	if err = config.LogCollector.validate(); err != nil {
+		return config, err
+	}
+
+	if err = ValidateFeatureFlags(config.FeatureFlags); err != nil {
+		return config, aoserrors.Errorf("featureFlags: %v", err)
+	}
+
+	if policy := config.SMController.UnreachableNodePolicy; policy != UnreachableNodeWait &&
+		policy != UnreachableNodeProceed {
+		return config, aoserrors.Errorf("smController.unreachableNodePolicy: unsupported policy %s", policy)
+	}
+
+	if compression := config.SMController.Compression; compression != "" && compression != SMCompressionGzip {
+		return config, aoserrors.Errorf("smController.compression: unsupported compression %s", compression)
+	}
+
+	if policy := config.HealthChecks.RestartPolicy; policy != HealthRestartNever &&
+		policy != HealthRestartOnFailure {
+		return config, aoserrors.Errorf("healthChecks.restartPolicy: unsupported policy %s", policy)
+	}
+
+	config.setDefaultPaths()
+
+	return config, nil
+}
+
+// IsMediaPath returns true if path is located on one of override bundle media dirs.
+func (bundle *OverrideBundle) IsMediaPath(filePath string) bool {
+	if !filepath.IsAbs(filePath) {
+		return false
+	}
+
+	filePath = filepath.Clean(filePath)
+
+	for _, mediaDir := range bundle.MediaDirs {
+		if rel, err := filepath.Rel(mediaDir, filePath); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetFeatureFlags returns default state of all feature flags. Flags which are not set in featureFlags section are
+// derived from related module settings: adaptive telemetry is enabled by adaptiveTelemetry section, node scoring is
+// enabled if scoring weights are set and delta unit status is enabled.
+func (config *Config) GetFeatureFlags() map[string]bool {
+	flags := map[string]bool{
+		FeatureAdaptiveTelemetry: config.AdaptiveTelemetry.Enabled,
+		FeatureNodeScoring:       config.Balancing.ScoringWeights != nil,
+		FeatureDeltaUnitStatus:   true,
+	}
+
+	for flag, enabled := range config.FeatureFlags {
+		flags[flag] = enabled
+	}
+
+	return flags
+}
+
+// IsValidSeverity returns true if severity is one of vulnerability advisory severities.
+func IsValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// ValidateFeatureFlags returns error if flags contain unknown feature flag.
+func ValidateFeatureFlags(flags map[string]bool) error {
+	for flag := range flags {
+		switch flag {
+		case FeatureAdaptiveTelemetry, FeatureNodeScoring, FeatureDeltaUnitStatus:
+
+		default:
+			return aoserrors.Errorf("unknown feature flag %s", flag)
+		}
+	}
+
+	return nil
+}
+
+// GetCircuitBreakerPolicy returns circuit breaker policy of the endpoint. Default policy is returned if the endpoint
+// policy is not set.
+func (config *Config) GetCircuitBreakerPolicy(endpoint string) CircuitBreakerPolicy {
+	if policy, ok := config.CircuitBreaker.Policies[endpoint]; ok {
+		return policy
+	}
+
+	return config.CircuitBreaker.Default
+}
+
+// GetRetentionPolicy returns retention policy of the data class. If the policy is not set in retention section, it is
+// derived from legacy per-module settings. False is returned if the data class has no retention policy.
+func (config *Config) GetRetentionPolicy(dataClass string) (policy RetentionPolicy, ok bool) {
+	if policy, ok = config.Retention.Policies[dataClass]; ok {
+		return policy, true
+	}
+
+	switch dataClass {
+	case RetentionMonitoring:
+		if config.Monitoring.HistoryRetention.Duration <= 0 {
+			return RetentionPolicy{}, false
+		}
+
+		return RetentionPolicy{
+			MaxAge: config.Monitoring.HistoryRetention, MaxRecords: config.Monitoring.MaxHistoryRecords,
+		}, true
+
+	case RetentionInstanceLifecycle:
+		return RetentionPolicy{MaxRecords: config.InstanceLifecycle.MaxEvents}, config.InstanceLifecycle.MaxEvents > 0
+
+	case RetentionAlerts:
+		return RetentionPolicy{
+			MaxAge: config.OfflineQueue.Alerts.TTL, MaxRecords: config.OfflineQueue.Alerts.MaxCount,
+		}, config.OfflineQueue.Alerts.MaxCount > 0
+
+	default:
+		return RetentionPolicy{}, false
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newDefaultConfig() *Config {
+	return &Config{
 		ServiceTTL:            aostypes.Duration{Duration: 30 * 24 * time.Hour},
 		LayerTTL:              aostypes.Duration{Duration: 30 * 24 * time.Hour},
 		UnitStatusSendTimeout: aostypes.Duration{Duration: 30 * time.Second},
+		StateVerifyPeriod:     aostypes.Duration{Duration: 1 * time.Hour},
+		StateSnapshots:        3,
+		UnitConfigTimeout:     aostypes.Duration{Duration: 1 * time.Minute},
+		ShutdownTimeout:       aostypes.Duration{Duration: 30 * time.Second},
+		SecretCacheTTL:        aostypes.Duration{Duration: 10 * time.Minute},
+		Crypt:                 Crypt{Provider: "iam"},
+		CMServerAuthorization: CMServerAuthorization{Enabled: true},
+		SecureWipe:            SecureWipe{Passes: 1},
 		Alerts: Alerts{
 			SendPeriod:         aostypes.Duration{Duration: 10 * time.Second},
 			MaxMessageSize:     65536,
@@ -137,6 +952,12 @@ func New(fileName string) (config *Config, err error) {
 			MaxOfflineMessages: 16,
 			SendPeriod:         aostypes.Duration{Duration: 1 * time.Minute},
 			MaxMessageSize:     65536,
+			HistoryRetention:   aostypes.Duration{Duration: 24 * time.Hour},
+			MaxHistoryRecords:  100000,
+		},
+		AdaptiveTelemetry: AdaptiveTelemetry{
+			MaxSendLatency:    aostypes.Duration{Duration: 5 * time.Second},
+			ReducedRateFactor: 4,
 		},
 		Downloader: Downloader{
 			MaxConcurrentDownloads: 4,
@@ -147,14 +968,109 @@ func New(fileName string) (config *Config, err error) {
 		SMController: SMController{
 			NodesConnectionTimeout: aostypes.Duration{Duration: 10 * time.Minute},
 			UpdateTTL:              aostypes.Duration{Duration: 30 * 24 * time.Hour},
+			UnreachableNodePolicy:  UnreachableNodeWait,
+		},
+		InstanceLifecycle: InstanceLifecycle{
+			SendPeriod: aostypes.Duration{Duration: 10 * time.Second},
+			MaxEvents:  10000,
+		},
+		HealthChecks: HealthChecks{
+			StartupTimeout: aostypes.Duration{Duration: 1 * time.Minute},
+			RestartPolicy:  HealthRestartNever,
+			MaxRestarts:    3,
 		},
 		UMController: UMController{UpdateTTL: aostypes.Duration{Duration: 30 * 24 * time.Hour}},
+		UpdateWindow: UpdateWindow{
+			Admission:          true,
+			DefaultLinkSpeed:   1 << 20,
+			DefaultInstallTime: aostypes.Duration{Duration: 1 * time.Minute},
+		},
+		EmergencyUpdate: EmergencyUpdate{IgnoreTimetable: true},
+		OwnerChange:     OwnerChange{MaxValidity: aostypes.Duration{Duration: 24 * time.Hour}},
+		LogCollector: LogCollector{
+			CollectTimeout:  aostypes.Duration{Duration: 5 * time.Minute},
+			MaxLogSize:      16 << 20,
+			ChunkSize:       64 << 10,
+			RetentionTime:   aostypes.Duration{Duration: 1 * time.Hour},
+			MaxRetainedSize: 32 << 20,
+			Compression:     LogCompressionGzip,
+		},
+		CircuitBreaker: CircuitBreaker{Default: CircuitBreakerPolicy{
+			FailureThreshold: 5,
+			OpenTimeout:      aostypes.Duration{Duration: 1 * time.Minute},
+		}},
+		Advisories: Advisories{
+			CheckPeriod: aostypes.Duration{Duration: 1 * time.Hour},
+			MinSeverity: SeverityLow,
+		},
+		QuotaEnforcement: QuotaEnforcement{
+			Window:           aostypes.Duration{Duration: 5 * time.Minute},
+			Action:           QuotaActionAlert,
+			ThrottleDuration: aostypes.Duration{Duration: 5 * time.Minute},
+		},
+		NodeReboot: NodeReboot{
+			DrainTimeout:  aostypes.Duration{Duration: 30 * time.Second},
+			RebootTimeout: aostypes.Duration{Duration: 5 * time.Minute},
+		},
+		Standby: Standby{
+			TLS:             true,
+			SyncPeriod:      aostypes.Duration{Duration: 5 * time.Second},
+			SnapshotPeriod:  aostypes.Duration{Duration: time.Minute},
+			FailoverTimeout: aostypes.Duration{Duration: 30 * time.Second},
+		},
+		StateStream: StateStream{HistorySize: 1024},
+		OfflineQueue: OfflineQueue{
+			Alerts:     OfflineQueueCategory{MaxCount: 256},
+			Statuses:   OfflineQueueCategory{MaxCount: 16},
+			Monitoring: OfflineQueueCategory{TTL: aostypes.Duration{Duration: 5 * time.Minute}, MaxCount: 16},
+			Logs:       OfflineQueueCategory{TTL: aostypes.Duration{Duration: 1 * time.Hour}, MaxCount: 64},
+		},
+		FileServer: FileServer{URLTTL: aostypes.Duration{Duration: 1 * time.Hour}},
+		DNSQueryLog: DNSQueryLog{
+			PollPeriod: aostypes.Duration{Duration: 10 * time.Second},
+			TopNames:   5,
+		},
+		Network: Network{MaxMirrorDuration: aostypes.Duration{Duration: 1 * time.Hour}},
+		DNSSecurity: DNSSecurity{
+			TrustAnchors: []string{
+				".,20326,8,2,E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+				".,38696,8,2,683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+			},
+			MinPort: 1024,
+		},
+		DatabaseEncryption: DatabaseEncryption{
+			CertType:   "offline",
+			RuntimeDir: "/run/aos/communicationmanager",
+			SyncPeriod: aostypes.Duration{Duration: 1 * time.Minute},
+		},
+		DatabaseMaintenance: DatabaseMaintenance{
+			VacuumPeriod: aostypes.Duration{Duration: 24 * time.Hour},
+		},
+		Retention:         Retention{PrunePeriod: aostypes.Duration{Duration: 1 * time.Minute}},
+		BandwidthBudget:   BandwidthBudget{BillingDay: 1, DeferThreshold: 90},
+		StorageEncryption: StorageEncryption{CertType: "offline"},
+		IAMCache: IAMCache{
+			CertTTL:                 aostypes.Duration{Duration: 1 * time.Hour},
+			PermissionsTTL:          aostypes.Duration{Duration: 5 * time.Minute},
+			PermissionsMaxStaleness: aostypes.Duration{Duration: 1 * time.Hour},
+			CheckPeriod:             aostypes.Duration{Duration: 1 * time.Hour},
+			RenewBefore:             aostypes.Duration{Duration: 7 * 24 * time.Hour},
+		},
+		Watchdog: Watchdog{
+			CheckPeriod:  aostypes.Duration{Duration: 10 * time.Second},
+			CheckTimeout: aostypes.Duration{Duration: 5 * time.Second},
+			MaxFailures:  3,
+		},
+		StorageQuota: StorageQuota{
+			AlertThresholds: []int{80, 90, 100},
+			Action:          "block",
+			CheckPeriod:     aostypes.Duration{Duration: 1 * time.Minute},
+		},
 	}
+}
 
-	if err = json.Unmarshal(raw, &config); err != nil {
-		return config, aoserrors.Wrap(err)
-	}
-
+// setDefaultPaths sets paths which are not set in config.
+func (config *Config) setDefaultPaths() {
 	if config.CertStorage == "" {
 		config.CertStorage = "/var/aos/crypt/cm/"
 	}
@@ -183,6 +1099,18 @@ func New(fileName string) (config *Config, err error) {
 		config.UnitConfigFile = path.Join(config.WorkingDir, "aos_unit.cfg")
 	}
 
+	if config.StateBackupDir == "" {
+		config.StateBackupDir = path.Join(config.WorkingDir, "backup")
+	}
+
+	if config.Watchdog.ReasonFile == "" {
+		config.Watchdog.ReasonFile = path.Join(config.WorkingDir, "watchdog_reason")
+	}
+
+	if config.FileServer.URLKeyFile == "" {
+		config.FileServer.URLKeyFile = path.Join(config.WorkingDir, "fileserver_url.key")
+	}
+
 	if config.Migration.MigrationPath == "" {
 		config.Migration.MigrationPath = "/usr/share/aos/communicationmanager/migration"
 	}
@@ -190,6 +1118,357 @@ func New(fileName string) (config *Config, err error) {
 	if config.Migration.MergedMigrationPath == "" {
 		config.Migration.MergedMigrationPath = path.Join(config.WorkingDir, "migration")
 	}
+}
 
-	return config, nil
+func (network *Network) validate() error {
+	for _, vlanID := range network.ReservedVlanIDs {
+		if vlanID == 0 || vlanID > MaxVlanID {
+			return aoserrors.Errorf("network.reservedVlanIds: VLAN ID %d is out of range 1..%d", vlanID, MaxVlanID)
+		}
+	}
+
+	for _, subnet := range network.ReservedSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return aoserrors.Errorf("network.reservedSubnets: invalid subnet %s", subnet)
+		}
+	}
+
+	for networkID, isolation := range network.Isolation {
+		if isolation.Level != "" && isolation.Level != IsolationOpen && isolation.Level != IsolationStrict {
+			return aoserrors.Errorf("network.isolation: wrong level %s of network %s", isolation.Level, networkID)
+		}
+	}
+
+	for i, rule := range network.ScheduledRules {
+		if err := rule.validate(); err != nil {
+			return aoserrors.Errorf("network.scheduledRules[%d]: %v", i, err)
+		}
+	}
+
+	for networkID, allocation := range network.IPAllocation {
+		if err := allocation.validate(); err != nil {
+			return aoserrors.Errorf("network.ipAllocation: network %s: %v", networkID, err)
+		}
+	}
+
+	return nil
+}
+
+func (allocation *IPAllocation) validate() error {
+	if allocation.Strategy != "" && allocation.Strategy != IPAllocationSequential &&
+		allocation.Strategy != IPAllocationRandom {
+		return aoserrors.Errorf("wrong strategy %s", allocation.Strategy)
+	}
+
+	for i, stickyRange := range allocation.StickyRanges {
+		if len(stickyRange.ServiceIDs) == 0 {
+			return aoserrors.Errorf("sticky range %d: service IDs are not set", i)
+		}
+
+		if stickyRange.First == 0 || stickyRange.Last < stickyRange.First {
+			return aoserrors.Errorf("sticky range %d: wrong range %d..%d", i, stickyRange.First, stickyRange.Last)
+		}
+
+		for _, other := range allocation.StickyRanges[:i] {
+			if stickyRange.First <= other.Last && other.First <= stickyRange.Last {
+				return aoserrors.Errorf("sticky range %d: overlaps other range", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (update *EmergencyUpdate) validate() error {
+	if !update.Enabled && update.SignerEKU == "" {
+		return nil
+	}
+
+	if !oidRegexp.MatchString(update.SignerEKU) {
+		return aoserrors.Errorf("emergencyUpdate.signerEku: wrong OID %q", update.SignerEKU)
+	}
+
+	return nil
+}
+
+func (bundle *OverrideBundle) validate() error {
+	if bundle.Enabled && len(bundle.MediaDirs) == 0 {
+		return aoserrors.New("overrideBundle.mediaDirs: media dirs are not set")
+	}
+
+	for _, mediaDir := range bundle.MediaDirs {
+		if !filepath.IsAbs(mediaDir) {
+			return aoserrors.Errorf("overrideBundle.mediaDirs: path %s is not absolute", mediaDir)
+		}
+	}
+
+	return nil
+}
+
+func (ownerChange *OwnerChange) validate() error {
+	if ownerChange.MaxValidity.Duration <= 0 {
+		return aoserrors.New("ownerChange.maxValidity: should be positive")
+	}
+
+	return nil
+}
+
+func (rule *ScheduledFirewallRule) validate() error {
+	if rule.NetworkID == "" {
+		return aoserrors.New("network ID is not set")
+	}
+
+	if rule.DstPort == "" {
+		return aoserrors.New("destination port is not set")
+	}
+
+	if rule.Proto != "tcp" && rule.Proto != "udp" {
+		return aoserrors.Errorf("wrong protocol %s", rule.Proto)
+	}
+
+	return validateTimetable(rule.Timetable)
+}
+
+func validateTimetable(timetable []cloudprotocol.TimetableEntry) error {
+	if len(timetable) == 0 {
+		return aoserrors.New("timetable is empty")
+	}
+
+	for _, entry := range timetable {
+		if entry.DayOfWeek < 1 || entry.DayOfWeek > 7 {
+			return aoserrors.Errorf("wrong day of week %d", entry.DayOfWeek)
+		}
+
+		for _, slot := range entry.TimeSlots {
+			if !slot.Start.Before(slot.End.Time) {
+				return aoserrors.New("time slot start should be before end")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (balancing *Balancing) validate() error {
+	if weights := balancing.ScoringWeights; weights != nil &&
+		(weights.Priority < 0 || weights.Load < 0 || weights.DeviceHeadroom < 0 || weights.LabelPreference < 0) {
+		return aoserrors.New("balancing.scoringWeights: weights should not be negative")
+	}
+
+	for nodeType, capacity := range balancing.ReservedCapacity {
+		for deviceName, count := range capacity.Devices {
+			if count <= 0 {
+				return aoserrors.Errorf("balancing.reservedCapacity.%s: invalid reserved count of device %s",
+					nodeType, deviceName)
+			}
+		}
+	}
+
+	for serviceID, maxUnavailable := range balancing.MaxUnavailable {
+		if maxUnavailable <= 0 {
+			return aoserrors.Errorf("balancing.maxUnavailable.%s: should be positive", serviceID)
+		}
+	}
+
+	for name, group := range balancing.NodeGroups {
+		if !nodeGroupNameRegexp.MatchString(name) || len(name) > maxNodeGroupNameLen {
+			return aoserrors.Errorf("balancing.nodeGroups: invalid group name %s", name)
+		}
+
+		if len(group.Labels) == 0 && len(group.NodeIDs) == 0 {
+			return aoserrors.Errorf("balancing.nodeGroups.%s: labels or node IDs should be set", name)
+		}
+	}
+
+	for _, name := range balancing.RolloutGroups {
+		if _, ok := balancing.NodeGroups[name]; !ok {
+			return aoserrors.Errorf("balancing.rolloutGroups: unknown node group %s", name)
+		}
+	}
+
+	if overcommit := balancing.Overcommit; overcommit != nil &&
+		((overcommit.CPU != 0 && overcommit.CPU < 1) || (overcommit.RAM != 0 && overcommit.RAM < 1)) {
+		return aoserrors.New("balancing.overcommit: ratios should not be less than 1")
+	}
+
+	for serviceID, timetable := range balancing.ActivationSchedules {
+		if err := validateTimetable(timetable); err != nil {
+			return aoserrors.Errorf("balancing.activationSchedules.%s: %v", serviceID, err)
+		}
+	}
+
+	return nil
+}
+
+func (retention *Retention) validate() error {
+	for dataClass, policy := range retention.Policies {
+		switch dataClass {
+		case RetentionMonitoring, RetentionAuditLog, RetentionUpdateHistory, RetentionInstanceLifecycle,
+			RetentionAlerts, RetentionNetworkEvents:
+
+		default:
+			return aoserrors.Errorf("retention.policies: unknown data class %s", dataClass)
+		}
+
+		if policy.MaxAge.Duration < 0 || policy.MaxRecords < 0 {
+			return aoserrors.Errorf("retention.policies: negative limit of data class %s", dataClass)
+		}
+	}
+
+	return nil
+}
+
+func (budget *BandwidthBudget) validate() error {
+	if budget.BillingDay < 1 || budget.BillingDay > maxBillingDay {
+		return aoserrors.Errorf("bandwidthBudget.billingDay: should be in range 1..%d", maxBillingDay)
+	}
+
+	if budget.DeferThreshold < 1 || budget.DeferThreshold > 100 {
+		return aoserrors.New("bandwidthBudget.deferThreshold: should be in range 1..100")
+	}
+
+	return nil
+}
+
+func (breaker *CircuitBreaker) validate() error {
+	if err := breaker.Default.validate(); err != nil {
+		return aoserrors.Errorf("circuitBreaker.default: %v", err)
+	}
+
+	for endpoint, policy := range breaker.Policies {
+		switch endpoint {
+		case CircuitBreakerCloud, CircuitBreakerDownload, CircuitBreakerIAM:
+
+		default:
+			return aoserrors.Errorf("circuitBreaker.policies: unknown endpoint %s", endpoint)
+		}
+
+		if err := policy.validate(); err != nil {
+			return aoserrors.Errorf("circuitBreaker.policies: endpoint %s: %v", endpoint, err)
+		}
+	}
+
+	return nil
+}
+
+func (advisories *Advisories) validate() error {
+	if advisories.CheckPeriod.Duration <= 0 {
+		return aoserrors.New("advisories.checkPeriod: should be positive")
+	}
+
+	if !IsValidSeverity(advisories.MinSeverity) {
+		return aoserrors.Errorf("advisories.minSeverity: unsupported severity %s", advisories.MinSeverity)
+	}
+
+	return nil
+}
+
+func (enforcement *QuotaEnforcement) validate() error {
+	if enforcement.Window.Duration <= 0 {
+		return aoserrors.New("quotaEnforcement.window: should be positive")
+	}
+
+	if enforcement.ThrottleDuration.Duration <= 0 {
+		return aoserrors.New("quotaEnforcement.throttleDuration: should be positive")
+	}
+
+	if !isValidQuotaAction(enforcement.Action) {
+		return aoserrors.Errorf("quotaEnforcement.action: unsupported action %s", enforcement.Action)
+	}
+
+	for parameter, action := range enforcement.Actions {
+		if !isValidQuotaAction(action) {
+			return aoserrors.Errorf("quotaEnforcement.actions.%s: unsupported action %s", parameter, action)
+		}
+	}
+
+	return nil
+}
+
+func (standby *Standby) validate() error {
+	if standby.Role == "" {
+		return nil
+	}
+
+	if standby.Role != StandbyRolePrimary && standby.Role != StandbyRoleStandby {
+		return aoserrors.Errorf("standby.role: unsupported role %s", standby.Role)
+	}
+
+	if standby.ReplicationURL == "" {
+		return aoserrors.New("standby.replicationUrl: should be set")
+	}
+
+	if standby.SyncPeriod.Duration <= 0 {
+		return aoserrors.New("standby.syncPeriod: should be positive")
+	}
+
+	if standby.SnapshotPeriod.Duration < standby.SyncPeriod.Duration {
+		return aoserrors.New("standby.snapshotPeriod: should not be less than sync period")
+	}
+
+	if standby.FailoverTimeout.Duration <= standby.SyncPeriod.Duration {
+		return aoserrors.New("standby.failoverTimeout: should be greater than sync period")
+	}
+
+	return nil
+}
+
+func (fileServer *FileServer) validate(nodesConnectionTimeout time.Duration) error {
+	if fileServer.URLTTL.Duration < 0 {
+		return aoserrors.New("fileServer.urlTtl: should not be negative")
+	}
+
+	if fileServer.URLTTL.Duration != 0 && fileServer.URLTTL.Duration < nodesConnectionTimeout {
+		return aoserrors.New("fileServer.urlTtl: should not be less than nodes connection timeout")
+	}
+
+	return nil
+}
+
+func (cache *IAMCache) validate() error {
+	if cache.PermissionsMaxStaleness.Duration < cache.PermissionsTTL.Duration {
+		return aoserrors.New("iamCache.permissionsMaxStaleness: value is less than permissions TTL")
+	}
+
+	return nil
+}
+
+func (collector *LogCollector) validate() error {
+	switch collector.Compression {
+	case LogCompressionNone, LogCompressionGzip, LogCompressionZstd:
+		return nil
+
+	default:
+		return aoserrors.Errorf("logCollector.compression: unsupported compression %s", collector.Compression)
+	}
+}
+
+func (stream *StateStream) validate() error {
+	if stream.HistorySize <= 0 {
+		return aoserrors.New("stateStream.historySize: should be positive")
+	}
+
+	return nil
+}
+
+func isValidQuotaAction(action string) bool {
+	switch action {
+	case QuotaActionAlert, QuotaActionThrottle, QuotaActionRestart, QuotaActionStop:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (policy *CircuitBreakerPolicy) validate() error {
+	if policy.RateLimit < 0 || policy.Burst < 0 || policy.FailureThreshold < 0 {
+		return aoserrors.New("negative limit")
+	}
+
+	if policy.FailureThreshold > 0 && policy.OpenTimeout.Duration <= 0 {
+		return aoserrors.New("open timeout should be set")
+	}
+
+	return nil
 }
@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	keySeparator    = "."
+	envKeySeparator = "_"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Overrides config parameters set in "<key>=<value>" format. Key is the path of JSON config keys separated by dot,
+// e.g. "downloader.maxConcurrentDownloads=2". Keys are case insensitive. Values of string, boolean and numeric
+// parameters are set as is, durations as duration strings ("10s", "1h") and other parameters as JSON values.
+//
+// Overrides implement flag.Value interface and may be used as repeatable command line flag.
+type Overrides []string
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var errUnknownKey = errors.New("unknown key")
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// String returns overrides as string.
+func (overrides *Overrides) String() string {
+	return strings.Join(*overrides, ",")
+}
+
+// Set adds override. Key and value type are validated against config structure.
+func (overrides *Overrides) Set(value string) error {
+	key, paramValue, ok := strings.Cut(value, "=")
+	if !ok {
+		return aoserrors.Errorf("invalid config override %s: expected <key>=<value>", value)
+	}
+
+	if err := setParam(&Config{}, strings.Split(key, keySeparator), paramValue); err != nil {
+		return aoserrors.Errorf("invalid config override %s: %v", key, err)
+	}
+
+	*overrides = append(*overrides, value)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// applyEnvOverrides applies config parameters set by environment variables. Variable name is EnvPrefix followed by
+// upper case config key path separated by underscore, e.g. AOS_CM_DOWNLOADER_MAXCONCURRENTDOWNLOADS. Variables with
+// unknown keys are ignored as the environment may contain variables of other CM versions or tools.
+func applyEnvOverrides(config *Config, environ []string) error {
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		err := setParam(config, strings.Split(strings.TrimPrefix(name, EnvPrefix), envKeySeparator), value)
+		if errors.Is(err, errUnknownKey) {
+			log.WithField("name", name).Warnf("Ignore config environment variable: %v", err)
+
+			continue
+		}
+
+		if err != nil {
+			return aoserrors.Errorf("invalid config environment variable %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyOverrides(config *Config, overrides Overrides) error {
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return aoserrors.Errorf("invalid config override %s: expected <key>=<value>", override)
+		}
+
+		if err := setParam(config, strings.Split(key, keySeparator), value); err != nil {
+			return aoserrors.Errorf("invalid config override %s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+func setParam(config *Config, keys []string, value string) error {
+	field := reflect.ValueOf(config).Elem()
+
+	for _, key := range keys {
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+
+			field = field.Elem()
+		}
+
+		if field.Kind() != reflect.Struct {
+			return aoserrors.Errorf("%w %s", errUnknownKey, key)
+		}
+
+		index := findFieldIndex(field.Type(), key)
+		if index < 0 {
+			return aoserrors.Errorf("%w %s", errUnknownKey, key)
+		}
+
+		field = field.Field(index)
+	}
+
+	return setValue(field, value)
+}
+
+// findFieldIndex returns index of struct field with JSON name matching the key or -1 if there is no such field.
+func findFieldIndex(structType reflect.Type, key string) int {
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(fieldType.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = fieldType.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func setValue(field reflect.Value, value string) error {
+	if _, ok := field.Addr().Interface().(json.Unmarshaler); ok && field.Kind() == reflect.Struct {
+		// Types with custom unmarshalling (e.g. durations) accept either JSON or plain string value.
+		if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err == nil {
+			return nil
+		}
+
+		return aoserrors.Wrap(json.Unmarshal([]byte(strconv.Quote(value)), field.Addr().Interface()))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return aoserrors.Errorf("expected boolean value: %s", value)
+		}
+
+		field.SetBool(boolValue)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intValue, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return aoserrors.Errorf("expected integer value: %s", value)
+		}
+
+		field.SetInt(intValue)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintValue, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return aoserrors.Errorf("expected unsigned integer value: %s", value)
+		}
+
+		field.SetUint(uintValue)
+
+	case reflect.Float32, reflect.Float64:
+		floatValue, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return aoserrors.Errorf("expected float value: %s", value)
+		}
+
+		field.SetFloat(floatValue)
+
+	default:
+		newValue := reflect.New(field.Type())
+
+		if err := json.Unmarshal([]byte(value), newValue.Interface()); err != nil {
+			return aoserrors.Errorf("expected JSON %s value: %s", field.Type(), value)
+		}
+
+		field.Set(newValue.Elem())
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ReloadConsumer config reload consumer interface.
+type ReloadConsumer interface {
+	ConfigReloaded(config *Config)
+}
+
+// Reloader reloads config file and notifies consumers about changed parameters which can be applied at runtime:
+// log level, telemetry send periods, download limits, timeouts and service discovery URL. Other parameters require
+// restart. Secret references are resolved on each reload, so rotated secrets of runtime parameters are applied.
+type Reloader struct {
+	sync.Mutex
+
+	fileName  string
+	overrides []string
+	resolver  *SecretResolver
+	config    *Config
+	consumers []ReloadConsumer
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewReloader creates config reloader. Overrides are applied on each reload the same way as by New. If secret
+// resolver is set, secret references are resolved in the reloaded config.
+func NewReloader(fileName string, config *Config, resolver *SecretResolver, overrides ...string) *Reloader {
+	return &Reloader{fileName: fileName, overrides: overrides, resolver: resolver, config: config}
+}
+
+// ResolveSecrets resolves secret references of the current config with currently registered secret providers.
+func (reloader *Reloader) ResolveSecrets() (*Config, error) {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	if reloader.resolver == nil {
+		return reloader.config, nil
+	}
+
+	config, err := reloader.resolver.Resolve(reloader.config)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader.config = config
+
+	return config, nil
+}
+
+// SubscribeForConfigReload subscribes for config reload.
+func (reloader *Reloader) SubscribeForConfigReload(consumer ReloadConsumer) error {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	if slices.Contains(reloader.consumers, consumer) {
+		return aoserrors.New("already subscribed")
+	}
+
+	reloader.consumers = append(reloader.consumers, consumer)
+
+	return nil
+}
+
+// UnsubscribeFromConfigReload unsubscribes from config reload.
+func (reloader *Reloader) UnsubscribeFromConfigReload(consumer ReloadConsumer) error {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	index := slices.Index(reloader.consumers, consumer)
+	if index < 0 {
+		return aoserrors.New("not subscribed")
+	}
+
+	reloader.consumers = slices.Delete(reloader.consumers, index, index+1)
+
+	return nil
+}
+
+// Reload reads config file and applies runtime parameters. Returns true if any parameter is changed.
+func (reloader *Reloader) Reload() (changed bool, err error) {
+	reloader.Lock()
+	defer reloader.Unlock()
+
+	log.WithField("configFile", reloader.fileName).Info("Reload config")
+
+	newConfig, err := New(reloader.fileName, reloader.overrides...)
+	if err != nil {
+		return false, err
+	}
+
+	if reloader.resolver != nil {
+		if newConfig, err = reloader.resolver.Resolve(newConfig); err != nil {
+			return false, err
+		}
+
+		if err = CheckSecretsResolved(newConfig); err != nil {
+			return false, err
+		}
+	}
+
+	if newConfig.LogLevel != "" {
+		if _, err = log.ParseLevel(newConfig.LogLevel); err != nil {
+			return false, aoserrors.Wrap(err)
+		}
+	}
+
+	for module, level := range newConfig.ModuleLogLevels {
+		if _, err = log.ParseLevel(level); err != nil {
+			return false, aoserrors.Errorf("wrong %s module log level: %v", module, err)
+		}
+	}
+
+	restartConfig := *newConfig
+
+	applyRuntimeParams(&restartConfig, reloader.config)
+
+	if !reflect.DeepEqual(&restartConfig, reloader.config) {
+		log.Warn("Changed config parameters which require restart are ignored")
+	}
+
+	config := *reloader.config
+
+	applyRuntimeParams(&config, newConfig)
+
+	if reflect.DeepEqual(&config, reloader.config) {
+		return false, nil
+	}
+
+	reloader.config = &config
+
+	for _, consumer := range reloader.consumers {
+		consumer.ConfigReloaded(reloader.config)
+	}
+
+	return true, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// applyRuntimeParams copies parameters which can be changed at runtime.
+func applyRuntimeParams(dst, src *Config) {
+	dst.LogLevel = src.LogLevel
+	dst.ModuleLogLevels = src.ModuleLogLevels
+	dst.UnitStatusSendTimeout = src.UnitStatusSendTimeout
+	dst.UnitConfigTimeout = src.UnitConfigTimeout
+	dst.Monitoring.SendPeriod = src.Monitoring.SendPeriod
+	dst.Alerts.SendPeriod = src.Alerts.SendPeriod
+	dst.Downloader.MaxConcurrentDownloads = src.Downloader.MaxConcurrentDownloads
+	dst.Downloader.RetryDelay = src.Downloader.RetryDelay
+	dst.Downloader.MaxRetryDelay = src.Downloader.MaxRetryDelay
+	dst.ServiceDiscoveryURL = src.ServiceDiscoveryURL
+}
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Secret providers.
+const (
+	FileSecretProvider = "file"
+	EnvSecretProvider  = "env"
+	IAMSecretProvider  = "iam"
+)
+
+const (
+	certURLSecret = "certUrl"
+	keyURLSecret  = "keyUrl"
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var secretReferenceRegexp = regexp.MustCompile(`\$\{([a-z]+):([^}]+)\}`)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SecretProvider provides secret value by name.
+type SecretProvider interface {
+	GetSecret(name string) (value string, err error)
+}
+
+// CertificateProvider certificate provider interface.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+}
+
+// SecretResolver resolves secret references in config string values. Reference has "${<provider>:<name>}" format
+// and may be a part of the value, e.g. "amqps://user:${file:/run/secrets/amqp}@host". Supported providers:
+//   - file: trimmed content of the file with the given path;
+//   - env: value of the environment variable with the given name;
+//   - iam: IAM certificate or key URL, name has "<certType>/certUrl" or "<certType>/keyUrl" format.
+//
+// Resolved secrets are cached for cache TTL, after that they are requested from the provider again, so rotated
+// secrets are picked up on the next config reload.
+type SecretResolver struct {
+	sync.Mutex
+
+	cacheTTL  time.Duration
+	providers map[string]SecretProvider
+	cache     map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+type fileSecretProvider struct{}
+
+type envSecretProvider struct{}
+
+type certSecretProvider struct {
+	certProvider CertificateProvider
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewSecretResolver creates secret resolver with file and env providers.
+func NewSecretResolver(cacheTTL time.Duration) *SecretResolver {
+	return &SecretResolver{
+		cacheTTL: cacheTTL,
+		providers: map[string]SecretProvider{
+			FileSecretProvider: &fileSecretProvider{},
+			EnvSecretProvider:  &envSecretProvider{},
+		},
+		cache: make(map[string]cachedSecret),
+	}
+}
+
+// NewCertSecretProvider creates provider of IAM certificate and key URLs.
+func NewCertSecretProvider(certProvider CertificateProvider) SecretProvider {
+	return &certSecretProvider{certProvider: certProvider}
+}
+
+// RegisterProvider registers secret provider.
+func (resolver *SecretResolver) RegisterProvider(name string, provider SecretProvider) error {
+	resolver.Lock()
+	defer resolver.Unlock()
+
+	if _, ok := resolver.providers[name]; ok {
+		return aoserrors.Errorf("secret provider %s already registered", name)
+	}
+
+	resolver.providers[name] = provider
+
+	return nil
+}
+
+// Resolve returns copy of config with resolved secret references. References to not registered providers are kept.
+func (resolver *SecretResolver) Resolve(config *Config) (*Config, error) {
+	resolver.Lock()
+	defer resolver.Unlock()
+
+	resolvedConfig, err := copyConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = walkStrings(reflect.ValueOf(resolvedConfig).Elem(), resolver.resolveValue); err != nil {
+		return nil, err
+	}
+
+	return resolvedConfig, nil
+}
+
+// HasReferences returns true if any secret was resolved by the resolver.
+func (resolver *SecretResolver) HasReferences() bool {
+	resolver.Lock()
+	defer resolver.Unlock()
+
+	return len(resolver.cache) != 0
+}
+
+// CheckSecretsResolved returns error if config contains not resolved secret references.
+func CheckSecretsResolved(config *Config) error {
+	var references []string
+
+	_ = walkStrings(reflect.ValueOf(config).Elem(), func(value string) (string, error) {
+		references = append(references, secretReferenceRegexp.FindAllString(value, -1)...)
+
+		return value, nil
+	})
+
+	if len(references) != 0 {
+		return aoserrors.Errorf("not resolved secret references: %s", strings.Join(references, ", "))
+	}
+
+	return nil
+}
+
+// GetSecret returns trimmed file content.
+func (provider *fileSecretProvider) GetSecret(name string) (value string, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetSecret returns environment variable value.
+func (provider *envSecretProvider) GetSecret(name string) (value string, err error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", aoserrors.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
+
+// GetSecret returns certificate or key URL.
+func (provider *certSecretProvider) GetSecret(name string) (value string, err error) {
+	certType, urlType, ok := strings.Cut(name, "/")
+	if !ok || (urlType != certURLSecret && urlType != keyURLSecret) {
+		return "", aoserrors.Errorf("wrong certificate secret name: %s", name)
+	}
+
+	certURL, keyURL, err := provider.certProvider.GetCertificate(certType, nil, "")
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	if urlType == keyURLSecret {
+		return keyURL, nil
+	}
+
+	return certURL, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (resolver *SecretResolver) resolveValue(value string) (resolvedValue string, err error) {
+	resolvedValue = secretReferenceRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		if err != nil {
+			return reference
+		}
+
+		var secret string
+
+		secret, err = resolver.getSecret(reference)
+
+		return secret
+	})
+
+	return resolvedValue, err
+}
+
+func (resolver *SecretResolver) getSecret(reference string) (value string, err error) {
+	match := secretReferenceRegexp.FindStringSubmatch(reference)
+
+	provider, ok := resolver.providers[match[1]]
+	if !ok {
+		return reference, nil
+	}
+
+	if cached, ok := resolver.cache[reference]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	if value, err = provider.GetSecret(match[2]); err != nil {
+		return "", aoserrors.Errorf("can't resolve secret %s: %v", reference, err)
+	}
+
+	if cached, ok := resolver.cache[reference]; ok && cached.value != value {
+		log.WithField("secret", reference).Info("Secret rotated")
+	}
+
+	resolver.cache[reference] = cachedSecret{value: value, expiresAt: time.Now().Add(resolver.cacheTTL)}
+
+	return value, nil
+}
+
+func copyConfig(config *Config) (*Config, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	var configCopy Config
+
+	if err = json.Unmarshal(data, &configCopy); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return &configCopy, nil
+}
+
+// walkStrings replaces all string values of struct fields, pointers and slices.
+func walkStrings(value reflect.Value, replace func(value string) (string, error)) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if !value.IsNil() {
+			return walkStrings(value.Elem(), replace)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if !value.Type().Field(i).IsExported() {
+				continue
+			}
+
+			if err := walkStrings(value.Field(i), replace); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if err := walkStrings(value.Index(i), replace); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		replaced, err := replace(value.String())
+		if err != nil {
+			return err
+		}
+
+		value.SetString(replaced)
+
+	default:
+	}
+
+	return nil
+}
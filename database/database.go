@@ -18,12 +18,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
@@ -31,13 +33,20 @@ import (
 	_ "github.com/mattn/go-sqlite3" // ignore lint
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/auditlog"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/lifecycle"
+	"github.com/aosedge/aos_communicationmanager/monitorcontroller"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
+	"github.com/aosedge/aos_communicationmanager/sbom"
+	"github.com/aosedge/aos_communicationmanager/statestream"
 	"github.com/aosedge/aos_communicationmanager/storagestate"
 	"github.com/aosedge/aos_communicationmanager/umcontroller"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
 )
 
 /***********************************************************************************************************************
@@ -50,7 +59,10 @@ const (
 	syncMode    = "NORMAL"
 )
 
-const dbVersion = 4
+const dbVersion = 10
+
+// dbBaseVersion is version of database schema created by create table functions.
+const dbBaseVersion = 8
 
 const dbFileName = "communicationmanager.db"
 
@@ -69,15 +81,30 @@ var (
 
 // Database structure with database information.
 type Database struct {
-	sql *sql.DB
+	sql               *sql.DB
+	fileName          string
+	encryption        *encryptedStorage
+	recoveryReport    *RecoveryReport
+	maintenanceCancel context.CancelFunc
+	maintenanceWG     sync.WaitGroup
+	retention         []retentionClass
+	txMutex           sync.Mutex
+	tx                *sql.Tx
+	txChanges         []stateChange
+	statePublisher    StatePublisher
+	versionMutex      sync.Mutex
+	versionConn       *sql.Conn
 }
 
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
 
-// New creates new database handle.
-func New(config *config.Config) (db *Database, err error) {
+// New creates new database handle. If database encryption is enabled, certificate provider and crypto context are
+// used to get the key which protects the database.
+func New(
+	config *config.Config, certProvider CertificateProvider, cryptoContext CryptoContext,
+) (db *Database, err error) {
 	fileName := filepath.Join(config.WorkingDir, dbFileName)
 
 	log.WithField("fileName", fileName).Debug("Open database")
@@ -91,13 +118,24 @@ func New(config *config.Config) (db *Database, err error) {
 		return db, aoserrors.Wrap(err)
 	}
 
-	sqlite, err := sql.Open("sqlite3", fmt.Sprintf("%s?_busy_timeout=%d&_journal_mode=%s&_sync=%s",
-		fileName, busyTimeout, journalMode, syncMode))
-	if err != nil {
-		return db, aoserrors.Wrap(err)
+	db = &Database{}
+
+	if config.DatabaseEncryption.Enabled {
+		if db.encryption, err = newEncryptedStorage(
+			config.DatabaseEncryption, fileName, certProvider, cryptoContext); err != nil {
+			return nil, err
+		}
+
+		fileName = db.encryption.runtimeFile
 	}
 
-	db = &Database{sqlite}
+	if db.sql, err = openSQLite(fileName); err != nil {
+		if db.encryption != nil {
+			db.encryption.removeRuntimeFiles()
+		}
+
+		return nil, err
+	}
 
 	defer func() {
 		if err != nil {
@@ -105,24 +143,30 @@ func New(config *config.Config) (db *Database, err error) {
 		}
 	}()
 
+	db.fileName = fileName
+
+	if err = db.checkIntegrity(fileName); err != nil {
+		return db, err
+	}
+
 	exists, err := db.isTableExist("config")
 	if err != nil {
 		return db, aoserrors.Wrap(err)
 	}
 
 	if !exists {
-		// Set database version if database not exist
-		if err = migration.SetDatabaseVersion(sqlite, config.Migration.MigrationPath, dbVersion); err != nil {
+		// Set base database version if database not exist, tables added after it are created by migration
+		if err = migration.SetDatabaseVersion(db.sql, config.Migration.MigrationPath, dbBaseVersion); err != nil {
 			return db, aoserrors.Wrap(err)
 		}
 
 		if err := db.createConfigTable(); err != nil {
 			return db, aoserrors.Wrap(err)
 		}
-	} else {
-		if err = migration.DoMigrate(db.sql, config.Migration.MergedMigrationPath, dbVersion); err != nil {
-			return db, aoserrors.Wrap(err)
-		}
+	}
+
+	if err = migration.DoMigrate(db.sql, config.Migration.MergedMigrationPath, dbVersion); err != nil {
+		return db, aoserrors.Wrap(err)
 	}
 
 	if err := db.createDownloadTable(); err != nil {
@@ -153,6 +197,14 @@ func New(config *config.Config) (db *Database, err error) {
 		return db, err
 	}
 
+	if db.encryption != nil {
+		db.encryption.start(db.sql, db.DataVersion)
+	}
+
+	db.retention = getRetentionClasses(config)
+
+	db.startMaintenance(config.DatabaseMaintenance, config.Retention.PrunePeriod.Duration)
+
 	return db, nil
 }
 
@@ -194,7 +246,7 @@ func (db *Database) SetComponentsUpdateInfo(updateInfo []umcontroller.ComponentS
 
 // GetComponentsUpdateInfo returns update data for system components.
 func (db *Database) GetComponentsUpdateInfo() (updateInfo []umcontroller.ComponentStatus, err error) {
-	stmt, err := db.sql.Prepare("SELECT componentsUpdateInfo FROM config")
+	stmt, err := db.executor().Prepare("SELECT componentsUpdateInfo FROM config")
 	if err != nil {
 		return updateInfo, aoserrors.Wrap(err)
 	}
@@ -231,6 +283,8 @@ func (db *Database) SetFirmwareUpdateState(state json.RawMessage) (err error) {
 		return err
 	}
 
+	db.notifyStateChange(statestream.KindFOTAState, statestream.OperationSet, state)
+
 	return nil
 }
 
@@ -253,6 +307,8 @@ func (db *Database) SetSoftwareUpdateState(state json.RawMessage) (err error) {
 		return err
 	}
 
+	db.notifyStateChange(statestream.KindSOTAState, statestream.OperationSet, state)
+
 	return nil
 }
 
@@ -269,6 +325,230 @@ func (db *Database) GetSoftwareUpdateState() (state json.RawMessage, err error)
 	return state, err
 }
 
+// SetMaintenanceMode sets unit maintenance mode state.
+func (db *Database) SetMaintenanceMode(state json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO maintenance (id, state) VALUES (0, ?)`, state); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMaintenanceMode returns unit maintenance mode state. Empty state is returned if it was never set.
+func (db *Database) GetMaintenanceMode() (state json.RawMessage, err error) {
+	if err = db.getDataFromQuery(
+		"SELECT state FROM maintenance WHERE id = 0",
+		[]any{}, &state); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil, nil
+		}
+	}
+
+	return state, err
+}
+
+// SetFeatureFlags sets feature flags set by the cloud.
+func (db *Database) SetFeatureFlags(flags json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO featureflags (id, flags) VALUES (0, ?)`, flags); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetFeatureFlags returns feature flags set by the cloud. Empty flags are returned if they were never set.
+func (db *Database) GetFeatureFlags() (flags json.RawMessage, err error) {
+	if err = db.getDataFromQuery(
+		"SELECT flags FROM featureflags WHERE id = 0",
+		[]any{}, &flags); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil, nil
+		}
+	}
+
+	return flags, err
+}
+
+// SetBandwidthUsage sets bandwidth budget usage.
+func (db *Database) SetBandwidthUsage(usage json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO bandwidthusage (id, usage) VALUES (0, ?)`, usage); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBandwidthUsage returns bandwidth budget usage. Empty usage is returned if it was never set.
+func (db *Database) GetBandwidthUsage() (usage json.RawMessage, err error) {
+	if err = db.getDataFromQuery(
+		"SELECT usage FROM bandwidthusage WHERE id = 0",
+		[]any{}, &usage); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil, nil
+		}
+	}
+
+	return usage, err
+}
+
+// SetAdvisories sets vulnerability advisories state.
+func (db *Database) SetAdvisories(state json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO advisories (id, state) VALUES (0, ?)`, state); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAdvisories returns vulnerability advisories state. Empty state is returned if it was never set.
+func (db *Database) GetAdvisories() (state json.RawMessage, err error) {
+	if err = db.getDataFromQuery(
+		"SELECT state FROM advisories WHERE id = 0",
+		[]any{}, &state); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil, nil
+		}
+	}
+
+	return state, err
+}
+
+// SetNodeReboot sets pending node reboot state.
+func (db *Database) SetNodeReboot(state json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO nodereboot (id, state) VALUES (0, ?)`, state); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNodeReboot returns pending node reboot state. Empty state is returned if it was never set.
+func (db *Database) GetNodeReboot() (state json.RawMessage, err error) {
+	if err = db.getDataFromQuery(
+		"SELECT state FROM nodereboot WHERE id = 0",
+		[]any{}, &state); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil, nil
+		}
+	}
+
+	return state, err
+}
+
+// AddOverrideBundleReport adds override bundle report pending to be sent to the cloud.
+func (db *Database) AddOverrideBundleReport(bundleID string, report json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO overridebundles (bundleId, report) VALUES (?, ?)`,
+		bundleID, report); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetOverrideBundleReports returns override bundle reports pending to be sent to the cloud.
+func (db *Database) GetOverrideBundleReports() (reports []json.RawMessage, err error) {
+	rows, err := db.executor().Query("SELECT report FROM overridebundles ORDER BY rowid")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	for rows.Next() {
+		var report json.RawMessage
+
+		if err = rows.Scan(&report); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// RemoveOverrideBundleReport removes sent override bundle report.
+func (db *Database) RemoveOverrideBundleReport(bundleID string) (err error) {
+	if err = db.executeQuery(
+		"DELETE FROM overridebundles WHERE bundleId = ?", bundleID); errors.Is(err, errNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// SetCampaignSummary stores campaign summary.
+func (db *Database) SetCampaignSummary(campaignID string, summary json.RawMessage) (err error) {
+	if err = db.executeQuery(`INSERT OR REPLACE INTO campaigns (campaignId, summary) VALUES (?, ?)`,
+		campaignID, summary); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetCampaignSummaries returns stored campaign summaries.
+func (db *Database) GetCampaignSummaries() (summaries []json.RawMessage, err error) {
+	rows, err := db.executor().Query("SELECT summary FROM campaigns ORDER BY rowid")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	for rows.Next() {
+		var summary json.RawMessage
+
+		if err = rows.Scan(&summary); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// RemoveCampaignSummary removes campaign summary.
+func (db *Database) RemoveCampaignSummary(campaignID string) (err error) {
+	if err = db.executeQuery(
+		"DELETE FROM campaigns WHERE campaignId = ?", campaignID); errors.Is(err, errNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// AddEmergencyUpdateID stores ID of applied emergency update and removes IDs of expired updates.
+func (db *Database) AddEmergencyUpdateID(updateID string, validUntil time.Time) (err error) {
+	if err = db.executeQuery(
+		"DELETE FROM emergencyupdates WHERE validUntil < ?", time.Now().UnixNano()); err != nil &&
+		!errors.Is(err, errNotExist) {
+		return err
+	}
+
+	return db.executeQuery(`INSERT OR REPLACE INTO emergencyupdates (updateId, validUntil) VALUES (?, ?)`,
+		updateID, validUntil.UnixNano())
+}
+
+// IsEmergencyUpdateIDUsed checks if emergency update with the ID is already applied.
+func (db *Database) IsEmergencyUpdateIDUsed(updateID string) (used bool, err error) {
+	var count int
+
+	if err = db.getDataFromQuery(
+		"SELECT COUNT(*) FROM emergencyupdates WHERE updateId = ?", []any{updateID}, &count); err != nil {
+		return false, err
+	}
+
+	return count != 0, nil
+}
+
 // GetDownloadInfo returns download info by file path.
 func (db *Database) GetDownloadInfo(filePath string) (downloadInfo downloader.DownloadInfo, err error) {
 	if err = db.getDataFromQuery(
@@ -285,7 +565,7 @@ func (db *Database) GetDownloadInfo(filePath string) (downloadInfo downloader.Do
 
 // GetDownloadInfos returns all download info.
 func (db *Database) GetDownloadInfos() (downloadInfos []downloader.DownloadInfo, err error) {
-	rows, err := db.sql.Query("SELECT * FROM download")
+	rows, err := db.executor().Query("SELECT * FROM download")
 	if err != nil {
 		return downloadInfos, aoserrors.Wrap(err)
 	}
@@ -388,10 +668,26 @@ func (db *Database) AddService(service imagemanager.ServiceInfo) error {
 		return aoserrors.Wrap(err)
 	}
 
-	return db.executeQuery("INSERT INTO services values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	healthEndpoints, err := json.Marshal(&service.HealthEndpoints)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	healthChecks, err := json.Marshal(&service.HealthChecks)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	layerMediaTypes, err := json.Marshal(&service.LayerMediaTypes)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return db.executeQuery("INSERT INTO services values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		service.ServiceID, service.Version, service.ProviderID, service.URL, service.RemoteURL,
 		service.Path, service.Size, service.Timestamp, service.State,
-		configJSON, layers, service.Sha256, exposedPorts, service.GID)
+		configJSON, layers, service.Sha256, exposedPorts, service.GID, healthEndpoints, healthChecks,
+		layerMediaTypes, service.SharedNetwork)
 }
 
 // SetServiceState sets service state.
@@ -404,8 +700,13 @@ func (db *Database) SetServiceState(serviceID, version string, state int) (err e
 	return err
 }
 
-// RemoveService removes existing service.
+// RemoveService removes existing service and its SBOM documents.
 func (db *Database) RemoveService(serviceID string, version string) (err error) {
+	if _, err = db.executor().Exec("DELETE FROM sboms WHERE serviceId = ? AND version = ?",
+		serviceID, version); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
 	if err = db.executeQuery("DELETE FROM services WHERE id = ? AND version = ?",
 		serviceID, version); errors.Is(err, errNotExist) {
 		return nil
@@ -414,6 +715,42 @@ func (db *Database) RemoveService(serviceID string, version string) (err error)
 	return err
 }
 
+// AddSBOM adds service SBOM document.
+func (db *Database) AddSBOM(document sbom.Document) (err error) {
+	return db.executeQuery(
+		`INSERT OR REPLACE INTO sboms (serviceId, version, digest, format, mediaType, document)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		document.ServiceID, document.Version, document.Digest, document.Format, document.MediaType,
+		[]byte(document.Document))
+}
+
+// GetSBOMs returns SBOM documents of all stored services.
+func (db *Database) GetSBOMs() (documents []sbom.Document, err error) {
+	rows, err := db.executor().Query(
+		"SELECT serviceId, version, digest, format, mediaType, document FROM sboms ORDER BY serviceId, version, rowid")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	for rows.Next() {
+		var document sbom.Document
+
+		if err = rows.Scan(&document.ServiceID, &document.Version, &document.Digest, &document.Format,
+			&document.MediaType, &document.Document); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
 // GetAllServiceVersions returns all service versions.
 func (db *Database) GetServiceVersions(serviceID string) (services []imagemanager.ServiceInfo, err error) {
 	if services, err = db.getServicesFromQuery(
@@ -479,9 +816,15 @@ func (db *Database) RemoveLayer(digest string) (err error) {
 
 // AddInstance adds instanace info.
 func (db *Database) AddInstance(instanceInfo launcher.InstanceInfo) error {
-	return db.executeQuery("INSERT INTO instances values(?, ?, ?, ?, ?, ?, ?, ?)",
+	if err := db.executeQuery("INSERT INTO instances values(?, ?, ?, ?, ?, ?, ?, ?)",
 		instanceInfo.ServiceID, instanceInfo.SubjectID, instanceInfo.Instance, instanceInfo.NodeID,
-		instanceInfo.PrevNodeID, instanceInfo.UID, instanceInfo.Timestamp, instanceInfo.State)
+		instanceInfo.PrevNodeID, instanceInfo.UID, instanceInfo.Timestamp, instanceInfo.State); err != nil {
+		return err
+	}
+
+	db.notifyStateChange(statestream.KindInstance, statestream.OperationSet, newInstanceState(instanceInfo))
+
+	return nil
 }
 
 // UpdateInstance updates instance info.
@@ -498,6 +841,8 @@ func (db *Database) UpdateInstance(instanceInfo launcher.InstanceInfo) error {
 		return err
 	}
 
+	db.notifyStateChange(statestream.KindInstance, statestream.OperationSet, newInstanceState(instanceInfo))
+
 	return nil
 }
 
@@ -524,7 +869,7 @@ func (db *Database) GetInstance(instance aostypes.InstanceIdent) (launcher.Insta
 
 // GetInstances gets all instances.
 func (db *Database) GetInstances() ([]launcher.InstanceInfo, error) {
-	rows, err := db.sql.Query("SELECT * FROM instances")
+	rows, err := db.executor().Query("SELECT * FROM instances")
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
@@ -553,8 +898,14 @@ func (db *Database) GetInstances() ([]launcher.InstanceInfo, error) {
 
 // RemoveInstance removes existing instance.
 func (db *Database) RemoveInstance(instance aostypes.InstanceIdent) error {
-	return db.executeQuery("DELETE FROM instances WHERE serviceId = ? AND subjectId = ? AND  instance = ?",
-		instance.ServiceID, instance.SubjectID, instance.Instance)
+	if err := db.executeQuery("DELETE FROM instances WHERE serviceId = ? AND subjectId = ? AND  instance = ?",
+		instance.ServiceID, instance.SubjectID, instance.Instance); err != nil {
+		return err
+	}
+
+	db.notifyStateChange(statestream.KindInstance, statestream.OperationRemove, instance)
+
+	return nil
 }
 
 // GetStorageStateInfo returns storage and state info by instance ident.
@@ -578,7 +929,7 @@ func (db *Database) GetStorageStateInfo(
 
 // GetStorageStateInfo returns storage and state infos.
 func (db *Database) GetAllStorageStateInfo() (infos []storagestate.StorageStateInstanceInfo, err error) {
-	rows, err := db.sql.Query("SELECT * FROM storagestate")
+	rows, err := db.executor().Query("SELECT * FROM storagestate")
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
@@ -650,21 +1001,35 @@ func (db *Database) RemoveStorageStateInfo(instanceIdent aostypes.InstanceIdent)
 }
 
 func (db *Database) AddNetworkInfo(networkInfo networkmanager.NetworkParametersStorage) error {
-	return db.executeQuery("INSERT INTO network values(?, ?, ?, ?, ?)",
-		networkInfo.NetworkID, networkInfo.IP, networkInfo.Subnet, networkInfo.VlanID, networkInfo.NodeID)
+	if err := db.executeQuery("INSERT INTO network values(?, ?, ?, ?, ?)",
+		networkInfo.NetworkID, networkInfo.IP, networkInfo.Subnet, networkInfo.VlanID, networkInfo.NodeID); err != nil {
+		return err
+	}
+
+	db.notifyStateChange(statestream.KindNetwork, statestream.OperationSet, newNetworkState(networkInfo))
+
+	return nil
 }
 
 func (db *Database) RemoveNetworkInfo(networkID string, nodeID string) (err error) {
 	if err = db.executeQuery(
-		"DELETE FROM network WHERE networkID = ? AND nodeID = ?", networkID, nodeID); errors.Is(err, errNotExist) {
-		return nil
+		"DELETE FROM network WHERE networkID = ? AND nodeID = ?", networkID, nodeID); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil
+		}
+
+		return err
 	}
 
-	return err
+	db.notifyStateChange(statestream.KindNetwork, statestream.OperationRemove, networkState{
+		NetworkParameters: aostypes.NetworkParameters{NetworkID: networkID}, NodeID: nodeID,
+	})
+
+	return nil
 }
 
 func (db *Database) GetNetworksInfo() ([]networkmanager.NetworkParametersStorage, error) {
-	rows, err := db.sql.Query("SELECT * FROM network")
+	rows, err := db.executor().Query("SELECT * FROM network")
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
@@ -690,6 +1055,58 @@ func (db *Database) GetNetworksInfo() ([]networkmanager.NetworkParametersStorage
 	return networks, nil
 }
 
+// AddIPAllocation adds IPAM allocation.
+func (db *Database) AddIPAllocation(allocation networkmanager.IPAllocation) error {
+	return db.executeQuery("INSERT OR REPLACE INTO ipam values(?, ?, ?)",
+		allocation.NetworkID, allocation.IP, allocation.Subnet)
+}
+
+// RemoveIPAllocation removes IPAM allocation.
+func (db *Database) RemoveIPAllocation(networkID, ip string) (err error) {
+	if err = db.executeQuery(
+		"DELETE FROM ipam WHERE networkID = ? AND ip = ?", networkID, ip); errors.Is(err, errNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// RemoveNetworkIPAllocations removes all IPAM allocations of network.
+func (db *Database) RemoveNetworkIPAllocations(networkID string) (err error) {
+	if err = db.executeQuery("DELETE FROM ipam WHERE networkID = ?", networkID); errors.Is(err, errNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// GetIPAllocations returns IPAM allocations.
+func (db *Database) GetIPAllocations() ([]networkmanager.IPAllocation, error) {
+	rows, err := db.executor().Query("SELECT networkID, ip, subnet FROM ipam ORDER BY networkID, ip")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	var allocations []networkmanager.IPAllocation
+
+	for rows.Next() {
+		var allocation networkmanager.IPAllocation
+
+		if err = rows.Scan(&allocation.NetworkID, &allocation.IP, &allocation.Subnet); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		allocations = append(allocations, allocation)
+	}
+
+	return allocations, nil
+}
+
 // AddNetworkInstanceInfo adds network instance info.
 func (db *Database) AddNetworkInstanceInfo(networkInfo networkmanager.InstanceNetworkInfo) error {
 	ports, err := json.Marshal(&networkInfo.Rules)
@@ -697,9 +1114,15 @@ func (db *Database) AddNetworkInstanceInfo(networkInfo networkmanager.InstanceNe
 		return aoserrors.Wrap(err)
 	}
 
-	return db.executeQuery("INSERT INTO instance_network values(?, ?, ?, ?, ?, ?, ?, ?)",
+	if err = db.executeQuery("INSERT INTO instance_network values(?, ?, ?, ?, ?, ?, ?, ?)",
 		networkInfo.ServiceID, networkInfo.SubjectID, networkInfo.Instance, networkInfo.NetworkID,
-		networkInfo.IP, networkInfo.Subnet, networkInfo.VlanID, ports)
+		networkInfo.IP, networkInfo.Subnet, networkInfo.VlanID, ports); err != nil {
+		return err
+	}
+
+	db.notifyStateChange(statestream.KindInstanceNetwork, statestream.OperationSet, networkInfo)
+
+	return nil
 }
 
 // RemoveNetworkInstanceInfo removes network instance info.
@@ -707,16 +1130,22 @@ func (db *Database) RemoveNetworkInstanceInfo(instanceIdent aostypes.InstanceIde
 	if err = db.executeQuery(
 		"DELETE FROM instance_network WHERE serviceID = ? AND subjectID = ? AND instance = ?",
 		instanceIdent.ServiceID, instanceIdent.SubjectID,
-		instanceIdent.Instance); errors.Is(err, errNotExist) {
-		return nil
+		instanceIdent.Instance); err != nil {
+		if errors.Is(err, errNotExist) {
+			return nil
+		}
+
+		return err
 	}
 
-	return err
+	db.notifyStateChange(statestream.KindInstanceNetwork, statestream.OperationRemove, instanceIdent)
+
+	return nil
 }
 
 // GetNetworkInstancesInfo returns network instances info.
 func (db *Database) GetNetworkInstancesInfo() (networkInfos []networkmanager.InstanceNetworkInfo, err error) {
-	rows, err := db.sql.Query("SELECT * FROM instance_network")
+	rows, err := db.executor().Query("SELECT * FROM instance_network")
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
@@ -747,9 +1176,395 @@ func (db *Database) GetNetworkInstancesInfo() (networkInfos []networkmanager.Ins
 	return networkInfos, nil
 }
 
+// AddMonitoringRecords adds monitoring history records.
+func (db *Database) AddMonitoringRecords(records []monitorcontroller.MonitoringRecord) (err error) {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Errorf("Can't rollback monitoring transaction: %v", rollbackErr)
+			}
+		}
+	}()
+
+	stmt, err := tx.Prepare("INSERT INTO monitoring values(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer stmt.Close()
+
+	for _, record := range records {
+		if _, err = stmt.Exec(record.NodeID, record.ServiceID, record.SubjectID, record.Instance,
+			record.Timestamp.UnixNano(), record.RAM, record.CPU, record.Download, record.Upload); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return aoserrors.Wrap(tx.Commit())
+}
+
+// GetMonitoringRecords returns monitoring history records sorted by timestamp.
+func (db *Database) GetMonitoringRecords(
+	filter monitorcontroller.HistoryFilter,
+) (records []monitorcontroller.MonitoringRecord, err error) {
+	query := "SELECT * FROM monitoring WHERE timestamp >= ? AND timestamp <= ?"
+	args := []any{filter.From.UnixNano(), filter.Till.UnixNano()}
+
+	if filter.NodeID != "" {
+		query += " AND nodeID = ?"
+		args = append(args, filter.NodeID)
+	}
+
+	if filter.Instance != nil {
+		query += " AND serviceID = ? AND subjectID = ? AND instance = ?"
+		args = append(args, filter.Instance.ServiceID, filter.Instance.SubjectID, filter.Instance.Instance)
+	} else {
+		query += " AND serviceID = ''"
+	}
+
+	rows, err := db.executor().Query(query+" ORDER BY timestamp", args...)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record    monitorcontroller.MonitoringRecord
+			timestamp int64
+		)
+
+		if err = rows.Scan(&record.NodeID, &record.ServiceID, &record.SubjectID, &record.Instance, &timestamp,
+			&record.RAM, &record.CPU, &record.Download, &record.Upload); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		record.Timestamp = time.Unix(0, timestamp)
+
+		records = append(records, record)
+	}
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	return records, nil
+}
+
+// AddAuditRecord adds audit log record.
+func (db *Database) AddAuditRecord(record auditlog.Record) error {
+	return db.executeQuery("INSERT INTO audit values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", record.ID,
+		record.Timestamp.UnixNano(), record.Source, record.Actor, record.Action, record.Details, record.Error,
+		record.Hash, record.Signature, false)
+}
+
+// GetAuditRecords returns audit log records sorted by ID.
+func (db *Database) GetAuditRecords(filter auditlog.Filter) (records []auditlog.Record, err error) {
+	query := "SELECT id, timestamp, source, actor, action, details, error, hash, signature FROM audit WHERE 1 = 1"
+	args := []any{}
+
+	if !filter.From.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.From.UnixNano())
+	}
+
+	if !filter.Till.IsZero() {
+		query += " AND timestamp <= ?"
+		args = append(args, filter.Till.UnixNano())
+	}
+
+	if filter.Source != "" {
+		query += " AND source = ?"
+		args = append(args, filter.Source)
+	}
+
+	if filter.Action != "" {
+		query += " AND action = ?"
+		args = append(args, filter.Action)
+	}
+
+	if filter.NotExported {
+		query += " AND exported = 0"
+	}
+
+	query += " ORDER BY id"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.sql.Query(query, args...)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanAuditRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	return records, nil
+}
+
+// GetLastAuditRecord returns last audit log record.
+func (db *Database) GetLastAuditRecord() (auditlog.Record, error) {
+	rows, err := db.sql.Query(
+		"SELECT id, timestamp, source, actor, action, details, error, hash, signature FROM audit ORDER BY id DESC LIMIT 1")
+	if err != nil {
+		return auditlog.Record{}, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if rows.Err() != nil {
+			return auditlog.Record{}, aoserrors.Wrap(rows.Err())
+		}
+
+		return auditlog.Record{}, auditlog.ErrNotExist
+	}
+
+	return scanAuditRecord(rows)
+}
+
+// SetAuditRecordsExported marks audit log records up to last ID as exported.
+func (db *Database) SetAuditRecordsExported(lastID uint64) error {
+	if _, err := db.sql.Exec("UPDATE audit SET exported = 1 WHERE id <= ?", lastID); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// AddLifecycleEvent adds instance lifecycle event.
+func (db *Database) AddLifecycleEvent(event lifecycle.Event) error {
+	return db.executeQuery(`INSERT INTO lifecycle (timestamp, serviceID, subjectID, instance, event, nodeID, prevNodeID,
+		error, exported) values(?, ?, ?, ?, ?, ?, ?, ?, ?)`, event.Timestamp.UnixNano(), event.ServiceID,
+		event.SubjectID, event.Instance, event.Event, event.NodeID, event.PrevNodeID, event.Error, false)
+}
+
+// GetNotExportedLifecycleEvents returns not exported instance lifecycle events sorted by ID.
+func (db *Database) GetNotExportedLifecycleEvents(limit int) (events []lifecycle.Event, err error) {
+	rows, err := db.executor().Query(`SELECT id, timestamp, serviceID, subjectID, instance, event, nodeID, prevNodeID,
+		error FROM lifecycle WHERE exported = 0 ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event     lifecycle.Event
+			timestamp int64
+		)
+
+		if err = rows.Scan(&event.ID, &timestamp, &event.ServiceID, &event.SubjectID, &event.Instance, &event.Event,
+			&event.NodeID, &event.PrevNodeID, &event.Error); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		event.Timestamp = time.Unix(0, timestamp).UTC()
+
+		events = append(events, event)
+	}
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	return events, nil
+}
+
+// SetLifecycleEventsExported marks instance lifecycle events up to last ID as exported.
+func (db *Database) SetLifecycleEventsExported(lastID uint64) error {
+	if _, err := db.executor().Exec("UPDATE lifecycle SET exported = 1 WHERE id <= ?", lastID); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// AddUpdateHistoryRecord adds update history record.
+func (db *Database) AddUpdateHistoryRecord(record unitstatushandler.UpdateHistoryRecord) error {
+	return db.executeQuery(`INSERT INTO updatehistory (timestamp, id, step, size, duration, campaignID)
+		values(?, ?, ?, ?, ?, ?)`, record.Timestamp.UnixNano(), record.ID, record.Step, record.Size,
+		record.Duration.Nanoseconds(), record.CampaignID)
+}
+
+// GetUpdateHistoryRecords returns last update history records of item step sorted from newest to oldest.
+func (db *Database) GetUpdateHistoryRecords(
+	id, step string, limit int,
+) (records []unitstatushandler.UpdateHistoryRecord, err error) {
+	rows, err := db.executor().Query(`SELECT timestamp, id, step, size, duration, campaignID FROM updatehistory
+		WHERE id = ? AND step = ? ORDER BY rowid DESC LIMIT ?`, id, step, limit)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			record    unitstatushandler.UpdateHistoryRecord
+			timestamp int64
+			duration  int64
+		)
+
+		if err = rows.Scan(
+			&timestamp, &record.ID, &record.Step, &record.Size, &duration, &record.CampaignID); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		record.Timestamp = time.Unix(0, timestamp).UTC()
+		record.Duration = time.Duration(duration)
+
+		records = append(records, record)
+	}
+
+	if rows.Err() != nil {
+		return nil, aoserrors.Wrap(rows.Err())
+	}
+
+	return records, nil
+}
+
+// RemoveOutdatedUpdateHistoryRecords removes oldest update history records of item step to keep max records count.
+func (db *Database) RemoveOutdatedUpdateHistoryRecords(id, step string, maxRecords int) error {
+	if _, err := db.executor().Exec(`DELETE FROM updatehistory WHERE rowid IN
+		(SELECT rowid FROM updatehistory WHERE id = ? AND step = ? ORDER BY rowid DESC LIMIT -1 OFFSET ?)`,
+		id, step, maxRecords); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetSize returns database size in bytes.
+func (db *Database) GetSize() (size uint64, err error) {
+	var pageCount, pageSize uint64
+
+	if err = db.sql.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	if err = db.sql.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return pageCount * pageSize, nil
+}
+
+// ExportSnapshot writes consistent snapshot of the database to the file.
+func (db *Database) ExportSnapshot(fileName string) (err error) {
+	if err = os.RemoveAll(fileName); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = db.sql.Exec("VACUUM INTO ?", fileName); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// Clear removes owner related data: services and their SBOMs, layers, instances, networks, storages and states,
+// downloads, monitoring history, instance lifecycle events, campaigns and update states. Journal cursor, components,
+// nodes info, update history, feature flags, bandwidth usage, vulnerability advisories, pending node reboot and audit
+// log are kept as they belong to the unit.
+func (db *Database) Clear() (err error) {
+	log.Debug("Clear database")
+
+	return db.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
+
+		for _, table := range []string{
+			"services", "layers", "instances", "instance_network", "network", "ipam", "storagestate", "download",
+			"monitoring", "lifecycle", "campaigns", "sboms",
+		} {
+			if _, err := txDB.executor().Exec("DELETE FROM " + table); err != nil {
+				return aoserrors.Wrap(err)
+			}
+		}
+
+		if _, err := txDB.executor().Exec(
+			"UPDATE config SET fotaUpdateState = ?, sotaUpdateState = ?, desiredInstances = ?",
+			json.RawMessage{}, json.RawMessage{}, json.RawMessage("[]")); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		return nil
+	})
+}
+
+// ImportSnapshot replaces database with the snapshot. It should be called before the database is opened.
+func ImportSnapshot(config *config.Config, fileName string) (err error) {
+	dbFile := filepath.Join(config.WorkingDir, dbFileName)
+
+	log.WithFields(log.Fields{"fileName": dbFile, "snapshot": fileName}).Debug("Import database snapshot")
+
+	if err = removeDatabaseFiles(dbFile); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(dbFile + encryptedFileSuffix); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return copyFile(fileName, dbFile)
+}
+
+// CheckHealth checks that database storage is reachable.
+func (db *Database) CheckHealth() error {
+	var tablesCount int
+
+	if err := db.sql.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&tablesCount); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
 // Close closes database.
 func (db *Database) Close() {
-	db.sql.Close()
+	db.stopMaintenance()
+
+	if db.encryption != nil {
+		db.encryption.close(db.sql)
+	}
+
+	db.versionMutex.Lock()
+
+	if db.versionConn != nil {
+		db.versionConn.Close()
+	}
+
+	db.versionMutex.Unlock()
+
+	if err := db.sql.Close(); err != nil {
+		log.Errorf("Can't close database: %v", err)
+
+		return
+	}
+
+	if db.encryption != nil {
+		db.encryption.removeRuntimeFiles()
+
+		return
+	}
+
+	db.setCleanShutdown()
 }
 
 /***********************************************************************************************************************
@@ -757,7 +1572,7 @@ func (db *Database) Close() {
  **********************************************************************************************************************/
 
 func (db *Database) getDataFromQuery(query string, queryParams []interface{}, result ...interface{}) error {
-	stmt, err := db.sql.Prepare(query)
+	stmt, err := db.executor().Prepare(query)
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -775,7 +1590,11 @@ func (db *Database) getDataFromQuery(query string, queryParams []interface{}, re
 }
 
 func (db *Database) executeQuery(query string, args ...interface{}) error {
-	stmt, err := db.sql.Prepare(query)
+	if err := faultinjection.Fail(faultinjection.StorageWriteFailure); err != nil {
+		return err
+	}
+
+	stmt, err := db.executor().Prepare(query)
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -826,6 +1645,10 @@ func (db *Database) createServiceTable() (err error) {
                                                                sha256 BLOB,
                                                                exposedPorts BLOB,
                                                                gid INTEGER,
+                                                               healthEndpoints BLOB,
+                                                               healthChecks BLOB,
+                                                               layerMediaTypes BLOB,
+                                                               sharedNetwork INTEGER DEFAULT 0,
                                                                PRIMARY KEY(id, version))`)
 
 	return aoserrors.Wrap(err)
@@ -956,7 +1779,7 @@ func (db *Database) createConfigTable() (err error) {
 func (db *Database) getServicesFromQuery(
 	query string, args ...interface{},
 ) (services []imagemanager.ServiceInfo, err error) {
-	rows, err := db.sql.Query(query, args...)
+	rows, err := db.executor().Query(query, args...)
 	if err != nil {
 		return services, aoserrors.Wrap(err)
 	}
@@ -964,15 +1787,19 @@ func (db *Database) getServicesFromQuery(
 
 	for rows.Next() {
 		var (
-			service      imagemanager.ServiceInfo
-			configJSON   []byte
-			layers       []byte
-			exposedPorts []byte
+			service         imagemanager.ServiceInfo
+			configJSON      []byte
+			layers          []byte
+			exposedPorts    []byte
+			healthEndpoints []byte
+			healthChecks    []byte
+			layerMediaTypes []byte
 		)
 
 		if err = rows.Scan(&service.ServiceID, &service.Version, &service.ProviderID, &service.URL, &service.RemoteURL,
 			&service.Path, &service.Size, &service.Timestamp, &service.State, &configJSON, &layers,
-			&service.Sha256, &exposedPorts, &service.GID); err != nil {
+			&service.Sha256, &exposedPorts, &service.GID, &healthEndpoints, &healthChecks,
+			&layerMediaTypes, &service.SharedNetwork); err != nil {
 			return nil, aoserrors.Wrap(err)
 		}
 
@@ -988,6 +1815,18 @@ func (db *Database) getServicesFromQuery(
 			return nil, aoserrors.Wrap(err)
 		}
 
+		if err = json.Unmarshal(healthEndpoints, &service.HealthEndpoints); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(healthChecks, &service.HealthChecks); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(layerMediaTypes, &service.LayerMediaTypes); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
 		services = append(services, service)
 	}
 
@@ -1001,7 +1840,7 @@ func (db *Database) getServicesFromQuery(
 func (db *Database) getLayersFromQuery(
 	query string, args ...interface{},
 ) (layers []imagemanager.LayerInfo, err error) {
-	rows, err := db.sql.Query(query, args...)
+	rows, err := db.executor().Query(query, args...)
 	if err != nil {
 		return layers, aoserrors.Wrap(err)
 	}
@@ -1026,3 +1865,16 @@ func (db *Database) getLayersFromQuery(
 
 	return layers, nil
 }
+
+func scanAuditRecord(rows *sql.Rows) (record auditlog.Record, err error) {
+	var timestamp int64
+
+	if err = rows.Scan(&record.ID, &timestamp, &record.Source, &record.Actor, &record.Action, &record.Details,
+		&record.Error, &record.Hash, &record.Signature); err != nil {
+		return record, aoserrors.Wrap(err)
+	}
+
+	record.Timestamp = time.Unix(0, timestamp).UTC()
+
+	return record, nil
+}
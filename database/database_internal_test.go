@@ -18,13 +18,21 @@
 package database
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"sync"
 	"testing"
@@ -36,13 +44,20 @@ import (
 	"github.com/aosedge/aos_common/migration"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/auditlog"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/lifecycle"
+	"github.com/aosedge/aos_communicationmanager/monitorcontroller"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
+	"github.com/aosedge/aos_communicationmanager/sbom"
+	"github.com/aosedge/aos_communicationmanager/statestream"
 	"github.com/aosedge/aos_communicationmanager/storagestate"
 	"github.com/aosedge/aos_communicationmanager/umcontroller"
+	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
 )
 
 /***********************************************************************************************************************
@@ -63,6 +78,29 @@ const (
 	subjectPrefix = "subj"
 )
 
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testCertProvider struct {
+	cert *x509.Certificate
+}
+
+type testCryptoContext struct {
+	cert *x509.Certificate
+	key  crypto.PrivateKey
+}
+
+type testStateChange struct {
+	kind      string
+	operation string
+	data      any
+}
+
+type testStatePublisher struct {
+	changes []testStateChange
+}
+
 /***********************************************************************************************************************
  * Init
  **********************************************************************************************************************/
@@ -92,10 +130,10 @@ func TestMain(m *testing.M) {
 	testDB, err = New(&config.Config{
 		WorkingDir: tmpDir,
 		Migration: config.Migration{
-			MigrationPath:       tmpDir,
-			MergedMigrationPath: tmpDir,
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(tmpDir, "migration"),
 		},
-	})
+	}, nil, nil)
 	if err != nil {
 		log.Fatalf("Can't create database: %s", err)
 	}
@@ -132,6 +170,12 @@ func TestCursor(t *testing.T) {
 	}
 }
 
+func TestCheckHealth(t *testing.T) {
+	if err := testDB.CheckHealth(); err != nil {
+		t.Errorf("Database should be healthy: %v", err)
+	}
+}
+
 func TestComponentsUpdateInfo(t *testing.T) {
 	testData := []umcontroller.ComponentStatus{
 		{
@@ -201,6 +245,287 @@ func TestSotaFotaInstancesFields(t *testing.T) {
 	}
 }
 
+func TestMaintenanceMode(t *testing.T) {
+	state, err := testDB.GetMaintenanceMode()
+	if err != nil {
+		t.Fatalf("Can't get maintenance mode: %v", err)
+	}
+
+	if state != nil {
+		t.Errorf("Unexpected maintenance mode: %s", string(state))
+	}
+
+	for _, expectedState := range []json.RawMessage{
+		json.RawMessage(`{"enabled":true}`), json.RawMessage(`{"enabled":false}`),
+	} {
+		if err := testDB.SetMaintenanceMode(expectedState); err != nil {
+			t.Fatalf("Can't set maintenance mode: %v", err)
+		}
+
+		if state, err = testDB.GetMaintenanceMode(); err != nil {
+			t.Fatalf("Can't get maintenance mode: %v", err)
+		}
+
+		if string(state) != string(expectedState) {
+			t.Errorf("Incorrect maintenance mode: %s", string(state))
+		}
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	flags, err := testDB.GetFeatureFlags()
+	if err != nil {
+		t.Fatalf("Can't get feature flags: %v", err)
+	}
+
+	if flags != nil {
+		t.Errorf("Unexpected feature flags: %s", string(flags))
+	}
+
+	for _, expectedFlags := range []json.RawMessage{
+		json.RawMessage(`{"nodeScoring":true}`), json.RawMessage(`{}`),
+	} {
+		if err := testDB.SetFeatureFlags(expectedFlags); err != nil {
+			t.Fatalf("Can't set feature flags: %v", err)
+		}
+
+		if flags, err = testDB.GetFeatureFlags(); err != nil {
+			t.Fatalf("Can't get feature flags: %v", err)
+		}
+
+		if string(flags) != string(expectedFlags) {
+			t.Errorf("Incorrect feature flags: %s", string(flags))
+		}
+	}
+}
+
+func TestBandwidthUsage(t *testing.T) {
+	usage, err := testDB.GetBandwidthUsage()
+	if err != nil {
+		t.Fatalf("Can't get bandwidth usage: %v", err)
+	}
+
+	if usage != nil {
+		t.Errorf("Unexpected bandwidth usage: %s", string(usage))
+	}
+
+	for _, expectedUsage := range []json.RawMessage{
+		json.RawMessage(`{"daily":1024}`), json.RawMessage(`{"daily":2048}`),
+	} {
+		if err := testDB.SetBandwidthUsage(expectedUsage); err != nil {
+			t.Fatalf("Can't set bandwidth usage: %v", err)
+		}
+
+		if usage, err = testDB.GetBandwidthUsage(); err != nil {
+			t.Fatalf("Can't get bandwidth usage: %v", err)
+		}
+
+		if string(usage) != string(expectedUsage) {
+			t.Errorf("Incorrect bandwidth usage: %s", string(usage))
+		}
+	}
+}
+
+func TestAdvisories(t *testing.T) {
+	state, err := testDB.GetAdvisories()
+	if err != nil {
+		t.Fatalf("Can't get advisories: %v", err)
+	}
+
+	if state != nil {
+		t.Errorf("Unexpected advisories: %s", string(state))
+	}
+
+	for _, expectedState := range []json.RawMessage{
+		json.RawMessage(`{"advisories":[{"id":"CVE-2025-0001"}]}`), json.RawMessage(`{"advisories":[]}`),
+	} {
+		if err := testDB.SetAdvisories(expectedState); err != nil {
+			t.Fatalf("Can't set advisories: %v", err)
+		}
+
+		if state, err = testDB.GetAdvisories(); err != nil {
+			t.Fatalf("Can't get advisories: %v", err)
+		}
+
+		if string(state) != string(expectedState) {
+			t.Errorf("Incorrect advisories: %s", string(state))
+		}
+	}
+}
+
+func TestNodeReboot(t *testing.T) {
+	state, err := testDB.GetNodeReboot()
+	if err != nil {
+		t.Fatalf("Can't get node reboot: %v", err)
+	}
+
+	if state != nil {
+		t.Errorf("Unexpected node reboot: %s", string(state))
+	}
+
+	for _, expectedState := range []json.RawMessage{
+		json.RawMessage(`{"requestId":"request1","nodeIds":["node1"]}`), json.RawMessage(`{}`),
+	} {
+		if err := testDB.SetNodeReboot(expectedState); err != nil {
+			t.Fatalf("Can't set node reboot: %v", err)
+		}
+
+		if state, err = testDB.GetNodeReboot(); err != nil {
+			t.Fatalf("Can't get node reboot: %v", err)
+		}
+
+		if string(state) != string(expectedState) {
+			t.Errorf("Incorrect node reboot: %s", string(state))
+		}
+	}
+}
+
+func TestSBOMs(t *testing.T) {
+	expectedSBOMs := []sbom.Document{
+		{
+			ServiceID: "sbomService", Version: "1.0.0", Format: sbom.FormatSPDX,
+			MediaType: sbom.SPDXMediaType, Digest: "sha256:1", Document: json.RawMessage(`{"spdxVersion":"SPDX-2.3"}`),
+		},
+		{
+			ServiceID: "sbomService", Version: "1.0.0", Format: sbom.FormatCycloneDX,
+			MediaType: sbom.CycloneDXMediaType, Digest: "sha256:2",
+			Document: json.RawMessage(`{"bomFormat":"CycloneDX"}`),
+		},
+		{
+			ServiceID: "sbomService", Version: "2.0.0", Format: sbom.FormatSPDX,
+			MediaType: sbom.SPDXMediaType, Digest: "sha256:3", Document: json.RawMessage(`{"spdxVersion":"SPDX-2.3"}`),
+		},
+	}
+
+	for _, sbom := range expectedSBOMs {
+		if err := testDB.AddSBOM(sbom); err != nil {
+			t.Fatalf("Can't add SBOM: %v", err)
+		}
+	}
+
+	sboms, err := testDB.GetSBOMs()
+	if err != nil {
+		t.Fatalf("Can't get SBOMs: %v", err)
+	}
+
+	if !reflect.DeepEqual(sboms, expectedSBOMs) {
+		t.Errorf("Incorrect SBOMs: %v", sboms)
+	}
+
+	if err = testDB.RemoveService("sbomService", "1.0.0"); err != nil {
+		t.Fatalf("Can't remove service: %v", err)
+	}
+
+	if sboms, err = testDB.GetSBOMs(); err != nil {
+		t.Fatalf("Can't get SBOMs: %v", err)
+	}
+
+	if !reflect.DeepEqual(sboms, expectedSBOMs[2:]) {
+		t.Errorf("Incorrect SBOMs: %v", sboms)
+	}
+
+	if err = testDB.RemoveService("sbomService", "2.0.0"); err != nil {
+		t.Fatalf("Can't remove service: %v", err)
+	}
+}
+
+func TestOverrideBundleReports(t *testing.T) {
+	expectedReports := []json.RawMessage{json.RawMessage(`{"bundleId":"bundle1"}`), json.RawMessage(`{"bundleId":"bundle2"}`)}
+
+	for i, report := range expectedReports {
+		if err := testDB.AddOverrideBundleReport(fmt.Sprintf("bundle%d", i+1), report); err != nil {
+			t.Fatalf("Can't add override bundle report: %v", err)
+		}
+	}
+
+	reports, err := testDB.GetOverrideBundleReports()
+	if err != nil {
+		t.Fatalf("Can't get override bundle reports: %v", err)
+	}
+
+	if !reflect.DeepEqual(reports, expectedReports) {
+		t.Errorf("Wrong override bundle reports: %s", reports)
+	}
+
+	if err = testDB.RemoveOverrideBundleReport("bundle1"); err != nil {
+		t.Fatalf("Can't remove override bundle report: %v", err)
+	}
+
+	if err = testDB.RemoveOverrideBundleReport("bundle1"); err != nil {
+		t.Errorf("Can't remove not existing override bundle report: %v", err)
+	}
+
+	if reports, err = testDB.GetOverrideBundleReports(); err != nil {
+		t.Fatalf("Can't get override bundle reports: %v", err)
+	}
+
+	if !reflect.DeepEqual(reports, expectedReports[1:]) {
+		t.Errorf("Wrong override bundle reports: %s", reports)
+	}
+}
+
+func TestEmergencyUpdateIDs(t *testing.T) {
+	if err := testDB.AddEmergencyUpdateID("expired", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Can't add emergency update ID: %v", err)
+	}
+
+	if err := testDB.AddEmergencyUpdateID("update1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Can't add emergency update ID: %v", err)
+	}
+
+	for updateID, expected := range map[string]bool{"update1": true, "update2": false, "expired": false} {
+		used, err := testDB.IsEmergencyUpdateIDUsed(updateID)
+		if err != nil {
+			t.Fatalf("Can't check emergency update ID: %v", err)
+		}
+
+		if used != expected {
+			t.Errorf("Wrong emergency update %s used state: %v", updateID, used)
+		}
+	}
+}
+
+func TestCampaignSummaries(t *testing.T) {
+	if err := testDB.SetCampaignSummary("campaign1", json.RawMessage(`{"state":"inProgress"}`)); err != nil {
+		t.Fatalf("Can't set campaign summary: %v", err)
+	}
+
+	expectedSummaries := []json.RawMessage{
+		json.RawMessage(`{"campaignId":"campaign1"}`), json.RawMessage(`{"campaignId":"campaign2"}`),
+	}
+
+	for i, summary := range expectedSummaries {
+		if err := testDB.SetCampaignSummary(fmt.Sprintf("campaign%d", i+1), summary); err != nil {
+			t.Fatalf("Can't set campaign summary: %v", err)
+		}
+	}
+
+	summaries, err := testDB.GetCampaignSummaries()
+	if err != nil {
+		t.Fatalf("Can't get campaign summaries: %v", err)
+	}
+
+	if !reflect.DeepEqual(summaries, expectedSummaries) {
+		t.Errorf("Wrong campaign summaries: %s", summaries)
+	}
+
+	if err = testDB.RemoveCampaignSummary("campaign1"); err != nil {
+		t.Fatalf("Can't remove campaign summary: %v", err)
+	}
+
+	if err = testDB.RemoveCampaignSummary("campaign1"); err != nil {
+		t.Errorf("Can't remove not existing campaign summary: %v", err)
+	}
+
+	if summaries, err = testDB.GetCampaignSummaries(); err != nil {
+		t.Fatalf("Can't get campaign summaries: %v", err)
+	}
+
+	if !reflect.DeepEqual(summaries, expectedSummaries[1:]) {
+		t.Errorf("Wrong campaign summaries: %s", summaries)
+	}
+}
+
 func TestMultiThread(t *testing.T) {
 	const numIterations = 1000
 
@@ -278,6 +603,16 @@ func TestServiceStore(t *testing.T) {
 						DownloadSpeed: allocateUint64(1000),
 					},
 				},
+				ExposedPorts:    []string{"8080/tcp"},
+				HealthEndpoints: map[string]string{"8080/tcp": "/healthz"},
+				LayerMediaTypes: map[string]string{"sha256:1": "application/vnd.oci.image.layer.v1.tar+gzip"},
+				SharedNetwork:   true,
+				HealthChecks: []healthcheck.Check{
+					{
+						Type: healthcheck.CheckHTTP, Port: 8080, Path: "/healthz",
+						Interval: aostypes.Duration{Duration: 5 * time.Second}, FailureThreshold: 3,
+					},
+				},
 			},
 			expectedServiceVersionsCount: 1,
 			expectedServiceCount:         1,
@@ -796,6 +1131,56 @@ func TestNetworkConfiguration(t *testing.T) {
 	}
 }
 
+func TestIPAllocations(t *testing.T) {
+	allocations := []networkmanager.IPAllocation{
+		{NetworkID: "network1", Subnet: "172.17.0.0/16"},
+		{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.2"},
+		{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.3"},
+		{NetworkID: "network2", Subnet: "172.18.0.0/16"},
+		{NetworkID: "network2", Subnet: "172.18.0.0/16", IP: "172.18.0.2"},
+	}
+
+	for _, allocation := range allocations {
+		if err := testDB.AddIPAllocation(allocation); err != nil {
+			t.Fatalf("Can't add IP allocation: %v", err)
+		}
+	}
+
+	// Adding existing allocation should replace it
+	if err := testDB.AddIPAllocation(allocations[1]); err != nil {
+		t.Fatalf("Can't add IP allocation: %v", err)
+	}
+
+	getAllocations := func() []networkmanager.IPAllocation {
+		allocations, err := testDB.GetIPAllocations()
+		if err != nil {
+			t.Fatalf("Can't get IP allocations: %v", err)
+		}
+
+		return allocations
+	}
+
+	if storedAllocations := getAllocations(); !reflect.DeepEqual(storedAllocations, allocations) {
+		t.Errorf("Wrong IP allocations: %v", storedAllocations)
+	}
+
+	if err := testDB.RemoveIPAllocation("network1", "172.17.0.3"); err != nil {
+		t.Errorf("Can't remove IP allocation: %v", err)
+	}
+
+	if err := testDB.RemoveNetworkIPAllocations("network2"); err != nil {
+		t.Errorf("Can't remove network IP allocations: %v", err)
+	}
+
+	if storedAllocations := getAllocations(); !reflect.DeepEqual(storedAllocations, allocations[:2]) {
+		t.Errorf("Wrong IP allocations: %v", storedAllocations)
+	}
+
+	if err := testDB.RemoveNetworkIPAllocations("network1"); err != nil {
+		t.Errorf("Can't remove network IP allocations: %v", err)
+	}
+}
+
 func allocateString(value string) *string {
 	return &value
 }
@@ -963,113 +1348,1135 @@ func TestInstance(t *testing.T) {
 	}
 }
 
-func TestStorageState(t *testing.T) {
-	var (
-		testInstanceID  = "test_instance_subjectID_serviceID"
-		newCheckSum     = []byte("newCheckSum")
-		newStorageQuota = uint64(88888)
-		newStateQuota   = uint64(99999)
-	)
-
-	instanceIdent := aostypes.InstanceIdent{
-		Instance:  1,
-		ServiceID: "service1",
-		SubjectID: "subject1",
+func TestTransaction(t *testing.T) {
+	instance := launcher.InstanceInfo{InstanceIdent: createInstanceIdent(200), NodeID: "node1", UID: 200}
+	networkInfo := networkmanager.InstanceNetworkInfo{
+		InstanceIdent: instance.InstanceIdent,
+		NetworkParameters: aostypes.NetworkParameters{
+			NetworkID: "network200", IP: "172.17.0.200", Subnet: "172.17.0.0/16",
+		},
 	}
 
-	if _, err := testDB.GetStorageStateInfo(instanceIdent); !errors.Is(err, storagestate.ErrNotExist) {
-		t.Errorf("Should be entry does not exist")
-	}
+	errRollback := errors.New("rollback")
 
-	testStateStorageInfo := storagestate.StorageStateInstanceInfo{
-		InstanceID:   testInstanceID,
-		StorageQuota: 12345, StateQuota: 54321,
-		StateChecksum: []byte("checksum1"),
-		InstanceIdent: instanceIdent,
-	}
+	if err := testDB.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
 
-	if err := testDB.AddStorageStateInfo(testStateStorageInfo); err != nil {
-		t.Fatalf("Can't add state storage info: %v", err)
-	}
+		if err := txDB.AddInstance(instance); err != nil {
+			return err
+		}
 
-	stateStorageInfos, err := testDB.GetAllStorageStateInfo()
-	if err != nil {
-		t.Fatalf("Can't get all state storage infos: %v", err)
+		if err := txDB.AddNetworkInstanceInfo(networkInfo); err != nil {
+			return err
+		}
+
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Errorf("Wrong transaction error: %v", err)
 	}
 
-	if len(stateStorageInfos) != 1 {
-		t.Errorf("Unexpected state storage info size")
+	if _, err := testDB.GetInstance(instance.InstanceIdent); !errors.Is(err, launcher.ErrNotExist) {
+		t.Errorf("Instance should not exist after rollback: %v", err)
 	}
 
-	if stateStorageInfos[0].InstanceID != testInstanceID {
-		t.Errorf("Unexpected instance path")
+	if err := testDB.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
+
+		if err := txDB.AddInstance(instance); err != nil {
+			return err
+		}
+
+		// storage calls made outside of the transaction don't see its changes
+		if _, err := testDB.GetInstance(instance.InstanceIdent); !errors.Is(err, launcher.ErrNotExist) {
+			return aoserrors.Errorf("uncommitted instance is visible outside of transaction: %v", err)
+		}
+
+		// nested transaction joins the outer one
+		return txDB.ExecuteInTransaction(func(txStorage any) error {
+			nestedDB, _ := txStorage.(*Database)
+
+			if nestedDB != txDB {
+				return aoserrors.New("nested transaction doesn't join the outer one")
+			}
+
+			return nestedDB.AddNetworkInstanceInfo(networkInfo)
+		})
+	}); err != nil {
+		t.Fatalf("Can't execute transaction: %v", err)
 	}
 
-	if stateStorageInfos[0].InstanceIdent != testStateStorageInfo.InstanceIdent {
-		t.Errorf("Unexpected instance ident")
+	if _, err := testDB.GetInstance(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't get instance: %v", err)
 	}
 
-	info, err := testDB.GetStorageStateInfo(instanceIdent)
+	networkInfos, err := testDB.GetNetworkInstancesInfo()
 	if err != nil {
-		t.Fatalf("Can't get state storage info: %v", err)
+		t.Fatalf("Can't get network instances info: %v", err)
 	}
 
-	if !reflect.DeepEqual(info, testStateStorageInfo) {
-		t.Error("State storage info from database doesn't match expected one")
+	found := false
+
+	for _, info := range networkInfos {
+		if info.InstanceIdent == instance.InstanceIdent {
+			found = true
+		}
 	}
 
-	notExistInstanceIdent := aostypes.InstanceIdent{
-		ServiceID: "serviceID",
-		SubjectID: "subjectID",
-		Instance:  20,
+	if !found {
+		t.Error("Network instance info should exist after commit")
 	}
 
-	if err := testDB.SetStateChecksum(notExistInstanceIdent, newCheckSum); !errors.Is(err, storagestate.ErrNotExist) {
-		t.Errorf("Should be entry does not exist")
+	if err = testDB.RemoveInstance(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't remove instance: %v", err)
 	}
 
-	if err := testDB.SetStateChecksum(instanceIdent, newCheckSum); err != nil {
-		t.Fatalf("Can't update checksum: %v", err)
+	if err = testDB.RemoveNetworkInstanceInfo(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't remove network instance info: %v", err)
 	}
+}
 
-	testStateStorageInfo.StateChecksum = newCheckSum
+func TestStatePublisher(t *testing.T) {
+	publisher := &testStatePublisher{}
 
-	info, err = testDB.GetStorageStateInfo(instanceIdent)
-	if err != nil {
-		t.Fatalf("Can't get state storage info: %v", err)
+	testDB.SetStatePublisher(publisher)
+	defer testDB.SetStatePublisher(nil)
+
+	instance := launcher.InstanceInfo{InstanceIdent: createInstanceIdent(300), NodeID: "node1", UID: 300}
+	networkInfo := networkmanager.InstanceNetworkInfo{
+		InstanceIdent: instance.InstanceIdent,
+		NetworkParameters: aostypes.NetworkParameters{
+			NetworkID: "network300", IP: "172.17.0.30", Subnet: "172.17.0.0/16",
+		},
 	}
 
-	if !reflect.DeepEqual(info, testStateStorageInfo) {
-		t.Error("Update state storage info from database doesn't match expected one")
+	errRollback := errors.New("rollback")
+
+	if err := testDB.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
+
+		if err := txDB.AddInstance(instance); err != nil {
+			return err
+		}
+
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Errorf("Wrong transaction error: %v", err)
 	}
 
-	if err := testDB.SetStorageStateQuotas(
-		notExistInstanceIdent, newStorageQuota, newStateQuota); !errors.Is(err, storagestate.ErrNotExist) {
-		t.Errorf("Should be: entry does not exist")
+	if len(publisher.changes) != 0 {
+		t.Errorf("Rolled back changes should not be published: %v", publisher.changes)
+	}
+
+	if err := testDB.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
+
+		if err := txDB.AddInstance(instance); err != nil {
+			return err
+		}
+
+		if err := txDB.AddNetworkInstanceInfo(networkInfo); err != nil {
+			return err
+		}
+
+		if len(publisher.changes) != 0 {
+			return aoserrors.New("changes published before commit")
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Can't execute transaction: %v", err)
+	}
+
+	if err := testDB.RemoveInstance(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't remove instance: %v", err)
+	}
+
+	if err := testDB.RemoveNetworkInstanceInfo(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't remove network instance info: %v", err)
+	}
+
+	if err := testDB.RemoveNetworkInfo("unknown", "node1"); err != nil {
+		t.Errorf("Can't remove network info: %v", err)
+	}
+
+	expectedChanges := []testStateChange{
+		{statestream.KindInstance, statestream.OperationSet, newInstanceState(instance)},
+		{statestream.KindInstanceNetwork, statestream.OperationSet, networkInfo},
+		{statestream.KindInstance, statestream.OperationRemove, instance.InstanceIdent},
+		{statestream.KindInstanceNetwork, statestream.OperationRemove, instance.InstanceIdent},
+	}
+
+	if !reflect.DeepEqual(publisher.changes, expectedChanges) {
+		t.Errorf("Wrong published changes: %v", publisher.changes)
+	}
+}
+
+func TestApplyStateChanges(t *testing.T) {
+	stream := statestream.New(config.StateStream{HistorySize: 16})
+
+	testDB.SetStatePublisher(stream)
+
+	version, err := testDB.DataVersion()
+	if err != nil {
+		t.Fatalf("Can't get data version: %v", err)
+	}
+
+	instance := launcher.InstanceInfo{InstanceIdent: createInstanceIdent(310), NodeID: "node1", UID: 310}
+	networkInfo := networkmanager.NetworkParametersStorage{
+		NetworkParameters: aostypes.NetworkParameters{NetworkID: "network310", Subnet: "172.18.0.0/16", VlanID: 310},
+		NodeID:            "node1",
+	}
+	instanceNetworkInfo := networkmanager.InstanceNetworkInfo{
+		InstanceIdent:     instance.InstanceIdent,
+		NetworkParameters: aostypes.NetworkParameters{NetworkID: "network310", IP: "172.18.0.10"},
+	}
+
+	if err = testDB.AddInstance(instance); err != nil {
+		t.Fatalf("Can't add instance: %v", err)
+	}
+
+	if newVersion, err := testDB.DataVersion(); err != nil || newVersion == version {
+		t.Errorf("Data version is not changed: %d, err: %v", newVersion, err)
+	}
+
+	instance.NodeID = "node2"
+
+	if err = testDB.UpdateInstance(instance); err != nil {
+		t.Fatalf("Can't update instance: %v", err)
+	}
+
+	if err = testDB.AddNetworkInfo(networkInfo); err != nil {
+		t.Fatalf("Can't add network info: %v", err)
+	}
+
+	if err = testDB.AddNetworkInstanceInfo(instanceNetworkInfo); err != nil {
+		t.Fatalf("Can't add network instance info: %v", err)
+	}
+
+	if err = testDB.RemoveNetworkInstanceInfo(instance.InstanceIdent); err != nil {
+		t.Fatalf("Can't remove network instance info: %v", err)
+	}
+
+	testDB.SetStatePublisher(nil)
+
+	epoch, _ := stream.Position()
+
+	changes, err := stream.Changes(epoch, 0)
+	if err != nil {
+		t.Fatalf("Can't get changes: %v", err)
+	}
+
+	if err = testDB.RemoveInstance(instance.InstanceIdent); err != nil {
+		t.Fatalf("Can't remove instance: %v", err)
+	}
+
+	if err = testDB.RemoveNetworkInfo(networkInfo.NetworkID, networkInfo.NodeID); err != nil {
+		t.Fatalf("Can't remove network info: %v", err)
+	}
+
+	// Changes may be applied repeatedly
+
+	for i := 0; i < 2; i++ {
+		if err = testDB.ApplyStateChanges(changes); err != nil {
+			t.Fatalf("Can't apply state changes: %v", err)
+		}
+	}
+
+	if storedInstance, err := testDB.GetInstance(instance.InstanceIdent); err != nil ||
+		!reflect.DeepEqual(storedInstance, instance) {
+		t.Errorf("Wrong instance: %v, err: %v", storedInstance, err)
+	}
+
+	networks, err := testDB.GetNetworksInfo()
+	if err != nil {
+		t.Fatalf("Can't get networks info: %v", err)
+	}
+
+	if !slices.ContainsFunc(networks, func(network networkmanager.NetworkParametersStorage) bool {
+		return reflect.DeepEqual(network, networkInfo)
+	}) {
+		t.Errorf("Network is not applied: %v", networks)
+	}
+
+	instanceNetworks, err := testDB.GetNetworkInstancesInfo()
+	if err != nil {
+		t.Fatalf("Can't get network instances info: %v", err)
+	}
+
+	for _, info := range instanceNetworks {
+		if info.InstanceIdent == instance.InstanceIdent {
+			t.Errorf("Removed network instance is applied: %v", info)
+		}
+	}
+
+	if err = testDB.RemoveInstance(instance.InstanceIdent); err != nil {
+		t.Errorf("Can't remove instance: %v", err)
+	}
+
+	if err = testDB.RemoveNetworkInfo(networkInfo.NetworkID, networkInfo.NodeID); err != nil {
+		t.Errorf("Can't remove network info: %v", err)
+	}
+}
+
+func TestStorageState(t *testing.T) {
+	var (
+		testInstanceID  = "test_instance_subjectID_serviceID"
+		newCheckSum     = []byte("newCheckSum")
+		newStorageQuota = uint64(88888)
+		newStateQuota   = uint64(99999)
+	)
+
+	instanceIdent := aostypes.InstanceIdent{
+		Instance:  1,
+		ServiceID: "service1",
+		SubjectID: "subject1",
+	}
+
+	if _, err := testDB.GetStorageStateInfo(instanceIdent); !errors.Is(err, storagestate.ErrNotExist) {
+		t.Errorf("Should be entry does not exist")
+	}
+
+	testStateStorageInfo := storagestate.StorageStateInstanceInfo{
+		InstanceID:   testInstanceID,
+		StorageQuota: 12345, StateQuota: 54321,
+		StateChecksum: []byte("checksum1"),
+		InstanceIdent: instanceIdent,
+	}
+
+	if err := testDB.AddStorageStateInfo(testStateStorageInfo); err != nil {
+		t.Fatalf("Can't add state storage info: %v", err)
+	}
+
+	stateStorageInfos, err := testDB.GetAllStorageStateInfo()
+	if err != nil {
+		t.Fatalf("Can't get all state storage infos: %v", err)
+	}
+
+	if len(stateStorageInfos) != 1 {
+		t.Errorf("Unexpected state storage info size")
+	}
+
+	if stateStorageInfos[0].InstanceID != testInstanceID {
+		t.Errorf("Unexpected instance path")
+	}
+
+	if stateStorageInfos[0].InstanceIdent != testStateStorageInfo.InstanceIdent {
+		t.Errorf("Unexpected instance ident")
+	}
+
+	info, err := testDB.GetStorageStateInfo(instanceIdent)
+	if err != nil {
+		t.Fatalf("Can't get state storage info: %v", err)
+	}
+
+	if !reflect.DeepEqual(info, testStateStorageInfo) {
+		t.Error("State storage info from database doesn't match expected one")
+	}
+
+	notExistInstanceIdent := aostypes.InstanceIdent{
+		ServiceID: "serviceID",
+		SubjectID: "subjectID",
+		Instance:  20,
+	}
+
+	if err := testDB.SetStateChecksum(notExistInstanceIdent, newCheckSum); !errors.Is(err, storagestate.ErrNotExist) {
+		t.Errorf("Should be entry does not exist")
+	}
+
+	if err := testDB.SetStateChecksum(instanceIdent, newCheckSum); err != nil {
+		t.Fatalf("Can't update checksum: %v", err)
+	}
+
+	testStateStorageInfo.StateChecksum = newCheckSum
+
+	info, err = testDB.GetStorageStateInfo(instanceIdent)
+	if err != nil {
+		t.Fatalf("Can't get state storage info: %v", err)
+	}
+
+	if !reflect.DeepEqual(info, testStateStorageInfo) {
+		t.Error("Update state storage info from database doesn't match expected one")
+	}
+
+	if err := testDB.SetStorageStateQuotas(
+		notExistInstanceIdent, newStorageQuota, newStateQuota); !errors.Is(err, storagestate.ErrNotExist) {
+		t.Errorf("Should be: entry does not exist")
 	}
 
 	if err := testDB.SetStorageStateQuotas(instanceIdent, newStorageQuota, newStateQuota); err != nil {
 		t.Fatalf("Can't update state and storage quotas: %v", err)
 	}
 
-	testStateStorageInfo.StateQuota = newStateQuota
-	testStateStorageInfo.StorageQuota = newStorageQuota
+	testStateStorageInfo.StateQuota = newStateQuota
+	testStateStorageInfo.StorageQuota = newStorageQuota
+
+	info, err = testDB.GetStorageStateInfo(instanceIdent)
+	if err != nil {
+		t.Fatalf("Can't get state storage info: %v", err)
+	}
+
+	if !reflect.DeepEqual(info, testStateStorageInfo) {
+		t.Error("Update state storage info from database doesn't match expected one")
+	}
+
+	if err := testDB.RemoveStorageStateInfo(instanceIdent); err != nil {
+		t.Fatalf("Can't remove state storage info: %v", err)
+	}
+
+	if _, err := testDB.GetStorageStateInfo(instanceIdent); !errors.Is(err, storagestate.ErrNotExist) {
+		t.Errorf("Should be: entry does not exist")
+	}
+}
+
+func TestMonitoringRecords(t *testing.T) {
+	timestamp := time.Now().Truncate(time.Second)
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 1}
+
+	var records []monitorcontroller.MonitoringRecord
+
+	for i := range 5 {
+		records = append(records,
+			monitorcontroller.MonitoringRecord{
+				NodeID: "node1", Timestamp: timestamp.Add(time.Duration(i) * time.Second),
+				RAM: uint64(i * 100), CPU: uint64(i), Download: uint64(i * 10), Upload: uint64(i * 20),
+			},
+			monitorcontroller.MonitoringRecord{
+				NodeID: "node1", InstanceIdent: instanceIdent, Timestamp: timestamp.Add(time.Duration(i) * time.Second),
+				RAM: uint64(i * 50),
+			})
+	}
+
+	if err := testDB.AddMonitoringRecords(records); err != nil {
+		t.Fatalf("Can't add monitoring records: %v", err)
+	}
+
+	nodeRecords, err := testDB.GetMonitoringRecords(monitorcontroller.HistoryFilter{
+		NodeID: "node1", From: timestamp.Add(time.Second), Till: timestamp.Add(3 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Can't get monitoring records: %v", err)
+	}
+
+	if len(nodeRecords) != 3 {
+		t.Fatalf("Wrong node records count: %d", len(nodeRecords))
+	}
+
+	for i, record := range nodeRecords {
+		if !record.Timestamp.Equal(records[(i+1)*2].Timestamp) || record.RAM != records[(i+1)*2].RAM ||
+			record.ServiceID != "" {
+			t.Errorf("Wrong node record: %v", record)
+		}
+	}
+
+	instanceRecords, err := testDB.GetMonitoringRecords(monitorcontroller.HistoryFilter{
+		Instance: &instanceIdent, From: timestamp, Till: timestamp.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Can't get monitoring records: %v", err)
+	}
+
+	if len(instanceRecords) != 5 {
+		t.Fatalf("Wrong instance records count: %d", len(instanceRecords))
+	}
+
+	if _, err = testDB.applyRetentionPolicy(retentionClass{
+		retentionTable: retentionTables[0],
+		policy:         config.RetentionPolicy{MaxAge: aostypes.Duration{Duration: time.Hour}, MaxRecords: 6},
+	}, timestamp.Add(time.Hour+time.Second)); err != nil {
+		t.Fatalf("Can't apply retention policy: %v", err)
+	}
+
+	if nodeRecords, err = testDB.GetMonitoringRecords(monitorcontroller.HistoryFilter{
+		NodeID: "node1", From: timestamp, Till: timestamp.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("Can't get monitoring records: %v", err)
+	}
+
+	if len(nodeRecords) != 3 || !nodeRecords[0].Timestamp.Equal(timestamp.Add(2*time.Second)) {
+		t.Errorf("Wrong node records after cleanup: %v", nodeRecords)
+	}
+
+	if _, err = testDB.applyRetentionPolicy(retentionClass{
+		retentionTable: retentionTables[0],
+		policy:         config.RetentionPolicy{MaxAge: aostypes.Duration{Duration: time.Hour}},
+	}, timestamp.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Can't apply retention policy: %v", err)
+	}
+}
+
+func TestAuditRecords(t *testing.T) {
+	if _, err := testDB.GetLastAuditRecord(); !errors.Is(err, auditlog.ErrNotExist) {
+		t.Errorf("Not exist error expected: %v", err)
+	}
+
+	timestamp := time.Now().UTC()
+
+	var records []auditlog.Record
+
+	for i := range 4 {
+		record := auditlog.Record{
+			ID: uint64(i + 1), Timestamp: timestamp.Add(time.Duration(i) * time.Second), Source: auditlog.SourceCloud,
+			Action: "DesiredStatus", Hash: []byte{byte(i)}, Signature: []byte("signature"),
+		}
+
+		if i%2 == 1 {
+			record.Source, record.Actor, record.Action, record.Error = auditlog.SourceLocal, "hmi", "ChangeOwner", "failed"
+		}
+
+		if err := testDB.AddAuditRecord(record); err != nil {
+			t.Fatalf("Can't add audit record: %v", err)
+		}
+
+		records = append(records, record)
+	}
+
+	lastRecord, err := testDB.GetLastAuditRecord()
+	if err != nil {
+		t.Fatalf("Can't get last audit record: %v", err)
+	}
+
+	if !reflect.DeepEqual(lastRecord, records[3]) {
+		t.Errorf("Wrong last audit record: %v", lastRecord)
+	}
+
+	localRecords, err := testDB.GetAuditRecords(auditlog.Filter{Source: auditlog.SourceLocal})
+	if err != nil {
+		t.Fatalf("Can't get audit records: %v", err)
+	}
+
+	if !reflect.DeepEqual(localRecords, []auditlog.Record{records[1], records[3]}) {
+		t.Errorf("Wrong local audit records: %v", localRecords)
+	}
+
+	rangeRecords, err := testDB.GetAuditRecords(auditlog.Filter{
+		From: timestamp.Add(time.Second), Till: timestamp.Add(2 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Can't get audit records: %v", err)
+	}
+
+	if !reflect.DeepEqual(rangeRecords, records[1:3]) {
+		t.Errorf("Wrong audit records range: %v", rangeRecords)
+	}
+
+	if err = testDB.SetAuditRecordsExported(2); err != nil {
+		t.Fatalf("Can't set audit records exported: %v", err)
+	}
+
+	notExported, err := testDB.GetAuditRecords(auditlog.Filter{NotExported: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("Can't get audit records: %v", err)
+	}
+
+	if !reflect.DeepEqual(notExported, records[2:3]) {
+		t.Errorf("Wrong not exported audit records: %v", notExported)
+	}
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	timestamp := time.Now().UTC()
+
+	var events []lifecycle.Event
+
+	for i, eventType := range []string{
+		lifecycle.EventScheduled, lifecycle.EventSentToNode, lifecycle.EventFailed, lifecycle.EventMigrated,
+	} {
+		event := lifecycle.Event{ID: uint64(i + 1)}
+
+		event.InstanceIdent = aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 1}
+		event.Timestamp = timestamp.Add(time.Duration(i) * time.Second)
+		event.Event = eventType
+		event.NodeID = "node1"
+
+		switch eventType {
+		case lifecycle.EventFailed:
+			event.Error = "failed"
+
+		case lifecycle.EventMigrated:
+			event.NodeID, event.PrevNodeID = "node2", "node1"
+		}
+
+		if err := testDB.AddLifecycleEvent(event); err != nil {
+			t.Fatalf("Can't add lifecycle event: %v", err)
+		}
+
+		events = append(events, event)
+	}
+
+	notExported, err := testDB.GetNotExportedLifecycleEvents(3)
+	if err != nil {
+		t.Fatalf("Can't get lifecycle events: %v", err)
+	}
+
+	if !reflect.DeepEqual(notExported, events[:3]) {
+		t.Errorf("Wrong lifecycle events: %v", notExported)
+	}
+
+	if err = testDB.SetLifecycleEventsExported(2); err != nil {
+		t.Fatalf("Can't set lifecycle events exported: %v", err)
+	}
+
+	if notExported, err = testDB.GetNotExportedLifecycleEvents(10); err != nil {
+		t.Fatalf("Can't get lifecycle events: %v", err)
+	}
+
+	if !reflect.DeepEqual(notExported, events[2:]) {
+		t.Errorf("Wrong not exported lifecycle events: %v", notExported)
+	}
+
+	// Not exported events are kept
+
+	if err = testDB.SetLifecycleEventsExported(3); err != nil {
+		t.Fatalf("Can't set lifecycle events exported: %v", err)
+	}
+
+	if _, err = testDB.applyRetentionPolicy(retentionClass{
+		retentionTable: retentionTables[2], policy: config.RetentionPolicy{MaxRecords: 1},
+	}, time.Now()); err != nil {
+		t.Fatalf("Can't apply retention policy: %v", err)
+	}
+
+	var count int
+
+	if err = testDB.sql.QueryRow("SELECT COUNT(*) FROM lifecycle").Scan(&count); err != nil {
+		t.Fatalf("Can't count lifecycle events: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Wrong lifecycle events count: %d", count)
+	}
+
+	if notExported, err = testDB.GetNotExportedLifecycleEvents(10); err != nil {
+		t.Fatalf("Can't get lifecycle events: %v", err)
+	}
+
+	if !reflect.DeepEqual(notExported, events[3:]) {
+		t.Errorf("Wrong not exported lifecycle events: %v", notExported)
+	}
+}
+
+func TestUpdateHistory(t *testing.T) {
+	timestamp := time.Now().UTC()
+
+	var records []unitstatushandler.UpdateHistoryRecord
+
+	for i := 0; i < 4; i++ {
+		record := unitstatushandler.UpdateHistoryRecord{
+			Timestamp: timestamp.Add(time.Duration(i) * time.Second), ID: "service1",
+			Step: unitstatushandler.UpdateStepInstall, Size: uint64(i * 1024), Duration: time.Duration(i) * time.Minute,
+			CampaignID: fmt.Sprintf("campaign%d", i%2),
+		}
+
+		if err := testDB.AddUpdateHistoryRecord(record); err != nil {
+			t.Fatalf("Can't add update history record: %v", err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := testDB.AddUpdateHistoryRecord(unitstatushandler.UpdateHistoryRecord{
+		Timestamp: timestamp, ID: "service1", Step: unitstatushandler.UpdateStepDownload, Duration: time.Second,
+	}); err != nil {
+		t.Fatalf("Can't add update history record: %v", err)
+	}
+
+	history, err := testDB.GetUpdateHistoryRecords("service1", unitstatushandler.UpdateStepInstall, 2)
+	if err != nil {
+		t.Fatalf("Can't get update history records: %v", err)
+	}
+
+	if !reflect.DeepEqual(history, []unitstatushandler.UpdateHistoryRecord{records[3], records[2]}) {
+		t.Errorf("Wrong update history records: %v", history)
+	}
+
+	if err = testDB.RemoveOutdatedUpdateHistoryRecords("service1", unitstatushandler.UpdateStepInstall, 1); err != nil {
+		t.Fatalf("Can't remove outdated update history records: %v", err)
+	}
+
+	if history, err = testDB.GetUpdateHistoryRecords(
+		"service1", unitstatushandler.UpdateStepInstall, 10); err != nil {
+		t.Fatalf("Can't get update history records: %v", err)
+	}
+
+	if !reflect.DeepEqual(history, records[3:]) {
+		t.Errorf("Wrong update history records: %v", history)
+	}
+
+	// Records of other steps are kept
+
+	if history, err = testDB.GetUpdateHistoryRecords(
+		"service1", unitstatushandler.UpdateStepDownload, 10); err != nil {
+		t.Fatalf("Can't get update history records: %v", err)
+	}
+
+	if len(history) != 1 {
+		t.Errorf("Wrong update history records count: %d", len(history))
+	}
+}
+
+func TestGetSize(t *testing.T) {
+	size, err := testDB.GetSize()
+	if err != nil {
+		t.Fatalf("Can't get database size: %v", err)
+	}
+
+	if size == 0 {
+		t.Error("Database size should not be zero")
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	workingDir := filepath.Join(tmpDir, "maintenance")
+
+	maintenanceDB, err := New(&config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create database: %v", err)
+	}
+	defer maintenanceDB.Close()
+
+	timestamp := time.Now()
+	records := make([]monitorcontroller.MonitoringRecord, 0, 10000)
+
+	for i := range 10000 {
+		records = append(records, monitorcontroller.MonitoringRecord{
+			NodeID: "node1", Timestamp: timestamp.Add(time.Duration(i) * time.Second),
+			RAM: uint64(i * 100), CPU: uint64(i), Download: uint64(i * 10), Upload: uint64(i * 20),
+		})
+	}
+
+	if err = maintenanceDB.AddMonitoringRecords(records); err != nil {
+		t.Fatalf("Can't add monitoring records: %v", err)
+	}
+
+	size, err := maintenanceDB.GetSize()
+	if err != nil {
+		t.Fatalf("Can't get database size: %v", err)
+	}
+
+	maxSize := size / 2
+
+	if err = maintenanceDB.performMaintenance(maxSize); err != nil {
+		t.Fatalf("Can't perform maintenance: %v", err)
+	}
+
+	if size, err = maintenanceDB.GetSize(); err != nil {
+		t.Fatalf("Can't get database size: %v", err)
+	}
+
+	if size > maxSize {
+		t.Errorf("Database size %d exceeds max size %d", size, maxSize)
+	}
+
+	remainingRecords, err := maintenanceDB.GetMonitoringRecords(monitorcontroller.HistoryFilter{
+		From: timestamp, Till: timestamp.Add(10000 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Can't get monitoring records: %v", err)
+	}
+
+	if len(remainingRecords) == 0 || len(remainingRecords) == len(records) {
+		t.Fatalf("Wrong remaining records count: %d", len(remainingRecords))
+	}
+
+	if !remainingRecords[len(remainingRecords)-1].Timestamp.Equal(records[len(records)-1].Timestamp) {
+		t.Error("Newest records should not be pruned")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	workingDir := filepath.Join(tmpDir, "retention")
+
+	retentionDB, err := New(&config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+		Retention: config.Retention{Policies: map[string]config.RetentionPolicy{
+			config.RetentionAuditLog:      {MaxAge: aostypes.Duration{Duration: time.Hour}, Priority: 1},
+			config.RetentionUpdateHistory: {MaxRecords: 2, Priority: -1},
+		}},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create database: %v", err)
+	}
+	defer retentionDB.Close()
+
+	if retentionDB.retention[0].dataClass != config.RetentionUpdateHistory ||
+		retentionDB.retention[len(retentionDB.retention)-1].dataClass != config.RetentionAuditLog {
+		t.Errorf("Wrong data classes prune order: %v", retentionDB.retention)
+	}
+
+	timestamp := time.Now().Add(-2 * time.Hour)
+
+	for i := range 4 {
+		if err = retentionDB.AddAuditRecord(auditlog.Record{
+			ID: uint64(i + 1), Timestamp: timestamp.Add(time.Duration(i) * time.Second), Action: "action",
+		}); err != nil {
+			t.Fatalf("Can't add audit record: %v", err)
+		}
+
+		if err = retentionDB.AddUpdateHistoryRecord(unitstatushandler.UpdateHistoryRecord{
+			Timestamp: timestamp.Add(time.Duration(i) * time.Second), ID: "service1",
+			Step: unitstatushandler.UpdateStepInstall,
+		}); err != nil {
+			t.Fatalf("Can't add update history record: %v", err)
+		}
+	}
+
+	if err = retentionDB.SetAuditRecordsExported(4); err != nil {
+		t.Fatalf("Can't set audit records exported: %v", err)
+	}
+
+	if err = retentionDB.applyRetention(); err != nil {
+		t.Fatalf("Can't apply retention: %v", err)
+	}
+
+	// The last audit record is kept to continue hash chain
+
+	auditRecords, err := retentionDB.GetAuditRecords(auditlog.Filter{})
+	if err != nil {
+		t.Fatalf("Can't get audit records: %v", err)
+	}
+
+	if len(auditRecords) != 1 || auditRecords[0].ID != 4 {
+		t.Errorf("Wrong audit records: %v", auditRecords)
+	}
+
+	historyRecords, err := retentionDB.GetUpdateHistoryRecords("service1", unitstatushandler.UpdateStepInstall, 10)
+	if err != nil {
+		t.Fatalf("Can't get update history records: %v", err)
+	}
+
+	if len(historyRecords) != 2 || !historyRecords[0].Timestamp.Equal(timestamp.Add(3*time.Second)) {
+		t.Errorf("Wrong update history records: %v", historyRecords)
+	}
+}
+
+func TestExportImportSnapshot(t *testing.T) {
+	setCursor := "snapshotCursor123"
+
+	if err := testDB.SetJournalCursor(setCursor); err != nil {
+		t.Fatalf("Can't set logging cursor: %v", err)
+	}
+
+	snapshotFile := filepath.Join(tmpDir, "snapshot.db")
+
+	if err := testDB.ExportSnapshot(snapshotFile); err != nil {
+		t.Fatalf("Can't export snapshot: %v", err)
+	}
+
+	workingDir := filepath.Join(tmpDir, "imported")
+
+	if err := os.MkdirAll(workingDir, 0o755); err != nil {
+		t.Fatalf("Can't create working dir: %v", err)
+	}
+
+	dbConfig := &config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+	}
+
+	if err := ImportSnapshot(dbConfig, snapshotFile); err != nil {
+		t.Fatalf("Can't import snapshot: %v", err)
+	}
+
+	importedDB, err := New(dbConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't open imported database: %v", err)
+	}
+	defer importedDB.Close()
+
+	getCursor, err := importedDB.GetJournalCursor()
+	if err != nil {
+		t.Fatalf("Can't get logger cursor: %v", err)
+	}
+
+	if getCursor != setCursor {
+		t.Errorf("Wrong cursor value: %s", getCursor)
+	}
+}
+
+func TestClear(t *testing.T) {
+	workingDir := filepath.Join(tmpDir, "clear")
+
+	if err := os.MkdirAll(workingDir, 0o755); err != nil {
+		t.Fatalf("Can't create working dir: %v", err)
+	}
+
+	db, err := New(&config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create database: %v", err)
+	}
+	defer db.Close()
+
+	cursor := "clearCursor"
+
+	if err = db.SetJournalCursor(cursor); err != nil {
+		t.Fatalf("Can't set journal cursor: %v", err)
+	}
+
+	if err = db.SetSoftwareUpdateState(json.RawMessage("sotaState")); err != nil {
+		t.Fatalf("Can't set SOTA state: %v", err)
+	}
+
+	if err = db.AddInstance(launcher.InstanceInfo{InstanceIdent: createInstanceIdent(300), UID: 300}); err != nil {
+		t.Fatalf("Can't add instance: %v", err)
+	}
+
+	if err = db.AddStorageStateInfo(storagestate.StorageStateInstanceInfo{
+		InstanceID: "clearInstance", InstanceIdent: createInstanceIdent(300),
+	}); err != nil {
+		t.Fatalf("Can't add storage state info: %v", err)
+	}
+
+	if err = db.Clear(); err != nil {
+		t.Fatalf("Can't clear database: %v", err)
+	}
+
+	instances, err := db.GetInstances()
+	if err != nil {
+		t.Fatalf("Can't get instances: %v", err)
+	}
+
+	if len(instances) != 0 {
+		t.Errorf("Unexpected instances: %v", instances)
+	}
+
+	storageStateInfos, err := db.GetAllStorageStateInfo()
+	if err != nil {
+		t.Fatalf("Can't get storage state infos: %v", err)
+	}
+
+	if len(storageStateInfos) != 0 {
+		t.Errorf("Unexpected storage state infos: %v", storageStateInfos)
+	}
+
+	sotaState, err := db.GetSoftwareUpdateState()
+	if err != nil {
+		t.Fatalf("Can't get SOTA state: %v", err)
+	}
+
+	if len(sotaState) != 0 {
+		t.Errorf("Unexpected SOTA state: %s", sotaState)
+	}
+
+	getCursor, err := db.GetJournalCursor()
+	if err != nil {
+		t.Fatalf("Can't get journal cursor: %v", err)
+	}
+
+	if getCursor != cursor {
+		t.Errorf("Wrong cursor value: %s", getCursor)
+	}
+}
+
+func TestIntegrityRecovery(t *testing.T) {
+	workingDir := filepath.Join(tmpDir, "integrity")
+
+	dbConfig := &config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+	}
+
+	setCursor := "integrityCursor123"
+
+	integrityDB, err := New(dbConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create database: %v", err)
+	}
+
+	if integrityDB.GetRecoveryReport() != nil {
+		t.Error("Unexpected recovery report")
+	}
+
+	if err = integrityDB.SetJournalCursor(setCursor); err != nil {
+		t.Fatalf("Can't set logging cursor: %v", err)
+	}
+
+	integrityDB.Close()
+
+	// Reopen to save last known good snapshot with the cursor
+	if integrityDB, err = New(dbConfig, nil, nil); err != nil {
+		t.Fatalf("Can't open database: %v", err)
+	}
+
+	if err = integrityDB.SetJournalCursor("uncleanCursor"); err != nil {
+		t.Fatalf("Can't set logging cursor: %v", err)
+	}
+
+	integrityDB.Close()
+
+	// Last known good snapshot should not be saved after unclean shutdown
+	if err = os.Remove(filepath.Join(workingDir, dbFileName+cleanShutdownSuffix)); err != nil {
+		t.Fatalf("Can't remove clean shutdown mark: %v", err)
+	}
+
+	if integrityDB, err = New(dbConfig, nil, nil); err != nil {
+		t.Fatalf("Can't open database: %v", err)
+	}
+
+	integrityDB.Close()
+
+	if err = corruptDatabase(filepath.Join(workingDir, dbFileName)); err != nil {
+		t.Fatalf("Can't corrupt database: %v", err)
+	}
+
+	if integrityDB, err = New(dbConfig, nil, nil); err != nil {
+		t.Fatalf("Can't open corrupted database: %v", err)
+	}
+
+	report := integrityDB.GetRecoveryReport()
+	if report == nil || report.Action != RecoveryActionSnapshot {
+		t.Errorf("Wrong recovery report: %v", report)
+	}
+
+	getCursor, err := integrityDB.GetJournalCursor()
+	if err != nil {
+		t.Fatalf("Can't get logger cursor: %v", err)
+	}
+
+	if getCursor != setCursor {
+		t.Errorf("Wrong cursor value: %s", getCursor)
+	}
+
+	integrityDB.Close()
+
+	// Without last known good snapshot, not recoverable database should fail
+	if err = os.Remove(filepath.Join(workingDir, dbFileName+lastKnownGoodSuffix)); err != nil {
+		t.Fatalf("Can't remove last known good snapshot: %v", err)
+	}
+
+	if err = corruptDatabase(filepath.Join(workingDir, dbFileName)); err != nil {
+		t.Fatalf("Can't corrupt database: %v", err)
+	}
+
+	if _, err = New(dbConfig, nil, nil); err == nil {
+		t.Error("Error expected for not recoverable database")
+	}
+}
+
+func TestEncryptedDatabase(t *testing.T) {
+	workingDir := filepath.Join(tmpDir, "encrypted")
+	runtimeDir := filepath.Join(tmpDir, "encryptedRuntime")
+
+	cert, key, err := createTestCertificate()
+	if err != nil {
+		t.Fatalf("Can't create test certificate: %v", err)
+	}
+
+	dbConfig := &config.Config{
+		WorkingDir: workingDir,
+		Migration: config.Migration{
+			MigrationPath:       "migration",
+			MergedMigrationPath: filepath.Join(workingDir, "migration"),
+		},
+		DatabaseEncryption: config.DatabaseEncryption{
+			Enabled:    true,
+			CertType:   "cm",
+			RuntimeDir: runtimeDir,
+		},
+	}
 
-	info, err = testDB.GetStorageStateInfo(instanceIdent)
+	if _, err = New(dbConfig, nil, nil); err == nil {
+		t.Error("Error expected if certificate provider is not set")
+	}
+
+	certProvider := &testCertProvider{cert: cert}
+	cryptoContext := &testCryptoContext{cert: cert, key: key}
+
+	encryptedDB, err := New(dbConfig, certProvider, cryptoContext)
 	if err != nil {
-		t.Fatalf("Can't get state storage info: %v", err)
+		t.Fatalf("Can't create encrypted database: %v", err)
 	}
 
-	if !reflect.DeepEqual(info, testStateStorageInfo) {
-		t.Error("Update state storage info from database doesn't match expected one")
+	setCursor := "encryptedCursor123"
+
+	if err = encryptedDB.SetJournalCursor(setCursor); err != nil {
+		t.Fatalf("Can't set logging cursor: %v", err)
 	}
 
-	if err := testDB.RemoveStorageStateInfo(instanceIdent); err != nil {
-		t.Fatalf("Can't remove state storage info: %v", err)
+	encryptedDB.Close()
+
+	if _, err = os.Stat(filepath.Join(workingDir, dbFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Not encrypted database should not exist")
 	}
 
-	if _, err := testDB.GetStorageStateInfo(instanceIdent); !errors.Is(err, storagestate.ErrNotExist) {
-		t.Errorf("Should be: entry does not exist")
+	if _, err = os.Stat(filepath.Join(runtimeDir, dbFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Runtime database should be removed on close")
+	}
+
+	encryptedData, err := os.ReadFile(filepath.Join(workingDir, dbFileName+encryptedFileSuffix))
+	if err != nil {
+		t.Fatalf("Can't read encrypted database: %v", err)
+	}
+
+	if bytes.Contains(encryptedData, []byte(setCursor)) {
+		t.Error("Encrypted database contains plain data")
+	}
+
+	if encryptedDB, err = New(dbConfig, certProvider, cryptoContext); err != nil {
+		t.Fatalf("Can't open encrypted database: %v", err)
+	}
+	defer encryptedDB.Close()
+
+	getCursor, err := encryptedDB.GetJournalCursor()
+	if err != nil {
+		t.Fatalf("Can't get logger cursor: %v", err)
+	}
+
+	if getCursor != setCursor {
+		t.Errorf("Wrong cursor value: %s", getCursor)
+	}
+
+	// Database is encrypted only if it is changed since the last sync
+
+	encryptedFile := filepath.Join(workingDir, dbFileName+encryptedFileSuffix)
+
+	if err = encryptedDB.encryption.sync(encryptedDB.sql); err != nil {
+		t.Fatalf("Can't sync encrypted database: %v", err)
+	}
+
+	if encryptedData, err = os.ReadFile(encryptedFile); err != nil {
+		t.Fatalf("Can't read encrypted database: %v", err)
+	}
+
+	if err = encryptedDB.encryption.sync(encryptedDB.sql); err != nil {
+		t.Fatalf("Can't sync encrypted database: %v", err)
+	}
+
+	if data, err := os.ReadFile(encryptedFile); err != nil || !bytes.Equal(data, encryptedData) {
+		t.Errorf("Not changed database is encrypted again: %v", err)
+	}
+
+	if err = encryptedDB.SetJournalCursor("changedCursor"); err != nil {
+		t.Fatalf("Can't set logging cursor: %v", err)
+	}
+
+	if err = encryptedDB.encryption.sync(encryptedDB.sql); err != nil {
+		t.Fatalf("Can't sync encrypted database: %v", err)
+	}
+
+	if data, err := os.ReadFile(encryptedFile); err != nil || bytes.Equal(data, encryptedData) {
+		t.Errorf("Changed database is not encrypted: %v", err)
 	}
 }
 
@@ -1141,8 +2548,104 @@ func TestMigration(t *testing.T) {
 		t.Fatalf("Error checking db version: %v", err)
 	}
 
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 5); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer5(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 6); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer6(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 7); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer7(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 8); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer8(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 9); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer9(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 10); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer10(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
 	// Migration downward
 
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 9); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer9(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 8); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer8(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 7); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer7(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 6); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer6(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 5); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer5(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
+	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 4); err != nil {
+		t.Fatalf("Can't perform migration: %v", err)
+	}
+
+	if err = checkDatabaseVer4(migrationDB); err != nil {
+		t.Fatalf("Error checking db version: %v", err)
+	}
+
 	if err = migration.DoMigrate(migrationDB, mergedMigrationDir, 3); err != nil {
 		t.Fatalf("Can't perform migration: %v", err)
 	}
@@ -1422,6 +2925,121 @@ func checkDatabaseVer4(sqlite *sql.DB) error {
 	return nil
 }
 
+func checkDatabaseVer5(sqlite *sql.DB) error {
+	if err := checkDatabaseVer4(sqlite); err != nil {
+		return err
+	}
+
+	exist, err := isColumnExist(sqlite, "services", "healthEndpoints")
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errWrongVersion
+	}
+
+	return nil
+}
+
+func checkDatabaseVer6(sqlite *sql.DB) error {
+	if err := checkDatabaseVer5(sqlite); err != nil {
+		return err
+	}
+
+	exist, err := isColumnExist(sqlite, "services", "healthChecks")
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errWrongVersion
+	}
+
+	return nil
+}
+
+func checkDatabaseVer7(sqlite *sql.DB) error {
+	if err := checkDatabaseVer6(sqlite); err != nil {
+		return err
+	}
+
+	exist, err := isColumnExist(sqlite, "services", "layerMediaTypes")
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errWrongVersion
+	}
+
+	return nil
+}
+
+func checkDatabaseVer8(sqlite *sql.DB) error {
+	if err := checkDatabaseVer7(sqlite); err != nil {
+		return err
+	}
+
+	exist, err := isColumnExist(sqlite, "services", "sharedNetwork")
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errWrongVersion
+	}
+
+	if exist, err = isColumnExist(sqlite, "updatehistory", "campaignID"); err != nil {
+		return err
+	}
+
+	if exist {
+		return errWrongVersion
+	}
+
+	return nil
+}
+
+func checkDatabaseVer9(sqlite *sql.DB) error {
+	exist, err := isColumnExist(sqlite, "updatehistory", "campaignID")
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return errWrongVersion
+	}
+
+	if exist, err = isTableExist(sqlite, "audit"); err != nil {
+		return err
+	}
+
+	if exist {
+		return errWrongVersion
+	}
+
+	return nil
+}
+
+func checkDatabaseVer10(sqlite *sql.DB) error {
+	for _, table := range []string{
+		"ipam", "monitoring", "audit", "lifecycle", "updatehistory", "maintenance", "overridebundles", "campaigns",
+		"emergencyupdates", "featureflags", "bandwidthusage", "advisories", "nodereboot", "sboms",
+	} {
+		exist, err := isTableExist(sqlite, table)
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			return errWrongVersion
+		}
+	}
+
+	return nil
+}
+
 func isTableExist(sqlite *sql.DB, tableName string) (exist bool, err error) {
 	if err = sqlite.QueryRow(
 		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = ? and type='table')",
@@ -1457,6 +3075,46 @@ func isColumnsExist(sqlite *sql.DB, tableName string, columns []string) (bool, e
 	return false, nil
 }
 
+func corruptDatabase(fileName string) error {
+	file, err := os.OpenFile(fileName, os.O_WRONLY, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	if _, err = file.WriteAt(bytes.Repeat([]byte{0xff}, 512), 0); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func createTestCertificate() (*x509.Certificate, crypto.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cm"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certData, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	return cert, key, nil
+}
+
 func createInstanceIdent(index int) aostypes.InstanceIdent {
 	return aostypes.InstanceIdent{
 		ServiceID: servicePrefix + strconv.Itoa(index),
@@ -1464,3 +3122,40 @@ func createInstanceIdent(index int) aostypes.InstanceIdent {
 		Instance:  uint64(index),
 	}
 }
+
+/***********************************************************************************************************************
+ * testCertProvider
+ **********************************************************************************************************************/
+
+func (provider *testCertProvider) GetCertificate(
+	certType string, issuer []byte, serial string,
+) (certURL, keyURL string, err error) {
+	if issuer != nil && (!bytes.Equal(issuer, provider.cert.RawIssuer) ||
+		serial != fmt.Sprintf("%X", provider.cert.SerialNumber)) {
+		return "", "", aoserrors.New("certificate not found")
+	}
+
+	return "cert:" + certType, "key:" + certType, nil
+}
+
+/***********************************************************************************************************************
+ * testCryptoContext
+ **********************************************************************************************************************/
+
+func (context *testCryptoContext) LoadCertificateByURL(certURL string) ([]*x509.Certificate, error) {
+	return []*x509.Certificate{context.cert}, nil
+}
+
+func (context *testCryptoContext) LoadPrivateKeyByURL(
+	keyURL string,
+) (privKey crypto.PrivateKey, supportPKCS1v15SessionKey bool, err error) {
+	return context.key, false, nil
+}
+
+/***********************************************************************************************************************
+ * testStatePublisher
+ **********************************************************************************************************************/
+
+func (publisher *testStatePublisher) Publish(kind, operation string, data any) {
+	publisher.changes = append(publisher.changes, testStateChange{kind: kind, operation: operation, data: data})
+}
@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	encryptedFileSuffix = ".enc"
+	snapshotFileSuffix  = ".snapshot"
+	encryptionVersion   = 1
+	dataKeySize         = 32
+	headerSizeLen       = 4
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrEncryptionKeyNotAvailable indicates that the key of encrypted database can't be retrieved.
+var ErrEncryptionKeyNotAvailable = errors.New("database encryption key not available")
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CertificateProvider provides certificate which key is used to wrap database encryption key.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+}
+
+// CryptoContext loads certificates and keys.
+type CryptoContext interface {
+	LoadCertificateByURL(certURL string) ([]*x509.Certificate, error)
+	LoadPrivateKeyByURL(keyURL string) (privKey crypto.PrivateKey, supportPKCS1v15SessionKey bool, err error)
+}
+
+// Database file is encrypted with random data key (AES-GCM). The data key is wrapped with the public key of the
+// configured certificate (RSA-OAEP) and stored in the encrypted file header. While CM is running, decrypted database
+// is located in the runtime directory which is expected to be non persistent storage. The database is encrypted each
+// sync period only if it is changed, and on close. Changes committed after the last sync are lost on power loss.
+type encryptedStorage struct {
+	sync.Mutex
+
+	dataVersion   func() (uint64, error)
+	synced        bool
+	syncedVersion uint64
+
+	config        config.DatabaseEncryption
+	encryptedFile string
+	plainFile     string
+	runtimeFile   string
+	dataKey       []byte
+	certProvider  CertificateProvider
+	cryptoContext CryptoContext
+	cancelFunc    context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+type encryptionHeader struct {
+	Version    int    `json:"version"`
+	Issuer     []byte `json:"issuer"`
+	Serial     string `json:"serial"`
+	WrappedKey []byte `json:"wrappedKey"`
+	Nonce      []byte `json:"nonce"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newEncryptedStorage(
+	cfg config.DatabaseEncryption, fileName string, certProvider CertificateProvider, cryptoContext CryptoContext,
+) (storage *encryptedStorage, err error) {
+	if certProvider == nil || cryptoContext == nil {
+		return nil, aoserrors.New("certificate provider is required for database encryption")
+	}
+
+	storage = &encryptedStorage{
+		config:        cfg,
+		encryptedFile: fileName + encryptedFileSuffix,
+		plainFile:     fileName,
+		runtimeFile:   filepath.Join(cfg.RuntimeDir, filepath.Base(fileName)),
+		certProvider:  certProvider,
+		cryptoContext: cryptoContext,
+	}
+
+	log.WithField("runtimeFile", storage.runtimeFile).Debug("Use encrypted database")
+
+	if err = os.MkdirAll(cfg.RuntimeDir, 0o700); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = removeDatabaseFiles(storage.runtimeFile); err != nil {
+		return nil, err
+	}
+
+	if _, err = os.Stat(storage.encryptedFile); err == nil {
+		if err = storage.decrypt(); err != nil {
+			return nil, err
+		}
+
+		return storage, nil
+	}
+
+	if storage.dataKey, err = generateDataKey(); err != nil {
+		return nil, err
+	}
+
+	// not encrypted database exists, it will be encrypted and removed on first sync
+	if _, err = os.Stat(storage.plainFile); err == nil {
+		log.Info("Encrypt existing database")
+
+		if err = copyFile(storage.plainFile, storage.runtimeFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return storage, nil
+}
+
+func (storage *encryptedStorage) start(sqlDB *sql.DB, dataVersion func() (uint64, error)) {
+	storage.dataVersion = dataVersion
+
+	if storage.config.SyncPeriod.Duration <= 0 {
+		return
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	storage.cancelFunc = cancelFunc
+
+	storage.wg.Add(1)
+
+	go func() {
+		defer storage.wg.Done()
+
+		syncTicker := time.NewTicker(storage.config.SyncPeriod.Duration)
+		defer syncTicker.Stop()
+
+		for {
+			select {
+			case <-syncTicker.C:
+				if err := storage.sync(sqlDB); err != nil {
+					log.Errorf("Can't sync encrypted database: %v", err)
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (storage *encryptedStorage) close(sqlDB *sql.DB) {
+	if storage.cancelFunc != nil {
+		storage.cancelFunc()
+	}
+
+	storage.wg.Wait()
+
+	if err := storage.sync(sqlDB); err != nil {
+		log.Errorf("Can't sync encrypted database: %v", err)
+	}
+}
+
+func (storage *encryptedStorage) removeRuntimeFiles() {
+	if err := removeDatabaseFiles(storage.runtimeFile); err != nil {
+		log.Errorf("Can't remove runtime database: %v", err)
+	}
+}
+
+// sync encrypts the database if it is changed since the last sync.
+func (storage *encryptedStorage) sync(sqlDB *sql.DB) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	var (
+		version uint64
+		err     error
+	)
+
+	if storage.dataVersion != nil {
+		if version, err = storage.dataVersion(); err != nil {
+			return err
+		}
+
+		if storage.synced && version == storage.syncedVersion {
+			return nil
+		}
+	}
+
+	if err = storage.encryptSnapshot(sqlDB); err != nil {
+		return err
+	}
+
+	storage.synced = true
+	storage.syncedVersion = version
+
+	return nil
+}
+
+func (storage *encryptedStorage) encryptSnapshot(sqlDB *sql.DB) error {
+	snapshotFile := storage.runtimeFile + snapshotFileSuffix
+
+	if err := os.RemoveAll(snapshotFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer os.RemoveAll(snapshotFile)
+
+	if _, err := sqlDB.Exec("VACUUM INTO ?", snapshotFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = storage.encrypt(data); err != nil {
+		return err
+	}
+
+	return removeDatabaseFiles(storage.plainFile)
+}
+
+func (storage *encryptedStorage) encrypt(data []byte) error {
+	certURL, _, err := storage.certProvider.GetCertificate(storage.config.CertType, nil, "")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	certs, err := storage.cryptoContext.LoadCertificateByURL(certURL)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(certs) == 0 {
+		return aoserrors.New("database encryption certificate not found")
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return aoserrors.New("database encryption requires RSA certificate")
+	}
+
+	header := encryptionHeader{
+		Version: encryptionVersion,
+		Issuer:  certs[0].RawIssuer,
+		Serial:  fmt.Sprintf("%X", certs[0].SerialNumber),
+	}
+
+	if header.WrappedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, storage.dataKey, nil); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	aead, err := newAEAD(storage.dataKey)
+	if err != nil {
+		return err
+	}
+
+	header.Nonce = make([]byte, aead.NonceSize())
+
+	if _, err = io.ReadFull(rand.Reader, header.Nonce); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	output := binary.BigEndian.AppendUint32(nil, uint32(len(headerData))) //nolint:gosec
+	output = append(output, headerData...)
+	output = aead.Seal(output, header.Nonce, data, headerData)
+
+	return writeFileAtomically(storage.encryptedFile, output)
+}
+
+func (storage *encryptedStorage) decrypt() error {
+	input, err := os.ReadFile(storage.encryptedFile)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(input) < headerSizeLen {
+		return aoserrors.New("wrong encrypted database format")
+	}
+
+	headerSize := int(binary.BigEndian.Uint32(input))
+
+	if len(input) < headerSizeLen+headerSize {
+		return aoserrors.New("wrong encrypted database format")
+	}
+
+	headerData := input[headerSizeLen : headerSizeLen+headerSize]
+
+	var header encryptionHeader
+
+	if err = json.Unmarshal(headerData, &header); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if header.Version != encryptionVersion {
+		return aoserrors.Errorf("unsupported encrypted database version: %d", header.Version)
+	}
+
+	if storage.dataKey, err = storage.unwrapKey(header); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(storage.dataKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := aead.Open(nil, header.Nonce, input[headerSizeLen+headerSize:], headerData)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(storage.runtimeFile, data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (storage *encryptedStorage) unwrapKey(header encryptionHeader) ([]byte, error) {
+	_, keyURL, err := storage.certProvider.GetCertificate(storage.config.CertType, header.Issuer, header.Serial)
+	if err != nil {
+		return nil, aoserrors.Errorf("%w: %v", ErrEncryptionKeyNotAvailable, err)
+	}
+
+	privKey, _, err := storage.cryptoContext.LoadPrivateKeyByURL(keyURL)
+	if err != nil {
+		return nil, aoserrors.Errorf("%w: %v", ErrEncryptionKeyNotAvailable, err)
+	}
+
+	decrypter, ok := privKey.(crypto.Decrypter)
+	if !ok {
+		return nil, aoserrors.New("private key doesn't support decryption")
+	}
+
+	dataKey, err := decrypter.Decrypt(rand.Reader, header.WrappedKey, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return dataKey, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return aead, nil
+}
+
+func generateDataKey() ([]byte, error) {
+	key := make([]byte, dataKeySize)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func writeFileAtomically(fileName string, data []byte) error {
+	tmpFile := fileName + ".tmp"
+
+	file, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+
+		return aoserrors.Wrap(err)
+	}
+
+	if err = file.Sync(); err != nil {
+		file.Close()
+
+		return aoserrors.Wrap(err)
+	}
+
+	if err = file.Close(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.Rename(tmpFile, fileName); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(dst, data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func removeDatabaseFiles(fileName string) error {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(fileName + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
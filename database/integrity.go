@@ -0,0 +1,352 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Database recovery actions.
+const (
+	RecoveryActionReindex  = "reindex"
+	RecoveryActionSnapshot = "snapshot"
+	RecoveryActionSalvage  = "salvage"
+)
+
+const (
+	lastKnownGoodSuffix = ".lkg"
+	salvageSuffix       = ".salvage"
+	cleanShutdownSuffix = ".clean"
+	integrityCheckOK    = "ok"
+	// max number of integrity errors reported.
+	maxIntegrityErrors = 10
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// RecoveryReport contains information about database recovery performed at startup.
+type RecoveryReport struct {
+	Errors     []string
+	Action     string
+	LostTables []string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetRecoveryReport returns database recovery report or nil if database was not recovered.
+func (db *Database) GetRecoveryReport() *RecoveryReport {
+	return db.recoveryReport
+}
+
+// String returns string representation of the recovery report.
+func (report *RecoveryReport) String() string {
+	message := fmt.Sprintf("database recovered with %s: %s", report.Action, strings.Join(report.Errors, "; "))
+
+	switch report.Action {
+	case RecoveryActionSnapshot:
+		message += ", changes after last known good snapshot are lost"
+
+	case RecoveryActionSalvage:
+		if len(report.LostTables) != 0 {
+			message += ", lost tables: " + strings.Join(report.LostTables, ", ")
+		}
+	}
+
+	return message
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func openSQLite(fileName string) (*sql.DB, error) {
+	sqlite, err := sql.Open("sqlite3", fmt.Sprintf("%s?_busy_timeout=%d&_journal_mode=%s&_sync=%s",
+		fileName, busyTimeout, journalMode, syncMode))
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return sqlite, nil
+}
+
+// checkIntegrity checks database integrity and tries to recover corrupted database: reindex, restore last known good
+// snapshot and salvage readable tables. Last known good snapshot is updated only if the database was closed cleanly
+// on previous shutdown. It is not used for encrypted database as it would be stored unencrypted.
+func (db *Database) checkIntegrity(fileName string) error {
+	cleanShutdown := isCleanShutdown(fileName)
+
+	integrityErrors := db.getIntegrityErrors()
+	if len(integrityErrors) == 0 {
+		if cleanShutdown {
+			db.saveLastKnownGood(fileName)
+		}
+
+		return nil
+	}
+
+	log.WithField("errors", integrityErrors).Error("Database integrity check failed")
+
+	db.recoveryReport = &RecoveryReport{Errors: integrityErrors}
+
+	if _, err := db.sql.Exec("REINDEX"); err == nil && len(db.getIntegrityErrors()) == 0 {
+		log.Warn("Database recovered by reindex")
+
+		db.recoveryReport.Action = RecoveryActionReindex
+
+		return nil
+	}
+
+	if db.encryption == nil {
+		if err := db.restoreLastKnownGood(fileName); err == nil {
+			log.Warn("Database recovered from last known good snapshot")
+
+			db.recoveryReport.Action = RecoveryActionSnapshot
+
+			return nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Errorf("Can't restore last known good snapshot: %v", err)
+		}
+	}
+
+	if err := db.salvage(fileName); err != nil {
+		return err
+	}
+
+	log.WithField("lostTables", db.recoveryReport.LostTables).Warn("Database recovered by salvage")
+
+	db.recoveryReport.Action = RecoveryActionSalvage
+
+	return nil
+}
+
+// getIntegrityErrors performs quick check which doesn't verify indexes content, so startup time doesn't grow with
+// the database size as much as with full integrity check.
+func (db *Database) getIntegrityErrors() (integrityErrors []string) {
+	rows, err := db.sql.Query(fmt.Sprintf("PRAGMA quick_check(%d)", maxIntegrityErrors))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result string
+
+		if err = rows.Scan(&result); err != nil {
+			return append(integrityErrors, err.Error())
+		}
+
+		if result != integrityCheckOK {
+			integrityErrors = append(integrityErrors, result)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		integrityErrors = append(integrityErrors, err.Error())
+	}
+
+	return integrityErrors
+}
+
+// isCleanShutdown returns if the database was closed cleanly on previous shutdown. Clean shutdown mark is removed, so
+// it is not set if CM is not stopped cleanly this time.
+func isCleanShutdown(fileName string) bool {
+	markFile := fileName + cleanShutdownSuffix
+
+	if _, err := os.Stat(markFile); err != nil {
+		return false
+	}
+
+	if err := os.Remove(markFile); err != nil {
+		log.Errorf("Can't remove clean shutdown mark: %v", err)
+	}
+
+	return true
+}
+
+func (db *Database) setCleanShutdown() {
+	if err := os.WriteFile(db.fileName+cleanShutdownSuffix, nil, 0o600); err != nil {
+		log.Errorf("Can't set clean shutdown mark: %v", err)
+	}
+}
+
+func (db *Database) saveLastKnownGood(fileName string) {
+	if db.encryption != nil {
+		return
+	}
+
+	snapshotFile := fileName + lastKnownGoodSuffix
+
+	if err := db.ExportSnapshot(snapshotFile + ".tmp"); err != nil {
+		log.Errorf("Can't save last known good database: %v", err)
+
+		return
+	}
+
+	if err := os.Rename(snapshotFile+".tmp", snapshotFile); err != nil {
+		log.Errorf("Can't save last known good database: %v", err)
+	}
+}
+
+func (db *Database) restoreLastKnownGood(fileName string) (err error) {
+	snapshotFile := fileName + lastKnownGoodSuffix
+
+	if _, err = os.Stat(snapshotFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = db.replaceDatabase(fileName, snapshotFile, copyFile); err != nil {
+		return err
+	}
+
+	if integrityErrors := db.getIntegrityErrors(); len(integrityErrors) != 0 {
+		return aoserrors.Errorf("snapshot integrity check failed: %s", strings.Join(integrityErrors, "; "))
+	}
+
+	return nil
+}
+
+func (db *Database) salvage(fileName string) (err error) {
+	salvageFile := fileName + salvageSuffix
+
+	if err = removeDatabaseFiles(salvageFile); err != nil {
+		return err
+	}
+
+	salvageDB, err := openSQLite(salvageFile)
+	if err != nil {
+		return err
+	}
+
+	if db.recoveryReport.LostTables, err = salvageTables(salvageDB, fileName); err != nil {
+		salvageDB.Close()
+
+		return err
+	}
+
+	if err = salvageDB.Close(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return db.replaceDatabase(fileName, salvageFile, func(src, dst string) error {
+		return aoserrors.Wrap(os.Rename(src, dst))
+	})
+}
+
+func salvageTables(salvageDB *sql.DB, fileName string) (lostTables []string, err error) {
+	ctx := context.Background()
+
+	// attached database is visible only within the connection
+	conn, err := salvageDB.Conn(ctx)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS corrupted", fileName); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if _, detachErr := conn.ExecContext(ctx, "DETACH DATABASE corrupted"); detachErr != nil && err == nil {
+			err = aoserrors.Wrap(detachErr)
+		}
+	}()
+
+	tables, err := getTablesSchema(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, schema := range tables {
+		if _, err = conn.ExecContext(ctx, schema); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if _, err = conn.ExecContext(
+			ctx, fmt.Sprintf(`INSERT INTO main."%s" SELECT * FROM corrupted."%s"`, name, name)); err != nil {
+			log.WithField("table", name).Errorf("Can't salvage table: %v", err)
+
+			lostTables = append(lostTables, name)
+		}
+	}
+
+	return lostTables, nil
+}
+
+func getTablesSchema(ctx context.Context, conn *sql.Conn) (map[string]string, error) {
+	rows, err := conn.QueryContext(ctx,
+		"SELECT name, sql FROM corrupted.sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string]string)
+
+	for rows.Next() {
+		var name, schema string
+
+		if err = rows.Scan(&name, &schema); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		tables[name] = schema
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return tables, nil
+}
+
+func (db *Database) replaceDatabase(fileName, newFile string, replaceFunc func(src, dst string) error) (err error) {
+	if err = db.sql.Close(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = removeDatabaseFiles(fileName); err != nil {
+		return err
+	}
+
+	if err = replaceFunc(newFile, fileName); err != nil {
+		return err
+	}
+
+	if db.sql, err = openSQLite(fileName); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// part of history table records removed at once when database exceeds max size.
+const pruneRatio = 10
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type retentionTable struct {
+	dataClass string
+	table     string
+	// condition selects records which may be removed.
+	condition string
+}
+
+type retentionClass struct {
+	retentionTable
+	policy    config.RetentionPolicy
+	hasPolicy bool
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// history tables of retention data classes in default pruning order. All tables should have timestamp column. Not
+// exported lifecycle events and audit records are kept as well as the last audit record which continues hash chain.
+var retentionTables = []retentionTable{ //nolint:gochecknoglobals
+	{dataClass: config.RetentionMonitoring, table: "monitoring", condition: "1 = 1"},
+	{dataClass: config.RetentionUpdateHistory, table: "updatehistory", condition: "1 = 1"},
+	{dataClass: config.RetentionInstanceLifecycle, table: "lifecycle", condition: "exported = 1"},
+	{
+		dataClass: config.RetentionAuditLog, table: "audit",
+		condition: "exported = 1 AND id < (SELECT MAX(id) FROM audit)",
+	},
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getRetentionClasses returns history data classes sorted by priority in which they are pruned to fit size budget.
+func getRetentionClasses(cfg *config.Config) []retentionClass {
+	classes := make([]retentionClass, 0, len(retentionTables))
+
+	for _, table := range retentionTables {
+		policy, ok := cfg.GetRetentionPolicy(table.dataClass)
+
+		classes = append(classes, retentionClass{retentionTable: table, policy: policy, hasPolicy: ok})
+	}
+
+	sort.SliceStable(classes, func(i, j int) bool { return classes[i].policy.Priority < classes[j].policy.Priority })
+
+	return classes
+}
+
+func (db *Database) startMaintenance(cfg config.DatabaseMaintenance, prunePeriod time.Duration) {
+	if cfg.VacuumPeriod.Duration <= 0 && prunePeriod <= 0 {
+		return
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	db.maintenanceCancel = cancelFunc
+
+	db.maintenanceWG.Add(1)
+
+	go func() {
+		defer db.maintenanceWG.Done()
+
+		var vacuumChannel, pruneChannel <-chan time.Time
+
+		if cfg.VacuumPeriod.Duration > 0 {
+			vacuumTicker := time.NewTicker(cfg.VacuumPeriod.Duration)
+			defer vacuumTicker.Stop()
+
+			vacuumChannel = vacuumTicker.C
+		}
+
+		if prunePeriod > 0 {
+			pruneTicker := time.NewTicker(prunePeriod)
+			defer pruneTicker.Stop()
+
+			pruneChannel = pruneTicker.C
+		}
+
+		for {
+			select {
+			case <-pruneChannel:
+				if err := db.applyRetention(); err != nil {
+					log.Errorf("Can't apply retention policies: %v", err)
+				}
+
+			case <-vacuumChannel:
+				if err := db.performMaintenance(cfg.MaxSize); err != nil {
+					log.Errorf("Database maintenance failed: %v", err)
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (db *Database) stopMaintenance() {
+	if db.maintenanceCancel != nil {
+		db.maintenanceCancel()
+	}
+
+	db.maintenanceWG.Wait()
+}
+
+func (db *Database) performMaintenance(maxSize uint64) error {
+	sizeBefore, err := db.GetSize()
+	if err != nil {
+		return err
+	}
+
+	if maxSize > 0 {
+		if err = db.pruneHistory(maxSize); err != nil {
+			return err
+		}
+	}
+
+	if _, err = db.sql.Exec("VACUUM"); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	sizeAfter, err := db.GetSize()
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"sizeBefore": sizeBefore, "sizeAfter": sizeAfter}).Debug("Database maintenance done")
+
+	if maxSize > 0 && sizeAfter > maxSize {
+		log.WithFields(log.Fields{"size": sizeAfter, "maxSize": maxSize}).Warn("Database exceeds max size")
+	}
+
+	return nil
+}
+
+// applyRetention removes history records which exceed max age or max records of their data class policies.
+func (db *Database) applyRetention() error {
+	for _, class := range db.retention {
+		if !class.hasPolicy {
+			continue
+		}
+
+		removed, err := db.applyRetentionPolicy(class, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if removed > 0 {
+			log.WithFields(log.Fields{
+				"dataClass": class.dataClass, "removed": removed,
+			}).Debug("Outdated history records removed")
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) applyRetentionPolicy(class retentionClass, now time.Time) (removed int64, err error) {
+	if class.policy.MaxAge.Duration > 0 {
+		result, err := db.sql.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s AND timestamp < ?", class.table,
+			class.condition), now.Add(-class.policy.MaxAge.Duration).UnixNano())
+		if err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+
+		if removed, err = result.RowsAffected(); err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+	}
+
+	if class.policy.MaxRecords > 0 {
+		result, err := db.sql.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE %s AND rowid IN (SELECT rowid FROM %s ORDER BY timestamp DESC LIMIT -1 OFFSET ?)",
+			class.table, class.condition, class.table), class.policy.MaxRecords)
+		if err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+
+		countRemoved, err := result.RowsAffected()
+		if err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+
+		removed += countRemoved
+	}
+
+	return removed, nil
+}
+
+// pruneHistory removes oldest history records while used database size exceeds max size. Data classes are pruned
+// one by one starting from the lowest priority.
+func (db *Database) pruneHistory(maxSize uint64) error {
+	for {
+		usedSize, err := db.getUsedSize()
+		if err != nil {
+			return err
+		}
+
+		if usedSize <= maxSize {
+			return nil
+		}
+
+		var removed int64
+
+		for _, class := range db.retention {
+			if removed, err = db.pruneHistoryTable(class.retentionTable); err != nil {
+				return err
+			}
+
+			if removed > 0 {
+				log.WithFields(log.Fields{
+					"dataClass": class.dataClass, "usedSize": usedSize, "removed": removed,
+				}).Debug("History records pruned")
+
+				break
+			}
+		}
+
+		if removed == 0 {
+			return nil
+		}
+	}
+}
+
+func (db *Database) pruneHistoryTable(table retentionTable) (removed int64, err error) {
+	var count int64
+
+	if err = db.sql.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table.table,
+		table.condition)).Scan(&count); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	result, err := db.sql.Exec(fmt.Sprintf(
+		"DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s ORDER BY timestamp LIMIT ?)",
+		table.table, table.table, table.condition), count/pruneRatio+1)
+	if err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	if removed, err = result.RowsAffected(); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return removed, nil
+}
+
+// getUsedSize returns database size without free pages.
+func (db *Database) getUsedSize() (size uint64, err error) {
+	var freePages, pageSize uint64
+
+	if size, err = db.GetSize(); err != nil {
+		return 0, err
+	}
+
+	if err = db.sql.QueryRow("PRAGMA freelist_count").Scan(&freePages); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	if err = db.sql.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return size - freePages*pageSize, nil
+}
@@ -0,0 +1,15 @@
+-- Down Migration Script for CM service tables
+
+DROP TABLE IF EXISTS sboms;
+DROP TABLE IF EXISTS nodereboot;
+DROP TABLE IF EXISTS advisories;
+DROP TABLE IF EXISTS bandwidthusage;
+DROP TABLE IF EXISTS featureflags;
+DROP TABLE IF EXISTS emergencyupdates;
+DROP TABLE IF EXISTS campaigns;
+DROP TABLE IF EXISTS overridebundles;
+DROP TABLE IF EXISTS maintenance;
+DROP TABLE IF EXISTS lifecycle;
+DROP TABLE IF EXISTS audit;
+DROP TABLE IF EXISTS monitoring;
+DROP TABLE IF EXISTS ipam;
@@ -0,0 +1,87 @@
+-- Up Migration Script for CM service tables
+
+-- IPAM allocation table
+CREATE TABLE IF NOT EXISTS ipam (
+    networkID TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    subnet TEXT,
+    PRIMARY KEY(networkID, ip)
+);
+
+-- Monitoring history
+CREATE TABLE IF NOT EXISTS monitoring (
+    nodeID TEXT NOT NULL,
+    serviceID TEXT,
+    subjectID TEXT,
+    instance INTEGER,
+    timestamp INTEGER,
+    ram INTEGER,
+    cpu INTEGER,
+    download INTEGER,
+    upload INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS monitoring_timestamp ON monitoring (timestamp);
+
+-- Audit log
+CREATE TABLE IF NOT EXISTS audit (
+    id INTEGER NOT NULL PRIMARY KEY,
+    timestamp INTEGER,
+    source TEXT,
+    actor TEXT,
+    action TEXT,
+    details TEXT,
+    error TEXT,
+    hash BLOB,
+    signature BLOB,
+    exported INTEGER
+);
+
+-- Instance lifecycle events
+CREATE TABLE IF NOT EXISTS lifecycle (
+    id INTEGER NOT NULL PRIMARY KEY,
+    timestamp INTEGER,
+    serviceID TEXT,
+    subjectID TEXT,
+    instance INTEGER,
+    event TEXT,
+    nodeID TEXT,
+    prevNodeID TEXT,
+    error TEXT,
+    exported INTEGER
+);
+
+-- Maintenance window state
+CREATE TABLE IF NOT EXISTS maintenance (id INTEGER NOT NULL PRIMARY KEY, state BLOB);
+
+-- Override bundle reports
+CREATE TABLE IF NOT EXISTS overridebundles (bundleId TEXT NOT NULL PRIMARY KEY, report BLOB);
+
+-- Campaign summaries
+CREATE TABLE IF NOT EXISTS campaigns (campaignId TEXT NOT NULL PRIMARY KEY, summary BLOB);
+
+-- Used emergency update IDs
+CREATE TABLE IF NOT EXISTS emergencyupdates (updateId TEXT NOT NULL PRIMARY KEY, validUntil INTEGER);
+
+-- Feature flags
+CREATE TABLE IF NOT EXISTS featureflags (id INTEGER NOT NULL PRIMARY KEY, flags BLOB);
+
+-- Bandwidth usage
+CREATE TABLE IF NOT EXISTS bandwidthusage (id INTEGER NOT NULL PRIMARY KEY, usage BLOB);
+
+-- Security advisories state
+CREATE TABLE IF NOT EXISTS advisories (id INTEGER NOT NULL PRIMARY KEY, state BLOB);
+
+-- Node reboot state
+CREATE TABLE IF NOT EXISTS nodereboot (id INTEGER NOT NULL PRIMARY KEY, state BLOB);
+
+-- Service SBOMs
+CREATE TABLE IF NOT EXISTS sboms (
+    serviceId TEXT NOT NULL,
+    version TEXT NOT NULL,
+    digest TEXT NOT NULL,
+    format TEXT,
+    mediaType TEXT,
+    document BLOB,
+    PRIMARY KEY(serviceId, version, digest)
+);
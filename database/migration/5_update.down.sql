@@ -0,0 +1,4 @@
+-- Down Migration Script for services table
+
+-- Remove health endpoints of service exposed ports
+ALTER TABLE services DROP COLUMN healthEndpoints;
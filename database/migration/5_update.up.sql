@@ -0,0 +1,4 @@
+-- Up Migration Script for services table
+
+-- Add health endpoints of service exposed ports
+ALTER TABLE services ADD COLUMN healthEndpoints BLOB DEFAULT 'null';
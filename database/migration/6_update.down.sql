@@ -0,0 +1,4 @@
+-- Down Migration Script for services table
+
+-- Remove service health checks
+ALTER TABLE services DROP COLUMN healthChecks;
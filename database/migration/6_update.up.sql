@@ -0,0 +1,4 @@
+-- Up Migration Script for services table
+
+-- Add service health checks
+ALTER TABLE services ADD COLUMN healthChecks BLOB DEFAULT 'null';
@@ -0,0 +1,4 @@
+-- Down Migration Script for services table
+
+-- Remove service layer media types
+ALTER TABLE services DROP COLUMN layerMediaTypes;
@@ -0,0 +1,4 @@
+-- Up Migration Script for services table
+
+-- Add service layer media types
+ALTER TABLE services ADD COLUMN layerMediaTypes BLOB DEFAULT 'null';
@@ -0,0 +1,4 @@
+-- Down Migration Script for services table
+
+-- Remove service shared network flag
+ALTER TABLE services DROP COLUMN sharedNetwork;
@@ -0,0 +1,4 @@
+-- Up Migration Script for services table
+
+-- Add service shared network flag
+ALTER TABLE services ADD COLUMN sharedNetwork INTEGER DEFAULT 0;
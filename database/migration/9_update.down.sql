@@ -0,0 +1,4 @@
+-- Down Migration Script for updatehistory table
+
+-- Remove campaign ID of update history record
+ALTER TABLE updatehistory DROP COLUMN campaignID;
@@ -0,0 +1,15 @@
+-- Up Migration Script for updatehistory table
+
+-- Create update history table if database doesn't have it yet
+CREATE TABLE IF NOT EXISTS updatehistory (
+    timestamp INTEGER,
+    id TEXT,
+    step TEXT,
+    size INTEGER,
+    duration INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS updatehistory_item ON updatehistory (id, step);
+
+-- Add campaign ID of update history record
+ALTER TABLE updatehistory ADD COLUMN campaignID TEXT DEFAULT '';
@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+
+	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
+	"github.com/aosedge/aos_communicationmanager/statestream"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// StatePublisher publishes state changes committed to the database.
+type StatePublisher interface {
+	Publish(kind, operation string, data any)
+}
+
+type stateChange struct {
+	kind      string
+	operation string
+	data      any
+}
+
+type networkState struct {
+	aostypes.NetworkParameters
+	NodeID string `json:"nodeId"`
+}
+
+type instanceState struct {
+	aostypes.InstanceIdent
+	NodeID     string    `json:"nodeId,omitempty"`
+	PrevNodeID string    `json:"prevNodeId,omitempty"`
+	UID        int       `json:"uid"`
+	Timestamp  time.Time `json:"timestamp"`
+	State      int       `json:"state"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetStatePublisher sets publisher of networks, instances and update state changes. Changes made within transaction
+// are published after the transaction is committed.
+func (db *Database) SetStatePublisher(publisher StatePublisher) {
+	db.txMutex.Lock()
+	defer db.txMutex.Unlock()
+
+	db.statePublisher = publisher
+}
+
+// DataVersion returns database data version which is changed on each commit. The version is valid till the database
+// is closed.
+func (db *Database) DataVersion() (version uint64, err error) {
+	db.versionMutex.Lock()
+	defer db.versionMutex.Unlock()
+
+	// Data version is changed by commits of other connections, so dedicated connection is used to read it
+	if db.versionConn == nil {
+		if db.versionConn, err = db.sql.Conn(context.Background()); err != nil {
+			return 0, aoserrors.Wrap(err)
+		}
+	}
+
+	if err = db.versionConn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version); err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return version, nil
+}
+
+// ApplyStateChanges applies state changes published by other database, e.g. replicated from primary CM. Changes are
+// applied within one transaction and may be applied repeatedly: set operation replaces the object and remove
+// operation ignores missing one.
+func (db *Database) ApplyStateChanges(changes []statestream.Change) error {
+	return db.ExecuteInTransaction(func(txStorage any) error {
+		txDB, _ := txStorage.(*Database)
+
+		for _, change := range changes {
+			if err := txDB.applyStateChange(change); err != nil {
+				return aoserrors.Errorf("can't apply %s %s change %d: %v",
+					change.Kind, change.Operation, change.Sequence, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newNetworkState(networkInfo networkmanager.NetworkParametersStorage) networkState {
+	return networkState{NetworkParameters: networkInfo.NetworkParameters, NodeID: networkInfo.NodeID}
+}
+
+func newInstanceState(instanceInfo launcher.InstanceInfo) instanceState {
+	return instanceState{
+		InstanceIdent: instanceInfo.InstanceIdent, NodeID: instanceInfo.NodeID, PrevNodeID: instanceInfo.PrevNodeID,
+		UID: instanceInfo.UID, Timestamp: instanceInfo.Timestamp, State: instanceInfo.State,
+	}
+}
+
+func (db *Database) notifyStateChange(kind, operation string, data any) {
+	if db.tx != nil {
+		db.txMutex.Lock()
+		db.txChanges = append(db.txChanges, stateChange{kind: kind, operation: operation, data: data})
+		db.txMutex.Unlock()
+
+		return
+	}
+
+	db.txMutex.Lock()
+	publisher := db.statePublisher
+	db.txMutex.Unlock()
+
+	if publisher != nil {
+		publisher.Publish(kind, operation, data)
+	}
+}
+
+func (db *Database) publishStateChanges(changes []stateChange) {
+	db.txMutex.Lock()
+	publisher := db.statePublisher
+	db.txMutex.Unlock()
+
+	if publisher == nil {
+		return
+	}
+
+	for _, change := range changes {
+		publisher.Publish(change.kind, change.operation, change.data)
+	}
+}
+
+func (db *Database) applyStateChange(change statestream.Change) error {
+	switch change.Kind {
+	case statestream.KindFOTAState:
+		return db.SetFirmwareUpdateState(change.Data)
+
+	case statestream.KindSOTAState:
+		return db.SetSoftwareUpdateState(change.Data)
+
+	case statestream.KindInstance:
+		return db.applyInstanceChange(change)
+
+	case statestream.KindNetwork:
+		return db.applyNetworkChange(change)
+
+	case statestream.KindInstanceNetwork:
+		return db.applyInstanceNetworkChange(change)
+
+	default:
+		return aoserrors.Errorf("unsupported state change kind: %s", change.Kind)
+	}
+}
+
+func (db *Database) applyInstanceChange(change statestream.Change) error {
+	var state instanceState
+
+	if err := json.Unmarshal(change.Data, &state); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := db.RemoveInstance(state.InstanceIdent); err != nil && !errors.Is(err, errNotExist) {
+		return err
+	}
+
+	if change.Operation == statestream.OperationRemove {
+		return nil
+	}
+
+	return db.AddInstance(launcher.InstanceInfo{
+		InstanceIdent: state.InstanceIdent, NodeID: state.NodeID, PrevNodeID: state.PrevNodeID, UID: state.UID,
+		Timestamp: state.Timestamp, State: state.State,
+	})
+}
+
+func (db *Database) applyNetworkChange(change statestream.Change) error {
+	var state networkState
+
+	if err := json.Unmarshal(change.Data, &state); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := db.RemoveNetworkInfo(state.NetworkID, state.NodeID); err != nil {
+		return err
+	}
+
+	if change.Operation == statestream.OperationRemove {
+		return nil
+	}
+
+	return db.AddNetworkInfo(networkmanager.NetworkParametersStorage{
+		NetworkParameters: state.NetworkParameters, NodeID: state.NodeID,
+	})
+}
+
+func (db *Database) applyInstanceNetworkChange(change statestream.Change) error {
+	var networkInfo networkmanager.InstanceNetworkInfo
+
+	if err := json.Unmarshal(change.Data, &networkInfo); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := db.RemoveNetworkInstanceInfo(networkInfo.InstanceIdent); err != nil {
+		return err
+	}
+
+	if change.Operation == statestream.OperationRemove {
+		return nil
+	}
+
+	return db.AddNetworkInstanceInfo(networkInfo)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"database/sql"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type sqlExecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// ExecuteInTransaction executes storage operations atomically. Operations receive txStorage: the database handle bound
+// to the transaction. Only storage calls made through txStorage are executed within the transaction, calls made
+// through the database itself are not affected. Calling ExecuteInTransaction on txStorage joins the transaction. The
+// transaction is rolled back if operations return error or commit fails.
+func (db *Database) ExecuteInTransaction(operations func(txStorage any) error) (err error) {
+	if db.tx != nil {
+		return operations(db)
+	}
+
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	txDB := &Database{sql: db.sql, encryption: db.encryption, retention: db.retention, tx: tx}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				log.Errorf("Can't rollback transaction: %v", rollbackErr)
+			}
+
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			err = aoserrors.Wrap(err)
+
+			return
+		}
+
+		db.publishStateChanges(txDB.txChanges)
+	}()
+
+	return operations(txDB)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (db *Database) executor() sqlExecutor {
+	if db.tx != nil {
+		return db.tx
+	}
+
+	return db.sql
+}
@@ -40,7 +40,9 @@ import (
 	"github.com/cavaliergopher/grab/v3"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
 )
 
 /***********************************************************************************************************************
@@ -61,11 +63,16 @@ type Downloader struct {
 
 	moduleID         string
 	config           config.Downloader
+	overrideBundle   config.OverrideBundle
 	sender           AlertSender
 	currentDownloads map[string]*downloadResult
 	waitQueue        *list.List
 	allocator        spaceallocator.Allocator
 	storage          Storage
+	bandwidthBudget  BandwidthBudget
+	circuitBreaker   CircuitBreaker
+	stopped          bool
+	downloadsWG      sync.WaitGroup
 }
 
 // PackageInfo struct contains download info data.
@@ -84,6 +91,8 @@ type PackageInfo struct {
 	TargetType    string
 	TargetID      string
 	TargetVersion string
+	// Critical critical package is downloaded regardless of bandwidth budget.
+	Critical bool
 }
 
 // Storage provides API to add, remove, update or access download info data.
@@ -99,14 +108,34 @@ type AlertSender interface {
 	SendAlert(alert interface{})
 }
 
+// BandwidthBudget bandwidth budget of metered connection.
+type BandwidthBudget interface {
+	IsDeferred() bool
+	Wait(ctx context.Context) error
+	AddUsage(category string, size uint64)
+}
+
+// CircuitBreaker circuit breaker of download endpoint.
+type CircuitBreaker interface {
+	Allow() error
+	Done(err error)
+}
+
+type faultRateLimiter struct{}
+
 var (
 	// NewSpaceAllocator space allocator constructor.
 	//nolint:gochecknoglobals // used for unit test mock
 	NewSpaceAllocator = spaceallocator.New
 
+	// SupportedSchemes URL schemes supported by downloader.
+	//nolint:gochecknoglobals
+	SupportedSchemes = []string{"http", "https"}
+
 	// ErrNotExist not exist download info error.
 	ErrNotExist         = errors.New("download info not exist")
 	ErrPartlyDownloaded = errors.New("file not fully downloaded")
+	errStopped          = errors.New("downloader is stopped")
 )
 
 /***********************************************************************************************************************
@@ -122,6 +151,7 @@ func New(moduleID string, cfg *config.Config, sender AlertSender, storage Storag
 	downloader = &Downloader{
 		moduleID:         moduleID,
 		config:           cfg.Downloader,
+		overrideBundle:   cfg.OverrideBundle,
 		sender:           sender,
 		currentDownloads: make(map[string]*downloadResult),
 		waitQueue:        list.New(),
@@ -154,6 +184,31 @@ func (downloader *Downloader) Close() (err error) {
 	return err
 }
 
+// Stop cancels current downloads and rejects queued and new ones. It returns when all current downloads are finished.
+func (downloader *Downloader) Stop() {
+	log.Debug("Stop downloader")
+
+	downloader.Lock()
+
+	downloader.stopped = true
+
+	for _, result := range downloader.currentDownloads {
+		result.cancelFunc()
+	}
+
+	for element := downloader.waitQueue.Front(); element != nil; element = element.Next() {
+		if result, ok := element.Value.(*downloadResult); ok {
+			downloader.finishDownload(result, errStopped)
+		}
+	}
+
+	downloader.waitQueue.Init()
+
+	downloader.Unlock()
+
+	downloader.downloadsWG.Wait()
+}
+
 // Download downloads, decrypts and verifies package.
 func (downloader *Downloader) Download(
 	ctx context.Context, packageInfo PackageInfo,
@@ -163,9 +218,12 @@ func (downloader *Downloader) Download(
 
 	id := base64.URLEncoding.EncodeToString(packageInfo.Sha256)
 
+	downloadCtx, cancelFunc := context.WithCancel(ctx)
+
 	downloadResult := &downloadResult{
 		id:               id,
-		ctx:              ctx,
+		ctx:              downloadCtx,
+		cancelFunc:       cancelFunc,
 		packageInfo:      packageInfo,
 		statusChannel:    make(chan error, 1),
 		downloadFileName: path.Join(downloader.config.DownloadDir, id+encryptedFileExt),
@@ -174,12 +232,56 @@ func (downloader *Downloader) Download(
 	log.WithField("id", id).Debug("Download")
 
 	if err = downloader.addToQueue(downloadResult); err != nil {
+		cancelFunc()
+
 		return nil, aoserrors.Wrap(err)
 	}
 
 	return downloadResult, nil
 }
 
+// SetBandwidthBudget sets bandwidth budget. Download of non-critical packages is deferred while the budget defers
+// non-critical traffic.
+func (downloader *Downloader) SetBandwidthBudget(budget BandwidthBudget) {
+	downloader.Lock()
+	defer downloader.Unlock()
+
+	downloader.bandwidthBudget = budget
+}
+
+// SetCircuitBreaker sets circuit breaker of download endpoint. Remote downloads are rejected while the circuit is
+// open.
+func (downloader *Downloader) SetCircuitBreaker(circuitBreaker CircuitBreaker) {
+	downloader.Lock()
+	defer downloader.Unlock()
+
+	downloader.circuitBreaker = circuitBreaker
+}
+
+// ConfigReloaded applies reloaded config.
+func (downloader *Downloader) ConfigReloaded(cfg *config.Config) {
+	downloader.Lock()
+	defer downloader.Unlock()
+
+	log.WithFields(log.Fields{
+		"maxConcurrentDownloads": cfg.Downloader.MaxConcurrentDownloads,
+		"retryDelay":             cfg.Downloader.RetryDelay,
+		"maxRetryDelay":          cfg.Downloader.MaxRetryDelay,
+	}).Debug("Apply downloader config")
+
+	if cfg.Downloader.MaxConcurrentDownloads > 0 {
+		downloader.config.MaxConcurrentDownloads = cfg.Downloader.MaxConcurrentDownloads
+	}
+
+	downloader.config.RetryDelay = cfg.Downloader.RetryDelay
+	downloader.config.MaxRetryDelay = cfg.Downloader.MaxRetryDelay
+
+	// start waiting downloads if limit is increased
+	if len(downloader.currentDownloads) < downloader.config.MaxConcurrentDownloads {
+		downloader.handleWaitQueue()
+	}
+}
+
 func (downloader *Downloader) Release(filePath string) error {
 	downloadInfo, err := downloader.storage.GetDownloadInfo(filePath)
 	if err != nil {
@@ -237,6 +339,10 @@ func (downloader *Downloader) releaseDownload(filePath string) error {
 }
 
 func (downloader *Downloader) addToQueue(result *downloadResult) error {
+	if downloader.stopped {
+		return errStopped
+	}
+
 	if len(result.packageInfo.URLs) == 0 {
 		return aoserrors.New("download URLs is empty")
 	}
@@ -269,8 +375,11 @@ func (downloader *Downloader) addToQueue(result *downloadResult) error {
 	}
 
 	downloader.currentDownloads[result.id] = result
+	downloader.downloadsWG.Add(1)
 
 	go func() {
+		defer downloader.downloadsWG.Done()
+
 		processErr := downloader.process(result)
 
 		if err := downloader.acceptSpace(result); err != nil {
@@ -282,7 +391,7 @@ func (downloader *Downloader) addToQueue(result *downloadResult) error {
 
 		delete(downloader.currentDownloads, result.id)
 
-		result.statusChannel <- processErr
+		downloader.finishDownload(result, processErr)
 
 		downloader.handleWaitQueue()
 	}()
@@ -456,6 +565,12 @@ func (downloader *Downloader) process(result *downloadResult) error {
 	return nil
 }
 
+func (downloader *Downloader) finishDownload(result *downloadResult, err error) {
+	result.cancelFunc()
+
+	result.statusChannel <- err
+}
+
 func (downloader *Downloader) handleWaitQueue() {
 	for range downloader.waitQueue.Len() {
 		// get first element from wait queue
@@ -483,7 +598,7 @@ func (downloader *Downloader) handleWaitQueue() {
 		}
 
 		if err != nil {
-			result.statusChannel <- err
+			downloader.finishDownload(result, err)
 			continue
 		}
 
@@ -494,6 +609,19 @@ func (downloader *Downloader) handleWaitQueue() {
 }
 
 func (downloader *Downloader) downloadPackage(result *downloadResult) (err error) {
+	downloader.Lock()
+	retryDelay, maxRetryDelay := downloader.config.RetryDelay.Duration, downloader.config.MaxRetryDelay.Duration
+	budget := downloader.bandwidthBudget
+	downloader.Unlock()
+
+	if budget != nil && !result.packageInfo.Critical && budget.IsDeferred() {
+		log.WithFields(log.Fields{"id": result.id}).Info("Download deferred by bandwidth budget")
+
+		if err = budget.Wait(result.ctx); err != nil {
+			return err
+		}
+	}
+
 	if err = retryhelper.Retry(result.ctx,
 		func() (err error) {
 			fileSize, err := getFileSize(result.downloadFileName)
@@ -524,7 +652,7 @@ func (downloader *Downloader) downloadPackage(result *downloadResult) (err error
 			log.Errorf("Can't download file: %v", err)
 			log.WithFields(log.Fields{"id": result.id}).Debugf("Retry download in %s", delay)
 		},
-		0, downloader.config.RetryDelay.Duration, downloader.config.MaxRetryDelay.Duration); err != nil {
+		0, retryDelay, maxRetryDelay); err != nil {
 		return aoserrors.New("can't download file from any source")
 	}
 
@@ -554,6 +682,22 @@ func (downloader *Downloader) downloadURLs(result *downloadResult) (err error) {
 }
 
 func (downloader *Downloader) download(url string, result *downloadResult) (err error) {
+	if filePath, ok := getLocalFilePath(url); ok {
+		return downloader.copyLocalFile(filePath, result)
+	}
+
+	downloader.Lock()
+	circuitBreaker := downloader.circuitBreaker
+	downloader.Unlock()
+
+	if circuitBreaker != nil {
+		if err = circuitBreaker.Allow(); err != nil {
+			return err
+		}
+
+		defer func() { circuitBreaker.Done(err) }()
+	}
+
 	timer := time.NewTicker(updateDownloadsTime)
 	defer timer.Stop()
 
@@ -565,8 +709,31 @@ func (downloader *Downloader) download(url string, result *downloadResult) (err
 	req = req.WithContext(result.ctx)
 	req.Size = int64(result.packageInfo.Size)
 
+	resumeSize, err := getFileSize(result.downloadFileName)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if faultinjection.Enabled {
+		req.RateLimiter = faultRateLimiter{}
+	}
+
 	resp := grab.DefaultClient.Do(req)
 
+	if !resp.DidResume {
+		resumeSize = 0
+	}
+
+	// Downloaded bytes are accounted by the bandwidth budget on progress update and on finish
+	accountUsage := func() {
+		if complete := uint64(resp.BytesComplete()); complete > resumeSize {
+			downloader.addBandwidthUsage(complete - resumeSize)
+			resumeSize = complete
+		}
+	}
+
+	defer accountUsage()
+
 	if !resp.DidResume {
 		log.WithFields(log.Fields{"url": url, "id": result.id}).Debug("Download started")
 
@@ -601,6 +768,8 @@ func (downloader *Downloader) download(url string, result *downloadResult) (err
 	for {
 		select {
 		case <-timer.C:
+			accountUsage()
+
 			downloader.sender.SendAlert(downloader.prepareDownloadAlert(resp, result, "Download status"))
 
 			log.WithFields(log.Fields{"complete": resp.BytesComplete(), "total": resp.Size()}).Debug("Download progress")
@@ -646,6 +815,16 @@ func (downloader *Downloader) download(url string, result *downloadResult) (err
 	}
 }
 
+func (downloader *Downloader) addBandwidthUsage(size uint64) {
+	downloader.Lock()
+	budget := downloader.bandwidthBudget
+	downloader.Unlock()
+
+	if budget != nil {
+		budget.AddUsage(bandwidthbudget.CategoryDownload, size)
+	}
+}
+
 func (downloader *Downloader) prepareDownloadAlert(
 	resp *grab.Response, result *downloadResult, msg string,
 ) cloudprotocol.DownloadAlert {
@@ -661,6 +840,11 @@ func (downloader *Downloader) prepareDownloadAlert(
 	}
 }
 
+// WaitN delays download of data chunk by the fault injection.
+func (limiter faultRateLimiter) WaitN(ctx context.Context, n int) error {
+	return faultinjection.Delay(ctx, faultinjection.DownloadDelay)
+}
+
 func getFileSize(fileName string) (size uint64, err error) {
 	var stat syscall.Stat_t
 
@@ -36,11 +36,13 @@ import (
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 	"github.com/aosedge/aos_common/image"
 	"github.com/aosedge/aos_common/spaceallocator"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/downloader"
 )
@@ -84,6 +86,13 @@ type testSpace struct {
 	size      uint64
 }
 
+type testBandwidthBudget struct {
+	sync.Mutex
+
+	deferred bool
+	usage    map[string]uint64
+}
+
 type testOutdatedItem struct {
 	id   string
 	size uint64
@@ -197,6 +206,88 @@ func TestDownload(t *testing.T) {
 	}
 }
 
+func TestDownloadLocalFile(t *testing.T) {
+	sender := testAlertSender{}
+	downloadAllocator = &testAllocator{}
+	testStorage := &testStorage{
+		data: make(map[string]downloader.DownloadInfo),
+	}
+
+	if err := clearDirs(); err != nil {
+		t.Fatalf("Can't clear dirs: %v", err)
+	}
+
+	fileName := path.Join(serverDir, "package.txt")
+
+	if err := os.WriteFile(fileName, []byte("Hello local downloader\n"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+	defer os.RemoveAll(fileName)
+
+	downloadInstance, err := downloader.New("testModule", &config.Config{
+		Downloader: config.Downloader{
+			DownloadDir:            downloadDir,
+			MaxConcurrentDownloads: 1,
+			DownloadPartLimit:      100,
+			RetryDelay:             aostypes.Duration{Duration: 100 * time.Millisecond},
+			MaxRetryDelay:          aostypes.Duration{Duration: 100 * time.Millisecond},
+		},
+		OverrideBundle: config.OverrideBundle{Enabled: true, MediaDirs: []string{serverDir}},
+	}, &sender, testStorage)
+	if err != nil {
+		t.Fatalf("Can't create downloader: %s", err)
+	}
+	defer downloadInstance.Close()
+
+	result, err := downloadInstance.Download(
+		context.Background(), preparePackageInfo("file://"+serverDir+"/", fileName, cloudprotocol.DownloadTargetLayer))
+	if err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	if err = result.Wait(); err != nil {
+		t.Errorf("Download error: %s", err)
+	}
+
+	data, err := os.ReadFile(result.GetFileName())
+	if err != nil {
+		t.Fatalf("Can't read downloaded file: %v", err)
+	}
+
+	if string(data) != "Hello local downloader\n" {
+		t.Errorf("Wrong downloaded file content: %s", string(data))
+	}
+
+	// Files outside of media dirs are not allowed
+
+	outsideFileName := path.Join(serverDir, "outside.txt")
+
+	if err := os.WriteFile(outsideFileName, []byte("Hello outside\n"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+	defer os.RemoveAll(outsideFileName)
+
+	packageInfo := preparePackageInfo("file://"+serverDir+"/", outsideFileName, cloudprotocol.DownloadTargetService)
+
+	if err := os.Rename(outsideFileName, path.Join(tmpDir, "outside.txt")); err != nil {
+		t.Fatalf("Can't move package file: %v", err)
+	}
+	defer os.RemoveAll(path.Join(tmpDir, "outside.txt"))
+
+	packageInfo.URLs = []string{"file://" + path.Join(tmpDir, "outside.txt")}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFunc()
+
+	if result, err = downloadInstance.Download(ctx, packageInfo); err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	if err = result.Wait(); err == nil {
+		t.Error("Error expected for local file outside of media dirs")
+	}
+}
+
 func TestInterruptResumeDownload(t *testing.T) {
 	sender := testAlertSender{}
 	downloadAllocator = &testAllocator{}
@@ -753,6 +844,141 @@ func TestReleaseByType(t *testing.T) {
 	}
 }
 
+func TestBandwidthBudget(t *testing.T) {
+	sender := testAlertSender{}
+	downloadAllocator = &testAllocator{}
+	testStorage := &testStorage{
+		data: make(map[string]downloader.DownloadInfo),
+	}
+	budget := &testBandwidthBudget{deferred: true, usage: make(map[string]uint64)}
+
+	if err := clearDirs(); err != nil {
+		t.Fatalf("Can't clear dirs: %v", err)
+	}
+
+	fileName := path.Join(serverDir, "package.txt")
+
+	if err := generateFile(fileName, 1*Kilobyte); err != nil {
+		t.Fatalf("Can't generate file: %s", err)
+	}
+	defer os.RemoveAll(fileName)
+
+	downloadInstance, err := downloader.New("testModule", &config.Config{
+		Downloader: config.Downloader{
+			DownloadDir:            downloadDir,
+			MaxConcurrentDownloads: 1,
+			DownloadPartLimit:      100,
+		},
+	}, &sender, testStorage)
+	if err != nil {
+		t.Fatalf("Can't create downloader: %s", err)
+	}
+	defer downloadInstance.Close()
+
+	downloadInstance.SetBandwidthBudget(budget)
+
+	// Non-critical download is deferred
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFunc()
+
+	packageInfo := preparePackageInfo("http://localhost:8001/", fileName, cloudprotocol.DownloadTargetLayer)
+
+	result, err := downloadInstance.Download(ctx, packageInfo)
+	if err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	if err = result.Wait(); err == nil {
+		t.Error("Non-critical download should be deferred")
+	}
+
+	if usage := budget.getUsage(bandwidthbudget.CategoryDownload); usage != 0 {
+		t.Errorf("Unexpected download usage: %d", usage)
+	}
+
+	// Critical download is not deferred
+
+	packageInfo.Critical = true
+
+	if result, err = downloadInstance.Download(context.Background(), packageInfo); err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	if err = result.Wait(); err != nil {
+		t.Errorf("Download error: %v", err)
+	}
+
+	if usage := budget.getUsage(bandwidthbudget.CategoryDownload); usage != packageInfo.Size {
+		t.Errorf("Unexpected download usage: %d", usage)
+	}
+}
+
+func TestStop(t *testing.T) {
+	sender := testAlertSender{}
+	downloadAllocator = &testAllocator{}
+	testStorage := &testStorage{
+		data: make(map[string]downloader.DownloadInfo),
+	}
+	budget := &testBandwidthBudget{deferred: true, usage: make(map[string]uint64)}
+
+	if err := clearDirs(); err != nil {
+		t.Fatalf("Can't clear dirs: %v", err)
+	}
+
+	fileName := path.Join(serverDir, "package.txt")
+
+	if err := generateFile(fileName, 1*Kilobyte); err != nil {
+		t.Fatalf("Can't generate file: %s", err)
+	}
+	defer os.RemoveAll(fileName)
+
+	downloadInstance, err := downloader.New("testModule", &config.Config{
+		Downloader: config.Downloader{
+			DownloadDir:            downloadDir,
+			MaxConcurrentDownloads: 1,
+			DownloadPartLimit:      100,
+		},
+	}, &sender, testStorage)
+	if err != nil {
+		t.Fatalf("Can't create downloader: %s", err)
+	}
+	defer downloadInstance.Close()
+
+	downloadInstance.SetBandwidthBudget(budget)
+
+	// Current download is deferred by budget and second one waits in queue
+
+	packageInfo := preparePackageInfo("http://localhost:8001/", fileName, cloudprotocol.DownloadTargetLayer)
+
+	currentResult, err := downloadInstance.Download(context.Background(), packageInfo)
+	if err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	queuedPackageInfo := packageInfo
+	queuedPackageInfo.Sha256 = []byte("queued")
+
+	queuedResult, err := downloadInstance.Download(context.Background(), queuedPackageInfo)
+	if err != nil {
+		t.Fatalf("Can't download package: %s", err)
+	}
+
+	downloadInstance.Stop()
+
+	if err = currentResult.Wait(); err == nil {
+		t.Error("Current download should be canceled")
+	}
+
+	if err = queuedResult.Wait(); err == nil {
+		t.Error("Queued download should be rejected")
+	}
+
+	if _, err = downloadInstance.Download(context.Background(), packageInfo); err == nil {
+		t.Error("Download should be rejected after stop")
+	}
+}
+
 /***********************************************************************************************************************
  * Interfaces
  **********************************************************************************************************************/
@@ -785,6 +1011,40 @@ func (instance *testAlertSender) SendAlert(alert interface{}) {
  * Interfaces
  **********************************************************************************************************************/
 
+func (budget *testBandwidthBudget) IsDeferred() bool {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.deferred
+}
+
+func (budget *testBandwidthBudget) Wait(ctx context.Context) error {
+	for budget.IsDeferred() {
+		select {
+		case <-ctx.Done():
+			return aoserrors.Wrap(ctx.Err())
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return nil
+}
+
+func (budget *testBandwidthBudget) AddUsage(category string, size uint64) {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.usage[category] += size
+}
+
+func (budget *testBandwidthBudget) getUsage(category string) uint64 {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.usage[category]
+}
+
 func (storage *testStorage) GetDownloadInfo(filePath string) (downloader.DownloadInfo, error) {
 	downloadInfo, ok := storage.data[filePath]
 	if !ok {
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downloader
+
+import (
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const fileScheme = "file"
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getLocalFilePath returns local file path if URL has file scheme.
+func getLocalFilePath(rawURL string) (filePath string, ok bool) {
+	fileURL, err := url.Parse(rawURL)
+	if err != nil || fileURL.Scheme != fileScheme {
+		return "", false
+	}
+
+	return fileURL.Path, true
+}
+
+// copyLocalFile copies package from local media. Only files located on override bundle media dirs are accepted.
+func (downloader *Downloader) copyLocalFile(filePath string, result *downloadResult) (err error) {
+	if !downloader.overrideBundle.Enabled || !downloader.overrideBundle.IsMediaPath(filePath) {
+		return aoserrors.Errorf("local file is not allowed: %s", filePath)
+	}
+
+	log.WithFields(log.Fields{"id": result.id, "file": filePath}).Debug("Copy local file")
+
+	srcFile, err := os.Open(filePath)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(result.downloadFileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer dstFile.Close()
+
+	downloadInfo := DownloadInfo{
+		Path:       result.downloadFileName,
+		TargetType: result.packageInfo.TargetType,
+	}
+
+	defer func() {
+		if errDB := downloader.storage.SetDownloadInfo(downloadInfo); errDB != nil && err == nil {
+			err = errDB
+		}
+	}()
+
+	if _, err = io.Copy(dstFile, io.LimitReader(srcFile, int64(result.packageInfo.Size))); err != nil {
+		downloadInfo.InterruptReason = err.Error()
+
+		return aoserrors.Wrap(err)
+	}
+
+	downloadInfo.Downloaded = true
+
+	return nil
+}
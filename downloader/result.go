@@ -38,6 +38,7 @@ type downloadResult struct {
 	id string
 
 	ctx         context.Context //nolint:containedctx
+	cancelFunc  context.CancelFunc
 	packageInfo PackageInfo
 
 	statusChannel chan error
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extension provides API to extend CM with placement policies, update gates and alert routers.
+//
+// Extensions register their factories in init function of the implementing package. The package is linked into CM by
+// blank import from the main package, which can be guarded by a build tag. Registered extensions are created on CM
+// startup.
+package extension
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Update types.
+const (
+	UpdateTypeFOTA = "fota"
+	UpdateTypeSOTA = "sota"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// PlacementPolicy restricts nodes for service instances.
+type PlacementPolicy interface {
+	// FilterNodes returns IDs of nodes allowed to run the instance. Error means the instance can't be placed.
+	FilterNodes(
+		instance aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig, nodes []cloudprotocol.NodeInfo,
+	) ([]string, error)
+}
+
+// UpdateGate allows or postpones update start.
+type UpdateGate interface {
+	// CheckUpdate returns error if update of the type is not allowed now. Scheduled update is retried later.
+	CheckUpdate(updateType string) error
+}
+
+// AlertRouter routes alerts.
+type AlertRouter interface {
+	// RouteAlert handles the alert and returns false if the alert should not be sent to the cloud.
+	RouteAlert(alert interface{}) (sendToCloud bool)
+}
+
+// PlacementPolicyFactory creates placement policy.
+type PlacementPolicyFactory func(cfg *config.Config) (PlacementPolicy, error)
+
+// UpdateGateFactory creates update gate.
+type UpdateGateFactory func(cfg *config.Config) (UpdateGate, error)
+
+// AlertRouterFactory creates alert router.
+type AlertRouterFactory func(cfg *config.Config) (AlertRouter, error)
+
+// Extensions extensions created on CM startup.
+type Extensions struct {
+	PlacementPolicies []PlacementPolicy
+	UpdateGates       []UpdateGate
+	AlertRouters      []AlertRouter
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var (
+	registryMutex            sync.Mutex
+	placementPolicyFactories = make(map[string]PlacementPolicyFactory)
+	updateGateFactories      = make(map[string]UpdateGateFactory)
+	alertRouterFactories     = make(map[string]AlertRouterFactory)
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// RegisterPlacementPolicy registers placement policy factory. It panics if the name is already registered.
+func RegisterPlacementPolicy(name string, factory PlacementPolicyFactory) {
+	register(placementPolicyFactories, name, factory)
+}
+
+// RegisterUpdateGate registers update gate factory. It panics if the name is already registered.
+func RegisterUpdateGate(name string, factory UpdateGateFactory) {
+	register(updateGateFactories, name, factory)
+}
+
+// RegisterAlertRouter registers alert router factory. It panics if the name is already registered.
+func RegisterAlertRouter(name string, factory AlertRouterFactory) {
+	register(alertRouterFactories, name, factory)
+}
+
+// New creates registered extensions. Extensions of each kind are created and applied in order of their names.
+func New(cfg *config.Config) (extensions *Extensions, err error) {
+	log.Debug("Create extensions")
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	extensions = &Extensions{}
+
+	if extensions.PlacementPolicies, err = create(placementPolicyFactories, cfg); err != nil {
+		return nil, err
+	}
+
+	if extensions.UpdateGates, err = create(updateGateFactories, cfg); err != nil {
+		return nil, err
+	}
+
+	if extensions.AlertRouters, err = create(alertRouterFactories, cfg); err != nil {
+		return nil, err
+	}
+
+	return extensions, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func register[F any](factories map[string]F, name string, factory F) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic("extension already registered: " + name)
+	}
+
+	factories[name] = factory
+}
+
+func create[T any, F ~func(cfg *config.Config) (T, error)](factories map[string]F, cfg *config.Config) ([]T, error) {
+	names := make([]string, 0, len(factories))
+
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	items := make([]T, 0, len(names))
+
+	for _, name := range names {
+		log.WithField("name", name).Info("Create extension")
+
+		item, err := factories[name](cfg)
+		if err != nil {
+			return nil, aoserrors.Errorf("can't create extension %s: %v", name, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
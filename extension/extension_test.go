@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extension_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/extension"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testGate struct {
+	name string
+}
+
+type testRouter struct{}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRegister(t *testing.T) {
+	extension.RegisterUpdateGate("gate2", func(cfg *config.Config) (extension.UpdateGate, error) {
+		return &testGate{name: "gate2"}, nil
+	})
+	extension.RegisterUpdateGate("gate1", func(cfg *config.Config) (extension.UpdateGate, error) {
+		return &testGate{name: "gate1"}, nil
+	})
+	extension.RegisterAlertRouter("router", func(cfg *config.Config) (extension.AlertRouter, error) {
+		return &testRouter{}, nil
+	})
+
+	extensions, err := extension.New(&config.Config{})
+	if err != nil {
+		t.Fatalf("Can't create extensions: %v", err)
+	}
+
+	if len(extensions.PlacementPolicies) != 0 || len(extensions.AlertRouters) != 1 {
+		t.Errorf("Wrong extensions: %v", extensions)
+	}
+
+	if len(extensions.UpdateGates) != 2 ||
+		extensions.UpdateGates[0].(*testGate).name != "gate1" || extensions.UpdateGates[1].(*testGate).name != "gate2" {
+		t.Errorf("Wrong update gates: %v", extensions.UpdateGates)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic expected on duplicated registration")
+			}
+		}()
+
+		extension.RegisterAlertRouter("router", func(cfg *config.Config) (extension.AlertRouter, error) {
+			return &testRouter{}, nil
+		})
+	}()
+
+	extension.RegisterPlacementPolicy("policy", func(cfg *config.Config) (extension.PlacementPolicy, error) {
+		return nil, aoserrors.New("policy error")
+	})
+
+	if _, err = extension.New(&config.Config{}); err == nil {
+		t.Error("Error expected on extension creation failure")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (gate *testGate) CheckUpdate(updateType string) error {
+	return nil
+}
+
+func (router *testRouter) RouteAlert(alert interface{}) bool {
+	return true
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !faultinjection
+
+package faultinjection
+
+import (
+	"context"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Enabled indicates that CM is built with fault injection.
+const Enabled = false
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetConfig sets faults to inject. Empty config disables fault injection.
+func SetConfig(config Config) error {
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+
+	return aoserrors.Wrap(ErrNotSupported)
+}
+
+// GetConfig returns current fault injection config.
+func GetConfig() (Config, error) {
+	return Config{}, aoserrors.Wrap(ErrNotSupported)
+}
+
+// Fail returns injected fault error if the fault of the injection point is triggered.
+func Fail(point string) error {
+	return nil
+}
+
+// Delay waits for the fault delay if the fault of the injection point is triggered.
+func Delay(ctx context.Context, point string) error {
+	return nil
+}
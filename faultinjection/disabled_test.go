@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !faultinjection
+
+package faultinjection_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestDisabled(t *testing.T) {
+	if err := faultinjection.SetConfig(faultinjection.Config{
+		Faults: map[string]faultinjection.Fault{faultinjection.StorageWriteFailure: {Probability: 1}},
+	}); !errors.Is(err, faultinjection.ErrNotSupported) {
+		t.Errorf("Not supported error expected: %v", err)
+	}
+
+	if _, err := faultinjection.GetConfig(); !errors.Is(err, faultinjection.ErrNotSupported) {
+		t.Errorf("Not supported error expected: %v", err)
+	}
+
+	if err := faultinjection.Fail(faultinjection.StorageWriteFailure); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := faultinjection.Delay(context.Background(), faultinjection.NodeStatusDelay); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build faultinjection
+
+package faultinjection
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Enabled indicates that CM is built with fault injection.
+const Enabled = true
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var (
+	mutex         sync.Mutex
+	currentConfig Config
+	random        = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // not used for security
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetConfig sets faults to inject. Empty config disables fault injection.
+func SetConfig(config Config) error {
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	log.WithFields(log.Fields{"seed": config.Seed, "faults": config.Faults}).Warn("Set fault injection config")
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	random = rand.New(rand.NewSource(seed)) //nolint:gosec // not used for security
+	currentConfig = config
+
+	return nil
+}
+
+// GetConfig returns current fault injection config.
+func GetConfig() (Config, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config := Config{Seed: currentConfig.Seed, Faults: make(map[string]Fault)}
+
+	for point, fault := range currentConfig.Faults {
+		config.Faults[point] = fault
+	}
+
+	return config, nil
+}
+
+// Fail returns injected fault error if the fault of the injection point is triggered.
+func Fail(point string) error {
+	if _, ok := trigger(point); !ok {
+		return nil
+	}
+
+	log.WithField("point", point).Warn("Inject failure")
+
+	return aoserrors.Wrap(ErrInjected)
+}
+
+// Delay waits for the fault delay if the fault of the injection point is triggered.
+func Delay(ctx context.Context, point string) error {
+	fault, ok := trigger(point)
+	if !ok || fault.Delay.Duration == 0 {
+		return nil
+	}
+
+	log.WithFields(log.Fields{"point": point, "delay": fault.Delay}).Debug("Inject delay")
+
+	timer := time.NewTimer(fault.Delay.Duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+
+	case <-ctx.Done():
+		return aoserrors.Wrap(ctx.Err())
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func trigger(point string) (fault Fault, triggered bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	fault, ok := currentConfig.Faults[point]
+	if !ok || fault.Probability == 0 {
+		return fault, false
+	}
+
+	return fault, random.Float64() < fault.Probability
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build faultinjection
+
+package faultinjection_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
+)
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestConfig(t *testing.T) {
+	defer setConfig(t, faultinjection.Config{})
+
+	if err := faultinjection.SetConfig(faultinjection.Config{
+		Faults: map[string]faultinjection.Fault{"unknown": {Probability: 1}},
+	}); err == nil {
+		t.Error("Error expected for unknown injection point")
+	}
+
+	if err := faultinjection.SetConfig(faultinjection.Config{
+		Faults: map[string]faultinjection.Fault{faultinjection.StorageWriteFailure: {Probability: 2}},
+	}); err == nil {
+		t.Error("Error expected for wrong probability")
+	}
+
+	setConfig(t, faultinjection.Config{
+		Seed:   1,
+		Faults: map[string]faultinjection.Fault{faultinjection.StorageWriteFailure: {Probability: 0.5}},
+	})
+
+	config, err := faultinjection.GetConfig()
+	if err != nil {
+		t.Fatalf("Can't get config: %v", err)
+	}
+
+	if config.Seed != 1 || len(config.Faults) != 1 ||
+		config.Faults[faultinjection.StorageWriteFailure].Probability != 0.5 {
+		t.Errorf("Wrong config: %v", config)
+	}
+}
+
+func TestFail(t *testing.T) {
+	defer setConfig(t, faultinjection.Config{})
+
+	if err := faultinjection.Fail(faultinjection.StorageWriteFailure); err != nil {
+		t.Errorf("Unexpected error without faults: %v", err)
+	}
+
+	setConfig(t, faultinjection.Config{
+		Faults: map[string]faultinjection.Fault{faultinjection.StorageWriteFailure: {Probability: 1}},
+	})
+
+	if err := faultinjection.Fail(faultinjection.StorageWriteFailure); !errors.Is(err, faultinjection.ErrInjected) {
+		t.Errorf("Injected error expected: %v", err)
+	}
+
+	if err := faultinjection.Fail(faultinjection.AMQPDisconnect); err != nil {
+		t.Errorf("Unexpected error for other injection point: %v", err)
+	}
+
+	// The same seed produces the same faults sequence
+
+	config := faultinjection.Config{
+		Seed:   42,
+		Faults: map[string]faultinjection.Fault{faultinjection.AMQPDisconnect: {Probability: 0.5}},
+	}
+
+	sequences := make([][]bool, 2)
+
+	for i := range sequences {
+		setConfig(t, config)
+
+		for range 32 {
+			sequences[i] = append(sequences[i], faultinjection.Fail(faultinjection.AMQPDisconnect) != nil)
+		}
+	}
+
+	for i := range sequences[0] {
+		if sequences[0][i] != sequences[1][i] {
+			t.Fatalf("Faults sequence mismatch: %v, %v", sequences[0], sequences[1])
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	defer setConfig(t, faultinjection.Config{})
+
+	setConfig(t, faultinjection.Config{
+		Faults: map[string]faultinjection.Fault{
+			faultinjection.NodeStatusDelay: {Probability: 1, Delay: aostypes.Duration{Duration: 200 * time.Millisecond}},
+		},
+	})
+
+	startTime := time.Now()
+
+	if err := faultinjection.Delay(context.Background(), faultinjection.NodeStatusDelay); err != nil {
+		t.Errorf("Can't delay: %v", err)
+	}
+
+	if time.Since(startTime) < 200*time.Millisecond {
+		t.Error("Delay expected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := faultinjection.Delay(ctx, faultinjection.NodeStatusDelay); err == nil {
+		t.Error("Error expected on context cancel")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func setConfig(t *testing.T, config faultinjection.Config) {
+	t.Helper()
+
+	if err := faultinjection.SetConfig(config); err != nil {
+		t.Fatalf("Can't set config: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package faultinjection injects faults into CM for robustness testing.
+//
+// Faults are injected only if CM is built with faultinjection build tag. Otherwise all injection points are no-op and
+// faults can't be set.
+package faultinjection
+
+import (
+	"errors"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Injection points.
+const (
+	// AMQPDisconnect disconnects from the cloud on sending message.
+	AMQPDisconnect = "amqpDisconnect"
+	// DownloadDelay delays download of each data chunk.
+	DownloadDelay = "downloadDelay"
+	// StorageWriteFailure fails database write.
+	StorageWriteFailure = "storageWriteFailure"
+	// NodeStatusDelay delays node run instances status.
+	NodeStatusDelay = "nodeStatusDelay"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Fault fault parameters.
+type Fault struct {
+	// Probability probability of the fault on each injection point hit from 0 to 1.
+	Probability float64 `json:"probability"`
+	// Delay delay for delay faults.
+	Delay aostypes.Duration `json:"delay,omitempty"`
+}
+
+// Config fault injection config.
+type Config struct {
+	// Seed random seed to reproduce faults sequence. Current time is used if not set.
+	Seed   int64            `json:"seed,omitempty"`
+	Faults map[string]Fault `json:"faults"`
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var (
+	// ErrInjected injected fault error.
+	ErrInjected = errors.New("injected fault")
+	// ErrNotSupported fault injection is not supported error.
+	ErrNotSupported = errors.New("fault injection is not supported")
+)
+
+//nolint:gochecknoglobals
+var injectionPoints = []string{AMQPDisconnect, DownloadDelay, StorageWriteFailure, NodeStatusDelay}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func validateConfig(config Config) error {
+	for point, fault := range config.Faults {
+		found := false
+
+		for _, injectionPoint := range injectionPoints {
+			if point == injectionPoint {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return aoserrors.Errorf("unknown injection point: %s", point)
+		}
+
+		if fault.Probability < 0 || fault.Probability > 1 {
+			return aoserrors.Errorf("wrong %s fault probability: %v", point, fault.Probability)
+		}
+
+		if fault.Delay.Duration < 0 {
+			return aoserrors.Errorf("wrong %s fault delay: %v", point, fault.Delay)
+		}
+	}
+
+	return nil
+}
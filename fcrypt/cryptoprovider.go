@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fcrypt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/utils/cryptutils"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Crypto providers.
+const (
+	IAMCryptoProvider    = "iam"
+	PKCS11CryptoProvider = "pkcs11"
+	TPMCryptoProvider    = "tpm"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CryptoProvider provides keys to decrypt artifacts and CA certificates to verify artifact signs.
+type CryptoProvider interface {
+	GetDecrypter(issuer []byte, serial string) (decrypter crypto.Decrypter, supportPKCS1v15SessionKey bool, err error)
+	GetCACertPool() (*x509.CertPool, error)
+}
+
+// iamCryptoProvider uses offline key of the receiver certificate provided by IAM.
+type iamCryptoProvider struct {
+	certProvider  CertificateProvider
+	cryptoContext *cryptutils.CryptoContext
+}
+
+// keyCryptoProvider uses hardware bound key with configured URL regardless of the receiver certificate.
+type keyCryptoProvider struct {
+	keyURL        string
+	caCertURL     string
+	cryptoContext *cryptutils.CryptoContext
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewCryptoProvider creates crypto provider selected in config.
+func NewCryptoProvider(
+	cfg config.Crypt, certProvider CertificateProvider, cryptoContext *cryptutils.CryptoContext,
+) (CryptoProvider, error) {
+	switch cfg.Provider {
+	case IAMCryptoProvider, "":
+		return &iamCryptoProvider{certProvider: certProvider, cryptoContext: cryptoContext}, nil
+
+	case PKCS11CryptoProvider, TPMCryptoProvider:
+		keyURL, err := url.Parse(cfg.KeyURL)
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if keyURL.Scheme != cfg.Provider {
+			return nil, aoserrors.Errorf("wrong key URL scheme for %s crypto provider: %s", cfg.Provider, cfg.KeyURL)
+		}
+
+		return &keyCryptoProvider{keyURL: cfg.KeyURL, caCertURL: cfg.CACertURL, cryptoContext: cryptoContext}, nil
+
+	default:
+		return nil, aoserrors.Errorf("unsupported crypto provider: %s", cfg.Provider)
+	}
+}
+
+// GetDecrypter returns decrypter of IAM offline key.
+func (provider *iamCryptoProvider) GetDecrypter(issuer []byte, serial string) (
+	decrypter crypto.Decrypter, supportPKCS1v15SessionKey bool, err error,
+) {
+	_, keyURL, err := provider.certProvider.GetCertificate(offlineCertificate, issuer, serial)
+	if err != nil {
+		if provider.isKeyRotated(serial) {
+			return nil, false, aoserrors.Errorf("%w: receiver certificate %s not found", ErrDecryptionKeyRotated, serial)
+		}
+
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	return loadDecrypter(provider.cryptoContext, keyURL)
+}
+
+// GetCACertPool returns CA cert pool of crypto context.
+func (provider *iamCryptoProvider) GetCACertPool() (*x509.CertPool, error) {
+	return provider.cryptoContext.GetCACertPool(), nil
+}
+
+// GetDecrypter returns decrypter of configured key.
+func (provider *keyCryptoProvider) GetDecrypter(issuer []byte, serial string) (
+	decrypter crypto.Decrypter, supportPKCS1v15SessionKey bool, err error,
+) {
+	return loadDecrypter(provider.cryptoContext, provider.keyURL)
+}
+
+// GetCACertPool returns pool of configured CA certificates or CA cert pool of crypto context if not configured.
+func (provider *keyCryptoProvider) GetCACertPool() (*x509.CertPool, error) {
+	if provider.caCertURL == "" {
+		return provider.cryptoContext.GetCACertPool(), nil
+	}
+
+	certs, err := provider.cryptoContext.LoadCertificateByURL(provider.caCertURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	pool := x509.NewCertPool()
+
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+
+	return pool, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// isKeyRotated checks if the receiver certificate is replaced by the new offline certificate.
+func (provider *iamCryptoProvider) isKeyRotated(serial string) bool {
+	certURL, _, err := provider.certProvider.GetCertificate(offlineCertificate, nil, "")
+	if err != nil {
+		return false
+	}
+
+	certs, err := provider.cryptoContext.LoadCertificateByURL(certURL)
+	if err != nil || len(certs) == 0 {
+		return false
+	}
+
+	return !strings.EqualFold(fmt.Sprintf("%X", certs[0].SerialNumber), serial)
+}
+
+func loadDecrypter(cryptoContext *cryptutils.CryptoContext, keyURL string) (
+	decrypter crypto.Decrypter, supportPKCS1v15SessionKey bool, err error,
+) {
+	privKey, supportPKCS1v15SessionKey, err := cryptoContext.LoadPrivateKeyByURL(keyURL)
+	if err != nil {
+		return nil, false, aoserrors.Wrap(err)
+	}
+
+	decrypter, ok := privKey.(crypto.Decrypter)
+	if !ok {
+		return nil, false, aoserrors.New("private key doesn't implement decrypter interface")
+	}
+
+	return decrypter, supportPKCS1v15SessionKey, nil
+}
@@ -18,12 +18,14 @@
 package fcrypt
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/asn1"
@@ -34,6 +36,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
@@ -41,6 +44,8 @@ import (
 	"github.com/aosedge/aos_common/utils/contextreader"
 	"github.com/aosedge/aos_common/utils/cryptutils"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
 )
 
 const (
@@ -59,6 +64,10 @@ const (
 //nolint:gochecknoglobals // use as const
 var issuerAltNameExtID = asn1.ObjectIdentifier{2, 5, 29, 18}
 
+// ErrDecryptionKeyRotated indicates that the artifact is encrypted for the receiver certificate which is already
+// replaced by the new one.
+var ErrDecryptionKeyRotated = errors.New("decryption key rotated")
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -80,7 +89,9 @@ type CryptoSessionKeyInfo struct {
 
 // CryptoHandler crypto handler.
 type CryptoHandler struct {
+	mutex               sync.Mutex
 	certProvider        CertificateProvider
+	cryptoProvider      CryptoProvider
 	cryptoContext       *cryptutils.CryptoContext
 	serviceDiscoveryURL string
 }
@@ -103,7 +114,7 @@ type SymmetricCipherContext struct {
 
 // SignContext sign context.
 type SignContext struct {
-	handler               *CryptoHandler
+	caCertPool            *x509.CertPool
 	signCertificates      []certificateInfo
 	signCertificateChains []certificateChainInfo
 }
@@ -144,23 +155,38 @@ type DecryptParams struct {
 
 // New create context for crypto operations.
 func New(
-	provider CertificateProvider, cryptocontext *cryptutils.CryptoContext, serviceDiscoveryURL string,
+	cfg *config.Config, provider CertificateProvider, cryptocontext *cryptutils.CryptoContext,
 ) (handler *CryptoHandler, err error) {
 	handler = &CryptoHandler{
 		certProvider:        provider,
 		cryptoContext:       cryptocontext,
-		serviceDiscoveryURL: serviceDiscoveryURL,
+		serviceDiscoveryURL: cfg.ServiceDiscoveryURL,
+	}
+
+	if handler.cryptoProvider, err = NewCryptoProvider(cfg.Crypt, provider, cryptocontext); err != nil {
+		return nil, err
 	}
 
 	return handler, nil
 }
 
+// ConfigReloaded applies service discovery URL of reloaded config, e.g. with rotated credentials.
+func (handler *CryptoHandler) ConfigReloaded(cfg *config.Config) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	handler.serviceDiscoveryURL = cfg.ServiceDiscoveryURL
+}
+
 // GetServiceDiscoveryURLs returns service discovery URLs.
 func (handler *CryptoHandler) GetServiceDiscoveryURLs() (serviceDiscoveryURLs []string) {
 	defer func() {
 		if len(serviceDiscoveryURLs) == 0 {
 			log.Warning("Service discovery URL can't be found in certificate and will be used from config")
 
+			handler.mutex.Lock()
+			defer handler.mutex.Unlock()
+
 			serviceDiscoveryURLs = append(serviceDiscoveryURLs, handler.serviceDiscoveryURL)
 		}
 	}()
@@ -193,11 +219,12 @@ func (handler *CryptoHandler) GetCertSerial(certURL string) (serial string, err
 
 // CreateSignContext creates sign context.
 func (handler *CryptoHandler) CreateSignContext() (signContext SignContextInterface, err error) {
-	if handler == nil || handler.cryptoContext.GetCACertPool() == nil {
-		return nil, aoserrors.New("asymmetric context not initialized")
+	signCtx, err := handler.newSignContext()
+	if err != nil {
+		return nil, err
 	}
 
-	return &SignContext{handler: handler}, nil
+	return signCtx, nil
 }
 
 // GetTLSConfig Provides TLS configuration for HTTPS client.
@@ -228,6 +255,49 @@ func (handler *CryptoHandler) GetTLSConfig() (cfg *tls.Config, err error) {
 	return cfg, nil
 }
 
+// SignData signs SHA256 digest of data with online key. Returns signature and raw online certificate chain.
+func (handler *CryptoHandler) SignData(data []byte) (signature []byte, certificates [][]byte, err error) {
+	certURLStr, keyURLStr, err := handler.certProvider.GetCertificate(onlineCertificate, nil, "")
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	certs, err := handler.cryptoContext.LoadCertificateByURL(certURLStr)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	privateKey, _, err := handler.cryptoContext.LoadPrivateKeyByURL(keyURLStr)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, aoserrors.New("private key doesn't implement signer interface")
+	}
+
+	digest := sha256.Sum256(data)
+
+	if signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	return signature, getRawCertificate(certs), nil
+}
+
+// VerifyDataSign verifies data signature with certificates and certificate chains issued by unit root CA.
+func (handler *CryptoHandler) VerifyDataSign(
+	data []byte, chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate, sign cloudprotocol.Signs,
+) error {
+	signCtx, err := handler.createSignContextWithCerts(chains, certs)
+	if err != nil {
+		return err
+	}
+
+	return signCtx.VerifyDataSign(data, sign)
+}
+
 // DecryptAndValidate decrypts and validates encrypted image.
 func (handler *CryptoHandler) DecryptAndValidate(
 	encryptedFile, decryptedFile string, params DecryptParams,
@@ -281,71 +351,41 @@ func (handler *CryptoHandler) DecryptMetadata(input []byte) (output []byte, err
 func (handler *CryptoHandler) ImportSessionKey(
 	keyInfo CryptoSessionKeyInfo,
 ) (symContext SymmetricContextInterface, err error) {
-	_, keyURLStr, err := handler.certProvider.GetCertificate(
-		offlineCertificate, keyInfo.ReceiverInfo.Issuer, keyInfo.ReceiverInfo.Serial)
+	cipherScheme, err := getCipherScheme(keyInfo.SymmetricAlgName)
 	if err != nil {
-		return nil, aoserrors.Wrap(err)
+		return nil, err
 	}
 
-	privKey, supportPKCS1v15SessionKey, err := handler.cryptoContext.LoadPrivateKeyByURL(keyURLStr)
+	keyScheme, err := getKeyScheme(keyInfo.AsymmetricAlgName)
 	if err != nil {
-		log.Errorf("Cant load private key: %s", err)
-
-		return nil, aoserrors.Wrap(err)
+		return nil, err
 	}
 
-	algName, _, _ := decodeAlgNames(keyInfo.SymmetricAlgName)
-
-	keySize, ivSize, err := getSymmetricAlgInfo(algName)
+	decrypter, supportPKCS1v15SessionKey, err := handler.cryptoProvider.GetDecrypter(
+		keyInfo.ReceiverInfo.Issuer, keyInfo.ReceiverInfo.Serial)
 	if err != nil {
-		return nil, aoserrors.Wrap(err)
-	}
-
-	if ivSize != len(keyInfo.SessionIV) {
-		return nil, aoserrors.New("invalid IV length")
-	}
-
-	var opts crypto.DecrypterOpts
-
-	switch strings.ToUpper(keyInfo.AsymmetricAlgName) {
-	case "RSA/PKCS1V1_5":
-		if !supportPKCS1v15SessionKey {
-			keySize = 0
-		}
-
-		opts = &rsa.PKCS1v15DecryptOptions{SessionKeyLen: keySize}
-
-	//nolint:goconst
-	case "RSA/OAEP":
-		opts = &rsa.OAEPOptions{Hash: crypto.SHA1}
-
-	case "RSA/OAEP-256":
-		opts = &rsa.OAEPOptions{Hash: crypto.SHA256}
-
-	case "RSA/OAEP-512":
-		opts = &rsa.OAEPOptions{Hash: crypto.SHA512}
+		log.Errorf("Cant load private key: %s", err)
 
-	default:
-		return nil, aoserrors.Errorf("unsupported asymmetric alg in import key: %s", keyInfo.AsymmetricAlgName)
+		return nil, err
 	}
 
-	decrypter, ok := privKey.(crypto.Decrypter)
-	if !ok {
-		return nil, aoserrors.New("private key doesn't implement decrypter interface")
-	}
-
-	decryptedKey, err := decrypter.Decrypt(rand.Reader, keyInfo.SessionKey, opts)
+	decryptedKey, err := keyScheme.DecryptKey(KeyParams{
+		Receiver:            keyInfo.ReceiverInfo,
+		Decrypter:           decrypter,
+		SessionKeySupported: supportPKCS1v15SessionKey,
+		EncryptedKey:        keyInfo.SessionKey,
+		KeySize:             cipherScheme.GetKeySize(),
+	})
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
-	ctxSym := CreateSymmetricCipherContext()
-
-	if err = ctxSym.set(keyInfo.SymmetricAlgName, decryptedKey, keyInfo.SessionIV); err != nil {
+	symContext, err = cipherScheme.NewDecrypter(keyInfo.SymmetricAlgName, decryptedKey, keyInfo.SessionIV)
+	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
-	return ctxSym, nil
+	return symContext, nil
 }
 
 // AddCertificate adds certificate to context.
@@ -394,6 +434,17 @@ func (signContext *SignContext) AddCertificateChain(name string, fingerprints []
 // VerifySign verifies signature.
 func (signContext *SignContext) VerifySign(
 	ctx context.Context, f *os.File, sign cloudprotocol.Signs,
+) (err error) {
+	return signContext.verifySign(ctx, f, sign)
+}
+
+// VerifyDataSign verifies data signature.
+func (signContext *SignContext) VerifyDataSign(data []byte, sign cloudprotocol.Signs) (err error) {
+	return signContext.verifySign(context.Background(), bytes.NewReader(data), sign)
+}
+
+func (signContext *SignContext) verifySign(
+	ctx context.Context, reader io.Reader, sign cloudprotocol.Signs,
 ) (err error) {
 	if len(signContext.signCertificateChains) == 0 || len(signContext.signCertificates) == 0 {
 		return aoserrors.New("sign context not initialized (no certificates)")
@@ -412,7 +463,7 @@ func (signContext *SignContext) VerifySign(
 	}
 
 	hash := hashFunc.New()
-	if _, err = io.Copy(hash, contextreader.New(ctx, f)); err != nil {
+	if _, err = io.Copy(hash, contextreader.New(ctx, reader)); err != nil {
 		log.Errorf("Error hashing file: %s", err)
 
 		return aoserrors.Wrap(err)
@@ -459,7 +510,7 @@ func (signContext *SignContext) VerifySign(
 	verifyOptions := x509.VerifyOptions{
 		CurrentTime:   signTime,
 		Intermediates: intermediatePool,
-		Roots:         signContext.handler.cryptoContext.GetCACertPool(),
+		Roots:         signContext.caCertPool,
 		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
 	}
 
@@ -574,24 +625,52 @@ func (handler *CryptoHandler) decrypt(encryptedFile, decryptedFile string, param
 	return aoserrors.Wrap(err)
 }
 
-func (handler *CryptoHandler) validateSigns(decryptedFile string, params *DecryptParams) (err error) {
-	signCtx, err := handler.CreateSignContext()
+func (handler *CryptoHandler) newSignContext() (*SignContext, error) {
+	if handler == nil {
+		return nil, aoserrors.New("asymmetric context not initialized")
+	}
+
+	caCertPool, err := handler.cryptoProvider.GetCACertPool()
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, err
+	}
+
+	if caCertPool == nil {
+		return nil, aoserrors.New("asymmetric context not initialized")
+	}
+
+	return &SignContext{caCertPool: caCertPool}, nil
+}
+
+func (handler *CryptoHandler) createSignContextWithCerts(
+	chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
+) (*SignContext, error) {
+	signCtx, err := handler.newSignContext()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
 	}
 
-	for _, cert := range params.Certs {
+	for _, cert := range certs {
 		if err = signCtx.AddCertificate(cert.Fingerprint, cert.Certificate); err != nil {
-			return aoserrors.Wrap(err)
+			return nil, aoserrors.Wrap(err)
 		}
 	}
 
-	for _, chain := range params.Chains {
+	for _, chain := range chains {
 		if err = signCtx.AddCertificateChain(chain.Name, chain.Fingerprints); err != nil {
-			return aoserrors.Wrap(err)
+			return nil, aoserrors.Wrap(err)
 		}
 	}
 
+	return signCtx, nil
+}
+
+func (handler *CryptoHandler) validateSigns(decryptedFile string, params *DecryptParams) (err error) {
+	signCtx, err := handler.createSignContextWithCerts(params.Chains, params.Certs)
+	if err != nil {
+		return err
+	}
+
 	file, err := os.Open(decryptedFile)
 	if err != nil {
 		return aoserrors.Wrap(err)
@@ -635,20 +714,9 @@ func (handler *CryptoHandler) getKeyForEnvelope(keyInfo keyTransRecipientInfo) (
 		return key, aoserrors.Wrap(err)
 	}
 
-	_, keyURLStr, err := handler.certProvider.GetCertificate(
-		offlineCertificate, issuer, fmt.Sprintf("%X", keyInfo.Rid.SerialNumber))
-	if err != nil {
-		return key, aoserrors.Wrap(err)
-	}
-
-	privKey, _, err := handler.cryptoContext.LoadPrivateKeyByURL(keyURLStr)
+	decrypter, _, err := handler.cryptoProvider.GetDecrypter(issuer, fmt.Sprintf("%X", keyInfo.Rid.SerialNumber))
 	if err != nil {
-		return key, aoserrors.Wrap(err)
-	}
-
-	decrypter, ok := privKey.(crypto.Decrypter)
-	if !ok {
-		return nil, aoserrors.New("private key doesn't have a decryption suite")
+		return key, err
 	}
 
 	if key, err = decryptCMSKey(&keyInfo, decrypter); err != nil {
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fcrypt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"sync"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// KeyParams contains parameters to decrypt artifact block key.
+type KeyParams struct {
+	// Receiver receiver info announced in artifact metadata.
+	Receiver ReceiverInfo
+	// Decrypter unit private key selected by receiver info.
+	Decrypter crypto.Decrypter
+	// SessionKeySupported indicates that decrypter supports PKCS #1 v1.5 session key option.
+	SessionKeySupported bool
+	// EncryptedKey encrypted block key or key envelope.
+	EncryptedKey []byte
+	// KeySize block key size required by cipher scheme.
+	KeySize int
+}
+
+// KeyScheme decrypts artifact block key. Schemes are selected by asymmetric algorithm name of artifact decryption
+// info, e.g. RSA/OAEP-256. Hybrid post-quantum envelopes are provided by schemes registered under own names.
+type KeyScheme interface {
+	DecryptKey(params KeyParams) (key []byte, err error)
+}
+
+// CipherScheme decrypts artifact content. Schemes are selected by algorithm and mode of artifact decryption info
+// block algorithm, e.g. AES256/CBC.
+type CipherScheme interface {
+	GetKeySize() int
+	NewDecrypter(algString string, key, iv []byte) (SymmetricContextInterface, error)
+}
+
+type rsaKeyScheme struct {
+	hash crypto.Hash
+	oaep bool
+}
+
+type aesCipherScheme struct {
+	keySize int
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var (
+	registryMutex sync.RWMutex
+	keySchemes    = make(map[string]KeyScheme)
+	cipherSchemes = make(map[string]CipherScheme)
+)
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	RegisterKeyScheme("RSA/PKCS1V1_5", &rsaKeyScheme{})
+	RegisterKeyScheme("RSA/OAEP", &rsaKeyScheme{oaep: true, hash: crypto.SHA1})
+	RegisterKeyScheme("RSA/OAEP-256", &rsaKeyScheme{oaep: true, hash: crypto.SHA256})
+	RegisterKeyScheme("RSA/OAEP-512", &rsaKeyScheme{oaep: true, hash: crypto.SHA512})
+
+	RegisterCipherScheme("AES128/CBC", &aesCipherScheme{keySize: 16})
+	RegisterCipherScheme("AES192/CBC", &aesCipherScheme{keySize: 24})
+	RegisterCipherScheme("AES256/CBC", &aesCipherScheme{keySize: 32})
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// RegisterKeyScheme registers block key decryption scheme. It panics if the name is already registered.
+func RegisterKeyScheme(name string, scheme KeyScheme) {
+	registerScheme(keySchemes, name, scheme)
+}
+
+// RegisterCipherScheme registers content decryption scheme. It panics if the name is already registered.
+func RegisterCipherScheme(name string, scheme CipherScheme) {
+	registerScheme(cipherSchemes, name, scheme)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func registerScheme[S any](schemes map[string]S, name string, scheme S) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	name = strings.ToUpper(name)
+
+	if _, ok := schemes[name]; ok {
+		panic("decryption scheme already registered: " + name)
+	}
+
+	schemes[name] = scheme
+}
+
+func getKeyScheme(asymAlgName string) (KeyScheme, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	scheme, ok := keySchemes[strings.ToUpper(asymAlgName)]
+	if !ok {
+		return nil, aoserrors.Errorf("unsupported asymmetric alg in import key: %s", asymAlgName)
+	}
+
+	return scheme, nil
+}
+
+func getCipherScheme(symAlgName string) (CipherScheme, error) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	algName, modeName, _ := decodeAlgNames(symAlgName)
+
+	scheme, ok := cipherSchemes[strings.ToUpper(algName+"/"+modeName)]
+	if !ok {
+		return nil, aoserrors.Errorf("unsupported symmetric alg: %s", symAlgName)
+	}
+
+	return scheme, nil
+}
+
+func (scheme *rsaKeyScheme) DecryptKey(params KeyParams) (key []byte, err error) {
+	if params.Decrypter == nil {
+		return nil, aoserrors.New("no decrypter")
+	}
+
+	var opts crypto.DecrypterOpts
+
+	if scheme.oaep {
+		opts = &rsa.OAEPOptions{Hash: scheme.hash}
+	} else {
+		keySize := params.KeySize
+
+		if !params.SessionKeySupported {
+			keySize = 0
+		}
+
+		opts = &rsa.PKCS1v15DecryptOptions{SessionKeyLen: keySize}
+	}
+
+	if key, err = params.Decrypter.Decrypt(rand.Reader, params.EncryptedKey, opts); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (scheme *aesCipherScheme) GetKeySize() int {
+	return scheme.keySize
+}
+
+func (scheme *aesCipherScheme) NewDecrypter(algString string, key, iv []byte) (SymmetricContextInterface, error) {
+	ctxSym := CreateSymmetricCipherContext()
+
+	if err := ctxSym.set(algString, key, iv); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return ctxSym, nil
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fcrypt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testKeyScheme struct{}
+
+type testCipherScheme struct{}
+
+type testSymmetricContext struct {
+	key, iv []byte
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestDecryptionSchemes(t *testing.T) {
+	RegisterKeyScheme("test/hybrid", &testKeyScheme{})
+	RegisterCipherScheme("TEST256/CTR", &testCipherScheme{})
+
+	keyScheme, err := getKeyScheme("TEST/HYBRID")
+	if err != nil {
+		t.Fatalf("Can't get key scheme: %v", err)
+	}
+
+	key, err := keyScheme.DecryptKey(KeyParams{EncryptedKey: []byte{0xff, 0xfe}, KeySize: 2})
+	if err != nil {
+		t.Fatalf("Can't decrypt key: %v", err)
+	}
+
+	if !bytes.Equal(key, []byte{0x00, 0x01}) {
+		t.Errorf("Wrong decrypted key: %v", key)
+	}
+
+	cipherScheme, err := getCipherScheme("test256/ctr/nopadding")
+	if err != nil {
+		t.Fatalf("Can't get cipher scheme: %v", err)
+	}
+
+	symContext, err := cipherScheme.NewDecrypter("test256/ctr/nopadding", key, []byte{0x02})
+	if err != nil {
+		t.Fatalf("Can't create decrypter: %v", err)
+	}
+
+	if testContext, ok := symContext.(*testSymmetricContext); !ok || !bytes.Equal(testContext.iv, []byte{0x02}) {
+		t.Errorf("Wrong symmetric context: %v", symContext)
+	}
+
+	for _, algName := range []string{"AES128", "AES192/CBC", "AES256/CBC/PKCS7PADDING"} {
+		if _, err = getCipherScheme(algName); err != nil {
+			t.Errorf("Can't get built-in cipher scheme %s: %v", algName, err)
+		}
+	}
+
+	for _, algName := range []string{"RSA/PKCS1v1_5", "RSA/OAEP", "RSA/OAEP-256", "RSA/OAEP-512"} {
+		if _, err = getKeyScheme(algName); err != nil {
+			t.Errorf("Can't get built-in key scheme %s: %v", algName, err)
+		}
+	}
+
+	if _, err = getKeyScheme("UNKNOWN"); err == nil {
+		t.Error("Error expected for unknown key scheme")
+	}
+
+	if _, err = getCipherScheme("AES256/GCM"); err == nil {
+		t.Error("Error expected for unknown cipher scheme")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Panic expected on duplicate scheme registration")
+		}
+	}()
+
+	RegisterKeyScheme("RSA/OAEP", &testKeyScheme{})
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (scheme *testKeyScheme) DecryptKey(params KeyParams) (key []byte, err error) {
+	key = make([]byte, len(params.EncryptedKey))
+
+	for i, value := range params.EncryptedKey {
+		key[i] = ^value
+	}
+
+	return key, nil
+}
+
+func (scheme *testCipherScheme) GetKeySize() int {
+	return 2
+}
+
+func (scheme *testCipherScheme) NewDecrypter(algString string, key, iv []byte) (SymmetricContextInterface, error) {
+	return &testSymmetricContext{key: key, iv: iv}, nil
+}
+
+func (symContext *testSymmetricContext) DecryptFile(ctx context.Context, encryptedFile, clearFile *os.File) error {
+	return nil
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featureflags manages unit feature flags which toggle experimental CM behaviors at runtime.
+//
+// Default state of flags is defined by CM config. The cloud overrides flags per unit, the overrides are persisted and
+// applied on start. Modules subscribe for flags changes and read the state of the flags they consume.
+package featureflags
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Storage feature flags storage.
+type Storage interface {
+	SetFeatureFlags(flags json.RawMessage) error
+	GetFeatureFlags() (json.RawMessage, error)
+}
+
+// Consumer feature flags consumer.
+type Consumer interface {
+	FeatureFlagsChanged(flags map[string]bool)
+}
+
+// FeatureFlags unit feature flags.
+type FeatureFlags struct {
+	sync.Mutex
+
+	storage   Storage
+	defaults  map[string]bool
+	overrides map[string]bool
+	consumers []Consumer
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates feature flags.
+func New(cfg *config.Config, storage Storage) (featureFlags *FeatureFlags, err error) {
+	log.Debug("Create feature flags")
+
+	featureFlags = &FeatureFlags{storage: storage, defaults: cfg.GetFeatureFlags()}
+
+	data, err := storage.GetFeatureFlags()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if len(data) != 0 {
+		if err = json.Unmarshal(data, &featureFlags.overrides); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+	}
+
+	log.WithField("flags", featureFlags.getFlags()).Debug("Feature flags")
+
+	return featureFlags, nil
+}
+
+// SetFlags sets feature flags overrides. Flags which are not set are reset to their defaults.
+func (featureFlags *FeatureFlags) SetFlags(overrides map[string]bool) error {
+	if err := config.ValidateFeatureFlags(overrides); err != nil {
+		return err
+	}
+
+	featureFlags.Lock()
+
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		featureFlags.Unlock()
+
+		return aoserrors.Wrap(err)
+	}
+
+	if err = featureFlags.storage.SetFeatureFlags(data); err != nil {
+		featureFlags.Unlock()
+
+		return aoserrors.Wrap(err)
+	}
+
+	prevFlags := featureFlags.getFlags()
+
+	featureFlags.overrides = overrides
+
+	flags := featureFlags.getFlags()
+	consumers := slices.Clone(featureFlags.consumers)
+
+	featureFlags.Unlock()
+
+	if maps.Equal(prevFlags, flags) {
+		return nil
+	}
+
+	log.WithField("flags", flags).Info("Feature flags changed")
+
+	// Consumers are notified outside the lock as they may take own locks and read the flags back.
+	for _, consumer := range consumers {
+		consumer.FeatureFlagsChanged(maps.Clone(flags))
+	}
+
+	return nil
+}
+
+// GetFlags returns current state of all feature flags.
+func (featureFlags *FeatureFlags) GetFlags() map[string]bool {
+	featureFlags.Lock()
+	defer featureFlags.Unlock()
+
+	return featureFlags.getFlags()
+}
+
+// IsEnabled returns true if the feature flag is enabled.
+func (featureFlags *FeatureFlags) IsEnabled(flag string) bool {
+	featureFlags.Lock()
+	defer featureFlags.Unlock()
+
+	return featureFlags.getFlags()[flag]
+}
+
+// Subscribe subscribes consumer for feature flags changes. The consumer is notified with current flags on subscription.
+func (featureFlags *FeatureFlags) Subscribe(consumer Consumer) error {
+	featureFlags.Lock()
+
+	if slices.Contains(featureFlags.consumers, consumer) {
+		featureFlags.Unlock()
+
+		return aoserrors.New("already subscribed")
+	}
+
+	featureFlags.consumers = append(featureFlags.consumers, consumer)
+	flags := featureFlags.getFlags()
+
+	featureFlags.Unlock()
+
+	consumer.FeatureFlagsChanged(flags)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (featureFlags *FeatureFlags) getFlags() map[string]bool {
+	flags := maps.Clone(featureFlags.defaults)
+
+	maps.Copy(flags, featureFlags.overrides)
+
+	return flags
+}
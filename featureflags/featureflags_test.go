@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflags_test
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/featureflags"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	flags json.RawMessage
+}
+
+type testConsumer struct {
+	flags []map[string]bool
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestFeatureFlags(t *testing.T) {
+	storage := &testStorage{}
+	consumer := &testConsumer{}
+
+	cfg := &config.Config{
+		AdaptiveTelemetry: config.AdaptiveTelemetry{Enabled: true},
+		FeatureFlags:      map[string]bool{config.FeatureDeltaUnitStatus: false},
+	}
+
+	featureFlags, err := featureflags.New(cfg, storage)
+	if err != nil {
+		t.Fatalf("Can't create feature flags: %v", err)
+	}
+
+	if err = featureFlags.Subscribe(consumer); err != nil {
+		t.Fatalf("Can't subscribe for feature flags: %v", err)
+	}
+
+	if err = featureFlags.Subscribe(consumer); err == nil {
+		t.Error("Error expected on second subscription")
+	}
+
+	defaultFlags := map[string]bool{
+		config.FeatureAdaptiveTelemetry: true,
+		config.FeatureNodeScoring:       false,
+		config.FeatureDeltaUnitStatus:   false,
+	}
+
+	if !reflect.DeepEqual(consumer.flags, []map[string]bool{defaultFlags}) {
+		t.Errorf("Wrong flags on subscription: %v", consumer.flags)
+	}
+
+	if err = featureFlags.SetFlags(map[string]bool{"unknown": true}); err == nil {
+		t.Error("Error expected for unknown flag")
+	}
+
+	if err = featureFlags.SetFlags(map[string]bool{
+		config.FeatureNodeScoring: true, config.FeatureAdaptiveTelemetry: true,
+	}); err != nil {
+		t.Fatalf("Can't set feature flags: %v", err)
+	}
+
+	if !featureFlags.IsEnabled(config.FeatureNodeScoring) || featureFlags.IsEnabled(config.FeatureDeltaUnitStatus) {
+		t.Errorf("Wrong feature flags: %v", featureFlags.GetFlags())
+	}
+
+	// Setting the same flags doesn't notify consumers
+
+	if err = featureFlags.SetFlags(map[string]bool{config.FeatureNodeScoring: true}); err != nil {
+		t.Fatalf("Can't set feature flags: %v", err)
+	}
+
+	if len(consumer.flags) != 2 || !consumer.flags[1][config.FeatureNodeScoring] {
+		t.Errorf("Wrong flags notifications: %v", consumer.flags)
+	}
+
+	// Overrides are restored from storage
+
+	restored, err := featureflags.New(cfg, storage)
+	if err != nil {
+		t.Fatalf("Can't create feature flags: %v", err)
+	}
+
+	if !reflect.DeepEqual(restored.GetFlags(), featureFlags.GetFlags()) {
+		t.Errorf("Wrong restored feature flags: %v", restored.GetFlags())
+	}
+
+	// Empty overrides reset flags to defaults
+
+	if err = featureFlags.SetFlags(nil); err != nil {
+		t.Fatalf("Can't set feature flags: %v", err)
+	}
+
+	if flags := featureFlags.GetFlags(); !reflect.DeepEqual(flags, defaultFlags) {
+		t.Errorf("Wrong feature flags: %v", flags)
+	}
+}
+
+/***********************************************************************************************************************
+ * testStorage
+ **********************************************************************************************************************/
+
+func (storage *testStorage) SetFeatureFlags(flags json.RawMessage) error {
+	storage.flags = flags
+
+	return nil
+}
+
+func (storage *testStorage) GetFeatureFlags() (json.RawMessage, error) {
+	return storage.flags, nil
+}
+
+/***********************************************************************************************************************
+ * testConsumer
+ **********************************************************************************************************************/
+
+func (consumer *testConsumer) FeatureFlagsChanged(flags map[string]bool) {
+	consumer.flags = append(consumer.flags, flags)
+}
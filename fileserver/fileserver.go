@@ -19,15 +19,29 @@ package fileserver
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/iamanager"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
 )
 
 /***********************************************************************************************************************
@@ -36,8 +50,38 @@ import (
 
 // FileServer file server instance.
 type FileServer struct {
-	host   string
-	server *http.Server
+	sync.Mutex
+
+	host        string
+	scheme      string
+	server      *http.Server
+	tlsParams   *TLSParams
+	tlsConfig   *tls.Config
+	certChannel <-chan *iamanager.CertInfo
+	stopChannel chan struct{}
+	urlTTL      time.Duration
+	urlKey      []byte
+	transfers   *transferManager
+	closed      bool
+}
+
+// CertificateProvider certificate and key provider interface.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+	SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error)
+	UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error
+}
+
+// CryptoContext crypto context interface.
+type CryptoContext interface {
+	GetServerMutualTLSConfig(certURLStr, keyURLStr string) (*tls.Config, error)
+}
+
+// TLSParams file server TLS parameters.
+type TLSParams struct {
+	CertType      string
+	CertProvider  CertificateProvider
+	CryptoContext CryptoContext
 }
 
 /***********************************************************************************************************************
@@ -45,17 +89,53 @@ type FileServer struct {
  **********************************************************************************************************************/
 
 const (
-	fileScheme = "file"
-	httpScheme = "http"
+	fileScheme  = "file"
+	httpScheme  = "http"
+	httpsScheme = "https"
+)
+
+const (
+	nodeParam      = "node"
+	expiresParam   = "expires"
+	signatureParam = "signature"
 )
 
+const urlKeySize = 32
+
 /***********************************************************************************************************************
  * public
  **********************************************************************************************************************/
 
-// New creates file server.
-func New(serverURL, dir string) (fileServer *FileServer, err error) {
-	fileServer = &FileServer{}
+// NewTLSParams returns file server TLS parameters. Nil is returned if TLS is disabled in config.
+func NewTLSParams(
+	cfg *config.Config, certProvider CertificateProvider, cryptoContext CryptoContext,
+) (tlsParams *TLSParams) {
+	if !cfg.FileServer.TLS {
+		return nil
+	}
+
+	certType := cfg.FileServer.CertType
+	if certType == "" {
+		certType = cfg.CertStorage
+	}
+
+	return &TLSParams{CertType: certType, CertProvider: certProvider, CryptoContext: cryptoContext}
+}
+
+// New creates file server. If TLS params are set, file server uses HTTPS with client certificate verification.
+// If URL TTL is set, only signed node URLs are served. URL signing key is persisted in URL key file, so URLs signed
+// before CM restart remain valid.
+func New(serverURL, dir string, cfg config.FileServer, tlsParams *TLSParams) (fileServer *FileServer, err error) {
+	fileServer = &FileServer{
+		scheme: httpScheme, tlsParams: tlsParams, stopChannel: make(chan struct{}), urlTTL: cfg.URLTTL.Duration,
+		transfers: newTransferManager(cfg.MaxConcurrentTransfers, cfg.MaxBandwidth),
+	}
+
+	if fileServer.urlTTL != 0 {
+		if fileServer.urlKey, err = loadURLKey(cfg.URLKeyFile); err != nil {
+			return nil, err
+		}
+	}
 
 	if serverURL != "" {
 		host, port, err := net.SplitHostPort(serverURL)
@@ -65,20 +145,51 @@ func New(serverURL, dir string) (fileServer *FileServer, err error) {
 
 		fileServer.host = host
 
+		handler := fileServer.signatureHandler(fileServer.transfers.handler(etagHandler(http.Dir(dir))))
+
 		fileServer.server = &http.Server{
 			Addr:              ":" + port,
-			Handler:           http.FileServer(http.Dir(dir)),
+			Handler:           handler,
 			ReadHeaderTimeout: 5 * time.Second,
 		}
 
+		if tlsParams != nil {
+			if err = fileServer.initTLS(); err != nil {
+				return nil, err
+			}
+
+			go fileServer.handleCertChanged()
+		}
+
 		go fileServer.startFileStorage()
 	}
 
 	return fileServer, nil
 }
 
-// Close closes file server.
+// Close closes file server. Subsequent calls do nothing.
 func (fileServer *FileServer) Close() (err error) {
+	fileServer.Lock()
+
+	if fileServer.closed {
+		fileServer.Unlock()
+
+		return nil
+	}
+
+	fileServer.closed = true
+
+	fileServer.Unlock()
+
+	close(fileServer.stopChannel)
+
+	if fileServer.certChannel != nil {
+		if unsubscribeErr := fileServer.tlsParams.CertProvider.UnsubscribeCertChanged(
+			fileServer.certChannel); unsubscribeErr != nil {
+			err = aoserrors.Wrap(unsubscribeErr)
+		}
+	}
+
 	if fileServer.server != nil {
 		if shutdownErr := fileServer.server.Shutdown(context.Background()); shutdownErr != nil {
 			if err == nil {
@@ -96,7 +207,7 @@ func (fileServer *FileServer) Close() (err error) {
 	return aoserrors.Wrap(err)
 }
 
-// TranslateURL convert image path url (file:// or http://).
+// TranslateURL convert image path url (file://, http:// or https://).
 func (fileServer *FileServer) TranslateURL(isLocal bool, inURL string) (outURL string, err error) {
 	if !isLocal {
 		if fileServer.server == nil {
@@ -112,7 +223,7 @@ func (fileServer *FileServer) TranslateURL(isLocal bool, inURL string) (outURL s
 			imgURL.Path = filepath.Base(imgURL.Path)
 		}
 
-		imgURL.Scheme = httpScheme
+		imgURL.Scheme = fileServer.scheme
 		imgURL.Host = fileServer.host + fileServer.server.Addr
 
 		outURL = imgURL.String()
@@ -123,10 +234,239 @@ func (fileServer *FileServer) TranslateURL(isLocal bool, inURL string) (outURL s
 	return outURL, nil
 }
 
+// SignURL signs remote URL for the node. Signed URL is valid only within URL TTL and can't be used to access
+// other files. If TLS is enabled, signed URL can be used only by the node with certificate issued for the node ID.
+func (fileServer *FileServer) SignURL(inURL, nodeID string) (outURL string, err error) {
+	if fileServer.urlKey == nil {
+		return inURL, nil
+	}
+
+	signedURL, err := url.Parse(inURL)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(fileServer.urlTTL).Unix(), 10)
+
+	query := signedURL.Query()
+
+	query.Set(nodeParam, nodeID)
+	query.Set(expiresParam, expires)
+	query.Set(signatureParam, fileServer.calculateSignature(signedURL.Path, nodeID, expires))
+
+	signedURL.RawQuery = query.Encode()
+
+	return signedURL.String(), nil
+}
+
 /***********************************************************************************************************************
- * public
+ * Private
  **********************************************************************************************************************/
 
+// loadURLKey reads URL signing key from the file. New key is generated and stored if the file doesn't exist. If the
+// file is not set, the key is not persisted.
+func loadURLKey(keyFile string) (key []byte, err error) {
+	if keyFile != "" {
+		if key, err = os.ReadFile(keyFile); err == nil && len(key) == urlKeySize {
+			return key, nil
+		}
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err == nil {
+			log.WithField("file", keyFile).Warn("Wrong URL key size, generate new key")
+		}
+	}
+
+	key = make([]byte, urlKeySize)
+
+	if _, err = rand.Read(key); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if keyFile == "" {
+		return key, nil
+	}
+
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0o755); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(keyFile, key, 0o600); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (fileServer *FileServer) calculateSignature(urlPath, nodeID, expires string) string {
+	mac := hmac.New(sha256.New, fileServer.urlKey)
+
+	mac.Write([]byte(path.Clean("/" + urlPath)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nodeID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (fileServer *FileServer) validateSignature(r *http.Request) error {
+	query := r.URL.Query()
+
+	nodeID, expires, signature := query.Get(nodeParam), query.Get(expiresParam), query.Get(signatureParam)
+
+	if signature == "" {
+		return aoserrors.New("URL is not signed")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(fileServer.calculateSignature(r.URL.Path, nodeID, expires))) {
+		return aoserrors.New("invalid URL signature")
+	}
+
+	expiresTime, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if time.Now().Unix() > expiresTime {
+		return aoserrors.New("URL expired")
+	}
+
+	// With TLS, the URL is scoped to the node identified by client certificate
+	if r.TLS != nil {
+		if len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return aoserrors.New("client certificate not found")
+		}
+
+		cert := r.TLS.VerifiedChains[0][0]
+
+		if cert.Subject.CommonName != nodeID && !slices.Contains(cert.DNSNames, nodeID) {
+			return aoserrors.Errorf("URL is signed for other node than %s", cert.Subject.CommonName)
+		}
+	}
+
+	return nil
+}
+
+func (fileServer *FileServer) signatureHandler(handler http.Handler) http.Handler {
+	if fileServer.urlKey == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fileServer.validateSignature(r); err != nil {
+			log.WithFields(log.Fields{
+				"path": r.URL.Path, "nodeID": r.URL.Query().Get(nodeParam), "remoteAddr": r.RemoteAddr,
+			}).Warnf("File access denied: %v", err)
+
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// etagHandler sets strong ETag for served files. The file server handles Range, If-Range and If-None-Match
+// requests using this ETag, so clients are able to resume interrupted downloads.
+func etagHandler(root http.Dir) http.Handler {
+	fileHandler := http.FileServer(root)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if file, err := root.Open(path.Clean("/" + r.URL.Path)); err == nil {
+			if info, err := file.Stat(); err == nil && !info.IsDir() {
+				w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+			}
+
+			file.Close()
+		}
+
+		fileHandler.ServeHTTP(w, r)
+	})
+}
+
+func (fileServer *FileServer) initTLS() (err error) {
+	if fileServer.certChannel, err = fileServer.tlsParams.CertProvider.SubscribeCertChanged(
+		fileServer.tlsParams.CertType); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			if unsubscribeErr := fileServer.tlsParams.CertProvider.UnsubscribeCertChanged(
+				fileServer.certChannel); unsubscribeErr != nil {
+				log.Errorf("Can't unsubscribe from certificate changes: %v", unsubscribeErr)
+			}
+
+			fileServer.certChannel = nil
+		}
+	}()
+
+	certURL, keyURL, err := fileServer.tlsParams.CertProvider.GetCertificate(fileServer.tlsParams.CertType, nil, "")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = fileServer.loadTLSConfig(certURL, keyURL); err != nil {
+		return err
+	}
+
+	fileServer.scheme = httpsScheme
+	fileServer.server.TLSConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: fileServer.getTLSConfig,
+	}
+
+	return nil
+}
+
+func (fileServer *FileServer) loadTLSConfig(certURL, keyURL string) error {
+	tlsConfig, err := fileServer.tlsParams.CryptoContext.GetServerMutualTLSConfig(certURL, keyURL)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	fileServer.Lock()
+	defer fileServer.Unlock()
+
+	fileServer.tlsConfig = tlsConfig
+
+	return nil
+}
+
+func (fileServer *FileServer) getTLSConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	fileServer.Lock()
+	defer fileServer.Unlock()
+
+	return fileServer.tlsConfig, nil
+}
+
+func (fileServer *FileServer) handleCertChanged() {
+	for {
+		select {
+		case certInfo, ok := <-fileServer.certChannel:
+			if !ok {
+				return
+			}
+
+			log.WithField("certType", certInfo.GetType()).Debug("File server certificate changed")
+
+			if err := fileServer.loadTLSConfig(certInfo.GetCertUrl(), certInfo.GetKeyUrl()); err != nil {
+				log.Errorf("Can't reload file server certificate: %v", err)
+			}
+
+		case <-fileServer.stopChannel:
+			return
+		}
+	}
+}
+
 func (fileServer *FileServer) startFileStorage() {
 	if fileServer.server == nil {
 		log.Debug("Do not start local file server")
@@ -135,7 +475,15 @@ func (fileServer *FileServer) startFileStorage() {
 
 	log.WithField("addr", fileServer.server.Addr).Debug("Start file server")
 
-	if err := fileServer.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+	var err error
+
+	if fileServer.server.TLSConfig != nil {
+		err = fileServer.server.ListenAndServeTLS("", "")
+	} else {
+		err = fileServer.server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
 		log.Errorf("Can't start local file server: %s", err)
 	}
 }
@@ -21,15 +21,29 @@ package fileserver_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"io"
+	"math/big"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/iamanager"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/fileserver"
 )
 
@@ -43,6 +57,17 @@ const serverDir = "/tmp/fileserverTest"
  * Types
  **********************************************************************************************************************/
 
+type testCertProvider struct {
+	certURL      string
+	certChannel  chan *iamanager.CertInfo
+	unsubscribed bool
+}
+
+type testCryptoContext struct {
+	caPool *x509.CertPool
+	certs  map[string]tls.Certificate
+}
+
 /***********************************************************************************************************************
  * Init
  **********************************************************************************************************************/
@@ -62,7 +87,7 @@ func init() {
  **********************************************************************************************************************/
 
 func TestOnlyLocalFileServer(t *testing.T) {
-	fileServer, err := fileserver.New("", serverDir)
+	fileServer, err := fileserver.New("", serverDir, config.FileServer{}, nil)
 	if err != nil {
 		t.Fatalf("Can't create fileServer: %s", err)
 	}
@@ -88,7 +113,7 @@ func TestFileServer(t *testing.T) {
 	}
 	defer os.RemoveAll(serverDir)
 
-	fileServer, err := fileserver.New("localhost:8092", serverDir)
+	fileServer, err := fileserver.New("localhost:8092", serverDir, config.FileServer{}, nil)
 	if err != nil {
 		t.Fatalf("Can't create fileServer: %s", err)
 	}
@@ -139,3 +164,600 @@ func TestFileServer(t *testing.T) {
 		t.Errorf("incorrect file content: %s", buffer.String())
 	}
 }
+
+func TestFileServerRange(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	fileServer, err := fileserver.New("localhost:8094", serverDir, config.FileServer{}, nil)
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	filename := "testFile.txt"
+
+	if err := os.WriteFile(filepath.Join(serverDir, filename), []byte("Hello fileserver"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+
+	outURL, err := fileServer.TranslateURL(false, "file://"+filepath.Join(serverDir, filename))
+	if err != nil {
+		t.Errorf("Can't translate remote url: %s", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	status, etag, content, err := downloadRange(outURL, "", "")
+	if err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if status != http.StatusOK || content != "Hello fileserver" {
+		t.Errorf("Wrong response: %d, %s", status, content)
+	}
+
+	if etag == "" {
+		t.Fatal("ETag is not set")
+	}
+
+	testData := []struct {
+		ifRange         string
+		expectedStatus  int
+		expectedContent string
+	}{
+		{expectedStatus: http.StatusPartialContent, expectedContent: "fileserver"},
+		{ifRange: etag, expectedStatus: http.StatusPartialContent, expectedContent: "fileserver"},
+		{ifRange: `"outdated"`, expectedStatus: http.StatusOK, expectedContent: "Hello fileserver"},
+	}
+
+	for _, data := range testData {
+		status, rangeETag, content, err := downloadRange(outURL, "bytes=6-", data.ifRange)
+		if err != nil {
+			t.Fatalf("Can't download file: %v", err)
+		}
+
+		if status != data.expectedStatus {
+			t.Errorf("Wrong status: %d", status)
+		}
+
+		if content != data.expectedContent {
+			t.Errorf("Wrong content: %s", content)
+		}
+
+		if rangeETag != etag {
+			t.Errorf("Wrong ETag: %s", rangeETag)
+		}
+	}
+}
+
+func TestFileServerSignedURL(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	fileServer, err := fileserver.New("localhost:8095", serverDir,
+		config.FileServer{URLTTL: aostypes.Duration{Duration: 2 * time.Second}}, nil)
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	for _, filename := range []string{"file1.txt", "file2.txt"} {
+		if err := os.WriteFile(filepath.Join(serverDir, filename), []byte("Hello fileserver"), 0o600); err != nil {
+			t.Fatalf("Can't create package file: %s", err)
+		}
+	}
+
+	time.Sleep(1 * time.Second)
+
+	file1URL, err := fileServer.TranslateURL(false, "file1.txt")
+	if err != nil {
+		t.Fatalf("Can't translate remote url: %s", err)
+	}
+
+	signedURL, err := fileServer.SignURL(file1URL, "node1")
+	if err != nil {
+		t.Fatalf("Can't sign url: %v", err)
+	}
+
+	parsedURL, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("Can't parse url: %v", err)
+	}
+
+	otherFileURL := *parsedURL
+	otherFileURL.Path = "/file2.txt"
+
+	otherNodeQuery := parsedURL.Query()
+	otherNodeQuery.Set("node", "node2")
+
+	otherNodeURL := *parsedURL
+	otherNodeURL.RawQuery = otherNodeQuery.Encode()
+
+	testData := []struct {
+		url            string
+		expectedStatus int
+	}{
+		{url: file1URL, expectedStatus: http.StatusForbidden},
+		{url: signedURL, expectedStatus: http.StatusOK},
+		{url: otherFileURL.String(), expectedStatus: http.StatusForbidden},
+		{url: otherNodeURL.String(), expectedStatus: http.StatusForbidden},
+	}
+
+	for _, data := range testData {
+		status, _, _, err := downloadRange(data.url, "", "")
+		if err != nil {
+			t.Fatalf("Can't download file: %v", err)
+		}
+
+		if status != data.expectedStatus {
+			t.Errorf("Wrong status for %s: %d", data.url, status)
+		}
+	}
+
+	time.Sleep(3 * time.Second)
+
+	status, _, _, err := downloadRange(signedURL, "", "")
+	if err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if status != http.StatusForbidden {
+		t.Errorf("Wrong status for expired url: %d", status)
+	}
+}
+
+func TestFileServerURLKeyPersisted(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	if err := os.WriteFile(filepath.Join(serverDir, "file1.txt"), []byte("Hello fileserver"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+
+	cfg := config.FileServer{
+		URLTTL: aostypes.Duration{Duration: time.Hour}, URLKeyFile: filepath.Join(t.TempDir(), "url.key"),
+	}
+
+	fileServer, err := fileserver.New("localhost:8097", serverDir, cfg, nil)
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+
+	fileURL, err := fileServer.TranslateURL(false, "file1.txt")
+	if err != nil {
+		t.Fatalf("Can't translate remote url: %s", err)
+	}
+
+	signedURL, err := fileServer.SignURL(fileURL, "node1")
+	if err != nil {
+		t.Fatalf("Can't sign url: %v", err)
+	}
+
+	if err = fileServer.Close(); err != nil {
+		t.Errorf("Can't close file server: %v", err)
+	}
+
+	// URL signed before restart is still valid
+
+	if fileServer, err = fileserver.New("localhost:8097", serverDir, cfg, nil); err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	time.Sleep(1 * time.Second)
+
+	status, _, _, err := downloadRange(signedURL, "", "")
+	if err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if status != http.StatusOK {
+		t.Errorf("Wrong status for URL signed before restart: %d", status)
+	}
+}
+
+func TestFileServerTransfers(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	const (
+		fileSize  = 128 * 1024
+		bandwidth = 64 * 1024
+	)
+
+	fileServer, err := fileserver.New("127.0.0.1:8096", serverDir,
+		config.FileServer{MaxConcurrentTransfers: 1, MaxBandwidth: bandwidth}, nil)
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	if err := os.WriteFile(filepath.Join(serverDir, "file.bin"), make([]byte, fileSize), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+
+	fileURL, err := fileServer.TranslateURL(false, "file.bin")
+	if err != nil {
+		t.Fatalf("Can't translate remote url: %s", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	type downloadResult struct {
+		status  int
+		size    int
+		elapsed time.Duration
+		err     error
+	}
+
+	resultChannel := make(chan downloadResult, 1)
+
+	go func() {
+		startTime := time.Now()
+
+		status, _, content, err := downloadRange(fileURL, "", "")
+
+		resultChannel <- downloadResult{status: status, size: len(content), elapsed: time.Since(startTime), err: err}
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+
+	status, _, _, err := downloadRange(fileURL, "", "")
+	if err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Wrong status: %d", status)
+	}
+
+	result := <-resultChannel
+	if result.err != nil {
+		t.Fatalf("Can't download file: %v", result.err)
+	}
+
+	if result.status != http.StatusOK || result.size != fileSize {
+		t.Errorf("Wrong download result: %d, %d", result.status, result.size)
+	}
+
+	if result.elapsed < time.Second {
+		t.Errorf("Bandwidth is not limited: %v", result.elapsed)
+	}
+
+	stats := fileServer.GetTransferStats()
+
+	expectedStats := map[string]fileserver.TransferStats{
+		"127.0.0.1": {Transfers: 1, RejectedTransfers: 1, SentBytes: fileSize},
+	}
+
+	if !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("Wrong transfer stats: %v", stats)
+	}
+}
+
+func TestFileServerTLS(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	caCert, caKey, err := createCACert()
+	if err != nil {
+		t.Fatalf("Can't create CA cert: %v", err)
+	}
+
+	cryptoContext := &testCryptoContext{caPool: x509.NewCertPool(), certs: make(map[string]tls.Certificate)}
+	cryptoContext.caPool.AddCert(caCert)
+
+	for i, name := range []string{"server1", "server2", "client"} {
+		if cryptoContext.certs[name], err = createCert(caCert, caKey, int64(i+2), name); err != nil {
+			t.Fatalf("Can't create cert: %v", err)
+		}
+	}
+
+	certProvider := &testCertProvider{certURL: "server1", certChannel: make(chan *iamanager.CertInfo, 1)}
+
+	fileServer, err := fileserver.New("localhost:8093", serverDir, config.FileServer{}, &fileserver.TLSParams{
+		CertType: "fileserver", CertProvider: certProvider, CryptoContext: cryptoContext,
+	})
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	filename := "testFile.txt"
+
+	if err := os.WriteFile(filepath.Join(serverDir, filename), []byte("Hello fileserver"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+
+	outURL, err := fileServer.TranslateURL(false, "file://"+filepath.Join(serverDir, filename))
+	if err != nil {
+		t.Errorf("Can't translate remote url: %s", err)
+	}
+
+	if outURL != "https://localhost:8093/"+filename {
+		t.Errorf("Incorrect remote translated url: %s", outURL)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	if _, err = downloadTLS(outURL, caCert, nil); err == nil {
+		t.Error("Error expected without client certificate")
+	}
+
+	clientCert := cryptoContext.certs["client"]
+
+	serverCert, err := downloadTLS(outURL, caCert, &clientCert)
+	if err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if serverCert.SerialNumber.Int64() != 2 {
+		t.Errorf("Wrong server cert serial: %v", serverCert.SerialNumber)
+	}
+
+	certProvider.certChannel <- &iamanager.CertInfo{Type: "fileserver", CertUrl: "server2", KeyUrl: "server2"}
+
+	time.Sleep(1 * time.Second)
+
+	if serverCert, err = downloadTLS(outURL, caCert, &clientCert); err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	if serverCert.SerialNumber.Int64() != 3 {
+		t.Errorf("Server cert is not reloaded: %v", serverCert.SerialNumber)
+	}
+
+	// Node query param should not affect stats key
+	if _, err = downloadTLS(outURL+"?node=node1", caCert, &clientCert); err != nil {
+		t.Fatalf("Can't download file: %v", err)
+	}
+
+	expectedStats := map[string]fileserver.TransferStats{
+		"client": {Transfers: 3, SentBytes: 3 * uint64(len("Hello fileserver"))},
+	}
+
+	if stats := fileServer.GetTransferStats(); !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("Wrong transfer stats: %v", stats)
+	}
+
+	if err = fileServer.Close(); err != nil {
+		t.Errorf("Can't close file server: %v", err)
+	}
+
+	if !certProvider.unsubscribed {
+		t.Error("Certificate changes should be unsubscribed")
+	}
+
+	if err = fileServer.Close(); err != nil {
+		t.Errorf("Can't close file server twice: %v", err)
+	}
+}
+
+func TestFileServerTLSSignedURL(t *testing.T) {
+	if err := os.MkdirAll(serverDir, 0o755); err != nil {
+		t.Fatalf("Can't create server dir: %v", err)
+	}
+	defer os.RemoveAll(serverDir)
+
+	caCert, caKey, err := createCACert()
+	if err != nil {
+		t.Fatalf("Can't create CA cert: %v", err)
+	}
+
+	cryptoContext := &testCryptoContext{caPool: x509.NewCertPool(), certs: make(map[string]tls.Certificate)}
+	cryptoContext.caPool.AddCert(caCert)
+
+	for i, name := range []string{"server", "node1"} {
+		if cryptoContext.certs[name], err = createCert(caCert, caKey, int64(i+2), name); err != nil {
+			t.Fatalf("Can't create cert: %v", err)
+		}
+	}
+
+	certProvider := &testCertProvider{certURL: "server", certChannel: make(chan *iamanager.CertInfo, 1)}
+
+	fileServer, err := fileserver.New("localhost:8098", serverDir,
+		config.FileServer{URLTTL: aostypes.Duration{Duration: time.Hour}}, &fileserver.TLSParams{
+			CertType: "fileserver", CertProvider: certProvider, CryptoContext: cryptoContext,
+		})
+	if err != nil {
+		t.Fatalf("Can't create fileServer: %s", err)
+	}
+	defer fileServer.Close()
+
+	if err := os.WriteFile(filepath.Join(serverDir, "file1.txt"), []byte("Hello fileserver"), 0o600); err != nil {
+		t.Fatalf("Can't create package file: %s", err)
+	}
+
+	fileURL, err := fileServer.TranslateURL(false, "file1.txt")
+	if err != nil {
+		t.Fatalf("Can't translate remote url: %s", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	clientCert := cryptoContext.certs["node1"]
+
+	node1URL, err := fileServer.SignURL(fileURL, "node1")
+	if err != nil {
+		t.Fatalf("Can't sign url: %v", err)
+	}
+
+	if _, err = downloadTLS(node1URL, caCert, &clientCert); err != nil {
+		t.Errorf("Can't download file: %v", err)
+	}
+
+	// URL signed for other node can't be used with node1 certificate
+
+	node2URL, err := fileServer.SignURL(fileURL, "node2")
+	if err != nil {
+		t.Fatalf("Can't sign url: %v", err)
+	}
+
+	if _, err = downloadTLS(node2URL, caCert, &clientCert); err == nil {
+		t.Error("Error expected for URL signed for other node")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (provider *testCertProvider) GetCertificate(
+	certType string, issuer []byte, serial string,
+) (certURL, keyURL string, err error) {
+	return provider.certURL, provider.certURL, nil
+}
+
+func (provider *testCertProvider) SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error) {
+	return provider.certChannel, nil
+}
+
+func (provider *testCertProvider) UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error {
+	provider.unsubscribed = true
+
+	return nil
+}
+
+func (context *testCryptoContext) GetServerMutualTLSConfig(certURLStr, keyURLStr string) (*tls.Config, error) {
+	cert, ok := context.certs[certURLStr]
+	if !ok {
+		return nil, aoserrors.New("cert not found")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    context.caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func createCACert() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	return cert, key, nil
+}
+
+func createCert(
+	caCert *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, commonName string,
+) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, aoserrors.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, aoserrors.Wrap(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func downloadTLS(
+	fileURL string, caCert *x509.Certificate, clientCert *tls.Certificate,
+) (serverCert *x509.Certificate, err error) {
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(caCert)
+
+	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}}
+
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if string(data) != "Hello fileserver" {
+		return nil, aoserrors.Errorf("incorrect file content: %s", string(data))
+	}
+
+	return resp.TLS.PeerCertificates[0], nil
+}
+
+func downloadRange(fileURL, rangeHeader, ifRange string) (status int, etag, content string, err error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, fileURL, nil)
+	if err != nil {
+		return 0, "", "", aoserrors.Wrap(err)
+	}
+
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+
+	if ifRange != "" {
+		req.Header.Set("If-Range", ifRange)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", "", aoserrors.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", "", aoserrors.Wrap(err)
+	}
+
+	return resp.StatusCode, resp.Header.Get("ETag"), string(data), nil
+}
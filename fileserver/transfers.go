@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fileserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	// max size of data written at once when bandwidth is limited.
+	throttleChunkSize = 32 * 1024
+	// retry after period in seconds sent to clients when transfers limit is reached.
+	retryAfterPeriod = 5
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// TransferStats node transfer statistics.
+type TransferStats struct {
+	// ActiveTransfers number of transfers in progress.
+	ActiveTransfers int
+	// Transfers number of finished transfers.
+	Transfers uint64
+	// RejectedTransfers number of transfers rejected due to concurrent transfers limit.
+	RejectedTransfers uint64
+	// SentBytes number of sent bytes.
+	SentBytes uint64
+}
+
+type transferManager struct {
+	sync.Mutex
+
+	maxTransfers    int
+	activeTransfers int
+	limiter         *bandwidthLimiter
+	stats           map[string]*TransferStats
+}
+
+type bandwidthLimiter struct {
+	sync.Mutex
+
+	rate     float64
+	nextSend time.Time
+}
+
+type transferWriter struct {
+	http.ResponseWriter
+
+	ctx     context.Context //nolint:containedctx // used to cancel throttling
+	manager *transferManager
+	nodeID  string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetTransferStats returns transfer statistics per node.
+func (fileServer *FileServer) GetTransferStats() map[string]TransferStats {
+	return fileServer.transfers.getStats()
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTransferManager(maxTransfers int, maxBandwidth uint64) *transferManager {
+	manager := &transferManager{maxTransfers: maxTransfers, stats: make(map[string]*TransferStats)}
+
+	if maxBandwidth != 0 {
+		manager.limiter = &bandwidthLimiter{rate: float64(maxBandwidth)}
+	}
+
+	return manager
+}
+
+func (manager *transferManager) handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nodeID := getRequestPeer(r)
+
+		if !manager.startTransfer(nodeID) {
+			log.WithFields(log.Fields{"path": r.URL.Path, "nodeID": nodeID}).Warn("Transfers limit reached")
+
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterPeriod))
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		defer manager.finishTransfer(nodeID)
+
+		handler.ServeHTTP(&transferWriter{ResponseWriter: w, ctx: r.Context(), manager: manager, nodeID: nodeID}, r)
+	})
+}
+
+func (manager *transferManager) startTransfer(nodeID string) bool {
+	manager.Lock()
+	defer manager.Unlock()
+
+	stats := manager.getNodeStats(nodeID)
+
+	if manager.maxTransfers != 0 && manager.activeTransfers >= manager.maxTransfers {
+		stats.RejectedTransfers++
+
+		return false
+	}
+
+	manager.activeTransfers++
+	stats.ActiveTransfers++
+
+	return true
+}
+
+func (manager *transferManager) finishTransfer(nodeID string) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	stats := manager.getNodeStats(nodeID)
+
+	manager.activeTransfers--
+	stats.ActiveTransfers--
+	stats.Transfers++
+}
+
+func (manager *transferManager) addSentBytes(nodeID string, size int) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	manager.getNodeStats(nodeID).SentBytes += uint64(size)
+}
+
+func (manager *transferManager) getNodeStats(nodeID string) *TransferStats {
+	stats, ok := manager.stats[nodeID]
+	if !ok {
+		stats = &TransferStats{}
+		manager.stats[nodeID] = stats
+	}
+
+	return stats
+}
+
+func (manager *transferManager) getStats() map[string]TransferStats {
+	manager.Lock()
+	defer manager.Unlock()
+
+	stats := make(map[string]TransferStats, len(manager.stats))
+
+	for nodeID, nodeStats := range manager.stats {
+		stats[nodeID] = *nodeStats
+	}
+
+	return stats
+}
+
+func (limiter *bandwidthLimiter) wait(ctx context.Context, size int) error {
+	limiter.Lock()
+
+	now := time.Now()
+
+	if limiter.nextSend.Before(now) {
+		limiter.nextSend = now
+	}
+
+	delay := limiter.nextSend.Sub(now)
+	limiter.nextSend = limiter.nextSend.Add(time.Duration(float64(size) / limiter.rate * float64(time.Second)))
+
+	limiter.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+
+	case <-ctx.Done():
+		return aoserrors.Wrap(ctx.Err())
+	}
+}
+
+func (writer *transferWriter) Write(data []byte) (written int, err error) {
+	for len(data) > 0 {
+		chunk := data
+
+		if writer.manager.limiter != nil {
+			if len(chunk) > throttleChunkSize {
+				chunk = chunk[:throttleChunkSize]
+			}
+
+			if err = writer.manager.limiter.wait(writer.ctx, len(chunk)); err != nil {
+				return written, err
+			}
+		}
+
+		n, err := writer.ResponseWriter.Write(chunk)
+
+		writer.manager.addSentBytes(writer.nodeID, n)
+		written += n
+
+		if err != nil {
+			return written, aoserrors.Wrap(err)
+		}
+
+		data = data[n:]
+	}
+
+	return written, nil
+}
+
+// ReadFrom keeps zero-copy transfer of underlying response writer when bandwidth is not limited.
+func (writer *transferWriter) ReadFrom(src io.Reader) (read int64, err error) {
+	readerFrom, ok := writer.ResponseWriter.(io.ReaderFrom)
+	if !ok || writer.manager.limiter != nil {
+		// Hide ReadFrom to copy data by Write
+		read, err = io.Copy(struct{ io.Writer }{writer}, src)
+
+		return read, aoserrors.Wrap(err)
+	}
+
+	read, err = readerFrom.ReadFrom(src)
+
+	writer.manager.addSentBytes(writer.nodeID, int(read))
+
+	return read, aoserrors.Wrap(err)
+}
+
+// Unwrap returns underlying response writer for http.ResponseController.
+func (writer *transferWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// getRequestPeer returns authenticated TLS peer. Client address is used if TLS is not enabled.
+func getRequestPeer(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) != 0 && len(r.TLS.VerifiedChains[0]) != 0 {
+		return r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
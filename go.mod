@@ -18,6 +18,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/hashicorp/go-version v1.7.0
 	github.com/jackpal/gateway v1.0.15
+	github.com/klauspost/compress v1.15.11
 	github.com/looplab/fsm v1.0.2
 	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/opencontainers/go-digest v1.0.0
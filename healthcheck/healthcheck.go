@@ -0,0 +1,440 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package healthcheck performs service instances health checks.
+//
+// Health checks are defined by healthChecks field of Aos service config. TCP and HTTP checks are performed by CM
+// against instance IP. Exec checks require running command inside instance on the node, which is not supported by SM
+// protocol, so they are validated but not performed.
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Health check types.
+const (
+	CheckExec = "exec"
+	CheckTCP  = "tcp"
+	CheckHTTP = "http"
+)
+
+const (
+	defaultInterval         = 10 * time.Second
+	defaultTimeout          = 1 * time.Second
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 1
+)
+
+const healthChannelSize = 100
+
+const (
+	checkUnknown = iota
+	checkHealthy
+	checkUnhealthy
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Check service health check.
+type Check struct {
+	Type string `json:"type"`
+	// Command command executed inside instance by exec check.
+	Command []string `json:"command,omitempty"`
+	// Port instance port of tcp and http checks.
+	Port uint16 `json:"port,omitempty"`
+	// Path URL path of http check.
+	Path     string            `json:"path,omitempty"`
+	Interval aostypes.Duration `json:"interval,omitempty"`
+	Timeout  aostypes.Duration `json:"timeout,omitempty"`
+	// FailureThreshold number of consecutive failures after which instance is unhealthy.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// SuccessThreshold number of consecutive successes after which instance is healthy.
+	SuccessThreshold int `json:"successThreshold,omitempty"`
+}
+
+// Instance instance to check.
+type Instance struct {
+	aostypes.InstanceIdent
+	IP     string
+	Checks []Check
+}
+
+// InstanceHealth instance health.
+type InstanceHealth struct {
+	aostypes.InstanceIdent
+	Healthy bool
+	// Reason error of the failed check if instance is unhealthy.
+	Reason string
+}
+
+// Checker performs instances health checks and reports changes of instances health.
+type Checker struct {
+	sync.Mutex
+
+	instances     map[aostypes.InstanceIdent]*instanceChecker
+	healthChannel chan InstanceHealth
+	wg            sync.WaitGroup
+}
+
+type instanceChecker struct {
+	instance   Instance
+	cancelFunc context.CancelFunc
+}
+
+type checkState struct {
+	check     Check
+	state     int
+	successes int
+	failures  int
+	err       error
+	nextRun   time.Time
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates health checker.
+func New() (checker *Checker) {
+	log.Debug("Create health checker")
+
+	return &Checker{
+		instances:     make(map[aostypes.InstanceIdent]*instanceChecker),
+		healthChannel: make(chan InstanceHealth, healthChannelSize),
+	}
+}
+
+// Close closes health checker.
+func (checker *Checker) Close() {
+	log.Debug("Close health checker")
+
+	checker.Lock()
+
+	for ident, instanceChecker := range checker.instances {
+		instanceChecker.cancelFunc()
+		delete(checker.instances, ident)
+	}
+
+	checker.Unlock()
+
+	checker.wg.Wait()
+
+	close(checker.healthChannel)
+}
+
+// UpdateInstances sets instances to check. Checks of new and changed instances are started, checks of not present
+// instances are stopped. Health of instance is reported when it is known and then on each change.
+func (checker *Checker) UpdateInstances(instances []Instance) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	newInstances := make(map[aostypes.InstanceIdent]Instance)
+
+	for _, instance := range instances {
+		newInstances[instance.InstanceIdent] = instance
+	}
+
+	for ident, instanceChecker := range checker.instances {
+		if instance, ok := newInstances[ident]; ok && reflect.DeepEqual(instance, instanceChecker.instance) {
+			delete(newInstances, ident)
+
+			continue
+		}
+
+		instanceChecker.cancelFunc()
+		delete(checker.instances, ident)
+	}
+
+	for _, instance := range newInstances {
+		checker.startInstance(instance)
+	}
+}
+
+// ResetInstances restarts checks of instances. Health of the instances is reported again when it is known.
+func (checker *Checker) ResetInstances(instances []aostypes.InstanceIdent) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	for _, ident := range instances {
+		instanceChecker, ok := checker.instances[ident]
+		if !ok {
+			continue
+		}
+
+		instanceChecker.cancelFunc()
+		checker.startInstance(instanceChecker.instance)
+	}
+}
+
+// GetHealthChannel returns channel of instances health changes.
+func (checker *Checker) GetHealthChannel() <-chan InstanceHealth {
+	return checker.healthChannel
+}
+
+// Validate validates health check.
+func (check Check) Validate() error {
+	switch check.Type {
+	case CheckExec:
+		if len(check.Command) == 0 {
+			return aoserrors.New("exec health check command is not set")
+		}
+
+	case CheckTCP, CheckHTTP:
+		if check.Port == 0 {
+			return aoserrors.Errorf("%s health check port is not set", check.Type)
+		}
+
+	default:
+		return aoserrors.Errorf("unsupported health check type: %s", check.Type)
+	}
+
+	if check.Interval.Duration < 0 || check.Timeout.Duration < 0 {
+		return aoserrors.New("negative health check interval or timeout")
+	}
+
+	if check.FailureThreshold < 0 || check.SuccessThreshold < 0 {
+		return aoserrors.New("negative health check threshold")
+	}
+
+	return nil
+}
+
+// Supported returns true if the check is performed by CM.
+func (check Check) Supported() bool {
+	return check.Type == CheckTCP || check.Type == CheckHTTP
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (checker *Checker) startInstance(instance Instance) {
+	log.WithFields(log.Fields{
+		"instance": instance.InstanceIdent, "ip": instance.IP,
+	}).Debug("Start instance health checks")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	checker.instances[instance.InstanceIdent] = &instanceChecker{instance: instance, cancelFunc: cancelFunc}
+
+	checker.wg.Add(1)
+
+	go checker.checkInstance(ctx, instance)
+}
+
+func (checker *Checker) checkInstance(ctx context.Context, instance Instance) {
+	defer checker.wg.Done()
+
+	checks := make([]checkState, 0, len(instance.Checks))
+
+	for _, check := range instance.Checks {
+		if !check.Supported() {
+			continue
+		}
+
+		checks = append(checks, checkState{check: check.withDefaults()})
+	}
+
+	if len(checks) == 0 {
+		return
+	}
+
+	var (
+		reported   bool
+		wasHealthy bool
+		timer      = time.NewTimer(0)
+	)
+
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		nextRun := now.Add(defaultInterval)
+
+		for i := range checks {
+			if !now.Before(checks[i].nextRun) {
+				checks[i].run(ctx, instance.IP)
+				checks[i].nextRun = now.Add(checks[i].check.Interval.Duration)
+			}
+
+			if checks[i].nextRun.Before(nextRun) {
+				nextRun = checks[i].nextRun
+			}
+		}
+
+		if health, known := getInstanceHealth(instance.InstanceIdent, checks); known &&
+			(!reported || health.Healthy != wasHealthy) {
+			if checker.sendHealth(ctx, health) {
+				reported, wasHealthy = true, health.Healthy
+			}
+		}
+
+		timer.Reset(time.Until(nextRun))
+	}
+}
+
+// sendHealth sends instance health if checks of the instance are not stopped. Health is not sent if the channel is
+// full and is sent again after next checks run.
+func (checker *Checker) sendHealth(ctx context.Context, health InstanceHealth) (sent bool) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case checker.healthChannel <- health:
+		log.WithFields(log.Fields{
+			"instance": health.InstanceIdent, "healthy": health.Healthy, "reason": health.Reason,
+		}).Info("Instance health changed")
+
+		return true
+
+	default:
+		log.WithField("instance", health.InstanceIdent).Error("Health channel is full")
+
+		return false
+	}
+}
+
+func getInstanceHealth(instance aostypes.InstanceIdent, checks []checkState) (health InstanceHealth, known bool) {
+	health = InstanceHealth{InstanceIdent: instance, Healthy: true}
+
+	for _, check := range checks {
+		switch check.state {
+		case checkUnhealthy:
+			return InstanceHealth{
+				InstanceIdent: instance,
+				Reason:        check.check.Type + " check failed: " + check.err.Error(),
+			}, true
+
+		case checkUnknown:
+			health.Healthy = false
+		}
+	}
+
+	return health, health.Healthy
+}
+
+func (check Check) withDefaults() Check {
+	if check.Interval.Duration == 0 {
+		check.Interval.Duration = defaultInterval
+	}
+
+	if check.Timeout.Duration == 0 {
+		check.Timeout.Duration = defaultTimeout
+	}
+
+	if check.FailureThreshold == 0 {
+		check.FailureThreshold = defaultFailureThreshold
+	}
+
+	if check.SuccessThreshold == 0 {
+		check.SuccessThreshold = defaultSuccessThreshold
+	}
+
+	return check
+}
+
+func (state *checkState) run(ctx context.Context, ip string) {
+	if err := state.check.probe(ctx, ip); err != nil {
+		state.err = err
+		state.successes = 0
+		state.failures++
+
+		if state.failures >= state.check.FailureThreshold {
+			state.state = checkUnhealthy
+		}
+
+		return
+	}
+
+	state.failures = 0
+	state.successes++
+
+	if state.successes >= state.check.SuccessThreshold {
+		state.state = checkHealthy
+	}
+}
+
+func (check Check) probe(ctx context.Context, ip string) error {
+	address := net.JoinHostPort(ip, strconv.FormatUint(uint64(check.Port), 10))
+
+	ctx, cancelFunc := context.WithTimeout(ctx, check.Timeout.Duration)
+	defer cancelFunc()
+
+	switch check.Type {
+	case CheckTCP:
+		var dialer net.Dialer
+
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		conn.Close()
+
+	case CheckHTTP:
+		request, err := http.NewRequestWithContext(
+			ctx, http.MethodGet, "http://"+address+"/"+strings.TrimPrefix(check.Path, "/"), nil)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		response.Body.Close()
+
+		if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+			return aoserrors.Errorf("unexpected status code: %d", response.StatusCode)
+		}
+
+	default:
+		return aoserrors.Errorf("unsupported health check type: %s", check.Type)
+	}
+
+	return nil
+}
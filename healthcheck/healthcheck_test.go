@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthcheck_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const checkInterval = 20 * time.Millisecond
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestValidate(t *testing.T) {
+	type testData struct {
+		name  string
+		check healthcheck.Check
+		valid bool
+	}
+
+	data := []testData{
+		{name: "exec", check: healthcheck.Check{Type: healthcheck.CheckExec, Command: []string{"true"}}, valid: true},
+		{name: "tcp", check: healthcheck.Check{Type: healthcheck.CheckTCP, Port: 8080}, valid: true},
+		{name: "http", check: healthcheck.Check{Type: healthcheck.CheckHTTP, Port: 8080, Path: "/healthz"}, valid: true},
+		{name: "no command", check: healthcheck.Check{Type: healthcheck.CheckExec}},
+		{name: "no port", check: healthcheck.Check{Type: healthcheck.CheckHTTP, Path: "/healthz"}},
+		{name: "unknown type", check: healthcheck.Check{Type: "grpc", Port: 8080}},
+		{
+			name:  "negative threshold",
+			check: healthcheck.Check{Type: healthcheck.CheckTCP, Port: 8080, FailureThreshold: -1},
+		},
+		{
+			name: "negative interval",
+			check: healthcheck.Check{
+				Type: healthcheck.CheckTCP, Port: 8080, Interval: aostypes.Duration{Duration: -time.Second},
+			},
+		},
+	}
+
+	for _, item := range data {
+		t.Run(item.name, func(t *testing.T) {
+			if err := item.check.Validate(); (err == nil) != item.valid {
+				t.Errorf("Wrong validation result: %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	var healthy atomic.Bool
+
+	healthy.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	checker := healthcheck.New()
+	defer checker.Close()
+
+	ip, port := getAddress(t, server.Listener.Addr())
+	instance := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}
+
+	checker.UpdateInstances([]healthcheck.Instance{{
+		InstanceIdent: instance, IP: ip,
+		Checks: []healthcheck.Check{{
+			Type: healthcheck.CheckHTTP, Port: port, Path: "healthz",
+			Interval: aostypes.Duration{Duration: checkInterval}, FailureThreshold: 2, SuccessThreshold: 2,
+		}},
+	}})
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Healthy: true,
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+
+	healthy.Store(false)
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Reason: "unexpected status code: 503",
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+
+	healthy.Store(true)
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Healthy: true,
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+
+	// Health is reported again after reset
+
+	checker.ResetInstances([]aostypes.InstanceIdent{instance})
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Healthy: true,
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+}
+
+func TestTCPCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can't create listener: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+		}
+	}()
+
+	checker := healthcheck.New()
+	defer checker.Close()
+
+	ip, port := getAddress(t, listener.Addr())
+	instance := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}
+
+	checker.UpdateInstances([]healthcheck.Instance{{
+		InstanceIdent: instance, IP: ip,
+		Checks: []healthcheck.Check{
+			{Type: healthcheck.CheckTCP, Port: port, Interval: aostypes.Duration{Duration: checkInterval}},
+			// Exec checks are not performed by CM
+			{Type: healthcheck.CheckExec, Command: []string{"false"}},
+		},
+	}})
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Healthy: true,
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+
+	listener.Close()
+
+	if err := waitHealth(checker.GetHealthChannel(), healthcheck.InstanceHealth{
+		InstanceIdent: instance, Reason: "tcp check failed",
+	}); err != nil {
+		t.Errorf("Wrong instance health: %v", err)
+	}
+
+	// Removed instance is not checked
+
+	checker.UpdateInstances(nil)
+
+	select {
+	case health := <-checker.GetHealthChannel():
+		t.Errorf("Unexpected instance health: %v", health)
+
+	case <-time.After(5 * checkInterval):
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func getAddress(t *testing.T, addr net.Addr) (ip string, port uint16) {
+	t.Helper()
+
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		t.Fatalf("Can't parse address: %v", err)
+	}
+
+	portValue, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		t.Fatalf("Can't parse port: %v", err)
+	}
+
+	return host, uint16(portValue)
+}
+
+func waitHealth(healthChannel <-chan healthcheck.InstanceHealth, expected healthcheck.InstanceHealth) error {
+	select {
+	case health := <-healthChannel:
+		if health.InstanceIdent != expected.InstanceIdent || health.Healthy != expected.Healthy ||
+			!strings.Contains(health.Reason, expected.Reason) {
+			return aoserrors.Errorf("unexpected health: %v", health)
+		}
+
+		return nil
+
+	case <-time.After(time.Second):
+		return aoserrors.New("wait health timeout")
+	}
+}
@@ -0,0 +1,537 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iamcache caches IAM certificates and permissions.
+package iamcache
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/api/iamanager"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// IAMClient IAM client interface.
+type IAMClient interface {
+	GetNodeID() string
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+	SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error)
+	UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error
+	GetPermissions(secret, funcServerID string) (
+		instance aostypes.InstanceIdent, permissions map[string]string, err error)
+	RenewCertificatesNotification(secrets cloudprotocol.UnitSecrets, certInfo []cloudprotocol.RenewCertData) error
+	SubscribeUnitSubjectsChanged() <-chan []string
+}
+
+// CircuitBreaker circuit breaker of IAM calls.
+type CircuitBreaker interface {
+	Allow() error
+	Done(err error)
+}
+
+// CertificateLoader loads certificates.
+type CertificateLoader interface {
+	LoadCertificateByURL(certURL string) ([]*x509.Certificate, error)
+}
+
+// Cache caches IAM certificates and permissions. Cached values are used when IAM is not available. Certificates are
+// periodically checked for validity: invalid certificates are removed from the cache and certificates which expire
+// soon are renewed if unit secrets are known. Permissions of instances of removed unit subjects are removed from the
+// cache as soon as unit subjects are changed, permissions rejected by IAM are removed on the next request.
+type Cache struct {
+	sync.Mutex
+
+	config       config.IAMCache
+	iam          IAMClient
+	certLoader   CertificateLoader
+	certs        map[certKey]certEntry
+	permissions  map[permissionsKey]permissionsEntry
+	certChannels map[string]<-chan *iamanager.CertInfo
+	unitSecrets  *cloudprotocol.UnitSecrets
+	renewedCerts map[string]string
+	breaker      CircuitBreaker
+	closeChannel chan struct{}
+	wg           sync.WaitGroup
+}
+
+type certKey struct {
+	certType string
+	issuer   string
+	serial   string
+}
+
+type certEntry struct {
+	certURL   string
+	keyURL    string
+	updatedAt time.Time
+}
+
+type permissionsKey struct {
+	secret       string
+	funcServerID string
+}
+
+type permissionsEntry struct {
+	instance    aostypes.InstanceIdent
+	permissions map[string]string
+	updatedAt   time.Time
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates IAM cache.
+func New(cfg *config.Config, iam IAMClient, certLoader CertificateLoader) (cache *Cache, err error) {
+	log.Debug("Create IAM cache")
+
+	cache = &Cache{
+		config:       cfg.IAMCache,
+		iam:          iam,
+		certLoader:   certLoader,
+		certs:        make(map[certKey]certEntry),
+		permissions:  make(map[permissionsKey]permissionsEntry),
+		certChannels: make(map[string]<-chan *iamanager.CertInfo),
+		renewedCerts: make(map[string]string),
+		closeChannel: make(chan struct{}),
+	}
+
+	if cache.config.CheckPeriod.Duration > 0 {
+		cache.wg.Add(1)
+
+		go cache.handleChecks()
+	}
+
+	cache.wg.Add(1)
+
+	go cache.handleSubjectsChanges(iam.SubscribeUnitSubjectsChanged())
+
+	return cache, nil
+}
+
+// Close closes IAM cache.
+func (cache *Cache) Close() {
+	log.Debug("Close IAM cache")
+
+	cache.Lock()
+	certChannels := maps.Clone(cache.certChannels)
+	cache.Unlock()
+
+	// Unsubscribe while cert changes are still handled as IAM client blocks on sending to full channel
+	for certType, certChannel := range certChannels {
+		if err := cache.iam.UnsubscribeCertChanged(certChannel); err != nil {
+			log.WithField("type", certType).Errorf("Can't unsubscribe from cert changes: %v", err)
+		}
+	}
+
+	close(cache.closeChannel)
+	cache.wg.Wait()
+}
+
+// GetCertificate returns certificate and key URLs. Cached URLs are returned if they are not older than cert TTL or
+// if IAM request fails.
+func (cache *Cache) GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error) {
+	key := certKey{certType: certType, issuer: hex.EncodeToString(issuer), serial: serial}
+
+	cache.Lock()
+	entry, cached := cache.certs[key]
+	cache.Unlock()
+
+	if cached && time.Since(entry.updatedAt) < cache.config.CertTTL.Duration {
+		return entry.certURL, entry.keyURL, nil
+	}
+
+	// IAM is requested without cache lock as IAM client notifies cert changes under its own lock
+	if err = cache.callIAM(func() (err error) {
+		certURL, keyURL, err = cache.iam.GetCertificate(certType, issuer, serial)
+
+		return err
+	}); err != nil {
+		if cached {
+			log.WithField("type", certType).Warnf("Use cached certificate, IAM request failed: %v", err)
+
+			return entry.certURL, entry.keyURL, nil
+		}
+
+		return "", "", aoserrors.Wrap(err)
+	}
+
+	cache.subscribeCertChanged(certType)
+
+	cache.Lock()
+	cache.certs[key] = certEntry{certURL: certURL, keyURL: keyURL, updatedAt: time.Now()}
+	cache.Unlock()
+
+	return certURL, keyURL, nil
+}
+
+// SubscribeCertChanged subscribes for certificate changes.
+func (cache *Cache) SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error) {
+	return cache.iam.SubscribeCertChanged(certType)
+}
+
+// UnsubscribeCertChanged unsubscribes from certificate changes.
+func (cache *Cache) UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error {
+	return cache.iam.UnsubscribeCertChanged(listener)
+}
+
+// GetPermissions returns instance permissions. Cached permissions are returned if they are not older than
+// permissions TTL or if IAM is not available and they are not older than permissions max staleness. Cached permissions
+// are dropped if IAM rejects the request, e.g. the instance secret is revoked.
+func (cache *Cache) GetPermissions(
+	secret, funcServerID string,
+) (instance aostypes.InstanceIdent, permissions map[string]string, err error) {
+	key := permissionsKey{secret: secret, funcServerID: funcServerID}
+
+	cache.Lock()
+	entry, cached := cache.permissions[key]
+	cache.Unlock()
+
+	if cached && time.Since(entry.updatedAt) < cache.config.PermissionsTTL.Duration {
+		return entry.instance, maps.Clone(entry.permissions), nil
+	}
+
+	if err = cache.callIAM(func() (err error) {
+		instance, permissions, err = cache.iam.GetPermissions(secret, funcServerID)
+
+		return err
+	}); err != nil {
+		if !cached {
+			return instance, nil, aoserrors.Wrap(err)
+		}
+
+		if !isIAMRejection(err) && time.Since(entry.updatedAt) < cache.config.PermissionsMaxStaleness.Duration {
+			log.WithField("funcServerID", funcServerID).Warnf("Use cached permissions, IAM request failed: %v", err)
+
+			return entry.instance, maps.Clone(entry.permissions), nil
+		}
+
+		log.WithField("funcServerID", funcServerID).Warnf("Drop cached permissions, IAM request failed: %v", err)
+
+		cache.Lock()
+		delete(cache.permissions, key)
+		cache.Unlock()
+
+		return instance, nil, aoserrors.Wrap(err)
+	}
+
+	cache.Lock()
+	cache.permissions[key] = permissionsEntry{
+		instance: instance, permissions: maps.Clone(permissions), updatedAt: time.Now(),
+	}
+	cache.Unlock()
+
+	return instance, permissions, nil
+}
+
+// InvalidatePermissions removes cached permissions of the instance, e.g. when the instance is removed.
+func (cache *Cache) InvalidatePermissions(instance aostypes.InstanceIdent) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	maps.DeleteFunc(cache.permissions, func(key permissionsKey, entry permissionsEntry) bool {
+		return entry.instance == instance
+	})
+}
+
+// SetCircuitBreaker sets circuit breaker of IAM calls. Cached values are used while the circuit is open.
+func (cache *Cache) SetCircuitBreaker(breaker CircuitBreaker) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	cache.breaker = breaker
+}
+
+// SetUnitSecrets sets unit secrets used to renew certificates before expiry. Secrets are kept in memory only.
+func (cache *Cache) SetUnitSecrets(secrets cloudprotocol.UnitSecrets) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	cache.unitSecrets = &secrets
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (cache *Cache) subscribeCertChanged(certType string) {
+	cache.Lock()
+	_, subscribed := cache.certChannels[certType]
+	cache.Unlock()
+
+	if subscribed {
+		return
+	}
+
+	certChannel, err := cache.iam.SubscribeCertChanged(certType)
+	if err != nil {
+		log.WithField("type", certType).Warnf("Can't subscribe for cert changes: %v", err)
+
+		return
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+
+	if _, ok := cache.certChannels[certType]; ok {
+		if err = cache.iam.UnsubscribeCertChanged(certChannel); err != nil {
+			log.WithField("type", certType).Errorf("Can't unsubscribe from cert changes: %v", err)
+		}
+
+		return
+	}
+
+	cache.certChannels[certType] = certChannel
+
+	cache.wg.Add(1)
+
+	go cache.handleCertChanges(certType, certChannel)
+}
+
+func (cache *Cache) handleCertChanges(certType string, certChannel <-chan *iamanager.CertInfo) {
+	defer cache.wg.Done()
+
+	for {
+		select {
+		case _, ok := <-certChannel:
+			if !ok {
+				return
+			}
+
+			log.WithField("type", certType).Debug("Certificate changed")
+
+			cache.Lock()
+			cache.invalidateCerts(certType)
+			cache.Unlock()
+
+		case <-cache.closeChannel:
+			return
+		}
+	}
+}
+
+func (cache *Cache) handleSubjectsChanges(subjectsChannel <-chan []string) {
+	defer cache.wg.Done()
+
+	for {
+		select {
+		case subjects, ok := <-subjectsChannel:
+			if !ok {
+				return
+			}
+
+			log.WithField("subjects", subjects).Debug("Unit subjects changed")
+
+			cache.Lock()
+			maps.DeleteFunc(cache.permissions, func(key permissionsKey, entry permissionsEntry) bool {
+				return !slices.Contains(subjects, entry.instance.SubjectID)
+			})
+			cache.Unlock()
+
+		case <-cache.closeChannel:
+			return
+		}
+	}
+}
+
+func (cache *Cache) handleChecks() {
+	defer cache.wg.Done()
+
+	checkTicker := time.NewTicker(cache.config.CheckPeriod.Duration)
+	defer checkTicker.Stop()
+
+	for {
+		select {
+		case <-checkTicker.C:
+			cache.checkCertificates()
+
+		case <-cache.closeChannel:
+			return
+		}
+	}
+}
+
+// checkCertificates checks current certificates of cached types: replaced certificates are refreshed, invalid
+// certificates are removed from the cache and certificates which expire soon are renewed.
+func (cache *Cache) checkCertificates() {
+	cache.Lock()
+
+	certTypes := make(map[string]struct{})
+
+	for key := range cache.certs {
+		certTypes[key.certType] = struct{}{}
+	}
+
+	cache.Unlock()
+
+	for certType := range certTypes {
+		if err := cache.checkCertificate(certType); err != nil {
+			log.WithField("type", certType).Warnf("Can't check certificate: %v", err)
+		}
+	}
+}
+
+func (cache *Cache) checkCertificate(certType string) error {
+	var certURL, keyURL string
+
+	if err := cache.callIAM(func() (err error) {
+		certURL, keyURL, err = cache.iam.GetCertificate(certType, nil, "")
+
+		return err
+	}); err != nil {
+		// IAM may be temporary unavailable, cached certificates are checked on next period
+		return aoserrors.Wrap(err)
+	}
+
+	currentKey := certKey{certType: certType}
+
+	cache.Lock()
+
+	if entry, ok := cache.certs[currentKey]; ok && entry.certURL != certURL {
+		log.WithField("type", certType).Info("Certificate replaced")
+
+		cache.invalidateCerts(certType)
+	}
+
+	cache.certs[currentKey] = certEntry{certURL: certURL, keyURL: keyURL, updatedAt: time.Now()}
+
+	cache.Unlock()
+
+	certs, err := cache.certLoader.LoadCertificateByURL(certURL)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(certs) == 0 {
+		return aoserrors.New("no certificate found")
+	}
+
+	now := time.Now()
+
+	if now.Before(certs[0].NotBefore) || now.After(certs[0].NotAfter) {
+		log.WithFields(log.Fields{
+			"type": certType, "notBefore": certs[0].NotBefore, "notAfter": certs[0].NotAfter,
+		}).Error("Certificate is not valid")
+
+		cache.Lock()
+		cache.invalidateCerts(certType)
+		cache.Unlock()
+	}
+
+	if certs[0].NotAfter.Sub(now) < cache.config.RenewBefore.Duration {
+		cache.renewCertificate(certType, certs[0])
+	}
+
+	return nil
+}
+
+func (cache *Cache) renewCertificate(certType string, cert *x509.Certificate) {
+	serial := fmt.Sprintf("%X", cert.SerialNumber)
+
+	cache.Lock()
+	renewedSerial, unitSecrets := cache.renewedCerts[certType], cache.unitSecrets
+	cache.Unlock()
+
+	if renewedSerial == serial {
+		return
+	}
+
+	logFields := log.Fields{"type": certType, "serial": serial, "notAfter": cert.NotAfter}
+
+	if unitSecrets == nil {
+		log.WithFields(logFields).Warn("Certificate expires soon, unit secrets are required to renew it")
+
+		return
+	}
+
+	log.WithFields(logFields).Info("Renew certificate before expiry")
+
+	if err := cache.callIAM(func() error {
+		return cache.iam.RenewCertificatesNotification(*unitSecrets, []cloudprotocol.RenewCertData{{
+			Type: certType, NodeID: cache.iam.GetNodeID(), Serial: serial, ValidTill: cert.NotAfter,
+		}})
+	}); err != nil {
+		log.WithFields(logFields).Errorf("Can't renew certificate: %v", err)
+
+		return
+	}
+
+	cache.Lock()
+	cache.renewedCerts[certType] = serial
+	cache.Unlock()
+}
+
+// isIAMRejection checks if the error is IAM response rejecting the request, not IAM unavailability.
+func isIAMRejection(err error) bool {
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		// Not gRPC errors are returned by connection or circuit breaker
+		return false
+	}
+
+	switch grpcStatus.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted, codes.Aborted:
+		return false
+
+	default:
+		return true
+	}
+}
+
+// callIAM calls IAM through the circuit breaker if it is set.
+func (cache *Cache) callIAM(call func() error) error {
+	cache.Lock()
+	breaker := cache.breaker
+	cache.Unlock()
+
+	if breaker == nil {
+		return call()
+	}
+
+	if err := breaker.Allow(); err != nil {
+		return err
+	}
+
+	err := call()
+
+	breaker.Done(err)
+
+	return err
+}
+
+func (cache *Cache) invalidateCerts(certType string) {
+	maps.DeleteFunc(cache.certs, func(key certKey, entry certEntry) bool {
+		return key.certType == certType
+	})
+}
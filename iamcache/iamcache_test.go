@@ -0,0 +1,589 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iamcache_test
+
+import (
+	"crypto/x509"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/api/iamanager"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/aosedge/aos_communicationmanager/circuitbreaker"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/iamcache"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const waitTimeout = 5 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testIAMClient struct {
+	sync.Mutex
+
+	certURL            string
+	permissions        map[string]string
+	unavailable        bool
+	revoked            bool
+	certRequests       int
+	permissionRequests int
+	certChannel        chan *iamanager.CertInfo
+	renewChannel       chan []cloudprotocol.RenewCertData
+	subjectsChannel    chan []string
+}
+
+type testCertLoader struct {
+	sync.Mutex
+
+	cert *x509.Certificate
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestCertificateCache(t *testing.T) {
+	iam := newTestIAMClient()
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		CertTTL: aostypes.Duration{Duration: time.Hour},
+	}}, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setCertURL("file:///cert1.pem")
+
+	certURL, _, err := cache.GetCertificate("online", nil, "")
+	if err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	if certURL != "file:///cert1.pem" {
+		t.Errorf("Wrong cert URL: %s", certURL)
+	}
+
+	// Cached certificate
+
+	iam.setCertURL("file:///cert2.pem")
+
+	if certURL, _, err = cache.GetCertificate("online", nil, ""); err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	if certURL != "file:///cert1.pem" || iam.getCertRequests() != 1 {
+		t.Errorf("Cached cert URL expected: %s, requests: %d", certURL, iam.getCertRequests())
+	}
+
+	// Certificate changed
+
+	iam.certChannel <- &iamanager.CertInfo{Type: "online", CertUrl: "file:///cert2.pem"}
+
+	if err = waitCertURL(cache, "file:///cert2.pem"); err != nil {
+		t.Errorf("Certificate is not updated: %v", err)
+	}
+
+	// Not cached certificate and IAM is unavailable
+
+	iam.setUnavailable(true)
+
+	if _, _, err = cache.GetCertificate("offline", nil, ""); err == nil {
+		t.Error("Error expected for not cached certificate")
+	}
+}
+
+func TestCertificateCacheIAMUnavailable(t *testing.T) {
+	iam := newTestIAMClient()
+
+	cache, err := iamcache.New(&config.Config{}, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setCertURL("file:///cert1.pem")
+
+	if _, _, err = cache.GetCertificate("online", nil, ""); err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	// Cert TTL is not set, IAM is requested each time and cached value is used when IAM is unavailable
+
+	iam.setCertURL("file:///cert2.pem")
+	iam.setUnavailable(true)
+
+	certURL, _, err := cache.GetCertificate("online", nil, "")
+	if err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	if certURL != "file:///cert1.pem" || iam.getCertRequests() != 2 {
+		t.Errorf("Cached cert URL expected: %s, requests: %d", certURL, iam.getCertRequests())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	iam := newTestIAMClient()
+	cfg := &config.Config{CircuitBreaker: config.CircuitBreaker{Default: config.CircuitBreakerPolicy{
+		FailureThreshold: 2, OpenTimeout: aostypes.Duration{Duration: time.Hour},
+	}}}
+	breakers := circuitbreaker.New(cfg)
+
+	cache, err := iamcache.New(cfg, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	cache.SetCircuitBreaker(breakers.Get(config.CircuitBreakerIAM))
+
+	iam.setCertURL("file:///cert1.pem")
+
+	if _, _, err = cache.GetCertificate("online", nil, ""); err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	// Consecutive failures open the circuit, IAM is not requested and cached value is used while it is open
+
+	iam.setUnavailable(true)
+
+	for range 3 {
+		certURL, _, err := cache.GetCertificate("online", nil, "")
+		if err != nil {
+			t.Fatalf("Can't get certificate: %v", err)
+		}
+
+		if certURL != "file:///cert1.pem" {
+			t.Errorf("Cached cert URL expected: %s", certURL)
+		}
+	}
+
+	if iam.getCertRequests() != 3 {
+		t.Errorf("Wrong cert requests count: %d", iam.getCertRequests())
+	}
+
+	if state := breakers.Get(config.CircuitBreakerIAM).GetState(); state.State != circuitbreaker.StateOpen ||
+		state.Rejected != 1 {
+		t.Errorf("Unexpected circuit breaker state: %v", state)
+	}
+}
+
+func TestPermissionsCache(t *testing.T) {
+	iam := newTestIAMClient()
+	instance := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		PermissionsTTL: aostypes.Duration{Duration: time.Hour},
+	}}, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setPermissions(map[string]string{"*": "rw"})
+
+	if _, permissions, err := cache.GetPermissions("secret1", "vis"); err != nil || permissions["*"] != "rw" {
+		t.Errorf("Wrong permissions: %v, err: %v", permissions, err)
+	}
+
+	iam.setPermissions(map[string]string{"*": "r"})
+	iam.setUnavailable(true)
+
+	if _, permissions, err := cache.GetPermissions("secret1", "vis"); err != nil || permissions["*"] != "rw" {
+		t.Errorf("Wrong permissions: %v, err: %v", permissions, err)
+	}
+
+	if iam.getPermissionRequests() != 1 {
+		t.Errorf("Wrong permission requests count: %d", iam.getPermissionRequests())
+	}
+
+	// Invalidate permissions
+
+	iam.setUnavailable(false)
+
+	cache.InvalidatePermissions(instance)
+
+	if _, permissions, err := cache.GetPermissions("secret1", "vis"); err != nil || permissions["*"] != "r" {
+		t.Errorf("Wrong permissions: %v, err: %v", permissions, err)
+	}
+
+	// Instance subject removed
+
+	iam.setUnavailable(true)
+
+	iam.subjectsChannel <- []string{"subject2"}
+
+	if err = waitPermissionsRemoved(cache, "secret1", "vis"); err != nil {
+		t.Errorf("Permissions are not removed: %v", err)
+	}
+}
+
+func TestPermissionsStaleness(t *testing.T) {
+	iam := newTestIAMClient()
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		PermissionsMaxStaleness: aostypes.Duration{Duration: 500 * time.Millisecond},
+	}}, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setPermissions(map[string]string{"*": "rw"})
+
+	if _, permissions, err := cache.GetPermissions("secret1", "vis"); err != nil || permissions["*"] != "rw" {
+		t.Errorf("Wrong permissions: %v, err: %v", permissions, err)
+	}
+
+	iam.setUnavailable(true)
+
+	if _, permissions, err := cache.GetPermissions("secret1", "vis"); err != nil || permissions["*"] != "rw" {
+		t.Errorf("Wrong permissions: %v, err: %v", permissions, err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	if _, _, err = cache.GetPermissions("secret1", "vis"); err == nil {
+		t.Error("Error expected for stale permissions")
+	}
+}
+
+func TestPermissionsRevoked(t *testing.T) {
+	iam := newTestIAMClient()
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		PermissionsMaxStaleness: aostypes.Duration{Duration: time.Hour},
+	}}, iam, &testCertLoader{})
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setPermissions(map[string]string{"*": "rw"})
+
+	if _, _, err = cache.GetPermissions("secret1", "vis"); err != nil {
+		t.Fatalf("Can't get permissions: %v", err)
+	}
+
+	iam.setRevoked(true)
+
+	if _, _, err = cache.GetPermissions("secret1", "vis"); err == nil {
+		t.Error("Error expected for revoked permissions")
+	}
+
+	// Revoked permissions are dropped from the cache
+
+	iam.setRevoked(false)
+	iam.setUnavailable(true)
+
+	if _, _, err = cache.GetPermissions("secret1", "vis"); err == nil {
+		t.Error("Error expected for dropped permissions")
+	}
+}
+
+func TestCertificateRenewal(t *testing.T) {
+	iam := newTestIAMClient()
+	certLoader := &testCertLoader{cert: &x509.Certificate{
+		SerialNumber: big.NewInt(0x1A),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}}
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		CertTTL:     aostypes.Duration{Duration: time.Hour},
+		CheckPeriod: aostypes.Duration{Duration: 100 * time.Millisecond},
+		RenewBefore: aostypes.Duration{Duration: 24 * time.Hour},
+	}}, iam, certLoader)
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setCertURL("file:///cert1.pem")
+
+	if _, _, err = cache.GetCertificate("online", nil, ""); err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	// Certificate is not renewed without unit secrets
+
+	select {
+	case <-iam.renewChannel:
+		t.Error("Unexpected certificate renewal")
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	cache.SetUnitSecrets(cloudprotocol.UnitSecrets{Nodes: map[string]string{"node0": "password"}})
+
+	select {
+	case renewCerts := <-iam.renewChannel:
+		if len(renewCerts) != 1 || renewCerts[0].Type != "online" || renewCerts[0].Serial != "1A" ||
+			renewCerts[0].NodeID != "node0" {
+			t.Errorf("Wrong renew certificates: %v", renewCerts)
+		}
+
+	case <-time.After(waitTimeout):
+		t.Fatal("Wait certificate renewal timeout")
+	}
+
+	// Certificate is renewed once
+
+	select {
+	case <-iam.renewChannel:
+		t.Error("Unexpected certificate renewal")
+
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestInvalidCertificate(t *testing.T) {
+	iam := newTestIAMClient()
+	certLoader := &testCertLoader{cert: &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}}
+
+	cache, err := iamcache.New(&config.Config{IAMCache: config.IAMCache{
+		CertTTL:     aostypes.Duration{Duration: time.Hour},
+		CheckPeriod: aostypes.Duration{Duration: 100 * time.Millisecond},
+	}}, iam, certLoader)
+	if err != nil {
+		t.Fatalf("Can't create IAM cache: %v", err)
+	}
+	defer cache.Close()
+
+	iam.setCertURL("file:///cert1.pem")
+
+	if _, _, err = cache.GetCertificate("online", []byte("issuer"), "01"); err != nil {
+		t.Fatalf("Can't get certificate: %v", err)
+	}
+
+	// Expired certificate is removed from the cache and can't be used when IAM is unavailable
+
+	certLoader.setNotAfter(time.Now().Add(-time.Minute))
+
+	time.Sleep(500 * time.Millisecond)
+
+	iam.setUnavailable(true)
+
+	if _, _, err = cache.GetCertificate("online", []byte("issuer"), "01"); err == nil {
+		t.Error("Expired certificate should be removed from cache")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestIAMClient() *testIAMClient {
+	return &testIAMClient{
+		certChannel:     make(chan *iamanager.CertInfo, 1),
+		renewChannel:    make(chan []cloudprotocol.RenewCertData, 10),
+		subjectsChannel: make(chan []string, 1),
+	}
+}
+
+func (iam *testIAMClient) GetNodeID() string {
+	return "node0"
+}
+
+func (iam *testIAMClient) GetCertificate(
+	certType string, issuer []byte, serial string,
+) (certURL, keyURL string, err error) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.certRequests++
+
+	if iam.unavailable {
+		return "", "", aoserrors.New("IAM is unavailable")
+	}
+
+	return iam.certURL, "", nil
+}
+
+func (iam *testIAMClient) SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error) {
+	return iam.certChannel, nil
+}
+
+func (iam *testIAMClient) UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error {
+	return nil
+}
+
+func (iam *testIAMClient) GetPermissions(
+	secret, funcServerID string,
+) (instance aostypes.InstanceIdent, permissions map[string]string, err error) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.permissionRequests++
+
+	if iam.unavailable {
+		return instance, nil, aoserrors.New("IAM is unavailable")
+	}
+
+	if iam.revoked {
+		return instance, nil, aoserrors.Wrap(status.Error(codes.NotFound, "secret not found"))
+	}
+
+	return aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}, iam.permissions, nil
+}
+
+func (iam *testIAMClient) RenewCertificatesNotification(
+	secrets cloudprotocol.UnitSecrets, certInfo []cloudprotocol.RenewCertData,
+) error {
+	iam.renewChannel <- certInfo
+
+	return nil
+}
+
+func (iam *testIAMClient) SubscribeUnitSubjectsChanged() <-chan []string {
+	return iam.subjectsChannel
+}
+
+func (iam *testIAMClient) setCertURL(certURL string) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.certURL = certURL
+}
+
+func (iam *testIAMClient) setPermissions(permissions map[string]string) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.permissions = permissions
+}
+
+func (iam *testIAMClient) setUnavailable(unavailable bool) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.unavailable = unavailable
+}
+
+func (iam *testIAMClient) setRevoked(revoked bool) {
+	iam.Lock()
+	defer iam.Unlock()
+
+	iam.revoked = revoked
+}
+
+func (iam *testIAMClient) getCertRequests() int {
+	iam.Lock()
+	defer iam.Unlock()
+
+	return iam.certRequests
+}
+
+func (iam *testIAMClient) getPermissionRequests() int {
+	iam.Lock()
+	defer iam.Unlock()
+
+	return iam.permissionRequests
+}
+
+func (loader *testCertLoader) LoadCertificateByURL(certURL string) ([]*x509.Certificate, error) {
+	loader.Lock()
+	defer loader.Unlock()
+
+	if loader.cert == nil {
+		return nil, aoserrors.New("certificate not found")
+	}
+
+	cert := *loader.cert
+
+	return []*x509.Certificate{&cert}, nil
+}
+
+func (loader *testCertLoader) setNotAfter(notAfter time.Time) {
+	loader.Lock()
+	defer loader.Unlock()
+
+	loader.cert.NotAfter = notAfter
+}
+
+func waitCertURL(cache *iamcache.Cache, expectedURL string) error {
+	start := time.Now()
+
+	for {
+		certURL, _, err := cache.GetCertificate("online", nil, "")
+		if err != nil {
+			return err
+		}
+
+		if certURL == expectedURL {
+			return nil
+		}
+
+		if time.Since(start) > waitTimeout {
+			return aoserrors.Errorf("wrong cert URL: %s", certURL)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func waitPermissionsRemoved(cache *iamcache.Cache, secret, funcServerID string) error {
+	start := time.Now()
+
+	for {
+		// IAM is unavailable, so error means permissions are not cached anymore
+		if _, _, err := cache.GetPermissions(secret, funcServerID); err != nil {
+			return nil
+		}
+
+		if time.Since(start) > waitTimeout {
+			return aoserrors.New("permissions are still cached")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
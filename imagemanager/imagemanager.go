@@ -25,6 +25,8 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/exp/slices"
@@ -32,8 +34,10 @@ import (
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/api/iamanager"
 	"github.com/aosedge/aos_common/image"
 	"github.com/aosedge/aos_common/spaceallocator"
+	"github.com/aosedge/aos_common/utils/cryptutils"
 	"github.com/aosedge/aos_common/utils/semverutils"
 	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
 	log "github.com/sirupsen/logrus"
@@ -41,7 +45,10 @@ import (
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/fcrypt"
 	"github.com/aosedge/aos_communicationmanager/fileserver"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
+	"github.com/aosedge/aos_communicationmanager/sbom"
 	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
+	"github.com/aosedge/aos_communicationmanager/utils/shred"
 	"github.com/aosedge/aos_communicationmanager/utils/uidgidpool"
 )
 
@@ -54,11 +61,16 @@ const (
 
 	fileScheme = "file"
 
-	blobsFolder = "blobs"
+	blobsFolder      = "blobs"
+	manifestFileName = "manifest.json"
 
 	removePeriod = 24 * time.Hour
 )
 
+// HealthEndpointLabelPrefix service image label prefix which specifies health endpoint of exposed port, e.g.
+// "aos.health.8080/tcp": "/healthz".
+const HealthEndpointLabelPrefix = "aos.health."
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -75,6 +87,15 @@ type Storage interface {
 	GetServiceVersions(serviceID string) ([]ServiceInfo, error)
 	RemoveService(serviceID string, version string) error
 	SetServiceState(serviceID, version string, state int) error
+	AddSBOM(document sbom.Document) error
+	GetSBOMs() ([]sbom.Document, error)
+}
+
+// CertificateProvider certificate and key provider interface.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+	SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error)
+	UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error
 }
 
 // Decrypter interface to decrypt and validate image.
@@ -98,6 +119,9 @@ type Imagemanager struct {
 	validateTTLStopChannel chan struct{}
 	removeServiceChannel   chan string
 	fileServer             *fileserver.FileServer
+	wipePasses             int
+	operationLock          sync.RWMutex
+	stopped                bool
 }
 
 // Service state.
@@ -110,13 +134,17 @@ const (
 // ServiceInfo service information.
 type ServiceInfo struct {
 	aostypes.ServiceInfo
-	RemoteURL    string
-	Path         string
-	Timestamp    time.Time
-	State        int
-	Config       aostypes.ServiceConfig
-	Layers       []string
-	ExposedPorts []string
+	RemoteURL       string
+	Path            string
+	Timestamp       time.Time
+	State           int
+	Config          aostypes.ServiceConfig
+	Layers          []string
+	ExposedPorts    []string
+	HealthEndpoints map[string]string
+	HealthChecks    []healthcheck.Check
+	LayerMediaTypes map[string]string
+	SharedNetwork   bool
 }
 
 // Layer state.
@@ -155,7 +183,8 @@ var (
  **********************************************************************************************************************/
 // New creates new image manager object.
 func New(
-	cfg *config.Config, storage Storage, decrypter Decrypter,
+	cfg *config.Config, storage Storage, decrypter Decrypter, certProvider CertificateProvider,
+	cryptocontext *cryptutils.CryptoContext,
 ) (imagemanager *Imagemanager, err error) {
 	imagemanager = &Imagemanager{
 		layersDir:              path.Join(cfg.ImageStoreDir, "layers"),
@@ -168,6 +197,7 @@ func New(
 		gidPool:                uidgidpool.NewGroupIDPool(),
 		validateTTLStopChannel: make(chan struct{}),
 		removeServiceChannel:   make(chan string, 1),
+		wipePasses:             cfg.SecureWipe.Passes,
 	}
 
 	if err := os.MkdirAll(imagemanager.layersDir, 0o755); err != nil {
@@ -184,7 +214,8 @@ func New(
 
 	if cfg.SMController.FileServerURL != "" {
 		if imagemanager.fileServer, err = fileserver.New(
-			cfg.SMController.FileServerURL, cfg.ImageStoreDir); err != nil {
+			cfg.SMController.FileServerURL, cfg.ImageStoreDir, cfg.FileServer,
+			fileserver.NewTLSParams(cfg, certProvider, cryptocontext)); err != nil {
 			return nil, aoserrors.Wrap(err)
 		}
 	}
@@ -245,6 +276,17 @@ func (imagemanager *Imagemanager) Close() {
 	close(imagemanager.removeServiceChannel)
 }
 
+// Stop stops image manager operations: waits for current install or remove operations to finish and rejects new
+// ones. Outdated images are not removed after stop.
+func (imagemanager *Imagemanager) Stop() {
+	log.Debug("Stop image manager")
+
+	imagemanager.operationLock.Lock()
+	defer imagemanager.operationLock.Unlock()
+
+	imagemanager.stopped = true
+}
+
 // GetServicesStatus gets all services status.
 func (imagemanager *Imagemanager) GetServicesStatus() ([]unitstatushandler.ServiceStatus, error) {
 	log.Debug("Get services status")
@@ -306,6 +348,11 @@ func (imagemanager *Imagemanager) GetRemoveServiceChannel() (channel <-chan stri
 func (imagemanager *Imagemanager) InstallService(serviceInfo cloudprotocol.ServiceInfo,
 	chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
 ) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"id": serviceInfo.ServiceID, "version": serviceInfo.Version}).Debug("Install service")
 
 	currentServices, err := imagemanager.storage.GetServiceVersions(serviceInfo.ServiceID)
@@ -382,7 +429,7 @@ func (imagemanager *Imagemanager) InstallService(serviceInfo cloudprotocol.Servi
 func (imagemanager *Imagemanager) addService(
 	decryptedFile string, serviceInfo cloudprotocol.ServiceInfo, gid int,
 ) error {
-	layers, exposedPorts, serviceConfig, err := imagemanager.getServiceDataFromManifest(decryptedFile)
+	service, sboms, err := imagemanager.getServiceDataFromManifest(decryptedFile)
 	if err != nil {
 		return err
 	}
@@ -401,32 +448,43 @@ func (imagemanager *Imagemanager) addService(
 		return err
 	}
 
-	if err = imagemanager.storage.AddService(ServiceInfo{
-		ServiceInfo: aostypes.ServiceInfo{
-			Version:    serviceInfo.Version,
-			ServiceID:  serviceInfo.ServiceID,
-			ProviderID: serviceInfo.ProviderID,
-			URL:        createLocalURL(decryptedFile),
-			Size:       fileInfo.Size,
-			GID:        uint32(gid),
-			Sha256:     fileInfo.Sha256,
-		},
-		State:        ServiceActive,
-		RemoteURL:    remoteURL,
-		Path:         decryptedFile,
-		Timestamp:    time.Now().UTC(),
-		Config:       serviceConfig,
-		Layers:       layers,
-		ExposedPorts: exposedPorts,
-	}); err != nil {
+	service.ServiceInfo = aostypes.ServiceInfo{
+		Version:    serviceInfo.Version,
+		ServiceID:  serviceInfo.ServiceID,
+		ProviderID: serviceInfo.ProviderID,
+		URL:        createLocalURL(decryptedFile),
+		Size:       fileInfo.Size,
+		GID:        uint32(gid),
+		Sha256:     fileInfo.Sha256,
+	}
+	service.State = ServiceActive
+	service.RemoteURL = remoteURL
+	service.Path = decryptedFile
+	service.Timestamp = time.Now().UTC()
+
+	if err = imagemanager.storage.AddService(service); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
+	for _, document := range sboms {
+		document.ServiceID = service.ServiceID
+		document.Version = service.Version
+
+		if err = imagemanager.storage.AddSBOM(document); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
 // RestoreService restores service from a cache.
 func (imagemanager *Imagemanager) RestoreService(serviceID string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Restore service")
 
 	existingServices, err := imagemanager.storage.GetServiceVersions(serviceID)
@@ -469,6 +527,11 @@ func (imagemanager *Imagemanager) RestoreService(serviceID string) error {
 
 // RemoveService makes service cached.
 func (imagemanager *Imagemanager) RemoveService(serviceID string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Remove service")
 
 	services, err := imagemanager.storage.GetServiceVersions(serviceID)
@@ -498,10 +561,43 @@ func (imagemanager *Imagemanager) RemoveService(serviceID string) error {
 	return nil
 }
 
+// WipeService securely removes all versions of the service. Service images are shredded and removed immediately
+// instead of being cached.
+func (imagemanager *Imagemanager) WipeService(serviceID string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
+	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Wipe service")
+
+	services, err := imagemanager.storage.GetServiceVersions(serviceID)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, service := range services {
+		if err := shred.RemoveAll(service.Path, imagemanager.wipePasses); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err := imagemanager.removeService(service); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // InstallLayer installs layer to the image store dir.
 func (imagemanager *Imagemanager) InstallLayer(layerInfo cloudprotocol.LayerInfo,
 	chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
 ) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{
 		"id": layerInfo.LayerID, "digest": layerInfo.Digest, "version": layerInfo.Version,
 	}).Debug("Install layer")
@@ -593,6 +689,11 @@ func (imagemanager *Imagemanager) InstallLayer(layerInfo cloudprotocol.LayerInfo
 
 // RemoveLayer makes layer cached.
 func (imagemanager *Imagemanager) RemoveLayer(digest string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"digest": digest}).Debug("Remove layer")
 
 	layer, err := imagemanager.storage.GetLayerInfo(digest)
@@ -615,6 +716,11 @@ func (imagemanager *Imagemanager) RemoveLayer(digest string) error {
 
 // RestoreLayer restores layer from a cache.
 func (imagemanager *Imagemanager) RestoreLayer(digest string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"digest": digest}).Debug("Restore layer")
 
 	layer, err := imagemanager.storage.GetLayerInfo(digest)
@@ -635,6 +741,29 @@ func (imagemanager *Imagemanager) RestoreLayer(digest string) error {
 	return nil
 }
 
+// SignRemoteURL signs service or layer remote URL for the node.
+func (imagemanager *Imagemanager) SignRemoteURL(remoteURL, nodeID string) (string, error) {
+	if imagemanager.fileServer == nil || remoteURL == "" {
+		return remoteURL, nil
+	}
+
+	signedURL, err := imagemanager.fileServer.SignURL(remoteURL, nodeID)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return signedURL, nil
+}
+
+// GetTransferStats returns file server transfer statistics.
+func (imagemanager *Imagemanager) GetTransferStats() map[string]fileserver.TransferStats {
+	if imagemanager.fileServer == nil {
+		return nil
+	}
+
+	return imagemanager.fileServer.GetTransferStats()
+}
+
 // GetServiceInfo returns active service information by id.
 func (imagemanager *Imagemanager) GetServiceInfo(serviceID string) (ServiceInfo, error) {
 	services, err := imagemanager.storage.GetServiceVersions(serviceID)
@@ -667,6 +796,11 @@ func (imagemanager *Imagemanager) GetLayerInfo(digest string) (LayerInfo, error)
 
 // RevertService reverts already stored service.
 func (imagemanager *Imagemanager) RevertService(serviceID string) error {
+	if err := imagemanager.startOperation(); err != nil {
+		return err
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	log.WithFields(log.Fields{"serviceID": serviceID}).Debug("Revert service")
 
 	services, err := imagemanager.storage.GetServiceVersions(serviceID)
@@ -872,17 +1006,19 @@ func (imagemanager *Imagemanager) setLayerState(layer LayerInfo, state int) erro
 	return nil
 }
 
+// getServiceDataFromManifest returns service info with data of service image: layers, config, exposed ports, health
+// endpoints, health checks and embedded SBOM documents.
 func (imagemanager *Imagemanager) getServiceDataFromManifest(
 	sourceFile string,
-) (layers []string, exposedPorts []string, serviceConfig aostypes.ServiceConfig, err error) {
+) (service ServiceInfo, sboms []sbom.Document, err error) {
 	size, err := image.GetUncompressedTarContentSize(sourceFile)
 	if err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
 	space, err := imagemanager.tmpAllocator.AllocateSpace(uint64(size))
 	if err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
 	defer func() {
@@ -893,21 +1029,22 @@ func (imagemanager *Imagemanager) getServiceDataFromManifest(
 
 	imagePath, err := os.MkdirTemp(imagemanager.tmpDir, "")
 	if err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
 	defer os.RemoveAll(imagePath)
 
 	if err = image.UnpackTarImage(sourceFile, imagePath); err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
 	manifest, err := image.GetImageManifest(imagePath)
 	if err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
-	layers = image.GetLayersFromManifest(manifest)
+	service.Layers = image.GetLayersFromManifest(manifest)
+	service.LayerMediaTypes = getLayerMediaTypes(manifest)
 
 	imageConfigPath := path.Join(imagePath, blobsFolder, string(manifest.Config.Digest.Algorithm()),
 		manifest.Config.Digest.Hex())
@@ -915,30 +1052,85 @@ func (imagemanager *Imagemanager) getServiceDataFromManifest(
 	var imageConfig imagespec.Image
 
 	if err = getJSONFromFile(imageConfigPath, &imageConfig); err != nil {
-		return nil, nil, serviceConfig, aoserrors.Wrap(err)
+		return service, nil, aoserrors.Wrap(err)
 	}
 
 	if manifest.AosService != nil {
 		if err = image.ValidateDigest(imagePath, manifest.AosService.Digest); err != nil {
-			return nil, nil, serviceConfig, aoserrors.Wrap(err)
+			return service, nil, aoserrors.Wrap(err)
 		}
 
 		byteValue, err := os.ReadFile(path.Join(
 			imagePath, blobsFolder, string(manifest.AosService.Digest.Algorithm()), manifest.AosService.Digest.Hex()))
 		if err != nil {
-			return nil, nil, serviceConfig, aoserrors.Wrap(err)
+			return service, nil, aoserrors.Wrap(err)
+		}
+
+		if err = json.Unmarshal(byteValue, &service.Config); err != nil {
+			return service, nil, aoserrors.Errorf("invalid Aos service config: %v", err)
 		}
 
-		if err = json.Unmarshal(byteValue, &serviceConfig); err != nil {
-			return nil, nil, serviceConfig, aoserrors.Errorf("invalid Aos service config: %v", err)
+		if service.HealthChecks, err = getHealthChecks(byteValue); err != nil {
+			return service, nil, err
 		}
+
+		if service.SharedNetwork, err = getSharedNetwork(byteValue); err != nil {
+			return service, nil, err
+		}
+	}
+
+	if sboms, err = getSBOMsFromImage(imagePath); err != nil {
+		return service, nil, err
 	}
 
 	for exposedPort := range imageConfig.Config.ExposedPorts {
-		exposedPorts = append(exposedPorts, exposedPort)
+		service.ExposedPorts = append(service.ExposedPorts, exposedPort)
+	}
+
+	for label, value := range imageConfig.Config.Labels {
+		if port, ok := strings.CutPrefix(label, HealthEndpointLabelPrefix); ok && port != "" {
+			if service.HealthEndpoints == nil {
+				service.HealthEndpoints = make(map[string]string)
+			}
+
+			service.HealthEndpoints[port] = value
+		}
+	}
+
+	return service, sboms, nil
+}
+
+// getHealthChecks returns health checks defined by healthChecks field of Aos service config.
+func getHealthChecks(serviceConfig []byte) ([]healthcheck.Check, error) {
+	var healthConfig struct {
+		HealthChecks []healthcheck.Check `json:"healthChecks"`
+	}
+
+	if err := json.Unmarshal(serviceConfig, &healthConfig); err != nil {
+		return nil, aoserrors.Errorf("invalid Aos service config: %v", err)
+	}
+
+	for _, check := range healthConfig.HealthChecks {
+		if err := check.Validate(); err != nil {
+			return nil, aoserrors.Errorf("invalid Aos service config: %v", err)
+		}
 	}
 
-	return layers, exposedPorts, serviceConfig, nil
+	return healthConfig.HealthChecks, nil
+}
+
+// getSharedNetwork returns sharedNetwork field of Aos service config: instances of the service share one IP and
+// network namespace.
+func getSharedNetwork(serviceConfig []byte) (bool, error) {
+	var networkConfig struct {
+		SharedNetwork bool `json:"sharedNetwork"`
+	}
+
+	if err := json.Unmarshal(serviceConfig, &networkConfig); err != nil {
+		return false, aoserrors.Errorf("invalid Aos service config: %v", err)
+	}
+
+	return networkConfig.SharedNetwork, nil
 }
 
 func (imagemanager *Imagemanager) clearServiceResource(service ServiceInfo) error {
@@ -1165,7 +1357,24 @@ func (imagemanager *Imagemanager) removeOutdatedLayers() error {
 	return nil
 }
 
-func (imagemanager *Imagemanager) validateTTLs() {
+func (imagemanager *Imagemanager) startOperation() error {
+	imagemanager.operationLock.RLock()
+
+	if imagemanager.stopped {
+		imagemanager.operationLock.RUnlock()
+
+		return aoserrors.New("image manager is stopped")
+	}
+
+	return nil
+}
+
+func (imagemanager *Imagemanager) removeOutdatedItems() {
+	if err := imagemanager.startOperation(); err != nil {
+		return
+	}
+	defer imagemanager.operationLock.RUnlock()
+
 	if err := imagemanager.removeOutdatedServices(); err != nil {
 		log.Errorf("Can't remove outdated services: %v", err)
 	}
@@ -1173,6 +1382,10 @@ func (imagemanager *Imagemanager) validateTTLs() {
 	if err := imagemanager.removeOutdatedLayers(); err != nil {
 		log.Errorf("Can't remove outdated layers: %v", err)
 	}
+}
+
+func (imagemanager *Imagemanager) validateTTLs() {
+	imagemanager.removeOutdatedItems()
 
 	removeTicker := time.NewTicker(removePeriod)
 	defer removeTicker.Stop()
@@ -1180,13 +1393,7 @@ func (imagemanager *Imagemanager) validateTTLs() {
 	for {
 		select {
 		case <-removeTicker.C:
-			if err := imagemanager.removeOutdatedLayers(); err != nil {
-				log.Errorf("Can't remove outdated layers: %v", err)
-			}
-
-			if err := imagemanager.removeOutdatedServices(); err != nil {
-				log.Errorf("Can't remove outdated layers: %v", err)
-			}
+			imagemanager.removeOutdatedItems()
 
 		case <-imagemanager.validateTTLStopChannel:
 			return
@@ -1230,6 +1437,20 @@ func releaseAllocatedSpace(filePath string, space spaceallocator.Space) {
 	}
 }
 
+func getLayerMediaTypes(manifest *aostypes.ServiceManifest) map[string]string {
+	if len(manifest.Layers) <= 1 {
+		return nil
+	}
+
+	mediaTypes := make(map[string]string)
+
+	for _, layer := range manifest.Layers[1:] {
+		mediaTypes[string(layer.Digest)] = layer.MediaType
+	}
+
+	return mediaTypes
+}
+
 func getJSONFromFile(fileName string, data interface{}) error {
 	byteValue, err := os.ReadFile(fileName)
 	if err != nil {
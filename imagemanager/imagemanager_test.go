@@ -53,7 +53,9 @@ import (
 
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/fcrypt"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
+	"github.com/aosedge/aos_communicationmanager/sbom"
 )
 
 /***********************************************************************************************************************
@@ -75,6 +77,7 @@ type testCryptoContext struct{}
 type testStorageProvider struct {
 	layers   map[string]imagemanager.LayerInfo
 	services map[string][]imagemanager.ServiceInfo
+	sboms    []sbom.Document
 }
 
 type testAllocator struct {
@@ -154,7 +157,7 @@ func TestInstallService(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -298,6 +301,12 @@ func TestInstallService(t *testing.T) {
 				t.Error("Unexpected layer digest")
 			}
 
+			for _, layerDigest := range layerDigests {
+				if service.LayerMediaTypes[layerDigest] != "application/vnd.aos.image.layer.v1.tar" {
+					t.Errorf("Unexpected layer media type: %s", service.LayerMediaTypes[layerDigest])
+				}
+			}
+
 			if !reflect.DeepEqual(service.Config, tCase.serviceConfig) {
 				t.Error("Unexpected service config")
 			}
@@ -320,6 +329,194 @@ func TestInstallService(t *testing.T) {
 	}
 }
 
+func TestServiceHealthChecks(t *testing.T) {
+	storage := &testStorageProvider{
+		services: make(map[string][]imagemanager.ServiceInfo),
+	}
+
+	serviceAllocator = &testAllocator{
+		totalSize: 3 * megabyte,
+	}
+
+	imagemanagerInstance, err := imagemanager.New(&config.Config{
+		ImageStoreDir: tmpDir,
+		WorkingDir:    tmpDir,
+	}, storage, &testCryptoContext{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create image manager instance: %v", err)
+	}
+	defer imagemanagerInstance.Close()
+
+	defer func() {
+		if err = clearServicesDir(); err != nil {
+			t.Errorf("Can't clear services dir: %v", err)
+		}
+	}()
+
+	cases := []struct {
+		serviceID     string
+		configJSON    string
+		healthChecks  []healthcheck.Check
+		sharedNetwork bool
+		installErr    bool
+	}{
+		{
+			serviceID: "service1",
+			configJSON: `{"hostname": "service1", "sharedNetwork": true, "healthChecks": [
+				{"type": "http", "port": 8080, "path": "/healthz", "interval": "5s", "failureThreshold": 2},
+				{"type": "exec", "command": ["/bin/check"]}
+			]}`,
+			healthChecks: []healthcheck.Check{
+				{
+					Type: healthcheck.CheckHTTP, Port: 8080, Path: "/healthz",
+					Interval: aostypes.Duration{Duration: 5 * time.Second}, FailureThreshold: 2,
+				},
+				{Type: healthcheck.CheckExec, Command: []string{"/bin/check"}},
+			},
+			sharedNetwork: true,
+		},
+		{
+			serviceID:  "service2",
+			configJSON: `{"hostname": "service2", "healthChecks": [{"type": "tcp"}]}`,
+			installErr: true,
+		},
+	}
+
+	for _, tCase := range cases {
+		servicePath, _, err := prepareService(megabyte, []byte(tCase.configJSON))
+		if err != nil {
+			t.Fatalf("Can't prepare service file: %v", err)
+		}
+
+		serviceInfo, err := prepareServiceInfo(servicePath, tCase.serviceID, "1.0.0")
+		if err != nil {
+			t.Fatalf("Can't prepare service info: %v", err)
+		}
+
+		if err := imagemanagerInstance.InstallService(serviceInfo, nil, nil); (err != nil) != tCase.installErr {
+			t.Errorf("Unexpected install result: %v", err)
+		}
+
+		if tCase.installErr {
+			continue
+		}
+
+		service, err := imagemanagerInstance.GetServiceInfo(tCase.serviceID)
+		if err != nil {
+			t.Fatalf("Can't get service: %v", err)
+		}
+
+		if !reflect.DeepEqual(service.HealthChecks, tCase.healthChecks) {
+			t.Errorf("Unexpected health checks: %v", service.HealthChecks)
+		}
+
+		if service.SharedNetwork != tCase.sharedNetwork {
+			t.Errorf("Unexpected shared network: %v", service.SharedNetwork)
+		}
+	}
+}
+
+func TestServiceSBOMs(t *testing.T) {
+	storage := &testStorageProvider{
+		services: make(map[string][]imagemanager.ServiceInfo),
+	}
+
+	serviceAllocator = &testAllocator{
+		totalSize: 5 * megabyte,
+	}
+
+	imagemanagerInstance, err := imagemanager.New(&config.Config{
+		ImageStoreDir: tmpDir,
+		WorkingDir:    tmpDir,
+	}, storage, &testCryptoContext{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create image manager instance: %v", err)
+	}
+	defer imagemanagerInstance.Close()
+
+	defer func() {
+		if err = clearServicesDir(); err != nil {
+			t.Errorf("Can't clear services dir: %v", err)
+		}
+	}()
+
+	spdxDocument := []byte(`{"spdxVersion":"SPDX-2.3","name":"service1"}`)
+
+	cases := []struct {
+		serviceID  string
+		version    string
+		sboms      map[string][]byte
+		installErr bool
+		expected   []sbom.Document
+	}{
+		{
+			serviceID: "service1",
+			version:   "1.0.0",
+			sboms:     map[string][]byte{"application/spdx+json": spdxDocument},
+			expected: []sbom.Document{{
+				ServiceID: "service1", Version: "1.0.0", Format: sbom.FormatSPDX,
+				MediaType: "application/spdx+json", Document: spdxDocument,
+			}},
+		},
+		{
+			serviceID: "service1",
+			version:   "2.0.0",
+			sboms: map[string][]byte{
+				"application/vnd.cyclonedx+json; version=1.5": []byte(`{"bomFormat":"CycloneDX"}`),
+			},
+			expected: []sbom.Document{{
+				ServiceID: "service1", Version: "2.0.0", Format: sbom.FormatCycloneDX,
+				MediaType: "application/vnd.cyclonedx+json; version=1.5", Document: []byte(`{"bomFormat":"CycloneDX"}`),
+			}},
+		},
+		{
+			serviceID:  "service2",
+			version:    "1.0.0",
+			sboms:      map[string][]byte{"text/plain": []byte("sbom")},
+			installErr: true,
+		},
+		{
+			serviceID:  "service3",
+			version:    "1.0.0",
+			sboms:      map[string][]byte{"application/spdx+json": []byte("not json")},
+			installErr: true,
+		},
+	}
+
+	for _, tCase := range cases {
+		servicePath, _, err := prepareServiceWithSBOMs(megabyte, []byte(`{"hostname": "service"}`), tCase.sboms)
+		if err != nil {
+			t.Fatalf("Can't prepare service file: %v", err)
+		}
+
+		serviceInfo, err := prepareServiceInfo(servicePath, tCase.serviceID, tCase.version)
+		if err != nil {
+			t.Fatalf("Can't prepare service info: %v", err)
+		}
+
+		if err := imagemanagerInstance.InstallService(serviceInfo, nil, nil); (err != nil) != tCase.installErr {
+			t.Errorf("Unexpected install result: %v", err)
+		}
+
+		if tCase.installErr {
+			continue
+		}
+
+		sboms, err := imagemanagerInstance.GetSBOMs()
+		if err != nil {
+			t.Fatalf("Can't get SBOMs: %v", err)
+		}
+
+		for i := range sboms {
+			sboms[i].Digest = ""
+		}
+
+		if !reflect.DeepEqual(sboms, tCase.expected) {
+			t.Errorf("Unexpected SBOMs: %v", sboms)
+		}
+	}
+}
+
 func TestRevertService(t *testing.T) {
 	storage := &testStorageProvider{
 		services: make(map[string][]imagemanager.ServiceInfo),
@@ -332,7 +529,7 @@ func TestRevertService(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -453,7 +650,7 @@ func TestRemoveService(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -571,6 +768,114 @@ func TestRemoveService(t *testing.T) {
 	}
 }
 
+func TestWipeService(t *testing.T) {
+	storage := &testStorageProvider{
+		services: make(map[string][]imagemanager.ServiceInfo),
+	}
+
+	serviceAllocator = &testAllocator{
+		totalSize: 2 * megabyte,
+	}
+
+	imagemanagerInstance, err := imagemanager.New(&config.Config{
+		ImageStoreDir: tmpDir,
+		WorkingDir:    tmpDir,
+		SecureWipe:    config.SecureWipe{Enabled: true, Passes: 1},
+	}, storage, &testCryptoContext{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create image manager instance: %v", err)
+	}
+	defer imagemanagerInstance.Close()
+
+	configJSON, err := json.Marshal(aostypes.ServiceConfig{Hostname: allocateString("service1")})
+	if err != nil {
+		t.Fatalf("Can't generate config json: %v", err)
+	}
+
+	servicePath, _, err := prepareService(1*megabyte, configJSON)
+	if err != nil {
+		t.Fatalf("Can't prepare service file: %v", err)
+	}
+
+	serviceInfo, err := prepareServiceInfo(servicePath, "service1", "1.0.0")
+	if err != nil {
+		t.Fatalf("Can't prepare service info: %v", err)
+	}
+
+	if err = imagemanagerInstance.InstallService(serviceInfo, nil, nil); err != nil {
+		t.Fatalf("Can't install service: %v", err)
+	}
+
+	installedService, err := imagemanagerInstance.GetServiceInfo("service1")
+	if err != nil {
+		t.Fatalf("Can't get service info: %v", err)
+	}
+
+	if err = imagemanagerInstance.RemoveService("service1"); err != nil {
+		t.Fatalf("Can't remove service: %v", err)
+	}
+
+	if err = imagemanagerInstance.WipeService("service1"); err != nil {
+		t.Fatalf("Can't wipe service: %v", err)
+	}
+
+	if _, ok := storage.services["service1"]; ok {
+		t.Error("Service should be removed from storage")
+	}
+
+	if _, err = os.Stat(installedService.Path); !os.IsNotExist(err) {
+		t.Errorf("Service image should be removed: %v", err)
+	}
+
+	if err = imagemanagerInstance.WipeService("service1"); err != nil {
+		t.Errorf("Can't wipe not existing service: %v", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	storage := &testStorageProvider{
+		services: make(map[string][]imagemanager.ServiceInfo),
+	}
+
+	serviceAllocator = &testAllocator{
+		totalSize: 2 * megabyte,
+	}
+
+	imagemanagerInstance, err := imagemanager.New(&config.Config{
+		ImageStoreDir: tmpDir,
+		WorkingDir:    tmpDir,
+	}, storage, &testCryptoContext{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create image manager instance: %v", err)
+	}
+	defer imagemanagerInstance.Close()
+
+	configJSON, err := json.Marshal(aostypes.ServiceConfig{Hostname: allocateString("service1")})
+	if err != nil {
+		t.Fatalf("Can't generate config json: %v", err)
+	}
+
+	servicePath, _, err := prepareService(1*megabyte, configJSON)
+	if err != nil {
+		t.Fatalf("Can't prepare service file: %v", err)
+	}
+
+	serviceInfo, err := prepareServiceInfo(servicePath, "service1", "1.0.0")
+	if err != nil {
+		t.Fatalf("Can't prepare service info: %v", err)
+	}
+
+	imagemanagerInstance.Stop()
+
+	if err = imagemanagerInstance.InstallService(serviceInfo, nil, nil); err == nil {
+		t.Error("Install should be rejected after stop")
+	}
+
+	if _, ok := storage.services["service1"]; ok {
+		t.Error("Service should not be installed")
+	}
+}
+
 func TestRestoreService(t *testing.T) {
 	storage := &testStorageProvider{
 		services: make(map[string][]imagemanager.ServiceInfo),
@@ -583,7 +888,7 @@ func TestRestoreService(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -706,7 +1011,7 @@ func TestRestoreLayer(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -814,7 +1119,7 @@ func TestRemoveLayer(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -916,7 +1221,7 @@ func TestInstallLayer(t *testing.T) {
 	imagemanagerInstance, err := imagemanager.New(&config.Config{
 		ImageStoreDir: tmpDir,
 		WorkingDir:    tmpDir,
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -1063,7 +1368,7 @@ func TestFileServer(t *testing.T) {
 		SMController: config.SMController{
 			FileServerURL: "localhost:8092",
 		},
-	}, storage, &testCryptoContext{})
+	}, storage, &testCryptoContext{}, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create image manager instance: %v", err)
 	}
@@ -1339,6 +1644,16 @@ func (storage *testStorageProvider) RemoveService(serviceID string, version stri
 	return nil
 }
 
+func (storage *testStorageProvider) AddSBOM(document sbom.Document) error {
+	storage.sboms = append(storage.sboms, document)
+
+	return nil
+}
+
+func (storage *testStorageProvider) GetSBOMs() ([]sbom.Document, error) {
+	return storage.sboms, nil
+}
+
 func (storage *testStorageProvider) RemoveLayer(digest string) error {
 	if _, ok := storage.layers[digest]; !ok {
 		return nil
@@ -1436,6 +1751,11 @@ func prepareServiceInfo(
 }
 
 func prepareService(servicelayerSize uint64, srvConfig []byte,
+) (outputURL string, layersDigest []string, err error) {
+	return prepareServiceWithSBOMs(servicelayerSize, srvConfig, nil)
+}
+
+func prepareServiceWithSBOMs(servicelayerSize uint64, srvConfig []byte, sboms map[string][]byte,
 ) (outputURL string, layersDigest []string, err error) {
 	imageDir, err := os.MkdirTemp("", "aos_")
 	if err != nil {
@@ -1481,9 +1801,20 @@ func prepareService(servicelayerSize uint64, srvConfig []byte,
 
 	layersDigest = append(layersDigest, string(imgAosLayerDigest))
 
+	var sbomDescriptors []imagespec.Descriptor
+
+	for mediaType, document := range sboms {
+		sbomDigest, err := generateAndSaveDigest(filepath.Join(imageDir, blobsFolder), document)
+		if err != nil {
+			return "", nil, aoserrors.Wrap(err)
+		}
+
+		sbomDescriptors = append(sbomDescriptors, imagespec.Descriptor{MediaType: mediaType, Digest: sbomDigest})
+	}
+
 	if err := genarateImageManfest(
 		imageDir, &imgSpecDigestDigest, &aosSrvConfigDigest, &fsDigest,
-		serviceSize, []digest.Digest{imgAosLayerDigest}); err != nil {
+		serviceSize, []digest.Digest{imgAosLayerDigest}, sbomDescriptors); err != nil {
 		return "", layersDigest, aoserrors.Wrap(err)
 	}
 
@@ -1511,9 +1842,14 @@ func packImage(source, name string) (err error) {
 }
 
 func genarateImageManfest(folderPath string, imgConfig, aosSrvConfig, rootfsLayer *digest.Digest,
-	rootfsLayerSize int64, srvLayers []digest.Digest,
+	rootfsLayerSize int64, srvLayers []digest.Digest, sboms []imagespec.Descriptor,
 ) (err error) {
-	var manifest aostypes.ServiceManifest
+	var manifest struct {
+		aostypes.ServiceManifest
+		AosSBOM []imagespec.Descriptor `json:"aosSbom,omitempty"`
+	}
+
+	manifest.AosSBOM = sboms
 
 	manifest.SchemaVersion = 2
 
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagemanager
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/image"
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/sbom"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// sbomManifest service manifest part which references SBOM documents blobs.
+type sbomManifest struct {
+	AosSBOM []imagespec.Descriptor `json:"aosSbom,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetSBOMs returns SBOM documents of active services which form the unit aggregate SBOM.
+func (imagemanager *Imagemanager) GetSBOMs() ([]sbom.Document, error) {
+	log.Debug("Get SBOMs")
+
+	services, err := imagemanager.storage.GetServicesInfo()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	activeServices := make(map[string]string)
+
+	for _, service := range services {
+		if service.State == ServiceActive {
+			activeServices[service.ServiceID] = service.Version
+		}
+	}
+
+	documents, err := imagemanager.storage.GetSBOMs()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	sboms := make([]sbom.Document, 0, len(documents))
+
+	for _, document := range documents {
+		if version, ok := activeServices[document.ServiceID]; ok && version == document.Version {
+			sboms = append(sboms, document)
+		}
+	}
+
+	return sboms, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getSBOMsFromImage returns SBOM documents referenced by aosSbom field of unpacked service image manifest.
+func getSBOMsFromImage(imagePath string) (sboms []sbom.Document, err error) {
+	var manifest sbomManifest
+
+	if err = getJSONFromFile(path.Join(imagePath, manifestFileName), &manifest); err != nil {
+		return nil, err
+	}
+
+	for _, descriptor := range manifest.AosSBOM {
+		format, err := sbom.GetFormat(descriptor.MediaType)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = image.ValidateDigest(imagePath, descriptor.Digest); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		document, err := os.ReadFile(path.Join(
+			imagePath, blobsFolder, string(descriptor.Digest.Algorithm()), descriptor.Digest.Hex()))
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if !json.Valid(document) {
+			return nil, aoserrors.Errorf("invalid SBOM document: %s", descriptor.Digest)
+		}
+
+		sboms = append(sboms, sbom.Document{
+			Format:    format,
+			MediaType: descriptor.MediaType,
+			Digest:    string(descriptor.Digest),
+			Document:  document,
+		})
+	}
+
+	return sboms, nil
+}
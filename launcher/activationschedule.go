@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// InstanceStateScheduled instance is not run as current time is outside activation schedule of the service.
+const InstanceStateScheduled = "scheduled"
+
+const daysInWeek = 7
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// runActivationSchedule reschedules desired instances at each activation window start and end.
+func (launcher *Launcher) runActivationSchedule(ctx context.Context) {
+	for {
+		next, ok := nextActivationBoundary(time.Now(), launcher.config.Balancing.ActivationSchedules)
+		if !ok {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+			launcher.applyActivationSchedule()
+
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+func (launcher *Launcher) applyActivationSchedule() {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	if launcher.desiredInstances == nil {
+		return
+	}
+
+	log.Debug("Apply activation schedule")
+
+	if err := launcher.rescheduleInstances(); err != nil {
+		log.Errorf("Can't run instances: %v", err)
+	}
+}
+
+// getActivatedInstances returns instances of services which are in activation window at the time. Instances of other
+// services get scheduled status.
+func (launcher *Launcher) getActivatedInstances(
+	instances []cloudprotocol.InstanceInfo, now time.Time,
+) []cloudprotocol.InstanceInfo {
+	if len(launcher.config.Balancing.ActivationSchedules) == 0 {
+		return instances
+	}
+
+	activated := make([]cloudprotocol.InstanceInfo, 0, len(instances))
+
+	for _, instance := range instances {
+		timetable, ok := launcher.config.Balancing.ActivationSchedules[instance.ServiceID]
+		if !ok || inTimetable(now, timetable) {
+			activated = append(activated, instance)
+
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"serviceID": instance.ServiceID, "subjectID": instance.SubjectID,
+		}).Debug("Service is outside activation schedule")
+
+		var serviceVersion string
+
+		if service, err := launcher.imageProvider.GetServiceInfo(instance.ServiceID); err == nil {
+			serviceVersion = service.Version
+		}
+
+		launcher.instanceManager.setAllInstanceScheduled(instance, serviceVersion)
+	}
+
+	return activated
+}
+
+func inTimetable(now time.Time, timetable []cloudprotocol.TimetableEntry) bool {
+	for _, entry := range timetable {
+		if weekday(entry.DayOfWeek) != now.Weekday() {
+			continue
+		}
+
+		for _, slot := range entry.TimeSlots {
+			start, end := slotTimes(now, slot)
+
+			if !now.Before(start) && now.Before(end) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nextActivationBoundary returns the nearest start or end of activation windows after the time.
+func nextActivationBoundary(
+	now time.Time, schedules map[string][]cloudprotocol.TimetableEntry,
+) (next time.Time, found bool) {
+	for day := 0; day <= daysInWeek; day++ {
+		date := now.AddDate(0, 0, day)
+
+		for _, timetable := range schedules {
+			for _, entry := range timetable {
+				if weekday(entry.DayOfWeek) != date.Weekday() {
+					continue
+				}
+
+				for _, slot := range entry.TimeSlots {
+					start, end := slotTimes(date, slot)
+
+					for _, boundary := range []time.Time{start, end} {
+						if boundary.After(now) && (!found || boundary.Before(next)) {
+							next, found = boundary, true
+						}
+					}
+				}
+			}
+		}
+
+		if found {
+			return next, true
+		}
+	}
+
+	return next, false
+}
+
+func slotTimes(date time.Time, slot cloudprotocol.TimeSlot) (start, end time.Time) {
+	start = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.Start.Hour(), slot.Start.Minute(), slot.Start.Second(), 0, time.Local) //nolint:gosmopolitan
+	end = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.End.Hour(), slot.End.Minute(), slot.End.Second(), 0, time.Local) //nolint:gosmopolitan
+
+	return start, end
+}
+
+// weekday converts timetable day of week (1 - Monday, 7 - Sunday) to time weekday.
+func weekday(dayOfWeek uint) time.Weekday {
+	return time.Weekday(dayOfWeek % daysInWeek)
+}
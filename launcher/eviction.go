@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// InstanceStateEvicted instance is stopped to relieve resource pressure of the node.
+const InstanceStateEvicted = "evicted"
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// reportEvictions reports instances of nodes under resource pressure which can't be placed on rebalancing as evicted.
+// As instances are placed by priority, the lowest priority instances are evicted first.
+func (launcher *Launcher) reportEvictions() {
+	for _, status := range launcher.instanceManager.getErrorInstanceStatuses() {
+		if status.Status != cloudprotocol.InstanceStateFailed {
+			continue
+		}
+
+		curInstance, err := launcher.instanceManager.getCurrentInstance(status.InstanceIdent)
+		if err != nil {
+			continue
+		}
+
+		node := launcher.getNode(curInstance.NodeID)
+		if node == nil || !node.needRebalancing {
+			continue
+		}
+
+		message := "evicted due to node " + node.nodeInfo.NodeID + " resource pressure"
+
+		if status.ErrorInfo != nil {
+			message += ": " + status.ErrorInfo.Message
+		}
+
+		log.WithFields(instanceIdentLogFields(status.InstanceIdent,
+			log.Fields{"nodeID": node.nodeInfo.NodeID})).Warn("Instance evicted")
+
+		launcher.instanceManager.setInstanceEvicted(status.InstanceIdent, message)
+
+		if launcher.alertSender != nil {
+			launcher.alertSender.SendAlert(cloudprotocol.ServiceInstanceAlert{
+				AlertItem: cloudprotocol.AlertItem{
+					Timestamp: time.Now(), Tag: cloudprotocol.AlertTagServiceInstance,
+				},
+				InstanceIdent:  status.InstanceIdent,
+				ServiceVersion: status.ServiceVersion,
+				Message:        message,
+			})
+		}
+	}
+}
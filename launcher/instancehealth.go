@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const healthTimeoutMessage = "health check timeout"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type instanceHealth struct {
+	known    bool
+	healthy  bool
+	reason   string
+	restarts int
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (launcher *Launcher) processHealth(healthChannel <-chan healthcheck.InstanceHealth) {
+	for health := range healthChannel {
+		launcher.processInstanceHealth(health)
+	}
+}
+
+func (launcher *Launcher) processInstanceHealth(health healthcheck.InstanceHealth) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	state, ok := launcher.instancesHealth[health.InstanceIdent]
+	if !ok {
+		return
+	}
+
+	changed := !state.known || state.healthy != health.Healthy
+
+	state.known, state.healthy, state.reason = true, health.Healthy, health.Reason
+
+	if health.Healthy {
+		state.restarts = 0
+	}
+
+	if !changed {
+		return
+	}
+
+	if !health.Healthy && launcher.restartUnhealthyInstance(health.InstanceIdent, state) {
+		return
+	}
+
+	if launcher.pendingStatus != nil {
+		if launcher.isInstancesHealthKnown() {
+			launcher.sendPendingStatus()
+		}
+
+		return
+	}
+
+	// Health is sent with run status if instances are being run
+	for _, node := range launcher.nodes {
+		if node.waitStatus {
+			return
+		}
+	}
+
+	launcher.sendCurrentStatus()
+}
+
+// holdStatusForHealth updates checked instances and holds run status till health of checked instances is known.
+func (launcher *Launcher) holdStatusForHealth(instancesStatus []cloudprotocol.InstanceStatus) (hold bool) {
+	if launcher.healthChecker == nil {
+		return false
+	}
+
+	checkInstances := launcher.getHealthCheckInstances(instancesStatus)
+	instancesHealth := make(map[aostypes.InstanceIdent]*instanceHealth)
+
+	for _, instance := range checkInstances {
+		state, ok := launcher.instancesHealth[instance.InstanceIdent]
+		if !ok {
+			state = &instanceHealth{}
+		}
+
+		instancesHealth[instance.InstanceIdent] = state
+	}
+
+	launcher.instancesHealth = instancesHealth
+	launcher.healthChecker.UpdateInstances(checkInstances)
+
+	if launcher.isInstancesHealthKnown() {
+		launcher.stopHealthTimer()
+		launcher.pendingStatus = nil
+
+		return false
+	}
+
+	log.Debug("Wait instances health")
+
+	launcher.pendingStatus = instancesStatus
+
+	if launcher.healthTimer == nil {
+		launcher.healthTimerID++
+		timerID := launcher.healthTimerID
+
+		launcher.healthTimer = time.AfterFunc(launcher.config.HealthChecks.StartupTimeout.Duration, func() {
+			launcher.healthWaitTimeout(timerID)
+		})
+	}
+
+	return true
+}
+
+func (launcher *Launcher) healthWaitTimeout(timerID uint64) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	// Skip timer stopped after it has fired
+	if launcher.healthTimer == nil || launcher.healthTimerID != timerID || launcher.pendingStatus == nil {
+		return
+	}
+
+	log.Warn("Wait instances health timeout")
+
+	launcher.sendPendingStatus()
+}
+
+func (launcher *Launcher) sendPendingStatus() {
+	launcher.stopHealthTimer()
+
+	instancesStatus := launcher.pendingStatus
+	launcher.pendingStatus = nil
+
+	launcher.applyInstancesHealth(instancesStatus)
+	launcher.runStatusChannel <- instancesStatus
+}
+
+func (launcher *Launcher) stopHealthTimer() {
+	if launcher.healthTimer != nil {
+		launcher.healthTimer.Stop()
+		launcher.healthTimer = nil
+	}
+}
+
+func (launcher *Launcher) isInstancesHealthKnown() bool {
+	for _, state := range launcher.instancesHealth {
+		if !state.known {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyInstancesHealth reports unhealthy instances and instances with unknown health as failed.
+func (launcher *Launcher) applyInstancesHealth(instancesStatus []cloudprotocol.InstanceStatus) {
+	for i, status := range instancesStatus {
+		state, ok := launcher.instancesHealth[status.InstanceIdent]
+		if !ok || (state.known && state.healthy) || status.Status != cloudprotocol.InstanceStateActive {
+			continue
+		}
+
+		message := healthTimeoutMessage
+
+		if state.known {
+			message = state.reason
+		}
+
+		instancesStatus[i].Status = cloudprotocol.InstanceStateFailed
+		instancesStatus[i].ErrorInfo = &cloudprotocol.ErrorInfo{Message: message}
+	}
+}
+
+// getHealthCheckInstances returns active instances which have health checks performed by CM.
+func (launcher *Launcher) getHealthCheckInstances(
+	instancesStatus []cloudprotocol.InstanceStatus,
+) (instances []healthcheck.Instance) {
+	for _, status := range instancesStatus {
+		if status.Status != cloudprotocol.InstanceStateActive {
+			continue
+		}
+
+		node := launcher.getNode(status.NodeID)
+		if node == nil {
+			continue
+		}
+
+		index := slices.IndexFunc(node.runRequest.Instances, func(instance aostypes.InstanceInfo) bool {
+			return instance.InstanceIdent == status.InstanceIdent
+		})
+		if index < 0 || node.runRequest.Instances[index].NetworkParameters.IP == "" {
+			continue
+		}
+
+		serviceInfo, err := launcher.imageProvider.GetServiceInfo(status.ServiceID)
+		if err != nil {
+			log.WithField("serviceID", status.ServiceID).Errorf("Can't get service info: %v", err)
+
+			continue
+		}
+
+		checks := make([]healthcheck.Check, 0, len(serviceInfo.HealthChecks))
+
+		for _, check := range serviceInfo.HealthChecks {
+			if check.Supported() {
+				checks = append(checks, check)
+			}
+		}
+
+		if len(checks) == 0 {
+			continue
+		}
+
+		instances = append(instances, healthcheck.Instance{
+			InstanceIdent: status.InstanceIdent,
+			IP:            node.runRequest.Instances[index].NetworkParameters.IP,
+			Checks:        checks,
+		})
+	}
+
+	return instances
+}
+
+// restartUnhealthyInstance restarts instances of the node which runs unhealthy instance according to restart policy.
+// SM supports restart of all node instances only.
+func (launcher *Launcher) restartUnhealthyInstance(ident aostypes.InstanceIdent, state *instanceHealth) bool {
+	if launcher.config.HealthChecks.RestartPolicy != config.HealthRestartOnFailure {
+		return false
+	}
+
+	if state.restarts >= launcher.config.HealthChecks.MaxRestarts {
+		log.WithField("instance", ident).Warn("Max restarts of unhealthy instance reached")
+
+		return false
+	}
+
+	node := launcher.findInstanceNode(ident)
+	if node == nil || node.waitStatus || node.pendingRun {
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"instance": ident, "nodeID": node.nodeInfo.NodeID, "reason": state.reason,
+	}).Warn("Restart unhealthy instance")
+
+	node.waitStatus = true
+
+	if err := launcher.sendNodeRunInstances(node, true); err != nil {
+		log.WithField("nodeID", node.nodeInfo.NodeID).Errorf("Can't restart instances: %v", err)
+
+		node.waitStatus = false
+
+		return false
+	}
+
+	launcher.connectionTimer.Reset(launcher.config.SMController.NodesConnectionTimeout.Duration)
+
+	state.restarts++
+
+	// Health of restarted instances is checked again
+	restartedInstances := make([]aostypes.InstanceIdent, 0, len(node.runRequest.Instances))
+
+	for _, instance := range node.runRequest.Instances {
+		if instanceState, ok := launcher.instancesHealth[instance.InstanceIdent]; ok {
+			instanceState.known = false
+
+			restartedInstances = append(restartedInstances, instance.InstanceIdent)
+		}
+	}
+
+	launcher.healthChecker.ResetInstances(restartedInstances)
+
+	return true
+}
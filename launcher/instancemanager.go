@@ -27,7 +27,6 @@ import (
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/exp/maps"
-	"golang.org/x/exp/slices"
 
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
@@ -68,6 +67,7 @@ type Storage interface {
 	RemoveInstance(instanceIdent aostypes.InstanceIdent) error
 	GetInstance(instanceIdent aostypes.InstanceIdent) (InstanceInfo, error)
 	GetInstances() ([]InstanceInfo, error)
+	ExecuteInTransaction(operations func(txStorage any) error) error
 }
 
 type instanceManager struct {
@@ -81,6 +81,7 @@ type instanceManager struct {
 	instances                        map[aostypes.InstanceIdent]aostypes.InstanceInfo
 	removeServiceChannel             <-chan string
 	curInstances                     []InstanceInfo
+	curInstancesIndex                map[aostypes.InstanceIdent]int
 	availableStorage, availableState uint64
 }
 
@@ -139,12 +140,14 @@ func (im *instanceManager) initInstances() {
 	}
 
 	im.curInstances = make([]InstanceInfo, 0, len(instances))
+	im.curInstancesIndex = make(map[aostypes.InstanceIdent]int, len(instances))
 
 	for _, instance := range instances {
 		if instance.State == InstanceCached {
 			continue
 		}
 
+		im.curInstancesIndex[instance.InstanceIdent] = len(im.curInstances)
 		im.curInstances = append(im.curInstances, instance)
 	}
 }
@@ -154,11 +157,8 @@ func (im *instanceManager) getCurrentInstances() []InstanceInfo {
 }
 
 func (im *instanceManager) getCurrentInstance(instanceIdent aostypes.InstanceIdent) (InstanceInfo, error) {
-	curIndex := slices.IndexFunc(im.curInstances, func(curInstance InstanceInfo) bool {
-		return curInstance.InstanceIdent == instanceIdent
-	})
-
-	if curIndex == -1 {
+	curIndex, ok := im.curInstancesIndex[instanceIdent]
+	if !ok {
 		return InstanceInfo{}, aoserrors.Wrap(ErrNotExist)
 	}
 
@@ -174,8 +174,8 @@ func (im *instanceManager) resetStorageStateUsage(storageSize, stateSize uint64)
 }
 
 func (im *instanceManager) setupInstance(
-	instance cloudprotocol.InstanceInfo, index uint64, node *nodeHandler, service imagemanager.ServiceInfo,
-	rebalancing bool,
+	storage deploymentStorage, instance cloudprotocol.InstanceInfo, index uint64, node *nodeHandler,
+	service imagemanager.ServiceInfo, rebalancing bool,
 ) (aostypes.InstanceInfo, error) {
 	instanceInfo := aostypes.InstanceInfo{
 		InstanceIdent: aostypes.InstanceIdent{
@@ -188,7 +188,7 @@ func (im *instanceManager) setupInstance(
 		return aostypes.InstanceInfo{}, aoserrors.Errorf("instance already set up")
 	}
 
-	storedInstance, err := im.storage.GetInstance(instanceInfo.InstanceIdent)
+	storedInstance, err := storage.GetInstance(instanceInfo.InstanceIdent)
 	if err != nil {
 		if !errors.Is(err, ErrNotExist) {
 			return aostypes.InstanceInfo{}, aoserrors.Wrap(err)
@@ -206,7 +206,7 @@ func (im *instanceManager) setupInstance(
 			Timestamp:     time.Now(),
 		}
 
-		if err := im.storage.AddInstance(storedInstance); err != nil {
+		if err := storage.AddInstance(storedInstance); err != nil {
 			log.Errorf("Can't add instance: %v", err)
 		}
 	} else {
@@ -220,7 +220,7 @@ func (im *instanceManager) setupInstance(
 		storedInstance.Timestamp = time.Now()
 		storedInstance.State = InstanceActive
 
-		if err := im.storage.UpdateInstance(storedInstance); err != nil {
+		if err := storage.UpdateInstance(storedInstance); err != nil {
 			log.Errorf("Can't update instance: %v", err)
 		}
 	}
@@ -240,7 +240,8 @@ func (im *instanceManager) setupInstance(
 			"requestedState":   requestedState,
 		})).Debug("Requested storage and state")
 
-	if err = im.setupInstanceStateStorage(&instanceInfo, service, requestedState, requestedStorage); err != nil {
+	if err = im.setupInstanceStateStorage(
+		storage, &instanceInfo, service, requestedState, requestedStorage); err != nil {
 		return aostypes.InstanceInfo{}, err
 	}
 
@@ -282,6 +283,30 @@ func (im *instanceManager) setAllInstanceError(
 	}
 }
 
+func (im *instanceManager) setAllInstanceScheduled(instance cloudprotocol.InstanceInfo, serviceVersion string) {
+	for i := range instance.NumInstances {
+		instanceIdent := createInstanceIdent(instance, i)
+
+		im.errorStatus[instanceIdent] = cloudprotocol.InstanceStatus{
+			InstanceIdent:  instanceIdent,
+			ServiceVersion: serviceVersion,
+			Status:         InstanceStateScheduled,
+		}
+	}
+}
+
+func (im *instanceManager) setInstanceEvicted(instanceIdent aostypes.InstanceIdent, message string) {
+	instanceStatus, ok := im.errorStatus[instanceIdent]
+	if !ok {
+		return
+	}
+
+	instanceStatus.Status = InstanceStateEvicted
+	instanceStatus.ErrorInfo = &cloudprotocol.ErrorInfo{Message: message}
+
+	im.errorStatus[instanceIdent] = instanceStatus
+}
+
 func (im *instanceManager) isInstanceScheduled(instanceIdent aostypes.InstanceIdent) bool {
 	if _, ok := im.instances[instanceIdent]; ok {
 		return true
@@ -298,13 +323,20 @@ func (im *instanceManager) getInstanceCheckSum(instance aostypes.InstanceIdent)
 	return im.storageStateProvider.GetInstanceCheckSum(instance)
 }
 
+func (im *instanceManager) getQuotaEnforcement(instance aostypes.InstanceIdent) string {
+	return im.storageStateProvider.GetQuotaEnforcement(instance)
+}
+
 func (im *instanceManager) setupInstanceStateStorage(
-	instanceInfo *aostypes.InstanceInfo, serviceInfo imagemanager.ServiceInfo,
+	storage storagestate.Storage, instanceInfo *aostypes.InstanceInfo, serviceInfo imagemanager.ServiceInfo,
 	requestedState, requestedStorage uint64,
 ) error {
 	stateStorageParams := storagestate.SetupParams{
-		InstanceIdent: instanceInfo.InstanceIdent,
-		UID:           int(instanceInfo.UID), GID: int(serviceInfo.GID),
+		InstanceIdent:  instanceInfo.InstanceIdent,
+		UID:            int(instanceInfo.UID),
+		GID:            int(serviceInfo.GID),
+		ServiceVersion: serviceInfo.Version,
+		Storage:        storage,
 	}
 
 	if serviceInfo.Config.Quotas.StateLimit != nil {
@@ -342,14 +374,14 @@ func (im *instanceManager) setupInstanceStateStorage(
 	return nil
 }
 
-func (im *instanceManager) cacheInstance(instanceInfo InstanceInfo) error {
+func (im *instanceManager) cacheInstance(storage Storage, instanceInfo InstanceInfo) error {
 	log.WithFields(instanceIdentLogFields(instanceInfo.InstanceIdent, nil)).Debug("Cache instance")
 
 	instanceInfo.State = InstanceCached
 	instanceInfo.NodeID = ""
 
-	if err := im.storage.UpdateInstance(instanceInfo); err != nil {
-		log.Errorf("Can't update instance: %v", err)
+	if err := storage.UpdateInstance(instanceInfo); err != nil {
+		return aoserrors.Wrap(err)
 	}
 
 	if err := im.storageStateProvider.Cleanup(instanceInfo.InstanceIdent); err != nil {
@@ -389,6 +421,21 @@ func (im *instanceManager) fillUIDPool() error {
 	return nil
 }
 
+// releaseLeftoverUIDs releases UIDs acquired for set up instances which are not stored.
+func (im *instanceManager) releaseLeftoverUIDs(storedInstances map[aostypes.InstanceIdent]struct{}) {
+	for instanceIdent, instance := range im.instances {
+		if _, ok := storedInstances[instanceIdent]; ok {
+			continue
+		}
+
+		if err := im.releaseUID(int(instance.UID)); err != nil {
+			log.WithFields(instanceIdentLogFields(instanceIdent, nil)).Errorf("Can't release UID: %v", err)
+		}
+
+		delete(im.instances, instanceIdent)
+	}
+}
+
 func (im *instanceManager) releaseUID(uid int) error {
 	if err := im.uidPool.RemoveID(uid); err != nil {
 		return aoserrors.Wrap(err)
@@ -438,6 +485,33 @@ func (im *instanceManager) removeInstance(instanceInfo InstanceInfo) error {
 	return nil
 }
 
+func (im *instanceManager) wipeServiceInstances(serviceID string) error {
+	if err := im.storageStateProvider.WipeServiceInstances(serviceID); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	instances, err := im.storage.GetInstances()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, instance := range instances {
+		if instance.ServiceID != serviceID {
+			continue
+		}
+
+		if err = im.storage.RemoveInstance(instance.InstanceIdent); err != nil && !errors.Is(err, ErrNotExist) {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = im.releaseUID(instance.UID); err != nil && !errors.Is(err, ErrNotExist) {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
 func (im *instanceManager) clearInstancesWithDeletedService() error {
 	instances, err := im.storage.GetInstances()
 	if err != nil {
@@ -31,7 +31,10 @@ import (
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/extension"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
 	"github.com/aosedge/aos_communicationmanager/storagestate"
@@ -43,8 +46,14 @@ import (
 
 var ErrNotExist = errors.New("entry not exist")
 
+// ErrIncompatibleNode is returned when no node supports runner version or layer media types required by service.
+var ErrIncompatibleNode = errors.New("no compatible nodes")
+
 const defaultResourceRation = 50.0
 
+// subjectNotAssignedReason stop reason of instances of subjects which are not assigned to the unit.
+const subjectNotAssignedReason = "subject is not assigned to the unit"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -66,25 +75,53 @@ type Launcher struct {
 	imageProvider    ImageProvider
 	resourceManager  ResourceManager
 	networkManager   NetworkManager
+	storage          Storage
 
 	runStatusChannel chan []cloudprotocol.InstanceStatus
 	nodes            map[string]*nodeHandler
 
+	subjectsChangedChannel <-chan []string
+	unitSubjects           []string
+	desiredInstances       []cloudprotocol.InstanceInfo
+
 	cancelFunc      context.CancelFunc
 	connectionTimer *time.Timer
 
-	instanceManager *instanceManager
+	instanceManager   *instanceManager
+	placementPolicies []extension.PlacementPolicy
+	lifecycleRecorder LifecycleRecorder
+	alertSender       AlertSender
+
+	unavailableDevices map[string][]string
+	drainedGroups      map[string]struct{}
+	drainedNodes       map[string]struct{}
+	stoppedInstances   map[aostypes.InstanceIdent]stoppedInstance
+
+	schedulingFrozen   bool
+	rescheduleDeferred bool
+	nodeScoring        bool
+
+	healthChecker   HealthChecker
+	instancesHealth map[aostypes.InstanceIdent]*instanceHealth
+	pendingStatus   []cloudprotocol.InstanceStatus
+	healthTimer     *time.Timer
+	healthTimerID   uint64
+
+	nodeStatuses map[string][]cloudprotocol.InstanceStatus
+	updateWaves  [][]*nodeHandler
+	currentWave  []*nodeHandler
+	forceRestart bool
 }
 
 // NetworkManager network manager interface.
 type NetworkManager interface {
 	PrepareInstanceNetworkParameters(
-		instanceIdent aostypes.InstanceIdent, networkID string,
+		storage networkmanager.Storage, instanceIdent aostypes.InstanceIdent, networkID string,
 		params networkmanager.NetworkParameters) (aostypes.NetworkParameters, error)
-	RemoveInstanceNetworkParameters(instanceIdent aostypes.InstanceIdent)
+	RemoveInstanceNetworkParameters(storage networkmanager.Storage, instanceIdent aostypes.InstanceIdent)
 	RestartDNSServer() error
 	GetInstances() []aostypes.InstanceIdent
-	UpdateProviderNetwork(providers []string, nodeID string) error
+	UpdateProviderNetwork(storage networkmanager.Storage, providers []string, nodeID string) error
 }
 
 // ImageProvider provides image information.
@@ -92,6 +129,7 @@ type ImageProvider interface {
 	GetServiceInfo(serviceID string) (imagemanager.ServiceInfo, error)
 	GetLayerInfo(digest string) (imagemanager.LayerInfo, error)
 	GetRemoveServiceChannel() (channel <-chan string)
+	SignRemoteURL(remoteURL, nodeID string) (string, error)
 }
 
 // NodeManager nodes controller.
@@ -111,17 +149,58 @@ type NodeInfoProvider interface {
 	GetAllNodeIDs() (nodeIDs []string, err error)
 }
 
+// UnitSubjectsProvider provides unit subjects.
+type UnitSubjectsProvider interface {
+	GetUnitSubjects() (subjects []string, err error)
+	SubscribeUnitSubjectsChanged() <-chan []string
+}
+
 // ResourceManager provides node resources.
 type ResourceManager interface {
 	GetNodeConfig(nodeID, nodeType string) (cloudprotocol.NodeConfig, error)
 }
 
+// LifecycleRecorder records instance lifecycle events.
+type LifecycleRecorder interface {
+	InstanceScheduled(instance aostypes.InstanceIdent, nodeID string)
+	InstanceSentToNode(instance aostypes.InstanceIdent, nodeID string)
+}
+
+// AlertSender sends alerts.
+type AlertSender interface {
+	SendAlert(alert interface{})
+}
+
+// HealthChecker checks instances health.
+type HealthChecker interface {
+	UpdateInstances(instances []healthcheck.Instance)
+	ResetInstances(instances []aostypes.InstanceIdent)
+	GetHealthChannel() <-chan healthcheck.InstanceHealth
+}
+
 // StorageStateProvider instances storage state provider.
 type StorageStateProvider interface {
 	Setup(params storagestate.SetupParams) (storagePath string, statePath string, err error)
 	Cleanup(instanceIdent aostypes.InstanceIdent) error
 	RemoveServiceInstance(instanceIdent aostypes.InstanceIdent) error
+	WipeServiceInstances(serviceID string) error
 	GetInstanceCheckSum(instance aostypes.InstanceIdent) string
+	GetQuotaEnforcement(instance aostypes.InstanceIdent) string
+	Reconcile(instances []aostypes.InstanceIdent) error
+}
+
+// deploymentStorage storage bound to deployment transaction. Instances, networks, storages and states are stored
+// with it.
+type deploymentStorage interface {
+	Storage
+	networkmanager.Storage
+	storagestate.Storage
+}
+
+// serviceSubject identifies desired instances of the service for the subject.
+type serviceSubject struct {
+	serviceID string
+	subjectID string
 }
 
 /***********************************************************************************************************************
@@ -132,14 +211,24 @@ type StorageStateProvider interface {
 func New(
 	config *config.Config, storage Storage, nodeInfoProvider NodeInfoProvider, nodeManager NodeManager,
 	imageProvider ImageProvider, resourceManager ResourceManager, storageStateProvider StorageStateProvider,
-	networkManager NetworkManager,
+	networkManager NetworkManager, subjectsProvider UnitSubjectsProvider,
 ) (launcher *Launcher, err error) {
 	log.Debug("Create launcher")
 
 	launcher = &Launcher{
 		config: config, nodeInfoProvider: nodeInfoProvider, nodeManager: nodeManager, imageProvider: imageProvider,
-		resourceManager: resourceManager, networkManager: networkManager,
-		runStatusChannel: make(chan []cloudprotocol.InstanceStatus, 10),
+		resourceManager: resourceManager, networkManager: networkManager, storage: storage,
+		runStatusChannel:       make(chan []cloudprotocol.InstanceStatus, 10),
+		subjectsChangedChannel: subjectsProvider.SubscribeUnitSubjectsChanged(),
+		unavailableDevices:     make(map[string][]string),
+		drainedGroups:          make(map[string]struct{}),
+		drainedNodes:           make(map[string]struct{}),
+		nodeStatuses:           make(map[string][]cloudprotocol.InstanceStatus),
+		nodeScoring:            isNodeScoringEnabled(config.GetFeatureFlags()),
+	}
+
+	if launcher.unitSubjects, err = subjectsProvider.GetUnitSubjects(); err != nil {
+		log.Errorf("Can't get unit subjects: %v", err)
 	}
 
 	if launcher.instanceManager, err = newInstanceManager(config, imageProvider, storageStateProvider, storage,
@@ -147,6 +236,10 @@ func New(
 		return nil, err
 	}
 
+	if err := launcher.reconcileDeployment(); err != nil {
+		log.Errorf("Can't reconcile deployment: %v", err)
+	}
+
 	if err := launcher.initNodes(false); err != nil {
 		return nil, err
 	}
@@ -159,6 +252,10 @@ func New(
 
 	go launcher.processChannels(ctx)
 
+	if len(config.Balancing.ActivationSchedules) != 0 {
+		go launcher.runActivationSchedule(ctx)
+	}
+
 	return launcher, nil
 }
 
@@ -171,50 +268,249 @@ func (launcher *Launcher) Close() {
 	}
 
 	launcher.instanceManager.close()
+
+	launcher.Lock()
+	launcher.stopHealthTimer()
+	launcher.Unlock()
 }
 
-// RunInstances performs run service instances.
+// SetPlacementPolicies sets placement policies applied on instances balancing.
+func (launcher *Launcher) SetPlacementPolicies(policies []extension.PlacementPolicy) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	launcher.placementPolicies = policies
+}
+
+// SetLifecycleRecorder sets recorder of instances scheduling events.
+func (launcher *Launcher) SetLifecycleRecorder(lifecycleRecorder LifecycleRecorder) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	launcher.lifecycleRecorder = lifecycleRecorder
+}
+
+// SetAlertSender sets sender of instance eviction alerts.
+func (launcher *Launcher) SetAlertSender(alertSender AlertSender) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	launcher.alertSender = alertSender
+}
+
+// SetHealthChecker sets checker of instances health. Run status is sent when health of started instances is known,
+// unhealthy instances are reported as failed.
+func (launcher *Launcher) SetHealthChecker(healthChecker HealthChecker) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	launcher.healthChecker = healthChecker
+
+	go launcher.processHealth(healthChecker.GetHealthChannel())
+}
+
+// RunInstances performs run service instances. If unit has subjects, only instances of these subjects are run.
 func (launcher *Launcher) RunInstances(instances []cloudprotocol.InstanceInfo, rebalancing bool) error {
 	launcher.Lock()
 	defer launcher.Unlock()
 
 	log.WithField("rebalancing", rebalancing).Debug("Run instances")
 
+	// Instances are fully ordered to get the same placement for the same desired instances regardless of their order
 	sort.Slice(instances, func(i, j int) bool {
-		if instances[i].Priority == instances[j].Priority {
+		// Critical services are scheduled first to get reserved capacity
+		if critical1, critical2 := launcher.isCritical(instances[i].ServiceID),
+			launcher.isCritical(instances[j].ServiceID); critical1 != critical2 {
+			return critical1
+		}
+
+		if instances[i].Priority != instances[j].Priority {
+			return instances[i].Priority > instances[j].Priority
+		}
+
+		if instances[i].ServiceID != instances[j].ServiceID {
 			return instances[i].ServiceID < instances[j].ServiceID
 		}
 
-		return instances[i].Priority > instances[j].Priority
+		return instances[i].SubjectID < instances[j].SubjectID
 	})
 
-	launcher.prepareBalancing(rebalancing)
+	launcher.desiredInstances = instances
+
+	launcher.resetStoppedInstances()
+
+	return launcher.runInstances(instances, rebalancing)
+}
+
+// UpdateDeviceAvailability updates availability of node device reported on device hotplug. Unavailable device is not
+// allocated to new instances, instances which use removed device are rescheduled.
+func (launcher *Launcher) UpdateDeviceAvailability(nodeID, deviceName string, available bool) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithFields(log.Fields{
+		"nodeID": nodeID, "device": deviceName, "available": available,
+	}).Debug("Update device availability")
 
-	if err := launcher.processRemovedInstances(instances); err != nil {
-		log.Errorf("Can't process removed instances: %v", err)
+	node := launcher.getNode(nodeID)
+	if node == nil {
+		return aoserrors.Errorf("node not found: %s", nodeID)
 	}
 
-	if err := launcher.updateNetworks(instances); err != nil {
-		log.Errorf("Can't update networks: %v", err)
+	if !slices.ContainsFunc(node.nodeConfig.Devices, func(device cloudprotocol.DeviceInfo) bool {
+		return device.Name == deviceName
+	}) {
+		return aoserrors.Errorf("device not found: %s", deviceName)
 	}
 
-	if rebalancing {
-		launcher.performPolicyBalancing(instances)
+	unavailable := launcher.unavailableDevices[nodeID]
+	index := slices.Index(unavailable, deviceName)
+
+	if (index >= 0) != available {
+		return nil
 	}
 
-	launcher.performNodeBalancing(instances, rebalancing)
+	if available {
+		launcher.unavailableDevices[nodeID] = slices.Delete(unavailable, index, index+1)
+	} else {
+		launcher.unavailableDevices[nodeID] = append(unavailable, deviceName)
 
-	// first prepare network for instance which have exposed ports
-	launcher.prepareNetworkForInstances(true)
+		node.removeDevice(deviceName)
+	}
 
-	// then prepare network for rest of instances
-	launcher.prepareNetworkForInstances(false)
+	if launcher.desiredInstances == nil || (!available && !launcher.deviceInUse(node, deviceName)) {
+		return nil
+	}
+
+	return launcher.rescheduleInstances()
+}
+
+// DrainNodeGroup drains or restores nodes of node group. Instances are not placed on drained nodes, instances running
+// on drained nodes are rescheduled to other nodes.
+func (launcher *Launcher) DrainNodeGroup(group string, drained bool) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithFields(log.Fields{"group": group, "drained": drained}).Debug("Drain node group")
+
+	if _, ok := launcher.config.Balancing.NodeGroups[group]; !ok {
+		return aoserrors.Errorf("node group not found: %s", group)
+	}
+
+	if _, ok := launcher.drainedGroups[group]; ok == drained {
+		return nil
+	}
+
+	if drained {
+		launcher.drainedGroups[group] = struct{}{}
+	} else {
+		delete(launcher.drainedGroups, group)
+	}
+
+	if launcher.desiredInstances == nil {
+		return nil
+	}
+
+	return launcher.rescheduleInstances()
+}
+
+// DrainNode drains or restores the node. It is used to move instances from the node before maintenance, e.g. node
+// reboot.
+func (launcher *Launcher) DrainNode(nodeID string, drained bool) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithFields(log.Fields{"nodeID": nodeID, "drained": drained}).Debug("Drain node")
+
+	if _, err := launcher.nodeInfoProvider.GetNodeInfo(nodeID); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, ok := launcher.drainedNodes[nodeID]; ok == drained {
+		return nil
+	}
+
+	if drained {
+		launcher.drainedNodes[nodeID] = struct{}{}
+	} else {
+		delete(launcher.drainedNodes, nodeID)
+	}
+
+	if launcher.desiredInstances == nil {
+		return nil
+	}
+
+	return launcher.rescheduleInstances()
+}
+
+// SetSchedulingFrozen freezes or unfreezes instances scheduling. While scheduling is frozen, instances are not
+// rescheduled on device availability, node group drain, activation schedule and unit subjects changes. Deferred
+// rescheduling is performed on unfreeze. Desired instances received from the cloud are still processed.
+func (launcher *Launcher) SetSchedulingFrozen(frozen bool) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithField("frozen", frozen).Debug("Set scheduling frozen")
+
+	launcher.schedulingFrozen = frozen
+
+	if frozen || !launcher.rescheduleDeferred {
+		return nil
+	}
+
+	launcher.rescheduleDeferred = false
+
+	if launcher.desiredInstances == nil {
+		return nil
+	}
+
+	return launcher.runInstances(launcher.desiredInstances, false)
+}
+
+// WipeService securely removes data of all service instances: storages and states are shredded, IP allocations, DNS
+// records and firewall rules of the instances are dropped.
+func (launcher *Launcher) WipeService(serviceID string) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithField("serviceID", serviceID).Debug("Wipe service instances")
+
+	if err := launcher.executeInTransaction(func(storage deploymentStorage) error {
+		for _, instanceIdent := range launcher.networkManager.GetInstances() {
+			if instanceIdent.ServiceID == serviceID {
+				launcher.networkManager.RemoveInstanceNetworkParameters(storage, instanceIdent)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
 
 	if err := launcher.networkManager.RestartDNSServer(); err != nil {
-		log.Errorf("Can't restart DNS server: %v", err)
+		return aoserrors.Wrap(err)
 	}
 
-	return launcher.sendRunInstances(false)
+	return launcher.instanceManager.wipeServiceInstances(serviceID)
+}
+
+// GetNodeCapabilities returns runners and layer media types supported by node.
+func (launcher *Launcher) GetNodeCapabilities(nodeInfo cloudprotocol.NodeInfo) amqphandler.NodeCapabilities {
+	capabilities := amqphandler.NodeCapabilities{
+		NodeID:          nodeInfo.NodeID,
+		Runners:         make([]amqphandler.RunnerCapability, 0),
+		LayerMediaTypes: getNodeLayerMediaTypes(nodeInfo),
+	}
+
+	for name, version := range getNodeRunners(nodeInfo) {
+		capabilities.Runners = append(capabilities.Runners, amqphandler.RunnerCapability{Name: name, Version: version})
+	}
+
+	sort.Slice(capabilities.Runners, func(i, j int) bool {
+		return capabilities.Runners[i].Name < capabilities.Runners[j].Name
+	})
+
+	return capabilities
 }
 
 // GetRunStatusesChannel gets channel with run status instances status.
@@ -226,6 +522,205 @@ func (launcher *Launcher) GetRunStatusesChannel() <-chan []cloudprotocol.Instanc
  * Private
  **********************************************************************************************************************/
 
+func (launcher *Launcher) runInstances(instances []cloudprotocol.InstanceInfo, rebalancing bool) error {
+	launcher.prepareBalancing(rebalancing)
+
+	// Deployment state of instances, networks and storages is committed atomically before run request is sent to
+	// nodes. Failed deployment is rolled back and run request is not sent. Leftovers which are not covered by the
+	// transaction are reconciled right after the failure or on next start if deployment was interrupted.
+	if err := launcher.executeInTransaction(func(storage deploymentStorage) error {
+		return launcher.prepareDeployment(storage, instances, rebalancing)
+	}); err != nil {
+		if reconcileErr := launcher.reconcileDeployment(); reconcileErr != nil {
+			log.Errorf("Can't reconcile deployment: %v", reconcileErr)
+		}
+
+		return err
+	}
+
+	if err := launcher.networkManager.RestartDNSServer(); err != nil {
+		log.Errorf("Can't restart DNS server: %v", err)
+	}
+
+	return launcher.sendRunInstances(false)
+}
+
+// rescheduleInstances reschedules desired instances on internal state change. Rescheduling is deferred while
+// scheduling is frozen.
+func (launcher *Launcher) rescheduleInstances() error {
+	if launcher.schedulingFrozen {
+		log.Debug("Instances rescheduling deferred due to frozen scheduling")
+
+		launcher.rescheduleDeferred = true
+
+		return nil
+	}
+
+	return launcher.runInstances(launcher.desiredInstances, false)
+}
+
+// getSubjectInstances returns instances of unit subjects. All instances are returned if unit has no subjects.
+func (launcher *Launcher) getSubjectInstances(instances []cloudprotocol.InstanceInfo) []cloudprotocol.InstanceInfo {
+	if len(launcher.unitSubjects) == 0 {
+		return instances
+	}
+
+	subjectInstances := make([]cloudprotocol.InstanceInfo, 0, len(instances))
+
+	for _, instance := range instances {
+		if slices.Contains(launcher.unitSubjects, instance.SubjectID) {
+			subjectInstances = append(subjectInstances, instance)
+		}
+	}
+
+	return subjectInstances
+}
+
+// filterSubjectInstances returns instances of unit subjects. Instances of other subjects are reported as stopped.
+func (launcher *Launcher) filterSubjectInstances(
+	instances []cloudprotocol.InstanceInfo,
+) []cloudprotocol.InstanceInfo {
+	subjectInstances := launcher.getSubjectInstances(instances)
+
+	if len(subjectInstances) == len(instances) {
+		return instances
+	}
+
+	for _, instance := range instances {
+		if slices.Contains(launcher.unitSubjects, instance.SubjectID) {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"serviceID": instance.ServiceID, "subjectID": instance.SubjectID,
+		}).Debug("Subject is not assigned to the unit")
+
+		var serviceVersion string
+
+		if service, err := launcher.imageProvider.GetServiceInfo(instance.ServiceID); err == nil {
+			serviceVersion = service.Version
+		}
+
+		for i := range instance.NumInstances {
+			launcher.instanceManager.setInstanceStopped(
+				createInstanceIdent(instance, i), serviceVersion, subjectNotAssignedReason)
+		}
+	}
+
+	return subjectInstances
+}
+
+func (launcher *Launcher) updateUnitSubjects(subjects []string) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithField("subjects", subjects).Debug("Unit subjects changed")
+
+	prevInstances := launcher.getSubjectInstances(launcher.desiredInstances)
+
+	launcher.unitSubjects = subjects
+
+	if launcher.desiredInstances == nil {
+		return
+	}
+
+	instances := launcher.getSubjectInstances(launcher.desiredInstances)
+
+	if slices.EqualFunc(instances, prevInstances, func(instance, prevInstance cloudprotocol.InstanceInfo) bool {
+		return instance.ServiceID == prevInstance.ServiceID && instance.SubjectID == prevInstance.SubjectID
+	}) {
+		return
+	}
+
+	if err := launcher.rescheduleInstances(); err != nil {
+		log.Errorf("Can't run instances: %v", err)
+	}
+}
+
+func (launcher *Launcher) prepareDeployment(
+	storage deploymentStorage, instances []cloudprotocol.InstanceInfo, rebalancing bool,
+) error {
+	instances = launcher.filterSubjectInstances(instances)
+
+	if err := launcher.processRemovedInstances(storage, instances); err != nil {
+		return err
+	}
+
+	if err := launcher.updateNetworks(storage, instances); err != nil {
+		return err
+	}
+
+	instances = launcher.getActivatedInstances(instances, time.Now())
+
+	if rebalancing {
+		launcher.performPolicyBalancing(storage, instances)
+	}
+
+	launcher.performNodeBalancing(storage, instances, rebalancing)
+
+	if rebalancing {
+		launcher.reportEvictions()
+	}
+
+	// first prepare network for instance which have exposed ports
+	launcher.prepareNetworkForInstances(storage, true)
+
+	// then prepare network for rest of instances
+	launcher.prepareNetworkForInstances(storage, false)
+
+	return nil
+}
+
+// executeInTransaction executes operations with storage bound to the transaction.
+func (launcher *Launcher) executeInTransaction(operations func(storage deploymentStorage) error) error {
+	return aoserrors.Wrap(launcher.storage.ExecuteInTransaction(func(txStorage any) error {
+		storage, ok := txStorage.(deploymentStorage)
+		if !ok {
+			return aoserrors.New("wrong transaction storage")
+		}
+
+		return operations(storage)
+	}))
+}
+
+// reconcileDeployment removes resources of instances which are not stored: network parameters, storages and states
+// and UIDs. Such leftovers appear if deployment was interrupted or rolled back.
+func (launcher *Launcher) reconcileDeployment() error {
+	instances, err := launcher.storage.GetInstances()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	storedInstances := make(map[aostypes.InstanceIdent]struct{}, len(instances))
+	instanceIdents := make([]aostypes.InstanceIdent, 0, len(instances))
+
+	for _, instance := range instances {
+		storedInstances[instance.InstanceIdent] = struct{}{}
+		instanceIdents = append(instanceIdents, instance.InstanceIdent)
+	}
+
+	if err = launcher.executeInTransaction(func(storage deploymentStorage) error {
+		for _, netInstance := range launcher.networkManager.GetInstances() {
+			if _, ok := storedInstances[netInstance]; ok {
+				continue
+			}
+
+			log.WithFields(instanceIdentLogFields(netInstance, nil)).Warn(
+				"Remove leftover instance network parameters")
+
+			launcher.networkManager.RemoveInstanceNetworkParameters(storage, netInstance)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	launcher.instanceManager.releaseLeftoverUIDs(storedInstances)
+
+	return aoserrors.Wrap(launcher.instanceManager.storageStateProvider.Reconcile(instanceIdents))
+}
+
 func (launcher *Launcher) prepareBalancing(rebalancing bool) {
 	if err := launcher.initNodes(rebalancing); err != nil {
 		log.Errorf("Can't init nodes: %v", err)
@@ -267,7 +762,8 @@ func (launcher *Launcher) initNodes(rebalancing bool) error {
 		}
 
 		nodeHandler, err := newNodeHandler(
-			nodeInfo, launcher.nodeManager, launcher.resourceManager,
+			nodeInfo, launcher.nodeManager, launcher.resourceManager, launcher.unavailableDevices[nodeID],
+			launcher.config.Balancing.ReservedCapacity[nodeInfo.NodeType], launcher.config.Balancing.Overcommit,
 			nodeInfo.NodeID == launcher.nodeInfoProvider.GetNodeID(), rebalancing)
 		if err != nil {
 			log.WithField("nodeID", nodeID).Errorf("Can't create node handler: %v", err)
@@ -275,6 +771,9 @@ func (launcher *Launcher) initNodes(rebalancing bool) error {
 			continue
 		}
 
+		nodeHandler.groups = launcher.getNodeGroups(nodeHandler)
+		nodeHandler.drained = launcher.isNodeDrained(nodeHandler)
+
 		launcher.nodes[nodeID] = nodeHandler
 	}
 
@@ -287,6 +786,13 @@ func (launcher *Launcher) processChannels(ctx context.Context) {
 		case instances := <-launcher.nodeManager.GetRunInstancesStatusChannel():
 			launcher.processRunInstanceStatus(instances)
 
+		case subjects, ok := <-launcher.subjectsChangedChannel:
+			if !ok {
+				return
+			}
+
+			launcher.updateUnitSubjects(subjects)
+
 		case <-ctx.Done():
 			return
 		}
@@ -297,21 +803,71 @@ func (launcher *Launcher) sendRunInstances(forceRestart bool) (err error) {
 	launcher.connectionTimer = time.AfterFunc(
 		launcher.config.SMController.NodesConnectionTimeout.Duration, launcher.sendCurrentStatus)
 
-	for _, node := range launcher.getNodesByPriorities() {
+	nodes := launcher.getNodesByPriorities()
+
+	for _, node := range nodes {
 		node.waitStatus = true
+	}
 
-		if runErr := launcher.nodeManager.RunInstances(
-			node.nodeInfo.NodeID, node.runRequest.Services, node.runRequest.Layers,
-			node.runRequest.Instances, forceRestart); runErr != nil {
-			log.WithField("nodeID", node.nodeInfo.NodeID).Errorf("Can't run instances: %v", runErr)
+	launcher.currentWave = nil
+	launcher.updateWaves = launcher.getUpdateWaves(nodes)
+	launcher.forceRestart = forceRestart
 
-			if err == nil {
-				err = runErr
-			}
+	err = launcher.sendUpdateWaves()
+
+	launcher.sendStatusIfReceived()
+
+	return err
+}
+
+func (launcher *Launcher) sendNodeRunInstances(node *nodeHandler, forceRestart bool) error {
+	services, layers, err := launcher.signNodeURLs(node)
+	if err != nil {
+		return err
+	}
+
+	if err = launcher.nodeManager.RunInstances(
+		node.nodeInfo.NodeID, services, layers, node.runRequest.Instances, forceRestart); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if launcher.lifecycleRecorder != nil {
+		for _, instance := range node.runRequest.Instances {
+			launcher.lifecycleRecorder.InstanceSentToNode(instance.InstanceIdent, node.nodeInfo.NodeID)
 		}
 	}
 
-	return err
+	return nil
+}
+
+func (launcher *Launcher) signNodeURLs(
+	node *nodeHandler,
+) (services []aostypes.ServiceInfo, layers []aostypes.LayerInfo, err error) {
+	if node.isLocalNode {
+		return node.runRequest.Services, node.runRequest.Layers, nil
+	}
+
+	services = make([]aostypes.ServiceInfo, len(node.runRequest.Services))
+
+	for i, service := range node.runRequest.Services {
+		if service.URL, err = launcher.imageProvider.SignRemoteURL(service.URL, node.nodeInfo.NodeID); err != nil {
+			return nil, nil, aoserrors.Wrap(err)
+		}
+
+		services[i] = service
+	}
+
+	layers = make([]aostypes.LayerInfo, len(node.runRequest.Layers))
+
+	for i, layer := range node.runRequest.Layers {
+		if layer.URL, err = launcher.imageProvider.SignRemoteURL(layer.URL, node.nodeInfo.NodeID); err != nil {
+			return nil, nil, aoserrors.Wrap(err)
+		}
+
+		layers[i] = layer
+	}
+
+	return services, layers, nil
 }
 
 func (launcher *Launcher) processRunInstanceStatus(runStatus NodeRunInstanceStatus) {
@@ -330,6 +886,28 @@ func (launcher *Launcher) processRunInstanceStatus(runStatus NodeRunInstanceStat
 	node.runStatus = runStatus.Instances
 	node.waitStatus = false
 
+	launcher.nodeStatuses[runStatus.NodeID] = runStatus.Instances
+
+	// Node returned, send run request postponed while the node was unreachable
+	if node.pendingRun {
+		log.WithField("nodeID", node.nodeInfo.NodeID).Info("Send pending instances to node")
+
+		if err := launcher.sendNodeRunInstances(node, false); err != nil {
+			log.WithField("nodeID", node.nodeInfo.NodeID).Errorf("Can't run pending instances: %v", err)
+		} else {
+			node.pendingRun = false
+			node.waitStatus = true
+		}
+	}
+
+	if err := launcher.sendUpdateWaves(); err != nil {
+		log.Errorf("Can't send update wave: %v", err)
+	}
+
+	launcher.sendStatusIfReceived()
+}
+
+func (launcher *Launcher) sendStatusIfReceived() {
 	for _, node := range launcher.nodes {
 		if node.waitStatus {
 			return
@@ -361,29 +939,61 @@ func (launcher *Launcher) sendCurrentStatus() {
 			continue
 		}
 
+		if node.pendingRun {
+			for _, pendingInstance := range node.runRequest.Instances {
+				status := cloudprotocol.InstanceStatus{
+					InstanceIdent: pendingInstance.InstanceIdent,
+					NodeID:        node.nodeInfo.NodeID, Status: cloudprotocol.InstanceStateActivating,
+				}
+
+				if index := slices.IndexFunc(node.runRequest.Services, func(service aostypes.ServiceInfo) bool {
+					return service.ServiceID == pendingInstance.ServiceID
+				}); index >= 0 {
+					status.ServiceVersion = node.runRequest.Services[index].Version
+				}
+
+				instancesStatus = append(instancesStatus, status)
+			}
+
+			continue
+		}
+
 		instancesStatus = append(instancesStatus, node.runStatus...)
 	}
 
 	for i := range instancesStatus {
 		instancesStatus[i].StateChecksum = launcher.instanceManager.getInstanceCheckSum(
 			instancesStatus[i].InstanceIdent)
+
+		if quotaAction := launcher.instanceManager.getQuotaEnforcement(
+			instancesStatus[i].InstanceIdent); quotaAction != "" && instancesStatus[i].ErrorInfo == nil {
+			instancesStatus[i].ErrorInfo = &cloudprotocol.ErrorInfo{
+				Message: "storage quota exceeded, action: " + quotaAction,
+			}
+		}
 	}
 
 	instancesStatus = append(instancesStatus, launcher.instanceManager.getErrorInstanceStatuses()...)
+
+	if launcher.holdStatusForHealth(instancesStatus) {
+		return
+	}
+
+	launcher.applyInstancesHealth(instancesStatus)
 	launcher.runStatusChannel <- instancesStatus
 }
 
-func (launcher *Launcher) processRemovedInstances(instances []cloudprotocol.InstanceInfo) error {
-	launcher.removeInstanceNetworkParameters(instances)
+func (launcher *Launcher) processRemovedInstances(
+	storage deploymentStorage, instances []cloudprotocol.InstanceInfo,
+) error {
+	launcher.removeInstanceNetworkParameters(storage, instances)
+
+	numInstances := getNumInstances(instances)
 
 	for _, curInstance := range launcher.instanceManager.getCurrentInstances() {
-		if !slices.ContainsFunc(instances, func(info cloudprotocol.InstanceInfo) bool {
-			return curInstance.ServiceID == info.ServiceID && curInstance.SubjectID == info.SubjectID &&
-				curInstance.Instance < info.NumInstances
-		}) {
-			if err := launcher.instanceManager.cacheInstance(curInstance); err != nil {
-				log.WithFields(instanceIdentLogFields(curInstance.InstanceIdent, nil)).Errorf(
-					"Can't cache instance: %v", err)
+		if !isInstanceDesired(numInstances, curInstance.InstanceIdent) {
+			if err := launcher.instanceManager.cacheInstance(storage, curInstance); err != nil {
+				return err
 			}
 		}
 	}
@@ -391,7 +1001,7 @@ func (launcher *Launcher) processRemovedInstances(instances []cloudprotocol.Inst
 	return nil
 }
 
-func (launcher *Launcher) updateNetworks(instances []cloudprotocol.InstanceInfo) error {
+func (launcher *Launcher) updateNetworks(storage deploymentStorage, instances []cloudprotocol.InstanceInfo) error {
 	providers := make([]string, len(instances))
 
 	for i, instance := range instances {
@@ -404,7 +1014,8 @@ func (launcher *Launcher) updateNetworks(instances []cloudprotocol.InstanceInfo)
 	}
 
 	for _, node := range launcher.nodes {
-		if err := launcher.networkManager.UpdateProviderNetwork(providers, node.nodeInfo.NodeID); err != nil {
+		if err := launcher.networkManager.UpdateProviderNetwork(
+			storage, providers, node.nodeInfo.NodeID); err != nil {
 			return aoserrors.Wrap(err)
 		}
 	}
@@ -412,7 +1023,7 @@ func (launcher *Launcher) updateNetworks(instances []cloudprotocol.InstanceInfo)
 	return nil
 }
 
-func (launcher *Launcher) performPolicyBalancing(instances []cloudprotocol.InstanceInfo) {
+func (launcher *Launcher) performPolicyBalancing(storage deploymentStorage, instances []cloudprotocol.InstanceInfo) {
 	for _, instance := range instances {
 		log.WithFields(log.Fields{
 			"serviceID":    instance.ServiceID,
@@ -433,6 +1044,10 @@ func (launcher *Launcher) performPolicyBalancing(instances []cloudprotocol.Insta
 		}
 
 		for instanceIndex := range instance.NumInstances {
+			if launcher.isInstanceStopped(createInstanceIdent(instance, instanceIndex), service.Version) {
+				continue
+			}
+
 			curInstance, err := launcher.instanceManager.getCurrentInstance(
 				createInstanceIdent(instance, instanceIndex))
 			if err != nil {
@@ -453,7 +1068,7 @@ func (launcher *Launcher) performPolicyBalancing(instances []cloudprotocol.Insta
 			}
 
 			instanceInfo, err := launcher.instanceManager.setupInstance(
-				instance, instanceIndex, node, service, true)
+				storage, instance, instanceIndex, node, service, true)
 			if err != nil {
 				launcher.instanceManager.setInstanceError(
 					createInstanceIdent(instance, instanceIndex), service.Version, err)
@@ -461,17 +1076,24 @@ func (launcher *Launcher) performPolicyBalancing(instances []cloudprotocol.Insta
 				continue
 			}
 
-			if err = node.addRunRequest(instanceInfo, service, layers); err != nil {
+			if err = node.addRunRequest(
+				instanceInfo, service, layers, launcher.isCritical(instance.ServiceID)); err != nil {
 				launcher.instanceManager.setInstanceError(
 					createInstanceIdent(instance, instanceIndex), service.Version, err)
 
 				continue
 			}
+
+			if launcher.lifecycleRecorder != nil {
+				launcher.lifecycleRecorder.InstanceScheduled(instanceInfo.InstanceIdent, node.nodeInfo.NodeID)
+			}
 		}
 	}
 }
 
-func (launcher *Launcher) performNodeBalancing(instances []cloudprotocol.InstanceInfo, rebalancing bool) {
+func (launcher *Launcher) performNodeBalancing(
+	storage deploymentStorage, instances []cloudprotocol.InstanceInfo, rebalancing bool,
+) {
 	for _, instance := range instances {
 		log.WithFields(log.Fields{
 			"serviceID":    instance.ServiceID,
@@ -493,7 +1115,9 @@ func (launcher *Launcher) performNodeBalancing(instances []cloudprotocol.Instanc
 			}).Warn("Skip resource limits")
 		}
 
-		nodes, err := getNodesByStaticResources(launcher.getNodesByPriorities(), service.Config, instance)
+		critical := launcher.isCritical(instance.ServiceID)
+
+		nodes, err := getNodesByStaticResources(launcher.getNodesByPriorities(), service, instance)
 		if err != nil {
 			launcher.instanceManager.setAllInstanceError(instance, service.Version, err)
 			continue
@@ -503,7 +1127,8 @@ func (launcher *Launcher) performNodeBalancing(instances []cloudprotocol.Instanc
 			instanceIdent := createInstanceIdent(instance, instanceIndex)
 			log.WithFields(instanceIdentLogFields(instanceIdent, nil)).Debug("Balance instance")
 
-			if launcher.instanceManager.isInstanceScheduled(instanceIdent) {
+			if launcher.instanceManager.isInstanceScheduled(instanceIdent) ||
+				launcher.isInstanceStopped(instanceIdent, service.Version) {
 				continue
 			}
 
@@ -527,25 +1152,69 @@ func (launcher *Launcher) performNodeBalancing(instances []cloudprotocol.Instanc
 				}
 			}
 
-			node, err := getInstanceNode(nodes, instanceIdent, service.Config)
+			instanceNodes, err := launcher.applyPlacementPolicies(nodes, instanceIdent, service.Config)
+			if err != nil {
+				launcher.instanceManager.setInstanceError(instanceIdent, service.Version, err)
+				continue
+			}
+
+			node, err := getInstanceNode(instanceNodes, instanceIdent, service.Config, critical,
+				launcher.getScoringWeights())
 			if err != nil {
 				launcher.instanceManager.setInstanceError(instanceIdent, service.Version, err)
 				continue
 			}
 
 			instanceInfo, err := launcher.instanceManager.setupInstance(
-				instance, instanceIndex, node, service, rebalancing)
+				storage, instance, instanceIndex, node, service, rebalancing)
 			if err != nil {
 				launcher.instanceManager.setInstanceError(instanceIdent, service.Version, err)
 				continue
 			}
 
-			if err = node.addRunRequest(instanceInfo, service, layers); err != nil {
+			if err = node.addRunRequest(instanceInfo, service, layers, critical); err != nil {
 				launcher.instanceManager.setInstanceError(instanceIdent, service.Version, err)
 				continue
 			}
+
+			if launcher.lifecycleRecorder != nil {
+				launcher.lifecycleRecorder.InstanceScheduled(instanceIdent, node.nodeInfo.NodeID)
+			}
+		}
+	}
+}
+
+func (launcher *Launcher) applyPlacementPolicies(
+	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig,
+) ([]*nodeHandler, error) {
+	for _, policy := range launcher.placementPolicies {
+		nodesInfo := make([]cloudprotocol.NodeInfo, 0, len(nodes))
+
+		for _, node := range nodes {
+			nodesInfo = append(nodesInfo, node.nodeInfo)
+		}
+
+		allowedNodes, err := policy.FilterNodes(instanceIdent, serviceConfig, nodesInfo)
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		resultNodes := make([]*nodeHandler, 0, len(nodes))
+
+		for _, node := range nodes {
+			if slices.Contains(allowedNodes, node.nodeInfo.NodeID) {
+				resultNodes = append(resultNodes, node)
+			}
+		}
+
+		nodes = resultNodes
+
+		if len(nodes) == 0 {
+			return nil, aoserrors.Errorf("no nodes allowed by placement policy")
 		}
 	}
+
+	return nodes, nil
 }
 
 func (launcher *Launcher) getServiceLayers(instance cloudprotocol.InstanceInfo) (
@@ -568,15 +1237,21 @@ func (launcher *Launcher) getServiceLayers(instance cloudprotocol.InstanceInfo)
 	return service, layers, nil
 }
 
-func (launcher *Launcher) prepareNetworkForInstances(onlyExposedPorts bool) {
+func (launcher *Launcher) prepareNetworkForInstances(storage deploymentStorage, onlyExposedPorts bool) {
+	services := make(map[string]imagemanager.ServiceInfo)
+
 	for _, node := range launcher.getNodesByPriorities() {
 		for i, instance := range node.runRequest.Instances {
 			serviceVersion := ""
 
-			if err := func() error {
-				serviceInfo, err := launcher.imageProvider.GetServiceInfo(instance.ServiceID)
-				if err != nil {
-					return aoserrors.Wrap(err)
+			if err := func() (err error) {
+				serviceInfo, ok := services[instance.ServiceID]
+				if !ok {
+					if serviceInfo, err = launcher.imageProvider.GetServiceInfo(instance.ServiceID); err != nil {
+						return aoserrors.Wrap(err)
+					}
+
+					services[instance.ServiceID] = serviceInfo
 				}
 
 				serviceVersion = serviceInfo.Version
@@ -586,7 +1261,7 @@ func (launcher *Launcher) prepareNetworkForInstances(onlyExposedPorts bool) {
 				}
 
 				if instance.NetworkParameters, err = launcher.networkManager.PrepareInstanceNetworkParameters(
-					instance.InstanceIdent, serviceInfo.ProviderID,
+					storage, instance.InstanceIdent, serviceInfo.ProviderID,
 					prepareNetworkParameters(serviceInfo)); err != nil {
 					return aoserrors.Wrap(err)
 				}
@@ -609,8 +1284,10 @@ func prepareNetworkParameters(serviceInfo imagemanager.ServiceInfo) networkmanag
 	}
 
 	params := networkmanager.NetworkParameters{
-		Hosts:       hosts,
-		ExposePorts: serviceInfo.ExposedPorts,
+		Hosts:           hosts,
+		ExposePorts:     serviceInfo.ExposedPorts,
+		HealthEndpoints: serviceInfo.HealthEndpoints,
+		SharedNetwork:   serviceInfo.SharedNetwork,
 	}
 
 	params.AllowConnections = make([]string, 0, len(serviceInfo.Config.AllowedConnections))
@@ -622,26 +1299,35 @@ func prepareNetworkParameters(serviceInfo imagemanager.ServiceInfo) networkmanag
 	return params
 }
 
-func (launcher *Launcher) removeInstanceNetworkParameters(instances []cloudprotocol.InstanceInfo) {
-	networkInstances := launcher.networkManager.GetInstances()
-
-nextNetInstance:
-	for _, netInstance := range networkInstances {
-		for _, instance := range instances {
-			for instanceIndex := range instance.NumInstances {
-				instanceIdent := aostypes.InstanceIdent{
-					ServiceID: instance.ServiceID, SubjectID: instance.SubjectID,
-					Instance: instanceIndex,
-				}
+func (launcher *Launcher) removeInstanceNetworkParameters(
+	storage deploymentStorage, instances []cloudprotocol.InstanceInfo,
+) {
+	numInstances := getNumInstances(instances)
 
-				if netInstance == instanceIdent {
-					continue nextNetInstance
-				}
-			}
+	for _, netInstance := range launcher.networkManager.GetInstances() {
+		if isInstanceDesired(numInstances, netInstance) {
+			continue
 		}
 
-		launcher.networkManager.RemoveInstanceNetworkParameters(netInstance)
+		launcher.networkManager.RemoveInstanceNetworkParameters(storage, netInstance)
+	}
+}
+
+// getNumInstances returns number of desired instances by service and subject.
+func getNumInstances(instances []cloudprotocol.InstanceInfo) map[serviceSubject]uint64 {
+	numInstances := make(map[serviceSubject]uint64, len(instances))
+
+	for _, instance := range instances {
+		numInstances[serviceSubject{serviceID: instance.ServiceID, subjectID: instance.SubjectID}] = instance.NumInstances
 	}
+
+	return numInstances
+}
+
+func isInstanceDesired(numInstances map[serviceSubject]uint64, instanceIdent aostypes.InstanceIdent) bool {
+	return instanceIdent.Instance < numInstances[serviceSubject{
+		serviceID: instanceIdent.ServiceID, subjectID: instanceIdent.SubjectID,
+	}]
 }
 
 func (launcher *Launcher) getNodesByPriorities() []*nodeHandler {
@@ -667,6 +1353,30 @@ func (launcher *Launcher) getNode(nodeID string) *nodeHandler {
 	return node
 }
 
+// deviceInUse checks if device is used by instances scheduled on the node.
+func (launcher *Launcher) deviceInUse(node *nodeHandler, deviceName string) bool {
+	for _, instance := range node.runRequest.Instances {
+		service, err := launcher.imageProvider.GetServiceInfo(instance.ServiceID)
+		if err != nil {
+			log.WithField("serviceID", instance.ServiceID).Errorf("Can't get service info: %v", err)
+
+			continue
+		}
+
+		if slices.ContainsFunc(service.Config.Devices, func(device aostypes.ServiceDevice) bool {
+			return device.Name == deviceName
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (launcher *Launcher) isCritical(serviceID string) bool {
+	return slices.Contains(launcher.config.Balancing.CriticalServices, serviceID)
+}
+
 func (launcher *Launcher) getLocalNode() *nodeHandler {
 	for _, node := range launcher.nodes {
 		if node.isLocalNode {
@@ -19,11 +19,14 @@ package launcher_test
 
 import (
 	"errors"
+	"maps"
 	"net"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,7 +36,9 @@ import (
 	"github.com/apparentlymart/go-cidr/cidr"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/healthcheck"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/launcher"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
@@ -62,6 +67,7 @@ const (
 
 const (
 	subject1          = "subject1"
+	subject2          = "subject2"
 	service1          = "service1"
 	service1LocalURL  = "service1LocalURL"
 	service1RemoteURL = "service1RemoteURL"
@@ -96,9 +102,11 @@ type testNodeInfoProvider struct {
 }
 
 type testNodeManager struct {
-	runStatusChan chan launcher.NodeRunInstanceStatus
-	runRequest    map[string]runRequest
-	monitoring    map[string]aostypes.NodeMonitoring
+	runStatusChan    chan launcher.NodeRunInstanceStatus
+	runRequest       map[string]runRequest
+	monitoring       map[string]aostypes.NodeMonitoring
+	unreachableNodes []string
+	holdStatus       bool
 }
 
 type testImageProvider struct {
@@ -113,11 +121,35 @@ type testResourceManager struct {
 
 type testStorage struct {
 	instanceInfo map[aostypes.InstanceIdent]*launcher.InstanceInfo
+	commitErr    error
+}
+
+// testTxStorage is transaction storage of testStorage, networks and storages and states are handled by test network
+// manager and storage state.
+type testTxStorage struct {
+	*testStorage
+	testNetworkStorage
+	testStorageStateStorage
+}
+
+type testNetworkStorage interface {
+	networkmanager.Storage
+}
+
+type testStorageStateStorage interface {
+	storagestate.Storage
 }
 
 type testStateStorage struct {
-	cleanedInstances []aostypes.InstanceIdent
-	removedInstances []aostypes.InstanceIdent
+	cleanedInstances    []aostypes.InstanceIdent
+	removedInstances    []aostypes.InstanceIdent
+	wipedServices       []string
+	reconciledInstances []aostypes.InstanceIdent
+}
+
+type testSubjectsProvider struct {
+	subjects               []string
+	subjectsChangedChannel chan []string
 }
 
 type testNetworkManager struct {
@@ -126,6 +158,22 @@ type testNetworkManager struct {
 	networkInfo map[string]map[aostypes.InstanceIdent]struct{}
 }
 
+type testLifecycleRecorder struct {
+	events []string
+}
+
+type testAlertSender struct {
+	alerts []interface{}
+}
+
+type testHealthChecker struct {
+	sync.Mutex
+
+	instances      []healthcheck.Instance
+	resetInstances []aostypes.InstanceIdent
+	healthChannel  chan healthcheck.InstanceHealth
+}
+
 type testData struct {
 	testCaseName        string
 	nodeConfigs         map[string]cloudprotocol.NodeConfig
@@ -177,7 +225,7 @@ func TestInstancesWithRemovedServiceInfoAreRemovedOnStart(t *testing.T) {
 	}
 
 	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, imageManager,
-		&testResourceManager{}, &testStateStorage{}, newTestNetworkManager(""))
+		&testResourceManager{}, &testStateStorage{}, newTestNetworkManager(""), newTestSubjectsProvider(nil))
 	if err != nil {
 		t.Fatalf("Can't create launcher %v", err)
 	}
@@ -193,6 +241,133 @@ func TestInstancesWithRemovedServiceInfoAreRemovedOnStart(t *testing.T) {
 	}
 }
 
+func TestLeftoverNetworkInstancesRemovedOnStart(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		imageManager     = newTestImageProvider()
+		testStorage      = newTestStorage(nil)
+		networkManager   = newTestNetworkManager("")
+		storedInstance   = aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+		leftoverInstance = aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 1}
+	)
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {ServiceInfo: aostypes.ServiceInfo{ServiceID: service1}},
+	}
+
+	if err := testStorage.AddInstance(launcher.InstanceInfo{
+		InstanceIdent: storedInstance, Timestamp: time.Now(),
+	}); err != nil {
+		t.Fatalf("Can't add instance %v", err)
+	}
+
+	networkManager.networkInfo["network1"] = map[aostypes.InstanceIdent]struct{}{
+		storedInstance: {}, leftoverInstance: {},
+	}
+
+	stateStorage := &testStateStorage{}
+
+	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, imageManager,
+		&testResourceManager{}, stateStorage, networkManager, newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	netInstances := networkManager.GetInstances()
+
+	if len(netInstances) != 1 || netInstances[0] != storedInstance {
+		t.Errorf("Wrong network instances: %v", netInstances)
+	}
+
+	if !reflect.DeepEqual(stateStorage.reconciledInstances, []aostypes.InstanceIdent{storedInstance}) {
+		t.Errorf("Wrong reconciled storage state instances: %v", stateStorage.reconciledInstances)
+	}
+}
+
+func TestDeploymentAbortedOnCommitFailure(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeManager      = newTestNodeManager()
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		imageManager     = newTestImageProvider()
+		testStorage      = newTestStorage(nil)
+		networkManager   = newTestNetworkManager("172.17.0.1/16")
+		errCommit        = aoserrors.New("commit failed")
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, imageManager,
+		newTestResourceManager(), &testStateStorage{}, networkManager, newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	testStorage.commitErr = errCommit
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); !errors.Is(err, errCommit) {
+		t.Errorf("Wrong run instances error: %v", err)
+	}
+
+	if len(nodeManager.runRequest) != 0 {
+		t.Errorf("Run request should not be sent: %v", nodeManager.runRequest)
+	}
+
+	if instances, _ := testStorage.GetInstances(); len(instances) != 0 {
+		t.Errorf("Instances should be rolled back: %v", instances)
+	}
+
+	if netInstances := networkManager.GetInstances(); len(netInstances) != 0 {
+		t.Errorf("Leftover network instances should be removed: %v", netInstances)
+	}
+
+	testStorage.commitErr = nil
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if instances, _ := testStorage.GetInstances(); len(instances) != 2 {
+		t.Errorf("Wrong stored instances: %v", instances)
+	}
+}
+
 func TestInstancesWithOutdatedTTLRemovedOnStart(t *testing.T) {
 	var (
 		cfg = &config.Config{
@@ -238,7 +413,7 @@ func TestInstancesWithOutdatedTTLRemovedOnStart(t *testing.T) {
 	}
 
 	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, imageManager,
-		&testResourceManager{}, testStateStorage, newTestNetworkManager(""))
+		&testResourceManager{}, testStateStorage, newTestNetworkManager(""), newTestSubjectsProvider(nil))
 	if err != nil {
 		t.Fatalf("Can't create launcher %v", err)
 	}
@@ -266,6 +441,65 @@ func TestInstancesWithOutdatedTTLRemovedOnStart(t *testing.T) {
 	}
 }
 
+func TestWipeService(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		imageManager     = newTestImageProvider()
+		testStorage      = newTestStorage(nil)
+		testStateStorage = &testStateStorage{}
+		networkManager   = newTestNetworkManager("")
+		wipedInstance    = aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1}
+		keptInstance     = aostypes.InstanceIdent{ServiceID: service2, SubjectID: subject1}
+	)
+
+	for i, instanceIdent := range []aostypes.InstanceIdent{wipedInstance, keptInstance} {
+		if err := testStorage.AddInstance(launcher.InstanceInfo{
+			InstanceIdent: instanceIdent,
+			State:         launcher.InstanceActive,
+			UID:           5000 + i,
+		}); err != nil {
+			t.Fatalf("Can't add instance %v", err)
+		}
+
+		imageManager.services[instanceIdent.ServiceID] = imagemanager.ServiceInfo{
+			ServiceInfo: createServiceInfo(instanceIdent.ServiceID, 0, ""),
+		}
+	}
+
+	networkManager.networkInfo["network1"] = map[aostypes.InstanceIdent]struct{}{
+		wipedInstance: {}, keptInstance: {},
+	}
+
+	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, imageManager,
+		&testResourceManager{}, testStateStorage, networkManager, newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	if err = launcherInstance.WipeService(service1); err != nil {
+		t.Fatalf("Can't wipe service: %v", err)
+	}
+
+	if !slices.Equal(testStateStorage.wipedServices, []string{service1}) {
+		t.Errorf("Wrong wiped services: %v", testStateStorage.wipedServices)
+	}
+
+	if instances, _ := testStorage.GetInstances(); len(instances) != 1 || instances[0].InstanceIdent != keptInstance {
+		t.Errorf("Wrong instances: %v", instances)
+	}
+
+	if netInstances := networkManager.GetInstances(); len(netInstances) != 1 || netInstances[0] != keptInstance {
+		t.Errorf("Wrong network instances: %v", netInstances)
+	}
+}
+
 func TestInstancesAreRemovedViaChannel(t *testing.T) {
 	var (
 		cfg = &config.Config{
@@ -290,7 +524,7 @@ func TestInstancesAreRemovedViaChannel(t *testing.T) {
 	}
 
 	launcherInstance, err := launcher.New(cfg, testStorage, nodeInfoProvider, nodeManager, testImageManager,
-		&testResourceManager{}, testStateStorage, newTestNetworkManager(""))
+		&testResourceManager{}, testStateStorage, newTestNetworkManager(""), newTestSubjectsProvider(nil))
 	if err != nil {
 		t.Fatalf("Can't create launcher %v", err)
 	}
@@ -367,7 +601,7 @@ func TestInitialStatus(t *testing.T) {
 	}
 
 	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
-		&testResourceManager{}, &testStateStorage{}, newTestNetworkManager(""))
+		&testResourceManager{}, &testStateStorage{}, newTestNetworkManager(""), newTestSubjectsProvider(nil))
 	if err != nil {
 		t.Fatalf("Can't create launcher %v", err)
 	}
@@ -493,7 +727,7 @@ func TestBalancing(t *testing.T) {
 		storage := newTestStorage(testItem.storedInstances)
 
 		launcherInstance, err := launcher.New(cfg, storage, nodeInfoProvider, nodeManager, imageManager,
-			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"))
+			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
 		if err != nil {
 			t.Fatalf("Can't create launcher %v", err)
 		}
@@ -607,7 +841,7 @@ func TestRebalancing(t *testing.T) {
 		storage := newTestStorage(testItem.storedInstances)
 
 		launcherInstance, err := launcher.New(cfg, storage, nodeInfoProvider, nodeManager, imageManager,
-			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"))
+			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
 		if err != nil {
 			t.Fatalf("Can't create launcher %v", err)
 		}
@@ -688,7 +922,7 @@ func TestStorageCleanup(t *testing.T) {
 	}
 
 	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
-		resourceManager, stateStorageProvider, newTestNetworkManager("172.17.0.1/16"))
+		resourceManager, stateStorageProvider, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
 	if err != nil {
 		t.Fatalf("Can't create launcher %v", err)
 	}
@@ -800,90 +1034,1857 @@ func TestStorageCleanup(t *testing.T) {
 	}
 }
 
-/***********************************************************************************************************************
- * Interfaces
- **********************************************************************************************************************/
+func TestUnitSubjectsChanged(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider     = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager          = newTestNodeManager()
+		resourceManager      = newTestResourceManager()
+		imageManager         = newTestImageProvider()
+		stateStorageProvider = &testStateStorage{}
+		subjectsProvider     = newTestSubjectsProvider([]string{subject1})
+	)
 
-// testNodeInfoProvider
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100}
 
-func newTestNodeInfoProvider(nodeID string) *testNodeInfoProvider {
-	return &testNodeInfoProvider{
-		nodeID:   nodeID,
-		nodeInfo: make(map[string]cloudprotocol.NodeInfo),
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL, Config: aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+		service2: {
+			ServiceInfo: createServiceInfo(service2, 5001, service2LocalURL),
+			RemoteURL:   service2RemoteURL, Config: aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
 	}
-}
 
-func (provider *testNodeInfoProvider) GetAllNodeIDs() (nodeIDs []string, err error) {
-	if provider.nodeInfo == nil {
-		return nil, aoserrors.New("node info not found")
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, stateStorageProvider, newTestNetworkManager("172.17.0.1/16"), subjectsProvider)
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
 	}
+	defer launcherInstance.Close()
 
-	nodeIDs = make([]string, 0, len(provider.nodeInfo))
-	for nodeID := range provider.nodeInfo {
-		nodeIDs = append(nodeIDs, nodeID)
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
 	}
 
-	return nodeIDs, nil
-}
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
 
-func (provider *testNodeInfoProvider) GetNodeID() string {
-	return provider.nodeID
-}
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: service2, SubjectID: subject2, Instance: 0}
 
-func (provider *testNodeInfoProvider) GetNodeInfo(nodeID string) (cloudprotocol.NodeInfo, error) {
-	nodeInfo, ok := provider.nodeInfo[nodeID]
-	if !ok {
-		return cloudprotocol.NodeInfo{}, aoserrors.New("node info not found")
+	desiredInstances := []cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		{ServiceID: service2, SubjectID: subject2, Priority: 100, NumInstances: 1},
 	}
 
-	return nodeInfo, nil
-}
+	createStoppedStatus := func(instance aostypes.InstanceIdent) cloudprotocol.InstanceStatus {
+		return cloudprotocol.InstanceStatus{
+			InstanceIdent: instance, ServiceVersion: "1.0", Status: launcher.InstanceStateStopped,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "subject is not assigned to the unit"},
+		}
+	}
 
-// testNodeManager
+	if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
 
-func newTestNodeManager() *testNodeManager {
-	nodeManager := &testNodeManager{
-		runStatusChan: make(chan launcher.NodeRunInstanceStatus, 10),
-		runRequest:    make(map[string]runRequest),
-		monitoring:    make(map[string]aostypes.NodeMonitoring),
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil), createStoppedStatus(instance2),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
 	}
 
-	return nodeManager
-}
+	// Replace subject: number of subject instances is not changed
 
-func (nodeManager *testNodeManager) RunInstances(nodeID string,
-	services []aostypes.ServiceInfo, layers []aostypes.LayerInfo, instances []aostypes.InstanceInfo, forceRestart bool,
-) error {
-	nodeManager.runRequest[nodeID] = runRequest{
-		services: services, layers: layers, instances: instances,
-		forceRestart: forceRestart,
-	}
+	subjectsProvider.subjectsChangedChannel <- []string{subject2}
 
-	successStatus := launcher.NodeRunInstanceStatus{
-		NodeID:    nodeID,
-		Instances: make([]cloudprotocol.InstanceStatus, len(instances)),
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createStoppedStatus(instance1), createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
 	}
 
-	for i, instance := range instances {
-		successStatus.Instances[i] = cloudprotocol.InstanceStatus{
-			InstanceIdent:  instance.InstanceIdent,
-			ServiceVersion: "1.0",
-			Status:         cloudprotocol.InstanceStateActive, NodeID: nodeID,
-		}
+	if err := deepSlicesCompare(
+		[]aostypes.InstanceIdent{instance1}, stateStorageProvider.cleanedInstances); err != nil {
+		t.Errorf("Incorrect state storage cleanup: %v", err)
 	}
 
-	nodeManager.runStatusChan <- successStatus
+	subjectsProvider.subjectsChangedChannel <- []string{subject1, subject2}
 
-	return nil
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
 }
 
-func (nodeManager *testNodeManager) GetRunInstancesStatusChannel() <-chan launcher.NodeRunInstanceStatus {
-	return nodeManager.runStatusChan
-}
+func TestDeviceAvailability(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
 
-func (nodeManager *testNodeManager) GetUpdateInstancesStatusChannel() <-chan []cloudprotocol.InstanceStatus {
-	return nil
-}
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{
+		Priority: 50, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+			},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	for nodeID, info := range nodeInfoProvider.nodeInfo {
+		nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+			NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+		}
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	instance := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Device removed from local node: instance is rescheduled to remote node
+
+	if err := launcherInstance.UpdateDeviceAvailability(nodeIDLocalSM, "dev1", false); err != nil {
+		t.Fatalf("Can't update device availability: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Device removed from remote node: no node to allocate device
+
+	if err := launcherInstance.UpdateDeviceAvailability(nodeIDRemoteSM1, "dev1", false); err != nil {
+		t.Fatalf("Can't update device availability: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance, "", errors.New("no nodes with devices")),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Device added back to local node
+
+	if err := launcherInstance.UpdateDeviceAvailability(nodeIDLocalSM, "dev1", true); err != nil {
+		t.Fatalf("Can't update device availability: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.UpdateDeviceAvailability(nodeIDLocalSM, "unknown", false); err == nil {
+		t.Error("Error expected for unknown device")
+	}
+}
+
+func TestCriticalServices(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+			Balancing: config.Balancing{
+				CriticalServices: []string{service2},
+				ReservedCapacity: map[string]config.ReservedCapacity{
+					nodeTypeLocalSM: {Devices: map[string]int{"dev1": 1}},
+				},
+			},
+		}
+		nodeManager      = newTestNodeManager()
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 2}},
+	}
+
+	serviceConfig := aostypes.ServiceConfig{
+		Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			Config:      serviceConfig,
+		},
+		service2: {
+			ServiceInfo: createServiceInfo(service2, 5001, service2LocalURL),
+			Config:      serviceConfig,
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Not critical service can't use reserved device allocation
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(aostypes.InstanceIdent{
+			ServiceID: service1, SubjectID: subject1, Instance: 0,
+		}, nodeIDLocalSM, nil),
+		createInstanceStatus(aostypes.InstanceIdent{
+			ServiceID: service1, SubjectID: subject1, Instance: 1,
+		}, "", errors.New("no nodes with devices")),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Critical service uses reserved device allocation even with lower priority
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+		{ServiceID: service2, SubjectID: subject1, Priority: 10, NumInstances: 1},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(aostypes.InstanceIdent{
+			ServiceID: service2, SubjectID: subject1, Instance: 0,
+		}, nodeIDLocalSM, nil),
+		createInstanceStatus(aostypes.InstanceIdent{
+			ServiceID: service1, SubjectID: subject1, Instance: 0,
+		}, nodeIDLocalSM, nil),
+		createInstanceStatus(aostypes.InstanceIdent{
+			ServiceID: service1, SubjectID: subject1, Instance: 1,
+		}, "", errors.New("no nodes with devices")),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+}
+
+func TestUnreachableNode(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Minute},
+				UnreachableNodePolicy:  config.UnreachableNodeProceed,
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{
+		Priority: 50, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+			},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	for nodeID, info := range nodeInfoProvider.nodeInfo {
+		nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+			NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+		}
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Instances are run on reachable node without waiting for unreachable one
+
+	nodeManager.unreachableNodes = []string{nodeIDRemoteSM1}
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	instance0 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 1}
+
+	pendingStatus := createInstanceStatus(instance1, nodeIDRemoteSM1, nil)
+	pendingStatus.Status = cloudprotocol.InstanceStateActivating
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance0, nodeIDLocalSM, nil), pendingStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Pending instances are sent to node once it returns
+
+	nodeManager.unreachableNodes = nil
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDRemoteSM1, NodeType: nodeTypeRemoteSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance0, nodeIDLocalSM, nil), createInstanceStatus(instance1, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+}
+
+func TestLifecycleRecorder(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeManager       = newTestNodeManager()
+		nodeInfoProvider  = newTestNodeInfoProvider(nodeIDLocalSM)
+		imageManager      = newTestImageProvider()
+		lifecycleRecorder = &testLifecycleRecorder{}
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		newTestResourceManager(), &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"),
+		newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	launcherInstance.SetLifecycleRecorder(lifecycleRecorder)
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	expectedEvents := []string{
+		"scheduled service1:0:" + nodeIDLocalSM, "scheduled service1:1:" + nodeIDLocalSM,
+		"sentToNode service1:0:" + nodeIDLocalSM, "sentToNode service1:1:" + nodeIDLocalSM,
+	}
+
+	if !reflect.DeepEqual(lifecycleRecorder.events, expectedEvents) {
+		t.Errorf("Wrong lifecycle events: %v", lifecycleRecorder.events)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Minute},
+			},
+			HealthChecks: config.HealthChecks{
+				StartupTimeout: aostypes.Duration{Duration: 500 * time.Millisecond},
+				RestartPolicy:  config.HealthRestartOnFailure,
+				MaxRestarts:    1,
+			},
+		}
+		nodeManager      = newTestNodeManager()
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		imageManager     = newTestImageProvider()
+		healthChecker    = &testHealthChecker{healthChannel: make(chan healthcheck.InstanceHealth, 1)}
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	tcpCheck := healthcheck.Check{Type: healthcheck.CheckTCP, Port: 8080}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo:  createServiceInfo(service1, 5000, service1LocalURL),
+			Config:       aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+			HealthChecks: []healthcheck.Check{tcpCheck},
+		},
+		// Exec checks are not performed by CM, so instances of the service are not checked
+		service2: {
+			ServiceInfo:  createServiceInfo(service2, 5001, service2LocalURL),
+			Config:       aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+			HealthChecks: []healthcheck.Check{{Type: healthcheck.CheckExec, Command: []string{"/bin/check"}}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		newTestResourceManager(), &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"),
+		newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	launcherInstance.SetHealthChecker(healthChecker)
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		{ServiceID: service2, SubjectID: subject1, Priority: 50, NumInstances: 1},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: service2, SubjectID: subject1, Instance: 0}
+
+	unhealthyStatus := func(message string) cloudprotocol.InstanceStatus {
+		status := createInstanceStatus(instance1, nodeIDLocalSM, nil)
+
+		status.Status = cloudprotocol.InstanceStateFailed
+		status.ErrorInfo = &cloudprotocol.ErrorInfo{Message: message}
+
+		return status
+	}
+
+	// Run status is held till health is known, instance is failed on startup timeout
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		unhealthyStatus("health check timeout"), createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, 2*time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	healthChecker.Lock()
+
+	if !reflect.DeepEqual(healthChecker.instances, []healthcheck.Instance{
+		{InstanceIdent: instance1, IP: "172.17.0.2", Checks: []healthcheck.Check{tcpCheck}},
+	}) {
+		t.Errorf("Wrong checked instances: %v", healthChecker.instances)
+	}
+
+	healthChecker.Unlock()
+
+	healthChecker.healthChannel <- healthcheck.InstanceHealth{InstanceIdent: instance1, Healthy: true}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil), createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Unhealthy instance is restarted till max restarts is reached
+
+	healthChecker.healthChannel <- healthcheck.InstanceHealth{
+		InstanceIdent: instance1, Reason: "tcp check failed: connection refused",
+	}
+	healthChecker.healthChannel <- healthcheck.InstanceHealth{
+		InstanceIdent: instance1, Reason: "tcp check failed: connection refused",
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		unhealthyStatus("tcp check failed"), createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if !nodeManager.runRequest[nodeIDLocalSM].forceRestart {
+		t.Error("Instances should be restarted")
+	}
+
+	healthChecker.Lock()
+
+	if !reflect.DeepEqual(healthChecker.resetInstances, []aostypes.InstanceIdent{instance1}) {
+		t.Errorf("Wrong reset instances: %v", healthChecker.resetInstances)
+	}
+
+	healthChecker.Unlock()
+
+	healthChecker.healthChannel <- healthcheck.InstanceHealth{InstanceIdent: instance1, Healthy: true}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil), createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+}
+
+func TestUpdateWaves(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Minute},
+			},
+			Balancing: config.Balancing{MaxUnavailable: map[string]int{service1: 1}},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{
+		Priority: 50, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+			},
+		},
+	}
+
+	instance0 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 1}
+
+	storage := newTestStorage([]launcher.InstanceInfo{
+		{InstanceIdent: instance0, NodeID: nodeIDLocalSM, PrevNodeID: nodeIDLocalSM, UID: 5000},
+		{InstanceIdent: instance1, NodeID: nodeIDRemoteSM1, PrevNodeID: nodeIDRemoteSM1, UID: 5001},
+	})
+
+	launcherInstance, err := launcher.New(cfg, storage, nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	// Nodes run instances of previous service version
+
+	prevStatus0 := createInstanceStatus(instance0, nodeIDLocalSM, nil)
+	prevStatus0.ServiceVersion = "0.9"
+
+	prevStatus1 := createInstanceStatus(instance1, nodeIDRemoteSM1, nil)
+	prevStatus1.ServiceVersion = "0.9"
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{prevStatus0},
+	}
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDRemoteSM1, NodeType: nodeTypeRemoteSM, Instances: []cloudprotocol.InstanceStatus{prevStatus1},
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		prevStatus0, prevStatus1,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Only one instance of the service is replaced at a time
+
+	nodeManager.holdStatus = true
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 2},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if _, ok := nodeManager.runRequest[nodeIDLocalSM]; !ok || len(nodeManager.runRequest) != 1 {
+		t.Errorf("Wrong first wave nodes count: %d", len(nodeManager.runRequest))
+	}
+
+	newStatus0 := createInstanceStatus(instance0, nodeIDLocalSM, nil)
+	newStatus1 := createInstanceStatus(instance1, nodeIDRemoteSM1, nil)
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{newStatus0},
+	}
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDRemoteSM1, NodeType: nodeTypeRemoteSM, Instances: []cloudprotocol.InstanceStatus{newStatus1},
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		newStatus0, newStatus1,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if _, ok := nodeManager.runRequest[nodeIDRemoteSM1]; !ok {
+		t.Error("Second wave is not sent")
+	}
+}
+
+func TestNodeGroups(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+			Balancing: config.Balancing{
+				NodeGroups: map[string]config.NodeGroup{
+					"cabin": {Labels: []string{"cabin"}},
+					"drive": {NodeIDs: []string{nodeIDRemoteSM1}},
+				},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100, Labels: []string{"cabin"}}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{Priority: 50}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+		service2: {
+			ServiceInfo: createServiceInfo(service2, 5001, service2LocalURL),
+			RemoteURL:   service2RemoteURL,
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	for nodeID, info := range nodeInfoProvider.nodeInfo {
+		nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+			NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+		}
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: service2, SubjectID: subject1, Instance: 0}
+
+	// Group name is used as instance label
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		{ServiceID: service2, SubjectID: subject1, Priority: 100, NumInstances: 1, Labels: []string{"drive"}},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Drained group nodes are excluded from placement
+
+	if err := launcherInstance.DrainNodeGroup("cabin", true); err != nil {
+		t.Fatalf("Can't drain node group: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDRemoteSM1, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Restored group nodes are used again
+
+	if err := launcherInstance.DrainNodeGroup("cabin", false); err != nil {
+		t.Fatalf("Can't restore node group: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Rescheduling is deferred while scheduling is frozen
+
+	if err := launcherInstance.SetSchedulingFrozen(true); err != nil {
+		t.Fatalf("Can't freeze scheduling: %v", err)
+	}
+
+	if err := launcherInstance.DrainNodeGroup("cabin", true); err != nil {
+		t.Fatalf("Can't drain node group: %v", err)
+	}
+
+	select {
+	case runStatus := <-launcherInstance.GetRunStatusesChannel():
+		t.Errorf("Unexpected run status while scheduling is frozen: %v", runStatus)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := launcherInstance.SetSchedulingFrozen(false); err != nil {
+		t.Fatalf("Can't unfreeze scheduling: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDRemoteSM1, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.DrainNodeGroup("unknown", true); err == nil {
+		t.Error("Error expected for unknown node group")
+	}
+
+	// Drained node is excluded from placement
+
+	if err := launcherInstance.DrainNodeGroup("cabin", false); err != nil {
+		t.Fatalf("Can't restore node group: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.DrainNode(nodeIDLocalSM, true); err != nil {
+		t.Fatalf("Can't drain node: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDRemoteSM1, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.DrainNode(nodeIDLocalSM, false); err != nil {
+		t.Fatalf("Can't restore node: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDRemoteSM1, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.DrainNode("unknown", true); err == nil {
+		t.Error("Error expected for unknown node")
+	}
+}
+
+func TestRolloutGroups(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Minute},
+			},
+			Balancing: config.Balancing{
+				NodeGroups:    map[string]config.NodeGroup{"drive": {NodeIDs: []string{nodeIDRemoteSM1}}},
+				RolloutGroups: []string{"drive"},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{Priority: 50}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	for nodeID, info := range nodeInfoProvider.nodeInfo {
+		nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+			NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+		}
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Nodes of rollout group are updated first
+
+	nodeManager.holdStatus = true
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if _, ok := nodeManager.runRequest[nodeIDRemoteSM1]; !ok || len(nodeManager.runRequest) != 1 {
+		t.Errorf("Wrong first stage nodes count: %d", len(nodeManager.runRequest))
+	}
+
+	status := createInstanceStatus(
+		aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}, nodeIDLocalSM, nil)
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDRemoteSM1, NodeType: nodeTypeRemoteSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{status},
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		status,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if _, ok := nodeManager.runRequest[nodeIDLocalSM]; !ok {
+		t.Error("Second stage is not sent")
+	}
+}
+
+func TestActivationSchedule(t *testing.T) {
+	start := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	end := start.Add(time.Second)
+
+	if end.Day() != time.Now().Day() {
+		t.Skip("Window crosses midnight")
+	}
+
+	timeOfDay := func(value time.Time) aostypes.Time {
+		return aostypes.Time{Time: time.Date(0, 1, 1, value.Hour(), value.Minute(), value.Second(), 0, time.Local)}
+	}
+
+	dayOfWeek := uint(start.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7
+	}
+
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+			Balancing: config.Balancing{
+				ActivationSchedules: map[string][]cloudprotocol.TimetableEntry{
+					service1: {{
+						DayOfWeek: dayOfWeek,
+						TimeSlots: []cloudprotocol.TimeSlot{{Start: timeOfDay(start), End: timeOfDay(end)}},
+					}},
+				},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	instance := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	scheduledStatus := cloudprotocol.InstanceStatus{
+		InstanceIdent: instance, ServiceVersion: "1.0", Status: launcher.InstanceStateScheduled,
+	}
+
+	// Instance is not run before activation window
+
+	if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+	}, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		scheduledStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Instance is run at window start and stopped at window end
+
+	for _, expectedStatus := range []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance, nodeIDLocalSM, nil), scheduledStatus,
+	} {
+		if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+			expectedStatus,
+		}, 3*time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+	}
+}
+
+func TestStopInstance(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status: cloudprotocol.NodeStatusProvisioned,
+		Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config:      aostypes.ServiceConfig{Runners: []string{runnerRunc}},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	instance := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	desiredInstances := []cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+	}
+	runningStatus := createInstanceStatus(instance, nodeIDLocalSM, nil)
+	stoppedStatus := cloudprotocol.InstanceStatus{
+		InstanceIdent: instance, ServiceVersion: "1.0", Status: launcher.InstanceStateStopped,
+		ErrorInfo: &cloudprotocol.ErrorInfo{Message: "quota exceeded"},
+	}
+
+	if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		runningStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.RestartInstance(instance); err != nil {
+		t.Errorf("Can't restart instance: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		runningStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Instance stopped for the duration is resumed automatically
+
+	if err := launcherInstance.StopInstance(instance, "quota exceeded", 500*time.Millisecond); err != nil {
+		t.Fatalf("Can't stop instance: %v", err)
+	}
+
+	for _, expectedStatus := range []cloudprotocol.InstanceStatus{stoppedStatus, runningStatus} {
+		if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+			expectedStatus,
+		}, 2*time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+	}
+
+	// Instance stopped without duration is resumed on new desired instances
+
+	if err := launcherInstance.StopInstance(instance, "quota exceeded", 0); err != nil {
+		t.Fatalf("Can't stop instance: %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		stoppedStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if err := launcherInstance.StopInstance(instance, "quota exceeded", 0); err == nil {
+		t.Error("Error expected for not running instance")
+	}
+
+	if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		runningStatus,
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+}
+
+func TestOvercommitEviction(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+			Balancing: config.Balancing{Overcommit: &config.OvercommitRatios{CPU: 1.5}},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+		alertSender      = &testAlertSender{}
+	)
+
+	nodeInfoProvider.nodeInfo[nodeIDLocalSM] = cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+		Status:   cloudprotocol.NodeStatusProvisioned,
+		Attrs:    map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		MaxDMIPs: 1000,
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100,
+		AlertRules: &aostypes.AlertRules{
+			CPU: &aostypes.AlertRulePercents{MinThreshold: 50.0, MaxThreshold: 80.0},
+		},
+	}
+
+	for serviceID, requestedCPU := range map[string]uint64{service1: 300, service2: 800} {
+		imageManager.services[serviceID] = imagemanager.ServiceInfo{
+			ServiceInfo: createServiceInfo(serviceID, 5000, "url"),
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc},
+				Quotas:  aostypes.ServiceQuotas{CPUDMIPSLimit: newQuota(1000)},
+				RequestedResources: &aostypes.RequestedResources{
+					CPU: newQuota(requestedCPU),
+				},
+			},
+		}
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	launcherInstance.SetAlertSender(alertSender)
+
+	nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+		NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM, Instances: []cloudprotocol.InstanceStatus{},
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	instance1 := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: service2, SubjectID: subject1, Instance: 0}
+	desiredInstances := []cloudprotocol.InstanceInfo{
+		{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		{ServiceID: service2, SubjectID: subject1, Priority: 50, NumInstances: 1},
+	}
+
+	// Requested CPU exceeds node capacity but fits overcommitted capacity
+
+	if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		createInstanceStatus(instance2, nodeIDLocalSM, nil),
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	// Node is under resource pressure: the lowest priority instance is evicted on rebalancing
+
+	nodeManager.monitoring = map[string]aostypes.NodeMonitoring{
+		nodeIDLocalSM: {
+			NodeData: aostypes.MonitoringData{CPU: 950},
+			InstancesData: []aostypes.InstanceMonitoring{
+				{InstanceIdent: instance1, MonitoringData: aostypes.MonitoringData{CPU: 300}},
+				{InstanceIdent: instance2, MonitoringData: aostypes.MonitoringData{CPU: 600}},
+			},
+		},
+	}
+
+	if err := launcherInstance.RunInstances(desiredInstances, true); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+		createInstanceStatus(instance1, nodeIDLocalSM, nil),
+		{
+			InstanceIdent: instance2, ServiceVersion: "1.0", Status: launcher.InstanceStateEvicted,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "resource pressure"},
+		},
+	}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	if len(alertSender.alerts) != 1 {
+		t.Fatalf("Wrong alerts count: %d", len(alertSender.alerts))
+	}
+
+	alert, ok := alertSender.alerts[0].(cloudprotocol.ServiceInstanceAlert)
+	if !ok || alert.InstanceIdent != instance2 || alert.Tag != cloudprotocol.AlertTagServiceInstance {
+		t.Errorf("Wrong eviction alert: %v", alertSender.alerts[0])
+	}
+}
+
+func TestSchedulingPerformance(t *testing.T) {
+	const (
+		numNodes            = 10
+		numServices         = 50
+		numServiceInstances = 10
+		maxSchedulingTime   = 500 * time.Millisecond
+		nodeTypePerformance = "performanceNode"
+		nodeIDPrefix        = "node"
+	)
+
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Minute},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDPrefix + "0")
+		nodeManager      = newTestNodeManager()
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+		desiredInstances = make([]cloudprotocol.InstanceInfo, 0, numServices)
+		expectedStatus   = make([]cloudprotocol.InstanceStatus, 0, numServices*numServiceInstances)
+	)
+
+	for i := range numNodes {
+		nodeID := nodeIDPrefix + strconv.Itoa(i)
+
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeTypePerformance, MaxDMIPs: 100000, TotalRAM: 1 << 40,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypePerformance] = cloudprotocol.NodeConfig{
+		Priority: 100, Labels: []string{"label1"}, Resources: []cloudprotocol.ResourceInfo{{Name: "resource1"}},
+	}
+
+	imageManager.services = make(map[string]imagemanager.ServiceInfo)
+
+	for i := range numServices {
+		serviceID := "service" + strconv.Itoa(i)
+		cpu, ram := uint64(100), uint64(1<<20)
+
+		imageManager.services[serviceID] = imagemanager.ServiceInfo{
+			ServiceInfo: createServiceInfo(serviceID, uint32(5000+i), "file:///"+serviceID),
+			RemoteURL:   "http://" + serviceID,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Resources: []string{"resource1"},
+				RequestedResources: &aostypes.RequestedResources{CPU: &cpu, RAM: &ram},
+			},
+		}
+
+		desiredInstances = append(desiredInstances, cloudprotocol.InstanceInfo{
+			ServiceID: serviceID, SubjectID: subject1, Priority: 100, NumInstances: numServiceInstances,
+			Labels: []string{"label1"},
+		})
+
+		for instanceIndex := range uint64(numServiceInstances) {
+			expectedStatus = append(expectedStatus, cloudprotocol.InstanceStatus{
+				InstanceIdent: aostypes.InstanceIdent{
+					ServiceID: serviceID, SubjectID: subject1, Instance: instanceIndex,
+				},
+				ServiceVersion: "1.0", Status: cloudprotocol.InstanceStateActive,
+			})
+		}
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+		resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	for nodeID := range nodeInfoProvider.nodeInfo {
+		nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+			NodeID: nodeID, NodeType: nodeTypePerformance, Instances: []cloudprotocol.InstanceStatus{},
+		}
+	}
+
+	if err := waitRunInstancesStatus(
+		launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+		t.Errorf("Incorrect run status: %v", err)
+	}
+
+	startTime := time.Now()
+
+	if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+		t.Fatalf("Can't run instances %v", err)
+	}
+
+	if schedulingTime := time.Since(startTime); schedulingTime > maxSchedulingTime {
+		t.Errorf("Scheduling takes too long: %v", schedulingTime)
+	}
+
+	receivedStatus := <-launcherInstance.GetRunStatusesChannel()
+
+	for _, status := range receivedStatus {
+		if status.Status != cloudprotocol.InstanceStateActive {
+			t.Errorf("Wrong instance %v status: %s", status.InstanceIdent, status.Status)
+		}
+	}
+
+	if len(receivedStatus) != len(expectedStatus) {
+		t.Errorf("Wrong instances count: %d", len(receivedStatus))
+	}
+
+	t.Logf("Scheduling time: %v", time.Since(startTime))
+}
+
+func TestDeterministicPlacement(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+		instance1        = cloudprotocol.InstanceInfo{
+			ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1,
+		}
+		instance2 = cloudprotocol.InstanceInfo{
+			ServiceID: service1, SubjectID: subject2, Priority: 100, NumInstances: 1,
+		}
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{
+		Priority: 50, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+			},
+		},
+	}
+
+	for _, desiredInstances := range [][]cloudprotocol.InstanceInfo{
+		{instance1, instance2}, {instance2, instance1},
+	} {
+		nodeManager := newTestNodeManager()
+
+		launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+		if err != nil {
+			t.Fatalf("Can't create launcher %v", err)
+		}
+
+		for nodeID, info := range nodeInfoProvider.nodeInfo {
+			nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+				NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+			}
+		}
+
+		if err := waitRunInstancesStatus(
+			launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+
+		if err := launcherInstance.RunInstances(desiredInstances, false); err != nil {
+			t.Fatalf("Can't run instances %v", err)
+		}
+
+		if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+			createInstanceStatus(aostypes.InstanceIdent{
+				ServiceID: service1, SubjectID: subject1, Instance: 0,
+			}, nodeIDLocalSM, nil),
+			createInstanceStatus(aostypes.InstanceIdent{
+				ServiceID: service1, SubjectID: subject2, Instance: 0,
+			}, nodeIDRemoteSM1, nil),
+		}, time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+
+		launcherInstance.Close()
+	}
+}
+
+func TestNodeScoring(t *testing.T) {
+	var (
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+	)
+
+	for nodeID, nodeType := range map[string]string{nodeIDLocalSM: nodeTypeLocalSM, nodeIDRemoteSM1: nodeTypeRemoteSM} {
+		nodeInfoProvider.nodeInfo[nodeID] = cloudprotocol.NodeInfo{
+			NodeID: nodeID, NodeType: nodeType,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+		}
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{
+		Priority: 100, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 1}},
+	}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{
+		Priority: 50, Devices: []cloudprotocol.DeviceInfo{{Name: "dev1", SharedCount: 3}},
+		Labels: []string{"label1"},
+	}
+
+	imageManager.services = map[string]imagemanager.ServiceInfo{
+		service1: {
+			ServiceInfo: createServiceInfo(service1, 5000, service1LocalURL),
+			RemoteURL:   service1RemoteURL,
+			Config: aostypes.ServiceConfig{
+				Runners: []string{runnerRunc}, Devices: []aostypes.ServiceDevice{{Name: "dev1"}},
+			},
+		},
+	}
+
+	instance := aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+
+	testData := []struct {
+		weights             *config.NodeScoringWeights
+		nodeScoringDisabled bool
+		expectedNodeID      string
+	}{
+		{weights: nil, expectedNodeID: nodeIDLocalSM},
+		{weights: &config.NodeScoringWeights{Priority: 1}, expectedNodeID: nodeIDLocalSM},
+		{weights: &config.NodeScoringWeights{Priority: 1, DeviceHeadroom: 1}, expectedNodeID: nodeIDRemoteSM1},
+		{
+			weights:             &config.NodeScoringWeights{Priority: 1, DeviceHeadroom: 1},
+			nodeScoringDisabled: true,
+			expectedNodeID:      nodeIDLocalSM,
+		},
+		{
+			weights:        &config.NodeScoringWeights{Priority: 1, LabelPreference: 0.4, PreferredLabels: []string{"label1"}},
+			expectedNodeID: nodeIDLocalSM,
+		},
+		{
+			weights:        &config.NodeScoringWeights{Priority: 1, LabelPreference: 0.6, PreferredLabels: []string{"label1"}},
+			expectedNodeID: nodeIDRemoteSM1,
+		},
+	}
+
+	for _, data := range testData {
+		cfg := &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+			Balancing: config.Balancing{ScoringWeights: data.weights},
+		}
+
+		nodeManager := newTestNodeManager()
+
+		launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+		if err != nil {
+			t.Fatalf("Can't create launcher %v", err)
+		}
+
+		if data.nodeScoringDisabled {
+			launcherInstance.FeatureFlagsChanged(map[string]bool{config.FeatureNodeScoring: false})
+		}
+
+		for nodeID, info := range nodeInfoProvider.nodeInfo {
+			nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+				NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+			}
+		}
+
+		if err := waitRunInstancesStatus(
+			launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+
+		if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+			{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		}, false); err != nil {
+			t.Fatalf("Can't run instances %v", err)
+		}
+
+		if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+			createInstanceStatus(instance, data.expectedNodeID, nil),
+		}, time.Second); err != nil {
+			t.Errorf("Incorrect run status for weights %v: %v", data.weights, err)
+		}
+
+		launcherInstance.Close()
+	}
+}
+
+func TestNodeCompatibility(t *testing.T) {
+	var (
+		cfg = &config.Config{
+			SMController: config.SMController{
+				NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+			},
+		}
+		nodeInfoProvider = newTestNodeInfoProvider(nodeIDLocalSM)
+		resourceManager  = newTestResourceManager()
+		imageManager     = newTestImageProvider()
+		instance         = aostypes.InstanceIdent{ServiceID: service1, SubjectID: subject1, Instance: 0}
+		ociLayer         = "application/vnd.oci.image.layer.v1.tar+gzip"
+		squashfsLayer    = "application/vnd.aos.image.layer.v1.squashfs"
+	)
+
+	nodeInfoProvider.nodeInfo = map[string]cloudprotocol.NodeInfo{
+		nodeIDLocalSM: {
+			NodeID: nodeIDLocalSM, NodeType: nodeTypeLocalSM,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs:  map[string]interface{}{cloudprotocol.NodeAttrRunners: "runc:1.1.0"},
+		},
+		nodeIDRemoteSM1: {
+			NodeID: nodeIDRemoteSM1, NodeType: nodeTypeRemoteSM,
+			Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs: map[string]interface{}{
+				cloudprotocol.NodeAttrRunners:    "runc:1.2.0",
+				launcher.NodeAttrLayerMediaTypes: ociLayer + "," + squashfsLayer,
+			},
+		},
+	}
+
+	resourceManager.nodeConfigs[nodeTypeLocalSM] = cloudprotocol.NodeConfig{Priority: 100}
+	resourceManager.nodeConfigs[nodeTypeRemoteSM] = cloudprotocol.NodeConfig{Priority: 50}
+
+	testData := []struct {
+		runners         []string
+		layerMediaTypes map[string]string
+		expectedNodeID  string
+		expectedErr     error
+	}{
+		{runners: []string{runnerRunc}, layerMediaTypes: map[string]string{layer1: ociLayer}, expectedNodeID: nodeIDLocalSM},
+		{runners: []string{"runc:>= 1.2"}, expectedNodeID: nodeIDRemoteSM1},
+		{layerMediaTypes: map[string]string{layer1: ociLayer, layer2: squashfsLayer}, expectedNodeID: nodeIDRemoteSM1},
+		{
+			runners:     []string{"runc:>= 1.3"},
+			expectedErr: errors.New("runner runc version 1.2.0 doesn't satisfy >= 1.3"),
+		},
+		{
+			runners: []string{"runc:< 1.2"}, layerMediaTypes: map[string]string{layer1: squashfsLayer},
+			expectedErr: errors.New("no nodes support layer media types [" + squashfsLayer + "]"),
+		},
+		{runners: []string{runnerRunx}, expectedErr: errors.New("no nodes with runner")},
+	}
+
+	for _, data := range testData {
+		imageManager.services = map[string]imagemanager.ServiceInfo{
+			service1: {
+				ServiceInfo:     createServiceInfo(service1, 5000, service1LocalURL),
+				RemoteURL:       service1RemoteURL,
+				Config:          aostypes.ServiceConfig{Runners: data.runners},
+				LayerMediaTypes: data.layerMediaTypes,
+			},
+		}
+
+		nodeManager := newTestNodeManager()
+
+		launcherInstance, err := launcher.New(cfg, newTestStorage(nil), nodeInfoProvider, nodeManager, imageManager,
+			resourceManager, &testStateStorage{}, newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+		if err != nil {
+			t.Fatalf("Can't create launcher %v", err)
+		}
+
+		for nodeID, info := range nodeInfoProvider.nodeInfo {
+			nodeManager.runStatusChan <- launcher.NodeRunInstanceStatus{
+				NodeID: nodeID, NodeType: info.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+			}
+		}
+
+		if err := waitRunInstancesStatus(
+			launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{}, time.Second); err != nil {
+			t.Errorf("Incorrect run status: %v", err)
+		}
+
+		if err := launcherInstance.RunInstances([]cloudprotocol.InstanceInfo{
+			{ServiceID: service1, SubjectID: subject1, Priority: 100, NumInstances: 1},
+		}, false); err != nil {
+			t.Fatalf("Can't run instances %v", err)
+		}
+
+		if err := waitRunInstancesStatus(launcherInstance.GetRunStatusesChannel(), []cloudprotocol.InstanceStatus{
+			createInstanceStatus(instance, data.expectedNodeID, data.expectedErr),
+		}, time.Second); err != nil {
+			t.Errorf("Incorrect run status for runners %v: %v", data.runners, err)
+		}
+
+		launcherInstance.Close()
+	}
+}
+
+func TestNodeCapabilities(t *testing.T) {
+	cfg := &config.Config{
+		SMController: config.SMController{
+			NodesConnectionTimeout: aostypes.Duration{Duration: time.Second},
+		},
+	}
+
+	launcherInstance, err := launcher.New(cfg, newTestStorage(nil), newTestNodeInfoProvider(nodeIDLocalSM),
+		newTestNodeManager(), newTestImageProvider(), newTestResourceManager(), &testStateStorage{},
+		newTestNetworkManager("172.17.0.1/16"), newTestSubjectsProvider(nil))
+	if err != nil {
+		t.Fatalf("Can't create launcher %v", err)
+	}
+	defer launcherInstance.Close()
+
+	capabilities := launcherInstance.GetNodeCapabilities(cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM,
+		Attrs: map[string]interface{}{
+			cloudprotocol.NodeAttrRunners:    "runc:1.1.0,crun",
+			launcher.NodeAttrLayerMediaTypes: "application/vnd.oci.image.layer.v1.tar",
+		},
+	})
+
+	expectedCapabilities := amqphandler.NodeCapabilities{
+		NodeID:          nodeIDLocalSM,
+		Runners:         []amqphandler.RunnerCapability{{Name: "crun"}, {Name: "runc", Version: "1.1.0"}},
+		LayerMediaTypes: []string{"application/vnd.oci.image.layer.v1.tar"},
+	}
+
+	if !reflect.DeepEqual(capabilities, expectedCapabilities) {
+		t.Errorf("Wrong node capabilities: %v", capabilities)
+	}
+
+	capabilities = launcherInstance.GetNodeCapabilities(cloudprotocol.NodeInfo{
+		NodeID: nodeIDLocalSM, Attrs: map[string]interface{}{cloudprotocol.NodeAttrRunners: runnerRunc},
+	})
+
+	if len(capabilities.LayerMediaTypes) == 0 {
+		t.Error("Default layer media types expected")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+// testNodeInfoProvider
+
+func newTestNodeInfoProvider(nodeID string) *testNodeInfoProvider {
+	return &testNodeInfoProvider{
+		nodeID:   nodeID,
+		nodeInfo: make(map[string]cloudprotocol.NodeInfo),
+	}
+}
+
+func (provider *testNodeInfoProvider) GetAllNodeIDs() (nodeIDs []string, err error) {
+	if provider.nodeInfo == nil {
+		return nil, aoserrors.New("node info not found")
+	}
+
+	nodeIDs = make([]string, 0, len(provider.nodeInfo))
+	for nodeID := range provider.nodeInfo {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	return nodeIDs, nil
+}
+
+func (provider *testNodeInfoProvider) GetNodeID() string {
+	return provider.nodeID
+}
+
+func (provider *testNodeInfoProvider) GetNodeInfo(nodeID string) (cloudprotocol.NodeInfo, error) {
+	nodeInfo, ok := provider.nodeInfo[nodeID]
+	if !ok {
+		return cloudprotocol.NodeInfo{}, aoserrors.New("node info not found")
+	}
+
+	return nodeInfo, nil
+}
+
+// testNodeManager
+
+func newTestNodeManager() *testNodeManager {
+	nodeManager := &testNodeManager{
+		runStatusChan: make(chan launcher.NodeRunInstanceStatus, 10),
+		runRequest:    make(map[string]runRequest),
+		monitoring:    make(map[string]aostypes.NodeMonitoring),
+	}
+
+	return nodeManager
+}
+
+func (nodeManager *testNodeManager) RunInstances(nodeID string,
+	services []aostypes.ServiceInfo, layers []aostypes.LayerInfo, instances []aostypes.InstanceInfo, forceRestart bool,
+) error {
+	if slices.Contains(nodeManager.unreachableNodes, nodeID) {
+		return aoserrors.Errorf("node %s is not connected", nodeID)
+	}
+
+	nodeManager.runRequest[nodeID] = runRequest{
+		services: services, layers: layers, instances: instances,
+		forceRestart: forceRestart,
+	}
+
+	if nodeManager.holdStatus {
+		return nil
+	}
+
+	successStatus := launcher.NodeRunInstanceStatus{
+		NodeID:    nodeID,
+		Instances: make([]cloudprotocol.InstanceStatus, len(instances)),
+	}
+
+	for i, instance := range instances {
+		successStatus.Instances[i] = cloudprotocol.InstanceStatus{
+			InstanceIdent:  instance.InstanceIdent,
+			ServiceVersion: "1.0",
+			Status:         cloudprotocol.InstanceStateActive, NodeID: nodeID,
+		}
+	}
+
+	nodeManager.runStatusChan <- successStatus
+
+	return nil
+}
+
+func (nodeManager *testNodeManager) GetRunInstancesStatusChannel() <-chan launcher.NodeRunInstanceStatus {
+	return nodeManager.runStatusChan
+}
+
+func (nodeManager *testNodeManager) GetUpdateInstancesStatusChannel() <-chan []cloudprotocol.InstanceStatus {
+	return nil
+}
 
 func (nodeManager *testNodeManager) GetAverageMonitoring(nodeID string) (aostypes.NodeMonitoring, error) {
 	monitoring, ok := nodeManager.monitoring[nodeID]
@@ -987,6 +2988,25 @@ func (storage *testStorage) GetInstances() ([]launcher.InstanceInfo, error) {
 	return instances, nil
 }
 
+func (storage *testStorage) ExecuteInTransaction(operations func(txStorage any) error) error {
+	instanceInfo := maps.Clone(storage.instanceInfo)
+
+	err := operations(&testTxStorage{testStorage: storage})
+	if err == nil {
+		err = storage.commitErr
+	}
+
+	if err != nil {
+		storage.instanceInfo = instanceInfo
+	}
+
+	return err
+}
+
+func (storage *testTxStorage) ExecuteInTransaction(operations func(txStorage any) error) error {
+	return operations(storage)
+}
+
 func (storage *testStorage) RemoveInstance(instanceIdent aostypes.InstanceIdent) error {
 	if _, ok := storage.instanceInfo[instanceIdent]; !ok {
 		return launcher.ErrNotExist
@@ -1015,12 +3035,28 @@ func (provider *testStateStorage) GetInstanceCheckSum(instance aostypes.Instance
 	return magicSum
 }
 
+func (provider *testStateStorage) GetQuotaEnforcement(instance aostypes.InstanceIdent) string {
+	return ""
+}
+
 func (provider *testStateStorage) RemoveServiceInstance(instanceIdent aostypes.InstanceIdent) error {
 	provider.removedInstances = append(provider.removedInstances, instanceIdent)
 
 	return nil
 }
 
+func (provider *testStateStorage) WipeServiceInstances(serviceID string) error {
+	provider.wipedServices = append(provider.wipedServices, serviceID)
+
+	return nil
+}
+
+func (provider *testStateStorage) Reconcile(instances []aostypes.InstanceIdent) error {
+	provider.reconciledInstances = instances
+
+	return nil
+}
+
 // testImageProvider
 
 func newTestImageProvider() *testImageProvider {
@@ -1047,6 +3083,10 @@ func (testProvider *testImageProvider) GetLayerInfo(digest string) (imagemanager
 	return imagemanager.LayerInfo{}, errors.New("layer does't exist") //nolint:goerr113
 }
 
+func (testProvider *testImageProvider) SignRemoteURL(remoteURL, nodeID string) (string, error) {
+	return remoteURL, nil
+}
+
 func (testProvider *testImageProvider) GetRemoveServiceChannel() (channel <-chan string) {
 	return testProvider.removeServiceInstancesChannel
 }
@@ -1071,12 +3111,14 @@ func newTestNetworkManager(network string) *testNetworkManager {
 	return networkManager
 }
 
-func (network *testNetworkManager) UpdateProviderNetwork(providers []string, nodeID string) error {
+func (network *testNetworkManager) UpdateProviderNetwork(
+	storage networkmanager.Storage, providers []string, nodeID string,
+) error {
 	return nil
 }
 
 func (network *testNetworkManager) PrepareInstanceNetworkParameters(
-	instanceIdent aostypes.InstanceIdent, networkID string,
+	storage networkmanager.Storage, instanceIdent aostypes.InstanceIdent, networkID string,
 	params networkmanager.NetworkParameters,
 ) (aostypes.NetworkParameters, error) {
 	if len(network.networkInfo[networkID]) == 0 {
@@ -1094,7 +3136,9 @@ func (network *testNetworkManager) PrepareInstanceNetworkParameters(
 	}, nil
 }
 
-func (network *testNetworkManager) RemoveInstanceNetworkParameters(instanceIdent aostypes.InstanceIdent) {
+func (network *testNetworkManager) RemoveInstanceNetworkParameters(
+	storage networkmanager.Storage, instanceIdent aostypes.InstanceIdent,
+) {
 	for _, network := range network.networkInfo {
 		if _, ok := network[instanceIdent]; ok {
 			delete(network, instanceIdent)
@@ -1118,6 +3162,59 @@ func (network *testNetworkManager) RestartDNSServer() error {
 	return nil
 }
 
+// testSubjectsProvider
+
+func newTestSubjectsProvider(subjects []string) *testSubjectsProvider {
+	return &testSubjectsProvider{
+		subjects:               subjects,
+		subjectsChangedChannel: make(chan []string, 1),
+	}
+}
+
+func (recorder *testLifecycleRecorder) InstanceScheduled(instance aostypes.InstanceIdent, nodeID string) {
+	recorder.events = append(recorder.events,
+		"scheduled "+instance.ServiceID+":"+strconv.FormatUint(instance.Instance, 10)+":"+nodeID)
+}
+
+func (recorder *testLifecycleRecorder) InstanceSentToNode(instance aostypes.InstanceIdent, nodeID string) {
+	recorder.events = append(recorder.events,
+		"sentToNode "+instance.ServiceID+":"+strconv.FormatUint(instance.Instance, 10)+":"+nodeID)
+}
+
+// testAlertSender
+
+func (sender *testAlertSender) SendAlert(alert interface{}) {
+	sender.alerts = append(sender.alerts, alert)
+}
+
+// testHealthChecker
+
+func (checker *testHealthChecker) UpdateInstances(instances []healthcheck.Instance) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	checker.instances = instances
+}
+
+func (checker *testHealthChecker) ResetInstances(instances []aostypes.InstanceIdent) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	checker.resetInstances = append(checker.resetInstances, instances...)
+}
+
+func (checker *testHealthChecker) GetHealthChannel() <-chan healthcheck.InstanceHealth {
+	return checker.healthChannel
+}
+
+func (provider *testSubjectsProvider) GetUnitSubjects() (subjects []string, err error) {
+	return provider.subjects, nil
+}
+
+func (provider *testSubjectsProvider) SubscribeUnitSubjectsChanged() <-chan []string {
+	return provider.subjectsChangedChannel
+}
+
 /***********************************************************************************************************************
  * Balancing test items
  **********************************************************************************************************************/
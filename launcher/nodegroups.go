@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getNodeGroups returns names of node groups the node belongs to. Node belongs to the group if it is listed in group
+// node IDs or has all group labels.
+func (launcher *Launcher) getNodeGroups(node *nodeHandler) map[string]struct{} {
+	groups := make(map[string]struct{})
+
+	for name, group := range launcher.config.Balancing.NodeGroups {
+		if slices.Contains(group.NodeIDs, node.nodeInfo.NodeID) || hasAllLabels(node, group.Labels) {
+			groups[name] = struct{}{}
+		}
+	}
+
+	return groups
+}
+
+func (launcher *Launcher) isNodeDrained(node *nodeHandler) bool {
+	if _, ok := launcher.drainedNodes[node.nodeInfo.NodeID]; ok {
+		return true
+	}
+
+	for group := range node.groups {
+		if _, ok := launcher.drainedGroups[group]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAllLabels(node *nodeHandler, labels []string) bool {
+	if len(labels) == 0 {
+		return false
+	}
+
+	for _, label := range labels {
+		if _, ok := node.capabilities.labels[label]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
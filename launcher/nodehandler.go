@@ -20,17 +20,32 @@ package launcher
 import (
 	"errors"
 	"math"
+	"strings"
 
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/imagemanager"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
+	"github.com/hashicorp/go-version"
 	log "github.com/sirupsen/logrus"
 )
 
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// NodeAttrLayerMediaTypes node attribute with comma separated list of supported layer media types.
+const NodeAttrLayerMediaTypes = "NodeLayerMediaTypes"
+
+// runnerVersionSeparator separates runner name and version in node runners and runner name and version
+// constraint in service config runners, e.g. "crun:1.14.0" and "crun:>= 1.12".
+const runnerVersionSeparator = ":"
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -49,10 +64,35 @@ type nodeHandler struct {
 	runRequest        runRequest
 	isLocalNode       bool
 	waitStatus        bool
+	pendingRun        bool
 	averageMonitoring aostypes.NodeMonitoring
 	needRebalancing   bool
 	availableCPU      uint64
 	availableRAM      uint64
+	reserved          reservedCapacity
+	overcommit        config.OvercommitRatios
+	capabilities      nodeCapabilities
+	groups            map[string]struct{}
+	drained           bool
+	// indexes to avoid linear search on each instance scheduling
+	instancesMonitoring map[aostypes.InstanceIdent]aostypes.InstanceMonitoring
+	services            map[string]struct{}
+	layers              map[string]struct{}
+}
+
+// nodeCapabilities static node capabilities precomputed on node init.
+type nodeCapabilities struct {
+	runners         map[string]string
+	layerMediaTypes map[string]struct{}
+	labels          map[string]struct{}
+	resources       map[string]struct{}
+}
+
+// reservedCapacity capacity left reserved for critical services.
+type reservedCapacity struct {
+	cpu     uint64
+	ram     uint64
+	devices map[string]int
 }
 
 /***********************************************************************************************************************
@@ -62,12 +102,21 @@ type nodeHandler struct {
 //nolint:gochecknoglobals
 var defaultRunners = []string{"crun", "runc"}
 
+//nolint:gochecknoglobals
+var defaultLayerMediaTypes = []string{
+	"application/vnd.oci.image.layer.v1.tar",
+	"application/vnd.oci.image.layer.v1.tar+gzip",
+	"application/vnd.oci.image.layer.v1.tar+zstd",
+	"application/vnd.aos.image.layer.v1.tar",
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
 
 func newNodeHandler(
 	nodeInfo cloudprotocol.NodeInfo, nodeManager NodeManager, resourceManager ResourceManager,
+	unavailableDevices []string, reserved config.ReservedCapacity, overcommit *config.OvercommitRatios,
 	isLocalNode bool, rebalancing bool,
 ) (*nodeHandler, error) {
 	log.WithFields(log.Fields{"nodeID": nodeInfo.NodeID}).Debug("Init node handler")
@@ -76,6 +125,15 @@ func newNodeHandler(
 		nodeInfo:    nodeInfo,
 		isLocalNode: isLocalNode,
 		waitStatus:  true,
+		reserved: reservedCapacity{
+			cpu: reserved.CPU, ram: reserved.RAM, devices: maps.Clone(reserved.Devices),
+		},
+		services: make(map[string]struct{}),
+		layers:   make(map[string]struct{}),
+	}
+
+	if overcommit != nil {
+		node.overcommit = *overcommit
 	}
 
 	nodeConfig, err := resourceManager.GetNodeConfig(node.nodeInfo.NodeID, node.nodeInfo.NodeType)
@@ -84,17 +142,48 @@ func newNodeHandler(
 	}
 
 	node.nodeConfig = nodeConfig
+	node.capabilities = newNodeCapabilities(nodeInfo, nodeConfig)
 	node.resetDeviceAllocations()
 
+	for _, deviceName := range unavailableDevices {
+		node.removeDevice(deviceName)
+	}
+
 	node.initAvailableResources(nodeManager, rebalancing)
 
 	return node, nil
 }
 
+func newNodeCapabilities(nodeInfo cloudprotocol.NodeInfo, nodeConfig cloudprotocol.NodeConfig) nodeCapabilities {
+	capabilities := nodeCapabilities{
+		runners:         make(map[string]string),
+		layerMediaTypes: make(map[string]struct{}),
+		labels:          make(map[string]struct{}),
+		resources:       make(map[string]struct{}),
+	}
+
+	capabilities.runners = getNodeRunners(nodeInfo)
+
+	for _, mediaType := range getNodeLayerMediaTypes(nodeInfo) {
+		capabilities.layerMediaTypes[mediaType] = struct{}{}
+	}
+
+	for _, label := range nodeConfig.Labels {
+		capabilities.labels[label] = struct{}{}
+	}
+
+	for _, resource := range nodeConfig.Resources {
+		capabilities.resources[resource.Name] = struct{}{}
+	}
+
+	return capabilities
+}
+
 func (node *nodeHandler) initAvailableResources(nodeManager NodeManager, rebalancing bool) {
 	var err error
 
 	node.averageMonitoring = aostypes.NodeMonitoring{}
+	node.instancesMonitoring = make(map[aostypes.InstanceIdent]aostypes.InstanceMonitoring)
 
 	if rebalancing && node.nodeConfig.AlertRules != nil &&
 		(node.nodeConfig.AlertRules.CPU != nil || node.nodeConfig.AlertRules.RAM != nil) {
@@ -113,6 +202,10 @@ func (node *nodeHandler) initAvailableResources(nodeManager NodeManager, rebalan
 						node.nodeConfig.AlertRules.RAM.MaxThreshold/100.0))) {
 			node.needRebalancing = true
 		}
+
+		for _, instance := range node.averageMonitoring.InstancesData {
+			node.instancesMonitoring[instance.InstanceIdent] = instance
+		}
 	}
 
 	nodeCPU := node.getNodeCPU()
@@ -131,6 +224,10 @@ func (node *nodeHandler) initAvailableResources(nodeManager NodeManager, rebalan
 			totalRAM = uint64(math.Round(float64(node.nodeInfo.TotalRAM) *
 				node.nodeConfig.AlertRules.RAM.MinThreshold / 100.0))
 		}
+	} else {
+		// Overcommit is not applied to nodes under resource pressure
+		totalCPU = applyOvercommit(totalCPU, node.overcommit.CPU)
+		totalRAM = applyOvercommit(totalRAM, node.overcommit.RAM)
 	}
 
 	if nodeCPU > totalCPU {
@@ -156,6 +253,14 @@ func (node *nodeHandler) initAvailableResources(nodeManager NodeManager, rebalan
 	}).Debug("Available resources on node")
 }
 
+func applyOvercommit(total uint64, ratio float64) uint64 {
+	if ratio <= 1 {
+		return total
+	}
+
+	return uint64(math.Round(float64(total) * ratio))
+}
+
 func (node *nodeHandler) getNodeCPU() uint64 {
 	instancesCPU := uint64(0)
 
@@ -196,27 +301,36 @@ func (node *nodeHandler) resetDeviceAllocations() {
 	}
 }
 
-func (node *nodeHandler) allocateDevices(serviceDevices []aostypes.ServiceDevice) error {
+// removeDevice removes unavailable device from allocations, so it can't be allocated to new instances.
+func (node *nodeHandler) removeDevice(deviceName string) {
+	delete(node.deviceAllocations, deviceName)
+}
+
+func (node *nodeHandler) allocateDevices(serviceDevices []aostypes.ServiceDevice, critical bool) error {
 	for _, serviceDevice := range serviceDevices {
 		count, ok := node.deviceAllocations[serviceDevice.Name]
 		if !ok {
 			return aoserrors.Errorf("device not found: %s", serviceDevice.Name)
 		}
 
-		if count == 0 {
+		if count <= node.getReservedDevices(serviceDevice.Name, critical) {
 			return aoserrors.Errorf("can't allocate device: %s", serviceDevice.Name)
 		}
 
 		node.deviceAllocations[serviceDevice.Name] = count - 1
+
+		if critical && node.reserved.devices[serviceDevice.Name] > 0 {
+			node.reserved.devices[serviceDevice.Name]--
+		}
 	}
 
 	return nil
 }
 
-func (node *nodeHandler) nodeHasDesiredDevices(desiredDevices []aostypes.ServiceDevice) bool {
+func (node *nodeHandler) nodeHasDesiredDevices(desiredDevices []aostypes.ServiceDevice, critical bool) bool {
 	for _, desiredDevice := range desiredDevices {
 		count, ok := node.deviceAllocations[desiredDevice.Name]
-		if !ok || count == 0 {
+		if !ok || count <= node.getReservedDevices(desiredDevice.Name, critical) {
 			return false
 		}
 	}
@@ -224,29 +338,74 @@ func (node *nodeHandler) nodeHasDesiredDevices(desiredDevices []aostypes.Service
 	return true
 }
 
+// getReservedDevices returns number of device allocations not available for the instance.
+func (node *nodeHandler) getReservedDevices(deviceName string, critical bool) int {
+	if critical {
+		return 0
+	}
+
+	return node.reserved.devices[deviceName]
+}
+
+// getAvailableCPU returns CPU available for the instance. Not critical instances can't use reserved CPU.
+func (node *nodeHandler) getAvailableCPU(critical bool) uint64 {
+	if critical {
+		return node.availableCPU
+	}
+
+	if node.availableCPU < node.reserved.cpu {
+		return 0
+	}
+
+	return node.availableCPU - node.reserved.cpu
+}
+
+// getAvailableRAM returns RAM available for the instance. Not critical instances can't use reserved RAM.
+func (node *nodeHandler) getAvailableRAM(critical bool) uint64 {
+	if critical {
+		return node.availableRAM
+	}
+
+	if node.availableRAM < node.reserved.ram {
+		return 0
+	}
+
+	return node.availableRAM - node.reserved.ram
+}
+
 func (node *nodeHandler) addRunRequest(instanceInfo aostypes.InstanceInfo, service imagemanager.ServiceInfo,
-	layers []imagemanager.LayerInfo,
+	layers []imagemanager.LayerInfo, critical bool,
 ) error {
-	log.WithFields(instanceIdentLogFields(
-		instanceInfo.InstanceIdent, log.Fields{"node": node.nodeInfo.NodeID})).Debug("Schedule instance on node")
+	log.WithFields(instanceIdentLogFields(instanceInfo.InstanceIdent, log.Fields{
+		"node": node.nodeInfo.NodeID, "critical": critical,
+	})).Debug("Schedule instance on node")
 
-	if err := node.allocateDevices(service.Config.Devices); err != nil {
+	if err := node.allocateDevices(service.Config.Devices, critical); err != nil {
 		return err
 	}
 
 	requestedCPU := node.getRequestedCPU(instanceInfo.InstanceIdent, service.Config)
-	if requestedCPU > node.availableCPU && !service.Config.SkipResourceLimits {
+	if requestedCPU > node.getAvailableCPU(critical) && !service.Config.SkipResourceLimits {
 		return aoserrors.Errorf("not enough CPU")
 	}
 
 	requestedRAM := node.getRequestedRAM(instanceInfo.InstanceIdent, service.Config)
-	if requestedRAM > node.availableRAM && !service.Config.SkipResourceLimits {
+	if requestedRAM > node.getAvailableRAM(critical) && !service.Config.SkipResourceLimits {
 		return aoserrors.Errorf("not enough RAM")
 	}
 
+	log.WithFields(instanceIdentLogFields(instanceInfo.InstanceIdent, log.Fields{
+		"CPU": requestedCPU, "RAM": requestedRAM, "nodeID": node.nodeInfo.NodeID,
+	})).Debug("Instance resources request")
+
 	if !service.Config.SkipResourceLimits {
 		node.availableCPU -= requestedCPU
 		node.availableRAM -= requestedRAM
+
+		if critical {
+			node.reserved.cpu -= min(requestedCPU, node.reserved.cpu)
+			node.reserved.ram -= min(requestedRAM, node.reserved.ram)
+		}
 	}
 
 	node.runRequest.Instances = append(node.runRequest.Instances, instanceInfo)
@@ -267,9 +426,7 @@ func (node *nodeHandler) addService(service imagemanager.ServiceInfo) {
 		serviceInfo.URL = service.RemoteURL
 	}
 
-	if slices.ContainsFunc(node.runRequest.Services, func(info aostypes.ServiceInfo) bool {
-		return info.ServiceID == serviceInfo.ServiceID
-	}) {
+	if _, ok := node.services[serviceInfo.ServiceID]; ok {
 		return
 	}
 
@@ -278,6 +435,7 @@ func (node *nodeHandler) addService(service imagemanager.ServiceInfo) {
 	}).Debug("Schedule service on node")
 
 	node.runRequest.Services = append(node.runRequest.Services, serviceInfo)
+	node.services[serviceInfo.ServiceID] = struct{}{}
 }
 
 func (node *nodeHandler) addLayers(layers []imagemanager.LayerInfo) {
@@ -288,9 +446,7 @@ func (node *nodeHandler) addLayers(layers []imagemanager.LayerInfo) {
 			layerInfo.URL = layer.RemoteURL
 		}
 
-		if slices.ContainsFunc(node.runRequest.Layers, func(info aostypes.LayerInfo) bool {
-			return info.Digest == layerInfo.Digest
-		}) {
+		if _, ok := node.layers[layerInfo.Digest]; ok {
 			continue
 		}
 
@@ -299,6 +455,7 @@ func (node *nodeHandler) addLayers(layers []imagemanager.LayerInfo) {
 		}).Debug("Schedule layer on node")
 
 		node.runRequest.Layers = append(node.runRequest.Layers, layerInfo)
+		node.layers[layerInfo.Digest] = struct{}{}
 	}
 }
 
@@ -327,14 +484,8 @@ func (node *nodeHandler) getRequestedCPU(
 	}
 
 	if node.needRebalancing {
-		index := slices.IndexFunc(node.averageMonitoring.InstancesData, func(instance aostypes.InstanceMonitoring) bool {
-			return instance.InstanceIdent == instanceIdent
-		})
-
-		if index != -1 {
-			if node.averageMonitoring.InstancesData[index].CPU > requestedCPU {
-				return node.averageMonitoring.InstancesData[index].CPU
-			}
+		if instance, ok := node.instancesMonitoring[instanceIdent]; ok && instance.CPU > requestedCPU {
+			return instance.CPU
 		}
 	}
 
@@ -354,31 +505,79 @@ func (node *nodeHandler) getRequestedRAM(
 	}
 
 	if node.needRebalancing {
-		index := slices.IndexFunc(node.averageMonitoring.InstancesData, func(instance aostypes.InstanceMonitoring) bool {
-			return instance.InstanceIdent == instanceIdent
-		})
-
-		if index != -1 {
-			if node.averageMonitoring.InstancesData[index].RAM > requestedRAM {
-				return node.averageMonitoring.InstancesData[index].RAM
-			}
+		if instance, ok := node.instancesMonitoring[instanceIdent]; ok && instance.RAM > requestedRAM {
+			return instance.RAM
 		}
 	}
 
 	return requestedRAM
 }
 
+// getNodeRunners returns node runners with their versions, version is empty if not specified.
+func getNodeRunners(nodeInfo cloudprotocol.NodeInfo) map[string]string {
+	runners := make(map[string]string)
+
+	nodeRunners, err := nodeInfo.GetNodeRunners()
+	if err != nil {
+		log.WithField("nodeID", nodeInfo.NodeID).Errorf("Can't get node runners: %v", err)
+
+		return runners
+	}
+
+	if len(nodeRunners) == 0 {
+		nodeRunners = defaultRunners
+	}
+
+	for _, runner := range nodeRunners {
+		name, runnerVersion, _ := strings.Cut(runner, runnerVersionSeparator)
+		runners[strings.TrimSpace(name)] = strings.TrimSpace(runnerVersion)
+	}
+
+	return runners
+}
+
+func getNodeLayerMediaTypes(nodeInfo cloudprotocol.NodeInfo) []string {
+	attrValue, ok := nodeInfo.Attrs[NodeAttrLayerMediaTypes]
+	if !ok {
+		return defaultLayerMediaTypes
+	}
+
+	attrString, ok := attrValue.(string)
+	if !ok {
+		log.WithField("nodeID", nodeInfo.NodeID).Errorf("Invalid node layer media types attribute type")
+
+		return nil
+	}
+
+	mediaTypes := make([]string, 0)
+
+	for _, mediaType := range strings.Split(attrString, ",") {
+		if mediaType = strings.TrimSpace(mediaType); mediaType != "" {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+
+	return mediaTypes
+}
+
 func getNodesByStaticResources(nodes []*nodeHandler,
-	serviceConfig aostypes.ServiceConfig, instanceInfo cloudprotocol.InstanceInfo,
+	service imagemanager.ServiceInfo, instanceInfo cloudprotocol.InstanceInfo,
 ) ([]*nodeHandler, error) {
+	serviceConfig := service.Config
+
 	resultNodes := getActiveNodes(nodes)
 	if len(resultNodes) == 0 {
 		return resultNodes, aoserrors.Errorf("no active nodes")
 	}
 
-	resultNodes = getNodeByRunners(resultNodes, serviceConfig.Runners)
-	if len(resultNodes) == 0 {
-		return resultNodes, aoserrors.Errorf("no nodes with runner: %s", serviceConfig.Runners)
+	resultNodes, err := getNodeByRunners(resultNodes, serviceConfig.Runners)
+	if err != nil {
+		return resultNodes, err
+	}
+
+	resultNodes, err = getNodesByLayerMediaTypes(resultNodes, service.LayerMediaTypes)
+	if err != nil {
+		return resultNodes, err
 	}
 
 	resultNodes = getNodesByLabels(resultNodes, instanceInfo.Labels)
@@ -398,7 +597,7 @@ func getActiveNodes(nodes []*nodeHandler) []*nodeHandler {
 	resultNodes := make([]*nodeHandler, 0)
 
 	for _, node := range nodes {
-		if node.nodeInfo.Status == cloudprotocol.NodeStatusProvisioned {
+		if node.nodeInfo.Status == cloudprotocol.NodeStatusProvisioned && !node.drained {
 			resultNodes = append(resultNodes, node)
 		}
 	}
@@ -406,7 +605,9 @@ func getActiveNodes(nodes []*nodeHandler) []*nodeHandler {
 	return resultNodes
 }
 
-func getNodesByDevices(nodes []*nodeHandler, desiredDevices []aostypes.ServiceDevice) []*nodeHandler {
+func getNodesByDevices(
+	nodes []*nodeHandler, desiredDevices []aostypes.ServiceDevice, critical bool,
+) []*nodeHandler {
 	if len(desiredDevices) == 0 {
 		return nodes
 	}
@@ -414,7 +615,7 @@ func getNodesByDevices(nodes []*nodeHandler, desiredDevices []aostypes.ServiceDe
 	resultNodes := make([]*nodeHandler, 0)
 
 	for _, node := range nodes {
-		if !node.nodeHasDesiredDevices(desiredDevices) {
+		if !node.nodeHasDesiredDevices(desiredDevices, critical) {
 			continue
 		}
 
@@ -433,14 +634,8 @@ func getNodesByResources(nodes []*nodeHandler, desiredResources []string) []*nod
 
 nodeLoop:
 	for _, node := range nodes {
-		if len(node.nodeConfig.Resources) == 0 {
-			continue
-		}
-
 		for _, resource := range desiredResources {
-			if !slices.ContainsFunc(node.nodeConfig.Resources, func(info cloudprotocol.ResourceInfo) bool {
-				return info.Name == resource
-			}) {
+			if _, ok := node.capabilities.resources[resource]; !ok {
 				continue nodeLoop
 			}
 		}
@@ -460,12 +655,13 @@ func getNodesByLabels(nodes []*nodeHandler, desiredLabels []string) []*nodeHandl
 
 nodeLoop:
 	for _, node := range nodes {
-		if len(node.nodeConfig.Labels) == 0 {
-			continue
-		}
-
 		for _, label := range desiredLabels {
-			if !slices.Contains(node.nodeConfig.Labels, label) {
+			if _, ok := node.capabilities.labels[label]; ok {
+				continue
+			}
+
+			// node group name matches all nodes of the group
+			if _, ok := node.groups[label]; !ok {
 				continue nodeLoop
 			}
 		}
@@ -476,45 +672,123 @@ nodeLoop:
 	return resultNodes
 }
 
-func getNodeByRunners(nodes []*nodeHandler, runners []string) []*nodeHandler {
+func getNodeByRunners(nodes []*nodeHandler, runners []string) ([]*nodeHandler, error) {
 	if len(runners) == 0 {
 		runners = defaultRunners
 	}
 
+	var incompatibleErr error
+
 	resultNodes := make([]*nodeHandler, 0)
 
 	for _, runner := range runners {
+		name, constraints, err := parseRunnerRequirement(runner)
+		if err != nil {
+			return resultNodes, err
+		}
+
 		for _, node := range nodes {
-			nodeRunners, err := node.nodeInfo.GetNodeRunners()
-			if err != nil {
-				log.WithField("nodeID", node.nodeInfo.NodeID).Errorf("Can't get node runners: %v", err)
+			nodeVersion, ok := node.capabilities.runners[name]
+			if !ok {
+				continue
+			}
+
+			if !isRunnerVersionCompatible(nodeVersion, constraints) {
+				incompatibleErr = aoserrors.Errorf("%w: runner %s version %s doesn't satisfy %s",
+					ErrIncompatibleNode, name, nodeVersion, constraints)
 
 				continue
 			}
 
-			if (len(nodeRunners) == 0 && slices.Contains(defaultRunners, runner)) ||
-				slices.Contains(nodeRunners, runner) {
-				resultNodes = append(resultNodes, node)
+			resultNodes = append(resultNodes, node)
+		}
+	}
+
+	if len(resultNodes) == 0 {
+		if incompatibleErr != nil {
+			return resultNodes, incompatibleErr
+		}
+
+		return resultNodes, aoserrors.Errorf("no nodes with runner: %s", runners)
+	}
+
+	return resultNodes, nil
+}
+
+func parseRunnerRequirement(runner string) (name string, constraints version.Constraints, err error) {
+	name, constraintStr, found := strings.Cut(runner, runnerVersionSeparator)
+	name = strings.TrimSpace(name)
+
+	if !found || strings.TrimSpace(constraintStr) == "" {
+		return name, nil, nil
+	}
+
+	if constraints, err = version.NewConstraint(constraintStr); err != nil {
+		return name, nil, aoserrors.Errorf("invalid runner %s version constraint: %v", name, err)
+	}
+
+	return name, constraints, nil
+}
+
+func isRunnerVersionCompatible(nodeVersion string, constraints version.Constraints) bool {
+	// Nodes which don't advertise runner version are considered compatible
+	if constraints == nil || nodeVersion == "" {
+		return true
+	}
+
+	runnerVersion, err := version.NewVersion(nodeVersion)
+	if err != nil {
+		log.Warnf("Invalid runner version %s: %v", nodeVersion, err)
+
+		return false
+	}
+
+	return constraints.Check(runnerVersion)
+}
+
+func getNodesByLayerMediaTypes(nodes []*nodeHandler, layerMediaTypes map[string]string) ([]*nodeHandler, error) {
+	if len(layerMediaTypes) == 0 {
+		return nodes, nil
+	}
+
+	resultNodes := make([]*nodeHandler, 0)
+	unsupported := make(map[string]struct{})
+
+	for _, node := range nodes {
+		compatible := true
+
+		for _, mediaType := range layerMediaTypes {
+			if _, ok := node.capabilities.layerMediaTypes[mediaType]; !ok {
+				unsupported[mediaType] = struct{}{}
+				compatible = false
 			}
 		}
+
+		if compatible {
+			resultNodes = append(resultNodes, node)
+		}
 	}
 
-	return resultNodes
+	if len(resultNodes) == 0 {
+		mediaTypes := maps.Keys(unsupported)
+		slices.Sort(mediaTypes)
+
+		return resultNodes, aoserrors.Errorf("%w: no nodes support layer media types %v",
+			ErrIncompatibleNode, mediaTypes)
+	}
+
+	return resultNodes, nil
 }
 
 func getNodesByCPU(
-	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig,
+	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig, critical bool,
 ) []*nodeHandler {
 	resultNodes := make([]*nodeHandler, 0)
 
 	for _, node := range nodes {
 		requestedCPU := node.getRequestedCPU(instanceIdent, serviceConfig)
 
-		log.WithFields(instanceIdentLogFields(instanceIdent, log.Fields{
-			"CPU": requestedCPU, "nodeID": node.nodeInfo.NodeID,
-		})).Debug("Instance CPU request")
-
-		if node.availableCPU >= requestedCPU || serviceConfig.SkipResourceLimits {
+		if node.getAvailableCPU(critical) >= requestedCPU || serviceConfig.SkipResourceLimits {
 			resultNodes = append(resultNodes, node)
 		}
 	}
@@ -523,18 +797,14 @@ func getNodesByCPU(
 }
 
 func getNodesByRAM(
-	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig,
+	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig, critical bool,
 ) []*nodeHandler {
 	resultNodes := make([]*nodeHandler, 0)
 
 	for _, node := range nodes {
 		requestedRAM := node.getRequestedRAM(instanceIdent, serviceConfig)
 
-		log.WithFields(instanceIdentLogFields(instanceIdent, log.Fields{
-			"RAM": requestedRAM, "nodeID": node.nodeInfo.NodeID,
-		})).Debug("Instance RAM request")
-
-		if node.availableRAM >= node.getRequestedRAM(instanceIdent, serviceConfig) || serviceConfig.SkipResourceLimits {
+		if node.getAvailableRAM(critical) >= requestedRAM || serviceConfig.SkipResourceLimits {
 			resultNodes = append(resultNodes, node)
 		}
 	}
@@ -578,22 +848,27 @@ func excludeNodes(nodes []*nodeHandler, excludeNodes []string) []*nodeHandler {
 
 func getInstanceNode(
 	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig,
+	critical bool, scoringWeights *config.NodeScoringWeights,
 ) (*nodeHandler, error) {
-	resultNodes := getNodesByDevices(nodes, serviceConfig.Devices)
+	resultNodes := getNodesByDevices(nodes, serviceConfig.Devices, critical)
 	if len(resultNodes) == 0 {
 		return nil, aoserrors.Errorf("no nodes with devices %v", serviceConfig.Devices)
 	}
 
-	resultNodes = getNodesByCPU(resultNodes, instanceIdent, serviceConfig)
+	resultNodes = getNodesByCPU(resultNodes, instanceIdent, serviceConfig, critical)
 	if len(resultNodes) == 0 {
 		return nil, aoserrors.Errorf("no nodes with available CPU")
 	}
 
-	resultNodes = getNodesByRAM(resultNodes, instanceIdent, serviceConfig)
+	resultNodes = getNodesByRAM(resultNodes, instanceIdent, serviceConfig, critical)
 	if len(resultNodes) == 0 {
 		return nil, aoserrors.Errorf("no nodes with available RAM")
 	}
 
+	if scoringWeights != nil {
+		return getNodeByScore(resultNodes, instanceIdent, serviceConfig, *scoringWeights), nil
+	}
+
 	resultNodes = getTopPriorityNodes(resultNodes)
 	if len(resultNodes) == 0 {
 		return nil, aoserrors.Errorf("can't get top priority nodes")
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"math"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// nodeScore node score with breakdown by criteria. Each criterion is in 0..1 range.
+type nodeScore struct {
+	priority        float64
+	load            float64
+	deviceHeadroom  float64
+	labelPreference float64
+	total           float64
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// FeatureFlagsChanged enables or disables node scoring by nodeScoring feature flag. The change is applied on next
+// instances balancing.
+func (launcher *Launcher) FeatureFlagsChanged(flags map[string]bool) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	if nodeScoring := isNodeScoringEnabled(flags); nodeScoring != launcher.nodeScoring {
+		log.WithField("enabled", nodeScoring).Info("Node scoring changed")
+
+		launcher.nodeScoring = nodeScoring
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func isNodeScoringEnabled(flags map[string]bool) bool {
+	return flags[config.FeatureNodeScoring]
+}
+
+// getScoringWeights returns node scoring weights or nil if node scoring is disabled and legacy node selection is used.
+func (launcher *Launcher) getScoringWeights() *config.NodeScoringWeights {
+	if !launcher.nodeScoring {
+		return nil
+	}
+
+	return launcher.config.Balancing.ScoringWeights
+}
+
+// getNodeByScore selects node with the highest weighted score. Nodes with equal score are selected in the nodes order.
+func getNodeByScore(
+	nodes []*nodeHandler, instanceIdent aostypes.InstanceIdent, serviceConfig aostypes.ServiceConfig,
+	weights config.NodeScoringWeights,
+) *nodeHandler {
+	var (
+		maxPriority uint32
+		bestNode    *nodeHandler
+		bestScore   nodeScore
+	)
+
+	for _, node := range nodes {
+		maxPriority = max(maxPriority, node.nodeConfig.Priority)
+	}
+
+	for _, node := range nodes {
+		score := nodeScore{
+			load:            node.getFreeResources(),
+			deviceHeadroom:  node.getDeviceHeadroom(serviceConfig.Devices),
+			labelPreference: node.getLabelPreference(weights.PreferredLabels),
+		}
+
+		if maxPriority > 0 {
+			score.priority = float64(node.nodeConfig.Priority) / float64(maxPriority)
+		}
+
+		score.total = weights.Priority*score.priority + weights.Load*score.load +
+			weights.DeviceHeadroom*score.deviceHeadroom + weights.LabelPreference*score.labelPreference
+
+		log.WithFields(instanceIdentLogFields(instanceIdent, log.Fields{
+			"nodeID": node.nodeInfo.NodeID, "score": score.total, "priority": score.priority, "load": score.load,
+			"deviceHeadroom": score.deviceHeadroom, "labelPreference": score.labelPreference,
+		})).Debug("Node score")
+
+		if bestNode == nil || score.total > bestScore.total {
+			bestNode, bestScore = node, score
+		}
+	}
+
+	if bestNode != nil {
+		log.WithFields(instanceIdentLogFields(instanceIdent, log.Fields{
+			"nodeID": bestNode.nodeInfo.NodeID, "score": bestScore.total,
+		})).Info("Node selected by score")
+	}
+
+	return bestNode
+}
+
+// getFreeResources returns average share of available CPU and RAM.
+func (node *nodeHandler) getFreeResources() float64 {
+	var (
+		free  float64
+		count int
+	)
+
+	if node.nodeInfo.MaxDMIPs > 0 {
+		free += math.Min(float64(node.availableCPU)/float64(node.nodeInfo.MaxDMIPs), 1)
+		count++
+	}
+
+	if node.nodeInfo.TotalRAM > 0 {
+		free += math.Min(float64(node.availableRAM)/float64(node.nodeInfo.TotalRAM), 1)
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return free / float64(count)
+}
+
+// getDeviceHeadroom returns average share of device allocations left after the devices are allocated. Not shared
+// devices have full headroom.
+func (node *nodeHandler) getDeviceHeadroom(serviceDevices []aostypes.ServiceDevice) float64 {
+	if len(serviceDevices) == 0 {
+		return 1
+	}
+
+	var headroom float64
+
+	for _, serviceDevice := range serviceDevices {
+		index := slices.IndexFunc(node.nodeConfig.Devices, func(device cloudprotocol.DeviceInfo) bool {
+			return device.Name == serviceDevice.Name
+		})
+		if index < 0 || node.nodeConfig.Devices[index].SharedCount == 0 {
+			headroom++
+
+			continue
+		}
+
+		left := node.deviceAllocations[serviceDevice.Name] - 1
+		if left > 0 {
+			headroom += float64(left) / float64(node.nodeConfig.Devices[index].SharedCount)
+		}
+	}
+
+	return headroom / float64(len(serviceDevices))
+}
+
+// getLabelPreference returns share of preferred labels the node has.
+func (node *nodeHandler) getLabelPreference(preferredLabels []string) float64 {
+	if len(preferredLabels) == 0 {
+		return 0
+	}
+
+	var count int
+
+	for _, label := range preferredLabels {
+		if slices.Contains(node.nodeConfig.Labels, label) {
+			count++
+		}
+	}
+
+	return float64(count) / float64(len(preferredLabels))
+}
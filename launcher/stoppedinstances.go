@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// InstanceStateStopped instance is stopped by CM, e.g. on quota enforcement.
+const InstanceStateStopped = "stopped"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type stoppedInstance struct {
+	reason string
+	timer  *time.Timer
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// StopInstance stops instance for the duration. Instance with zero duration is stopped till next desired instances
+// are received from the cloud. Stopped instance is reported with stopped status and error info containing the reason.
+func (launcher *Launcher) StopInstance(ident aostypes.InstanceIdent, reason string, duration time.Duration) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	log.WithFields(instanceIdentLogFields(ident, log.Fields{
+		"reason": reason, "duration": duration,
+	})).Warn("Stop instance")
+
+	if launcher.findInstanceNode(ident) == nil {
+		return aoserrors.Errorf("instance not running: %v", ident)
+	}
+
+	if stopped, ok := launcher.stoppedInstances[ident]; ok && stopped.timer != nil {
+		stopped.timer.Stop()
+	}
+
+	stopped := stoppedInstance{reason: reason}
+
+	if duration > 0 {
+		stopped.timer = time.AfterFunc(duration, func() { launcher.resumeInstance(ident) })
+	}
+
+	if launcher.stoppedInstances == nil {
+		launcher.stoppedInstances = make(map[aostypes.InstanceIdent]stoppedInstance)
+	}
+
+	launcher.stoppedInstances[ident] = stopped
+
+	return launcher.rescheduleInstances()
+}
+
+// RestartInstance restarts instance. SM supports restart of all node instances only.
+func (launcher *Launcher) RestartInstance(ident aostypes.InstanceIdent) error {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	node := launcher.findInstanceNode(ident)
+	if node == nil {
+		return aoserrors.Errorf("instance not running: %v", ident)
+	}
+
+	if node.waitStatus || node.pendingRun {
+		return aoserrors.Errorf("node %s is busy", node.nodeInfo.NodeID)
+	}
+
+	log.WithFields(instanceIdentLogFields(ident, log.Fields{"nodeID": node.nodeInfo.NodeID})).Warn("Restart instance")
+
+	node.waitStatus = true
+
+	if err := launcher.sendNodeRunInstances(node, true); err != nil {
+		node.waitStatus = false
+
+		return err
+	}
+
+	launcher.connectionTimer.Reset(launcher.config.SMController.NodesConnectionTimeout.Duration)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (launcher *Launcher) resumeInstance(ident aostypes.InstanceIdent) {
+	launcher.Lock()
+	defer launcher.Unlock()
+
+	if _, ok := launcher.stoppedInstances[ident]; !ok {
+		return
+	}
+
+	log.WithFields(instanceIdentLogFields(ident, nil)).Info("Resume stopped instance")
+
+	delete(launcher.stoppedInstances, ident)
+
+	if err := launcher.rescheduleInstances(); err != nil {
+		log.Errorf("Can't run instances: %v", err)
+	}
+}
+
+// resetStoppedInstances resumes all stopped instances on new desired instances.
+func (launcher *Launcher) resetStoppedInstances() {
+	for _, stopped := range launcher.stoppedInstances {
+		if stopped.timer != nil {
+			stopped.timer.Stop()
+		}
+	}
+
+	launcher.stoppedInstances = nil
+}
+
+// isInstanceStopped returns true and sets stopped status if instance is stopped.
+func (launcher *Launcher) isInstanceStopped(ident aostypes.InstanceIdent, serviceVersion string) bool {
+	stopped, ok := launcher.stoppedInstances[ident]
+	if !ok {
+		return false
+	}
+
+	launcher.instanceManager.setInstanceStopped(ident, serviceVersion, stopped.reason)
+
+	return true
+}
+
+// findInstanceNode returns node which runs the instance.
+func (launcher *Launcher) findInstanceNode(ident aostypes.InstanceIdent) *nodeHandler {
+	for _, node := range launcher.nodes {
+		if slices.ContainsFunc(node.runRequest.Instances, func(instance aostypes.InstanceInfo) bool {
+			return instance.InstanceIdent == ident
+		}) {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func (im *instanceManager) setInstanceStopped(ident aostypes.InstanceIdent, serviceVersion, reason string) {
+	im.errorStatus[ident] = cloudprotocol.InstanceStatus{
+		InstanceIdent:  ident,
+		ServiceVersion: serviceVersion,
+		Status:         InstanceStateStopped,
+		ErrorInfo:      &cloudprotocol.ErrorInfo{Message: reason},
+	}
+}
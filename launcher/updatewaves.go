@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launcher
+
+import (
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getUpdateWaves splits nodes into waves. Nodes are updated by rollout groups in configured order, nodes of each
+// rollout stage are split into waves by max unavailable limits.
+func (launcher *Launcher) getUpdateWaves(nodes []*nodeHandler) (waves [][]*nodeHandler) {
+	for _, stage := range launcher.getRolloutStages(nodes) {
+		waves = append(waves, launcher.splitMaxUnavailable(stage)...)
+	}
+
+	if len(waves) > 1 {
+		log.WithField("waves", len(waves)).Info("Update instances in waves")
+	}
+
+	return waves
+}
+
+// getRolloutStages splits nodes by rollout groups. Node included in few groups is updated with the first one, nodes
+// not included in any rollout group are updated last.
+func (launcher *Launcher) getRolloutStages(nodes []*nodeHandler) (stages [][]*nodeHandler) {
+	if len(launcher.config.Balancing.RolloutGroups) == 0 {
+		return [][]*nodeHandler{nodes}
+	}
+
+	for _, group := range launcher.config.Balancing.RolloutGroups {
+		var stage, rest []*nodeHandler
+
+		for _, node := range nodes {
+			if _, ok := node.groups[group]; ok {
+				stage = append(stage, node)
+			} else {
+				rest = append(rest, node)
+			}
+		}
+
+		if len(stage) != 0 {
+			stages = append(stages, stage)
+		}
+
+		nodes = rest
+	}
+
+	if len(nodes) != 0 {
+		stages = append(stages, nodes)
+	}
+
+	return stages
+}
+
+// splitMaxUnavailable splits nodes into waves, so number of instances of each service with max unavailable limit
+// which are replaced by new service version in a wave doesn't exceed the limit. Node is not split between waves, as
+// node runs single version of a service.
+func (launcher *Launcher) splitMaxUnavailable(nodes []*nodeHandler) (waves [][]*nodeHandler) {
+	if len(launcher.config.Balancing.MaxUnavailable) == 0 {
+		return [][]*nodeHandler{nodes}
+	}
+
+	for len(nodes) != 0 {
+		var (
+			wave, deferred []*nodeHandler
+			unavailable    = make(map[string]int)
+		)
+
+		for _, node := range nodes {
+			replaced := launcher.getReplacedInstances(node)
+
+			if len(wave) != 0 && exceedsMaxUnavailable(launcher.config.Balancing, unavailable, replaced) {
+				deferred = append(deferred, node)
+
+				continue
+			}
+
+			if len(wave) == 0 && exceedsMaxUnavailable(launcher.config.Balancing, unavailable, replaced) {
+				log.WithField("nodeID", node.nodeInfo.NodeID).Warn("Node replaces more instances than allowed")
+			}
+
+			for serviceID, count := range replaced {
+				unavailable[serviceID] += count
+			}
+
+			wave = append(wave, node)
+		}
+
+		waves = append(waves, wave)
+		nodes = deferred
+	}
+
+	return waves
+}
+
+// getReplacedInstances returns number of active instances of services with max unavailable limit by service ID,
+// which are restarted on the node with another service version.
+func (launcher *Launcher) getReplacedInstances(node *nodeHandler) (replaced map[string]int) {
+	replaced = make(map[string]int)
+
+	instances := make(map[aostypes.InstanceIdent]struct{}, len(node.runRequest.Instances))
+
+	for _, instance := range node.runRequest.Instances {
+		instances[instance.InstanceIdent] = struct{}{}
+	}
+
+	serviceVersions := make(map[string]string, len(node.runRequest.Services))
+
+	for _, service := range node.runRequest.Services {
+		serviceVersions[service.ServiceID] = service.Version
+	}
+
+	for _, status := range launcher.nodeStatuses[node.nodeInfo.NodeID] {
+		if _, ok := launcher.config.Balancing.MaxUnavailable[status.ServiceID]; !ok ||
+			status.Status != cloudprotocol.InstanceStateActive {
+			continue
+		}
+
+		if _, ok := instances[status.InstanceIdent]; !ok {
+			continue
+		}
+
+		if version, ok := serviceVersions[status.ServiceID]; ok && version != status.ServiceVersion {
+			replaced[status.ServiceID]++
+		}
+	}
+
+	return replaced
+}
+
+func exceedsMaxUnavailable(balancing config.Balancing, unavailable, replaced map[string]int) bool {
+	for serviceID, count := range replaced {
+		if unavailable[serviceID]+count > balancing.MaxUnavailable[serviceID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendUpdateWaves sends run request to nodes of next wave when nodes of current wave report run status.
+func (launcher *Launcher) sendUpdateWaves() (err error) {
+	for len(launcher.updateWaves) != 0 && !launcher.isWaveRunning() {
+		launcher.currentWave = launcher.updateWaves[0]
+		launcher.updateWaves = launcher.updateWaves[1:]
+
+		for _, node := range launcher.currentWave {
+			node.waitStatus = true
+
+			if runErr := launcher.sendNodeRunInstances(node, launcher.forceRestart); runErr != nil {
+				log.WithField("nodeID", node.nodeInfo.NodeID).Errorf("Can't run instances: %v", runErr)
+
+				// Instances of unreachable node are pending till the node returns
+				if launcher.config.SMController.UnreachableNodePolicy == config.UnreachableNodeProceed {
+					log.WithField("nodeID", node.nodeInfo.NodeID).Warn("Proceed without unreachable node")
+
+					node.waitStatus = false
+					node.pendingRun = true
+
+					continue
+				}
+
+				if err == nil {
+					err = runErr
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+func (launcher *Launcher) isWaveRunning() bool {
+	return slices.ContainsFunc(launcher.currentWave, func(node *nodeHandler) bool {
+		return node.waitStatus
+	})
+}
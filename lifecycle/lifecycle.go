@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Instance lifecycle events.
+const (
+	EventScheduled  = "scheduled"
+	EventSentToNode = "sentToNode"
+	EventStarted    = "started"
+	EventHealthy    = "healthy"
+	EventFailed     = "failed"
+	EventEvicted    = "evicted"
+	EventMigrated   = "migrated"
+)
+
+const exportBatchSize = 100
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Event stored instance lifecycle event.
+type Event struct {
+	ID uint64 `json:"id"`
+	amqp.InstanceLifecycleEvent
+}
+
+// Storage lifecycle events storage.
+type Storage interface {
+	AddLifecycleEvent(event Event) error
+	GetNotExportedLifecycleEvents(limit int) ([]Event, error)
+	SetLifecycleEventsExported(lastID uint64) error
+}
+
+// Sender sends lifecycle events to the cloud.
+type Sender interface {
+	SendInstanceLifecycle(lifecycle amqp.InstanceLifecycle) error
+	SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+	UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+}
+
+// Lifecycle tracks instances and records their lifecycle events. Events are stored and sent to the cloud in batches.
+type Lifecycle struct {
+	sync.Mutex
+
+	config        config.InstanceLifecycle
+	storage       Storage
+	sender        Sender
+	instances     map[aostypes.InstanceIdent]instanceState
+	isConnected   bool
+	exportChannel chan struct{}
+	cancelFunc    context.CancelFunc
+}
+
+type instanceState struct {
+	nodeID    string
+	status    string
+	scheduled bool
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates lifecycle instance.
+func New(cfg *config.Config, storage Storage, sender Sender) (lifecycle *Lifecycle, err error) {
+	log.Debug("Create instance lifecycle")
+
+	lifecycle = &Lifecycle{
+		config:        cfg.InstanceLifecycle,
+		storage:       storage,
+		sender:        sender,
+		instances:     make(map[aostypes.InstanceIdent]instanceState),
+		exportChannel: make(chan struct{}, 1),
+	}
+
+	if err = sender.SubscribeForConnectionEvents(lifecycle); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	lifecycle.cancelFunc = cancelFunc
+
+	go lifecycle.handleExport(ctx)
+
+	return lifecycle, nil
+}
+
+// Close closes lifecycle instance.
+func (lifecycle *Lifecycle) Close() {
+	log.Debug("Close instance lifecycle")
+
+	if err := lifecycle.sender.UnsubscribeFromConnectionEvents(lifecycle); err != nil {
+		log.Errorf("Can't unsubscribe from connection events: %v", err)
+	}
+
+	lifecycle.cancelFunc()
+}
+
+// InstanceScheduled records instance scheduled on node. Instance moved from another node is recorded as migrated.
+// Instance kept on the same node is not recorded.
+func (lifecycle *Lifecycle) InstanceScheduled(instance aostypes.InstanceIdent, nodeID string) {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	state, ok := lifecycle.instances[instance]
+	if ok && state.nodeID == nodeID && state.status != cloudprotocol.InstanceStateFailed {
+		return
+	}
+
+	if ok && state.nodeID != "" && state.nodeID != nodeID {
+		lifecycle.addEvent(amqp.InstanceLifecycleEvent{
+			InstanceIdent: instance, Event: EventMigrated, NodeID: nodeID, PrevNodeID: state.nodeID,
+		})
+	}
+
+	lifecycle.addEvent(amqp.InstanceLifecycleEvent{InstanceIdent: instance, Event: EventScheduled, NodeID: nodeID})
+
+	lifecycle.instances[instance] = instanceState{nodeID: nodeID, scheduled: true}
+}
+
+// InstanceSentToNode records run request of scheduled instance sent to node.
+func (lifecycle *Lifecycle) InstanceSentToNode(instance aostypes.InstanceIdent, nodeID string) {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	state, ok := lifecycle.instances[instance]
+	if !ok || !state.scheduled {
+		return
+	}
+
+	lifecycle.addEvent(amqp.InstanceLifecycleEvent{InstanceIdent: instance, Event: EventSentToNode, NodeID: nodeID})
+
+	state.scheduled = false
+	lifecycle.instances[instance] = state
+}
+
+// ProcessRunStatus processes run status of all instances. Previously running instances missing in the run status are
+// recorded as evicted.
+func (lifecycle *Lifecycle) ProcessRunStatus(instances []cloudprotocol.InstanceStatus) {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	reported := make(map[aostypes.InstanceIdent]struct{})
+
+	for _, instance := range instances {
+		reported[instance.InstanceIdent] = struct{}{}
+
+		lifecycle.processStatus(instance)
+	}
+
+	for instance, state := range lifecycle.instances {
+		if _, ok := reported[instance]; ok {
+			continue
+		}
+
+		if state.nodeID != "" {
+			lifecycle.addEvent(amqp.InstanceLifecycleEvent{
+				InstanceIdent: instance, Event: EventEvicted, NodeID: state.nodeID,
+			})
+		}
+
+		delete(lifecycle.instances, instance)
+	}
+}
+
+// ProcessUpdateInstanceStatus processes instances status changes.
+func (lifecycle *Lifecycle) ProcessUpdateInstanceStatus(instances []cloudprotocol.InstanceStatus) {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	for _, instance := range instances {
+		lifecycle.processStatus(instance)
+	}
+}
+
+// CloudConnected indicates connection to the cloud is established.
+func (lifecycle *Lifecycle) CloudConnected() {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	lifecycle.isConnected = true
+
+	select {
+	case lifecycle.exportChannel <- struct{}{}:
+
+	default:
+	}
+}
+
+// CloudDisconnected indicates connection to the cloud is lost.
+func (lifecycle *Lifecycle) CloudDisconnected() {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	lifecycle.isConnected = false
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (lifecycle *Lifecycle) processStatus(instance cloudprotocol.InstanceStatus) {
+	state := lifecycle.instances[instance.InstanceIdent]
+
+	// Instance failed to be scheduled is evicted from its previous node
+	if instance.NodeID == "" && instance.Status == cloudprotocol.InstanceStateFailed {
+		if state.nodeID != "" {
+			lifecycle.addEvent(amqp.InstanceLifecycleEvent{
+				InstanceIdent: instance.InstanceIdent, Event: EventEvicted, NodeID: state.nodeID,
+				Error: getErrorMessage(instance.ErrorInfo),
+			})
+
+			state.nodeID = ""
+		} else if state.status != cloudprotocol.InstanceStateFailed {
+			lifecycle.addEvent(amqp.InstanceLifecycleEvent{
+				InstanceIdent: instance.InstanceIdent, Event: EventFailed, Error: getErrorMessage(instance.ErrorInfo),
+			})
+		}
+
+		state.status = instance.Status
+		lifecycle.instances[instance.InstanceIdent] = state
+
+		return
+	}
+
+	if instance.NodeID != state.nodeID || instance.Status != state.status {
+		event := amqp.InstanceLifecycleEvent{InstanceIdent: instance.InstanceIdent, NodeID: instance.NodeID}
+
+		switch instance.Status {
+		case cloudprotocol.InstanceStateActivating:
+			event.Event = EventStarted
+			lifecycle.addEvent(event)
+
+		case cloudprotocol.InstanceStateActive:
+			if state.status != cloudprotocol.InstanceStateActivating || instance.NodeID != state.nodeID {
+				event.Event = EventStarted
+				lifecycle.addEvent(event)
+			}
+
+			event.Event = EventHealthy
+			lifecycle.addEvent(event)
+
+		case cloudprotocol.InstanceStateFailed:
+			event.Event = EventFailed
+			event.Error = getErrorMessage(instance.ErrorInfo)
+			lifecycle.addEvent(event)
+		}
+	}
+
+	state.nodeID = instance.NodeID
+	state.status = instance.Status
+	lifecycle.instances[instance.InstanceIdent] = state
+}
+
+func (lifecycle *Lifecycle) addEvent(event amqp.InstanceLifecycleEvent) {
+	event.Timestamp = time.Now().UTC()
+
+	log.WithFields(log.Fields{
+		"serviceID": event.ServiceID, "subjectID": event.SubjectID, "instance": event.Instance, "event": event.Event,
+		"nodeID": event.NodeID, "prevNodeID": event.PrevNodeID, "error": event.Error,
+	}).Debug("Instance lifecycle event")
+
+	if err := lifecycle.storage.AddLifecycleEvent(Event{InstanceLifecycleEvent: event}); err != nil {
+		log.Errorf("Can't store lifecycle event: %v", err)
+	}
+}
+
+func (lifecycle *Lifecycle) handleExport(ctx context.Context) {
+	ticker := time.NewTicker(lifecycle.config.SendPeriod.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lifecycle.Lock()
+			isConnected := lifecycle.isConnected
+			lifecycle.Unlock()
+
+			if !isConnected {
+				continue
+			}
+
+			if err := lifecycle.export(); err != nil {
+				log.Errorf("Can't export lifecycle events: %v", err)
+			}
+
+		case <-lifecycle.exportChannel:
+			if err := lifecycle.export(); err != nil {
+				log.Errorf("Can't export lifecycle events: %v", err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (lifecycle *Lifecycle) export() error {
+	for {
+		events, err := lifecycle.storage.GetNotExportedLifecycleEvents(exportBatchSize)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if len(events) == 0 {
+			break
+		}
+
+		cloudEvents := make([]amqp.InstanceLifecycleEvent, len(events))
+
+		for i, event := range events {
+			cloudEvents[i] = event.InstanceLifecycleEvent
+		}
+
+		if err = lifecycle.sender.SendInstanceLifecycle(amqp.InstanceLifecycle{Events: cloudEvents}); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = lifecycle.storage.SetLifecycleEventsExported(events[len(events)-1].ID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if len(events) < exportBatchSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+func getErrorMessage(errorInfo *cloudprotocol.ErrorInfo) string {
+	if errorInfo == nil {
+		return ""
+	}
+
+	return errorInfo.Message
+}
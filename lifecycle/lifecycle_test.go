@@ -0,0 +1,346 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle_test
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/lifecycle"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	sync.Mutex
+
+	events     []lifecycle.Event
+	exportedID uint64
+	checkedID  uint64
+}
+
+type testSender struct {
+	lifecycles chan amqp.InstanceLifecycle
+}
+
+type testEvent struct {
+	event      string
+	nodeID     string
+	prevNodeID string
+	err        string
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestInstanceEvents(t *testing.T) {
+	storage := &testStorage{}
+	sender := &testSender{lifecycles: make(chan amqp.InstanceLifecycle, 1)}
+
+	instanceLifecycle, err := lifecycle.New(newTestConfig(time.Hour), storage, sender)
+	if err != nil {
+		t.Fatalf("Can't create lifecycle: %v", err)
+	}
+	defer instanceLifecycle.Close()
+
+	instance1 := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1", Instance: 0}
+
+	// Start instance
+
+	instanceLifecycle.InstanceScheduled(instance1, "node1")
+	instanceLifecycle.InstanceSentToNode(instance1, "node1")
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance1, NodeID: "node1", Status: cloudprotocol.InstanceStateActivating},
+		{
+			InstanceIdent: instance2, Status: cloudprotocol.InstanceStateFailed,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "no nodes with devices"},
+		},
+	})
+	instanceLifecycle.ProcessUpdateInstanceStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance1, NodeID: "node1", Status: cloudprotocol.InstanceStateActive},
+	})
+
+	checkEvents(t, storage, map[aostypes.InstanceIdent][]testEvent{
+		instance1: {
+			{event: lifecycle.EventScheduled, nodeID: "node1"},
+			{event: lifecycle.EventSentToNode, nodeID: "node1"},
+			{event: lifecycle.EventStarted, nodeID: "node1"},
+			{event: lifecycle.EventHealthy, nodeID: "node1"},
+		},
+		instance2: {{event: lifecycle.EventFailed, err: "no nodes with devices"}},
+	})
+
+	// Instance kept on the same node is not rescheduled
+
+	instanceLifecycle.InstanceScheduled(instance1, "node1")
+	instanceLifecycle.InstanceSentToNode(instance1, "node1")
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance1, NodeID: "node1", Status: cloudprotocol.InstanceStateActive},
+		{
+			InstanceIdent: instance2, Status: cloudprotocol.InstanceStateFailed,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "no nodes with devices"},
+		},
+	})
+
+	checkEvents(t, storage, nil)
+
+	// Migrate instance, then fail on the new node
+
+	instanceLifecycle.InstanceScheduled(instance1, "node2")
+	instanceLifecycle.InstanceSentToNode(instance1, "node2")
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance1, NodeID: "node2", Status: cloudprotocol.InstanceStateActive},
+	})
+	instanceLifecycle.ProcessUpdateInstanceStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent: instance1, NodeID: "node2", Status: cloudprotocol.InstanceStateFailed,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "exit code 1"},
+		},
+	})
+
+	checkEvents(t, storage, map[aostypes.InstanceIdent][]testEvent{
+		instance1: {
+			{event: lifecycle.EventMigrated, nodeID: "node2", prevNodeID: "node1"},
+			{event: lifecycle.EventScheduled, nodeID: "node2"},
+			{event: lifecycle.EventSentToNode, nodeID: "node2"},
+			{event: lifecycle.EventStarted, nodeID: "node2"},
+			{event: lifecycle.EventHealthy, nodeID: "node2"},
+			{event: lifecycle.EventFailed, nodeID: "node2", err: "exit code 1"},
+		},
+	})
+
+	// Evict instance which can't be scheduled anymore
+
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent: instance1, Status: cloudprotocol.InstanceStateFailed,
+			ErrorInfo: &cloudprotocol.ErrorInfo{Message: "no nodes with devices"},
+		},
+	})
+
+	checkEvents(t, storage, map[aostypes.InstanceIdent][]testEvent{
+		instance1: {{event: lifecycle.EventEvicted, nodeID: "node2", err: "no nodes with devices"}},
+	})
+
+	// Evict removed instance
+
+	instanceLifecycle.InstanceScheduled(instance2, "node1")
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance2, NodeID: "node1", Status: cloudprotocol.InstanceStateActive},
+	})
+	instanceLifecycle.ProcessRunStatus(nil)
+
+	checkEvents(t, storage, map[aostypes.InstanceIdent][]testEvent{
+		instance2: {
+			{event: lifecycle.EventScheduled, nodeID: "node1"},
+			{event: lifecycle.EventStarted, nodeID: "node1"},
+			{event: lifecycle.EventHealthy, nodeID: "node1"},
+			{event: lifecycle.EventEvicted, nodeID: "node1"},
+		},
+	})
+}
+
+func TestExport(t *testing.T) {
+	storage := &testStorage{}
+	sender := &testSender{lifecycles: make(chan amqp.InstanceLifecycle, 1)}
+
+	instanceLifecycle, err := lifecycle.New(newTestConfig(500*time.Millisecond), storage, sender)
+	if err != nil {
+		t.Fatalf("Can't create lifecycle: %v", err)
+	}
+	defer instanceLifecycle.Close()
+
+	instance := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+
+	instanceLifecycle.InstanceScheduled(instance, "node1")
+	instanceLifecycle.InstanceSentToNode(instance, "node1")
+
+	select {
+	case <-sender.lifecycles:
+		t.Fatal("Lifecycle events should not be sent before connection")
+
+	case <-time.After(time.Second):
+	}
+
+	instanceLifecycle.CloudConnected()
+
+	if events := receiveEvents(t, sender); len(events) != 2 ||
+		events[0].Event != lifecycle.EventScheduled || events[1].Event != lifecycle.EventSentToNode {
+		t.Errorf("Wrong exported events: %v", events)
+	}
+
+	// Events are sent in batches with send period
+
+	instanceLifecycle.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{InstanceIdent: instance, NodeID: "node1", Status: cloudprotocol.InstanceStateActive},
+	})
+
+	if events := receiveEvents(t, sender); len(events) != 2 ||
+		events[0].Event != lifecycle.EventStarted || events[1].Event != lifecycle.EventHealthy {
+		t.Errorf("Wrong exported events: %v", events)
+	}
+
+	// Exported events are kept in storage, they are removed by retention policy
+
+	storage.Lock()
+	defer storage.Unlock()
+
+	if len(storage.events) != 4 || storage.exportedID != 4 {
+		t.Errorf("Wrong stored events: count %d, exported ID %d", len(storage.events), storage.exportedID)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (storage *testStorage) AddLifecycleEvent(event lifecycle.Event) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	event.ID = uint64(len(storage.events)) + 1
+
+	if len(storage.events) > 0 {
+		event.ID = storage.events[len(storage.events)-1].ID + 1
+	}
+
+	storage.events = append(storage.events, event)
+
+	return nil
+}
+
+func (storage *testStorage) GetNotExportedLifecycleEvents(limit int) (events []lifecycle.Event, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, event := range storage.events {
+		if event.ID <= storage.exportedID {
+			continue
+		}
+
+		if len(events) == limit {
+			break
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (storage *testStorage) SetLifecycleEventsExported(lastID uint64) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.exportedID = lastID
+
+	return nil
+}
+
+func (sender *testSender) SendInstanceLifecycle(lifecycle amqp.InstanceLifecycle) error {
+	sender.lifecycles <- lifecycle
+
+	return nil
+}
+
+func (sender *testSender) SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+func (sender *testSender) UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestConfig(sendPeriod time.Duration) *config.Config {
+	return &config.Config{
+		InstanceLifecycle: config.InstanceLifecycle{
+			SendPeriod: aostypes.Duration{Duration: sendPeriod},
+		},
+	}
+}
+
+// checkEvents checks events of instances added since previous check.
+func checkEvents(t *testing.T, storage *testStorage, expectedEvents map[aostypes.InstanceIdent][]testEvent) {
+	t.Helper()
+
+	storage.Lock()
+	defer storage.Unlock()
+
+	events := make(map[aostypes.InstanceIdent][]testEvent)
+
+	for _, event := range storage.events {
+		if event.ID <= storage.checkedID {
+			continue
+		}
+
+		events[event.InstanceIdent] = append(events[event.InstanceIdent], testEvent{
+			event: event.Event, nodeID: event.NodeID, prevNodeID: event.PrevNodeID, err: event.Error,
+		})
+
+		storage.checkedID = event.ID
+	}
+
+	if len(events) != len(expectedEvents) || (len(events) != 0 && !reflect.DeepEqual(events, expectedEvents)) {
+		t.Errorf("Wrong instance events: %v", events)
+	}
+}
+
+func receiveEvents(t *testing.T, sender *testSender) []amqp.InstanceLifecycleEvent {
+	t.Helper()
+
+	select {
+	case instanceLifecycle := <-sender.lifecycles:
+		return instanceLifecycle.Events
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait lifecycle events timeout")
+	}
+
+	return nil
+}
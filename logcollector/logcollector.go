@@ -0,0 +1,890 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logcollector
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	// maxSendQueueLength log parts are sent only while AMQP send queue is shorter, so big logs don't block other
+	// messages and don't overflow the queue.
+	maxSendQueueLength = 8
+	sendRetryPeriod    = 1 * time.Second
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// LogProvider requests logs from nodes and provides received log parts.
+type LogProvider interface {
+	GetLog(logRequest cloudprotocol.RequestLog) error
+	GetLogChannel() <-chan cloudprotocol.PushLog
+}
+
+// NodeInfoProvider provides unit nodes.
+type NodeInfoProvider interface {
+	GetAllNodeIDs() ([]string, error)
+}
+
+// Sender sends logs to the cloud.
+type Sender interface {
+	SendLog(pushLog amqp.PushLog) error
+	GetSendQueueLength() int
+	SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+	UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+}
+
+// BandwidthBudget bandwidth budget of metered connection.
+type BandwidthBudget interface {
+	IsDeferred() bool
+	AddUsage(category string, size uint64)
+}
+
+// Collector collects filtered logs from unit nodes and uploads them to the cloud in compressed parts.
+type Collector struct {
+	sync.Mutex
+
+	config           config.LogCollector
+	logProvider      LogProvider
+	nodeInfoProvider NodeInfoProvider
+	sender           Sender
+	bandwidthBudget  BandwidthBudget
+	compression      string
+	encoder          *zstd.Encoder
+	requests         map[string]*logRequest
+	uploads          []*logUpload
+	sentUploads      map[uploadKey]*logUpload
+	sentSize         uint64
+	isConnected      bool
+	sendChannel      chan struct{}
+	cancelFunc       context.CancelFunc
+	wg               sync.WaitGroup
+}
+
+type logRequest struct {
+	logID    string
+	pattern  *regexp.Regexp
+	severity int
+	maxSize  uint64
+	nodes    map[string]*nodeLog
+	timer    *time.Timer
+}
+
+type nodeLog struct {
+	parts      map[uint64][]byte
+	partsCount uint64
+	done       bool
+}
+
+type uploadKey struct {
+	logID  string
+	nodeID string
+}
+
+type logUpload struct {
+	nodeID    string
+	logID     string
+	parts     [][]byte
+	pending   []int
+	status    string
+	errorInfo *cloudprotocol.ErrorInfo
+	truncated bool
+	checksum  string
+	expiresAt time.Time
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var severityRanks = map[string]int{
+	amqp.LogSeverityDebug:    0,
+	amqp.LogSeverityInfo:     1,
+	amqp.LogSeverityNotice:   2,
+	amqp.LogSeverityWarning:  3,
+	"warn":                   3,
+	amqp.LogSeverityError:    4,
+	"err":                    4,
+	amqp.LogSeverityCritical: 5,
+	"crit":                   5,
+	"fatal":                  5,
+	amqp.LogSeverityAlert:    6,
+	"panic":                  6,
+	amqp.LogSeverityEmerg:    7,
+	"emerg":                  7,
+}
+
+// severityRegexp matches line severity in "level=error", "[ERROR]" or syslog "<3>" forms.
+var severityRegexp = regexp.MustCompile( //nolint:gochecknoglobals
+	`(?i)(?:level=|\[)(debug|info|notice|warning|warn|error|err|critical|crit|fatal|alert|panic|emergency|emerg)\b` +
+		`|^<([0-7])>`)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates log collector.
+func New(
+	cfg *config.Config, logProvider LogProvider, nodeInfoProvider NodeInfoProvider, sender Sender,
+) (collector *Collector, err error) {
+	log.Debug("Create log collector")
+
+	collector = &Collector{
+		config:           cfg.LogCollector,
+		logProvider:      logProvider,
+		nodeInfoProvider: nodeInfoProvider,
+		sender:           sender,
+		requests:         make(map[string]*logRequest),
+		sentUploads:      make(map[uploadKey]*logUpload),
+		sendChannel:      make(chan struct{}, 1),
+	}
+
+	switch cfg.LogCollector.Compression {
+	case config.LogCompressionNone:
+
+	case config.LogCompressionZstd:
+		collector.compression = amqp.LogCompressionZstd
+
+		if collector.encoder, err = zstd.NewWriter(nil); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+	default:
+		collector.compression = amqp.LogCompressionGzip
+	}
+
+	if err = sender.SubscribeForConnectionEvents(collector); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	collector.cancelFunc = cancelFunc
+
+	collector.wg.Add(2) //nolint:mnd
+
+	go collector.handleLogs(ctx)
+	go collector.handleSend(ctx)
+
+	return collector, nil
+}
+
+// Close closes log collector.
+func (collector *Collector) Close() {
+	log.Debug("Close log collector")
+
+	if err := collector.sender.UnsubscribeFromConnectionEvents(collector); err != nil {
+		log.Errorf("Can't unsubscribe from connection events: %v", err)
+	}
+
+	collector.cancelFunc()
+	collector.wg.Wait()
+
+	collector.Lock()
+	defer collector.Unlock()
+
+	for _, request := range collector.requests {
+		request.timer.Stop()
+	}
+
+	if collector.encoder != nil {
+		collector.encoder.Close()
+	}
+}
+
+// SetBandwidthBudget sets bandwidth budget. Log parts are not sent while the budget defers non-critical traffic.
+func (collector *Collector) SetBandwidthBudget(budget BandwidthBudget) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	collector.bandwidthBudget = budget
+}
+
+// ProcessLogRequest requests log from nodes. Log of each node is filtered and uploaded when it is fully received.
+func (collector *Collector) ProcessLogRequest(request amqp.RequestLog) error {
+	collector.Lock()
+	defer collector.Unlock()
+
+	log.WithFields(log.Fields{
+		"logID":    request.LogID,
+		"logType":  request.LogType,
+		"pattern":  request.Filter.Pattern,
+		"severity": request.Filter.Severity,
+		"maxSize":  request.Filter.MaxSize,
+	}).Debug("Process log request")
+
+	if _, ok := collector.requests[request.LogID]; ok {
+		return aoserrors.Errorf("log %s is already being collected", request.LogID)
+	}
+
+	newRequest, err := collector.newLogRequest(request)
+	if err != nil {
+		collector.addUpload(&logUpload{
+			logID: request.LogID, status: cloudprotocol.LogStatusError,
+			errorInfo: &cloudprotocol.ErrorInfo{Message: err.Error()},
+		})
+
+		return err
+	}
+
+	collector.requests[request.LogID] = newRequest
+
+	nodeIDs := make([]string, 0, len(newRequest.nodes))
+
+	for nodeID := range newRequest.nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	sort.Strings(nodeIDs)
+
+	for _, nodeID := range nodeIDs {
+		nodeRequest := cloudprotocol.RequestLog{
+			MessageType: request.MessageType,
+			LogID:       request.LogID,
+			LogType:     request.LogType,
+			Filter:      request.Filter.LogFilter,
+		}
+
+		nodeRequest.Filter.NodeIDs = []string{nodeID}
+
+		if err := collector.logProvider.GetLog(nodeRequest); err != nil {
+			log.WithFields(log.Fields{"logID": request.LogID, "nodeID": nodeID}).Errorf("Can't get log: %v", err)
+
+			collector.completeNodeLog(newRequest, nodeID, &logUpload{
+				status: cloudprotocol.LogStatusError, errorInfo: &cloudprotocol.ErrorInfo{Message: err.Error()},
+			})
+		}
+	}
+
+	if _, ok := collector.requests[request.LogID]; ok {
+		newRequest.timer = time.AfterFunc(collector.config.CollectTimeout.Duration, func() {
+			collector.collectTimeout(newRequest)
+		})
+	}
+
+	return nil
+}
+
+// ProcessLogPartsRequest resends log parts requested by the cloud. Uploaded logs are kept for retention time while
+// their total size doesn't exceed max retained size.
+func (collector *Collector) ProcessLogPartsRequest(request amqp.LogPartsRequest) error {
+	collector.Lock()
+	defer collector.Unlock()
+
+	log.WithFields(log.Fields{
+		"logID": request.LogID, "nodeID": request.NodeID, "parts": request.Parts,
+	}).Debug("Process log parts request")
+
+	key := uploadKey{logID: request.LogID, nodeID: request.NodeID}
+
+	upload, sent := collector.sentUploads[key]
+	if !sent {
+		if upload = collector.findUpload(key); upload == nil {
+			return aoserrors.Errorf("log %s of node %s is not available", request.LogID, request.NodeID)
+		}
+	}
+
+	parts := make([]int, 0, len(request.Parts))
+
+	for _, part := range request.Parts {
+		if part == 0 || part > uint64(len(upload.parts)) {
+			return aoserrors.Errorf("invalid log part: %d", part)
+		}
+
+		parts = append(parts, int(part-1))
+	}
+
+	if len(parts) == 0 {
+		parts = allParts(len(upload.parts))
+	}
+
+	if !sent {
+		for _, part := range parts {
+			if !slices.Contains(upload.pending, part) {
+				upload.pending = append(upload.pending, part)
+			}
+		}
+
+		return nil
+	}
+
+	collector.removeSentUpload(key)
+
+	upload.pending = parts
+	collector.uploads = append(collector.uploads, upload)
+
+	collector.triggerSend()
+
+	return nil
+}
+
+// CloudConnected indicates connection to the cloud is established.
+func (collector *Collector) CloudConnected() {
+	collector.Lock()
+	defer collector.Unlock()
+
+	collector.isConnected = true
+
+	collector.triggerSend()
+}
+
+// CloudDisconnected indicates connection to the cloud is lost. Upload is resumed from the first not sent part when
+// connection is restored.
+func (collector *Collector) CloudDisconnected() {
+	collector.Lock()
+	defer collector.Unlock()
+
+	collector.isConnected = false
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (collector *Collector) newLogRequest(request amqp.RequestLog) (*logRequest, error) {
+	newRequest := &logRequest{
+		logID:    request.LogID,
+		severity: -1,
+		maxSize:  request.Filter.MaxSize,
+		nodes:    make(map[string]*nodeLog),
+	}
+
+	if newRequest.maxSize == 0 || newRequest.maxSize > collector.config.MaxLogSize {
+		newRequest.maxSize = collector.config.MaxLogSize
+	}
+
+	if request.Filter.Pattern != "" {
+		pattern, err := regexp.Compile(request.Filter.Pattern)
+		if err != nil {
+			return nil, aoserrors.Errorf("invalid log pattern: %v", err)
+		}
+
+		newRequest.pattern = pattern
+	}
+
+	if request.Filter.Severity != "" {
+		severity, ok := severityRanks[strings.ToLower(request.Filter.Severity)]
+		if !ok {
+			return nil, aoserrors.Errorf("invalid log severity: %s", request.Filter.Severity)
+		}
+
+		newRequest.severity = severity
+	}
+
+	nodeIDs := request.Filter.NodeIDs
+
+	if len(nodeIDs) == 0 {
+		var err error
+
+		if nodeIDs, err = collector.nodeInfoProvider.GetAllNodeIDs(); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+	}
+
+	for _, nodeID := range nodeIDs {
+		newRequest.nodes[nodeID] = &nodeLog{parts: make(map[uint64][]byte)}
+	}
+
+	return newRequest, nil
+}
+
+func (collector *Collector) handleLogs(ctx context.Context) {
+	defer collector.wg.Done()
+
+	for {
+		select {
+		case pushLog := <-collector.logProvider.GetLogChannel():
+			collector.processLogPart(pushLog)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (collector *Collector) processLogPart(pushLog cloudprotocol.PushLog) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	logFields := log.Fields{
+		"logID": pushLog.LogID, "nodeID": pushLog.NodeID, "part": pushLog.Part, "partsCount": pushLog.PartsCount,
+	}
+
+	request, ok := collector.requests[pushLog.LogID]
+	if !ok {
+		log.WithFields(logFields).Warn("Skip log part of unknown request")
+
+		return
+	}
+
+	node, ok := request.nodes[pushLog.NodeID]
+	if !ok || node.done {
+		log.WithFields(logFields).Warn("Skip unexpected log part")
+
+		return
+	}
+
+	log.WithFields(logFields).Debug("Receive log part")
+
+	if pushLog.Status != cloudprotocol.LogStatusOk {
+		upload := &logUpload{status: pushLog.Status}
+
+		if pushLog.Status == cloudprotocol.LogStatusError {
+			upload.errorInfo = pushLog.ErrorInfo
+		}
+
+		collector.completeNodeLog(request, pushLog.NodeID, upload)
+
+		return
+	}
+
+	node.parts[pushLog.Part] = pushLog.Content
+	node.partsCount = pushLog.PartsCount
+
+	if uint64(len(node.parts)) < node.partsCount {
+		return
+	}
+
+	content := make([]byte, 0)
+
+	for part := uint64(1); part <= node.partsCount; part++ {
+		content = append(content, node.parts[part]...)
+	}
+
+	collector.completeNodeLog(request, pushLog.NodeID, collector.filterLog(request, content))
+}
+
+func (collector *Collector) filterLog(request *logRequest, content []byte) *logUpload {
+	content, err := decompressLog(content)
+	if err != nil {
+		return &logUpload{status: cloudprotocol.LogStatusError, errorInfo: &cloudprotocol.ErrorInfo{
+			Message: err.Error(),
+		}}
+	}
+
+	filtered, truncated := filterLines(content, request.pattern, request.severity, request.maxSize)
+	if len(filtered) == 0 {
+		return &logUpload{status: cloudprotocol.LogStatusEmpty}
+	}
+
+	compressed, err := collector.compressLog(filtered)
+	if err != nil {
+		return &logUpload{status: cloudprotocol.LogStatusError, errorInfo: &cloudprotocol.ErrorInfo{
+			Message: err.Error(),
+		}}
+	}
+
+	checksum := sha256.Sum256(compressed)
+
+	return &logUpload{
+		status: cloudprotocol.LogStatusOk, parts: splitParts(compressed, collector.config.ChunkSize),
+		truncated: truncated, checksum: hex.EncodeToString(checksum[:]),
+	}
+}
+
+func (collector *Collector) completeNodeLog(request *logRequest, nodeID string, upload *logUpload) {
+	node := request.nodes[nodeID]
+
+	node.done = true
+	node.parts = nil
+
+	upload.nodeID = nodeID
+	upload.logID = request.logID
+
+	log.WithFields(log.Fields{
+		"logID": upload.logID, "nodeID": upload.nodeID, "status": upload.status, "parts": len(upload.parts),
+		"truncated": upload.truncated,
+	}).Debug("Node log collected")
+
+	collector.addUpload(upload)
+
+	for _, node := range request.nodes {
+		if !node.done {
+			return
+		}
+	}
+
+	if request.timer != nil {
+		request.timer.Stop()
+	}
+
+	delete(collector.requests, request.logID)
+}
+
+func (collector *Collector) collectTimeout(request *logRequest) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	if collector.requests[request.logID] != request {
+		return
+	}
+
+	log.WithField("logID", request.logID).Warn("Log collect timeout")
+
+	for nodeID, node := range request.nodes {
+		if !node.done {
+			collector.completeNodeLog(request, nodeID, &logUpload{
+				status: cloudprotocol.LogStatusAbsent, errorInfo: &cloudprotocol.ErrorInfo{Message: "log collect timeout"},
+			})
+		}
+	}
+}
+
+func (collector *Collector) addUpload(upload *logUpload) {
+	upload.pending = allParts(len(upload.parts))
+	collector.uploads = append(collector.uploads, upload)
+
+	collector.triggerSend()
+}
+
+func (collector *Collector) triggerSend() {
+	select {
+	case collector.sendChannel <- struct{}{}:
+
+	default:
+	}
+}
+
+func (collector *Collector) handleSend(ctx context.Context) {
+	defer collector.wg.Done()
+
+	ticker := time.NewTicker(sendRetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-collector.sendChannel:
+			collector.sendParts()
+
+		case <-ticker.C:
+			collector.removeExpiredUploads()
+			collector.sendParts()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (collector *Collector) sendParts() {
+	for {
+		pushLog, ok := collector.getNextPart()
+		if !ok {
+			return
+		}
+
+		// Sender is called without lock as connection events are notified under sender lock.
+		if err := collector.sender.SendLog(pushLog); err != nil {
+			log.WithFields(log.Fields{
+				"logID": pushLog.LogID, "nodeID": pushLog.NodeID, "part": pushLog.Part,
+			}).Warnf("Can't send log part: %v", err)
+
+			return
+		}
+
+		collector.partSent(uint64(len(pushLog.Content)))
+	}
+}
+
+func (collector *Collector) getNextPart() (pushLog amqp.PushLog, ok bool) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	if len(collector.uploads) == 0 || !collector.isConnected ||
+		collector.sender.GetSendQueueLength() >= maxSendQueueLength {
+		return pushLog, false
+	}
+
+	if collector.bandwidthBudget != nil && collector.bandwidthBudget.IsDeferred() {
+		return pushLog, false
+	}
+
+	upload := collector.uploads[0]
+
+	pushLog = amqp.PushLog{PushLog: cloudprotocol.PushLog{
+		NodeID: upload.nodeID, LogID: upload.logID, Status: upload.status, ErrorInfo: upload.errorInfo,
+	}}
+
+	if len(upload.parts) > 0 {
+		part := upload.pending[0]
+		partChecksum := sha256.Sum256(upload.parts[part])
+
+		pushLog.PartsCount = uint64(len(upload.parts))
+		pushLog.Part = uint64(part + 1)
+		pushLog.Content = upload.parts[part]
+		pushLog.Truncated = upload.truncated && part == len(upload.parts)-1
+		pushLog.Compression = collector.compression
+		pushLog.Checksum = upload.checksum
+		pushLog.PartChecksum = hex.EncodeToString(partChecksum[:])
+	}
+
+	return pushLog, true
+}
+
+// partSent removes sent part from the first upload. Only send goroutine removes parts and uploads from the queue.
+func (collector *Collector) partSent(size uint64) {
+	collector.Lock()
+	defer collector.Unlock()
+
+	if collector.bandwidthBudget != nil {
+		collector.bandwidthBudget.AddUsage(bandwidthbudget.CategoryLog, size)
+	}
+
+	upload := collector.uploads[0]
+
+	if len(upload.pending) > 0 {
+		upload.pending = upload.pending[1:]
+	}
+
+	if len(upload.pending) > 0 {
+		return
+	}
+
+	collector.uploads = collector.uploads[1:]
+
+	upload.expiresAt = time.Now().Add(collector.config.RetentionTime.Duration)
+	collector.sentUploads[uploadKey{logID: upload.logID, nodeID: upload.nodeID}] = upload
+	collector.sentSize += upload.size()
+
+	collector.limitSentUploads()
+}
+
+// limitSentUploads drops the oldest sent uploads while their total size exceeds max retained size.
+func (collector *Collector) limitSentUploads() {
+	if collector.config.MaxRetainedSize == 0 {
+		return
+	}
+
+	for collector.sentSize > collector.config.MaxRetainedSize {
+		var (
+			oldestKey    uploadKey
+			oldestUpload *logUpload
+		)
+
+		for key, upload := range collector.sentUploads {
+			if oldestUpload == nil || upload.expiresAt.Before(oldestUpload.expiresAt) {
+				oldestKey, oldestUpload = key, upload
+			}
+		}
+
+		if oldestUpload == nil {
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"logID": oldestKey.logID, "nodeID": oldestKey.nodeID,
+		}).Debug("Drop uploaded log due to max retained size")
+
+		collector.removeSentUpload(oldestKey)
+	}
+}
+
+func (collector *Collector) removeSentUpload(key uploadKey) {
+	upload, ok := collector.sentUploads[key]
+	if !ok {
+		return
+	}
+
+	collector.sentSize -= upload.size()
+
+	delete(collector.sentUploads, key)
+}
+
+func (collector *Collector) findUpload(key uploadKey) *logUpload {
+	for _, upload := range collector.uploads {
+		if upload.logID == key.logID && upload.nodeID == key.nodeID {
+			return upload
+		}
+	}
+
+	return nil
+}
+
+func (collector *Collector) removeExpiredUploads() {
+	collector.Lock()
+	defer collector.Unlock()
+
+	now := time.Now()
+
+	for key, upload := range collector.sentUploads {
+		if now.After(upload.expiresAt) {
+			collector.removeSentUpload(key)
+		}
+	}
+}
+
+// filterLines returns lines matching pattern with severity not lower than requested one. Lines without severity
+// (e.g. multiline messages) inherit severity of the previous line. The result is cut at line boundary to max size.
+func filterLines(
+	content []byte, pattern *regexp.Regexp, minSeverity int, maxSize uint64,
+) (filtered []byte, truncated bool) {
+	var buffer bytes.Buffer
+
+	severity := severityRanks[amqp.LogSeverityInfo]
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		if lineSeverity, ok := getLineSeverity(line); ok {
+			severity = lineSeverity
+		}
+
+		if minSeverity >= 0 && severity < minSeverity {
+			continue
+		}
+
+		if pattern != nil && !pattern.Match(line) {
+			continue
+		}
+
+		if maxSize != 0 && uint64(buffer.Len()+len(line)+1) > maxSize {
+			return buffer.Bytes(), true
+		}
+
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes(), false
+}
+
+func (collector *Collector) compressLog(content []byte) ([]byte, error) {
+	switch collector.compression {
+	case amqp.LogCompressionZstd:
+		return collector.encoder.EncodeAll(content, nil), nil
+
+	case amqp.LogCompressionGzip:
+		var buffer bytes.Buffer
+
+		writer := gzip.NewWriter(&buffer)
+
+		if _, err := writer.Write(content); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		return buffer.Bytes(), nil
+
+	default:
+		return content, nil
+	}
+}
+
+func (upload *logUpload) size() (size uint64) {
+	for _, part := range upload.parts {
+		size += uint64(len(part))
+	}
+
+	return size
+}
+
+func decompressLog(content []byte) ([]byte, error) {
+	if len(content) < 2 || content[0] != 0x1f || content[1] != 0x8b {
+		return content, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if content, err = io.ReadAll(reader); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return content, nil
+}
+
+func getLineSeverity(line []byte) (severity int, ok bool) {
+	match := severityRegexp.FindSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+
+	if len(match[2]) != 0 {
+		priority, err := strconv.Atoi(string(match[2]))
+		if err != nil {
+			return 0, false
+		}
+
+		// syslog priority: 0 - emergency, 7 - debug
+		return severityRanks[amqp.LogSeverityEmerg] - priority, true
+	}
+
+	severity, ok = severityRanks[strings.ToLower(string(match[1]))]
+
+	return severity, ok
+}
+
+func allParts(count int) []int {
+	parts := make([]int, count)
+
+	for i := range parts {
+		parts[i] = i
+	}
+
+	return parts
+}
+
+func splitParts(content []byte, chunkSize uint64) (parts [][]byte) {
+	if chunkSize == 0 {
+		return [][]byte{content}
+	}
+
+	for uint64(len(content)) > chunkSize {
+		parts = append(parts, content[:chunkSize])
+		content = content[chunkSize:]
+	}
+
+	return append(parts, content)
+}
@@ -0,0 +1,794 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logcollector_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/logcollector"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const waitTimeout = 5 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testLogProvider struct {
+	requests   chan cloudprotocol.RequestLog
+	logChannel chan cloudprotocol.PushLog
+}
+
+type testNodeInfoProvider struct {
+	nodeIDs []string
+}
+
+type testSender struct {
+	logs chan amqp.PushLog
+}
+
+type testBandwidthBudget struct {
+	sync.Mutex
+
+	deferred bool
+	usage    map[string]uint64
+}
+
+type receivedLog struct {
+	status        string
+	partsCount    uint64
+	content       []byte
+	truncated     bool
+	receivedParts uint64
+	parts         [][]byte
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestLogCollection(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+		nodeLog     = "2024-01-01 app[1]: level=info msg=started\n" +
+			"2024-01-01 app[1]: level=error msg=\"connection failed\"\n" +
+			"  stack line\n" +
+			"2024-01-01 app[1]: [WARN] connection retry\n" +
+			"<3>kernel: i/o error\n" +
+			"<6>kernel: link up\n"
+	)
+
+	collector, err := logcollector.New(newTestConfig(16), logProvider,
+		&testNodeInfoProvider{nodeIDs: []string{"node0", "node1"}}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.SystemLog,
+		Filter: amqp.LogFilter{Severity: amqp.LogSeverityWarning, Pattern: "error|stack"},
+	}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	for _, nodeID := range []string{"node0", "node1"} {
+		request, err := logProvider.waitRequest()
+		if err != nil {
+			t.Fatalf("Can't wait log request: %v", err)
+		}
+
+		if len(request.Filter.NodeIDs) != 1 || request.Filter.NodeIDs[0] != nodeID {
+			t.Errorf("Unexpected request node IDs: %v", request.Filter.NodeIDs)
+		}
+	}
+
+	content := compress(t, []byte(nodeLog))
+
+	logProvider.sendLog("node0", "log0", content)
+	logProvider.logChannel <- cloudprotocol.PushLog{NodeID: "node1", LogID: "log0", Status: cloudprotocol.LogStatusEmpty}
+
+	logs, err := sender.waitLogs(2)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if logs["node1"].status != cloudprotocol.LogStatusEmpty {
+		t.Errorf("Unexpected node1 log status: %s", logs["node1"].status)
+	}
+
+	if logs["node0"].status != cloudprotocol.LogStatusOk || logs["node0"].partsCount < 2 {
+		t.Fatalf("Unexpected node0 log: status %s, parts %d", logs["node0"].status, logs["node0"].partsCount)
+	}
+
+	expectedLog := "2024-01-01 app[1]: level=error msg=\"connection failed\"\n" +
+		"  stack line\n" +
+		"<3>kernel: i/o error\n"
+
+	if received := string(decompressZstd(t, logs["node0"].content)); received != expectedLog {
+		t.Errorf("Unexpected log content: %s", received)
+	}
+
+	if logs["node0"].truncated {
+		t.Error("Log should not be truncated")
+	}
+}
+
+func TestLogSizeCap(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+	)
+
+	collector, err := logcollector.New(newTestConfig(0), logProvider, &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.ServiceLog,
+		Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}, MaxSize: 12},
+	}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	if _, err := logProvider.waitRequest(); err != nil {
+		t.Fatalf("Can't wait log request: %v", err)
+	}
+
+	logProvider.sendLog("node0", "log0", []byte("line 1\nline 2\nline 3\n"))
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if received := string(decompressZstd(t, logs["node0"].content)); received != "line 1\n" ||
+		!logs["node0"].truncated {
+		t.Errorf("Unexpected log: %s, truncated: %v", received, logs["node0"].truncated)
+	}
+}
+
+func TestLogCollectTimeout(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+		cfg         = newTestConfig(0)
+	)
+
+	cfg.LogCollector.CollectTimeout = aostypes.Duration{Duration: 500 * time.Millisecond}
+
+	collector, err := logcollector.New(cfg, logProvider, &testNodeInfoProvider{nodeIDs: []string{"node0"}}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{LogID: "log0", LogType: cloudprotocol.CrashLog}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if logs["node0"].status != cloudprotocol.LogStatusAbsent {
+		t.Errorf("Unexpected log status: %s", logs["node0"].status)
+	}
+}
+
+func TestInvalidLogRequest(t *testing.T) {
+	sender := newTestSender()
+
+	collector, err := logcollector.New(newTestConfig(0), newTestLogProvider(), &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.SystemLog, Filter: amqp.LogFilter{Pattern: "("},
+	}); err == nil {
+		t.Error("Error expected")
+	}
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if logs[""].status != cloudprotocol.LogStatusError {
+		t.Errorf("Unexpected log status: %s", logs[""].status)
+	}
+}
+
+func TestLogUploadResume(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+	)
+
+	collector, err := logcollector.New(newTestConfig(4), logProvider, &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.SystemLog,
+		Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}},
+	}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	if _, err := logProvider.waitRequest(); err != nil {
+		t.Fatalf("Can't wait log request: %v", err)
+	}
+
+	logProvider.sendLog("node0", "log0", []byte("line 1\nline 2\n"))
+
+	select {
+	case pushLog := <-sender.logs:
+		t.Errorf("Unexpected log part while disconnected: %d", pushLog.Part)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	collector.CloudConnected()
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if received := string(decompressZstd(t, logs["node0"].content)); received != "line 1\nline 2\n" {
+		t.Errorf("Unexpected log content: %s", received)
+	}
+}
+
+func TestLogBandwidthBudget(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+		budget      = &testBandwidthBudget{deferred: true, usage: make(map[string]uint64)}
+	)
+
+	collector, err := logcollector.New(newTestConfig(4), logProvider, &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.SetBandwidthBudget(budget)
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.SystemLog,
+		Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}},
+	}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	if _, err := logProvider.waitRequest(); err != nil {
+		t.Fatalf("Can't wait log request: %v", err)
+	}
+
+	logProvider.sendLog("node0", "log0", []byte("line 1\nline 2\n"))
+
+	select {
+	case pushLog := <-sender.logs:
+		t.Errorf("Unexpected log part while budget is deferred: %d", pushLog.Part)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	budget.setDeferred(false)
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if err := budget.waitUsage(bandwidthbudget.CategoryLog, uint64(len(logs["node0"].content))); err != nil {
+		t.Errorf("Wrong bandwidth usage: %v", err)
+	}
+}
+
+func TestLogPartsResend(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+	)
+
+	collector, err := logcollector.New(newTestConfig(8), logProvider, &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	if err = collector.ProcessLogRequest(amqp.RequestLog{
+		LogID: "log0", LogType: cloudprotocol.SystemLog,
+		Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}},
+	}); err != nil {
+		t.Fatalf("Can't process log request: %v", err)
+	}
+
+	if _, err := logProvider.waitRequest(); err != nil {
+		t.Fatalf("Can't wait log request: %v", err)
+	}
+
+	logProvider.sendLog("node0", "log0", []byte("line 1\nline 2\nline 3\n"))
+
+	logs, err := sender.waitLogs(1)
+	if err != nil {
+		t.Fatalf("Can't wait logs: %v", err)
+	}
+
+	if logs["node0"].partsCount < 3 {
+		t.Fatalf("Unexpected parts count: %d", logs["node0"].partsCount)
+	}
+
+	if err = collector.ProcessLogPartsRequest(amqp.LogPartsRequest{
+		LogID: "log0", NodeID: "node0", Parts: []uint64{2, 3},
+	}); err != nil {
+		t.Fatalf("Can't process log parts request: %v", err)
+	}
+
+	for _, expectedPart := range []uint64{2, 3} {
+		pushLog, err := sender.waitPart()
+		if err != nil {
+			t.Fatalf("Can't wait log part: %v", err)
+		}
+
+		if pushLog.Part != expectedPart {
+			t.Errorf("Unexpected part: %d", pushLog.Part)
+		}
+
+		if !bytes.Equal(pushLog.Content, logs["node0"].parts[expectedPart-1]) {
+			t.Errorf("Unexpected part %d content", pushLog.Part)
+		}
+	}
+
+	if err = collector.ProcessLogPartsRequest(amqp.LogPartsRequest{
+		LogID: "log0", NodeID: "node0", Parts: []uint64{100},
+	}); err == nil {
+		t.Error("Error expected for invalid part")
+	}
+
+	if err = collector.ProcessLogPartsRequest(amqp.LogPartsRequest{LogID: "log1", NodeID: "node0"}); err == nil {
+		t.Error("Error expected for unknown log")
+	}
+}
+
+func TestLogCompression(t *testing.T) {
+	for _, compression := range []string{config.LogCompressionGzip, config.LogCompressionNone} {
+		logProvider := newTestLogProvider()
+		sender := newTestSender()
+		cfg := newTestConfig(0)
+
+		cfg.LogCollector.Compression = compression
+
+		collector, err := logcollector.New(cfg, logProvider, &testNodeInfoProvider{}, sender)
+		if err != nil {
+			t.Fatalf("Can't create log collector: %v", err)
+		}
+
+		collector.CloudConnected()
+
+		if err = collector.ProcessLogRequest(amqp.RequestLog{
+			LogID: "log0", LogType: cloudprotocol.SystemLog,
+			Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}},
+		}); err != nil {
+			t.Fatalf("Can't process log request: %v", err)
+		}
+
+		if _, err := logProvider.waitRequest(); err != nil {
+			t.Fatalf("Can't wait log request: %v", err)
+		}
+
+		logProvider.sendLog("node0", "log0", []byte("line 1\nline 2\n"))
+
+		pushLog, err := sender.waitPart()
+		if err != nil {
+			t.Fatalf("Can't wait log part: %v", err)
+		}
+
+		content := pushLog.Content
+
+		switch compression {
+		case config.LogCompressionGzip:
+			if pushLog.Compression != amqp.LogCompressionGzip {
+				t.Errorf("Unexpected compression: %s", pushLog.Compression)
+			}
+
+			content = decompressGzip(t, content)
+
+		default:
+			if pushLog.Compression != "" {
+				t.Errorf("Unexpected compression: %s", pushLog.Compression)
+			}
+		}
+
+		if string(content) != "line 1\nline 2\n" {
+			t.Errorf("Unexpected log content: %s", content)
+		}
+
+		collector.Close()
+	}
+}
+
+func TestLogMaxRetainedSize(t *testing.T) {
+	var (
+		logProvider = newTestLogProvider()
+		sender      = newTestSender()
+		cfg         = newTestConfig(0)
+		content     = []byte("line 1\nline 2\nline 3\n")
+	)
+
+	cfg.LogCollector.Compression = config.LogCompressionNone
+	cfg.LogCollector.MaxRetainedSize = uint64(len(content)) * 3 / 2
+
+	collector, err := logcollector.New(cfg, logProvider, &testNodeInfoProvider{}, sender)
+	if err != nil {
+		t.Fatalf("Can't create log collector: %v", err)
+	}
+	defer collector.Close()
+
+	collector.CloudConnected()
+
+	for _, logID := range []string{"log0", "log1"} {
+		if err = collector.ProcessLogRequest(amqp.RequestLog{
+			LogID: logID, LogType: cloudprotocol.SystemLog,
+			Filter: amqp.LogFilter{LogFilter: cloudprotocol.LogFilter{NodeIDs: []string{"node0"}}},
+		}); err != nil {
+			t.Fatalf("Can't process log request: %v", err)
+		}
+
+		if _, err := logProvider.waitRequest(); err != nil {
+			t.Fatalf("Can't wait log request: %v", err)
+		}
+
+		logProvider.sendLog("node0", logID, content)
+
+		if _, err := sender.waitLogs(1); err != nil {
+			t.Fatalf("Can't wait logs: %v", err)
+		}
+	}
+
+	// The oldest log is dropped when the second one is sent
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		err = collector.ProcessLogPartsRequest(amqp.LogPartsRequest{LogID: "log0", NodeID: "node0"})
+		if err != nil {
+			break
+		}
+
+		if time.Since(start) > waitTimeout {
+			t.Fatal("Oldest log should be dropped")
+		}
+
+		if _, err := sender.waitPart(); err != nil {
+			t.Fatalf("Can't wait log part: %v", err)
+		}
+	}
+
+	if err = collector.ProcessLogPartsRequest(amqp.LogPartsRequest{LogID: "log1", NodeID: "node0"}); err != nil {
+		t.Errorf("Can't process log parts request: %v", err)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func newTestLogProvider() *testLogProvider {
+	return &testLogProvider{
+		requests:   make(chan cloudprotocol.RequestLog, 10),
+		logChannel: make(chan cloudprotocol.PushLog, 10),
+	}
+}
+
+func (provider *testLogProvider) GetLog(logRequest cloudprotocol.RequestLog) error {
+	provider.requests <- logRequest
+
+	return nil
+}
+
+func (provider *testLogProvider) GetLogChannel() <-chan cloudprotocol.PushLog {
+	return provider.logChannel
+}
+
+func (provider *testLogProvider) waitRequest() (cloudprotocol.RequestLog, error) {
+	select {
+	case request := <-provider.requests:
+		return request, nil
+
+	case <-time.After(waitTimeout):
+		return cloudprotocol.RequestLog{}, aoserrors.New("wait request timeout")
+	}
+}
+
+// sendLog sends log content in two parts in reverse order.
+func (provider *testLogProvider) sendLog(nodeID, logID string, content []byte) {
+	provider.logChannel <- cloudprotocol.PushLog{
+		NodeID: nodeID, LogID: logID, PartsCount: 2, Part: 2, Content: content[len(content)/2:],
+		Status: cloudprotocol.LogStatusOk,
+	}
+
+	provider.logChannel <- cloudprotocol.PushLog{
+		NodeID: nodeID, LogID: logID, PartsCount: 2, Part: 1, Content: content[:len(content)/2],
+		Status: cloudprotocol.LogStatusOk,
+	}
+}
+
+func (provider *testNodeInfoProvider) GetAllNodeIDs() ([]string, error) {
+	return provider.nodeIDs, nil
+}
+
+func (budget *testBandwidthBudget) IsDeferred() bool {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.deferred
+}
+
+func (budget *testBandwidthBudget) AddUsage(category string, size uint64) {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.usage[category] += size
+}
+
+func (budget *testBandwidthBudget) setDeferred(deferred bool) {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.deferred = deferred
+}
+
+func (budget *testBandwidthBudget) getUsage(category string) uint64 {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.usage[category]
+}
+
+// waitUsage waits till usage is accounted as it is added after the data is sent.
+func (budget *testBandwidthBudget) waitUsage(category string, size uint64) error {
+	timeout := time.After(waitTimeout)
+
+	for {
+		usage := budget.getUsage(category)
+		if usage == size {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return aoserrors.Errorf("unexpected %s usage: %d", category, usage)
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func newTestSender() *testSender {
+	return &testSender{logs: make(chan amqp.PushLog, 100)}
+}
+
+func (sender *testSender) SendLog(pushLog amqp.PushLog) error {
+	sender.logs <- pushLog
+
+	return nil
+}
+
+func (sender *testSender) GetSendQueueLength() int {
+	return 0
+}
+
+func (sender *testSender) SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+func (sender *testSender) UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+// waitLogs waits till logs of specified number of nodes are fully received.
+func (sender *testSender) waitLogs(count int) (map[string]*receivedLog, error) {
+	logs := make(map[string]*receivedLog)
+	completed := 0
+
+	for completed < count {
+		select {
+		case pushLog := <-sender.logs:
+			nodeLog, ok := logs[pushLog.NodeID]
+			if !ok {
+				nodeLog = &receivedLog{status: pushLog.Status, partsCount: pushLog.PartsCount}
+				logs[pushLog.NodeID] = nodeLog
+			}
+
+			if pushLog.Part != nodeLog.receivedParts+1 && pushLog.PartsCount != 0 {
+				return nil, aoserrors.Errorf("unexpected part %d", pushLog.Part)
+			}
+
+			if pushLog.PartsCount != 0 {
+				if err := checkPart(pushLog); err != nil {
+					return nil, err
+				}
+			}
+
+			nodeLog.receivedParts++
+			nodeLog.parts = append(nodeLog.parts, pushLog.Content)
+			nodeLog.content = append(nodeLog.content, pushLog.Content...)
+			nodeLog.truncated = nodeLog.truncated || pushLog.Truncated
+
+			if pushLog.Part != pushLog.PartsCount {
+				continue
+			}
+
+			if checksum := sha256.Sum256(nodeLog.content); pushLog.PartsCount != 0 &&
+				hex.EncodeToString(checksum[:]) != pushLog.Checksum {
+				return nil, aoserrors.New("wrong log checksum")
+			}
+
+			completed++
+
+		case <-time.After(waitTimeout):
+			return nil, aoserrors.New("wait logs timeout")
+		}
+	}
+
+	return logs, nil
+}
+
+func (sender *testSender) waitPart() (amqp.PushLog, error) {
+	select {
+	case pushLog := <-sender.logs:
+		return pushLog, checkPart(pushLog)
+
+	case <-time.After(waitTimeout):
+		return amqp.PushLog{}, aoserrors.New("wait log part timeout")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func checkPart(pushLog amqp.PushLog) error {
+	if pushLog.Compression != amqp.LogCompressionZstd && pushLog.Compression != amqp.LogCompressionGzip &&
+		pushLog.Compression != "" {
+		return aoserrors.Errorf("unexpected compression: %s", pushLog.Compression)
+	}
+
+	if checksum := sha256.Sum256(pushLog.Content); hex.EncodeToString(checksum[:]) != pushLog.PartChecksum {
+		return aoserrors.Errorf("wrong part %d checksum", pushLog.Part)
+	}
+
+	return nil
+}
+
+func newTestConfig(chunkSize uint64) *config.Config {
+	return &config.Config{LogCollector: config.LogCollector{
+		CollectTimeout: aostypes.Duration{Duration: waitTimeout},
+		MaxLogSize:     1 << 20,
+		ChunkSize:      chunkSize,
+		RetentionTime:  aostypes.Duration{Duration: time.Minute},
+		Compression:    config.LogCompressionZstd,
+	}}
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("Can't compress data: %v", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Can't compress data: %v", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func decompressGzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Can't create reader: %v", err)
+	}
+
+	result, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Can't decompress data: %v", err)
+	}
+
+	return result
+}
+
+func decompressZstd(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("Can't create decoder: %v", err)
+	}
+	defer decoder.Close()
+
+	result, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		t.Fatalf("Can't decompress data: %v", err)
+	}
+
+	return result
+}
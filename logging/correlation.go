@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// CorrelationIDField log field which contains correlation ID.
+const CorrelationIDField = "correlationID"
+
+// CampaignIDField log field which contains campaign ID.
+const CampaignIDField = "campaignID"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// flowIDs IDs of active flows, the current ID is ID of the most recently started flow.
+type flowIDs struct {
+	sync.RWMutex
+	ids     map[string]string
+	current string
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var (
+	correlationIDs = flowIDs{ids: make(map[string]string)}
+	campaignIDs    = flowIDs{ids: make(map[string]string)}
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewCorrelationID generates new correlation ID.
+func NewCorrelationID() string {
+	return uuid.NewString()
+}
+
+// SetCorrelationID sets correlation ID of the flow (e.g. FOTA or SOTA update). Empty ID finishes the flow. While any
+// flow is active, all log entries and cloud messages are marked by correlation ID of the most recently started flow.
+func SetCorrelationID(flow, correlationID string) {
+	correlationIDs.set(flow, correlationID)
+}
+
+// GetCorrelationID returns current correlation ID.
+func GetCorrelationID() string {
+	return correlationIDs.get()
+}
+
+// SetCampaignID sets campaign ID of the flow. Empty ID finishes the flow. Campaign ID marks log entries and cloud
+// messages the same way as correlation ID.
+func SetCampaignID(flow, campaignID string) {
+	campaignIDs.set(flow, campaignID)
+}
+
+// GetCampaignID returns current campaign ID.
+func GetCampaignID() string {
+	return campaignIDs.get()
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (flows *flowIDs) set(flow, id string) {
+	flows.Lock()
+	defer flows.Unlock()
+
+	if id != "" {
+		flows.ids[flow] = id
+		flows.current = id
+
+		return
+	}
+
+	delete(flows.ids, flow)
+
+	if slices.Contains(maps.Values(flows.ids), flows.current) {
+		return
+	}
+
+	flows.current = ""
+
+	names := maps.Keys(flows.ids)
+
+	if len(names) != 0 {
+		slices.Sort(names)
+
+		flows.current = flows.ids[names[0]]
+	}
+}
+
+func (flows *flowIDs) get() string {
+	flows.RLock()
+	defer flows.RUnlock()
+
+	return flows.current
+}
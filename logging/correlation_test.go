@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging_test
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestCorrelationID(t *testing.T) {
+	output := setOutput(t)
+
+	if err := logging.SetFormat(logging.FormatText); err != nil {
+		t.Fatalf("Can't set format: %v", err)
+	}
+
+	setLevels(t, logging.Levels{Level: "debug"})
+
+	if logging.NewCorrelationID() == logging.NewCorrelationID() {
+		t.Error("Correlation IDs should be unique")
+	}
+
+	logging.SetCorrelationID("fota", "id1")
+	logging.SetCorrelationID("sota", "id2")
+
+	if correlationID := logging.GetCorrelationID(); correlationID != "id2" {
+		t.Errorf("Wrong correlation ID: %s", correlationID)
+	}
+
+	log.Info("correlated message")
+
+	if !strings.Contains(output.String(), "correlationID=id2") {
+		t.Errorf("Correlation ID field expected: %s", output.String())
+	}
+
+	logging.SetCorrelationID("sota", "")
+
+	if correlationID := logging.GetCorrelationID(); correlationID != "id1" {
+		t.Errorf("Wrong correlation ID: %s", correlationID)
+	}
+
+	logging.SetCorrelationID("fota", "")
+
+	if correlationID := logging.GetCorrelationID(); correlationID != "" {
+		t.Errorf("Wrong correlation ID: %s", correlationID)
+	}
+
+	output.Reset()
+
+	log.Info("not correlated message")
+
+	if strings.Contains(output.String(), "correlationID") {
+		t.Errorf("Unexpected correlation ID field: %s", output.String())
+	}
+}
+
+func TestCampaignID(t *testing.T) {
+	output := setOutput(t)
+
+	if err := logging.SetFormat(logging.FormatText); err != nil {
+		t.Fatalf("Can't set format: %v", err)
+	}
+
+	setLevels(t, logging.Levels{Level: "debug"})
+
+	logging.SetCampaignID("sota", "campaign1")
+
+	if campaignID := logging.GetCampaignID(); campaignID != "campaign1" {
+		t.Errorf("Wrong campaign ID: %s", campaignID)
+	}
+
+	log.Info("campaign message")
+
+	if !strings.Contains(output.String(), "campaignID=campaign1") {
+		t.Errorf("Campaign ID field expected: %s", output.String())
+	}
+
+	logging.SetCampaignID("sota", "")
+
+	if campaignID := logging.GetCampaignID(); campaignID != "" {
+		t.Errorf("Wrong campaign ID: %s", campaignID)
+	}
+
+	output.Reset()
+
+	log.Info("not campaign message")
+
+	if strings.Contains(output.String(), "campaignID") {
+		t.Errorf("Unexpected campaign ID field: %s", output.String())
+	}
+}
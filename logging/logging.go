@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging provides per-module log levels, structured JSON log output and correlation IDs.
+//
+// Module of the log entry is the CM package which logs the entry (the last package path element for other packages).
+// The global logrus level is set to the most verbose of the default and module levels, entries of modules with less
+// verbose levels are dropped by the formatter.
+package logging
+
+import (
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ModuleField log field which contains module name in JSON format.
+const ModuleField = "module"
+
+const (
+	modulePrefix    = "github.com/aosedge/aos_communicationmanager/"
+	timestampFormat = "2006-01-02 15:04:05.000"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Levels log levels.
+type Levels struct {
+	Level        string            `json:"level"`
+	ModuleLevels map[string]string `json:"moduleLevels,omitempty"`
+}
+
+type moduleFormatter struct {
+	formatter  log.Formatter
+	jsonFormat bool
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var (
+	mutex         sync.RWMutex
+	defaultLevel  = log.InfoLevel
+	moduleLevels  = make(map[string]log.Level)
+	currentLevels = Levels{Level: log.InfoLevel.String()}
+	jsonFormat    bool
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetFormat sets log output format: text (default) or json.
+func SetFormat(format string) error {
+	var formatter log.Formatter
+
+	switch format {
+	case "", FormatText:
+		formatter = &log.TextFormatter{
+			DisableTimestamp: false,
+			TimestampFormat:  timestampFormat,
+			FullTimestamp:    true,
+		}
+
+	case FormatJSON:
+		formatter = &log.JSONFormatter{TimestampFormat: timestampFormat}
+
+	default:
+		return aoserrors.Errorf("unsupported log format: %s", format)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	jsonFormat = format == FormatJSON
+
+	log.SetFormatter(&moduleFormatter{formatter: formatter, jsonFormat: jsonFormat})
+	log.SetReportCaller(jsonFormat || len(moduleLevels) != 0)
+
+	return nil
+}
+
+// SetLevels sets default and per-module log levels.
+func SetLevels(levels Levels) error {
+	level, err := log.ParseLevel(levels.Level)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	maxLevel := level
+	newModuleLevels := make(map[string]log.Level)
+
+	for module, moduleLevel := range levels.ModuleLevels {
+		if newModuleLevels[module], err = log.ParseLevel(moduleLevel); err != nil {
+			return aoserrors.Errorf("wrong %s module log level: %v", module, err)
+		}
+
+		maxLevel = max(maxLevel, newModuleLevels[module])
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	defaultLevel = level
+	moduleLevels = newModuleLevels
+	currentLevels = Levels{Level: level.String(), ModuleLevels: maps.Clone(levels.ModuleLevels)}
+
+	log.SetReportCaller(jsonFormat || len(moduleLevels) != 0)
+	log.SetLevel(maxLevel)
+
+	return nil
+}
+
+// GetLevels returns current log levels.
+func GetLevels() Levels {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return Levels{Level: currentLevels.Level, ModuleLevels: maps.Clone(currentLevels.ModuleLevels)}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (formatter *moduleFormatter) Format(entry *log.Entry) ([]byte, error) {
+	var module string
+
+	if entry.Caller != nil {
+		module = getModule(entry.Caller.Function)
+	}
+
+	if !isLevelEnabled(module, entry.Level) {
+		return nil, nil
+	}
+
+	formatEntry := *entry
+	formatEntry.Caller = nil
+
+	correlationID := GetCorrelationID()
+	if _, ok := entry.Data[CorrelationIDField]; ok {
+		correlationID = ""
+	}
+
+	campaignID := GetCampaignID()
+	if _, ok := entry.Data[CampaignIDField]; ok {
+		campaignID = ""
+	}
+
+	if (formatter.jsonFormat && module != "") || correlationID != "" || campaignID != "" {
+		formatEntry.Data = make(log.Fields, len(entry.Data)+3) //nolint:mnd
+
+		maps.Copy(formatEntry.Data, entry.Data)
+
+		if formatter.jsonFormat && module != "" {
+			formatEntry.Data[ModuleField] = module
+		}
+
+		if correlationID != "" {
+			formatEntry.Data[CorrelationIDField] = correlationID
+		}
+
+		if campaignID != "" {
+			formatEntry.Data[CampaignIDField] = campaignID
+		}
+	}
+
+	data, err := formatter.formatter.Format(&formatEntry)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return data, nil
+}
+
+func isLevelEnabled(module string, level log.Level) bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	moduleLevel, ok := moduleLevels[module]
+	if !ok {
+		moduleLevel = defaultLevel
+	}
+
+	return level <= moduleLevel
+}
+
+// getModule returns module name from caller function name e.g.
+// github.com/aosedge/aos_communicationmanager/launcher.(*Launcher).Close.
+func getModule(function string) string {
+	packagePath := function
+
+	if index := strings.LastIndex(packagePath, "/"); index >= 0 {
+		if dotIndex := strings.Index(packagePath[index:], "."); dotIndex >= 0 {
+			packagePath = packagePath[:index+dotIndex]
+		}
+	} else if dotIndex := strings.Index(packagePath, "."); dotIndex >= 0 {
+		packagePath = packagePath[:dotIndex]
+	}
+
+	if strings.HasPrefix(packagePath, modulePrefix) {
+		module, _, _ := strings.Cut(strings.TrimPrefix(packagePath, modulePrefix), "/")
+
+		return module
+	}
+
+	return packagePath[strings.LastIndex(packagePath, "/")+1:]
+}
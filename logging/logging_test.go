@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestModuleLevels(t *testing.T) {
+	output := setOutput(t)
+
+	if err := logging.SetFormat(logging.FormatText); err != nil {
+		t.Fatalf("Can't set format: %v", err)
+	}
+
+	setLevels(t, logging.Levels{Level: "info", ModuleLevels: map[string]string{"logging_test": "debug"}})
+
+	if log.GetLevel() != log.DebugLevel {
+		t.Errorf("Wrong global log level: %v", log.GetLevel())
+	}
+
+	log.Debug("debug message")
+
+	if !strings.Contains(output.String(), "debug message") {
+		t.Error("Debug message should be logged")
+	}
+
+	if strings.Contains(output.String(), "func=") {
+		t.Error("Caller should not be logged")
+	}
+
+	output.Reset()
+
+	setLevels(t, logging.Levels{Level: "debug", ModuleLevels: map[string]string{"logging_test": "warn"}})
+
+	log.Info("info message")
+	log.Warn("warn message")
+
+	if strings.Contains(output.String(), "info message") {
+		t.Error("Info message should not be logged")
+	}
+
+	if !strings.Contains(output.String(), "warn message") {
+		t.Error("Warn message should be logged")
+	}
+
+	if err := logging.SetLevels(logging.Levels{
+		Level: "info", ModuleLevels: map[string]string{"launcher": "unknown"},
+	}); err == nil {
+		t.Error("Error expected for wrong module level")
+	}
+
+	levels := logging.GetLevels()
+
+	if levels.Level != "debug" || levels.ModuleLevels["logging_test"] != "warn" {
+		t.Errorf("Wrong log levels: %v", levels)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	output := setOutput(t)
+
+	if err := logging.SetFormat(logging.FormatJSON); err != nil {
+		t.Fatalf("Can't set format: %v", err)
+	}
+	defer func() {
+		if err := logging.SetFormat(logging.FormatText); err != nil {
+			t.Errorf("Can't set format: %v", err)
+		}
+	}()
+
+	setLevels(t, logging.Levels{Level: "info"})
+
+	log.WithField("id", "service1").Info("json message")
+
+	var entry map[string]any
+
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("Can't parse log entry: %v", err)
+	}
+
+	if entry["msg"] != "json message" || entry["id"] != "service1" || entry[logging.ModuleField] != "logging_test" ||
+		entry["level"] != "info" {
+		t.Errorf("Wrong log entry: %v", entry)
+	}
+
+	if err := logging.SetFormat("xml"); err == nil {
+		t.Error("Error expected for unsupported format")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func setOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	output := &bytes.Buffer{}
+
+	log.SetOutput(output)
+
+	t.Cleanup(func() {
+		log.SetOutput(os.Stdout)
+	})
+
+	return output
+}
+
+func setLevels(t *testing.T, levels logging.Levels) {
+	t.Helper()
+
+	if err := logging.SetLevels(levels); err != nil {
+		t.Fatalf("Can't set levels: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitorcontroller
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const historyFlushPeriod = 10 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// MonitoringStorage monitoring history storage interface.
+type MonitoringStorage interface {
+	AddMonitoringRecords(records []MonitoringRecord) error
+	GetMonitoringRecords(filter HistoryFilter) ([]MonitoringRecord, error)
+}
+
+// MonitoringRecord monitoring history record. Instance ident is empty for node records.
+type MonitoringRecord struct {
+	NodeID string `json:"nodeId"`
+	aostypes.InstanceIdent
+	Timestamp time.Time `json:"timestamp"`
+	RAM       uint64    `json:"ram"`
+	CPU       uint64    `json:"cpu"`
+	Download  uint64    `json:"download"`
+	Upload    uint64    `json:"upload"`
+}
+
+// HistoryFilter monitoring history filter.
+type HistoryFilter struct {
+	NodeID   string                  `json:"nodeId"`
+	Instance *aostypes.InstanceIdent `json:"instance,omitempty"`
+	From     time.Time               `json:"from"`
+	Till     time.Time               `json:"till"`
+}
+
+// HistoryRequest monitoring history request.
+type HistoryRequest struct {
+	HistoryFilter
+	Interval aostypes.Duration `json:"interval"`
+}
+
+// HistoryValue aggregated history value.
+type HistoryValue struct {
+	Min uint64 `json:"min"`
+	Avg uint64 `json:"avg"`
+	Max uint64 `json:"max"`
+}
+
+// HistoryItem aggregated monitoring data over interval.
+type HistoryItem struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Samples   int          `json:"samples"`
+	RAM       HistoryValue `json:"ram"`
+	CPU       HistoryValue `json:"cpu"`
+	Download  HistoryValue `json:"download"`
+	Upload    HistoryValue `json:"upload"`
+}
+
+type historyAccumulator struct {
+	min, max, sum uint64
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetMonitoringHistory returns monitoring history aggregated over requested interval.
+func (monitor *MonitorController) GetMonitoringHistory(request HistoryRequest) ([]HistoryItem, error) {
+	if monitor.storage == nil {
+		return nil, aoserrors.New("monitoring history is disabled")
+	}
+
+	if request.NodeID == "" && request.Instance == nil {
+		return nil, aoserrors.New("node ID or instance should be specified")
+	}
+
+	if request.Till.IsZero() {
+		request.Till = time.Now()
+	}
+
+	if request.Till.Before(request.From) {
+		return nil, aoserrors.New("wrong history time range")
+	}
+
+	// flush buffered records to have actual data in the result
+	monitor.flushHistory()
+
+	records, err := monitor.storage.GetMonitoringRecords(request.HistoryFilter)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return aggregateHistory(records, request.Interval.Duration), nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (monitor *MonitorController) addHistoryRecords(nodeMonitoring aostypes.NodeMonitoring) {
+	if monitor.storage == nil {
+		return
+	}
+
+	monitor.historyBuffer = append(monitor.historyBuffer,
+		newMonitoringRecord(nodeMonitoring.NodeID, aostypes.InstanceIdent{}, nodeMonitoring.NodeData))
+
+	for _, instanceData := range nodeMonitoring.InstancesData {
+		monitor.historyBuffer = append(monitor.historyBuffer,
+			newMonitoringRecord(nodeMonitoring.NodeID, instanceData.InstanceIdent, instanceData.MonitoringData))
+	}
+}
+
+func (monitor *MonitorController) flushHistory() {
+	if monitor.storage == nil {
+		return
+	}
+
+	monitor.Lock()
+
+	records := monitor.historyBuffer
+	monitor.historyBuffer = nil
+
+	monitor.Unlock()
+
+	if len(records) != 0 {
+		if err := monitor.storage.AddMonitoringRecords(records); err != nil {
+			log.Errorf("Can't store monitoring history: %v", err)
+		}
+	}
+}
+
+func newMonitoringRecord(
+	nodeID string, instanceIdent aostypes.InstanceIdent, data aostypes.MonitoringData,
+) MonitoringRecord {
+	return MonitoringRecord{
+		NodeID:        nodeID,
+		InstanceIdent: instanceIdent,
+		Timestamp:     data.Timestamp,
+		RAM:           data.RAM,
+		CPU:           data.CPU,
+		Download:      data.Download,
+		Upload:        data.Upload,
+	}
+}
+
+func aggregateHistory(records []MonitoringRecord, interval time.Duration) (items []HistoryItem) {
+	var (
+		item                       *HistoryItem
+		ram, cpu, download, upload historyAccumulator
+	)
+
+	finishItem := func() {
+		if item == nil {
+			return
+		}
+
+		item.RAM = ram.value(item.Samples)
+		item.CPU = cpu.value(item.Samples)
+		item.Download = download.value(item.Samples)
+		item.Upload = upload.value(item.Samples)
+
+		items = append(items, *item)
+	}
+
+	for _, record := range records {
+		timestamp := record.Timestamp
+
+		if interval > 0 {
+			timestamp = timestamp.Truncate(interval)
+		}
+
+		if item == nil || !item.Timestamp.Equal(timestamp) {
+			finishItem()
+
+			item = &HistoryItem{Timestamp: timestamp}
+			ram, cpu, download, upload = historyAccumulator{}, historyAccumulator{}, historyAccumulator{},
+				historyAccumulator{}
+		}
+
+		ram.add(record.RAM, item.Samples)
+		cpu.add(record.CPU, item.Samples)
+		download.add(record.Download, item.Samples)
+		upload.add(record.Upload, item.Samples)
+
+		item.Samples++
+	}
+
+	finishItem()
+
+	return items
+}
+
+func (accumulator *historyAccumulator) add(value uint64, samples int) {
+	if samples == 0 || value < accumulator.min {
+		accumulator.min = value
+	}
+
+	if value > accumulator.max {
+		accumulator.max = value
+	}
+
+	accumulator.sum += value
+}
+
+func (accumulator *historyAccumulator) value(samples int) HistoryValue {
+	if samples == 0 {
+		return HistoryValue{}
+	}
+
+	return HistoryValue{Min: accumulator.min, Avg: accumulator.sum / uint64(samples), Max: accumulator.max}
+}
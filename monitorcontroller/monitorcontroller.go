@@ -30,6 +30,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
 	"github.com/aosedge/aos_communicationmanager/config"
 )
 
@@ -41,7 +42,22 @@ import (
 type MonitoringSender interface {
 	SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
 	UnsubscribeFromConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
+	SubscribeForTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error
+	UnsubscribeFromTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error
 	SendMonitoringData(monitoringData cloudprotocol.Monitoring) error
+	SendUnitMonitoringData(unitMonitoring amqphandler.UnitMonitoring) error
+	GetSendQueueLength() int
+}
+
+// StorageSizeProvider provides storage size.
+type StorageSizeProvider interface {
+	GetSize() (uint64, error)
+}
+
+// BandwidthBudget bandwidth budget of metered connection.
+type BandwidthBudget interface {
+	IsDeferred() bool
+	AddUsage(category string, size uint64)
 }
 
 // MonitorController instance.
@@ -57,6 +73,25 @@ type MonitorController struct {
 	monitoringSender MonitoringSender
 	cancelFunction   context.CancelFunc
 	isConnected      bool
+	bandwidthBudget  BandwidthBudget
+
+	rateFactor        int
+	aggregatedSamples map[string]int
+
+	storage       MonitoringStorage
+	historyBuffer []MonitoringRecord
+
+	rollupPeriod           time.Duration
+	sizeProvider           StorageSizeProvider
+	networkProvider        InstanceNetworkProvider
+	transferStatsProviders []TransferStatsProvider
+	dnsStatsProvider       DNSStatsProvider
+	latestNodeData         map[string]aostypes.MonitoringData
+	latestInstanceData     map[instanceStatusKey]aostypes.MonitoringData
+	instancesStatus        map[instanceStatusKey]string
+
+	snapshotWindow  time.Duration
+	snapshotSamples []aostypes.NodeMonitoring
 }
 
 /***********************************************************************************************************************
@@ -65,14 +100,27 @@ type MonitorController struct {
 
 // New creates new monitor controller instance.
 func New(
-	config *config.Config, monitoringSender MonitoringSender,
+	cfg *config.Config, monitoringSender MonitoringSender, storage MonitoringStorage,
+	sizeProvider StorageSizeProvider,
 ) (monitor *MonitorController, err error) {
 	monitor = &MonitorController{
-		monitoringSender: monitoringSender,
-		offlineMessages:  make([]cloudprotocol.Monitoring, 0, config.Monitoring.MaxOfflineMessages),
-		sendMessageEvent: make(chan struct{}, 1),
-		maxMessageSize:   config.Monitoring.MaxMessageSize,
-		sendPeriod:       config.Monitoring.SendPeriod,
+		monitoringSender:   monitoringSender,
+		sizeProvider:       sizeProvider,
+		offlineMessages:    make([]cloudprotocol.Monitoring, 0, cfg.Monitoring.MaxOfflineMessages),
+		sendMessageEvent:   make(chan struct{}, 1),
+		maxMessageSize:     cfg.Monitoring.MaxMessageSize,
+		sendPeriod:         cfg.Monitoring.SendPeriod,
+		rateFactor:         1,
+		aggregatedSamples:  make(map[string]int),
+		rollupPeriod:       cfg.Monitoring.UnitRollupPeriod.Duration,
+		latestNodeData:     make(map[string]aostypes.MonitoringData),
+		latestInstanceData: make(map[instanceStatusKey]aostypes.MonitoringData),
+		instancesStatus:    make(map[instanceStatusKey]string),
+		snapshotWindow:     cfg.Alerts.MonitoringSnapshotWindow.Duration,
+	}
+
+	if _, ok := cfg.GetRetentionPolicy(config.RetentionMonitoring); storage != nil && ok {
+		monitor.storage = storage
 	}
 
 	if monitor.sendPeriod.Seconds() < 1.0 {
@@ -84,6 +132,10 @@ func New(
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if err = monitor.monitoringSender.SubscribeForTelemetryProfileChanges(monitor); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	monitor.cancelFunction = cancelFunc
 
@@ -98,9 +150,15 @@ func (monitor *MonitorController) Close() {
 		log.Errorf("Can't unsubscribe from connection events: %v", err)
 	}
 
+	if err := monitor.monitoringSender.UnsubscribeFromTelemetryProfileChanges(monitor); err != nil {
+		log.Errorf("Can't unsubscribe from telemetry profile changes: %v", err)
+	}
+
 	if monitor.cancelFunction != nil {
 		monitor.cancelFunction()
 	}
+
+	monitor.flushHistory()
 }
 
 // SendNodeMonitoring sends monitoring data.
@@ -127,12 +185,23 @@ func (monitor *MonitorController) SendNodeMonitoring(nodeMonitoring aostypes.Nod
 
 		monitor.offlineMessages = append(monitor.offlineMessages, cloudprotocol.Monitoring{})
 		monitor.currentMessageSize = 0
+		monitor.aggregatedSamples = make(map[string]int)
 	}
 
-	monitor.currentMessageSize += messageSize
-
 	// add monitoring data
-	monitor.addNodeMonitoring(nodeMonitoring)
+	if !monitor.addNodeMonitoring(nodeMonitoring) {
+		monitor.currentMessageSize += messageSize
+	}
+
+	monitor.addHistoryRecords(nodeMonitoring)
+
+	if monitor.rollupPeriod > 0 {
+		monitor.addRollupData(nodeMonitoring)
+	}
+
+	if monitor.snapshotWindow > 0 {
+		monitor.addSnapshotSample(nodeMonitoring)
+	}
 
 	// send notification message
 	monitor.Unlock()
@@ -142,6 +211,15 @@ func (monitor *MonitorController) SendNodeMonitoring(nodeMonitoring aostypes.Nod
 	}
 }
 
+// SetBandwidthBudget sets bandwidth budget. Monitoring data is kept offline while the budget defers non-critical
+// traffic.
+func (monitor *MonitorController) SetBandwidthBudget(budget BandwidthBudget) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	monitor.bandwidthBudget = budget
+}
+
 /***********************************************************************************************************************
  * Interface
  **********************************************************************************************************************/
@@ -162,21 +240,73 @@ func (monitor *MonitorController) CloudDisconnected() {
 	monitor.isConnected = false
 }
 
+// TelemetryProfileChanged indicates telemetry profile is changed.
+func (monitor *MonitorController) TelemetryProfileChanged(profile amqphandler.TelemetryProfile) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	log.WithFields(log.Fields{
+		"profile": profile.Name, "rateFactor": profile.RateFactor,
+	}).Debug("Monitoring telemetry profile changed")
+
+	monitor.rateFactor = max(profile.RateFactor, 1)
+}
+
+// ConfigReloaded applies reloaded config.
+func (monitor *MonitorController) ConfigReloaded(cfg *config.Config) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	if cfg.Monitoring.SendPeriod.Seconds() < 1.0 {
+		log.Warningf("MonitorController send interval is less than 1sec.: %v", cfg.Monitoring.SendPeriod)
+
+		return
+	}
+
+	if monitor.sendPeriod != cfg.Monitoring.SendPeriod {
+		log.WithField("sendPeriod", cfg.Monitoring.SendPeriod).Info("Monitoring send period changed")
+	}
+
+	monitor.sendPeriod = cfg.Monitoring.SendPeriod
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
 
 func (monitor *MonitorController) processQueue(ctx context.Context) {
 	sendTicker := time.NewTicker(monitor.sendPeriod.Duration)
+	historyTicker := time.NewTicker(historyFlushPeriod)
+
+	var rollupChannel <-chan time.Time
+
+	if monitor.rollupPeriod > 0 {
+		rollupTicker := time.NewTicker(monitor.rollupPeriod)
+		defer rollupTicker.Stop()
+
+		rollupChannel = rollupTicker.C
+	}
+
+	defer func() {
+		sendTicker.Stop()
+		historyTicker.Stop()
+	}()
 
 	for {
 		select {
 		case <-sendTicker.C:
 			monitor.sendMessages()
+			sendTicker.Reset(monitor.getSendPeriod())
+
+		case <-historyTicker.C:
+			monitor.flushHistory()
+
+		case <-rollupChannel:
+			monitor.sendUnitRollup()
 
 		case <-monitor.sendMessageEvent:
 			monitor.sendMessages()
-			sendTicker.Reset(monitor.sendPeriod.Duration)
+			sendTicker.Reset(monitor.getSendPeriod())
 
 		case <-ctx.Done():
 			return
@@ -188,28 +318,71 @@ func (monitor *MonitorController) sendMessages() {
 	monitor.Lock()
 	defer monitor.Unlock()
 
-	if len(monitor.offlineMessages) > 0 && monitor.isConnected {
+	if len(monitor.offlineMessages) > 0 && monitor.isConnected && !monitor.isBandwidthDeferred() {
 		for _, offlineMessage := range monitor.offlineMessages {
 			err := monitor.monitoringSender.SendMonitoringData(offlineMessage)
 			if err != nil && !errors.Is(err, amqphandler.ErrNotConnected) {
 				log.Errorf("Can't send monitoring data: %v", err)
 			}
+
+			if err == nil {
+				monitor.addBandwidthUsage(offlineMessage)
+			}
 		}
 
 		monitor.offlineMessages = make([]cloudprotocol.Monitoring, 0, cap(monitor.offlineMessages))
 		monitor.currentMessageSize = 0
+		monitor.aggregatedSamples = make(map[string]int)
+	}
+}
+
+func (monitor *MonitorController) isBandwidthDeferred() bool {
+	return monitor.bandwidthBudget != nil && monitor.bandwidthBudget.IsDeferred()
+}
+
+func (monitor *MonitorController) addBandwidthUsage(message interface{}) {
+	if monitor.bandwidthBudget == nil {
+		return
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Errorf("Can't marshal monitoring message: %v", err)
+
+		return
 	}
+
+	monitor.bandwidthBudget.AddUsage(bandwidthbudget.CategoryMonitoring, uint64(len(data)))
+}
+
+func (monitor *MonitorController) getSendPeriod() time.Duration {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	return monitor.sendPeriod.Duration * time.Duration(monitor.rateFactor)
 }
 
-func (monitor *MonitorController) addNodeMonitoring(nodeMonitoring aostypes.NodeMonitoring) {
+// addNodeMonitoring adds node monitoring to the latest message. In reduced telemetry profile, rate factor samples
+// are aggregated into one item. Returns true if the data is aggregated into existing item.
+func (monitor *MonitorController) addNodeMonitoring(nodeMonitoring aostypes.NodeMonitoring) (aggregated bool) {
 	latestMessage := &monitor.offlineMessages[len(monitor.offlineMessages)-1]
 
+	samples := monitor.aggregatedSamples[nodeMonitoring.NodeID]
+	aggregated = samples > 0 && samples < monitor.rateFactor
+
+	if aggregated {
+		monitor.aggregatedSamples[nodeMonitoring.NodeID]++
+	} else {
+		monitor.aggregatedSamples[nodeMonitoring.NodeID] = 1
+	}
+
 	// add node monitoring data
 	nodeDataFound := false
 
 	for i, nodeData := range latestMessage.Nodes {
 		if nodeData.NodeID == nodeMonitoring.NodeID {
-			latestMessage.Nodes[i].Items = append(latestMessage.Nodes[i].Items, nodeMonitoring.NodeData)
+			latestMessage.Nodes[i].Items = addMonitoringItem(
+				latestMessage.Nodes[i].Items, nodeMonitoring.NodeData, aggregated, samples)
 			nodeDataFound = true
 
 			break
@@ -229,8 +402,8 @@ func (monitor *MonitorController) addNodeMonitoring(nodeMonitoring aostypes.Node
 
 		for i, item := range latestMessage.ServiceInstances {
 			if item.NodeID == nodeMonitoring.NodeID && item.InstanceIdent == instanceData.InstanceIdent {
-				latestMessage.ServiceInstances[i].Items = append(latestMessage.ServiceInstances[i].Items,
-					instanceData.MonitoringData)
+				latestMessage.ServiceInstances[i].Items = addMonitoringItem(
+					latestMessage.ServiceInstances[i].Items, instanceData.MonitoringData, aggregated, samples)
 				instanceDataFound = true
 
 				break
@@ -246,4 +419,29 @@ func (monitor *MonitorController) addNodeMonitoring(nodeMonitoring aostypes.Node
 				})
 		}
 	}
+
+	return aggregated
+}
+
+func addMonitoringItem(
+	items []aostypes.MonitoringData, data aostypes.MonitoringData, aggregated bool, samples int,
+) []aostypes.MonitoringData {
+	if !aggregated || len(items) == 0 {
+		return append(items, data)
+	}
+
+	lastItem := &items[len(items)-1]
+
+	lastItem.Timestamp = data.Timestamp
+	lastItem.RAM = averageValue(lastItem.RAM, data.RAM, samples)
+	lastItem.CPU = averageValue(lastItem.CPU, data.CPU, samples)
+	lastItem.Download = averageValue(lastItem.Download, data.Download, samples)
+	lastItem.Upload = averageValue(lastItem.Upload, data.Upload, samples)
+	lastItem.Partitions = data.Partitions
+
+	return items
+}
+
+func averageValue(current, value uint64, samples int) uint64 {
+	return (current*uint64(samples) + value) / uint64(samples+1)
 }
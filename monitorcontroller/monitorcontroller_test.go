@@ -18,8 +18,10 @@
 package monitorcontroller_test
 
 import (
+	"encoding/json"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,17 +31,49 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/bandwidthbudget"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/fileserver"
 	"github.com/aosedge/aos_communicationmanager/monitorcontroller"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
 )
 
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
 
+type testSizeProvider struct {
+	size uint64
+}
+
+type testNetworkProvider struct {
+	networks map[string]string
+}
+
+type testTransferStatsProvider struct {
+	stats map[string]fileserver.TransferStats
+}
+
+type testDNSStatsProvider struct {
+	stats []networkmanager.InstanceDNSStats
+}
+
+type testMonitoringStorage struct {
+	records []monitorcontroller.MonitoringRecord
+}
+
+type testBandwidthBudget struct {
+	sync.Mutex
+
+	deferred bool
+	usage    map[string]uint64
+}
+
 type testMonitoringSender struct {
-	consumer       amqphandler.ConnectionEventsConsumer
-	monitoringData chan cloudprotocol.Monitoring
+	consumer           amqphandler.ConnectionEventsConsumer
+	telemetryConsumer  amqphandler.TelemetryProfileConsumer
+	monitoringData     chan cloudprotocol.Monitoring
+	unitMonitoringData chan amqphandler.UnitMonitoring
 }
 
 /***********************************************************************************************************************
@@ -65,7 +99,7 @@ func TestSendMonitorData(t *testing.T) {
 
 	controller, err := monitorcontroller.New(&config.Config{
 		Monitoring: config.Monitoring{MaxOfflineMessages: 8, SendPeriod: aostypes.Duration{Duration: 1 * time.Second}},
-	}, sender)
+	}, sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create monitoring controller: %v", err)
 	}
@@ -86,6 +120,49 @@ func TestSendMonitorData(t *testing.T) {
 	}
 }
 
+func TestMonitoringBandwidthBudget(t *testing.T) {
+	sender := newTestMonitoringSender()
+	budget := &testBandwidthBudget{deferred: true, usage: make(map[string]uint64)}
+
+	controller, err := monitorcontroller.New(&config.Config{
+		Monitoring: config.Monitoring{MaxOfflineMessages: 8, SendPeriod: aostypes.Duration{Duration: 1 * time.Second}},
+	}, sender, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create monitoring controller: %v", err)
+	}
+	defer controller.Close()
+
+	controller.SetBandwidthBudget(budget)
+	sender.consumer.CloudConnected()
+
+	inputData, expectedData := getTestMonitoringData()
+	controller.SendNodeMonitoring(inputData)
+
+	if _, err := sender.waitMonitoringData(); err == nil {
+		t.Error("Should not be monitoring data received while budget is deferred")
+	}
+
+	budget.setDeferred(false)
+
+	receivedMonitoringData, err := sender.waitMonitoringData()
+	if err != nil {
+		t.Fatalf("Error waiting for monitoring data: %v", err)
+	}
+
+	if !reflect.DeepEqual(receivedMonitoringData, expectedData) {
+		t.Errorf("Incorrect monitoring data: %v", receivedMonitoringData)
+	}
+
+	message, err := json.Marshal(receivedMonitoringData)
+	if err != nil {
+		t.Fatalf("Can't marshal monitoring data: %v", err)
+	}
+
+	if err := budget.waitUsage(bandwidthbudget.CategoryMonitoring, uint64(len(message))); err != nil {
+		t.Errorf("Wrong bandwidth usage: %v", err)
+	}
+}
+
 func TestSendMonitorOffline(t *testing.T) {
 	const (
 		numOfflineMessages = 2
@@ -100,7 +177,7 @@ func TestSendMonitorOffline(t *testing.T) {
 			MaxOfflineMessages: numOfflineMessages,
 			SendPeriod:         aostypes.Duration{Duration: 1 * time.Second},
 			MaxMessageSize:     maxMessageSize,
-		}}, sender)
+		}}, sender, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create monitoring controller: %v", err)
 	}
@@ -141,12 +218,348 @@ func TestSendMonitorOffline(t *testing.T) {
 	}
 }
 
+func TestReducedTelemetryProfile(t *testing.T) {
+	sender := newTestMonitoringSender()
+
+	controller, err := monitorcontroller.New(&config.Config{
+		Monitoring: config.Monitoring{
+			MaxOfflineMessages: 8,
+			SendPeriod:         aostypes.Duration{Duration: 1 * time.Second},
+			MaxMessageSize:     4096,
+		},
+	}, sender, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create monitoring controller: %v", err)
+	}
+	defer controller.Close()
+
+	sender.telemetryConsumer.TelemetryProfileChanged(amqphandler.TelemetryProfile{
+		Name: amqphandler.TelemetryProfileReduced, RateFactor: 2,
+	})
+
+	timestamp := time.Now().UTC()
+
+	for i, ram := range []uint64{1000, 2000, 4000} {
+		controller.SendNodeMonitoring(aostypes.NodeMonitoring{
+			NodeID: "mainNode",
+			NodeData: aostypes.MonitoringData{
+				RAM: ram, CPU: ram / 10, Timestamp: timestamp.Add(time.Duration(i) * time.Second),
+			},
+		})
+	}
+
+	sender.consumer.CloudConnected()
+
+	receivedData, err := sender.waitMonitoringData()
+	if err != nil {
+		t.Fatalf("Error waiting for monitoring data: %v", err)
+	}
+
+	expectedData := cloudprotocol.Monitoring{
+		Nodes: []cloudprotocol.NodeMonitoringData{{
+			NodeID: "mainNode",
+			Items: []aostypes.MonitoringData{
+				{RAM: 1500, CPU: 150, Timestamp: timestamp.Add(time.Second)},
+				{RAM: 4000, CPU: 400, Timestamp: timestamp.Add(2 * time.Second)},
+			},
+		}},
+	}
+
+	if !reflect.DeepEqual(receivedData, expectedData) {
+		t.Errorf("Wrong monitoring data: %v", receivedData)
+	}
+}
+
+func TestUnitRollup(t *testing.T) {
+	sender := newTestMonitoringSender()
+
+	controller, err := monitorcontroller.New(&config.Config{
+		Monitoring: config.Monitoring{
+			MaxOfflineMessages: 8,
+			SendPeriod:         aostypes.Duration{Duration: 1 * time.Minute},
+			MaxMessageSize:     4096,
+			UnitRollupPeriod:   aostypes.Duration{Duration: 1 * time.Second},
+		},
+	}, sender, nil, &testSizeProvider{size: 4096})
+	if err != nil {
+		t.Fatalf("Can't create monitoring controller: %v", err)
+	}
+	defer controller.Close()
+
+	controller.SetNetworkProvider(&testNetworkProvider{networks: map[string]string{"service1": "network1"}})
+	controller.SetTransferStatsProviders(
+		&testTransferStatsProvider{stats: map[string]fileserver.TransferStats{
+			"node1": {ActiveTransfers: 1, Transfers: 2, RejectedTransfers: 1, SentBytes: 1000},
+		}},
+		&testTransferStatsProvider{stats: map[string]fileserver.TransferStats{
+			"node1": {Transfers: 1, SentBytes: 500},
+			"node2": {ActiveTransfers: 2, SentBytes: 200},
+		}},
+	)
+
+	controller.SetDNSStatsProvider(&testDNSStatsProvider{stats: []networkmanager.InstanceDNSStats{
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+			Queries:       10, NXDomain: 2, UniqueNames: 3,
+			TopNames: []networkmanager.DNSNameStats{{Name: "service2", Queries: 6}, {Name: "unknown", Queries: 2}},
+		},
+	}})
+
+	controller.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+			NodeID:        "node1", Status: cloudprotocol.InstanceStateActive,
+		},
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 1},
+			NodeID:        "node2", Status: cloudprotocol.InstanceStateActive,
+		},
+	})
+
+	controller.ProcessUpdateInstanceStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 1},
+			NodeID:        "node2", Status: cloudprotocol.InstanceStateFailed,
+		},
+	})
+
+	timestamp := time.Now().UTC()
+
+	for i, nodeID := range []string{"node1", "node2"} {
+		value := uint64(i + 1)
+
+		controller.SendNodeMonitoring(aostypes.NodeMonitoring{
+			NodeID: nodeID,
+			NodeData: aostypes.MonitoringData{
+				Timestamp: timestamp, RAM: 1000 * value, CPU: 10 * value, Download: 100 * value, Upload: 50 * value,
+				Partitions: []aostypes.PartitionUsage{{Name: "state", UsedSize: 500 * value}},
+			},
+			InstancesData: []aostypes.InstanceMonitoring{{
+				InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: uint64(i)},
+				MonitoringData: aostypes.MonitoringData{
+					Timestamp: timestamp, Download: 10 * value, Upload: 5 * value,
+				},
+			}},
+		})
+	}
+
+	sender.consumer.CloudConnected()
+
+	select {
+	case rollup := <-sender.unitMonitoringData:
+		if rollup.CM == nil || rollup.CM.Goroutines == 0 || rollup.CM.HeapAlloc == 0 || rollup.CM.DBSize != 4096 {
+			t.Errorf("Wrong CM self-monitoring data: %v", rollup.CM)
+		}
+
+		rollup.CM = nil
+
+		expectedRollup := amqphandler.UnitMonitoring{
+			Timestamp: rollup.Timestamp, Nodes: 2, RAM: 3000, CPU: 30, Disk: 1500, Download: 300, Upload: 150,
+			Instances: map[string]int{cloudprotocol.InstanceStateActive: 1, cloudprotocol.InstanceStateFailed: 1},
+			Networks:  map[string]amqphandler.NetworkTraffic{"network1": {Instances: 2, Download: 30, Upload: 15}},
+			Transfers: map[string]amqphandler.NodeTransfers{
+				"node1": {Active: 1, Finished: 3, Rejected: 1, SentBytes: 1500},
+				"node2": {Active: 2, SentBytes: 200},
+			},
+			DNS: []amqphandler.InstanceDNS{{
+				InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+				Queries:       10, NXDomain: 2, UniqueNames: 3,
+				TopNames: map[string]uint64{"service2": 6, "unknown": 2},
+			}},
+		}
+
+		if !reflect.DeepEqual(rollup, expectedRollup) {
+			t.Errorf("Wrong unit rollup: %v", rollup)
+		}
+
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait unit rollup timeout")
+	}
+}
+
+func TestMonitoringSnapshot(t *testing.T) {
+	sender := newTestMonitoringSender()
+
+	controller, err := monitorcontroller.New(&config.Config{
+		Monitoring: config.Monitoring{
+			MaxOfflineMessages: 8,
+			SendPeriod:         aostypes.Duration{Duration: 1 * time.Minute},
+			MaxMessageSize:     65536,
+		},
+		Alerts: config.Alerts{MonitoringSnapshotWindow: aostypes.Duration{Duration: 10 * time.Second}},
+	}, sender, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create monitoring controller: %v", err)
+	}
+	defer controller.Close()
+
+	alertTime := time.Now().UTC()
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+
+	for _, offset := range []time.Duration{-15 * time.Second, -5 * time.Second, 0, 5 * time.Second} {
+		timestamp := alertTime.Add(offset)
+
+		controller.SendNodeMonitoring(aostypes.NodeMonitoring{
+			NodeID:   "node1",
+			NodeData: aostypes.MonitoringData{Timestamp: timestamp, RAM: 1000},
+			InstancesData: []aostypes.InstanceMonitoring{
+				{InstanceIdent: instanceIdent, MonitoringData: aostypes.MonitoringData{Timestamp: timestamp, RAM: 100}},
+			},
+		})
+
+		controller.SendNodeMonitoring(aostypes.NodeMonitoring{
+			NodeID:   "node2",
+			NodeData: aostypes.MonitoringData{Timestamp: timestamp, RAM: 2000},
+		})
+	}
+
+	snapshot, err := controller.GetMonitoringSnapshot(cloudprotocol.InstanceQuotaAlert{
+		AlertItem:     cloudprotocol.AlertItem{Timestamp: alertTime, Tag: cloudprotocol.AlertTagInstanceQuota},
+		InstanceIdent: instanceIdent,
+		Parameter:     "ram",
+	})
+	if err != nil {
+		t.Fatalf("Can't get monitoring snapshot: %v", err)
+	}
+
+	snapshotAlert, ok := snapshot.(monitorcontroller.MonitoringSnapshotAlert)
+	if !ok {
+		t.Fatalf("Wrong snapshot type: %T", snapshot)
+	}
+
+	if snapshotAlert.Tag != monitorcontroller.AlertTagMonitoringSnapshot ||
+		snapshotAlert.TriggerTag != cloudprotocol.AlertTagInstanceQuota || !snapshotAlert.TriggerTime.Equal(alertTime) {
+		t.Errorf("Wrong snapshot alert: %v", snapshotAlert.AlertItem)
+	}
+
+	if len(snapshotAlert.Nodes) != 1 || snapshotAlert.Nodes[0].NodeID != "node1" ||
+		len(snapshotAlert.Nodes[0].Items) != 3 {
+		t.Errorf("Wrong snapshot nodes data: %v", snapshotAlert.Nodes)
+	}
+
+	if len(snapshotAlert.ServiceInstances) != 1 || snapshotAlert.ServiceInstances[0].InstanceIdent != instanceIdent ||
+		len(snapshotAlert.ServiceInstances[0].Items) != 3 {
+		t.Errorf("Wrong snapshot instances data: %v", snapshotAlert.ServiceInstances)
+	}
+
+	if snapshot, err = controller.GetMonitoringSnapshot(cloudprotocol.SystemQuotaAlert{
+		AlertItem: cloudprotocol.AlertItem{Timestamp: alertTime, Tag: cloudprotocol.AlertTagSystemQuota},
+		NodeID:    "node2",
+		Parameter: "ram",
+	}); err != nil {
+		t.Fatalf("Can't get monitoring snapshot: %v", err)
+	}
+
+	if snapshotAlert, ok = snapshot.(monitorcontroller.MonitoringSnapshotAlert); !ok {
+		t.Fatalf("Wrong snapshot type: %T", snapshot)
+	}
+
+	if len(snapshotAlert.Nodes) != 1 || snapshotAlert.Nodes[0].NodeID != "node2" ||
+		len(snapshotAlert.Nodes[0].Items) != 3 || len(snapshotAlert.ServiceInstances) != 0 {
+		t.Errorf("Wrong snapshot data: %v", snapshotAlert)
+	}
+
+	if _, err = controller.GetMonitoringSnapshot(cloudprotocol.SystemAlert{}); err == nil {
+		t.Error("Error expected for unsupported trigger alert")
+	}
+}
+
+func TestMonitoringHistory(t *testing.T) {
+	sender := newTestMonitoringSender()
+	storage := &testMonitoringStorage{}
+
+	controller, err := monitorcontroller.New(&config.Config{
+		Monitoring: config.Monitoring{
+			MaxOfflineMessages: 8, SendPeriod: aostypes.Duration{Duration: 1 * time.Second},
+			HistoryRetention: aostypes.Duration{Duration: time.Hour},
+		},
+	}, sender, storage, nil)
+	if err != nil {
+		t.Fatalf("Can't create monitoring controller: %v", err)
+	}
+	defer controller.Close()
+
+	timestamp := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service0", SubjectID: "subj1", Instance: 1}
+
+	for i, data := range []struct {
+		offset   time.Duration
+		ram, cpu uint64
+	}{
+		{offset: 0, ram: 100, cpu: 10},
+		{offset: 10 * time.Second, ram: 300, cpu: 30},
+		{offset: 20 * time.Second, ram: 200, cpu: 20},
+		{offset: 70 * time.Second, ram: 500, cpu: 50},
+	} {
+		controller.SendNodeMonitoring(aostypes.NodeMonitoring{
+			NodeID: "node0",
+			NodeData: aostypes.MonitoringData{
+				Timestamp: timestamp.Add(data.offset), RAM: data.ram, CPU: data.cpu, Download: uint64(i),
+			},
+			InstancesData: []aostypes.InstanceMonitoring{{
+				InstanceIdent:  instanceIdent,
+				MonitoringData: aostypes.MonitoringData{Timestamp: timestamp.Add(data.offset), RAM: data.ram / 2},
+			}},
+		})
+	}
+
+	items, err := controller.GetMonitoringHistory(monitorcontroller.HistoryRequest{
+		HistoryFilter: monitorcontroller.HistoryFilter{
+			NodeID: "node0", From: timestamp, Till: timestamp.Add(time.Hour),
+		},
+		Interval: aostypes.Duration{Duration: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Can't get monitoring history: %v", err)
+	}
+
+	expectedItems := []monitorcontroller.HistoryItem{
+		{
+			Timestamp: timestamp, Samples: 3,
+			RAM:      monitorcontroller.HistoryValue{Min: 100, Avg: 200, Max: 300},
+			CPU:      monitorcontroller.HistoryValue{Min: 10, Avg: 20, Max: 30},
+			Download: monitorcontroller.HistoryValue{Min: 0, Avg: 1, Max: 2},
+		},
+		{
+			Timestamp: timestamp.Add(time.Minute), Samples: 1,
+			RAM:      monitorcontroller.HistoryValue{Min: 500, Avg: 500, Max: 500},
+			CPU:      monitorcontroller.HistoryValue{Min: 50, Avg: 50, Max: 50},
+			Download: monitorcontroller.HistoryValue{Min: 3, Avg: 3, Max: 3},
+		},
+	}
+
+	if !reflect.DeepEqual(items, expectedItems) {
+		t.Errorf("Wrong node history: %v", items)
+	}
+
+	if items, err = controller.GetMonitoringHistory(monitorcontroller.HistoryRequest{
+		HistoryFilter: monitorcontroller.HistoryFilter{
+			Instance: &instanceIdent, From: timestamp, Till: timestamp.Add(time.Hour),
+		},
+		Interval: aostypes.Duration{Duration: time.Hour},
+	}); err != nil {
+		t.Fatalf("Can't get monitoring history: %v", err)
+	}
+
+	if len(items) != 1 || items[0].Samples != 4 ||
+		items[0].RAM != (monitorcontroller.HistoryValue{Min: 50, Avg: 137, Max: 250}) {
+		t.Errorf("Wrong instance history: %v", items)
+	}
+
+	if _, err = controller.GetMonitoringHistory(monitorcontroller.HistoryRequest{}); err == nil {
+		t.Error("Error expected for request without node and instance")
+	}
+}
+
 /***********************************************************************************************************************
  * Interfaces
  **********************************************************************************************************************/
 
 func newTestMonitoringSender() *testMonitoringSender {
-	return &testMonitoringSender{monitoringData: make(chan cloudprotocol.Monitoring)}
+	return &testMonitoringSender{
+		monitoringData:     make(chan cloudprotocol.Monitoring),
+		unitMonitoringData: make(chan amqphandler.UnitMonitoring, 1),
+	}
 }
 
 func (sender *testMonitoringSender) SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error {
@@ -161,12 +574,36 @@ func (sender *testMonitoringSender) UnsubscribeFromConnectionEvents(
 	return nil
 }
 
+func (sender *testMonitoringSender) SubscribeForTelemetryProfileChanges(
+	consumer amqphandler.TelemetryProfileConsumer,
+) error {
+	sender.telemetryConsumer = consumer
+
+	return nil
+}
+
+func (sender *testMonitoringSender) UnsubscribeFromTelemetryProfileChanges(
+	consumer amqphandler.TelemetryProfileConsumer,
+) error {
+	return nil
+}
+
 func (sender *testMonitoringSender) SendMonitoringData(monitoringData cloudprotocol.Monitoring) error {
 	sender.monitoringData <- monitoringData
 
 	return nil
 }
 
+func (sender *testMonitoringSender) SendUnitMonitoringData(unitMonitoring amqphandler.UnitMonitoring) error {
+	sender.unitMonitoringData <- unitMonitoring
+
+	return nil
+}
+
+func (sender *testMonitoringSender) GetSendQueueLength() int {
+	return 0
+}
+
 func (sender *testMonitoringSender) waitMonitoringData() (cloudprotocol.Monitoring, error) {
 	select {
 	case monitoringData := <-sender.monitoringData:
@@ -177,6 +614,102 @@ func (sender *testMonitoringSender) waitMonitoringData() (cloudprotocol.Monitori
 	}
 }
 
+func (budget *testBandwidthBudget) IsDeferred() bool {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.deferred
+}
+
+func (budget *testBandwidthBudget) AddUsage(category string, size uint64) {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.usage[category] += size
+}
+
+func (budget *testBandwidthBudget) setDeferred(deferred bool) {
+	budget.Lock()
+	defer budget.Unlock()
+
+	budget.deferred = deferred
+}
+
+func (budget *testBandwidthBudget) getUsage(category string) uint64 {
+	budget.Lock()
+	defer budget.Unlock()
+
+	return budget.usage[category]
+}
+
+// waitUsage waits till usage is accounted as it is added after the data is sent.
+func (budget *testBandwidthBudget) waitUsage(category string, size uint64) error {
+	timeout := time.After(2 * time.Second)
+
+	for {
+		usage := budget.getUsage(category)
+		if usage == size {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return aoserrors.Errorf("unexpected %s usage: %d", category, usage)
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (provider *testSizeProvider) GetSize() (uint64, error) {
+	return provider.size, nil
+}
+
+func (provider *testNetworkProvider) GetInstanceNetworkID(
+	instanceIdent aostypes.InstanceIdent,
+) (networkID string, found bool) {
+	networkID, found = provider.networks[instanceIdent.ServiceID]
+
+	return networkID, found
+}
+
+func (provider *testDNSStatsProvider) GetDNSStats() []networkmanager.InstanceDNSStats {
+	return provider.stats
+}
+
+func (provider *testTransferStatsProvider) GetTransferStats() map[string]fileserver.TransferStats {
+	return provider.stats
+}
+
+func (storage *testMonitoringStorage) AddMonitoringRecords(records []monitorcontroller.MonitoringRecord) error {
+	storage.records = append(storage.records, records...)
+
+	return nil
+}
+
+func (storage *testMonitoringStorage) GetMonitoringRecords(
+	filter monitorcontroller.HistoryFilter,
+) (records []monitorcontroller.MonitoringRecord, err error) {
+	for _, record := range storage.records {
+		if record.Timestamp.Before(filter.From) || record.Timestamp.After(filter.Till) {
+			continue
+		}
+
+		if filter.NodeID != "" && record.NodeID != filter.NodeID {
+			continue
+		}
+
+		if filter.Instance != nil && record.InstanceIdent != *filter.Instance ||
+			filter.Instance == nil && record.ServiceID != "" {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
 func getTestMonitoringData() (aostypes.NodeMonitoring, cloudprotocol.Monitoring) {
 	timestamp := time.Now().UTC()
 	nodeMonitoring := cloudprotocol.NodeMonitoringData{
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitorcontroller
+
+import (
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/fileserver"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	// node data older than staleNodeDataPeriods rollup periods is not included into the rollup.
+	staleNodeDataPeriods = 3
+	// size of runtime GC pause circular buffer.
+	gcPauseBufferSize = 256
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// InstanceNetworkProvider provides instance provider network.
+type InstanceNetworkProvider interface {
+	GetInstanceNetworkID(instanceIdent aostypes.InstanceIdent) (networkID string, found bool)
+}
+
+// TransferStatsProvider provides file server transfer statistics.
+type TransferStatsProvider interface {
+	GetTransferStats() map[string]fileserver.TransferStats
+}
+
+// DNSStatsProvider provides instances DNS resolution statistics collected since previous call.
+type DNSStatsProvider interface {
+	GetDNSStats() []networkmanager.InstanceDNSStats
+}
+
+type instanceStatusKey struct {
+	aostypes.InstanceIdent
+	nodeID string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetNetworkProvider sets instance network provider used to calculate provider networks traffic in unit rollup.
+func (monitor *MonitorController) SetNetworkProvider(networkProvider InstanceNetworkProvider) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	monitor.networkProvider = networkProvider
+}
+
+// SetTransferStatsProviders sets file server transfer statistics providers used in unit rollup.
+func (monitor *MonitorController) SetTransferStatsProviders(providers ...TransferStatsProvider) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	monitor.transferStatsProviders = providers
+}
+
+// SetDNSStatsProvider sets instances DNS resolution statistics provider used in unit rollup.
+func (monitor *MonitorController) SetDNSStatsProvider(provider DNSStatsProvider) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	monitor.dnsStatsProvider = provider
+}
+
+// ProcessRunStatus updates instances states used in unit rollup.
+func (monitor *MonitorController) ProcessRunStatus(instances []cloudprotocol.InstanceStatus) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	monitor.instancesStatus = make(map[instanceStatusKey]string)
+
+	for _, instance := range instances {
+		monitor.instancesStatus[instanceStatusKey{instance.InstanceIdent, instance.NodeID}] = instance.Status
+	}
+}
+
+// ProcessUpdateInstanceStatus updates instances states used in unit rollup.
+func (monitor *MonitorController) ProcessUpdateInstanceStatus(instances []cloudprotocol.InstanceStatus) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	for _, instance := range instances {
+		monitor.instancesStatus[instanceStatusKey{instance.InstanceIdent, instance.NodeID}] = instance.Status
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (monitor *MonitorController) sendUnitRollup() {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	if !monitor.isConnected || monitor.isBandwidthDeferred() {
+		return
+	}
+
+	rollup := monitor.createUnitRollup(time.Now())
+
+	if err := monitor.monitoringSender.SendUnitMonitoringData(rollup); err != nil {
+		if !errors.Is(err, amqphandler.ErrNotConnected) {
+			log.Errorf("Can't send unit monitoring data: %v", err)
+		}
+
+		return
+	}
+
+	monitor.addBandwidthUsage(rollup)
+}
+
+func (monitor *MonitorController) createUnitRollup(timestamp time.Time) amqphandler.UnitMonitoring {
+	rollup := amqphandler.UnitMonitoring{Timestamp: timestamp, Instances: make(map[string]int)}
+
+	for nodeID, nodeData := range monitor.latestNodeData {
+		if timestamp.Sub(nodeData.Timestamp) > staleNodeDataPeriods*monitor.rollupPeriod {
+			delete(monitor.latestNodeData, nodeID)
+
+			continue
+		}
+
+		rollup.Nodes++
+		rollup.RAM += nodeData.RAM
+		rollup.CPU += nodeData.CPU
+		rollup.Download += nodeData.Download
+		rollup.Upload += nodeData.Upload
+
+		for _, partition := range nodeData.Partitions {
+			rollup.Disk += partition.UsedSize
+		}
+	}
+
+	for _, status := range monitor.instancesStatus {
+		rollup.Instances[status]++
+	}
+
+	rollup.Networks = monitor.getNetworksTraffic(timestamp)
+	rollup.Transfers = monitor.getTransfers()
+	rollup.DNS = monitor.getDNSStats()
+
+	rollup.CM = monitor.getSelfMonitoring()
+
+	return rollup
+}
+
+func (monitor *MonitorController) addRollupData(nodeMonitoring aostypes.NodeMonitoring) {
+	monitor.latestNodeData[nodeMonitoring.NodeID] = nodeMonitoring.NodeData
+
+	for _, instanceData := range nodeMonitoring.InstancesData {
+		monitor.latestInstanceData[instanceStatusKey{instanceData.InstanceIdent, nodeMonitoring.NodeID}] =
+			instanceData.MonitoringData
+	}
+}
+
+func (monitor *MonitorController) getNetworksTraffic(timestamp time.Time) map[string]amqphandler.NetworkTraffic {
+	if monitor.networkProvider == nil {
+		return nil
+	}
+
+	networks := make(map[string]amqphandler.NetworkTraffic)
+
+	for key, instanceData := range monitor.latestInstanceData {
+		if timestamp.Sub(instanceData.Timestamp) > staleNodeDataPeriods*monitor.rollupPeriod {
+			delete(monitor.latestInstanceData, key)
+
+			continue
+		}
+
+		networkID, found := monitor.networkProvider.GetInstanceNetworkID(key.InstanceIdent)
+		if !found {
+			continue
+		}
+
+		traffic := networks[networkID]
+
+		traffic.Instances++
+		traffic.Download += instanceData.Download
+		traffic.Upload += instanceData.Upload
+
+		networks[networkID] = traffic
+	}
+
+	return networks
+}
+
+func (monitor *MonitorController) getTransfers() map[string]amqphandler.NodeTransfers {
+	if len(monitor.transferStatsProviders) == 0 {
+		return nil
+	}
+
+	transfers := make(map[string]amqphandler.NodeTransfers)
+
+	for _, provider := range monitor.transferStatsProviders {
+		for nodeID, stats := range provider.GetTransferStats() {
+			nodeTransfers := transfers[nodeID]
+
+			nodeTransfers.Active += stats.ActiveTransfers
+			nodeTransfers.Finished += stats.Transfers
+			nodeTransfers.Rejected += stats.RejectedTransfers
+			nodeTransfers.SentBytes += stats.SentBytes
+
+			transfers[nodeID] = nodeTransfers
+		}
+	}
+
+	return transfers
+}
+
+func (monitor *MonitorController) getDNSStats() []amqphandler.InstanceDNS {
+	if monitor.dnsStatsProvider == nil {
+		return nil
+	}
+
+	var dnsStats []amqphandler.InstanceDNS
+
+	for _, stats := range monitor.dnsStatsProvider.GetDNSStats() {
+		instanceDNS := amqphandler.InstanceDNS{
+			InstanceIdent: stats.InstanceIdent,
+			Queries:       stats.Queries,
+			NXDomain:      stats.NXDomain,
+			UniqueNames:   stats.UniqueNames,
+		}
+
+		if len(stats.TopNames) > 0 {
+			instanceDNS.TopNames = make(map[string]uint64)
+
+			for _, name := range stats.TopNames {
+				instanceDNS.TopNames[name.Name] = name.Queries
+			}
+		}
+
+		dnsStats = append(dnsStats, instanceDNS)
+	}
+
+	return dnsStats
+}
+
+func (monitor *MonitorController) getSelfMonitoring() *amqphandler.CMMonitoring {
+	var memStats runtime.MemStats
+
+	runtime.ReadMemStats(&memStats)
+
+	selfMonitoring := &amqphandler.CMMonitoring{
+		Goroutines:      runtime.NumGoroutine(),
+		HeapAlloc:       memStats.HeapAlloc,
+		HeapSys:         memStats.HeapSys,
+		NumGC:           memStats.NumGC,
+		SendQueue:       monitor.monitoringSender.GetSendQueueLength(),
+		MonitoringQueue: len(monitor.offlineMessages),
+		HistoryQueue:    len(monitor.historyBuffer),
+	}
+
+	if memStats.NumGC > 0 {
+		selfMonitoring.GCPause = memStats.PauseNs[(memStats.NumGC+gcPauseBufferSize-1)%gcPauseBufferSize]
+	}
+
+	if monitor.sizeProvider != nil {
+		dbSize, err := monitor.sizeProvider.GetSize()
+		if err != nil {
+			log.Errorf("Can't get storage size: %v", err)
+		}
+
+		selfMonitoring.DBSize = dbSize
+	}
+
+	return selfMonitoring
+}
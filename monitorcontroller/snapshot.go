@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitorcontroller
+
+import (
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// AlertTagMonitoringSnapshot monitoring snapshot alert tag.
+const AlertTagMonitoringSnapshot = "monitoringSnapshotAlert"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// MonitoringSnapshotAlert contains not aggregated monitoring samples of the node and instances implicated by the
+// trigger alert. Samples are captured within snapshot window before and after the trigger alert.
+type MonitoringSnapshotAlert struct {
+	cloudprotocol.AlertItem
+	TriggerTag       string                                 `json:"triggerTag"`
+	TriggerTime      time.Time                              `json:"triggerTime"`
+	Nodes            []cloudprotocol.NodeMonitoringData     `json:"nodes"`
+	ServiceInstances []cloudprotocol.InstanceMonitoringData `json:"serviceInstances"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetMonitoringSnapshot returns monitoring snapshot for the trigger alert.
+func (monitor *MonitorController) GetMonitoringSnapshot(alert interface{}) (snapshot interface{}, err error) {
+	monitor.Lock()
+	defer monitor.Unlock()
+
+	if monitor.snapshotWindow <= 0 {
+		return nil, aoserrors.New("monitoring snapshot is disabled")
+	}
+
+	var (
+		alertItem cloudprotocol.AlertItem
+		nodeID    string
+		instance  *aostypes.InstanceIdent
+	)
+
+	switch concreteAlert := alert.(type) {
+	case cloudprotocol.SystemQuotaAlert:
+		alertItem, nodeID = concreteAlert.AlertItem, concreteAlert.NodeID
+
+	case cloudprotocol.InstanceQuotaAlert:
+		alertItem, instance = concreteAlert.AlertItem, &concreteAlert.InstanceIdent
+
+	default:
+		return nil, aoserrors.Errorf("unsupported snapshot trigger alert: %T", alert)
+	}
+
+	snapshotAlert := MonitoringSnapshotAlert{
+		AlertItem:   cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: AlertTagMonitoringSnapshot},
+		TriggerTag:  alertItem.Tag,
+		TriggerTime: alertItem.Timestamp,
+	}
+
+	from, till := alertItem.Timestamp.Add(-monitor.snapshotWindow), alertItem.Timestamp.Add(monitor.snapshotWindow)
+
+	for _, sample := range monitor.snapshotSamples {
+		if sample.NodeData.Timestamp.Before(from) || sample.NodeData.Timestamp.After(till) {
+			continue
+		}
+
+		if nodeID != "" && sample.NodeID != nodeID {
+			continue
+		}
+
+		if !snapshotAlert.addSample(sample, instance) {
+			continue
+		}
+
+		snapshotAlert.addNodeData(sample.NodeID, sample.NodeData)
+	}
+
+	return snapshotAlert, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (monitor *MonitorController) addSnapshotSample(nodeMonitoring aostypes.NodeMonitoring) {
+	// Snapshot is requested when snapshot window is passed after the alert, keep samples for two windows.
+	from := time.Now().Add(-2 * monitor.snapshotWindow)
+
+	for len(monitor.snapshotSamples) > 0 && monitor.snapshotSamples[0].NodeData.Timestamp.Before(from) {
+		monitor.snapshotSamples = monitor.snapshotSamples[1:]
+	}
+
+	monitor.snapshotSamples = append(monitor.snapshotSamples, nodeMonitoring)
+}
+
+// addSample adds instances data of the sample to the snapshot. If instance is specified, only data of this instance
+// is added. Returns false if the sample doesn't contain implicated data.
+func (snapshotAlert *MonitoringSnapshotAlert) addSample(
+	sample aostypes.NodeMonitoring, instance *aostypes.InstanceIdent,
+) bool {
+	found := instance == nil
+
+	for _, instanceData := range sample.InstancesData {
+		if instance != nil && instanceData.InstanceIdent != *instance {
+			continue
+		}
+
+		found = true
+
+		snapshotAlert.addInstanceData(sample.NodeID, instanceData)
+	}
+
+	return found
+}
+
+func (snapshotAlert *MonitoringSnapshotAlert) addNodeData(nodeID string, data aostypes.MonitoringData) {
+	for i, nodeData := range snapshotAlert.Nodes {
+		if nodeData.NodeID == nodeID {
+			snapshotAlert.Nodes[i].Items = append(snapshotAlert.Nodes[i].Items, data)
+
+			return
+		}
+	}
+
+	snapshotAlert.Nodes = append(snapshotAlert.Nodes, cloudprotocol.NodeMonitoringData{
+		NodeID: nodeID, Items: []aostypes.MonitoringData{data},
+	})
+}
+
+func (snapshotAlert *MonitoringSnapshotAlert) addInstanceData(
+	nodeID string, instanceData aostypes.InstanceMonitoring,
+) {
+	for i, item := range snapshotAlert.ServiceInstances {
+		if item.NodeID == nodeID && item.InstanceIdent == instanceData.InstanceIdent {
+			snapshotAlert.ServiceInstances[i].Items = append(snapshotAlert.ServiceInstances[i].Items,
+				instanceData.MonitoringData)
+
+			return
+		}
+	}
+
+	snapshotAlert.ServiceInstances = append(snapshotAlert.ServiceInstances, cloudprotocol.InstanceMonitoringData{
+		NodeID: nodeID, InstanceIdent: instanceData.InstanceIdent,
+		Items: []aostypes.MonitoringData{instanceData.MonitoringData},
+	})
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// CNIVersion CNI specification version of generated configuration.
+const CNIVersion = "1.0.0"
+
+const (
+	cniBridgePrefix     = "br-"
+	cniBridgePlugin     = "bridge"
+	cniStaticIPAM       = "static"
+	cniFirewallPlugin   = "aos-firewall"
+	cniInterfaceNameLen = 15
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CNIConfig CNI network configuration list of instance.
+type CNIConfig struct {
+	CNIVersion string            `json:"cniVersion"`
+	Name       string            `json:"name"`
+	Plugins    []json.RawMessage `json:"plugins"`
+}
+
+// CNIBridgePlugin CNI bridge plugin configuration.
+type CNIBridgePlugin struct {
+	Type      string  `json:"type"`
+	Bridge    string  `json:"bridge"`
+	IsGateway bool    `json:"isGateway"`
+	IPMasq    bool    `json:"ipMasq"`
+	Vlan      uint64  `json:"vlan,omitempty"`
+	IPAM      CNIIPAM `json:"ipam"`
+	DNS       CNIDNS  `json:"dns"`
+}
+
+// CNIIPAM CNI static IPAM configuration.
+type CNIIPAM struct {
+	Type      string       `json:"type"`
+	Addresses []CNIAddress `json:"addresses"`
+}
+
+// CNIAddress CNI static IPAM address.
+type CNIAddress struct {
+	Address string `json:"address"`
+}
+
+// CNIDNS CNI DNS configuration.
+type CNIDNS struct {
+	Nameservers []string `json:"nameservers,omitempty"`
+}
+
+// CNIFirewallPlugin Aos firewall CNI plugin configuration.
+type CNIFirewallPlugin struct {
+	Type         string            `json:"type"`
+	UUID         string            `json:"uuid"`
+	OutputAccess []CNIOutputAccess `json:"outputAccess,omitempty"`
+}
+
+// CNIOutputAccess Aos firewall CNI plugin output access rule.
+type CNIOutputAccess struct {
+	DstIP   string `json:"dstIp"`
+	DstPort string `json:"dstPort"`
+	Proto   string `json:"proto"`
+	SrcIP   string `json:"srcIp"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetCNIConfig generates CNI configuration list of instance network: bridge plugin with static IPAM and DNS servers
+// and Aos firewall plugin with firewall rules.
+func GetCNIConfig(
+	instanceIdent aostypes.InstanceIdent, networkParameters aostypes.NetworkParameters,
+) ([]byte, error) {
+	if networkParameters.NetworkID == "" {
+		return nil, aoserrors.New("network ID is not set")
+	}
+
+	ip := net.ParseIP(networkParameters.IP)
+	if ip == nil {
+		return nil, aoserrors.Errorf("invalid IP %s", networkParameters.IP)
+	}
+
+	_, subnet, err := net.ParseCIDR(networkParameters.Subnet)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	prefixLen, _ := subnet.Mask.Size()
+
+	bridge := CNIBridgePlugin{
+		Type:      cniBridgePlugin,
+		Bridge:    getBridgeName(networkParameters.NetworkID),
+		IsGateway: true,
+		IPMasq:    true,
+		Vlan:      networkParameters.VlanID,
+		IPAM: CNIIPAM{
+			Type:      cniStaticIPAM,
+			Addresses: []CNIAddress{{Address: fmt.Sprintf("%s/%d", ip, prefixLen)}},
+		},
+		DNS: CNIDNS{Nameservers: networkParameters.DNSServers},
+	}
+
+	firewall := CNIFirewallPlugin{
+		Type: cniFirewallPlugin,
+		UUID: fmt.Sprintf("%s.%s.%d", instanceIdent.ServiceID, instanceIdent.SubjectID, instanceIdent.Instance),
+	}
+
+	for _, rule := range networkParameters.FirewallRules {
+		firewall.OutputAccess = append(firewall.OutputAccess, CNIOutputAccess(rule))
+	}
+
+	cniConfig := CNIConfig{CNIVersion: CNIVersion, Name: networkParameters.NetworkID}
+
+	for _, plugin := range []interface{}{bridge, firewall} {
+		rawPlugin, err := json.Marshal(plugin)
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		cniConfig.Plugins = append(cniConfig.Plugins, rawPlugin)
+	}
+
+	rawConfig, err := json.Marshal(cniConfig)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return rawConfig, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func getBridgeName(networkID string) string {
+	name := cniBridgePrefix + networkID
+
+	if len(name) > cniInterfaceNameLen {
+		name = name[:cniInterfaceNameLen]
+	}
+
+	return name
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"sort"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Connectivity check reasons.
+const (
+	ConnectivitySameNetwork    = "same network"
+	ConnectivityFirewallRule   = "allowed by firewall rule"
+	ConnectivityNoRule         = "no firewall rule"
+	ConnectivityPortNotExposed = "port is not exposed"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// DNSRecord DNS record.
+type DNSRecord struct {
+	IP    string   `json:"ip"`
+	Hosts []string `json:"hosts"`
+}
+
+// ConnectivityRequest connectivity check request.
+type ConnectivityRequest struct {
+	Source      aostypes.InstanceIdent `json:"source"`
+	Destination aostypes.InstanceIdent `json:"destination"`
+	Port        string                 `json:"port,omitempty"`
+	Protocol    string                 `json:"protocol,omitempty"`
+}
+
+// ConnectivityResult connectivity check result.
+type ConnectivityResult struct {
+	Reachable     bool   `json:"reachable"`
+	Reason        string `json:"reason"`
+	SourceIP      string `json:"sourceIp"`
+	DestinationIP string `json:"destinationIp"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetProviderNetworks returns provider networks of all nodes.
+func (manager *NetworkManager) GetProviderNetworks() []NetworkParametersStorage {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	networks := make([]NetworkParametersStorage, 0)
+
+	for _, networkInfos := range manager.providerNetworks {
+		networks = append(networks, networkInfos...)
+	}
+
+	sort.Slice(networks, func(i, j int) bool {
+		if networks[i].NetworkID != networks[j].NetworkID {
+			return networks[i].NetworkID < networks[j].NetworkID
+		}
+
+		return networks[i].NodeID < networks[j].NodeID
+	})
+
+	return networks
+}
+
+// GetInstanceNetworks returns instances network parameters with firewall rules.
+func (manager *NetworkManager) GetInstanceNetworks() []InstanceNetworkInfo {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	instances := make([]InstanceNetworkInfo, 0)
+
+	for _, instancesData := range manager.instancesData {
+		for _, instanceNetworkInfo := range instancesData {
+			instances = append(instances, instanceNetworkInfo)
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].IP < instances[j].IP
+	})
+
+	return instances
+}
+
+// GetDNSRecords returns DNS records served by DNS server.
+func (manager *NetworkManager) GetDNSRecords() []DNSRecord {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	dnsHosts := manager.dns.getHosts()
+	records := make([]DNSRecord, 0, len(dnsHosts))
+
+	for ip, hosts := range dnsHosts {
+		records = append(records, DNSRecord{IP: ip, Hosts: hosts})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].IP < records[j].IP
+	})
+
+	return records
+}
+
+// CheckConnectivity checks if destination instance is reachable from source instance according to instances
+// networks and firewall rules.
+func (manager *NetworkManager) CheckConnectivity(request ConnectivityRequest) (ConnectivityResult, error) {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	source, found := manager.getInstanceNetworkInfo(request.Source)
+	if !found {
+		return ConnectivityResult{}, aoserrors.New("source instance network not found")
+	}
+
+	destination, found := manager.getInstanceNetworkInfo(request.Destination)
+	if !found {
+		return ConnectivityResult{}, aoserrors.New("destination instance network not found")
+	}
+
+	result := ConnectivityResult{SourceIP: source.IP, DestinationIP: destination.IP}
+
+	if source.NetworkID == destination.NetworkID &&
+		manager.isolation[source.NetworkID].Level != config.IsolationStrict {
+		result.Reachable, result.Reason = true, ConnectivitySameNetwork
+
+		return result, nil
+	}
+
+	protocol := request.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	if request.Port != "" && !ruleExists(destination, request.Port, protocol) {
+		result.Reason = ConnectivityPortNotExposed
+
+		return result, nil
+	}
+
+	for _, rule := range source.FirewallRules {
+		if rule.DstIP == destination.IP && rule.Proto == protocol &&
+			(request.Port == "" || rule.DstPort == request.Port) {
+			result.Reachable, result.Reason = true, ConnectivityFirewallRule
+
+			return result, nil
+		}
+	}
+
+	result.Reason = ConnectivityNoRule
+
+	return result, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *NetworkManager) getInstanceNetworkInfo(
+	instanceIdent aostypes.InstanceIdent,
+) (instanceNetworkInfo InstanceNetworkInfo, found bool) {
+	for _, instancesData := range manager.instancesData {
+		if instanceNetworkInfo, found = instancesData[instanceIdent]; found {
+			return instanceNetworkInfo, true
+		}
+	}
+
+	return InstanceNetworkInfo{}, false
+}
+
+func (manager *NetworkManager) setInstanceFirewallRules(
+	networkID string, instanceIdent aostypes.InstanceIdent, rules []aostypes.FirewallRule,
+) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	instanceNetworkInfo, ok := manager.instancesData[networkID][instanceIdent]
+	if !ok {
+		return
+	}
+
+	instanceNetworkInfo.FirewallRules = rules
+	manager.instancesData[networkID][instanceIdent] = instanceNetworkInfo
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	queryLogFileName = "querylog"
+
+	// query log is truncated when it exceeds this size.
+	maxQueryLogSize = 1024 * 1024
+	// distinct names kept per client, names above the limit are counted in queries only.
+	maxClientNames = 1000
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// DNSNameStats DNS name queries statistics.
+type DNSNameStats struct {
+	Name    string `json:"name"`
+	Queries uint64 `json:"queries"`
+}
+
+// InstanceDNSStats instance DNS resolution statistics.
+type InstanceDNSStats struct {
+	aostypes.InstanceIdent
+	Queries  uint64 `json:"queries"`
+	NXDomain uint64 `json:"nxDomain"`
+	// UniqueNames number of distinct queried names, saturates at max tracked names.
+	UniqueNames int            `json:"uniqueNames"`
+	TopNames    []DNSNameStats `json:"topNames"`
+}
+
+type dnsClientStats struct {
+	queries  uint64
+	nxDomain uint64
+	names    map[string]uint64
+}
+
+type dnsQueryLog struct {
+	sync.Mutex
+	logFile    string
+	offset     int64
+	topNames   int
+	clients    map[string]*dnsClientStats
+	cancelFunc context.CancelFunc
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetDNSStats returns instances DNS resolution statistics collected since previous call.
+func (manager *NetworkManager) GetDNSStats() []InstanceDNSStats {
+	if manager.queryLog == nil {
+		return nil
+	}
+
+	clients := manager.queryLog.popStats()
+
+	manager.RLock()
+	defer manager.RUnlock()
+
+	var dnsStats []InstanceDNSStats
+
+	for _, instances := range manager.instancesData {
+		for instanceIdent, instanceInfo := range instances {
+			clientStats, ok := clients[instanceInfo.IP]
+			if !ok {
+				continue
+			}
+
+			dnsStats = append(dnsStats, InstanceDNSStats{
+				InstanceIdent: instanceIdent,
+				Queries:       clientStats.queries,
+				NXDomain:      clientStats.nxDomain,
+				UniqueNames:   len(clientStats.names),
+				TopNames:      clientStats.getTopNames(manager.queryLog.topNames),
+			})
+		}
+	}
+
+	slices.SortFunc(dnsStats, func(a, b InstanceDNSStats) int {
+		return cmp.Or(cmp.Compare(a.ServiceID, b.ServiceID), cmp.Compare(a.SubjectID, b.SubjectID),
+			cmp.Compare(a.Instance, b.Instance))
+	})
+
+	return dnsStats
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newDNSQueryLog(logFile string, cfg config.DNSQueryLog) *dnsQueryLog {
+	log.WithField("file", logFile).Debug("Start DNS query log")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	queryLog := &dnsQueryLog{
+		logFile:    logFile,
+		topNames:   cfg.TopNames,
+		clients:    make(map[string]*dnsClientStats),
+		cancelFunc: cancelFunc,
+	}
+
+	if cfg.PollPeriod.Duration > 0 {
+		go queryLog.run(ctx, cfg.PollPeriod.Duration)
+	}
+
+	return queryLog
+}
+
+func (queryLog *dnsQueryLog) close() {
+	queryLog.cancelFunc()
+}
+
+func (queryLog *dnsQueryLog) run(ctx context.Context, pollPeriod time.Duration) {
+	ticker := time.NewTicker(pollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			queryLog.Lock()
+			queryLog.readLog()
+			queryLog.Unlock()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (queryLog *dnsQueryLog) popStats() map[string]*dnsClientStats {
+	queryLog.Lock()
+	defer queryLog.Unlock()
+
+	queryLog.readLog()
+
+	clients := queryLog.clients
+
+	queryLog.clients = make(map[string]*dnsClientStats)
+
+	return clients
+}
+
+func (queryLog *dnsQueryLog) readLog() {
+	if err := queryLog.processLog(); err != nil {
+		log.Errorf("Can't read DNS query log: %v", err)
+	}
+
+	if queryLog.offset < maxQueryLogSize {
+		return
+	}
+
+	// dnsmasq opens log file in append mode and continues writing from the beginning of truncated file. Lines written
+	// between the last read and truncation are lost.
+	if err := os.Truncate(queryLog.logFile, 0); err != nil {
+		log.Errorf("Can't truncate DNS query log: %v", err)
+
+		return
+	}
+
+	queryLog.offset = 0
+}
+
+func (queryLog *dnsQueryLog) processLog() error {
+	file, err := os.Open(queryLog.logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	// Log was truncated or recreated
+	if info.Size() < queryLog.offset {
+		queryLog.offset = 0
+	}
+
+	if _, err = file.Seek(queryLog.offset, io.SeekStart); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	reader := bufio.NewReader(file)
+
+	for {
+		// Incomplete line is processed on next read
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+
+		queryLog.offset += int64(len(line))
+
+		queryLog.processLine(line)
+	}
+}
+
+// processLine processes dnsmasq extra query log line:
+// "<date> dnsmasq[<pid>]: <serial> <client IP>/<port> <event> <name> [from|is|to ...]".
+func (queryLog *dnsQueryLog) processLine(line string) {
+	_, message, found := strings.Cut(line, "]: ")
+	if !found {
+		return
+	}
+
+	fields := strings.Fields(message)
+	if len(fields) < 4 {
+		return
+	}
+
+	if _, err := strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return
+	}
+
+	slash := strings.LastIndex(fields[1], "/")
+	if slash <= 0 {
+		return
+	}
+
+	clientIP, event, name := fields[1][:slash], fields[2], strings.ToLower(fields[3])
+
+	clientStats, ok := queryLog.clients[clientIP]
+	if !ok {
+		clientStats = &dnsClientStats{names: make(map[string]uint64)}
+		queryLog.clients[clientIP] = clientStats
+	}
+
+	switch {
+	case strings.HasPrefix(event, "query["):
+		clientStats.queries++
+
+		if _, ok := clientStats.names[name]; ok || len(clientStats.names) < maxClientNames {
+			clientStats.names[name]++
+		}
+
+	case event == "reply" || event == "cached" || event == "config":
+		if fields[len(fields)-1] == "NXDOMAIN" {
+			clientStats.nxDomain++
+		}
+	}
+}
+
+func (clientStats *dnsClientStats) getTopNames(count int) []DNSNameStats {
+	names := make([]DNSNameStats, 0, len(clientStats.names))
+
+	for name, queries := range clientStats.names {
+		names = append(names, DNSNameStats{Name: name, Queries: queries})
+	}
+
+	slices.SortFunc(names, func(a, b DNSNameStats) int {
+		return cmp.Or(cmp.Compare(b.Queries, a.Queries), cmp.Compare(a.Name, b.Name))
+	})
+
+	if count = max(count, 0); len(names) > count {
+		names = names[:count]
+	}
+
+	return names
+}
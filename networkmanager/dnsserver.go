@@ -25,11 +25,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/jackpal/gateway"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
@@ -40,6 +44,9 @@ const (
 	hostsFileName = "addnhosts"
 	pidFileName   = "pidfile"
 
+	stopTimeout    = 5 * time.Second
+	stopPollPeriod = 100 * time.Millisecond
+
 	dnsMasqTemplate = `## WARNING: THIS IS AN AUTOGENERATED FILE
 ## AND SHOULD NOT BE EDITED MANUALLY AS IT
 ## LIKELY TO AUTOMATICALLY BE REPLACED.
@@ -51,16 +58,43 @@ except-interface=lo
 bind-dynamic
 no-hosts
 listen-address={{.IPAddress}}
-addn-hosts={{.AddOnHostsFile}}`
+addn-hosts={{.AddOnHostsFile}}
+conf-file={{.RecordsFile}}{{if .QueryLogFile}}
+log-queries=extra
+log-facility={{.QueryLogFile}}{{end}}{{if .DNSSEC}}
+dnssec{{range .TrustAnchors}}
+trust-anchor={{.}}{{end}}
+dnssec-check-unsigned{{end}}{{if .Hardened}}
+stop-dns-rebind
+rebind-localhost-ok
+min-port={{.MinPort}}{{end}}{{if .LocalTTL}}
+local-ttl={{.LocalTTL}}{{end}}{{if .NoNegCache}}
+no-negcache{{else if .NegTTL}}
+neg-ttl={{.NegTTL}}{{end}}`
 )
 
+// dnsServer manages dnsmasq instance. Hosts, endpoints and restart flag are guarded by the mutex as DNS records are
+// read by diagnostics concurrently with network updates.
 type dnsServer struct {
-	AddOnHostsFile string
-	binary         string
-	configFile     string
-	PidFile        string
-	IPAddress      string
-	hosts          map[string][]string
+	sync.Mutex
+
+	AddOnHostsFile  string
+	RecordsFile     string
+	QueryLogFile    string
+	binary          string
+	configFile      string
+	PidFile         string
+	IPAddress       string
+	DNSSEC          bool
+	TrustAnchors    []string
+	Hardened        bool
+	MinPort         int
+	LocalTTL        int64
+	NegTTL          int64
+	NoNegCache      bool
+	hosts           map[string][]string
+	endpoints       map[string][]ServiceEndpoint
+	restartRequired bool
 }
 
 /***********************************************************************************************************************
@@ -82,7 +116,9 @@ var errProcessNotExist = aoserrors.New("process not exist")
  * Private
  **********************************************************************************************************************/
 
-func newDNSServer(networkDir string, dnsIP string) (*dnsServer, error) {
+func newDNSServer(
+	networkDir string, dnsIP string, queryLog bool, security config.DNSSecurity, cache config.DNSCache,
+) (*dnsServer, error) {
 	dnsMasqBinary, err := LookPath("dnsmasq")
 	if err != nil {
 		return nil, aoserrors.New("dnsmasq binary not found")
@@ -105,15 +141,32 @@ func newDNSServer(networkDir string, dnsIP string) (*dnsServer, error) {
 		configFile:     filepath.Join(networkDir, confFileName),
 		PidFile:        filepath.Join(networkDir, pidFileName),
 		AddOnHostsFile: filepath.Join(networkDir, hostsFileName),
+		RecordsFile:    filepath.Join(networkDir, recordsFileName),
 		IPAddress:      dnsIP,
+		DNSSEC:         security.DNSSEC,
+		TrustAnchors:   security.TrustAnchors,
+		Hardened:       security.Hardened,
+		MinPort:        security.MinPort,
+		LocalTTL:       int64(cache.RecordTTL.Seconds()),
+		NegTTL:         int64(cache.NegativeTTL.Seconds()),
+		NoNegCache:     cache.NoNegativeCache,
 		binary:         dnsMasqBinary,
 		hosts:          make(map[string][]string),
+		endpoints:      make(map[string][]ServiceEndpoint),
+	}
+
+	if queryLog {
+		dnsServer.QueryLogFile = filepath.Join(networkDir, queryLogFileName)
 	}
 
 	if err := dnsServer.prepareDNSConfFile(); err != nil {
 		return nil, err
 	}
 
+	if err := dnsServer.rewriteRecordsFile(); err != nil {
+		return nil, err
+	}
+
 	if err := dnsServer.restart(); err != nil {
 		return nil, err
 	}
@@ -122,6 +175,9 @@ func newDNSServer(networkDir string, dnsIP string) (*dnsServer, error) {
 }
 
 func (dns *dnsServer) addHosts(hosts []string, ip string) error {
+	dns.Lock()
+	defer dns.Unlock()
+
 	for _, host := range hosts {
 		for dnsIP, existHosts := range dns.hosts {
 			if ip == dnsIP {
@@ -141,7 +197,33 @@ func (dns *dnsServer) addHosts(hosts []string, ip string) error {
 	return nil
 }
 
+// removeHosts removes hosts and service endpoints of the IP.
+func (dns *dnsServer) removeHosts(ip string) {
+	dns.Lock()
+	defer dns.Unlock()
+
+	delete(dns.hosts, ip)
+	delete(dns.endpoints, ip)
+}
+
+// getHosts returns copy of hosts by IP.
+func (dns *dnsServer) getHosts() map[string][]string {
+	dns.Lock()
+	defer dns.Unlock()
+
+	hosts := make(map[string][]string, len(dns.hosts))
+
+	for ip, ipHosts := range dns.hosts {
+		hosts[ip] = slices.Clone(ipHosts)
+	}
+
+	return hosts
+}
+
 func (dns *dnsServer) rewriteHostsFile() error {
+	dns.Lock()
+	defer dns.Unlock()
+
 	f, err := os.OpenFile(dns.AddOnHostsFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
 		return aoserrors.Wrap(err)
@@ -171,19 +253,28 @@ func (dns *dnsServer) rewriteHostsFile() error {
 }
 
 func (dns *dnsServer) cleanCacheHosts() {
+	dns.Lock()
+	defer dns.Unlock()
+
 	dns.hosts = make(map[string][]string)
+	dns.endpoints = make(map[string][]ServiceEndpoint)
 }
 
 func (dns *dnsServer) prepareDNSConfFile() error {
-	if _, err := os.Stat(dns.configFile); err == nil {
-		return nil
-	}
-
 	newConfig, err := dns.generateDNSMasqConfig()
 	if err != nil {
 		return aoserrors.Wrap(err)
 	}
 
+	// Config file is rewritten if it is generated by previous version
+	if currentConfig, err := os.ReadFile(dns.configFile); err == nil && bytes.Equal(currentConfig, newConfig) {
+		return nil
+	}
+
+	dns.Lock()
+	dns.restartRequired = true
+	dns.Unlock()
+
 	return aoserrors.Wrap(os.WriteFile(dns.configFile, newConfig, 0o600))
 }
 
@@ -205,12 +296,24 @@ func (dns *dnsServer) generateDNSMasqConfig() ([]byte, error) {
 }
 
 func (dns *dnsServer) restart() error {
+	dns.Lock()
+	defer dns.Unlock()
+
 	process, _ := dns.findServerProcess()
 
 	if process == nil || !dns.isRunning(process) {
 		return dns.start()
 	}
 
+	// SIGHUP reloads hosts only, config file and SRV, TXT records are read on start
+	if dns.restartRequired {
+		if err := dns.stop(process); err != nil {
+			return err
+		}
+
+		return dns.start()
+	}
+
 	return restartProcess(process)
 }
 
@@ -262,9 +365,25 @@ func (dns *dnsServer) start() error {
 		return aoserrors.Errorf("message: %s, err: %v", output, err)
 	}
 
+	dns.restartRequired = false
+
 	return nil
 }
 
+func (dns *dnsServer) stop(process *os.Process) error {
+	if err := process.Signal(unix.SIGTERM); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for start := time.Now(); time.Since(start) < stopTimeout; time.Sleep(stopPollPeriod) {
+		if !dns.isRunning(process) {
+			return nil
+		}
+	}
+
+	return aoserrors.New("DNS server stop timeout")
+}
+
 func restartProcess(pid *os.Process) error {
 	if err := pid.Signal(unix.SIGHUP); err != nil {
 		return aoserrors.Wrap(err)
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const daysInWeek = 7
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// runFirewallSchedule pushes provider networks with regenerated scheduled rules to nodes at each window boundary.
+func (manager *NetworkManager) runFirewallSchedule(ctx context.Context) {
+	for {
+		next, ok := nextScheduleBoundary(time.Now(), manager.scheduledRules)
+		if !ok {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+			manager.pushScheduledRules()
+
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+	}
+}
+
+func (manager *NetworkManager) pushScheduledRules() {
+	manager.Lock()
+	defer manager.Unlock()
+
+	nodesNetworks := make(map[string][]aostypes.NetworkParameters)
+
+	for _, networksInfo := range manager.providerNetworks {
+		for _, networkInfo := range networksInfo {
+			if networkInfo.NodeID != "" {
+				nodesNetworks[networkInfo.NodeID] = append(
+					nodesNetworks[networkInfo.NodeID], networkInfo.NetworkParameters)
+			}
+		}
+	}
+
+	now := time.Now()
+
+	for nodeID, networkParameters := range nodesNetworks {
+		if !slices.ContainsFunc(networkParameters, func(params aostypes.NetworkParameters) bool {
+			return manager.hasScheduledRules(params.NetworkID)
+		}) {
+			continue
+		}
+
+		slices.SortFunc(networkParameters, func(a, b aostypes.NetworkParameters) int {
+			return cmp.Compare(a.NetworkID, b.NetworkID)
+		})
+
+		log.WithField("nodeID", nodeID).Debug("Push scheduled firewall rules")
+
+		if err := manager.updateNodeNetwork(
+			nodeID, manager.applyScheduledRules(networkParameters, now)); err != nil {
+			log.WithField("nodeID", nodeID).Errorf("Can't update node network: %v", err)
+		}
+	}
+}
+
+func (manager *NetworkManager) hasScheduledRules(networkID string) bool {
+	return slices.ContainsFunc(manager.scheduledRules, func(rule config.ScheduledFirewallRule) bool {
+		return rule.NetworkID == networkID
+	})
+}
+
+// applyScheduledRules sets firewall rules of provider networks to the rules which windows are active at the time.
+func (manager *NetworkManager) applyScheduledRules(
+	networkParameters []aostypes.NetworkParameters, now time.Time,
+) []aostypes.NetworkParameters {
+	for i, params := range networkParameters {
+		if !manager.hasScheduledRules(params.NetworkID) {
+			continue
+		}
+
+		params.FirewallRules = nil
+
+		for _, rule := range manager.scheduledRules {
+			if rule.NetworkID != params.NetworkID || !inTimetable(now, rule.Timetable) {
+				continue
+			}
+
+			params.FirewallRules = append(params.FirewallRules, aostypes.FirewallRule{
+				DstIP:   params.IP,
+				DstPort: rule.DstPort,
+				Proto:   rule.Proto,
+				SrcIP:   rule.SrcIP,
+			})
+		}
+
+		networkParameters[i] = params
+	}
+
+	return networkParameters
+}
+
+func inTimetable(now time.Time, timetable []cloudprotocol.TimetableEntry) bool {
+	for _, entry := range timetable {
+		if weekday(entry.DayOfWeek) != now.Weekday() {
+			continue
+		}
+
+		for _, slot := range entry.TimeSlots {
+			start, end := slotTimes(now, slot)
+
+			if !now.Before(start) && now.Before(end) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nextScheduleBoundary returns the nearest start or end of scheduled rules windows after the time.
+func nextScheduleBoundary(now time.Time, rules []config.ScheduledFirewallRule) (next time.Time, found bool) {
+	for day := 0; day <= daysInWeek; day++ {
+		date := now.AddDate(0, 0, day)
+
+		for _, rule := range rules {
+			for _, entry := range rule.Timetable {
+				if weekday(entry.DayOfWeek) != date.Weekday() {
+					continue
+				}
+
+				for _, slot := range entry.TimeSlots {
+					start, end := slotTimes(date, slot)
+
+					for _, boundary := range []time.Time{start, end} {
+						if boundary.After(now) && (!found || boundary.Before(next)) {
+							next, found = boundary, true
+						}
+					}
+				}
+			}
+		}
+
+		if found {
+			return next, true
+		}
+	}
+
+	return next, false
+}
+
+func slotTimes(date time.Time, slot cloudprotocol.TimeSlot) (start, end time.Time) {
+	start = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.Start.Hour(), slot.Start.Minute(), slot.Start.Second(), 0, time.Local) //nolint:gosmopolitan
+	end = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.End.Hour(), slot.End.Minute(), slot.End.Second(), 0, time.Local) //nolint:gosmopolitan
+
+	return start, end
+}
+
+// weekday converts timetable day of week (1 - Monday, 7 - Sunday) to time weekday.
+func weekday(dayOfWeek uint) time.Weekday {
+	return time.Weekday(dayOfWeek % daysInWeek)
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Firewall simulation verdicts.
+const (
+	FirewallVerdictAllow = "allow"
+	FirewallVerdictDeny  = "deny"
+)
+
+// ConnectivitySharedNetwork instances share the same IP of shared network.
+const ConnectivitySharedNetwork = "shared network"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// FirewallSimulationRequest firewall rules simulation request.
+type FirewallSimulationRequest struct {
+	Source      aostypes.InstanceIdent `json:"source"`
+	Destination aostypes.InstanceIdent `json:"destination"`
+	Port        string                 `json:"port"`
+	Protocol    string                 `json:"protocol,omitempty"`
+}
+
+// FirewallSimulationResult firewall rules simulation result. Matched rule is set if connection is allowed by firewall
+// rule. If connection is denied, candidate rules contain source rules to the destination for other ports or
+// protocols.
+type FirewallSimulationResult struct {
+	Verdict        string                  `json:"verdict"`
+	Reason         string                  `json:"reason"`
+	SourceIP       string                  `json:"sourceIp"`
+	DestinationIP  string                  `json:"destinationIp"`
+	MatchedRule    *aostypes.FirewallRule  `json:"matchedRule,omitempty"`
+	CandidateRules []aostypes.FirewallRule `json:"candidateRules,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SimulateFirewall evaluates configured firewall rules for connection from source instance to destination instance
+// port and returns allow or deny verdict with the matching rule.
+func (manager *NetworkManager) SimulateFirewall(
+	request FirewallSimulationRequest,
+) (FirewallSimulationResult, error) {
+	if request.Port == "" {
+		return FirewallSimulationResult{}, aoserrors.New("port is not set")
+	}
+
+	protocol := request.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	manager.RLock()
+	defer manager.RUnlock()
+
+	source, found := manager.getInstanceNetworkInfo(request.Source)
+	if !found {
+		return FirewallSimulationResult{}, aoserrors.New("source instance network not found")
+	}
+
+	destination, found := manager.getInstanceNetworkInfo(request.Destination)
+	if !found {
+		return FirewallSimulationResult{}, aoserrors.New("destination instance network not found")
+	}
+
+	result := FirewallSimulationResult{
+		Verdict: FirewallVerdictDeny, SourceIP: source.IP, DestinationIP: destination.IP,
+	}
+
+	switch {
+	case source.NetworkID == destination.NetworkID && source.IP == destination.IP:
+		result.Verdict, result.Reason = FirewallVerdictAllow, ConnectivitySharedNetwork
+
+		return result, nil
+
+	case source.NetworkID == destination.NetworkID &&
+		manager.isolation[source.NetworkID].Level != config.IsolationStrict:
+		result.Verdict, result.Reason = FirewallVerdictAllow, ConnectivitySameNetwork
+
+		return result, nil
+
+	case !ruleExists(destination, request.Port, protocol):
+		result.Reason = ConnectivityPortNotExposed
+
+		return result, nil
+	}
+
+	for _, rule := range source.FirewallRules {
+		if rule.DstIP != destination.IP {
+			continue
+		}
+
+		if rule.Proto == protocol && portMatches(rule.DstPort, request.Port) {
+			matchedRule := rule
+
+			result.Verdict, result.Reason, result.MatchedRule = FirewallVerdictAllow, ConnectivityFirewallRule,
+				&matchedRule
+			result.CandidateRules = nil
+
+			return result, nil
+		}
+
+		result.CandidateRules = append(result.CandidateRules, rule)
+	}
+
+	result.Reason = ConnectivityNoRule
+
+	return result, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// portMatches checks if port matches rule port which is either single port or "<first>-<last>" range.
+func portMatches(rulePort, port string) bool {
+	if rulePort == port {
+		return true
+	}
+
+	first, last, isRange := strings.Cut(rulePort, "-")
+	if !isRange {
+		return false
+	}
+
+	portValue, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return false
+	}
+
+	firstValue, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return false
+	}
+
+	lastValue, err := strconv.ParseUint(last, 10, 16)
+	if err != nil {
+		return false
+	}
+
+	return portValue >= firstValue && portValue <= lastValue
+}
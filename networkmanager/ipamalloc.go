@@ -20,12 +20,17 @@
 package networkmanager
 
 import (
+	"encoding/binary"
+	"math/rand"
 	"net"
+	"slices"
 	"sync"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/apparentlymart/go-cidr/cidr"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
 )
 
 /***********************************************************************************************************************
@@ -41,21 +46,30 @@ type ipSubnet struct {
 	sync.Mutex
 	predefinedPrivateNetworks []*net.IPNet
 	usedIPSubnets             map[string]subnetwork
+	allocation                map[string]config.IPAllocation
 }
 
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
 
-func newIPam() (ipam *ipSubnet, err error) {
+func newIPam(
+	reservedSubnets []*net.IPNet, allocation map[string]config.IPAllocation,
+) (ipam *ipSubnet, err error) {
 	log.Debug("Create ipam allocator")
 
-	ipam = &ipSubnet{}
+	ipam = &ipSubnet{allocation: allocation}
 
 	if ipam.predefinedPrivateNetworks, err = makeNetPools(); err != nil {
 		return nil, err
 	}
 
+	ipam.predefinedPrivateNetworks = slices.DeleteFunc(ipam.predefinedPrivateNetworks, func(ipNetPool *net.IPNet) bool {
+		return slices.ContainsFunc(reservedSubnets, func(reserved *net.IPNet) bool {
+			return ipNetPool.Contains(reserved.IP) || reserved.Contains(ipNetPool.IP)
+		})
+	})
+
 	ipam.usedIPSubnets = make(map[string]subnetwork)
 
 	return ipam, nil
@@ -79,17 +93,61 @@ func (ipam *ipSubnet) requestIPNetPool(networkID string) (allocIPNet *net.IPNet,
 	return allocIPNet, nil
 }
 
-func (ipam *ipSubnet) findAvailableIP(networkID string) (ip net.IP, err error) {
+// findAvailableIP allocates IP of the network subnet according to the network allocation strategy. Instances of
+// services with sticky range get IPs of the range only, IPs of sticky ranges are not allocated to others.
+func (ipam *ipSubnet) findAvailableIP(networkID, serviceID string) (ip net.IP, err error) {
 	subnet, ok := ipam.usedIPSubnets[networkID]
 	if !ok {
 		return ip, aoserrors.Errorf("incorrect subnet %s", networkID)
 	}
 
-	if len(subnet.ips) == 0 {
+	allocation := ipam.allocation[networkID]
+	stickyRange, sticky := getStickyRange(allocation, serviceID)
+
+	var candidates []int
+
+	for i, ip := range subnet.ips {
+		offset := ipOffset(subnet.ipNet, ip)
+
+		if sticky {
+			if offset >= stickyRange.First && offset <= stickyRange.Last {
+				candidates = append(candidates, i)
+			}
+
+			continue
+		}
+
+		if !slices.ContainsFunc(allocation.StickyRanges, func(stickyRange config.StickyIPRange) bool {
+			return offset >= stickyRange.First && offset <= stickyRange.Last
+		}) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if sticky {
+			return ip, aoserrors.Errorf("no available ip in sticky range %d..%d", stickyRange.First, stickyRange.Last)
+		}
+
 		return ip, aoserrors.Errorf("no available ip")
 	}
 
-	ip, subnet.ips = subnet.ips[0], subnet.ips[1:]
+	index := candidates[0]
+
+	switch allocation.Strategy {
+	case config.IPAllocationRandom:
+		index = candidates[rand.Intn(len(candidates))] //nolint:gosec // IP randomization is not a security measure
+
+	default:
+		for _, candidate := range candidates[1:] {
+			if ipOffset(subnet.ipNet, subnet.ips[candidate]) < ipOffset(subnet.ipNet, subnet.ips[index]) {
+				index = candidate
+			}
+		}
+	}
+
+	ip = subnet.ips[index]
+	subnet.ips = slices.Delete(subnet.ips, index, index+1)
 
 	ipam.usedIPSubnets[networkID] = subnet
 
@@ -141,7 +199,7 @@ func (ipam *ipSubnet) findUnusedIPSubnet() (unusedIPNet *net.IPNet, err error) {
 	return nil, aoserrors.Errorf("no available network")
 }
 
-func (ipam *ipSubnet) prepareSubnet(networkID string) (allocIPNet *net.IPNet, ip net.IP, err error) {
+func (ipam *ipSubnet) prepareSubnet(networkID, serviceID string) (allocIPNet *net.IPNet, ip net.IP, err error) {
 	ipam.Lock()
 	defer ipam.Unlock()
 
@@ -150,7 +208,7 @@ func (ipam *ipSubnet) prepareSubnet(networkID string) (allocIPNet *net.IPNet, ip
 		return nil, ip, err
 	}
 
-	ip, err = ipam.findAvailableIP(networkID)
+	ip, err = ipam.findAvailableIP(networkID, serviceID)
 	if err != nil {
 		return nil, ip, err
 	}
@@ -172,52 +230,57 @@ func (ipam *ipSubnet) getAvailableSubnet(networkID string) (*net.IPNet, error) {
 	return subnet.ipNet, nil
 }
 
-func (ipam *ipSubnet) removeAllocatedSubnets(networks []NetworkParametersStorage,
-	networkInstances []InstanceNetworkInfo,
-) {
+func (ipam *ipSubnet) restoreAllocations(allocations []IPAllocation) {
 	ipam.Lock()
 	defer ipam.Unlock()
 
-	for _, network := range networks {
-		_, ipNet, err := net.ParseCIDR(network.Subnet)
+	for _, allocation := range allocations {
+		if allocation.IP != "" {
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(allocation.Subnet)
 		if err != nil {
-			log.Errorf("Failed to parse subnet %s: %v", network.Subnet, err)
+			log.Errorf("Failed to parse subnet %s: %v", allocation.Subnet, err)
 
 			continue
 		}
 
-		for i, ipNetPool := range ipam.predefinedPrivateNetworks {
+		inPool := false
+
+		ipam.predefinedPrivateNetworks = slices.DeleteFunc(ipam.predefinedPrivateNetworks, func(ipNetPool *net.IPNet) bool {
 			if ipNetPool.String() == ipNet.String() {
-				ipam.usedIPSubnets[network.NetworkID] = subnetwork{
-					ipNet: ipNetPool,
-					ips:   generateSubnetIPs(ipNetPool),
-				}
+				inPool = true
+			}
 
-				ipam.predefinedPrivateNetworks = append(
-					ipam.predefinedPrivateNetworks[:i], ipam.predefinedPrivateNetworks[i+1:]...)
+			return ipNetPool.Contains(ipNet.IP) || ipNet.Contains(ipNetPool.IP)
+		})
 
-				log.Debugf("Allocated subnet %s was removed", ipNet.String())
+		if !inPool {
+			log.Warnf("Allocated subnet %s is out of IP pool", ipNet.String())
+		}
 
-				break
-			}
+		ipam.usedIPSubnets[allocation.NetworkID] = subnetwork{
+			ipNet: ipNet,
+			ips:   generateSubnetIPs(ipNet),
 		}
+
+		log.Debugf("Allocated subnet %s was restored", ipNet.String())
 	}
 
-	for _, networkInstance := range networkInstances {
-		subnet, ok := ipam.usedIPSubnets[networkInstance.NetworkID]
-		if !ok {
+	for _, allocation := range allocations {
+		subnet, ok := ipam.usedIPSubnets[allocation.NetworkID]
+		if !ok || allocation.IP == "" {
 			continue
 		}
 
-		for i, ip := range subnet.ips {
-			if ip.String() == networkInstance.IP {
-				subnet.ips = append(subnet.ips[:i], subnet.ips[i+1:]...)
+		subnet.ips = slices.DeleteFunc(subnet.ips, func(ip net.IP) bool {
+			return ip.String() == allocation.IP
+		})
 
-				log.Debugf("Allocated ip %s was removed", ip.String())
+		ipam.usedIPSubnets[allocation.NetworkID] = subnet
 
-				break
-			}
-		}
+		log.Debugf("Allocated ip %s was restored", allocation.IP)
 	}
 }
 
@@ -241,3 +304,27 @@ func generateSubnetIPs(ipNet *net.IPNet) []net.IP {
 
 	return ips
 }
+
+func getStickyRange(allocation config.IPAllocation, serviceID string) (config.StickyIPRange, bool) {
+	if serviceID == "" {
+		return config.StickyIPRange{}, false
+	}
+
+	for _, stickyRange := range allocation.StickyRanges {
+		if slices.Contains(stickyRange.ServiceIDs, serviceID) {
+			return stickyRange, true
+		}
+	}
+
+	return config.StickyIPRange{}, false
+}
+
+// ipOffset returns host offset of IPv4 address in the subnet.
+func ipOffset(ipNet *net.IPNet, ip net.IP) uint32 {
+	ip4, base4 := ip.To4(), ipNet.IP.To4()
+	if ip4 == nil || base4 == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint32(ip4) - binary.BigEndian.Uint32(base4)
+}
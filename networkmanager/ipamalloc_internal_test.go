@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"net"
+	"testing"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestIPAllocationStrategy(t *testing.T) {
+	ipam := &ipSubnet{
+		usedIPSubnets: make(map[string]subnetwork),
+		allocation: map[string]config.IPAllocation{
+			"network1": {
+				StickyRanges: []config.StickyIPRange{{ServiceIDs: []string{"service1"}, First: 100, Last: 101}},
+			},
+			"network2": {Strategy: config.IPAllocationRandom},
+		},
+	}
+
+	ipam.restoreAllocations([]IPAllocation{
+		{NetworkID: "network1", Subnet: "172.17.0.0/24"},
+		{NetworkID: "network1", Subnet: "172.17.0.0/24", IP: "172.17.0.1"},
+		{NetworkID: "network2", Subnet: "172.18.0.0/24"},
+	})
+
+	// Sequential allocation gets the lowest free IP
+
+	allocateIP := func(networkID, serviceID string) string {
+		t.Helper()
+
+		_, ip, err := ipam.prepareSubnet(networkID, serviceID)
+		if err != nil {
+			t.Fatalf("Can't allocate IP: %v", err)
+		}
+
+		return ip.String()
+	}
+
+	if ip := allocateIP("network1", "service2"); ip != "172.17.0.2" {
+		t.Errorf("Wrong allocated IP: %s", ip)
+	}
+
+	ipam.releaseIPToSubnet("network1", net.ParseIP("172.17.0.2"))
+
+	if ip := allocateIP("network1", "service2"); ip != "172.17.0.2" {
+		t.Errorf("Wrong allocated IP: %s", ip)
+	}
+
+	// Sticky range services get IPs of the range only
+
+	if ip := allocateIP("network1", "service1"); ip != "172.17.0.100" {
+		t.Errorf("Wrong allocated IP: %s", ip)
+	}
+
+	if ip := allocateIP("network1", "service1"); ip != "172.17.0.101" {
+		t.Errorf("Wrong allocated IP: %s", ip)
+	}
+
+	if _, _, err := ipam.prepareSubnet("network1", "service1"); err == nil {
+		t.Error("Error expected for exhausted sticky range")
+	}
+
+	// Sticky range IPs are not allocated to other services and provider nodes
+
+	for {
+		_, ip, err := ipam.prepareSubnet("network1", "")
+		if err != nil {
+			break
+		}
+
+		if ip.Equal(net.ParseIP("172.17.0.100")) || ip.Equal(net.ParseIP("172.17.0.101")) {
+			t.Fatalf("Sticky range IP allocated: %s", ip)
+		}
+	}
+
+	// Random allocation gets IPs of the subnet
+
+	allocated := make(map[string]bool)
+
+	for range 10 {
+		ip := allocateIP("network2", "service1")
+
+		if !(&net.IPNet{IP: net.ParseIP("172.18.0.0"), Mask: net.CIDRMask(24, 32)}).Contains(net.ParseIP(ip)) {
+			t.Errorf("IP %s is out of subnet", ip)
+		}
+
+		if allocated[ip] {
+			t.Errorf("IP %s is allocated twice", ip)
+		}
+
+		allocated[ip] = true
+	}
+}
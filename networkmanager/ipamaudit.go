@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const alertCoreComponent = "aos-communicationmanager"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type ipamAudit struct {
+	subnets         map[string]*net.IPNet
+	owners          map[IPAllocation]string
+	sharedBy        map[IPAllocation]string
+	reservedSubnets []*net.IPNet
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// String returns allocation description.
+func (allocation IPAllocation) String() string {
+	if allocation.IP == "" {
+		return fmt.Sprintf("subnet %s of network %s", allocation.Subnet, allocation.NetworkID)
+	}
+
+	return fmt.Sprintf("IP %s of network %s", allocation.IP, allocation.NetworkID)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// auditIPAllocations checks stored node and instance networks against each other and against IPAM allocation table.
+// Networks with wrong subnet, IP out of subnet or already used IP are removed, allocation table is synced with
+// remaining networks. Networks which use reserved subnet or VLAN ID are removed as well. As VLAN ID is shared by all
+// nodes of the network, instance networks of network with reserved VLAN ID are released too. Each repair is reported
+// as alert.
+func (manager *NetworkManager) auditIPAllocations(
+	networksInfo []NetworkParametersStorage, instancesInfo []InstanceNetworkInfo,
+) (
+	validNetworks []NetworkParametersStorage, validInstances []InstanceNetworkInfo, allocations []IPAllocation,
+	err error,
+) {
+	storedAllocations, err := manager.storage.GetIPAllocations()
+	if err != nil {
+		return nil, nil, nil, aoserrors.Wrap(err)
+	}
+
+	slices.SortFunc(networksInfo, func(a, b NetworkParametersStorage) int {
+		return cmp.Or(cmp.Compare(a.NetworkID, b.NetworkID), cmp.Compare(a.NodeID, b.NodeID))
+	})
+
+	slices.SortFunc(instancesInfo, func(a, b InstanceNetworkInfo) int {
+		return cmp.Or(cmp.Compare(a.NetworkID, b.NetworkID), cmp.Compare(a.ServiceID, b.ServiceID),
+			cmp.Compare(a.SubjectID, b.SubjectID), cmp.Compare(a.Instance, b.Instance))
+	})
+
+	audit := ipamAudit{
+		subnets: make(map[string]*net.IPNet), owners: make(map[IPAllocation]string),
+		sharedBy: make(map[IPAllocation]string), reservedSubnets: manager.reservedSubnets,
+	}
+
+	reservedVlanNetworks := make(map[string]uint64)
+
+	if err = manager.storage.ExecuteInTransaction(func(txStorage any) error {
+		storage, err := transactionStorage(txStorage)
+		if err != nil {
+			return err
+		}
+
+		for _, networkInfo := range networksInfo {
+			owner := fmt.Sprintf("node %s", networkInfo.NodeID)
+
+			var err error
+
+			if slices.Contains(manager.reservedVlanIDs, networkInfo.VlanID) {
+				reservedVlanNetworks[networkInfo.NetworkID] = networkInfo.VlanID
+				err = aoserrors.Errorf("VLAN ID %d is reserved", networkInfo.VlanID)
+			} else {
+				err = audit.addOwner(networkInfo.NetworkID, networkInfo.Subnet, networkInfo.IP, owner, "")
+			}
+
+			if err != nil {
+				manager.reportIPAMRepair("network %s of %s removed: %v", networkInfo.NetworkID, owner, err)
+
+				if err := storage.RemoveNetworkInfo(networkInfo.NetworkID, networkInfo.NodeID); err != nil {
+					return aoserrors.Wrap(err)
+				}
+
+				continue
+			}
+
+			validNetworks = append(validNetworks, networkInfo)
+		}
+
+		for _, instanceInfo := range instancesInfo {
+			owner := fmt.Sprintf("instance %s:%s:%d",
+				instanceInfo.ServiceID, instanceInfo.SubjectID, instanceInfo.Instance)
+
+			// Instances of the same service and subject may share IP of shared network
+			sharedBy := instanceInfo.ServiceID + ":" + instanceInfo.SubjectID
+
+			var err error
+
+			if vlanID, ok := reservedVlanNetworks[instanceInfo.NetworkID]; ok {
+				err = aoserrors.Errorf("VLAN ID %d is reserved", vlanID)
+			} else {
+				err = audit.addOwner(instanceInfo.NetworkID, instanceInfo.Subnet, instanceInfo.IP, owner, sharedBy)
+			}
+
+			if err != nil {
+				manager.reportIPAMRepair("network %s of %s removed: %v", instanceInfo.NetworkID, owner, err)
+
+				if err := storage.RemoveNetworkInstanceInfo(instanceInfo.InstanceIdent); err != nil {
+					return aoserrors.Wrap(err)
+				}
+
+				continue
+			}
+
+			validInstances = append(validInstances, instanceInfo)
+		}
+
+		allocations = audit.allocations()
+
+		return manager.syncIPAllocations(storage, storedAllocations, allocations)
+	}); err != nil {
+		return nil, nil, nil, aoserrors.Wrap(err)
+	}
+
+	return validNetworks, validInstances, allocations, nil
+}
+
+func (manager *NetworkManager) syncIPAllocations(storage Storage, storedAllocations, allocations []IPAllocation) error {
+	// Allocation table didn't exist before, fill it without alerts
+	initial := len(storedAllocations) == 0
+
+	for _, allocation := range storedAllocations {
+		if slices.Contains(allocations, allocation) {
+			continue
+		}
+
+		if !slices.ContainsFunc(allocations, func(item IPAllocation) bool {
+			return item.NetworkID == allocation.NetworkID && item.IP == allocation.IP
+		}) {
+			manager.reportIPAMRepair("stale allocation %s removed", allocation)
+		}
+
+		if err := storage.RemoveIPAllocation(allocation.NetworkID, allocation.IP); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	for _, allocation := range allocations {
+		if slices.Contains(storedAllocations, allocation) {
+			continue
+		}
+
+		if !initial {
+			manager.reportIPAMRepair("missing allocation %s restored", allocation)
+		}
+
+		if err := storage.AddIPAllocation(allocation); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	if initial && len(allocations) != 0 {
+		log.Info("IPAM allocation table initialized")
+	}
+
+	return nil
+}
+
+func (manager *NetworkManager) reportIPAMRepair(format string, args ...interface{}) {
+	message := "IPAM audit: " + fmt.Sprintf(format, args...)
+
+	log.Warn(message)
+
+	if manager.alertSender == nil {
+		return
+	}
+
+	manager.alertSender.SendAlert(cloudprotocol.CoreAlert{
+		AlertItem:     cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagAosCore},
+		CoreComponent: alertCoreComponent,
+		Message:       message,
+	})
+}
+
+func (audit *ipamAudit) addOwner(networkID, subnet, ip, owner, sharedBy string) error {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return aoserrors.Errorf("invalid subnet %s", subnet)
+	}
+
+	networkSubnet, ok := audit.subnets[networkID]
+	if ok && networkSubnet.String() != ipNet.String() {
+		return aoserrors.Errorf("subnet %s differs from network subnet %s", ipNet, networkSubnet)
+	}
+
+	if !ok {
+		for _, reserved := range audit.reservedSubnets {
+			if reserved.Contains(ipNet.IP) || ipNet.Contains(reserved.IP) {
+				return aoserrors.Errorf("subnet %s overlaps reserved subnet %s", ipNet, reserved)
+			}
+		}
+
+		for otherID, otherSubnet := range audit.subnets {
+			if otherSubnet.Contains(ipNet.IP) || ipNet.Contains(otherSubnet.IP) {
+				return aoserrors.Errorf("subnet %s overlaps network %s subnet %s", ipNet, otherID, otherSubnet)
+			}
+		}
+	}
+
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || !ipNet.Contains(parsedIP) {
+		return aoserrors.Errorf("IP %s is out of subnet %s", ip, ipNet)
+	}
+
+	allocation := IPAllocation{NetworkID: networkID, Subnet: ipNet.String(), IP: parsedIP.String()}
+
+	if usedBy, ok := audit.owners[allocation]; ok {
+		if sharedBy != "" && audit.sharedBy[allocation] == sharedBy {
+			return nil
+		}
+
+		return aoserrors.Errorf("IP %s is already used by %s", ip, usedBy)
+	}
+
+	audit.subnets[networkID] = ipNet
+	audit.owners[allocation] = owner
+	audit.sharedBy[allocation] = sharedBy
+
+	return nil
+}
+
+func (audit *ipamAudit) allocations() (allocations []IPAllocation) {
+	for networkID, subnet := range audit.subnets {
+		allocations = append(allocations, IPAllocation{NetworkID: networkID, Subnet: subnet.String()})
+	}
+
+	for allocation := range audit.owners {
+		allocations = append(allocations, allocation)
+	}
+
+	slices.SortFunc(allocations, func(a, b IPAllocation) int {
+		return cmp.Or(cmp.Compare(a.NetworkID, b.NetworkID), cmp.Compare(a.IP, b.IP))
+	})
+
+	return allocations
+}
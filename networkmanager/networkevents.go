@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Provider network lifecycle events.
+const (
+	NetworkEventCreated      = "created"
+	NetworkEventVlanAssigned = "vlanAssigned"
+	NetworkEventNodeAttached = "nodeAttached"
+	NetworkEventNodeDetached = "nodeDetached"
+	NetworkEventRemoved      = "removed"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// NetworkEventsSender sends provider network lifecycle events to the cloud.
+type NetworkEventsSender interface {
+	SendProviderNetworkEvents(events amqphandler.ProviderNetworkEvents) error
+}
+
+type providerNetworkState struct {
+	subnet string
+	vlanID uint64
+	nodes  map[string]string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetNetworkEventsSender sets sender of provider network lifecycle events.
+func (manager *NetworkManager) SetNetworkEventsSender(sender NetworkEventsSender) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	manager.eventsSender = sender
+}
+
+// GetProviderNetworksStatus returns provider networks with attached nodes sorted by network ID.
+func (manager *NetworkManager) GetProviderNetworksStatus() []amqphandler.ProviderNetworkStatus {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	states := manager.getProviderNetworksState()
+	networks := make([]amqphandler.ProviderNetworkStatus, 0, len(states))
+
+	for _, networkID := range sortedKeys(states) {
+		state := states[networkID]
+		network := amqphandler.ProviderNetworkStatus{NetworkID: networkID, Subnet: state.subnet, VlanID: state.vlanID}
+
+		for _, nodeID := range sortedKeys(state.nodes) {
+			network.Nodes = append(network.Nodes, amqphandler.ProviderNetworkNode{
+				NodeID: nodeID, IP: state.nodes[nodeID],
+			})
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *NetworkManager) getProviderNetworksState() map[string]providerNetworkState {
+	states := make(map[string]providerNetworkState)
+
+	for networkID, networksInfo := range manager.providerNetworks {
+		if len(networksInfo) == 0 {
+			continue
+		}
+
+		state := providerNetworkState{
+			subnet: networksInfo[0].Subnet, vlanID: networksInfo[0].VlanID, nodes: make(map[string]string),
+		}
+
+		for _, info := range networksInfo {
+			if info.NodeID != "" {
+				state.nodes[info.NodeID] = info.IP
+			}
+		}
+
+		states[networkID] = state
+	}
+
+	return states
+}
+
+// sendNetworkEvents sends events of provider network changes since the previous state.
+func (manager *NetworkManager) sendNetworkEvents(prevStates map[string]providerNetworkState) {
+	if manager.eventsSender == nil {
+		return
+	}
+
+	events := getNetworkEvents(prevStates, manager.getProviderNetworksState(), time.Now().UTC())
+	if len(events) == 0 {
+		return
+	}
+
+	log.WithField("count", len(events)).Debug("Send provider network events")
+
+	if err := manager.eventsSender.SendProviderNetworkEvents(
+		amqphandler.ProviderNetworkEvents{Events: events}); err != nil {
+		log.Errorf("Can't send provider network events: %v", aoserrors.Wrap(err))
+	}
+}
+
+func getNetworkEvents(
+	prevStates, states map[string]providerNetworkState, timestamp time.Time,
+) (events []amqphandler.ProviderNetworkEvent) {
+	networkIDs := sortedKeys(prevStates)
+
+	for _, networkID := range sortedKeys(states) {
+		if _, ok := prevStates[networkID]; !ok {
+			networkIDs = append(networkIDs, networkID)
+		}
+	}
+
+	slices.Sort(networkIDs)
+
+	newEvent := func(event, networkID string) amqphandler.ProviderNetworkEvent {
+		return amqphandler.ProviderNetworkEvent{Timestamp: timestamp, Event: event, NetworkID: networkID}
+	}
+
+	for _, networkID := range networkIDs {
+		prevState, existed := prevStates[networkID]
+		state, exists := states[networkID]
+
+		if !existed {
+			event := newEvent(NetworkEventCreated, networkID)
+			event.Subnet = state.subnet
+
+			events = append(events, event)
+		}
+
+		if exists && (!existed || prevState.vlanID != state.vlanID) {
+			event := newEvent(NetworkEventVlanAssigned, networkID)
+			event.VlanID = state.vlanID
+
+			events = append(events, event)
+		}
+
+		for _, nodeID := range sortedKeys(prevState.nodes) {
+			if _, ok := state.nodes[nodeID]; !ok {
+				event := newEvent(NetworkEventNodeDetached, networkID)
+				event.NodeID = nodeID
+
+				events = append(events, event)
+			}
+		}
+
+		for _, nodeID := range sortedKeys(state.nodes) {
+			if _, ok := prevState.nodes[nodeID]; !ok {
+				event := newEvent(NetworkEventNodeAttached, networkID)
+				event.NodeID, event.IP = nodeID, state.nodes[nodeID]
+
+				events = append(events, event)
+			}
+		}
+
+		if !exists {
+			events = append(events, newEvent(NetworkEventRemoved, networkID))
+		}
+	}
+
+	return events
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
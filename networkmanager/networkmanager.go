@@ -20,14 +20,17 @@
 package networkmanager
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
 	"net"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
@@ -44,6 +47,7 @@ const (
 	vlanIDCapacity                = 4096
 	allowedConnectionsExpectedLen = 3
 	exposePortConfigExpectedLen   = 2
+	hostAccessPorts               = "1-65535"
 )
 
 /***********************************************************************************************************************
@@ -58,6 +62,16 @@ type Storage interface {
 	RemoveNetworkInfo(networkID string, nodeID string) error
 	AddNetworkInfo(info NetworkParametersStorage) error
 	GetNetworksInfo() ([]NetworkParametersStorage, error)
+	AddIPAllocation(allocation IPAllocation) error
+	RemoveIPAllocation(networkID, ip string) error
+	RemoveNetworkIPAllocations(networkID string) error
+	GetIPAllocations() ([]IPAllocation, error)
+	ExecuteInTransaction(operations func(txStorage any) error) error
+}
+
+// AlertSender sends alerts.
+type AlertSender interface {
+	SendAlert(alert interface{})
 }
 
 // NodeManager nodes controller.
@@ -70,15 +84,34 @@ type NetworkParametersStorage struct {
 	NodeID string
 }
 
+// IPAllocation IPAM allocation table entry. Entry with empty IP reserves subnet for the network.
+type IPAllocation struct {
+	NetworkID string
+	Subnet    string
+	IP        string
+}
+
 // NetworkManager networks manager instance.
 type NetworkManager struct {
 	sync.RWMutex
-	instancesData    map[string]map[aostypes.InstanceIdent]InstanceNetworkInfo
-	providerNetworks map[string][]NetworkParametersStorage
-	ipamSubnet       *ipSubnet
-	dns              *dnsServer
-	storage          Storage
-	nodeManager      NodeManager
+	instancesData     map[string]map[aostypes.InstanceIdent]InstanceNetworkInfo
+	providerNetworks  map[string][]NetworkParametersStorage
+	ipamSubnet        *ipSubnet
+	dns               *dnsServer
+	queryLog          *dnsQueryLog
+	storage           Storage
+	nodeManager       NodeManager
+	alertSender       AlertSender
+	reservedVlanIDs   []uint64
+	reservedSubnets   []*net.IPNet
+	isolation         map[string]config.NetworkIsolation
+	scheduledRules    []config.ScheduledFirewallRule
+	mirrorSender      TrafficMirrorSender
+	eventsSender      NetworkEventsSender
+	maxMirrorDuration time.Duration
+	trafficMirrors    map[string]TrafficMirror
+	mirrorTimers      map[string]*time.Timer
+	cancelFunc        context.CancelFunc
 }
 
 // FirewallRule represents firewall rule.
@@ -99,6 +132,10 @@ type NetworkParameters struct {
 	Hosts            []string
 	AllowConnections []string
 	ExposePorts      []string
+	// HealthEndpoints health endpoints of exposed ports by exposed port ("8080/tcp") or port ("8080") key.
+	HealthEndpoints map[string]string
+	// SharedNetwork instances of the same service and subject share one IP and network namespace.
+	SharedNetwork bool
 }
 
 /***********************************************************************************************************************
@@ -109,7 +146,7 @@ type NetworkParameters struct {
 //
 //nolint:gochecknoglobals
 var (
-	GetIPSubnet func(networkID string) (allocIPNet *net.IPNet, ip net.IP, err error)
+	GetIPSubnet func(networkID, serviceID string) (allocIPNet *net.IPNet, ip net.IP, err error)
 	GetVlanID   func(networkID string) (uint64, error)
 )
 
@@ -120,15 +157,38 @@ var errRuleNotFound = aoserrors.New("rule not found")
  **********************************************************************************************************************/
 
 // New creates network manager instance.
-func New(storage Storage, nodeManager NodeManager, config *config.Config) (*NetworkManager, error) {
+func New(
+	storage Storage, nodeManager NodeManager, alertSender AlertSender, config *config.Config,
+) (*NetworkManager, error) {
 	log.Debug("Create network manager")
 
-	ipamSubnet, err := newIPam()
+	reservedSubnets := make([]*net.IPNet, 0, len(config.Network.ReservedSubnets))
+
+	for _, subnet := range config.Network.ReservedSubnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		reservedSubnets = append(reservedSubnets, ipNet)
+	}
+
+	reservedVlanIDs := slices.Clone(config.Network.ReservedVlanIDs)
+
+	slices.Sort(reservedVlanIDs)
+	reservedVlanIDs = slices.Compact(reservedVlanIDs)
+
+	if len(reservedVlanIDs) >= vlanIDCapacity-1 {
+		return nil, aoserrors.New("all VLAN IDs are reserved")
+	}
+
+	ipamSubnet, err := newIPam(reservedSubnets, config.Network.IPAllocation)
 	if err != nil {
 		return nil, err
 	}
 
-	dns, err := newDNSServer(filepath.Join(config.WorkingDir, "network"), config.DNSIP)
+	dns, err := newDNSServer(filepath.Join(config.WorkingDir, "network"), config.DNSIP, config.DNSQueryLog.Enabled,
+		config.DNSSecurity, config.DNSCache)
 	if err != nil {
 		return nil, err
 	}
@@ -138,12 +198,20 @@ func New(storage Storage, nodeManager NodeManager, config *config.Config) (*Netw
 	}
 
 	networkManager := &NetworkManager{
-		instancesData:    make(map[string]map[aostypes.InstanceIdent]InstanceNetworkInfo),
-		providerNetworks: make(map[string][]NetworkParametersStorage),
-		ipamSubnet:       ipamSubnet,
-		dns:              dns,
-		storage:          storage,
-		nodeManager:      nodeManager,
+		instancesData:     make(map[string]map[aostypes.InstanceIdent]InstanceNetworkInfo),
+		providerNetworks:  make(map[string][]NetworkParametersStorage),
+		ipamSubnet:        ipamSubnet,
+		dns:               dns,
+		storage:           storage,
+		nodeManager:       nodeManager,
+		alertSender:       alertSender,
+		reservedVlanIDs:   reservedVlanIDs,
+		reservedSubnets:   reservedSubnets,
+		isolation:         config.Network.Isolation,
+		scheduledRules:    config.Network.ScheduledRules,
+		maxMirrorDuration: config.Network.MaxMirrorDuration.Duration,
+		trafficMirrors:    make(map[string]TrafficMirror),
+		mirrorTimers:      make(map[string]*time.Timer),
 	}
 
 	if GetVlanID == nil {
@@ -155,16 +223,22 @@ func New(storage Storage, nodeManager NodeManager, config *config.Config) (*Netw
 		return nil, aoserrors.Wrap(err)
 	}
 
-	for _, networkInfo := range networksInfo {
-		networkManager.providerNetworks[networkInfo.NetworkID] = append(
-			networkManager.providerNetworks[networkInfo.NetworkID], networkInfo)
-	}
-
 	networkInstancesInfos, err := storage.GetNetworkInstancesInfo()
 	if err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	networksInfo, networkInstancesInfos, allocations, err := networkManager.auditIPAllocations(
+		networksInfo, networkInstancesInfos)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, networkInfo := range networksInfo {
+		networkManager.providerNetworks[networkInfo.NetworkID] = append(
+			networkManager.providerNetworks[networkInfo.NetworkID], networkInfo)
+	}
+
 	for _, networkInfo := range networkInstancesInfos {
 		if len(networkManager.instancesData[networkInfo.NetworkID]) == 0 {
 			networkManager.instancesData[networkInfo.NetworkID] = make(
@@ -175,13 +249,45 @@ func New(storage Storage, nodeManager NodeManager, config *config.Config) (*Netw
 		networkManager.instancesData[networkInfo.NetworkID][networkInfo.InstanceIdent] = networkInfo
 	}
 
-	ipamSubnet.removeAllocatedSubnets(networksInfo, networkInstancesInfos)
+	ipamSubnet.restoreAllocations(allocations)
+
+	if dns.QueryLogFile != "" {
+		networkManager.queryLog = newDNSQueryLog(dns.QueryLogFile, config.DNSQueryLog)
+	}
+
+	if len(networkManager.scheduledRules) > 0 {
+		var ctx context.Context
+
+		ctx, networkManager.cancelFunc = context.WithCancel(context.Background())
+
+		go networkManager.runFirewallSchedule(ctx)
+	}
 
 	return networkManager, nil
 }
 
-// RemoveInstanceNetworkConf removes stored instance network parameters.
-func (manager *NetworkManager) RemoveInstanceNetworkParameters(instanceIdent aostypes.InstanceIdent) {
+// Close closes network manager.
+func (manager *NetworkManager) Close() {
+	log.Debug("Close network manager")
+
+	if manager.queryLog != nil {
+		manager.queryLog.close()
+	}
+
+	if manager.cancelFunc != nil {
+		manager.cancelFunc()
+	}
+
+	manager.Lock()
+	manager.clearTrafficMirrors()
+	manager.Unlock()
+}
+
+// RemoveInstanceNetworkParameters removes stored instance network parameters. Network changes are stored with
+// storage which may be bound to the caller transaction.
+func (manager *NetworkManager) RemoveInstanceNetworkParameters(
+	storage Storage, instanceIdent aostypes.InstanceIdent,
+) {
 	manager.Lock()
 	defer manager.Unlock()
 
@@ -191,7 +297,7 @@ func (manager *NetworkManager) RemoveInstanceNetworkParameters(instanceIdent aos
 	}
 
 	if err := manager.removeInstanceNetworkParameters(
-		networkID, instanceIdent, net.IP(networkParameters.IP)); err != nil {
+		storage, networkID, instanceIdent, net.ParseIP(networkParameters.IP)); err != nil {
 		log.Errorf("Can't remove network info: %v", err)
 	}
 }
@@ -212,19 +318,113 @@ func (manager *NetworkManager) GetInstances() []aostypes.InstanceIdent {
 	return instances
 }
 
-// UpdateProviderNetwork updates provider network.
-func (manager *NetworkManager) UpdateProviderNetwork(providers []string, nodeID string) error {
+// GetInstanceNetworkID returns provider network ID of instance.
+func (manager *NetworkManager) GetInstanceNetworkID(instanceIdent aostypes.InstanceIdent) (networkID string, found bool) {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	_, networkID, found = manager.getNetworkParametersToCache(instanceIdent)
+
+	return networkID, found
+}
+
+// UpdateProviderNetwork updates provider network. Network changes are stored with storage which may be bound to the
+// caller transaction.
+func (manager *NetworkManager) UpdateProviderNetwork(storage Storage, providers []string, nodeID string) error {
 	manager.Lock()
 	defer manager.Unlock()
 
-	manager.removeProviderNetworks(providers, nodeID)
+	defer manager.sendNetworkEvents(manager.getProviderNetworksState())
+
+	var networkParameters []aostypes.NetworkParameters
+
+	if err := storage.ExecuteInTransaction(func(txStorage any) (err error) {
+		if storage, err = transactionStorage(txStorage); err != nil {
+			return err
+		}
+
+		manager.removeProviderNetworks(storage, providers, nodeID)
+
+		networkParameters, err = manager.addProviderNetworks(storage, providers, nodeID)
 
-	networkParameters, err := manager.addProviderNetworks(providers, nodeID)
-	if err != nil {
+		return err
+	}); err != nil {
 		return err
 	}
 
-	return aoserrors.Wrap(manager.nodeManager.UpdateNetwork(nodeID, networkParameters))
+	networkParameters = manager.applyScheduledRules(networkParameters, time.Now())
+
+	return manager.updateNodeNetwork(nodeID, networkParameters)
+}
+
+// Purge removes all instance network parameters, DNS hosts and provider networks of all nodes.
+func (manager *NetworkManager) Purge() error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	log.Debug("Purge networks")
+
+	defer manager.sendNetworkEvents(manager.getProviderNetworksState())
+
+	nodeIDs := []string{""}
+
+	for _, networksInfo := range manager.providerNetworks {
+		for _, info := range networksInfo {
+			if !slices.Contains(nodeIDs, info.NodeID) {
+				nodeIDs = append(nodeIDs, info.NodeID)
+			}
+		}
+	}
+
+	if err := manager.storage.ExecuteInTransaction(func(txStorage any) error {
+		storage, err := transactionStorage(txStorage)
+		if err != nil {
+			return err
+		}
+
+		for networkID, instancesData := range manager.instancesData {
+			for instanceIdent, netInfo := range instancesData {
+				if err := manager.removeInstanceNetworkParameters(
+					storage, networkID, instanceIdent, net.ParseIP(netInfo.IP)); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, nodeID := range nodeIDs {
+			manager.removeProviderNetworks(storage, nil, nodeID)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	manager.clearTrafficMirrors()
+
+	var purgeErr error
+
+	for _, nodeID := range nodeIDs[1:] {
+		if err := manager.updateNodeNetwork(nodeID, nil); err != nil && purgeErr == nil {
+			purgeErr = aoserrors.Wrap(err)
+		}
+	}
+
+	manager.dns.cleanCacheHosts()
+
+	if err := manager.dns.rewriteHostsFile(); err != nil && purgeErr == nil {
+		purgeErr = err
+	}
+
+	if err := manager.dns.rewriteRecordsFile(); err != nil && purgeErr == nil {
+		purgeErr = err
+	}
+
+	if err := manager.dns.restart(); err != nil && purgeErr == nil {
+		purgeErr = err
+	}
+
+	return purgeErr
 }
 
 // Restart restarts DNS server.
@@ -233,44 +433,57 @@ func (manager *NetworkManager) RestartDNSServer() error {
 		return err
 	}
 
+	if err := manager.dns.rewriteRecordsFile(); err != nil {
+		return err
+	}
+
 	manager.dns.cleanCacheHosts()
 
 	return manager.dns.restart()
 }
 
-// PrepareInstanceNetworkParameters prepares network parameters for instance.
+// PrepareInstanceNetworkParameters prepares network parameters for instance. Network changes are stored with storage
+// which may be bound to the caller transaction.
 func (manager *NetworkManager) PrepareInstanceNetworkParameters(
-	instanceIdent aostypes.InstanceIdent, networkID string, params NetworkParameters,
+	storage Storage, instanceIdent aostypes.InstanceIdent, networkID string, params NetworkParameters,
 ) (networkParameters aostypes.NetworkParameters, err error) {
-	if instanceIdent.ServiceID != "" && instanceIdent.SubjectID != "" {
+	hostIdent := sharedNetworkIdent(instanceIdent, params.SharedNetwork)
+
+	if hostIdent.ServiceID != "" && hostIdent.SubjectID != "" {
 		params.Hosts = append(
 			params.Hosts, fmt.Sprintf(
-				"%d.%s.%s", instanceIdent.Instance, instanceIdent.SubjectID, instanceIdent.ServiceID))
+				"%d.%s.%s", hostIdent.Instance, hostIdent.SubjectID, hostIdent.ServiceID))
 
 		params.Hosts = append(
 			params.Hosts, fmt.Sprintf(
-				"%d.%s.%s.%s", instanceIdent.Instance, instanceIdent.SubjectID, instanceIdent.ServiceID, networkID))
+				"%d.%s.%s.%s", hostIdent.Instance, hostIdent.SubjectID, hostIdent.ServiceID, networkID))
 
-		if instanceIdent.Instance == 0 {
-			params.Hosts = append(params.Hosts, fmt.Sprintf("%s.%s", instanceIdent.SubjectID, instanceIdent.ServiceID))
+		if hostIdent.Instance == 0 {
+			params.Hosts = append(params.Hosts, fmt.Sprintf("%s.%s", hostIdent.SubjectID, hostIdent.ServiceID))
 			params.Hosts = append(
 				params.Hosts, fmt.Sprintf(
-					"%s.%s.%s", instanceIdent.SubjectID, instanceIdent.ServiceID, networkID))
+					"%s.%s.%s", hostIdent.SubjectID, hostIdent.ServiceID, networkID))
 		}
 	}
 
 	networkParameters, currentNetworkID, found := manager.getNetworkParametersToCache(instanceIdent)
 	if found && networkID != currentNetworkID {
 		if err := manager.removeInstanceNetworkParameters(
-			networkID, instanceIdent, net.IP(networkParameters.IP)); err != nil {
+			storage, currentNetworkID, instanceIdent, net.ParseIP(networkParameters.IP)); err != nil {
 			log.Errorf("Can't remove network info: %v", err)
 		}
 
 		found = false
 	}
 
+	if found && manager.sharedNetworkChanged(networkID, instanceIdent, networkParameters.IP, params.SharedNetwork) {
+		manager.leaveNetwork(storage, networkID, instanceIdent, networkParameters.IP)
+
+		found = false
+	}
+
 	if !found {
-		if networkParameters, err = manager.createNetwork(instanceIdent, networkID, params); err != nil {
+		if networkParameters, err = manager.allocateNetwork(storage, instanceIdent, networkID, params); err != nil {
 			return networkParameters, err
 		}
 	}
@@ -279,9 +492,20 @@ func (manager *NetworkManager) PrepareInstanceNetworkParameters(
 		return networkParameters, err
 	}
 
+	endpoints, err := getServiceEndpoints(hostIdent, networkParameters.IP, params)
+	if err != nil {
+		return networkParameters, err
+	}
+
+	manager.dns.addServiceEndpoints(networkParameters.IP, endpoints)
+
+	networkParameters.FirewallRules = nil
+
+	isolation := manager.isolation[networkID]
+
 	if len(params.AllowConnections) > 0 {
-		firewallRules, err := manager.prepareFirewallRules(
-			networkParameters.Subnet, networkParameters.IP, params.AllowConnections)
+		firewallRules, err := manager.prepareFirewallRules(networkParameters.Subnet, networkParameters.IP,
+			params.AllowConnections, isolation.Level == config.IsolationStrict)
 		if err != nil {
 			return networkParameters, err
 		}
@@ -289,6 +513,13 @@ func (manager *NetworkManager) PrepareInstanceNetworkParameters(
 		networkParameters.FirewallRules = firewallRules
 	}
 
+	if isolation.HostAccess {
+		networkParameters.FirewallRules = append(networkParameters.FirewallRules,
+			manager.prepareHostAccessRules(networkID, networkParameters.IP)...)
+	}
+
+	manager.setInstanceFirewallRules(networkID, instanceIdent, networkParameters.FirewallRules)
+
 	return networkParameters, nil
 }
 
@@ -296,12 +527,30 @@ func (manager *NetworkManager) PrepareInstanceNetworkParameters(
  * Private
  **********************************************************************************************************************/
 
+func transactionStorage(txStorage any) (Storage, error) {
+	storage, ok := txStorage.(Storage)
+	if !ok {
+		return nil, aoserrors.New("wrong transaction storage")
+	}
+
+	return storage, nil
+}
+
 func (manager *NetworkManager) removeInstanceNetworkParameters(
-	networkID string, instanceIdent aostypes.InstanceIdent, ip net.IP,
+	storage Storage, networkID string, instanceIdent aostypes.InstanceIdent, ip net.IP,
 ) error {
 	manager.deleteNetworkParametersFromCache(networkID, instanceIdent, ip)
 
-	if err := manager.storage.RemoveNetworkInstanceInfo(instanceIdent); err != nil {
+	if err := storage.RemoveNetworkInstanceInfo(instanceIdent); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	// IP of shared network is released with the last instance
+	if manager.isIPUsed(networkID, instanceIdent, ip) {
+		return nil
+	}
+
+	if err := storage.RemoveIPAllocation(networkID, ip.String()); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
@@ -309,7 +558,7 @@ func (manager *NetworkManager) removeInstanceNetworkParameters(
 }
 
 func (manager *NetworkManager) createNetwork(
-	instanceIdent aostypes.InstanceIdent, networkID string, params NetworkParameters,
+	storage Storage, instanceIdent aostypes.InstanceIdent, networkID string, params NetworkParameters,
 ) (networkParameters aostypes.NetworkParameters, err error) {
 	var (
 		ip     net.IP
@@ -317,16 +566,24 @@ func (manager *NetworkManager) createNetwork(
 	)
 
 	defer func() {
-		if err != nil {
+		if err != nil && ip != nil {
 			manager.deleteNetworkParametersFromCache(networkID, instanceIdent, ip)
+
+			if removeErr := storage.RemoveIPAllocation(networkID, ip.String()); removeErr != nil {
+				log.Errorf("Can't remove IP allocation: %v", removeErr)
+			}
 		}
 	}()
 
-	subnet, ip, err = GetIPSubnet(networkID)
+	subnet, ip, err = GetIPSubnet(networkID, instanceIdent.ServiceID)
 	if err != nil {
 		return networkParameters, err
 	}
 
+	if err = manager.addIPAllocation(storage, networkID, subnet, ip); err != nil {
+		return networkParameters, err
+	}
+
 	networkParameters.NetworkID = networkID
 	networkParameters.IP = ip.String()
 	networkParameters.Subnet = subnet.String()
@@ -344,7 +601,7 @@ func (manager *NetworkManager) createNetwork(
 		}
 	}
 
-	if err := manager.storage.AddNetworkInstanceInfo(instanceNetworkInfo); err != nil {
+	if err := storage.AddNetworkInstanceInfo(instanceNetworkInfo); err != nil {
 		return networkParameters, aoserrors.Wrap(err)
 	}
 
@@ -353,16 +610,18 @@ func (manager *NetworkManager) createNetwork(
 	return networkParameters, nil
 }
 
+// prepareFirewallRules prepares rules for allowed connections. Instances of the same network are reachable without
+// rules unless the network is strictly isolated.
 func (manager *NetworkManager) prepareFirewallRules(
-	subnet, ip string, allowConnection []string,
+	subnet, ip string, allowConnection []string, strict bool,
 ) (rules []aostypes.FirewallRule, err error) {
 	for _, connection := range allowConnection {
-		serviceID, port, protocol, err := parseAllowConnection(connection)
+		serviceID, subjectID, port, protocol, err := parseAllowConnection(connection)
 		if err != nil {
 			return nil, err
 		}
 
-		instanceRule, err := manager.getInstanceRule(serviceID, subnet, port, protocol, ip)
+		instanceRule, err := manager.getInstanceRule(serviceID, subjectID, subnet, port, protocol, ip, strict)
 		if err != nil {
 			if !errors.Is(err, errRuleNotFound) {
 				return nil, err
@@ -378,7 +637,7 @@ func (manager *NetworkManager) prepareFirewallRules(
 }
 
 func (manager *NetworkManager) getInstanceRule(
-	serviceID, subnet, port, protocol, ip string,
+	serviceID, subjectID, subnet, port, protocol, ip string, strict bool,
 ) (rule aostypes.FirewallRule, err error) {
 	for _, instances := range manager.instancesData {
 		for _, instanceNetworkInfo := range instances {
@@ -386,12 +645,16 @@ func (manager *NetworkManager) getInstanceRule(
 				continue
 			}
 
+			if subjectID != "" && instanceNetworkInfo.SubjectID != subjectID {
+				continue
+			}
+
 			same, err := checkIPInSubnet(subnet, instanceNetworkInfo.NetworkParameters.IP)
 			if err != nil {
 				return rule, err
 			}
 
-			if same {
+			if (same && !strict) || instanceNetworkInfo.NetworkParameters.IP == ip {
 				continue
 			}
 
@@ -409,6 +672,28 @@ func (manager *NetworkManager) getInstanceRule(
 	return rule, errRuleNotFound
 }
 
+func (manager *NetworkManager) prepareHostAccessRules(networkID, ip string) (rules []aostypes.FirewallRule) {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	for _, networkInfo := range manager.providerNetworks[networkID] {
+		if networkInfo.IP == "" {
+			continue
+		}
+
+		for _, protocol := range []string{"tcp", "udp"} {
+			rules = append(rules, aostypes.FirewallRule{
+				DstIP:   networkInfo.IP,
+				SrcIP:   ip,
+				Proto:   protocol,
+				DstPort: hostAccessPorts,
+			})
+		}
+	}
+
+	return rules
+}
+
 func checkIPInSubnet(subnet, ip string) (bool, error) {
 	_, ipnet, err := net.ParseCIDR(subnet)
 	if err != nil {
@@ -427,7 +712,12 @@ func (manager *NetworkManager) deleteNetworkParametersFromCache(
 	networkID string, instanceIdent aostypes.InstanceIdent, ip net.IP,
 ) {
 	delete(manager.instancesData[networkID], instanceIdent)
-	delete(manager.dns.hosts, ip.String())
+
+	if manager.isIPUsed(networkID, instanceIdent, ip) {
+		return
+	}
+
+	manager.dns.removeHosts(ip.String())
 
 	manager.ipamSubnet.releaseIPToSubnet(networkID, ip)
 }
@@ -455,16 +745,14 @@ func (manager *NetworkManager) getNetworkParametersToCache(
 	return aostypes.NetworkParameters{}, "", false
 }
 
-func (manager *NetworkManager) removeProviderNetworks(providers []string, nodeID string) {
+func (manager *NetworkManager) removeProviderNetworks(storage Storage, providers []string, nodeID string) {
 	for networkID, networksInfo := range manager.providerNetworks {
 		var validNetworks []NetworkParametersStorage
 
 		// If network is not assigned to any node, remove it
 		for _, info := range networksInfo {
 			if info.NodeID == "" {
-				if err := manager.storage.RemoveNetworkInfo(networkID, ""); err != nil {
-					log.Errorf("Can't remove network info: %v", err)
-				}
+				manager.removeNodeNetworkInfo(storage, info)
 
 				continue
 			}
@@ -494,9 +782,7 @@ func (manager *NetworkManager) removeProviderNetworks(providers []string, nodeID
 
 		for _, info := range validNetworks {
 			if info.NodeID == nodeID {
-				if err := manager.storage.RemoveNetworkInfo(networkID, nodeID); err != nil {
-					log.Errorf("Can't remove network info: %v", err)
-				}
+				manager.removeNodeNetworkInfo(storage, info)
 
 				continue
 			}
@@ -512,28 +798,63 @@ func (manager *NetworkManager) removeProviderNetworks(providers []string, nodeID
 
 		for instanceIdent, netInfo := range manager.instancesData[networkID] {
 			if err := manager.removeInstanceNetworkParameters(
-				networkID, instanceIdent, net.IP(netInfo.IP)); err != nil {
+				storage, networkID, instanceIdent, net.ParseIP(netInfo.IP)); err != nil {
 				log.Errorf("Can't remove network info: %v", err)
 			}
 		}
 
 		delete(manager.providerNetworks, networkID)
 		manager.ipamSubnet.releaseIPNetPool(networkID)
+
+		if err := storage.RemoveNetworkIPAllocations(networkID); err != nil {
+			log.Errorf("Can't remove network IP allocations: %v", err)
+		}
 	}
 }
 
+func (manager *NetworkManager) removeNodeNetworkInfo(storage Storage, info NetworkParametersStorage) {
+	if err := storage.RemoveNetworkInfo(info.NetworkID, info.NodeID); err != nil {
+		log.Errorf("Can't remove network info: %v", err)
+	}
+
+	if ip := net.ParseIP(info.IP); ip != nil {
+		manager.ipamSubnet.releaseIPToSubnet(info.NetworkID, ip)
+	}
+
+	if err := storage.RemoveIPAllocation(info.NetworkID, info.IP); err != nil {
+		log.Errorf("Can't remove IP allocation: %v", err)
+	}
+}
+
+func (manager *NetworkManager) addIPAllocation(storage Storage, networkID string, subnet *net.IPNet, ip net.IP) error {
+	for _, allocation := range []IPAllocation{
+		{NetworkID: networkID, Subnet: subnet.String()},
+		{NetworkID: networkID, Subnet: subnet.String(), IP: ip.String()},
+	} {
+		if err := storage.AddIPAllocation(allocation); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
 func (manager *NetworkManager) setupNetworkParameters(
-	providerID string, networkParameter *NetworkParametersStorage,
+	storage Storage, providerID string, networkParameter *NetworkParametersStorage,
 ) error {
-	subnet, ip, err := GetIPSubnet(providerID)
+	subnet, ip, err := GetIPSubnet(providerID, "")
 	if err != nil {
 		return err
 	}
 
+	if err := manager.addIPAllocation(storage, providerID, subnet, ip); err != nil {
+		return err
+	}
+
 	networkParameter.Subnet = subnet.String()
 	networkParameter.IP = ip.String()
 
-	if err := manager.storage.AddNetworkInfo(*networkParameter); err != nil {
+	if err := storage.AddNetworkInfo(*networkParameter); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
@@ -542,7 +863,9 @@ func (manager *NetworkManager) setupNetworkParameters(
 	return nil
 }
 
-func (manager *NetworkManager) createProviderNetwork(providerID, nodeID string) (aostypes.NetworkParameters, error) {
+func (manager *NetworkManager) createProviderNetwork(
+	storage Storage, providerID, nodeID string,
+) (aostypes.NetworkParameters, error) {
 	networkParameter := NetworkParametersStorage{
 		NetworkParameters: aostypes.NetworkParameters{
 			NetworkID: providerID,
@@ -555,7 +878,7 @@ func (manager *NetworkManager) createProviderNetwork(providerID, nodeID string)
 		return aostypes.NetworkParameters{}, err
 	}
 
-	if err := manager.setupNetworkParameters(providerID, &networkParameter); err != nil {
+	if err := manager.setupNetworkParameters(storage, providerID, &networkParameter); err != nil {
 		return aostypes.NetworkParameters{}, err
 	}
 
@@ -563,7 +886,7 @@ func (manager *NetworkManager) createProviderNetwork(providerID, nodeID string)
 }
 
 func (manager *NetworkManager) updateProviderNetworkForNode(
-	providerID, nodeID string, existingNetwork aostypes.NetworkParameters,
+	storage Storage, providerID, nodeID string, existingNetwork aostypes.NetworkParameters,
 ) (aostypes.NetworkParameters, error) {
 	networkParameter := NetworkParametersStorage{
 		// vlanID should be the same for the same network provider
@@ -571,7 +894,7 @@ func (manager *NetworkManager) updateProviderNetworkForNode(
 		NodeID:            nodeID,
 	}
 
-	if err := manager.setupNetworkParameters(providerID, &networkParameter); err != nil {
+	if err := manager.setupNetworkParameters(storage, providerID, &networkParameter); err != nil {
 		return aostypes.NetworkParameters{}, err
 	}
 
@@ -579,7 +902,7 @@ func (manager *NetworkManager) updateProviderNetworkForNode(
 }
 
 func (manager *NetworkManager) addProviderNetworks(
-	providers []string, nodeID string,
+	storage Storage, providers []string, nodeID string,
 ) (networkParameters []aostypes.NetworkParameters, err error) {
 nextProvider:
 	for _, providerID := range providers {
@@ -592,7 +915,7 @@ nextProvider:
 				}
 			}
 
-			netParam, err := manager.updateProviderNetworkForNode(providerID, nodeID, networks[0].NetworkParameters)
+			netParam, err := manager.updateProviderNetworkForNode(storage, providerID, nodeID, networks[0].NetworkParameters)
 			if err != nil {
 				return networkParameters, err
 			}
@@ -602,7 +925,7 @@ nextProvider:
 			continue
 		}
 
-		netParam, err := manager.createProviderNetwork(providerID, nodeID)
+		netParam, err := manager.createProviderNetwork(storage, providerID, nodeID)
 		if err != nil {
 			return networkParameters, err
 		}
@@ -614,21 +937,42 @@ nextProvider:
 }
 
 func (manager *NetworkManager) getVlanID(networkID string) (uint64, error) {
-	vlanID, err := rand.Int(rand.Reader, big.NewInt(vlanIDCapacity))
+	vlanID, err := rand.Int(rand.Reader, big.NewInt(int64(vlanIDCapacity-len(manager.reservedVlanIDs))))
 	if err != nil {
 		return 0, aoserrors.Wrap(err)
 	}
 
-	return vlanID.Uint64() + 1, nil
+	// Reserved IDs are sorted, shift random value over reserved IDs not greater than it
+	result := vlanID.Uint64() + 1
+
+	for _, reservedID := range manager.reservedVlanIDs {
+		if reservedID > result {
+			break
+		}
+
+		result++
+	}
+
+	return result, nil
 }
 
-func parseAllowConnection(connection string) (serviceID, port, protocol string, err error) {
+func parseAllowConnection(connection string) (serviceID, subjectID, port, protocol string, err error) {
 	connConf := strings.Split(connection, "/")
 	if len(connConf) > allowedConnectionsExpectedLen || len(connConf) < 2 {
-		return "", "", "", aoserrors.Errorf("unsupported AllowedConnections format %s", connConf)
+		return "", "", "", "", aoserrors.Errorf("unsupported AllowedConnections format %s", connConf)
 	}
 
 	serviceID = connConf[0]
+
+	// Rule may be scoped to instances of particular subject: serviceID@subjectID/port/proto
+	if service, subject, found := strings.Cut(serviceID, "@"); found {
+		if service == "" || subject == "" {
+			return "", "", "", "", aoserrors.Errorf("unsupported AllowedConnections format %s", connConf)
+		}
+
+		serviceID, subjectID = service, subject
+	}
+
 	port = connConf[1]
 	protocol = "tcp"
 
@@ -636,7 +980,7 @@ func parseAllowConnection(connection string) (serviceID, port, protocol string,
 		protocol = connConf[2]
 	}
 
-	return serviceID, port, protocol, nil
+	return serviceID, subjectID, port, protocol, nil
 }
 
 func ruleExists(info InstanceNetworkInfo, port, protocol string) bool {
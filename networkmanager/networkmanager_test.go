@@ -18,20 +18,26 @@
 package networkmanager_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
 	"github.com/apparentlymart/go-cidr/cidr"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/networkmanager"
 )
@@ -50,14 +56,26 @@ type ipamTest struct {
 }
 
 type testStore struct {
-	networkInfos map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo
+	networkInfos  map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo
+	ipAllocations []networkmanager.IPAllocation
+	networks      []networkmanager.NetworkParametersStorage
+}
+
+type testAlertSender struct {
+	alerts []interface{}
 }
 
 type testNodeManager struct {
+	sync.Mutex
 	network   map[string][]aostypes.NetworkParameters
+	mirrors   map[string][]networkmanager.TrafficMirror
 	chanReady chan struct{}
 }
 
+type testEventsSender struct {
+	events []amqphandler.ProviderNetworkEvent
+}
+
 type testVlan struct {
 	vlanID int
 }
@@ -117,7 +135,7 @@ func TestBaseNetwork(t *testing.T) {
 		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
 	}
 
-	manager, err := networkmanager.New(storage, nil, &config.Config{
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
 		WorkingDir: tmpDir,
 	})
 	if err != nil {
@@ -158,13 +176,13 @@ func TestBaseNetwork(t *testing.T) {
 
 	for _, data := range testData {
 		if data.removeConfig {
-			manager.RemoveInstanceNetworkParameters(data.instance)
+			manager.RemoveInstanceNetworkParameters(storage, data.instance)
 
 			continue
 		}
 
 		networkParameters, err := manager.PrepareInstanceNetworkParameters(
-			data.instance, "network1", networkmanager.NetworkParameters{
+			storage, data.instance, "network1", networkmanager.NetworkParameters{
 				Hosts: data.hosts,
 			})
 		if err != nil {
@@ -249,7 +267,7 @@ func TestAllowConnection(t *testing.T) {
 		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
 	}
 
-	manager, err := networkmanager.New(storage, nil, &config.Config{
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
 		WorkingDir: tmpDir,
 	})
 	if err != nil {
@@ -301,7 +319,7 @@ func TestAllowConnection(t *testing.T) {
 
 	for _, data := range testData {
 		networkParameters, err := manager.PrepareInstanceNetworkParameters(
-			data.instance, data.network, networkmanager.NetworkParameters{
+			storage, data.instance, data.network, networkmanager.NetworkParameters{
 				AllowConnections: data.allowConnections,
 				ExposePorts:      data.exposePorts,
 			})
@@ -329,7 +347,7 @@ func TestAllowConnection(t *testing.T) {
 	}
 }
 
-func TestNetworkStorage(t *testing.T) {
+func TestAllowConnectionBySubject(t *testing.T) {
 	ipam, err := newIpam()
 	if err != nil {
 		t.Fatalf("Can't init ipam management: %v", err)
@@ -344,92 +362,54 @@ func TestNetworkStorage(t *testing.T) {
 		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
 	}
 
-	manager, err := networkmanager.New(storage, nil, &config.Config{
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
 		WorkingDir: tmpDir,
 	})
 	if err != nil {
 		t.Fatalf("Can't create network manager: %v", err)
 	}
 
-	testData := []struct {
-		networkParameters aostypes.NetworkParameters
-		instance          aostypes.InstanceIdent
-		hosts             []string
-	}{
-		{
-			networkParameters: aostypes.NetworkParameters{
-				IP:     ("172.17.0.1"),
-				Subnet: ("172.17.0.0/16"),
-			},
-			instance: aostypes.InstanceIdent{
-				ServiceID: "service1",
-				SubjectID: "subject1",
-				Instance:  1,
-			},
-			hosts: []string{"hosts1"},
-		},
-		{
-			networkParameters: aostypes.NetworkParameters{
-				IP:     ("172.17.0.2"),
-				Subnet: ("172.17.0.0/16"),
-			},
-			instance: aostypes.InstanceIdent{
-				ServiceID: "service1",
-				SubjectID: "subject1",
-				Instance:  2,
-			},
-			hosts: []string{"hosts2"},
-		},
-	}
-
-	for _, data := range testData {
+	for _, subjectID := range []string{"subject1", "subject2"} {
 		if _, err := manager.PrepareInstanceNetworkParameters(
-			data.instance, "network1", networkmanager.NetworkParameters{
-				Hosts: data.hosts,
-			}); err != nil {
+			storage, aostypes.InstanceIdent{ServiceID: "service1", SubjectID: subjectID, Instance: 0}, "network1",
+			networkmanager.NetworkParameters{ExposePorts: []string{"10001/udp"}}); err != nil {
 			t.Fatalf("Can't prepare instance network configuration: %v", err)
 		}
 	}
 
-	manager1, err := networkmanager.New(storage, nil, &config.Config{
-		WorkingDir: tmpDir,
-	})
+	networkParameters, err := manager.PrepareInstanceNetworkParameters(
+		storage, aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject2", Instance: 0}, "network2",
+		networkmanager.NetworkParameters{AllowConnections: []string{"service1@subject2/10001/udp"}})
 	if err != nil {
-		t.Fatalf("Can't create network manager: %v", err)
+		t.Fatalf("Can't prepare instance network configuration: %v", err)
 	}
 
-	expectedInstancesIdent := []aostypes.InstanceIdent{
-		{
-			ServiceID: "service1",
-			SubjectID: "subject1",
-			Instance:  1,
-		},
-		{
-			ServiceID: "service1",
-			SubjectID: "subject1",
-			Instance:  2,
-		},
+	expectedRules := []aostypes.FirewallRule{
+		{Proto: "udp", DstPort: "10001", SrcIP: "172.18.0.1", DstIP: "172.17.0.2"},
 	}
 
-	instances := manager1.GetInstances()
-	if !compareInstancesIdent(instances, expectedInstancesIdent) {
-		t.Error("Unexpected instances ident")
+	if !reflect.DeepEqual(networkParameters.FirewallRules, expectedRules) {
+		t.Errorf("Wrong firewall rules: %v", networkParameters.FirewallRules)
+	}
+
+	if _, err = manager.PrepareInstanceNetworkParameters(
+		storage, aostypes.InstanceIdent{ServiceID: "service3", SubjectID: "subject2", Instance: 0}, "network2",
+		networkmanager.NetworkParameters{AllowConnections: []string{"service1@/10001/udp"}}); err == nil {
+		t.Error("Error expected for empty subject")
 	}
 }
 
-func TestNetworkUpdates(t *testing.T) {
+func TestNetworkIsolation(t *testing.T) {
 	ipam, err := newIpam()
 	if err != nil {
 		t.Fatalf("Can't init ipam management: %v", err)
 	}
 
-	vlan := &testVlan{}
-
 	networkmanager.GetIPSubnet = ipam.getIPSubnet
 	networkmanager.LookPath = lookPath
 	networkmanager.DiscoverInterface = discoverInterface
 	networkmanager.ExecContext = newTestShellCommander
-	networkmanager.GetVlanID = vlan.getVlanID
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
 
 	storage := &testStore{
 		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
@@ -437,152 +417,1800 @@ func TestNetworkUpdates(t *testing.T) {
 
 	nodeManager := &testNodeManager{
 		network:   make(map[string][]aostypes.NetworkParameters),
-		chanReady: make(chan struct{}, 2),
+		chanReady: make(chan struct{}, 1),
 	}
 
-	manager, err := networkmanager.New(storage, nodeManager, &config.Config{
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
 		WorkingDir: tmpDir,
+		Network: config.Network{
+			Isolation: map[string]config.NetworkIsolation{
+				"network1": {Level: config.IsolationStrict, HostAccess: true},
+				"network2": {Level: config.IsolationOpen},
+			},
+		},
 	})
 	if err != nil {
 		t.Fatalf("Can't create network manager: %v", err)
 	}
 
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	hostRules := func(srcIP string) []aostypes.FirewallRule {
+		return []aostypes.FirewallRule{
+			{Proto: "tcp", DstPort: "1-65535", SrcIP: srcIP, DstIP: "172.17.0.1"},
+			{Proto: "udp", DstPort: "1-65535", SrcIP: srcIP, DstIP: "172.17.0.1"},
+		}
+	}
+
 	testData := []struct {
-		providers                 []string
-		nodeID                    string
-		expectedNetworkParameters []aostypes.NetworkParameters
+		instance         aostypes.InstanceIdent
+		network          string
+		exposePorts      []string
+		allowConnections []string
+		expectedRules    []aostypes.FirewallRule
 	}{
 		{
-			providers: []string{"network1", "network2"},
-			nodeID:    "node1",
-			expectedNetworkParameters: []aostypes.NetworkParameters{
-				{
-					NetworkID: "network1",
-					IP:        "172.17.0.1",
-					Subnet:    "172.17.0.0/16",
-					VlanID:    1,
-				},
-				{
-					NetworkID: "network2",
-					IP:        "172.18.0.1",
-					Subnet:    "172.18.0.0/16",
-					VlanID:    2,
-				},
-			},
+			instance:      aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"},
+			network:       "network1",
+			exposePorts:   []string{"10001/udp"},
+			expectedRules: hostRules("172.17.0.2"),
 		},
 		{
-			providers: []string{"network1"},
-			nodeID:    "node1",
-			expectedNetworkParameters: []aostypes.NetworkParameters{
+			instance:         aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"},
+			network:          "network1",
+			allowConnections: []string{"service1/10001/udp"},
+			expectedRules: append([]aostypes.FirewallRule{
+				{Proto: "udp", DstPort: "10001", SrcIP: "172.17.0.3", DstIP: "172.17.0.2"},
+			}, hostRules("172.17.0.3")...),
+		},
+		{
+			instance:    aostypes.InstanceIdent{ServiceID: "service3", SubjectID: "subject1"},
+			network:     "network2",
+			exposePorts: []string{"10002/tcp"},
+		},
+		{
+			instance:         aostypes.InstanceIdent{ServiceID: "service4", SubjectID: "subject1"},
+			network:          "network2",
+			allowConnections: []string{"service3/10002/tcp"},
+		},
+	}
+
+	for _, data := range testData {
+		networkParameters, err := manager.PrepareInstanceNetworkParameters(
+			storage, data.instance, data.network, networkmanager.NetworkParameters{
+				AllowConnections: data.allowConnections,
+				ExposePorts:      data.exposePorts,
+			})
+		if err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+
+		if !reflect.DeepEqual(networkParameters.FirewallRules, data.expectedRules) {
+			t.Errorf("Wrong firewall rules of %s: %v", data.instance.ServiceID, networkParameters.FirewallRules)
+		}
+	}
+
+	for _, data := range []struct {
+		source, destination string
+		reachable           bool
+	}{
+		{source: "service1", destination: "service2", reachable: false},
+		{source: "service2", destination: "service1", reachable: true},
+		{source: "service4", destination: "service3", reachable: true},
+	} {
+		result, err := manager.CheckConnectivity(networkmanager.ConnectivityRequest{
+			Source:      aostypes.InstanceIdent{ServiceID: data.source, SubjectID: "subject1"},
+			Destination: aostypes.InstanceIdent{ServiceID: data.destination, SubjectID: "subject1"},
+			Protocol:    "udp",
+		})
+		if err != nil {
+			t.Fatalf("Can't check connectivity: %v", err)
+		}
+
+		if result.Reachable != data.reachable {
+			t.Errorf("Wrong %s to %s connectivity: %v", data.source, data.destination, result)
+		}
+	}
+}
+
+func TestScheduledFirewallRules(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	start := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	end := start.Add(time.Second)
+
+	if end.Day() != time.Now().Day() {
+		t.Skip("Window crosses midnight")
+	}
+
+	timeOfDay := func(value time.Time) aostypes.Time {
+		return aostypes.Time{Time: time.Date(0, 1, 1, value.Hour(), value.Minute(), value.Second(), 0, time.Local)}
+	}
+
+	dayOfWeek := uint(start.Weekday())
+	if dayOfWeek == 0 {
+		dayOfWeek = 7
+	}
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 1),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: tmpDir,
+		Network: config.Network{
+			ScheduledRules: []config.ScheduledFirewallRule{
 				{
-					NetworkID: "network1",
-					IP:        "172.17.0.1",
-					Subnet:    "172.17.0.0/16",
-					VlanID:    1,
+					NetworkID: "network1", DstPort: "8022", Proto: "tcp",
+					Timetable: []cloudprotocol.TimetableEntry{{
+						DayOfWeek: dayOfWeek,
+						TimeSlots: []cloudprotocol.TimeSlot{{Start: timeOfDay(start), End: timeOfDay(end)}},
+					}},
 				},
 			},
 		},
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
 	}
+	defer manager.Close()
 
-	for _, data := range testData {
-		if err := manager.UpdateProviderNetwork(data.providers, data.nodeID); err != nil {
-			t.Fatalf("Can't update node network parameters: %v", err)
-		}
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
 
+	expectedRules := []aostypes.FirewallRule{{DstIP: "172.17.0.1", DstPort: "8022", Proto: "tcp"}}
+
+	for _, rules := range [][]aostypes.FirewallRule{nil, expectedRules, nil} {
 		select {
 		case <-nodeManager.chanReady:
-		case <-time.After(1 * time.Second):
+		case <-time.After(3 * time.Second):
 			t.Fatal("Timeout waiting for node manager")
 		}
 
-		networkParameters := nodeManager.network[data.nodeID]
+		networkParameters := nodeManager.getNetwork("node1")
 
-		if !reflect.DeepEqual(networkParameters, data.expectedNetworkParameters) {
-			t.Error("Unexpected network parameters")
+		if len(networkParameters) != 1 || !reflect.DeepEqual(networkParameters[0].FirewallRules, rules) {
+			t.Errorf("Wrong node network parameters: %v", networkParameters)
 		}
 	}
 }
 
-/***********************************************************************************************************************
- * Interfaces
- **********************************************************************************************************************/
+func TestTrafficMirror(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
 
-func newIpam() (*ipamTest, error) {
-	ipamInfo := &ipamTest{
-		ipamData: make(map[string]*ipam),
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		mirrors:   make(map[string][]networkmanager.TrafficMirror),
+		chanReady: make(chan struct{}, 1),
 	}
 
-	ip, ipnet, err := net.ParseCIDR("172.17.0.0/16")
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: filepath.Join(tmpDir, "mirror"),
+		Network:    config.Network{MaxMirrorDuration: aostypes.Duration{Duration: time.Hour}},
+	})
 	if err != nil {
-		return nil, aoserrors.Wrap(err)
+		t.Fatalf("Can't create network manager: %v", err)
 	}
+	defer manager.Close()
 
-	ipamInfo.ipamData["network1"] = &ipam{
-		subnet: *ipnet,
-		ip:     ip,
+	manager.SetTrafficMirrorSender(nodeManager)
+
+	waitNodeUpdate := func() {
+		t.Helper()
+
+		select {
+		case <-nodeManager.chanReady:
+		case <-time.After(3 * time.Second):
+			t.Fatal("Timeout waiting for node manager")
+		}
 	}
 
-	if ip, ipnet, err = net.ParseCIDR("172.18.0.0/16"); err != nil {
-		return nil, aoserrors.Wrap(err)
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
 	}
 
-	ipamInfo.ipamData["network2"] = &ipam{
-		subnet: *ipnet,
-		ip:     ip,
+	waitNodeUpdate()
+
+	source := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+	collector := aostypes.InstanceIdent{ServiceID: "collector", SubjectID: "subject1", Instance: 0}
+
+	for _, instance := range []aostypes.InstanceIdent{source, collector} {
+		if _, err := manager.PrepareInstanceNetworkParameters(
+			storage, instance, "network1", networkmanager.NetworkParameters{}); err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
 	}
 
-	return ipamInfo, nil
-}
+	for _, request := range []networkmanager.TrafficMirrorRequest{
+		{Source: source, Collector: collector, Duration: aostypes.Duration{Duration: 2 * time.Hour}},
+		{Source: source, Collector: collector, Protocol: "icmp", Duration: aostypes.Duration{Duration: time.Minute}},
+		{Source: source, Collector: collector, Port: "80-20", Duration: aostypes.Duration{Duration: time.Minute}},
+		{
+			Source: source, Collector: aostypes.InstanceIdent{ServiceID: "unknown"},
+			Duration: aostypes.Duration{Duration: time.Minute},
+		},
+	} {
+		if _, err := manager.StartTrafficMirror(request); err == nil {
+			t.Errorf("Error expected for request: %v", request)
+		}
+	}
 
-func (ipam *ipamTest) getIPSubnet(networkID string) (*net.IPNet, net.IP, error) {
-	ipamInfo, ok := ipam.ipamData[networkID]
-	if !ok {
-		return nil, nil, aoserrors.Errorf("Can't find network %v", networkID)
+	mirror, err := manager.StartTrafficMirror(networkmanager.TrafficMirrorRequest{
+		Source: source, Collector: collector, Port: "8080", Protocol: "tcp",
+		Duration: aostypes.Duration{Duration: time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Can't start traffic mirror: %v", err)
 	}
 
-	ipamInfo.ip = cidr.Inc(ipamInfo.ip)
+	waitNodeUpdate()
 
-	return &ipamInfo.subnet, ipamInfo.ip, nil
-}
+	if mirror.NetworkID != "network1" || mirror.SourceIP == "" || mirror.CollectorIP == "" ||
+		mirror.SourceIP == mirror.CollectorIP {
+		t.Errorf("Wrong traffic mirror: %v", mirror)
+	}
 
-func (storage *testStore) AddNetworkInstanceInfo(networkInfo networkmanager.InstanceNetworkInfo) error {
-	storage.networkInfos[networkInfo.InstanceIdent] = networkInfo
+	if mirrors := nodeManager.getMirrors("node1"); !reflect.DeepEqual(mirrors, []networkmanager.TrafficMirror{mirror}) {
+		t.Errorf("Wrong node traffic mirrors: %v", mirrors)
+	}
 
-	return nil
-}
+	if len(nodeManager.getNetwork("node1")) != 1 {
+		t.Errorf("Wrong node network parameters: %v", nodeManager.getNetwork("node1"))
+	}
 
-func (storage *testStore) RemoveNetworkInstanceInfo(instanceIdent aostypes.InstanceIdent) error {
-	delete(storage.networkInfos, instanceIdent)
+	if mirrors := manager.GetTrafficMirrors(); len(mirrors) != 1 || mirrors[0].ID != mirror.ID {
+		t.Errorf("Wrong traffic mirrors: %v", mirrors)
+	}
 
-	return nil
-}
+	// Mirror expires
 
-func (storage *testStore) GetNetworkInstancesInfo() (networkInfos []networkmanager.InstanceNetworkInfo, err error) {
-	for _, networkInfo := range storage.networkInfos {
-		networkInfos = append(networkInfos, networkInfo)
+	waitNodeUpdate()
+
+	if mirrors := nodeManager.getMirrors("node1"); len(mirrors) != 0 {
+		t.Errorf("Traffic mirror is not removed from node: %v", mirrors)
 	}
 
-	return networkInfos, err
-}
+	if mirrors := manager.GetTrafficMirrors(); len(mirrors) != 0 {
+		t.Errorf("Traffic mirror is not expired: %v", mirrors)
+	}
 
-func (storage *testStore) RemoveNetworkInfo(networkID string, nodeID string) error {
-	return nil
-}
+	if mirror, err = manager.StartTrafficMirror(networkmanager.TrafficMirrorRequest{
+		Source: source, Collector: collector, Duration: aostypes.Duration{Duration: time.Minute},
+	}); err != nil {
+		t.Fatalf("Can't start traffic mirror: %v", err)
+	}
 
-func (storage *testStore) AddNetworkInfo(networkInfo networkmanager.NetworkParametersStorage) error {
-	return nil
-}
+	waitNodeUpdate()
 
-func (storage *testStore) GetNetworksInfo() (networkInfos []networkmanager.NetworkParametersStorage, err error) {
-	return nil, nil
-}
+	if err = manager.StopTrafficMirror(mirror.ID); err != nil {
+		t.Fatalf("Can't stop traffic mirror: %v", err)
+	}
 
-func (node *testNodeManager) UpdateNetwork(nodeID string, networkParameters []aostypes.NetworkParameters) error {
-	node.network[nodeID] = networkParameters
+	waitNodeUpdate()
 
-	node.chanReady <- struct{}{}
+	if mirrors := nodeManager.getMirrors("node1"); len(mirrors) != 0 {
+		t.Errorf("Traffic mirror is not removed from node: %v", mirrors)
+	}
 
-	return nil
+	if err = manager.StopTrafficMirror(mirror.ID); err == nil {
+		t.Error("Error expected for stopped traffic mirror")
+	}
+}
+
+func TestProviderNetworkEvents(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 10),
+	}
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{WorkingDir: filepath.Join(tmpDir, "events")})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+	defer manager.Close()
+
+	sender := &testEventsSender{}
+
+	manager.SetNetworkEventsSender(sender)
+
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	expectedEvents := []amqphandler.ProviderNetworkEvent{
+		{Event: networkmanager.NetworkEventCreated, NetworkID: "network1", Subnet: "172.17.0.0/16"},
+		{Event: networkmanager.NetworkEventVlanAssigned, NetworkID: "network1", VlanID: 1},
+		{Event: networkmanager.NetworkEventNodeAttached, NetworkID: "network1", NodeID: "node1", IP: "172.17.0.1"},
+	}
+
+	if events := sender.getEvents(); !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("Wrong provider network events: %v", events)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node2"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	expectedEvents = []amqphandler.ProviderNetworkEvent{
+		{Event: networkmanager.NetworkEventNodeAttached, NetworkID: "network1", NodeID: "node2", IP: "172.17.0.2"},
+	}
+
+	if events := sender.getEvents(); !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("Wrong provider network events: %v", events)
+	}
+
+	expectedStatus := []amqphandler.ProviderNetworkStatus{{
+		NetworkID: "network1", Subnet: "172.17.0.0/16", VlanID: 1,
+		Nodes: []amqphandler.ProviderNetworkNode{{NodeID: "node1", IP: "172.17.0.1"}, {NodeID: "node2", IP: "172.17.0.2"}},
+	}}
+
+	if status := manager.GetProviderNetworksStatus(); !reflect.DeepEqual(status, expectedStatus) {
+		t.Errorf("Wrong provider networks status: %v", status)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, nil, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	expectedEvents = []amqphandler.ProviderNetworkEvent{
+		{Event: networkmanager.NetworkEventNodeDetached, NetworkID: "network1", NodeID: "node1"},
+	}
+
+	if events := sender.getEvents(); !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("Wrong provider network events: %v", events)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, nil, "node2"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	expectedEvents = []amqphandler.ProviderNetworkEvent{
+		{Event: networkmanager.NetworkEventNodeDetached, NetworkID: "network1", NodeID: "node2"},
+		{Event: networkmanager.NetworkEventRemoved, NetworkID: "network1"},
+	}
+
+	if events := sender.getEvents(); !reflect.DeepEqual(events, expectedEvents) {
+		t.Errorf("Wrong provider network events: %v", events)
+	}
+
+	if status := manager.GetProviderNetworksStatus(); len(status) != 0 {
+		t.Errorf("Wrong provider networks status: %v", status)
+	}
+}
+
+func TestIPAllocationsAudit(t *testing.T) {
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	newInstanceInfo := func(
+		serviceID string, instance uint64, networkID, subnet, ip string,
+	) networkmanager.InstanceNetworkInfo {
+		return networkmanager.InstanceNetworkInfo{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: serviceID, SubjectID: "subject1", Instance: instance},
+			NetworkParameters: aostypes.NetworkParameters{
+				NetworkID: networkID, Subnet: subnet, IP: ip,
+			},
+		}
+	}
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+		ipAllocations: []networkmanager.IPAllocation{
+			{NetworkID: "network1", Subnet: "172.17.0.0/16"},
+			{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.2"},
+			{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.10"},
+			{NetworkID: "network3", Subnet: "10.0.0.0/24"},
+		},
+	}
+
+	for _, info := range []networkmanager.InstanceNetworkInfo{
+		newInstanceInfo("service1", 0, "network1", "172.17.0.0/16", "172.17.0.2"),
+		newInstanceInfo("service1", 1, "network1", "172.17.0.0/16", "172.17.0.3"),
+		// IP is already used
+		newInstanceInfo("service2", 0, "network1", "172.17.0.0/16", "172.17.0.2"),
+		// Subnet differs from network subnet
+		newInstanceInfo("service3", 0, "network1", "172.18.0.0/16", "172.18.0.2"),
+		// Subnet overlaps other network subnet
+		newInstanceInfo("service4", 0, "network2", "172.17.128.0/17", "172.17.128.2"),
+	} {
+		storage.networkInfos[info.InstanceIdent] = info
+	}
+
+	alertSender := &testAlertSender{}
+
+	manager, err := networkmanager.New(storage, nil, alertSender, &config.Config{WorkingDir: tmpDir})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	expectedInstances := []aostypes.InstanceIdent{
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 1},
+	}
+
+	if !compareInstancesIdent(manager.GetInstances(), expectedInstances) {
+		t.Errorf("Wrong instances: %v", manager.GetInstances())
+	}
+
+	if len(storage.networkInfos) != len(expectedInstances) {
+		t.Errorf("Wrong stored instances count: %d", len(storage.networkInfos))
+	}
+
+	expectedAllocations := []networkmanager.IPAllocation{
+		{NetworkID: "network1", Subnet: "172.17.0.0/16"},
+		{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.2"},
+		{NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.3"},
+	}
+
+	allocations, _ := storage.GetIPAllocations()
+
+	sort.Slice(allocations, func(i, j int) bool { return allocations[i].IP < allocations[j].IP })
+
+	if !reflect.DeepEqual(allocations, expectedAllocations) {
+		t.Errorf("Wrong IP allocations: %v", allocations)
+	}
+
+	// 3 removed instances, 2 stale allocations and 1 missing allocation
+	if len(alertSender.alerts) != 6 {
+		t.Errorf("Wrong alerts count: %d", len(alertSender.alerts))
+	}
+
+	alertSender.alerts = nil
+
+	if _, err = networkmanager.New(storage, nil, alertSender, &config.Config{WorkingDir: tmpDir}); err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if len(alertSender.alerts) != 0 {
+		t.Errorf("Unexpected alerts: %v", alertSender.alerts)
+	}
+}
+
+func TestReservedNetworks(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	getVlanID := networkmanager.GetVlanID
+	networkmanager.GetVlanID = nil
+
+	defer func() { networkmanager.GetVlanID = getVlanID }()
+
+	const maxReservedVlanID = 4000
+
+	reservedVlanIDs := make([]uint64, 0, maxReservedVlanID)
+
+	for vlanID := uint64(1); vlanID <= maxReservedVlanID; vlanID++ {
+		reservedVlanIDs = append(reservedVlanIDs, vlanID)
+	}
+
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+	vlanInstanceIdent := aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1", Instance: 0}
+
+	storage := &testStore{
+		networkInfos: map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo{
+			instanceIdent: {
+				InstanceIdent: instanceIdent,
+				NetworkParameters: aostypes.NetworkParameters{
+					NetworkID: "network1", Subnet: "10.10.1.0/24", IP: "10.10.1.2",
+				},
+			},
+			vlanInstanceIdent: {
+				InstanceIdent: vlanInstanceIdent,
+				NetworkParameters: aostypes.NetworkParameters{
+					NetworkID: "network2", Subnet: "172.19.0.0/16", IP: "172.19.0.2",
+				},
+			},
+		},
+		networks: []networkmanager.NetworkParametersStorage{
+			{
+				NodeID: "node1",
+				NetworkParameters: aostypes.NetworkParameters{
+					NetworkID: "network2", Subnet: "172.19.0.0/16", IP: "172.19.0.1", VlanID: 1,
+				},
+			},
+		},
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 1),
+	}
+
+	alertSender := &testAlertSender{}
+
+	manager, err := networkmanager.New(storage, nodeManager, alertSender, &config.Config{
+		WorkingDir: tmpDir,
+		Network: config.Network{
+			ReservedVlanIDs: reservedVlanIDs,
+			ReservedSubnets: []string{"10.10.0.0/16"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if len(manager.GetInstances()) != 0 || len(storage.networkInfos) != 0 {
+		t.Error("Instances in reserved subnet or VLAN ID should be removed")
+	}
+
+	if len(storage.networks) != 0 {
+		t.Error("Network with reserved VLAN ID should be removed")
+	}
+
+	if len(alertSender.alerts) == 0 {
+		t.Error("Reserved subnet repair should be reported")
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+			t.Fatalf("Can't update provider network: %v", err)
+		}
+
+		select {
+		case <-nodeManager.chanReady:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for node manager")
+		}
+
+		if vlanID := nodeManager.network["node1"][0].VlanID; vlanID <= maxReservedVlanID {
+			t.Errorf("Reserved VLAN ID allocated: %d", vlanID)
+		}
+
+		if err := manager.UpdateProviderNetwork(storage, nil, "node1"); err != nil {
+			t.Fatalf("Can't update provider network: %v", err)
+		}
+
+		select {
+		case <-nodeManager.chanReady:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for node manager")
+		}
+	}
+
+	for vlanID := uint64(maxReservedVlanID + 1); vlanID <= config.MaxVlanID; vlanID++ {
+		reservedVlanIDs = append(reservedVlanIDs, vlanID)
+	}
+
+	if _, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir: tmpDir,
+		Network:    config.Network{ReservedVlanIDs: reservedVlanIDs},
+	}); err == nil {
+		t.Error("Error expected when all VLAN IDs are reserved")
+	}
+}
+
+func TestDNSQueryLog(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	workingDir := filepath.Join(tmpDir, "querylog")
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir:  workingDir,
+		DNSQueryLog: config.DNSQueryLog{Enabled: true, TopNames: 2},
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+	defer manager.Close()
+
+	rawConfig, err := os.ReadFile(filepath.Join(workingDir, "network", "dnsmasq.conf"))
+	if err != nil {
+		t.Fatalf("Can't read DNS config: %v", err)
+	}
+
+	if !strings.Contains(string(rawConfig), "log-facility="+filepath.Join(workingDir, "network", "querylog")) {
+		t.Errorf("Query log is not configured: %s", rawConfig)
+	}
+
+	instances := []aostypes.InstanceIdent{
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+		{ServiceID: "service2", SubjectID: "subject1", Instance: 0},
+	}
+
+	for _, instance := range instances {
+		if _, err := manager.PrepareInstanceNetworkParameters(
+			storage, instance, "network1", networkmanager.NetworkParameters{}); err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+	}
+
+	logFile := filepath.Join(workingDir, "network", "querylog")
+
+	if err = os.WriteFile(logFile, []byte(`Oct 15 10:00:00 dnsmasq[100]: started, version 2.89 cachesize 150
+Oct 15 10:00:01 dnsmasq[100]: 1 172.17.0.1/40001 query[A] service2 from 172.17.0.1
+Oct 15 10:00:01 dnsmasq[100]: 1 172.17.0.1/40001 config service2 is 172.17.0.2
+Oct 15 10:00:02 dnsmasq[100]: 2 172.17.0.1/40002 query[A] Service2 from 172.17.0.1
+Oct 15 10:00:02 dnsmasq[100]: 2 172.17.0.1/40002 config service2 is 172.17.0.2
+Oct 15 10:00:03 dnsmasq[100]: 3 172.17.0.1/40003 query[A] unknown.example from 172.17.0.1
+Oct 15 10:00:03 dnsmasq[100]: 3 172.17.0.1/40003 forwarded unknown.example to 10.10.0.254
+Oct 15 10:00:03 dnsmasq[100]: 3 172.17.0.1/40003 reply unknown.example is NXDOMAIN
+Oct 15 10:00:04 dnsmasq[100]: 4 172.17.0.1/40004 query[AAAA] service3 from 172.17.0.1
+Oct 15 10:00:04 dnsmasq[100]: 4 172.17.0.1/40004 config service3 is NXDOMAIN
+Oct 15 10:00:05 dnsmasq[100]: 5 10.10.0.1/40005 query[A] node from 10.10.0.1
+Oct 15 10:00:06 dnsmasq[100]: 6 172.17.0.2/40006 query[A] service1 from 172.17.0.2
+`), 0o600); err != nil {
+		t.Fatalf("Can't write query log: %v", err)
+	}
+
+	expectedStats := []networkmanager.InstanceDNSStats{
+		{
+			InstanceIdent: instances[0], Queries: 4, NXDomain: 2, UniqueNames: 3,
+			TopNames: []networkmanager.DNSNameStats{{Name: "service2", Queries: 2}, {Name: "service3", Queries: 1}},
+		},
+		{
+			InstanceIdent: instances[1], Queries: 1, UniqueNames: 1,
+			TopNames: []networkmanager.DNSNameStats{{Name: "service1", Queries: 1}},
+		},
+	}
+
+	if stats := manager.GetDNSStats(); !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("Wrong DNS stats: %v", stats)
+	}
+
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("Can't open query log: %v", err)
+	}
+	defer file.Close()
+
+	// Incomplete line should be processed after it is completed
+	if _, err = file.WriteString("Oct 15 10:00:07 dnsmasq[100]: 7 172.17.0.2/40007 query[A] service1"); err != nil {
+		t.Fatalf("Can't write query log: %v", err)
+	}
+
+	if stats := manager.GetDNSStats(); len(stats) != 0 {
+		t.Errorf("Unexpected DNS stats: %v", stats)
+	}
+
+	if _, err = file.WriteString(" from 172.17.0.2\n"); err != nil {
+		t.Fatalf("Can't write query log: %v", err)
+	}
+
+	expectedStats = []networkmanager.InstanceDNSStats{
+		{
+			InstanceIdent: instances[1], Queries: 1, UniqueNames: 1,
+			TopNames: []networkmanager.DNSNameStats{{Name: "service1", Queries: 1}},
+		},
+	}
+
+	if stats := manager.GetDNSStats(); !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("Wrong DNS stats: %v", stats)
+	}
+}
+
+func TestDNSServerOptions(t *testing.T) {
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	workingDir := filepath.Join(tmpDir, "dnssecurity")
+	trustAnchor := ".,20326,8,2,E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir: workingDir,
+		DNSSecurity: config.DNSSecurity{
+			DNSSEC: true, TrustAnchors: []string{trustAnchor}, Hardened: true, MinPort: 1024,
+		},
+		DNSCache: config.DNSCache{
+			RecordTTL:   aostypes.Duration{Duration: 30 * time.Second},
+			NegativeTTL: aostypes.Duration{Duration: time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+	defer manager.Close()
+
+	rawConfig, err := os.ReadFile(filepath.Join(workingDir, "network", "dnsmasq.conf"))
+	if err != nil {
+		t.Fatalf("Can't read DNS config: %v", err)
+	}
+
+	for _, option := range []string{
+		"dnssec\n", "trust-anchor=" + trustAnchor + "\n", "dnssec-check-unsigned\n", "stop-dns-rebind\n",
+		"rebind-localhost-ok\n", "min-port=1024\n", "local-ttl=30\n", "neg-ttl=60",
+	} {
+		if !strings.Contains(string(rawConfig), option) {
+			t.Errorf("Option %q is not configured: %s", option, rawConfig)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 1),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1", "network2"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	providerNetworks := manager.GetProviderNetworks()
+
+	if len(providerNetworks) != 2 || providerNetworks[0].NetworkID != "network1" ||
+		providerNetworks[1].NetworkID != "network2" || providerNetworks[0].NodeID != "node1" {
+		t.Errorf("Wrong provider networks: %v", providerNetworks)
+	}
+
+	instance1 := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}
+	instance2 := aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"}
+	instance3 := aostypes.InstanceIdent{ServiceID: "service3", SubjectID: "subject1"}
+
+	for _, data := range []struct {
+		instance aostypes.InstanceIdent
+		network  string
+		params   networkmanager.NetworkParameters
+	}{
+		{instance: instance1, network: "network1", params: networkmanager.NetworkParameters{
+			ExposePorts: []string{"8080/tcp"},
+		}},
+		{instance: instance2, network: "network2", params: networkmanager.NetworkParameters{
+			AllowConnections: []string{"service1/8080/tcp"},
+		}},
+		{instance: instance3, network: "network1"},
+	} {
+		if _, err := manager.PrepareInstanceNetworkParameters(storage, data.instance, data.network, data.params); err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+	}
+
+	instanceNetworks := manager.GetInstanceNetworks()
+	if len(instanceNetworks) != 3 {
+		t.Fatalf("Wrong instance networks count: %d", len(instanceNetworks))
+	}
+
+	instanceIPs := make(map[aostypes.InstanceIdent]string)
+
+	for _, instanceNetwork := range instanceNetworks {
+		instanceIPs[instanceNetwork.InstanceIdent] = instanceNetwork.IP
+
+		if instanceNetwork.InstanceIdent == instance2 && len(instanceNetwork.FirewallRules) != 1 {
+			t.Errorf("Wrong firewall rules: %v", instanceNetwork.FirewallRules)
+		}
+	}
+
+	records := manager.GetDNSRecords()
+
+	recordIndex := slices.IndexFunc(records, func(record networkmanager.DNSRecord) bool {
+		return record.IP == instanceIPs[instance1]
+	})
+
+	if recordIndex < 0 || !slices.Contains(records[recordIndex].Hosts, "0.subject1.service1") {
+		t.Errorf("Wrong DNS records: %v", records)
+	}
+
+	testData := []struct {
+		request        networkmanager.ConnectivityRequest
+		expectedResult networkmanager.ConnectivityResult
+	}{
+		{
+			request: networkmanager.ConnectivityRequest{Source: instance2, Destination: instance1, Port: "8080"},
+			expectedResult: networkmanager.ConnectivityResult{
+				Reachable: true, Reason: networkmanager.ConnectivityFirewallRule,
+			},
+		},
+		{
+			request: networkmanager.ConnectivityRequest{Source: instance1, Destination: instance3},
+			expectedResult: networkmanager.ConnectivityResult{
+				Reachable: true, Reason: networkmanager.ConnectivitySameNetwork,
+			},
+		},
+		{
+			request:        networkmanager.ConnectivityRequest{Source: instance3, Destination: instance2},
+			expectedResult: networkmanager.ConnectivityResult{Reason: networkmanager.ConnectivityNoRule},
+		},
+		{
+			request:        networkmanager.ConnectivityRequest{Source: instance2, Destination: instance1, Port: "9090"},
+			expectedResult: networkmanager.ConnectivityResult{Reason: networkmanager.ConnectivityPortNotExposed},
+		},
+	}
+
+	for _, data := range testData {
+		data.expectedResult.SourceIP = instanceIPs[data.request.Source]
+		data.expectedResult.DestinationIP = instanceIPs[data.request.Destination]
+
+		result, err := manager.CheckConnectivity(data.request)
+		if err != nil {
+			t.Fatalf("Can't check connectivity: %v", err)
+		}
+
+		if result != data.expectedResult {
+			t.Errorf("Wrong connectivity result: %v", result)
+		}
+	}
+
+	if _, err := manager.CheckConnectivity(networkmanager.ConnectivityRequest{
+		Source: instance1, Destination: aostypes.InstanceIdent{ServiceID: "unknown"},
+	}); err == nil {
+		t.Error("Error expected for unknown instance")
+	}
+}
+
+func TestConcurrentDNSRecords(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 1),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update provider network: %v", err)
+	}
+
+	// DNS records are read by diagnostics while hosts are added, removed and cleaned by network updates
+
+	started, done := make(chan struct{}), make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		close(started)
+
+		for {
+			select {
+			case <-done:
+				return
+
+			default:
+				manager.GetDNSRecords()
+			}
+		}
+	}()
+
+	<-started
+
+	for i := range 50 {
+		instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: uint64(i)}
+
+		if _, err := manager.PrepareInstanceNetworkParameters(storage, instanceIdent, "network1",
+			networkmanager.NetworkParameters{Hosts: []string{fmt.Sprintf("host%d", i)}}); err != nil {
+			t.Errorf("Can't prepare instance network configuration: %v", err)
+		}
+
+		if i%2 == 0 {
+			manager.RemoveInstanceNetworkParameters(storage, instanceIdent)
+		}
+
+		if err := manager.RestartDNSServer(); err != nil {
+			t.Errorf("Can't restart DNS server: %v", err)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+func TestFirewallSimulation(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{WorkingDir: tmpDir})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	instance1 := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}
+	instance2 := aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"}
+	instance3 := aostypes.InstanceIdent{ServiceID: "service3", SubjectID: "subject1"}
+
+	instanceIPs := make(map[aostypes.InstanceIdent]string)
+
+	for _, data := range []struct {
+		instance aostypes.InstanceIdent
+		network  string
+		params   networkmanager.NetworkParameters
+	}{
+		{instance: instance1, network: "network1", params: networkmanager.NetworkParameters{
+			ExposePorts: []string{"8080/tcp", "9090/tcp"},
+		}},
+		{instance: instance2, network: "network2", params: networkmanager.NetworkParameters{
+			AllowConnections: []string{"service1/8080/tcp"},
+		}},
+		{instance: instance3, network: "network1"},
+	} {
+		networkParameters, err := manager.PrepareInstanceNetworkParameters(storage, data.instance, data.network, data.params)
+		if err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+
+		instanceIPs[data.instance] = networkParameters.IP
+	}
+
+	allowedRule := aostypes.FirewallRule{
+		DstIP: instanceIPs[instance1], SrcIP: instanceIPs[instance2], Proto: "tcp", DstPort: "8080",
+	}
+
+	testData := []struct {
+		request        networkmanager.FirewallSimulationRequest
+		expectedResult networkmanager.FirewallSimulationResult
+	}{
+		{
+			request: networkmanager.FirewallSimulationRequest{Source: instance2, Destination: instance1, Port: "8080"},
+			expectedResult: networkmanager.FirewallSimulationResult{
+				Verdict: networkmanager.FirewallVerdictAllow, Reason: networkmanager.ConnectivityFirewallRule,
+				MatchedRule: &allowedRule,
+			},
+		},
+		{
+			request: networkmanager.FirewallSimulationRequest{Source: instance2, Destination: instance1, Port: "9090"},
+			expectedResult: networkmanager.FirewallSimulationResult{
+				Verdict: networkmanager.FirewallVerdictDeny, Reason: networkmanager.ConnectivityNoRule,
+				CandidateRules: []aostypes.FirewallRule{allowedRule},
+			},
+		},
+		{
+			request: networkmanager.FirewallSimulationRequest{
+				Source: instance2, Destination: instance1, Port: "8080", Protocol: "udp",
+			},
+			expectedResult: networkmanager.FirewallSimulationResult{
+				Verdict: networkmanager.FirewallVerdictDeny, Reason: networkmanager.ConnectivityPortNotExposed,
+			},
+		},
+		{
+			request: networkmanager.FirewallSimulationRequest{Source: instance3, Destination: instance1, Port: "8080"},
+			expectedResult: networkmanager.FirewallSimulationResult{
+				Verdict: networkmanager.FirewallVerdictAllow, Reason: networkmanager.ConnectivitySameNetwork,
+			},
+		},
+	}
+
+	for _, data := range testData {
+		data.expectedResult.SourceIP = instanceIPs[data.request.Source]
+		data.expectedResult.DestinationIP = instanceIPs[data.request.Destination]
+
+		result, err := manager.SimulateFirewall(data.request)
+		if err != nil {
+			t.Fatalf("Can't simulate firewall: %v", err)
+		}
+
+		if !reflect.DeepEqual(result, data.expectedResult) {
+			t.Errorf("Wrong firewall simulation result: %v", result)
+		}
+	}
+
+	if _, err := manager.SimulateFirewall(networkmanager.FirewallSimulationRequest{
+		Source: instance2, Destination: instance1,
+	}); err == nil {
+		t.Error("Error expected for request without port")
+	}
+
+	if _, err := manager.SimulateFirewall(networkmanager.FirewallSimulationRequest{
+		Source: instance1, Destination: aostypes.InstanceIdent{ServiceID: "unknown"}, Port: "8080",
+	}); err == nil {
+		t.Error("Error expected for unknown instance")
+	}
+}
+
+func TestNetworkStorage(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	testData := []struct {
+		networkParameters aostypes.NetworkParameters
+		instance          aostypes.InstanceIdent
+		hosts             []string
+	}{
+		{
+			networkParameters: aostypes.NetworkParameters{
+				IP:     ("172.17.0.1"),
+				Subnet: ("172.17.0.0/16"),
+			},
+			instance: aostypes.InstanceIdent{
+				ServiceID: "service1",
+				SubjectID: "subject1",
+				Instance:  1,
+			},
+			hosts: []string{"hosts1"},
+		},
+		{
+			networkParameters: aostypes.NetworkParameters{
+				IP:     ("172.17.0.2"),
+				Subnet: ("172.17.0.0/16"),
+			},
+			instance: aostypes.InstanceIdent{
+				ServiceID: "service1",
+				SubjectID: "subject1",
+				Instance:  2,
+			},
+			hosts: []string{"hosts2"},
+		},
+	}
+
+	for _, data := range testData {
+		if _, err := manager.PrepareInstanceNetworkParameters(
+			storage, data.instance, "network1", networkmanager.NetworkParameters{
+				Hosts: data.hosts,
+			}); err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+	}
+
+	manager1, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	expectedInstancesIdent := []aostypes.InstanceIdent{
+		{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  1,
+		},
+		{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  2,
+		},
+	}
+
+	instances := manager1.GetInstances()
+	if !compareInstancesIdent(instances, expectedInstancesIdent) {
+		t.Error("Unexpected instances ident")
+	}
+
+	for _, instance := range expectedInstancesIdent {
+		if networkID, found := manager1.GetInstanceNetworkID(instance); !found || networkID != "network1" {
+			t.Errorf("Wrong instance network ID: %s", networkID)
+		}
+	}
+
+	if _, found := manager1.GetInstanceNetworkID(aostypes.InstanceIdent{ServiceID: "unknown"}); found {
+		t.Error("Network ID should not be found for unknown instance")
+	}
+}
+
+func TestNetworkUpdates(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	vlan := &testVlan{}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = vlan.getVlanID
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 2),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	testData := []struct {
+		providers                 []string
+		nodeID                    string
+		expectedNetworkParameters []aostypes.NetworkParameters
+	}{
+		{
+			providers: []string{"network1", "network2"},
+			nodeID:    "node1",
+			expectedNetworkParameters: []aostypes.NetworkParameters{
+				{
+					NetworkID: "network1",
+					IP:        "172.17.0.1",
+					Subnet:    "172.17.0.0/16",
+					VlanID:    1,
+				},
+				{
+					NetworkID: "network2",
+					IP:        "172.18.0.1",
+					Subnet:    "172.18.0.0/16",
+					VlanID:    2,
+				},
+			},
+		},
+		{
+			providers: []string{"network1"},
+			nodeID:    "node1",
+			expectedNetworkParameters: []aostypes.NetworkParameters{
+				{
+					NetworkID: "network1",
+					IP:        "172.17.0.1",
+					Subnet:    "172.17.0.0/16",
+					VlanID:    1,
+				},
+			},
+		},
+	}
+
+	for _, data := range testData {
+		if err := manager.UpdateProviderNetwork(storage, data.providers, data.nodeID); err != nil {
+			t.Fatalf("Can't update node network parameters: %v", err)
+		}
+
+		select {
+		case <-nodeManager.chanReady:
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout waiting for node manager")
+		}
+
+		networkParameters := nodeManager.network[data.nodeID]
+
+		if !reflect.DeepEqual(networkParameters, data.expectedNetworkParameters) {
+			t.Error("Unexpected network parameters")
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+	networkmanager.GetVlanID = (&testVlan{}).getVlanID
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	nodeManager := &testNodeManager{
+		network:   make(map[string][]aostypes.NetworkParameters),
+		chanReady: make(chan struct{}, 2),
+	}
+
+	manager, err := networkmanager.New(storage, nodeManager, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if err := manager.UpdateProviderNetwork(storage, []string{"network1"}, "node1"); err != nil {
+		t.Fatalf("Can't update node network parameters: %v", err)
+	}
+
+	<-nodeManager.chanReady
+
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+
+	if _, err := manager.PrepareInstanceNetworkParameters(
+		storage, instanceIdent, "network1", networkmanager.NetworkParameters{Hosts: []string{"hosts1"}}); err != nil {
+		t.Fatalf("Can't prepare instance network configuration: %v", err)
+	}
+
+	if err = manager.RestartDNSServer(); err != nil {
+		t.Fatalf("Can't restart dns server: %v", err)
+	}
+
+	if err = manager.Purge(); err != nil {
+		t.Fatalf("Can't purge networks: %v", err)
+	}
+
+	select {
+	case <-nodeManager.chanReady:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for node manager")
+	}
+
+	if len(nodeManager.network["node1"]) != 0 {
+		t.Errorf("Unexpected node network parameters: %v", nodeManager.network["node1"])
+	}
+
+	if instances := manager.GetInstances(); len(instances) != 0 {
+		t.Errorf("Unexpected instances: %v", instances)
+	}
+
+	if len(storage.networkInfos) != 0 {
+		t.Errorf("Unexpected stored network infos: %v", storage.networkInfos)
+	}
+
+	rawHosts, err := os.ReadFile(filepath.Join(tmpDir, "network", "addnhosts"))
+	if err != nil {
+		t.Fatalf("Can't read hosts file: %v", err)
+	}
+
+	if len(rawHosts) != 0 {
+		t.Errorf("Unexpected hosts file content: %s", rawHosts)
+	}
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{
+		WorkingDir: tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	rawConfig, err := os.ReadFile(filepath.Join(tmpDir, "network", "dnsmasq.conf"))
+	if err != nil {
+		t.Fatalf("Can't read DNS config file: %v", err)
+	}
+
+	if !strings.Contains(string(rawConfig), "conf-file="+filepath.Join(tmpDir, "network", "srvrecords")) {
+		t.Errorf("DNS config file doesn't include records file: %s", rawConfig)
+	}
+
+	if _, err = manager.PrepareInstanceNetworkParameters(
+		storage, aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}, "network1",
+		networkmanager.NetworkParameters{
+			ExposePorts:     []string{"8080/tcp", "5353/udp"},
+			HealthEndpoints: map[string]string{"8080/tcp": "/healthz"},
+		}); err != nil {
+		t.Fatalf("Can't prepare instance network configuration: %v", err)
+	}
+
+	if _, err = manager.PrepareInstanceNetworkParameters(
+		storage, aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1", Instance: 0}, "network1",
+		networkmanager.NetworkParameters{
+			ExposePorts:     []string{"9000"},
+			HealthEndpoints: map[string]string{"9000": "/health\",\"injected"},
+		}); err == nil {
+		t.Error("Error expected for invalid health endpoint")
+	}
+
+	if err = manager.RestartDNSServer(); err != nil {
+		t.Fatalf("Can't restart dns server: %v", err)
+	}
+
+	expected := []string{
+		"srv-host=_aos._tcp.0.subject1.service1,0.subject1.service1,8080",
+		"srv-host=_aos._tcp.service1,0.subject1.service1,8080",
+		"srv-host=_aos._udp.0.subject1.service1,0.subject1.service1,5353",
+		"srv-host=_aos._udp.service1,0.subject1.service1,5353",
+		"txt-record=_aos._tcp.0.subject1.service1,\"host=0.subject1.service1\",\"port=8080\",\"health=/healthz\"",
+		"txt-record=_aos._tcp.service1,\"host=0.subject1.service1\",\"port=8080\",\"health=/healthz\"",
+	}
+
+	rawRecords, err := os.ReadFile(filepath.Join(tmpDir, "network", "srvrecords"))
+	if err != nil {
+		t.Fatalf("Can't read records file: %v", err)
+	}
+
+	if records := strings.Split(strings.TrimSpace(string(rawRecords)), "\n"); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Wrong records: %v", records)
+	}
+
+	manager.RemoveInstanceNetworkParameters(
+		storage, aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0})
+
+	if err = manager.RestartDNSServer(); err != nil {
+		t.Fatalf("Can't restart dns server: %v", err)
+	}
+
+	if rawRecords, err = os.ReadFile(filepath.Join(tmpDir, "network", "srvrecords")); err != nil {
+		t.Fatalf("Can't read records file: %v", err)
+	}
+
+	if len(rawRecords) != 0 {
+		t.Errorf("Unexpected records file content: %s", rawRecords)
+	}
+}
+
+func TestSharedNetwork(t *testing.T) {
+	ipam, err := newIpam()
+	if err != nil {
+		t.Fatalf("Can't init ipam management: %v", err)
+	}
+
+	networkmanager.GetIPSubnet = ipam.getIPSubnet
+	networkmanager.LookPath = lookPath
+	networkmanager.DiscoverInterface = discoverInterface
+	networkmanager.ExecContext = newTestShellCommander
+
+	storage := &testStore{
+		networkInfos: make(map[aostypes.InstanceIdent]networkmanager.InstanceNetworkInfo),
+	}
+
+	manager, err := networkmanager.New(storage, nil, nil, &config.Config{WorkingDir: tmpDir})
+	if err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	sharedParams := networkmanager.NetworkParameters{ExposePorts: []string{"8080/tcp"}, SharedNetwork: true}
+	instances := []aostypes.InstanceIdent{
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 1},
+	}
+
+	var ips []string
+
+	for _, instance := range instances {
+		networkParameters, err := manager.PrepareInstanceNetworkParameters(storage, instance, "network1", sharedParams)
+		if err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+
+		ips = append(ips, networkParameters.IP)
+	}
+
+	if ips[0] != ips[1] {
+		t.Errorf("Instances should share IP: %v", ips)
+	}
+
+	if allocations, _ := storage.GetIPAllocations(); len(allocations) != 2 {
+		t.Errorf("Wrong IP allocations: %v", allocations)
+	}
+
+	if err = manager.RestartDNSServer(); err != nil {
+		t.Fatalf("Can't restart dns server: %v", err)
+	}
+
+	rawHosts, err := os.ReadFile(filepath.Join(tmpDir, "network", "addnhosts"))
+	if err != nil {
+		t.Fatalf("Can't read hosts file: %v", err)
+	}
+
+	expectedHosts := ips[0] + "\t0.subject1.service1\t0.subject1.service1.network1\tsubject1.service1" +
+		"\tsubject1.service1.network1"
+
+	if hosts := strings.TrimSpace(string(rawHosts)); hosts != expectedHosts {
+		t.Errorf("Unexpected hosts file content: %v", hosts)
+	}
+
+	// Shared IP is accepted by IPAM audit
+
+	alertSender := &testAlertSender{}
+
+	if _, err = networkmanager.New(storage, nil, alertSender, &config.Config{WorkingDir: tmpDir}); err != nil {
+		t.Fatalf("Can't create network manager: %v", err)
+	}
+
+	if len(alertSender.alerts) != 0 {
+		t.Errorf("Unexpected alerts: %v", alertSender.alerts)
+	}
+
+	// Shared IP is released with the last instance
+
+	manager.RemoveInstanceNetworkParameters(storage, instances[0])
+
+	if allocations, _ := storage.GetIPAllocations(); len(allocations) != 2 {
+		t.Errorf("Wrong IP allocations: %v", allocations)
+	}
+
+	if networkID, found := manager.GetInstanceNetworkID(instances[1]); !found || networkID != "network1" {
+		t.Error("Instance network should be kept")
+	}
+
+	manager.RemoveInstanceNetworkParameters(storage, instances[1])
+
+	if allocations, _ := storage.GetIPAllocations(); len(allocations) != 1 {
+		t.Errorf("Wrong IP allocations: %v", allocations)
+	}
+
+	// Instances get own IPs when network is not shared anymore
+
+	ips = nil
+
+	for _, instance := range instances {
+		if _, err = manager.PrepareInstanceNetworkParameters(storage, instance, "network1", sharedParams); err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+	}
+
+	for _, instance := range instances {
+		networkParameters, err := manager.PrepareInstanceNetworkParameters(
+			storage, instance, "network1", networkmanager.NetworkParameters{ExposePorts: []string{"8080/tcp"}})
+		if err != nil {
+			t.Fatalf("Can't prepare instance network configuration: %v", err)
+		}
+
+		ips = append(ips, networkParameters.IP)
+	}
+
+	if ips[0] == ips[1] {
+		t.Errorf("Instances should not share IP: %v", ips)
+	}
+
+	if allocations, _ := storage.GetIPAllocations(); len(allocations) != 3 {
+		t.Errorf("Wrong IP allocations: %v", allocations)
+	}
+}
+
+func TestCNIConfig(t *testing.T) {
+	instanceIdent := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 1}
+
+	rawConfig, err := networkmanager.GetCNIConfig(instanceIdent, aostypes.NetworkParameters{
+		NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "172.17.0.3", VlanID: 10,
+		DNSServers: []string{"10.0.0.1"},
+		FirewallRules: []aostypes.FirewallRule{
+			{DstIP: "172.18.0.2", DstPort: "8080", Proto: "tcp", SrcIP: "172.17.0.3"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Can't get CNI config: %v", err)
+	}
+
+	var cniConfig networkmanager.CNIConfig
+
+	if err = json.Unmarshal(rawConfig, &cniConfig); err != nil {
+		t.Fatalf("Can't parse CNI config: %v", err)
+	}
+
+	if cniConfig.CNIVersion != networkmanager.CNIVersion || cniConfig.Name != "network1" ||
+		len(cniConfig.Plugins) != 2 {
+		t.Fatalf("Wrong CNI config: %s", rawConfig)
+	}
+
+	var (
+		bridge   networkmanager.CNIBridgePlugin
+		firewall networkmanager.CNIFirewallPlugin
+	)
+
+	if err = json.Unmarshal(cniConfig.Plugins[0], &bridge); err != nil {
+		t.Fatalf("Can't parse bridge plugin: %v", err)
+	}
+
+	expectedBridge := networkmanager.CNIBridgePlugin{
+		Type: "bridge", Bridge: "br-network1", IsGateway: true, IPMasq: true, Vlan: 10,
+		IPAM: networkmanager.CNIIPAM{
+			Type: "static", Addresses: []networkmanager.CNIAddress{{Address: "172.17.0.3/16"}},
+		},
+		DNS: networkmanager.CNIDNS{Nameservers: []string{"10.0.0.1"}},
+	}
+
+	if !reflect.DeepEqual(bridge, expectedBridge) {
+		t.Errorf("Wrong bridge plugin: %v", bridge)
+	}
+
+	if err = json.Unmarshal(cniConfig.Plugins[1], &firewall); err != nil {
+		t.Fatalf("Can't parse firewall plugin: %v", err)
+	}
+
+	expectedFirewall := networkmanager.CNIFirewallPlugin{
+		Type: "aos-firewall", UUID: "service1.subject1.1",
+		OutputAccess: []networkmanager.CNIOutputAccess{
+			{DstIP: "172.18.0.2", DstPort: "8080", Proto: "tcp", SrcIP: "172.17.0.3"},
+		},
+	}
+
+	if !reflect.DeepEqual(firewall, expectedFirewall) {
+		t.Errorf("Wrong firewall plugin: %v", firewall)
+	}
+
+	if _, err = networkmanager.GetCNIConfig(instanceIdent, aostypes.NetworkParameters{
+		NetworkID: "network1", Subnet: "172.17.0.0/16", IP: "invalid",
+	}); err == nil {
+		t.Error("Error expected for invalid IP")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func newIpam() (*ipamTest, error) {
+	ipamInfo := &ipamTest{
+		ipamData: make(map[string]*ipam),
+	}
+
+	ip, ipnet, err := net.ParseCIDR("172.17.0.0/16")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	ipamInfo.ipamData["network1"] = &ipam{
+		subnet: *ipnet,
+		ip:     ip,
+	}
+
+	if ip, ipnet, err = net.ParseCIDR("172.18.0.0/16"); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	ipamInfo.ipamData["network2"] = &ipam{
+		subnet: *ipnet,
+		ip:     ip,
+	}
+
+	return ipamInfo, nil
+}
+
+func (ipam *ipamTest) getIPSubnet(networkID, serviceID string) (*net.IPNet, net.IP, error) {
+	ipamInfo, ok := ipam.ipamData[networkID]
+	if !ok {
+		return nil, nil, aoserrors.Errorf("Can't find network %v", networkID)
+	}
+
+	ipamInfo.ip = cidr.Inc(ipamInfo.ip)
+
+	return &ipamInfo.subnet, ipamInfo.ip, nil
+}
+
+func (storage *testStore) AddNetworkInstanceInfo(networkInfo networkmanager.InstanceNetworkInfo) error {
+	storage.networkInfos[networkInfo.InstanceIdent] = networkInfo
+
+	return nil
+}
+
+func (storage *testStore) RemoveNetworkInstanceInfo(instanceIdent aostypes.InstanceIdent) error {
+	delete(storage.networkInfos, instanceIdent)
+
+	return nil
+}
+
+func (storage *testStore) GetNetworkInstancesInfo() (networkInfos []networkmanager.InstanceNetworkInfo, err error) {
+	for _, networkInfo := range storage.networkInfos {
+		networkInfos = append(networkInfos, networkInfo)
+	}
+
+	return networkInfos, err
+}
+
+func (storage *testStore) RemoveNetworkInfo(networkID string, nodeID string) error {
+	storage.networks = slices.DeleteFunc(storage.networks, func(item networkmanager.NetworkParametersStorage) bool {
+		return item.NetworkID == networkID && item.NodeID == nodeID
+	})
+
+	return nil
+}
+
+func (storage *testStore) AddNetworkInfo(networkInfo networkmanager.NetworkParametersStorage) error {
+	return nil
+}
+
+func (storage *testStore) GetNetworksInfo() (networkInfos []networkmanager.NetworkParametersStorage, err error) {
+	return slices.Clone(storage.networks), nil
+}
+
+func (storage *testStore) AddIPAllocation(allocation networkmanager.IPAllocation) error {
+	_ = storage.RemoveIPAllocation(allocation.NetworkID, allocation.IP)
+
+	storage.ipAllocations = append(storage.ipAllocations, allocation)
+
+	return nil
+}
+
+func (storage *testStore) RemoveIPAllocation(networkID, ip string) error {
+	storage.ipAllocations = slices.DeleteFunc(storage.ipAllocations, func(allocation networkmanager.IPAllocation) bool {
+		return allocation.NetworkID == networkID && allocation.IP == ip
+	})
+
+	return nil
+}
+
+func (storage *testStore) RemoveNetworkIPAllocations(networkID string) error {
+	storage.ipAllocations = slices.DeleteFunc(storage.ipAllocations, func(allocation networkmanager.IPAllocation) bool {
+		return allocation.NetworkID == networkID
+	})
+
+	return nil
+}
+
+func (storage *testStore) GetIPAllocations() ([]networkmanager.IPAllocation, error) {
+	return slices.Clone(storage.ipAllocations), nil
+}
+
+func (storage *testStore) ExecuteInTransaction(operations func(txStorage any) error) error {
+	return operations(storage)
+}
+
+func (sender *testAlertSender) SendAlert(alert interface{}) {
+	sender.alerts = append(sender.alerts, alert)
+}
+
+func (node *testNodeManager) UpdateNetwork(nodeID string, networkParameters []aostypes.NetworkParameters) error {
+	node.Lock()
+	node.network[nodeID] = networkParameters
+	node.Unlock()
+
+	node.chanReady <- struct{}{}
+
+	return nil
+}
+
+func (node *testNodeManager) UpdateNetworkMirrors(
+	nodeID string, networkParameters []aostypes.NetworkParameters, mirrors []networkmanager.TrafficMirror,
+) error {
+	node.Lock()
+	node.network[nodeID] = networkParameters
+	node.mirrors[nodeID] = mirrors
+	node.Unlock()
+
+	node.chanReady <- struct{}{}
+
+	return nil
+}
+
+func (node *testNodeManager) getMirrors(nodeID string) []networkmanager.TrafficMirror {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.mirrors[nodeID]
+}
+
+func (sender *testEventsSender) SendProviderNetworkEvents(events amqphandler.ProviderNetworkEvents) error {
+	sender.events = append(sender.events, events.Events...)
+
+	return nil
+}
+
+func (sender *testEventsSender) getEvents() (events []amqphandler.ProviderNetworkEvent) {
+	for _, event := range sender.events {
+		event.Timestamp = time.Time{}
+		events = append(events, event)
+	}
+
+	sender.events = nil
+
+	return events
+}
+
+func (node *testNodeManager) getNetwork(nodeID string) []aostypes.NetworkParameters {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.network[nodeID]
 }
 
 /***********************************************************************************************************************
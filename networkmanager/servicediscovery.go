@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	recordsFileName = "srvrecords"
+
+	// ServiceDiscoveryName service name of SRV and TXT records: _aos._<protocol>.<service ID or instance host>.
+	ServiceDiscoveryName = "_aos"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ServiceEndpoint instance endpoint published in service discovery registry.
+type ServiceEndpoint struct {
+	aostypes.InstanceIdent
+	Host           string `json:"host"`
+	IP             string `json:"ip"`
+	Port           string `json:"port"`
+	Protocol       string `json:"protocol"`
+	HealthEndpoint string `json:"healthEndpoint,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func getServiceEndpoints(
+	instanceIdent aostypes.InstanceIdent, ip string, params NetworkParameters,
+) ([]ServiceEndpoint, error) {
+	if instanceIdent.ServiceID == "" || instanceIdent.SubjectID == "" || len(params.ExposePorts) == 0 {
+		return nil, nil
+	}
+
+	rules, err := parseExposedPorts(params.ExposePorts)
+	if err != nil {
+		return nil, err
+	}
+
+	host := fmt.Sprintf("%d.%s.%s", instanceIdent.Instance, instanceIdent.SubjectID, instanceIdent.ServiceID)
+	endpoints := make([]ServiceEndpoint, 0, len(rules))
+
+	for i, rule := range rules {
+		healthEndpoint, ok := params.HealthEndpoints[params.ExposePorts[i]]
+		if !ok {
+			healthEndpoint = params.HealthEndpoints[rule.Port]
+		}
+
+		if strings.ContainsAny(healthEndpoint, "\",\n") {
+			return nil, aoserrors.Errorf("invalid health endpoint %s", healthEndpoint)
+		}
+
+		endpoints = append(endpoints, ServiceEndpoint{
+			InstanceIdent:  instanceIdent,
+			Host:           host,
+			IP:             ip,
+			Port:           rule.Port,
+			Protocol:       rule.Protocol,
+			HealthEndpoint: healthEndpoint,
+		})
+	}
+
+	return endpoints, nil
+}
+
+func (dns *dnsServer) addServiceEndpoints(ip string, endpoints []ServiceEndpoint) {
+	dns.Lock()
+	defer dns.Unlock()
+
+	if len(endpoints) == 0 {
+		delete(dns.endpoints, ip)
+
+		return
+	}
+
+	dns.endpoints[ip] = endpoints
+}
+
+// rewriteRecordsFile writes SRV and TXT records of service endpoints. As dnsmasq reads these records only on start,
+// full DNS server restart is requested if records are changed.
+func (dns *dnsServer) rewriteRecordsFile() error {
+	dns.Lock()
+	defer dns.Unlock()
+
+	var records []string
+
+	for _, endpoints := range dns.endpoints {
+		for _, endpoint := range endpoints {
+			for _, name := range []string{endpoint.ServiceID, endpoint.Host} {
+				recordName := ServiceDiscoveryName + "._" + endpoint.Protocol + "." + name
+
+				records = append(records,
+					fmt.Sprintf("srv-host=%s,%s,%s", recordName, endpoint.Host, endpoint.Port))
+
+				if endpoint.HealthEndpoint != "" {
+					records = append(records, fmt.Sprintf("txt-record=%s,\"host=%s\",\"port=%s\",\"health=%s\"",
+						recordName, endpoint.Host, endpoint.Port, endpoint.HealthEndpoint))
+				}
+			}
+		}
+	}
+
+	sort.Strings(records)
+
+	var content []byte
+
+	if len(records) > 0 {
+		content = []byte(strings.Join(records, "\n") + "\n")
+	}
+
+	currentContent, err := os.ReadFile(dns.RecordsFile)
+	if err == nil && bytes.Equal(currentContent, content) {
+		return nil
+	}
+
+	if err = os.WriteFile(dns.RecordsFile, content, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	dns.restartRequired = true
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"net"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// allocateNetwork allocates network for instance. Instance of shared network joins network of another instance of the
+// same service and subject if it exists.
+func (manager *NetworkManager) allocateNetwork(
+	storage Storage, instanceIdent aostypes.InstanceIdent, networkID string, params NetworkParameters,
+) (networkParameters aostypes.NetworkParameters, err error) {
+	if params.SharedNetwork {
+		if peer, found := manager.findSharedPeer(networkID, instanceIdent); found {
+			return manager.joinSharedNetwork(storage, instanceIdent, peer, params)
+		}
+	}
+
+	return manager.createNetwork(storage, instanceIdent, networkID, params)
+}
+
+func (manager *NetworkManager) joinSharedNetwork(
+	storage Storage, instanceIdent aostypes.InstanceIdent, peer InstanceNetworkInfo, params NetworkParameters,
+) (networkParameters aostypes.NetworkParameters, err error) {
+	log.WithFields(log.Fields{
+		"serviceID": instanceIdent.ServiceID, "subjectID": instanceIdent.SubjectID,
+		"instance": instanceIdent.Instance, "ip": peer.IP,
+	}).Debug("Join shared network")
+
+	networkParameters.NetworkID = peer.NetworkID
+	networkParameters.IP = peer.IP
+	networkParameters.Subnet = peer.Subnet
+	networkParameters.DNSServers = []string{manager.dns.IPAddress}
+
+	instanceNetworkInfo := InstanceNetworkInfo{
+		InstanceIdent:     instanceIdent,
+		NetworkParameters: networkParameters,
+	}
+
+	if len(params.ExposePorts) > 0 {
+		if instanceNetworkInfo.Rules, err = parseExposedPorts(params.ExposePorts); err != nil {
+			return networkParameters, err
+		}
+	}
+
+	if err := storage.AddNetworkInstanceInfo(instanceNetworkInfo); err != nil {
+		return networkParameters, aoserrors.Wrap(err)
+	}
+
+	manager.addNetworkParametersToCache(instanceNetworkInfo)
+
+	return networkParameters, nil
+}
+
+// leaveNetwork removes instance network which is reallocated due to shared network change. DNS records of the IP are
+// removed as well and republished by remaining instances.
+func (manager *NetworkManager) leaveNetwork(
+	storage Storage, networkID string, instanceIdent aostypes.InstanceIdent, ip string,
+) {
+	manager.dns.removeHosts(ip)
+
+	if err := manager.removeInstanceNetworkParameters(storage, networkID, instanceIdent, net.ParseIP(ip)); err != nil {
+		log.Errorf("Can't remove network info: %v", err)
+	}
+}
+
+// sharedNetworkChanged checks whether instance network should be reallocated: shared instance uses IP different from
+// its peer or not shared instance uses IP of another instance.
+func (manager *NetworkManager) sharedNetworkChanged(
+	networkID string, instanceIdent aostypes.InstanceIdent, ip string, shared bool,
+) bool {
+	if shared {
+		peer, found := manager.findSharedPeer(networkID, instanceIdent)
+
+		return found && peer.IP != ip
+	}
+
+	return manager.isIPUsed(networkID, instanceIdent, net.ParseIP(ip))
+}
+
+// findSharedPeer returns another instance of the same service and subject in the network. Instance with the lowest
+// index is returned, so all instances select the same peer.
+func (manager *NetworkManager) findSharedPeer(
+	networkID string, instanceIdent aostypes.InstanceIdent,
+) (peer InstanceNetworkInfo, found bool) {
+	for ident, info := range manager.instancesData[networkID] {
+		if ident == instanceIdent || ident.ServiceID != instanceIdent.ServiceID ||
+			ident.SubjectID != instanceIdent.SubjectID {
+			continue
+		}
+
+		if !found || ident.Instance < peer.Instance {
+			peer, found = info, true
+		}
+	}
+
+	return peer, found
+}
+
+// isIPUsed checks whether IP is used by instance of the network other than the specified one.
+func (manager *NetworkManager) isIPUsed(networkID string, instanceIdent aostypes.InstanceIdent, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for ident, info := range manager.instancesData[networkID] {
+		if ident != instanceIdent && info.IP == ip.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sharedNetworkIdent returns instance ident used for DNS records: all instances of shared network are published as
+// instance 0, so they have one record set.
+func sharedNetworkIdent(instanceIdent aostypes.InstanceIdent, shared bool) aostypes.InstanceIdent {
+	if shared {
+		instanceIdent.Instance = 0
+	}
+
+	return instanceIdent
+}
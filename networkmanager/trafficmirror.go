@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmanager
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// TrafficMirrorSender sends node networks together with traffic mirrors of instances of these networks to the node.
+type TrafficMirrorSender interface {
+	UpdateNetworkMirrors(nodeID string, networkParameters []aostypes.NetworkParameters, mirrors []TrafficMirror) error
+}
+
+// TrafficMirrorRequest request to mirror instance traffic to diagnostic collector instance. Empty port and protocol
+// mirror all instance traffic.
+type TrafficMirrorRequest struct {
+	Source    aostypes.InstanceIdent `json:"source"`
+	Collector aostypes.InstanceIdent `json:"collector"`
+	Port      string                 `json:"port,omitempty"`
+	Protocol  string                 `json:"protocol,omitempty"`
+	Duration  aostypes.Duration      `json:"duration"`
+}
+
+// TrafficMirror network parameters of traffic mirror: node copies traffic of source IP matching port and protocol
+// to collector IP till expiration time.
+type TrafficMirror struct {
+	ID          string                 `json:"id"`
+	Source      aostypes.InstanceIdent `json:"source"`
+	Collector   aostypes.InstanceIdent `json:"collector"`
+	NetworkID   string                 `json:"networkId"`
+	SourceIP    string                 `json:"sourceIp"`
+	CollectorIP string                 `json:"collectorIp"`
+	Port        string                 `json:"port,omitempty"`
+	Protocol    string                 `json:"protocol,omitempty"`
+	ExpiresAt   time.Time              `json:"expiresAt"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetTrafficMirrorSender sets traffic mirror sender. Traffic mirroring is not supported if sender is not set.
+func (manager *NetworkManager) SetTrafficMirrorSender(sender TrafficMirrorSender) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	manager.mirrorSender = sender
+}
+
+// StartTrafficMirror starts mirroring of source instance traffic to collector instance for the requested duration.
+func (manager *NetworkManager) StartTrafficMirror(request TrafficMirrorRequest) (TrafficMirror, error) {
+	if request.Duration.Duration <= 0 || request.Duration.Duration > manager.maxMirrorDuration {
+		return TrafficMirror{}, aoserrors.Errorf("mirror duration should be positive and not exceed %v",
+			manager.maxMirrorDuration)
+	}
+
+	if request.Protocol != "" && request.Protocol != "tcp" && request.Protocol != "udp" {
+		return TrafficMirror{}, aoserrors.Errorf("unsupported protocol: %s", request.Protocol)
+	}
+
+	if request.Port != "" && !isValidPort(request.Port) {
+		return TrafficMirror{}, aoserrors.Errorf("wrong port: %s", request.Port)
+	}
+
+	manager.Lock()
+	defer manager.Unlock()
+
+	if manager.mirrorSender == nil {
+		return TrafficMirror{}, aoserrors.New("traffic mirroring is not supported")
+	}
+
+	source, found := manager.getInstanceNetworkInfo(request.Source)
+	if !found {
+		return TrafficMirror{}, aoserrors.New("source instance network not found")
+	}
+
+	collector, found := manager.getInstanceNetworkInfo(request.Collector)
+	if !found {
+		return TrafficMirror{}, aoserrors.New("collector instance network not found")
+	}
+
+	if source.IP == collector.IP {
+		return TrafficMirror{}, aoserrors.New("collector instance shares source instance IP")
+	}
+
+	mirror := TrafficMirror{
+		ID:          uuid.NewString(),
+		Source:      request.Source,
+		Collector:   request.Collector,
+		NetworkID:   source.NetworkID,
+		SourceIP:    source.IP,
+		CollectorIP: collector.IP,
+		Port:        request.Port,
+		Protocol:    request.Protocol,
+		ExpiresAt:   time.Now().Add(request.Duration.Duration),
+	}
+
+	manager.trafficMirrors[mirror.ID] = mirror
+
+	if err := manager.pushTrafficMirrors(mirror.NetworkID); err != nil {
+		delete(manager.trafficMirrors, mirror.ID)
+
+		return TrafficMirror{}, err
+	}
+
+	manager.mirrorTimers[mirror.ID] = time.AfterFunc(request.Duration.Duration, func() {
+		manager.expireTrafficMirror(mirror.ID)
+	})
+
+	log.WithFields(log.Fields{
+		"id": mirror.ID, "source": mirror.Source, "collector": mirror.Collector, "port": mirror.Port,
+		"protocol": mirror.Protocol, "expiresAt": mirror.ExpiresAt,
+	}).Info("Start traffic mirror")
+
+	return mirror, nil
+}
+
+// StopTrafficMirror stops traffic mirror before its expiration.
+func (manager *NetworkManager) StopTrafficMirror(id string) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	mirror, ok := manager.trafficMirrors[id]
+	if !ok {
+		return aoserrors.New("traffic mirror not found")
+	}
+
+	log.WithField("id", id).Info("Stop traffic mirror")
+
+	return manager.removeTrafficMirror(mirror)
+}
+
+// GetTrafficMirrors returns active traffic mirrors sorted by expiration time.
+func (manager *NetworkManager) GetTrafficMirrors() []TrafficMirror {
+	manager.RLock()
+	defer manager.RUnlock()
+
+	return manager.getTrafficMirrors()
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *NetworkManager) expireTrafficMirror(id string) {
+	manager.Lock()
+	defer manager.Unlock()
+
+	mirror, ok := manager.trafficMirrors[id]
+	if !ok {
+		return
+	}
+
+	log.WithField("id", id).Info("Traffic mirror expired")
+
+	if err := manager.removeTrafficMirror(mirror); err != nil {
+		log.WithField("id", id).Errorf("Can't remove traffic mirror: %v", err)
+	}
+}
+
+func (manager *NetworkManager) removeTrafficMirror(mirror TrafficMirror) error {
+	delete(manager.trafficMirrors, mirror.ID)
+
+	if timer, ok := manager.mirrorTimers[mirror.ID]; ok {
+		timer.Stop()
+		delete(manager.mirrorTimers, mirror.ID)
+	}
+
+	return manager.pushTrafficMirrors(mirror.NetworkID)
+}
+
+func (manager *NetworkManager) clearTrafficMirrors() {
+	for id, timer := range manager.mirrorTimers {
+		timer.Stop()
+		delete(manager.mirrorTimers, id)
+	}
+
+	clear(manager.trafficMirrors)
+}
+
+func (manager *NetworkManager) getTrafficMirrors() []TrafficMirror {
+	mirrors := make([]TrafficMirror, 0, len(manager.trafficMirrors))
+
+	for _, mirror := range manager.trafficMirrors {
+		mirrors = append(mirrors, mirror)
+	}
+
+	slices.SortFunc(mirrors, func(a, b TrafficMirror) int {
+		return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return mirrors
+}
+
+// pushTrafficMirrors sends networks with traffic mirrors to each node of the network.
+func (manager *NetworkManager) pushTrafficMirrors(networkID string) error {
+	var nodeIDs []string
+
+	for _, networkInfo := range manager.providerNetworks[networkID] {
+		if networkInfo.NodeID != "" && !slices.Contains(nodeIDs, networkInfo.NodeID) {
+			nodeIDs = append(nodeIDs, networkInfo.NodeID)
+		}
+	}
+
+	if len(nodeIDs) == 0 {
+		return aoserrors.Errorf("network %s is not deployed on nodes", networkID)
+	}
+
+	now := time.Now()
+
+	for _, nodeID := range nodeIDs {
+		var networkParameters []aostypes.NetworkParameters
+
+		for _, networksInfo := range manager.providerNetworks {
+			for _, networkInfo := range networksInfo {
+				if networkInfo.NodeID == nodeID {
+					networkParameters = append(networkParameters, networkInfo.NetworkParameters)
+				}
+			}
+		}
+
+		slices.SortFunc(networkParameters, func(a, b aostypes.NetworkParameters) int {
+			return cmp.Compare(a.NetworkID, b.NetworkID)
+		})
+
+		if err := manager.updateNodeNetwork(
+			nodeID, manager.applyScheduledRules(networkParameters, now)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateNodeNetwork sends node networks with traffic mirrors of these networks if traffic mirroring is supported.
+func (manager *NetworkManager) updateNodeNetwork(
+	nodeID string, networkParameters []aostypes.NetworkParameters,
+) error {
+	if manager.mirrorSender == nil {
+		return aoserrors.Wrap(manager.nodeManager.UpdateNetwork(nodeID, networkParameters))
+	}
+
+	var mirrors []TrafficMirror
+
+	for _, mirror := range manager.getTrafficMirrors() {
+		if slices.ContainsFunc(networkParameters, func(params aostypes.NetworkParameters) bool {
+			return params.NetworkID == mirror.NetworkID
+		}) {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+
+	return aoserrors.Wrap(manager.mirrorSender.UpdateNetworkMirrors(nodeID, networkParameters, mirrors))
+}
+
+func isValidPort(port string) bool {
+	first, last, isRange := strings.Cut(port, "-")
+
+	firstPort, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return false
+	}
+
+	if !isRange {
+		return true
+	}
+
+	lastPort, err := strconv.ParseUint(last, 10, 16)
+
+	return err == nil && firstPort <= lastPort
+}
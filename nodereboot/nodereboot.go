@@ -0,0 +1,547 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodereboot orchestrates reboot of unit nodes requested by the cloud.
+//
+// Nodes are rebooted one by one within the requested timetable window. Before reboot, the node is drained, so its
+// instances are moved to other nodes. The reboot is confirmed by reconnection of the node SM. The node CM runs on is
+// rebooted last; its pending reboot state is persisted and the result is reported after CM restart.
+package nodereboot
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const daysInWeek = 7
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Storage pending node reboot storage.
+type Storage interface {
+	SetNodeReboot(state json.RawMessage) error
+	GetNodeReboot() (json.RawMessage, error)
+}
+
+// NodeInfoProvider provides unit nodes.
+type NodeInfoProvider interface {
+	GetNodeID() string
+	GetAllNodeIDs() (nodeIDs []string, err error)
+}
+
+// NodeDrainer moves instances from the node.
+type NodeDrainer interface {
+	DrainNode(nodeID string, drained bool) error
+}
+
+// ConnectionProvider provides SM connection state of nodes.
+type ConnectionProvider interface {
+	IsNodeConnected(nodeID string) bool
+}
+
+// StatusSender sends node reboot status to the cloud.
+type StatusSender interface {
+	SendNodeRebootStatus(status amqp.NodeRebootStatus) error
+}
+
+// Orchestrator node reboot orchestrator.
+type Orchestrator struct {
+	sync.Mutex
+
+	config             config.NodeReboot
+	storage            Storage
+	nodeInfoProvider   NodeInfoProvider
+	drainer            NodeDrainer
+	connectionProvider ConnectionProvider
+	statusSender       StatusSender
+	inProgress         bool
+
+	ctx        context.Context //nolint:containedctx
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// rebootState persisted state of the request which reboots the node CM runs on.
+type rebootState struct {
+	RequestID string                  `json:"requestId"`
+	Nodes     []amqp.NodeRebootResult `json:"nodes"`
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used in unit tests
+var (
+	pollPeriod = 1 * time.Second
+	timeNow    = time.Now
+	runCommand = func(name string, arg ...string) error {
+		output, err := exec.Command(name, arg...).CombinedOutput()
+		if err != nil {
+			return aoserrors.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+		}
+
+		return nil
+	}
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates node reboot orchestrator. Pending reboot of the node CM runs on is completed on creation.
+func New(
+	cfg config.NodeReboot, storage Storage, nodeInfoProvider NodeInfoProvider, drainer NodeDrainer,
+	connectionProvider ConnectionProvider, statusSender StatusSender,
+) (orchestrator *Orchestrator, err error) {
+	log.WithFields(log.Fields{
+		"drainTimeout": cfg.DrainTimeout, "rebootTimeout": cfg.RebootTimeout,
+	}).Debug("Create node reboot orchestrator")
+
+	orchestrator = &Orchestrator{
+		config: cfg, storage: storage, nodeInfoProvider: nodeInfoProvider, drainer: drainer,
+		connectionProvider: connectionProvider, statusSender: statusSender,
+	}
+
+	orchestrator.ctx, orchestrator.cancelFunc = context.WithCancel(context.Background())
+
+	data, err := storage.GetNodeReboot()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	var state rebootState
+
+	if len(data) != 0 {
+		if err = json.Unmarshal(data, &state); err != nil {
+			log.Errorf("Can't parse pending node reboot: %v", err)
+		}
+	}
+
+	if state.RequestID != "" {
+		orchestrator.inProgress = true
+
+		orchestrator.wg.Add(1)
+
+		go orchestrator.completeReboot(state)
+	}
+
+	return orchestrator, nil
+}
+
+// Close closes node reboot orchestrator.
+func (orchestrator *Orchestrator) Close() {
+	orchestrator.cancelFunc()
+	orchestrator.wg.Wait()
+}
+
+// RebootNodes starts reboot of the requested nodes. Failed status is sent if the request can't be started.
+func (orchestrator *Orchestrator) RebootNodes(request amqp.NodeRebootRequest) (err error) {
+	log.WithFields(log.Fields{
+		"requestID": request.RequestID, "nodeIDs": request.NodeIDs,
+	}).Info("Reboot nodes")
+
+	defer func() {
+		if err != nil {
+			orchestrator.sendStatus(amqp.NodeRebootStatus{
+				RequestID: request.RequestID, Status: amqp.NodeRebootFailed, Error: err.Error(),
+			})
+		}
+	}()
+
+	orchestrator.Lock()
+	defer orchestrator.Unlock()
+
+	if orchestrator.inProgress {
+		return aoserrors.New("node reboot is already in progress")
+	}
+
+	if len(orchestrator.config.Command) == 0 {
+		return aoserrors.New("reboot command is not configured")
+	}
+
+	if len(request.Timetable) != 0 {
+		if _, ok := nextWindowStart(timeNow(), request.Timetable); !ok {
+			return aoserrors.New("timetable has no windows")
+		}
+	}
+
+	nodeIDs, err := orchestrator.getRebootNodes(request.NodeIDs)
+	if err != nil {
+		return err
+	}
+
+	orchestrator.inProgress = true
+
+	orchestrator.wg.Add(1)
+
+	go orchestrator.reboot(request, nodeIDs)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getRebootNodes returns nodes to reboot with the node CM runs on placed last. All nodes are returned if no node IDs
+// are requested.
+func (orchestrator *Orchestrator) getRebootNodes(requestedIDs []string) ([]string, error) {
+	allIDs, err := orchestrator.nodeInfoProvider.GetAllNodeIDs()
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	nodeIDs := requestedIDs
+
+	if len(nodeIDs) == 0 {
+		nodeIDs = allIDs
+	}
+
+	ownNodeID := orchestrator.nodeInfoProvider.GetNodeID()
+	result := make([]string, 0, len(nodeIDs))
+
+	for _, nodeID := range nodeIDs {
+		if !slices.Contains(allIDs, nodeID) {
+			return nil, aoserrors.Errorf("unknown node: %s", nodeID)
+		}
+
+		if nodeID != ownNodeID && !slices.Contains(result, nodeID) {
+			result = append(result, nodeID)
+		}
+	}
+
+	if slices.Contains(nodeIDs, ownNodeID) {
+		result = append(result, ownNodeID)
+	}
+
+	return result, nil
+}
+
+func (orchestrator *Orchestrator) reboot(request amqp.NodeRebootRequest, nodeIDs []string) {
+	defer orchestrator.wg.Done()
+
+	status := amqp.NodeRebootStatus{RequestID: request.RequestID, Status: amqp.NodeRebootDone}
+
+	defer func() {
+		orchestrator.Lock()
+		orchestrator.inProgress = false
+		orchestrator.Unlock()
+	}()
+
+	if len(request.Timetable) != 0 && !inTimetable(timeNow(), request.Timetable) {
+		orchestrator.sendStatus(amqp.NodeRebootStatus{RequestID: request.RequestID, Status: amqp.NodeRebootScheduled})
+
+		start, _ := nextWindowStart(timeNow(), request.Timetable)
+
+		log.WithFields(log.Fields{"requestID": request.RequestID, "start": start}).Debug("Wait reboot window")
+
+		if !orchestrator.wait(start.Sub(timeNow())) {
+			return
+		}
+	}
+
+	ownNodeID := orchestrator.nodeInfoProvider.GetNodeID()
+
+	for _, nodeID := range nodeIDs {
+		result := amqp.NodeRebootResult{NodeID: nodeID, Status: amqp.NodeRebootDone}
+
+		var err error
+
+		if nodeID == ownNodeID {
+			err = orchestrator.rebootOwnNode(request.RequestID, status.Nodes)
+		} else {
+			err = orchestrator.rebootNode(nodeID)
+		}
+
+		if orchestrator.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.WithField("nodeID", nodeID).Errorf("Can't reboot node: %v", err)
+
+			result.Status, result.Error = amqp.NodeRebootFailed, err.Error()
+			status.Status = amqp.NodeRebootFailed
+		}
+
+		status.Nodes = append(status.Nodes, result)
+	}
+
+	orchestrator.sendStatus(status)
+}
+
+// rebootNode drains the node, reboots it and waits for its SM reconnection. The node is restored in any case.
+func (orchestrator *Orchestrator) rebootNode(nodeID string) (err error) {
+	log.WithField("nodeID", nodeID).Info("Reboot node")
+
+	if err = orchestrator.drainer.DrainNode(nodeID, true); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if drainErr := orchestrator.drainer.DrainNode(nodeID, false); drainErr != nil && err == nil {
+			err = aoserrors.Wrap(drainErr)
+		}
+	}()
+
+	if !orchestrator.wait(orchestrator.config.DrainTimeout.Duration) {
+		return aoserrors.New("reboot is canceled")
+	}
+
+	if err = runCommand(orchestrator.config.Command[0],
+		append(slices.Clone(orchestrator.config.Command[1:]), nodeID)...); err != nil {
+		return err
+	}
+
+	return orchestrator.waitReconnection(nodeID)
+}
+
+// rebootOwnNode persists the request state and reboots the node CM runs on. Normally CM is stopped by the reboot and
+// the request is completed after restart.
+func (orchestrator *Orchestrator) rebootOwnNode(requestID string, results []amqp.NodeRebootResult) (err error) {
+	nodeID := orchestrator.nodeInfoProvider.GetNodeID()
+
+	log.WithField("nodeID", nodeID).Info("Reboot own node")
+
+	if err = orchestrator.drainer.DrainNode(nodeID, true); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if drainErr := orchestrator.drainer.DrainNode(nodeID, false); drainErr != nil && err == nil {
+			err = aoserrors.Wrap(drainErr)
+		}
+	}()
+
+	if !orchestrator.wait(orchestrator.config.DrainTimeout.Duration) {
+		return aoserrors.New("reboot is canceled")
+	}
+
+	if err = orchestrator.saveState(rebootState{RequestID: requestID, Nodes: results}); err != nil {
+		return err
+	}
+
+	defer func() {
+		if orchestrator.ctx.Err() != nil {
+			return
+		}
+
+		if saveErr := orchestrator.clearState(); saveErr != nil {
+			log.Errorf("Can't clear pending node reboot: %v", saveErr)
+		}
+	}()
+
+	if err = runCommand(orchestrator.config.Command[0],
+		append(slices.Clone(orchestrator.config.Command[1:]), nodeID)...); err != nil {
+		return err
+	}
+
+	if !orchestrator.wait(orchestrator.config.RebootTimeout.Duration) {
+		return aoserrors.New("reboot is canceled")
+	}
+
+	return aoserrors.New("node is not rebooted")
+}
+
+// completeReboot reports pending reboot of the node CM runs on after CM restart.
+func (orchestrator *Orchestrator) completeReboot(state rebootState) {
+	defer orchestrator.wg.Done()
+
+	defer func() {
+		orchestrator.Lock()
+		orchestrator.inProgress = false
+		orchestrator.Unlock()
+	}()
+
+	nodeID := orchestrator.nodeInfoProvider.GetNodeID()
+	status := amqp.NodeRebootStatus{RequestID: state.RequestID, Status: amqp.NodeRebootDone, Nodes: state.Nodes}
+	result := amqp.NodeRebootResult{NodeID: nodeID, Status: amqp.NodeRebootDone}
+
+	log.WithFields(log.Fields{"requestID": state.RequestID, "nodeID": nodeID}).Info("Complete own node reboot")
+
+	deadline := timeNow().Add(orchestrator.config.RebootTimeout.Duration)
+
+	if err := orchestrator.waitConnection(nodeID, true, deadline); err != nil {
+		if orchestrator.ctx.Err() != nil {
+			return
+		}
+
+		result.Status, result.Error = amqp.NodeRebootFailed, err.Error()
+	}
+
+	status.Nodes = append(status.Nodes, result)
+
+	for _, node := range status.Nodes {
+		if node.Status != amqp.NodeRebootDone {
+			status.Status = amqp.NodeRebootFailed
+		}
+	}
+
+	if err := orchestrator.clearState(); err != nil {
+		log.Errorf("Can't clear pending node reboot: %v", err)
+	}
+
+	orchestrator.sendStatus(status)
+}
+
+// waitReconnection waits for SM of the node to disconnect and connect again.
+func (orchestrator *Orchestrator) waitReconnection(nodeID string) error {
+	deadline := timeNow().Add(orchestrator.config.RebootTimeout.Duration)
+
+	if err := orchestrator.waitConnection(nodeID, false, deadline); err != nil {
+		return aoserrors.New("node is not disconnected")
+	}
+
+	if err := orchestrator.waitConnection(nodeID, true, deadline); err != nil {
+		return aoserrors.New("node is not reconnected")
+	}
+
+	return nil
+}
+
+func (orchestrator *Orchestrator) waitConnection(nodeID string, connected bool, deadline time.Time) error {
+	for orchestrator.connectionProvider.IsNodeConnected(nodeID) != connected {
+		if !timeNow().Before(deadline) {
+			return aoserrors.New("wait connection timeout")
+		}
+
+		if !orchestrator.wait(pollPeriod) {
+			return aoserrors.New("wait connection canceled")
+		}
+	}
+
+	return nil
+}
+
+// wait waits for the duration and returns false if the orchestrator is closed.
+func (orchestrator *Orchestrator) wait(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+
+	case <-orchestrator.ctx.Done():
+		return false
+	}
+}
+
+func (orchestrator *Orchestrator) saveState(state rebootState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(orchestrator.storage.SetNodeReboot(data))
+}
+
+func (orchestrator *Orchestrator) clearState() error {
+	return aoserrors.Wrap(orchestrator.storage.SetNodeReboot(nil))
+}
+
+func (orchestrator *Orchestrator) sendStatus(status amqp.NodeRebootStatus) {
+	log.WithFields(log.Fields{
+		"requestID": status.RequestID, "status": status.Status,
+	}).Debug("Send node reboot status")
+
+	if err := orchestrator.statusSender.SendNodeRebootStatus(status); err != nil {
+		log.Errorf("Can't send node reboot status: %v", err)
+	}
+}
+
+func inTimetable(now time.Time, timetable []cloudprotocol.TimetableEntry) bool {
+	for _, entry := range timetable {
+		if weekday(entry.DayOfWeek) != now.Weekday() {
+			continue
+		}
+
+		for _, slot := range entry.TimeSlots {
+			start, end := slotTimes(now, slot)
+
+			if !now.Before(start) && now.Before(end) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nextWindowStart returns the nearest start of timetable windows after the time.
+func nextWindowStart(now time.Time, timetable []cloudprotocol.TimetableEntry) (next time.Time, found bool) {
+	for day := 0; day <= daysInWeek; day++ {
+		date := now.AddDate(0, 0, day)
+
+		for _, entry := range timetable {
+			if weekday(entry.DayOfWeek) != date.Weekday() {
+				continue
+			}
+
+			for _, slot := range entry.TimeSlots {
+				start, end := slotTimes(date, slot)
+
+				if !end.After(start) {
+					continue
+				}
+
+				if start.After(now) && (!found || start.Before(next)) {
+					next, found = start, true
+				}
+			}
+		}
+
+		if found {
+			return next, true
+		}
+	}
+
+	return next, false
+}
+
+func slotTimes(date time.Time, slot cloudprotocol.TimeSlot) (start, end time.Time) {
+	start = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.Start.Hour(), slot.Start.Minute(), slot.Start.Second(), 0, time.Local) //nolint:gosmopolitan
+	end = time.Date(date.Year(), date.Month(), date.Day(),
+		slot.End.Hour(), slot.End.Minute(), slot.End.Second(), 0, time.Local) //nolint:gosmopolitan
+
+	return start, end
+}
+
+// weekday converts timetable day of week (1 - Monday, 7 - Sunday) to time weekday.
+func weekday(dayOfWeek uint) time.Weekday {
+	return time.Weekday(dayOfWeek % daysInWeek)
+}
@@ -0,0 +1,412 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodereboot
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	mainNodeID = "main"
+	nodeID1    = "node1"
+	nodeID2    = "node2"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	sync.Mutex
+	state json.RawMessage
+}
+
+type testNodeInfoProvider struct{}
+
+type drainCall struct {
+	nodeID  string
+	drained bool
+}
+
+type testNodeDrainer struct {
+	sync.Mutex
+	calls []drainCall
+}
+
+type testConnectionProvider struct {
+	sync.Mutex
+	connected map[string]bool
+}
+
+type testStatusSender struct {
+	statuses chan amqp.NodeRebootStatus
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals
+var defaultRunCommand = runCommand
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRebootUnit(t *testing.T) {
+	storage := &testStorage{}
+	drainer := &testNodeDrainer{}
+	connectionProvider := newTestConnectionProvider()
+	statusSender := newTestStatusSender()
+	rebootedNodes := make(chan string, 10)
+
+	pollPeriod = 10 * time.Millisecond
+	defer func() { pollPeriod = time.Second }()
+
+	runCommand = func(name string, arg ...string) error {
+		nodeID := arg[len(arg)-1]
+
+		if name != "reboot" || !slices.Equal(arg, []string{"-f", nodeID}) {
+			t.Errorf("Wrong reboot command: %s %v", name, arg)
+		}
+
+		if nodeID != mainNodeID {
+			connectionProvider.reconnect(nodeID, 50*time.Millisecond)
+		}
+
+		rebootedNodes <- nodeID
+
+		return nil
+	}
+	defer func() { runCommand = defaultRunCommand }()
+
+	cfg := config.NodeReboot{
+		Command:       []string{"reboot", "-f"},
+		DrainTimeout:  aostypes.Duration{Duration: 10 * time.Millisecond},
+		RebootTimeout: aostypes.Duration{Duration: time.Second},
+	}
+
+	orchestrator, err := New(cfg, storage, &testNodeInfoProvider{}, drainer, connectionProvider, statusSender)
+	if err != nil {
+		t.Fatalf("Can't create node reboot orchestrator: %v", err)
+	}
+
+	if err = orchestrator.RebootNodes(amqp.NodeRebootRequest{RequestID: "request1"}); err != nil {
+		t.Fatalf("Can't reboot nodes: %v", err)
+	}
+
+	if err = orchestrator.RebootNodes(amqp.NodeRebootRequest{RequestID: "request2"}); err == nil {
+		t.Error("Error expected while reboot is in progress")
+	}
+
+	if status := statusSender.waitStatus(t); status.RequestID != "request2" || status.Status != amqp.NodeRebootFailed {
+		t.Errorf("Wrong reboot status: %v", status)
+	}
+
+	// Own node is rebooted last
+
+	for _, expectedNodeID := range []string{nodeID1, nodeID2, mainNodeID} {
+		select {
+		case nodeID := <-rebootedNodes:
+			if nodeID != expectedNodeID {
+				t.Errorf("Wrong rebooted node: %s", nodeID)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Wait node reboot timeout: %s", expectedNodeID)
+		}
+	}
+
+	// Simulate CM stop by own node reboot
+
+	orchestrator.Close()
+
+	var state rebootState
+
+	if err = json.Unmarshal(storage.get(), &state); err != nil {
+		t.Fatalf("Can't parse reboot state: %v", err)
+	}
+
+	if state.RequestID != "request1" || len(state.Nodes) != 2 {
+		t.Errorf("Wrong reboot state: %v", state)
+	}
+
+	if calls := drainer.getCalls(); !slices.Equal(calls[:4], []drainCall{
+		{nodeID1, true}, {nodeID1, false}, {nodeID2, true}, {nodeID2, false},
+	}) || calls[4] != (drainCall{mainNodeID, true}) {
+		t.Errorf("Wrong drain calls: %v", calls)
+	}
+
+	// Reboot is completed after restart
+
+	if orchestrator, err = New(
+		cfg, storage, &testNodeInfoProvider{}, drainer, connectionProvider, statusSender); err != nil {
+		t.Fatalf("Can't create node reboot orchestrator: %v", err)
+	}
+	defer orchestrator.Close()
+
+	status := statusSender.waitStatus(t)
+
+	if status.RequestID != "request1" || status.Status != amqp.NodeRebootDone || len(status.Nodes) != 3 {
+		t.Fatalf("Wrong reboot status: %v", status)
+	}
+
+	for i, nodeID := range []string{nodeID1, nodeID2, mainNodeID} {
+		if status.Nodes[i].NodeID != nodeID || status.Nodes[i].Status != amqp.NodeRebootDone {
+			t.Errorf("Wrong node reboot result: %v", status.Nodes[i])
+		}
+	}
+
+	if state := storage.get(); len(state) != 0 {
+		t.Errorf("Reboot state is not cleared: %s", string(state))
+	}
+}
+
+func TestRebootNodeFailed(t *testing.T) {
+	connectionProvider := newTestConnectionProvider()
+	statusSender := newTestStatusSender()
+
+	pollPeriod = 10 * time.Millisecond
+	defer func() { pollPeriod = time.Second }()
+
+	runCommand = func(name string, arg ...string) error { return nil }
+	defer func() { runCommand = defaultRunCommand }()
+
+	orchestrator, err := New(config.NodeReboot{
+		Command:       []string{"reboot"},
+		RebootTimeout: aostypes.Duration{Duration: 100 * time.Millisecond},
+	}, &testStorage{}, &testNodeInfoProvider{}, &testNodeDrainer{}, connectionProvider, statusSender)
+	if err != nil {
+		t.Fatalf("Can't create node reboot orchestrator: %v", err)
+	}
+	defer orchestrator.Close()
+
+	if err = orchestrator.RebootNodes(
+		amqp.NodeRebootRequest{RequestID: "request1", NodeIDs: []string{"unknown"}}); err == nil {
+		t.Error("Error expected for unknown node")
+	}
+
+	if status := statusSender.waitStatus(t); status.Status != amqp.NodeRebootFailed || status.Error == "" {
+		t.Errorf("Wrong reboot status: %v", status)
+	}
+
+	// Node SM is not reconnected
+
+	if err = orchestrator.RebootNodes(
+		amqp.NodeRebootRequest{RequestID: "request2", NodeIDs: []string{nodeID1}}); err != nil {
+		t.Fatalf("Can't reboot nodes: %v", err)
+	}
+
+	status := statusSender.waitStatus(t)
+
+	if status.Status != amqp.NodeRebootFailed || len(status.Nodes) != 1 || status.Nodes[0].Error == "" {
+		t.Errorf("Wrong reboot status: %v", status)
+	}
+}
+
+func TestRebootWindow(t *testing.T) {
+	statusSender := newTestStatusSender()
+	now := time.Date(2024, time.March, 18, 10, 0, 0, 0, time.Local) //nolint:gosmopolitan
+
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	runCommand = func(name string, arg ...string) error {
+		t.Errorf("Unexpected reboot: %v", arg)
+
+		return nil
+	}
+	defer func() { runCommand = defaultRunCommand }()
+
+	orchestrator, err := New(config.NodeReboot{Command: []string{"reboot"}}, &testStorage{}, &testNodeInfoProvider{},
+		&testNodeDrainer{}, newTestConnectionProvider(), statusSender)
+	if err != nil {
+		t.Fatalf("Can't create node reboot orchestrator: %v", err)
+	}
+	defer orchestrator.Close()
+
+	if err = orchestrator.RebootNodes(amqp.NodeRebootRequest{
+		RequestID: "request1", NodeIDs: []string{nodeID1},
+		Timetable: []cloudprotocol.TimetableEntry{{DayOfWeek: 1, TimeSlots: []cloudprotocol.TimeSlot{
+			{Start: timeOfDay(12, 0), End: timeOfDay(13, 0)},
+		}}},
+	}); err != nil {
+		t.Fatalf("Can't reboot nodes: %v", err)
+	}
+
+	if status := statusSender.waitStatus(t); status.Status != amqp.NodeRebootScheduled {
+		t.Errorf("Wrong reboot status: %v", status)
+	}
+
+	if start, _ := nextWindowStart(now, []cloudprotocol.TimetableEntry{{DayOfWeek: 1, TimeSlots: []cloudprotocol.TimeSlot{
+		{Start: timeOfDay(8, 0), End: timeOfDay(9, 0)},
+	}}}); !start.Equal(now.AddDate(0, 0, 7).Add(-2 * time.Hour)) {
+		t.Errorf("Wrong next window start: %v", start)
+	}
+}
+
+/***********************************************************************************************************************
+ * testStorage
+ **********************************************************************************************************************/
+
+func (storage *testStorage) SetNodeReboot(state json.RawMessage) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.state = state
+
+	return nil
+}
+
+func (storage *testStorage) GetNodeReboot() (json.RawMessage, error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.state, nil
+}
+
+func (storage *testStorage) get() json.RawMessage {
+	state, _ := storage.GetNodeReboot()
+
+	return state
+}
+
+/***********************************************************************************************************************
+ * testNodeInfoProvider
+ **********************************************************************************************************************/
+
+func (provider *testNodeInfoProvider) GetNodeID() string {
+	return mainNodeID
+}
+
+func (provider *testNodeInfoProvider) GetAllNodeIDs() ([]string, error) {
+	return []string{mainNodeID, nodeID1, nodeID2}, nil
+}
+
+/***********************************************************************************************************************
+ * testNodeDrainer
+ **********************************************************************************************************************/
+
+func (drainer *testNodeDrainer) DrainNode(nodeID string, drained bool) error {
+	drainer.Lock()
+	defer drainer.Unlock()
+
+	drainer.calls = append(drainer.calls, drainCall{nodeID: nodeID, drained: drained})
+
+	return nil
+}
+
+func (drainer *testNodeDrainer) getCalls() []drainCall {
+	drainer.Lock()
+	defer drainer.Unlock()
+
+	return slices.Clone(drainer.calls)
+}
+
+/***********************************************************************************************************************
+ * testConnectionProvider
+ **********************************************************************************************************************/
+
+func newTestConnectionProvider() *testConnectionProvider {
+	return &testConnectionProvider{connected: map[string]bool{mainNodeID: true, nodeID1: true, nodeID2: true}}
+}
+
+func (provider *testConnectionProvider) IsNodeConnected(nodeID string) bool {
+	provider.Lock()
+	defer provider.Unlock()
+
+	return provider.connected[nodeID]
+}
+
+func (provider *testConnectionProvider) reconnect(nodeID string, delay time.Duration) {
+	provider.Lock()
+	provider.connected[nodeID] = false
+	provider.Unlock()
+
+	time.AfterFunc(delay, func() {
+		provider.Lock()
+		provider.connected[nodeID] = true
+		provider.Unlock()
+	})
+}
+
+/***********************************************************************************************************************
+ * testStatusSender
+ **********************************************************************************************************************/
+
+func newTestStatusSender() *testStatusSender {
+	return &testStatusSender{statuses: make(chan amqp.NodeRebootStatus, 10)}
+}
+
+func (sender *testStatusSender) SendNodeRebootStatus(status amqp.NodeRebootStatus) error {
+	sender.statuses <- status
+
+	return nil
+}
+
+func (sender *testStatusSender) waitStatus(t *testing.T) amqp.NodeRebootStatus {
+	t.Helper()
+
+	select {
+	case status := <-sender.statuses:
+		return status
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait node reboot status timeout")
+	}
+
+	return amqp.NodeRebootStatus{}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func timeOfDay(hour, minute int) aostypes.Time {
+	return aostypes.Time{Time: time.Date(0, 1, 1, hour, minute, 0, 0, time.Local)} //nolint:gosmopolitan
+}
@@ -0,0 +1,583 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ownerchange
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Owner change steps.
+const (
+	StepStopUpdates    = "stopUpdates"
+	StepStopInstances  = "stopInstances"
+	StepWipeStorages   = "wipeStorages"
+	StepPurgeNetwork   = "purgeNetwork"
+	StepClearDatabase  = "clearDatabase"
+	StepRemoveImages   = "removeImages"
+	StepRotateIdentity = "rotateIdentity"
+	StepReonboard      = "reonboard"
+)
+
+// Step statuses.
+const (
+	StepStatusDone    = "done"
+	StepStatusFailed  = "failed"
+	StepStatusSkipped = "skipped"
+	StepStatusPending = "pending"
+)
+
+const (
+	pendingReportFileName = "ownerchange.json"
+	usedRequestsFileName  = "ownerchangerequests.json"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// UpdateStopper stops update activities: downloads and image install or remove operations.
+type UpdateStopper interface {
+	Stop()
+}
+
+// InstanceRunner runs service instances.
+type InstanceRunner interface {
+	RunInstances(instances []cloudprotocol.InstanceInfo, rebalancing bool) error
+}
+
+// StorageStateCleaner removes instance storages and states.
+type StorageStateCleaner interface {
+	RemoveAll() error
+}
+
+// NetworkPurger removes instance and provider networks.
+type NetworkPurger interface {
+	Purge() error
+}
+
+// Storage clears owner related data.
+type Storage interface {
+	Clear() error
+}
+
+// SecretsCache caches unit secrets.
+type SecretsCache interface {
+	SetUnitSecrets(secrets cloudprotocol.UnitSecrets)
+}
+
+// CertificateRenewer renews unit certificates.
+type CertificateRenewer interface {
+	RenewCertificatesNotification(secrets cloudprotocol.UnitSecrets, certInfo []cloudprotocol.RenewCertData) error
+}
+
+// Signer signs data with unit online key.
+type Signer interface {
+	SignData(data []byte) (signature []byte, certificates [][]byte, err error)
+}
+
+// SignVerifier verifies data signed by the cloud.
+type SignVerifier interface {
+	VerifyDataSign(data []byte, chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
+		sign cloudprotocol.Signs) error
+}
+
+// StatusSender sends owner change report to the cloud.
+type StatusSender interface {
+	SendOwnerChangeStatus(status amqp.OwnerChangeStatus) error
+	SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+	UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error
+}
+
+// Restarter restarts CM to re-onboard the unit with new identity and empty state.
+type Restarter interface {
+	Restart()
+}
+
+// Request owner change request. Info contains JSON encoded request info signed by authorization sign. Certificates
+// and certificate chains are used to verify the authorization.
+type Request struct {
+	Info          json.RawMessage                  `json:"info"`
+	Authorization cloudprotocol.Signs              `json:"authorization"`
+	CertChains    []cloudprotocol.CertificateChain `json:"certificateChains,omitempty"`
+	Certs         []cloudprotocol.Certificate      `json:"certificates,omitempty"`
+}
+
+// RequestInfo owner change request info. The request is rejected after valid until time. Unit secrets and renew
+// certificates are used to rotate unit identities.
+type RequestInfo struct {
+	RequestID    string                        `json:"requestId"`
+	ValidUntil   time.Time                     `json:"validUntil"`
+	UnitSecrets  *cloudprotocol.UnitSecrets    `json:"unitSecrets"`
+	Certificates []cloudprotocol.RenewCertData `json:"renewCertificates"`
+}
+
+// Report owner change report.
+type Report struct {
+	RequestID   string       `json:"requestId"`
+	StartedAt   time.Time    `json:"startedAt"`
+	CompletedAt time.Time    `json:"completedAt"`
+	Steps       []StepResult `json:"steps"`
+}
+
+// StepResult owner change step result.
+type StepResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OwnerChange performs coordinated owner change: stops updates and instances, wipes storages and states, purges
+// networks, clears database and images, rotates unit identity and re-onboards the unit by restarting CM. The final
+// report is signed and sent to the cloud on the first connection after restart.
+type OwnerChange struct {
+	sync.Mutex
+
+	config         *config.Config
+	updateStoppers []UpdateStopper
+	instanceRunner InstanceRunner
+	storageState   StorageStateCleaner
+	network        NetworkPurger
+	storage        Storage
+	secretsCache   SecretsCache
+	certRenewer    CertificateRenewer
+	signer         Signer
+	signVerifier   SignVerifier
+	statusSender   StatusSender
+	restarter      Restarter
+	inProgress     bool
+}
+
+type step struct {
+	name   string
+	action func(info RequestInfo) (skipped bool, err error)
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates owner change instance.
+func New(
+	config *config.Config, updateStoppers []UpdateStopper, instanceRunner InstanceRunner,
+	storageState StorageStateCleaner, network NetworkPurger, storage Storage, secretsCache SecretsCache,
+	certRenewer CertificateRenewer, signer Signer, signVerifier SignVerifier, statusSender StatusSender,
+	restarter Restarter,
+) (ownerChange *OwnerChange, err error) {
+	log.Debug("Create owner change")
+
+	ownerChange = &OwnerChange{
+		config:         config,
+		updateStoppers: updateStoppers,
+		instanceRunner: instanceRunner,
+		storageState:   storageState,
+		network:        network,
+		storage:        storage,
+		secretsCache:   secretsCache,
+		certRenewer:    certRenewer,
+		signer:         signer,
+		signVerifier:   signVerifier,
+		statusSender:   statusSender,
+		restarter:      restarter,
+	}
+
+	// Pending report is completed on the first connection after restart
+	if err = statusSender.SubscribeForConnectionEvents(ownerChange); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return ownerChange, nil
+}
+
+// Close closes owner change instance.
+func (ownerChange *OwnerChange) Close() {
+	log.Debug("Close owner change")
+
+	if err := ownerChange.statusSender.UnsubscribeFromConnectionEvents(ownerChange); err != nil {
+		log.Errorf("Can't unsubscribe from connection events: %v", err)
+	}
+}
+
+// ChangeOwner performs owner change. The request is rejected if its authorization can't be verified, it is already
+// used or unit identity can't be rotated. If all steps succeed, CM is restarted and re-onboarding step is reported as pending. Otherwise,
+// the steps are stopped on the first failure and the report is sent immediately.
+func (ownerChange *OwnerChange) ChangeOwner(request Request) (report Report, err error) {
+	info, err := ownerChange.authorize(request)
+	if err != nil {
+		log.Errorf("Owner change request is rejected: %v", err)
+
+		return report, err
+	}
+
+	ownerChange.Lock()
+
+	if ownerChange.inProgress {
+		ownerChange.Unlock()
+
+		return report, aoserrors.New("owner change is already in progress")
+	}
+
+	ownerChange.inProgress = true
+
+	ownerChange.Unlock()
+
+	defer func() {
+		ownerChange.Lock()
+		defer ownerChange.Unlock()
+
+		// After successful owner change CM is restarted, block other requests till that
+		ownerChange.inProgress = err == nil
+	}()
+
+	// Request is consumed before any data is wiped, so it can't be replayed even if owner change fails
+	if err = ownerChange.setRequestUsed(info); err != nil {
+		return report, err
+	}
+
+	log.WithField("requestID", info.RequestID).Info("Change owner")
+
+	report = Report{RequestID: info.RequestID, StartedAt: time.Now().UTC()}
+
+	for _, step := range []step{
+		{name: StepStopUpdates, action: ownerChange.stopUpdates},
+		{name: StepStopInstances, action: ownerChange.stopInstances},
+		{name: StepWipeStorages, action: ownerChange.wipeStorages},
+		{name: StepPurgeNetwork, action: ownerChange.purgeNetwork},
+		{name: StepClearDatabase, action: ownerChange.clearDatabase},
+		{name: StepRemoveImages, action: ownerChange.removeImages},
+		{name: StepRotateIdentity, action: ownerChange.rotateIdentity},
+	} {
+		log.WithField("step", step.name).Debug("Perform owner change step")
+
+		result := StepResult{Name: step.name, Status: StepStatusDone}
+
+		skipped, stepErr := step.action(info)
+
+		switch {
+		case stepErr != nil:
+			log.WithField("step", step.name).Errorf("Owner change step failed: %v", stepErr)
+
+			result.Status = StepStatusFailed
+			result.Error = stepErr.Error()
+
+		case skipped:
+			result.Status = StepStatusSkipped
+		}
+
+		report.Steps = append(report.Steps, result)
+
+		if stepErr != nil {
+			report.CompletedAt = time.Now().UTC()
+
+			if sendErr := ownerChange.sendReport(report); sendErr != nil {
+				log.Errorf("Can't send owner change report: %v", sendErr)
+			}
+
+			return report, aoserrors.Errorf("owner change step %s failed: %v", step.name, stepErr)
+		}
+	}
+
+	report.Steps = append(report.Steps, StepResult{Name: StepReonboard, Status: StepStatusPending})
+
+	if err = ownerChange.savePendingReport(report); err != nil {
+		return report, err
+	}
+
+	log.Info("Restart to re-onboard the unit")
+
+	ownerChange.restarter.Restart()
+
+	return report, nil
+}
+
+// CloudConnected completes pending owner change when the unit is re-onboarded.
+func (ownerChange *OwnerChange) CloudConnected() {
+	go func() {
+		if err := ownerChange.completePendingReport(); err != nil {
+			log.Errorf("Can't complete owner change: %v", err)
+		}
+	}()
+}
+
+// CloudDisconnected indicates connection to the cloud is lost.
+func (ownerChange *OwnerChange) CloudDisconnected() {
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// authorize verifies mandatory authorization sign of owner change request, rejects already used requests and
+// validates that unit identity can be rotated before any data is wiped.
+func (ownerChange *OwnerChange) authorize(request Request) (info RequestInfo, err error) {
+	if ownerChange.signVerifier == nil {
+		return info, aoserrors.New("authorization can't be verified")
+	}
+
+	if err = ownerChange.signVerifier.VerifyDataSign(
+		request.Info, request.CertChains, request.Certs, request.Authorization); err != nil {
+		return info, aoserrors.Errorf("authorization failed: %v", err)
+	}
+
+	if err = json.Unmarshal(request.Info, &info); err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	if info.RequestID == "" {
+		return info, aoserrors.New("request ID is empty")
+	}
+
+	if info.ValidUntil.IsZero() || time.Now().After(info.ValidUntil) {
+		return info, aoserrors.New("request is expired")
+	}
+
+	if info.ValidUntil.After(time.Now().Add(ownerChange.config.OwnerChange.MaxValidity.Duration)) {
+		return info, aoserrors.New("request validity exceeds maximum")
+	}
+
+	used, err := ownerChange.isRequestUsed(info.RequestID)
+	if err != nil {
+		return info, err
+	}
+
+	if used {
+		return info, aoserrors.New("request is already used")
+	}
+
+	if len(info.Certificates) == 0 {
+		return info, aoserrors.New("certificates are required to rotate identity")
+	}
+
+	if info.UnitSecrets == nil {
+		return info, aoserrors.New("unit secrets are required to rotate identity")
+	}
+
+	if info.UnitSecrets.Version != cloudprotocol.UnitSecretVersion {
+		return info, aoserrors.New("unit secure version mismatch")
+	}
+
+	return info, nil
+}
+
+func (ownerChange *OwnerChange) stopUpdates(info RequestInfo) (skipped bool, err error) {
+	// Stop returns when current operations are finished, so nothing is downloaded or installed after wipe
+	for _, stopper := range ownerChange.updateStoppers {
+		stopper.Stop()
+	}
+
+	return false, nil
+}
+
+func (ownerChange *OwnerChange) stopInstances(info RequestInfo) (skipped bool, err error) {
+	return false, aoserrors.Wrap(ownerChange.instanceRunner.RunInstances(nil, false))
+}
+
+func (ownerChange *OwnerChange) wipeStorages(info RequestInfo) (skipped bool, err error) {
+	return false, aoserrors.Wrap(ownerChange.storageState.RemoveAll())
+}
+
+func (ownerChange *OwnerChange) purgeNetwork(info RequestInfo) (skipped bool, err error) {
+	return false, aoserrors.Wrap(ownerChange.network.Purge())
+}
+
+func (ownerChange *OwnerChange) clearDatabase(info RequestInfo) (skipped bool, err error) {
+	return false, aoserrors.Wrap(ownerChange.storage.Clear())
+}
+
+func (ownerChange *OwnerChange) removeImages(info RequestInfo) (skipped bool, err error) {
+	// Image directories are recreated on CM start
+	for _, dir := range []string{
+		ownerChange.config.ImageStoreDir, ownerChange.config.ComponentsDir, ownerChange.config.Downloader.DownloadDir,
+	} {
+		if dir == "" {
+			continue
+		}
+
+		if err = os.RemoveAll(dir); err != nil {
+			return false, aoserrors.Wrap(err)
+		}
+	}
+
+	return false, nil
+}
+
+func (ownerChange *OwnerChange) rotateIdentity(info RequestInfo) (skipped bool, err error) {
+	// Certificates and unit secrets are validated on authorization
+	ownerChange.secretsCache.SetUnitSecrets(*info.UnitSecrets)
+
+	return false, aoserrors.Wrap(
+		ownerChange.certRenewer.RenewCertificatesNotification(*info.UnitSecrets, info.Certificates))
+}
+
+func (ownerChange *OwnerChange) sendReport(report Report) error {
+	reportData, err := json.Marshal(report)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	signature, certificates, err := ownerChange.signer.SignData(reportData)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(ownerChange.statusSender.SendOwnerChangeStatus(amqp.OwnerChangeStatus{
+		RequestID:    report.RequestID,
+		Report:       reportData,
+		Signature:    signature,
+		Certificates: certificates,
+	}))
+}
+
+// Used requests are stored in working dir as database is cleared by owner change.
+func (ownerChange *OwnerChange) getUsedRequestsFile() string {
+	return filepath.Join(ownerChange.config.WorkingDir, usedRequestsFileName)
+}
+
+func (ownerChange *OwnerChange) loadUsedRequests() (usedRequests map[string]time.Time, err error) {
+	usedRequests = make(map[string]time.Time)
+
+	data, err := os.ReadFile(ownerChange.getUsedRequestsFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return usedRequests, nil
+		}
+
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, &usedRequests); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return usedRequests, nil
+}
+
+func (ownerChange *OwnerChange) isRequestUsed(requestID string) (used bool, err error) {
+	usedRequests, err := ownerChange.loadUsedRequests()
+	if err != nil {
+		return false, err
+	}
+
+	_, used = usedRequests[requestID]
+
+	return used, nil
+}
+
+func (ownerChange *OwnerChange) setRequestUsed(info RequestInfo) error {
+	usedRequests, err := ownerChange.loadUsedRequests()
+	if err != nil {
+		return err
+	}
+
+	// Expired requests are rejected anyway, no need to keep them
+	for requestID, validUntil := range usedRequests {
+		if time.Now().After(validUntil) {
+			delete(usedRequests, requestID)
+		}
+	}
+
+	usedRequests[info.RequestID] = info.ValidUntil
+
+	data, err := json.Marshal(usedRequests)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(ownerChange.getUsedRequestsFile(), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (ownerChange *OwnerChange) getPendingReportFile() string {
+	return filepath.Join(ownerChange.config.WorkingDir, pendingReportFileName)
+}
+
+func (ownerChange *OwnerChange) savePendingReport(report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(ownerChange.getPendingReportFile(), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (ownerChange *OwnerChange) completePendingReport() error {
+	ownerChange.Lock()
+	defer ownerChange.Unlock()
+
+	// Restart is not performed yet
+	if ownerChange.inProgress {
+		return nil
+	}
+
+	data, err := os.ReadFile(ownerChange.getPendingReportFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return aoserrors.Wrap(err)
+	}
+
+	var report Report
+
+	if err = json.Unmarshal(data, &report); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	log.WithField("requestID", report.RequestID).Info("Owner change completed")
+
+	for i := range report.Steps {
+		if report.Steps[i].Name == StepReonboard {
+			report.Steps[i].Status = StepStatusDone
+		}
+	}
+
+	report.CompletedAt = time.Now().UTC()
+
+	if err = ownerChange.sendReport(report); err != nil {
+		return err
+	}
+
+	if err = os.Remove(ownerChange.getPendingReportFile()); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
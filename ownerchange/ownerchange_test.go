@@ -0,0 +1,467 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ownerchange_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	amqp "github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/ownerchange"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	testSignature     = "signature"
+	testAuthorization = "authorization"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// testUnit implements all owner change dependencies and records performed steps.
+type testUnit struct {
+	failStep       string
+	verified       bool
+	steps          []string
+	unitSecrets    *cloudprotocol.UnitSecrets
+	certificates   []cloudprotocol.RenewCertData
+	restarted      bool
+	statusChannel  chan amqp.OwnerChangeStatus
+	eventConsumers []amqp.ConnectionEventsConsumer
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var tmpDir string
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Main
+ **********************************************************************************************************************/
+
+func TestMain(m *testing.M) {
+	var err error
+
+	tmpDir, err = os.MkdirTemp("", "cm_")
+	if err != nil {
+		log.Fatalf("Error create temporary dir: %v", err)
+	}
+
+	ret := m.Run()
+
+	if err = os.RemoveAll(tmpDir); err != nil {
+		log.Fatalf("Error cleaning up: %v", err)
+	}
+
+	os.Exit(ret)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestChangeOwner(t *testing.T) {
+	cfg := createTestConfig(t, "success")
+	unit := newTestUnit("")
+
+	ownerChange := createOwnerChange(t, cfg, unit)
+	defer ownerChange.Close()
+
+	info := newRequestInfo("request1")
+	request := createRequest(t, info, testAuthorization)
+
+	report, err := ownerChange.ChangeOwner(request)
+	if err != nil {
+		t.Fatalf("Can't change owner: %v", err)
+	}
+
+	if !unit.verified {
+		t.Error("Request authorization should be verified")
+	}
+
+	expectedSteps := []string{
+		ownerchange.StepStopUpdates, ownerchange.StepStopInstances, ownerchange.StepWipeStorages, ownerchange.StepPurgeNetwork,
+		ownerchange.StepClearDatabase, ownerchange.StepRotateIdentity,
+	}
+
+	if !reflect.DeepEqual(unit.steps, expectedSteps) {
+		t.Errorf("Wrong performed steps: %v", unit.steps)
+	}
+
+	if !reflect.DeepEqual(unit.certificates, info.Certificates) || unit.unitSecrets == nil {
+		t.Errorf("Wrong renew certificates request: %v", unit.certificates)
+	}
+
+	if _, err = os.Stat(cfg.ImageStoreDir); !os.IsNotExist(err) {
+		t.Error("Image store should be removed")
+	}
+
+	if !unit.restarted {
+		t.Error("CM should be restarted")
+	}
+
+	checkReport(t, report, ownerchange.StepReonboard, ownerchange.StepStatusPending)
+
+	if _, err = ownerChange.ChangeOwner(request); err == nil {
+		t.Error("Error expected while restart is pending")
+	}
+
+	// Simulate CM restart
+
+	restartedUnit := newTestUnit("")
+
+	restartedOwnerChange := createOwnerChange(t, cfg, restartedUnit)
+	defer restartedOwnerChange.Close()
+
+	restartedUnit.connect()
+
+	status, err := restartedUnit.waitStatus()
+	if err != nil {
+		t.Fatalf("Can't wait owner change status: %v", err)
+	}
+
+	if err = json.Unmarshal(status.Report, &report); err != nil {
+		t.Fatalf("Can't parse report: %v", err)
+	}
+
+	checkReport(t, report, ownerchange.StepReonboard, ownerchange.StepStatusDone)
+
+	if status.RequestID != info.RequestID || string(status.Signature) != testSignature {
+		t.Errorf("Wrong owner change status: %v", status)
+	}
+
+	// Report should be sent only once
+
+	restartedUnit.connect()
+
+	if _, err = restartedUnit.waitStatus(); err == nil {
+		t.Error("Unexpected owner change status")
+	}
+
+	// Used request can't be replayed after restart
+
+	if _, err = restartedOwnerChange.ChangeOwner(request); err == nil {
+		t.Error("Error expected for used request")
+	}
+
+	if len(restartedUnit.steps) != 0 {
+		t.Errorf("No steps should be performed: %v", restartedUnit.steps)
+	}
+}
+
+func TestChangeOwnerFailed(t *testing.T) {
+	cfg := createTestConfig(t, "failed")
+	unit := newTestUnit(ownerchange.StepPurgeNetwork)
+
+	ownerChange := createOwnerChange(t, cfg, unit)
+	defer ownerChange.Close()
+
+	report, err := ownerChange.ChangeOwner(createRequest(t, newRequestInfo("request2"), testAuthorization))
+	if err == nil {
+		t.Fatal("Error expected")
+	}
+
+	if unit.restarted {
+		t.Error("CM should not be restarted")
+	}
+
+	checkReport(t, report, ownerchange.StepPurgeNetwork, ownerchange.StepStatusFailed)
+
+	status, err := unit.waitStatus()
+	if err != nil {
+		t.Fatalf("Can't wait owner change status: %v", err)
+	}
+
+	if err = json.Unmarshal(status.Report, &report); err != nil {
+		t.Fatalf("Can't parse report: %v", err)
+	}
+
+	checkReport(t, report, ownerchange.StepPurgeNetwork, ownerchange.StepStatusFailed)
+
+	// Failed owner change can be repeated with new request only
+
+	unit.failStep = ""
+
+	if _, err = ownerChange.ChangeOwner(createRequest(t, newRequestInfo("request2"), testAuthorization)); err == nil {
+		t.Error("Error expected for used request")
+	}
+
+	if _, err = ownerChange.ChangeOwner(createRequest(t, newRequestInfo("request3"), testAuthorization)); err != nil {
+		t.Fatalf("Can't change owner: %v", err)
+	}
+}
+
+func TestChangeOwnerRejected(t *testing.T) {
+	cfg := createTestConfig(t, "rejected")
+	unit := newTestUnit("")
+
+	ownerChange := createOwnerChange(t, cfg, unit)
+	defer ownerChange.Close()
+
+	expired := newRequestInfo("expired")
+	expired.ValidUntil = time.Now().Add(-time.Minute)
+
+	tooLong := newRequestInfo("tooLong")
+	tooLong.ValidUntil = time.Now().Add(cfg.OwnerChange.MaxValidity.Duration + time.Hour)
+
+	noCertificates := newRequestInfo("noCertificates")
+	noCertificates.Certificates = nil
+
+	noSecrets := newRequestInfo("noSecrets")
+	noSecrets.UnitSecrets = nil
+
+	testData := []struct {
+		name          string
+		info          ownerchange.RequestInfo
+		authorization string
+	}{
+		{name: "unauthorized", info: newRequestInfo("unauthorized"), authorization: "invalid"},
+		{name: "expired", info: expired, authorization: testAuthorization},
+		{name: "too long validity", info: tooLong, authorization: testAuthorization},
+		{name: "no certificates", info: noCertificates, authorization: testAuthorization},
+		{name: "no unit secrets", info: noSecrets, authorization: testAuthorization},
+	}
+
+	for _, item := range testData {
+		t.Run(item.name, func(t *testing.T) {
+			if _, err := ownerChange.ChangeOwner(createRequest(t, item.info, item.authorization)); err == nil {
+				t.Error("Error expected")
+			}
+
+			if len(unit.steps) != 0 {
+				t.Errorf("No steps should be performed: %v", unit.steps)
+			}
+
+			if _, err := unit.waitStatus(); err == nil {
+				t.Error("Unexpected owner change status")
+			}
+		})
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (unit *testUnit) Stop() {
+	_ = unit.performStep(ownerchange.StepStopUpdates)
+}
+
+func (unit *testUnit) RunInstances(instances []cloudprotocol.InstanceInfo, rebalancing bool) error {
+	if len(instances) != 0 {
+		return aoserrors.New("instances should be stopped")
+	}
+
+	return unit.performStep(ownerchange.StepStopInstances)
+}
+
+func (unit *testUnit) RemoveAll() error {
+	return unit.performStep(ownerchange.StepWipeStorages)
+}
+
+func (unit *testUnit) Purge() error {
+	return unit.performStep(ownerchange.StepPurgeNetwork)
+}
+
+func (unit *testUnit) Clear() error {
+	return unit.performStep(ownerchange.StepClearDatabase)
+}
+
+func (unit *testUnit) SetUnitSecrets(secrets cloudprotocol.UnitSecrets) {
+	unit.unitSecrets = &secrets
+}
+
+func (unit *testUnit) RenewCertificatesNotification(
+	secrets cloudprotocol.UnitSecrets, certInfo []cloudprotocol.RenewCertData,
+) error {
+	unit.certificates = certInfo
+
+	return unit.performStep(ownerchange.StepRotateIdentity)
+}
+
+func (unit *testUnit) SignData(data []byte) (signature []byte, certificates [][]byte, err error) {
+	return []byte(testSignature), [][]byte{[]byte("certificate")}, nil
+}
+
+func (unit *testUnit) VerifyDataSign(data []byte, chains []cloudprotocol.CertificateChain,
+	certs []cloudprotocol.Certificate, sign cloudprotocol.Signs,
+) error {
+	if string(sign.Value) != testAuthorization {
+		return aoserrors.New("invalid signature")
+	}
+
+	unit.verified = true
+
+	return nil
+}
+
+func (unit *testUnit) SendOwnerChangeStatus(status amqp.OwnerChangeStatus) error {
+	unit.statusChannel <- status
+
+	return nil
+}
+
+func (unit *testUnit) SubscribeForConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	unit.eventConsumers = append(unit.eventConsumers, consumer)
+
+	return nil
+}
+
+func (unit *testUnit) UnsubscribeFromConnectionEvents(consumer amqp.ConnectionEventsConsumer) error {
+	return nil
+}
+
+func (unit *testUnit) Restart() {
+	unit.restarted = true
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestUnit(failStep string) *testUnit {
+	return &testUnit{failStep: failStep, statusChannel: make(chan amqp.OwnerChangeStatus, 1)}
+}
+
+func newRequestInfo(requestID string) ownerchange.RequestInfo {
+	return ownerchange.RequestInfo{
+		RequestID:    requestID,
+		ValidUntil:   time.Now().Add(time.Hour).UTC(),
+		UnitSecrets:  &cloudprotocol.UnitSecrets{Version: cloudprotocol.UnitSecretVersion},
+		Certificates: []cloudprotocol.RenewCertData{{NodeID: "node0", Type: "online", Serial: "1234"}},
+	}
+}
+
+func createRequest(t *testing.T, info ownerchange.RequestInfo, authorization string) ownerchange.Request {
+	t.Helper()
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Can't marshal request info: %v", err)
+	}
+
+	return ownerchange.Request{
+		Info:          data,
+		Authorization: cloudprotocol.Signs{ChainName: "chain1", Alg: "RSA/SHA256", Value: []byte(authorization)},
+	}
+}
+
+func (unit *testUnit) performStep(step string) error {
+	unit.steps = append(unit.steps, step)
+
+	if step == unit.failStep {
+		return aoserrors.Errorf("step %s failed", step)
+	}
+
+	return nil
+}
+
+func (unit *testUnit) connect() {
+	for _, consumer := range unit.eventConsumers {
+		consumer.CloudConnected()
+	}
+}
+
+func (unit *testUnit) waitStatus() (amqp.OwnerChangeStatus, error) {
+	select {
+	case status := <-unit.statusChannel:
+		return status, nil
+
+	case <-time.After(1 * time.Second):
+		return amqp.OwnerChangeStatus{}, aoserrors.New("wait owner change status timeout")
+	}
+}
+
+func createTestConfig(t *testing.T, name string) *config.Config {
+	t.Helper()
+
+	workingDir := filepath.Join(tmpDir, name)
+
+	cfg := &config.Config{
+		WorkingDir: workingDir, ImageStoreDir: filepath.Join(workingDir, "imagestore"),
+		OwnerChange: config.OwnerChange{MaxValidity: aostypes.Duration{Duration: 24 * time.Hour}},
+	}
+
+	if err := os.MkdirAll(cfg.ImageStoreDir, 0o755); err != nil {
+		t.Fatalf("Can't create image store dir: %v", err)
+	}
+
+	return cfg
+}
+
+func createOwnerChange(t *testing.T, cfg *config.Config, unit *testUnit) *ownerchange.OwnerChange {
+	t.Helper()
+
+	ownerChange, err := ownerchange.New(
+		cfg, []ownerchange.UpdateStopper{unit}, unit, unit, unit, unit, unit, unit, unit, unit, unit, unit)
+	if err != nil {
+		t.Fatalf("Can't create owner change: %v", err)
+	}
+
+	return ownerChange
+}
+
+func checkReport(t *testing.T, report ownerchange.Report, lastStep, lastStatus string) {
+	t.Helper()
+
+	if len(report.Steps) == 0 {
+		t.Fatal("Empty report steps")
+	}
+
+	step := report.Steps[len(report.Steps)-1]
+
+	if step.Name != lastStep || step.Status != lastStatus {
+		t.Errorf("Wrong last report step: %v", step)
+	}
+
+	for _, step := range report.Steps[:len(report.Steps)-1] {
+		if step.Status != ownerchange.StepStatusDone && step.Status != ownerchange.StepStatusSkipped {
+			t.Errorf("Wrong report step status: %v", step)
+		}
+	}
+}
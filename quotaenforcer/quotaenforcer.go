@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quotaenforcer applies configured policy to instances persistently exceeding their quotas.
+//
+// Instance quota alerts generated by node monitoring are tracked. When an instance exceeds a quota continuously
+// longer than the enforcement window, the configured action is applied: the alert is raised only, the instance is
+// throttled by suspending it for the throttle duration, restarted or stopped till next desired status. Each action is
+// reported to the cloud by quota enforcement alert.
+package quotaenforcer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/resourcemonitor"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// AlertTagQuotaEnforcement quota enforcement alert tag.
+const AlertTagQuotaEnforcement = "quotaEnforcementAlert"
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used in unit tests
+var (
+	// checkPeriod period of checking exceeded quotas.
+	checkPeriod = 10 * time.Second
+	// timeNow returns current time.
+	timeNow = time.Now
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// InstanceController controls service instances.
+type InstanceController interface {
+	RestartInstance(ident aostypes.InstanceIdent) error
+	StopInstance(ident aostypes.InstanceIdent, reason string, duration time.Duration) error
+}
+
+// AlertSender alert sender.
+type AlertSender interface {
+	SendAlert(alert interface{})
+}
+
+// QuotaEnforcementAlert alert raised when action is applied to instance exceeding the quota since the time.
+type QuotaEnforcementAlert struct {
+	cloudprotocol.AlertItem
+	aostypes.InstanceIdent
+	Parameter string    `json:"parameter"`
+	Value     uint64    `json:"value"`
+	Since     time.Time `json:"since"`
+	Action    string    `json:"action"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Enforcer instance quota enforcer.
+type Enforcer struct {
+	sync.Mutex
+
+	config      config.QuotaEnforcement
+	controller  InstanceController
+	alertSender AlertSender
+	exceeded    map[quotaKey]*exceededQuota
+
+	cancelFunc context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+type quotaKey struct {
+	aostypes.InstanceIdent
+	parameter string
+}
+
+type exceededQuota struct {
+	since      time.Time
+	value      uint64
+	lastAction time.Time
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates instance quota enforcer.
+func New(cfg config.QuotaEnforcement, controller InstanceController, alertSender AlertSender) *Enforcer {
+	log.WithFields(log.Fields{
+		"window": cfg.Window, "action": cfg.Action, "actions": cfg.Actions,
+	}).Debug("Create quota enforcer")
+
+	enforcer := &Enforcer{
+		config: cfg, controller: controller, alertSender: alertSender, exceeded: make(map[quotaKey]*exceededQuota),
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	enforcer.cancelFunc = cancelFunc
+
+	enforcer.wg.Add(1)
+
+	go enforcer.handleCheck(ctx)
+
+	return enforcer
+}
+
+// Close closes instance quota enforcer.
+func (enforcer *Enforcer) Close() {
+	enforcer.cancelFunc()
+	enforcer.wg.Wait()
+}
+
+// AlertReceived tracks instance quota alerts.
+func (enforcer *Enforcer) AlertReceived(alert interface{}) {
+	quotaAlert, ok := alert.(cloudprotocol.InstanceQuotaAlert)
+	if !ok {
+		return
+	}
+
+	enforcer.Lock()
+	defer enforcer.Unlock()
+
+	key := quotaKey{InstanceIdent: quotaAlert.InstanceIdent, parameter: quotaAlert.Parameter}
+
+	if quotaAlert.Status == resourcemonitor.AlertStatusFall {
+		delete(enforcer.exceeded, key)
+
+		return
+	}
+
+	quota, ok := enforcer.exceeded[key]
+	if !ok {
+		since := quotaAlert.Timestamp
+		if since.IsZero() {
+			since = timeNow()
+		}
+
+		quota = &exceededQuota{since: since}
+		enforcer.exceeded[key] = quota
+	}
+
+	quota.value = quotaAlert.Value
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (enforcer *Enforcer) handleCheck(ctx context.Context) {
+	defer enforcer.wg.Done()
+
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			enforcer.check()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (enforcer *Enforcer) check() {
+	for _, alert := range enforcer.getDueAlerts() {
+		if err := enforcer.applyAction(alert); err != nil {
+			log.WithFields(log.Fields{
+				"instance": alert.InstanceIdent, "parameter": alert.Parameter, "action": alert.Action,
+			}).Errorf("Can't apply quota enforcement action: %v", err)
+
+			alert.Error = err.Error()
+		}
+
+		if enforcer.alertSender != nil {
+			enforcer.alertSender.SendAlert(alert)
+		}
+	}
+}
+
+// getDueAlerts returns alerts of quotas exceeded longer than window. Alert action is repeated each window while
+// quota is exceeded, other actions restart tracking as the instance is started again.
+func (enforcer *Enforcer) getDueAlerts() (alerts []QuotaEnforcementAlert) {
+	enforcer.Lock()
+	defer enforcer.Unlock()
+
+	now := timeNow()
+
+	for key, quota := range enforcer.exceeded {
+		if now.Sub(quota.since) < enforcer.config.Window.Duration ||
+			(!quota.lastAction.IsZero() && now.Sub(quota.lastAction) < enforcer.config.Window.Duration) {
+			continue
+		}
+
+		action := enforcer.getAction(key.parameter)
+
+		if action == config.QuotaActionAlert {
+			quota.lastAction = now
+		} else {
+			delete(enforcer.exceeded, key)
+		}
+
+		alerts = append(alerts, QuotaEnforcementAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: now, Tag: AlertTagQuotaEnforcement},
+			InstanceIdent: key.InstanceIdent,
+			Parameter:     key.parameter,
+			Value:         quota.value,
+			Since:         quota.since,
+			Action:        action,
+		})
+	}
+
+	return alerts
+}
+
+func (enforcer *Enforcer) getAction(parameter string) string {
+	if action, ok := enforcer.config.Actions[parameter]; ok {
+		return action
+	}
+
+	return enforcer.config.Action
+}
+
+func (enforcer *Enforcer) applyAction(alert QuotaEnforcementAlert) error {
+	log.WithFields(log.Fields{
+		"instance": alert.InstanceIdent, "parameter": alert.Parameter, "value": alert.Value, "since": alert.Since,
+		"action": alert.Action,
+	}).Warn("Instance persistently exceeds quota")
+
+	reason := fmt.Sprintf("%s quota exceeded since %s", alert.Parameter, alert.Since.Format(time.RFC3339))
+
+	switch alert.Action {
+	case config.QuotaActionThrottle:
+		return enforcer.controller.StopInstance(alert.InstanceIdent, reason, enforcer.config.ThrottleDuration.Duration)
+
+	case config.QuotaActionRestart:
+		return enforcer.controller.RestartInstance(alert.InstanceIdent)
+
+	case config.QuotaActionStop:
+		return enforcer.controller.StopInstance(alert.InstanceIdent, reason, 0)
+
+	default:
+		return nil
+	}
+}
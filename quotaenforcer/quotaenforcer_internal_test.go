@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quotaenforcer
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/resourcemonitor"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testAction struct {
+	ident    aostypes.InstanceIdent
+	action   string
+	duration time.Duration
+}
+
+type testInstanceController struct {
+	actions []testAction
+	err     error
+}
+
+type testAlertSender struct {
+	sync.Mutex
+	alerts []QuotaEnforcementAlert
+}
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestEnforcement(t *testing.T) {
+	now := time.Date(2024, time.March, 20, 10, 0, 0, 0, time.UTC)
+
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	controller := &testInstanceController{}
+	alertSender := &testAlertSender{}
+
+	enforcer := New(config.QuotaEnforcement{
+		Window:           aostypes.Duration{Duration: 5 * time.Minute},
+		Action:           config.QuotaActionAlert,
+		Actions:          map[string]string{"cpu": config.QuotaActionThrottle, "ram": config.QuotaActionStop},
+		ThrottleDuration: aostypes.Duration{Duration: time.Minute},
+	}, controller, alertSender)
+	defer enforcer.Close()
+
+	instance1 := aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: 0}
+	instance2 := aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1", Instance: 0}
+
+	sendQuotaAlert := func(ident aostypes.InstanceIdent, parameter, status string) {
+		enforcer.AlertReceived(cloudprotocol.InstanceQuotaAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: now, Tag: cloudprotocol.AlertTagInstanceQuota},
+			InstanceIdent: ident, Parameter: parameter, Value: 100, Status: status,
+		})
+	}
+
+	sendQuotaAlert(instance1, "cpu", resourcemonitor.AlertStatusRaise)
+	sendQuotaAlert(instance1, "download", resourcemonitor.AlertStatusRaise)
+	sendQuotaAlert(instance2, "ram", resourcemonitor.AlertStatusRaise)
+	sendQuotaAlert(instance2, "ram", resourcemonitor.AlertStatusContinue)
+
+	// Quota exceeded shorter than window is ignored
+
+	now = now.Add(time.Minute)
+
+	sendQuotaAlert(instance1, "download", resourcemonitor.AlertStatusFall)
+
+	enforcer.check()
+
+	if len(controller.actions) != 0 || len(alertSender.getAlerts()) != 0 {
+		t.Errorf("Unexpected actions: %v", controller.actions)
+	}
+
+	// Actions are applied after window
+
+	sendQuotaAlert(instance1, "pids", resourcemonitor.AlertStatusRaise)
+
+	now = now.Add(4 * time.Minute)
+
+	enforcer.check()
+
+	expectedActions := map[testAction]bool{
+		{ident: instance1, action: config.QuotaActionThrottle, duration: time.Minute}: true,
+		{ident: instance2, action: config.QuotaActionStop}:                            true,
+	}
+
+	if len(controller.actions) != len(expectedActions) {
+		t.Fatalf("Wrong actions: %v", controller.actions)
+	}
+
+	for _, action := range controller.actions {
+		if !expectedActions[action] {
+			t.Errorf("Unexpected action: %v", action)
+		}
+	}
+
+	if alerts := alertSender.getAlerts(); len(alerts) != 2 || alerts[0].Tag != AlertTagQuotaEnforcement {
+		t.Errorf("Wrong alerts: %v", alerts)
+	}
+
+	// Alert action is repeated each window, failed action is reported
+
+	controller.err = errors.New("node is busy")
+
+	now = now.Add(time.Minute)
+
+	enforcer.check()
+
+	now = now.Add(5 * time.Minute)
+
+	enforcer.check()
+
+	alerts := alertSender.getAlerts()
+	if len(alerts) != 4 {
+		t.Fatalf("Wrong alerts count: %d", len(alerts))
+	}
+
+	for _, alert := range alerts[2:] {
+		if alert.Parameter != "pids" || alert.Action != config.QuotaActionAlert || alert.Error != "" {
+			t.Errorf("Wrong alert: %v", alert)
+		}
+	}
+
+	sendQuotaAlert(instance2, "ram", resourcemonitor.AlertStatusRaise)
+
+	now = now.Add(5 * time.Minute)
+
+	enforcer.check()
+
+	if alerts = alertSender.getAlerts(); len(alerts) != 6 {
+		t.Fatalf("Wrong alerts count: %d", len(alerts))
+	}
+
+	for _, alert := range alerts[4:] {
+		if alert.Parameter == "ram" && alert.Error == "" {
+			t.Error("Action error expected")
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * testInstanceController
+ **********************************************************************************************************************/
+
+func (controller *testInstanceController) RestartInstance(ident aostypes.InstanceIdent) error {
+	if controller.err != nil {
+		return controller.err
+	}
+
+	controller.actions = append(controller.actions, testAction{ident: ident, action: config.QuotaActionRestart})
+
+	return nil
+}
+
+func (controller *testInstanceController) StopInstance(
+	ident aostypes.InstanceIdent, reason string, duration time.Duration,
+) error {
+	if controller.err != nil {
+		return controller.err
+	}
+
+	action := config.QuotaActionStop
+
+	if duration != 0 {
+		action = config.QuotaActionThrottle
+	}
+
+	controller.actions = append(controller.actions, testAction{ident: ident, action: action, duration: duration})
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * testAlertSender
+ **********************************************************************************************************************/
+
+func (sender *testAlertSender) SendAlert(alert interface{}) {
+	sender.Lock()
+	defer sender.Unlock()
+
+	if enforcementAlert, ok := alert.(QuotaEnforcementAlert); ok {
+		sender.alerts = append(sender.alerts, enforcementAlert)
+	}
+}
+
+func (sender *testAlertSender) getAlerts() []QuotaEnforcementAlert {
+	sender.Lock()
+	defer sender.Unlock()
+
+	return sender.alerts
+}
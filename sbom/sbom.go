@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom provides software bill of materials documents of installed services.
+package sbom
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// SBOM formats.
+const (
+	FormatSPDX      = "spdx"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// SBOM media types.
+const (
+	SPDXMediaType      = "application/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Document SBOM document of installed service.
+type Document struct {
+	ServiceID string          `json:"serviceId"`
+	Version   string          `json:"version"`
+	Format    string          `json:"format"`
+	MediaType string          `json:"mediaType"`
+	Digest    string          `json:"digest"`
+	Document  json.RawMessage `json:"document"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetFormat returns SBOM format by document media type. Media type parameters, e.g. version, are ignored.
+func GetFormat(mediaType string) (string, error) {
+	baseType, _, _ := strings.Cut(mediaType, ";")
+
+	switch strings.TrimSpace(baseType) {
+	case SPDXMediaType:
+		return FormatSPDX, nil
+
+	case CycloneDXMediaType:
+		return FormatCycloneDX, nil
+
+	default:
+		return "", aoserrors.Errorf("unsupported SBOM media type: %s", mediaType)
+	}
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom_test
+
+import (
+	"testing"
+
+	"github.com/aosedge/aos_communicationmanager/sbom"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestGetFormat(t *testing.T) {
+	cases := []struct {
+		mediaType string
+		format    string
+		err       bool
+	}{
+		{mediaType: "application/spdx+json", format: sbom.FormatSPDX},
+		{mediaType: "application/vnd.cyclonedx+json", format: sbom.FormatCycloneDX},
+		{mediaType: "application/vnd.cyclonedx+json; version=1.5", format: sbom.FormatCycloneDX},
+		{mediaType: "application/spdx+xml", err: true},
+		{mediaType: "", err: true},
+	}
+
+	for _, tCase := range cases {
+		format, err := sbom.GetFormat(tCase.mediaType)
+		if (err != nil) != tCase.err {
+			t.Errorf("Unexpected error for %s: %v", tCase.mediaType, err)
+		}
+
+		if format != tCase.format {
+			t.Errorf("Wrong format for %s: %s", tCase.mediaType, format)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulator provides SM controller backend with virtual nodes defined in a scenario file.
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/unitconfig"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const statusChanSize = 10
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Node virtual node description.
+type Node struct {
+	cloudprotocol.NodeInfo
+	// ConnectDelay delay before the node sends its initial status.
+	ConnectDelay aostypes.Duration `json:"connectDelay"`
+	// RunDelay delay before the node sends run instances status.
+	RunDelay aostypes.Duration `json:"runDelay"`
+	// FailedServices services which instances fail to start on the node.
+	FailedServices []string `json:"failedServices,omitempty"`
+	// FailureRate probability from 0 to 1 that an instance fails to start on the node.
+	FailureRate float64 `json:"failureRate,omitempty"`
+	// FailNodeConfig node rejects node config.
+	FailNodeConfig bool `json:"failNodeConfig,omitempty"`
+	// CPU and RAM average node usage.
+	CPU uint64 `json:"cpu,omitempty"`
+	RAM uint64 `json:"ram,omitempty"`
+}
+
+// Scenario simulation scenario.
+type Scenario struct {
+	// Seed random generator seed to reproduce failures.
+	Seed  int64  `json:"seed"`
+	Nodes []Node `json:"nodes"`
+}
+
+// Simulator SM controller simulator.
+type Simulator struct {
+	sync.Mutex
+
+	nodes            []*virtualNode
+	random           *rand.Rand
+	cancelFunc       context.CancelFunc
+	ctx              context.Context //nolint:containedctx
+	wg               sync.WaitGroup
+	runStatusChannel chan launcher.NodeRunInstanceStatus
+	updateChannel    chan []cloudprotocol.InstanceStatus
+	logChannel       chan cloudprotocol.PushLog
+	alertChannel     chan cloudprotocol.SystemQuotaAlert
+	nodeConfigChan   chan unitconfig.NodeConfigStatus
+}
+
+type virtualNode struct {
+	Node
+	nodeConfigVersion string
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates simulator from the scenario file.
+func New(scenarioFile string) (simulator *Simulator, err error) {
+	log.WithField("scenario", scenarioFile).Warn("Create SM simulator")
+
+	data, err := os.ReadFile(scenarioFile)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	var scenario Scenario
+
+	if err = json.Unmarshal(data, &scenario); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if len(scenario.Nodes) == 0 {
+		return nil, aoserrors.New("no nodes in scenario")
+	}
+
+	for _, node := range scenario.Nodes {
+		if node.NodeID == "" {
+			return nil, aoserrors.New("node ID is empty")
+		}
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	simulator = &Simulator{
+		random:           rand.New(rand.NewSource(scenario.Seed)), //nolint:gosec // used for failure injection only
+		ctx:              ctx,
+		cancelFunc:       cancelFunc,
+		runStatusChannel: make(chan launcher.NodeRunInstanceStatus, statusChanSize),
+		updateChannel:    make(chan []cloudprotocol.InstanceStatus, statusChanSize),
+		logChannel:       make(chan cloudprotocol.PushLog),
+		alertChannel:     make(chan cloudprotocol.SystemQuotaAlert, statusChanSize),
+		nodeConfigChan:   make(chan unitconfig.NodeConfigStatus, statusChanSize),
+	}
+
+	for _, node := range scenario.Nodes {
+		if node.Status == "" {
+			node.Status = cloudprotocol.NodeStatusProvisioned
+		}
+
+		virtualNode := &virtualNode{Node: node}
+
+		simulator.nodes = append(simulator.nodes, virtualNode)
+
+		simulator.wg.Add(1)
+
+		go simulator.connectNode(virtualNode)
+	}
+
+	return simulator, nil
+}
+
+// Close closes simulator.
+func (simulator *Simulator) Close() error {
+	log.Debug("Close SM simulator")
+
+	simulator.cancelFunc()
+	simulator.wg.Wait()
+
+	return nil
+}
+
+// GetNodeID returns main node ID.
+func (simulator *Simulator) GetNodeID() string {
+	return simulator.nodes[0].NodeID
+}
+
+// GetNodeInfo returns virtual node info.
+func (simulator *Simulator) GetNodeInfo(nodeID string) (cloudprotocol.NodeInfo, error) {
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return cloudprotocol.NodeInfo{}, err
+	}
+
+	return node.NodeInfo, nil
+}
+
+// GetAllNodeIDs returns all virtual node IDs.
+func (simulator *Simulator) GetAllNodeIDs() (nodeIDs []string, err error) {
+	for _, node := range simulator.nodes {
+		nodeIDs = append(nodeIDs, node.NodeID)
+	}
+
+	return nodeIDs, nil
+}
+
+// GetNodeConfigStatus returns node config status.
+func (simulator *Simulator) GetNodeConfigStatus(nodeID string) (unitconfig.NodeConfigStatus, error) {
+	simulator.Lock()
+	defer simulator.Unlock()
+
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return unitconfig.NodeConfigStatus{}, err
+	}
+
+	return node.getNodeConfigStatus(), nil
+}
+
+// CheckNodeConfig checks node config.
+func (simulator *Simulator) CheckNodeConfig(nodeID, version string, nodeConfig cloudprotocol.NodeConfig) error {
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return err
+	}
+
+	if node.FailNodeConfig {
+		return aoserrors.Errorf("node %s rejects node config", nodeID)
+	}
+
+	return nil
+}
+
+// SetNodeConfig sets node config.
+func (simulator *Simulator) SetNodeConfig(nodeID, version string, nodeConfig cloudprotocol.NodeConfig) error {
+	if err := simulator.CheckNodeConfig(nodeID, version, nodeConfig); err != nil {
+		return err
+	}
+
+	simulator.Lock()
+	defer simulator.Unlock()
+
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return err
+	}
+
+	node.nodeConfigVersion = version
+
+	return nil
+}
+
+// GetNodeConfigStatuses returns node config statuses of all nodes.
+func (simulator *Simulator) GetNodeConfigStatuses() (statuses []unitconfig.NodeConfigStatus, err error) {
+	simulator.Lock()
+	defer simulator.Unlock()
+
+	for _, node := range simulator.nodes {
+		statuses = append(statuses, node.getNodeConfigStatus())
+	}
+
+	return statuses, nil
+}
+
+// NodeConfigStatusChannel returns channel with node config statuses.
+func (simulator *Simulator) NodeConfigStatusChannel() <-chan unitconfig.NodeConfigStatus {
+	return simulator.nodeConfigChan
+}
+
+// RunInstances simulates running instances on the node.
+func (simulator *Simulator) RunInstances(nodeID string,
+	services []aostypes.ServiceInfo, layers []aostypes.LayerInfo, instances []aostypes.InstanceInfo, forceRestart bool,
+) error {
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"nodeID": nodeID, "instances": len(instances)}).Debug("Simulate run instances")
+
+	serviceVersions := make(map[string]string)
+
+	for _, service := range services {
+		serviceVersions[service.ServiceID] = service.Version
+	}
+
+	runStatus := launcher.NodeRunInstanceStatus{
+		NodeID: nodeID, NodeType: node.NodeType, Instances: make([]cloudprotocol.InstanceStatus, 0, len(instances)),
+	}
+
+	simulator.Lock()
+
+	for _, instance := range instances {
+		instanceStatus := cloudprotocol.InstanceStatus{
+			InstanceIdent:  instance.InstanceIdent,
+			ServiceVersion: serviceVersions[instance.ServiceID],
+			NodeID:         nodeID,
+			Status:         cloudprotocol.InstanceStateActive,
+		}
+
+		if slices.Contains(node.FailedServices, instance.ServiceID) || simulator.random.Float64() < node.FailureRate {
+			instanceStatus.Status = cloudprotocol.InstanceStateFailed
+			instanceStatus.ErrorInfo = &cloudprotocol.ErrorInfo{Message: "simulated instance failure"}
+		}
+
+		runStatus.Instances = append(runStatus.Instances, instanceStatus)
+	}
+
+	simulator.Unlock()
+
+	simulator.wg.Add(1)
+
+	go func() {
+		defer simulator.wg.Done()
+
+		simulator.sendRunStatus(node.RunDelay.Duration, runStatus)
+	}()
+
+	return nil
+}
+
+// UpdateNetwork simulates updating node networks configuration.
+func (simulator *Simulator) UpdateNetwork(nodeID string, networkParameters []aostypes.NetworkParameters) error {
+	_, err := simulator.getNode(nodeID)
+
+	return err
+}
+
+// OverrideEnvVars simulates overriding instance env vars.
+func (simulator *Simulator) OverrideEnvVars(envVars cloudprotocol.OverrideEnvVars) error {
+	return nil
+}
+
+// GetLog returns error as logs are not available in simulation.
+func (simulator *Simulator) GetLog(logRequest cloudprotocol.RequestLog) error {
+	return aoserrors.New("logs are not available in simulation")
+}
+
+// GetAverageMonitoring returns node usage defined in the scenario.
+func (simulator *Simulator) GetAverageMonitoring(nodeID string) (aostypes.NodeMonitoring, error) {
+	node, err := simulator.getNode(nodeID)
+	if err != nil {
+		return aostypes.NodeMonitoring{}, err
+	}
+
+	return aostypes.NodeMonitoring{
+		NodeID:   nodeID,
+		NodeData: aostypes.MonitoringData{Timestamp: time.Now(), CPU: node.CPU, RAM: node.RAM},
+	}, nil
+}
+
+// GetLogChannel returns log parts channel, no logs are provided in simulation.
+func (simulator *Simulator) GetLogChannel() <-chan cloudprotocol.PushLog {
+	return simulator.logChannel
+}
+
+// GetUpdateInstancesStatusChannel returns channel with update instances status.
+func (simulator *Simulator) GetUpdateInstancesStatusChannel() <-chan []cloudprotocol.InstanceStatus {
+	return simulator.updateChannel
+}
+
+// GetRunInstancesStatusChannel returns channel with run instances status.
+func (simulator *Simulator) GetRunInstancesStatusChannel() <-chan launcher.NodeRunInstanceStatus {
+	return simulator.runStatusChannel
+}
+
+// GetSystemQuoteAlertChannel returns channel with system quota alerts.
+func (simulator *Simulator) GetSystemQuoteAlertChannel() <-chan cloudprotocol.SystemQuotaAlert {
+	return simulator.alertChannel
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (simulator *Simulator) getNode(nodeID string) (*virtualNode, error) {
+	for _, node := range simulator.nodes {
+		if node.NodeID == nodeID {
+			return node, nil
+		}
+	}
+
+	return nil, aoserrors.Errorf("node %s not found", nodeID)
+}
+
+func (simulator *Simulator) connectNode(node *virtualNode) {
+	defer simulator.wg.Done()
+
+	select {
+	case <-time.After(node.ConnectDelay.Duration):
+
+	case <-simulator.ctx.Done():
+		return
+	}
+
+	log.WithField("nodeID", node.NodeID).Debug("Virtual node connected")
+
+	simulator.Lock()
+	nodeConfigStatus := node.getNodeConfigStatus()
+	simulator.Unlock()
+
+	select {
+	case simulator.nodeConfigChan <- nodeConfigStatus:
+
+	case <-simulator.ctx.Done():
+		return
+	}
+
+	simulator.sendRunStatus(0, launcher.NodeRunInstanceStatus{
+		NodeID: node.NodeID, NodeType: node.NodeType, Instances: []cloudprotocol.InstanceStatus{},
+	})
+}
+
+func (simulator *Simulator) sendRunStatus(delay time.Duration, runStatus launcher.NodeRunInstanceStatus) {
+	select {
+	case <-time.After(delay):
+
+	case <-simulator.ctx.Done():
+		return
+	}
+
+	select {
+	case simulator.runStatusChannel <- runStatus:
+
+	case <-simulator.ctx.Done():
+	}
+}
+
+func (node *virtualNode) getNodeConfigStatus() unitconfig.NodeConfigStatus {
+	return unitconfig.NodeConfigStatus{
+		NodeID: node.NodeID, NodeType: node.NodeType, Version: node.nodeConfigVersion,
+	}
+}
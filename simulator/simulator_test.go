@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/simulator"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const testScenario = `{
+	"nodes": [
+		{
+			"id": "main",
+			"type": "mainType",
+			"maxDmips": 10000,
+			"totalRam": 8192,
+			"attrs": {"MainNode": "", "NodeRunners": "crun"},
+			"cpu": 1000,
+			"ram": 1024
+		},
+		{
+			"id": "secondary",
+			"type": "secondaryType",
+			"connectDelay": "100ms",
+			"runDelay": "200ms",
+			"failedServices": ["service2"],
+			"failNodeConfig": true
+		}
+	]
+}`
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestNodes(t *testing.T) {
+	smSimulator := newTestSimulator(t)
+	defer smSimulator.Close()
+
+	if nodeID := smSimulator.GetNodeID(); nodeID != "main" {
+		t.Errorf("Wrong main node ID: %s", nodeID)
+	}
+
+	nodeIDs, err := smSimulator.GetAllNodeIDs()
+	if err != nil {
+		t.Fatalf("Can't get node IDs: %v", err)
+	}
+
+	if !slices.Equal(nodeIDs, []string{"main", "secondary"}) {
+		t.Errorf("Wrong node IDs: %v", nodeIDs)
+	}
+
+	nodeInfo, err := smSimulator.GetNodeInfo("main")
+	if err != nil {
+		t.Fatalf("Can't get node info: %v", err)
+	}
+
+	if nodeInfo.NodeType != "mainType" || nodeInfo.TotalRAM != 8192 ||
+		nodeInfo.Status != cloudprotocol.NodeStatusProvisioned {
+		t.Errorf("Wrong node info: %v", nodeInfo)
+	}
+
+	if _, err = smSimulator.GetNodeInfo("unknown"); err == nil {
+		t.Error("Error expected for unknown node")
+	}
+
+	monitoring, err := smSimulator.GetAverageMonitoring("main")
+	if err != nil {
+		t.Fatalf("Can't get average monitoring: %v", err)
+	}
+
+	if monitoring.NodeData.CPU != 1000 || monitoring.NodeData.RAM != 1024 {
+		t.Errorf("Wrong average monitoring: %v", monitoring.NodeData)
+	}
+
+	// Initial statuses
+
+	for _, nodeID := range []string{"main", "secondary"} {
+		runStatus := waitRunStatus(t, smSimulator)
+
+		if runStatus.NodeID != nodeID || len(runStatus.Instances) != 0 {
+			t.Errorf("Wrong initial run status: %v", runStatus)
+		}
+	}
+
+	// Node config
+
+	if err = smSimulator.SetNodeConfig("main", "1.0.0", cloudprotocol.NodeConfig{}); err != nil {
+		t.Errorf("Can't set node config: %v", err)
+	}
+
+	if err = smSimulator.CheckNodeConfig("secondary", "1.0.0", cloudprotocol.NodeConfig{}); err == nil {
+		t.Error("Error expected for node rejecting node config")
+	}
+
+	statuses, err := smSimulator.GetNodeConfigStatuses()
+	if err != nil {
+		t.Fatalf("Can't get node config statuses: %v", err)
+	}
+
+	if len(statuses) != 2 || statuses[0].Version != "1.0.0" || statuses[1].Version != "" {
+		t.Errorf("Wrong node config statuses: %v", statuses)
+	}
+}
+
+func TestRunInstances(t *testing.T) {
+	smSimulator := newTestSimulator(t)
+	defer smSimulator.Close()
+
+	waitRunStatus(t, smSimulator)
+	waitRunStatus(t, smSimulator)
+
+	services := []aostypes.ServiceInfo{
+		{ServiceID: "service1", Version: "1.0.0"},
+		{ServiceID: "service2", Version: "2.0.0"},
+	}
+
+	instances := []aostypes.InstanceInfo{
+		{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}},
+		{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"}},
+	}
+
+	if err := smSimulator.RunInstances("secondary", services, nil, instances, false); err != nil {
+		t.Fatalf("Can't run instances: %v", err)
+	}
+
+	startTime := time.Now()
+	runStatus := waitRunStatus(t, smSimulator)
+
+	if time.Since(startTime) < 200*time.Millisecond {
+		t.Error("Run status should be delayed")
+	}
+
+	if runStatus.NodeID != "secondary" || len(runStatus.Instances) != 2 {
+		t.Fatalf("Wrong run status: %v", runStatus)
+	}
+
+	if runStatus.Instances[0].Status != cloudprotocol.InstanceStateActive ||
+		runStatus.Instances[0].ServiceVersion != "1.0.0" {
+		t.Errorf("Wrong instance status: %v", runStatus.Instances[0])
+	}
+
+	if runStatus.Instances[1].Status != cloudprotocol.InstanceStateFailed ||
+		runStatus.Instances[1].ErrorInfo == nil {
+		t.Errorf("Wrong instance status: %v", runStatus.Instances[1])
+	}
+
+	if err := smSimulator.RunInstances("unknown", services, nil, instances, false); err == nil {
+		t.Error("Error expected for unknown node")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestSimulator(t *testing.T) *simulator.Simulator {
+	t.Helper()
+
+	scenarioFile := filepath.Join(t.TempDir(), "scenario.json")
+
+	if err := os.WriteFile(scenarioFile, []byte(testScenario), 0o600); err != nil {
+		t.Fatalf("Can't write scenario: %v", err)
+	}
+
+	smSimulator, err := simulator.New(scenarioFile)
+	if err != nil {
+		t.Fatalf("Can't create simulator: %v", err)
+	}
+
+	return smSimulator
+}
+
+func waitRunStatus(t *testing.T, smSimulator *simulator.Simulator) (runStatus launcher.NodeRunInstanceStatus) {
+	t.Helper()
+
+	select {
+	case runStatus = <-smSimulator.GetRunInstancesStatusChannel():
+		return runStatus
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait run status timeout")
+	}
+
+	return runStatus
+}
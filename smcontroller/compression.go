@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smcontroller
+
+import (
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc"
+
+	// Standard gRPC gzip compressor is registered. It is enabled only on SM streams by negotiateCompression.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// negotiateCompression enables compression of messages sent to SM if it is configured and accepted by SM. The
+// compressor is set per stream, other gRPC connections are not affected.
+func negotiateCompression(stream grpc.ServerStream, nodeID, compression string) {
+	if compression == "" {
+		return
+	}
+
+	supported, err := grpc.ClientSupportedCompressors(stream.Context())
+	if err != nil {
+		log.WithField("nodeID", nodeID).Errorf("Can't get SM supported compressors: %v", err)
+
+		return
+	}
+
+	if !slices.Contains(supported, compression) {
+		log.WithFields(log.Fields{
+			"nodeID": nodeID, "compression": compression, "supported": supported,
+		}).Warn("SM doesn't accept compression, send uncompressed messages")
+
+		return
+	}
+
+	if err = grpc.SetSendCompressor(stream.Context(), compression); err != nil {
+		log.WithField("nodeID", nodeID).Errorf("Can't set SM compressor: %v", err)
+
+		return
+	}
+
+	log.WithFields(log.Fields{"nodeID": nodeID, "compression": compression}).Debug("SM compression enabled")
+}
@@ -20,6 +20,7 @@ package smcontroller
 import (
 	"errors"
 	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
 )
 
@@ -49,6 +51,11 @@ const statusChanSize = 10
 
 const smRestartInterval = 10 * time.Second
 
+const healthCheckDialTimeout = 5 * time.Second
+
+// recoverMinFailures number of consecutive recover attempts which failed to reach SM server to restart it.
+const recoverMinFailures = 2
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -73,11 +80,13 @@ type Controller struct {
 	runInstancesStatusChan    chan launcher.NodeRunInstanceStatus
 	systemQuotaAlertChan      chan cloudprotocol.SystemQuotaAlert
 	nodeConfigStatusChan      chan unitconfig.NodeConfigStatus
+	logChan                   chan cloudprotocol.PushLog
 	closeChannel              chan struct{}
 	restartTimer              *time.Timer
 
 	isCloudConnected bool
 	grpcServer       *grpchelpers.GRPCServer
+	recoverFailures  int
 	pb.UnimplementedSMServiceServer
 }
 
@@ -96,7 +105,6 @@ type MessageSender interface {
 	SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
 	UnsubscribeFromConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
 	SendOverrideEnvVarsStatus(envs cloudprotocol.OverrideEnvVarsStatus) error
-	SendLog(serviceLog cloudprotocol.PushLog) error
 }
 
 // CertificateProvider certificate and key provider interface.
@@ -130,6 +138,7 @@ func New(
 		updateInstancesStatusChan: make(chan []cloudprotocol.InstanceStatus, statusChanSize),
 		systemQuotaAlertChan:      make(chan cloudprotocol.SystemQuotaAlert, statusChanSize),
 		nodeConfigStatusChan:      make(chan unitconfig.NodeConfigStatus, statusChanSize),
+		logChan:                   make(chan cloudprotocol.PushLog, statusChanSize),
 		nodes:                     make(map[string]*smHandler),
 		closeChannel:              make(chan struct{}, 1),
 		grpcServer:                grpchelpers.NewGRPCServer(cfg.SMController.CMServerURL),
@@ -268,7 +277,19 @@ func (controller *Controller) UpdateNetwork(nodeID string, networkParameters []a
 		return err
 	}
 
-	return handler.updateNetworks(networkParameters)
+	return handler.updateNetworks(networkParameters, nil)
+}
+
+// UpdateNetworkMirrors updates node networks configuration with traffic mirrors of instances of these networks.
+func (controller *Controller) UpdateNetworkMirrors(
+	nodeID string, networkParameters []aostypes.NetworkParameters, mirrors []networkmanager.TrafficMirror,
+) error {
+	handler, err := controller.getNodeHandlerByID(nodeID)
+	if err != nil {
+		return err
+	}
+
+	return handler.updateNetworks(networkParameters, mirrors)
 }
 
 // OverrideEnvVars overrides instance env vars.
@@ -321,6 +342,11 @@ func (controller *Controller) GetSystemQuoteAlertChannel() <-chan cloudprotocol.
 	return controller.systemQuotaAlertChan
 }
 
+// GetLogChannel returns channel with log parts received from SMs.
+func (controller *Controller) GetLogChannel() <-chan cloudprotocol.PushLog {
+	return controller.logChan
+}
+
 // RegisterSM registers new SM client connection.
 func (controller *Controller) RegisterSM(stream pb.SMService_RegisterSMServer) error {
 	var handler *smHandler
@@ -359,13 +385,17 @@ func (controller *Controller) RegisterSM(stream pb.SMService_RegisterSMServer) e
 
 			handler, err = newSMHandler(nodeID, nodeType, stream, controller.messageSender, controller.alertSender,
 				controller.monitoringSender, controller.runInstancesStatusChan, controller.updateInstancesStatusChan,
-				controller.systemQuotaAlertChan)
+				controller.systemQuotaAlertChan, controller.logChan)
 			if err != nil {
 				log.Errorf("Can't crate SM handler: %v", err)
 
 				return err
 			}
 
+			handler.cniExport = controller.config.Network.CNIExport
+
+			negotiateCompression(stream, nodeID, controller.config.SMController.Compression)
+
 			if err := controller.handleNewConnection(
 				nodeConfigStatusFromPB(nodeConfigStatus.NodeConfigStatus), handler); err != nil {
 				log.Errorf("Can't register new SM connection: %v", err)
@@ -380,6 +410,55 @@ func (controller *Controller) RegisterSM(stream pb.SMService_RegisterSMServer) e
 	}
 }
 
+// IsNodeConnected returns true if SM of the node is connected.
+func (controller *Controller) IsNodeConnected(nodeID string) bool {
+	controller.Lock()
+	defer controller.Unlock()
+
+	_, ok := controller.nodes[nodeID]
+
+	return ok
+}
+
+// CheckHealth checks that SM controller is not blocked and SM server accepts connections.
+func (controller *Controller) CheckHealth() error {
+	return controller.checkServer()
+}
+
+// Recover restarts SM server. As failed health check may be caused by temporary overload, the server is restarted only
+// if it is not reachable on several recover attempts in a row.
+func (controller *Controller) Recover() error {
+	err := controller.checkServer()
+
+	controller.Lock()
+
+	if err == nil {
+		controller.recoverFailures = 0
+		controller.Unlock()
+
+		log.Debug("SM server is reachable, skip restart")
+
+		return nil
+	}
+
+	controller.recoverFailures++
+
+	if controller.recoverFailures < recoverMinFailures {
+		controller.Unlock()
+
+		log.Warnf("SM server is not reachable: %v", err)
+
+		return nil
+	}
+
+	controller.recoverFailures = 0
+	controller.Unlock()
+
+	controller.restartGRPCServer()
+
+	return nil
+}
+
 /***********************************************************************************************************************
  * Interface
  **********************************************************************************************************************/
@@ -408,6 +487,21 @@ func (controller *Controller) CloudDisconnected() {
  * Private
  **********************************************************************************************************************/
 
+func (controller *Controller) checkServer() error {
+	controller.Lock()
+	serverURL := controller.config.SMController.CMServerURL
+	controller.Unlock()
+
+	conn, err := net.DialTimeout("tcp", serverURL, healthCheckDialTimeout)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	conn.Close()
+
+	return nil
+}
+
 func (controller *Controller) getSystemLog(logRequest cloudprotocol.RequestLog) error {
 	handlers, err := controller.getNodeHandlersByIDs(logRequest.Filter.NodeIDs)
 	if err != nil {
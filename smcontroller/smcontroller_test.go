@@ -19,9 +19,11 @@ package smcontroller_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -38,13 +40,16 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
 	"github.com/aosedge/aos_communicationmanager/smcontroller"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
 )
@@ -82,6 +87,11 @@ type testMonitoringSender struct {
 	messageChannel chan aostypes.NodeMonitoring
 }
 
+type testStatsHandler struct {
+	sync.Mutex
+	compression string
+}
+
 /***********************************************************************************************************************
  * Init
  **********************************************************************************************************************/
@@ -834,7 +844,7 @@ func TestLogMessages(t *testing.T) {
 		},
 	}
 
-	if err := waitMessage(messageSender.messageChannel, expectedLog, messageTimeout); err != nil {
+	if err := waitMessage(controller.GetLogChannel(), expectedLog, messageTimeout); err != nil {
 		t.Errorf("Incorrect log message: %v", err)
 	}
 }
@@ -1007,6 +1017,154 @@ func TestRunInstances(t *testing.T) {
 	}
 }
 
+func TestRunInstancesCNIExport(t *testing.T) {
+	var (
+		nodeID        = "mainSM"
+		nodeType      = "mainSMType"
+		messageSender = newTestMessageSender()
+		config        = config.Config{
+			SMController: config.SMController{CMServerURL: cmServerURL},
+			Network:      config.Network{CNIExport: true},
+		}
+		sendInstances = []aostypes.InstanceInfo{{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "s1", SubjectID: "subj1", Instance: 1},
+			NetworkParameters: aostypes.NetworkParameters{
+				NetworkID: "network1", IP: "172.17.0.3", Subnet: "172.17.0.0/16", VlanID: 1,
+				DNSServers: []string{"10.0.0.1"},
+			},
+		}}
+	)
+
+	controller, err := smcontroller.New(&config, messageSender, nil, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM controller: %v", err)
+	}
+	defer controller.Close()
+
+	smClient, err := newTestSMClient(cmServerURL, unitconfig.NodeConfigStatus{
+		NodeID: nodeID, NodeType: nodeType,
+	}, &pbsm.RunInstancesStatus{})
+	if err != nil {
+		t.Fatalf("Can't create test SM: %v", err)
+	}
+
+	defer smClient.close()
+
+	if err := smClient.waitInitMessages(false, messageTimeout); err != nil {
+		t.Fatalf("Can't wait init messages: %v", err)
+	}
+
+	if err := waitMessage(controller.GetRunInstancesStatusChannel(), launcher.NodeRunInstanceStatus{
+		NodeID: nodeID, NodeType: nodeType, Instances: make([]cloudprotocol.InstanceStatus, 0),
+	}, messageTimeout); err != nil {
+		t.Fatalf("Wait message error: %v", err)
+	}
+
+	if err := controller.RunInstances(nodeID, nil, nil, sendInstances, false); err != nil {
+		t.Fatalf("Can't send run instances: %v", err)
+	}
+
+	select {
+	case <-time.After(messageTimeout):
+		t.Fatal("Wait message timeout")
+
+	case message := <-smClient.receivedMessagesChannel:
+		runInstances, ok := message.GetSMIncomingMessage().(*pbsm.SMIncomingMessages_RunInstances)
+		if !ok || len(runInstances.RunInstances.GetInstances()) != 1 {
+			t.Fatalf("Incorrect message: %v", message)
+		}
+
+		rawField := runInstances.RunInstances.GetInstances()[0].ProtoReflect().GetUnknown()
+
+		number, fieldType, n := protowire.ConsumeTag(rawField)
+		if n < 0 || number != smcontroller.CNIConfigFieldNumber || fieldType != protowire.BytesType {
+			t.Fatalf("CNI config field expected: %v", rawField)
+		}
+
+		cniConfig, n := protowire.ConsumeBytes(rawField[n:])
+		if n < 0 {
+			t.Fatalf("Can't consume CNI config: %v", protowire.ParseError(n))
+		}
+
+		expectedConfig, err := networkmanager.GetCNIConfig(
+			sendInstances[0].InstanceIdent, sendInstances[0].NetworkParameters)
+		if err != nil {
+			t.Fatalf("Can't get CNI config: %v", err)
+		}
+
+		if string(cniConfig) != string(expectedConfig) {
+			t.Errorf("Wrong CNI config: %s", cniConfig)
+		}
+	}
+}
+
+func TestRunInstancesCompression(t *testing.T) {
+	var (
+		nodeID        = "mainSM"
+		nodeType      = "mainSMType"
+		messageSender = newTestMessageSender()
+		statsHandler  = &testStatsHandler{}
+		config        = config.Config{
+			SMController: config.SMController{CMServerURL: cmServerURL, Compression: config.SMCompressionGzip},
+		}
+		sendInstances []aostypes.InstanceInfo
+	)
+
+	for i := range 100 {
+		sendInstances = append(sendInstances, aostypes.InstanceInfo{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "s1", SubjectID: "subj1", Instance: uint64(i)},
+			UID:           5000 + uint32(i), StoragePath: fmt.Sprintf("/var/aos/storages/s1_subj1_%d", i),
+		})
+	}
+
+	controller, err := smcontroller.New(&config, messageSender, nil, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM controller: %v", err)
+	}
+	defer controller.Close()
+
+	smClient, err := newTestSMClient(cmServerURL, unitconfig.NodeConfigStatus{
+		NodeID: nodeID, NodeType: nodeType,
+	}, &pbsm.RunInstancesStatus{}, grpc.WithStatsHandler(statsHandler))
+	if err != nil {
+		t.Fatalf("Can't create test SM: %v", err)
+	}
+
+	defer smClient.close()
+
+	if err := smClient.waitInitMessages(false, messageTimeout); err != nil {
+		t.Fatalf("Can't wait init messages: %v", err)
+	}
+
+	if err := waitMessage(controller.GetRunInstancesStatusChannel(), launcher.NodeRunInstanceStatus{
+		NodeID: nodeID, NodeType: nodeType, Instances: make([]cloudprotocol.InstanceStatus, 0),
+	}, messageTimeout); err != nil {
+		t.Fatalf("Wait message error: %v", err)
+	}
+
+	if err := controller.RunInstances(nodeID, nil, nil, sendInstances, false); err != nil {
+		t.Fatalf("Can't send run instances: %v", err)
+	}
+
+	select {
+	case <-time.After(messageTimeout):
+		t.Fatal("Wait message timeout")
+
+	case message := <-smClient.receivedMessagesChannel:
+		runInstances, ok := message.GetSMIncomingMessage().(*pbsm.SMIncomingMessages_RunInstances)
+		if !ok || len(runInstances.RunInstances.GetInstances()) != len(sendInstances) {
+			t.Fatalf("Incorrect message: %v", message)
+		}
+	}
+
+	statsHandler.Lock()
+	defer statsHandler.Unlock()
+
+	if statsHandler.compression != config.SMController.Compression {
+		t.Errorf("Wrong SM channel compression: %s", statsHandler.compression)
+	}
+}
+
 func TestUpdateNetwork(t *testing.T) {
 	var (
 		nodeID        = "mainSM"
@@ -1077,6 +1235,88 @@ func TestUpdateNetwork(t *testing.T) {
 	}
 }
 
+func TestUpdateNetworkMirrors(t *testing.T) {
+	var (
+		nodeID        = "mainSM"
+		nodeType      = "mainSMType"
+		messageSender = newTestMessageSender()
+		config        = config.Config{SMController: config.SMController{CMServerURL: cmServerURL}}
+	)
+
+	networkParameters := []aostypes.NetworkParameters{
+		{Subnet: "172.17.0.0/16", IP: "172.17.0.1", VlanID: 1, NetworkID: "network1"},
+	}
+
+	mirror := networkmanager.TrafficMirror{
+		ID:          "mirror1",
+		Source:      aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"},
+		Collector:   aostypes.InstanceIdent{ServiceID: "collector", SubjectID: "subject1"},
+		NetworkID:   "network1",
+		SourceIP:    "172.17.0.2",
+		CollectorIP: "172.17.0.3",
+		Port:        "8080",
+		Protocol:    "tcp",
+		ExpiresAt:   time.Now().UTC().Truncate(time.Second).Add(time.Minute),
+	}
+
+	controller, err := smcontroller.New(&config, messageSender, nil, nil, nil, nil, true)
+	if err != nil {
+		t.Fatalf("Can't create SM controller: %v", err)
+	}
+	defer controller.Close()
+
+	smClient, err := newTestSMClient(cmServerURL, unitconfig.NodeConfigStatus{
+		NodeID: nodeID, NodeType: nodeType,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Can't create test SM: %v", err)
+	}
+
+	defer smClient.close()
+
+	if err := smClient.waitInitMessages(false, messageTimeout); err != nil {
+		t.Fatalf("Can't wait init messages: %v", err)
+	}
+
+	if err := controller.UpdateNetworkMirrors(
+		nodeID, networkParameters, []networkmanager.TrafficMirror{mirror}); err != nil {
+		t.Fatalf("Can't update network mirrors: %v", err)
+	}
+
+	select {
+	case <-time.After(messageTimeout):
+		t.Fatal("Wait message timeout")
+
+	case message := <-smClient.receivedMessagesChannel:
+		updateNetworks, ok := message.GetSMIncomingMessage().(*pbsm.SMIncomingMessages_UpdateNetworks)
+		if !ok || len(updateNetworks.UpdateNetworks.GetNetworks()) != 1 {
+			t.Fatalf("Incorrect message: %v", message)
+		}
+
+		rawField := updateNetworks.UpdateNetworks.ProtoReflect().GetUnknown()
+
+		number, fieldType, n := protowire.ConsumeTag(rawField)
+		if n < 0 || number != smcontroller.TrafficMirrorsFieldNumber || fieldType != protowire.BytesType {
+			t.Fatalf("Traffic mirrors field expected: %v", rawField)
+		}
+
+		rawMirror, n := protowire.ConsumeBytes(rawField[n:])
+		if n < 0 {
+			t.Fatalf("Can't consume traffic mirror: %v", protowire.ParseError(n))
+		}
+
+		var receivedMirror networkmanager.TrafficMirror
+
+		if err := json.Unmarshal(rawMirror, &receivedMirror); err != nil {
+			t.Fatalf("Can't unmarshal traffic mirror: %v", err)
+		}
+
+		if !reflect.DeepEqual(receivedMirror, mirror) {
+			t.Errorf("Wrong traffic mirror: %v", receivedMirror)
+		}
+	}
+}
+
 func TestSyncClock(t *testing.T) {
 	var (
 		nodeID        = "mainSM"
@@ -1261,6 +1501,14 @@ func TestConnectionStatus(t *testing.T) {
 		t.Fatalf("Can't wait init messages: %v", err)
 	}
 
+	if !controller.IsNodeConnected(nodeID) {
+		t.Error("Node should be connected")
+	}
+
+	if controller.IsNodeConnected("unknown") {
+		t.Error("Unknown node should not be connected")
+	}
+
 	// check receive correct connection status when cloud disconnected
 
 	controller.CloudDisconnected()
@@ -1312,12 +1560,6 @@ func (sender *testMessageSender) SendOverrideEnvVarsStatus(envStatus cloudprotoc
 	return nil
 }
 
-func (sender *testMessageSender) SendLog(serviceLog cloudprotocol.PushLog) error {
-	sender.messageChannel <- serviceLog
-
-	return nil
-}
-
 func (sender *testMessageSender) SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error {
 	return nil
 }
@@ -1326,6 +1568,25 @@ func (sender *testMessageSender) UnsubscribeFromConnectionEvents(consumer amqpha
 	return nil
 }
 
+func (handler *testStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (handler *testStatsHandler) HandleRPC(ctx context.Context, rpcStats stats.RPCStats) {
+	if header, ok := rpcStats.(*stats.InHeader); ok {
+		handler.Lock()
+		handler.compression = header.Compression
+		handler.Unlock()
+	}
+}
+
+func (handler *testStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (handler *testStatsHandler) HandleConn(ctx context.Context, connStats stats.ConnStats) {
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
@@ -1366,6 +1627,7 @@ func waitMessage[T any](messageChannel <-chan T, expectedMsg interface{}, timeou
 
 func newTestSMClient(
 	url string, nodeConfigStatus unitconfig.NodeConfigStatus, runStatus *pbsm.RunInstancesStatus,
+	options ...grpc.DialOption,
 ) (client *testSMClient, err error) {
 	client = &testSMClient{
 		sendMessageChannel:      make(chan *pbsm.SMOutgoingMessages, 10),
@@ -1373,7 +1635,7 @@ func newTestSMClient(
 	}
 
 	if client.connection, err = grpc.NewClient(
-		url, grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+		url, append(options, grpc.WithTransportCredentials(insecure.NewCredentials()))...); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
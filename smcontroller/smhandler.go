@@ -32,9 +32,12 @@ import (
 	"github.com/aosedge/aos_common/utils/pbconvert"
 	"github.com/aosedge/aos_common/utils/syncstream"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/aosedge/aos_communicationmanager/faultinjection"
 	"github.com/aosedge/aos_communicationmanager/launcher"
+	"github.com/aosedge/aos_communicationmanager/networkmanager"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
 )
 
@@ -44,6 +47,14 @@ import (
 
 const waitMessageTimeout = 5 * time.Second
 
+// CNIConfigFieldNumber field number of instance info message which contains CNI configuration of instance network.
+// The field is unknown to SM which doesn't consume CNI and is ignored by it.
+const CNIConfigFieldNumber protowire.Number = 7
+
+// TrafficMirrorsFieldNumber repeated field number of update networks message which contains JSON encoded traffic
+// mirrors of node networks. The field is ignored by SM which doesn't support traffic mirroring.
+const TrafficMirrorsFieldNumber protowire.Number = 15
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
@@ -60,6 +71,8 @@ type smHandler struct {
 	runStatusCh            chan<- launcher.NodeRunInstanceStatus
 	updateInstanceStatusCh chan<- []cloudprotocol.InstanceStatus
 	systemQuotasAlertCh    chan<- cloudprotocol.SystemQuotaAlert
+	logCh                  chan<- cloudprotocol.PushLog
+	cniExport              bool
 }
 
 /***********************************************************************************************************************
@@ -71,7 +84,7 @@ func newSMHandler(
 	stream pb.SMService_RegisterSMServer, messageSender MessageSender, alertSender AlertSender,
 	monitoringSender MonitoringSender, runStatusCh chan<- launcher.NodeRunInstanceStatus,
 	updateInstanceStatusCh chan<- []cloudprotocol.InstanceStatus,
-	systemQuotasAlertCh chan<- cloudprotocol.SystemQuotaAlert,
+	systemQuotasAlertCh chan<- cloudprotocol.SystemQuotaAlert, logCh chan<- cloudprotocol.PushLog,
 ) (*smHandler, error) {
 	handler := smHandler{
 		nodeID:                 nodeID,
@@ -84,6 +97,7 @@ func newSMHandler(
 		runStatusCh:            runStatusCh,
 		updateInstanceStatusCh: updateInstanceStatusCh,
 		systemQuotasAlertCh:    systemQuotasAlertCh,
+		logCh:                  logCh,
 	}
 
 	return &handler, nil
@@ -162,7 +176,9 @@ func (handler *smHandler) setNodeConfig(version string, cfg cloudprotocol.NodeCo
 	return nil
 }
 
-func (handler *smHandler) updateNetworks(networkParameters []aostypes.NetworkParameters) error {
+func (handler *smHandler) updateNetworks(
+	networkParameters []aostypes.NetworkParameters, mirrors []networkmanager.TrafficMirror,
+) error {
 	log.WithFields(log.Fields{
 		"nodeID":   handler.nodeID,
 		"nodeType": handler.nodeType,
@@ -179,10 +195,14 @@ func (handler *smHandler) updateNetworks(networkParameters []aostypes.NetworkPar
 		}
 	}
 
+	pbUpdateNetworks := &pb.UpdateNetworks{Networks: pbNetworkParameters}
+
+	if err := setTrafficMirrors(pbUpdateNetworks, mirrors); err != nil {
+		return err
+	}
+
 	if err := handler.stream.Send(&pb.SMIncomingMessages{SMIncomingMessage: &pb.SMIncomingMessages_UpdateNetworks{
-		UpdateNetworks: &pb.UpdateNetworks{
-			Networks: pbNetworkParameters,
-		},
+		UpdateNetworks: pbUpdateNetworks,
 	}}); err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -237,6 +257,12 @@ func (handler *smHandler) runInstances(
 			StatePath:         instanceInfo.StatePath,
 			NetworkParameters: pbconvert.NetworkParametersToPB(instanceInfo.NetworkParameters),
 		}
+
+		if handler.cniExport && instanceInfo.NetworkID != "" {
+			if err := setCNIConfig(pbRunInstances.Instances[i], instanceInfo); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := handler.stream.Send(&pb.SMIncomingMessages{SMIncomingMessage: &pb.SMIncomingMessages_RunInstances{
@@ -436,6 +462,8 @@ func (handler *smHandler) processSMMessages(message *pb.SMOutgoingMessages) {
 }
 
 func (handler *smHandler) processRunInstanceStatus(status *pb.RunInstancesStatus) {
+	_ = faultinjection.Delay(context.Background(), faultinjection.NodeStatusDelay)
+
 	runStatus := launcher.NodeRunInstanceStatus{
 		NodeID:    handler.nodeID,
 		NodeType:  handler.nodeType,
@@ -561,7 +589,7 @@ func (handler *smHandler) processLogMessage(data *pb.LogData) {
 		"partCount": data.GetPartCount(),
 	}).Debug("Receive SM push log")
 
-	if err := handler.messageSender.SendLog(cloudprotocol.PushLog{
+	handler.logCh <- cloudprotocol.PushLog{
 		NodeID:     handler.nodeID,
 		LogID:      data.GetLogId(),
 		PartsCount: data.GetPartCount(),
@@ -573,8 +601,6 @@ func (handler *smHandler) processLogMessage(data *pb.LogData) {
 			Message:  data.GetError().GetMessage(),
 		},
 		Status: data.GetStatus(),
-	}); err != nil {
-		log.Errorf("Can't send log: %v", err)
 	}
 }
 
@@ -778,3 +804,37 @@ func nodeConfigStatusFromPB(pbStatus *pb.NodeConfigStatus) unitconfig.NodeConfig
 		Error:    pbconvert.ErrorInfoFromPB(pbStatus.GetError()),
 	}
 }
+
+// setCNIConfig adds CNI configuration of instance network to instance info message.
+func setCNIConfig(pbInstance *pb.InstanceInfo, instanceInfo aostypes.InstanceInfo) error {
+	cniConfig, err := networkmanager.GetCNIConfig(instanceInfo.InstanceIdent, instanceInfo.NetworkParameters)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	rawField := protowire.AppendTag(nil, CNIConfigFieldNumber, protowire.BytesType)
+	rawField = protowire.AppendBytes(rawField, cniConfig)
+
+	pbInstance.ProtoReflect().SetUnknown(append(pbInstance.ProtoReflect().GetUnknown(), rawField...))
+
+	return nil
+}
+
+// setTrafficMirrors adds traffic mirrors to update networks message.
+func setTrafficMirrors(pbUpdateNetworks *pb.UpdateNetworks, mirrors []networkmanager.TrafficMirror) error {
+	rawFields := pbUpdateNetworks.ProtoReflect().GetUnknown()
+
+	for _, mirror := range mirrors {
+		rawMirror, err := json.Marshal(mirror)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		rawFields = protowire.AppendTag(rawFields, TrafficMirrorsFieldNumber, protowire.BytesType)
+		rawFields = protowire.AppendBytes(rawFields, rawMirror)
+	}
+
+	pbUpdateNetworks.ProtoReflect().SetUnknown(rawFields)
+
+	return nil
+}
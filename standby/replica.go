@@ -0,0 +1,598 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standby
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/statestream"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SnapshotImporter imports database snapshot. It is called before the database is opened.
+type SnapshotImporter func(cfg *config.Config, fileName string) error
+
+// ChangesApplier applies replicated state changes to the database.
+type ChangesApplier interface {
+	ApplyStateChanges(changes []statestream.Change) error
+}
+
+// Takeover promoted standby. It keeps fencing the former primary till it is closed.
+type Takeover struct {
+	changesFile string
+	changes     []statestream.Change
+	cancelFunc  context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// changesHeader is the first line of the change log: position and entity tag of the replicated snapshot.
+type changesHeader struct {
+	Epoch    string `json:"epoch"`
+	Sequence uint64 `json:"sequence"`
+	ETag     string `json:"etag"`
+	Term     uint64 `json:"term"`
+}
+
+type replica struct {
+	config       config.Standby
+	workingDir   string
+	baseURL      string
+	client       *http.Client
+	replicated   bool
+	header       changesHeader
+	synced       bool
+	sequence     uint64
+	lastSnapshot time.Time
+	lastContact  time.Time
+	lease        time.Duration
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used in unit tests
+var timeNow = time.Now
+
+var errPositionExpired = errors.New("replicated position expired")
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// WaitFailover replicates state of the primary CM and blocks till the primary is lost and its lease is expired. Then
+// the standby is promoted and the replicated snapshot is imported, so CM can continue start and take over. Failover is
+// performed only if the state was replicated at least once, state replicated before standby restart is taken into
+// account. If the standby was promoted before, it takes over immediately.
+func WaitFailover(
+	ctx context.Context, cfg *config.Config, tlsParams *TLSParams, importSnapshot SnapshotImporter,
+) (takeover *Takeover, err error) {
+	log.WithField("url", cfg.Standby.ReplicationURL).Info("Start standby")
+
+	standby := &replica{
+		config:     cfg.Standby,
+		workingDir: cfg.WorkingDir,
+		baseURL:    "http://" + cfg.Standby.ReplicationURL,
+		client:     &http.Client{Timeout: cfg.Standby.FailoverTimeout.Duration},
+		lease:      cfg.Standby.FailoverTimeout.Duration,
+	}
+
+	if tlsParams != nil {
+		tlsConfig, err := tlsParams.getTLSConfig(false)
+		if err != nil {
+			return nil, err
+		}
+
+		standby.baseURL = "https://" + cfg.Standby.ReplicationURL
+		standby.client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	if err = os.MkdirAll(cfg.WorkingDir, 0o755); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	termFile := standby.path(termFileName)
+
+	promotion, promoted, err := readPromotion(termFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if promoted {
+		log.WithField("term", promotion.Term).Warn("Standby is already promoted, take over")
+	} else {
+		if err = standby.load(); err != nil {
+			return nil, err
+		}
+
+		if err = standby.replicate(ctx); err != nil {
+			return nil, err
+		}
+
+		// Term is persisted before the state is imported, so restarted standby doesn't wait for the lost primary
+		promotion.Term = standby.header.Term + 1
+
+		log.WithFields(log.Fields{
+			"lastContact": standby.lastContact, "term": promotion.Term,
+		}).Warn("Primary CM is lost, take over")
+
+		if err = writePromotion(termFile, promotion); err != nil {
+			return nil, err
+		}
+	}
+
+	if takeover, err = standby.takeOver(cfg, importSnapshot); err != nil {
+		return nil, err
+	}
+
+	if !promotion.PrimaryFenced {
+		takeover.startFencing(standby, promotion, termFile)
+	}
+
+	return takeover, nil
+}
+
+// ApplyChanges applies changes replicated after the snapshot. It should be called after the database is opened.
+func (takeover *Takeover) ApplyChanges(applier ChangesApplier) error {
+	if len(takeover.changes) != 0 {
+		log.WithField("count", len(takeover.changes)).Debug("Apply replicated state changes")
+
+		if err := applier.ApplyStateChanges(takeover.changes); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return aoserrors.Wrap(os.RemoveAll(takeover.changesFile))
+}
+
+// Close stops fencing of the former primary.
+func (takeover *Takeover) Close() {
+	if takeover.cancelFunc != nil {
+		takeover.cancelFunc()
+	}
+
+	takeover.wg.Wait()
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (standby *replica) path(fileName string) string {
+	return filepath.Join(standby.workingDir, fileName)
+}
+
+// load loads state replicated before standby restart.
+func (standby *replica) load() error {
+	if _, err := os.Stat(standby.path(snapshotFileName)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return aoserrors.Wrap(err)
+	}
+
+	standby.replicated = true
+	standby.lastContact = timeNow()
+
+	header, changes, err := readChanges(standby.path(changesFileName))
+	if err != nil {
+		// Snapshot is replicated again as its position is unknown
+		log.Warnf("Can't read replicated changes: %v", err)
+
+		return nil
+	}
+
+	standby.header = header
+	standby.synced = true
+	standby.sequence = header.Sequence
+
+	if len(changes) != 0 {
+		standby.sequence = changes[len(changes)-1].Sequence
+	}
+
+	return nil
+}
+
+// replicate syncs state each sync period and returns when failover timeout and the primary lease expire.
+func (standby *replica) replicate(ctx context.Context) error {
+	ticker := time.NewTicker(standby.config.SyncPeriod.Duration)
+	defer ticker.Stop()
+
+	for {
+		if err := standby.sync(ctx); err != nil {
+			log.Warnf("Can't replicate primary CM state: %v", err)
+		}
+
+		if standby.replicated && timeNow().Sub(standby.lastContact) >= standby.failoverTimeout() {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+
+		case <-ctx.Done():
+			return aoserrors.Wrap(ctx.Err())
+		}
+	}
+}
+
+func (standby *replica) failoverTimeout() time.Duration {
+	if standby.lease > standby.config.FailoverTimeout.Duration {
+		return standby.lease
+	}
+
+	return standby.config.FailoverTimeout.Duration
+}
+
+// sync pulls changes following the replicated position. Snapshot is replicated if the position is unknown or expired,
+// and each snapshot period.
+func (standby *replica) sync(ctx context.Context) error {
+	if standby.synced && timeNow().Sub(standby.lastSnapshot) < standby.config.SnapshotPeriod.Duration {
+		err := standby.syncChanges(ctx)
+		if !errors.Is(err, errPositionExpired) {
+			return err
+		}
+
+		log.Debug("Replicated position expired, replicate snapshot")
+
+		standby.synced = false
+	}
+
+	return standby.syncSnapshot(ctx)
+}
+
+func (standby *replica) syncChanges(ctx context.Context) error {
+	query := url.Values{
+		"epoch": {standby.header.Epoch}, "since": {strconv.FormatUint(standby.sequence, 10)},
+	}
+
+	response, err := standby.request(ctx, http.MethodGet, changesPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+
+	case http.StatusGone:
+		return errPositionExpired
+
+	default:
+		return aoserrors.Errorf("unexpected status: %s", response.Status)
+	}
+
+	var changes []statestream.Change
+
+	if err = json.NewDecoder(response.Body).Decode(&changes); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err = appendChanges(standby.path(changesFileName), changes); err != nil {
+		return err
+	}
+
+	standby.sequence = changes[len(changes)-1].Sequence
+
+	log.WithFields(log.Fields{"count": len(changes), "sequence": standby.sequence}).Debug("Primary CM changes replicated")
+
+	return nil
+}
+
+// syncSnapshot downloads snapshot of the primary if the database is changed since the replicated one.
+func (standby *replica) syncSnapshot(ctx context.Context) error {
+	var header http.Header
+
+	if standby.synced {
+		header = http.Header{"If-None-Match": {standby.header.ETag}}
+	}
+
+	response, err := standby.request(ctx, http.MethodGet, snapshotPath, header)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		standby.lastSnapshot = timeNow()
+
+		return nil
+
+	case http.StatusOK:
+
+	default:
+		return aoserrors.Errorf("unexpected status: %s", response.Status)
+	}
+
+	snapshotHeader := changesHeader{
+		Epoch: response.Header.Get(epochHeader), ETag: response.Header.Get("ETag"), Term: standby.header.Term,
+	}
+
+	if snapshotHeader.Sequence, err = strconv.ParseUint(response.Header.Get(sequenceHeader), 10, 64); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = standby.storeSnapshot(response.Body, snapshotHeader); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"etag": snapshotHeader.ETag, "sequence": snapshotHeader.Sequence,
+	}).Debug("Primary CM snapshot replicated")
+
+	standby.header = snapshotHeader
+	standby.sequence = snapshotHeader.Sequence
+	standby.synced = true
+	standby.replicated = true
+	standby.lastSnapshot = timeNow()
+
+	return nil
+}
+
+// storeSnapshot replaces replicated snapshot and resets the change log. The change log is removed before the snapshot
+// is replaced, so changes of the previous snapshot are never applied to the new one.
+func (standby *replica) storeSnapshot(reader io.Reader, header changesHeader) error {
+	snapshotFile := standby.path(snapshotFileName)
+	changesFile := standby.path(changesFileName)
+
+	defer os.RemoveAll(snapshotFile + ".tmp")
+	defer os.RemoveAll(changesFile + ".tmp")
+
+	if err := writeFile(snapshotFile+".tmp", reader); err != nil {
+		return err
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = writeFileAtomic(changesFile+".tmp", append(headerData, '\n')); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(changesFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.Rename(snapshotFile+".tmp", snapshotFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(os.Rename(changesFile+".tmp", changesFile))
+}
+
+// request sends request to the primary. Each response renews the primary lease and updates the primary term.
+func (standby *replica) request(
+	ctx context.Context, method, path string, header http.Header,
+) (response *http.Response, err error) {
+	request, err := http.NewRequestWithContext(ctx, method, standby.baseURL+path, nil)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	for key, values := range header {
+		request.Header[key] = values
+	}
+
+	if response, err = standby.client.Do(request); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if response.StatusCode == http.StatusServiceUnavailable {
+		// Fenced primary doesn't grant the lease
+		return response, nil
+	}
+
+	standby.lastContact = timeNow()
+
+	if lease, err := time.ParseDuration(response.Header.Get(leaseHeader)); err == nil {
+		standby.lease = lease
+	}
+
+	if term, err := strconv.ParseUint(response.Header.Get(termHeader), 10, 64); err == nil && term > standby.header.Term {
+		standby.header.Term = term
+	}
+
+	return response, nil
+}
+
+// takeOver imports replicated snapshot and reads changes replicated after it.
+func (standby *replica) takeOver(cfg *config.Config, importSnapshot SnapshotImporter) (*Takeover, error) {
+	takeover := &Takeover{changesFile: standby.path(changesFileName)}
+
+	snapshotFile := standby.path(snapshotFileName)
+
+	if _, err := os.Stat(snapshotFile); err == nil {
+		if err = importSnapshot(cfg, snapshotFile); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+
+		if err = os.RemoveAll(snapshotFile); err != nil {
+			return nil, aoserrors.Wrap(err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	_, changes, err := readChanges(takeover.changesFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Errorf("Can't read replicated changes: %v", err)
+	}
+
+	takeover.changes = changes
+
+	return takeover, nil
+}
+
+// startFencing fences the former primary with the promotion term till it succeeds.
+func (takeover *Takeover) startFencing(standby *replica, promotion promotion, termFile string) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	takeover.cancelFunc = cancelFunc
+
+	takeover.wg.Add(1)
+
+	go func() {
+		defer takeover.wg.Done()
+
+		ticker := time.NewTicker(standby.config.SyncPeriod.Duration)
+		defer ticker.Stop()
+
+		for {
+			done, err := standby.fence(ctx, promotion.Term)
+			if err != nil {
+				log.Debugf("Can't fence former primary CM: %v", err)
+			}
+
+			if done {
+				promotion.PrimaryFenced = true
+
+				if err = writePromotion(termFile, promotion); err != nil {
+					log.Errorf("Can't persist promotion: %v", err)
+				}
+
+				return
+			}
+
+			select {
+			case <-ticker.C:
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// fence sends fencing request to the former primary, done is true if the primary is fenced or has greater term.
+func (standby *replica) fence(ctx context.Context, term uint64) (done bool, err error) {
+	response, err := standby.request(ctx, http.MethodPost, fencePath,
+		http.Header{termHeader: {strconv.FormatUint(term, 10)}})
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		log.WithField("term", term).Info("Former primary CM is fenced")
+
+		return true, nil
+
+	case http.StatusConflict:
+		log.WithField("term", term).Error("Former primary CM has greater term, it is not fenced")
+
+		return true, nil
+
+	default:
+		return false, aoserrors.Errorf("unexpected status: %s", response.Status)
+	}
+}
+
+// readChanges reads change log. Incomplete last line written on power loss is ignored.
+func readChanges(fileName string) (header changesHeader, changes []statestream.Change, err error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return header, nil, aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	scanner.Buffer(nil, 16*1024*1024)
+
+	if !scanner.Scan() {
+		return header, nil, aoserrors.New("change log header is missing")
+	}
+
+	if err = json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return header, nil, aoserrors.Wrap(err)
+	}
+
+	for scanner.Scan() {
+		var change statestream.Change
+
+		if err = json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			log.Warnf("Skip corrupted replicated change: %v", err)
+
+			break
+		}
+
+		changes = append(changes, change)
+	}
+
+	return header, changes, aoserrors.Wrap(scanner.Err())
+}
+
+func appendChanges(fileName string, changes []statestream.Change) error {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+
+	for _, change := range changes {
+		if err = encoder.Encode(change); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return aoserrors.Wrap(file.Sync())
+}
+
+func writeFile(fileName string, reader io.Reader) error {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, reader); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(file.Sync())
+}
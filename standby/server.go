@@ -0,0 +1,324 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standby
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/statestream"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const readHeaderTimeout = 5 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// StateProvider provides primary database state.
+type StateProvider interface {
+	ExportSnapshot(fileName string) error
+	DataVersion() (uint64, error)
+}
+
+// ChangesProvider provides state changes committed to the primary database.
+type ChangesProvider interface {
+	Position() (epoch string, sequence uint64)
+	Changes(epoch string, since uint64) ([]statestream.Change, error)
+}
+
+// Server primary replication server.
+type Server struct {
+	sync.Mutex
+
+	state        StateProvider
+	changes      ChangesProvider
+	snapshotFile string
+	fencedFile   string
+	term         uint64
+	lease        time.Duration
+	fenced       bool
+	onFenced     func()
+	server       *http.Server
+	wg           sync.WaitGroup
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewServer creates primary replication server. If TLS params are set, the server uses HTTPS with client certificate
+// verification. The onFenced callback is called when the primary is fenced by promoted standby, ErrFenced is returned
+// if the primary was fenced before.
+func NewServer(
+	cfg *config.Config, state StateProvider, changes ChangesProvider, tlsParams *TLSParams, onFenced func(),
+) (server *Server, err error) {
+	log.WithFields(log.Fields{
+		"url": cfg.Standby.ReplicationURL, "tls": tlsParams != nil,
+	}).Debug("Create standby replication server")
+
+	server = &Server{
+		state:        state,
+		changes:      changes,
+		snapshotFile: filepath.Join(cfg.WorkingDir, snapshotFileName),
+		fencedFile:   filepath.Join(cfg.WorkingDir, fencedFileName),
+		lease:        cfg.Standby.FailoverTimeout.Duration,
+		onFenced:     onFenced,
+	}
+
+	if _, err = os.Stat(server.fencedFile); err == nil {
+		return nil, aoserrors.Wrap(ErrFenced)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	// Promoted standby serves with its promotion term
+	promotion, _, err := readPromotion(filepath.Join(cfg.WorkingDir, termFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	server.term = promotion.Term
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(snapshotPath, server.handleSnapshot)
+	mux.HandleFunc(changesPath, server.handleChanges)
+	mux.HandleFunc(fencePath, server.handleFence)
+
+	server.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	listener, err := net.Listen("tcp", cfg.Standby.ReplicationURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if tlsParams != nil {
+		tlsConfig, err := tlsParams.getTLSConfig(true)
+		if err != nil {
+			listener.Close()
+
+			return nil, err
+		}
+
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	server.wg.Add(1)
+
+	go func() {
+		defer server.wg.Done()
+
+		if err := server.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Standby replication server error: %v", err)
+		}
+	}()
+
+	return server, nil
+}
+
+// Close closes primary replication server.
+func (server *Server) Close() error {
+	log.Debug("Close standby replication server")
+
+	err := server.server.Shutdown(context.Background())
+
+	server.wg.Wait()
+
+	return aoserrors.Wrap(err)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// handleSnapshot sends database snapshot. The entity tag is built from the stream epoch and the database data version,
+// so the snapshot is exported only if the database is changed since the requested entity tag.
+func (server *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !server.checkRequest(w, r, http.MethodGet) {
+		return
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	// Position is taken before export: changes following it may be already in the snapshot and are applied again
+	epoch, sequence := server.changes.Position()
+
+	version, err := server.state.DataVersion()
+	if err != nil {
+		log.Errorf("Can't get database data version: %v", err)
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, epoch, version)
+
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	defer os.RemoveAll(server.snapshotFile)
+
+	if err := server.state.ExportSnapshot(server.snapshotFile); err != nil {
+		log.Errorf("Can't export standby snapshot: %v", err)
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"remoteAddr": r.RemoteAddr, "epoch": epoch, "sequence": sequence,
+	}).Debug("Send standby snapshot")
+
+	w.Header().Set(epochHeader, epoch)
+	w.Header().Set(sequenceHeader, strconv.FormatUint(sequence, 10))
+	w.Header().Set("Content-Type", "application/octet-stream")
+
+	http.ServeFile(w, r, server.snapshotFile)
+}
+
+// handleChanges sends state changes following the requested position. Gone status is sent if the changes are not
+// available anymore and the snapshot should be replicated.
+func (server *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if !server.checkRequest(w, r, http.MethodGet) {
+		return
+	}
+
+	since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	changes, err := server.changes.Changes(r.URL.Query().Get("epoch"), since)
+	if err != nil {
+		if errors.Is(err, statestream.ErrSequenceExpired) {
+			http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(w).Encode(changes); err != nil {
+		log.Errorf("Can't send standby changes: %v", err)
+	}
+}
+
+// handleFence fences the primary if the requested term is greater than the primary one. Fenced primary doesn't serve
+// replication channel anymore and is not started till it is re-provisioned.
+func (server *Server) handleFence(w http.ResponseWriter, r *http.Request) {
+	if !server.checkRequest(w, r, http.MethodPost) {
+		return
+	}
+
+	term, err := strconv.ParseUint(r.Header.Get(termHeader), 10, 64)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	if term <= server.term {
+		log.WithFields(log.Fields{"term": term, "ownTerm": server.term}).Warn("Reject stale fencing request")
+
+		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+
+		return
+	}
+
+	if err = writeFileAtomic(server.fencedFile, []byte(strconv.FormatUint(term, 10))); err != nil {
+		log.Errorf("Can't persist fencing: %v", err)
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	log.WithField("term", term).Warn("Primary CM is fenced by promoted standby")
+
+	server.fenced = true
+
+	w.WriteHeader(http.StatusOK)
+
+	if server.onFenced != nil {
+		go server.onFenced()
+	}
+}
+
+// checkRequest checks request method and sets lease headers. Fenced primary doesn't grant the lease.
+func (server *Server) checkRequest(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return false
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	if server.fenced {
+		http.Error(w, ErrFenced.Error(), http.StatusServiceUnavailable)
+
+		return false
+	}
+
+	w.Header().Set(termHeader, strconv.FormatUint(server.term, 10))
+	w.Header().Set(leaseHeader, server.lease.String())
+
+	return true
+}
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package standby provides warm standby failover of CM.
+//
+// Primary CM serves replication channel. Standby CM doesn't connect to SMs, UMs and the cloud. It pulls state changes
+// of the primary each sync period and refreshes full database snapshot each snapshot period. The snapshot is checked
+// by the database data version and transferred only if the database is changed. Changes published after the snapshot
+// are kept in the change log and applied on top of the snapshot at takeover.
+//
+// Each primary response grants a lease to the standby. If the primary is not reachable during failover timeout and
+// the lease is expired, standby promotes itself with the term greater than the primary one, imports the replicated
+// state and continues start as regular CM taking over SM, UM and cloud connections. The promoted standby fences the
+// former primary with the new term as soon as it is reachable, the fenced primary shuts down and doesn't start serving
+// till it is re-provisioned.
+package standby
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/aosedge/aos_common/aoserrors"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	snapshotPath = "/snapshot"
+	changesPath  = "/changes"
+	fencePath    = "/fence"
+)
+
+const (
+	termHeader     = "X-Aos-Term"
+	leaseHeader    = "X-Aos-Lease"
+	epochHeader    = "X-Aos-Epoch"
+	sequenceHeader = "X-Aos-Sequence"
+)
+
+const (
+	snapshotFileName = "standby.snapshot"
+	changesFileName  = "standby.changes"
+	termFileName     = "standby.term"
+	fencedFileName   = "standby.fenced"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CertificateProvider provides certificate of replication channel.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+}
+
+// CryptoContext crypto context interface.
+type CryptoContext interface {
+	GetServerMutualTLSConfig(certURLStr, keyURLStr string) (*tls.Config, error)
+	GetClientMutualTLSConfig(certURLStr, keyURLStr string) (*tls.Config, error)
+}
+
+// TLSParams replication channel TLS parameters.
+type TLSParams struct {
+	CertType      string
+	CertProvider  CertificateProvider
+	CryptoContext CryptoContext
+}
+
+// promotion is persisted by standby when it takes over.
+type promotion struct {
+	Term          uint64 `json:"term"`
+	PrimaryFenced bool   `json:"primaryFenced"`
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrFenced is returned if primary is fenced by promoted standby.
+var ErrFenced = errors.New("primary is fenced by promoted standby")
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewTLSParams returns replication channel TLS parameters. Nil is returned if TLS is disabled in config.
+func NewTLSParams(
+	cfg *config.Config, certProvider CertificateProvider, cryptoContext CryptoContext,
+) (tlsParams *TLSParams) {
+	if !cfg.Standby.TLS {
+		return nil
+	}
+
+	certType := cfg.Standby.CertType
+	if certType == "" {
+		certType = cfg.CertStorage
+	}
+
+	return &TLSParams{CertType: certType, CertProvider: certProvider, CryptoContext: cryptoContext}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (tlsParams *TLSParams) getTLSConfig(server bool) (*tls.Config, error) {
+	certURL, keyURL, err := tlsParams.CertProvider.GetCertificate(tlsParams.CertType, nil, "")
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if !server {
+		tlsConfig, err := tlsParams.CryptoContext.GetClientMutualTLSConfig(certURL, keyURL)
+
+		return tlsConfig, aoserrors.Wrap(err)
+	}
+
+	tlsConfig, err := tlsParams.CryptoContext.GetServerMutualTLSConfig(certURL, keyURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return tlsConfig, nil
+}
+
+// readPromotion reads persisted promotion, promoted is false if CM was not promoted.
+func readPromotion(fileName string) (result promotion, promoted bool, err error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return result, false, nil
+		}
+
+		return result, false, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, &result); err != nil {
+		return result, false, aoserrors.Wrap(err)
+	}
+
+	return result, true, nil
+}
+
+func writePromotion(fileName string, value promotion) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return writeFileAtomic(fileName, data)
+}
+
+// writeFileAtomic writes file through temporary one, so either old or new content is kept on power loss.
+func writeFileAtomic(fileName string, data []byte) error {
+	tmpFile := fileName + ".tmp"
+
+	file, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.RemoveAll(tmpFile)
+
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(os.Rename(tmpFile, fileName))
+}
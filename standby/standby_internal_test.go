@@ -0,0 +1,436 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standby
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/statestream"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const replicationURL = "localhost:8096"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testState struct {
+	sync.Mutex
+	data        string
+	version     uint64
+	exportCount int
+}
+
+type testApplier struct {
+	changes []statestream.Change
+}
+
+type failoverResult struct {
+	data     string
+	takeover *Takeover
+	err      error
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var tmpDir string
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Main
+ **********************************************************************************************************************/
+
+func TestMain(m *testing.M) {
+	var err error
+
+	if tmpDir, err = os.MkdirTemp("", "cm_"); err != nil {
+		log.Fatalf("Error create temporary dir: %v", err)
+	}
+
+	ret := m.Run()
+
+	if err := os.RemoveAll(tmpDir); err != nil {
+		log.Errorf("Can't remove tmp dir: %v", err)
+	}
+
+	os.Exit(ret)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestFailover(t *testing.T) {
+	primaryCfg := newTestConfig(t, config.StandbyRolePrimary)
+	standbyCfg := newTestConfig(t, config.StandbyRoleStandby)
+	state := &testState{}
+	stream := statestream.New(config.StateStream{HistorySize: 16})
+
+	state.set("state1")
+
+	server, err := NewServer(primaryCfg, state, stream, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create replication server: %v", err)
+	}
+
+	resultChannel := startFailover(standbyCfg)
+
+	snapshotFile := filepath.Join(standbyCfg.WorkingDir, snapshotFileName)
+
+	if err = waitCondition(func() bool { return fileContent(snapshotFile) == "state1" }); err != nil {
+		t.Errorf("State is not replicated: %v", err)
+	}
+
+	// Snapshot is not exported if the database is not changed
+
+	time.Sleep(2 * standbyCfg.Standby.SnapshotPeriod.Duration)
+
+	if exportCount := state.getExportCount(); exportCount != 1 {
+		t.Errorf("Wrong export count: %d", exportCount)
+	}
+
+	// Changes are replicated incrementally
+
+	stream.Publish(statestream.KindInstance, statestream.OperationSet, "instance1")
+	stream.Publish(statestream.KindInstance, statestream.OperationRemove, "instance2")
+
+	changesFile := filepath.Join(standbyCfg.WorkingDir, changesFileName)
+
+	if err = waitCondition(func() bool {
+		_, changes, err := readChanges(changesFile)
+
+		return err == nil && len(changes) == 2
+	}); err != nil {
+		t.Errorf("Changes are not replicated: %v", err)
+	}
+
+	// Changed snapshot is replicated and resets changes
+
+	state.set("state2")
+
+	if err = waitCondition(func() bool { return fileContent(snapshotFile) == "state2" }); err != nil {
+		t.Errorf("State is not replicated: %v", err)
+	}
+
+	if _, changes, err := readChanges(changesFile); err != nil || len(changes) != 0 {
+		t.Errorf("Changes are not reset: %v, err: %v", changes, err)
+	}
+
+	stream.Publish(statestream.KindNetwork, statestream.OperationSet, "network1")
+
+	if err = waitCondition(func() bool {
+		_, changes, err := readChanges(changesFile)
+
+		return err == nil && len(changes) == 1
+	}); err != nil {
+		t.Errorf("Changes are not replicated: %v", err)
+	}
+
+	select {
+	case result := <-resultChannel:
+		t.Fatalf("Unexpected failover: %v", result)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// Standby takes over when primary is lost
+
+	if err = server.Close(); err != nil {
+		t.Errorf("Can't close replication server: %v", err)
+	}
+
+	result := waitFailoverResult(t, resultChannel)
+	defer result.takeover.Close()
+
+	if result.data != "state2" {
+		t.Errorf("Wrong imported state: %s", result.data)
+	}
+
+	if _, err = os.Stat(snapshotFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Replicated snapshot is not removed: %v", err)
+	}
+
+	applier := &testApplier{}
+
+	if err = result.takeover.ApplyChanges(applier); err != nil {
+		t.Fatalf("Can't apply changes: %v", err)
+	}
+
+	if len(applier.changes) != 1 || applier.changes[0].Kind != statestream.KindNetwork {
+		t.Errorf("Wrong applied changes: %v", applier.changes)
+	}
+
+	// Promoted standby fences former primary
+
+	fencedChannel := make(chan struct{})
+
+	if server, err = NewServer(primaryCfg, state, stream, nil, func() { close(fencedChannel) }); err != nil {
+		t.Fatalf("Can't create replication server: %v", err)
+	}
+	defer server.Close()
+
+	select {
+	case <-fencedChannel:
+
+	case <-time.After(5 * time.Second):
+		t.Error("Wait fencing timeout")
+	}
+
+	if _, err = NewServer(primaryCfg, state, stream, nil, nil); !errors.Is(err, ErrFenced) {
+		t.Errorf("Fenced error expected: %v", err)
+	}
+
+	promotion, promoted, err := readPromotion(filepath.Join(standbyCfg.WorkingDir, termFileName))
+	if err != nil || !promoted {
+		t.Fatalf("Can't read promotion: %v", err)
+	}
+
+	if promotion.Term != 1 {
+		t.Errorf("Wrong promotion term: %d", promotion.Term)
+	}
+}
+
+func TestFailoverAfterLease(t *testing.T) {
+	primaryCfg := newTestConfig(t, config.StandbyRolePrimary)
+	standbyCfg := newTestConfig(t, config.StandbyRoleStandby)
+	state := &testState{}
+
+	primaryCfg.Standby.FailoverTimeout.Duration = 2 * time.Second
+
+	state.set("state")
+
+	server, err := NewServer(primaryCfg, state, statestream.New(config.StateStream{HistorySize: 16}), nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create replication server: %v", err)
+	}
+
+	resultChannel := startFailover(standbyCfg)
+
+	if err = waitCondition(func() bool {
+		return fileContent(filepath.Join(standbyCfg.WorkingDir, snapshotFileName)) == "state"
+	}); err != nil {
+		t.Errorf("State is not replicated: %v", err)
+	}
+
+	if err = server.Close(); err != nil {
+		t.Errorf("Can't close replication server: %v", err)
+	}
+
+	closeTime := time.Now()
+
+	result := waitFailoverResult(t, resultChannel)
+	defer result.takeover.Close()
+
+	if time.Since(closeTime) < primaryCfg.Standby.FailoverTimeout.Duration-standbyCfg.Standby.SyncPeriod.Duration {
+		t.Error("Standby takes over before primary lease expires")
+	}
+}
+
+func TestPromotedStandbyTakesOver(t *testing.T) {
+	standbyCfg := newTestConfig(t, config.StandbyRoleStandby)
+
+	if err := writePromotion(
+		filepath.Join(standbyCfg.WorkingDir, termFileName), promotion{Term: 2, PrimaryFenced: true}); err != nil {
+		t.Fatalf("Can't write promotion: %v", err)
+	}
+
+	takeover, err := WaitFailover(context.Background(), standbyCfg, nil, func(cfg *config.Config, fileName string) error {
+		t.Error("Unexpected snapshot import")
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failover error: %v", err)
+	}
+
+	takeover.Close()
+}
+
+func TestNoFailoverWithoutState(t *testing.T) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFunc()
+
+	if _, err := WaitFailover(ctx, newTestConfig(t, config.StandbyRoleStandby), nil,
+		func(cfg *config.Config, fileName string) error {
+			t.Error("Unexpected snapshot import")
+
+			return nil
+		}); err == nil {
+		t.Error("Error expected if primary state is not replicated")
+	}
+}
+
+/***********************************************************************************************************************
+ * testState
+ **********************************************************************************************************************/
+
+func (state *testState) ExportSnapshot(fileName string) error {
+	state.Lock()
+	defer state.Unlock()
+
+	state.exportCount++
+
+	return os.WriteFile(fileName, []byte(state.data), 0o600)
+}
+
+func (state *testState) DataVersion() (uint64, error) {
+	state.Lock()
+	defer state.Unlock()
+
+	return state.version, nil
+}
+
+func (state *testState) set(data string) {
+	state.Lock()
+	defer state.Unlock()
+
+	state.data = data
+	state.version++
+}
+
+func (state *testState) getExportCount() int {
+	state.Lock()
+	defer state.Unlock()
+
+	return state.exportCount
+}
+
+/***********************************************************************************************************************
+ * testApplier
+ **********************************************************************************************************************/
+
+func (applier *testApplier) ApplyStateChanges(changes []statestream.Change) error {
+	applier.changes = append(applier.changes, changes...)
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestConfig(t *testing.T, role string) *config.Config {
+	t.Helper()
+
+	workingDir := filepath.Join(tmpDir, t.Name(), role)
+
+	if err := os.MkdirAll(workingDir, 0o755); err != nil {
+		t.Fatalf("Can't create working dir: %v", err)
+	}
+
+	return &config.Config{
+		WorkingDir: workingDir,
+		Standby: config.Standby{
+			Role:            role,
+			ReplicationURL:  replicationURL,
+			SyncPeriod:      aostypes.Duration{Duration: 50 * time.Millisecond},
+			SnapshotPeriod:  aostypes.Duration{Duration: 200 * time.Millisecond},
+			FailoverTimeout: aostypes.Duration{Duration: 300 * time.Millisecond},
+		},
+	}
+}
+
+func startFailover(cfg *config.Config) <-chan failoverResult {
+	resultChannel := make(chan failoverResult, 1)
+
+	go func() {
+		var result failoverResult
+
+		result.takeover, result.err = WaitFailover(context.Background(), cfg, nil,
+			func(cfg *config.Config, fileName string) error {
+				data, err := os.ReadFile(fileName)
+				result.data = string(data)
+
+				return err
+			})
+
+		resultChannel <- result
+	}()
+
+	return resultChannel
+}
+
+func waitFailoverResult(t *testing.T, resultChannel <-chan failoverResult) failoverResult {
+	t.Helper()
+
+	select {
+	case result := <-resultChannel:
+		if result.err != nil {
+			t.Fatalf("Failover error: %v", result.err)
+		}
+
+		return result
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait failover timeout")
+	}
+
+	return failoverResult{}
+}
+
+func fileContent(fileName string) string {
+	data, _ := os.ReadFile(fileName)
+
+	return string(data)
+}
+
+func waitCondition(condition func() bool) error {
+	timeout := time.After(5 * time.Second)
+
+	for {
+		if condition() {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return errors.New("wait condition timeout")
+
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
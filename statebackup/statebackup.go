@@ -0,0 +1,528 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statebackup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	manifestVersion    = 1
+	manifestFileName   = "manifest.json"
+	databaseFileName   = "database.db"
+	unitConfigFileName = "unitconfig.json"
+	pendingStateDir    = "pendingstate"
+	maxStateFileSize   = 1 << 30
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Storage provides database snapshot.
+type Storage interface {
+	ExportSnapshot(fileName string) error
+}
+
+// DatabaseImporter replaces database with the snapshot.
+type DatabaseImporter func(config *config.Config, fileName string) error
+
+// Manifest state archive manifest.
+type Manifest struct {
+	Version   int        `json:"version"`
+	Timestamp time.Time  `json:"timestamp"`
+	Files     []FileInfo `json:"files"`
+}
+
+// FileInfo state archive file info.
+type FileInfo struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// StateBackup exports and imports CM state. Imported state is applied on next CM start.
+type StateBackup struct {
+	sync.Mutex
+
+	config  *config.Config
+	storage Storage
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var (
+	// ErrEncryptionEnabled state export error if database encryption is enabled.
+	ErrEncryptionEnabled = errors.New("state export is not allowed when database encryption is enabled")
+
+	// ErrWrongPath state archive is outside of state backup dir.
+	ErrWrongPath = errors.New("state archive should be in state backup dir")
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates state backup instance.
+func New(config *config.Config, storage Storage) (backup *StateBackup, err error) {
+	log.Debug("Create state backup")
+
+	return &StateBackup{config: config, storage: storage}, nil
+}
+
+// ExportState writes CM database snapshot and unit config to the archive in state backup dir. Returns SHA256 hash
+// of the archive. The snapshot is not encrypted, so export is refused if database encryption is enabled.
+func (backup *StateBackup) ExportState(fileName string) (hash string, err error) {
+	backup.Lock()
+	defer backup.Unlock()
+
+	log.WithField("fileName", fileName).Info("Export CM state")
+
+	if backup.config.DatabaseEncryption.Enabled {
+		return "", aoserrors.Wrap(ErrEncryptionEnabled)
+	}
+
+	if fileName, err = backup.getArchivePath(fileName); err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(backup.config.StateBackupDir, 0o700); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	tmpDir, err := os.MkdirTemp(backup.config.WorkingDir, "export_")
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = backup.storage.ExportSnapshot(filepath.Join(tmpDir, databaseFileName)); err != nil {
+		return "", err
+	}
+
+	files := []string{databaseFileName}
+
+	if _, err = os.Stat(backup.config.UnitConfigFile); err == nil {
+		if err = copyFile(backup.config.UnitConfigFile, filepath.Join(tmpDir, unitConfigFileName)); err != nil {
+			return "", err
+		}
+
+		files = append(files, unitConfigFileName)
+	}
+
+	manifest := Manifest{Version: manifestVersion, Timestamp: time.Now().UTC()}
+
+	for _, name := range files {
+		var fileInfo FileInfo
+
+		if fileInfo, err = getFileInfo(tmpDir, name); err != nil {
+			return "", err
+		}
+
+		manifest.Files = append(manifest.Files, fileInfo)
+	}
+
+	if err = writeManifest(tmpDir, manifest); err != nil {
+		return "", err
+	}
+
+	if hash, err = writeArchive(fileName, tmpDir, append([]string{manifestFileName}, files...)); err != nil {
+		return "", err
+	}
+
+	log.WithFields(log.Fields{"fileName": fileName, "sha256": hash}).Debug("CM state exported")
+
+	return hash, nil
+}
+
+// ImportState verifies state archive in state backup dir and stages it to be applied on next CM start. If hash is
+// not empty, it is compared with SHA256 hash of the archive.
+func (backup *StateBackup) ImportState(fileName, hash string) (err error) {
+	backup.Lock()
+	defer backup.Unlock()
+
+	log.WithField("fileName", fileName).Info("Import CM state")
+
+	if fileName, err = backup.getArchivePath(fileName); err != nil {
+		return err
+	}
+
+	stat, err := os.Lstat(fileName)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if !stat.Mode().IsRegular() {
+		return aoserrors.Errorf("state archive is not a regular file: %s", fileName)
+	}
+
+	if hash != "" {
+		var archiveHash string
+
+		if archiveHash, err = calculateHash(fileName); err != nil {
+			return err
+		}
+
+		if archiveHash != hash {
+			return aoserrors.New("state archive hash mismatch")
+		}
+	}
+
+	stagingDir := filepath.Join(backup.config.WorkingDir, pendingStateDir+".tmp")
+
+	if err = os.RemoveAll(stagingDir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	defer os.RemoveAll(stagingDir)
+
+	if err = extractArchive(fileName, stagingDir); err != nil {
+		return err
+	}
+
+	if err = verifyState(stagingDir); err != nil {
+		return err
+	}
+
+	pendingDir := filepath.Join(backup.config.WorkingDir, pendingStateDir)
+
+	if err = os.RemoveAll(pendingDir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.Rename(stagingDir, pendingDir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	log.Info("CM state will be applied on next start")
+
+	return nil
+}
+
+// ApplyPendingState applies imported state if any. It should be called before the database is opened.
+func ApplyPendingState(config *config.Config, dbImporter DatabaseImporter) (err error) {
+	pendingDir := filepath.Join(config.WorkingDir, pendingStateDir)
+
+	if _, err = os.Stat(pendingDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	log.Info("Apply imported CM state")
+
+	if err = verifyState(pendingDir); err != nil {
+		return err
+	}
+
+	if err = dbImporter(config, filepath.Join(pendingDir, databaseFileName)); err != nil {
+		return err
+	}
+
+	unitConfigFile := filepath.Join(pendingDir, unitConfigFileName)
+
+	if _, err = os.Stat(unitConfigFile); err == nil {
+		if err = copyFile(unitConfigFile, config.UnitConfigFile); err != nil {
+			return err
+		}
+	}
+
+	if err = os.RemoveAll(pendingDir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getArchivePath returns archive path in state backup dir. Relative file name is resolved against the dir.
+func (backup *StateBackup) getArchivePath(fileName string) (string, error) {
+	backupDir := filepath.Clean(backup.config.StateBackupDir)
+
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(backupDir, fileName)
+	}
+
+	fileName = filepath.Clean(fileName)
+
+	if backupDir == "" || filepath.Dir(fileName) != backupDir {
+		return "", aoserrors.Wrap(ErrWrongPath)
+	}
+
+	return fileName, nil
+}
+
+func verifyState(stateDir string) error {
+	manifestData, err := os.ReadFile(filepath.Join(stateDir, manifestFileName))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	var manifest Manifest
+
+	if err = json.Unmarshal(manifestData, &manifest); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if manifest.Version != manifestVersion {
+		return aoserrors.Errorf("unsupported state manifest version: %d", manifest.Version)
+	}
+
+	databaseFound := false
+
+	for _, expectedInfo := range manifest.Files {
+		if !isStateFile(expectedInfo.Name) {
+			return aoserrors.Errorf("unexpected state file: %s", expectedInfo.Name)
+		}
+
+		fileInfo, err := getFileInfo(stateDir, expectedInfo.Name)
+		if err != nil {
+			return err
+		}
+
+		if fileInfo != expectedInfo {
+			return aoserrors.Errorf("state file %s integrity check failed", expectedInfo.Name)
+		}
+
+		if expectedInfo.Name == databaseFileName {
+			databaseFound = true
+		}
+	}
+
+	if !databaseFound {
+		return aoserrors.New("state archive doesn't contain database")
+	}
+
+	return nil
+}
+
+func isStateFile(name string) bool {
+	return name == databaseFileName || name == unitConfigFileName
+}
+
+func writeManifest(dir string, manifest Manifest) error {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, manifestFileName), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func getFileInfo(dir, name string) (FileInfo, error) {
+	fileName := filepath.Join(dir, name)
+
+	stat, err := os.Stat(fileName)
+	if err != nil {
+		return FileInfo{}, aoserrors.Wrap(err)
+	}
+
+	hash, err := calculateHash(fileName)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	return FileInfo{Name: name, Size: stat.Size(), SHA256: hash}, nil
+}
+
+func calculateHash(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeArchive(fileName, dir string, files []string) (hash string, err error) {
+	tmpFile := fileName + ".tmp"
+
+	file, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpFile)
+		}
+	}()
+
+	archiveHash := sha256.New()
+	gzipWriter := gzip.NewWriter(io.MultiWriter(file, archiveHash))
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, name := range files {
+		if err = addArchiveFile(tarWriter, dir, name); err != nil {
+			file.Close()
+
+			return "", err
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		file.Close()
+
+		return "", aoserrors.Wrap(err)
+	}
+
+	if err = gzipWriter.Close(); err != nil {
+		file.Close()
+
+		return "", aoserrors.Wrap(err)
+	}
+
+	if err = file.Close(); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	if err = os.Rename(tmpFile, fileName); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return hex.EncodeToString(archiveHash.Sum(nil)), nil
+}
+
+func addArchiveFile(tarWriter *tar.Writer, dir, name string) error {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	header, err := tar.FileInfoHeader(stat, "")
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	header.Name = name
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if _, err = io.Copy(tarWriter, file); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func extractArchive(fileName, dir string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer gzipReader.Close()
+
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if header.Typeflag != tar.TypeReg || (header.Name != manifestFileName && !isStateFile(header.Name)) {
+			return aoserrors.Errorf("unexpected state archive entry: %s", header.Name)
+		}
+
+		if err = extractArchiveFile(tarReader, filepath.Join(dir, header.Name)); err != nil {
+			return err
+		}
+	}
+}
+
+func extractArchiveFile(reader io.Reader, fileName string) error {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, io.LimitReader(reader, maxStateFileSize)); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(dst, data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
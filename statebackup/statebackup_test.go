@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statebackup_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/statebackup"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	testDatabaseData   = "test database data"
+	testUnitConfigData = `{"formatVersion": 1, "version": "1.0.0"}`
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testStorage struct {
+	data string
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var tmpDir string
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Main
+ **********************************************************************************************************************/
+
+func TestMain(m *testing.M) {
+	var err error
+
+	tmpDir, err = os.MkdirTemp("", "cm_")
+	if err != nil {
+		log.Fatalf("Error create temporary dir: %v", err)
+	}
+
+	ret := m.Run()
+
+	if err = os.RemoveAll(tmpDir); err != nil {
+		log.Fatalf("Error cleaning up: %v", err)
+	}
+
+	os.Exit(ret)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestExportImportState(t *testing.T) {
+	srcConfig := createTestConfig(t, "src")
+	dstConfig := createTestConfig(t, "dst")
+
+	if err := os.WriteFile(srcConfig.UnitConfigFile, []byte(testUnitConfigData), 0o600); err != nil {
+		t.Fatalf("Can't write unit config: %v", err)
+	}
+
+	srcBackup, err := statebackup.New(srcConfig, &testStorage{data: testDatabaseData})
+	if err != nil {
+		t.Fatalf("Can't create state backup: %v", err)
+	}
+
+	hash, err := srcBackup.ExportState("state.tar.gz")
+	if err != nil {
+		t.Fatalf("Can't export state: %v", err)
+	}
+
+	archiveFile := filepath.Join(dstConfig.StateBackupDir, "state.tar.gz")
+
+	if err = os.MkdirAll(dstConfig.StateBackupDir, 0o700); err != nil {
+		t.Fatalf("Can't create backup dir: %v", err)
+	}
+
+	if err = os.Rename(filepath.Join(srcConfig.StateBackupDir, "state.tar.gz"), archiveFile); err != nil {
+		t.Fatalf("Can't move archive: %v", err)
+	}
+
+	dstBackup, err := statebackup.New(dstConfig, &testStorage{})
+	if err != nil {
+		t.Fatalf("Can't create state backup: %v", err)
+	}
+
+	if err = dstBackup.ImportState(archiveFile, "wrongHash"); err == nil {
+		t.Error("Error expected for wrong archive hash")
+	}
+
+	if err = dstBackup.ImportState(archiveFile, hash); err != nil {
+		t.Fatalf("Can't import state: %v", err)
+	}
+
+	var importedDatabase string
+
+	if err = statebackup.ApplyPendingState(dstConfig, func(config *config.Config, fileName string) error {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		importedDatabase = string(data)
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Can't apply pending state: %v", err)
+	}
+
+	if importedDatabase != testDatabaseData {
+		t.Errorf("Wrong imported database: %s", importedDatabase)
+	}
+
+	unitConfigData, err := os.ReadFile(dstConfig.UnitConfigFile)
+	if err != nil {
+		t.Fatalf("Can't read unit config: %v", err)
+	}
+
+	if string(unitConfigData) != testUnitConfigData {
+		t.Errorf("Wrong imported unit config: %s", string(unitConfigData))
+	}
+
+	// Pending state should be applied only once
+	if err = statebackup.ApplyPendingState(dstConfig, func(config *config.Config, fileName string) error {
+		return aoserrors.New("unexpected database import")
+	}); err != nil {
+		t.Errorf("Can't apply pending state: %v", err)
+	}
+}
+
+func TestExportStateRestrictions(t *testing.T) {
+	cfg := createTestConfig(t, "restrictions")
+
+	backup, err := statebackup.New(cfg, &testStorage{data: testDatabaseData})
+	if err != nil {
+		t.Fatalf("Can't create state backup: %v", err)
+	}
+
+	for _, fileName := range []string{
+		filepath.Join(tmpDir, "state.tar.gz"), "../state.tar.gz", "dir/state.tar.gz", "",
+	} {
+		if _, err = backup.ExportState(fileName); !errors.Is(err, statebackup.ErrWrongPath) {
+			t.Errorf("Wrong path error expected for %s: %v", fileName, err)
+		}
+
+		if err = backup.ImportState(fileName, ""); !errors.Is(err, statebackup.ErrWrongPath) {
+			t.Errorf("Wrong path error expected for %s: %v", fileName, err)
+		}
+	}
+
+	if _, err = backup.ExportState(filepath.Join(cfg.StateBackupDir, "state.tar.gz")); err != nil {
+		t.Errorf("Can't export state: %v", err)
+	}
+
+	cfg.DatabaseEncryption.Enabled = true
+
+	if _, err = backup.ExportState("state.tar.gz"); !errors.Is(err, statebackup.ErrEncryptionEnabled) {
+		t.Errorf("Encryption enabled error expected: %v", err)
+	}
+}
+
+func TestImportCorruptedState(t *testing.T) {
+	cfg := createTestConfig(t, "corrupted")
+
+	if err := os.MkdirAll(cfg.StateBackupDir, 0o700); err != nil {
+		t.Fatalf("Can't create backup dir: %v", err)
+	}
+
+	archiveFile := filepath.Join(cfg.StateBackupDir, "corrupted.tar.gz")
+
+	if err := os.WriteFile(archiveFile, []byte("corrupted archive"), 0o600); err != nil {
+		t.Fatalf("Can't write archive: %v", err)
+	}
+
+	backup, err := statebackup.New(cfg, &testStorage{})
+	if err != nil {
+		t.Fatalf("Can't create state backup: %v", err)
+	}
+
+	if err = backup.ImportState(archiveFile, ""); err == nil {
+		t.Error("Error expected for corrupted archive")
+	}
+
+	if _, err = os.Stat(filepath.Join(cfg.WorkingDir, "pendingstate")); err == nil {
+		t.Error("Corrupted state should not be staged")
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func createTestConfig(t *testing.T, name string) *config.Config {
+	t.Helper()
+
+	workingDir := filepath.Join(tmpDir, name)
+
+	if err := os.MkdirAll(workingDir, 0o755); err != nil {
+		t.Fatalf("Can't create working dir: %v", err)
+	}
+
+	return &config.Config{
+		WorkingDir: workingDir, UnitConfigFile: filepath.Join(workingDir, "aos_unit.cfg"),
+		StateBackupDir: filepath.Join(workingDir, "backup"),
+	}
+}
+
+func (storage *testStorage) ExportSnapshot(fileName string) error {
+	if err := os.WriteFile(fileName, []byte(storage.data), 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statestream provides stream of CM state changes to external agents.
+//
+// State mutations (networks, instances and update state) committed to the storage are published to the stream and
+// numbered by sequence. The stream keeps the configured number of last changes, so a subscriber can resume from the
+// last received change. Sequence numbers are valid within the stream epoch which is changed on each CM start.
+package statestream
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// State change kinds.
+const (
+	KindNetwork         = "network"
+	KindInstance        = "instance"
+	KindInstanceNetwork = "instanceNetwork"
+	KindFOTAState       = "fotaState"
+	KindSOTAState       = "sotaState"
+)
+
+// State change operations.
+const (
+	OperationSet    = "set"
+	OperationRemove = "remove"
+)
+
+const subscriberChannelSize = 64
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrSequenceExpired is returned when requested changes are not available anymore.
+var ErrSequenceExpired = errors.New("sequence expired")
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Change state change.
+type Change struct {
+	Epoch     string          `json:"epoch"`
+	Sequence  uint64          `json:"sequence"`
+	Timestamp time.Time       `json:"timestamp"`
+	Kind      string          `json:"kind"`
+	Operation string          `json:"operation"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// Stream state change stream.
+type Stream struct {
+	sync.Mutex
+
+	epoch       string
+	sequence    uint64
+	historySize int
+	history     []Change
+	subscribers []chan Change
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates state change stream.
+func New(cfg config.StateStream) *Stream {
+	stream := &Stream{epoch: uuid.NewString(), historySize: cfg.HistorySize}
+
+	log.WithFields(log.Fields{"epoch": stream.epoch, "historySize": cfg.HistorySize}).Debug("Create state stream")
+
+	return stream
+}
+
+// Publish publishes state change. Data contains the changed object for set operation and its key for remove one.
+func (stream *Stream) Publish(kind, operation string, data any) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		log.WithField("kind", kind).Errorf("Can't marshal state change: %v", aoserrors.Wrap(err))
+
+		return
+	}
+
+	stream.Lock()
+	defer stream.Unlock()
+
+	stream.sequence++
+
+	change := Change{
+		Epoch: stream.epoch, Sequence: stream.sequence, Timestamp: time.Now().UTC(), Kind: kind,
+		Operation: operation, Data: dataJSON,
+	}
+
+	log.WithFields(log.Fields{
+		"sequence": change.Sequence, "kind": kind, "operation": operation,
+	}).Debug("Publish state change")
+
+	stream.history = append(stream.history, change)
+
+	if len(stream.history) > stream.historySize {
+		stream.history = stream.history[len(stream.history)-stream.historySize:]
+	}
+
+	subscribers := stream.subscribers[:0]
+
+	for _, subscriber := range stream.subscribers {
+		select {
+		case subscriber <- change:
+			subscribers = append(subscribers, subscriber)
+
+		default:
+			log.Warn("State stream subscriber is too slow, drop it")
+
+			close(subscriber)
+		}
+	}
+
+	stream.subscribers = subscribers
+}
+
+// Subscribe subscribes to state changes. If since is set, changes of the epoch following the since sequence are
+// returned as history, otherwise only new changes are sent to the channel. The channel is closed if the subscriber
+// doesn't read changes in time, in this case it should resubscribe from the last received sequence.
+func (stream *Stream) Subscribe(epoch string, since uint64) (history []Change, channel <-chan Change, err error) {
+	stream.Lock()
+	defer stream.Unlock()
+
+	if since != 0 {
+		firstSequence := stream.sequence - uint64(len(stream.history)) + 1
+
+		if epoch != stream.epoch || since > stream.sequence || since+1 < firstSequence {
+			return nil, nil, aoserrors.Wrap(ErrSequenceExpired)
+		}
+
+		history = append(history, stream.history[since+1-firstSequence:]...)
+	}
+
+	subscriber := make(chan Change, subscriberChannelSize)
+
+	stream.subscribers = append(stream.subscribers, subscriber)
+
+	return history, subscriber, nil
+}
+
+// Position returns epoch and sequence of the last published change.
+func (stream *Stream) Position() (epoch string, sequence uint64) {
+	stream.Lock()
+	defer stream.Unlock()
+
+	return stream.epoch, stream.sequence
+}
+
+// Changes returns changes of the epoch following the since sequence. Zero since requests all changes of the epoch.
+func (stream *Stream) Changes(epoch string, since uint64) (changes []Change, err error) {
+	stream.Lock()
+	defer stream.Unlock()
+
+	firstSequence := stream.sequence - uint64(len(stream.history)) + 1
+
+	if epoch != stream.epoch || since > stream.sequence || since+1 < firstSequence {
+		return nil, aoserrors.Wrap(ErrSequenceExpired)
+	}
+
+	return append(changes, stream.history[since+1-firstSequence:]...), nil
+}
+
+// Unsubscribe unsubscribes from state changes.
+func (stream *Stream) Unsubscribe(channel <-chan Change) {
+	stream.Lock()
+	defer stream.Unlock()
+
+	for i, subscriber := range stream.subscribers {
+		if subscriber == channel {
+			stream.subscribers = append(stream.subscribers[:i], stream.subscribers[i+1:]...)
+
+			close(subscriber)
+
+			return
+		}
+	}
+}
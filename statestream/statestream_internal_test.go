@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statestream
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestStream(t *testing.T) {
+	stream := New(config.StateStream{HistorySize: 3})
+
+	history, channel, err := stream.Subscribe("", 0)
+	if err != nil {
+		t.Fatalf("Can't subscribe: %v", err)
+	}
+
+	if len(history) != 0 {
+		t.Errorf("Unexpected history: %v", history)
+	}
+
+	for i := 0; i < 5; i++ {
+		stream.Publish(KindNetwork, OperationSet, map[string]int{"index": i})
+	}
+
+	for i := 1; i <= 5; i++ {
+		change := <-channel
+
+		if change.Sequence != uint64(i) || change.Epoch != stream.epoch || change.Kind != KindNetwork ||
+			change.Operation != OperationSet {
+			t.Errorf("Wrong change: %v", change)
+		}
+	}
+
+	stream.Unsubscribe(channel)
+
+	if _, ok := <-channel; ok {
+		t.Error("Channel should be closed")
+	}
+
+	if history, channel, err = stream.Subscribe(stream.epoch, 3); err != nil {
+		t.Fatalf("Can't subscribe: %v", err)
+	}
+	defer stream.Unsubscribe(channel)
+
+	if len(history) != 2 || history[0].Sequence != 4 || history[1].Sequence != 5 {
+		t.Errorf("Wrong history: %v", history)
+	}
+
+	if history, _, err = stream.Subscribe(stream.epoch, 5); err != nil || len(history) != 0 {
+		t.Errorf("Wrong history: %v, err: %v", history, err)
+	}
+
+	for _, since := range []uint64{1, 6} {
+		if _, _, err = stream.Subscribe(stream.epoch, since); !errors.Is(err, ErrSequenceExpired) {
+			t.Errorf("Sequence expired error expected for %d: %v", since, err)
+		}
+	}
+
+	if _, _, err = stream.Subscribe("unknown", 4); !errors.Is(err, ErrSequenceExpired) {
+		t.Errorf("Sequence expired error expected for unknown epoch: %v", err)
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	stream := New(config.StateStream{HistorySize: 1})
+
+	_, channel, err := stream.Subscribe("", 0)
+	if err != nil {
+		t.Fatalf("Can't subscribe: %v", err)
+	}
+
+	for i := 0; i <= subscriberChannelSize; i++ {
+		stream.Publish(KindInstance, OperationRemove, i)
+	}
+
+	received := 0
+
+	for range channel {
+		received++
+	}
+
+	if received != subscriberChannelSize {
+		t.Errorf("Wrong received changes count: %d", received)
+	}
+
+	if len(stream.subscribers) != 0 {
+		t.Error("Slow subscriber should be dropped")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	stream := New(config.StateStream{HistorySize: 3})
+
+	epoch, sequence := stream.Position()
+	if epoch != stream.epoch || sequence != 0 {
+		t.Errorf("Wrong position: %s, %d", epoch, sequence)
+	}
+
+	for i := 0; i < 2; i++ {
+		stream.Publish(KindInstance, OperationSet, i)
+	}
+
+	changes, err := stream.Changes(epoch, 0)
+	if err != nil {
+		t.Fatalf("Can't get changes: %v", err)
+	}
+
+	if len(changes) != 2 || changes[0].Sequence != 1 || changes[1].Sequence != 2 {
+		t.Errorf("Wrong changes: %v", changes)
+	}
+
+	for i := 0; i < 2; i++ {
+		stream.Publish(KindInstance, OperationSet, i)
+	}
+
+	if _, sequence = stream.Position(); sequence != 4 {
+		t.Errorf("Wrong sequence: %d", sequence)
+	}
+
+	if changes, err = stream.Changes(epoch, 2); err != nil || len(changes) != 2 || changes[0].Sequence != 3 {
+		t.Errorf("Wrong changes: %v, err: %v", changes, err)
+	}
+
+	for _, since := range []uint64{0, 5} {
+		if _, err = stream.Changes(epoch, since); !errors.Is(err, ErrSequenceExpired) {
+			t.Errorf("Sequence expired error expected for %d: %v", since, err)
+		}
+	}
+
+	if _, err = stream.Changes("unknown", 4); !errors.Is(err, ErrSequenceExpired) {
+		t.Errorf("Sequence expired error expected for unknown epoch: %v", err)
+	}
+}
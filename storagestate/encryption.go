@@ -0,0 +1,558 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagestate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/utils/shred"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	keysDirName = "keys"
+	keyFileExt  = ".key"
+	// suffixes of new encrypted and replaced not encrypted data during migration.
+	encryptingDirSuffix = ".encrypting"
+	plainDirSuffix      = ".plain"
+	// fscrypt v2 master key size.
+	instanceKeySize = 64
+)
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// These global variables are used to be able to mocking the functionality in tests.
+//
+//nolint:gochecknoglobals
+var (
+	AddFSEncryptionKey    = fscryptAddKey
+	RemoveFSEncryptionKey = fscryptRemoveKey
+	SetFSEncryptionPolicy = fscryptSetPolicy
+	GetFSEncryptionPolicy = fscryptGetPolicy
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// CertificateProvider provides certificate which key is used to wrap instance encryption keys.
+type CertificateProvider interface {
+	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
+}
+
+// CryptoContext loads certificates and keys.
+type CryptoContext interface {
+	LoadCertificateByURL(certURL string) ([]*x509.Certificate, error)
+	LoadPrivateKeyByURL(keyURL string) (privKey crypto.PrivateKey, supportPKCS1v15SessionKey bool, err error)
+}
+
+// Instance storage and state directories are encrypted by fscrypt with random per-instance key. The key is wrapped
+// with the public key of the configured certificate (RSA-OAEP) and stored in the key file. Removing the key file
+// destroys the instance data.
+type instanceKey struct {
+	Issuer     []byte `json:"issuer"`
+	Serial     string `json:"serial"`
+	WrappedKey []byte `json:"wrappedKey"`
+	KeyID      []byte `json:"keyId"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// prepareEncryption unlocks instance key and sets encryption policy on instance storage and state directories.
+// Existing not encrypted storage and state are migrated into encrypted directories.
+func (storageState *StorageState) prepareEncryption(instanceID string, params SetupParams) error {
+	keyID, err := storageState.unlockInstance(instanceID, true)
+	if err != nil {
+		return err
+	}
+
+	if params.StorageQuota != 0 {
+		if err = storageState.prepareEncryptedDir(storageState.getStoragePath(instanceID), keyID); err != nil {
+			return err
+		}
+	}
+
+	if params.StateQuota != 0 {
+		if err = storageState.prepareEncryptedDir(storageState.getInstanceStateDir(instanceID), keyID); err != nil {
+			return err
+		}
+
+		if err = storageState.migratePlainState(instanceID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unlockInstance adds instance key to the filesystems. New key is generated if create is set and there is no key.
+func (storageState *StorageState) unlockInstance(instanceID string, create bool) (keyID []byte, err error) {
+	key, err := storageState.loadInstanceKey(instanceID)
+	if err != nil {
+		if !create || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		return storageState.createInstanceKey(instanceID)
+	}
+
+	rawKey, err := storageState.unwrapInstanceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(rawKey)
+
+	if _, err = storageState.addFSKey(rawKey); err != nil {
+		return nil, err
+	}
+
+	return key.KeyID, nil
+}
+
+// destroyInstanceKey removes instance key from the filesystems and deletes the key file.
+func (storageState *StorageState) destroyInstanceKey(instanceID string) error {
+	key, err := storageState.loadInstanceKey(instanceID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, mountPoint := range storageState.getMountPoints() {
+		if err = RemoveFSEncryptionKey(mountPoint, key.KeyID); err != nil {
+			log.WithField("instanceID", instanceID).Warnf("Can't remove FS encryption key: %v", err)
+		}
+	}
+
+	log.WithField("instanceID", instanceID).Debug("Destroy instance key")
+
+	if err = os.Remove(storageState.getKeyFile(instanceID)); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (storageState *StorageState) createInstanceKey(instanceID string) (keyID []byte, err error) {
+	rawKey := make([]byte, instanceKeySize)
+	defer clear(rawKey)
+
+	if _, err = io.ReadFull(rand.Reader, rawKey); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	key, err := storageState.wrapInstanceKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.KeyID, err = storageState.addFSKey(rawKey); err != nil {
+		return nil, err
+	}
+
+	if err = storageState.saveInstanceKey(instanceID, key); err != nil {
+		return nil, err
+	}
+
+	return key.KeyID, nil
+}
+
+func (storageState *StorageState) addFSKey(rawKey []byte) (keyID []byte, err error) {
+	for _, mountPoint := range storageState.getMountPoints() {
+		if keyID, err = AddFSEncryptionKey(mountPoint, rawKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyID, nil
+}
+
+func (storageState *StorageState) wrapInstanceKey(rawKey []byte) (key instanceKey, err error) {
+	certURL, _, err := storageState.certProvider.GetCertificate(storageState.encryptionCertType, nil, "")
+	if err != nil {
+		return key, aoserrors.Wrap(err)
+	}
+
+	certs, err := storageState.cryptoContext.LoadCertificateByURL(certURL)
+	if err != nil {
+		return key, aoserrors.Wrap(err)
+	}
+
+	if len(certs) == 0 {
+		return key, aoserrors.New("storage encryption certificate not found")
+	}
+
+	publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return key, aoserrors.New("storage encryption requires RSA certificate")
+	}
+
+	key.Issuer = certs[0].RawIssuer
+	key.Serial = fmt.Sprintf("%X", certs[0].SerialNumber)
+
+	if key.WrappedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, rawKey, nil); err != nil {
+		return key, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (storageState *StorageState) unwrapInstanceKey(key instanceKey) ([]byte, error) {
+	_, keyURL, err := storageState.certProvider.GetCertificate(storageState.encryptionCertType, key.Issuer, key.Serial)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	privKey, _, err := storageState.cryptoContext.LoadPrivateKeyByURL(keyURL)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	decrypter, ok := privKey.(crypto.Decrypter)
+	if !ok {
+		return nil, aoserrors.New("private key doesn't support decryption")
+	}
+
+	rawKey, err := decrypter.Decrypt(rand.Reader, key.WrappedKey, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return rawKey, nil
+}
+
+func (storageState *StorageState) loadInstanceKey(instanceID string) (key instanceKey, err error) {
+	data, err := os.ReadFile(storageState.getKeyFile(instanceID))
+	if err != nil {
+		return key, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, &key); err != nil {
+		return key, aoserrors.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (storageState *StorageState) saveInstanceKey(instanceID string, key instanceKey) error {
+	if err := os.MkdirAll(filepath.Dir(storageState.getKeyFile(instanceID)), 0o700); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(storageState.getKeyFile(instanceID), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (storageState *StorageState) getMountPoints() []string {
+	if storageState.isSamePartition {
+		return []string{storageState.storageMountPoint}
+	}
+
+	return []string{storageState.storageMountPoint, storageState.stateMountPoint}
+}
+
+func (storageState *StorageState) getKeyFile(instanceID string) string {
+	return filepath.Join(storageState.stateDir, keysDirName, instanceID+keyFileExt)
+}
+
+func (storageState *StorageState) getInstanceStateDir(instanceID string) string {
+	return filepath.Join(storageState.stateDir, instanceID)
+}
+
+// prepareEncryptedDir sets encryption policy on new or empty directory. Policy can't be set on directory with content,
+// so existing not encrypted directory is migrated: its content is copied into new encrypted directory which replaces
+// it, then not encrypted data is shredded.
+func (storageState *StorageState) prepareEncryptedDir(dir string, keyID []byte) error {
+	if err := storageState.recoverMigration(dir); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return aoserrors.Wrap(err)
+		}
+
+		return createEncryptedDir(dir, keyID)
+	}
+
+	policyKeyID, err := GetFSEncryptionPolicy(dir)
+	if err != nil {
+		return err
+	}
+
+	if policyKeyID != nil {
+		if !bytes.Equal(policyKeyID, keyID) {
+			return aoserrors.Errorf("directory %s is encrypted with other key", dir)
+		}
+
+		return nil
+	}
+
+	if len(entries) == 0 {
+		return SetFSEncryptionPolicy(dir, keyID)
+	}
+
+	return storageState.migrateToEncryptedDir(dir, keyID)
+}
+
+// migrateToEncryptedDir copies content of not encrypted directory into new encrypted directory and replaces it.
+func (storageState *StorageState) migrateToEncryptedDir(dir string, keyID []byte) error {
+	log.WithField("dir", dir).Info("Migrate directory to encrypted")
+
+	encryptingDir := dir + encryptingDirSuffix
+
+	if err := createEncryptedDir(encryptingDir, keyID); err != nil {
+		return err
+	}
+
+	if err := copyDir(dir, encryptingDir); err != nil {
+		return err
+	}
+
+	plainDir := dir + plainDirSuffix
+
+	if err := os.Rename(dir, plainDir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := os.Rename(encryptingDir, dir); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return aoserrors.Wrap(shred.RemoveAll(plainDir, storageState.wipePasses))
+}
+
+// recoverMigration completes or rolls back interrupted migration of the directory.
+func (storageState *StorageState) recoverMigration(dir string) error {
+	plainDir := dir + plainDirSuffix
+
+	if _, err := os.Stat(plainDir); err == nil {
+		if _, err = os.Stat(dir); err != nil {
+			// interrupted between renames: encrypted copy is complete
+			if err = os.Rename(dir+encryptingDirSuffix, dir); err != nil {
+				return aoserrors.Wrap(err)
+			}
+		}
+
+		log.WithField("dir", dir).Warn("Complete interrupted migration to encrypted directory")
+
+		if err = shred.RemoveAll(plainDir, storageState.wipePasses); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	// partial encrypted copy is recreated by migration
+	if err := os.RemoveAll(dir + encryptingDirSuffix); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// migratePlainState moves not encrypted state file and snapshots into encrypted instance state directory.
+func (storageState *StorageState) migratePlainState(instanceID string) error {
+	for plainPath, encryptedPath := range map[string]string{
+		filepath.Join(storageState.stateDir, fmt.Sprintf(stateFileFormat, instanceID)): storageState.getStatePath(
+			instanceID),
+		filepath.Join(storageState.stateDir, snapshotsDirName, instanceID): storageState.getSnapshotsDir(instanceID),
+	} {
+		// shred not encrypted data left by interrupted migration, encrypted copy is already complete
+		if err := shred.RemoveAll(plainPath+plainDirSuffix, storageState.wipePasses); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		info, err := os.Lstat(plainPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return aoserrors.Wrap(err)
+		}
+
+		log.WithFields(log.Fields{"instanceID": instanceID, "path": plainPath}).Info("Migrate state to encrypted")
+
+		if err = os.RemoveAll(encryptedPath); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if info.IsDir() {
+			if err = os.Mkdir(encryptedPath, info.Mode().Perm()); err != nil {
+				return aoserrors.Wrap(err)
+			}
+
+			err = copyDir(plainPath, encryptedPath)
+		} else {
+			err = copyFile(plainPath, encryptedPath, info)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = os.Rename(plainPath, plainPath+plainDirSuffix); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = shred.RemoveAll(plainPath+plainDirSuffix, storageState.wipePasses); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func createEncryptedDir(dir string, keyID []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := SetFSEncryptionPolicy(dir, keyID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// copyDir copies content of source directory into existing destination directory preserving modes and owners.
+func copyDir(srcDir, dstDir string) error {
+	if err := filepath.WalkDir(srcDir, func(srcPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcDir, srcPath)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		dstPath := filepath.Join(dstDir, relPath)
+
+		switch {
+		case relPath == ".":
+			if err = os.Chmod(dstPath, info.Mode().Perm()); err != nil {
+				return err
+			}
+
+		case entry.IsDir():
+			if err = os.Mkdir(dstPath, info.Mode().Perm()); err != nil {
+				return err
+			}
+
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+
+			if err = os.Symlink(target, dstPath); err != nil {
+				return err
+			}
+
+		case entry.Type().IsRegular():
+			return copyFile(srcPath, dstPath, info)
+
+		default:
+			log.WithField("path", srcPath).Warn("Skip special file on migration")
+
+			return nil
+		}
+
+		return chownAsSource(dstPath, info)
+	}); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func copyFile(srcPath, dstPath string, info fs.FileInfo) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer dstFile.Close()
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = dstFile.Sync(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return chownAsSource(dstPath, info)
+}
+
+func chownAsSource(dstPath string, info fs.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Lchown(dstPath, int(stat.Uid), int(stat.Gid)); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagestate
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"unsafe"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"golang.org/x/sys/unix"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// fscryptAddKeyArg is fscrypt_add_key_arg followed by the raw key.
+type fscryptAddKeyArg struct {
+	unix.FscryptAddKeyArg
+	raw [unix.FSCRYPT_MAX_KEY_SIZE]byte
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// fscryptAddKey adds fscrypt v2 key to the filesystem and returns key identifier.
+func fscryptAddKey(mountPoint string, key []byte) (keyID []byte, err error) {
+	var arg fscryptAddKeyArg
+
+	if len(key) > len(arg.raw) {
+		return nil, aoserrors.New("fscrypt key is too long")
+	}
+
+	defer clear(arg.raw[:])
+
+	arg.Key_spec.Type = unix.FSCRYPT_KEY_SPEC_TYPE_IDENTIFIER
+	arg.Raw_size = uint32(len(key)) //nolint:gosec
+	copy(arg.raw[:], key)
+
+	if err = fscryptIoctl(mountPoint, unix.FS_IOC_ADD_ENCRYPTION_KEY, unsafe.Pointer(&arg)); err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(arg.Key_spec.U[:unix.FSCRYPT_KEY_IDENTIFIER_SIZE]), nil
+}
+
+// fscryptRemoveKey removes fscrypt v2 key from the filesystem. Files encrypted by the key become inaccessible.
+func fscryptRemoveKey(mountPoint string, keyID []byte) error {
+	var arg unix.FscryptRemoveKeyArg
+
+	arg.Key_spec.Type = unix.FSCRYPT_KEY_SPEC_TYPE_IDENTIFIER
+	copy(arg.Key_spec.U[:], keyID)
+
+	return fscryptIoctl(mountPoint, unix.FS_IOC_REMOVE_ENCRYPTION_KEY, unsafe.Pointer(&arg))
+}
+
+// fscryptSetPolicy sets fscrypt v2 policy on the empty directory. Setting the same policy again succeeds.
+func fscryptSetPolicy(dir string, keyID []byte) error {
+	policy := unix.FscryptPolicyV2{
+		Version:                   unix.FSCRYPT_POLICY_V2,
+		Contents_encryption_mode:  unix.FSCRYPT_MODE_AES_256_XTS,
+		Filenames_encryption_mode: unix.FSCRYPT_MODE_AES_256_CTS,
+		Flags:                     unix.FSCRYPT_POLICY_FLAGS_PAD_32,
+	}
+
+	copy(policy.Master_key_identifier[:], keyID)
+
+	return fscryptIoctl(dir, unix.FS_IOC_SET_ENCRYPTION_POLICY, unsafe.Pointer(&policy))
+}
+
+// fscryptGetPolicy returns key identifier of fscrypt v2 policy of the directory or nil if it is not encrypted.
+func fscryptGetPolicy(dir string) (keyID []byte, err error) {
+	var arg unix.FscryptGetPolicyExArg
+
+	arg.Size = uint64(len(arg.Policy))
+
+	if err = fscryptIoctl(dir, unix.FS_IOC_GET_ENCRYPTION_POLICY_EX, unsafe.Pointer(&arg)); err != nil {
+		if errors.Is(err, unix.ENODATA) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if arg.Policy[0] != unix.FSCRYPT_POLICY_V2 {
+		return nil, aoserrors.New("unsupported fscrypt policy version")
+	}
+
+	policy := (*unix.FscryptPolicyV2)(unsafe.Pointer(&arg.Policy))
+
+	return slices.Clone(policy.Master_key_identifier[:]), nil
+}
+
+func fscryptIoctl(path string, request uintptr, arg unsafe.Pointer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, file.Fd(), request, uintptr(arg)); errno != 0 {
+		return aoserrors.Wrap(errno)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagestate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/aosedge/aos_common/resourcemonitor"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Quota enforcement actions.
+const (
+	// QuotaActionAlert only raises alerts when quota is exceeded.
+	QuotaActionAlert = "alert"
+	// QuotaActionBlock blocks writes above quota by FS quotas.
+	QuotaActionBlock = "block"
+	// QuotaActionEvict stops instance exceeding the quota till next desired instances are received. Instance data is
+	// kept.
+	QuotaActionEvict = "evict"
+)
+
+const (
+	quotaParameterStorage = "storage"
+	quotaParameterState   = "state"
+	percents              = 100
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// InstanceStopper stops instances exceeding the quota with evict action.
+type InstanceStopper interface {
+	StopInstance(ident aostypes.InstanceIdent, reason string, duration time.Duration) error
+}
+
+type quotaParams struct {
+	storagePath   string
+	stateFilePath string
+	storageQuota  uint64
+	stateQuota    uint64
+	storageLevel  int
+	stateLevel    int
+	exceeded      bool
+	stopped       bool
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetQuotaEnforcement returns enforcement action applied to the instance if its storage or state quota is exceeded.
+func (storageState *StorageState) GetQuotaEnforcement(instanceIdent aostypes.InstanceIdent) string {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	quota, ok := storageState.quotasMap[instanceIdent]
+	if !ok || !quota.exceeded {
+		return ""
+	}
+
+	return storageState.quotaAction
+}
+
+// SetInstanceStopper sets instance stopper used by evict quota action.
+func (storageState *StorageState) SetInstanceStopper(stopper InstanceStopper) {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	storageState.instanceStopper = stopper
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (storageState *StorageState) initQuotaConfig(cfg config.StorageQuota) error {
+	switch cfg.Action {
+	case "", QuotaActionBlock:
+		storageState.quotaAction = QuotaActionBlock
+
+	case QuotaActionAlert, QuotaActionEvict:
+		storageState.quotaAction = cfg.Action
+
+	default:
+		return aoserrors.Errorf("unsupported quota action: %s", cfg.Action)
+	}
+
+	for _, threshold := range cfg.AlertThresholds {
+		if threshold <= 0 {
+			return aoserrors.Errorf("wrong quota alert threshold: %d", threshold)
+		}
+	}
+
+	storageState.alertThresholds = slices.Clone(cfg.AlertThresholds)
+
+	slices.Sort(storageState.alertThresholds)
+
+	return nil
+}
+
+// setQuotaParams sets quota params on instance setup. Quota usage state is reset if the params are changed. Evicted
+// instance is started again on setup, so it is stopped again if the quota is still exceeded.
+func (storageState *StorageState) setQuotaParams(
+	instanceIdent aostypes.InstanceIdent, storagePath, stateFilePath string, storageQuota, stateQuota uint64,
+) {
+	newQuota := &quotaParams{
+		storagePath: storagePath, stateFilePath: stateFilePath, storageQuota: storageQuota, stateQuota: stateQuota,
+	}
+
+	if quota, ok := storageState.quotasMap[instanceIdent]; ok && quota.isSameParams(newQuota) {
+		quota.stopped = false
+
+		return
+	}
+
+	storageState.quotasMap[instanceIdent] = newQuota
+}
+
+// checkQuotas checks quotas usage. Usage is calculated without lock as walking big storages takes time.
+func (storageState *StorageState) checkQuotas() {
+	for instanceIdent, quota := range storageState.getQuotas() {
+		var storageUsage, stateUsage uint64
+
+		if quota.storageQuota != 0 {
+			usage, err := getDirSize(quota.storagePath)
+			if err != nil {
+				log.WithFields(instanceLogFields(instanceIdent)).Errorf("Can't get storage usage: %v", err)
+			}
+
+			storageUsage = usage
+		}
+
+		if quota.stateQuota != 0 {
+			usage, err := getFileSize(quota.stateFilePath)
+			if err != nil {
+				log.WithFields(instanceLogFields(instanceIdent)).Errorf("Can't get state usage: %v", err)
+			}
+
+			stateUsage = usage
+		}
+
+		reason, stopper := storageState.updateQuotaUsage(instanceIdent, quota, storageUsage, stateUsage)
+		if reason == "" {
+			continue
+		}
+
+		// Instance is stopped without lock as launcher tears down its storage and state on stop
+		if err := stopper.StopInstance(instanceIdent, reason, 0); err != nil {
+			log.WithFields(instanceLogFields(instanceIdent)).Errorf("Can't evict instance: %v", err)
+		}
+	}
+}
+
+// getQuotas returns copy of instances quota params.
+func (storageState *StorageState) getQuotas() map[aostypes.InstanceIdent]quotaParams {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	quotas := make(map[aostypes.InstanceIdent]quotaParams, len(storageState.quotasMap))
+
+	for instanceIdent, quota := range storageState.quotasMap {
+		quotas[instanceIdent] = *quota
+	}
+
+	return quotas
+}
+
+// updateQuotaUsage updates quota levels by calculated usage and returns stop reason if the instance should be
+// evicted. Usage is skipped if quota params are changed while it is calculated.
+func (storageState *StorageState) updateQuotaUsage(
+	instanceIdent aostypes.InstanceIdent, checkedQuota quotaParams, storageUsage, stateUsage uint64,
+) (reason string, stopper InstanceStopper) {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	quota, ok := storageState.quotasMap[instanceIdent]
+	if !ok || !quota.isSameParams(&checkedQuota) {
+		return "", nil
+	}
+
+	var storageExceeded, stateExceeded bool
+
+	if quota.storageQuota != 0 {
+		storageExceeded = storageUsage >= quota.storageQuota
+		quota.storageLevel = storageState.updateQuotaLevel(
+			instanceIdent, quotaParameterStorage, storageUsage, quota.storageQuota, quota.storageLevel)
+
+		if storageUsage > quota.storageQuota {
+			reason = fmt.Sprintf("%s quota exceeded", quotaParameterStorage)
+		}
+	}
+
+	if quota.stateQuota != 0 {
+		stateExceeded = stateUsage >= quota.stateQuota
+		quota.stateLevel = storageState.updateQuotaLevel(
+			instanceIdent, quotaParameterState, stateUsage, quota.stateQuota, quota.stateLevel)
+
+		if stateUsage > quota.stateQuota && reason == "" {
+			reason = fmt.Sprintf("%s quota exceeded", quotaParameterState)
+		}
+	}
+
+	quota.exceeded = storageExceeded || stateExceeded
+
+	if reason == "" || quota.stopped || storageState.quotaAction != QuotaActionEvict ||
+		storageState.instanceStopper == nil {
+		return "", nil
+	}
+
+	quota.stopped = true
+
+	return reason, storageState.instanceStopper
+}
+
+func (quota *quotaParams) isSameParams(other *quotaParams) bool {
+	return quota.storagePath == other.storagePath && quota.stateFilePath == other.stateFilePath &&
+		quota.storageQuota == other.storageQuota && quota.stateQuota == other.stateQuota
+}
+
+// updateQuotaLevel returns number of crossed alert thresholds and raises alert if new threshold is crossed.
+func (storageState *StorageState) updateQuotaLevel(
+	instanceIdent aostypes.InstanceIdent, parameter string, usage, quota uint64, prevLevel int,
+) (level int) {
+	usagePercent := usage * percents / quota
+
+	for _, threshold := range storageState.alertThresholds {
+		if usagePercent >= uint64(threshold) {
+			level++
+		}
+	}
+
+	logFields := instanceLogFields(instanceIdent)
+
+	logFields["parameter"] = parameter
+	logFields["usage"] = usage
+	logFields["quota"] = quota
+
+	switch {
+	case level > prevLevel:
+		log.WithFields(logFields).Warn("Quota alert threshold crossed")
+
+		storageState.alertSender.SendAlert(cloudprotocol.InstanceQuotaAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagInstanceQuota},
+			InstanceIdent: instanceIdent,
+			Parameter:     parameter,
+			Value:         usage,
+			Status:        resourcemonitor.AlertStatusRaise,
+		})
+
+	case level == 0 && prevLevel != 0:
+		log.WithFields(logFields).Info("Quota usage is below alert thresholds")
+	}
+
+	return level
+}
+
+func getDirSize(dirPath string) (size uint64, err error) {
+	if err = filepath.WalkDir(dirPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += uint64(info.Size())
+
+		return nil
+	}); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return size, nil
+}
+
+func getFileSize(fileName string) (uint64, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, aoserrors.Wrap(err)
+	}
+
+	return uint64(info.Size()), nil
+}
+
+func instanceLogFields(instanceIdent aostypes.InstanceIdent) log.Fields {
+	return log.Fields{
+		"instance":  instanceIdent.Instance,
+		"serviceID": instanceIdent.ServiceID,
+		"subjectID": instanceIdent.SubjectID,
+	}
+}
@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagestate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/utils/semverutils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const (
+	snapshotsDirName  = "snapshots"
+	snapshotIndexFile = "snapshots.json"
+	snapshotExt       = ".dat"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// StateSnapshotInfo state snapshot info.
+type StateSnapshotInfo struct {
+	ID             string    `json:"id"`
+	ServiceVersion string    `json:"serviceVersion"`
+	Checksum       string    `json:"checksum"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+type snapshotIndex struct {
+	// service version the current state belongs to
+	ServiceVersion string              `json:"serviceVersion"`
+	Snapshots      []StateSnapshotInfo `json:"snapshots"`
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// GetStateSnapshots returns available instance state snapshots starting from the oldest one.
+func (storageState *StorageState) GetStateSnapshots(instanceIdent aostypes.InstanceIdent) ([]StateSnapshotInfo, error) {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	storageStateInfo, err := storageState.storage.GetStorageStateInfo(instanceIdent)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	index, err := storageState.loadSnapshotIndex(storageStateInfo.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.Snapshots, nil
+}
+
+// RestoreStateSnapshot reverts instance state to the snapshot and reports the restored state to the cloud.
+func (storageState *StorageState) RestoreStateSnapshot(instanceIdent aostypes.InstanceIdent, snapshotID string) error {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	state, ok := storageState.statesMap[instanceIdent]
+	if !ok {
+		return ErrNotFound
+	}
+
+	index, err := storageState.loadSnapshotIndex(state.instanceID)
+	if err != nil {
+		return err
+	}
+
+	snapshotIndex := slices.IndexFunc(index.Snapshots, func(snapshot StateSnapshotInfo) bool {
+		return snapshot.ID == snapshotID
+	})
+	if snapshotIndex < 0 {
+		return aoserrors.Errorf("state snapshot %s not found", snapshotID)
+	}
+
+	return storageState.restoreSnapshot(instanceIdent, state, index.Snapshots[snapshotIndex])
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// takeSnapshot saves confirmed state as a new snapshot and removes snapshots above the limit.
+func (storageState *StorageState) takeSnapshot(state *stateParams, data []byte, checksum []byte) error {
+	if storageState.maxSnapshots <= 0 {
+		return nil
+	}
+
+	index, err := storageState.loadSnapshotIndex(state.instanceID)
+	if err != nil {
+		return err
+	}
+
+	if len(index.Snapshots) > 0 &&
+		index.Snapshots[len(index.Snapshots)-1].Checksum == hex.EncodeToString(checksum) {
+		return nil
+	}
+
+	snapshotsDir := storageState.getSnapshotsDir(state.instanceID)
+
+	if err = os.MkdirAll(snapshotsDir, 0o755); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	snapshot := StateSnapshotInfo{
+		ID:             uuid.New().String(),
+		ServiceVersion: index.ServiceVersion,
+		Checksum:       hex.EncodeToString(checksum),
+		Timestamp:      time.Now(),
+	}
+
+	if err = os.WriteFile(filepath.Join(snapshotsDir, snapshot.ID+snapshotExt), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	index.Snapshots = append(index.Snapshots, snapshot)
+
+	for len(index.Snapshots) > storageState.maxSnapshots {
+		if err = os.RemoveAll(filepath.Join(snapshotsDir, index.Snapshots[0].ID+snapshotExt)); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		index.Snapshots = index.Snapshots[1:]
+	}
+
+	return storageState.saveSnapshotIndex(state.instanceID, index)
+}
+
+// snapshotStateFile takes snapshot of the current state file if it matches the accepted checksum.
+func (storageState *StorageState) snapshotStateFile(state *stateParams, checksum []byte) error {
+	data, fileChecksum, err := getFileDataChecksum(state.stateFilePath)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(fileChecksum, checksum) {
+		return aoserrors.New("state file doesn't match accepted checksum")
+	}
+
+	return storageState.takeSnapshot(state, data, checksum)
+}
+
+// processServiceVersion restores state snapshot matching service version if service is rolled back.
+func (storageState *StorageState) processServiceVersion(
+	instanceIdent aostypes.InstanceIdent, state *stateParams, serviceVersion string,
+) error {
+	if storageState.maxSnapshots <= 0 || serviceVersion == "" {
+		return nil
+	}
+
+	index, err := storageState.loadSnapshotIndex(state.instanceID)
+	if err != nil {
+		return err
+	}
+
+	if index.ServiceVersion == serviceVersion {
+		return nil
+	}
+
+	if index.ServiceVersion != "" {
+		// Service versions are not validated to be semver, rollback can't be detected for such versions
+		rollback, err := semverutils.LessThan(serviceVersion, index.ServiceVersion)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"serviceID": instanceIdent.ServiceID, "version": serviceVersion, "prevVersion": index.ServiceVersion,
+			}).Warnf("Can't compare service versions, state snapshot is not restored: %v", err)
+		}
+
+		if rollback {
+			if err = storageState.restoreRollbackSnapshot(instanceIdent, state, index, serviceVersion); err != nil {
+				return err
+			}
+		}
+	}
+
+	index.ServiceVersion = serviceVersion
+
+	return storageState.saveSnapshotIndex(state.instanceID, index)
+}
+
+func (storageState *StorageState) restoreRollbackSnapshot(
+	instanceIdent aostypes.InstanceIdent, state *stateParams, index snapshotIndex, serviceVersion string,
+) error {
+	for i := len(index.Snapshots) - 1; i >= 0; i-- {
+		snapshot := index.Snapshots[i]
+
+		if snapshot.ServiceVersion == "" {
+			continue
+		}
+
+		newer, err := semverutils.GreaterThan(snapshot.ServiceVersion, serviceVersion)
+		if err != nil {
+			log.WithField("snapshotID", snapshot.ID).Warnf("Wrong snapshot service version: %v", err)
+
+			continue
+		}
+
+		if !newer {
+			log.WithFields(instanceLogFields(instanceIdent)).WithField(
+				"version", serviceVersion).Info("Service rollback, restore state snapshot")
+
+			return storageState.restoreSnapshot(instanceIdent, state, snapshot)
+		}
+	}
+
+	log.WithFields(instanceLogFields(instanceIdent)).WithField(
+		"version", serviceVersion).Warn("Service rollback, no state snapshot for the version")
+
+	return nil
+}
+
+func (storageState *StorageState) restoreSnapshot(
+	instanceIdent aostypes.InstanceIdent, state *stateParams, snapshot StateSnapshotInfo,
+) error {
+	data, err := os.ReadFile(
+		filepath.Join(storageState.getSnapshotsDir(state.instanceID), snapshot.ID+snapshotExt))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	checksum, err := hex.DecodeString(snapshot.Checksum)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = checkChecksum(data, checksum); err != nil {
+		return err
+	}
+
+	log.WithFields(instanceLogFields(instanceIdent)).WithField("snapshotID", snapshot.ID).Debug(
+		"Restore state snapshot")
+
+	if bytes.Equal(state.checksum, checksum) {
+		return nil
+	}
+
+	// set checksum before writing to skip file change notification
+	state.checksum = checksum
+
+	if err = os.WriteFile(state.stateFilePath, data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = storageState.storage.SetStateChecksum(instanceIdent, checksum); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return storageState.pushNewStateMessage(instanceIdent, snapshot.Checksum, string(data))
+}
+
+func (storageState *StorageState) loadSnapshotIndex(instanceID string) (index snapshotIndex, err error) {
+	data, err := os.ReadFile(filepath.Join(storageState.getSnapshotsDir(instanceID), snapshotIndexFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return index, nil
+		}
+
+		return index, aoserrors.Wrap(err)
+	}
+
+	if err = json.Unmarshal(data, &index); err != nil {
+		return index, aoserrors.Wrap(err)
+	}
+
+	return index, nil
+}
+
+func (storageState *StorageState) saveSnapshotIndex(instanceID string, index snapshotIndex) error {
+	snapshotsDir := storageState.getSnapshotsDir(instanceID)
+
+	if err := os.MkdirAll(snapshotsDir, 0o755); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(snapshotsDir, snapshotIndexFile), data, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (storageState *StorageState) getSnapshotsDir(instanceID string) string {
+	// keep snapshots of encrypted state encrypted as well
+	if storageState.encryptionEnabled {
+		return filepath.Join(storageState.getInstanceStateDir(instanceID), snapshotsDirName)
+	}
+
+	return filepath.Join(storageState.stateDir, snapshotsDirName, instanceID)
+}
@@ -19,12 +19,14 @@ package storagestate
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +42,7 @@ import (
 
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/utils/shred"
 )
 
 /***********************************************************************************************************************
@@ -88,6 +91,11 @@ type MessageSender interface {
 	SendInstanceStateRequest(request cloudprotocol.StateRequest) error
 }
 
+// AlertSender sends alerts.
+type AlertSender interface {
+	SendAlert(alert interface{})
+}
+
 // Storage storage interface.
 type Storage interface {
 	GetAllStorageStateInfo() ([]StorageStateInstanceInfo, error)
@@ -101,10 +109,14 @@ type Storage interface {
 // SetupParams setup storage state instance params.
 type SetupParams struct {
 	aostypes.InstanceIdent
-	UID          int
-	GID          int
-	StateQuota   uint64
-	StorageQuota uint64
+	UID            int
+	GID            int
+	StateQuota     uint64
+	StorageQuota   uint64
+	ServiceVersion string
+	// Storage is used to store storage and state info instead of storagestate storage if set, e.g. to perform setup
+	// within the caller transaction.
+	Storage Storage
 }
 
 // StateChangedInfo contains state changed information.
@@ -117,10 +129,26 @@ type StateChangedInfo struct {
 type StorageState struct {
 	sync.Mutex
 	messageSender       MessageSender
+	alertSender         AlertSender
 	storageDir          string
 	stateDir            string
 	storage             Storage
 	statesMap           map[aostypes.InstanceIdent]*stateParams
+	quotasMap           map[aostypes.InstanceIdent]*quotaParams
+	quotaAction         string
+	alertThresholds     []int
+	instanceStopper     InstanceStopper
+	maxSnapshots        int
+	encryptionEnabled   bool
+	encryptionCertType  string
+	wipePasses          int
+	certProvider        CertificateProvider
+	cryptoContext       CryptoContext
+	storageMountPoint   string
+	stateMountPoint     string
+	jobsCtx             context.Context //nolint:containedctx
+	jobsCancel          context.CancelFunc
+	jobsWG              sync.WaitGroup
 	watcher             *fsnotify.Watcher
 	newStateChannel     chan cloudprotocol.NewState
 	stateRequestChannel chan cloudprotocol.StateRequest
@@ -128,11 +156,13 @@ type StorageState struct {
 }
 
 type stateParams struct {
+	instanceID         string
 	stateFilePath      string
 	quota              uint64
 	checksum           []byte
 	changeTimer        *time.Timer
 	changeTimerChannel chan bool
+	verifyFailed       bool
 }
 
 /***********************************************************************************************************************
@@ -140,7 +170,10 @@ type stateParams struct {
  **********************************************************************************************************************/
 
 // New creates storagestate instance.
-func New(cfg *config.Config, messageSender MessageSender, storage Storage) (storageState *StorageState, err error) {
+func New(
+	cfg *config.Config, messageSender MessageSender, alertSender AlertSender, storage Storage,
+	certProvider CertificateProvider, cryptoContext CryptoContext,
+) (storageState *StorageState, err error) {
 	log.Debug("Create storagestate")
 
 	storageState = &StorageState{
@@ -148,11 +181,23 @@ func New(cfg *config.Config, messageSender MessageSender, storage Storage) (stor
 		storageDir:          cfg.StorageDir,
 		stateDir:            cfg.StateDir,
 		messageSender:       messageSender,
+		alertSender:         alertSender,
+		maxSnapshots:        cfg.StateSnapshots,
+		encryptionEnabled:   cfg.StorageEncryption.Enabled,
+		encryptionCertType:  cfg.StorageEncryption.CertType,
+		wipePasses:          cfg.SecureWipe.Passes,
+		certProvider:        certProvider,
+		cryptoContext:       cryptoContext,
 		statesMap:           make(map[aostypes.InstanceIdent]*stateParams),
+		quotasMap:           make(map[aostypes.InstanceIdent]*quotaParams),
 		newStateChannel:     make(chan cloudprotocol.NewState, stateChannelSize),
 		stateRequestChannel: make(chan cloudprotocol.StateRequest, stateChannelSize),
 	}
 
+	if err = storageState.initQuotaConfig(cfg.StorageQuota); err != nil {
+		return nil, err
+	}
+
 	if err = os.MkdirAll(storageState.storageDir, 0o755); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
@@ -165,17 +210,15 @@ func New(cfg *config.Config, messageSender MessageSender, storage Storage) (stor
 		return nil, aoserrors.Wrap(err)
 	}
 
-	storageMountPoint, err := fs.GetMountPoint(cfg.StorageDir)
-	if err != nil {
+	if storageState.storageMountPoint, err = fs.GetMountPoint(cfg.StorageDir); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
-	stateMountPoint, err := fs.GetMountPoint(cfg.StateDir)
-	if err != nil {
+	if storageState.stateMountPoint, err = fs.GetMountPoint(cfg.StateDir); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
-	storageState.isSamePartition = storageMountPoint == stateMountPoint
+	storageState.isSamePartition = storageState.storageMountPoint == storageState.stateMountPoint
 
 	if err = storageState.initStateWatching(); err != nil {
 		log.Errorf("Can't init state watching: %v", err)
@@ -183,6 +226,11 @@ func New(cfg *config.Config, messageSender MessageSender, storage Storage) (stor
 
 	go storageState.processWatcher()
 
+	storageState.jobsCtx, storageState.jobsCancel = context.WithCancel(context.Background())
+
+	storageState.startPeriodicJob(cfg.StorageQuota.CheckPeriod.Duration, storageState.checkQuotas)
+	storageState.startPeriodicJob(cfg.StateVerifyPeriod.Duration, storageState.verifyStates)
+
 	return storageState, nil
 }
 
@@ -190,6 +238,9 @@ func New(cfg *config.Config, messageSender MessageSender, storage Storage) (stor
 func (storageState *StorageState) Close() {
 	log.Debug("Close storagestate")
 
+	storageState.jobsCancel()
+	storageState.jobsWG.Wait()
+
 	storageState.watcher.Close()
 }
 
@@ -208,7 +259,12 @@ func (storageState *StorageState) Setup(
 		"stateQuota":   params.StateQuota,
 	}).Debug("Setup storage and state")
 
-	storageStateInfo, err := storageState.storage.GetStorageStateInfo(params.InstanceIdent)
+	storage := storageState.storage
+	if params.Storage != nil {
+		storage = params.Storage
+	}
+
+	storageStateInfo, err := storage.GetStorageStateInfo(params.InstanceIdent)
 	if err != nil {
 		if !errors.Is(err, ErrNotExist) {
 			return "", "", aoserrors.Wrap(err)
@@ -219,37 +275,53 @@ func (storageState *StorageState) Setup(
 			InstanceIdent: params.InstanceIdent,
 		}
 
-		if err = storageState.storage.AddStorageStateInfo(storageStateInfo); err != nil {
+		if err = storage.AddStorageStateInfo(storageStateInfo); err != nil {
 			return "", "", aoserrors.Wrap(err)
 		}
 	}
 
 	instanceID := storageStateInfo.InstanceID
 
+	if storageState.encryptionEnabled {
+		if err = storageState.prepareEncryption(instanceID, params); err != nil {
+			return "", "", err
+		}
+	}
+
 	if storagePath, err = storageState.prepareStorage(instanceID, params); err != nil {
 		return "", "", aoserrors.Wrap(err)
 	}
 
+	// Storage and state are hosted by CM and shared with nodes, so they follow the instance when it is relocated to
+	// another node. Pending state change of the previous instance run is reported before the state is set up again.
+	reportedChecksum := storageState.flushStateChange(params.InstanceIdent)
+
 	if err := storageState.stopStateWatching(params.InstanceIdent); err != nil {
 		return "", "", aoserrors.Wrap(err)
 	}
 
 	if storageStateInfo.StateQuota != params.StateQuota || storageStateInfo.StorageQuota != params.StorageQuota {
-		if err = storageState.setQuotasFS(params); err != nil {
-			return "", "", aoserrors.Wrap(err)
+		// FS quotas are set only to block writes, other actions are performed by quota monitoring
+		if storageState.quotaAction == QuotaActionBlock {
+			if err = storageState.setQuotasFS(params); err != nil {
+				return "", "", aoserrors.Wrap(err)
+			}
 		}
 
-		if err = storageState.storage.SetStorageStateQuotas(
+		if err = storage.SetStorageStateQuotas(
 			params.InstanceIdent, params.StorageQuota, params.StateQuota); err != nil {
 			return "", "", aoserrors.Wrap(err)
 		}
 	}
 
 	if statePath, err = storageState.prepareState(
-		instanceID, params, storageStateInfo.StateChecksum); err != nil {
+		instanceID, params, storageStateInfo.StateChecksum, reportedChecksum); err != nil {
 		return "", "", aoserrors.Wrap(err)
 	}
 
+	storageState.setQuotaParams(params.InstanceIdent, storageState.getStoragePath(instanceID),
+		storageState.getStatePath(instanceID), params.StorageQuota, params.StateQuota)
+
 	return storagePath, statePath, nil
 }
 
@@ -268,6 +340,8 @@ func (storageState *StorageState) Cleanup(instanceIdent aostypes.InstanceIdent)
 		return aoserrors.Wrap(err)
 	}
 
+	delete(storageState.quotasMap, instanceIdent)
+
 	return nil
 }
 
@@ -302,6 +376,126 @@ func (storageState *StorageState) RemoveServiceInstance(instanceIdent aostypes.I
 	return nil
 }
 
+// WipeServiceInstances securely removes storages and states of all service instances. The data is shredded before
+// removal.
+func (storageState *StorageState) WipeServiceInstances(serviceID string) error {
+	stateStorageInfos, err := storageState.storage.GetAllStorageStateInfo()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, stateStorageInfo := range stateStorageInfos {
+		if stateStorageInfo.ServiceID != serviceID {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"instance":  stateStorageInfo.Instance,
+			"serviceID": stateStorageInfo.ServiceID,
+			"subjectID": stateStorageInfo.SubjectID,
+		}).Debug("Wipe storage and state")
+
+		if err := storageState.Cleanup(stateStorageInfo.InstanceIdent); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err := storageState.shred(stateStorageInfo.InstanceID); err != nil {
+			return err
+		}
+
+		if err := storageState.remove(stateStorageInfo.InstanceIdent, stateStorageInfo.InstanceID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// Reconcile removes storages and states which don't belong to the instances: stored storages and states of other
+// instances and storages and states which setup was rolled back.
+func (storageState *StorageState) Reconcile(instances []aostypes.InstanceIdent) error {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	stateStorageInfos, err := storageState.storage.GetAllStorageStateInfo()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	storedInstances := make(map[aostypes.InstanceIdent]struct{}, len(stateStorageInfos))
+
+	for _, stateStorageInfo := range stateStorageInfos {
+		if slices.Contains(instances, stateStorageInfo.InstanceIdent) {
+			storedInstances[stateStorageInfo.InstanceIdent] = struct{}{}
+
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"instance":  stateStorageInfo.Instance,
+			"serviceID": stateStorageInfo.ServiceID,
+			"subjectID": stateStorageInfo.SubjectID,
+		}).Warn("Remove leftover storage and state")
+
+		if err := storageState.stopStateWatching(stateStorageInfo.InstanceIdent); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		delete(storageState.quotasMap, stateStorageInfo.InstanceIdent)
+
+		if err := storageState.remove(stateStorageInfo.InstanceIdent, stateStorageInfo.InstanceID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	// Storage and state info of rolled back setup is not stored, its data is found by instance ID of quota params.
+	for instanceIdent, quota := range storageState.quotasMap {
+		if _, ok := storedInstances[instanceIdent]; ok {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"instance":  instanceIdent.Instance,
+			"serviceID": instanceIdent.ServiceID,
+			"subjectID": instanceIdent.SubjectID,
+		}).Warn("Remove rolled back storage and state")
+
+		if err := storageState.stopStateWatching(instanceIdent); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		delete(storageState.quotasMap, instanceIdent)
+
+		if err := storageState.removeData(filepath.Base(quota.storagePath)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveAll removes storages and states of all instances.
+func (storageState *StorageState) RemoveAll() error {
+	stateStorageInfos, err := storageState.storage.GetAllStorageStateInfo()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	log.Debug("Remove all storages and states")
+
+	for _, stateStorageInfo := range stateStorageInfos {
+		if err := storageState.Cleanup(stateStorageInfo.InstanceIdent); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err := storageState.remove(stateStorageInfo.InstanceIdent, stateStorageInfo.InstanceID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
 // UpdateState updates state.
 func (storageState *StorageState) UpdateState(updateState cloudprotocol.UpdateState) error {
 	storageState.Lock()
@@ -318,7 +512,8 @@ func (storageState *StorageState) UpdateState(updateState cloudprotocol.UpdateSt
 		"subjectID": updateState.InstanceIdent.SubjectID,
 	}).Debug("Update state")
 
-	if len(updateState.State) > int(state.quota) {
+	// state can't be evicted, so it is rejected for all actions except alert only
+	if len(updateState.State) > int(state.quota) && storageState.quotaAction != QuotaActionAlert {
 		return aoserrors.New("update state is too big")
 	}
 
@@ -341,6 +536,10 @@ func (storageState *StorageState) UpdateState(updateState cloudprotocol.UpdateSt
 
 	state.checksum = sumBytes
 
+	if err := storageState.takeSnapshot(state, []byte(updateState.State), sumBytes); err != nil {
+		log.WithFields(instanceLogFields(updateState.InstanceIdent)).Errorf("Can't take state snapshot: %v", err)
+	}
+
 	return nil
 }
 
@@ -373,6 +572,11 @@ func (storageState *StorageState) StateAcceptance(updateState cloudprotocol.Stat
 		if err := storageState.storage.SetStateChecksum(updateState.InstanceIdent, sumBytes); err != nil {
 			return aoserrors.Wrap(err)
 		}
+
+		if err := storageState.snapshotStateFile(state, sumBytes); err != nil {
+			log.WithFields(instanceLogFields(updateState.InstanceIdent)).Errorf(
+				"Can't take state snapshot: %v", err)
+		}
 	} else {
 		log.WithFields(log.Fields{
 			"instance":  updateState.InstanceIdent.Instance,
@@ -401,6 +605,31 @@ func (storageState *StorageState) GetInstanceCheckSum(instanceIdent aostypes.Ins
  * Private
  **********************************************************************************************************************/
 
+func (storageState *StorageState) startPeriodicJob(period time.Duration, job func()) {
+	if period <= 0 {
+		return
+	}
+
+	storageState.jobsWG.Add(1)
+
+	go func() {
+		defer storageState.jobsWG.Done()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				job()
+
+			case <-storageState.jobsCtx.Done():
+				return
+			}
+		}
+	}()
+}
+
 func (storageState *StorageState) initStateWatching() error {
 	infos, err := storageState.storage.GetAllStorageStateInfo()
 	if err != nil {
@@ -408,11 +637,21 @@ func (storageState *StorageState) initStateWatching() error {
 	}
 
 	for _, info := range infos {
+		if storageState.encryptionEnabled {
+			if _, err = storageState.unlockInstance(info.InstanceID, false); err != nil &&
+				!errors.Is(err, os.ErrNotExist) {
+				log.WithField("instanceID", info.InstanceID).Errorf("Can't unlock instance: %v", err)
+			}
+		}
+
+		storageState.setQuotaParams(info.InstanceIdent, storageState.getStoragePath(info.InstanceID),
+			storageState.getStatePath(info.InstanceID), info.StorageQuota, info.StateQuota)
+
 		if info.StateQuota == 0 {
 			continue
 		}
 
-		if err = storageState.startStateWatching(info.InstanceIdent,
+		if err = storageState.startStateWatching(info.InstanceIdent, info.InstanceID,
 			storageState.getStatePath(info.InstanceID), info.StateQuota); err != nil {
 			log.WithField("instanceID", info.InstanceID).Errorf("Can't setup state watching: %v", err)
 		}
@@ -422,7 +661,7 @@ func (storageState *StorageState) initStateWatching() error {
 }
 
 func (storageState *StorageState) prepareState(
-	instanceID string, params SetupParams, checksum []byte,
+	instanceID string, params SetupParams, checksum, reportedChecksum []byte,
 ) (stateFilePath string, err error) {
 	if params.StateQuota == 0 {
 		if err := os.RemoveAll(storageState.getStatePath(instanceID)); err != nil {
@@ -434,7 +673,7 @@ func (storageState *StorageState) prepareState(
 
 	stateFilePath = storageState.getStatePath(instanceID)
 
-	if err = storageState.setupStateWatching(stateFilePath, params); err != nil {
+	if err = storageState.setupStateWatching(instanceID, stateFilePath, params); err != nil {
 		return "", aoserrors.Wrap(err)
 	}
 
@@ -446,7 +685,21 @@ func (storageState *StorageState) prepareState(
 		}
 	}()
 
-	storageState.statesMap[params.InstanceIdent].checksum = checksum
+	state := storageState.statesMap[params.InstanceIdent]
+
+	state.checksum = checksum
+
+	// state reported to the cloud and not accepted yet should not be requested from the cloud
+	if reportedChecksum != nil {
+		if _, fileChecksum, err := getFileDataChecksum(stateFilePath); err == nil &&
+			bytes.Equal(fileChecksum, reportedChecksum) {
+			state.checksum = reportedChecksum
+		}
+	}
+
+	if err = storageState.processServiceVersion(params.InstanceIdent, state, params.ServiceVersion); err != nil {
+		return "", err
+	}
 
 	if err = storageState.checkChecksumAndSendUpdateRequest(
 		stateFilePath, params.InstanceIdent); err != nil {
@@ -465,6 +718,18 @@ func (storageState *StorageState) remove(instanceIdent aostypes.InstanceIdent, i
 		"statePath":   storageState.getStatePath(instanceID),
 	}).Debug("Remove storage and state")
 
+	if err := storageState.removeData(instanceID); err != nil {
+		return err
+	}
+
+	if err := storageState.storage.RemoveStorageStateInfo(instanceIdent); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+func (storageState *StorageState) removeData(instanceID string) error {
 	if err := os.RemoveAll(storageState.getStoragePath(instanceID)); err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -473,10 +738,38 @@ func (storageState *StorageState) remove(instanceIdent aostypes.InstanceIdent, i
 		return aoserrors.Wrap(err)
 	}
 
-	if err := storageState.storage.RemoveStorageStateInfo(instanceIdent); err != nil {
+	if err := os.RemoveAll(storageState.getSnapshotsDir(instanceID)); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
+	if storageState.encryptionEnabled {
+		if err := os.RemoveAll(storageState.getInstanceStateDir(instanceID)); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err := storageState.destroyInstanceKey(instanceID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (storageState *StorageState) shred(instanceID string) error {
+	paths := []string{storageState.getStoragePath(instanceID)}
+
+	if storageState.encryptionEnabled {
+		paths = append(paths, storageState.getInstanceStateDir(instanceID))
+	} else {
+		paths = append(paths, storageState.getStatePath(instanceID), storageState.getSnapshotsDir(instanceID))
+	}
+
+	for _, dataPath := range paths {
+		if err := shred.RemoveAll(dataPath, storageState.wipePasses); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
@@ -502,13 +795,15 @@ func (storageState *StorageState) checkChecksumAndSendUpdateRequest(
 	return nil
 }
 
-func (storageState *StorageState) setupStateWatching(stateFilePath string, params SetupParams) error {
+func (storageState *StorageState) setupStateWatching(
+	instanceID, stateFilePath string, params SetupParams,
+) error {
 	if err := createStateFileIfNotExist(stateFilePath, params.UID, params.GID); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
 	if err := storageState.startStateWatching(
-		params.InstanceIdent, stateFilePath, params.StateQuota); err != nil {
+		params.InstanceIdent, instanceID, stateFilePath, params.StateQuota); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
@@ -516,13 +811,14 @@ func (storageState *StorageState) setupStateWatching(stateFilePath string, param
 }
 
 func (storageState *StorageState) startStateWatching(
-	instanceIdent aostypes.InstanceIdent, stateFilePath string, quota uint64,
+	instanceIdent aostypes.InstanceIdent, instanceID, stateFilePath string, quota uint64,
 ) (err error) {
 	if err = storageState.watcher.Add(stateFilePath); err != nil {
 		return aoserrors.Wrap(err)
 	}
 
 	storageState.statesMap[instanceIdent] = &stateParams{
+		instanceID:         instanceID,
 		stateFilePath:      stateFilePath,
 		quota:              quota,
 		changeTimerChannel: make(chan bool, 1),
@@ -610,11 +906,13 @@ func (storageState *StorageState) processStateChanged(stateFile string) {
 			}).Debug("State file changed")
 
 			if state.changeTimer == nil {
-				state.changeTimer = time.NewTimer(StateChangeTimeout)
+				// timer is captured as the goroutine reads it without the lock
+				timer := time.NewTimer(StateChangeTimeout)
+				state.changeTimer = timer
 
 				go func() {
 					select {
-					case <-state.changeTimer.C:
+					case <-timer.C:
 						storageState.stateChanged(stateFile, instanceIdent, state)
 
 					case <-state.changeTimerChannel:
@@ -640,6 +938,28 @@ func (storageState *StorageState) stateChanged(
 	storageState.Lock()
 	defer storageState.Unlock()
 
+	storageState.handleStateChange(fileName, instanceIdent, state)
+}
+
+// flushStateChange reports pending state change immediately and returns last reported state checksum.
+func (storageState *StorageState) flushStateChange(instanceIdent aostypes.InstanceIdent) []byte {
+	state, ok := storageState.statesMap[instanceIdent]
+	if !ok {
+		return nil
+	}
+
+	if state.changeTimer != nil && state.changeTimer.Stop() {
+		state.changeTimerChannel <- false
+
+		storageState.handleStateChange(state.stateFilePath, instanceIdent, state)
+	}
+
+	return state.checksum
+}
+
+func (storageState *StorageState) handleStateChange(
+	fileName string, instanceIdent aostypes.InstanceIdent, state *stateParams,
+) {
 	state.changeTimer = nil
 
 	stateData, checksum, err := getFileDataChecksum(fileName)
@@ -712,6 +1032,11 @@ func (storageState *StorageState) setQuotasFS(params SetupParams) error {
 }
 
 func (storageState *StorageState) getStatePath(instanceID string) string {
+	// encrypted state is located in the instance state directory as policy can be set only for directory
+	if storageState.encryptionEnabled {
+		return path.Join(storageState.getInstanceStateDir(instanceID), fmt.Sprintf(stateFileFormat, instanceID))
+	}
+
 	return path.Join(storageState.stateDir, fmt.Sprintf(stateFileFormat, instanceID))
 }
 
@@ -19,9 +19,18 @@ package storagestate_test
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
+	"fmt"
+	"math/big"
 	"os"
 	"path"
+	"slices"
+	"syscall"
 	"testing"
 	"time"
 
@@ -52,6 +61,35 @@ type testStorageInterface struct {
 	errorAdd          bool
 }
 
+type testAlertSender struct {
+	alerts chan interface{}
+}
+
+type testInstanceStopper struct {
+	stopped chan aostypes.InstanceIdent
+}
+
+type testCertProvider struct {
+	cert *x509.Certificate
+}
+
+type testCryptoContext struct {
+	cert *x509.Certificate
+	key  crypto.PrivateKey
+}
+
+type testFSEncryption struct {
+	keys map[string][]byte
+	// policies are bound to directory inodes as the real ones, so they follow renamed directories
+	policies    map[uint64][]byte
+	removedKeys [][]byte
+
+	addKey    func(mountPoint string, key []byte) ([]byte, error)
+	removeKey func(mountPoint string, keyID []byte) error
+	setPolicy func(dir string, keyID []byte) error
+	getPolicy func(dir string) ([]byte, error)
+}
+
 type testMessageSender struct {
 	chanNewState     chan cloudprotocol.NewState
 	chanStateRequest chan cloudprotocol.StateRequest
@@ -199,7 +237,7 @@ func TestSetupClean(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -439,7 +477,7 @@ func TestUpdateState(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -640,7 +678,7 @@ func TestUpdateStateFailed(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -736,7 +774,7 @@ func TestStateAcceptanceFailed(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -790,7 +828,7 @@ func TestNewStateCancel(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -821,6 +859,252 @@ func TestNewStateCancel(t *testing.T) {
 	}
 }
 
+func TestRemoveAll(t *testing.T) {
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir: storageDir,
+		StateDir:   stateDir,
+	}, &testMessageSender{}, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	var paths []string
+
+	for i := uint64(0); i < 2; i++ {
+		storagePath, _, err := instance.Setup(storagestate.SetupParams{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1", Instance: i},
+			UID:           1003,
+			GID:           1003,
+			StorageQuota:  1000,
+		})
+		if err != nil {
+			t.Fatalf("Can't setup instance: %v", err)
+		}
+
+		if _, err := os.Stat(path.Join(storageDir, storagePath)); err != nil {
+			t.Fatalf("Storage is not created: %v", err)
+		}
+
+		paths = append(paths, path.Join(storageDir, storagePath))
+	}
+
+	if err = instance.RemoveAll(); err != nil {
+		t.Fatalf("Can't remove all storages and states: %v", err)
+	}
+
+	if len(storage.data) != 0 {
+		t.Errorf("Unexpected storage state infos: %v", storage.data)
+	}
+
+	for _, storagePath := range paths {
+		if _, err := os.Stat(storagePath); !os.IsNotExist(err) {
+			t.Errorf("Storage %s should be removed", storagePath)
+		}
+	}
+}
+
+func TestWipeServiceInstances(t *testing.T) {
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir: storageDir,
+		StateDir:   stateDir,
+		SecureWipe: config.SecureWipe{Enabled: true, Passes: 2},
+	}, &testMessageSender{}, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	paths := make(map[string]string)
+
+	for _, serviceID := range []string{"service1", "service2"} {
+		storagePath, _, err := instance.Setup(storagestate.SetupParams{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: serviceID, SubjectID: "subject1"},
+			UID:           1003,
+			GID:           1003,
+			StorageQuota:  1000,
+		})
+		if err != nil {
+			t.Fatalf("Can't setup instance: %v", err)
+		}
+
+		if err = os.WriteFile(path.Join(storageDir, storagePath, "data.bin"), []byte("secret"), 0o600); err != nil {
+			t.Fatalf("Can't write storage data: %v", err)
+		}
+
+		paths[serviceID] = path.Join(storageDir, storagePath)
+	}
+
+	if err = instance.WipeServiceInstances("service1"); err != nil {
+		t.Fatalf("Can't wipe service instances: %v", err)
+	}
+
+	if _, err := os.Stat(paths["service1"]); !os.IsNotExist(err) {
+		t.Errorf("Storage %s should be removed", paths["service1"])
+	}
+
+	if _, err := os.Stat(paths["service2"]); err != nil {
+		t.Errorf("Storage %s should not be removed: %v", paths["service2"], err)
+	}
+
+	if len(storage.data) != 1 {
+		t.Errorf("Unexpected storage state infos: %v", storage.data)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir: storageDir,
+		StateDir:   stateDir,
+	}, &testMessageSender{}, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	instances := []aostypes.InstanceIdent{
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 0},
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 1},
+		{ServiceID: "service1", SubjectID: "subject1", Instance: 2},
+	}
+
+	// storage state info of the last instance is stored within rolled back transaction
+	rolledBackStorage := &testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	paths := make([]string, 0, len(instances))
+
+	for i, instanceIdent := range instances {
+		setupParams := storagestate.SetupParams{
+			InstanceIdent: instanceIdent, UID: 1003, GID: 1003, StorageQuota: 1000,
+		}
+
+		if i == len(instances)-1 {
+			setupParams.Storage = rolledBackStorage
+		}
+
+		storagePath, _, err := instance.Setup(setupParams)
+		if err != nil {
+			t.Fatalf("Can't setup instance: %v", err)
+		}
+
+		paths = append(paths, path.Join(storageDir, storagePath))
+	}
+
+	if len(storage.data) != 2 || len(rolledBackStorage.data) != 1 {
+		t.Fatalf("Wrong storage state infos: %v, %v", storage.data, rolledBackStorage.data)
+	}
+
+	if err = instance.Reconcile(instances[:1]); err != nil {
+		t.Fatalf("Can't reconcile storages and states: %v", err)
+	}
+
+	if _, ok := storage.data[instances[0]]; !ok || len(storage.data) != 1 {
+		t.Errorf("Unexpected storage state infos: %v", storage.data)
+	}
+
+	if _, err := os.Stat(paths[0]); err != nil {
+		t.Errorf("Storage %s should not be removed: %v", paths[0], err)
+	}
+
+	for _, storagePath := range paths[1:] {
+		if _, err := os.Stat(storagePath); !os.IsNotExist(err) {
+			t.Errorf("Storage %s should be removed", storagePath)
+		}
+	}
+}
+
+func TestPendingStateFlushedOnSetup(t *testing.T) {
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  6,
+		},
+		UID:        1003,
+		GID:        1003,
+		StateQuota: 2000,
+	}
+
+	stateChangeTimeout := storagestate.StateChangeTimeout
+	storagestate.StateChangeTimeout = 5 * time.Second
+
+	defer func() { storagestate.StateChangeTimeout = stateChangeTimeout }()
+
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 1),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 1),
+	}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir: storageDir,
+		StateDir:   stateDir,
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	_, statePath, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitStateRequest(messageSender.chanStateRequest, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait state request error: %v", err)
+	}
+
+	if err = os.WriteFile(path.Join(stateDir, statePath), []byte("relocated state"), 0o600); err != nil {
+		t.Fatalf("Can't write state file: %v", err)
+	}
+
+	// Wait for FS notification
+	time.Sleep(500 * time.Millisecond)
+
+	// Instance is set up again e.g. on another node
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	select {
+	case newState := <-messageSender.chanNewState:
+		if newState.State != "relocated state" {
+			t.Errorf("Wrong new state: %s", newState.State)
+		}
+
+	default:
+		t.Error("Pending state change should be sent on setup")
+	}
+
+	select {
+	case request := <-messageSender.chanStateRequest:
+		t.Errorf("Unexpected state request: %v", request)
+
+	default:
+	}
+
+	if err = instance.RemoveServiceInstance(setupParams.InstanceIdent); err != nil {
+		t.Errorf("Can't remove service instance: %v", err)
+	}
+}
+
 func TestStateAcceptance(t *testing.T) {
 	testsData := []struct {
 		storagestate.SetupParams
@@ -890,7 +1174,7 @@ func TestStateAcceptance(t *testing.T) {
 	instance, err := storagestate.New(&config.Config{
 		StorageDir: storageDir,
 		StateDir:   stateDir,
-	}, messageSender, &storage)
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
 	if err != nil {
 		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
@@ -1004,96 +1288,897 @@ func TestStateAcceptance(t *testing.T) {
 	}
 }
 
-/***********************************************************************************************************************
- * Interfaces
- **********************************************************************************************************************/
+func TestQuotaEnforcement(t *testing.T) {
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  2,
+		},
+		UID:          1003,
+		GID:          1003,
+		StorageQuota: 1000,
+	}
 
-func (messageSender *testMessageSender) SendInstanceNewState(newState cloudprotocol.NewState) error {
-	messageSender.chanNewState <- newState
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
 
-	return nil
-}
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 1),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 1),
+	}
 
-func (messageSender *testMessageSender) SendInstanceStateRequest(request cloudprotocol.StateRequest) error {
-	messageSender.chanStateRequest <- request
+	alertSender := &testAlertSender{alerts: make(chan interface{}, 1)}
 
-	return nil
-}
+	stateStorageQuotaLimit = 0
 
-func (storage *testStorageInterface) GetStorageStateInfo(
-	instanceIdent aostypes.InstanceIdent,
-) (storageStateInfo storagestate.StorageStateInstanceInfo, err error) {
-	if storage.errorGet {
-		return storageStateInfo, aoserrors.New("can't get storagestate info")
+	instance, err := storagestate.New(&config.Config{
+		StorageDir: storageDir,
+		StateDir:   stateDir,
+		StorageQuota: config.StorageQuota{
+			AlertThresholds: []int{100, 50},
+			Action:          storagestate.QuotaActionEvict,
+			CheckPeriod:     aostypes.Duration{Duration: 100 * time.Millisecond},
+		},
+	}, messageSender, alertSender, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
 	}
+	defer instance.Close()
 
-	data, ok := storage.data[instanceIdent]
-	if !ok {
-		return storageStateInfo, storagestate.ErrNotExist
-	}
+	stopper := &testInstanceStopper{stopped: make(chan aostypes.InstanceIdent, 1)}
 
-	return data, nil
-}
+	instance.SetInstanceStopper(stopper)
 
-func (storage *testStorageInterface) SetStorageStateQuotas(
-	instanceIdent aostypes.InstanceIdent, storageQuota, stateQuota uint64,
-) error {
-	if storage.errorSave {
-		return aoserrors.New("can't save storage state info")
+	storagePath, _, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
 	}
 
-	data := storage.data[instanceIdent]
-
-	data.StateQuota = stateQuota
-	data.StorageQuota = storageQuota
+	if stateStorageQuotaLimit != 0 {
+		t.Error("FS quota should not be set for evict action")
+	}
 
-	storage.data[instanceIdent] = data
+	oldFile := path.Join(storageDir, storagePath, "old.dat")
+	newFile := path.Join(storageDir, storagePath, "new.dat")
 
-	return nil
-}
+	if err = os.WriteFile(oldFile, make([]byte, 600), 0o600); err != nil {
+		t.Fatalf("Can't write storage file: %v", err)
+	}
 
-func (storage *testStorageInterface) AddStorageStateInfo(
-	storageStateInfo storagestate.StorageStateInstanceInfo,
-) error {
-	if storage.errorAdd {
-		return aoserrors.New("can't add statestorage entry")
+	if err = waitQuotaAlert(alertSender.alerts, setupParams.InstanceIdent, "storage", 600); err != nil {
+		t.Fatalf("Wait quota alert error: %v", err)
 	}
 
-	storage.data[storageStateInfo.InstanceIdent] = storageStateInfo
+	if action := instance.GetQuotaEnforcement(setupParams.InstanceIdent); action != "" {
+		t.Errorf("Unexpected quota enforcement: %s", action)
+	}
 
-	return nil
-}
+	if err = os.WriteFile(newFile, make([]byte, 600), 0o600); err != nil {
+		t.Fatalf("Can't write storage file: %v", err)
+	}
 
-func (storage *testStorageInterface) SetStateChecksum(instanceIdent aostypes.InstanceIdent, checksum []byte) error {
-	if storage.errorSaveCheckSum {
-		return aoserrors.New("can't save checksum")
+	if err = waitQuotaAlert(alertSender.alerts, setupParams.InstanceIdent, "storage", 1200); err != nil {
+		t.Fatalf("Wait quota alert error: %v", err)
 	}
 
-	storageStateInfo, ok := storage.data[instanceIdent]
-	if !ok {
-		return aoserrors.New("instance not found")
+	if action := instance.GetQuotaEnforcement(setupParams.InstanceIdent); action != storagestate.QuotaActionEvict {
+		t.Errorf("Unexpected quota enforcement: %s", action)
 	}
 
-	storageStateInfo.StateChecksum = checksum
+	if err = waitInstanceStopped(stopper.stopped, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait instance stopped error: %v", err)
+	}
 
-	storage.data[instanceIdent] = storageStateInfo
+	if !isPathExist(oldFile) || !isPathExist(newFile) {
+		t.Error("Storage files should be kept on evict")
+	}
 
-	return nil
-}
+	select {
+	case instanceIdent := <-stopper.stopped:
+		t.Errorf("Unexpected instance stop: %v", instanceIdent)
 
-func (storage *testStorageInterface) RemoveStorageStateInfo(instanceIdent aostypes.InstanceIdent) error {
-	if _, ok := storage.data[instanceIdent]; !ok {
-		return aoserrors.New("instance not found")
+	case <-time.After(300 * time.Millisecond):
 	}
 
-	delete(storage.data, instanceIdent)
+	// Changed quota resets quota usage state
 
-	return nil
-}
+	setupParams.StorageQuota = 2000
 
-func (storage *testStorageInterface) GetAllStorageStateInfo() (
-	infos []storagestate.StorageStateInstanceInfo, err error,
-) {
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitQuotaAlert(alertSender.alerts, setupParams.InstanceIdent, "storage", 1200); err != nil {
+		t.Fatalf("Wait quota alert error: %v", err)
+	}
+
+	if action := instance.GetQuotaEnforcement(setupParams.InstanceIdent); action != "" {
+		t.Errorf("Unexpected quota enforcement: %s", action)
+	}
+
+	select {
+	case instanceIdent := <-stopper.stopped:
+		t.Errorf("Unexpected instance stop: %v", instanceIdent)
+
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// Setup of evicted instance with the same quota stops it again
+
+	setupParams.StorageQuota = 1000
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitInstanceStopped(stopper.stopped, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait instance stopped error: %v", err)
+	}
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitInstanceStopped(stopper.stopped, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait instance stopped error: %v", err)
+	}
+
+	if err = instance.RemoveServiceInstance(setupParams.InstanceIdent); err != nil {
+		t.Errorf("Can't remove service instance: %v", err)
+	}
+}
+
+func TestStateVerification(t *testing.T) {
+	const acceptedState = "accepted state"
+
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  3,
+		},
+		UID:        1003,
+		GID:        1003,
+		StateQuota: 1000,
+	}
+
+	acceptedChecksum := sha3.Sum224([]byte(acceptedState))
+
+	storage := testStorageInterface{
+		data: map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo{
+			setupParams.InstanceIdent: {
+				InstanceIdent: setupParams.InstanceIdent,
+				InstanceID:    "verifyState",
+				StateQuota:    setupParams.StateQuota,
+				StateChecksum: acceptedChecksum[:],
+			},
+		},
+	}
+
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatalf("Can't create state dir: %v", err)
+	}
+
+	if err := os.WriteFile(
+		path.Join(stateDir, "verifyState_state.dat"), []byte("corrupted state"), 0o600); err != nil {
+		t.Fatalf("Can't write state file: %v", err)
+	}
+
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 1),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 10),
+	}
+
+	alertSender := &testAlertSender{alerts: make(chan interface{}, 1)}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir:        storageDir,
+		StateDir:          stateDir,
+		StateVerifyPeriod: aostypes.Duration{Duration: 100 * time.Millisecond},
+	}, messageSender, alertSender, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	// Initial state request is sent on setup
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitStateRequest(messageSender.chanStateRequest, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait state request error: %v", err)
+	}
+
+	select {
+	case receivedAlert := <-alertSender.alerts:
+		alert, ok := receivedAlert.(cloudprotocol.ServiceInstanceAlert)
+		if !ok || alert.InstanceIdent != setupParams.InstanceIdent {
+			t.Errorf("Unexpected alert: %v", receivedAlert)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait state alert timeout")
+	}
+
+	if err = waitStateRequest(messageSender.chanStateRequest, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait state request error: %v", err)
+	}
+
+	if err = instance.UpdateState(cloudprotocol.UpdateState{
+		InstanceIdent: setupParams.InstanceIdent,
+		Checksum:      hex.EncodeToString(acceptedChecksum[:]),
+		State:         acceptedState,
+	}); err != nil {
+		t.Fatalf("Can't update state: %v", err)
+	}
+
+	// Drop requests sent before update
+	for len(messageSender.chanStateRequest) > 0 {
+		<-messageSender.chanStateRequest
+	}
+
+	select {
+	case request := <-messageSender.chanStateRequest:
+		t.Errorf("Unexpected state request: %v", request)
+
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestStateSnapshots(t *testing.T) {
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  4,
+		},
+		UID:            1003,
+		GID:            1003,
+		StateQuota:     1000,
+		ServiceVersion: "1.0.0",
+	}
+
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 1),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 1),
+	}
+
+	instance, err := storagestate.New(&config.Config{
+		StorageDir:     storageDir,
+		StateDir:       stateDir,
+		StateSnapshots: 2,
+	}, messageSender, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitStateRequest(messageSender.chanStateRequest, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait state request error: %v", err)
+	}
+
+	if err = updateTestState(instance, setupParams.InstanceIdent, "state v1"); err != nil {
+		t.Fatalf("Can't update state: %v", err)
+	}
+
+	setupParams.ServiceVersion = "2.0.0"
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = updateTestState(instance, setupParams.InstanceIdent, "state v2"); err != nil {
+		t.Fatalf("Can't update state: %v", err)
+	}
+
+	snapshots, err := instance.GetStateSnapshots(setupParams.InstanceIdent)
+	if err != nil {
+		t.Fatalf("Can't get state snapshots: %v", err)
+	}
+
+	if len(snapshots) != 2 || snapshots[0].ServiceVersion != "1.0.0" || snapshots[1].ServiceVersion != "2.0.0" {
+		t.Fatalf("Unexpected state snapshots: %v", snapshots)
+	}
+
+	// Restore by request
+
+	if err = instance.RestoreStateSnapshot(setupParams.InstanceIdent, snapshots[0].ID); err != nil {
+		t.Fatalf("Can't restore state snapshot: %v", err)
+	}
+
+	if err = waitNewState(messageSender.chanNewState, setupParams.InstanceIdent, "state v1"); err != nil {
+		t.Errorf("Wait new state error: %v", err)
+	}
+
+	if err = instance.RestoreStateSnapshot(setupParams.InstanceIdent, snapshots[1].ID); err != nil {
+		t.Fatalf("Can't restore state snapshot: %v", err)
+	}
+
+	if err = waitNewState(messageSender.chanNewState, setupParams.InstanceIdent, "state v2"); err != nil {
+		t.Errorf("Wait new state error: %v", err)
+	}
+
+	// Restore on service rollback
+
+	setupParams.ServiceVersion = "1.0.0"
+
+	_, statePath, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitNewState(messageSender.chanNewState, setupParams.InstanceIdent, "state v1"); err != nil {
+		t.Errorf("Wait new state error: %v", err)
+	}
+
+	stateData, err := os.ReadFile(path.Join(stateDir, statePath))
+	if err != nil {
+		t.Fatalf("Can't read state file: %v", err)
+	}
+
+	if string(stateData) != "state v1" {
+		t.Errorf("Wrong restored state: %s", string(stateData))
+	}
+
+	// Old snapshots are removed
+
+	if err = updateTestState(instance, setupParams.InstanceIdent, "state v3"); err != nil {
+		t.Fatalf("Can't update state: %v", err)
+	}
+
+	if snapshots, err = instance.GetStateSnapshots(setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Can't get state snapshots: %v", err)
+	}
+
+	checksum := sha3.Sum224([]byte("state v3"))
+
+	if len(snapshots) != 2 || snapshots[1].Checksum != hex.EncodeToString(checksum[:]) {
+		t.Errorf("Unexpected state snapshots: %v", snapshots)
+	}
+
+	// Not semver service version doesn't fail setup
+
+	setupParams.ServiceVersion = "custom"
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Errorf("Can't setup instance with not semver version: %v", err)
+	}
+
+	setupParams.ServiceVersion = "1.0.0"
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Errorf("Can't setup instance after not semver version: %v", err)
+	}
+
+	if err = instance.RemoveServiceInstance(setupParams.InstanceIdent); err != nil {
+		t.Errorf("Can't remove service instance: %v", err)
+	}
+}
+
+func TestEncryptedStorageState(t *testing.T) {
+	fsEncryption := newTestFSEncryption()
+	defer fsEncryption.restore()
+
+	cert, key, err := createTestCertificate()
+	if err != nil {
+		t.Fatalf("Can't create test certificate: %v", err)
+	}
+
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  5,
+		},
+		UID:          1003,
+		GID:          1003,
+		StateQuota:   1000,
+		StorageQuota: 1000,
+	}
+
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 1),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 1),
+	}
+
+	cfg := &config.Config{
+		StorageDir:        storageDir,
+		StateDir:          stateDir,
+		StorageEncryption: config.StorageEncryption{Enabled: true, CertType: "storage"},
+	}
+
+	instance, err := storagestate.New(cfg, messageSender, &testAlertSender{}, &storage,
+		&testCertProvider{cert: cert}, &testCryptoContext{cert: cert, key: key})
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+
+	storagePath, statePath, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if err = waitStateRequest(messageSender.chanStateRequest, setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Wait state request error: %v", err)
+	}
+
+	if len(fsEncryption.keys) != 1 {
+		t.Fatalf("Wrong FS encryption keys count: %d", len(fsEncryption.keys))
+	}
+
+	var rawKey []byte
+
+	for _, key := range fsEncryption.keys {
+		rawKey = key
+	}
+
+	encryptedStorageDir := path.Join(storageDir, storagePath)
+	encryptedStateDir := path.Dir(path.Join(stateDir, statePath))
+
+	for _, dir := range []string{encryptedStorageDir, encryptedStateDir} {
+		if keyID, _ := fsEncryption.getFSPolicy(dir); keyID == nil {
+			t.Errorf("Encryption policy is not set for: %s", dir)
+		}
+	}
+
+	if encryptedStateDir == stateDir {
+		t.Error("State should be located in the encrypted instance directory")
+	}
+
+	instance.Close()
+
+	// Instance key is unlocked on start
+
+	fsEncryption.keys = make(map[string][]byte)
+
+	if instance, err = storagestate.New(cfg, messageSender, &testAlertSender{}, &storage,
+		&testCertProvider{cert: cert}, &testCryptoContext{cert: cert, key: key}); err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	if len(fsEncryption.keys) != 1 {
+		t.Fatalf("Wrong FS encryption keys count: %d", len(fsEncryption.keys))
+	}
+
+	for _, unlockedKey := range fsEncryption.keys {
+		if !bytes.Equal(unlockedKey, rawKey) {
+			t.Error("Wrong unlocked instance key")
+		}
+	}
+
+	// Instance key is destroyed on remove
+
+	if err = instance.RemoveServiceInstance(setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Can't remove service instance: %v", err)
+	}
+
+	if len(fsEncryption.removedKeys) != 1 {
+		t.Errorf("Wrong removed keys count: %d", len(fsEncryption.removedKeys))
+	}
+
+	keyFiles, err := os.ReadDir(path.Join(stateDir, "keys"))
+	if err != nil {
+		t.Fatalf("Can't read keys dir: %v", err)
+	}
+
+	if len(keyFiles) != 0 {
+		t.Error("Instance key file should be removed")
+	}
+}
+
+func TestEncryptedStorageStateMigration(t *testing.T) {
+	fsEncryption := newTestFSEncryption()
+	defer fsEncryption.restore()
+
+	cert, key, err := createTestCertificate()
+	if err != nil {
+		t.Fatalf("Can't create test certificate: %v", err)
+	}
+
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  6,
+		},
+		UID:          1003,
+		GID:          1003,
+		StateQuota:   1000,
+		StorageQuota: 1000,
+	}
+
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	messageSender := &testMessageSender{
+		chanNewState:     make(chan cloudprotocol.NewState, 10),
+		chanStateRequest: make(chan cloudprotocol.StateRequest, 10),
+	}
+
+	// Create not encrypted storage and state
+
+	cfg := &config.Config{StorageDir: storageDir, StateDir: stateDir}
+
+	instance, err := storagestate.New(cfg, messageSender, &testAlertSender{}, &storage, nil, nil)
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+
+	storagePath, plainStatePath, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	storageFile := path.Join(storageDir, storagePath, "data", "file")
+
+	if err = os.MkdirAll(path.Dir(storageFile), 0o700); err != nil {
+		t.Fatalf("Can't create storage dir: %v", err)
+	}
+
+	if err = os.WriteFile(storageFile, []byte("storage data"), 0o600); err != nil {
+		t.Fatalf("Can't write storage file: %v", err)
+	}
+
+	if err = os.WriteFile(path.Join(stateDir, plainStatePath), []byte("state data"), 0o600); err != nil {
+		t.Fatalf("Can't write state file: %v", err)
+	}
+
+	instance.Close()
+
+	// Enable encryption
+
+	cfg.StorageEncryption = config.StorageEncryption{Enabled: true, CertType: "storage"}
+
+	if instance, err = storagestate.New(cfg, messageSender, &testAlertSender{}, &storage,
+		&testCertProvider{cert: cert}, &testCryptoContext{cert: cert, key: key}); err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	for range 2 {
+		storagePath, statePath, err := instance.Setup(setupParams)
+		if err != nil {
+			t.Fatalf("Can't setup instance: %v", err)
+		}
+
+		for _, dir := range []string{path.Join(storageDir, storagePath), path.Dir(path.Join(stateDir, statePath))} {
+			if keyID, _ := fsEncryption.getFSPolicy(dir); keyID == nil {
+				t.Errorf("Encryption policy is not set for: %s", dir)
+			}
+		}
+
+		if data, err := os.ReadFile(storageFile); err != nil || string(data) != "storage data" {
+			t.Errorf("Wrong migrated storage data: %s, %v", data, err)
+		}
+
+		if data, err := os.ReadFile(path.Join(stateDir, statePath)); err != nil || string(data) != "state data" {
+			t.Errorf("Wrong migrated state data: %s, %v", data, err)
+		}
+
+		if _, err = os.Stat(path.Join(stateDir, plainStatePath)); !os.IsNotExist(err) {
+			t.Error("Not encrypted state should be removed")
+		}
+
+		for _, leftover := range []string{
+			path.Join(storageDir, storagePath) + ".plain", path.Join(storageDir, storagePath) + ".encrypting",
+		} {
+			if _, err = os.Stat(leftover); !os.IsNotExist(err) {
+				t.Errorf("Migration leftover should be removed: %s", leftover)
+			}
+		}
+	}
+
+	if err = instance.RemoveServiceInstance(setupParams.InstanceIdent); err != nil {
+		t.Fatalf("Can't remove service instance: %v", err)
+	}
+}
+
+func TestInterruptedEncryptedStorageMigration(t *testing.T) {
+	fsEncryption := newTestFSEncryption()
+	defer fsEncryption.restore()
+
+	cert, key, err := createTestCertificate()
+	if err != nil {
+		t.Fatalf("Can't create test certificate: %v", err)
+	}
+
+	setupParams := storagestate.SetupParams{
+		InstanceIdent: aostypes.InstanceIdent{
+			ServiceID: "service1",
+			SubjectID: "subject1",
+			Instance:  7,
+		},
+		UID:          1003,
+		GID:          1003,
+		StorageQuota: 1000,
+	}
+
+	storage := testStorageInterface{
+		data: make(map[aostypes.InstanceIdent]storagestate.StorageStateInstanceInfo),
+	}
+
+	cfg := &config.Config{
+		StorageDir:        storageDir,
+		StateDir:          stateDir,
+		StorageEncryption: config.StorageEncryption{Enabled: true, CertType: "storage"},
+	}
+
+	instance, err := storagestate.New(cfg, &testMessageSender{}, &testAlertSender{}, &storage,
+		&testCertProvider{cert: cert}, &testCryptoContext{cert: cert, key: key})
+	if err != nil {
+		t.Fatalf("Can't create storagestate instance: %v", err)
+	}
+	defer instance.Close()
+
+	storagePath, _, err := instance.Setup(setupParams)
+	if err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	encryptedDir := path.Join(storageDir, storagePath)
+
+	// Simulate migration interrupted between renames: encrypted copy is complete, not encrypted dir is replaced
+
+	if err = os.WriteFile(path.Join(encryptedDir, "file"), []byte("storage data"), 0o600); err != nil {
+		t.Fatalf("Can't write storage file: %v", err)
+	}
+
+	if err = os.Rename(encryptedDir, encryptedDir+".encrypting"); err != nil {
+		t.Fatalf("Can't rename dir: %v", err)
+	}
+
+	if err = os.MkdirAll(encryptedDir+".plain", 0o700); err != nil {
+		t.Fatalf("Can't create dir: %v", err)
+	}
+
+	if err = os.WriteFile(path.Join(encryptedDir+".plain", "file"), []byte("storage data"), 0o600); err != nil {
+		t.Fatalf("Can't write storage file: %v", err)
+	}
+
+	if _, _, err = instance.Setup(setupParams); err != nil {
+		t.Fatalf("Can't setup instance: %v", err)
+	}
+
+	if keyID, _ := fsEncryption.getFSPolicy(encryptedDir); keyID == nil {
+		t.Error("Encryption policy is not set")
+	}
+
+	if data, err := os.ReadFile(path.Join(encryptedDir, "file")); err != nil || string(data) != "storage data" {
+		t.Errorf("Wrong storage data: %s, %v", data, err)
+	}
+
+	if _, err = os.Stat(encryptedDir + ".plain"); !os.IsNotExist(err) {
+		t.Error("Not encrypted dir should be removed")
+	}
+}
+
+func TestWrongQuotaAction(t *testing.T) {
+	if _, err := storagestate.New(&config.Config{
+		StorageDir:   storageDir,
+		StateDir:     stateDir,
+		StorageQuota: config.StorageQuota{Action: "unknown"},
+	}, &testMessageSender{}, &testAlertSender{}, &testStorageInterface{}, nil, nil); err == nil {
+		t.Error("Error expected for unknown quota action")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func newTestFSEncryption() *testFSEncryption {
+	fsEncryption := &testFSEncryption{
+		keys:      make(map[string][]byte),
+		policies:  make(map[uint64][]byte),
+		addKey:    storagestate.AddFSEncryptionKey,
+		removeKey: storagestate.RemoveFSEncryptionKey,
+		setPolicy: storagestate.SetFSEncryptionPolicy,
+		getPolicy: storagestate.GetFSEncryptionPolicy,
+	}
+
+	storagestate.AddFSEncryptionKey = fsEncryption.addFSKey
+	storagestate.RemoveFSEncryptionKey = fsEncryption.removeFSKey
+	storagestate.SetFSEncryptionPolicy = fsEncryption.setFSPolicy
+	storagestate.GetFSEncryptionPolicy = fsEncryption.getFSPolicy
+
+	return fsEncryption
+}
+
+func (fsEncryption *testFSEncryption) restore() {
+	storagestate.AddFSEncryptionKey = fsEncryption.addKey
+	storagestate.RemoveFSEncryptionKey = fsEncryption.removeKey
+	storagestate.SetFSEncryptionPolicy = fsEncryption.setPolicy
+	storagestate.GetFSEncryptionPolicy = fsEncryption.getPolicy
+}
+
+func (fsEncryption *testFSEncryption) addFSKey(mountPoint string, key []byte) ([]byte, error) {
+	keyHash := sha3.Sum224(key)
+	keyID := keyHash[:16]
+
+	fsEncryption.keys[hex.EncodeToString(keyID)] = slices.Clone(key)
+
+	return keyID, nil
+}
+
+func (fsEncryption *testFSEncryption) removeFSKey(mountPoint string, keyID []byte) error {
+	fsEncryption.removedKeys = append(fsEncryption.removedKeys, keyID)
+
+	return nil
+}
+
+func (fsEncryption *testFSEncryption) setFSPolicy(dir string, keyID []byte) error {
+	if _, ok := fsEncryption.keys[hex.EncodeToString(keyID)]; !ok {
+		return aoserrors.New("key not found")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(entries) != 0 {
+		return aoserrors.New("directory not empty")
+	}
+
+	inode, err := getInode(dir)
+	if err != nil {
+		return err
+	}
+
+	fsEncryption.policies[inode] = keyID
+
+	return nil
+}
+
+func (fsEncryption *testFSEncryption) getFSPolicy(dir string) ([]byte, error) {
+	inode, err := getInode(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return fsEncryption.policies[inode], nil
+}
+
+func (provider *testCertProvider) GetCertificate(
+	certType string, issuer []byte, serial string,
+) (certURL, keyURL string, err error) {
+	if issuer != nil && (!bytes.Equal(issuer, provider.cert.RawIssuer) ||
+		serial != fmt.Sprintf("%X", provider.cert.SerialNumber)) {
+		return "", "", aoserrors.New("certificate not found")
+	}
+
+	return "cert:" + certType, "key:" + certType, nil
+}
+
+func (cryptoContext *testCryptoContext) LoadCertificateByURL(certURL string) ([]*x509.Certificate, error) {
+	return []*x509.Certificate{cryptoContext.cert}, nil
+}
+
+func (cryptoContext *testCryptoContext) LoadPrivateKeyByURL(
+	keyURL string,
+) (privKey crypto.PrivateKey, supportPKCS1v15SessionKey bool, err error) {
+	return cryptoContext.key, false, nil
+}
+
+func (stopper *testInstanceStopper) StopInstance(
+	instanceIdent aostypes.InstanceIdent, reason string, duration time.Duration,
+) error {
+	stopper.stopped <- instanceIdent
+
+	return nil
+}
+
+func (alertSender *testAlertSender) SendAlert(alert interface{}) {
+	if alertSender.alerts == nil {
+		return
+	}
+
+	alertSender.alerts <- alert
+}
+
+func (messageSender *testMessageSender) SendInstanceNewState(newState cloudprotocol.NewState) error {
+	messageSender.chanNewState <- newState
+
+	return nil
+}
+
+func (messageSender *testMessageSender) SendInstanceStateRequest(request cloudprotocol.StateRequest) error {
+	messageSender.chanStateRequest <- request
+
+	return nil
+}
+
+func (storage *testStorageInterface) GetStorageStateInfo(
+	instanceIdent aostypes.InstanceIdent,
+) (storageStateInfo storagestate.StorageStateInstanceInfo, err error) {
+	if storage.errorGet {
+		return storageStateInfo, aoserrors.New("can't get storagestate info")
+	}
+
+	data, ok := storage.data[instanceIdent]
+	if !ok {
+		return storageStateInfo, storagestate.ErrNotExist
+	}
+
+	return data, nil
+}
+
+func (storage *testStorageInterface) SetStorageStateQuotas(
+	instanceIdent aostypes.InstanceIdent, storageQuota, stateQuota uint64,
+) error {
+	if storage.errorSave {
+		return aoserrors.New("can't save storage state info")
+	}
+
+	data := storage.data[instanceIdent]
+
+	data.StateQuota = stateQuota
+	data.StorageQuota = storageQuota
+
+	storage.data[instanceIdent] = data
+
+	return nil
+}
+
+func (storage *testStorageInterface) AddStorageStateInfo(
+	storageStateInfo storagestate.StorageStateInstanceInfo,
+) error {
+	if storage.errorAdd {
+		return aoserrors.New("can't add statestorage entry")
+	}
+
+	storage.data[storageStateInfo.InstanceIdent] = storageStateInfo
+
+	return nil
+}
+
+func (storage *testStorageInterface) SetStateChecksum(instanceIdent aostypes.InstanceIdent, checksum []byte) error {
+	if storage.errorSaveCheckSum {
+		return aoserrors.New("can't save checksum")
+	}
+
+	storageStateInfo, ok := storage.data[instanceIdent]
+	if !ok {
+		return aoserrors.New("instance not found")
+	}
+
+	storageStateInfo.StateChecksum = checksum
+
+	storage.data[instanceIdent] = storageStateInfo
+
+	return nil
+}
+
+func (storage *testStorageInterface) RemoveStorageStateInfo(instanceIdent aostypes.InstanceIdent) error {
+	if _, ok := storage.data[instanceIdent]; !ok {
+		return aoserrors.New("instance not found")
+	}
+
+	delete(storage.data, instanceIdent)
+
+	return nil
+}
+
+func (storage *testStorageInterface) GetAllStorageStateInfo() (
+	infos []storagestate.StorageStateInstanceInfo, err error,
+) {
 	for _, storageStateInfo := range storage.data {
 		infos = append(infos, storageStateInfo)
 	}
@@ -1151,6 +2236,129 @@ func getStateFileChecksum(fileName string) (checksum []byte, err error) {
 	return calcSum[:], nil
 }
 
+func waitQuotaAlert(
+	alerts <-chan interface{}, instanceIdent aostypes.InstanceIdent, parameter string, value uint64,
+) error {
+	select {
+	case receivedAlert := <-alerts:
+		alert, ok := receivedAlert.(cloudprotocol.InstanceQuotaAlert)
+		if !ok {
+			return aoserrors.Errorf("unexpected alert: %v", receivedAlert)
+		}
+
+		if alert.InstanceIdent != instanceIdent || alert.Parameter != parameter || alert.Value != value {
+			return aoserrors.Errorf("unexpected quota alert: %v", alert)
+		}
+
+		if alert.Tag != cloudprotocol.AlertTagInstanceQuota {
+			return aoserrors.Errorf("wrong alert tag: %s", alert.Tag)
+		}
+
+		return nil
+
+	case <-time.After(5 * time.Second):
+		return aoserrors.New("wait quota alert timeout")
+	}
+}
+
+func waitInstanceStopped(stopped <-chan aostypes.InstanceIdent, instanceIdent aostypes.InstanceIdent) error {
+	select {
+	case stoppedIdent := <-stopped:
+		if stoppedIdent != instanceIdent {
+			return aoserrors.Errorf("unexpected stopped instance: %v", stoppedIdent)
+		}
+
+		return nil
+
+	case <-time.After(5 * time.Second):
+		return aoserrors.New("wait instance stopped timeout")
+	}
+}
+
+func getInode(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, aoserrors.Wrap(err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, aoserrors.New("can't get inode")
+	}
+
+	return stat.Ino, nil
+}
+
+func waitStateRequest(
+	requests <-chan cloudprotocol.StateRequest, instanceIdent aostypes.InstanceIdent,
+) error {
+	select {
+	case request := <-requests:
+		if request.InstanceIdent != instanceIdent {
+			return aoserrors.Errorf("unexpected state request: %v", request)
+		}
+
+		return nil
+
+	case <-time.After(5 * time.Second):
+		return aoserrors.New("wait state request timeout")
+	}
+}
+
+func updateTestState(
+	instance *storagestate.StorageState, instanceIdent aostypes.InstanceIdent, state string,
+) error {
+	checksum := sha3.Sum224([]byte(state))
+
+	return aoserrors.Wrap(instance.UpdateState(cloudprotocol.UpdateState{
+		InstanceIdent: instanceIdent,
+		Checksum:      hex.EncodeToString(checksum[:]),
+		State:         state,
+	}))
+}
+
+func waitNewState(
+	newStates <-chan cloudprotocol.NewState, instanceIdent aostypes.InstanceIdent, state string,
+) error {
+	select {
+	case newState := <-newStates:
+		if newState.InstanceIdent != instanceIdent || newState.State != state {
+			return aoserrors.Errorf("unexpected new state: %v", newState)
+		}
+
+		return nil
+
+	case <-time.After(5 * time.Second):
+		return aoserrors.New("wait new state timeout")
+	}
+}
+
+func createTestCertificate() (*x509.Certificate, crypto.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cm"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certData, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		return nil, nil, aoserrors.Wrap(err)
+	}
+
+	return cert, key, nil
+}
+
 func isPathExist(dir string) bool {
 	if _, err := os.Stat(dir); err != nil {
 		return false
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagestate
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// verifyStates recalculates state checksums and requests state from the cloud if state file doesn't match neither
+// accepted nor last reported state. State changes made by the service are detected by FS watcher, so such mismatch
+// means silent state corruption.
+func (storageState *StorageState) verifyStates() {
+	storageState.Lock()
+	defer storageState.Unlock()
+
+	for instanceIdent, state := range storageState.statesMap {
+		// state change is pending
+		if state.changeTimer != nil {
+			continue
+		}
+
+		if err := storageState.verifyState(instanceIdent, state); err != nil {
+			log.WithFields(instanceLogFields(instanceIdent)).Errorf("Can't verify state: %v", err)
+		}
+	}
+}
+
+func (storageState *StorageState) verifyState(instanceIdent aostypes.InstanceIdent, state *stateParams) error {
+	storageStateInfo, err := storageState.storage.GetStorageStateInfo(instanceIdent)
+	if err != nil {
+		return err
+	}
+
+	// nothing to compare with until state is accepted
+	if len(storageStateInfo.StateChecksum) == 0 {
+		return nil
+	}
+
+	_, checksum, err := getFileDataChecksum(state.stateFilePath)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(checksum, storageStateInfo.StateChecksum) || bytes.Equal(checksum, state.checksum) {
+		if state.verifyFailed {
+			log.WithFields(instanceLogFields(instanceIdent)).Info("State is repaired")
+		}
+
+		state.verifyFailed = false
+
+		return nil
+	}
+
+	log.WithFields(instanceLogFields(instanceIdent)).Error("State checksum mismatch, request state")
+
+	if !state.verifyFailed {
+		storageState.alertSender.SendAlert(cloudprotocol.ServiceInstanceAlert{
+			AlertItem:     cloudprotocol.AlertItem{Timestamp: time.Now(), Tag: cloudprotocol.AlertTagServiceInstance},
+			InstanceIdent: instanceIdent,
+			Message:       "State checksum mismatch, state is corrupted",
+		})
+	}
+
+	state.verifyFailed = true
+
+	return storageState.pushStateRequestMessage(instanceIdent, false)
+}
@@ -149,6 +149,7 @@ type storage interface {
 type CertificateProvider interface {
 	GetCertificate(certType string, issuer []byte, serial string) (certURL, keyURL string, err error)
 	SubscribeCertChanged(certType string) (<-chan *iamanager.CertInfo, error)
+	UnsubscribeCertChanged(listener <-chan *iamanager.CertInfo) error
 }
 
 // Decrypter interface to decrypt and validate image.
@@ -271,8 +272,14 @@ func New(config *config.Config, storage storage, certProvider CertificateProvide
 		return nil, aoserrors.Wrap(err)
 	}
 
+	var fileServerTLS *fileserver.TLSParams
+
+	if !insecure {
+		fileServerTLS = fileserver.NewTLSParams(config, certProvider, cryptocontext)
+	}
+
 	if umCtrl.fileServer, err = fileserver.New(
-		config.UMController.FileServerURL, config.ComponentsDir); err != nil {
+		config.UMController.FileServerURL, config.ComponentsDir, config.FileServer, fileServerTLS); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
@@ -303,6 +310,15 @@ func New(config *config.Config, storage storage, certProvider CertificateProvide
 	return umCtrl, nil
 }
 
+// GetTransferStats returns file server transfer statistics.
+func (umCtrl *Controller) GetTransferStats() map[string]fileserver.TransferStats {
+	if umCtrl.fileServer == nil {
+		return nil
+	}
+
+	return umCtrl.fileServer.GetTransferStats()
+}
+
 // Close close server.
 func (umCtrl *Controller) Close() {
 	if umCtrl.fileServer != nil {
@@ -747,6 +763,12 @@ func (umCtrl *Controller) addComponentForUpdateToUm(componentInfo ComponentStatu
 					return aoserrors.Wrap(err)
 				}
 
+				if !umCtrl.connections[i].isLocalClient {
+					if newURL, err = umCtrl.fileServer.SignURL(newURL, umCtrl.connections[i].nodeID); err != nil {
+						return aoserrors.Wrap(err)
+					}
+				}
+
 				componentInfo.URL = newURL
 
 				umCtrl.connections[i].updatePackages = append(umCtrl.connections[i].updatePackages, componentInfo)
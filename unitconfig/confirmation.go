@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/auditlog"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const prevUnitConfigExt = ".prev"
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// ConfirmUnitConfig confirms applied unit config. Unconfirmed unit config is checked by node config statuses on
+// confirmation timeout and reverted to the previous one if any node fails to apply it.
+func (instance *Instance) ConfirmUnitConfig(version string) error {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.prevUnitConfig == nil {
+		return nil
+	}
+
+	if version != instance.unitConfig.Version {
+		return aoserrors.Errorf("wrong unit config version: %s", version)
+	}
+
+	instance.confirmUnitConfig()
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (instance *Instance) startConfirmation(prevUnitConfig cloudprotocol.UnitConfig) error {
+	if err := saveUnitConfig(instance.getPrevUnitConfigFile(), prevUnitConfig); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"version": instance.unitConfig.Version, "prevVersion": prevUnitConfig.Version,
+	}).Debug("Wait unit config confirmation")
+
+	instance.prevUnitConfig = &prevUnitConfig
+
+	instance.startConfirmTimer()
+
+	return nil
+}
+
+func (instance *Instance) startConfirmTimer() {
+	if instance.confirmTimer != nil {
+		instance.confirmTimer.Stop()
+	}
+
+	instance.confirmTimer = time.AfterFunc(instance.confirmTimeout, instance.handleConfirmTimeout)
+}
+
+func (instance *Instance) stopConfirmation() {
+	if instance.confirmTimer != nil {
+		instance.confirmTimer.Stop()
+		instance.confirmTimer = nil
+	}
+
+	instance.prevUnitConfig = nil
+
+	if err := os.RemoveAll(instance.getPrevUnitConfigFile()); err != nil {
+		log.Errorf("Can't remove previous unit config: %v", err)
+	}
+}
+
+func (instance *Instance) confirmUnitConfig() {
+	log.WithField("version", instance.unitConfig.Version).Info("Unit config confirmed")
+
+	instance.stopConfirmation()
+}
+
+func (instance *Instance) handleConfirmTimeout() {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.prevUnitConfig == nil {
+		return
+	}
+
+	if reason := instance.checkNodeConfigStatuses(); reason != "" {
+		err := instance.revertUnitConfig(*instance.prevUnitConfig, "confirmation timeout: "+reason)
+
+		log.Errorf("Unit config is not confirmed: %v", err)
+
+		return
+	}
+
+	instance.confirmUnitConfig()
+}
+
+// checkNodeConfigStatuses returns the reason why applied unit config is considered unhealthy.
+func (instance *Instance) checkNodeConfigStatuses() (reason string) {
+	nodeConfigStatuses, err := instance.client.GetNodeConfigStatuses()
+	if err != nil {
+		return "can't get node config statuses: " + err.Error()
+	}
+
+	for _, nodeConfigStatus := range nodeConfigStatuses {
+		if nodeConfigStatus.Error != nil {
+			return "node " + nodeConfigStatus.NodeID + " config error: " + nodeConfigStatus.Error.Message
+		}
+
+		if nodeConfigStatus.Version != instance.unitConfig.Version {
+			return "node " + nodeConfigStatus.NodeID + " has config version " + nodeConfigStatus.Version
+		}
+	}
+
+	return ""
+}
+
+// revertUnitConfig applies previous unit config and returns error with the revert reason.
+func (instance *Instance) revertUnitConfig(prevUnitConfig cloudprotocol.UnitConfig, reason string) error {
+	revertErr := aoserrors.Errorf("unit config %s reverted to %s: %s",
+		instance.unitConfig.Version, prevUnitConfig.Version, reason)
+
+	log.WithFields(log.Fields{
+		"version": instance.unitConfig.Version, "prevVersion": prevUnitConfig.Version,
+	}).Warnf("Revert unit config: %s", reason)
+
+	instance.stopConfirmation()
+
+	if instance.auditRecorder != nil {
+		instance.auditRecorder.Record(auditlog.SourceSystem, "", "UnitConfigRevert",
+			instance.unitConfig.Version+" reverted to "+prevUnitConfig.Version+": "+reason, nil)
+	}
+
+	instance.unitConfig = prevUnitConfig
+	instance.unitConfigError = revertErr
+
+	nodeConfigStatuses, err := instance.client.GetNodeConfigStatuses()
+	if err != nil {
+		log.Errorf("Error getting node config statuses: %v", err)
+	}
+
+	for _, nodeConfigStatus := range nodeConfigStatuses {
+		nodeConfig := instance.getNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, prevUnitConfig)
+
+		if err := instance.client.SetNodeConfig(
+			nodeConfigStatus.NodeID, prevUnitConfig.Version, nodeConfig); err != nil {
+			log.WithField("NodeID", nodeConfigStatus.NodeID).Errorf("Can't revert node config: %v", err)
+		}
+
+		if nodeConfigStatus.NodeID == instance.curNodeID {
+			instance.updateCurrentNodeConfigListeners(nodeConfig)
+		}
+	}
+
+	if err = saveUnitConfig(instance.unitConfigFile, prevUnitConfig); err != nil {
+		log.Errorf("Can't save reverted unit config: %v", err)
+	}
+
+	return revertErr
+}
+
+// loadPrevUnitConfig resumes confirmation of unit config applied before restart.
+func (instance *Instance) loadPrevUnitConfig() error {
+	byteValue, err := os.ReadFile(instance.getPrevUnitConfigFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return aoserrors.Wrap(err)
+	}
+
+	if instance.confirmTimeout == 0 || instance.unitConfigError != nil {
+		return aoserrors.Wrap(os.RemoveAll(instance.getPrevUnitConfigFile()))
+	}
+
+	var prevUnitConfig cloudprotocol.UnitConfig
+
+	if err = json.Unmarshal(byteValue, &prevUnitConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	instance.prevUnitConfig = &prevUnitConfig
+
+	instance.startConfirmTimer()
+
+	return nil
+}
+
+func (instance *Instance) getPrevUnitConfigFile() string {
+	return instance.unitConfigFile + prevUnitConfigExt
+}
+
+func saveUnitConfig(fileName string, unitConfig cloudprotocol.UnitConfig) error {
+	configJSON, err := json.Marshal(unitConfig)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = os.WriteFile(fileName, configJSON, 0o600); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
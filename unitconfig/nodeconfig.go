@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitconfig
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Node config variables substituted with node specific values.
+const (
+	NodeIDVariable   = "${nodeId}"
+	NodeTypeVariable = "${nodeType}"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// resolveNodeConfig returns node config for the node. By default, node config with node ID is used as is, otherwise
+// node config of the node type. If inheritance is enabled, node config without node ID is the base config for the node
+// type and node config with node ID overrides the base config fields which are set in it. Node variables are
+// substituted in the resolved config.
+func resolveNodeConfig(
+	nodeID, nodeType string, unitConfig cloudprotocol.UnitConfig, inheritance bool,
+) (nodeConfig cloudprotocol.NodeConfig, found bool) {
+	if !inheritance {
+		return findNodeConfig(nodeID, nodeType, unitConfig)
+	}
+
+	baseIndex := slices.IndexFunc(unitConfig.Nodes, func(nodeConfig cloudprotocol.NodeConfig) bool {
+		return nodeConfig.NodeID == nil && nodeConfig.NodeType == nodeType
+	})
+
+	if baseIndex >= 0 {
+		nodeConfig = unitConfig.Nodes[baseIndex]
+		found = true
+	}
+
+	overrideIndex := slices.IndexFunc(unitConfig.Nodes, func(nodeConfig cloudprotocol.NodeConfig) bool {
+		return nodeConfig.NodeID != nil && *nodeConfig.NodeID == nodeID
+	})
+
+	if overrideIndex >= 0 {
+		nodeConfig = mergeNodeConfig(nodeConfig, unitConfig.Nodes[overrideIndex])
+		found = true
+	}
+
+	if !found {
+		return cloudprotocol.NodeConfig{}, false
+	}
+
+	return substituteNodeVariables(nodeConfig, strings.NewReplacer(
+		NodeIDVariable, nodeID, NodeTypeVariable, nodeType)), true
+}
+
+func findNodeConfig(
+	nodeID, nodeType string, unitConfig cloudprotocol.UnitConfig,
+) (nodeConfig cloudprotocol.NodeConfig, found bool) {
+	for _, node := range unitConfig.Nodes {
+		if node.NodeID != nil && *node.NodeID == nodeID {
+			return node, true
+		}
+	}
+
+	for _, node := range unitConfig.Nodes {
+		if node.NodeType == nodeType {
+			return node, true
+		}
+	}
+
+	return cloudprotocol.NodeConfig{}, false
+}
+
+func mergeNodeConfig(base, override cloudprotocol.NodeConfig) cloudprotocol.NodeConfig {
+	merged := base
+
+	merged.NodeID = override.NodeID
+	merged.NodeType = override.NodeType
+
+	if override.ResourceRatios != nil {
+		merged.ResourceRatios = mergeResourceRatios(base.ResourceRatios, *override.ResourceRatios)
+	}
+
+	if override.AlertRules != nil {
+		merged.AlertRules = mergeAlertRules(base.AlertRules, *override.AlertRules)
+	}
+
+	merged.Devices = mergeByName(base.Devices, override.Devices, func(device cloudprotocol.DeviceInfo) string {
+		return device.Name
+	})
+	merged.Resources = mergeByName(base.Resources, override.Resources,
+		func(resource cloudprotocol.ResourceInfo) string {
+			return resource.Name
+		})
+
+	for _, label := range override.Labels {
+		if !slices.Contains(merged.Labels, label) {
+			merged.Labels = append(slices.Clip(merged.Labels), label)
+		}
+	}
+
+	if override.Priority != 0 {
+		merged.Priority = override.Priority
+	}
+
+	return merged
+}
+
+func mergeResourceRatios(
+	base *aostypes.ResourceRatiosInfo, override aostypes.ResourceRatiosInfo,
+) *aostypes.ResourceRatiosInfo {
+	if base == nil {
+		return &override
+	}
+
+	merged := *base
+
+	if override.CPU != nil {
+		merged.CPU = override.CPU
+	}
+
+	if override.RAM != nil {
+		merged.RAM = override.RAM
+	}
+
+	if override.Storage != nil {
+		merged.Storage = override.Storage
+	}
+
+	if override.State != nil {
+		merged.State = override.State
+	}
+
+	return &merged
+}
+
+func mergeAlertRules(base *aostypes.AlertRules, override aostypes.AlertRules) *aostypes.AlertRules {
+	if base == nil {
+		return &override
+	}
+
+	merged := *base
+
+	if override.RAM != nil {
+		merged.RAM = override.RAM
+	}
+
+	if override.CPU != nil {
+		merged.CPU = override.CPU
+	}
+
+	merged.Partitions = mergeByName(base.Partitions, override.Partitions,
+		func(partition aostypes.PartitionAlertRule) string {
+			return partition.Name
+		})
+
+	if override.Download != nil {
+		merged.Download = override.Download
+	}
+
+	if override.Upload != nil {
+		merged.Upload = override.Upload
+	}
+
+	return &merged
+}
+
+// mergeByName replaces base items by override items with the same name and appends new override items.
+func mergeByName[T any](base, override []T, getName func(item T) string) []T {
+	if len(override) == 0 {
+		return base
+	}
+
+	merged := slices.Clone(base)
+
+	for _, item := range override {
+		index := slices.IndexFunc(merged, func(mergedItem T) bool {
+			return getName(mergedItem) == getName(item)
+		})
+
+		if index >= 0 {
+			merged[index] = item
+		} else {
+			merged = append(merged, item)
+		}
+	}
+
+	return merged
+}
+
+// substituteNodeVariables returns copy of node config with substituted variables.
+func substituteNodeVariables(
+	nodeConfig cloudprotocol.NodeConfig, replacer *strings.Replacer,
+) cloudprotocol.NodeConfig {
+	substituteStrings := func(values []string) []string {
+		if values == nil {
+			return nil
+		}
+
+		substituted := make([]string, len(values))
+
+		for i, value := range values {
+			substituted[i] = replacer.Replace(value)
+		}
+
+		return substituted
+	}
+
+	nodeConfig.Labels = substituteStrings(nodeConfig.Labels)
+
+	if nodeConfig.Devices != nil {
+		devices := make([]cloudprotocol.DeviceInfo, len(nodeConfig.Devices))
+
+		for i, device := range nodeConfig.Devices {
+			device.Groups = substituteStrings(device.Groups)
+			device.HostDevices = substituteStrings(device.HostDevices)
+			devices[i] = device
+		}
+
+		nodeConfig.Devices = devices
+	}
+
+	if nodeConfig.Resources != nil {
+		resources := make([]cloudprotocol.ResourceInfo, len(nodeConfig.Resources))
+
+		for i, resource := range nodeConfig.Resources {
+			resource.Groups = substituteStrings(resource.Groups)
+			resource.Env = substituteStrings(resource.Env)
+
+			if resource.Mounts != nil {
+				mounts := make([]cloudprotocol.FileSystemMount, len(resource.Mounts))
+
+				for j, mount := range resource.Mounts {
+					mount.Destination = replacer.Replace(mount.Destination)
+					mount.Source = replacer.Replace(mount.Source)
+					mount.Options = substituteStrings(mount.Options)
+					mounts[j] = mount
+				}
+
+				resource.Mounts = mounts
+			}
+
+			if resource.Hosts != nil {
+				hosts := make([]cloudprotocol.HostInfo, len(resource.Hosts))
+
+				for j, host := range resource.Hosts {
+					host.IP = replacer.Replace(host.IP)
+					host.Hostname = replacer.Replace(host.Hostname)
+					hosts[j] = host
+				}
+
+				resource.Hosts = hosts
+			}
+
+			resources[i] = resource
+		}
+
+		nodeConfig.Resources = resources
+	}
+
+	return nodeConfig
+}
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitconfig
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+
+	"github.com/aosedge/aos_communicationmanager/utils/jsonschema"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const schemaRootPath = "$"
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//go:embed unitconfig.schema.json
+var unitConfigSchemaJSON []byte
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ValidationError unit config violation.
+type ValidationError struct {
+	Path   string
+	Reason string
+}
+
+// ValidationErrors unit config violations.
+type ValidationErrors []ValidationError
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// NewSchema creates unit config JSON schema. It is used to validate raw unit config received from the cloud.
+func NewSchema() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.New(unitConfigSchemaJSON)
+	if err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	return schema, nil
+}
+
+// ValidateUnitConfigData validates raw unit config JSON against unit config schema and unit config constraints.
+func ValidateUnitConfigData(data []byte) error {
+	if err := validateDocument(data); err != nil {
+		return err
+	}
+
+	var unitConfig cloudprotocol.UnitConfig
+
+	if err := json.Unmarshal(data, &unitConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return checkUnitConfig(unitConfig)
+}
+
+// Error returns violation as string.
+func (validationError ValidationError) Error() string {
+	return validationError.Path + ": " + validationError.Reason
+}
+
+// Error returns all violations as string.
+func (validationErrors ValidationErrors) Error() string {
+	violations := make([]string, 0, len(validationErrors))
+
+	for _, validationError := range validationErrors {
+		violations = append(violations, validationError.Error())
+	}
+
+	return "invalid unit config: " + strings.Join(violations, "; ")
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// checkUnitConfig checks unit config constraints which can't be expressed by the schema. Unlike the schema, they
+// are checked on decoded unit config, so zero values of missing fields are not reported.
+func checkUnitConfig(unitConfig cloudprotocol.UnitConfig) error {
+	if violations := checkNodeConfigs(unitConfig.Nodes); len(violations) != 0 {
+		return violations
+	}
+
+	return nil
+}
+
+// validateDocument validates JSON document against unit config schema.
+func validateDocument(data []byte) error {
+	schema, err := NewSchema()
+	if err != nil {
+		return err
+	}
+
+	violations, err := schema.Validate(data)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	validationErrors := make(ValidationErrors, 0, len(violations))
+
+	for _, violation := range violations {
+		validationErrors = append(validationErrors, ValidationError{
+			Path: getViolationPath(violation.Path), Reason: violation.Message,
+		})
+	}
+
+	return validationErrors
+}
+
+// getViolationPath converts JSON pointer to unit config path: /nodes/0/nodeType -> $.nodes[0].nodeType.
+func getViolationPath(pointer string) string {
+	path := schemaRootPath
+
+	if pointer == "" {
+		return path
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if _, err := strconv.Atoi(token); err == nil {
+			path += "[" + token + "]"
+		} else {
+			path += "." + token
+		}
+	}
+
+	return path
+}
+
+// checkNodeConfigs checks node configs constraints which can't be expressed by the schema.
+func checkNodeConfigs(nodeConfigs []cloudprotocol.NodeConfig) (violations ValidationErrors) {
+	nodeIDs := make(map[string]struct{})
+	nodeTypes := make(map[string]struct{})
+
+	for i, nodeConfig := range nodeConfigs {
+		path := fmt.Sprintf("%s.nodes[%d]", schemaRootPath, i)
+
+		if nodeConfig.NodeID != nil {
+			if _, ok := nodeIDs[*nodeConfig.NodeID]; ok {
+				violations = append(violations, ValidationError{
+					Path: path + ".nodeId", Reason: fmt.Sprintf("duplicated node ID %s", *nodeConfig.NodeID),
+				})
+			}
+
+			nodeIDs[*nodeConfig.NodeID] = struct{}{}
+		} else {
+			if _, ok := nodeTypes[nodeConfig.NodeType]; ok {
+				violations = append(violations, ValidationError{
+					Path: path + ".nodeType", Reason: fmt.Sprintf("duplicated node type %s", nodeConfig.NodeType),
+				})
+			}
+
+			nodeTypes[nodeConfig.NodeType] = struct{}{}
+		}
+
+		violations = append(violations, checkAlertRules(nodeConfig, path+".alertRules")...)
+	}
+
+	return violations
+}
+
+func checkAlertRules(nodeConfig cloudprotocol.NodeConfig, path string) (violations ValidationErrors) {
+	if nodeConfig.AlertRules == nil {
+		return nil
+	}
+
+	checkThresholds := func(rulePath string, minThreshold, maxThreshold float64) {
+		if minThreshold > maxThreshold {
+			violations = append(violations, ValidationError{
+				Path: rulePath + ".minThreshold", Reason: "must be <= maxThreshold",
+			})
+		}
+	}
+
+	rules := nodeConfig.AlertRules
+
+	if rules.RAM != nil {
+		checkThresholds(path+".ram", rules.RAM.MinThreshold, rules.RAM.MaxThreshold)
+	}
+
+	if rules.CPU != nil {
+		checkThresholds(path+".cpu", rules.CPU.MinThreshold, rules.CPU.MaxThreshold)
+	}
+
+	for i, partition := range rules.Partitions {
+		checkThresholds(fmt.Sprintf("%s.partitions[%d]", path, i), partition.MinThreshold, partition.MaxThreshold)
+	}
+
+	if rules.Download != nil {
+		checkThresholds(path+".download", float64(rules.Download.MinThreshold), float64(rules.Download.MaxThreshold))
+	}
+
+	if rules.Upload != nil {
+		checkThresholds(path+".upload", float64(rules.Upload.MinThreshold), float64(rules.Upload.MaxThreshold))
+	}
+
+	return violations
+}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	semver "github.com/hashicorp/go-version"
@@ -53,6 +54,11 @@ type Instance struct {
 	unitConfig                 cloudprotocol.UnitConfig
 	currentNodeConfigListeners []chan cloudprotocol.NodeConfig
 	unitConfigError            error
+	confirmTimeout             time.Duration
+	prevUnitConfig             *cloudprotocol.UnitConfig
+	confirmTimer               *time.Timer
+	auditRecorder              AuditRecorder
+	nodeConfigInheritance      bool
 }
 
 // NodeInfoProvider node info provider interface.
@@ -60,6 +66,11 @@ type NodeInfoProvider interface {
 	GetCurrentNodeInfo() (cloudprotocol.NodeInfo, error)
 }
 
+// AuditRecorder records actions to the audit log.
+type AuditRecorder interface {
+	Record(source, actor, action, details string, actionErr error)
+}
+
 // Client client unit config interface.
 type Client interface {
 	CheckNodeConfig(nodeID, version string, nodeConfig cloudprotocol.NodeConfig) error
@@ -89,6 +100,8 @@ func New(cfg *config.Config, nodeInfoProvider NodeInfoProvider, client Client) (
 		client:                     client,
 		unitConfigFile:             cfg.UnitConfigFile,
 		currentNodeConfigListeners: make([]chan cloudprotocol.NodeConfig, 0),
+		confirmTimeout:             cfg.UnitConfigTimeout.Duration,
+		nodeConfigInheritance:      cfg.NodeConfigInheritance,
 	}
 
 	var nodeInfo cloudprotocol.NodeInfo
@@ -104,6 +117,10 @@ func New(cfg *config.Config, nodeInfoProvider NodeInfoProvider, client Client) (
 		instance.unitConfigError = err
 	}
 
+	if err := instance.loadPrevUnitConfig(); err != nil {
+		log.Errorf("Can't load previous unit config: %v", err)
+	}
+
 	go instance.handleNodeConfigStatus()
 
 	return instance, nil
@@ -136,6 +153,10 @@ func (instance *Instance) CheckUnitConfig(unitConfig cloudprotocol.UnitConfig) e
 		return aoserrors.Wrap(err)
 	}
 
+	if err := checkUnitConfig(unitConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
 	nodeConfigStatuses, err := instance.client.GetNodeConfigStatuses()
 	if err != nil {
 		log.Errorf("Error getting node config statuses: %v", err)
@@ -143,7 +164,7 @@ func (instance *Instance) CheckUnitConfig(unitConfig cloudprotocol.UnitConfig) e
 
 	for i, nodeConfigStatus := range nodeConfigStatuses {
 		if nodeConfigStatus.Version != unitConfig.Version || nodeConfigStatus.Error != nil {
-			nodeConfig := findNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, unitConfig)
+			nodeConfig := instance.getNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, unitConfig)
 
 			nodeConfig.NodeID = &nodeConfigStatuses[i].NodeID
 
@@ -159,19 +180,12 @@ func (instance *Instance) CheckUnitConfig(unitConfig cloudprotocol.UnitConfig) e
 
 // GetNodeConfig returns node config for node or node type.
 func (instance *Instance) GetNodeConfig(nodeID, nodeType string) (cloudprotocol.NodeConfig, error) {
-	for _, node := range instance.unitConfig.Nodes {
-		if node.NodeID != nil && *node.NodeID == nodeID {
-			return node, nil
-		}
-	}
-
-	for _, node := range instance.unitConfig.Nodes {
-		if node.NodeType == nodeType {
-			return node, nil
-		}
+	nodeConfig, ok := resolveNodeConfig(nodeID, nodeType, instance.unitConfig, instance.nodeConfigInheritance)
+	if !ok {
+		return cloudprotocol.NodeConfig{}, ErrNotFound
 	}
 
-	return cloudprotocol.NodeConfig{}, ErrNotFound
+	return nodeConfig, nil
 }
 
 // GetNodeConfig returns node config of the node with given id and type.
@@ -179,6 +193,26 @@ func (instance *Instance) GetCurrentNodeConfig() (cloudprotocol.NodeConfig, erro
 	return instance.GetNodeConfig(instance.curNodeID, instance.curNodeType)
 }
 
+// ConfigReloaded applies reloaded config.
+func (instance *Instance) ConfigReloaded(cfg *config.Config) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.confirmTimeout != cfg.UnitConfigTimeout.Duration {
+		log.WithField("timeout", cfg.UnitConfigTimeout).Info("Unit config confirmation timeout changed")
+	}
+
+	instance.confirmTimeout = cfg.UnitConfigTimeout.Duration
+}
+
+// SetAuditRecorder sets recorder of unit config reverts.
+func (instance *Instance) SetAuditRecorder(auditRecorder AuditRecorder) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	instance.auditRecorder = auditRecorder
+}
+
 // SubscribeCurrentNodeConfigChange subscribes new current node config listener.
 func (instance *Instance) SubscribeCurrentNodeConfigChange() <-chan cloudprotocol.NodeConfig {
 	instance.Lock()
@@ -207,6 +241,17 @@ func (instance *Instance) UpdateUnitConfig(unitConfig cloudprotocol.UnitConfig)
 		return aoserrors.Wrap(err)
 	}
 
+	if err := checkUnitConfig(unitConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	prevUnitConfig := instance.unitConfig
+
+	// revert to the last confirmed unit config if previous update is not confirmed yet
+	if instance.prevUnitConfig != nil {
+		prevUnitConfig = *instance.prevUnitConfig
+	}
+
 	instance.unitConfig = unitConfig
 
 	nodeConfigStatuses, err := instance.client.GetNodeConfigStatuses()
@@ -216,10 +261,14 @@ func (instance *Instance) UpdateUnitConfig(unitConfig cloudprotocol.UnitConfig)
 
 	for _, nodeConfigStatus := range nodeConfigStatuses {
 		if nodeConfigStatus.Version != unitConfig.Version || nodeConfigStatus.Error != nil {
-			nodeConfig := findNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, unitConfig)
+			nodeConfig := instance.getNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, unitConfig)
 
 			if err := instance.client.SetNodeConfig(
 				nodeConfigStatus.NodeID, unitConfig.Version, nodeConfig); err != nil {
+				if instance.confirmTimeout != 0 {
+					return instance.revertUnitConfig(prevUnitConfig, err.Error())
+				}
+
 				return aoserrors.Wrap(err)
 			}
 
@@ -229,13 +278,14 @@ func (instance *Instance) UpdateUnitConfig(unitConfig cloudprotocol.UnitConfig)
 		}
 	}
 
-	configJSON, err := json.Marshal(instance.unitConfig)
-	if err != nil {
-		return aoserrors.Wrap(err)
+	if err = saveUnitConfig(instance.unitConfigFile, instance.unitConfig); err != nil {
+		return err
 	}
 
-	if err = os.WriteFile(instance.unitConfigFile, configJSON, 0o600); err != nil {
-		return aoserrors.Wrap(err)
+	if instance.confirmTimeout != 0 {
+		if err = instance.startConfirmation(prevUnitConfig); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -266,6 +316,11 @@ func (instance *Instance) load() (err error) {
 		return aoserrors.Wrap(err)
 	}
 
+	// unit config stored by previous CM versions is accepted even if it doesn't match the schema
+	if err := ValidateUnitConfigData(byteValue); err != nil {
+		log.WithField("version", instance.unitConfig.Version).Warnf("Unit config doesn't match schema: %v", err)
+	}
+
 	return nil
 }
 
@@ -298,42 +353,51 @@ func (instance *Instance) handleNodeConfigStatus() {
 			return
 		}
 
-		if instance.unitConfigError != nil {
-			log.WithField("NodeID", nodeConfigStatus.NodeID).Warnf(
-				"Can't update node config due to: %v", instance.unitConfigError)
-		}
+		instance.processNodeConfigStatus(nodeConfigStatus)
+	}
+}
 
-		if nodeConfigStatus.Version == instance.unitConfig.Version && nodeConfigStatus.Error == nil {
-			continue
-		}
+func (instance *Instance) processNodeConfigStatus(nodeConfigStatus NodeConfigStatus) {
+	instance.Lock()
+	defer instance.Unlock()
 
-		nodeConfig := findNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, instance.unitConfig)
+	if instance.unitConfigError != nil {
+		log.WithField("NodeID", nodeConfigStatus.NodeID).Warnf(
+			"Can't update node config due to: %v", instance.unitConfigError)
+	}
 
-		if err := instance.client.SetNodeConfig(
-			nodeConfigStatus.NodeID, instance.unitConfig.Version, nodeConfig); err != nil {
-			log.WithField("NodeID", nodeConfigStatus.NodeID).Errorf("Can't update node config: %v", err)
-		}
+	if instance.prevUnitConfig != nil && nodeConfigStatus.Version == instance.unitConfig.Version &&
+		nodeConfigStatus.Error != nil {
+		err := instance.revertUnitConfig(*instance.prevUnitConfig, "node "+nodeConfigStatus.NodeID+
+			" config error: "+nodeConfigStatus.Error.Message)
 
-		if nodeConfigStatus.NodeID == instance.curNodeID {
-			instance.updateCurrentNodeConfigListeners(nodeConfig)
-		}
+		log.Errorf("Unit config is not confirmed: %v", err)
+
+		return
 	}
-}
 
-func findNodeConfig(nodeID, nodeType string, unitConfig cloudprotocol.UnitConfig) cloudprotocol.NodeConfig {
-	for i, nodeConfig := range unitConfig.Nodes {
-		if nodeConfig.NodeID != nil && *nodeConfig.NodeID == nodeID {
-			return unitConfig.Nodes[i]
-		}
+	if nodeConfigStatus.Version == instance.unitConfig.Version && nodeConfigStatus.Error == nil {
+		return
 	}
 
-	for i, nodeConfig := range unitConfig.Nodes {
-		if nodeConfig.NodeType == nodeType {
-			return unitConfig.Nodes[i]
-		}
+	nodeConfig := instance.getNodeConfig(nodeConfigStatus.NodeID, nodeConfigStatus.NodeType, instance.unitConfig)
+
+	if err := instance.client.SetNodeConfig(
+		nodeConfigStatus.NodeID, instance.unitConfig.Version, nodeConfig); err != nil {
+		log.WithField("NodeID", nodeConfigStatus.NodeID).Errorf("Can't update node config: %v", err)
+	}
+
+	if nodeConfigStatus.NodeID == instance.curNodeID {
+		instance.updateCurrentNodeConfigListeners(nodeConfig)
 	}
+}
+
+func (instance *Instance) getNodeConfig(
+	nodeID, nodeType string, unitConfig cloudprotocol.UnitConfig,
+) cloudprotocol.NodeConfig {
+	nodeConfig, _ := resolveNodeConfig(nodeID, nodeType, unitConfig, instance.nodeConfigInheritance)
 
-	return cloudprotocol.NodeConfig{}
+	return nodeConfig
 }
 
 func (instance *Instance) updateCurrentNodeConfigListeners(curNodeConfig cloudprotocol.NodeConfig) {
@@ -19,12 +19,18 @@ package unitconfig_test
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
@@ -70,6 +76,7 @@ type testNodeConfig struct {
 }
 
 type testClient struct {
+	sync.Mutex
 	nodeConfigStatuses        []unitconfig.NodeConfigStatus
 	nodeConfigStatusChannel   chan unitconfig.NodeConfigStatus
 	nodeConfigSetCheckChannel chan testNodeConfig
@@ -388,6 +395,497 @@ func TestCurrentNodeConfigUpdate(t *testing.T) {
 	}
 }
 
+func TestUnitConfigValidation(t *testing.T) {
+	type testData struct {
+		unitConfig string
+		violations unitconfig.ValidationErrors
+	}
+
+	data := []testData{
+		{
+			unitConfig: `{"formatVersion": "1", "version": "2.0.0", "nodes": [{"nodeId": "node1"}]}`,
+			violations: unitconfig.ValidationErrors{
+				{Path: "$.nodes[0].nodeType", Reason: "required property is missing"},
+			},
+		},
+		{
+			unitConfig: `{"formatVersion": "1", "version": "2.0.0", "nodes": [{
+				"nodeType": "type1", "resourceRatios": {"cpu": 150, "ram": "high"},
+				"resources": [{"name": "resource1", "mounts": [{"destination": "tmp"}]}]
+			}]}`,
+			violations: unitconfig.ValidationErrors{
+				{Path: "$.nodes[0].resourceRatios.cpu", Reason: "value is greater than 100"},
+				{Path: "$.nodes[0].resourceRatios.ram", Reason: "wrong type: expected number, got string"},
+				{Path: "$.nodes[0].resources[0].mounts[0].destination", Reason: "value doesn't match pattern ^/"},
+			},
+		},
+		{
+			unitConfig: `{"formatVersion": "1", "version": "2.0.0", "nodes": [
+				{"nodeId": "node1", "nodeType": "type1"},
+				{"nodeId": "node1", "nodeType": "type2", "alertRules": {"cpu": {"minThreshold": 90, "maxThreshold": 80}}}
+			]}`,
+			violations: unitconfig.ValidationErrors{
+				{Path: "$.nodes[1].nodeId", Reason: "duplicated node ID node1"},
+				{Path: "$.nodes[1].alertRules.cpu.minThreshold", Reason: "must be <= maxThreshold"},
+			},
+		},
+	}
+
+	for i, item := range data {
+		var violations unitconfig.ValidationErrors
+
+		if err := unitconfig.ValidateUnitConfigData([]byte(item.unitConfig)); !errors.As(err, &violations) {
+			t.Fatalf("Validation error expected: %v", err)
+		}
+
+		if !reflect.DeepEqual(violations, item.violations) {
+			t.Errorf("Wrong violations in config %d: %v", i, violations)
+		}
+	}
+}
+
+func TestUnitConfigConstraints(t *testing.T) {
+	if err := os.WriteFile(path.Join(tmpDir, "aos_unit.cfg"), []byte(validTestUnitConfig), 0o600); err != nil {
+		t.Fatalf("Can't create unit config file: %v", err)
+	}
+
+	client := newTestClient()
+	nodeInfoProvider := newTestInfoProvider("node0", "type1")
+
+	unitConfig, err := unitconfig.New(
+		&config.Config{UnitConfigFile: path.Join(tmpDir, "aos_unit.cfg")}, nodeInfoProvider, client)
+	if err != nil {
+		t.Fatalf("Can't create unit config instance: %v", err)
+	}
+
+	nodeID := "node1"
+	nodes := []cloudprotocol.NodeConfig{{NodeID: &nodeID, NodeType: "type1"}, {NodeID: &nodeID, NodeType: "type2"}}
+
+	var violations unitconfig.ValidationErrors
+
+	if err = unitConfig.CheckUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "2.0.0", Nodes: nodes,
+	}); !errors.As(err, &violations) {
+		t.Fatalf("Validation error expected: %v", err)
+	}
+
+	if !reflect.DeepEqual(violations, unitconfig.ValidationErrors{
+		{Path: "$.nodes[1].nodeId", Reason: "duplicated node ID node1"},
+	}) {
+		t.Errorf("Wrong violations: %v", violations)
+	}
+
+	if err = unitConfig.UpdateUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "2.0.0", Nodes: nodes,
+	}); err == nil {
+		t.Fatal("Error expected")
+	}
+
+	status, err := unitConfig.GetStatus()
+	if err != nil {
+		t.Fatalf("Get unit config status error: %v", err)
+	}
+
+	if status.Status != cloudprotocol.ErrorStatus || status.ErrorInfo == nil ||
+		!strings.Contains(status.ErrorInfo.Message, "$.nodes[1].nodeId: duplicated node ID node1") {
+		t.Errorf("Wrong unit config status: %v", status)
+	}
+
+	if len(client.nodeConfigSetCheckChannel) != 0 {
+		t.Error("Invalid unit config should not be sent to nodes")
+	}
+}
+
+func TestLoadUnitConfigNotMatchingSchema(t *testing.T) {
+	// unit config stored by previous CM version
+	if err := os.WriteFile(path.Join(tmpDir, "aos_unit.cfg"), []byte(`{
+		"formatVersion": "1", "version": "1.0.0", "nodes": [{"nodeType": "type1", "resourceRatios": {"cpu": 150}}]
+	}`), 0o600); err != nil {
+		t.Fatalf("Can't create unit config file: %v", err)
+	}
+
+	unitConfig, err := unitconfig.New(&config.Config{UnitConfigFile: path.Join(tmpDir, "aos_unit.cfg")},
+		newTestInfoProvider("node0", "type1"), newTestClient())
+	if err != nil {
+		t.Fatalf("Can't create unit config instance: %v", err)
+	}
+
+	status, err := unitConfig.GetStatus()
+	if err != nil {
+		t.Fatalf("Get unit config status error: %v", err)
+	}
+
+	if status.Status != cloudprotocol.InstalledStatus || status.Version != "1.0.0" {
+		t.Errorf("Wrong unit config status: %v", status)
+	}
+}
+
+func TestUnitConfigRevert(t *testing.T) {
+	if err := os.WriteFile(path.Join(tmpDir, "aos_unit.cfg"), []byte(validTestUnitConfig), 0o600); err != nil {
+		t.Fatalf("Can't create unit config file: %v", err)
+	}
+
+	client := newTestClient()
+	nodeInfoProvider := newTestInfoProvider("node0", "type1")
+
+	unitConfig, err := unitconfig.New(&config.Config{
+		UnitConfigFile:    path.Join(tmpDir, "aos_unit.cfg"),
+		UnitConfigTimeout: aostypes.Duration{Duration: 500 * time.Millisecond},
+	}, nodeInfoProvider, client)
+	if err != nil {
+		t.Fatalf("Can't create unit config instance: %v", err)
+	}
+
+	client.setNodeConfigStatuses([]unitconfig.NodeConfigStatus{
+		{NodeID: "id1", NodeType: "type1", Version: "1.0.0"},
+		{NodeID: "id2", NodeType: "type1", Version: "1.0.0"},
+	})
+
+	// Revert on confirmation timeout
+
+	if err = unitConfig.UpdateUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "2.0.0", Nodes: []cloudprotocol.NodeConfig{{NodeType: "type1"}},
+	}); err != nil {
+		t.Fatalf("Can't update unit config: %v", err)
+	}
+
+	if err = waitNodeConfigs(client, "2.0.0", "2.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	client.setNodeConfigStatuses([]unitconfig.NodeConfigStatus{
+		{NodeID: "id1", NodeType: "type1", Version: "2.0.0"},
+		{NodeID: "id2", NodeType: "type1", Version: "2.0.0", Error: &cloudprotocol.ErrorInfo{Message: "failed"}},
+	})
+
+	if err = waitNodeConfigs(client, "1.0.0", "1.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	status, err := unitConfig.GetStatus()
+	if err != nil {
+		t.Fatalf("Get unit config status error: %v", err)
+	}
+
+	if status.Version != "1.0.0" || status.Status != cloudprotocol.ErrorStatus || status.ErrorInfo == nil ||
+		!strings.Contains(status.ErrorInfo.Message, "node id2 config error: failed") {
+		t.Errorf("Wrong unit config status: %v", status)
+	}
+
+	if err = checkUnitConfigFileVersion("1.0.0"); err != nil {
+		t.Errorf("Wrong unit config file: %v", err)
+	}
+
+	// Confirm by node config statuses
+
+	client.setNodeConfigStatuses([]unitconfig.NodeConfigStatus{
+		{NodeID: "id1", NodeType: "type1", Version: "1.0.0"},
+		{NodeID: "id2", NodeType: "type1", Version: "1.0.0"},
+	})
+
+	if err = unitConfig.UpdateUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "2.0.0", Nodes: []cloudprotocol.NodeConfig{{NodeType: "type1"}},
+	}); err != nil {
+		t.Fatalf("Can't update unit config: %v", err)
+	}
+
+	if err = waitNodeConfigs(client, "2.0.0", "2.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	if err = checkNoNodeConfigs(client, time.Second); err != nil {
+		t.Errorf("Unexpected node configs: %v", err)
+	}
+
+	if status, err = unitConfig.GetStatus(); err != nil {
+		t.Fatalf("Get unit config status error: %v", err)
+	}
+
+	if status.Version != "2.0.0" || status.Status != cloudprotocol.InstalledStatus {
+		t.Errorf("Wrong unit config status: %v", status)
+	}
+
+	// Confirm by cloud
+
+	if err = unitConfig.UpdateUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "3.0.0", Nodes: []cloudprotocol.NodeConfig{{NodeType: "type1"}},
+	}); err != nil {
+		t.Fatalf("Can't update unit config: %v", err)
+	}
+
+	if err = waitNodeConfigs(client, "3.0.0", "3.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	if err = unitConfig.ConfirmUnitConfig("3.0.0"); err != nil {
+		t.Fatalf("Can't confirm unit config: %v", err)
+	}
+
+	client.setNodeConfigStatuses([]unitconfig.NodeConfigStatus{
+		{NodeID: "id1", NodeType: "type1", Version: "2.0.0"},
+		{NodeID: "id2", NodeType: "type1", Version: "3.0.0"},
+	})
+
+	if err = checkNoNodeConfigs(client, time.Second); err != nil {
+		t.Errorf("Unexpected node configs: %v", err)
+	}
+
+	if err = checkUnitConfigFileVersion("3.0.0"); err != nil {
+		t.Errorf("Wrong unit config file: %v", err)
+	}
+
+	// Revert on node config error
+
+	if err = unitConfig.UpdateUnitConfig(cloudprotocol.UnitConfig{
+		FormatVersion: "1", Version: "4.0.0", Nodes: []cloudprotocol.NodeConfig{{NodeType: "type1"}},
+	}); err != nil {
+		t.Fatalf("Can't update unit config: %v", err)
+	}
+
+	if err = waitNodeConfigs(client, "4.0.0", "4.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	client.nodeConfigStatusChannel <- unitconfig.NodeConfigStatus{
+		NodeID: "id1", NodeType: "type1", Version: "4.0.0", Error: &cloudprotocol.ErrorInfo{Message: "failed"},
+	}
+
+	if err = waitNodeConfigs(client, "3.0.0", "3.0.0"); err != nil {
+		t.Fatalf("Wrong node configs: %v", err)
+	}
+
+	if status, err = unitConfig.GetStatus(); err != nil {
+		t.Fatalf("Get unit config status error: %v", err)
+	}
+
+	if status.Version != "3.0.0" || status.Status != cloudprotocol.ErrorStatus {
+		t.Errorf("Wrong unit config status: %v", status)
+	}
+}
+
+func TestNodeConfigInheritance(t *testing.T) {
+	testUnitConfig := `
+{
+	"formatVersion": "1",
+	"version": "1.0.0",
+	"nodes": [
+		{
+			"nodeType": "type1",
+			"resourceRatios": {"cpu": 50, "ram": 60},
+			"devices": [{"name": "dev1", "hostDevices": ["/dev/${nodeId}"]}],
+			"resources": [{"name": "res1", "env": ["NODE=${nodeId}", "TYPE=${nodeType}"]}],
+			"labels": ["base"],
+			"priority": 1
+		},
+		{
+			"nodeId": "node1",
+			"nodeType": "type1",
+			"resourceRatios": {"cpu": 80},
+			"devices": [{"name": "dev2", "hostDevices": ["/dev/gpu"]}],
+			"labels": ["gpu"]
+		}
+	]
+}`
+
+	if err := os.WriteFile(path.Join(tmpDir, "aos_unit.cfg"), []byte(testUnitConfig), 0o600); err != nil {
+		t.Fatalf("Can't create unit config file: %v", err)
+	}
+
+	unitConfig, err := unitconfig.New(&config.Config{
+		UnitConfigFile: path.Join(tmpDir, "aos_unit.cfg"), NodeConfigInheritance: true,
+	}, newTestInfoProvider("node0", "type1"), newTestClient())
+	if err != nil {
+		t.Fatalf("Can't create unit config instance: %v", err)
+	}
+
+	var (
+		node1    = "node1"
+		cpuBase  = 50.0
+		cpuNode1 = 80.0
+		ram      = 60.0
+	)
+
+	type testData struct {
+		nodeID     string
+		nodeConfig cloudprotocol.NodeConfig
+	}
+
+	data := []testData{
+		{
+			nodeID: "node0",
+			nodeConfig: cloudprotocol.NodeConfig{
+				NodeType:       "type1",
+				ResourceRatios: &aostypes.ResourceRatiosInfo{CPU: &cpuBase, RAM: &ram},
+				Devices:        []cloudprotocol.DeviceInfo{{Name: "dev1", HostDevices: []string{"/dev/node0"}}},
+				Resources: []cloudprotocol.ResourceInfo{
+					{Name: "res1", Env: []string{"NODE=node0", "TYPE=type1"}},
+				},
+				Labels:   []string{"base"},
+				Priority: 1,
+			},
+		},
+		{
+			nodeID: "node1",
+			nodeConfig: cloudprotocol.NodeConfig{
+				NodeID:         &node1,
+				NodeType:       "type1",
+				ResourceRatios: &aostypes.ResourceRatiosInfo{CPU: &cpuNode1, RAM: &ram},
+				Devices: []cloudprotocol.DeviceInfo{
+					{Name: "dev1", HostDevices: []string{"/dev/node1"}},
+					{Name: "dev2", HostDevices: []string{"/dev/gpu"}},
+				},
+				Resources: []cloudprotocol.ResourceInfo{
+					{Name: "res1", Env: []string{"NODE=node1", "TYPE=type1"}},
+				},
+				Labels:   []string{"base", "gpu"},
+				Priority: 1,
+			},
+		},
+	}
+
+	for _, item := range data {
+		nodeConfig, err := unitConfig.GetNodeConfig(item.nodeID, "type1")
+		if err != nil {
+			t.Fatalf("Can't get node config: %v", err)
+		}
+
+		if !reflect.DeepEqual(nodeConfig, item.nodeConfig) {
+			t.Errorf("Wrong node %s config: %+v", item.nodeID, nodeConfig)
+		}
+	}
+
+	if _, err = unitConfig.GetNodeConfig("node2", "type2"); !errors.Is(err, unitconfig.ErrNotFound) {
+		t.Errorf("Not found error expected: %v", err)
+	}
+}
+
+func TestNodeConfigReplace(t *testing.T) {
+	testUnitConfig := `
+{
+	"formatVersion": "1",
+	"version": "1.0.0",
+	"nodes": [
+		{
+			"nodeType": "type1",
+			"resourceRatios": {"cpu": 50, "ram": 60},
+			"labels": ["base"],
+			"priority": 1
+		},
+		{
+			"nodeId": "node1",
+			"nodeType": "type1",
+			"resourceRatios": {"cpu": 80},
+			"devices": [{"name": "dev1", "hostDevices": ["/dev/${nodeId}"]}],
+			"labels": ["gpu"]
+		}
+	]
+}`
+
+	if err := os.WriteFile(path.Join(tmpDir, "aos_unit.cfg"), []byte(testUnitConfig), 0o600); err != nil {
+		t.Fatalf("Can't create unit config file: %v", err)
+	}
+
+	unitConfig, err := unitconfig.New(&config.Config{UnitConfigFile: path.Join(tmpDir, "aos_unit.cfg")},
+		newTestInfoProvider("node0", "type1"), newTestClient())
+	if err != nil {
+		t.Fatalf("Can't create unit config instance: %v", err)
+	}
+
+	var (
+		node1    = "node1"
+		cpuBase  = 50.0
+		cpuNode1 = 80.0
+		ram      = 60.0
+	)
+
+	type testData struct {
+		nodeID     string
+		nodeConfig cloudprotocol.NodeConfig
+	}
+
+	data := []testData{
+		{
+			nodeID: "node0",
+			nodeConfig: cloudprotocol.NodeConfig{
+				NodeType:       "type1",
+				ResourceRatios: &aostypes.ResourceRatiosInfo{CPU: &cpuBase, RAM: &ram},
+				Labels:         []string{"base"},
+				Priority:       1,
+			},
+		},
+		{
+			nodeID: "node1",
+			nodeConfig: cloudprotocol.NodeConfig{
+				NodeID:         &node1,
+				NodeType:       "type1",
+				ResourceRatios: &aostypes.ResourceRatiosInfo{CPU: &cpuNode1},
+				Devices:        []cloudprotocol.DeviceInfo{{Name: "dev1", HostDevices: []string{"/dev/${nodeId}"}}},
+				Labels:         []string{"gpu"},
+			},
+		},
+	}
+
+	for _, item := range data {
+		nodeConfig, err := unitConfig.GetNodeConfig(item.nodeID, "type1")
+		if err != nil {
+			t.Fatalf("Can't get node config: %v", err)
+		}
+
+		if !reflect.DeepEqual(nodeConfig, item.nodeConfig) {
+			t.Errorf("Wrong node %s config: %+v", item.nodeID, nodeConfig)
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func waitNodeConfigs(client *testClient, versions ...string) error {
+	for _, version := range versions {
+		select {
+		case nodeConfig := <-client.nodeConfigSetCheckChannel:
+			if nodeConfig.Version != version {
+				return aoserrors.Errorf("wrong node config version: %s", nodeConfig.Version)
+			}
+
+		case <-time.After(2 * time.Second):
+			return aoserrors.New("wait node config timeout")
+		}
+	}
+
+	return nil
+}
+
+func checkNoNodeConfigs(client *testClient, timeout time.Duration) error {
+	select {
+	case nodeConfig := <-client.nodeConfigSetCheckChannel:
+		return aoserrors.Errorf("unexpected node config: %v", nodeConfig)
+
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func checkUnitConfigFileVersion(version string) error {
+	data, err := os.ReadFile(path.Join(tmpDir, "aos_unit.cfg"))
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	var unitConfig cloudprotocol.UnitConfig
+
+	if err = json.Unmarshal(data, &unitConfig); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if unitConfig.Version != version {
+		return aoserrors.Errorf("wrong unit config version: %s", unitConfig.Version)
+	}
+
+	return nil
+}
+
 /***********************************************************************************************************************
  * testClient
  **********************************************************************************************************************/
@@ -410,6 +908,15 @@ func (client *testClient) CheckNodeConfig(nodeID string, version string, nodeCon
 }
 
 func (client *testClient) SetNodeConfig(nodeID string, version string, nodeConfig cloudprotocol.NodeConfig) error {
+	client.Lock()
+	defer client.Unlock()
+
+	for i := range client.nodeConfigStatuses {
+		if client.nodeConfigStatuses[i].NodeID == nodeID && client.nodeConfigStatuses[i].Error == nil {
+			client.nodeConfigStatuses[i].Version = version
+		}
+	}
+
 	client.nodeConfigSetCheckChannel <- testNodeConfig{
 		NodeID:   nodeID,
 		NodeType: nodeConfig.NodeType,
@@ -420,7 +927,17 @@ func (client *testClient) SetNodeConfig(nodeID string, version string, nodeConfi
 }
 
 func (client *testClient) GetNodeConfigStatuses() ([]unitconfig.NodeConfigStatus, error) {
-	return client.nodeConfigStatuses, nil
+	client.Lock()
+	defer client.Unlock()
+
+	return slices.Clone(client.nodeConfigStatuses), nil
+}
+
+func (client *testClient) setNodeConfigStatuses(nodeConfigStatuses []unitconfig.NodeConfigStatus) {
+	client.Lock()
+	defer client.Unlock()
+
+	client.nodeConfigStatuses = nodeConfigStatuses
 }
 
 func (client *testClient) NodeConfigStatusChannel() <-chan unitconfig.NodeConfigStatus {
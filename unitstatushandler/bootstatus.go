@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"encoding/json"
+	"maps"
+
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Node attributes reported by nodes with A/B boot slots and secure boot.
+const (
+	// NodeAttrBootSlot currently booted slot.
+	NodeAttrBootSlot = "BootSlot"
+	// NodeAttrBootSlotDefault slot the node is configured to boot from.
+	NodeAttrBootSlotDefault = "BootSlotDefault"
+	// NodeAttrSecureBoot secure boot verification status.
+	NodeAttrSecureBoot = "SecureBoot"
+	// NodeAttrBootStatus boot status of the node set by CM.
+	NodeAttrBootStatus = "BootStatus"
+)
+
+// Secure boot verification statuses.
+const (
+	SecureBootVerified = "verified"
+	SecureBootDisabled = "disabled"
+	SecureBootFailed   = "failed"
+)
+
+// componentAnnotationBootStatus component annotation which contains boot status of the component node.
+const componentAnnotationBootStatus = "bootStatus"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// BootStatus node boot status. Fallback is true if the node is booted from other than default slot.
+type BootStatus struct {
+	Slot        string `json:"slot,omitempty"`
+	DefaultSlot string `json:"defaultSlot,omitempty"`
+	Fallback    bool   `json:"fallback"`
+	SecureBoot  string `json:"secureBoot,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// getBootStatus returns boot status from node attributes. False is returned if the node doesn't report boot status.
+func getBootStatus(attrs map[string]interface{}) (bootStatus BootStatus, ok bool) {
+	getAttr := func(name string) string {
+		value, _ := attrs[name].(string)
+
+		return value
+	}
+
+	bootStatus = BootStatus{
+		Slot:        getAttr(NodeAttrBootSlot),
+		DefaultSlot: getAttr(NodeAttrBootSlotDefault),
+		SecureBoot:  getAttr(NodeAttrSecureBoot),
+	}
+
+	if bootStatus == (BootStatus{}) {
+		return bootStatus, false
+	}
+
+	bootStatus.Fallback = bootStatus.Slot != "" && bootStatus.DefaultSlot != "" &&
+		bootStatus.Slot != bootStatus.DefaultSlot
+
+	return bootStatus, true
+}
+
+// applyNodeBootStatus adds boot status to node attributes and annotations of node components. Should be called with
+// locked status mutex.
+func (instance *Instance) applyNodeBootStatus(nodeInfo *cloudprotocol.NodeInfo) {
+	bootStatus, ok := getBootStatus(nodeInfo.Attrs)
+	if !ok {
+		delete(instance.bootStatuses, nodeInfo.NodeID)
+
+		return
+	}
+
+	attrs := maps.Clone(nodeInfo.Attrs)
+	attrs[NodeAttrBootStatus] = bootStatus
+	nodeInfo.Attrs = attrs
+
+	if prevStatus, ok := instance.bootStatuses[nodeInfo.NodeID]; !ok || prevStatus != bootStatus {
+		logEntry := log.WithFields(log.Fields{
+			"nodeID": nodeInfo.NodeID, "slot": bootStatus.Slot, "defaultSlot": bootStatus.DefaultSlot,
+			"secureBoot": bootStatus.SecureBoot,
+		})
+
+		if bootStatus.Fallback || (bootStatus.SecureBoot != "" && bootStatus.SecureBoot != SecureBootVerified) {
+			logEntry.Warn("Node runs fallback slot or without secure boot verification")
+		} else {
+			logEntry.Debug("Node boot status changed")
+		}
+	}
+
+	if instance.bootStatuses == nil {
+		instance.bootStatuses = make(map[string]BootStatus)
+	}
+
+	instance.bootStatuses[nodeInfo.NodeID] = bootStatus
+
+	for i, component := range instance.unitStatus.Components {
+		if component.NodeID != nil && *component.NodeID == nodeInfo.NodeID {
+			instance.applyComponentBootStatus(&instance.unitStatus.Components[i])
+		}
+	}
+}
+
+// applyComponentBootStatus adds boot status of the component node to component annotations. Should be called with
+// locked status mutex.
+func (instance *Instance) applyComponentBootStatus(status *cloudprotocol.ComponentStatus) {
+	if status.NodeID == nil {
+		return
+	}
+
+	bootStatus, ok := instance.bootStatuses[*status.NodeID]
+	if !ok {
+		return
+	}
+
+	annotations := make(map[string]json.RawMessage)
+
+	if len(status.Annotations) != 0 {
+		if err := json.Unmarshal(status.Annotations, &annotations); err != nil {
+			log.WithField("id", status.ComponentID).Errorf("Can't parse component annotations: %v", err)
+
+			return
+		}
+	}
+
+	bootStatusData, err := json.Marshal(bootStatus)
+	if err != nil {
+		log.Errorf("Can't marshal boot status: %v", err)
+
+		return
+	}
+
+	annotations[componentAnnotationBootStatus] = bootStatusData
+
+	if status.Annotations, err = json.Marshal(annotations); err != nil {
+		log.Errorf("Can't marshal component annotations: %v", err)
+	}
+}
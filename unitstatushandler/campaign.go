@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// maxCampaignSummaries max number of stored campaign summaries, the oldest finished campaigns are removed first.
+const maxCampaignSummaries = 16
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type campaignUpdater interface {
+	updateCampaign(campaignID, updateType, correlationID, state string, errorInfo *cloudprotocol.ErrorInfo)
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (instance *Instance) loadCampaigns() error {
+	instance.campaigns = make(map[string]*amqphandler.CampaignSummary)
+
+	summaries, err := instance.storage.GetCampaignSummaries()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, data := range summaries {
+		var summary amqphandler.CampaignSummary
+
+		if err = json.Unmarshal(data, &summary); err != nil {
+			log.Errorf("Can't parse campaign summary: %v", err)
+
+			continue
+		}
+
+		instance.campaigns[summary.CampaignID] = &summary
+	}
+
+	return nil
+}
+
+// addCampaignDesiredStatus adds desired status identified by correlation ID to the campaign summary. It is called after
+// the desired status is processed, so the summary already contains started updates.
+func (instance *Instance) addCampaignDesiredStatus(campaignID, correlationID string) {
+	if campaignID == "" {
+		return
+	}
+
+	instance.campaignsMutex.Lock()
+	defer instance.campaignsMutex.Unlock()
+
+	summary := instance.getCampaign(campaignID)
+
+	if slices.Contains(summary.DesiredStatuses, correlationID) {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		logging.CampaignIDField: campaignID, logging.CorrelationIDField: correlationID,
+	}).Debug("Add campaign desired status")
+
+	summary.DesiredStatuses = append(summary.DesiredStatuses, correlationID)
+
+	instance.campaignChanged(summary)
+}
+
+// updateCampaign updates state of FOTA or SOTA update of the campaign. Update state is one of update manager states.
+func (instance *Instance) updateCampaign(
+	campaignID, updateType, correlationID, state string, errorInfo *cloudprotocol.ErrorInfo,
+) {
+	if campaignID == "" {
+		return
+	}
+
+	instance.campaignsMutex.Lock()
+	defer instance.campaignsMutex.Unlock()
+
+	summary := instance.getCampaign(campaignID)
+
+	update := amqphandler.CampaignUpdate{
+		Type: updateType, CorrelationID: correlationID, State: amqphandler.CampaignStateInProgress,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if state == stateNoUpdate {
+		update.State = amqphandler.CampaignStateCompleted
+
+		if errorInfo != nil {
+			update.State = amqphandler.CampaignStateFailed
+			update.ErrorInfo = errorInfo
+		}
+	}
+
+	index := slices.IndexFunc(summary.Updates, func(item amqphandler.CampaignUpdate) bool {
+		return item.Type == updateType && item.CorrelationID == correlationID
+	})
+
+	switch {
+	case index < 0:
+		summary.Updates = append(summary.Updates, update)
+
+	case summary.Updates[index].State != update.State:
+		summary.Updates[index] = update
+
+	default:
+		return
+	}
+
+	if !slices.Contains(summary.DesiredStatuses, correlationID) {
+		summary.DesiredStatuses = append(summary.DesiredStatuses, correlationID)
+	}
+
+	instance.campaignChanged(summary)
+}
+
+func (instance *Instance) getCampaign(campaignID string) *amqphandler.CampaignSummary {
+	summary, ok := instance.campaigns[campaignID]
+	if !ok {
+		summary = &amqphandler.CampaignSummary{CampaignID: campaignID, StartedAt: time.Now().UTC()}
+		instance.campaigns[campaignID] = summary
+	}
+
+	return summary
+}
+
+func (instance *Instance) campaignChanged(summary *amqphandler.CampaignSummary) {
+	summary.UpdatedAt = time.Now().UTC()
+	summary.State = getCampaignState(summary.Updates)
+
+	log.WithFields(log.Fields{
+		logging.CampaignIDField: summary.CampaignID, "state": summary.State,
+	}).Debug("Campaign changed")
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Errorf("Can't marshal campaign summary: %v", err)
+
+		return
+	}
+
+	if err = instance.storage.SetCampaignSummary(summary.CampaignID, data); err != nil {
+		log.Errorf("Can't store campaign summary: %v", err)
+	}
+
+	instance.removeOutdatedCampaigns()
+
+	if err = instance.statusSender.SendCampaignSummary(*summary); err != nil {
+		log.Errorf("Can't send campaign summary: %v", err)
+	}
+}
+
+func (instance *Instance) removeOutdatedCampaigns() {
+	for len(instance.campaigns) > maxCampaignSummaries {
+		var oldest *amqphandler.CampaignSummary
+
+		for _, summary := range instance.campaigns {
+			if summary.State != amqphandler.CampaignStateInProgress &&
+				(oldest == nil || summary.UpdatedAt.Before(oldest.UpdatedAt)) {
+				oldest = summary
+			}
+		}
+
+		if oldest == nil {
+			return
+		}
+
+		delete(instance.campaigns, oldest.CampaignID)
+
+		if err := instance.storage.RemoveCampaignSummary(oldest.CampaignID); err != nil {
+			log.Errorf("Can't remove campaign summary: %v", err)
+		}
+	}
+}
+
+func getCampaignState(updates []amqphandler.CampaignUpdate) string {
+	state := amqphandler.CampaignStateCompleted
+
+	for _, update := range updates {
+		switch update.State {
+		case amqphandler.CampaignStateFailed:
+			return amqphandler.CampaignStateFailed
+
+		case amqphandler.CampaignStateInProgress:
+			state = amqphandler.CampaignStateInProgress
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/downloader"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// CM feature flags announced in unit capabilities.
+const (
+	FeatureEmergencyUpdate       = "emergencyUpdate"
+	FeatureUpdateWindowAdmission = "updateWindowAdmission"
+	FeatureAdaptiveTelemetry     = "adaptiveTelemetry"
+	FeatureStorageEncryption     = "storageEncryption"
+	FeatureDNSQueryLog           = "dnsQueryLog"
+)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func getFeatures(cfg *config.Config) map[string]bool {
+	return map[string]bool{
+		FeatureEmergencyUpdate:       cfg.EmergencyUpdate.Enabled,
+		FeatureUpdateWindowAdmission: cfg.UpdateWindow.Admission,
+		FeatureAdaptiveTelemetry:     cfg.AdaptiveTelemetry.Enabled,
+		FeatureStorageEncryption:     cfg.StorageEncryption.Enabled,
+		FeatureDNSQueryLog:           cfg.DNSQueryLog.Enabled,
+	}
+}
+
+// getUnitCapabilities returns unit capabilities. Protocol capabilities are set by the status sender.
+func (instance *Instance) getUnitCapabilities() *amqphandler.UnitCapabilities {
+	capabilities := &amqphandler.UnitCapabilities{
+		Transports: append([]string{}, downloader.SupportedSchemes...),
+		Features:   instance.features,
+	}
+
+	if instance.nodeCapabilitiesProvider == nil {
+		return capabilities
+	}
+
+	for _, nodeInfo := range instance.unitStatus.Nodes {
+		if nodeInfo.Status == cloudprotocol.NodeStatusUnprovisioned {
+			continue
+		}
+
+		capabilities.Nodes = append(capabilities.Nodes,
+			instance.nodeCapabilitiesProvider.GetNodeCapabilities(nodeInfo))
+	}
+
+	return capabilities
+}
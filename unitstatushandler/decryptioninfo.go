@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/fcrypt"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const defaultDecryptionInfoTimeout = 2 * time.Minute
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type decryptionInfoSender interface {
+	SendDecryptionInfoRequest(request amqphandler.DecryptionInfoRequest) error
+}
+
+type decryptionInfoRefresher interface {
+	refreshDecryptionInfo(correlationID string, items []decryptionInfoItem) error
+}
+
+// decryptionInfoItem update item which decryption info is refreshed in place.
+type decryptionInfoItem struct {
+	amqphandler.DecryptionItem
+	decryptionInfo *cloudprotocol.DecryptionInfo
+}
+
+// decryptionInfoProvider requests new decryption info from the cloud when the receiver certificate is rotated during
+// update.
+type decryptionInfoProvider struct {
+	sync.Mutex
+
+	sender          decryptionInfoSender
+	timeout         time.Duration
+	pendingRequests map[string]chan amqphandler.DecryptionInfoUpdate
+	ctx             context.Context //nolint:containedctx
+	cancelFunc      context.CancelFunc
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newDecryptionInfoProvider(sender decryptionInfoSender, timeout time.Duration) *decryptionInfoProvider {
+	provider := &decryptionInfoProvider{
+		sender:          sender,
+		timeout:         timeout,
+		pendingRequests: make(map[string]chan amqphandler.DecryptionInfoUpdate),
+	}
+
+	provider.ctx, provider.cancelFunc = context.WithCancel(context.Background())
+
+	return provider
+}
+
+func (provider *decryptionInfoProvider) close() {
+	provider.cancelFunc()
+}
+
+func (provider *decryptionInfoProvider) refreshDecryptionInfo(
+	correlationID string, items []decryptionInfoItem,
+) error {
+	request := amqphandler.DecryptionInfoRequest{
+		RequestID:     uuid.New().String(),
+		CorrelationID: correlationID,
+		Items:         make([]amqphandler.DecryptionItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		request.Items = append(request.Items, item.DecryptionItem)
+	}
+
+	log.WithFields(log.Fields{
+		logging.CorrelationIDField: correlationID, "requestID": request.RequestID, "items": len(items),
+	}).Info("Request decryption info")
+
+	updateChannel := make(chan amqphandler.DecryptionInfoUpdate, 1)
+
+	provider.Lock()
+	provider.pendingRequests[request.RequestID] = updateChannel
+	provider.Unlock()
+
+	defer func() {
+		provider.Lock()
+		delete(provider.pendingRequests, request.RequestID)
+		provider.Unlock()
+	}()
+
+	if err := provider.sender.SendDecryptionInfoRequest(request); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	var update amqphandler.DecryptionInfoUpdate
+
+	select {
+	case update = <-updateChannel:
+
+	case <-time.After(provider.timeout):
+		return aoserrors.New("wait decryption info timeout")
+
+	case <-provider.ctx.Done():
+		return aoserrors.Wrap(provider.ctx.Err())
+	}
+
+	for _, item := range items {
+		found := false
+
+		for _, itemInfo := range update.Items {
+			if itemInfo.DecryptionItem == item.DecryptionItem {
+				*item.decryptionInfo = itemInfo.DecryptionInfo
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return aoserrors.Errorf("no decryption info for %s %s", item.Type, item.ID)
+		}
+	}
+
+	return nil
+}
+
+func (provider *decryptionInfoProvider) processUpdate(update amqphandler.DecryptionInfoUpdate) error {
+	provider.Lock()
+	defer provider.Unlock()
+
+	updateChannel, ok := provider.pendingRequests[update.RequestID]
+	if !ok {
+		return aoserrors.Errorf("no pending decryption info request %s", update.RequestID)
+	}
+
+	select {
+	case updateChannel <- update:
+
+	default:
+		return aoserrors.Errorf("decryption info request %s already updated", update.RequestID)
+	}
+
+	return nil
+}
+
+// installWithKeyRotation performs install and, if it fails because the receiver certificate was rotated, refreshes
+// decryption info of the items and retries install once.
+func installWithKeyRotation(
+	refresher decryptionInfoRefresher, correlationID string, items []decryptionInfoItem, install func() error,
+) error {
+	err := install()
+	if !errors.Is(err, fcrypt.ErrDecryptionKeyRotated) {
+		return err
+	}
+
+	log.WithField(logging.CorrelationIDField, correlationID).Warnf("Decryption key rotated: %v", err)
+
+	if refreshErr := refresher.refreshDecryptionInfo(correlationID, items); refreshErr != nil {
+		return aoserrors.Errorf("%w: can't refresh decryption info: %v", err, refreshErr)
+	}
+
+	return install()
+}
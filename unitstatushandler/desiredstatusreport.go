@@ -0,0 +1,355 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"slices"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type pendingDesiredStatus struct {
+	reportID      string
+	correlationID string
+	campaignID    string
+	desiredStatus cloudprotocol.DesiredStatus
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// ProcessDesiredStatusRequest processes desired status according to its options. Invalid desired status is rejected
+// with the list of validation errors and is not processed. On dry run, only changes report is sent. If confirmation
+// is required and desired status contains destructive changes, the report is sent and desired status is kept pending
+// till confirmation. Otherwise, desired status is processed as usual.
+func (instance *Instance) ProcessDesiredStatusRequest(request amqphandler.DesiredStatus) error {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.shuttingDown {
+		return ErrShuttingDown
+	}
+
+	correlationID := request.CorrelationID
+	if correlationID == "" {
+		correlationID = logging.NewCorrelationID()
+	}
+
+	if instance.maintenanceMode.Enabled {
+		log.WithField(logging.CorrelationIDField, correlationID).Warn("Desired status rejected due to maintenance mode")
+
+		if err := instance.statusSender.SendDesiredStatusRejection(amqphandler.DesiredStatusRejection{
+			CorrelationID: correlationID, CampaignID: request.CampaignID,
+			Errors: []amqphandler.ValidationError{{Message: ErrMaintenanceMode.Error()}},
+		}); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		return aoserrors.Wrap(ErrMaintenanceMode)
+	}
+
+	if validationErrors := validateDesiredStatus(request.DesiredStatus); len(validationErrors) != 0 {
+		log.WithFields(log.Fields{
+			logging.CorrelationIDField: correlationID, "errors": len(validationErrors),
+		}).Warn("Desired status rejected")
+
+		if err := instance.statusSender.SendDesiredStatusRejection(amqphandler.DesiredStatusRejection{
+			CorrelationID: correlationID, CampaignID: request.CampaignID, Errors: validationErrors,
+		}); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		return aoserrors.Errorf("invalid desired status: %s", validationErrors[0])
+	}
+
+	if !request.DryRun && !request.RequireConfirmation {
+		instance.pendingDesiredStatus = nil
+		instance.processDesiredStatus(request.DesiredStatus, correlationID, request.CampaignID)
+
+		return nil
+	}
+
+	report, err := instance.getDesiredStatusReport(request.DesiredStatus)
+	if err != nil {
+		return err
+	}
+
+	report.DryRun = request.DryRun
+	report.CorrelationID = correlationID
+	report.CampaignID = request.CampaignID
+
+	if !request.DryRun {
+		instance.pendingDesiredStatus = nil
+
+		if !report.IsDestructive() {
+			instance.processDesiredStatus(request.DesiredStatus, correlationID, request.CampaignID)
+
+			return nil
+		}
+
+		log.WithField("reportID", report.ReportID).Info("Desired status waits for confirmation")
+
+		report.ConfirmationRequired = true
+		instance.pendingDesiredStatus = &pendingDesiredStatus{
+			reportID: report.ReportID, correlationID: correlationID, campaignID: request.CampaignID,
+			desiredStatus: request.DesiredStatus,
+		}
+	}
+
+	if err = instance.statusSender.SendDesiredStatusReport(report); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+// ConfirmDesiredStatus applies or discards desired status waiting for confirmation.
+func (instance *Instance) ConfirmDesiredStatus(confirmation amqphandler.DesiredStatusConfirmation) error {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.shuttingDown {
+		return ErrShuttingDown
+	}
+
+	if instance.maintenanceMode.Enabled {
+		return aoserrors.Wrap(ErrMaintenanceMode)
+	}
+
+	log.WithFields(log.Fields{
+		"reportID": confirmation.ReportID, "confirmed": confirmation.Confirmed,
+	}).Debug("Confirm desired status")
+
+	if instance.pendingDesiredStatus == nil || instance.pendingDesiredStatus.reportID != confirmation.ReportID {
+		return aoserrors.Errorf("no desired status pending for report %s", confirmation.ReportID)
+	}
+
+	pending := instance.pendingDesiredStatus
+	instance.pendingDesiredStatus = nil
+
+	if confirmation.Confirmed {
+		instance.processDesiredStatus(pending.desiredStatus, pending.correlationID, pending.campaignID)
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (instance *Instance) getDesiredStatusReport(
+	desiredStatus cloudprotocol.DesiredStatus,
+) (report amqphandler.DesiredStatusReport, err error) {
+	report.ReportID = uuid.New().String()
+
+	if err = instance.firmwareManager.getChangesReport(desiredStatus, &report); err != nil {
+		return report, err
+	}
+
+	if err = instance.softwareManager.getChangesReport(desiredStatus, &report); err != nil {
+		return report, err
+	}
+
+	for _, download := range report.Downloads {
+		report.DownloadSize += download.Size
+	}
+
+	return report, nil
+}
+
+func (manager *firmwareManager) getChangesReport(
+	desiredStatus cloudprotocol.DesiredStatus, report *amqphandler.DesiredStatusReport,
+) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	update, err := manager.prepareUpdate(desiredStatus)
+	if err != nil {
+		return err
+	}
+
+	installedComponents, err := manager.firmwareUpdater.GetStatus()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for _, component := range update.Components {
+		change := amqphandler.ItemChange{
+			ID: *component.ComponentID, Type: component.ComponentType, ToVersion: component.Version,
+		}
+
+		for _, installedComponent := range installedComponents {
+			if installedComponent.ComponentID == change.ID && installedComponent.ComponentType == change.Type {
+				change.FromVersion = installedComponent.Version
+			}
+		}
+
+		report.Components = append(report.Components, change)
+		report.Downloads = append(report.Downloads, amqphandler.DownloadItem{
+			ID: change.ID, Type: amqphandler.DownloadTypeComponent, Version: component.Version, Size: component.Size,
+		})
+	}
+
+	return nil
+}
+
+func (manager *softwareManager) getChangesReport(
+	desiredStatus cloudprotocol.DesiredStatus, report *amqphandler.DesiredStatusReport,
+) error {
+	manager.Lock()
+	defer manager.Unlock()
+
+	update, err := manager.prepareUpdate(desiredStatus)
+	if err != nil {
+		return err
+	}
+
+	allServices, err := manager.softwareUpdater.GetServicesStatus()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if update.UnitConfig != nil {
+		unitConfigStatus, err := manager.unitConfigUpdater.GetStatus()
+		if err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if unitConfigStatus.Version != update.UnitConfig.Version {
+			report.UnitConfig = &amqphandler.ItemChange{
+				FromVersion: unitConfigStatus.Version, ToVersion: update.UnitConfig.Version,
+			}
+		}
+	}
+
+	reportServicesChanges(report, update, allServices)
+	reportLayersChanges(report, update)
+	manager.reportInstancesChanges(report, update)
+
+	return nil
+}
+
+func reportServicesChanges(
+	report *amqphandler.DesiredStatusReport, update *softwareUpdate, allServices []ServiceStatus,
+) {
+	for _, service := range update.InstallServices {
+		change := amqphandler.ItemChange{ID: service.ServiceID, ToVersion: service.Version}
+
+		for _, installedService := range allServices {
+			if installedService.ServiceID == service.ServiceID && !installedService.Cached &&
+				installedService.Status == cloudprotocol.InstalledStatus {
+				change.FromVersion = installedService.Version
+			}
+		}
+
+		if change.FromVersion != "" {
+			report.UpdatedServices = append(report.UpdatedServices, change)
+		} else {
+			report.AddedServices = append(report.AddedServices, change)
+		}
+
+		report.Downloads = append(report.Downloads, amqphandler.DownloadItem{
+			ID: service.ServiceID, Type: amqphandler.DownloadTypeService, Version: service.Version, Size: service.Size,
+		})
+	}
+
+	for _, service := range update.RestoreServices {
+		report.AddedServices = append(report.AddedServices, amqphandler.ItemChange{
+			ID: service.ServiceID, ToVersion: service.Version,
+		})
+	}
+
+	for _, service := range update.RemoveServices {
+		report.RemovedServices = append(report.RemovedServices, amqphandler.ItemChange{
+			ID: service.ServiceID, FromVersion: service.Version,
+		})
+	}
+}
+
+func reportLayersChanges(report *amqphandler.DesiredStatusReport, update *softwareUpdate) {
+	for _, layer := range update.InstallLayers {
+		report.AddedLayers = append(report.AddedLayers, amqphandler.ItemChange{ID: layer.Digest, ToVersion: layer.Version})
+		report.Downloads = append(report.Downloads, amqphandler.DownloadItem{
+			ID: layer.Digest, Type: amqphandler.DownloadTypeLayer, Version: layer.Version, Size: layer.Size,
+		})
+	}
+
+	for _, layer := range update.RestoreLayers {
+		report.AddedLayers = append(report.AddedLayers, amqphandler.ItemChange{ID: layer.Digest, ToVersion: layer.Version})
+	}
+
+	for _, layer := range update.RemoveLayers {
+		report.RemovedLayers = append(report.RemovedLayers, amqphandler.ItemChange{
+			ID: layer.Digest, FromVersion: layer.Version,
+		})
+	}
+}
+
+func (manager *softwareManager) reportInstancesChanges(
+	report *amqphandler.DesiredStatusReport, update *softwareUpdate,
+) {
+	var desiredIdents []aostypes.InstanceIdent
+
+	for _, instance := range update.RunInstances {
+		for i := range instance.NumInstances {
+			desiredIdents = append(desiredIdents, aostypes.InstanceIdent{
+				ServiceID: instance.ServiceID, SubjectID: instance.SubjectID, Instance: i,
+			})
+		}
+	}
+
+	currentIdents := make([]aostypes.InstanceIdent, 0, len(manager.InstanceStatuses))
+
+	for _, instanceStatus := range manager.InstanceStatuses {
+		currentIdents = append(currentIdents, instanceStatus.InstanceIdent)
+
+		if !slices.Contains(desiredIdents, instanceStatus.InstanceIdent) {
+			report.StoppedInstances = append(report.StoppedInstances, instanceStatus.InstanceIdent)
+
+			continue
+		}
+
+		// Instances of nodes which are paused or unprovisioned are moved to other nodes
+		if slices.ContainsFunc(update.NodesStatus, func(nodeStatus cloudprotocol.NodeStatus) bool {
+			return nodeStatus.NodeID == instanceStatus.NodeID && nodeStatus.Status != cloudprotocol.NodeStatusProvisioned
+		}) {
+			report.MovedInstances = append(report.MovedInstances, amqphandler.InstanceMove{
+				InstanceIdent: instanceStatus.InstanceIdent, NodeID: instanceStatus.NodeID,
+			})
+		}
+	}
+
+	for _, ident := range desiredIdents {
+		if !slices.Contains(currentIdents, ident) {
+			report.StartedInstances = append(report.StartedInstances, ident)
+		}
+	}
+}
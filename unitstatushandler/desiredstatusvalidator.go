@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	semver "github.com/hashicorp/go-version"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const maxLabelLength = 63
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var labelRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// validateDesiredStatus checks semantic rules which can't be expressed by desired status schema: version formats,
+// label syntax, unique items and references between items.
+func validateDesiredStatus(desiredStatus cloudprotocol.DesiredStatus) (validationErrors []amqphandler.ValidationError) {
+	addError := func(path, format string, args ...interface{}) {
+		validationErrors = append(validationErrors, amqphandler.ValidationError{
+			Path: path, Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	checkVersion := func(path, version string) {
+		if _, err := semver.NewVersion(version); err != nil {
+			addError(path, "invalid version %s", version)
+		}
+	}
+
+	checkLabels := func(path string, labels []string) {
+		for i, label := range labels {
+			if len(label) > maxLabelLength || !labelRegexp.MatchString(label) {
+				addError(fmt.Sprintf("%s/%d", path, i), "invalid label %s", label)
+			}
+		}
+	}
+
+	if desiredStatus.UnitConfig != nil {
+		checkVersion("/unitConfig/version", desiredStatus.UnitConfig.Version)
+
+		for i, node := range desiredStatus.UnitConfig.Nodes {
+			checkLabels(fmt.Sprintf("/unitConfig/nodes/%d/labels", i), node.Labels)
+		}
+	}
+
+	components := make(map[string]struct{})
+
+	for i, component := range desiredStatus.Components {
+		path := fmt.Sprintf("/components/%d", i)
+
+		checkVersion(path+"/version", component.Version)
+
+		if component.ComponentID == nil {
+			continue
+		}
+
+		key := *component.ComponentID + "/" + component.ComponentType
+
+		if _, ok := components[key]; ok {
+			addError(path+"/id", "duplicated component %s", *component.ComponentID)
+		}
+
+		components[key] = struct{}{}
+	}
+
+	layers := make(map[string]struct{})
+
+	for i, layer := range desiredStatus.Layers {
+		path := fmt.Sprintf("/layers/%d", i)
+
+		checkVersion(path+"/version", layer.Version)
+
+		if _, ok := layers[layer.Digest]; ok {
+			addError(path+"/digest", "duplicated layer %s", layer.Digest)
+		}
+
+		layers[layer.Digest] = struct{}{}
+	}
+
+	services := make(map[string]struct{})
+
+	for i, service := range desiredStatus.Services {
+		path := fmt.Sprintf("/services/%d", i)
+
+		checkVersion(path+"/version", service.Version)
+
+		if _, ok := services[service.ServiceID]; ok {
+			addError(path+"/id", "duplicated service %s", service.ServiceID)
+		}
+
+		services[service.ServiceID] = struct{}{}
+	}
+
+	instances := make(map[aostypes.InstanceIdent]struct{})
+
+	for i, instance := range desiredStatus.Instances {
+		path := fmt.Sprintf("/instances/%d", i)
+
+		if _, ok := services[instance.ServiceID]; !ok {
+			addError(path+"/serviceId", "service %s not found", instance.ServiceID)
+		}
+
+		ident := aostypes.InstanceIdent{ServiceID: instance.ServiceID, SubjectID: instance.SubjectID}
+
+		if _, ok := instances[ident]; ok {
+			addError(path, "duplicated instances of service %s and subject %s", instance.ServiceID, instance.SubjectID)
+		}
+
+		instances[ident] = struct{}{}
+
+		checkLabels(path+"/labels", instance.Labels)
+	}
+
+	return validationErrors
+}
@@ -0,0 +1,385 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/downloader"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// emergencyDownloadTarget separate download target type to not release emergency component by regular FOTA.
+const emergencyDownloadTarget = "emergencyComponent"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SignVerifier verifies data signed by the cloud.
+type SignVerifier interface {
+	VerifyDataSign(data []byte, chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
+		sign cloudprotocol.Signs) error
+}
+
+type emergencyStatusSender interface {
+	SendEmergencyUpdateStatus(status amqphandler.EmergencyUpdateStatus) error
+}
+
+type emergencyUpdateStorage interface {
+	AddEmergencyUpdateID(updateID string, validUntil time.Time) error
+	IsEmergencyUpdateIDUsed(updateID string) (used bool, err error)
+}
+
+// emergencyUpdater applies authorized emergency update of single component bypassing FOTA update state machine.
+type emergencyUpdater struct {
+	config          config.EmergencyUpdate
+	downloader      Downloader
+	firmwareUpdater FirmwareUpdater
+	signVerifier    SignVerifier
+	statusSender    emergencyStatusSender
+	statusHandler   firmwareStatusHandler
+	storage         emergencyUpdateStorage
+	ctx             context.Context //nolint:containedctx
+	cancelFunc      context.CancelFunc
+}
+
+// lockedFirmwareUpdater serializes components update of regular FOTA and emergency updates.
+type lockedFirmwareUpdater struct {
+	FirmwareUpdater
+	mutex sync.Mutex
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var errEmergencyUpdateDisabled = errors.New("emergency updates are disabled")
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newEmergencyUpdater(
+	config config.EmergencyUpdate, downloader Downloader, firmwareUpdater FirmwareUpdater, signVerifier SignVerifier,
+	statusSender emergencyStatusSender, statusHandler firmwareStatusHandler, storage emergencyUpdateStorage,
+) *emergencyUpdater {
+	updater := &emergencyUpdater{
+		config:          config,
+		downloader:      downloader,
+		firmwareUpdater: firmwareUpdater,
+		signVerifier:    signVerifier,
+		statusSender:    statusSender,
+		statusHandler:   statusHandler,
+		storage:         storage,
+	}
+
+	updater.ctx, updater.cancelFunc = context.WithCancel(context.Background())
+
+	return updater
+}
+
+func (updater *emergencyUpdater) close() {
+	updater.cancelFunc()
+}
+
+func (updater *emergencyUpdater) processUpdate(update amqphandler.EmergencyUpdate) (err error) {
+	var info amqphandler.EmergencyUpdateInfo
+
+	// Parse update ID before authorization to report status of rejected update
+	_ = json.Unmarshal(update.Update, &info)
+
+	defer func() {
+		if err != nil {
+			log.WithField("updateID", info.UpdateID).Errorf("Emergency update failed: %v", err)
+
+			updater.sendStatus(info.UpdateID, cloudprotocol.ErrorStatus, &cloudprotocol.ErrorInfo{Message: err.Error()})
+
+			return
+		}
+
+		log.WithField("updateID", info.UpdateID).Info("Emergency update successfully applied")
+
+		updater.sendStatus(info.UpdateID, cloudprotocol.InstalledStatus, nil)
+	}()
+
+	if !updater.config.Enabled {
+		return aoserrors.Wrap(errEmergencyUpdateDisabled)
+	}
+
+	authorizedInfo, err := updater.authorize(update)
+	if err != nil {
+		return err
+	}
+
+	info = authorizedInfo
+
+	component := info.Component
+
+	log.WithFields(log.Fields{
+		"updateID": info.UpdateID,
+		"id":       *component.ComponentID,
+		"type":     component.ComponentType,
+		"version":  component.Version,
+	}).Info("Process emergency update")
+
+	installedComponents, err := updater.firmwareUpdater.GetStatus()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if isComponentInstalled(component, installedComponents) {
+		log.WithField("updateID", info.UpdateID).Debug("Emergency component already installed")
+
+		updater.setUpdateUsed(info)
+
+		return nil
+	}
+
+	if err = updater.waitSchedule(info.Schedule); err != nil {
+		return err
+	}
+
+	updater.sendStatus(info.UpdateID, cloudprotocol.DownloadingStatus, nil)
+
+	fileName, err := updater.download(component)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if releaseErr := updater.downloader.Release(fileName); releaseErr != nil {
+			log.Errorf("Can't release emergency component: %v", releaseErr)
+		}
+	}()
+
+	updater.sendStatus(info.UpdateID, cloudprotocol.InstallingStatus, nil)
+
+	// Failures before install are temporary and the same update may be resent. Once install is attempted, the update
+	// is used regardless of the result.
+	err = updater.updateComponent(component, update.CertChains, update.Certs, fileName)
+
+	updater.setUpdateUsed(info)
+
+	return err
+}
+
+// authorize verifies mandatory authorization sign of emergency update made by dedicated signer, validates update info
+// and rejects already used update.
+func (updater *emergencyUpdater) authorize(
+	update amqphandler.EmergencyUpdate,
+) (info amqphandler.EmergencyUpdateInfo, err error) {
+	if updater.signVerifier == nil {
+		return info, aoserrors.New("authorization can't be verified")
+	}
+
+	if err = updater.signVerifier.VerifyDataSign(
+		update.Update, update.CertChains, update.Certs, update.Authorization); err != nil {
+		return info, aoserrors.Errorf("authorization failed: %v", err)
+	}
+
+	if err = updater.checkSigner(update); err != nil {
+		return info, aoserrors.Errorf("authorization failed: %v", err)
+	}
+
+	if err = json.Unmarshal(update.Update, &info); err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	if info.UpdateID == "" {
+		return info, aoserrors.New("update ID is empty")
+	}
+
+	if info.ValidUntil.IsZero() || time.Now().After(info.ValidUntil) {
+		return info, aoserrors.New("update is expired")
+	}
+
+	if info.Component.ComponentID == nil {
+		return info, aoserrors.New("component ID is empty")
+	}
+
+	if err = validateComponent(info.Component); err != nil {
+		return info, err
+	}
+
+	used, err := updater.storage.IsEmergencyUpdateIDUsed(info.UpdateID)
+	if err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	if used {
+		return info, aoserrors.New("update is already used")
+	}
+
+	return info, nil
+}
+
+// setUpdateUsed marks the update as used to reject its replay.
+func (updater *emergencyUpdater) setUpdateUsed(info amqphandler.EmergencyUpdateInfo) {
+	if err := updater.storage.AddEmergencyUpdateID(info.UpdateID, info.ValidUntil); err != nil {
+		log.WithField("updateID", info.UpdateID).Errorf("Can't mark emergency update as used: %v", err)
+	}
+}
+
+// checkSigner checks that emergency update is signed by certificate issued for emergency updates, so other cloud
+// signing certificates can't authorize it.
+func (updater *emergencyUpdater) checkSigner(update amqphandler.EmergencyUpdate) error {
+	chainIndex := slices.IndexFunc(update.CertChains, func(chain cloudprotocol.CertificateChain) bool {
+		return chain.Name == update.Authorization.ChainName
+	})
+	if chainIndex < 0 || len(update.CertChains[chainIndex].Fingerprints) == 0 {
+		return aoserrors.New("signing certificate chain not found")
+	}
+
+	fingerprint := update.CertChains[chainIndex].Fingerprints[0]
+
+	certIndex := slices.IndexFunc(update.Certs, func(cert cloudprotocol.Certificate) bool {
+		return strings.EqualFold(cert.Fingerprint, fingerprint)
+	})
+	if certIndex < 0 {
+		return aoserrors.New("signing certificate not found")
+	}
+
+	signCert, err := x509.ParseCertificate(update.Certs[certIndex].Certificate)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if !slices.ContainsFunc(signCert.UnknownExtKeyUsage, func(usage asn1.ObjectIdentifier) bool {
+		return usage.String() == updater.config.SignerEKU
+	}) {
+		return aoserrors.Errorf("signing certificate %s has no emergency update key usage", signCert.Subject)
+	}
+
+	return nil
+}
+
+// waitSchedule waits for timetable window if emergency updates are not allowed to ignore timetable.
+func (updater *emergencyUpdater) waitSchedule(schedule cloudprotocol.ScheduleRule) error {
+	if updater.config.IgnoreTimetable || schedule.Type != cloudprotocol.TimetableUpdate {
+		return nil
+	}
+
+	availableTime, err := getAvailableTimetableTime(time.Now(), schedule.Timetable)
+	if err != nil {
+		return err
+	}
+
+	if availableTime == 0 {
+		return nil
+	}
+
+	log.WithField("in", availableTime).Debug("Wait for emergency update window")
+
+	select {
+	case <-time.After(availableTime):
+		return nil
+
+	case <-updater.ctx.Done():
+		return aoserrors.Wrap(updater.ctx.Err())
+	}
+}
+
+func (updater *emergencyUpdater) download(component cloudprotocol.ComponentInfo) (fileName string, err error) {
+	result, err := updater.downloader.Download(updater.ctx, downloader.PackageInfo{
+		URLs:          component.URLs,
+		Sha256:        component.Sha256,
+		Size:          component.Size,
+		TargetType:    emergencyDownloadTarget,
+		TargetID:      getDownloadID(component),
+		TargetVersion: component.Version,
+		Critical:      true,
+	})
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	if err = result.Wait(); err != nil {
+		if releaseErr := updater.downloader.Release(result.GetFileName()); releaseErr != nil {
+			log.Errorf("Can't release emergency component: %v", releaseErr)
+		}
+
+		return "", aoserrors.Wrap(err)
+	}
+
+	return result.GetFileName(), nil
+}
+
+func (updater *emergencyUpdater) updateComponent(component cloudprotocol.ComponentInfo,
+	chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate, fileName string,
+) error {
+	fileURL := url.URL{Scheme: "file", Path: fileName}
+
+	component.URLs = []string{fileURL.String()}
+
+	updateResult, err := updater.firmwareUpdater.UpdateComponents(
+		[]cloudprotocol.ComponentInfo{component}, chains, certs)
+	if err != nil {
+		err = aoserrors.Wrap(err)
+	}
+
+	for _, status := range updateResult {
+		if status.ComponentID != *component.ComponentID || status.Version != component.Version {
+			continue
+		}
+
+		if err == nil && status.ErrorInfo != nil {
+			err = aoserrors.New(status.ErrorInfo.Message)
+		}
+
+		updater.statusHandler.updateComponentStatus(status)
+	}
+
+	return err
+}
+
+func (updater *emergencyUpdater) sendStatus(updateID, status string, errorInfo *cloudprotocol.ErrorInfo) {
+	if err := updater.statusSender.SendEmergencyUpdateStatus(amqphandler.EmergencyUpdateStatus{
+		UpdateID: updateID, Status: status, ErrorInfo: errorInfo,
+	}); err != nil {
+		log.Errorf("Can't send emergency update status: %v", err)
+	}
+}
+
+func (updater *lockedFirmwareUpdater) UpdateComponents(
+	components []cloudprotocol.ComponentInfo, chains []cloudprotocol.CertificateChain,
+	certs []cloudprotocol.Certificate,
+) (status []cloudprotocol.ComponentStatus, err error) {
+	updater.mutex.Lock()
+	defer updater.mutex.Unlock()
+
+	return updater.FirmwareUpdater.UpdateComponents(components, chains, certs)
+}
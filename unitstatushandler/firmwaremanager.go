@@ -33,8 +33,11 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_common/utils/semverutils"
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/cmserver"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/extension"
+	"github.com/aosedge/aos_communicationmanager/logging"
 )
 
 /***********************************************************************************************************************
@@ -52,14 +55,18 @@ type firmwareDownloader interface {
 }
 
 type firmwareStatusHandler interface {
+	decryptionInfoRefresher
+	campaignUpdater
 	updateComponentStatus(componentInfo cloudprotocol.ComponentStatus) bool
 }
 
 type firmwareUpdate struct {
-	Schedule   cloudprotocol.ScheduleRule       `json:"schedule,omitempty"`
-	Components []cloudprotocol.ComponentInfo    `json:"components,omitempty"`
-	CertChains []cloudprotocol.CertificateChain `json:"certChains,omitempty"`
-	Certs      []cloudprotocol.Certificate      `json:"certs,omitempty"`
+	Schedule      cloudprotocol.ScheduleRule       `json:"schedule,omitempty"`
+	Components    []cloudprotocol.ComponentInfo    `json:"components,omitempty"`
+	CertChains    []cloudprotocol.CertificateChain `json:"certChains,omitempty"`
+	Certs         []cloudprotocol.Certificate      `json:"certs,omitempty"`
+	CorrelationID string                           `json:"correlationId,omitempty"`
+	CampaignID    string                           `json:"campaignId,omitempty"`
 }
 
 type firmwareManager struct {
@@ -72,9 +79,11 @@ type firmwareManager struct {
 	firmwareUpdater FirmwareUpdater
 	storage         Storage
 
-	stateMachine  *updateStateMachine
-	statusMutex   sync.RWMutex
-	pendingUpdate *firmwareUpdate
+	stateMachine    *updateStateMachine
+	updateGates     []extension.UpdateGate
+	updateEstimator *updateEstimator
+	statusMutex     sync.RWMutex
+	pendingUpdate   *firmwareUpdate
 
 	ComponentStatuses map[string]*cloudprotocol.ComponentStatus `json:"componentStatuses,omitempty"`
 	CurrentUpdate     *firmwareUpdate                           `json:"currentUpdate,omitempty"`
@@ -89,10 +98,11 @@ type firmwareManager struct {
  **********************************************************************************************************************/
 
 func newFirmwareManager(statusHandler firmwareStatusHandler, downloader firmwareDownloader,
-	firmwareUpdater FirmwareUpdater, storage Storage, defaultTTL time.Duration,
+	firmwareUpdater FirmwareUpdater, storage Storage, defaultTTL time.Duration, updateEstimator *updateEstimator,
 ) (manager *firmwareManager, err error) {
 	manager = &firmwareManager{
 		statusChannel:   make(chan cmserver.UpdateFOTAStatus, 1),
+		updateEstimator: updateEstimator,
 		downloader:      downloader,
 		statusHandler:   statusHandler,
 		firmwareUpdater: firmwareUpdater,
@@ -104,6 +114,11 @@ func newFirmwareManager(statusHandler firmwareStatusHandler, downloader firmware
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if manager.CurrentState != stateNoUpdate && manager.CurrentUpdate != nil {
+		logging.SetCorrelationID(extension.UpdateTypeFOTA, manager.CurrentUpdate.CorrelationID)
+		logging.SetCampaignID(extension.UpdateTypeFOTA, manager.CurrentUpdate.CampaignID)
+	}
+
 	log.WithFields(log.Fields{"state": manager.CurrentState, "error": manager.UpdateErr}).Debug("New firmware manager")
 
 	manager.stateMachine = newUpdateStateMachine(manager.CurrentState, fsm.Events{
@@ -159,15 +174,45 @@ func (manager *firmwareManager) getCurrentStatus() (status cmserver.UpdateFOTASt
 		}
 	}
 
+	if status.State == cmserver.Downloading || status.State == cmserver.Updating {
+		manager.statusMutex.RLock()
+		status.ETA = manager.updateEstimator.getETA(manager.getUpdateItems())
+		manager.statusMutex.RUnlock()
+	}
+
 	return status
 }
 
-func (manager *firmwareManager) processDesiredStatus(desiredStatus cloudprotocol.DesiredStatus) error {
+func (manager *firmwareManager) processDesiredStatus(
+	desiredStatus cloudprotocol.DesiredStatus, correlationID, campaignID string,
+) error {
 	manager.Lock()
 	defer manager.Unlock()
 
 	log.Debug("Process desired FOTA")
 
+	update, err := manager.prepareUpdate(desiredStatus)
+	if err != nil {
+		return err
+	}
+
+	update.CorrelationID = correlationID
+	update.CampaignID = campaignID
+
+	if len(update.Components) != 0 {
+		log.WithField("components", update.Components).Debug("FOTA update required")
+
+		if err = manager.newUpdate(update); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	} else {
+		log.Debug("No FOTA update required")
+	}
+
+	return nil
+}
+
+func (manager *firmwareManager) prepareUpdate(desiredStatus cloudprotocol.DesiredStatus) (*firmwareUpdate, error) {
 	update := &firmwareUpdate{
 		Schedule:   desiredStatus.FOTASchedule,
 		Components: make([]cloudprotocol.ComponentInfo, 0),
@@ -177,7 +222,7 @@ func (manager *firmwareManager) processDesiredStatus(desiredStatus cloudprotocol
 
 	installedComponents, err := manager.firmwareUpdater.GetStatus()
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
 	var desiredComponentsWithNoID []cloudprotocol.ComponentInfo
@@ -220,17 +265,7 @@ desiredLoop:
 
 	handleDesiredComponentsWithNoID(desiredComponentsWithNoID, installedComponents, update)
 
-	if len(update.Components) != 0 {
-		log.WithField("components", update.Components).Debug("FOTA update required")
-
-		if err = manager.newUpdate(update); err != nil {
-			return aoserrors.Wrap(err)
-		}
-	} else {
-		log.Debug("No FOTA update required")
-	}
-
-	return nil
+	return update, nil
 }
 
 func (manager *firmwareManager) startUpdate() (err error) {
@@ -239,6 +274,19 @@ func (manager *firmwareManager) startUpdate() (err error) {
 
 	log.Debug("Start firmware update")
 
+	if manager.stateMachine.canTransit(eventStartUpdate) {
+		if err = checkUpdateGates(manager.updateGates, extension.UpdateTypeFOTA); err != nil {
+			manager.stateMachine.postponeUpdate()
+
+			return err
+		}
+
+		if err = manager.updateEstimator.checkUpdateWindow(
+			time.Now(), manager.CurrentUpdate.Schedule, manager.getUpdateItems()); err != nil {
+			return manager.deferUpdate(err)
+		}
+	}
+
 	if err = manager.stateMachine.sendEvent(eventStartUpdate, nil); err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -286,6 +334,11 @@ func (manager *firmwareManager) getComponentStatuses() (status []cloudprotocol.C
 func (manager *firmwareManager) stateChanged(event, state string, updateErr error) {
 	var errorInfo *cloudprotocol.ErrorInfo
 
+	if state != stateNoUpdate && manager.CurrentUpdate != nil {
+		logging.SetCorrelationID(extension.UpdateTypeFOTA, manager.CurrentUpdate.CorrelationID)
+		logging.SetCampaignID(extension.UpdateTypeFOTA, manager.CurrentUpdate.CampaignID)
+	}
+
 	if updateErr != nil {
 		errorInfo = &cloudprotocol.ErrorInfo{Message: updateErr.Error()}
 	}
@@ -315,6 +368,16 @@ func (manager *firmwareManager) stateChanged(event, state string, updateErr erro
 	if err := manager.saveState(); err != nil {
 		log.Errorf("Can't save current firmware manager state: %v", err)
 	}
+
+	if manager.CurrentUpdate != nil {
+		manager.statusHandler.updateCampaign(manager.CurrentUpdate.CampaignID, extension.UpdateTypeFOTA,
+			manager.CurrentUpdate.CorrelationID, state, errorInfo)
+	}
+
+	if state == stateNoUpdate {
+		logging.SetCorrelationID(extension.UpdateTypeFOTA, "")
+		logging.SetCampaignID(extension.UpdateTypeFOTA, "")
+	}
 }
 
 func (manager *firmwareManager) noUpdate() {
@@ -397,10 +460,9 @@ func (manager *firmwareManager) download(ctx context.Context) {
 		}()
 	}()
 
-	manager.DownloadResult = nil
-
 	manager.statusMutex.Lock()
 
+	manager.DownloadResult = nil
 	manager.ComponentStatuses = make(map[string]*cloudprotocol.ComponentStatus)
 	request := createDownloadRequest(manager.CurrentUpdate.Components)
 
@@ -426,7 +488,13 @@ func (manager *firmwareManager) download(ctx context.Context) {
 		return
 	}
 
-	manager.DownloadResult = manager.downloader.download(ctx, request, false, manager.updateComponentStatusByDownloadID)
+	result := manager.downloader.download(ctx, request, false, manager.updateComponentStatusByDownloadID)
+
+	manager.statusMutex.Lock()
+	manager.DownloadResult = result
+	manager.statusMutex.Unlock()
+
+	manager.updateEstimator.learnDownloadTimes(manager.getUpdateItems(), manager.DownloadResult)
 
 	downloadErr = getDownloadError(manager.DownloadResult)
 
@@ -589,11 +657,29 @@ func (manager *firmwareManager) updateComponents(ctx context.Context) (component
 
 	updateComponents := make([]cloudprotocol.ComponentInfo, 0, len(manager.CurrentUpdate.Components))
 
+	// Components could be already updated if the update was interrupted by CM restart
+	installedComponents, err := manager.firmwareUpdater.GetStatus()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
 	for _, component := range manager.CurrentUpdate.Components {
 		if component.ComponentID == nil {
 			continue
 		}
 
+		if isComponentInstalled(component, installedComponents) {
+			log.WithFields(log.Fields{
+				"id":      component.ComponentID,
+				"type":    component.ComponentType,
+				"version": component.Version,
+			}).Debug("Component already updated")
+
+			manager.updateComponentStatusByID(*component.ComponentID, cloudprotocol.InstalledStatus, nil)
+
+			continue
+		}
+
 		log.WithFields(log.Fields{
 			"id":      component.ComponentID,
 			"type":    component.ComponentType,
@@ -622,6 +708,10 @@ func (manager *firmwareManager) updateComponents(ctx context.Context) (component
 		updateComponents = append(updateComponents, component)
 	}
 
+	if len(updateComponents) == 0 {
+		return nil
+	}
+
 	select {
 	case err := <-manager.asyncUpdate(updateComponents):
 		return err
@@ -725,8 +815,28 @@ func (manager *firmwareManager) asyncUpdate(updateComponents []cloudprotocol.Com
 	go func() {
 		var err error
 
-		updateResult, updateErr := manager.firmwareUpdater.UpdateComponents(
-			updateComponents, manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs)
+		startTime := time.Now()
+
+		var updateResult []cloudprotocol.ComponentStatus
+
+		decryptionItems := make([]decryptionInfoItem, 0, len(updateComponents))
+
+		for i, component := range updateComponents {
+			decryptionItems = append(decryptionItems, decryptionInfoItem{
+				DecryptionItem: amqphandler.DecryptionItem{
+					ID: *component.ComponentID, Type: amqphandler.DownloadTypeComponent, Version: component.Version,
+				},
+				decryptionInfo: &updateComponents[i].DecryptionInfo,
+			})
+		}
+
+		updateErr := installWithKeyRotation(manager.statusHandler, manager.CurrentUpdate.CorrelationID,
+			decryptionItems, func() (err error) {
+				updateResult, err = manager.firmwareUpdater.UpdateComponents(
+					updateComponents, manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs)
+
+				return err
+			})
 		if updateErr != nil {
 			err = aoserrors.Wrap(updateErr)
 		}
@@ -745,12 +855,83 @@ func (manager *firmwareManager) asyncUpdate(updateComponents []cloudprotocol.Com
 			}
 		}
 
+		if err == nil {
+			manager.learnInstallTimes(updateComponents, time.Since(startTime))
+		}
+
 		finishChannel <- err
 	}()
 
 	return finishChannel
 }
 
+// learnInstallTimes stores install time of updated components. As components are updated at once, the update time is
+// split between components proportionally to their sizes.
+func (manager *firmwareManager) learnInstallTimes(components []cloudprotocol.ComponentInfo, updateTime time.Duration) {
+	var totalSize uint64
+
+	for _, component := range components {
+		totalSize += component.Size
+	}
+
+	for _, component := range components {
+		installTime := updateTime / time.Duration(len(components))
+
+		if totalSize != 0 {
+			installTime = time.Duration(float64(updateTime) * float64(component.Size) / float64(totalSize))
+		}
+
+		manager.updateEstimator.learnInstallTime(
+			updateItem{id: component.ComponentType, size: component.Size}, installTime)
+	}
+}
+
+func isComponentInstalled(
+	component cloudprotocol.ComponentInfo, installedComponents []cloudprotocol.ComponentStatus,
+) bool {
+	for _, installedComponent := range installedComponents {
+		if installedComponent.ComponentID == *component.ComponentID &&
+			installedComponent.ComponentType == component.ComponentType &&
+			installedComponent.Version == component.Version &&
+			installedComponent.Status == cloudprotocol.InstalledStatus {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (manager *firmwareManager) getUpdateItems() []updateItem {
+	items := make([]updateItem, 0, len(manager.CurrentUpdate.Components))
+
+	for _, component := range manager.CurrentUpdate.Components {
+		downloadID := getDownloadID(component)
+
+		items = append(items, updateItem{
+			id: component.ComponentType, downloadID: downloadID, size: component.Size,
+			downloaded: isDownloaded(manager.DownloadResult, downloadID),
+		})
+	}
+
+	return items
+}
+
+// deferUpdate defers the update which doesn't fit update window to the next window.
+func (manager *firmwareManager) deferUpdate(windowErr error) error {
+	if !errors.Is(windowErr, errWindowTooShort) {
+		log.Errorf("Can't check update window: %v", windowErr)
+
+		return manager.stateMachine.sendEvent(eventStartUpdate, nil)
+	}
+
+	manager.stateMachine.deferUpdate(manager.CurrentUpdate.Schedule)
+
+	manager.UpdateErr = &cloudprotocol.ErrorInfo{Message: windowErr.Error()}
+	manager.sendCurrentStatus()
+
+	return aoserrors.Errorf("%w: %v", errUpdatePostponed, windowErr)
+}
+
 func getDownloadID(component cloudprotocol.ComponentInfo) string {
 	return component.ComponentType + ":" + component.Version
 }
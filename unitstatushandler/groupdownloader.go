@@ -21,6 +21,8 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aosedge/aos_common/aoserrors"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
@@ -28,19 +30,29 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// minLinkSpeedMeasureTime min download time to measure link speed. Shorter downloads are mostly already downloaded
+// or cached files and don't reflect the link speed.
+const minLinkSpeedMeasureTime = 1 * time.Second
+
 /***********************************************************************************************************************
  * Types
  **********************************************************************************************************************/
 
 type downloadResult struct {
-	FileName string `json:"fileName"`
-	Error    string `json:"error"`
+	FileName     string        `json:"fileName"`
+	Error        string        `json:"error"`
+	DownloadTime time.Duration `json:"-"`
 }
 
 type statusNotifier func(id string, status string, componentErr *cloudprotocol.ErrorInfo)
 
 type groupDownloader struct {
 	Downloader
+	linkSpeed atomic.Uint64
 }
 
 /***********************************************************************************************************************
@@ -65,6 +77,8 @@ func (downloader *groupDownloader) download(ctx context.Context, request map[str
 	downloadCtx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
 
+	startTime := time.Now()
+
 	var wg sync.WaitGroup
 
 	handleError := func(id string, err error) {
@@ -102,6 +116,8 @@ func (downloader *groupDownloader) download(ctx context.Context, request map[str
 				return
 			}
 
+			result[id].DownloadTime = time.Since(startTime)
+
 			updateStatus(id, cloudprotocol.DownloadedStatus, nil)
 		}(id)
 	}
@@ -120,9 +136,42 @@ func (downloader *groupDownloader) download(ctx context.Context, request map[str
 		}
 	}
 
+	downloader.measureLinkSpeed(request, result, time.Since(startTime))
+
 	return result
 }
 
+// getLinkSpeed returns link speed in bytes per second measured on last download, zero if it is not measured.
+func (downloader *groupDownloader) getLinkSpeed() uint64 {
+	return downloader.linkSpeed.Load()
+}
+
+func (downloader *groupDownloader) measureLinkSpeed(
+	request map[string]downloader.PackageInfo, result map[string]*downloadResult, downloadTime time.Duration,
+) {
+	if downloadTime < minLinkSpeedMeasureTime {
+		return
+	}
+
+	var downloadedSize uint64
+
+	for id, item := range result {
+		if item.Error == "" {
+			downloadedSize += request[id].Size
+		}
+	}
+
+	if downloadedSize == 0 {
+		return
+	}
+
+	linkSpeed := uint64(float64(downloadedSize) / downloadTime.Seconds())
+
+	log.WithField("linkSpeed", linkSpeed).Debug("Link speed measured")
+
+	downloader.linkSpeed.Store(linkSpeed)
+}
+
 func (downloader *groupDownloader) releaseDownloadedFirmware() error {
 	if err := downloader.ReleaseByType(cloudprotocol.DownloadTargetComponent); err != nil {
 		return aoserrors.Wrap(err)
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/cmserver"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// SchedulingFreezer freezes instances scheduling while unit is in maintenance mode.
+type SchedulingFreezer interface {
+	SetSchedulingFrozen(frozen bool) error
+}
+
+// maintenanceGate postpones FOTA and SOTA updates while unit is in maintenance mode. The gate is checked under
+// update managers locks, so it has own lock.
+type maintenanceGate struct {
+	sync.RWMutex
+	enabled bool
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrMaintenanceMode returned when desired status is received while unit is in maintenance mode.
+var ErrMaintenanceMode = errors.New("unit is in maintenance mode")
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetMaintenanceMode enables or disables unit maintenance mode. In maintenance mode new desired statuses are
+// rejected, pending FOTA and SOTA updates are postponed till the mode is disabled and instances are not rescheduled.
+// Telemetry and emergency updates are not affected. The mode is persisted and reported in full unit status.
+func (instance *Instance) SetMaintenanceMode(enabled bool, reason string) error {
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.shuttingDown {
+		return ErrShuttingDown
+	}
+
+	if instance.maintenanceMode.Enabled == enabled {
+		return nil
+	}
+
+	mode := cmserver.MaintenanceMode{Enabled: enabled}
+
+	if enabled {
+		mode.Reason = reason
+		mode.Since = time.Now().UTC()
+
+		instance.pendingDesiredStatus = nil
+	}
+
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if err = instance.storage.SetMaintenanceMode(data); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	log.WithFields(log.Fields{"enabled": enabled, "reason": reason}).Info("Maintenance mode changed")
+
+	instance.applyMaintenanceMode(mode)
+
+	if !instance.initDone {
+		return nil
+	}
+
+	if err = instance.initCurrentStatus(); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	instance.sendCurrentStatus(false)
+
+	return nil
+}
+
+// GetMaintenanceMode returns unit maintenance mode.
+func (instance *Instance) GetMaintenanceMode() cmserver.MaintenanceMode {
+	instance.Lock()
+	defer instance.Unlock()
+
+	return instance.maintenanceMode
+}
+
+// SetSchedulingFreezer sets scheduling freezer used to freeze instances scheduling in maintenance mode.
+func (instance *Instance) SetSchedulingFreezer(freezer SchedulingFreezer) {
+	instance.Lock()
+	defer instance.Unlock()
+
+	instance.schedulingFreezer = freezer
+
+	if instance.maintenanceMode.Enabled {
+		if err := freezer.SetSchedulingFrozen(true); err != nil {
+			log.Errorf("Can't freeze scheduling: %v", err)
+		}
+	}
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (instance *Instance) loadMaintenanceMode(storage Storage) error {
+	data, err := storage.GetMaintenanceMode()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	var mode cmserver.MaintenanceMode
+
+	if err = json.Unmarshal(data, &mode); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	if mode.Enabled {
+		log.WithField("reason", mode.Reason).Warn("Unit is in maintenance mode")
+	}
+
+	instance.applyMaintenanceMode(mode)
+
+	return nil
+}
+
+func (instance *Instance) applyMaintenanceMode(mode cmserver.MaintenanceMode) {
+	instance.maintenanceMode = mode
+	instance.maintenanceGate.setEnabled(mode.Enabled)
+
+	if instance.schedulingFreezer != nil {
+		if err := instance.schedulingFreezer.SetSchedulingFrozen(mode.Enabled); err != nil {
+			log.Errorf("Can't set scheduling frozen: %v", err)
+		}
+	}
+
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	instance.maintenanceStatus = nil
+
+	if mode.Enabled {
+		instance.maintenanceStatus = &amqphandler.MaintenanceStatus{Reason: mode.Reason, Since: mode.Since}
+	}
+}
+
+func (instance *Instance) isMaintenanceMode() bool {
+	return instance.maintenanceGate.isEnabled()
+}
+
+/***********************************************************************************************************************
+ * maintenanceGate
+ **********************************************************************************************************************/
+
+func (gate *maintenanceGate) CheckUpdate(updateType string) error {
+	if gate.isEnabled() {
+		return ErrMaintenanceMode
+	}
+
+	return nil
+}
+
+func (gate *maintenanceGate) setEnabled(enabled bool) {
+	gate.Lock()
+	defer gate.Unlock()
+
+	gate.enabled = enabled
+}
+
+func (gate *maintenanceGate) isEnabled() bool {
+	gate.RLock()
+	defer gate.RUnlock()
+
+	return gate.enabled
+}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// OverrideBundleManifest name of override bundle manifest file in bundle dir.
+const OverrideBundleManifest = "bundle.json"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// OverrideBundle override bundle manifest. Bundle info is signed by OEM key.
+type OverrideBundle struct {
+	Bundle        json.RawMessage                  `json:"bundle"`
+	Authorization cloudprotocol.Signs              `json:"authorization"`
+	CertChains    []cloudprotocol.CertificateChain `json:"certificateChains,omitempty"`
+	Certs         []cloudprotocol.Certificate      `json:"certificates,omitempty"`
+}
+
+// OverrideBundleInfo override bundle info. Bundle is rejected after valid until time. Artifact URLs of desired status
+// are paths relative to bundle dir.
+type OverrideBundleInfo struct {
+	BundleID      string                      `json:"bundleId"`
+	ValidUntil    time.Time                   `json:"validUntil"`
+	DesiredStatus cloudprotocol.DesiredStatus `json:"desiredStatus"`
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var errOverrideBundleDisabled = errors.New("override bundles are disabled")
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// ProcessOverrideBundle applies desired status of signed override bundle located on local media. Desired status is
+// processed as received from the cloud, bundle artifacts are downloaded from the bundle dir. Bundle report is sent to
+// the cloud on next connection.
+func (instance *Instance) ProcessOverrideBundle(bundleDir string) (bundleID string, err error) {
+	if !instance.overrideBundle.Enabled {
+		return "", aoserrors.Wrap(errOverrideBundleDisabled)
+	}
+
+	if !instance.overrideBundle.IsMediaPath(bundleDir) {
+		return "", aoserrors.Errorf("bundle dir is not on local media: %s", bundleDir)
+	}
+
+	info, err := instance.readOverrideBundle(bundleDir)
+	if err != nil {
+		return "", err
+	}
+
+	instance.Lock()
+	defer instance.Unlock()
+
+	if instance.shuttingDown {
+		return "", ErrShuttingDown
+	}
+
+	if instance.maintenanceMode.Enabled {
+		return "", aoserrors.Wrap(ErrMaintenanceMode)
+	}
+
+	if validationErrors := validateDesiredStatus(info.DesiredStatus); len(validationErrors) != 0 {
+		return "", aoserrors.Errorf("invalid desired status: %s", validationErrors[0])
+	}
+
+	report, err := json.Marshal(amqphandler.OverrideBundleReport{
+		BundleID: info.BundleID, AppliedAt: time.Now().UTC(), DesiredStatus: info.DesiredStatus,
+	})
+	if err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	desiredStatus, err := resolveBundleURLs(info.DesiredStatus, bundleDir)
+	if err != nil {
+		return "", err
+	}
+
+	if err = instance.storage.AddOverrideBundleReport(info.BundleID, report); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	correlationID := logging.NewCorrelationID()
+
+	log.WithFields(log.Fields{
+		"bundleID": info.BundleID, logging.CorrelationIDField: correlationID,
+	}).Info("Apply override bundle")
+
+	instance.pendingDesiredStatus = nil
+	instance.processDesiredStatus(desiredStatus, correlationID, "")
+
+	go instance.sendOverrideBundleReports()
+
+	return info.BundleID, nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+// readOverrideBundle reads bundle manifest, verifies mandatory authorization sign and validates bundle info.
+func (instance *Instance) readOverrideBundle(bundleDir string) (info OverrideBundleInfo, err error) {
+	data, err := os.ReadFile(filepath.Join(bundleDir, OverrideBundleManifest))
+	if err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	var bundle OverrideBundle
+
+	if err = json.Unmarshal(data, &bundle); err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	if instance.signVerifier == nil {
+		return info, aoserrors.New("authorization can't be verified")
+	}
+
+	if err = instance.signVerifier.VerifyDataSign(
+		bundle.Bundle, bundle.CertChains, bundle.Certs, bundle.Authorization); err != nil {
+		return info, aoserrors.Errorf("authorization failed: %v", err)
+	}
+
+	if err = json.Unmarshal(bundle.Bundle, &info); err != nil {
+		return info, aoserrors.Wrap(err)
+	}
+
+	if info.BundleID == "" {
+		return info, aoserrors.New("bundle ID is empty")
+	}
+
+	if info.ValidUntil.IsZero() || time.Now().After(info.ValidUntil) {
+		return info, aoserrors.New("bundle is expired")
+	}
+
+	return info, nil
+}
+
+// sendOverrideBundleReports sends pending override bundle reports if the unit is connected to the cloud. Sent reports
+// are removed from the storage.
+func (instance *Instance) sendOverrideBundleReports() {
+	instance.overrideReportsMutex.Lock()
+	defer instance.overrideReportsMutex.Unlock()
+
+	instance.statusMutex.Lock()
+	isConnected := instance.isConnected
+	instance.statusMutex.Unlock()
+
+	if !isConnected {
+		return
+	}
+
+	reports, err := instance.storage.GetOverrideBundleReports()
+	if err != nil {
+		log.Errorf("Can't get override bundle reports: %v", err)
+
+		return
+	}
+
+	for _, data := range reports {
+		var report amqphandler.OverrideBundleReport
+
+		if err = json.Unmarshal(data, &report); err != nil {
+			log.Errorf("Can't parse override bundle report: %v", err)
+
+			continue
+		}
+
+		if err = instance.statusSender.SendOverrideBundleReport(report); err != nil {
+			log.Errorf("Can't send override bundle report: %v", err)
+
+			return
+		}
+
+		if err = instance.storage.RemoveOverrideBundleReport(report.BundleID); err != nil {
+			log.Errorf("Can't remove override bundle report: %v", err)
+		}
+	}
+}
+
+// resolveBundleURLs returns desired status with artifact paths converted to file URLs of the bundle dir.
+func resolveBundleURLs(
+	desiredStatus cloudprotocol.DesiredStatus, bundleDir string,
+) (resolved cloudprotocol.DesiredStatus, err error) {
+	resolved = desiredStatus
+	resolved.Components = slices.Clone(desiredStatus.Components)
+	resolved.Layers = slices.Clone(desiredStatus.Layers)
+	resolved.Services = slices.Clone(desiredStatus.Services)
+
+	for i := range resolved.Components {
+		if err = resolveDownloadURLs(&resolved.Components[i].DownloadInfo, bundleDir); err != nil {
+			return resolved, err
+		}
+	}
+
+	for i := range resolved.Layers {
+		if err = resolveDownloadURLs(&resolved.Layers[i].DownloadInfo, bundleDir); err != nil {
+			return resolved, err
+		}
+	}
+
+	for i := range resolved.Services {
+		if err = resolveDownloadURLs(&resolved.Services[i].DownloadInfo, bundleDir); err != nil {
+			return resolved, err
+		}
+	}
+
+	return resolved, nil
+}
+
+func resolveDownloadURLs(downloadInfo *cloudprotocol.DownloadInfo, bundleDir string) error {
+	urls := make([]string, 0, len(downloadInfo.URLs))
+
+	for _, artifactPath := range downloadInfo.URLs {
+		if !filepath.IsLocal(artifactPath) {
+			return aoserrors.Errorf("artifact is out of bundle dir: %s", artifactPath)
+		}
+
+		fileURL := url.URL{Scheme: "file", Path: filepath.Join(bundleDir, artifactPath)}
+
+		urls = append(urls, fileURL.String())
+	}
+
+	downloadInfo.URLs = urls
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// ServiceWiper securely removes data of removed service.
+type ServiceWiper interface {
+	WipeService(serviceID string) error
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// SetServiceWipers sets wipers called synchronously on service removal in the wipers order. Service removal fails if
+// any wiper fails. Wiped services are confirmed in full unit status till the next SOTA update.
+func (instance *Instance) SetServiceWipers(wipers []ServiceWiper) {
+	instance.softwareManager.Lock()
+	defer instance.softwareManager.Unlock()
+
+	instance.softwareManager.serviceWipers = wipers
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (manager *softwareManager) wipeService(service cloudprotocol.ServiceStatus) error {
+	if len(manager.serviceWipers) == 0 {
+		return nil
+	}
+
+	for _, wiper := range manager.serviceWipers {
+		if err := wiper.WipeService(service.ServiceID); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	log.WithFields(log.Fields{"id": service.ServiceID, "version": service.Version}).Info("Service data wiped")
+
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	manager.WipedServices = append(manager.WipedServices, amqphandler.ServiceWipeStatus{
+		ServiceID: service.ServiceID, Version: service.Version, WipedAt: time.Now().UTC(),
+	})
+
+	return nil
+}
+
+func (manager *softwareManager) getWipedServices() []amqphandler.ServiceWipeStatus {
+	manager.statusMutex.RLock()
+	defer manager.statusMutex.RUnlock()
+
+	return slices.Clone(manager.WipedServices)
+}
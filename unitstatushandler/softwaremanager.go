@@ -33,8 +33,11 @@ import (
 	"github.com/looplab/fsm"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/cmserver"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/extension"
+	"github.com/aosedge/aos_communicationmanager/logging"
 	"github.com/aosedge/aos_communicationmanager/unitconfig"
 )
 
@@ -54,6 +57,8 @@ type softwareDownloader interface {
 	releaseDownloadedSoftware() error
 }
 type softwareStatusHandler interface {
+	decryptionInfoRefresher
+	campaignUpdater
 	updateLayerStatus(status cloudprotocol.LayerStatus) bool
 	updateServiceStatus(status cloudprotocol.ServiceStatus) bool
 	updateUnitConfigStatus(status cloudprotocol.UnitConfigStatus) bool
@@ -75,6 +80,8 @@ type softwareUpdate struct {
 	Certs            []cloudprotocol.Certificate      `json:"certs,omitempty"`
 	NodesStatus      []cloudprotocol.NodeStatus       `json:"nodesStatus,omitempty"`
 	RebalanceRequest bool                             `json:"rebalanceRequest,omitempty"`
+	CorrelationID    string                           `json:"correlationId,omitempty"`
+	CampaignID       string                           `json:"campaignId,omitempty"`
 }
 
 const (
@@ -100,10 +107,13 @@ type softwareManager struct {
 	instanceRunner    InstanceRunner
 	storage           Storage
 
-	stateMachine  *updateStateMachine
-	actionHandler *action.Handler
-	statusMutex   sync.RWMutex
-	pendingUpdate *softwareUpdate
+	stateMachine    *updateStateMachine
+	updateGates     []extension.UpdateGate
+	serviceWipers   []ServiceWiper
+	updateEstimator *updateEstimator
+	actionHandler   *action.Handler
+	statusMutex     sync.RWMutex
+	pendingUpdate   *softwareUpdate
 
 	newServices    []string
 	revertServices []string
@@ -117,6 +127,8 @@ type softwareManager struct {
 	CurrentState     string                                  `json:"currentState,omitempty"`
 	UpdateErr        *cloudprotocol.ErrorInfo                `json:"updateErr,omitempty"`
 	TTLDate          time.Time                               `json:"ttlDate,omitempty"`
+	Checkpoint       *updateCheckpoint                       `json:"checkpoint,omitempty"`
+	WipedServices    []amqphandler.ServiceWipeStatus         `json:"wipedServices,omitempty"`
 }
 
 /***********************************************************************************************************************
@@ -125,10 +137,11 @@ type softwareManager struct {
 
 func newSoftwareManager(statusHandler softwareStatusHandler, downloader softwareDownloader, unitManager UnitManager,
 	unitConfigUpdater UnitConfigUpdater, softwareUpdater SoftwareUpdater, instanceRunner InstanceRunner,
-	storage Storage, defaultTTL time.Duration,
+	storage Storage, defaultTTL time.Duration, updateEstimator *updateEstimator,
 ) (manager *softwareManager, err error) {
 	manager = &softwareManager{
 		statusChannel:     make(chan cmserver.UpdateSOTAStatus, 1),
+		updateEstimator:   updateEstimator,
 		downloader:        downloader,
 		statusHandler:     statusHandler,
 		unitManager:       unitManager,
@@ -146,6 +159,11 @@ func newSoftwareManager(statusHandler softwareStatusHandler, downloader software
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if manager.CurrentState != stateNoUpdate && manager.CurrentUpdate != nil {
+		logging.SetCorrelationID(extension.UpdateTypeSOTA, manager.CurrentUpdate.CorrelationID)
+		logging.SetCampaignID(extension.UpdateTypeSOTA, manager.CurrentUpdate.CampaignID)
+	}
+
 	log.WithFields(log.Fields{"state": manager.CurrentState, "error": manager.UpdateErr}).Debug("New software manager")
 
 	manager.stateMachine = newUpdateStateMachine(manager.CurrentState, fsm.Events{
@@ -226,6 +244,12 @@ func (manager *softwareManager) getCurrentStatus() (status cmserver.UpdateSOTASt
 		status.UnitConfig = &cloudprotocol.UnitConfigStatus{Version: manager.CurrentUpdate.UnitConfig.Version}
 	}
 
+	if status.State == cmserver.Downloading || status.State == cmserver.Updating {
+		manager.statusMutex.RLock()
+		status.ETA = manager.updateEstimator.getETA(manager.getUpdateItems())
+		manager.statusMutex.RUnlock()
+	}
+
 	return status
 }
 
@@ -246,12 +270,34 @@ func (manager *softwareManager) processRunStatus(instances []cloudprotocol.Insta
 	return len(manager.revertServices) != 0
 }
 
-func (manager *softwareManager) processDesiredStatus(desiredStatus cloudprotocol.DesiredStatus) error {
+func (manager *softwareManager) processDesiredStatus(
+	desiredStatus cloudprotocol.DesiredStatus, correlationID, campaignID string,
+) error {
 	manager.Lock()
 	defer manager.Unlock()
 
 	log.Debug("Process desired SOTA")
 
+	update, err := manager.prepareUpdate(desiredStatus)
+	if err != nil {
+		return err
+	}
+
+	update.CorrelationID = correlationID
+	update.CampaignID = campaignID
+
+	if manager.isUpdateRequired(update) {
+		if err := manager.newUpdate(update); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	} else {
+		log.Debug("No SOTA update required")
+	}
+
+	return nil
+}
+
+func (manager *softwareManager) prepareUpdate(desiredStatus cloudprotocol.DesiredStatus) (*softwareUpdate, error) {
 	update := &softwareUpdate{
 		Schedule:        desiredStatus.SOTASchedule,
 		UnitConfig:      desiredStatus.UnitConfig,
@@ -266,30 +312,26 @@ func (manager *softwareManager) processDesiredStatus(desiredStatus cloudprotocol
 
 	allServices, err := manager.softwareUpdater.GetServicesStatus()
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
 	allLayers, err := manager.softwareUpdater.GetLayersStatus()
 	if err != nil {
-		return aoserrors.Wrap(err)
+		return nil, aoserrors.Wrap(err)
 	}
 
 	manager.processDesiredServices(update, allServices, desiredStatus.Services)
 	manager.processDesiredLayers(update, allLayers, desiredStatus.Layers)
 	manager.processNodesStatus(update, desiredStatus.Nodes)
 
-	if len(update.InstallServices) != 0 || len(update.RemoveServices) != 0 ||
-		len(update.InstallLayers) != 0 || len(update.RemoveLayers) != 0 || len(update.RestoreServices) != 0 ||
-		len(update.RestoreLayers) != 0 || manager.needRunInstances(desiredStatus.Instances) ||
-		update.UnitConfig != nil || len(update.NodesStatus) != 0 {
-		if err := manager.newUpdate(update); err != nil {
-			return aoserrors.Wrap(err)
-		}
-	} else {
-		log.Debug("No SOTA update required")
-	}
+	return update, nil
+}
 
-	return nil
+func (manager *softwareManager) isUpdateRequired(update *softwareUpdate) bool {
+	return len(update.InstallServices) != 0 || len(update.RemoveServices) != 0 ||
+		len(update.InstallLayers) != 0 || len(update.RemoveLayers) != 0 || len(update.RestoreServices) != 0 ||
+		len(update.RestoreLayers) != 0 || manager.needRunInstances(update.RunInstances) ||
+		update.UnitConfig != nil || len(update.NodesStatus) != 0
 }
 
 func (manager *softwareManager) requestRebalancing() error {
@@ -311,6 +353,7 @@ func (manager *softwareManager) requestRebalancing() error {
 		RunInstances:     manager.CurrentUpdate.RunInstances,
 		NodesStatus:      make([]cloudprotocol.NodeStatus, 0),
 		RebalanceRequest: true,
+		CorrelationID:    logging.NewCorrelationID(),
 	}
 
 	if err := manager.newUpdate(update); err != nil {
@@ -466,6 +509,19 @@ func (manager *softwareManager) startUpdate() (err error) {
 
 	log.Debug("Start software update")
 
+	if manager.stateMachine.canTransit(eventStartUpdate) {
+		if err = checkUpdateGates(manager.updateGates, extension.UpdateTypeSOTA); err != nil {
+			manager.stateMachine.postponeUpdate()
+
+			return err
+		}
+
+		if err = manager.updateEstimator.checkUpdateWindow(
+			time.Now(), manager.CurrentUpdate.Schedule, manager.getUpdateItems()); err != nil {
+			return manager.deferUpdate(err)
+		}
+	}
+
 	if err = manager.stateMachine.sendEvent(eventStartUpdate, nil); err != nil {
 		return aoserrors.Wrap(err)
 	}
@@ -579,6 +635,11 @@ func (manager *softwareManager) getInstancesStatus() ([]cloudprotocol.InstanceSt
 func (manager *softwareManager) stateChanged(event, state string, updateErr error) {
 	var errorInfo *cloudprotocol.ErrorInfo
 
+	if state != stateNoUpdate && manager.CurrentUpdate != nil {
+		logging.SetCorrelationID(extension.UpdateTypeSOTA, manager.CurrentUpdate.CorrelationID)
+		logging.SetCampaignID(extension.UpdateTypeSOTA, manager.CurrentUpdate.CampaignID)
+	}
+
 	if updateErr != nil {
 		errorInfo = &cloudprotocol.ErrorInfo{Message: updateErr.Error()}
 	}
@@ -610,6 +671,14 @@ func (manager *softwareManager) stateChanged(event, state string, updateErr erro
 		}
 	}
 
+	switch {
+	case event == eventStartUpdate:
+		manager.Checkpoint = newUpdateCheckpoint()
+
+	case state == stateNoUpdate:
+		manager.Checkpoint = nil
+	}
+
 	manager.CurrentState = state
 	manager.UpdateErr = errorInfo
 
@@ -627,6 +696,16 @@ func (manager *softwareManager) stateChanged(event, state string, updateErr erro
 	if err := manager.saveState(); err != nil {
 		log.Errorf("Can't save current software manager state: %v", err)
 	}
+
+	if manager.CurrentUpdate != nil {
+		manager.statusHandler.updateCampaign(manager.CurrentUpdate.CampaignID, extension.UpdateTypeSOTA,
+			manager.CurrentUpdate.CorrelationID, state, errorInfo)
+	}
+
+	if state == stateNoUpdate {
+		logging.SetCorrelationID(extension.UpdateTypeSOTA, "")
+		logging.SetCampaignID(extension.UpdateTypeSOTA, "")
+	}
 }
 
 func (manager *softwareManager) noUpdate() {
@@ -672,7 +751,7 @@ func (manager *softwareManager) download(ctx context.Context) {
 		}()
 	}()
 
-	manager.DownloadResult = nil
+	manager.setDownloadResult(nil)
 
 	request := manager.prepareDownloadRequest()
 
@@ -681,7 +760,8 @@ func (manager *softwareManager) download(ctx context.Context) {
 		return
 	}
 
-	manager.DownloadResult = manager.downloader.download(ctx, request, true, manager.updateStatusByID)
+	manager.setDownloadResult(manager.downloader.download(ctx, request, true, manager.updateStatusByID))
+	manager.updateEstimator.learnDownloadTimes(manager.getUpdateItems(), manager.DownloadResult)
 
 	// Set pending state
 
@@ -831,52 +911,73 @@ func (manager *softwareManager) update(ctx context.Context) { //nolint: funlen
 		return
 	}
 
-	if err := manager.updateNodes(); err != nil {
+	// Checkpoint is restored from the storage if the update was interrupted by CM restart
+	if manager.Checkpoint == nil {
+		manager.Checkpoint = newUpdateCheckpoint()
+
+		manager.statusMutex.Lock()
+		manager.WipedServices = nil
+		manager.statusMutex.Unlock()
+	}
+
+	if manager.Checkpoint.Error != "" {
+		updateErr = aoserrors.New(manager.Checkpoint.Error)
+	}
+
+	if err := manager.doUpdateStep(stepUpdateNodes, manager.updateNodes); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
 	if manager.CurrentUpdate.UnitConfig != nil {
-		if err := manager.updateUnitConfig(); err != nil {
+		if err := manager.doUpdateStep(stepUpdateUnitConfig, manager.updateUnitConfig); err != nil {
 			updateErr = err
 		}
 	}
 
-	if err := manager.removeServices(); err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepRemoveServices, manager.removeServices); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	if err := manager.installLayers(); err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepInstallLayers, manager.installLayers); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	if err := manager.restoreServices(); err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepRestoreServices, manager.restoreServices); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	newServices, err := manager.installServices()
-	if err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepInstallServices, manager.installServices); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	manager.newServices = newServices
+	manager.newServices = manager.Checkpoint.getDoneItems(stepInstallServices)
 	manager.revertServices = nil
 
-	if err := manager.removeLayers(); err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepRemoveLayers, manager.removeLayers); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	if err := manager.restoreLayers(); err != nil && updateErr == nil {
+	if err := manager.doUpdateStep(stepRestoreLayers, manager.restoreLayers); err != nil && updateErr == nil {
 		updateErr = err
 	}
 
-	if err := manager.runInstances(); err != nil && updateErr == nil {
-		updateErr = err
-	}
+	if manager.Checkpoint.isStepDone(stepRunInstances) {
+		// Instances were run before restart: check new services against the initial run status instead of sending
+		// run request again.
+		if len(manager.newServices) != 0 && len(manager.CurrentUpdate.RunInstances) != 0 {
+			manager.checkNewServices()
+			manager.newServices = nil
+		}
+	} else {
+		if err := manager.doUpdateStep(stepRunInstances, manager.runInstances); err != nil && updateErr == nil {
+			updateErr = err
+		}
 
-	manager.runStatusEvent = runStatusEventNone
+		manager.runStatusEvent = runStatusEventNone
 
-	for manager.runStatusEvent == runStatusEventNone {
-		manager.runStatusCV.Wait()
+		for manager.runStatusEvent == runStatusEventNone {
+			manager.runStatusCV.Wait()
+		}
 	}
 
 	if manager.runStatusEvent != runStatusEventReceived {
@@ -1049,6 +1150,52 @@ func (manager *softwareManager) sendCurrentStatus() {
 	manager.statusChannel <- manager.getCurrentStatus()
 }
 
+// setDownloadResult sets download result under status lock as it is read by status requests.
+func (manager *softwareManager) setDownloadResult(result map[string]*downloadResult) {
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	manager.DownloadResult = result
+}
+
+func (manager *softwareManager) getUpdateItems() []updateItem {
+	items := make([]updateItem, 0, len(manager.CurrentUpdate.InstallServices)+len(manager.CurrentUpdate.InstallLayers))
+
+	for _, service := range manager.CurrentUpdate.InstallServices {
+		items = append(items, updateItem{
+			id: service.ServiceID, downloadID: service.ServiceID, size: service.Size,
+			downloaded: isDownloaded(manager.DownloadResult, service.ServiceID),
+			installed:  manager.isItemDone(stepInstallServices, service.ServiceID),
+		})
+	}
+
+	for _, layer := range manager.CurrentUpdate.InstallLayers {
+		items = append(items, updateItem{
+			id: layer.LayerID, downloadID: layer.Digest, size: layer.Size,
+			downloaded: isDownloaded(manager.DownloadResult, layer.Digest),
+			installed:  manager.isItemDone(stepInstallLayers, layer.Digest),
+		})
+	}
+
+	return items
+}
+
+// deferUpdate defers the update which doesn't fit update window to the next window.
+func (manager *softwareManager) deferUpdate(windowErr error) error {
+	if !errors.Is(windowErr, errWindowTooShort) {
+		log.Errorf("Can't check update window: %v", windowErr)
+
+		return manager.stateMachine.sendEvent(eventStartUpdate, nil)
+	}
+
+	manager.stateMachine.deferUpdate(manager.CurrentUpdate.Schedule)
+
+	manager.UpdateErr = &cloudprotocol.ErrorInfo{Message: windowErr.Error()}
+	manager.sendCurrentStatus()
+
+	return aoserrors.Errorf("%w: %v", errUpdatePostponed, windowErr)
+}
+
 func (manager *softwareManager) updateStatusByID(id string, status string, errorInfo *cloudprotocol.ErrorInfo) {
 	if _, ok := manager.LayerStatuses[id]; ok {
 		manager.updateLayerStatusByID(id, status, errorInfo)
@@ -1121,6 +1268,42 @@ func (manager *softwareManager) saveState() (err error) {
 	return nil
 }
 
+func (manager *softwareManager) doUpdateStep(step string, stepFunc func() error) error {
+	if manager.Checkpoint.isStepDone(step) {
+		log.WithField("step", step).Debug("Skip completed update step")
+
+		return nil
+	}
+
+	err := stepFunc()
+
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	manager.Checkpoint.setStepDone(step, err)
+
+	if saveErr := manager.saveState(); saveErr != nil {
+		log.Errorf("Can't save software update checkpoint: %v", saveErr)
+	}
+
+	return err
+}
+
+func (manager *softwareManager) setItemDone(step, id string) {
+	manager.statusMutex.Lock()
+	defer manager.statusMutex.Unlock()
+
+	manager.Checkpoint.setItemDone(step, id)
+
+	if err := manager.saveState(); err != nil {
+		log.Errorf("Can't save software update checkpoint: %v", err)
+	}
+}
+
+func (manager *softwareManager) isItemDone(step, id string) bool {
+	return manager.Checkpoint != nil && manager.Checkpoint.isItemDone(step, id)
+}
+
 func (manager *softwareManager) installLayers() (installErr error) {
 	var mutex sync.Mutex
 
@@ -1155,8 +1338,8 @@ func (manager *softwareManager) installLayers() (installErr error) {
 			continue
 		}
 
-		// Do not install not downloaded layers
-		if downloadInfo.Error != "" {
+		// Do not install not downloaded layers and layers installed before restart
+		if downloadInfo.Error != "" || manager.Checkpoint.isItemDone(stepInstallLayers, layer.Digest) {
 			continue
 		}
 
@@ -1183,12 +1366,25 @@ func (manager *softwareManager) installLayers() (installErr error) {
 		layerInfo := layer
 
 		manager.actionHandler.Execute(layerInfo.Digest, func(digest string) error {
-			if err := manager.softwareUpdater.InstallLayer(layerInfo,
-				manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs); err != nil {
+			startTime := time.Now()
+
+			if err := installWithKeyRotation(manager.statusHandler, manager.CurrentUpdate.CorrelationID,
+				[]decryptionInfoItem{{
+					DecryptionItem: amqphandler.DecryptionItem{
+						ID: layerInfo.Digest, Type: amqphandler.DownloadTypeLayer, Version: layerInfo.Version,
+					},
+					decryptionInfo: &layerInfo.DecryptionInfo,
+				}}, func() error {
+					return manager.softwareUpdater.InstallLayer(layerInfo,
+						manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs)
+				}); err != nil {
 				handleError(layerInfo, aoserrors.Wrap(err))
 				return aoserrors.Wrap(err)
 			}
 
+			manager.updateEstimator.learnInstallTime(
+				updateItem{id: layerInfo.LayerID, size: layerInfo.Size}, time.Since(startTime))
+
 			log.WithFields(log.Fields{
 				"id":         layerInfo.LayerID,
 				"aosVersion": layerInfo.Version,
@@ -1196,6 +1392,7 @@ func (manager *softwareManager) installLayers() (installErr error) {
 			}).Info("Layer successfully installed")
 
 			manager.updateLayerStatusByID(layerInfo.Digest, cloudprotocol.InstalledStatus, nil)
+			manager.setItemDone(stepInstallLayers, layerInfo.Digest)
 
 			return nil
 		})
@@ -1207,16 +1404,16 @@ func (manager *softwareManager) installLayers() (installErr error) {
 }
 
 func (manager *softwareManager) removeLayers() (removeErr error) {
-	return manager.processRemoveRestoreLayers(
+	return manager.processRemoveRestoreLayers(stepRemoveLayers,
 		manager.CurrentUpdate.RemoveLayers, "Remove", cloudprotocol.RemovedStatus, manager.softwareUpdater.RemoveLayer)
 }
 
 func (manager *softwareManager) restoreLayers() (restoreErr error) {
-	return manager.processRemoveRestoreLayers(
+	return manager.processRemoveRestoreLayers(stepRestoreLayers,
 		manager.CurrentUpdate.RestoreLayers, "Restore", cloudprotocol.InstalledStatus, manager.softwareUpdater.RestoreLayer)
 }
 
-func (manager *softwareManager) processRemoveRestoreLayers(
+func (manager *softwareManager) processRemoveRestoreLayers(step string,
 	layers []cloudprotocol.LayerStatus, operationStr, successStatus string, operation func(digest string) error,
 ) (processError error) {
 	var mutex sync.Mutex
@@ -1244,6 +1441,10 @@ func (manager *softwareManager) processRemoveRestoreLayers(
 	}
 
 	for _, layer := range layers {
+		if manager.Checkpoint.isItemDone(step, layer.Digest) {
+			continue
+		}
+
 		log.WithFields(log.Fields{
 			"id":         layer.LayerID,
 			"aosVersion": layer.Version,
@@ -1274,6 +1475,7 @@ func (manager *softwareManager) processRemoveRestoreLayers(
 			}).Infof("Layer successfully %sd", operationStr)
 
 			manager.updateLayerStatusByID(layerInfo.Digest, successStatus, nil)
+			manager.setItemDone(step, layerInfo.Digest)
 
 			return nil
 		})
@@ -1284,7 +1486,7 @@ func (manager *softwareManager) processRemoveRestoreLayers(
 	return nil
 }
 
-func (manager *softwareManager) installServices() (newServices []string, installErr error) {
+func (manager *softwareManager) installServices() (installErr error) {
 	var mutex sync.Mutex
 
 	handleError := func(service cloudprotocol.ServiceInfo, serviceErr error) {
@@ -1317,8 +1519,8 @@ func (manager *softwareManager) installServices() (newServices []string, install
 			continue
 		}
 
-		// Skip not downloaded services
-		if downloadInfo.Error != "" {
+		// Skip not downloaded services and services installed before restart
+		if downloadInfo.Error != "" || manager.Checkpoint.isItemDone(stepInstallServices, service.ServiceID) {
 			continue
 		}
 
@@ -1344,21 +1546,33 @@ func (manager *softwareManager) installServices() (newServices []string, install
 		serviceInfo := service
 
 		manager.actionHandler.Execute(serviceInfo.ServiceID, func(serviceID string) error {
-			err := manager.softwareUpdater.InstallService(serviceInfo,
-				manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs)
+			startTime := time.Now()
+
+			err := installWithKeyRotation(manager.statusHandler, manager.CurrentUpdate.CorrelationID,
+				[]decryptionInfoItem{{
+					DecryptionItem: amqphandler.DecryptionItem{
+						ID: serviceInfo.ServiceID, Type: amqphandler.DownloadTypeService, Version: serviceInfo.Version,
+					},
+					decryptionInfo: &serviceInfo.DecryptionInfo,
+				}}, func() error {
+					return manager.softwareUpdater.InstallService(serviceInfo,
+						manager.CurrentUpdate.CertChains, manager.CurrentUpdate.Certs)
+				})
 			if err != nil {
 				handleError(serviceInfo, aoserrors.Wrap(err))
 				return aoserrors.Wrap(err)
 			}
 
+			manager.updateEstimator.learnInstallTime(
+				updateItem{id: serviceInfo.ServiceID, size: serviceInfo.Size}, time.Since(startTime))
+
 			log.WithFields(log.Fields{
 				"id":         serviceInfo.ServiceID,
 				"aosVersion": serviceInfo.Version,
 			}).Info("Service successfully installed")
 
-			newServices = append(newServices, serviceInfo.ServiceID)
-
 			manager.updateServiceStatusByID(serviceInfo.ServiceID, cloudprotocol.InstalledStatus, nil)
+			manager.setItemDone(stepInstallServices, serviceInfo.ServiceID)
 
 			return nil
 		})
@@ -1366,7 +1580,7 @@ func (manager *softwareManager) installServices() (newServices []string, install
 
 	manager.actionHandler.Wait()
 
-	return newServices, installErr
+	return installErr
 }
 
 func (manager *softwareManager) restoreServices() (restoreErr error) {
@@ -1394,16 +1608,22 @@ func (manager *softwareManager) restoreServices() (restoreErr error) {
 	}
 
 	for _, service := range manager.CurrentUpdate.RestoreServices {
+		if manager.Checkpoint.isItemDone(stepRestoreServices, service.ServiceID) {
+			continue
+		}
+
 		log.WithFields(log.Fields{
 			"id":         service.ServiceID,
 			"aosVersion": service.Version,
 		}).Debug("Restore service")
 
+		manager.statusMutex.Lock()
 		manager.ServiceStatuses[service.ServiceID] = &cloudprotocol.ServiceStatus{
 			ServiceID: service.ServiceID,
 			Version:   service.Version,
 			Status:    cloudprotocol.InstallingStatus,
 		}
+		manager.statusMutex.Unlock()
 
 		// Create new variable to be captured by action function
 		serviceInfo := service
@@ -1420,6 +1640,7 @@ func (manager *softwareManager) restoreServices() (restoreErr error) {
 			}).Info("Service successfully restored")
 
 			manager.updateServiceStatusByID(serviceInfo.ServiceID, cloudprotocol.InstalledStatus, nil)
+			manager.setItemDone(stepRestoreServices, serviceInfo.ServiceID)
 
 			return nil
 		})
@@ -1455,6 +1676,10 @@ func (manager *softwareManager) removeServices() (removeErr error) {
 	}
 
 	for _, service := range manager.CurrentUpdate.RemoveServices {
+		if manager.Checkpoint.isItemDone(stepRemoveServices, service.ServiceID) {
+			continue
+		}
+
 		log.WithFields(log.Fields{
 			"id":         service.ServiceID,
 			"aosVersion": service.Version,
@@ -1482,12 +1707,19 @@ func (manager *softwareManager) removeServices() (removeErr error) {
 				return err
 			}
 
+			if err := manager.wipeService(serviceStatus); err != nil {
+				handleError(serviceStatus, err)
+
+				return err
+			}
+
 			log.WithFields(log.Fields{
 				"id":         serviceStatus.ServiceID,
 				"aosVersion": serviceStatus.Version,
 			}).Info("Service successfully removed")
 
 			manager.updateServiceStatusByID(serviceStatus.ServiceID, cloudprotocol.RemovedStatus, nil)
+			manager.setItemDone(stepRemoveServices, serviceStatus.ServiceID)
 
 			return nil
 		})
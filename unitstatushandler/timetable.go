@@ -127,3 +127,34 @@ func getAvailableTimetableTime(
 
 	return availableTime, aoserrors.New("no available time")
 }
+
+// getRemainingTimetableTime returns remaining time of the timetable slot which contains the date. Zero is returned if
+// the date is out of timetable slots.
+func getRemainingTimetableTime(
+	fromDate time.Time, timetable []cloudprotocol.TimetableEntry,
+) (remainingTime time.Duration, err error) {
+	if err = validateTimetable(timetable); err != nil {
+		return remainingTime, err
+	}
+
+	for _, entry := range timetable {
+		if time.Weekday(entry.DayOfWeek%daysInWeek) != fromDate.Weekday() {
+			continue
+		}
+
+		for _, slot := range entry.TimeSlots {
+			startTime := time.Date(fromDate.Year(), fromDate.Month(), fromDate.Day(),
+				slot.Start.Hour(), slot.Start.Minute(), slot.Start.Second(), slot.Start.Nanosecond(),
+				time.Local) //nolint:gosmopolitan
+			endTime := time.Date(fromDate.Year(), fromDate.Month(), fromDate.Day(),
+				slot.End.Hour(), slot.End.Minute(), slot.End.Second(), slot.End.Nanosecond(),
+				time.Local) //nolint:gosmopolitan
+
+			if !startTime.After(fromDate) && endTime.After(fromDate) {
+				remainingTime = max(remainingTime, endTime.Sub(fromDate))
+			}
+		}
+	}
+
+	return remainingTime, nil
+}
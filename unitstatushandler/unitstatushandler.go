@@ -19,9 +19,14 @@ package unitstatushandler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +41,23 @@ import (
 	"github.com/aosedge/aos_communicationmanager/cmserver"
 	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/extension"
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// NodeAttrTelemetryProfile main node attribute which reports current telemetry profile.
+const NodeAttrTelemetryProfile = "TelemetryProfile"
+
+const shutdownPollPeriod = 100 * time.Millisecond
+
+// Update history steps.
+const (
+	UpdateStepDownload = "download"
+	UpdateStepInstall  = "install"
 )
 
 /***********************************************************************************************************************
@@ -62,9 +84,26 @@ type Downloader interface {
 
 // StatusSender sends unit status to cloud.
 type StatusSender interface {
-	SendUnitStatus(unitStatus cloudprotocol.UnitStatus) (err error)
+	SendUnitStatus(unitStatus amqphandler.UnitStatus) (err error)
 	SendDeltaUnitStatus(deltaUnitStatus cloudprotocol.DeltaUnitStatus) (err error)
+	SendDesiredStatusReport(report amqphandler.DesiredStatusReport) error
+	SendDesiredStatusRejection(rejection amqphandler.DesiredStatusRejection) error
+	SendDecryptionInfoRequest(request amqphandler.DecryptionInfoRequest) error
+	SendEmergencyUpdateStatus(status amqphandler.EmergencyUpdateStatus) error
+	SendOverrideBundleReport(report amqphandler.OverrideBundleReport) error
+	SendCampaignSummary(summary amqphandler.CampaignSummary) error
 	SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error
+	SubscribeForTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error
+}
+
+// NodeCapabilitiesProvider provides node capabilities announced in unit status.
+type NodeCapabilitiesProvider interface {
+	GetNodeCapabilities(nodeInfo cloudprotocol.NodeInfo) amqphandler.NodeCapabilities
+}
+
+// ProviderNetworksProvider provides provider networks reported in full unit status.
+type ProviderNetworksProvider interface {
+	GetProviderNetworksStatus() []amqphandler.ProviderNetworkStatus
 }
 
 // UnitConfigUpdater updates unit configuration.
@@ -113,6 +152,30 @@ type Storage interface {
 	GetFirmwareUpdateState() (state json.RawMessage, err error)
 	SetSoftwareUpdateState(state json.RawMessage) (err error)
 	GetSoftwareUpdateState() (state json.RawMessage, err error)
+	AddUpdateHistoryRecord(record UpdateHistoryRecord) error
+	GetUpdateHistoryRecords(id, step string, limit int) (records []UpdateHistoryRecord, err error)
+	RemoveOutdatedUpdateHistoryRecords(id, step string, maxRecords int) error
+	SetMaintenanceMode(state json.RawMessage) error
+	GetMaintenanceMode() (state json.RawMessage, err error)
+	AddOverrideBundleReport(bundleID string, report json.RawMessage) error
+	GetOverrideBundleReports() (reports []json.RawMessage, err error)
+	RemoveOverrideBundleReport(bundleID string) error
+	SetCampaignSummary(campaignID string, summary json.RawMessage) error
+	GetCampaignSummaries() (summaries []json.RawMessage, err error)
+	RemoveCampaignSummary(campaignID string) error
+	AddEmergencyUpdateID(updateID string, validUntil time.Time) error
+	IsEmergencyUpdateIDUsed(updateID string) (used bool, err error)
+}
+
+// UpdateHistoryRecord actual duration of update step of component, service or layer. Campaign ID is set if the update
+// belongs to a campaign.
+type UpdateHistoryRecord struct {
+	Timestamp  time.Time
+	ID         string
+	Step       string
+	Size       uint64
+	Duration   time.Duration
+	CampaignID string
 }
 
 // ServiceStatus represents service status.
@@ -127,31 +190,65 @@ type LayerStatus struct {
 	Cached bool
 }
 
+type shutdownGate struct{}
+
 // Instance instance of unit status handler.
 type Instance struct {
 	sync.Mutex
 
 	unitManager  UnitManager
 	statusSender StatusSender
+	storage      Storage
+	signVerifier SignVerifier
 
 	statusMutex sync.Mutex
 
 	unitStatus       cloudprotocol.UnitStatus
 	statusTimer      *time.Timer
 	sendStatusPeriod time.Duration
+	mainNodeAttrs    map[string]interface{}
+	bootStatuses     map[string]BootStatus
+
+	features                 map[string]bool
+	nodeCapabilitiesProvider NodeCapabilitiesProvider
+	networksProvider         ProviderNetworksProvider
+	maintenanceStatus        *amqphandler.MaintenanceStatus
+	featureFlags             map[string]bool
 
-	firmwareManager *firmwareManager
-	softwareManager *softwareManager
+	firmwareManager        *firmwareManager
+	softwareManager        *softwareManager
+	emergencyUpdater       *emergencyUpdater
+	decryptionInfoProvider *decryptionInfoProvider
+
+	pendingDesiredStatus *pendingDesiredStatus
+
+	maintenanceMode   cmserver.MaintenanceMode
+	maintenanceGate   *maintenanceGate
+	schedulingFreezer SchedulingFreezer
+
+	overrideBundle       config.OverrideBundle
+	overrideReportsMutex sync.Mutex
+
+	campaignsMutex sync.Mutex
+	campaigns      map[string]*amqphandler.CampaignSummary
 
 	newComponentsChannel       <-chan []cloudprotocol.ComponentStatus
 	nodeChangedChannel         <-chan cloudprotocol.NodeInfo
 	unitSubjectsChangedChannel <-chan []string
 	systemQuotaAlertChannel    <-chan cloudprotocol.SystemQuotaAlert
 
-	initDone    bool
-	isConnected bool
+	initDone     bool
+	isConnected  bool
+	shuttingDown bool
 }
 
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+// ErrShuttingDown returned when desired status is received during shutdown.
+var ErrShuttingDown = errors.New("unit status handler is shutting down")
+
 /***********************************************************************************************************************
  * Public
  **********************************************************************************************************************/
@@ -168,13 +265,20 @@ func New(
 	storage Storage,
 	statusSender StatusSender,
 	systemQuotaAlertProvider SystemQuotaAlertProvider,
+	signVerifier SignVerifier,
 ) (instance *Instance, err error) {
 	log.Debug("Create unit status handler")
 
 	instance = &Instance{
 		unitManager:                unitManager,
 		statusSender:               statusSender,
+		storage:                    storage,
+		signVerifier:               signVerifier,
+		overrideBundle:             cfg.OverrideBundle,
+		maintenanceGate:            &maintenanceGate{},
 		sendStatusPeriod:           cfg.UnitStatusSendTimeout.Duration,
+		features:                   getFeatures(cfg),
+		featureFlags:               cfg.GetFeatureFlags(),
 		newComponentsChannel:       firmwareUpdater.NewComponentsChannel(),
 		nodeChangedChannel:         unitManager.SubscribeNodeInfoChange(),
 		unitSubjectsChangedChannel: unitManager.SubscribeUnitSubjectsChanged(),
@@ -183,22 +287,42 @@ func New(
 
 	instance.resetUnitStatus()
 
+	if err = instance.loadCampaigns(); err != nil {
+		log.Errorf("Can't load campaigns: %v", err)
+	}
+
 	groupDownloader := newGroupDownloader(downloader)
+	firmwareUpdater = &lockedFirmwareUpdater{FirmwareUpdater: firmwareUpdater}
+	updateEstimator := newUpdateEstimator(cfg.UpdateWindow, groupDownloader, newUpdateHistory(storage))
+	instance.decryptionInfoProvider = newDecryptionInfoProvider(statusSender, defaultDecryptionInfoTimeout)
 
 	if instance.firmwareManager, err = newFirmwareManager(instance, groupDownloader, firmwareUpdater,
-		storage, cfg.UMController.UpdateTTL.Duration); err != nil {
+		storage, cfg.UMController.UpdateTTL.Duration, updateEstimator); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
 	if instance.softwareManager, err = newSoftwareManager(instance, groupDownloader, unitManager, unitConfigUpdater,
-		softwareUpdater, instanceRunner, storage, cfg.SMController.UpdateTTL.Duration); err != nil {
+		softwareUpdater, instanceRunner, storage, cfg.SMController.UpdateTTL.Duration, updateEstimator); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	instance.SetUpdateGates(nil)
+
+	if err = instance.loadMaintenanceMode(storage); err != nil {
+		log.Errorf("Can't load maintenance mode: %v", err)
+	}
+
+	instance.emergencyUpdater = newEmergencyUpdater(
+		cfg.EmergencyUpdate, downloader, firmwareUpdater, signVerifier, statusSender, instance, storage)
+
 	if err = instance.statusSender.SubscribeForConnectionEvents(instance); err != nil {
 		return nil, aoserrors.Wrap(err)
 	}
 
+	if err = instance.statusSender.SubscribeForTelemetryProfileChanges(instance); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
 	go instance.handleChannels()
 
 	return instance, nil
@@ -219,6 +343,9 @@ func (instance *Instance) Close() (err error) {
 
 	instance.statusMutex.Unlock()
 
+	instance.emergencyUpdater.close()
+	instance.decryptionInfoProvider.close()
+
 	if managerErr := instance.firmwareManager.close(); managerErr != nil {
 		if err == nil {
 			err = aoserrors.Wrap(managerErr)
@@ -234,6 +361,39 @@ func (instance *Instance) Close() (err error) {
 	return aoserrors.Wrap(err)
 }
 
+// Shutdown prepares unit status handler to CM shutdown. New desired statuses are rejected, new FOTA and SOTA updates
+// are postponed and updates being applied are waited to be finished till ctx is done. Interrupted updates are
+// continued from the persisted state on next start.
+func (instance *Instance) Shutdown(ctx context.Context) error {
+	log.Debug("Shutdown unit status handler")
+
+	instance.Lock()
+	instance.shuttingDown = true
+	instance.pendingDesiredStatus = nil
+	instance.Unlock()
+
+	instance.firmwareManager.Lock()
+	instance.firmwareManager.updateGates = append(
+		slices.Clone(instance.firmwareManager.updateGates), extension.UpdateGate(shutdownGate{}))
+	instance.firmwareManager.Unlock()
+
+	instance.softwareManager.Lock()
+	instance.softwareManager.updateGates = append(
+		slices.Clone(instance.softwareManager.updateGates), extension.UpdateGate(shutdownGate{}))
+	instance.softwareManager.Unlock()
+
+	for instance.GetFOTAStatus().State == cmserver.Updating || instance.GetSOTAStatus().State == cmserver.Updating {
+		select {
+		case <-ctx.Done():
+			return aoserrors.Errorf("update is not finished: %v", ctx.Err())
+
+		case <-time.After(shutdownPollPeriod):
+		}
+	}
+
+	return nil
+}
+
 // SendUnitStatus send unit status.
 func (instance *Instance) SendUnitStatus() error {
 	instance.Lock()
@@ -285,13 +445,41 @@ func (instance *Instance) ProcessDesiredStatus(desiredStatus cloudprotocol.Desir
 	instance.Lock()
 	defer instance.Unlock()
 
-	if err := instance.firmwareManager.processDesiredStatus(desiredStatus); err != nil {
-		log.Errorf("Error processing firmware desired status: %s", err)
+	if instance.shuttingDown {
+		log.Warn("Desired status ignored due to shutdown")
+
+		return
 	}
 
-	if err := instance.softwareManager.processDesiredStatus(desiredStatus); err != nil {
-		log.Errorf("Error processing software desired status: %s", err)
+	if instance.maintenanceMode.Enabled {
+		log.Warn("Desired status ignored due to maintenance mode")
+
+		return
 	}
+
+	instance.pendingDesiredStatus = nil
+
+	instance.processDesiredStatus(desiredStatus, logging.NewCorrelationID(), "")
+}
+
+// ProcessEmergencyUpdate applies authorized emergency update of single component bypassing regular FOTA update flow.
+// The function blocks till the update is finished, the update status is sent to the cloud.
+func (instance *Instance) ProcessEmergencyUpdate(update amqphandler.EmergencyUpdate) error {
+	instance.Lock()
+	shuttingDown := instance.shuttingDown
+	instance.Unlock()
+
+	if shuttingDown {
+		return ErrShuttingDown
+	}
+
+	return instance.emergencyUpdater.processUpdate(update)
+}
+
+// ProcessDecryptionInfoUpdate processes decryption info received from the cloud in response to decryption info
+// request.
+func (instance *Instance) ProcessDecryptionInfoUpdate(update amqphandler.DecryptionInfoUpdate) error {
+	return instance.decryptionInfoProvider.processUpdate(update)
 }
 
 // GetFOTAStatusChannel returns FOTA status channels.
@@ -326,6 +514,50 @@ func (instance *Instance) GetSOTAStatus() (status cmserver.UpdateSOTAStatus) {
 	return instance.softwareManager.getCurrentStatus()
 }
 
+// SetUpdateGates sets update gates checked before FOTA and SOTA update start. Maintenance mode gate is always checked.
+func (instance *Instance) SetUpdateGates(gates []extension.UpdateGate) {
+	gates = append(slices.Clone(gates), extension.UpdateGate(instance.maintenanceGate))
+
+	instance.firmwareManager.Lock()
+	instance.firmwareManager.updateGates = gates
+	instance.firmwareManager.Unlock()
+
+	instance.softwareManager.Lock()
+	instance.softwareManager.updateGates = gates
+	instance.softwareManager.Unlock()
+}
+
+// SetNodeCapabilitiesProvider sets provider of node capabilities announced in full unit status.
+func (instance *Instance) SetNodeCapabilitiesProvider(provider NodeCapabilitiesProvider) {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	instance.nodeCapabilitiesProvider = provider
+}
+
+// SetProviderNetworksProvider sets provider of provider networks reported in full unit status.
+func (instance *Instance) SetProviderNetworksProvider(provider ProviderNetworksProvider) {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	instance.networksProvider = provider
+}
+
+// FeatureFlagsChanged reports changed feature flags in full unit status. If deltaUnitStatus flag is disabled, full
+// unit status is sent on each status change instead of delta one.
+func (instance *Instance) FeatureFlagsChanged(flags map[string]bool) {
+	instance.statusMutex.Lock()
+
+	changed := !maps.Equal(instance.featureFlags, flags)
+	instance.featureFlags = flags
+
+	instance.statusMutex.Unlock()
+
+	if changed {
+		instance.sendCurrentStatus(false)
+	}
+}
+
 // StartFOTAUpdate triggers FOTA update.
 func (instance *Instance) StartFOTAUpdate() (err error) {
 	instance.Lock()
@@ -348,6 +580,8 @@ func (instance *Instance) CloudConnected() {
 	defer instance.statusMutex.Unlock()
 
 	instance.isConnected = true
+
+	go instance.sendOverrideBundleReports()
 }
 
 // CloudDisconnected indicates unit disconnected from cloud.
@@ -358,10 +592,57 @@ func (instance *Instance) CloudDisconnected() {
 	instance.isConnected = false
 }
 
+// TelemetryProfileChanged indicates telemetry profile is changed.
+func (instance *Instance) TelemetryProfileChanged(profile amqphandler.TelemetryProfile) {
+	instance.statusMutex.Lock()
+
+	if instance.mainNodeAttrs == nil {
+		instance.mainNodeAttrs = make(map[string]interface{})
+	}
+
+	instance.mainNodeAttrs[NodeAttrTelemetryProfile] = profile.Name
+
+	instance.statusMutex.Unlock()
+
+	// node info is requested from unit manager, do not block the caller
+	go instance.updateMainNodeInfo()
+}
+
+// ConfigReloaded applies reloaded config.
+func (instance *Instance) ConfigReloaded(cfg *config.Config) {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	if instance.sendStatusPeriod != cfg.UnitStatusSendTimeout.Duration {
+		log.WithField("sendTimeout", cfg.UnitStatusSendTimeout).Info("Unit status send timeout changed")
+	}
+
+	instance.sendStatusPeriod = cfg.UnitStatusSendTimeout.Duration
+	instance.features = getFeatures(cfg)
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
 
+func (instance *Instance) processDesiredStatus(
+	desiredStatus cloudprotocol.DesiredStatus, correlationID, campaignID string,
+) {
+	log.WithFields(log.Fields{
+		logging.CorrelationIDField: correlationID, logging.CampaignIDField: campaignID,
+	}).Debug("Process desired status")
+
+	if err := instance.firmwareManager.processDesiredStatus(desiredStatus, correlationID, campaignID); err != nil {
+		log.Errorf("Error processing firmware desired status: %s", err)
+	}
+
+	if err := instance.softwareManager.processDesiredStatus(desiredStatus, correlationID, campaignID); err != nil {
+		log.Errorf("Error processing software desired status: %s", err)
+	}
+
+	instance.addCampaignDesiredStatus(campaignID, correlationID)
+}
+
 func (instance *Instance) resetUnitStatus() {
 	instance.unitStatus = cloudprotocol.UnitStatus{
 		MessageType:  cloudprotocol.UnitStatusMessageType,
@@ -549,6 +830,8 @@ func (instance *Instance) setComponentStatus(status cloudprotocol.ComponentStatu
 		"error":   status.ErrorInfo,
 	}).Debug("Set component status")
 
+	instance.applyComponentBootStatus(&status)
+
 	index := slices.IndexFunc(instance.unitStatus.Components, func(componentStatus cloudprotocol.ComponentStatus) bool {
 		return componentStatus.ComponentID == status.ComponentID && componentStatus.Version == status.Version
 	})
@@ -567,6 +850,10 @@ func (instance *Instance) setComponentStatus(status cloudprotocol.ComponentStatu
 	return false
 }
 
+func (instance *Instance) refreshDecryptionInfo(correlationID string, items []decryptionInfoItem) error {
+	return instance.decryptionInfoProvider.refreshDecryptionInfo(correlationID, items)
+}
+
 func (instance *Instance) updateComponentStatus(status cloudprotocol.ComponentStatus) bool {
 	if instance.setComponentStatus(status) {
 		instance.statusChanged()
@@ -701,6 +988,17 @@ func (instance *Instance) setNodeInfo(nodeInfo cloudprotocol.NodeInfo) bool {
 	instance.statusMutex.Lock()
 	defer instance.statusMutex.Unlock()
 
+	if nodeInfo.IsMainNode() && len(instance.mainNodeAttrs) != 0 {
+		attrs := make(map[string]interface{}, len(nodeInfo.Attrs)+len(instance.mainNodeAttrs))
+
+		maps.Copy(attrs, nodeInfo.Attrs)
+		maps.Copy(attrs, instance.mainNodeAttrs)
+
+		nodeInfo.Attrs = attrs
+	}
+
+	instance.applyNodeBootStatus(&nodeInfo)
+
 	log.WithFields(log.Fields{
 		"nodeID":   nodeInfo.NodeID,
 		"nodeType": nodeInfo.NodeType,
@@ -735,6 +1033,20 @@ func (instance *Instance) updateNodeInfo(nodeInfo cloudprotocol.NodeInfo) bool {
 	return false
 }
 
+func (instance *Instance) updateMainNodeInfo() {
+	nodesInfo, err := instance.getAllNodesInfo()
+	if err != nil {
+		log.Errorf("Can't get nodes info: %v", err)
+		return
+	}
+
+	for _, nodeInfo := range nodesInfo {
+		if nodeInfo.IsMainNode() {
+			instance.updateNodeInfo(nodeInfo)
+		}
+	}
+}
+
 func (instance *Instance) setSubjects(subjects []string) {
 	instance.statusMutex.Lock()
 	defer instance.statusMutex.Unlock()
@@ -746,7 +1058,9 @@ func (instance *Instance) setSubjects(subjects []string) {
 
 func (instance *Instance) updateSubjects(subjects []string) {
 	instance.setSubjects(subjects)
-	instance.statusChanged()
+
+	// Subjects change starts or stops instances, so it is sent to the cloud without waiting for the status period.
+	instance.sendCurrentStatus(true)
 }
 
 func (instance *Instance) statusChanged() {
@@ -763,6 +1077,18 @@ func (instance *Instance) statusChanged() {
 }
 
 func (instance *Instance) sendCurrentStatus(deltaStatus bool) {
+	var wipedServices []amqphandler.ServiceWipeStatus
+
+	if deltaStatus && !instance.isDeltaUnitStatusEnabled() {
+		deltaStatus = false
+	}
+
+	// Software manager status lock is taken before the instance one on status update, so wiped services are got
+	// before locking the instance status.
+	if !deltaStatus {
+		wipedServices = instance.softwareManager.getWipedServices()
+	}
+
 	instance.statusMutex.Lock()
 	defer instance.statusMutex.Unlock()
 
@@ -784,8 +1110,20 @@ func (instance *Instance) sendCurrentStatus(deltaStatus bool) {
 	}
 
 	if !deltaStatus {
-		if err := instance.statusSender.SendUnitStatus(
-			instance.unitStatus); err != nil && !errors.Is(err, amqphandler.ErrNotConnected) {
+		unitStatus := amqphandler.UnitStatus{
+			UnitStatus:    instance.unitStatus,
+			PlacementHash: getPlacementHash(instance.unitStatus.Instances),
+			Capabilities:  instance.getUnitCapabilities(),
+			Maintenance:   instance.maintenanceStatus,
+			WipedServices: wipedServices,
+			FeatureFlags:  instance.featureFlags,
+		}
+
+		if instance.networksProvider != nil {
+			unitStatus.Networks = instance.networksProvider.GetProviderNetworksStatus()
+		}
+
+		if err := instance.statusSender.SendUnitStatus(unitStatus); err != nil && !errors.Is(err, amqphandler.ErrNotConnected) {
 			log.Errorf("Can't send unit status: %s", err)
 		}
 
@@ -801,6 +1139,29 @@ func (instance *Instance) sendCurrentStatus(deltaStatus bool) {
 	}
 }
 
+func (instance *Instance) isDeltaUnitStatusEnabled() bool {
+	instance.statusMutex.Lock()
+	defer instance.statusMutex.Unlock()
+
+	return instance.featureFlags[config.FeatureDeltaUnitStatus]
+}
+
+// getPlacementHash returns hash of instances placement on nodes. The hash doesn't depend on instances order.
+func getPlacementHash(instances []cloudprotocol.InstanceStatus) string {
+	placement := make([]string, 0, len(instances))
+
+	for _, instance := range instances {
+		placement = append(placement, fmt.Sprintf("%s/%s/%d:%s",
+			instance.ServiceID, instance.SubjectID, instance.Instance, instance.NodeID))
+	}
+
+	slices.Sort(placement)
+
+	hash := sha256.Sum256([]byte(strings.Join(placement, "\n")))
+
+	return hex.EncodeToString(hash[:])
+}
+
 func (instance *Instance) getAllNodesInfo() ([]cloudprotocol.NodeInfo, error) {
 	nodeIDs, err := instance.unitManager.GetAllNodeIDs()
 	if err != nil {
@@ -854,7 +1215,7 @@ func (instance *Instance) handleChannels() {
 				return
 			}
 
-			if instance.updateNodeInfo(nodeInfo) {
+			if instance.updateNodeInfo(nodeInfo) && !instance.isMaintenanceMode() {
 				if err := instance.softwareManager.requestRebalancing(); err != nil {
 					log.Errorf("Can't perform rebalancing: %v", err)
 				}
@@ -876,7 +1237,7 @@ func (instance *Instance) handleChannels() {
 				continue
 			}
 
-			if slices.Contains([]string{"cpu", "ram"}, systemQuotaAlert.Parameter) {
+			if slices.Contains([]string{"cpu", "ram"}, systemQuotaAlert.Parameter) && !instance.isMaintenanceMode() {
 				if err := instance.softwareManager.requestRebalancing(); err != nil {
 					log.Errorf("Can't perform rebalancing: %v", err)
 				}
@@ -884,3 +1245,7 @@ func (instance *Instance) handleChannels() {
 		}
 	}
 }
+
+func (gate shutdownGate) CheckUpdate(updateType string) error {
+	return aoserrors.New("CM is shutting down")
+}
@@ -19,9 +19,23 @@ package unitstatushandler
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,7 +46,10 @@ import (
 
 	"github.com/aosedge/aos_communicationmanager/amqphandler"
 	"github.com/aosedge/aos_communicationmanager/cmserver"
+	"github.com/aosedge/aos_communicationmanager/config"
 	"github.com/aosedge/aos_communicationmanager/downloader"
+	"github.com/aosedge/aos_communicationmanager/extension"
+	"github.com/aosedge/aos_communicationmanager/fcrypt"
 )
 
 /***********************************************************************************************************************
@@ -59,8 +76,23 @@ type TestUnitManager struct {
 }
 
 type TestSender struct {
-	Consumer      amqphandler.ConnectionEventsConsumer
-	statusChannel chan cloudprotocol.UnitStatus
+	Consumer          amqphandler.ConnectionEventsConsumer
+	TelemetryConsumer amqphandler.TelemetryProfileConsumer
+	statusChannel     chan cloudprotocol.UnitStatus
+	reportChannel     chan amqphandler.DesiredStatusReport
+	emergencyChannel  chan amqphandler.EmergencyUpdateStatus
+	rejectionChannel  chan amqphandler.DesiredStatusRejection
+	decryptionChannel chan amqphandler.DecryptionInfoRequest
+	overrideChannel   chan amqphandler.OverrideBundleReport
+	campaignChannel   chan amqphandler.CampaignSummary
+	featureFlags      map[string]bool
+}
+
+type testNodeCapabilitiesProvider struct{}
+
+type TestSchedulingFreezer struct {
+	sync.Mutex
+	frozen bool
 }
 
 type TestUnitConfigUpdater struct {
@@ -78,10 +110,13 @@ type TestFirmwareUpdater struct {
 }
 
 type TestSoftwareUpdater struct {
-	AllServices      []ServiceStatus
-	AllLayers        []LayerStatus
-	RevertedServices []string
-	UpdateError      error
+	sync.Mutex
+
+	AllServices       []ServiceStatus
+	AllLayers         []LayerStatus
+	InstalledServices []string
+	RevertedServices  []string
+	UpdateError       error
 }
 
 type TestInstanceRunner struct {
@@ -93,6 +128,8 @@ type TestSystemQuotaAlertProvider struct {
 }
 
 type TestDownloader struct {
+	sync.Mutex
+
 	DownloadTime   time.Duration
 	DownloadedURLs []string
 
@@ -116,9 +153,39 @@ type testGroupDownloader struct {
 
 type testStatusHandler struct{}
 
+type testLinkSpeedProvider struct {
+	linkSpeed uint64
+}
+
+type testSignVerifier struct {
+	err error
+}
+
+type testUpdateGate struct {
+	sync.Mutex
+	err error
+}
+
+type testServiceWiper struct {
+	name  string
+	err   error
+	wipes *[]string
+}
+
 type TestStorage struct {
-	sotaState json.RawMessage
-	fotaState json.RawMessage
+	sync.Mutex
+	sotaState        json.RawMessage
+	fotaState        json.RawMessage
+	maintenanceState json.RawMessage
+	updateHistory    []UpdateHistoryRecord
+	overrideReports  []overrideBundleReport
+	campaigns        map[string]json.RawMessage
+	emergencyUpdates map[string]time.Time
+}
+
+type overrideBundleReport struct {
+	bundleID string
+	report   json.RawMessage
 }
 
 /***********************************************************************************************************************
@@ -857,7 +924,7 @@ func TestFirmwareManager(t *testing.T) {
 		// Create firmware manager
 
 		firmwareManager, err := newFirmwareManager(newTestStatusHandler(), firmwareDownloader, firmwareUpdater,
-			testStorage, 30*time.Second)
+			testStorage, 30*time.Second, nil)
 		if err != nil {
 			t.Errorf("Can't create firmware manager: %s", err)
 			continue
@@ -874,7 +941,7 @@ func TestFirmwareManager(t *testing.T) {
 		// Process desired status
 
 		if item.desiredStatus != nil {
-			if err = firmwareManager.processDesiredStatus(*item.desiredStatus); err != nil {
+			if err = firmwareManager.processDesiredStatus(*item.desiredStatus, "", ""); err != nil {
 				t.Errorf("Process desired status failed: %s", err)
 				goto closeFM
 			}
@@ -1308,7 +1375,7 @@ func TestSoftwareManager(t *testing.T) {
 		// Create software manager
 
 		softwareManager, err := newSoftwareManager(newTestStatusHandler(), softwareDownloader, unitManager,
-			unitConfigUpdater, softwareUpdater, instanceRunner, testStorage, 30*time.Second)
+			unitConfigUpdater, softwareUpdater, instanceRunner, testStorage, 30*time.Second, nil)
 		if err != nil {
 			t.Errorf("Can't create software manager: %s", err)
 			continue
@@ -1331,7 +1398,7 @@ func TestSoftwareManager(t *testing.T) {
 		// Process desired status
 
 		if item.desiredStatus != nil {
-			if err = softwareManager.processDesiredStatus(*item.desiredStatus); err != nil {
+			if err = softwareManager.processDesiredStatus(*item.desiredStatus, "", ""); err != nil {
 				t.Errorf("Process desired status failed: %s", err)
 				goto closeSM
 			}
@@ -1397,6 +1464,245 @@ func TestSoftwareManager(t *testing.T) {
 	}
 }
 
+func TestSoftwareUpdateResume(t *testing.T) {
+	type testData struct {
+		testID            string
+		checkpoint        *updateCheckpoint
+		installedServices []string
+		runInstances      bool
+	}
+
+	updateServices := []cloudprotocol.ServiceInfo{
+		{ServiceID: "service1", Version: "1.0.0"},
+		{ServiceID: "service2", Version: "2.0.0"},
+	}
+
+	runInstances := []cloudprotocol.InstanceInfo{
+		{ServiceID: "service1", SubjectID: "subject1", NumInstances: 1},
+		{ServiceID: "service2", SubjectID: "subject1", NumInstances: 1},
+	}
+
+	data := []testData{
+		{
+			testID: "resume installing services",
+			checkpoint: &updateCheckpoint{
+				Steps: []string{stepUpdateNodes, stepRemoveServices, stepInstallLayers, stepRestoreServices},
+				Items: map[string][]string{stepInstallServices: {"service1"}},
+			},
+			installedServices: []string{"service2"},
+			runInstances:      true,
+		},
+		{
+			testID: "resume after run instances",
+			checkpoint: &updateCheckpoint{
+				Steps: []string{
+					stepUpdateNodes, stepRemoveServices, stepInstallLayers, stepRestoreServices, stepInstallServices,
+					stepRemoveLayers, stepRestoreLayers, stepRunInstances,
+				},
+				Items: map[string][]string{stepInstallServices: {"service1", "service2"}},
+			},
+		},
+		{
+			testID:            "no checkpoint",
+			installedServices: []string{"service1", "service2"},
+			runInstances:      true,
+		},
+	}
+
+	unitManager := NewTestUnitManager(nil, nil)
+	unitConfigUpdater := NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{})
+	softwareDownloader := newTestGroupDownloader()
+	testStorage := NewTestStorage()
+
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
+
+		softwareUpdater := NewTestSoftwareUpdater(nil, nil)
+		instanceRunner := NewTestInstanceRunner()
+
+		initState := &softwareManager{
+			CurrentState: stateUpdating,
+			CurrentUpdate: &softwareUpdate{
+				InstallServices: updateServices,
+				RunInstances:    runInstances,
+			},
+			DownloadResult: map[string]*downloadResult{
+				updateServices[0].ServiceID: {}, updateServices[1].ServiceID: {},
+			},
+			Checkpoint: item.checkpoint,
+		}
+
+		if err := testStorage.saveSoftwareState(initState); err != nil {
+			t.Fatalf("Can't save init state: %v", err)
+		}
+
+		softwareManager, err := newSoftwareManager(newTestStatusHandler(), softwareDownloader, unitManager,
+			unitConfigUpdater, softwareUpdater, instanceRunner, testStorage, 30*time.Second, nil)
+		if err != nil {
+			t.Fatalf("Can't create software manager: %v", err)
+		}
+
+		// Process initial run status
+
+		instanceStatuses := []cloudprotocol.InstanceStatus{
+			{
+				InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"},
+				Status:        cloudprotocol.InstanceStateActive,
+			},
+			{
+				InstanceIdent: aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"},
+				Status:        cloudprotocol.InstanceStateActive,
+			},
+		}
+
+		if softwareManager.processRunStatus(instanceStatuses) {
+			t.Error("Revert should not be required")
+		}
+
+		if item.runInstances {
+			if _, err := instanceRunner.WaitForRunInstance(time.Second); err != nil {
+				t.Errorf("Wait run instances error: %v", err)
+			}
+
+			if softwareManager.processRunStatus(instanceStatuses) {
+				t.Error("Revert should not be required")
+			}
+		}
+
+		if err = waitForSOTAUpdateStatus(
+			softwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.NoUpdate}); err != nil {
+			t.Errorf("Wait for update status error: %v", err)
+		}
+
+		if !item.runInstances {
+			if _, err := instanceRunner.WaitForRunInstance(100 * time.Millisecond); err == nil {
+				t.Error("Run instances should not be requested")
+			}
+		}
+
+		softwareUpdater.Lock()
+		installedServices := softwareUpdater.InstalledServices
+		softwareUpdater.Unlock()
+
+		slices.Sort(installedServices)
+
+		if !slices.Equal(installedServices, item.installedServices) {
+			t.Errorf("Wrong installed services: %v", installedServices)
+		}
+
+		softwareManager.Lock()
+
+		if softwareManager.Checkpoint != nil {
+			t.Error("Checkpoint should be cleared after update")
+		}
+
+		softwareManager.Unlock()
+
+		if err = softwareManager.close(); err != nil {
+			t.Errorf("Error closing software manager: %v", err)
+		}
+	}
+}
+
+func TestUpdateGates(t *testing.T) {
+	gate := &testUpdateGate{err: aoserrors.New("vehicle is moving")}
+	testStorage := NewTestStorage()
+
+	initState := &softwareManager{
+		CurrentState: stateReadyToUpdate,
+		CurrentUpdate: &softwareUpdate{
+			Schedule: cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate},
+		},
+	}
+
+	if err := testStorage.saveSoftwareState(initState); err != nil {
+		t.Fatalf("Can't save init state: %v", err)
+	}
+
+	softwareManager, err := newSoftwareManager(newTestStatusHandler(), newTestGroupDownloader(),
+		NewTestUnitManager(nil, nil), NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+		NewTestSoftwareUpdater(nil, nil), NewTestInstanceRunner(), testStorage, 30*time.Second, nil)
+	if err != nil {
+		t.Fatalf("Can't create software manager: %v", err)
+	}
+	defer softwareManager.close()
+
+	softwareManager.updateGates = []extension.UpdateGate{gate}
+
+	if err = softwareManager.startUpdate(); !errors.Is(err, errUpdatePostponed) {
+		t.Errorf("Update postponed error expected: %v", err)
+	}
+
+	gate.Lock()
+	gate.err = nil
+	gate.Unlock()
+
+	if err = softwareManager.startUpdate(); err != nil {
+		t.Errorf("Start update failed: %v", err)
+	}
+
+	if err = waitForSOTAUpdateStatus(
+		softwareManager.statusChannel, cmserver.UpdateStatus{State: cmserver.Updating}); err != nil {
+		t.Errorf("Wait for update status error: %v", err)
+	}
+}
+
+func TestSecureWipe(t *testing.T) {
+	softwareManager, err := newSoftwareManager(newTestStatusHandler(), newTestGroupDownloader(),
+		NewTestUnitManager(nil, nil), NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+		NewTestSoftwareUpdater(nil, nil), NewTestInstanceRunner(), NewTestStorage(), 30*time.Second, nil)
+	if err != nil {
+		t.Fatalf("Can't create software manager: %v", err)
+	}
+	defer softwareManager.close()
+
+	var wipes []string
+
+	softwareManager.serviceWipers = []ServiceWiper{
+		&testServiceWiper{name: "launcher", wipes: &wipes},
+		&testServiceWiper{name: "imagemanager", wipes: &wipes},
+	}
+
+	if err = softwareManager.wipeService(
+		cloudprotocol.ServiceStatus{ServiceID: "service1", Version: "1.0.0"}); err != nil {
+		t.Fatalf("Can't wipe service: %v", err)
+	}
+
+	if expectedWipes := []string{"launcher:service1", "imagemanager:service1"}; !reflect.DeepEqual(
+		wipes, expectedWipes) {
+		t.Errorf("Wrong wipes: %v, expected: %v", wipes, expectedWipes)
+	}
+
+	wipedServices := softwareManager.getWipedServices()
+
+	if len(wipedServices) != 1 || wipedServices[0].ServiceID != "service1" || wipedServices[0].Version != "1.0.0" ||
+		wipedServices[0].WipedAt.IsZero() {
+		t.Errorf("Wrong wiped services: %v", wipedServices)
+	}
+
+	// Failed wipe is not confirmed
+
+	wipes = nil
+
+	softwareManager.serviceWipers = []ServiceWiper{
+		&testServiceWiper{name: "launcher", err: aoserrors.New("wipe failed"), wipes: &wipes},
+		&testServiceWiper{name: "imagemanager", wipes: &wipes},
+	}
+
+	if err = softwareManager.wipeService(
+		cloudprotocol.ServiceStatus{ServiceID: "service2", Version: "1.0.0"}); err == nil {
+		t.Error("Error expected")
+	}
+
+	if expectedWipes := []string{"launcher:service2"}; !reflect.DeepEqual(wipes, expectedWipes) {
+		t.Errorf("Wrong wipes: %v, expected: %v", wipes, expectedWipes)
+	}
+
+	if wipedServices = softwareManager.getWipedServices(); len(wipedServices) != 1 {
+		t.Errorf("Wrong wiped services: %v", wipedServices)
+	}
+}
+
 func TestTimeTable(t *testing.T) {
 	type testData struct {
 		fromDate  time.Time
@@ -1661,135 +1967,1036 @@ func TestSyncExecutor(t *testing.T) {
 	}
 }
 
-/***********************************************************************************************************************
- * Interfaces
- **********************************************************************************************************************/
+func TestPlacementHash(t *testing.T) {
+	instances := []cloudprotocol.InstanceStatus{
+		{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject1"}, NodeID: "node1"},
+		{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subject2"}, NodeID: "node2"},
+		{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service2", SubjectID: "subject1"}, NodeID: "node1"},
+	}
 
-/***********************************************************************************************************************
- * TestUnitManager
- **********************************************************************************************************************/
+	hash := getPlacementHash(instances)
 
-func NewTestUnitManager(nodesInfo []cloudprotocol.NodeInfo, subjects []string) *TestUnitManager {
-	nodesInfoMap := make(map[string]*cloudprotocol.NodeInfo)
+	reordered := []cloudprotocol.InstanceStatus{instances[2], instances[0], instances[1]}
+	reordered[0].Status = cloudprotocol.InstanceStateFailed
 
-	for _, nodeInfo := range nodesInfo {
-		nodesInfoMap[nodeInfo.NodeID] = &cloudprotocol.NodeInfo{}
-		*nodesInfoMap[nodeInfo.NodeID] = nodeInfo
+	if reorderedHash := getPlacementHash(reordered); reorderedHash != hash {
+		t.Errorf("Wrong placement hash of reordered instances: %s", reorderedHash)
 	}
 
-	return &TestUnitManager{
-		nodesInfo:       nodesInfoMap,
-		currentSubjects: subjects,
+	moved := slices.Clone(instances)
+	moved[0].NodeID = "node2"
+
+	if movedHash := getPlacementHash(moved); movedHash == hash {
+		t.Error("Placement hash of moved instance should be changed")
 	}
 }
 
-func (manager *TestUnitManager) GetAllNodeIDs() ([]string, error) {
-	nodeIDs := make([]string, 0, len(manager.nodesInfo))
+func TestUnitCapabilities(t *testing.T) {
+	instance := &Instance{
+		features: getFeatures(&config.Config{EmergencyUpdate: config.EmergencyUpdate{Enabled: true}}),
+		unitStatus: cloudprotocol.UnitStatus{Nodes: []cloudprotocol.NodeInfo{
+			{NodeID: "node1", Status: cloudprotocol.NodeStatusProvisioned},
+			{NodeID: "node2", Status: cloudprotocol.NodeStatusUnprovisioned},
+		}},
+		nodeCapabilitiesProvider: &testNodeCapabilitiesProvider{},
+	}
 
-	for nodeID := range manager.nodesInfo {
-		nodeIDs = append(nodeIDs, nodeID)
+	capabilities := instance.getUnitCapabilities()
+
+	if !reflect.DeepEqual(capabilities.Transports, []string{"http", "https"}) {
+		t.Errorf("Wrong transports: %v", capabilities.Transports)
 	}
 
-	return nodeIDs, nil
-}
+	if !capabilities.Features[FeatureEmergencyUpdate] || capabilities.Features[FeatureUpdateWindowAdmission] {
+		t.Errorf("Wrong features: %v", capabilities.Features)
+	}
 
-func (manager *TestUnitManager) GetNodeInfo(nodeID string) (cloudprotocol.NodeInfo, error) {
-	nodeInfo, ok := manager.nodesInfo[nodeID]
-	if !ok {
-		return cloudprotocol.NodeInfo{}, aoserrors.New("node not found")
+	expectedNodes := []amqphandler.NodeCapabilities{
+		{NodeID: "node1", Runners: []amqphandler.RunnerCapability{{Name: "runc"}}},
 	}
 
-	return *nodeInfo, nil
+	if !reflect.DeepEqual(capabilities.Nodes, expectedNodes) {
+		t.Errorf("Wrong node capabilities: %v", capabilities.Nodes)
+	}
 }
 
-func (manager *TestUnitManager) SubscribeNodeInfoChange() <-chan cloudprotocol.NodeInfo {
-	manager.nodeInfoChannel = make(chan cloudprotocol.NodeInfo, 1)
+func TestBootStatus(t *testing.T) {
+	nodeID := "node1"
+	instance := &Instance{}
 
-	return manager.nodeInfoChannel
-}
+	instance.resetUnitStatus()
 
-func (manager *TestUnitManager) NodeInfoChanged(nodeInfo cloudprotocol.NodeInfo) {
-	if _, ok := manager.nodesInfo[nodeInfo.NodeID]; !ok {
-		manager.nodesInfo[nodeInfo.NodeID] = &cloudprotocol.NodeInfo{}
-	}
+	instance.setComponentStatus(cloudprotocol.ComponentStatus{
+		ComponentID: "rootfs", Version: "1.0.0", NodeID: &nodeID, Annotations: json.RawMessage(`{"type":"full"}`),
+	})
 
-	*manager.nodesInfo[nodeInfo.NodeID] = nodeInfo
+	instance.setNodeInfo(cloudprotocol.NodeInfo{NodeID: nodeID, Attrs: map[string]interface{}{
+		NodeAttrBootSlot: "b", NodeAttrBootSlotDefault: "a", NodeAttrSecureBoot: SecureBootDisabled,
+	}})
+	instance.setNodeInfo(cloudprotocol.NodeInfo{NodeID: "node2", Attrs: map[string]interface{}{"MainNode": ""}})
 
-	if manager.nodeInfoChannel != nil {
-		manager.nodeInfoChannel <- nodeInfo
+	expectedStatus := BootStatus{Slot: "b", DefaultSlot: "a", Fallback: true, SecureBoot: SecureBootDisabled}
+
+	if bootStatus := instance.unitStatus.Nodes[0].Attrs[NodeAttrBootStatus]; bootStatus != expectedStatus {
+		t.Errorf("Wrong node boot status: %v", bootStatus)
 	}
-}
 
-func (manager *TestUnitManager) GetAllNodesInfo() []cloudprotocol.NodeInfo {
-	nodesInfo := make([]cloudprotocol.NodeInfo, 0, len(manager.nodesInfo))
+	if _, ok := instance.unitStatus.Nodes[1].Attrs[NodeAttrBootStatus]; ok {
+		t.Error("Unexpected node boot status")
+	}
 
-	for _, nodeInfo := range manager.nodesInfo {
-		nodesInfo = append(nodesInfo, *nodeInfo)
+	var annotations struct {
+		Type       string     `json:"type"`
+		BootStatus BootStatus `json:"bootStatus"`
 	}
 
-	return nodesInfo
-}
+	if err := json.Unmarshal(instance.unitStatus.Components[0].Annotations, &annotations); err != nil {
+		t.Fatalf("Can't parse component annotations: %v", err)
+	}
 
-func (manager *TestUnitManager) PauseNode(nodeID string) error {
-	if _, ok := manager.nodesInfo[nodeID]; !ok {
-		return aoserrors.New("node not found")
+	if annotations.Type != "full" || annotations.BootStatus != expectedStatus {
+		t.Errorf("Wrong component annotations: %s", string(instance.unitStatus.Components[0].Annotations))
 	}
 
-	manager.nodesInfo[nodeID].Status = cloudprotocol.NodeStatusPaused
+	// Components updated after node info get boot status as well
 
-	return nil
+	instance.setComponentStatus(cloudprotocol.ComponentStatus{ComponentID: "boot", Version: "2.0.0", NodeID: &nodeID})
+
+	annotations.BootStatus = BootStatus{}
+
+	if err := json.Unmarshal(instance.unitStatus.Components[1].Annotations, &annotations); err != nil {
+		t.Fatalf("Can't parse component annotations: %v", err)
+	}
+
+	if annotations.BootStatus != expectedStatus {
+		t.Errorf("Wrong component annotations: %s", string(instance.unitStatus.Components[1].Annotations))
+	}
 }
 
-func (manager *TestUnitManager) ResumeNode(nodeID string) error {
-	if _, ok := manager.nodesInfo[nodeID]; !ok {
-		return aoserrors.New("node not found")
+func TestUpdateWindow(t *testing.T) {
+	type testData struct {
+		testID    string
+		config    config.UpdateWindow
+		linkSpeed uint64
+		schedule  cloudprotocol.ScheduleRule
+		items     []updateItem
+		err       error
 	}
 
-	manager.nodesInfo[nodeID].Status = cloudprotocol.NodeStatusProvisioned
+	// Monday 10:00, window till 12:00
+	fromDate := time.Date(1, 1, 1, 10, 0, 0, 0, time.Local)
+	timetableSchedule := cloudprotocol.ScheduleRule{
+		Type: cloudprotocol.TimetableUpdate,
+		Timetable: []cloudprotocol.TimetableEntry{
+			{
+				DayOfWeek: 1, TimeSlots: []cloudprotocol.TimeSlot{
+					{
+						Start: aostypes.Time{Time: time.Date(0, 1, 1, 9, 0, 0, 0, time.Local)},
+						End:   aostypes.Time{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.Local)},
+					},
+				},
+			},
+		},
+	}
 
-	return nil
-}
+	remainingTime, err := getRemainingTimetableTime(fromDate, timetableSchedule.Timetable)
+	if err != nil {
+		t.Fatalf("Can't get remaining timetable time: %v", err)
+	}
 
-func (manager *TestUnitManager) GetUnitSubjects() (subjects []string, err error) {
-	return manager.currentSubjects, nil
-}
+	if remainingTime != 2*time.Hour {
+		t.Errorf("Wrong remaining timetable time: %v", remainingTime)
+	}
 
-func (manager *TestUnitManager) SubscribeUnitSubjectsChanged() <-chan []string {
-	manager.subjectsChannel = make(chan []string, 1)
+	data := []testData{
+		{
+			testID: "fits window",
+			config: config.UpdateWindow{
+				Admission: true, DefaultLinkSpeed: 1 << 20, DefaultInstallTime: aostypes.Duration{Duration: time.Minute},
+			},
+			schedule: timetableSchedule,
+			items:    []updateItem{{id: "item1", size: 1 << 30}, {id: "item2", size: 1 << 30}},
+		},
+		{
+			testID: "exceeds window at default link speed",
+			config: config.UpdateWindow{
+				Admission: true, DefaultLinkSpeed: 1 << 20, DefaultInstallTime: aostypes.Duration{Duration: time.Minute},
+			},
+			schedule: timetableSchedule,
+			items:    []updateItem{{id: "item1", size: 8 << 30}},
+			err:      errWindowTooShort,
+		},
+		{
+			testID: "fits window at measured link speed",
+			config: config.UpdateWindow{
+				Admission: true, DefaultLinkSpeed: 1 << 20, DefaultInstallTime: aostypes.Duration{Duration: time.Minute},
+			},
+			linkSpeed: 10 << 20,
+			schedule:  timetableSchedule,
+			items:     []updateItem{{id: "item1", size: 8 << 30}},
+		},
+		{
+			testID: "downloaded items",
+			config: config.UpdateWindow{
+				Admission: true, DefaultLinkSpeed: 1 << 20, DefaultInstallTime: aostypes.Duration{Duration: time.Minute},
+			},
+			schedule: timetableSchedule,
+			items:    []updateItem{{id: "item1", size: 8 << 30, downloaded: true}},
+		},
+		{
+			testID: "exceeds window by install time",
+			config: config.UpdateWindow{
+				Admission: true, DefaultLinkSpeed: 1 << 20,
+				DefaultInstallTime: aostypes.Duration{Duration: 90 * time.Minute},
+			},
+			schedule: timetableSchedule,
+			items:    []updateItem{{id: "item1", downloaded: true}, {id: "item2", downloaded: true}},
+			err:      errWindowTooShort,
+		},
+		{
+			testID:   "admission disabled",
+			config:   config.UpdateWindow{DefaultLinkSpeed: 1 << 20},
+			schedule: timetableSchedule,
+			items:    []updateItem{{id: "item1", size: 8 << 30}},
+		},
+		{
+			testID:   "not timetable schedule",
+			config:   config.UpdateWindow{Admission: true, DefaultLinkSpeed: 1 << 20},
+			schedule: cloudprotocol.ScheduleRule{Type: cloudprotocol.TriggerUpdate},
+			items:    []updateItem{{id: "item1", size: 8 << 30}},
+		},
+	}
 
-	return manager.subjectsChannel
-}
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
 
-func (manager *TestUnitManager) SubjectsChanged(subjects []string) {
-	manager.currentSubjects = subjects
+		estimator := newUpdateEstimator(item.config, &testLinkSpeedProvider{linkSpeed: item.linkSpeed}, nil)
 
-	if manager.subjectsChannel != nil {
-		manager.subjectsChannel <- subjects
+		if err := estimator.checkUpdateWindow(fromDate, item.schedule, item.items); !errors.Is(err, item.err) {
+			t.Errorf("Wrong check update window error: %v", err)
+		}
 	}
-}
 
-/***********************************************************************************************************************
- * TestSender
- **********************************************************************************************************************/
+	var estimator *updateEstimator
 
-func NewTestSender() (sender *TestSender) {
-	return &TestSender{statusChannel: make(chan cloudprotocol.UnitStatus, 1)}
+	if err := estimator.checkUpdateWindow(fromDate, timetableSchedule, nil); err != nil {
+		t.Errorf("Wrong check update window error: %v", err)
+	}
 }
 
-func (sender *TestSender) SendUnitStatus(unitStatus cloudprotocol.UnitStatus) (err error) {
-	sender.statusChannel <- unitStatus
+func TestDecryptionKeyRotation(t *testing.T) {
+	sender := NewTestSender()
 
-	return nil
-}
+	provider := newDecryptionInfoProvider(sender, time.Second)
+	defer provider.close()
 
-func (sender *TestSender) SendDeltaUnitStatus(deltaUnitStatus cloudprotocol.DeltaUnitStatus) (err error) {
-	sender.statusChannel <- cloudprotocol.UnitStatus(deltaUnitStatus)
+	serviceItem := amqphandler.DecryptionItem{ID: "service1", Type: amqphandler.DownloadTypeService, Version: "1.0.0"}
+	oldDecryptionInfo := cloudprotocol.DecryptionInfo{BlockAlg: "AES256/CBC/pkcs7", BlockKey: []byte{1}}
+	newDecryptionInfo := cloudprotocol.DecryptionInfo{BlockAlg: "AES256/CBC/pkcs7", BlockKey: []byte{2}}
 
-	return nil
-}
+	// Retry install with refreshed decryption info
 
-func (sender *TestSender) WaitForStatus(timeout time.Duration) (status cloudprotocol.UnitStatus, err error) {
-	select {
+	decryptionInfo := oldDecryptionInfo
+	installCount := 0
+
+	install := func() error {
+		installCount++
+
+		if !reflect.DeepEqual(decryptionInfo, newDecryptionInfo) {
+			return aoserrors.Errorf("can't install: %w", fcrypt.ErrDecryptionKeyRotated)
+		}
+
+		return nil
+	}
+
+	go func() {
+		request, err := sender.WaitForDecryptionInfoRequest(time.Second)
+		if err != nil {
+			t.Errorf("Can't receive decryption info request: %v", err)
+			return
+		}
+
+		if request.CorrelationID != "correlation1" ||
+			!reflect.DeepEqual(request.Items, []amqphandler.DecryptionItem{serviceItem}) {
+			t.Errorf("Wrong decryption info request: %v", request)
+		}
+
+		if err := provider.processUpdate(amqphandler.DecryptionInfoUpdate{
+			RequestID: request.RequestID,
+			Items: []amqphandler.ItemDecryptionInfo{
+				{DecryptionItem: serviceItem, DecryptionInfo: newDecryptionInfo},
+			},
+		}); err != nil {
+			t.Errorf("Can't process decryption info update: %v", err)
+		}
+	}()
+
+	if err := installWithKeyRotation(provider, "correlation1",
+		[]decryptionInfoItem{{DecryptionItem: serviceItem, decryptionInfo: &decryptionInfo}}, install); err != nil {
+		t.Errorf("Can't install item: %v", err)
+	}
+
+	if installCount != 2 {
+		t.Errorf("Wrong install count: %d", installCount)
+	}
+
+	// Decryption info is not received
+
+	decryptionInfo = oldDecryptionInfo
+	installCount = 0
+
+	if err := installWithKeyRotation(provider, "correlation1",
+		[]decryptionInfoItem{{DecryptionItem: serviceItem, decryptionInfo: &decryptionInfo}},
+		install); !errors.Is(err, fcrypt.ErrDecryptionKeyRotated) {
+		t.Errorf("Key rotated error expected: %v", err)
+	}
+
+	if _, err := sender.WaitForDecryptionInfoRequest(time.Second); err != nil {
+		t.Errorf("Can't receive decryption info request: %v", err)
+	}
+
+	if installCount != 1 {
+		t.Errorf("Wrong install count: %d", installCount)
+	}
+
+	// Other errors are not retried
+
+	installCount = 0
+
+	if err := installWithKeyRotation(provider, "correlation1", nil, func() error {
+		installCount++
+
+		return aoserrors.New("install error")
+	}); err == nil {
+		t.Error("Install error expected")
+	}
+
+	if installCount != 1 {
+		t.Errorf("Wrong install count: %d", installCount)
+	}
+
+	if err := provider.processUpdate(amqphandler.DecryptionInfoUpdate{RequestID: "unknown"}); err == nil {
+		t.Error("Error expected for unknown request")
+	}
+}
+
+func TestEmergencyUpdate(t *testing.T) {
+	type testData struct {
+		testID       string
+		config       config.EmergencyUpdate
+		verifyErr    error
+		signCert     []byte
+		updateID     string
+		validUntil   time.Time
+		version      string
+		downloadErr  error
+		statuses     []string
+		downloadURLs []string
+	}
+
+	const signerEKU = "1.3.6.1.4.1.55555.1.1"
+
+	validUntil := time.Now().Add(time.Hour)
+	updateConfig := config.EmergencyUpdate{Enabled: true, IgnoreTimetable: true, SignerEKU: signerEKU}
+
+	signerCert := createTestSignCert(t, signerEKU)
+	otherCert := createTestSignCert(t, "1.3.6.1.4.1.55555.1.2")
+
+	data := []testData{
+		{
+			testID:     "disabled",
+			signCert:   signerCert,
+			updateID:   "update1",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses:   []string{cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:     "authorization failed",
+			config:     updateConfig,
+			verifyErr:  aoserrors.New("wrong sign"),
+			signCert:   signerCert,
+			updateID:   "update1",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses:   []string{cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:     "wrong signer",
+			config:     updateConfig,
+			signCert:   otherCert,
+			updateID:   "update1",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses:   []string{cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:     "expired",
+			config:     updateConfig,
+			signCert:   signerCert,
+			updateID:   "update1",
+			validUntil: time.Now().Add(-time.Hour),
+			version:    "2.0.0",
+			statuses:   []string{cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:     "already installed",
+			config:     updateConfig,
+			signCert:   signerCert,
+			updateID:   "update1",
+			validUntil: validUntil,
+			version:    "1.0.0",
+			statuses:   []string{cloudprotocol.InstalledStatus},
+		},
+		{
+			testID:     "success",
+			config:     updateConfig,
+			signCert:   signerCert,
+			updateID:   "update2",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses: []string{
+				cloudprotocol.DownloadingStatus, cloudprotocol.InstallingStatus, cloudprotocol.InstalledStatus,
+			},
+			downloadURLs: []string{"http://emergency/component1"},
+		},
+		{
+			testID:     "replay",
+			config:     updateConfig,
+			signCert:   signerCert,
+			updateID:   "update2",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses:   []string{cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:      "download failed",
+			config:      updateConfig,
+			signCert:    signerCert,
+			updateID:    "update3",
+			validUntil:  validUntil,
+			version:     "2.0.0",
+			downloadErr: aoserrors.New("download failed"),
+			statuses:    []string{cloudprotocol.DownloadingStatus, cloudprotocol.ErrorStatus},
+		},
+		{
+			testID:     "retry after download failure",
+			config:     updateConfig,
+			signCert:   signerCert,
+			updateID:   "update3",
+			validUntil: validUntil,
+			version:    "2.0.0",
+			statuses: []string{
+				cloudprotocol.DownloadingStatus, cloudprotocol.InstallingStatus, cloudprotocol.InstalledStatus,
+			},
+			downloadURLs: []string{"http://emergency/component1"},
+		},
+	}
+
+	componentID := "component1"
+	storage := NewTestStorage()
+
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
+
+		firmwareUpdater := NewTestFirmwareUpdater([]cloudprotocol.ComponentStatus{
+			{
+				ComponentID: componentID, ComponentType: "type1", Version: "1.0.0",
+				Status: cloudprotocol.InstalledStatus,
+			},
+		})
+		firmwareUpdater.UpdateComponentsInfo = []cloudprotocol.ComponentStatus{
+			{
+				ComponentID: componentID, ComponentType: "type1", Version: item.version,
+				Status: cloudprotocol.InstalledStatus,
+			},
+		}
+
+		testDownloader := NewTestDownloader()
+		testDownloader.DownloadTime = 0
+
+		if item.downloadErr != nil {
+			testDownloader.errorURL = "http://emergency/component1"
+			testDownloader.downloadErr = item.downloadErr
+		}
+
+		sender := NewTestSender()
+
+		updater := newEmergencyUpdater(item.config, testDownloader, firmwareUpdater,
+			&testSignVerifier{err: item.verifyErr}, sender, newTestStatusHandler(), storage)
+
+		updateInfo, err := json.Marshal(amqphandler.EmergencyUpdateInfo{
+			UpdateID:   item.updateID,
+			ValidUntil: item.validUntil,
+			Component: cloudprotocol.ComponentInfo{
+				ComponentID: &componentID, ComponentType: "type1", Version: item.version,
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"http://emergency/component1"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Can't marshal update info: %v", err)
+		}
+
+		err = updater.processUpdate(amqphandler.EmergencyUpdate{
+			Update:        updateInfo,
+			CertChains:    []cloudprotocol.CertificateChain{{Name: "signer", Fingerprints: []string{"ab01"}}},
+			Certs:         []cloudprotocol.Certificate{{Fingerprint: "AB01", Certificate: item.signCert}},
+			Authorization: cloudprotocol.Signs{ChainName: "signer"},
+		})
+		if (err != nil) != (item.statuses[len(item.statuses)-1] == cloudprotocol.ErrorStatus) {
+			t.Errorf("Wrong process emergency update error: %v", err)
+		}
+
+		if statuses := sender.getEmergencyUpdateStatuses(); !slices.Equal(statuses, item.statuses) {
+			t.Errorf("Wrong emergency update statuses: %v", statuses)
+		}
+
+		if !slices.Equal(testDownloader.DownloadedURLs, item.downloadURLs) {
+			t.Errorf("Wrong downloaded URLs: %v", testDownloader.DownloadedURLs)
+		}
+
+		updater.close()
+	}
+}
+
+func TestOverrideBundle(t *testing.T) {
+	type testData struct {
+		testID     string
+		enabled    bool
+		verifyErr  error
+		validUntil time.Time
+		urls       []string
+		bundleErr  bool
+	}
+
+	validUntil := time.Now().Add(time.Hour)
+
+	data := []testData{
+		{testID: "disabled", validUntil: validUntil, urls: []string{"component1.bin"}, bundleErr: true},
+		{
+			testID: "authorization failed", enabled: true, verifyErr: aoserrors.New("wrong sign"),
+			validUntil: validUntil, urls: []string{"component1.bin"}, bundleErr: true,
+		},
+		{
+			testID: "expired", enabled: true, validUntil: time.Now().Add(-time.Hour),
+			urls: []string{"component1.bin"}, bundleErr: true,
+		},
+		{
+			testID: "artifact out of bundle", enabled: true, validUntil: validUntil,
+			urls: []string{"../component1.bin"}, bundleErr: true,
+		},
+		{testID: "success", enabled: true, validUntil: validUntil, urls: []string{"component1.bin"}},
+	}
+
+	bundleDir := filepath.Join(tmpDir, "media", "bundle")
+
+	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+		t.Fatalf("Can't create bundle dir: %v", err)
+	}
+
+	for _, item := range data {
+		t.Logf("Test item: %s", item.testID)
+
+		desiredStatus := cloudprotocol.DesiredStatus{
+			Components: []cloudprotocol.ComponentInfo{{
+				ComponentType: "type1", Version: "2.0.0",
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: item.urls},
+			}},
+		}
+
+		bundleInfo, err := json.Marshal(OverrideBundleInfo{
+			BundleID: "bundle1", ValidUntil: item.validUntil, DesiredStatus: desiredStatus,
+		})
+		if err != nil {
+			t.Fatalf("Can't marshal bundle info: %v", err)
+		}
+
+		manifest, err := json.Marshal(OverrideBundle{Bundle: bundleInfo})
+		if err != nil {
+			t.Fatalf("Can't marshal bundle manifest: %v", err)
+		}
+
+		if err = os.WriteFile(filepath.Join(bundleDir, OverrideBundleManifest), manifest, 0o600); err != nil {
+			t.Fatalf("Can't write bundle manifest: %v", err)
+		}
+
+		storage := NewTestStorage()
+		sender := NewTestSender()
+
+		statusHandler, err := New(&config.Config{
+			UnitStatusSendTimeout: aostypes.Duration{Duration: 3 * time.Second},
+			OverrideBundle: config.OverrideBundle{
+				Enabled: item.enabled, MediaDirs: []string{filepath.Join(tmpDir, "media")},
+			},
+		}, NewTestUnitManager(nil, nil), NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+			NewTestFirmwareUpdater(nil), NewTestSoftwareUpdater(nil, nil), NewTestInstanceRunner(),
+			NewTestDownloader(), storage, sender, NewTestSystemQuotaAlertProvider(),
+			&testSignVerifier{err: item.verifyErr})
+		if err != nil {
+			t.Fatalf("Can't create unit status handler: %v", err)
+		}
+
+		bundleID, err := statusHandler.ProcessOverrideBundle(bundleDir)
+		if (err != nil) != item.bundleErr {
+			t.Errorf("Wrong process override bundle error: %v", err)
+		}
+
+		if item.bundleErr {
+			statusHandler.Close()
+
+			continue
+		}
+
+		if bundleID != "bundle1" {
+			t.Errorf("Wrong bundle ID: %s", bundleID)
+		}
+
+		// Report is sent on cloud connection
+
+		sender.Consumer.CloudConnected()
+
+		report, err := sender.WaitForOverrideBundleReport(waitStatusTimeout)
+		if err != nil {
+			t.Fatalf("Can't receive override bundle report: %v", err)
+		}
+
+		if report.BundleID != "bundle1" || !reflect.DeepEqual(report.DesiredStatus, desiredStatus) {
+			t.Errorf("Wrong override bundle report: %v", report)
+		}
+
+		if reports, _ := storage.GetOverrideBundleReports(); len(reports) != 0 {
+			t.Errorf("Override bundle reports should be removed: %s", reports)
+		}
+
+		statusHandler.Close()
+	}
+
+	resolved, err := resolveBundleURLs(cloudprotocol.DesiredStatus{
+		Services: []cloudprotocol.ServiceInfo{{
+			ServiceID: "service1", DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"services/service1.tar"}},
+		}},
+	}, bundleDir)
+	if err != nil {
+		t.Fatalf("Can't resolve bundle URLs: %v", err)
+	}
+
+	if urls := resolved.Services[0].DownloadInfo.URLs; !slices.Equal(
+		urls, []string{"file://" + filepath.Join(bundleDir, "services/service1.tar")}) {
+		t.Errorf("Wrong resolved URLs: %v", urls)
+	}
+}
+
+func TestUpdateHistory(t *testing.T) {
+	history := newUpdateHistory(NewTestStorage())
+	estimator := newUpdateEstimator(config.UpdateWindow{
+		DefaultLinkSpeed: 1 << 20, DefaultInstallTime: aostypes.Duration{Duration: time.Minute},
+	}, &testLinkSpeedProvider{}, history)
+
+	items := []updateItem{{id: "item1", downloadID: "download1", size: 60 << 20}}
+
+	if updateTime := estimator.estimateUpdateTime(items); updateTime != 2*time.Minute {
+		t.Errorf("Wrong default update time: %v", updateTime)
+	}
+
+	estimator.learnDownloadTimes(items, map[string]*downloadResult{
+		"download1": {FileName: "file1", DownloadTime: 30 * time.Second},
+	})
+
+	for i := 0; i < updateHistorySize+1; i++ {
+		estimator.learnInstallTime(items[0], time.Duration(i)*time.Minute)
+	}
+
+	// Install time of the first record is dropped: (1+2+3+4+5)/5 = 3 minutes
+	if installTime, ok := history.getInstallTime("item1"); !ok || installTime != 3*time.Minute {
+		t.Errorf("Wrong learned install time: %v", installTime)
+	}
+
+	if updateTime := estimator.estimateUpdateTime(items); updateTime != 3*time.Minute+30*time.Second {
+		t.Errorf("Wrong learned update time: %v", updateTime)
+	}
+
+	items[0].downloaded = true
+
+	if updateTime := estimator.estimateUpdateTime(items); updateTime != 3*time.Minute {
+		t.Errorf("Wrong learned update time: %v", updateTime)
+	}
+
+	items[0].installed = true
+
+	if updateTime := estimator.estimateUpdateTime(items); updateTime != 0 {
+		t.Errorf("Wrong learned update time: %v", updateTime)
+	}
+
+	// Short downloads and not learned items use default values
+	estimator.learnDownloadTimes([]updateItem{{id: "item2", downloadID: "download2", size: 60 << 20}},
+		map[string]*downloadResult{"download2": {FileName: "file2", DownloadTime: time.Millisecond}})
+
+	if _, ok := history.getDownloadSpeed("item2"); ok {
+		t.Error("Short download should not be learned")
+	}
+
+	if eta := estimator.getETA([]updateItem{{id: "item2", size: 60 << 20}}); eta == nil ||
+		eta.Before(time.Now().Add(2*time.Minute-time.Second)) {
+		t.Errorf("Wrong ETA: %v", eta)
+	}
+}
+
+func TestCampaign(t *testing.T) {
+	storage := NewTestStorage()
+	sender := NewTestSender()
+
+	instance := &Instance{storage: storage, statusSender: sender}
+
+	if err := instance.loadCampaigns(); err != nil {
+		t.Fatalf("Can't load campaigns: %v", err)
+	}
+
+	instance.updateCampaign("campaign1", "FOTA", "correlation1", stateDownloading, nil)
+	instance.updateCampaign("campaign1", "SOTA", "correlation2", stateDownloading, nil)
+	instance.addCampaignDesiredStatus("campaign1", "correlation2")
+
+	if _, err := sender.WaitForCampaignSummary(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't wait campaign summary: %v", err)
+	}
+
+	summary, err := sender.WaitForCampaignSummary(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't wait campaign summary: %v", err)
+	}
+
+	if summary.State != amqphandler.CampaignStateInProgress ||
+		!reflect.DeepEqual(summary.DesiredStatuses, []string{"correlation1", "correlation2"}) {
+		t.Errorf("Wrong campaign summary: %v", summary)
+	}
+
+	if _, err = sender.WaitForCampaignSummary(100 * time.Millisecond); err == nil {
+		t.Error("Unexpected campaign summary for known desired status")
+	}
+
+	instance.updateCampaign("campaign1", "FOTA", "correlation1", stateNoUpdate, nil)
+
+	if summary, err = sender.WaitForCampaignSummary(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't wait campaign summary: %v", err)
+	}
+
+	if summary.State != amqphandler.CampaignStateInProgress {
+		t.Errorf("Wrong campaign state: %s", summary.State)
+	}
+
+	instance.updateCampaign("campaign1", "SOTA", "correlation2", stateNoUpdate,
+		&cloudprotocol.ErrorInfo{Message: "install failed"})
+
+	if summary, err = sender.WaitForCampaignSummary(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't wait campaign summary: %v", err)
+	}
+
+	if summary.State != amqphandler.CampaignStateFailed {
+		t.Errorf("Wrong campaign state: %s", summary.State)
+	}
+
+	// Stored summaries are restored and the oldest finished campaigns are removed
+
+	for i := range maxCampaignSummaries {
+		instance.updateCampaign(fmt.Sprintf("campaign%d", i+2), "SOTA", "correlation", stateNoUpdate, nil)
+	}
+
+	restored := &Instance{storage: storage, statusSender: sender}
+
+	if err = restored.loadCampaigns(); err != nil {
+		t.Fatalf("Can't load campaigns: %v", err)
+	}
+
+	if len(restored.campaigns) != maxCampaignSummaries {
+		t.Errorf("Wrong campaigns count: %d", len(restored.campaigns))
+	}
+
+	if _, ok := restored.campaigns["campaign1"]; ok {
+		t.Error("Oldest campaign should be removed")
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+/***********************************************************************************************************************
+ * TestUnitManager
+ **********************************************************************************************************************/
+
+func NewTestUnitManager(nodesInfo []cloudprotocol.NodeInfo, subjects []string) *TestUnitManager {
+	nodesInfoMap := make(map[string]*cloudprotocol.NodeInfo)
+
+	for _, nodeInfo := range nodesInfo {
+		nodesInfoMap[nodeInfo.NodeID] = &cloudprotocol.NodeInfo{}
+		*nodesInfoMap[nodeInfo.NodeID] = nodeInfo
+	}
+
+	return &TestUnitManager{
+		nodesInfo:       nodesInfoMap,
+		currentSubjects: subjects,
+	}
+}
+
+func (manager *TestUnitManager) GetAllNodeIDs() ([]string, error) {
+	nodeIDs := make([]string, 0, len(manager.nodesInfo))
+
+	for nodeID := range manager.nodesInfo {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	return nodeIDs, nil
+}
+
+func (manager *TestUnitManager) GetNodeInfo(nodeID string) (cloudprotocol.NodeInfo, error) {
+	nodeInfo, ok := manager.nodesInfo[nodeID]
+	if !ok {
+		return cloudprotocol.NodeInfo{}, aoserrors.New("node not found")
+	}
+
+	return *nodeInfo, nil
+}
+
+func (manager *TestUnitManager) SubscribeNodeInfoChange() <-chan cloudprotocol.NodeInfo {
+	manager.nodeInfoChannel = make(chan cloudprotocol.NodeInfo, 1)
+
+	return manager.nodeInfoChannel
+}
+
+func (manager *TestUnitManager) NodeInfoChanged(nodeInfo cloudprotocol.NodeInfo) {
+	if _, ok := manager.nodesInfo[nodeInfo.NodeID]; !ok {
+		manager.nodesInfo[nodeInfo.NodeID] = &cloudprotocol.NodeInfo{}
+	}
+
+	*manager.nodesInfo[nodeInfo.NodeID] = nodeInfo
+
+	if manager.nodeInfoChannel != nil {
+		manager.nodeInfoChannel <- nodeInfo
+	}
+}
+
+func (manager *TestUnitManager) GetAllNodesInfo() []cloudprotocol.NodeInfo {
+	nodesInfo := make([]cloudprotocol.NodeInfo, 0, len(manager.nodesInfo))
+
+	for _, nodeInfo := range manager.nodesInfo {
+		nodesInfo = append(nodesInfo, *nodeInfo)
+	}
+
+	return nodesInfo
+}
+
+func (manager *TestUnitManager) PauseNode(nodeID string) error {
+	if _, ok := manager.nodesInfo[nodeID]; !ok {
+		return aoserrors.New("node not found")
+	}
+
+	manager.nodesInfo[nodeID].Status = cloudprotocol.NodeStatusPaused
+
+	return nil
+}
+
+func (manager *TestUnitManager) ResumeNode(nodeID string) error {
+	if _, ok := manager.nodesInfo[nodeID]; !ok {
+		return aoserrors.New("node not found")
+	}
+
+	manager.nodesInfo[nodeID].Status = cloudprotocol.NodeStatusProvisioned
+
+	return nil
+}
+
+func (manager *TestUnitManager) GetUnitSubjects() (subjects []string, err error) {
+	return manager.currentSubjects, nil
+}
+
+func (manager *TestUnitManager) SubscribeUnitSubjectsChanged() <-chan []string {
+	manager.subjectsChannel = make(chan []string, 1)
+
+	return manager.subjectsChannel
+}
+
+func (manager *TestUnitManager) SubjectsChanged(subjects []string) {
+	manager.currentSubjects = subjects
+
+	if manager.subjectsChannel != nil {
+		manager.subjectsChannel <- subjects
+	}
+}
+
+/***********************************************************************************************************************
+ * TestSender
+ **********************************************************************************************************************/
+
+func NewTestSender() (sender *TestSender) {
+	return &TestSender{
+		statusChannel:     make(chan cloudprotocol.UnitStatus, 1),
+		reportChannel:     make(chan amqphandler.DesiredStatusReport, 1),
+		emergencyChannel:  make(chan amqphandler.EmergencyUpdateStatus, 8),
+		rejectionChannel:  make(chan amqphandler.DesiredStatusRejection, 1),
+		decryptionChannel: make(chan amqphandler.DecryptionInfoRequest, 1),
+		overrideChannel:   make(chan amqphandler.OverrideBundleReport, 1),
+		campaignChannel:   make(chan amqphandler.CampaignSummary, 16),
+	}
+}
+
+func (provider *testNodeCapabilitiesProvider) GetNodeCapabilities(
+	nodeInfo cloudprotocol.NodeInfo,
+) amqphandler.NodeCapabilities {
+	return amqphandler.NodeCapabilities{
+		NodeID: nodeInfo.NodeID, Runners: []amqphandler.RunnerCapability{{Name: "runc"}},
+	}
+}
+
+/***********************************************************************************************************************
+ * TestSchedulingFreezer
+ **********************************************************************************************************************/
+
+func NewTestSchedulingFreezer() *TestSchedulingFreezer {
+	return &TestSchedulingFreezer{}
+}
+
+func (freezer *TestSchedulingFreezer) SetSchedulingFrozen(frozen bool) error {
+	freezer.Lock()
+	defer freezer.Unlock()
+
+	freezer.frozen = frozen
+
+	return nil
+}
+
+func (freezer *TestSchedulingFreezer) IsFrozen() bool {
+	freezer.Lock()
+	defer freezer.Unlock()
+
+	return freezer.frozen
+}
+
+func (sender *TestSender) SendUnitStatus(unitStatus amqphandler.UnitStatus) (err error) {
+	sender.featureFlags = unitStatus.FeatureFlags
+	sender.statusChannel <- unitStatus.UnitStatus
+
+	return nil
+}
+
+func (sender *TestSender) SendDeltaUnitStatus(deltaUnitStatus cloudprotocol.DeltaUnitStatus) (err error) {
+	sender.statusChannel <- cloudprotocol.UnitStatus(deltaUnitStatus)
+
+	return nil
+}
+
+func (sender *TestSender) SendDesiredStatusReport(report amqphandler.DesiredStatusReport) error {
+	sender.reportChannel <- report
+
+	return nil
+}
+
+func (sender *TestSender) WaitForDesiredStatusReport(
+	timeout time.Duration,
+) (report amqphandler.DesiredStatusReport, err error) {
+	select {
+	case report = <-sender.reportChannel:
+		return report, nil
+
+	case <-time.After(timeout):
+		return report, aoserrors.New("receive desired status report timeout")
+	}
+}
+
+func (sender *TestSender) SendDesiredStatusRejection(rejection amqphandler.DesiredStatusRejection) error {
+	sender.rejectionChannel <- rejection
+
+	return nil
+}
+
+func (sender *TestSender) WaitForDesiredStatusRejection(
+	timeout time.Duration,
+) (rejection amqphandler.DesiredStatusRejection, err error) {
+	select {
+	case rejection = <-sender.rejectionChannel:
+		return rejection, nil
+
+	case <-time.After(timeout):
+		return rejection, aoserrors.New("receive desired status rejection timeout")
+	}
+}
+
+func (sender *TestSender) SendDecryptionInfoRequest(request amqphandler.DecryptionInfoRequest) error {
+	sender.decryptionChannel <- request
+
+	return nil
+}
+
+func (sender *TestSender) WaitForDecryptionInfoRequest(
+	timeout time.Duration,
+) (request amqphandler.DecryptionInfoRequest, err error) {
+	select {
+	case request = <-sender.decryptionChannel:
+		return request, nil
+
+	case <-time.After(timeout):
+		return request, aoserrors.New("receive decryption info request timeout")
+	}
+}
+
+func (sender *TestSender) SendEmergencyUpdateStatus(status amqphandler.EmergencyUpdateStatus) error {
+	sender.emergencyChannel <- status
+
+	return nil
+}
+
+func (sender *TestSender) SendOverrideBundleReport(report amqphandler.OverrideBundleReport) error {
+	sender.overrideChannel <- report
+
+	return nil
+}
+
+func (sender *TestSender) WaitForOverrideBundleReport(
+	timeout time.Duration,
+) (report amqphandler.OverrideBundleReport, err error) {
+	select {
+	case report = <-sender.overrideChannel:
+		return report, nil
+
+	case <-time.After(timeout):
+		return report, aoserrors.New("receive override bundle report timeout")
+	}
+}
+
+func (sender *TestSender) SendCampaignSummary(summary amqphandler.CampaignSummary) error {
+	select {
+	case sender.campaignChannel <- summary:
+
+	default:
+	}
+
+	return nil
+}
+
+func (sender *TestSender) WaitForCampaignSummary(
+	timeout time.Duration,
+) (summary amqphandler.CampaignSummary, err error) {
+	select {
+	case summary = <-sender.campaignChannel:
+		return summary, nil
+
+	case <-time.After(timeout):
+		return summary, aoserrors.New("receive campaign summary timeout")
+	}
+}
+
+func (sender *TestSender) getEmergencyUpdateStatuses() (statuses []string) {
+	for {
+		select {
+		case status := <-sender.emergencyChannel:
+			statuses = append(statuses, status.Status)
+
+		default:
+			return statuses
+		}
+	}
+}
+
+func (sender *TestSender) WaitForStatus(timeout time.Duration) (status cloudprotocol.UnitStatus, err error) {
+	select {
 	case receivedUnitStatus := <-sender.statusChannel:
 		return receivedUnitStatus, nil
 
@@ -1798,12 +3005,23 @@ func (sender *TestSender) WaitForStatus(timeout time.Duration) (status cloudprot
 	}
 }
 
+// GetFeatureFlags returns feature flags of the last full unit status.
+func (sender *TestSender) GetFeatureFlags() map[string]bool {
+	return sender.featureFlags
+}
+
 func (sender *TestSender) SubscribeForConnectionEvents(consumer amqphandler.ConnectionEventsConsumer) error {
 	sender.Consumer = consumer
 
 	return nil
 }
 
+func (sender *TestSender) SubscribeForTelemetryProfileChanges(consumer amqphandler.TelemetryProfileConsumer) error {
+	sender.TelemetryConsumer = consumer
+
+	return nil
+}
+
 /***********************************************************************************************************************
  * TestUnitConfigUpdater
  **********************************************************************************************************************/
@@ -1874,6 +3092,11 @@ func (updater *TestSoftwareUpdater) GetLayersStatus() ([]LayerStatus, error) {
 func (updater *TestSoftwareUpdater) InstallService(serviceInfo cloudprotocol.ServiceInfo,
 	chains []cloudprotocol.CertificateChain, certs []cloudprotocol.Certificate,
 ) error {
+	updater.Lock()
+	defer updater.Unlock()
+
+	updater.InstalledServices = append(updater.InstalledServices, serviceInfo.ServiceID)
+
 	return updater.UpdateError
 }
 
@@ -1975,7 +3198,9 @@ func (testDownloader *TestDownloader) Download(
 		}
 
 		if downloadErr == nil {
+			testDownloader.Lock()
 			testDownloader.DownloadedURLs = append(testDownloader.DownloadedURLs, packageInfo.URLs[0])
+			testDownloader.Unlock()
 		}
 	}
 
@@ -2067,6 +3292,16 @@ func (downloader *testGroupDownloader) download(
 	}
 }
 
+func (verifier *testSignVerifier) VerifyDataSign(data []byte, chains []cloudprotocol.CertificateChain,
+	certs []cloudprotocol.Certificate, sign cloudprotocol.Signs,
+) error {
+	return verifier.err
+}
+
+func (provider *testLinkSpeedProvider) getLinkSpeed() uint64 {
+	return provider.linkSpeed
+}
+
 func (downloader *testGroupDownloader) releaseDownloadedFirmware() error {
 	downloader.fotaReleased = true
 
@@ -2087,6 +3322,15 @@ func newTestStatusHandler() *testStatusHandler {
 	return &testStatusHandler{}
 }
 
+func (statusHandler *testStatusHandler) refreshDecryptionInfo(correlationID string, items []decryptionInfoItem) error {
+	return aoserrors.New("decryption info not available")
+}
+
+func (statusHandler *testStatusHandler) updateCampaign(
+	campaignID, updateType, correlationID, state string, errorInfo *cloudprotocol.ErrorInfo,
+) {
+}
+
 func (statusHandler *testStatusHandler) updateComponentStatus(status cloudprotocol.ComponentStatus) bool {
 	log.WithFields(log.Fields{
 		"id":      status.ComponentID,
@@ -2148,6 +3392,27 @@ func (statusHandler *testStatusHandler) getNodesStatus() ([]cloudprotocol.NodeSt
 	return []cloudprotocol.NodeStatus{}, nil
 }
 
+/***********************************************************************************************************************
+ * testUpdateGate
+ **********************************************************************************************************************/
+
+func (gate *testUpdateGate) CheckUpdate(updateType string) error {
+	gate.Lock()
+	defer gate.Unlock()
+
+	return gate.err
+}
+
+/***********************************************************************************************************************
+ * testServiceWiper
+ **********************************************************************************************************************/
+
+func (wiper *testServiceWiper) WipeService(serviceID string) error {
+	*wiper.wipes = append(*wiper.wipes, wiper.name+":"+serviceID)
+
+	return wiper.err
+}
+
 /***********************************************************************************************************************
  * testStorage
  **********************************************************************************************************************/
@@ -2174,6 +3439,149 @@ func (storage *TestStorage) GetSoftwareUpdateState() (state json.RawMessage, err
 	return storage.sotaState, nil
 }
 
+func (storage *TestStorage) SetMaintenanceMode(state json.RawMessage) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.maintenanceState = state
+
+	return nil
+}
+
+func (storage *TestStorage) GetMaintenanceMode() (state json.RawMessage, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	return storage.maintenanceState, nil
+}
+
+func (storage *TestStorage) AddUpdateHistoryRecord(record UpdateHistoryRecord) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.updateHistory = append(storage.updateHistory, record)
+
+	return nil
+}
+
+func (storage *TestStorage) GetUpdateHistoryRecords(
+	id, step string, limit int,
+) (records []UpdateHistoryRecord, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for i := len(storage.updateHistory) - 1; i >= 0 && len(records) < limit; i-- {
+		if record := storage.updateHistory[i]; record.ID == id && record.Step == step {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func (storage *TestStorage) RemoveOutdatedUpdateHistoryRecords(id, step string, maxRecords int) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	count := 0
+
+	for i := len(storage.updateHistory) - 1; i >= 0; i-- {
+		if record := storage.updateHistory[i]; record.ID == id && record.Step == step {
+			if count++; count > maxRecords {
+				storage.updateHistory = slices.Delete(storage.updateHistory, i, i+1)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (storage *TestStorage) AddOverrideBundleReport(bundleID string, report json.RawMessage) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.overrideReports = append(storage.overrideReports, overrideBundleReport{bundleID: bundleID, report: report})
+
+	return nil
+}
+
+func (storage *TestStorage) GetOverrideBundleReports() (reports []json.RawMessage, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, item := range storage.overrideReports {
+		reports = append(reports, item.report)
+	}
+
+	return reports, nil
+}
+
+func (storage *TestStorage) RemoveOverrideBundleReport(bundleID string) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.overrideReports = slices.DeleteFunc(storage.overrideReports, func(item overrideBundleReport) bool {
+		return item.bundleID == bundleID
+	})
+
+	return nil
+}
+
+func (storage *TestStorage) SetCampaignSummary(campaignID string, summary json.RawMessage) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.campaigns == nil {
+		storage.campaigns = make(map[string]json.RawMessage)
+	}
+
+	storage.campaigns[campaignID] = summary
+
+	return nil
+}
+
+func (storage *TestStorage) GetCampaignSummaries() (summaries []json.RawMessage, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	for _, summary := range storage.campaigns {
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (storage *TestStorage) RemoveCampaignSummary(campaignID string) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	delete(storage.campaigns, campaignID)
+
+	return nil
+}
+
+func (storage *TestStorage) AddEmergencyUpdateID(updateID string, validUntil time.Time) error {
+	storage.Lock()
+	defer storage.Unlock()
+
+	if storage.emergencyUpdates == nil {
+		storage.emergencyUpdates = make(map[string]time.Time)
+	}
+
+	storage.emergencyUpdates[updateID] = validUntil
+
+	return nil
+}
+
+func (storage *TestStorage) IsEmergencyUpdateIDUsed(updateID string) (used bool, err error) {
+	storage.Lock()
+	defer storage.Unlock()
+
+	_, used = storage.emergencyUpdates[updateID]
+
+	return used, nil
+}
+
 func (storage *TestStorage) saveFirmwareState(state *firmwareManager) (err error) {
 	if state == nil {
 		storage.fotaState = nil
@@ -2299,3 +3707,38 @@ func convertToComponentID(id string) *string {
 func convertToDownloadID(component cloudprotocol.ComponentInfo) string {
 	return component.ComponentType + ":" + component.Version
 }
+
+func createTestSignCert(t *testing.T, keyUsage string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Can't generate key: %v", err)
+	}
+
+	var usage asn1.ObjectIdentifier
+
+	for _, item := range strings.Split(keyUsage, ".") {
+		value, err := strconv.Atoi(item)
+		if err != nil {
+			t.Fatalf("Can't parse key usage: %v", err)
+		}
+
+		usage = append(usage, value)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "signer"},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{usage},
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Can't create certificate: %v", err)
+	}
+
+	return cert
+}
@@ -18,6 +18,8 @@
 package unitstatushandler_test
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -26,7 +28,10 @@ import (
 	"github.com/aosedge/aos_common/aostypes"
 	"github.com/aosedge/aos_common/api/cloudprotocol"
 
+	"github.com/aosedge/aos_communicationmanager/amqphandler"
+	"github.com/aosedge/aos_communicationmanager/cmserver"
 	"github.com/aosedge/aos_communicationmanager/config"
+	"github.com/aosedge/aos_communicationmanager/logging"
 	"github.com/aosedge/aos_communicationmanager/unitstatushandler"
 )
 
@@ -115,7 +120,7 @@ func TestSendInitialStatus(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, initialSubjects),
 		unitConfigUpdater, fotaUpdater, sotaUpdater, instanceRunner,
 		unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -163,7 +168,7 @@ func TestUpdateUnitConfig(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, fotaUpdater, sotaUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -258,7 +263,7 @@ func TestUpdateComponents(t *testing.T) {
 
 	statusHandler, err := unitstatushandler.New(cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater, instanceRunner, unitstatushandler.NewTestDownloader(),
-		unitstatushandler.NewTestStorage(), sender, unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestStorage(), sender, unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -351,7 +356,7 @@ func TestUpdateLayers(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -516,7 +521,7 @@ func TestUpdateServices(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -678,7 +683,7 @@ func TestRunInstances(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -801,7 +806,7 @@ func TestRevertServices(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -916,7 +921,7 @@ func TestUpdateInstancesStatus(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -1030,7 +1035,7 @@ func TestUpdateCachedSOTA(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, downloader, unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %s", err)
 	}
@@ -1154,7 +1159,7 @@ func TestNewComponents(t *testing.T) {
 		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
 		unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -1231,7 +1236,7 @@ func TestNodeInfoChanged(t *testing.T) {
 	statusHandler, err := unitstatushandler.New(
 		cfg, nodeInfoProvider, unitConfigUpdater, firmwareUpdater, softwareUpdater,
 		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
-		unitstatushandler.NewTestSystemQuotaAlertProvider())
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -1286,6 +1291,66 @@ func TestNodeInfoChanged(t *testing.T) {
 	}
 }
 
+func TestTelemetryProfileChanged(t *testing.T) {
+	unitConfigUpdater := unitstatushandler.NewTestUnitConfigUpdater(
+		cloudprotocol.UnitConfigStatus{Version: "1.0.0", Status: cloudprotocol.InstalledStatus})
+	sender := unitstatushandler.NewTestSender()
+	nodeInfoProvider := unitstatushandler.NewTestUnitManager([]cloudprotocol.NodeInfo{
+		{
+			NodeID: "node1", NodeType: "type1", Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs: map[string]interface{}{cloudprotocol.NodeAttrMainNode: ""},
+		},
+		{NodeID: "node2", NodeType: "type2", Status: cloudprotocol.NodeStatusProvisioned},
+	},
+		nil)
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, nodeInfoProvider, unitConfigUpdater, unitstatushandler.NewTestFirmwareUpdater(nil),
+		unitstatushandler.NewTestSoftwareUpdater(nil, nil), unitstatushandler.NewTestInstanceRunner(),
+		unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer statusHandler.Close()
+
+	sender.Consumer.CloudConnected()
+
+	go handleUpdateStatus(statusHandler)
+
+	if err := statusHandler.ProcessRunStatus(nil); err != nil {
+		t.Fatalf("Can't process run status: %v", err)
+	}
+
+	if _, err := sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	sender.TelemetryConsumer.TelemetryProfileChanged(amqphandler.TelemetryProfile{
+		Name: amqphandler.TelemetryProfileReduced, RateFactor: 4,
+	})
+
+	receivedUnitStatus, err := sender.WaitForStatus(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	expectedUnitStatus := cloudprotocol.UnitStatus{
+		Nodes: []cloudprotocol.NodeInfo{{
+			NodeID: "node1", NodeType: "type1", Status: cloudprotocol.NodeStatusProvisioned,
+			Attrs: map[string]interface{}{
+				cloudprotocol.NodeAttrMainNode:             "",
+				unitstatushandler.NodeAttrTelemetryProfile: amqphandler.TelemetryProfileReduced,
+			},
+		}},
+		IsDeltaInfo: true,
+	}
+
+	if err = compareUnitStatus(receivedUnitStatus, expectedUnitStatus); err != nil {
+		t.Errorf("Wrong unit status received: %v, expected: %v", receivedUnitStatus, expectedUnitStatus)
+	}
+}
+
 func TestSubjectsChanged(t *testing.T) {
 	initialSubjects := []string{"initial1", "initial2"}
 
@@ -1298,7 +1363,7 @@ func TestSubjectsChanged(t *testing.T) {
 		cfg, unitManager, unitConfigUpdater,
 		unitstatushandler.NewTestFirmwareUpdater(nil), unitstatushandler.NewTestSoftwareUpdater(nil, nil),
 		unitstatushandler.NewTestInstanceRunner(), unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(),
-		sender, unitstatushandler.NewTestSystemQuotaAlertProvider())
+		sender, unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
 	if err != nil {
 		t.Fatalf("Can't create unit status handler: %v", err)
 	}
@@ -1330,7 +1395,7 @@ func TestSubjectsChanged(t *testing.T) {
 
 	unitManager.SubjectsChanged(newSubjects)
 
-	receivedUnitStatus, err = sender.WaitForStatus(waitStatusTimeout)
+	receivedUnitStatus, err = sender.WaitForStatus(cfg.UnitStatusSendTimeout.Duration / 2)
 	if err != nil {
 		t.Fatalf("Can't receive unit status: %v", err)
 	}
@@ -1345,6 +1410,447 @@ func TestSubjectsChanged(t *testing.T) {
 	}
 }
 
+func TestDesiredStatusReport(t *testing.T) {
+	unitConfigUpdater := unitstatushandler.NewTestUnitConfigUpdater(
+		cloudprotocol.UnitConfigStatus{Version: "1.0.0", Status: cloudprotocol.InstalledStatus})
+	firmwareUpdater := unitstatushandler.NewTestFirmwareUpdater([]cloudprotocol.ComponentStatus{
+		{ComponentID: "comp0", ComponentType: "type0", Version: "1.0.0", Status: cloudprotocol.InstalledStatus},
+	})
+	softwareUpdater := unitstatushandler.NewTestSoftwareUpdater([]unitstatushandler.ServiceStatus{
+		{ServiceStatus: cloudprotocol.ServiceStatus{
+			ServiceID: "service0", Version: "1.0.0", Status: cloudprotocol.InstalledStatus,
+		}},
+		{ServiceStatus: cloudprotocol.ServiceStatus{
+			ServiceID: "service1", Version: "1.0.0", Status: cloudprotocol.InstalledStatus,
+		}},
+	}, []unitstatushandler.LayerStatus{
+		{LayerStatus: cloudprotocol.LayerStatus{
+			LayerID: "layer0", Digest: "digest0", Version: "1.0.0", Status: cloudprotocol.InstalledStatus,
+		}},
+	})
+	instanceRunner := unitstatushandler.NewTestInstanceRunner()
+	sender := unitstatushandler.NewTestSender()
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, unitstatushandler.NewTestUnitManager([]cloudprotocol.NodeInfo{
+			{NodeID: "node1", Status: cloudprotocol.NodeStatusProvisioned},
+			{NodeID: "node2", Status: cloudprotocol.NodeStatusProvisioned},
+		}, nil),
+		unitConfigUpdater, firmwareUpdater, softwareUpdater,
+		instanceRunner, unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer statusHandler.Close()
+
+	sender.Consumer.CloudConnected()
+
+	go handleUpdateStatus(statusHandler)
+
+	if err := statusHandler.ProcessRunStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service0", SubjectID: "subj1"},
+			NodeID:        "node1",
+		},
+		{
+			InstanceIdent: aostypes.InstanceIdent{ServiceID: "service1", SubjectID: "subj1"},
+			NodeID:        "node2",
+		},
+	}); err != nil {
+		t.Fatalf("Can't process run status: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	desiredStatus := cloudprotocol.DesiredStatus{
+		UnitConfig: &cloudprotocol.UnitConfig{Version: "2.0.0"},
+		Nodes:      []cloudprotocol.NodeStatus{{NodeID: "node1", Status: cloudprotocol.NodeStatusPaused}},
+		Components: []cloudprotocol.ComponentInfo{
+			{
+				ComponentID: convertToComponentID("comp0"), ComponentType: "type0", Version: "2.0.0",
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"comp0"}, Size: 1000},
+			},
+		},
+		Layers: []cloudprotocol.LayerInfo{
+			{
+				LayerID: "layer1", Digest: "digest1", Version: "1.0.0",
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"layer1"}, Size: 10},
+			},
+		},
+		Services: []cloudprotocol.ServiceInfo{
+			{
+				ServiceID: "service0", Version: "2.0.0",
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"service0"}, Size: 100},
+			},
+			{
+				ServiceID: "service2", Version: "1.0.0",
+				DownloadInfo: cloudprotocol.DownloadInfo{URLs: []string{"service2"}, Size: 50},
+			},
+		},
+		Instances: []cloudprotocol.InstanceInfo{
+			{ServiceID: "service0", SubjectID: "subj1", NumInstances: 1},
+			{ServiceID: "service2", SubjectID: "subj1", NumInstances: 1},
+		},
+	}
+
+	expectedReport := amqphandler.DesiredStatusReport{
+		DryRun:          true,
+		UnitConfig:      &amqphandler.ItemChange{FromVersion: "1.0.0", ToVersion: "2.0.0"},
+		AddedServices:   []amqphandler.ItemChange{{ID: "service2", ToVersion: "1.0.0"}},
+		UpdatedServices: []amqphandler.ItemChange{{ID: "service0", FromVersion: "1.0.0", ToVersion: "2.0.0"}},
+		RemovedServices: []amqphandler.ItemChange{{ID: "service1", FromVersion: "1.0.0"}},
+		AddedLayers:     []amqphandler.ItemChange{{ID: "digest1", ToVersion: "1.0.0"}},
+		RemovedLayers:   []amqphandler.ItemChange{{ID: "digest0", FromVersion: "1.0.0"}},
+		Components: []amqphandler.ItemChange{
+			{ID: "comp0", Type: "type0", FromVersion: "1.0.0", ToVersion: "2.0.0"},
+		},
+		StartedInstances: []aostypes.InstanceIdent{{ServiceID: "service2", SubjectID: "subj1"}},
+		StoppedInstances: []aostypes.InstanceIdent{{ServiceID: "service1", SubjectID: "subj1"}},
+		MovedInstances: []amqphandler.InstanceMove{
+			{InstanceIdent: aostypes.InstanceIdent{ServiceID: "service0", SubjectID: "subj1"}, NodeID: "node1"},
+		},
+		Downloads: []amqphandler.DownloadItem{
+			{ID: "comp0", Type: amqphandler.DownloadTypeComponent, Version: "2.0.0", Size: 1000},
+			{ID: "service0", Type: amqphandler.DownloadTypeService, Version: "2.0.0", Size: 100},
+			{ID: "service2", Type: amqphandler.DownloadTypeService, Version: "1.0.0", Size: 50},
+			{ID: "digest1", Type: amqphandler.DownloadTypeLayer, Version: "1.0.0", Size: 10},
+		},
+		DownloadSize: 1160,
+	}
+
+	// Dry run
+
+	if err = statusHandler.ProcessDesiredStatusRequest(amqphandler.DesiredStatus{
+		DesiredStatus: desiredStatus, DryRun: true,
+	}); err != nil {
+		t.Fatalf("Can't process desired status: %v", err)
+	}
+
+	report, err := sender.WaitForDesiredStatusReport(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't receive desired status report: %v", err)
+	}
+
+	if report.ReportID == "" || report.CorrelationID == "" {
+		t.Error("Empty report or correlation ID")
+	}
+
+	expectedReport.ReportID = report.ReportID
+	expectedReport.CorrelationID = report.CorrelationID
+
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Errorf("Wrong desired status report: %v, expected: %v", report, expectedReport)
+	}
+
+	if state := statusHandler.GetSOTAStatus().State; state != cmserver.NoUpdate {
+		t.Errorf("Wrong SOTA state after dry run: %s", state)
+	}
+
+	// Require confirmation
+
+	if err = statusHandler.ProcessDesiredStatusRequest(amqphandler.DesiredStatus{
+		DesiredStatus: desiredStatus, RequireConfirmation: true, CorrelationID: "correlation1",
+	}); err != nil {
+		t.Fatalf("Can't process desired status: %v", err)
+	}
+
+	if report, err = sender.WaitForDesiredStatusReport(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive desired status report: %v", err)
+	}
+
+	if !report.ConfirmationRequired || report.DryRun || report.CorrelationID != "correlation1" {
+		t.Errorf("Wrong desired status report: %v", report)
+	}
+
+	if state := statusHandler.GetSOTAStatus().State; state != cmserver.NoUpdate {
+		t.Errorf("Wrong SOTA state before confirmation: %s", state)
+	}
+
+	if err = statusHandler.ConfirmDesiredStatus(amqphandler.DesiredStatusConfirmation{
+		ReportID: "unknown", Confirmed: true,
+	}); err == nil {
+		t.Error("Error expected for unknown report")
+	}
+
+	if err = statusHandler.ConfirmDesiredStatus(amqphandler.DesiredStatusConfirmation{
+		ReportID: report.ReportID, Confirmed: true,
+	}); err != nil {
+		t.Fatalf("Can't confirm desired status: %v", err)
+	}
+
+	if state := statusHandler.GetSOTAStatus().State; state == cmserver.NoUpdate {
+		t.Error("SOTA update expected after confirmation")
+	}
+
+	if correlationID := logging.GetCorrelationID(); correlationID != "correlation1" {
+		t.Errorf("Wrong update correlation ID: %s", correlationID)
+	}
+
+	if err = statusHandler.ConfirmDesiredStatus(amqphandler.DesiredStatusConfirmation{
+		ReportID: report.ReportID, Confirmed: true,
+	}); err == nil {
+		t.Error("Error expected for already confirmed report")
+	}
+
+	// Invalid desired status
+
+	if err = statusHandler.ProcessDesiredStatusRequest(amqphandler.DesiredStatus{
+		DesiredStatus: cloudprotocol.DesiredStatus{
+			Services: []cloudprotocol.ServiceInfo{{ServiceID: "service0", Version: "latest"}},
+			Instances: []cloudprotocol.InstanceInfo{
+				{ServiceID: "service0", SubjectID: "subj1", NumInstances: 1, Labels: []string{"-label"}},
+				{ServiceID: "service3", SubjectID: "subj1", NumInstances: 1},
+			},
+		},
+		CorrelationID: "correlation2",
+	}); err == nil {
+		t.Error("Error expected for invalid desired status")
+	}
+
+	rejection, err := sender.WaitForDesiredStatusRejection(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't receive desired status rejection: %v", err)
+	}
+
+	expectedRejection := amqphandler.DesiredStatusRejection{
+		CorrelationID: "correlation2",
+		Errors: []amqphandler.ValidationError{
+			{Path: "/services/0/version", Message: "invalid version latest"},
+			{Path: "/instances/0/labels/0", Message: "invalid label -label"},
+			{Path: "/instances/1/serviceId", Message: "service service3 not found"},
+		},
+	}
+
+	if !reflect.DeepEqual(rejection, expectedRejection) {
+		t.Errorf("Wrong desired status rejection: %v, expected: %v", rejection, expectedRejection)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	firmwareUpdater := unitstatushandler.NewTestFirmwareUpdater([]cloudprotocol.ComponentStatus{
+		{ComponentID: "comp0", ComponentType: "type0", Version: "1.0.0", Status: cloudprotocol.InstalledStatus},
+	})
+	sender := unitstatushandler.NewTestSender()
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
+		unitstatushandler.NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}), firmwareUpdater,
+		unitstatushandler.NewTestSoftwareUpdater(nil, nil), unitstatushandler.NewTestInstanceRunner(),
+		unitstatushandler.NewTestDownloader(), unitstatushandler.NewTestStorage(), sender,
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer statusHandler.Close()
+
+	sender.Consumer.CloudConnected()
+
+	go handleUpdateStatus(statusHandler)
+
+	if err := statusHandler.ProcessRunStatus(nil); err != nil {
+		t.Fatalf("Can't process run status: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), waitStatusTimeout)
+	defer cancelFunc()
+
+	if err = statusHandler.Shutdown(ctx); err != nil {
+		t.Errorf("Can't shutdown unit status handler: %v", err)
+	}
+
+	if err = statusHandler.ProcessDesiredStatusRequest(amqphandler.DesiredStatus{
+		DesiredStatus: cloudprotocol.DesiredStatus{
+			Components: []cloudprotocol.ComponentInfo{
+				{ComponentID: convertToComponentID("comp0"), ComponentType: "type0", Version: "2.0.0"},
+			},
+		},
+	}); !errors.Is(err, unitstatushandler.ErrShuttingDown) {
+		t.Errorf("Shutting down error expected: %v", err)
+	}
+
+	if err = statusHandler.ConfirmDesiredStatus(
+		amqphandler.DesiredStatusConfirmation{ReportID: "report", Confirmed: true},
+	); !errors.Is(err, unitstatushandler.ErrShuttingDown) {
+		t.Errorf("Shutting down error expected: %v", err)
+	}
+
+	if status := statusHandler.GetFOTAStatus(); status.State != cmserver.NoUpdate {
+		t.Errorf("Wrong FOTA state: %v", status.State)
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	storage := unitstatushandler.NewTestStorage()
+	sender := unitstatushandler.NewTestSender()
+	freezer := unitstatushandler.NewTestSchedulingFreezer()
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
+		unitstatushandler.NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+		unitstatushandler.NewTestFirmwareUpdater(nil), unitstatushandler.NewTestSoftwareUpdater(nil, nil),
+		unitstatushandler.NewTestInstanceRunner(), unitstatushandler.NewTestDownloader(), storage, sender,
+		unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer statusHandler.Close()
+
+	statusHandler.SetSchedulingFreezer(freezer)
+
+	sender.Consumer.CloudConnected()
+
+	if err := statusHandler.ProcessRunStatus(nil); err != nil {
+		t.Fatalf("Can't process run status: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if err = statusHandler.SetMaintenanceMode(true, "workshop"); err != nil {
+		t.Fatalf("Can't set maintenance mode: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if mode := statusHandler.GetMaintenanceMode(); !mode.Enabled || mode.Reason != "workshop" || mode.Since.IsZero() {
+		t.Errorf("Wrong maintenance mode: %v", mode)
+	}
+
+	if !freezer.IsFrozen() {
+		t.Error("Scheduling should be frozen")
+	}
+
+	if err = statusHandler.ProcessDesiredStatusRequest(amqphandler.DesiredStatus{
+		CorrelationID: "correlation",
+	}); !errors.Is(err, unitstatushandler.ErrMaintenanceMode) {
+		t.Errorf("Maintenance mode error expected: %v", err)
+	}
+
+	rejection, err := sender.WaitForDesiredStatusRejection(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't receive desired status rejection: %v", err)
+	}
+
+	expectedRejection := amqphandler.DesiredStatusRejection{
+		CorrelationID: "correlation",
+		Errors:        []amqphandler.ValidationError{{Message: unitstatushandler.ErrMaintenanceMode.Error()}},
+	}
+
+	if !reflect.DeepEqual(rejection, expectedRejection) {
+		t.Errorf("Wrong desired status rejection: %v, expected: %v", rejection, expectedRejection)
+	}
+
+	if err = statusHandler.ConfirmDesiredStatus(
+		amqphandler.DesiredStatusConfirmation{ReportID: "report", Confirmed: true},
+	); !errors.Is(err, unitstatushandler.ErrMaintenanceMode) {
+		t.Errorf("Maintenance mode error expected: %v", err)
+	}
+
+	// Maintenance mode is restored on restart
+
+	restoredHandler, err := unitstatushandler.New(
+		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
+		unitstatushandler.NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+		unitstatushandler.NewTestFirmwareUpdater(nil), unitstatushandler.NewTestSoftwareUpdater(nil, nil),
+		unitstatushandler.NewTestInstanceRunner(), unitstatushandler.NewTestDownloader(), storage,
+		unitstatushandler.NewTestSender(), unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer restoredHandler.Close()
+
+	if mode := restoredHandler.GetMaintenanceMode(); !mode.Enabled || mode.Reason != "workshop" {
+		t.Errorf("Wrong restored maintenance mode: %v", mode)
+	}
+
+	if err = statusHandler.SetMaintenanceMode(false, ""); err != nil {
+		t.Fatalf("Can't reset maintenance mode: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if freezer.IsFrozen() {
+		t.Error("Scheduling should not be frozen")
+	}
+
+	if mode := statusHandler.GetMaintenanceMode(); mode.Enabled {
+		t.Errorf("Wrong maintenance mode: %v", mode)
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	sender := unitstatushandler.NewTestSender()
+
+	statusHandler, err := unitstatushandler.New(
+		cfg, unitstatushandler.NewTestUnitManager(nil, nil),
+		unitstatushandler.NewTestUnitConfigUpdater(cloudprotocol.UnitConfigStatus{}),
+		unitstatushandler.NewTestFirmwareUpdater(nil), unitstatushandler.NewTestSoftwareUpdater(nil, nil),
+		unitstatushandler.NewTestInstanceRunner(), unitstatushandler.NewTestDownloader(),
+		unitstatushandler.NewTestStorage(), sender, unitstatushandler.NewTestSystemQuotaAlertProvider(), nil)
+	if err != nil {
+		t.Fatalf("Can't create unit status handler: %v", err)
+	}
+	defer statusHandler.Close()
+
+	sender.Consumer.CloudConnected()
+
+	if err := statusHandler.ProcessRunStatus(nil); err != nil {
+		t.Fatalf("Can't process run status: %v", err)
+	}
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if flags := sender.GetFeatureFlags(); !flags[config.FeatureDeltaUnitStatus] {
+		t.Errorf("Wrong feature flags: %v", flags)
+	}
+
+	// Changed flags are reported in full unit status
+
+	flags := map[string]bool{config.FeatureDeltaUnitStatus: false}
+
+	statusHandler.FeatureFlagsChanged(flags)
+
+	if _, err = sender.WaitForStatus(waitStatusTimeout); err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if !reflect.DeepEqual(sender.GetFeatureFlags(), flags) {
+		t.Errorf("Wrong feature flags: %v", sender.GetFeatureFlags())
+	}
+
+	// Full unit status is sent on change if delta unit status is disabled
+
+	statusHandler.ProcessUpdateInstanceStatus([]cloudprotocol.InstanceStatus{
+		{
+			InstanceIdent:  aostypes.InstanceIdent{ServiceID: "Serv1", SubjectID: "Subj1", Instance: 0},
+			ServiceVersion: "1.0.0",
+		},
+	})
+
+	unitStatus, err := sender.WaitForStatus(waitStatusTimeout)
+	if err != nil {
+		t.Fatalf("Can't receive unit status: %v", err)
+	}
+
+	if unitStatus.IsDeltaInfo {
+		t.Error("Full unit status expected")
+	}
+}
+
 /***********************************************************************************************************************
  * Private
  **********************************************************************************************************************/
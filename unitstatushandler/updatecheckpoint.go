@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"slices"
+	"sync"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// Software update steps.
+const (
+	stepUpdateNodes      = "updateNodes"
+	stepUpdateUnitConfig = "updateUnitConfig"
+	stepRemoveServices   = "removeServices"
+	stepInstallLayers    = "installLayers"
+	stepRestoreServices  = "restoreServices"
+	stepInstallServices  = "installServices"
+	stepRemoveLayers     = "removeLayers"
+	stepRestoreLayers    = "restoreLayers"
+	stepRunInstances     = "runInstances"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// updateCheckpoint keeps completed steps and items of the update in progress. It is stored with the manager state,
+// so after CM restart the update is resumed from the last completed item instead of starting from the beginning.
+type updateCheckpoint struct {
+	sync.Mutex
+
+	Steps []string            `json:"steps,omitempty"`
+	Items map[string][]string `json:"items,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newUpdateCheckpoint() *updateCheckpoint {
+	return &updateCheckpoint{Items: make(map[string][]string)}
+}
+
+func (checkpoint *updateCheckpoint) isStepDone(step string) bool {
+	checkpoint.Lock()
+	defer checkpoint.Unlock()
+
+	return slices.Contains(checkpoint.Steps, step)
+}
+
+func (checkpoint *updateCheckpoint) setStepDone(step string, stepErr error) {
+	checkpoint.Lock()
+	defer checkpoint.Unlock()
+
+	checkpoint.Steps = append(checkpoint.Steps, step)
+
+	if stepErr != nil && checkpoint.Error == "" {
+		checkpoint.Error = stepErr.Error()
+	}
+}
+
+func (checkpoint *updateCheckpoint) isItemDone(step, id string) bool {
+	checkpoint.Lock()
+	defer checkpoint.Unlock()
+
+	return slices.Contains(checkpoint.Items[step], id)
+}
+
+func (checkpoint *updateCheckpoint) getDoneItems(step string) []string {
+	checkpoint.Lock()
+	defer checkpoint.Unlock()
+
+	return slices.Clone(checkpoint.Items[step])
+}
+
+func (checkpoint *updateCheckpoint) setItemDone(step, id string) {
+	checkpoint.Lock()
+	defer checkpoint.Unlock()
+
+	if checkpoint.Items == nil {
+		checkpoint.Items = make(map[string][]string)
+	}
+
+	checkpoint.Items[step] = append(checkpoint.Items[step], id)
+}
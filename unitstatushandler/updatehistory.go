@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/logging"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// updateHistorySize number of last update history records of item step used to learn update time.
+const updateHistorySize = 5
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// updateHistory stores actual durations of update steps per component, service and layer and provides values
+// learned from previous updates.
+type updateHistory struct {
+	storage Storage
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newUpdateHistory(storage Storage) *updateHistory {
+	return &updateHistory{storage: storage}
+}
+
+func (history *updateHistory) addRecord(id, step string, size uint64, duration time.Duration) {
+	if history == nil || id == "" {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"id": id, "step": step, "size": size, "duration": duration,
+	}).Debug("Add update history record")
+
+	if err := history.storage.AddUpdateHistoryRecord(UpdateHistoryRecord{
+		Timestamp: time.Now().UTC(), ID: id, Step: step, Size: size, Duration: duration,
+		CampaignID: logging.GetCampaignID(),
+	}); err != nil {
+		log.Errorf("Can't add update history record: %v", err)
+
+		return
+	}
+
+	if err := history.storage.RemoveOutdatedUpdateHistoryRecords(id, step, updateHistorySize); err != nil {
+		log.Errorf("Can't remove outdated update history records: %v", err)
+	}
+}
+
+// getDownloadSpeed returns item download speed in bytes per second learned from previous updates.
+func (history *updateHistory) getDownloadSpeed(id string) (speed uint64, ok bool) {
+	var (
+		size         uint64
+		downloadTime time.Duration
+	)
+
+	for _, record := range history.getRecords(id, UpdateStepDownload) {
+		size += record.Size
+		downloadTime += record.Duration
+	}
+
+	if size == 0 || downloadTime <= 0 {
+		return 0, false
+	}
+
+	return uint64(float64(size) / downloadTime.Seconds()), true
+}
+
+// getInstallTime returns average item install time learned from previous updates.
+func (history *updateHistory) getInstallTime(id string) (installTime time.Duration, ok bool) {
+	records := history.getRecords(id, UpdateStepInstall)
+	if len(records) == 0 {
+		return 0, false
+	}
+
+	for _, record := range records {
+		installTime += record.Duration
+	}
+
+	return installTime / time.Duration(len(records)), true
+}
+
+func (history *updateHistory) getRecords(id, step string) []UpdateHistoryRecord {
+	if history == nil {
+		return nil
+	}
+
+	records, err := history.storage.GetUpdateHistoryRecords(id, step, updateHistorySize)
+	if err != nil {
+		log.Errorf("Can't get update history records: %v", err)
+
+		return nil
+	}
+
+	return records
+}
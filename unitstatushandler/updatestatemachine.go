@@ -20,6 +20,7 @@ package unitstatushandler
 import (
 	"container/list"
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -29,12 +30,15 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/aosedge/aos_communicationmanager/cmserver"
+	"github.com/aosedge/aos_communicationmanager/extension"
 )
 
 /***********************************************************************************************************************
  * Consts
  **********************************************************************************************************************/
 
+const updateGateRetryPeriod = 1 * time.Minute
+
 const (
 	stateNoUpdate      = "noUpdate"
 	stateDownloading   = "downloading"
@@ -90,6 +94,8 @@ type syncExecutor struct {
 
 var updateSynchronizer = newSyncExecutor() //nolint:gochecknoglobals
 
+var errUpdatePostponed = errors.New("update postponed")
+
 /***********************************************************************************************************************
  * Interface
  **********************************************************************************************************************/
@@ -185,11 +191,42 @@ func (stateMachine *updateStateMachine) scheduleUpdate(schedule cloudprotocol.Sc
 		log.WithFields(log.Fields{"in": updateTime}).Debug("Schedule forced update")
 	}
 
-	stateMachine.updateTimer = time.AfterFunc(updateTime, func() {
-		if err := stateMachine.manager.startUpdate(); err != nil {
-			log.Errorf("Can't start update: %v", err)
-		}
-	})
+	stateMachine.updateTimer = time.AfterFunc(updateTime, stateMachine.startScheduledUpdate)
+}
+
+func (stateMachine *updateStateMachine) postponeUpdate() {
+	log.WithField("in", updateGateRetryPeriod).Debug("Postpone update")
+
+	if stateMachine.updateTimer != nil {
+		stateMachine.updateTimer.Stop()
+	}
+
+	stateMachine.updateTimer = time.AfterFunc(updateGateRetryPeriod, stateMachine.startScheduledUpdate)
+}
+
+// deferUpdate defers the update till the next timetable window after the current one.
+func (stateMachine *updateStateMachine) deferUpdate(schedule cloudprotocol.ScheduleRule) {
+	now := time.Now()
+
+	remainingTime, err := getRemainingTimetableTime(now, schedule.Timetable)
+	if err != nil {
+		log.WithField("err", err).Error("Can't get remaining timetable time")
+		return
+	}
+
+	nextTime, err := getAvailableTimetableTime(now.Add(remainingTime), schedule.Timetable)
+	if err != nil {
+		log.WithField("err", err).Error("Can't get available timetable time")
+		return
+	}
+
+	log.WithField("in", remainingTime+nextTime).Debug("Defer update to next window")
+
+	if stateMachine.updateTimer != nil {
+		stateMachine.updateTimer.Stop()
+	}
+
+	stateMachine.updateTimer = time.AfterFunc(remainingTime+nextTime, stateMachine.startScheduledUpdate)
 }
 
 func (stateMachine *updateStateMachine) finishOperation(ctx context.Context, finishEvent string, operationErr error) {
@@ -249,6 +286,17 @@ func convertState(state string) (updateState cmserver.UpdateState) {
  * Private
  **********************************************************************************************************************/
 
+func (stateMachine *updateStateMachine) startScheduledUpdate() {
+	if err := stateMachine.manager.startUpdate(); err != nil {
+		if errors.Is(err, errUpdatePostponed) {
+			log.Warnf("Update postponed: %v", err)
+			return
+		}
+
+		log.Errorf("Can't start update: %v", err)
+	}
+}
+
 func (stateMachine *updateStateMachine) setTTLTimer(ttlTime time.Duration) {
 	stateMachine.ttlTimer = time.AfterFunc(ttlTime, func() {
 		stateMachine.manager.updateTimeout()
@@ -311,6 +359,16 @@ func (stateMachine *updateStateMachine) onStateUpdating(ctx context.Context, eve
 	}()
 }
 
+func checkUpdateGates(gates []extension.UpdateGate, updateType string) error {
+	for _, gate := range gates {
+		if err := gate.CheckUpdate(updateType); err != nil {
+			return aoserrors.Errorf("%w: %v", errUpdatePostponed, err)
+		}
+	}
+
+	return nil
+}
+
 func (stateMachine *updateStateMachine) resetTimers() {
 	// Reset update timer
 	if stateMachine.updateTimer != nil {
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitstatushandler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/api/cloudprotocol"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// updateItem component, service or layer of the update.
+type updateItem struct {
+	id         string
+	downloadID string
+	size       uint64
+	downloaded bool
+	installed  bool
+}
+
+type linkSpeedProvider interface {
+	getLinkSpeed() uint64
+}
+
+// updateEstimator estimates update time to check if the update fits remaining time of timetable update window.
+type updateEstimator struct {
+	config            config.UpdateWindow
+	linkSpeedProvider linkSpeedProvider
+	history           *updateHistory
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var errWindowTooShort = errors.New("window too short")
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newUpdateEstimator(
+	config config.UpdateWindow, linkSpeedProvider linkSpeedProvider, history *updateHistory,
+) *updateEstimator {
+	return &updateEstimator{config: config, linkSpeedProvider: linkSpeedProvider, history: history}
+}
+
+// estimateUpdateTime estimates time to download not downloaded items and install not installed items. Download speed
+// and install time learned from previous updates of the item are used if available, otherwise measured link speed and
+// default install time.
+func (estimator *updateEstimator) estimateUpdateTime(items []updateItem) (updateTime time.Duration) {
+	linkSpeed := estimator.linkSpeedProvider.getLinkSpeed()
+	if linkSpeed == 0 {
+		linkSpeed = estimator.config.DefaultLinkSpeed
+	}
+
+	for _, item := range items {
+		if item.installed {
+			continue
+		}
+
+		if !item.downloaded {
+			itemSpeed, ok := estimator.history.getDownloadSpeed(item.id)
+			if !ok {
+				itemSpeed = linkSpeed
+			}
+
+			if itemSpeed != 0 {
+				updateTime += time.Duration(float64(item.size) / float64(itemSpeed) * float64(time.Second))
+			}
+		}
+
+		installTime, ok := estimator.history.getInstallTime(item.id)
+		if !ok {
+			installTime = estimator.config.DefaultInstallTime.Duration
+		}
+
+		updateTime += installTime
+	}
+
+	return updateTime
+}
+
+// getETA returns estimated finish time of the update items, nil if estimator is not set.
+func (estimator *updateEstimator) getETA(items []updateItem) *time.Time {
+	if estimator == nil {
+		return nil
+	}
+
+	eta := time.Now().Add(estimator.estimateUpdateTime(items)).UTC()
+
+	return &eta
+}
+
+// learnDownloadTimes stores actual download time of successfully downloaded items. Short downloads are skipped as
+// they are mostly already downloaded or cached files.
+func (estimator *updateEstimator) learnDownloadTimes(items []updateItem, result map[string]*downloadResult) {
+	if estimator == nil {
+		return
+	}
+
+	for _, item := range items {
+		downloadInfo, ok := result[item.downloadID]
+		if !ok || downloadInfo.Error != "" || downloadInfo.DownloadTime < minLinkSpeedMeasureTime {
+			continue
+		}
+
+		estimator.history.addRecord(item.id, UpdateStepDownload, item.size, downloadInfo.DownloadTime)
+	}
+}
+
+// learnInstallTime stores actual install time of the item.
+func (estimator *updateEstimator) learnInstallTime(item updateItem, installTime time.Duration) {
+	if estimator == nil {
+		return
+	}
+
+	estimator.history.addRecord(item.id, UpdateStepInstall, item.size, installTime)
+}
+
+// checkUpdateWindow returns window too short error if update with timetable schedule is estimated to not fit
+// remaining time of the current update window.
+func (estimator *updateEstimator) checkUpdateWindow(
+	fromDate time.Time, schedule cloudprotocol.ScheduleRule, items []updateItem,
+) error {
+	if estimator == nil || !estimator.config.Admission || schedule.Type != cloudprotocol.TimetableUpdate {
+		return nil
+	}
+
+	remainingTime, err := getRemainingTimetableTime(fromDate, schedule.Timetable)
+	if err != nil {
+		return err
+	}
+
+	updateTime := estimator.estimateUpdateTime(items)
+
+	log.WithFields(log.Fields{
+		"updateTime": updateTime, "remainingTime": remainingTime,
+	}).Debug("Check update window")
+
+	if updateTime > remainingTime {
+		return aoserrors.Errorf("%w: estimated update time %v, remaining window time %v",
+			errWindowTooShort, updateTime, remainingTime)
+	}
+
+	return nil
+}
+
+func isDownloaded(result map[string]*downloadResult, downloadID string) bool {
+	item, ok := result[downloadID]
+
+	return ok && item.Error == "" && item.FileName != ""
+}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonschema validates JSON documents against the subset of JSON schema used by CM embedded schemas: type,
+// properties, required, items, enum, pattern, minLength, minimum, maximum and local definition references. Unknown
+// properties are allowed to keep compatibility with newer cloud versions. Null values are treated as missing ones.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const definitionsRef = "#/definitions/"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// Schema compiled JSON schema.
+type Schema struct {
+	root *schemaNode
+}
+
+// Violation schema violation. Path is JSON pointer to the invalid value.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+type schemaNode struct {
+	Ref         string                 `json:"$ref"`
+	Type        schemaTypes            `json:"type"`
+	Properties  map[string]*schemaNode `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *schemaNode            `json:"items"`
+	Enum        []interface{}          `json:"enum"`
+	Pattern     string                 `json:"pattern"`
+	MinLength   *int                   `json:"minLength"`
+	Minimum     *float64               `json:"minimum"`
+	Maximum     *float64               `json:"maximum"`
+	Definitions map[string]*schemaNode `json:"definitions"`
+
+	compiledPattern *regexp.Regexp
+}
+
+type schemaTypes []string
+
+type validator struct {
+	root       *schemaNode
+	violations []Violation
+}
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New compiles JSON schema.
+func New(data []byte) (*Schema, error) {
+	var root schemaNode
+
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	if err := root.compile(&root); err != nil {
+		return nil, err
+	}
+
+	return &Schema{root: &root}, nil
+}
+
+// Validate validates JSON document against the schema. Error is returned if the document is not valid JSON.
+func (schema *Schema) Validate(data []byte) ([]Violation, error) {
+	return schema.validateData(schema.root, data)
+}
+
+// ValidateDefinition validates JSON document against the schema definition.
+func (schema *Schema) ValidateDefinition(data []byte, definition string) ([]Violation, error) {
+	node, ok := schema.root.Definitions[definition]
+	if !ok {
+		return nil, aoserrors.Errorf("schema definition %s not found", definition)
+	}
+
+	return schema.validateData(node, data)
+}
+
+// String returns violation string representation.
+func (violation Violation) String() string {
+	if violation.Path == "" {
+		return violation.Message
+	}
+
+	return fmt.Sprintf("%s: %s", violation.Path, violation.Message)
+}
+
+// UnmarshalJSON unmarshals schema type which could be either string or array of strings.
+func (types *schemaTypes) UnmarshalJSON(data []byte) error {
+	var schemaType string
+
+	if err := json.Unmarshal(data, &schemaType); err == nil {
+		*types = schemaTypes{schemaType}
+
+		return nil
+	}
+
+	var schemaTypeList []string
+
+	if err := json.Unmarshal(data, &schemaTypeList); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	*types = schemaTypeList
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func (node *schemaNode) compile(root *schemaNode) (err error) {
+	if node == nil {
+		return nil
+	}
+
+	if node.Ref != "" {
+		if _, err = root.resolveRef(node.Ref); err != nil {
+			return err
+		}
+	}
+
+	if node.Pattern != "" {
+		if node.compiledPattern, err = regexp.Compile(node.Pattern); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	for _, property := range node.Properties {
+		if err = property.compile(root); err != nil {
+			return err
+		}
+	}
+
+	for _, definition := range node.Definitions {
+		if err = definition.compile(root); err != nil {
+			return err
+		}
+	}
+
+	return node.Items.compile(root)
+}
+
+func (node *schemaNode) resolveRef(ref string) (*schemaNode, error) {
+	definition, ok := node.Definitions[strings.TrimPrefix(ref, definitionsRef)]
+	if !strings.HasPrefix(ref, definitionsRef) || !ok {
+		return nil, aoserrors.Errorf("unsupported schema reference: %s", ref)
+	}
+
+	return definition, nil
+}
+
+func (schema *Schema) validateData(node *schemaNode, data []byte) ([]Violation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, aoserrors.Wrap(err)
+	}
+
+	validator := validator{root: schema.root}
+
+	validator.validate(node, value, "")
+
+	return validator.violations, nil
+}
+
+func (validator *validator) addViolation(path, format string, args ...interface{}) {
+	validator.violations = append(validator.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (validator *validator) validate(node *schemaNode, value interface{}, path string) {
+	if node.Ref != "" {
+		// references are checked on compile
+		refNode, _ := validator.root.resolveRef(node.Ref)
+
+		validator.validate(refNode, value, path)
+	}
+
+	valueType := getJSONType(value)
+
+	if len(node.Type) != 0 && !slices.Contains(node.Type, valueType) &&
+		!(valueType == "integer" && slices.Contains(node.Type, "number")) {
+		validator.addViolation(path, "wrong type: expected %s, got %s", strings.Join(node.Type, " or "), valueType)
+
+		return
+	}
+
+	if len(node.Enum) != 0 && !slices.ContainsFunc(node.Enum, func(enumValue interface{}) bool {
+		return fmt.Sprint(enumValue) == fmt.Sprint(value)
+	}) {
+		validator.addViolation(path, "value %v is not allowed", value)
+	}
+
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		validator.validateObject(node, typedValue, path)
+
+	case []interface{}:
+		if node.Items != nil {
+			for i, item := range typedValue {
+				validator.validate(node.Items, item, path+"/"+strconv.Itoa(i))
+			}
+		}
+
+	case string:
+		if node.MinLength != nil && len(typedValue) < *node.MinLength {
+			validator.addViolation(path, "length is less than %d", *node.MinLength)
+		}
+
+		if node.compiledPattern != nil && !node.compiledPattern.MatchString(typedValue) {
+			validator.addViolation(path, "value doesn't match pattern %s", node.Pattern)
+		}
+
+	case json.Number:
+		number, err := typedValue.Float64()
+		if err != nil {
+			validator.addViolation(path, "%v", err)
+
+			return
+		}
+
+		if node.Minimum != nil && number < *node.Minimum {
+			validator.addViolation(path, "value is less than %v", *node.Minimum)
+		}
+
+		if node.Maximum != nil && number > *node.Maximum {
+			validator.addViolation(path, "value is greater than %v", *node.Maximum)
+		}
+	}
+}
+
+func (validator *validator) validateObject(node *schemaNode, object map[string]interface{}, path string) {
+	for _, name := range node.Required {
+		if value, ok := object[name]; !ok || value == nil {
+			validator.addViolation(path+"/"+name, "required property is missing")
+		}
+	}
+
+	names := make([]string, 0, len(node.Properties))
+
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+
+	// keep violations order stable
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value, ok := object[name]; ok && value != nil {
+			validator.validate(node.Properties[name], value, path+"/"+name)
+		}
+	}
+}
+
+func getJSONType(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return "null"
+
+	case bool:
+		return "boolean"
+
+	case string:
+		return "string"
+
+	case json.Number:
+		if strings.ContainsAny(typedValue.String(), ".eE") {
+			return "number"
+		}
+
+		return "integer"
+
+	case []interface{}:
+		return "array"
+
+	case map[string]interface{}:
+		return "object"
+
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aosedge/aos_communicationmanager/utils/jsonschema"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const testSchema = `{
+	"type": "object",
+	"required": ["id", "items"],
+	"properties": {
+		"id": {"type": "string", "minLength": 1},
+		"kind": {"enum": ["a", "b"]},
+		"items": {"type": "array", "items": {"$ref": "#/definitions/item"}}
+	},
+	"definitions": {
+		"item": {
+			"type": "object",
+			"required": ["path"],
+			"properties": {
+				"path": {"type": "string", "pattern": "^/"},
+				"weight": {"type": "integer", "minimum": 0, "maximum": 10},
+				"ratio": {"type": "number"}
+			}
+		}
+	}
+}`
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestValidate(t *testing.T) {
+	schema, err := jsonschema.New([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Can't create schema: %v", err)
+	}
+
+	type testData struct {
+		document   string
+		violations []jsonschema.Violation
+	}
+
+	data := []testData{
+		{document: `{"id": "id1", "items": [{"path": "/a", "weight": 1, "ratio": 1}], "unknown": 1}`},
+		{document: `{"id": "id1", "kind": null, "items": []}`},
+		{
+			document: `{"id": "", "kind": "c", "items": null}`,
+			violations: []jsonschema.Violation{
+				{Path: "/items", Message: "required property is missing"},
+				{Path: "/id", Message: "length is less than 1"},
+				{Path: "/kind", Message: "value c is not allowed"},
+			},
+		},
+		{
+			document: `{"id": 1, "items": [{"path": "a", "weight": 11}, {"weight": 1.5}, "item"]}`,
+			violations: []jsonschema.Violation{
+				{Path: "/id", Message: "wrong type: expected string, got integer"},
+				{Path: "/items/0/path", Message: "value doesn't match pattern ^/"},
+				{Path: "/items/0/weight", Message: "value is greater than 10"},
+				{Path: "/items/1/path", Message: "required property is missing"},
+				{Path: "/items/1/weight", Message: "wrong type: expected integer, got number"},
+				{Path: "/items/2", Message: "wrong type: expected object, got string"},
+			},
+		},
+	}
+
+	for i, item := range data {
+		violations, err := schema.Validate([]byte(item.document))
+		if err != nil {
+			t.Fatalf("Can't validate document %d: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(violations, item.violations) {
+			t.Errorf("Wrong violations of document %d: %v", i, violations)
+		}
+	}
+
+	if _, err = schema.Validate([]byte(`{"id": `)); err == nil {
+		t.Error("Error expected for invalid JSON")
+	}
+}
+
+func TestValidateDefinition(t *testing.T) {
+	schema, err := jsonschema.New([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Can't create schema: %v", err)
+	}
+
+	violations, err := schema.ValidateDefinition([]byte(`{"path": "a"}`), "item")
+	if err != nil {
+		t.Fatalf("Can't validate document: %v", err)
+	}
+
+	if !reflect.DeepEqual(violations, []jsonschema.Violation{
+		{Path: "/path", Message: "value doesn't match pattern ^/"},
+	}) {
+		t.Errorf("Wrong violations: %v", violations)
+	}
+
+	if _, err = schema.ValidateDefinition([]byte(`{}`), "unknown"); err == nil {
+		t.Error("Error expected for unknown definition")
+	}
+}
+
+func TestInvalidSchema(t *testing.T) {
+	for _, data := range []string{
+		`{"properties": {"id": {"$ref": "#/definitions/unknown"}}}`,
+		`{"properties": {"id": {"pattern": "["}}}`,
+		`{"type": 1}`,
+	} {
+		if _, err := jsonschema.New([]byte(data)); err == nil {
+			t.Errorf("Error expected for schema: %s", data)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shred provides secure deletion of files and directories.
+package shred
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aosedge/aos_common/aoserrors"
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// RemoveAll overwrites content of all regular files located at the path with random data the passes number of times,
+// syncs them to the storage and removes the path. Missing path is not an error.
+func RemoveAll(path string, passes int) error {
+	if err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		return overwriteFile(filePath, passes)
+	}); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return aoserrors.Wrap(err)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func overwriteFile(filePath string, passes int) error {
+	file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return aoserrors.Wrap(err)
+	}
+
+	for range max(passes, 1) {
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if _, err = io.CopyN(file, rand.Reader, fileInfo.Size()); err != nil {
+			return aoserrors.Wrap(err)
+		}
+
+		if err = file.Sync(); err != nil {
+			return aoserrors.Wrap(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shred_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aosedge/aos_communicationmanager/utils/shred"
+)
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRemoveAll(t *testing.T) {
+	rootDir := filepath.Join(t.TempDir(), "data")
+
+	if err := os.MkdirAll(filepath.Join(rootDir, "nested"), 0o755); err != nil {
+		t.Fatalf("Can't create dir: %v", err)
+	}
+
+	for _, fileName := range []string{"file1.dat", "nested/file2.dat"} {
+		if err := os.WriteFile(filepath.Join(rootDir, fileName), []byte("secret data"), 0o600); err != nil {
+			t.Fatalf("Can't create file: %v", err)
+		}
+	}
+
+	if err := os.Symlink("/etc/hostname", filepath.Join(rootDir, "link")); err != nil {
+		t.Fatalf("Can't create symlink: %v", err)
+	}
+
+	if err := shred.RemoveAll(rootDir, 3); err != nil {
+		t.Fatalf("Can't shred dir: %v", err)
+	}
+
+	if _, err := os.Stat(rootDir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Dir should be removed: %v", err)
+	}
+
+	if err := shred.RemoveAll(rootDir, 1); err != nil {
+		t.Errorf("Can't shred not existing dir: %v", err)
+	}
+}
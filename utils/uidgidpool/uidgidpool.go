@@ -41,7 +41,7 @@ const (
 
 type IdentifierPool struct {
 	sync.Mutex
-	lockedIDs          []int
+	lockedIDs          map[int]struct{}
 	systemAvailability func(int) bool
 }
 
@@ -51,6 +51,7 @@ type IdentifierPool struct {
 
 func NewGroupIDPool() (pool *IdentifierPool) {
 	pool = &IdentifierPool{
+		lockedIDs: make(map[int]struct{}),
 		systemAvailability: func(gid int) bool {
 			if group, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil || group != nil {
 				return false
@@ -65,6 +66,7 @@ func NewGroupIDPool() (pool *IdentifierPool) {
 
 func NewUserIDPool() (pool *IdentifierPool) {
 	pool = &IdentifierPool{
+		lockedIDs: make(map[int]struct{}),
 		systemAvailability: func(uid int) bool {
 			if user, err := user.LookupId(strconv.Itoa(uid)); err == nil || user != nil {
 				return false
@@ -85,7 +87,7 @@ func (pool *IdentifierPool) GetFreeID() (id int, err error) {
 		return 0, err
 	}
 
-	pool.lockedIDs = append(pool.lockedIDs, id)
+	pool.lockedIDs[id] = struct{}{}
 
 	return id, nil
 }
@@ -94,11 +96,11 @@ func (pool *IdentifierPool) AddID(id int) error {
 	pool.Lock()
 	defer pool.Unlock()
 
-	if isInPool(pool.lockedIDs, id) {
+	if _, ok := pool.lockedIDs[id]; ok {
 		return aoserrors.New("given ID already exist in pool")
 	}
 
-	pool.lockedIDs = append(pool.lockedIDs, id)
+	pool.lockedIDs[id] = struct{}{}
 
 	return nil
 }
@@ -107,11 +109,10 @@ func (pool *IdentifierPool) RemoveID(id int) (err error) {
 	pool.Lock()
 	defer pool.Unlock()
 
-	for i, value := range pool.lockedIDs {
-		if value == id {
-			pool.lockedIDs = append(pool.lockedIDs[:i], pool.lockedIDs[i+1:]...)
-			return nil
-		}
+	if _, ok := pool.lockedIDs[id]; ok {
+		delete(pool.lockedIDs, id)
+
+		return nil
 	}
 
 	return aoserrors.New("can't remove ID from pool: UID/GID is not found")
@@ -123,7 +124,7 @@ func (pool *IdentifierPool) RemoveID(id int) (err error) {
 
 func (pool *IdentifierPool) getFreeIDFromPool(systemAvailability func(int) bool) (id int, err error) {
 	for i := idsRangeBegin; i <= idsRangeEnd; i++ {
-		if isInPool(pool.lockedIDs, i) {
+		if _, ok := pool.lockedIDs[i]; ok {
 			continue
 		}
 
@@ -136,13 +137,3 @@ func (pool *IdentifierPool) getFreeIDFromPool(systemAvailability func(int) bool)
 
 	return 0, aoserrors.New("can't get free id")
 }
-
-func isInPool(pool []int, id int) (exist bool) {
-	for _, value := range pool {
-		if id == value {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watchdog checks health of CM subsystems and notifies systemd watchdog while CM is healthy.
+package watchdog
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/coreos/go-systemd/daemon"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+// sdNotifyWatchdogTrigger tells systemd to perform watchdog action immediately.
+const sdNotifyWatchdogTrigger = "WATCHDOG=trigger"
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+// HealthChecker checks subsystem health.
+type HealthChecker interface {
+	CheckHealth() error
+}
+
+// Recoverer restarts subsystem internally. Health checkers implementing this interface are recovered on failed
+// health check.
+type Recoverer interface {
+	Recover() error
+}
+
+// Watchdog periodically checks health of registered subsystems. While all subsystems are healthy, systemd watchdog
+// is notified. If subsystem health check fails, the subsystem is recovered if possible. If subsystem health check
+// fails max failures times in a row, the reason is saved, systemd watchdog is triggered and not notified anymore.
+// The saved reason is reported on next start. If systemd watchdog is not enabled, health checks and recovering of
+// subsystems continue.
+type Watchdog struct {
+	config          config.Watchdog
+	subsystems      []*subsystem
+	watchdogEnabled bool
+	restartReason   string
+	closeChannel    chan struct{}
+	wg              sync.WaitGroup
+}
+
+type subsystem struct {
+	name       string
+	checker    HealthChecker
+	failures   int
+	pending    chan error
+	recovering atomic.Bool
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+//nolint:gochecknoglobals // used to mock systemd in tests
+var (
+	sdNotify          = daemon.SdNotify
+	sdWatchdogEnabled = daemon.SdWatchdogEnabled
+)
+
+/***********************************************************************************************************************
+ * Public
+ **********************************************************************************************************************/
+
+// New creates watchdog.
+func New(cfg *config.Config, checkers map[string]HealthChecker) (watchdog *Watchdog, err error) {
+	log.Debug("Create watchdog")
+
+	watchdog = &Watchdog{config: cfg.Watchdog, closeChannel: make(chan struct{})}
+
+	if watchdog.restartReason, err = popRestartReason(cfg.Watchdog.ReasonFile); err != nil {
+		return nil, err
+	}
+
+	interval, err := sdWatchdogEnabled(false)
+	if err != nil {
+		log.Errorf("Can't get systemd watchdog interval: %v", err)
+	}
+
+	checkPeriod := watchdog.config.CheckPeriod.Duration
+
+	if interval > 0 {
+		watchdog.watchdogEnabled = true
+
+		if checkPeriod <= 0 || interval/2 < checkPeriod {
+			checkPeriod = interval / 2
+		}
+	}
+
+	for name, checker := range checkers {
+		watchdog.subsystems = append(watchdog.subsystems, &subsystem{name: name, checker: checker})
+	}
+
+	sort.Slice(watchdog.subsystems, func(i, j int) bool {
+		return watchdog.subsystems[i].name < watchdog.subsystems[j].name
+	})
+
+	log.WithFields(log.Fields{
+		"watchdogEnabled": watchdog.watchdogEnabled, "checkPeriod": checkPeriod,
+	}).Debug("Start health checks")
+
+	if checkPeriod > 0 {
+		watchdog.wg.Add(1)
+
+		go watchdog.handleChecks(checkPeriod)
+	}
+
+	return watchdog, nil
+}
+
+// Close closes watchdog.
+func (watchdog *Watchdog) Close() {
+	log.Debug("Close watchdog")
+
+	close(watchdog.closeChannel)
+	watchdog.wg.Wait()
+}
+
+// GetRestartReason returns reason of previous CM restart caused by failed health check.
+func (watchdog *Watchdog) GetRestartReason() string {
+	return watchdog.restartReason
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func popRestartReason(reasonFile string) (string, error) {
+	if reasonFile == "" {
+		return "", nil
+	}
+
+	reason, err := os.ReadFile(reasonFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", aoserrors.Wrap(err)
+	}
+
+	if err = os.Remove(reasonFile); err != nil {
+		return "", aoserrors.Wrap(err)
+	}
+
+	return string(reason), nil
+}
+
+func (watchdog *Watchdog) handleChecks(checkPeriod time.Duration) {
+	defer watchdog.wg.Done()
+
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !watchdog.checkHealth() {
+				return
+			}
+
+			if watchdog.watchdogEnabled {
+				if _, err := sdNotify(false, daemon.SdNotifyWatchdog); err != nil {
+					log.Errorf("Can't notify systemd watchdog: %v", err)
+				}
+			}
+
+		case <-watchdog.closeChannel:
+			return
+		}
+	}
+}
+
+func (watchdog *Watchdog) checkHealth() (healthy bool) {
+	for _, subsystem := range watchdog.subsystems {
+		err := watchdog.checkSubsystem(subsystem)
+		if err == nil {
+			if subsystem.failures > 0 {
+				log.WithField("subsystem", subsystem.name).Info("Subsystem is healthy again")
+			}
+
+			subsystem.failures = 0
+
+			continue
+		}
+
+		subsystem.failures++
+
+		log.WithFields(log.Fields{
+			"subsystem": subsystem.name, "failures": subsystem.failures,
+		}).Errorf("Subsystem health check failed: %v", err)
+
+		if subsystem.failures >= watchdog.config.MaxFailures {
+			watchdog.setUnhealthy(subsystem, err)
+
+			if watchdog.watchdogEnabled {
+				return false
+			}
+
+			// CM is not restarted without systemd watchdog, keep checking and recovering subsystems
+			subsystem.failures = 0
+		}
+
+		if recoverer, ok := subsystem.checker.(Recoverer); ok {
+			recoverSubsystem(subsystem, recoverer)
+		}
+	}
+
+	return true
+}
+
+func (watchdog *Watchdog) checkSubsystem(subsystem *subsystem) error {
+	// Previous check may still be in progress if subsystem hangs, wait for it instead of starting the new one
+	if subsystem.pending == nil {
+		pending := make(chan error, 1)
+		subsystem.pending = pending
+
+		go func() {
+			pending <- subsystem.checker.CheckHealth()
+		}()
+	}
+
+	select {
+	case err := <-subsystem.pending:
+		subsystem.pending = nil
+
+		return err
+
+	case <-time.After(watchdog.config.CheckTimeout.Duration):
+		return aoserrors.New("health check timeout")
+
+	case <-watchdog.closeChannel:
+		return nil
+	}
+}
+
+func recoverSubsystem(subsystem *subsystem, recoverer Recoverer) {
+	if !subsystem.recovering.CompareAndSwap(false, true) {
+		return
+	}
+
+	log.WithField("subsystem", subsystem.name).Info("Recover subsystem")
+
+	go func() {
+		defer subsystem.recovering.Store(false)
+
+		if err := recoverer.Recover(); err != nil {
+			log.WithField("subsystem", subsystem.name).Errorf("Can't recover subsystem: %v", err)
+		}
+	}()
+}
+
+func (watchdog *Watchdog) setUnhealthy(subsystem *subsystem, checkErr error) {
+	reason := subsystem.name + ": " + checkErr.Error()
+
+	log.WithField("reason", reason).Error("CM is unhealthy")
+
+	if watchdog.config.ReasonFile != "" {
+		if err := os.WriteFile(watchdog.config.ReasonFile, []byte(reason), 0o600); err != nil {
+			log.Errorf("Can't save restart reason: %v", err)
+		}
+	}
+
+	if !watchdog.watchdogEnabled {
+		log.Warn("Systemd watchdog is not enabled, CM is not restarted")
+
+		return
+	}
+
+	if _, err := sdNotify(false, sdNotifyWatchdogTrigger); err != nil {
+		log.Errorf("Can't trigger systemd watchdog: %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+// Copyright (C) 2025 Renesas Electronics Corporation.
+// Copyright (C) 2025 EPAM Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosedge/aos_common/aoserrors"
+	"github.com/aosedge/aos_common/aostypes"
+	"github.com/coreos/go-systemd/daemon"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/aosedge/aos_communicationmanager/config"
+)
+
+/***********************************************************************************************************************
+ * Consts
+ **********************************************************************************************************************/
+
+const waitTimeout = 5 * time.Second
+
+/***********************************************************************************************************************
+ * Types
+ **********************************************************************************************************************/
+
+type testChecker struct {
+	sync.Mutex
+	err          error
+	block        chan struct{}
+	recoverCount int
+}
+
+/***********************************************************************************************************************
+ * Vars
+ **********************************************************************************************************************/
+
+var notifyChannel = make(chan string, 100)
+
+/***********************************************************************************************************************
+ * Init
+ **********************************************************************************************************************/
+
+func init() {
+	log.SetFormatter(&log.TextFormatter{
+		DisableTimestamp: false,
+		TimestampFormat:  "2006-01-02 15:04:05.000",
+		FullTimestamp:    true,
+	})
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(os.Stdout)
+
+	sdNotify = func(unsetEnvironment bool, state string) (bool, error) {
+		notifyChannel <- state
+
+		return true, nil
+	}
+
+	sdWatchdogEnabled = func(unsetEnvironment bool) (time.Duration, error) {
+		return 100 * time.Millisecond, nil
+	}
+}
+
+/***********************************************************************************************************************
+ * Tests
+ **********************************************************************************************************************/
+
+func TestRestartReason(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	if err := os.WriteFile(cfg.Watchdog.ReasonFile, []byte("storage: not reachable"), 0o600); err != nil {
+		t.Fatalf("Can't write reason file: %v", err)
+	}
+
+	watchdog, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("Can't create watchdog: %v", err)
+	}
+	defer watchdog.Close()
+
+	if reason := watchdog.GetRestartReason(); reason != "storage: not reachable" {
+		t.Errorf("Wrong restart reason: %s", reason)
+	}
+
+	if _, err = os.Stat(cfg.Watchdog.ReasonFile); !os.IsNotExist(err) {
+		t.Errorf("Reason file should be removed: %v", err)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	cfg := newTestConfig(t)
+	checker := &testChecker{}
+
+	watchdog, err := New(cfg, map[string]HealthChecker{"storage": checker})
+	if err != nil {
+		t.Fatalf("Can't create watchdog: %v", err)
+	}
+	defer watchdog.Close()
+
+	if watchdog.GetRestartReason() != "" {
+		t.Errorf("Unexpected restart reason: %s", watchdog.GetRestartReason())
+	}
+
+	if err = waitNotification(daemon.SdNotifyWatchdog); err != nil {
+		t.Errorf("Wait watchdog notification error: %v", err)
+	}
+
+	checker.setError(aoserrors.New("not reachable"))
+
+	if err = waitNotification(sdNotifyWatchdogTrigger); err != nil {
+		t.Fatalf("Wait watchdog trigger error: %v", err)
+	}
+
+	if checker.getRecoverCount() == 0 {
+		t.Error("Subsystem should be recovered")
+	}
+
+	reason, err := os.ReadFile(cfg.Watchdog.ReasonFile)
+	if err != nil {
+		t.Fatalf("Can't read reason file: %v", err)
+	}
+
+	if !strings.HasPrefix(string(reason), "storage: ") {
+		t.Errorf("Wrong restart reason: %s", reason)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	cfg := newTestConfig(t)
+	checker := &testChecker{block: make(chan struct{})}
+
+	defer close(checker.block)
+
+	watchdog, err := New(cfg, map[string]HealthChecker{"amqp": checker})
+	if err != nil {
+		t.Fatalf("Can't create watchdog: %v", err)
+	}
+	defer watchdog.Close()
+
+	if err = waitNotification(sdNotifyWatchdogTrigger); err != nil {
+		t.Fatalf("Wait watchdog trigger error: %v", err)
+	}
+
+	reason, err := os.ReadFile(cfg.Watchdog.ReasonFile)
+	if err != nil {
+		t.Fatalf("Can't read reason file: %v", err)
+	}
+
+	if !strings.Contains(string(reason), "timeout") {
+		t.Errorf("Wrong restart reason: %s", reason)
+	}
+}
+
+func TestHealthChecksWatchdogDisabled(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Watchdog.CheckPeriod.Duration = 50 * time.Millisecond
+
+	checker := &testChecker{err: aoserrors.New("not reachable")}
+
+	sdWatchdogEnabled = func(unsetEnvironment bool) (time.Duration, error) {
+		return 0, nil
+	}
+
+	defer func() {
+		sdWatchdogEnabled = func(unsetEnvironment bool) (time.Duration, error) {
+			return 100 * time.Millisecond, nil
+		}
+	}()
+
+	watchdog, err := New(cfg, map[string]HealthChecker{"storage": checker})
+	if err != nil {
+		t.Fatalf("Can't create watchdog: %v", err)
+	}
+	defer watchdog.Close()
+
+	// Subsystem is recovered more times than max failures as checks are not stopped after failure
+	timeout := time.After(waitTimeout)
+
+	for checker.getRecoverCount() <= cfg.Watchdog.MaxFailures {
+		select {
+		case <-timeout:
+			t.Fatalf("Wrong recover count: %d", checker.getRecoverCount())
+
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	if len(notifyChannel) != 0 {
+		t.Errorf("Unexpected systemd notification: %s", <-notifyChannel)
+	}
+}
+
+/***********************************************************************************************************************
+ * Interfaces
+ **********************************************************************************************************************/
+
+func (checker *testChecker) CheckHealth() error {
+	if checker.block != nil {
+		<-checker.block
+	}
+
+	checker.Lock()
+	defer checker.Unlock()
+
+	return checker.err
+}
+
+func (checker *testChecker) Recover() error {
+	checker.Lock()
+	defer checker.Unlock()
+
+	checker.recoverCount++
+
+	return nil
+}
+
+/***********************************************************************************************************************
+ * Private
+ **********************************************************************************************************************/
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	// Drop notifications of previous tests
+	for len(notifyChannel) > 0 {
+		<-notifyChannel
+	}
+
+	return &config.Config{Watchdog: config.Watchdog{
+		CheckPeriod:  aostypes.Duration{Duration: time.Second},
+		CheckTimeout: aostypes.Duration{Duration: 50 * time.Millisecond},
+		MaxFailures:  3,
+		ReasonFile:   filepath.Join(t.TempDir(), "watchdog_reason"),
+	}}
+}
+
+func (checker *testChecker) setError(err error) {
+	checker.Lock()
+	defer checker.Unlock()
+
+	checker.err = err
+}
+
+func (checker *testChecker) getRecoverCount() int {
+	checker.Lock()
+	defer checker.Unlock()
+
+	return checker.recoverCount
+}
+
+func waitNotification(state string) error {
+	timeout := time.After(waitTimeout)
+
+	for {
+		select {
+		case notification := <-notifyChannel:
+			if notification == state {
+				return nil
+			}
+
+		case <-timeout:
+			return aoserrors.Errorf("wait %s notification timeout", state)
+		}
+	}
+}